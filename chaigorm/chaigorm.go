@@ -0,0 +1,161 @@
+// Package chaigorm is a GORM dialector for Chai.
+//
+//	db, err := gorm.Open(chaigorm.Open("mydb"), &gorm.Config{})
+//
+// The dialector uses the database/sql driver of the driver package.
+// Migrations are supported with the following limitations:
+// columns cannot be altered, dropped or renamed, and foreign keys
+// are not created since Chai doesn't support them.
+package chaigorm
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	_ "github.com/chaisql/chai/driver"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// DriverName is the name of the database/sql driver used by default.
+const DriverName = "chai"
+
+// Config of the dialector.
+type Config struct {
+	// Name of the database/sql driver. Defaults to DriverName.
+	DriverName string
+	// Data source name passed to the driver, ignored if Conn is set.
+	DSN string
+	// Existing connection pool to use instead of opening a new one.
+	Conn gorm.ConnPool
+}
+
+// Dialector implements the gorm.Dialector interface.
+type Dialector struct {
+	*Config
+}
+
+// Open returns a dialector opening the database at the given path.
+func Open(dsn string) gorm.Dialector {
+	return &Dialector{Config: &Config{DSN: dsn}}
+}
+
+// New returns a dialector using the given configuration.
+func New(config Config) gorm.Dialector {
+	return &Dialector{Config: &config}
+}
+
+// Name returns the name of the dialect.
+func (d Dialector) Name() string {
+	return "chai"
+}
+
+// Initialize registers the callbacks of the dialect and opens
+// the connection pool.
+func (d Dialector) Initialize(db *gorm.DB) error {
+	if d.DriverName == "" {
+		d.DriverName = DriverName
+	}
+
+	// foreign keys are not supported
+	db.DisableForeignKeyConstraintWhenMigrating = true
+
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
+		CreateClauses: []string{"INSERT", "VALUES", "ON CONFLICT", "RETURNING"},
+		UpdateClauses: []string{"UPDATE", "SET", "WHERE", "RETURNING"},
+		DeleteClauses: []string{"DELETE", "FROM", "WHERE", "RETURNING"},
+	})
+
+	if d.Conn != nil {
+		db.ConnPool = d.Conn
+		return nil
+	}
+
+	sqlDB, err := sql.Open(d.DriverName, d.DSN)
+	if err != nil {
+		return err
+	}
+	db.ConnPool = sqlDB
+
+	return nil
+}
+
+// Migrator returns the migrator of the dialect.
+func (d Dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return Migrator{
+		Migrator: migrator.Migrator{
+			Config: migrator.Config{
+				DB:                          db,
+				Dialector:                   d,
+				CreateIndexAfterCreateTable: true,
+			},
+		},
+	}
+}
+
+// DataTypeOf returns the type of the column of a field.
+func (d Dialector) DataTypeOf(field *schema.Field) string {
+	switch field.DataType {
+	case schema.Bool:
+		return "BOOLEAN"
+	case schema.Int, schema.Uint:
+		tp := "BIGINT"
+		// unsigned 32-bit integers don't fit in an INTEGER
+		if field.Size > 0 && (field.Size < 32 || field.Size == 32 && field.DataType == schema.Int) {
+			tp = "INTEGER"
+		}
+		if field.AutoIncrement {
+			tp += " AUTOINCREMENT"
+		}
+		return tp
+	case schema.Float:
+		if field.Precision > 0 {
+			return "DECIMAL(" + strconv.Itoa(field.Precision) + "," + strconv.Itoa(field.Scale) + ")"
+		}
+		return "DOUBLE"
+	case schema.String:
+		return "TEXT"
+	case schema.Time:
+		return "TIMESTAMP"
+	case schema.Bytes:
+		return "BLOB"
+	}
+
+	return string(field.DataType)
+}
+
+// DefaultValueOf returns the value inserted in a column for which
+// some rows of a batch don't have a value.
+// Chai doesn't support DEFAULT in VALUES, NULL is used instead.
+func (d Dialector) DefaultValueOf(field *schema.Field) clause.Expression {
+	return clause.Expr{SQL: "NULL"}
+}
+
+// BindVarTo writes a positional parameter.
+func (d Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
+	writer.WriteByte('?')
+}
+
+// QuoteTo quotes an identifier with backquotes.
+// Qualified names are quoted part by part.
+func (d Dialector) QuoteTo(writer clause.Writer, str string) {
+	for i, part := range strings.Split(str, ".") {
+		if i > 0 {
+			writer.WriteByte('.')
+		}
+		writer.WriteByte('`')
+		writer.WriteString(strings.ReplaceAll(part, "`", "\\`"))
+		writer.WriteByte('`')
+	}
+}
+
+// Explain returns the SQL with its parameters replaced by their values,
+// for logging.
+func (d Dialector) Explain(sql string, vars ...interface{}) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
@@ -0,0 +1,216 @@
+package chaigorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/chaigorm"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type User struct {
+	gorm.Model
+	Name     string  `gorm:"not null;default:'anon'"`
+	Email    *string `gorm:"uniqueIndex"`
+	Code     string  `gorm:"unique"`
+	Age      uint8   `gorm:"index;check:age_checker,age < 200"`
+	Active   bool    `gorm:"default:true"`
+	Score    float64
+	Balance  float64 `gorm:"precision:10;scale:2"`
+	Avatar   []byte
+	Birthday *time.Time
+}
+
+type UserWithNickname struct {
+	User
+	Nickname string `gorm:"index:idx_users_nickname"`
+}
+
+func (UserWithNickname) TableName() string {
+	return "users"
+}
+
+func open(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(chaigorm.Open(":memory:"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB.Close()
+	})
+
+	return db
+}
+
+func TestAutoMigrate(t *testing.T) {
+	db := open(t)
+
+	require.NoError(t, db.AutoMigrate(&User{}))
+
+	m := db.Migrator()
+	require.True(t, m.HasTable(&User{}))
+	require.True(t, m.HasTable("users"))
+	require.False(t, m.HasTable("unknown"))
+	require.True(t, m.HasColumn(&User{}, "Email"))
+	require.True(t, m.HasColumn(&User{}, "deleted_at"))
+	require.False(t, m.HasColumn(&User{}, "nickname"))
+	require.True(t, m.HasIndex(&User{}, "idx_users_deleted_at"))
+	require.True(t, m.HasIndex(&User{}, "Email"))
+	require.True(t, m.HasIndex(&User{}, "idx_users_age"))
+	require.True(t, m.HasConstraint(&User{}, "age_checker"))
+	require.True(t, m.HasConstraint(&User{}, "uni_users_code"))
+	require.False(t, m.HasConstraint(&User{}, "unknown"))
+
+	tables, err := m.GetTables()
+	require.NoError(t, err)
+	require.Equal(t, []string{"users"}, tables)
+
+	columnTypes, err := m.ColumnTypes(&User{})
+	require.NoError(t, err)
+
+	types := make(map[string]string)
+	for _, ct := range columnTypes {
+		types[ct.Name()] = ct.DatabaseTypeName()
+	}
+	require.Equal(t, map[string]string{
+		"id":         "bigint",
+		"created_at": "timestamp",
+		"updated_at": "timestamp",
+		"deleted_at": "timestamp",
+		"name":       "text",
+		"email":      "text",
+		"code":       "text",
+		"age":        "integer",
+		"active":     "boolean",
+		"score":      "double",
+		"balance":    "decimal",
+		"avatar":     "blob",
+		"birthday":   "timestamp",
+	}, types)
+
+	for _, ct := range columnTypes {
+		switch ct.Name() {
+		case "id":
+			pk, _ := ct.PrimaryKey()
+			require.True(t, pk)
+			ai, _ := ct.AutoIncrement()
+			require.True(t, ai)
+		case "name":
+			nullable, _ := ct.Nullable()
+			require.False(t, nullable)
+			dv, ok := ct.DefaultValue()
+			require.True(t, ok)
+			require.Equal(t, "anon", dv)
+		case "code":
+			unique, _ := ct.Unique()
+			require.True(t, unique)
+		case "balance":
+			precision, scale, _ := ct.DecimalSize()
+			require.EqualValues(t, 10, precision)
+			require.EqualValues(t, 2, scale)
+		}
+	}
+
+	t.Run("Idempotent", func(t *testing.T) {
+		require.NoError(t, db.AutoMigrate(&User{}))
+	})
+
+	t.Run("New column", func(t *testing.T) {
+		require.NoError(t, db.AutoMigrate(&UserWithNickname{}))
+		require.True(t, m.HasColumn(&User{}, "nickname"))
+		require.True(t, m.HasIndex(&UserWithNickname{}, "idx_users_nickname"))
+	})
+
+	t.Run("Indexes", func(t *testing.T) {
+		require.NoError(t, m.DropIndex(&User{}, "idx_users_age"))
+		require.False(t, m.HasIndex(&User{}, "idx_users_age"))
+		require.NoError(t, m.CreateIndex(&User{}, "Age"))
+		require.True(t, m.HasIndex(&User{}, "idx_users_age"))
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		require.ErrorIs(t, m.DropColumn(&User{}, "score"), chaigorm.ErrNotSupported)
+		require.ErrorIs(t, m.RenameColumn(&User{}, "score", "points"), chaigorm.ErrNotSupported)
+		require.ErrorIs(t, m.AlterColumn(&User{}, "score"), chaigorm.ErrNotSupported)
+	})
+
+	t.Run("DropTable", func(t *testing.T) {
+		require.NoError(t, m.DropTable(&User{}))
+		require.False(t, m.HasTable(&User{}))
+	})
+}
+
+func TestCRUD(t *testing.T) {
+	db := open(t)
+	require.NoError(t, db.AutoMigrate(&User{}))
+
+	email := "jo@example.com"
+	birthday := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	u := User{Name: "jo", Email: &email, Code: "a", Age: 30, Score: 1.5, Avatar: []byte{1, 2}, Birthday: &birthday}
+	require.NoError(t, db.Create(&u).Error)
+	require.NotZero(t, u.ID)
+	require.True(t, u.Active)
+
+	users := []User{{Code: "b", Age: 20}, {Code: "c", Age: 40, Active: false}}
+	require.NoError(t, db.Create(&users).Error)
+	require.NotZero(t, users[0].ID)
+	require.NotEqual(t, users[0].ID, users[1].ID)
+
+	var got User
+	require.NoError(t, db.First(&got, u.ID).Error)
+	require.Equal(t, "jo", got.Name)
+	require.Equal(t, email, *got.Email)
+	require.Equal(t, []byte{1, 2}, got.Avatar)
+	require.True(t, birthday.Equal(*got.Birthday))
+
+	got = User{}
+	require.NoError(t, db.Where("code = ?", "b").First(&got).Error)
+	require.Equal(t, "anon", got.Name)
+	require.EqualValues(t, 20, got.Age)
+
+	var count int64
+	require.NoError(t, db.Model(&User{}).Where("age > ?", 25).Count(&count).Error)
+	require.EqualValues(t, 2, count)
+
+	got.Name = "bo"
+	require.NoError(t, db.Save(&got).Error)
+
+	res := db.Model(&User{}).Where("age < ?", 35).Update("score", 9.5)
+	require.NoError(t, res.Error)
+	require.EqualValues(t, 2, res.RowsAffected)
+
+	got = User{}
+	require.NoError(t, db.First(&got, users[0].ID).Error)
+	require.Equal(t, "bo", got.Name)
+	require.Equal(t, 9.5, got.Score)
+
+	res = db.Delete(&User{}, u.ID)
+	require.NoError(t, res.Error)
+	require.EqualValues(t, 1, res.RowsAffected)
+	require.ErrorIs(t, db.First(&User{}, u.ID).Error, gorm.ErrRecordNotFound)
+	require.NoError(t, db.Unscoped().First(&User{}, u.ID).Error)
+
+	require.NoError(t, db.Unscoped().Delete(&User{}, u.ID).Error)
+	require.NoError(t, db.Unscoped().Model(&User{}).Count(&count).Error)
+	require.EqualValues(t, 2, count)
+
+	var codes []string
+	require.NoError(t, db.Model(&User{}).Order("code DESC").Pluck("code", &codes).Error)
+	require.Equal(t, []string{"c", "b"}, codes)
+
+	require.Error(t, db.Create(&User{Code: "b"}).Error)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&User{Code: "d"}).Error)
+		return gorm.ErrInvalidTransaction
+	})
+	require.ErrorIs(t, err, gorm.ErrInvalidTransaction)
+	require.ErrorIs(t, db.Where("code = ?", "d").First(&User{}).Error, gorm.ErrRecordNotFound)
+}
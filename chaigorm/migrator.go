@@ -0,0 +1,328 @@
+package chaigorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/migrator"
+)
+
+// ErrNotSupported is returned by the migrations that Chai cannot run.
+var ErrNotSupported = errors.New("not supported by chai")
+
+// Migrator implements the gorm.Migrator interface.
+// Tables and columns are read from the information schema,
+// indexes and constraints from the catalog.
+type Migrator struct {
+	migrator.Migrator
+}
+
+// splitTable returns the schema and the name of a table
+// as listed in the information schema.
+func splitTable(table string) (schema, name string) {
+	schema, name = database.SplitQualifiedName(table)
+	if schema == "" {
+		schema = database.DefaultSchema
+	}
+
+	return schema, name
+}
+
+// CurrentDatabase returns the default schema.
+func (m Migrator) CurrentDatabase() string {
+	return database.DefaultSchema
+}
+
+// GetTables returns the tables of the default schema.
+func (m Migrator) GetTables() (tableList []string, err error) {
+	err = m.DB.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = ?", m.CurrentDatabase()).
+		Scan(&tableList).Error
+	return
+}
+
+// HasTable reports whether the table of the value exists.
+func (m Migrator) HasTable(value interface{}) bool {
+	var count int64
+
+	_ = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		schema, name := splitTable(stmt.Table)
+		return m.DB.Raw("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?", schema, name).
+			Row().Scan(&count)
+	})
+
+	return count > 0
+}
+
+// HasColumn reports whether the table of the value has the given column.
+func (m Migrator) HasColumn(value interface{}, field string) bool {
+	var count int64
+
+	_ = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		name := field
+		if stmt.Schema != nil {
+			if f := stmt.Schema.LookUpField(field); f != nil {
+				name = f.DBName
+			}
+		}
+
+		schema, table := splitTable(stmt.Table)
+		return m.DB.Raw("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_name = ?", schema, table, name).
+			Row().Scan(&count)
+	})
+
+	return count > 0
+}
+
+// ColumnTypes returns the columns of the table of the value.
+func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
+	var columnTypes []gorm.ColumnType
+
+	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		schema, table := splitTable(stmt.Table)
+
+		// a column is unique if it is the only column of a unique constraint
+		constraints := make(map[string][]string)
+		keys := make(map[string]string)
+		rows, err := m.DB.Raw("SELECT constraint_name, column_name, constraint_type FROM information_schema.key_column_usage WHERE table_schema = ? AND table_name = ?", schema, table).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var name, column, tp string
+			if err := rows.Scan(&name, &column, &tp); err != nil {
+				return err
+			}
+			constraints[name] = append(constraints[name], column)
+			keys[name] = tp
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		primaryKey := make(map[string]bool)
+		unique := make(map[string]bool)
+		for name, columns := range constraints {
+			switch keys[name] {
+			case "PRIMARY KEY":
+				for _, c := range columns {
+					primaryKey[c] = true
+				}
+			case "UNIQUE":
+				if len(columns) == 1 {
+					unique[columns[0]] = true
+				}
+			}
+		}
+
+		rows, err = m.DB.Raw("SELECT column_name, data_type, is_nullable, column_default, numeric_precision, numeric_scale FROM information_schema.columns WHERE table_schema = ? AND table_name = ? ORDER BY ordinal_position", schema, table).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				name, dataType, nullable string
+				defaultValue             sql.NullString
+				precision, scale         sql.NullInt64
+			)
+			if err := rows.Scan(&name, &dataType, &nullable, &defaultValue, &precision, &scale); err != nil {
+				return err
+			}
+
+			ct := migrator.ColumnType{
+				NameValue:         sql.NullString{String: name, Valid: true},
+				DataTypeValue:     sql.NullString{String: dataType, Valid: true},
+				ColumnTypeValue:   sql.NullString{String: dataType, Valid: true},
+				PrimaryKeyValue:   sql.NullBool{Bool: primaryKey[name], Valid: true},
+				UniqueValue:       sql.NullBool{Bool: unique[name], Valid: true},
+				NullableValue:     sql.NullBool{Bool: nullable == "YES", Valid: true},
+				LengthValue:       sql.NullInt64{Valid: true},
+				DecimalSizeValue:  sql.NullInt64{Int64: precision.Int64, Valid: true},
+				ScaleValue:        scale,
+				DefaultValueValue: defaultValueOf(defaultValue),
+			}
+			if defaultValue.Valid && strings.HasPrefix(defaultValue.String, "NEXT VALUE FOR ") {
+				ct.AutoIncrementValue = sql.NullBool{Bool: true, Valid: true}
+			}
+
+			columnTypes = append(columnTypes, ct)
+		}
+
+		return rows.Err()
+	})
+
+	return columnTypes, err
+}
+
+// defaultValueOf returns the default value of a column as
+// GORM expects it: text literals are not quoted.
+func defaultValueOf(v sql.NullString) sql.NullString {
+	if !v.Valid {
+		return v
+	}
+
+	e, err := parser.ParseExpr(v.String)
+	if err != nil {
+		return v
+	}
+
+	if lv, ok := e.(expr.LiteralValue); ok && lv.Value.Type() == types.TypeText {
+		v.String = types.AsString(lv.Value)
+	}
+
+	return v
+}
+
+// tableInfo returns the definition of the table of the statement,
+// parsed from the catalog.
+func (m Migrator) tableInfo(stmt *gorm.Statement) (*database.TableInfo, error) {
+	schema, name := splitTable(stmt.Table)
+
+	var q string
+	err := m.DB.Raw("SELECT sql FROM __chai_catalog WHERE name = ? AND type = ?", database.QualifiedName(schema, name), database.RelationTableType).
+		Row().Scan(&q)
+	if err != nil {
+		return nil, err
+	}
+
+	pq, err := parser.ParseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pq.Statements) != 1 {
+		return nil, errors.New("invalid table definition")
+	}
+	ct, ok := pq.Statements[0].(*statement.CreateTableStmt)
+	if !ok {
+		return nil, errors.New("invalid table definition")
+	}
+
+	return &ct.Info, nil
+}
+
+// HasConstraint reports whether the table of the value has
+// a constraint with the given name.
+func (m Migrator) HasConstraint(value interface{}, name string) bool {
+	var found bool
+
+	_ = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		constraint, _ := m.GuessConstraintInterfaceAndTable(stmt, name)
+		if constraint != nil {
+			name = constraint.GetName()
+		}
+
+		info, err := m.tableInfo(stmt)
+		if err != nil {
+			return err
+		}
+
+		for _, tc := range info.TableConstraints {
+			if tc.Name == name {
+				found = true
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return found
+}
+
+// HasIndex reports whether the table of the value has
+// an index with the given name.
+func (m Migrator) HasIndex(value interface{}, name string) bool {
+	var count int64
+
+	_ = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema != nil {
+			if idx := stmt.Schema.LookIndex(name); idx != nil {
+				name = idx.Name
+			}
+		}
+
+		schema, table := splitTable(stmt.Table)
+		return m.DB.Raw("SELECT COUNT(*) FROM __chai_catalog WHERE name = ? AND type = ? AND owner_table_name = ?",
+			database.QualifiedName(schema, name), database.RelationIndexType, database.QualifiedName(schema, table)).
+			Row().Scan(&count)
+	})
+
+	return count > 0
+}
+
+// DropIndex drops the index with the given name.
+func (m Migrator) DropIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema != nil {
+			if idx := stmt.Schema.LookIndex(name); idx != nil {
+				name = idx.Name
+			}
+		}
+
+		schema, _ := splitTable(stmt.Table)
+		return m.DB.Exec("DROP INDEX ?", clause.Table{Name: database.QualifiedName(schema, name)}).Error
+	})
+}
+
+// AddColumn adds the column of the given field to the table of the value.
+func (m Migrator) AddColumn(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema == nil {
+			return errors.New("failed to get schema")
+		}
+		f := stmt.Schema.LookUpField(name)
+		if f == nil {
+			return fmt.Errorf("failed to look up field with name: %s", name)
+		}
+		if f.IgnoreMigration {
+			return nil
+		}
+
+		return m.DB.Exec("ALTER TABLE ? ADD COLUMN ? ?",
+			m.CurrentTable(stmt), clause.Column{Name: f.DBName}, m.DB.Migrator().FullDataTypeOf(f)).Error
+	})
+}
+
+// AlterColumn is not supported.
+func (m Migrator) AlterColumn(value interface{}, field string) error {
+	return ErrNotSupported
+}
+
+// DropColumn is not supported.
+func (m Migrator) DropColumn(value interface{}, name string) error {
+	return ErrNotSupported
+}
+
+// RenameColumn is not supported.
+func (m Migrator) RenameColumn(value interface{}, oldName, newName string) error {
+	return ErrNotSupported
+}
+
+// RenameIndex is not supported.
+func (m Migrator) RenameIndex(value interface{}, oldName, newName string) error {
+	return ErrNotSupported
+}
+
+// CreateConstraint is not supported: constraints can only be
+// created with their table.
+func (m Migrator) CreateConstraint(value interface{}, name string) error {
+	return ErrNotSupported
+}
+
+// DropConstraint is not supported.
+func (m Migrator) DropConstraint(value interface{}, name string) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,74 @@
+// Package chaiprom exports the metrics of a Chai database to Prometheus.
+//
+//	prometheus.MustRegister(chaiprom.NewCollector(db))
+//
+// or, to serve them without a registry of the application:
+//
+//	http.Handle("/metrics", chaiprom.Handler(db))
+package chaiprom
+
+import (
+	"net/http"
+
+	"github.com/chaisql/chai"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collector is a prometheus.Collector reading the metrics
+// of a database every time it is collected.
+type Collector struct {
+	db *chai.DB
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector returns a collector of the metrics of db.
+func NewCollector(db *chai.DB) *Collector {
+	return &Collector{db: db}
+}
+
+// Describe sends the descriptions of the metrics to ch.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+// Collect sends the current values of the metrics to ch.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.db.CollectMetrics(&metricsSender{ch: ch})
+}
+
+// Handler returns an HTTP handler serving the metrics of db
+// in the Prometheus format.
+func Handler(db *chai.DB) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewCollector(db))
+
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
+
+// metricsSender converts the metrics passed by chai.DB.CollectMetrics
+// to Prometheus metrics.
+type metricsSender struct {
+	ch chan<- prometheus.Metric
+}
+
+func (s *metricsSender) Counter(name, help string, value float64) {
+	s.ch <- prometheus.MustNewConstMetric(prometheus.NewDesc(name, help, nil, nil), prometheus.CounterValue, value)
+}
+
+func (s *metricsSender) Gauge(name, help string, value float64) {
+	s.ch <- prometheus.MustNewConstMetric(prometheus.NewDesc(name, help, nil, nil), prometheus.GaugeValue, value)
+}
+
+func (s *metricsSender) Histogram(name, help string, h *chai.Histogram) {
+	// Prometheus buckets are cumulative
+	buckets := make(map[float64]uint64, len(h.Bounds))
+	var count uint64
+	for i, b := range h.Bounds {
+		count += h.Counts[i]
+		buckets[b.Seconds()] = count
+	}
+
+	s.ch <- prometheus.MustNewConstHistogram(prometheus.NewDesc(name, help, nil, nil), h.Count, h.Sum.Seconds(), buckets)
+}
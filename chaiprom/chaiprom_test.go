@@ -0,0 +1,38 @@
+package chaiprom_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/chaiprom"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	db, err := chai.Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Exec("CREATE TABLE test(a INT PRIMARY KEY)"))
+	require.NoError(t, db.Exec("INSERT INTO test (a) VALUES (1), (2)"))
+
+	c := chaiprom.NewCollector(db)
+	require.Equal(t, 17, testutil.CollectAndCount(c))
+	err = testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP chai_rows_written_total Number of rows inserted, updated or deleted.
+# TYPE chai_rows_written_total counter
+chai_rows_written_total 2
+`), "chai_rows_written_total")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	chaiprom.Handler(db).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Result().Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "chai_queries_total 2\n")
+	require.Contains(t, string(body), `chai_query_duration_seconds_bucket{le="+Inf"} 2`)
+}
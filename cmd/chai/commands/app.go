@@ -22,8 +22,15 @@ func NewApp() *cli.App {
 		NewVersionCommand(),
 		NewDumpCommand(),
 		NewRestoreCommand(),
+		NewExportCommand(),
+		NewQueryCommand(),
+		NewInsertCommand(),
 		NewBenchCommand(),
 		NewPebbleCommand(),
+		NewSelfTestCommand(),
+		NewLintCommand(),
+		NewFsckCommand(),
+		NewBuildReleaseCommand(),
 	}
 
 	// inject cancelable context to all commands (except the shell command)
@@ -45,10 +52,23 @@ func NewApp() *cli.App {
 		}
 	}
 
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format of the query results: pretty, json, table, csv or markdown",
+			Value: string(dbutil.FormatPretty),
+		},
+	}
+
 	// Root command
 	app.Action = func(c *cli.Context) error {
 		dbpath := c.Args().First()
 
+		format, err := dbutil.ParseFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+
 		if dbutil.CanReadFromStandardInput() {
 			db, err := dbutil.OpenDB(c.Context, dbpath)
 			if err != nil {
@@ -56,11 +76,12 @@ func NewApp() *cli.App {
 			}
 			defer db.Close()
 
-			return dbutil.ExecSQL(c.Context, db, os.Stdin, os.Stdout)
+			return dbutil.ExecSQLWithFormat(c.Context, db, os.Stdin, os.Stdout, format)
 		}
 
 		return shell.Run(c.Context, &shell.Options{
 			DBPath: dbpath,
+			Format: format,
 		})
 	}
 
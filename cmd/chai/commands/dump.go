@@ -29,7 +29,17 @@ $ chai dump -t foo -f bar my.db
 
 The dump command can also write directly into a file:
 
-$ chai dump -f dump.sql my.db`,
+$ chai dump -f dump.sql my.db
+
+Columns can be anonymized while dumping, to turn production data into
+development fixtures, by declaring masking rules of the form table.column=method:
+
+$ chai dump -m users.email=hash -m users.name=shuffle -m users.phone=redact my.db
+
+The hash method replaces values by a hash of the same type, equal values having
+the same hash within a dump, the shuffle method permutes the values of the column
+between rows and the redact method replaces values by NULL, or by the zero value
+of the type of NOT NULL columns.`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "file",
@@ -41,12 +51,27 @@ $ chai dump -f dump.sql my.db`,
 				Aliases: []string{"t"},
 				Usage:   "name of the table, it must already exist. Defaults to all tables.",
 			},
+			&cli.StringSliceFlag{
+				Name:    "mask",
+				Aliases: []string{"m"},
+				Usage:   "masking rule of the form table.column=method, with method one of hash, shuffle or redact.",
+			},
 		},
 	}
 
 	cmd.Action = func(c *cli.Context) error {
 		tables := c.StringSlice("table")
 		f := c.String("file")
+
+		var rules []dbutil.MaskingRule
+		for _, m := range c.StringSlice("mask") {
+			r, err := dbutil.ParseMaskingRule(m)
+			if err != nil {
+				return err
+			}
+			rules = append(rules, r)
+		}
+
 		dbPath := c.Args().First()
 		if dbPath == "" {
 			return errors.New(cmd.UsageText)
@@ -70,7 +95,7 @@ $ chai dump -f dump.sql my.db`,
 			w = file
 		}
 
-		return dbutil.Dump(db, w, tables...)
+		return dbutil.DumpAnonymized(db, w, rules, tables...)
 	}
 
 	return &cmd
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewExportCommand returns a cli.Command for "chai export".
+func NewExportCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "export",
+		Usage:     "Export a table or the result of a query as CSV, NDJSON or Parquet.",
+		UsageText: `chai export [options] dbpath [file]`,
+		Description: `The export command writes the rows of a table or the result of a query
+to a file, or to the standard output if no file is given:
+
+$ chai export --table foo --format csv my.db foo.csv
+
+The format defaults to the one matching the extension of the file:
+
+$ chai export --table foo my.db foo.parquet
+
+Any query can be exported and the columns can be selected:
+
+$ chai export --query "SELECT * FROM foo WHERE a > 10" --columns a,b my.db out.ndjson
+
+The types of the Parquet columns are those of their first non-NULL values.
+Types without a Parquet equivalent are written as text.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "table",
+				Aliases: []string{"t"},
+				Usage:   "name of the table to export.",
+			},
+			&cli.StringFlag{
+				Name:    "query",
+				Aliases: []string{"q"},
+				Usage:   "query whose result is exported.",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "output format: csv, ndjson or parquet. Defaults to the extension of the file.",
+			},
+			&cli.StringSliceFlag{
+				Name:    "columns",
+				Aliases: []string{"c"},
+				Usage:   "columns to export, in order. Defaults to all columns.",
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		dbPath := c.Args().First()
+		if dbPath == "" || c.Args().Len() > 2 {
+			return errors.New(cmd.UsageText)
+		}
+		path := c.Args().Get(1)
+
+		opts := dbutil.ExportOptions{
+			Format:  c.String("format"),
+			Table:   c.String("table"),
+			Query:   c.String("query"),
+			Columns: c.StringSlice("columns"),
+		}
+		if opts.Format == "" {
+			opts.Format = dbutil.ExportFormat(path)
+			if opts.Format == "" {
+				return errors.New("cannot guess the output format, use --format")
+			}
+		}
+
+		db, err := dbutil.OpenDB(c.Context, dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		var w io.Writer = os.Stdout
+
+		if path != "" {
+			file, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			w = file
+		}
+
+		bw := bufio.NewWriter(w)
+		_, err = dbutil.Export(c.Context, db, bw, opts)
+		if err == nil {
+			err = bw.Flush()
+		}
+		if err != nil && path != "" {
+			// don't leave a truncated file behind
+			_ = os.Remove(path)
+		}
+
+		return err
+	}
+
+	return &cmd
+}
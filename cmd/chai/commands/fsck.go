@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewFsckCommand returns a cli.Command for "chai fsck".
+func NewFsckCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "fsck",
+		Usage:     "Check the integrity of a database",
+		UsageText: `chai fsck [options] dbpath`,
+		Description: `The fsck command reads the whole database and reports:
+- catalog: an object references another object that doesn't exist
+- invalid-key: the key of a row cannot be decoded
+- undecodable-row: a row cannot be decoded with the columns of its table
+- orphaned-keys: keys don't belong to any table or index
+- orphaned-sequence: the value of a sequence that doesn't exist is stored
+- index-mismatch: an index is missing an entry for a row, or has an entry without row
+
+$ chai fsck my.db
+undecodable-row: users: (4): cannot decode column name
+index-mismatch: users_email_idx: missing entry for row (2) with values ("a@b.c")
+3 tables, 2 indexes, 1 sequences, 1204 rows checked: 2 problems found, 0 repaired
+
+The rows that cannot be decoded can be moved to the __chai_quarantine table
+with the --quarantine option, along with their raw key and value, so that
+the rest of their table can be read. The indexes that don't match their
+table are rebuilt with the --reindex option.
+
+The command fails if problems remain.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "quarantine",
+				Usage: "move the rows that cannot be decoded to the __chai_quarantine table.",
+			},
+			&cli.BoolFlag{
+				Name:  "reindex",
+				Usage: "rebuild the indexes that don't match their table.",
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		dbPath := c.Args().First()
+		if dbPath == "" {
+			return errors.New(cmd.UsageText)
+		}
+
+		db, err := dbutil.OpenDB(c.Context, dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		n, err := dbutil.Fsck(c.Context, db, os.Stdout, &dbutil.FsckOptions{
+			Quarantine: c.Bool("quarantine"),
+			Reindex:    c.Bool("reindex"),
+		})
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			return errors.Errorf("%d problems found", n)
+		}
+
+		return nil
+	}
+
+	return &cmd
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewInsertCommand returns a cli.Command for "chai insert".
+func NewInsertCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "insert",
+		Usage:     "Insert JSON or CSV rows into a table.",
+		UsageText: `chai insert [options] dbpath table [file...]`,
+		Description: `The insert command reads rows from files, or from the standard input
+if no file is given, and inserts them into a table.
+
+By default, the input is JSON: either an array of objects or one object per line,
+whose keys are the columns:
+
+$ echo '{"a": 1, "b": "foo"}' | chai insert my.db foo
+
+With --format csv, the first record is a header mapping the fields to the columns:
+
+$ chai insert --format csv my.db foo data.csv
+
+If the table doesn't exist, it is created. Its columns are the keys or the header
+of the input and their types are inferred from the first rows, unless the columns
+are defined explicitly:
+
+$ chai insert --format csv --schema "id INT PRIMARY KEY, name TEXT" my.db foo data.csv
+
+Empty CSV fields are inserted as NULL, except in TEXT columns. The --null option
+defines a token that is inserted as NULL in any column:
+
+$ chai insert --format csv --null NA my.db foo data.csv
+
+Rows are decoded and inserted in batches, each in its own transaction,
+so that large inputs are inserted with bounded memory. If a batch fails,
+the previous ones remain inserted. With --progress, the number of rows
+inserted so far is reported on the standard error after each batch.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "format of the input: json or csv.",
+				Value: dbutil.ImportJSON,
+			},
+			&cli.BoolFlag{
+				Name:  "no-header",
+				Usage: "the first CSV record is data and the columns are named c1, c2, etc.",
+			},
+			&cli.StringFlag{
+				Name:  "delimiter",
+				Usage: "delimiter of the CSV fields.",
+				Value: ",",
+			},
+			&cli.StringFlag{
+				Name:  "null",
+				Usage: "CSV token inserted as NULL.",
+			},
+			&cli.StringFlag{
+				Name:  "schema",
+				Usage: "column definitions of the table if it doesn't exist, e.g. \"id INT PRIMARY KEY, name TEXT\".",
+			},
+			&cli.BoolFlag{
+				Name:  "no-infer",
+				Usage: "create the columns of the table as TEXT instead of inferring their types.",
+			},
+			&cli.BoolFlag{
+				Name:  "progress",
+				Usage: "report the number of rows inserted after each batch.",
+			},
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Usage: "number of rows inserted per transaction.",
+				Value: dbutil.DefaultImportBatchSize,
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		if c.NArg() < 2 {
+			return errors.New(cmd.UsageText)
+		}
+		dbPath, table, files := c.Args().Get(0), c.Args().Get(1), c.Args().Slice()[2:]
+
+		delimiter, err := dbutil.ParseDelimiter(c.String("delimiter"))
+		if err != nil {
+			return err
+		}
+
+		opts := dbutil.ImportOptions{
+			Format:    c.String("format"),
+			NoHeader:  c.Bool("no-header"),
+			Delimiter: delimiter,
+			NoInfer:   c.Bool("no-infer"),
+			BatchSize: c.Int("batch-size"),
+			NullToken: c.String("null"),
+			Schema:    c.String("schema"),
+		}
+		if opts.BatchSize <= 0 {
+			return errors.New("batch size must be positive")
+		}
+
+		var total int
+		if c.Bool("progress") {
+			var inserted int
+			opts.Progress = func(n int) {
+				fmt.Fprintf(os.Stderr, "\rinserted %d rows", total+n)
+				inserted = n
+			}
+			defer func() {
+				if inserted > 0 {
+					fmt.Fprintln(os.Stderr)
+				}
+			}()
+		}
+
+		db, err := dbutil.OpenDB(c.Context, dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if len(files) == 0 {
+			_, err = dbutil.Import(c.Context, db, bufio.NewReaderSize(os.Stdin, 1<<20), table, opts)
+			return err
+		}
+
+		for _, name := range files {
+			n, err := dbutil.ImportFile(c.Context, db, name, table, opts)
+			if err != nil {
+				return errors.Wrap(err, name)
+			}
+			total += n
+		}
+
+		return nil
+	}
+
+	return &cmd
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewLintCommand returns a cli.Command for "chai lint".
+func NewLintCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "lint",
+		Usage:     "Check SQL files for common problems",
+		UsageText: `chai lint [options] file.sql...`,
+		Description: `The lint command checks the SELECT, INSERT, UPDATE and DELETE statements
+of SQL files against a database schema, without running them.
+
+By default, the schema is defined by the CREATE, ALTER and DROP statements
+of the files, which are run in order on an in-memory database:
+
+$ chai lint schema.sql queries.sql
+queries.sql:3: missing-index: no index can be used to evaluate email = "x", all the rows of table users are read
+
+To check the statements against the schema and the data of an existing
+database, use the --db option. The schema statements of the files are then
+ignored and the database is not modified:
+
+$ chai lint --db my.db queries.sql
+
+The following rules are checked:
+- syntax: the statement cannot be parsed
+- invalid: the statement references a table or a column that doesn't exist
+- missing-index: the rows of a table are filtered without the help of an index
+- select-star: a SELECT statement uses *, instead of listing the columns
+- non-sargable: an indexed column is transformed by an expression, which prevents the use of the index
+- strict-typing: the statement compares values of incompatible types and fails with strict typing
+
+The strict-typing rule can be used as a migration report before enabling
+strict typing, by only checking the statements of an application against
+its database:
+
+$ chai lint --db my.db -d select-star -d missing-index -d non-sargable queries.sql
+
+The command fails if a problem is found.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "db",
+				Usage: "path of the database whose schema is used. Defaults to the schema defined by the files.",
+			},
+			&cli.StringSliceFlag{
+				Name:    "disable",
+				Aliases: []string{"d"},
+				Usage:   "name of a rule not to check.",
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		if c.NArg() == 0 {
+			return errors.New(cmd.UsageText)
+		}
+
+		dbPath := c.String("db")
+		db, err := dbutil.OpenDB(c.Context, dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		opts := dbutil.LintOptions{
+			ApplySchema: dbPath == "",
+			Disabled:    c.StringSlice("disable"),
+		}
+
+		var n int
+		for _, name := range c.Args().Slice() {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+
+			issues, err := dbutil.Lint(db, f, &opts)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+			for _, i := range issues {
+				fmt.Printf("%s:%s\n", name, i)
+			}
+			n += len(issues)
+		}
+
+		if n > 0 {
+			return errors.Errorf("%d problems found", n)
+		}
+
+		return nil
+	}
+
+	return &cmd
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewQueryCommand returns a cli.Command for "chai query".
+func NewQueryCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "query",
+		Usage:     "Run SQL against a database without starting the shell.",
+		UsageText: `chai query [options] dbpath [file.sql...]`,
+		Description: `The query command runs SQL statements and prints their results,
+for use in scripts, pipelines and cron jobs.
+
+Statements can be passed with the -c option:
+
+$ chai query -c "INSERT INTO foo VALUES (1); SELECT * FROM foo" --format csv my.db
+
+or read from files, which are run in order:
+
+$ chai query my.db schema.sql data.sql
+
+or, without -c nor files, from the standard input:
+
+$ echo "SELECT COUNT(*) FROM foo" | chai query my.db
+
+Execution stops at the first failing statement. The exit status is 0 on success,
+1 if a statement fails and 2 if the command is misused or the database cannot be opened.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "command",
+				Aliases: []string{"c"},
+				Usage:   "SQL statements to run.",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "output format of the query results: pretty, json, table, csv or markdown",
+				Value: string(dbutil.FormatPretty),
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		dbPath := c.Args().First()
+		if dbPath == "" {
+			return errors.New(cmd.UsageText)
+		}
+		files := c.Args().Tail()
+		command := c.String("command")
+		if command != "" && len(files) > 0 {
+			return errors.New("cannot use both -c and files")
+		}
+
+		format, err := dbutil.ParseFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+
+		db, err := dbutil.OpenDB(c.Context, dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		switch {
+		case command != "":
+			err = dbutil.ExecSQLWithFormat(c.Context, db, strings.NewReader(command), os.Stdout, format)
+		case len(files) > 0:
+			for _, name := range files {
+				f, err := os.Open(name)
+				if err != nil {
+					return err
+				}
+
+				err = dbutil.ExecSQLWithFormat(c.Context, db, f, os.Stdout, format)
+				f.Close()
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("%s: %v", name, err), 1)
+				}
+			}
+		default:
+			err = dbutil.ExecSQLWithFormat(c.Context, db, os.Stdin, os.Stdout, format)
+		}
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		return nil
+	}
+
+	return &cmd
+}
@@ -0,0 +1,22 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/urfave/cli/v2"
+)
+
+// NewSelfTestCommand returns a cli.Command for "chai self-test".
+func NewSelfTestCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "self-test",
+		Usage: "Checks that the database engine works on this platform",
+		Description: `The self-test command runs a series of queries on an in-memory database
+and on an on-disk database created in a temporary directory, and fails if
+any of them returns an unexpected result.`,
+		Action: func(c *cli.Context) error {
+			return dbutil.SelfTest(c.Context, os.Stdout)
+		},
+	}
+}
@@ -2,17 +2,29 @@ package commands
 
 import (
 	"fmt"
+	"runtime"
 	"runtime/debug"
 
 	"github.com/urfave/cli/v2"
 )
 
+// version and commit of the CLI, set at link time by release builds.
+var (
+	version string
+	commit  string
+)
+
 // NewVersionCommand returns a cli.Command for "chai version".
 func NewVersionCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "version",
 		Usage: "Shows Chai and Chai CLI version",
 		Action: func(c *cli.Context) error {
+			if version != "" {
+				fmt.Printf("Chai CLI %v (commit %v, %v)\n", version, commit, runtime.Version())
+				return nil
+			}
+
 			var cliVersion, chaiVersion string
 			info, ok := debug.ReadBuildInfo()
 
@@ -0,0 +1,299 @@
+package dbutil
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// MaskingMethod is the way the values of a column are anonymized.
+type MaskingMethod string
+
+// List of masking methods.
+const (
+	// MaskHash replaces each value by a keyed hash of the value,
+	// of the type of the column. Equal values have the same hash
+	// within a dump, which preserves uniqueness and joins.
+	MaskHash MaskingMethod = "hash"
+	// MaskShuffle permutes the values of the column between the rows of the table.
+	MaskShuffle MaskingMethod = "shuffle"
+	// MaskRedact replaces each value by NULL, or by the zero value of
+	// the type of the column if it is NOT NULL.
+	MaskRedact MaskingMethod = "redact"
+)
+
+// A MaskingRule declares how to anonymize a column when dumping a table.
+type MaskingRule struct {
+	Table  string
+	Column string
+	Method MaskingMethod
+}
+
+// ParseMaskingRule parses a rule of the form table.column=method.
+func ParseMaskingRule(s string) (MaskingRule, error) {
+	target, method, ok := strings.Cut(s, "=")
+	if !ok {
+		return MaskingRule{}, fmt.Errorf("invalid masking rule %q, expected table.column=method", s)
+	}
+
+	// tables may be qualified by a schema, the column is after the last dot.
+	i := strings.LastIndexByte(target, '.')
+	if i <= 0 || i == len(target)-1 {
+		return MaskingRule{}, fmt.Errorf("invalid masking rule %q, expected table.column=method", s)
+	}
+
+	r := MaskingRule{
+		Table:  strings.TrimSpace(target[:i]),
+		Column: strings.TrimSpace(target[i+1:]),
+		Method: MaskingMethod(strings.ToLower(strings.TrimSpace(method))),
+	}
+
+	switch r.Method {
+	case MaskHash, MaskShuffle, MaskRedact:
+	default:
+		return MaskingRule{}, fmt.Errorf("unknown masking method %q, expected hash, shuffle or redact", method)
+	}
+
+	return r, nil
+}
+
+// anonymizer applies the masking rules while dumping tables.
+type anonymizer struct {
+	rules map[string]map[string]MaskingMethod
+	// key of the hashes, random for each dump
+	key []byte
+}
+
+func newAnonymizer(rules []MaskingRule) (*anonymizer, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	a := anonymizer{
+		rules: make(map[string]map[string]MaskingMethod),
+		key:   make([]byte, 32),
+	}
+
+	for _, r := range rules {
+		if a.rules[r.Table] == nil {
+			a.rules[r.Table] = make(map[string]MaskingMethod)
+		}
+		if _, ok := a.rules[r.Table][r.Column]; ok {
+			return nil, fmt.Errorf("column %s.%s has more than one masking rule", r.Table, r.Column)
+		}
+		a.rules[r.Table][r.Column] = r.Method
+	}
+
+	_, err := rand.Read(a.key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &a, nil
+}
+
+// tableMasker anonymizes the rows of a table.
+type tableMasker struct {
+	a       *anonymizer
+	columns map[string]*columnMask
+}
+
+type columnMask struct {
+	cc     *database.ColumnConstraint
+	method MaskingMethod
+	// shuffled values of the column, consumed in order
+	shuffled []types.Value
+}
+
+// table returns the masker of the table created by the given query,
+// or nil if none of its columns is masked.
+func (a *anonymizer) table(tableName, query string) (*tableMasker, error) {
+	if a == nil || len(a.rules[tableName]) == 0 {
+		return nil, nil
+	}
+
+	q, err := parser.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	info := q.Statements[0].(*statement.CreateTableStmt).Info
+
+	m := tableMasker{
+		a:       a,
+		columns: make(map[string]*columnMask),
+	}
+	for column, method := range a.rules[tableName] {
+		cc := info.GetColumnConstraint(column)
+		if cc == nil {
+			return nil, fmt.Errorf("cannot mask unknown column %s.%s", tableName, column)
+		}
+
+		if method == MaskHash {
+			switch cc.Type {
+			case types.TypeText, types.TypeBlob, types.TypeInteger, types.TypeBigint, types.TypeDouble, types.TypeBoolean, types.TypeUUID:
+			default:
+				return nil, fmt.Errorf("cannot hash column %s.%s of type %s", tableName, column, cc.Type)
+			}
+		}
+
+		m.columns[column] = &columnMask{cc: cc, method: method}
+	}
+
+	return &m, nil
+}
+
+// needsShuffle returns whether the values of some columns must be read
+// before dumping the table.
+func (m *tableMasker) needsShuffle() bool {
+	for _, c := range m.columns {
+		if c.method == MaskShuffle {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collect adds the values of the shuffled columns of a row.
+// It must be called for every row of the table, in order, before shuffle.
+func (m *tableMasker) collect(column string, v types.Value) {
+	c, ok := m.columns[column]
+	if ok && c.method == MaskShuffle {
+		c.shuffled = append(c.shuffled, v)
+	}
+}
+
+// shuffle permutes the collected values of each shuffled column.
+func (m *tableMasker) shuffle() {
+	for _, c := range m.columns {
+		mrand.Shuffle(len(c.shuffled), func(i, j int) {
+			c.shuffled[i], c.shuffled[j] = c.shuffled[j], c.shuffled[i]
+		})
+	}
+}
+
+// mask returns the anonymized value of a column.
+func (m *tableMasker) mask(column string, v types.Value) (types.Value, error) {
+	if m == nil {
+		return v, nil
+	}
+
+	c, ok := m.columns[column]
+	if !ok {
+		return v, nil
+	}
+
+	switch c.method {
+	case MaskShuffle:
+		if len(c.shuffled) == 0 {
+			return nil, errors.Errorf("table changed while shuffling column %q", column)
+		}
+		v, c.shuffled = c.shuffled[0], c.shuffled[1:]
+		return v, nil
+	case MaskRedact:
+		if !c.cc.IsNotNull {
+			return types.NewNullValue(), nil
+		}
+		return zeroValue(c.cc), nil
+	}
+
+	if v.Type() == types.TypeNull {
+		return v, nil
+	}
+
+	return m.a.hash(c.cc.Type, v), nil
+}
+
+// hash returns a keyed hash of v, as a value of type tp.
+func (a *anonymizer) hash(tp types.Type, v types.Value) types.Value {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(v.String()))
+	sum := mac.Sum(nil)
+
+	switch tp {
+	case types.TypeBoolean:
+		return types.NewBooleanValue(sum[0]&1 == 1)
+	case types.TypeInteger:
+		return types.NewIntegerValue(int32(binary.BigEndian.Uint32(sum)))
+	case types.TypeBigint:
+		return types.NewBigintValue(int64(binary.BigEndian.Uint64(sum)))
+	case types.TypeDouble:
+		return types.NewDoubleValue(float64(binary.BigEndian.Uint64(sum) >> 11))
+	case types.TypeBlob:
+		return types.NewBlobValue(sum)
+	case types.TypeUUID:
+		return types.NewUUIDValue([16]byte(sum))
+	}
+
+	return types.NewTextValue(hex.EncodeToString(sum[:16]))
+}
+
+// zeroValue returns the zero value of the type of the column.
+func zeroValue(cc *database.ColumnConstraint) types.Value {
+	switch cc.Type {
+	case types.TypeBoolean:
+		return types.NewBooleanValue(false)
+	case types.TypeInteger:
+		return types.NewIntegerValue(0)
+	case types.TypeBigint:
+		return types.NewBigintValue(0)
+	case types.TypeDouble:
+		return types.NewDoubleValue(0)
+	case types.TypeDecimal:
+		return types.NewDecimalValue(new(big.Int), 0)
+	case types.TypeTimestamp:
+		return types.NewTimestampValue(time.Unix(0, 0).UTC())
+	case types.TypeDate:
+		return types.NewDateValue(time.Unix(0, 0).UTC())
+	case types.TypeInterval:
+		return types.NewIntervalValue(0, 0, 0)
+	case types.TypeBlob:
+		return types.NewBlobValue([]byte{})
+	case types.TypeUUID:
+		return types.NewUUIDValue([16]byte{})
+	case types.TypeVector:
+		return types.NewVectorValue(make([]float32, cc.VectorSpec.Dimension))
+	}
+
+	return types.NewTextValue("")
+}
+
+// checkTables returns an error if a rule targets a table
+// that doesn't exist, to avoid dumping it unmasked by mistake
+// if its name is misspelled.
+func (a *anonymizer) checkTables(tx *chai.Tx) error {
+	if a == nil {
+		return nil
+	}
+
+	for table := range a.rules {
+		r, err := tx.QueryRow("SELECT COUNT(*) FROM __chai_catalog WHERE type = 'table' AND name = ?", table)
+		if err != nil {
+			return err
+		}
+
+		var n int
+		if err = r.Scan(&n); err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("cannot mask columns of unknown table %q", table)
+		}
+	}
+
+	return nil
+}
@@ -6,12 +6,24 @@ import (
 	"strings"
 
 	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/types"
 	"go.uber.org/multierr"
 )
 
 // Dump takes a database and dumps its content as SQL queries in the given writer.
 // If tables is provided, only selected tables will be outputted.
 func Dump(db *chai.DB, w io.Writer, tables ...string) error {
+	return DumpAnonymized(db, w, nil, tables...)
+}
+
+// DumpAnonymized dumps the content of a database like Dump, anonymizing
+// the columns targeted by the given masking rules.
+func DumpAnonymized(db *chai.DB, w io.Writer, rules []MaskingRule, tables ...string) error {
+	a, err := newAnonymizer(rules)
+	if err != nil {
+		return err
+	}
+
 	conn, err := db.Connect()
 	if err != nil {
 		return err
@@ -24,10 +36,19 @@ func Dump(db *chai.DB, w io.Writer, tables ...string) error {
 	}
 	defer tx.Rollback()
 
+	if err = a.checkTables(tx); err != nil {
+		return err
+	}
+
 	if _, err = fmt.Fprintln(w, "BEGIN TRANSACTION;"); err != nil {
 		return err
 	}
 
+	if err = dumpSchemas(tx, w); err != nil {
+		_, er := fmt.Fprintln(w, "ROLLBACK;")
+		return multierr.Append(err, er)
+	}
+
 	i := 0
 	err = QueryTables(tx, tables, func(name, query string) error {
 		// Blank separation between tables.
@@ -38,7 +59,7 @@ func Dump(db *chai.DB, w io.Writer, tables ...string) error {
 		}
 		i++
 
-		return dumpTable(tx, w, query, name)
+		return dumpTable(tx, w, a, query, name)
 	})
 	if err != nil {
 		_, er := fmt.Fprintln(w, "ROLLBACK;")
@@ -50,56 +71,77 @@ func Dump(db *chai.DB, w io.Writer, tables ...string) error {
 }
 
 // dumpTable displays the content of the given table as SQL statements.
-func dumpTable(tx *chai.Tx, w io.Writer, query, tableName string) error {
-	// Dump schema first.
-	if err := dumpSchema(tx, w, query, tableName); err != nil {
+func dumpTable(tx *chai.Tx, w io.Writer, a *anonymizer, query, tableName string) error {
+	m, err := a.table(tableName, query)
+	if err != nil {
 		return err
 	}
 
-	q := fmt.Sprintf("SELECT * FROM %s", tableName)
-	res, err := tx.Query(q)
-	if err != nil {
+	// Dump schema first.
+	if err := dumpSchema(tx, w, query, tableName); err != nil {
 		return err
 	}
-	defer res.Close()
 
-	// Inserts statements.
-	return res.Iterate(func(r *chai.Row) error {
-		cols, err := r.Columns()
-		if err != nil {
-			return err
-		}
+	q := fmt.Sprintf("SELECT * FROM %s", tableName)
 
-		m := make(map[string]interface{}, len(cols))
-		err = r.MapScan(m)
+	// Shuffled columns are read entirely before dumping the rows.
+	if m != nil && m.needsShuffle() {
+		err = iterateTable(tx, q, func(r *chai.Row) error {
+			return r.Row.Iterate(func(column string, v types.Value) error {
+				m.collect(column, v)
+				return nil
+			})
+		})
 		if err != nil {
 			return err
 		}
+		m.shuffle()
+	}
 
+	// Inserts statements.
+	return iterateTable(tx, q, func(r *chai.Row) error {
 		var sb strings.Builder
 
-		for i, c := range cols {
+		i := 0
+		err := r.Row.Iterate(func(column string, v types.Value) error {
 			if i > 0 {
 				sb.WriteString(", ")
 			}
+			i++
 
-			v := m[c]
-			if v == nil {
-				sb.WriteString("NULL")
-				continue
+			v, err := m.mask(column, v)
+			if err != nil {
+				return err
 			}
 
-			fmt.Fprintf(&sb, "%v", v)
-		}
+			// decimals are written as texts to be restored
+			// exactly, numbers are parsed as doubles
+			if v.Type() == types.TypeDecimal {
+				v = types.NewTextValue(v.String())
+			}
 
-		if _, err := fmt.Fprintf(w, "INSERT INTO %s VALUES (%s);\n", tableName, sb.String()); err != nil {
+			sb.WriteString(v.String())
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 
-		return nil
+		_, err = fmt.Fprintf(w, "INSERT INTO %s VALUES (%s);\n", tableName, sb.String())
+		return err
 	})
 }
 
+func iterateTable(tx *chai.Tx, query string, fn func(r *chai.Row) error) error {
+	res, err := tx.Query(query)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	return res.Iterate(fn)
+}
+
 // DumpSchema takes a database and dumps its schema as SQL queries in the given writer.
 // If tables are provided, only selected tables will be outputted.
 func DumpSchema(db *chai.DB, w io.Writer, tables ...string) error {
@@ -115,6 +157,10 @@ func DumpSchema(db *chai.DB, w io.Writer, tables ...string) error {
 	}
 	defer tx.Rollback()
 
+	if err = dumpSchemas(tx, w); err != nil {
+		return err
+	}
+
 	i := 0
 	return QueryTables(tx, tables, func(name, query string) error {
 		// Blank separation between tables.
@@ -129,6 +175,46 @@ func DumpSchema(db *chai.DB, w io.Writer, tables ...string) error {
 	})
 }
 
+// dumpSchemas displays the CREATE SCHEMA and CREATE FUNCTION statements of the database,
+// so that they are run before the creation of the relations that contain or use them.
+func dumpSchemas(tx *chai.Tx, w io.Writer) error {
+	n := 0
+	for _, tp := range []string{"schema", "function"} {
+		res, err := tx.Query("SELECT sql FROM __chai_catalog WHERE type = ? ORDER BY name", tp)
+		if err != nil {
+			return err
+		}
+
+		err = res.Iterate(func(r *chai.Row) error {
+			var q string
+
+			err := r.Scan(&q)
+			if err != nil {
+				return err
+			}
+			n++
+
+			_, err = fmt.Fprintf(w, "%s;\n", q)
+			return err
+		})
+		if err != nil {
+			res.Close()
+			return err
+		}
+
+		if err = res.Close(); err != nil {
+			return err
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	// Blank separation between schemas and tables.
+	_, err := fmt.Fprintln(w, "")
+	return err
+}
+
 // dumpSchema displays the schema of the given table as SQL statements.
 func dumpSchema(tx *chai.Tx, w io.Writer, query string, tableName string) error {
 	_, err := fmt.Fprintf(w, "%s;\n", query)
@@ -136,10 +222,11 @@ func dumpSchema(tx *chai.Tx, w io.Writer, query string, tableName string) error
 		return err
 	}
 
-	// Indexes statements.
+	// Indexes statements. Indexes owned by a constraint
+	// are created with the table.
 	res, err := tx.Query(`
 		SELECT sql FROM __chai_catalog WHERE 
-			type = 'index' AND owner_table_name = ? OR
+			type = 'index' AND owner_table_name = ? AND owner_table_columns IS NULL OR
 			type = 'sequence' AND owner_table_name IS NULL
 	`, tableName)
 	if err != nil {
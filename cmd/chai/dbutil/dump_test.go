@@ -3,6 +3,7 @@ package dbutil
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/chaisql/chai"
@@ -92,3 +93,168 @@ func TestDump(t *testing.T) {
 		})
 	}
 }
+
+func TestDumpDecimal(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE prices (a DECIMAL, b DECIMAL(10, 2));
+		INSERT INTO prices VALUES ('123456789012345678901234567890.123456789', 1.5);
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = Dump(db, &buf)
+	require.NoError(t, err)
+
+	restored, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer restored.Close()
+
+	err = restored.Exec(buf.String())
+	require.NoError(t, err)
+
+	r, err := restored.QueryRow("SELECT a, b FROM prices")
+	require.NoError(t, err)
+	var a, b string
+	require.NoError(t, r.Scan(&a, &b))
+	require.Equal(t, "123456789012345678901234567890.123456789", a)
+	require.Equal(t, "1.50", b)
+}
+
+func TestDumpFunctions(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE FUNCTION area(w, h) RETURNS (w * h);
+		CREATE TABLE rects (w INT, h INT);
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = Dump(db, &buf)
+	require.NoError(t, err)
+	require.Equal(t, `BEGIN TRANSACTION;
+CREATE FUNCTION area(w, h) RETURNS (w * h);
+
+CREATE TABLE rects (w INTEGER, h INTEGER);
+COMMIT;
+`, buf.String())
+}
+
+func TestDumpAnonymized(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT UNIQUE, name TEXT, phone TEXT, age INTEGER NOT NULL);
+		INSERT INTO users VALUES (1, 'a@example.com', 'alice', '0102', 30);
+		INSERT INTO users VALUES (2, 'b@example.com', 'bob', '0304', 40);
+		INSERT INTO users VALUES (3, 'c@example.com', 'carol', NULL, 50);
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, email TEXT);
+		INSERT INTO orders VALUES (1, 'b@example.com');
+	`)
+	require.NoError(t, err)
+
+	parse := func(rules ...string) []MaskingRule {
+		var l []MaskingRule
+		for _, s := range rules {
+			r, err := ParseMaskingRule(s)
+			require.NoError(t, err)
+			l = append(l, r)
+		}
+		return l
+	}
+
+	var buf bytes.Buffer
+	err = DumpAnonymized(db, &buf, parse("users.email=hash", "orders.email=hash", "users.name=shuffle", "users.phone=redact", "users.age=redact"))
+	require.NoError(t, err)
+
+	require.NotContains(t, buf.String(), "example.com")
+	require.NotContains(t, buf.String(), "0102")
+
+	// the dump can be restored
+	restored, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer restored.Close()
+
+	err = restored.Exec(buf.String())
+	require.NoError(t, err)
+
+	var emails []string
+	var names []string
+	conn, err := restored.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	res, err := conn.Query("SELECT email, name, phone, age FROM users ORDER BY id")
+	require.NoError(t, err)
+	defer res.Close()
+	err = res.Iterate(func(r *chai.Row) error {
+		var email, name string
+		var phone *string
+		var age int
+		err := r.Scan(&email, &name, &phone, &age)
+		require.NoError(t, err)
+		require.Nil(t, phone)
+		require.Zero(t, age)
+		emails = append(emails, email)
+		names = append(names, name)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, emails, 3)
+	require.NotEqual(t, emails[0], emails[1])
+	require.ElementsMatch(t, []string{"alice", "bob", "carol"}, names)
+
+	// equal values have the same hash across tables
+	r, err := restored.QueryRow("SELECT email FROM orders")
+	require.NoError(t, err)
+	var email string
+	require.NoError(t, r.Scan(&email))
+	require.Equal(t, emails[1], email)
+
+	t.Run("Unknown table", func(t *testing.T) {
+		err := DumpAnonymized(db, io.Discard, parse("user.email=hash"))
+		require.Error(t, err)
+	})
+
+	t.Run("Unknown column", func(t *testing.T) {
+		err := DumpAnonymized(db, io.Discard, parse("users.mail=hash"))
+		require.Error(t, err)
+	})
+}
+
+func TestParseMaskingRule(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  MaskingRule
+		fails bool
+	}{
+		{"users.email=hash", MaskingRule{"users", "email", MaskHash}, false},
+		{"app.users.email=SHUFFLE", MaskingRule{"app.users", "email", MaskShuffle}, false},
+		{"users.email = redact", MaskingRule{"users", "email", MaskRedact}, false},
+		{"users.email", MaskingRule{}, true},
+		{"email=hash", MaskingRule{}, true},
+		{"users.=hash", MaskingRule{}, true},
+		{"users.email=encrypt", MaskingRule{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			r, err := ParseMaskingRule(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, r)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package dbutil
 
 import (
 	"context"
-	"encoding/json"
 	"io"
 
 	"github.com/chaisql/chai"
@@ -13,11 +12,15 @@ import (
 )
 
 // ExecSQL reads SQL queries from reader and executes them until the reader is exhausted.
-// If the query has results, they will be outputted to w.
+// If the query has results, they will be outputted to w as indented JSON objects.
 func ExecSQL(ctx context.Context, db *chai.DB, r io.Reader, w io.Writer) error {
-	enc := json.NewEncoder(w)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
+	return ExecSQLWithFormat(ctx, db, r, w, FormatPretty)
+}
+
+// ExecSQLWithFormat executes the SQL queries like ExecSQL,
+// outputting their results in the given format.
+func ExecSQLWithFormat(ctx context.Context, db *chai.DB, r io.Reader, w io.Writer, format Format) error {
+	rw := newRowWriter(w, format)
 
 	conn, err := db.Connect()
 	if err != nil {
@@ -49,13 +52,19 @@ func ExecSQL(ctx context.Context, db *chai.DB, r io.Reader, w io.Writer) error {
 			default:
 			}
 
-			return enc.Encode(r)
+			return rw.WriteRow(r)
 		})
 		if err != nil {
 			res.Close()
 			return err
 		}
 
+		err = rw.Flush()
+		if err != nil {
+			res.Close()
+			return err
+		}
+
 		return res.Close()
 	})
 }
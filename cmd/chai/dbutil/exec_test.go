@@ -39,3 +39,41 @@ func TestExecSQL(t *testing.T) {
 	require.Equal(t, 1, res.A)
 	require.Equal(t, 2, res.B)
 }
+
+func TestExecSQLWithFormat(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatJSON, "{\"a\":1,\"b\":\"x\",\"c\":null}\n{\"a\":2,\"b\":\"y|z\",\"c\":\"qw==\"}\n"},
+		{FormatTable, "+---+-----+------+\n| a | b   | c    |\n+---+-----+------+\n| 1 | x   | NULL |\n| 2 | y|z | \\xab |\n+---+-----+------+\n"},
+		{FormatCSV, "a,b,c\n1,x,\n2,y|z,\\xab\n"},
+		{FormatMarkdown, "| a   | b    | c    |\n| --- | ---- | ---- |\n| 1   | x    | NULL |\n| 2   | y\\|z | \\xab |\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			db, err := chai.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			var got bytes.Buffer
+			err = ExecSQLWithFormat(context.Background(), db, strings.NewReader(`
+				CREATE TABLE test(a INT, b TEXT, c BLOB);
+				INSERT INTO test (a, b, c) VALUES (1, 'x', NULL), (2, 'y|z', '\xab');
+				SELECT * FROM test;
+			`), &got, test.format)
+			require.NoError(t, err)
+			require.Equal(t, test.want, got.String())
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("TABLE")
+	require.NoError(t, err)
+	require.Equal(t, FormatTable, f)
+
+	_, err = ParseFormat("xml")
+	require.Error(t, err)
+}
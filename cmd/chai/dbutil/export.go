@@ -0,0 +1,319 @@
+package dbutil
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Export file formats.
+const (
+	ExportCSV     = "csv"
+	ExportNDJSON  = "ndjson"
+	ExportParquet = "parquet"
+)
+
+// ExportOptions controls what Export writes.
+type ExportOptions struct {
+	// Format of the output, ExportCSV, ExportNDJSON or ExportParquet.
+	Format string
+	// Table to export. Exclusive with Query.
+	Table string
+	// Query whose result is exported. Exclusive with Table.
+	Query string
+	// Columns to export, in order. Defaults to all the columns of the result.
+	Columns []string
+}
+
+// ExportFormat returns the export format matching the extension of the file,
+// or an empty string if there is none.
+func ExportFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ExportCSV
+	case ".ndjson", ".jsonl", ".json":
+		return ExportNDJSON
+	case ".parquet":
+		return ExportParquet
+	}
+
+	return ""
+}
+
+// Export streams the rows of a table or the result of a query to w
+// and returns the number of rows written.
+// CSV output has a header row and NULL values are written as empty fields.
+// Parquet columns are optional and their types are those of the first
+// non-NULL values of the column.
+func Export(ctx context.Context, db *chai.DB, w io.Writer, opts ExportOptions) (int, error) {
+	q := opts.Query
+	switch {
+	case opts.Table != "" && q != "":
+		return 0, errors.New("cannot export both a table and a query")
+	case opts.Table != "":
+		q = "SELECT * FROM " + quoteIdent(opts.Table)
+	case q == "":
+		return 0, errors.New("a table or a query is required")
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(q)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Close()
+
+	columns, err := res.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if len(opts.Columns) > 0 {
+		for _, c := range opts.Columns {
+			if !stringutil.Contains(columns, c) {
+				return 0, errors.Errorf("no such column: %s", c)
+			}
+		}
+		columns = opts.Columns
+	}
+
+	var rw rowWriter
+	switch strings.ToLower(opts.Format) {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		err = cw.Write(columns)
+		if err != nil {
+			return 0, err
+		}
+		rw = &csvWriter{w: cw, header: true}
+	case ExportNDJSON:
+		rw = newRowWriter(w, FormatJSON)
+	case ExportParquet:
+		rw = &parquetWriter{w: w, columns: columns}
+	default:
+		return 0, errors.Errorf("unsupported format %q, expected csv, ndjson or parquet", opts.Format)
+	}
+
+	var n int
+	var br database.BasicRow
+	cb := row.NewColumnBuffer()
+	err = res.Iterate(func(r *chai.Row) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// keep the selected columns, in order
+		cb.Reset()
+		for _, c := range columns {
+			v, err := r.Row.Get(c)
+			if err != nil {
+				return err
+			}
+			cb.Add(c, v)
+		}
+		br.ResetWith(r.Row.TableName(), nil, cb)
+
+		n++
+		return rw.WriteRow(&br)
+	})
+	if err != nil {
+		return n, err
+	}
+
+	return n, rw.Flush()
+}
+
+// parquetSampleSize is the number of rows buffered by the parquet writer
+// to find the types of the columns.
+const parquetSampleSize = 1000
+
+// parquetWriter writes rows as a parquet file.
+// The schema is only known once a non-NULL value was seen for each column,
+// or after parquetSampleSize rows, so rows are buffered until then.
+type parquetWriter struct {
+	w       io.Writer
+	columns []string
+	types   []types.Type
+	indexes []int
+	rows    []database.Row
+	pw      *parquet.Writer
+}
+
+func (p *parquetWriter) WriteRow(r database.Row) error {
+	if p.pw != nil {
+		return p.write(r)
+	}
+
+	p.init()
+
+	known := true
+	for i, c := range p.columns {
+		if p.types[i] != types.TypeNull {
+			continue
+		}
+		v, err := r.Get(c)
+		if err != nil {
+			return err
+		}
+		p.types[i] = v.Type()
+		known = known && p.types[i] != types.TypeNull
+	}
+
+	// the row is reused by the caller
+	cb := row.NewColumnBuffer()
+	err := cb.Copy(r)
+	if err != nil {
+		return err
+	}
+	p.rows = append(p.rows, database.NewBasicRow(cb))
+
+	if known || len(p.rows) >= parquetSampleSize {
+		return p.open()
+	}
+
+	return nil
+}
+
+func (p *parquetWriter) init() {
+	if p.types != nil {
+		return
+	}
+
+	p.types = make([]types.Type, len(p.columns))
+	for i := range p.types {
+		p.types[i] = types.TypeNull
+	}
+}
+
+// open creates the parquet writer and writes the buffered rows.
+func (p *parquetWriter) open() error {
+	p.init()
+
+	group := make(parquet.Group, len(p.columns))
+	for i, c := range p.columns {
+		if _, ok := group[c]; ok {
+			return errors.Errorf("duplicate column %s", c)
+		}
+
+		var node parquet.Node
+		switch p.types[i] {
+		case types.TypeBoolean:
+			node = parquet.Leaf(parquet.BooleanType)
+		case types.TypeInteger:
+			node = parquet.Int(32)
+		case types.TypeBigint:
+			node = parquet.Int(64)
+		case types.TypeDouble:
+			node = parquet.Leaf(parquet.DoubleType)
+		case types.TypeTimestamp:
+			node = parquet.Timestamp(parquet.Microsecond)
+		case types.TypeDate:
+			node = parquet.Date()
+		case types.TypeBlob:
+			node = parquet.Leaf(parquet.ByteArrayType)
+		default:
+			// other types are written as text
+			p.types[i] = types.TypeText
+			node = parquet.String()
+		}
+		group[c] = parquet.Optional(node)
+	}
+
+	schema := parquet.NewSchema("row", group)
+	p.indexes = make([]int, len(p.columns))
+	for i, c := range p.columns {
+		leaf, _ := schema.Lookup(c)
+		p.indexes[i] = leaf.ColumnIndex
+	}
+	p.pw = parquet.NewWriter(p.w, schema)
+
+	for _, r := range p.rows {
+		err := p.write(r)
+		if err != nil {
+			return err
+		}
+	}
+	p.rows = nil
+
+	return nil
+}
+
+func (p *parquetWriter) write(r database.Row) error {
+	prow := make(parquet.Row, len(p.columns))
+	for i, c := range p.columns {
+		v, err := r.Get(c)
+		if err != nil {
+			return err
+		}
+
+		if v.Type() == types.TypeNull {
+			prow[p.indexes[i]] = parquet.NullValue().Level(0, 0, p.indexes[i])
+			continue
+		}
+
+		if v.Type() != p.types[i] {
+			v, err = v.CastAs(p.types[i])
+			if err != nil {
+				return fmt.Errorf("column %s: %w", c, err)
+			}
+		}
+
+		var pv parquet.Value
+		switch p.types[i] {
+		case types.TypeBoolean:
+			pv = parquet.BooleanValue(types.AsBool(v))
+		case types.TypeInteger:
+			pv = parquet.Int32Value(types.AsInt32(v))
+		case types.TypeBigint:
+			pv = parquet.Int64Value(types.AsInt64(v))
+		case types.TypeDouble:
+			pv = parquet.DoubleValue(types.AsFloat64(v))
+		case types.TypeTimestamp:
+			pv = parquet.Int64Value(types.AsTime(v).UnixMicro())
+		case types.TypeDate:
+			days := types.AsTime(v).Unix() / (24 * 60 * 60)
+			if types.AsTime(v).Unix()%(24*60*60) < 0 {
+				days--
+			}
+			pv = parquet.Int32Value(int32(days))
+		case types.TypeBlob:
+			pv = parquet.ByteArrayValue(types.AsByteSlice(v))
+		default:
+			s, err := formatValue(v, "")
+			if err != nil {
+				return err
+			}
+			pv = parquet.ByteArrayValue([]byte(s))
+		}
+		prow[p.indexes[i]] = pv.Level(0, 1, p.indexes[i])
+	}
+
+	_, err := p.pw.WriteRows([]parquet.Row{prow})
+	return err
+}
+
+func (p *parquetWriter) Flush() error {
+	if p.pw == nil {
+		err := p.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.pw.Close()
+}
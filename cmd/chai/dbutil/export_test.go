@@ -0,0 +1,85 @@
+package dbutil
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b TEXT, c DOUBLE, d TIMESTAMP);
+		INSERT INTO test (a, b, c, d) VALUES (1, 'foo', 1.5, '2024-01-02T03:04:05Z'), (2, NULL, 2, NULL);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		opts ExportOptions
+		want string
+		n    int
+	}{
+		{"CSV", ExportOptions{Format: ExportCSV, Table: "test"}, "a,b,c,d\n1,foo,1.5,2024-01-02T03:04:05Z\n2,,2.0,\n", 2},
+		{"CSV/columns", ExportOptions{Format: ExportCSV, Table: "test", Columns: []string{"c", "a"}}, "c,a\n1.5,1\n2.0,2\n", 2},
+		{"CSV/empty", ExportOptions{Format: ExportCSV, Query: "SELECT a FROM test WHERE a > 10"}, "a\n", 0},
+		{"NDJSON", ExportOptions{Format: ExportNDJSON, Query: "SELECT a, b FROM test"}, "{\"a\":1,\"b\":\"foo\"}\n{\"a\":2,\"b\":null}\n", 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := Export(context.Background(), db, &buf, test.opts)
+			require.NoError(t, err)
+			require.Equal(t, test.n, n)
+			require.Equal(t, test.want, buf.String())
+		})
+	}
+
+	t.Run("Parquet", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := Export(context.Background(), db, &buf, ExportOptions{Format: ExportParquet, Query: "SELECT b, a, d FROM test ORDER BY a DESC"})
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		type record struct {
+			A *int32  `parquet:"a,optional"`
+			B *string `parquet:"b,optional"`
+			D *int64  `parquet:"d,optional"`
+		}
+
+		rows, err := parquet.Read[record](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		require.Equal(t, int32(2), *rows[0].A)
+		require.Nil(t, rows[0].B)
+		require.Nil(t, rows[0].D)
+		require.Equal(t, int32(1), *rows[1].A)
+		require.Equal(t, "foo", *rows[1].B)
+		require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).UnixMicro(), *rows[1].D)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := Export(context.Background(), db, &bytes.Buffer{}, ExportOptions{Format: ExportCSV})
+		require.Error(t, err)
+		_, err = Export(context.Background(), db, &bytes.Buffer{}, ExportOptions{Format: ExportCSV, Table: "test", Columns: []string{"z"}})
+		require.Error(t, err)
+		_, err = Export(context.Background(), db, &bytes.Buffer{}, ExportOptions{Format: "xml", Table: "test"})
+		require.Error(t, err)
+	})
+}
+
+func TestExportFormat(t *testing.T) {
+	require.Equal(t, ExportCSV, ExportFormat("a/b.CSV"))
+	require.Equal(t, ExportNDJSON, ExportFormat("b.jsonl"))
+	require.Equal(t, ExportParquet, ExportFormat("b.parquet"))
+	require.Equal(t, "", ExportFormat("b"))
+}
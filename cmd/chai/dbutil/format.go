@@ -0,0 +1,266 @@
+package dbutil
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// Format is the format in which ExecSQL outputs the results of the queries.
+type Format string
+
+// Supported formats.
+const (
+	// FormatPretty outputs each row as an indented JSON object.
+	FormatPretty Format = "pretty"
+	// FormatJSON outputs each row as a JSON object on its own line.
+	FormatJSON Format = "json"
+	// FormatTable outputs the rows of each query as an ASCII table.
+	FormatTable Format = "table"
+	// FormatCSV outputs the rows of each query as CSV, with a header row.
+	FormatCSV Format = "csv"
+	// FormatMarkdown outputs the rows of each query as a markdown table.
+	FormatMarkdown Format = "markdown"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{FormatPretty, FormatJSON, FormatTable, FormatCSV, FormatMarkdown}
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+
+	return "", fmt.Errorf("unknown format %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// A rowWriter writes the rows of a query in a format.
+type rowWriter interface {
+	WriteRow(r database.Row) error
+	// Flush is called once all the rows of a query are written.
+	Flush() error
+}
+
+func newRowWriter(w io.Writer, f Format) rowWriter {
+	switch f {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return &jsonWriter{enc: enc}
+	case FormatTable:
+		return &tableWriter{w: w}
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}
+	case FormatMarkdown:
+		return &tableWriter{w: w, markdown: true}
+	default:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return &jsonWriter{enc: enc}
+	}
+}
+
+type jsonWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonWriter) WriteRow(r database.Row) error {
+	return j.enc.Encode(r)
+}
+
+func (j *jsonWriter) Flush() error {
+	return nil
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvWriter) WriteRow(r database.Row) error {
+	// NULL values are written as empty fields
+	columns, values, err := rowCells(r, "")
+	if err != nil {
+		return err
+	}
+
+	if !c.header {
+		c.header = true
+		err = c.w.Write(columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.w.Write(values)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// tableWriter buffers the rows of a query to align their columns
+// and writes them as an ASCII or a markdown table.
+type tableWriter struct {
+	w        io.Writer
+	markdown bool
+	columns  []string
+	rows     [][]string
+}
+
+func (t *tableWriter) WriteRow(r database.Row) error {
+	columns, values, err := rowCells(r, "NULL")
+	if err != nil {
+		return err
+	}
+
+	if t.columns == nil {
+		t.columns = columns
+	}
+	t.rows = append(t.rows, values)
+	return nil
+}
+
+func (t *tableWriter) Flush() error {
+	defer func() {
+		t.columns = nil
+		t.rows = nil
+	}()
+
+	if t.columns == nil {
+		return nil
+	}
+
+	widths := make([]int, len(t.columns))
+	if t.markdown {
+		// the header separator needs at least three dashes
+		for i := range widths {
+			widths[i] = 3
+		}
+	}
+	for _, row := range append([][]string{t.columns}, t.rows...) {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(t.escape(cell)))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	separator := func() {
+		sb.WriteByte('+')
+		for _, w := range widths {
+			sb.WriteString(strings.Repeat("-", w+2))
+			sb.WriteByte('+')
+		}
+		sb.WriteByte('\n')
+	}
+	line := func(cells []string) {
+		sb.WriteByte('|')
+		for i, w := range widths {
+			var cell string
+			if i < len(cells) {
+				cell = t.escape(cells[i])
+			}
+			sb.WriteByte(' ')
+			sb.WriteString(cell)
+			sb.WriteString(strings.Repeat(" ", w-utf8.RuneCountInString(cell)))
+			sb.WriteString(" |")
+		}
+		sb.WriteByte('\n')
+	}
+
+	if t.markdown {
+		line(t.columns)
+		sb.WriteByte('|')
+		for _, w := range widths {
+			sb.WriteString(" " + strings.Repeat("-", w) + " |")
+		}
+		sb.WriteByte('\n')
+		for _, row := range t.rows {
+			line(row)
+		}
+	} else {
+		separator()
+		line(t.columns)
+		separator()
+		for _, row := range t.rows {
+			line(row)
+		}
+		separator()
+	}
+
+	_, err := io.WriteString(t.w, sb.String())
+	return err
+}
+
+// escape escapes the characters that would break the table.
+func (t *tableWriter) escape(cell string) string {
+	cell = strings.ReplaceAll(cell, "\n", `\n`)
+	if t.markdown {
+		cell = strings.ReplaceAll(cell, "|", `\|`)
+	}
+
+	return cell
+}
+
+// rowCells returns the columns of the row and their values as text,
+// with NULL values replaced by null.
+func rowCells(r database.Row, null string) (columns, values []string, err error) {
+	err = r.Iterate(func(column string, v types.Value) error {
+		s, err := formatValue(v, null)
+		if err != nil {
+			return err
+		}
+
+		columns = append(columns, column)
+		values = append(values, s)
+		return nil
+	})
+
+	return columns, values, err
+}
+
+// formatValue returns the value as it is displayed in a table:
+// texts are not quoted, blobs are displayed in hexadecimal
+// and other values use their SQL representation.
+func formatValue(v types.Value, null string) (string, error) {
+	switch v.Type() {
+	case types.TypeNull:
+		return null, nil
+	case types.TypeText:
+		return types.AsString(v), nil
+	case types.TypeBlob:
+		return `\x` + hex.EncodeToString(types.AsByteSlice(v)), nil
+	}
+
+	b, err := v.MarshalText()
+	if err != nil {
+		return "", err
+	}
+
+	s := string(b)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted, nil
+	}
+
+	return s, nil
+}
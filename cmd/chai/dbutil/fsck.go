@@ -0,0 +1,63 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+)
+
+// FsckOptions configure Fsck.
+type FsckOptions struct {
+	// If true, the rows that cannot be decoded are moved
+	// to the __chai_quarantine table.
+	Quarantine bool
+	// If true, the indexes that don't match their table are rebuilt.
+	Reindex bool
+}
+
+// Fsck checks the integrity of the database and writes the problems it finds to w,
+// followed by a summary. It returns the number of problems that were not repaired.
+func Fsck(ctx context.Context, db *chai.DB, w io.Writer, opts *FsckOptions) (int, error) {
+	if opts == nil {
+		opts = &FsckOptions{}
+	}
+
+	report, err := db.Check(ctx, &chai.CheckOptions{
+		Quarantine: opts.Quarantine,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var remaining int
+	var reindex []string
+	for _, p := range report.Problems {
+		fmt.Fprintln(w, p)
+
+		switch {
+		case p.Quarantined:
+		case p.Kind == database.CheckIndexMismatch && opts.Reindex:
+			if len(reindex) == 0 || reindex[len(reindex)-1] != p.Object {
+				reindex = append(reindex, p.Object)
+			}
+		default:
+			remaining++
+		}
+	}
+
+	for _, name := range reindex {
+		err = db.Exec("REINDEX " + quoteIdent(name))
+		if err != nil {
+			return 0, err
+		}
+		fmt.Fprintf(w, "index %s rebuilt\n", name)
+	}
+
+	fmt.Fprintf(w, "%d tables, %d indexes, %d sequences, %d rows checked: %d problems found, %d repaired\n",
+		report.Tables, report.Indexes, report.Sequences, report.Rows, len(report.Problems), len(report.Problems)-remaining)
+
+	return remaining, nil
+}
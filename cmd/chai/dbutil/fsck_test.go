@@ -0,0 +1,68 @@
+package dbutil
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFsck(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b');
+	`)
+	require.NoError(t, err)
+
+	fsck := func(opts *FsckOptions) (int, string) {
+		t.Helper()
+
+		var buf bytes.Buffer
+		n, err := Fsck(context.Background(), db, &buf, opts)
+		require.NoError(t, err)
+		return n, buf.String()
+	}
+
+	n, out := fsck(nil)
+	require.Zero(t, n)
+	require.Contains(t, out, "1 indexes")
+	require.Contains(t, out, "0 problems found, 0 repaired\n")
+
+	// remove an index entry and corrupt a row
+	tx, err := db.DB.Begin(true)
+	require.NoError(t, err)
+	tb, err := tx.Catalog.GetTable(tx, "test")
+	require.NoError(t, err)
+	idx, err := tx.Catalog.GetIndex(tx, "test_b_idx")
+	require.NoError(t, err)
+	pk, err := tb.Info.EncodeKey(tree.NewKey(types.NewIntegerValue(1)))
+	require.NoError(t, err)
+	require.NoError(t, idx.Delete([]types.Value{types.NewTextValue("a")}, pk))
+	require.NoError(t, tb.Tree.Put(tree.NewKey(types.NewIntegerValue(3)), []byte{0xff}))
+	require.NoError(t, tx.Commit())
+
+	n, out = fsck(nil)
+	require.Equal(t, 1, n)
+	require.Contains(t, out, "undecodable-row: test: (3): cannot decode column a\n")
+	require.Contains(t, out, "1 problems found, 0 repaired\n")
+
+	n, out = fsck(&FsckOptions{Quarantine: true, Reindex: true})
+	require.Zero(t, n)
+	require.Contains(t, out, "undecodable-row: test: (3): cannot decode column a (quarantined)\n")
+	require.Contains(t, out, `index-mismatch: test_b_idx: missing entry for row (1) with values ("a")`+"\n")
+	require.Contains(t, out, "index test_b_idx rebuilt\n")
+	require.Contains(t, out, "2 problems found, 2 repaired\n")
+
+	n, out = fsck(nil)
+	require.Zero(t, n)
+	require.Contains(t, out, "0 problems found")
+}
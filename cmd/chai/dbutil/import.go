@@ -0,0 +1,546 @@
+package dbutil
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultImportBatchSize is the number of rows inserted per transaction
+// by Import when no batch size is specified.
+const DefaultImportBatchSize = 1000
+
+// Import file formats.
+const (
+	ImportCSV  = "csv"
+	ImportJSON = "json"
+)
+
+// ImportOptions controls how Import reads a file.
+type ImportOptions struct {
+	// Format of the file, ImportCSV or ImportJSON.
+	// JSON files contain either an array of objects or one object per line.
+	// If empty, ImportFile guesses it from the file extension.
+	Format string
+	// If true, the first CSV record is data and the columns are named c1, c2, etc.
+	NoHeader bool
+	// Delimiter of the CSV fields. Defaults to a comma.
+	Delimiter rune
+	// If true, the columns of a created table are all TEXT instead of
+	// being inferred from the first batch of rows.
+	NoInfer bool
+	// Number of rows inserted per transaction. Defaults to DefaultImportBatchSize.
+	BatchSize int
+	// If not empty, CSV fields equal to NullToken are inserted as NULL in any column.
+	// Empty fields are always NULL, except in TEXT columns.
+	NullToken string
+	// Column definitions used to create the table if it doesn't exist,
+	// as in a CREATE TABLE statement, e.g. "id INT PRIMARY KEY, name TEXT".
+	// If empty, the columns are found in the first batch of rows.
+	Schema string
+	// If set, Progress is called after each committed batch
+	// with the total number of rows inserted so far.
+	Progress func(n int)
+}
+
+// ImportFile imports the rows of a CSV or JSON file into a table.
+// See Import.
+func ImportFile(ctx context.Context, db *chai.DB, path, table string, opts ImportOptions) (int, error) {
+	if opts.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv", ".tsv":
+			opts.Format = ImportCSV
+			if opts.Delimiter == 0 && strings.EqualFold(filepath.Ext(path), ".tsv") {
+				opts.Delimiter = '\t'
+			}
+		case ".json", ".ndjson", ".jsonl":
+			opts.Format = ImportJSON
+		default:
+			return 0, errors.Errorf("cannot guess the format of %s, expected csv or json", path)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return Import(ctx, db, f, table, opts)
+}
+
+// Import reads rows from r and inserts them into the table, committing
+// a transaction every opts.BatchSize rows. Only one batch is held in memory
+// at a time, so the size of the input is not limited by the available memory.
+// It returns the number of rows inserted.
+// If the table doesn't exist, it is created with the columns found in the first
+// batch of rows, whose types are inferred from their values unless opts.NoInfer is set.
+// Empty CSV fields are inserted as NULL, except in TEXT columns.
+func Import(ctx context.Context, db *chai.DB, r io.Reader, table string, opts ImportOptions) (int, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultImportBatchSize
+	}
+
+	var rr recordReader
+	switch strings.ToLower(opts.Format) {
+	case ImportCSV:
+		cr := csv.NewReader(r)
+		if opts.Delimiter != 0 {
+			cr.Comma = opts.Delimiter
+		}
+		cr.FieldsPerRecord = -1
+		rr = &csvRecordReader{r: cr, noHeader: opts.NoHeader, null: opts.NullToken}
+	case ImportJSON:
+		rr = newJSONRecordReader(r)
+	default:
+		return 0, errors.Errorf("unsupported format %q, expected csv or json", opts.Format)
+	}
+
+	batch, err := readRecords(rr, opts.BatchSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	done := err != nil
+
+	columnTypes, err := importTable(db, table, rr, batch, &opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for len(batch) > 0 {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		err = insertRecords(db, table, columnTypes, batch)
+		if err != nil {
+			return n, err
+		}
+		n += len(batch)
+		if opts.Progress != nil {
+			opts.Progress(n)
+		}
+
+		if done {
+			break
+		}
+
+		batch, err = readRecords(rr, opts.BatchSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		done = err != nil
+	}
+
+	return n, nil
+}
+
+// importTable returns the types of the columns of the table,
+// creating it from opts.Schema or the records if it doesn't exist.
+func importTable(db *chai.DB, table string, rr recordReader, records []record, opts *ImportOptions) (map[string]types.Type, error) {
+	info, err := db.DB.Catalog().GetTableInfo(table)
+	if err != nil && !errs.IsNotFoundError(err) {
+		return nil, err
+	}
+
+	if info == nil && opts.Schema != "" {
+		err = db.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(table), opts.Schema))
+		if err != nil {
+			return nil, err
+		}
+
+		info, err = db.DB.Catalog().GetTableInfo(table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if info == nil {
+		columns, colTypes := inferColumns(rr, records, !opts.NoInfer)
+		if len(columns) == 0 {
+			return nil, errors.Errorf("cannot create table %s: no columns found", table)
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "CREATE TABLE %s (", quoteIdent(table))
+		for i, c := range columns {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "%s %s", quoteIdent(c), strings.ToUpper(colTypes[i].String()))
+		}
+		sb.WriteString(")")
+
+		err = db.Exec(sb.String())
+		if err != nil {
+			return nil, err
+		}
+
+		info, err = db.DB.Catalog().GetTableInfo(table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	columnTypes := make(map[string]types.Type)
+	for _, cc := range info.ColumnConstraints.Ordered {
+		columnTypes[cc.Column] = cc.Type
+	}
+
+	return columnTypes, nil
+}
+
+// inferColumns returns the columns of the records, in order of appearance,
+// and the narrowest type able to hold all their values.
+// Columns without any non-NULL value or whose types are mixed are TEXT.
+func inferColumns(rr recordReader, records []record, infer bool) ([]string, []types.Type) {
+	var columns []string
+	index := make(map[string]int)
+	add := func(c string) {
+		if _, ok := index[c]; !ok {
+			index[c] = len(columns)
+			columns = append(columns, c)
+		}
+	}
+
+	if h, ok := rr.(*csvRecordReader); ok {
+		for _, c := range h.header {
+			add(c)
+		}
+	}
+	for _, rec := range records {
+		for _, c := range rec.columns {
+			add(c)
+		}
+	}
+
+	colTypes := make([]types.Type, len(columns))
+	for i := range colTypes {
+		colTypes[i] = types.TypeText
+	}
+	if !infer {
+		return columns, colTypes
+	}
+
+	// columns without values yet are NULL
+	for i := range colTypes {
+		colTypes[i] = types.TypeNull
+	}
+
+	for _, rec := range records {
+		for i, c := range rec.columns {
+			t := inferType(rec.values[i])
+			if t == types.TypeNull {
+				continue
+			}
+
+			j := index[c]
+			switch {
+			case colTypes[j] == types.TypeNull, colTypes[j] == t:
+				colTypes[j] = t
+			case colTypes[j] == types.TypeBigint && t == types.TypeDouble,
+				colTypes[j] == types.TypeDouble && t == types.TypeBigint:
+				colTypes[j] = types.TypeDouble
+			default:
+				colTypes[j] = types.TypeText
+			}
+		}
+	}
+
+	for i := range colTypes {
+		if colTypes[i] == types.TypeNull {
+			colTypes[i] = types.TypeText
+		}
+	}
+
+	return columns, colTypes
+}
+
+// inferType returns the type of a record value.
+// CSV fields are parsed to find the type they represent.
+func inferType(v any) types.Type {
+	switch v := v.(type) {
+	case nil:
+		return types.TypeNull
+	case bool:
+		return types.TypeBoolean
+	case int64:
+		return types.TypeBigint
+	case float64:
+		return types.TypeDouble
+	case csvField:
+		s := string(v)
+		if s == "" {
+			return types.TypeNull
+		}
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return types.TypeBigint
+		}
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return types.TypeDouble
+		}
+		if _, err := strconv.ParseBool(s); err == nil && strings.ContainsAny(s[:1], "tTfF") {
+			return types.TypeBoolean
+		}
+	}
+
+	return types.TypeText
+}
+
+// insertRecords inserts the records into the table within a single transaction.
+func insertRecords(db *chai.DB, table string, columnTypes map[string]types.Type, records []record) error {
+	conn, err := db.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// records with the same columns share the same statement
+	stmts := make(map[string]*chai.Statement)
+
+	var sb strings.Builder
+	for _, rec := range records {
+		sb.Reset()
+		for i, c := range rec.columns {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(quoteIdent(c))
+		}
+		columns := sb.String()
+
+		stmt, ok := stmts[columns]
+		if !ok {
+			q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				quoteIdent(table), columns, strings.TrimSuffix(strings.Repeat("?, ", len(rec.columns)), ", "))
+			stmt, err = tx.Prepare(q)
+			if err != nil {
+				return err
+			}
+			stmts[columns] = stmt
+		}
+
+		args := make([]any, len(rec.values))
+		for i, v := range rec.values {
+			if f, ok := v.(csvField); ok {
+				if f == "" && columnTypes[rec.columns[i]] != types.TypeText {
+					continue
+				}
+				v = string(f)
+			}
+			args[i] = v
+		}
+
+		err = stmt.Exec(args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func quoteIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "\\`") + "`"
+}
+
+// A record is a row read from an imported file.
+type record struct {
+	columns []string
+	values  []any
+}
+
+// A recordReader reads the records of an imported file.
+// It returns io.EOF when there are no more records.
+type recordReader interface {
+	Read() (record, error)
+}
+
+// readRecords reads up to n records. It returns io.EOF along with
+// the last records read once the reader is exhausted.
+func readRecords(rr recordReader, n int) ([]record, error) {
+	records := make([]record, 0, n)
+	for len(records) < n {
+		rec, err := rr.Read()
+		if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// csvField is a raw CSV field, whose type is decided by the column it is inserted into.
+type csvField string
+
+type csvRecordReader struct {
+	r        *csv.Reader
+	noHeader bool
+	null     string
+	header   []string
+	line     int
+}
+
+func (c *csvRecordReader) Read() (record, error) {
+	if c.header == nil && !c.noHeader {
+		header, err := c.r.Read()
+		if err != nil {
+			return record{}, err
+		}
+		c.header = header
+	}
+
+	fields, err := c.r.Read()
+	if err != nil {
+		return record{}, err
+	}
+	c.line++
+
+	if c.noHeader {
+		for i := len(c.header); i < len(fields); i++ {
+			c.header = append(c.header, "c"+strconv.Itoa(i+1))
+		}
+	} else if len(fields) > len(c.header) {
+		return record{}, errors.Errorf("record %d has %d fields, expected %d", c.line, len(fields), len(c.header))
+	}
+
+	rec := record{
+		columns: c.header[:len(fields)],
+		values:  make([]any, len(fields)),
+	}
+	for i, f := range fields {
+		if c.null != "" && f == c.null {
+			continue
+		}
+		rec.values[i] = csvField(f)
+	}
+
+	return rec, nil
+}
+
+type jsonRecordReader struct {
+	dec     *json.Decoder
+	started bool
+	array   bool
+}
+
+func newJSONRecordReader(r io.Reader) *jsonRecordReader {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+	return &jsonRecordReader{dec: dec}
+}
+
+func (j *jsonRecordReader) Read() (record, error) {
+	if !j.started {
+		j.started = true
+
+		// the file is either an array of objects or a stream of objects
+		tok, err := j.dec.Token()
+		if err != nil {
+			return record{}, err
+		}
+		switch tok {
+		case json.Delim('['):
+			j.array = true
+		case json.Delim('{'):
+			return j.readObject()
+		default:
+			return record{}, errors.Errorf("expected a JSON object or array, got %v", tok)
+		}
+	}
+
+	if j.array && !j.dec.More() {
+		return record{}, io.EOF
+	}
+
+	tok, err := j.dec.Token()
+	if err != nil {
+		return record{}, err
+	}
+	if tok != json.Delim('{') {
+		return record{}, errors.Errorf("expected a JSON object, got %v", tok)
+	}
+
+	return j.readObject()
+}
+
+// readObject reads the members of an object whose opening brace was consumed,
+// keeping their order.
+func (j *jsonRecordReader) readObject() (record, error) {
+	var rec record
+	for j.dec.More() {
+		tok, err := j.dec.Token()
+		if err != nil {
+			return record{}, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return record{}, errors.Errorf("expected a JSON object key, got %v", tok)
+		}
+
+		var v any
+		err = j.dec.Decode(&v)
+		if err != nil {
+			return record{}, err
+		}
+
+		switch t := v.(type) {
+		case json.Number:
+			if i, err := t.Int64(); err == nil {
+				v = i
+			} else if v, err = t.Float64(); err != nil {
+				return record{}, err
+			}
+		case map[string]any, []any:
+			// nested values are stored as JSON text
+			b, err := json.Marshal(t)
+			if err != nil {
+				return record{}, err
+			}
+			v = string(b)
+		}
+
+		rec.columns = append(rec.columns, key)
+		rec.values = append(rec.values, v)
+	}
+
+	// closing brace
+	_, err := j.dec.Token()
+	return rec, err
+}
+
+// ParseDelimiter returns the rune of a CSV delimiter,
+// which is either a single character or `\t` for tabs.
+func ParseDelimiter(s string) (rune, error) {
+	if s == `\t` || strings.EqualFold(s, "tab") {
+		return '\t', nil
+	}
+
+	r := []rune(s)
+	if len(r) != 1 || !stringutil.NeedsQuotes(s) {
+		return 0, errors.Errorf("invalid delimiter %q, expected a single non-alphanumeric character", s)
+	}
+
+	return r[0], nil
+}
@@ -0,0 +1,173 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		opts   ImportOptions
+		schema string
+		want   string
+	}{
+		{
+			"CSV",
+			"a,b,c,d\n1,1.5,true,foo\n2,,false,\n",
+			ImportOptions{Format: ImportCSV},
+			"CREATE TABLE test (a BIGINT, b DOUBLE, c BOOLEAN, d TEXT);",
+			`{"a":1,"b":1.5,"c":true,"d":"foo"}` + "\n" + `{"a":2,"b":null,"c":false,"d":""}` + "\n",
+		},
+		{
+			"CSV/no header",
+			"1;x\n2;y\n",
+			ImportOptions{Format: ImportCSV, NoHeader: true, Delimiter: ';'},
+			"CREATE TABLE test (c1 BIGINT, c2 TEXT);",
+			`{"c1":1,"c2":"x"}` + "\n" + `{"c1":2,"c2":"y"}` + "\n",
+		},
+		{
+			"CSV/no infer",
+			"a,b\n1,2\n",
+			ImportOptions{Format: ImportCSV, NoInfer: true},
+			"CREATE TABLE test (a TEXT, b TEXT);",
+			`{"a":"1","b":"2"}` + "\n",
+		},
+		{
+			"CSV/mixed types",
+			"a,b\n1,x\n2.5,3\n",
+			ImportOptions{Format: ImportCSV},
+			"CREATE TABLE test (a DOUBLE, b TEXT);",
+			`{"a":1,"b":"x"}` + "\n" + `{"a":2.5,"b":"3"}` + "\n",
+		},
+		{
+			"CSV/null token",
+			"a,b\n1,NA\nNA,x\n",
+			ImportOptions{Format: ImportCSV, NullToken: "NA"},
+			"CREATE TABLE test (a BIGINT, b TEXT);",
+			`{"a":1,"b":null}` + "\n" + `{"a":null,"b":"x"}` + "\n",
+		},
+		{
+			"CSV/schema",
+			"b,a\nx,1\n",
+			ImportOptions{Format: ImportCSV, Schema: "a INT PRIMARY KEY, b TEXT NOT NULL"},
+			"CREATE TABLE test (a INTEGER NOT NULL, b TEXT NOT NULL, CONSTRAINT test_pk PRIMARY KEY (a));",
+			`{"a":1,"b":"x"}` + "\n",
+		},
+		{
+			"JSON/array",
+			`[{"a": 1, "b": "x"}, {"b": "y", "a": 2, "c": {"d": [1]}}]`,
+			ImportOptions{Format: ImportJSON},
+			"CREATE TABLE test (a BIGINT, b TEXT, c TEXT);",
+			`{"a":1,"b":"x","c":null}` + "\n" + `{"a":2,"b":"y","c":"{\"d\":[1]}"}` + "\n",
+		},
+		{
+			"JSON/lines",
+			"{\"a\": 1.5, \"b\": null}\n{\"a\": 2, \"b\": true}\n",
+			ImportOptions{Format: ImportJSON},
+			"CREATE TABLE test (a DOUBLE, b BOOLEAN);",
+			`{"a":1.5,"b":null}` + "\n" + `{"a":2,"b":true}` + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := chai.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			n, err := Import(context.Background(), db, strings.NewReader(test.data), "test", test.opts)
+			require.NoError(t, err)
+			require.Equal(t, strings.Count(test.want, "\n"), n)
+
+			var schema strings.Builder
+			err = DumpSchema(db, &schema, "test")
+			require.NoError(t, err)
+			require.Equal(t, test.schema+"\n", schema.String())
+
+			var got strings.Builder
+			err = ExecSQLWithFormat(context.Background(), db, strings.NewReader("SELECT * FROM test"), &got, FormatJSON)
+			require.NoError(t, err)
+			require.Equal(t, test.want, got.String())
+		})
+	}
+}
+
+func TestImportExistingTable(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test (id INT PRIMARY KEY, name TEXT NOT NULL)")
+	require.NoError(t, err)
+
+	n, err := Import(context.Background(), db, strings.NewReader("id,name\n1,a\n2,b\n3,c\n"), "test", ImportOptions{Format: ImportCSV, BatchSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	// the first batch is committed even if the second one fails
+	n, err = Import(context.Background(), db, strings.NewReader("id,name\n4,d\n5,e\n1,f\n"), "test", ImportOptions{Format: ImportCSV, BatchSize: 2})
+	require.Error(t, err)
+	require.Equal(t, 2, n)
+
+	var count int
+	r, err := db.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	err = r.Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+}
+
+func TestImportProgress(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var data strings.Builder
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&data, "{\"a\": %d}\n", i)
+	}
+
+	var progress []int
+	n, err := Import(context.Background(), db, strings.NewReader(data.String()), "test", ImportOptions{
+		Format:    ImportJSON,
+		BatchSize: 10,
+		Progress:  func(n int) { progress = append(progress, n) },
+	})
+	require.NoError(t, err)
+	require.Equal(t, 25, n)
+	require.Equal(t, []int{10, 20, 25}, progress)
+}
+
+func TestImportFile(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, "data.tsv"), []byte("a\tb\n1\t2\n"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "data.ndjson"), []byte(`{"a": 3, "b": 4}`), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "data.txt"), nil, 0644)
+	require.NoError(t, err)
+
+	n, err := ImportFile(context.Background(), db, filepath.Join(dir, "data.tsv"), "test", ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	n, err = ImportFile(context.Background(), db, filepath.Join(dir, "data.ndjson"), "test", ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, err = ImportFile(context.Background(), db, filepath.Join(dir, "data.txt"), "test", ImportOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,502 @@
+package dbutil
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/plan"
+)
+
+// Lint rules.
+// There is no rule for implicit cross joins: selecting from more than one
+// table is not valid SQL for Chai and is reported as a syntax error.
+const (
+	// The statement cannot be parsed.
+	LintSyntax = "syntax"
+	// The statement cannot be planned, for example because it
+	// references a table or a column that doesn't exist.
+	LintInvalid = "invalid"
+	// The rows of a table are filtered without the help of an index,
+	// by reading the whole table.
+	LintMissingIndex = "missing-index"
+	// A SELECT statement returns all the columns of a table, which
+	// changes its results when columns are added to the table.
+	LintSelectStar = "select-star"
+	// An indexed column is compared after being transformed by
+	// an expression, which prevents the use of the index.
+	LintNonSargable = "non-sargable"
+	// The statement compares values of incompatible types, such as a text
+	// column with an integer, and fails if strict typing is enabled.
+	LintStrictTyping = "strict-typing"
+)
+
+// LintIssue is a problem found in a statement.
+type LintIssue struct {
+	// Line of the statement in the linted text, starting at 1.
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	// Text of the statement.
+	Query string `json:"query"`
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%d: %s: %s", i.Line, i.Rule, i.Message)
+}
+
+// LintOptions configure Lint.
+type LintOptions struct {
+	// If true, the CREATE, ALTER, DROP and REINDEX statements are run on the
+	// database, so that the following statements are checked against the
+	// schema they define. Otherwise they are ignored.
+	ApplySchema bool
+	// Rules not to check.
+	Disabled []string
+}
+
+// Lint reads SQL statements from r and checks the SELECT, INSERT, UPDATE and DELETE
+// statements against the schema of the database, using the plans of the statements
+// and the number of rows of the tables. The statements are not run.
+func Lint(db *chai.DB, r io.Reader, opts *LintOptions) ([]LintIssue, error) {
+	if opts == nil {
+		opts = &LintOptions{}
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	l := linter{
+		db:       db,
+		opts:     opts,
+		src:      string(data),
+		disabled: make(map[string]bool),
+		rows:     make(map[string]int64),
+	}
+	for _, r := range opts.Disabled {
+		l.disabled[r] = true
+	}
+
+	defer func() {
+		if l.strict != nil {
+			_ = l.strict.Close()
+		}
+	}()
+
+	err = l.run()
+	return l.issues, err
+}
+
+type linter struct {
+	db       *chai.DB
+	opts     *LintOptions
+	src      string
+	disabled map[string]bool
+	// number of rows of the tables, by name
+	rows   map[string]int64
+	issues []LintIssue
+	// connection with strict typing enabled
+	strict *chai.Connection
+}
+
+func (l *linter) run() error {
+	p := parser.NewParser(strings.NewReader(l.src))
+
+	for {
+		tok, start, _ := p.ScanIgnoreWhitespace()
+		if tok == scanner.EOF {
+			return nil
+		}
+		if tok == scanner.SEMICOLON {
+			continue
+		}
+		p.Unscan()
+
+		stmt, err := p.ParseStatement()
+		if err == nil {
+			var lit string
+			var end scanner.Pos
+			tok, end, lit = p.ScanIgnoreWhitespace()
+			if tok == scanner.SEMICOLON || tok == scanner.EOF {
+				err = l.lint(stmt, l.text(start, end), start.Line+1)
+				if err != nil {
+					return err
+				}
+				if tok == scanner.EOF {
+					return nil
+				}
+				continue
+			}
+
+			err = &parser.ParseError{Found: scanner.Tokstr(tok, lit), Expected: []string{";"}, Pos: end}
+		}
+
+		l.report(start.Line+1, LintSyntax, err.Error(), "")
+
+		// skip the rest of the statement
+		for tok != scanner.SEMICOLON && tok != scanner.EOF {
+			tok, _, _ = p.ScanIgnoreWhitespace()
+		}
+		if tok == scanner.EOF {
+			return nil
+		}
+	}
+}
+
+// text returns the source text between the two positions.
+func (l *linter) text(start, end scanner.Pos) string {
+	return strings.TrimSpace(l.src[l.offset(start):l.offset(end)])
+}
+
+// offset returns the byte offset of the position in the source.
+// Positions count lines and runes from zero.
+func (l *linter) offset(pos scanner.Pos) int {
+	off := 0
+	for i := 0; i < pos.Line; i++ {
+		n := strings.IndexByte(l.src[off:], '\n')
+		if n < 0 {
+			return len(l.src)
+		}
+		off += n + 1
+	}
+
+	for i := 0; i < pos.Char && off < len(l.src); i++ {
+		_, size := utf8.DecodeRuneInString(l.src[off:])
+		off += size
+	}
+
+	return off
+}
+
+func (l *linter) report(line int, rule, msg, query string) {
+	if l.disabled[rule] {
+		return
+	}
+
+	l.issues = append(l.issues, LintIssue{
+		Line:    line,
+		Rule:    rule,
+		Message: msg,
+		Query:   query,
+	})
+}
+
+func (l *linter) lint(stmt statement.Statement, q string, line int) error {
+	switch stmt.(type) {
+	case *statement.SelectStmt, *statement.InsertStmt, *statement.UpdateStmt, *statement.DeleteStmt:
+	case *statement.CreateTableStmt, *statement.CreateIndexStmt, *statement.CreateSequenceStmt, *statement.CreateSchemaStmt,
+		*statement.AlterTableRenameStmt, *statement.AlterTableAddColumnStmt, *statement.AlterTableSetRetentionStmt, *statement.AlterSequenceStmt,
+		*statement.DropTableStmt, *statement.DropIndexStmt, *statement.DropSequenceStmt, *statement.DropSchemaStmt,
+		*statement.ReIndexStmt:
+		if l.opts.ApplySchema {
+			err := l.db.Exec(q)
+			if err != nil {
+				l.report(line, LintInvalid, err.Error(), q)
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	n, err := l.db.Plan(q)
+	if err != nil {
+		l.report(line, LintInvalid, err.Error(), q)
+		return nil
+	}
+
+	if s, ok := stmt.(*statement.SelectStmt); ok {
+		l.lintSelectStar(s, q, line)
+	}
+
+	err = l.lintStrictTyping(q, line)
+	if err != nil {
+		return err
+	}
+
+	err = l.lintMissingIndex(n, q, line)
+	if err != nil {
+		return err
+	}
+
+	return l.lintNonSargable(stmt, q, line)
+}
+
+func (l *linter) lintSelectStar(s *statement.SelectStmt, q string, line int) {
+	for _, core := range s.CompoundSelect {
+		for _, e := range core.ProjectionExprs {
+			if _, ok := e.(expr.Wildcard); ok {
+				l.report(line, LintSelectStar, "SELECT * returns all the columns of table "+core.TableName+", list the columns instead", q)
+				return
+			}
+		}
+	}
+}
+
+// lintStrictTyping reports the statements that could be planned
+// but fail once strict typing is enabled.
+func (l *linter) lintStrictTyping(q string, line int) error {
+	if l.disabled[LintStrictTyping] {
+		return nil
+	}
+
+	if l.strict == nil {
+		conn, err := l.db.Connect()
+		if err != nil {
+			return err
+		}
+		l.strict = conn
+
+		err = conn.Exec("SET strict_typing = on")
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := l.strict.Plan(q)
+	if err != nil {
+		l.report(line, LintStrictTyping, err.Error(), q)
+	}
+
+	return nil
+}
+
+// lintMissingIndex reports the filters run on the rows of full table scans.
+func (l *linter) lintMissingIndex(n *plan.Node, q string, line int) error {
+	var scans []*plan.Node
+	filters := make(map[*plan.Node][]string)
+
+	n.Walk(func(n *plan.Node) bool {
+		if n.Type != plan.RowsFilter {
+			return true
+		}
+
+		scan := n
+		for scan.Input != nil {
+			scan = scan.Input
+		}
+		if scan.Type != plan.TableScan || len(scan.Ranges) > 0 {
+			return true
+		}
+
+		if _, ok := filters[scan]; !ok {
+			scans = append(scans, scan)
+		}
+		filters[scan] = append(filters[scan], n.Exprs...)
+		return true
+	})
+
+	for _, scan := range scans {
+		rows, err := l.tableRows(scan.Table)
+		if err != nil {
+			return err
+		}
+
+		msg := fmt.Sprintf("no index can be used to evaluate %s, all the rows of table %s are read", strings.Join(filters[scan], " AND "), scan.Table)
+		if rows > 0 {
+			msg += fmt.Sprintf(" (%d rows)", rows)
+		}
+		l.report(line, LintMissingIndex, msg, q)
+	}
+
+	return nil
+}
+
+// tableRows returns the number of rows of the table.
+func (l *linter) tableRows(table string) (int64, error) {
+	if n, ok := l.rows[table]; ok {
+		return n, nil
+	}
+
+	stats, err := l.db.TableStorageStats(table)
+	if err != nil {
+		return 0, err
+	}
+
+	l.rows[table] = stats.Rows
+	return stats.Rows, nil
+}
+
+// lintNonSargable reports the comparisons that could use an index
+// if the indexed column was not part of an expression.
+func (l *linter) lintNonSargable(stmt statement.Statement, q string, line int) error {
+	type where struct {
+		table string
+		e     expr.Expr
+	}
+
+	var wheres []where
+	switch t := stmt.(type) {
+	case *statement.SelectStmt:
+		for _, core := range t.CompoundSelect {
+			wheres = append(wheres, where{core.TableName, core.WhereExpr})
+		}
+	case *statement.UpdateStmt:
+		wheres = append(wheres, where{t.TableName, t.WhereExpr})
+	case *statement.DeleteStmt:
+		wheres = append(wheres, where{t.TableName, t.WhereExpr})
+	}
+
+	for _, w := range wheres {
+		if w.e == nil || w.table == "" {
+			continue
+		}
+
+		indexed, err := l.indexedColumns(w.table)
+		if err != nil {
+			return err
+		}
+
+		expr.Walk(w.e, func(e expr.Expr) bool {
+			for _, operand := range nonSargableOperands(e) {
+				walkColumns(operand, func(c *expr.Column) bool {
+					if indexed[c.Name] == "" {
+						return true
+					}
+
+					l.report(line, LintNonSargable, fmt.Sprintf("%s cannot use %s because column %s is part of the expression %s", e, indexed[c.Name], c.Name, operand), q)
+					return false
+				})
+			}
+
+			return true
+		})
+	}
+
+	return nil
+}
+
+// nonSargableOperands returns the operands of a comparison that are neither
+// a column nor an expression without columns, if it is compared to an
+// expression without columns.
+func nonSargableOperands(e expr.Expr) []expr.Expr {
+	op, ok := e.(expr.Operator)
+	if !ok {
+		return nil
+	}
+
+	var tested, others []expr.Expr
+	switch t := op.(type) {
+	case *expr.BetweenOperator:
+		tested = []expr.Expr{t.X}
+		others = []expr.Expr{t.LeftHand(), t.RightHand()}
+	case *expr.InOperator:
+		tested = []expr.Expr{t.LeftHand()}
+		others = []expr.Expr{t.RightHand()}
+	default:
+		switch op.Token() {
+		case scanner.EQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+		default:
+			return nil
+		}
+
+		lh, rh := op.LeftHand(), op.RightHand()
+		switch {
+		case !hasColumn(lh):
+			tested, others = []expr.Expr{rh}, []expr.Expr{lh}
+		case !hasColumn(rh):
+			tested, others = []expr.Expr{lh}, []expr.Expr{rh}
+		default:
+			return nil
+		}
+	}
+
+	for _, o := range others {
+		if hasColumn(o) {
+			return nil
+		}
+	}
+
+	var l []expr.Expr
+	for _, t := range tested {
+		for {
+			p, ok := t.(expr.Parentheses)
+			if !ok {
+				break
+			}
+			t = p.E
+		}
+
+		if _, ok := t.(*expr.Column); !ok && hasColumn(t) {
+			l = append(l, t)
+		}
+	}
+
+	return l
+}
+
+func hasColumn(e expr.Expr) bool {
+	return !walkColumns(e, func(*expr.Column) bool {
+		return false
+	})
+}
+
+// walkColumns calls fn for each column of the expression, including
+// those in parentheses and casts, until fn returns false.
+func walkColumns(e expr.Expr, fn func(*expr.Column) bool) bool {
+	ok := true
+	expr.Walk(e, func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case *expr.Column:
+			ok = fn(t)
+		case expr.Parentheses:
+			ok = walkColumns(t.E, fn)
+		case *expr.Cast:
+			ok = walkColumns(t.Expr, fn)
+		case *expr.BetweenOperator:
+			ok = walkColumns(t.X, fn)
+		}
+
+		return ok
+	})
+
+	return ok
+}
+
+// indexedColumns returns the columns that can be read from an index,
+// associated with a description of that index.
+// Only the first column of an index can be used to look up rows.
+func (l *linter) indexedColumns(table string) (map[string]string, error) {
+	tx, err := l.db.DB.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	table, err = tx.Catalog.ResolveName(tx, database.RelationTableType, table)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := tx.Catalog.GetTableInfo(table)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string)
+	for _, name := range tx.Catalog.ListIndexes(table) {
+		idx, err := tx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := m[idx.Columns[0]]; !ok {
+			m[idx.Columns[0]] = "index " + name
+		}
+	}
+
+	if info.PrimaryKey != nil {
+		m[info.PrimaryKey.Columns[0]] = "the primary key of table " + table
+	}
+
+	return m, nil
+}
@@ -0,0 +1,75 @@
+package dbutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	issues, err := Lint(db, strings.NewReader(`
+		CREATE TABLE users(id INT PRIMARY KEY, email TEXT, age INT, name TEXT);
+		CREATE INDEX users_email_idx ON users(email);
+
+		SELECT id FROM users WHERE email = 'a@b.c';
+		SELECT * FROM users WHERE id = 1;
+		SELECT id FROM users
+		WHERE name = 'foo' AND age > 10;
+		DELETE FROM users WHERE lower(email) = 'a@b.c';
+		UPDATE users SET age = 1 WHERE (id + 1) IN (2, 3);
+		SELECT id FROM users WHERE id = age;
+		SELECT id FROM users WHERE name = 10;
+		SELECT id FROM users, others;
+		SELECT id FROM unknown;
+		INSERT INTO users (id, email) VALUES (1, 'a@b.c');
+	`), &LintOptions{ApplySchema: true})
+	require.NoError(t, err)
+
+	type issue struct {
+		Line int
+		Rule string
+	}
+	var got []issue
+	for _, i := range issues {
+		got = append(got, issue{i.Line, i.Rule})
+	}
+	require.Equal(t, []issue{
+		{6, LintSelectStar},
+		{7, LintMissingIndex},
+		{9, LintMissingIndex},
+		{9, LintNonSargable},
+		{10, LintMissingIndex},
+		{10, LintNonSargable},
+		{11, LintMissingIndex},
+		{12, LintStrictTyping},
+		{12, LintMissingIndex},
+		{13, LintSyntax},
+		{14, LintInvalid},
+	}, got)
+
+	require.Equal(t, "SELECT id FROM users\n\t\tWHERE name = 'foo' AND age > 10", issues[1].Query)
+	require.Equal(t, `no index can be used to evaluate name = "foo" AND age > 10, all the rows of table users are read`, issues[1].Message)
+	require.Equal(t, `LOWER(email) = "a@b.c" cannot use index users_email_idx because column email is part of the expression LOWER(email)`, issues[3].Message)
+	require.Equal(t, "strict typing: cannot compare name of type text with 10 of type integer", issues[7].Message)
+
+	t.Run("existing database", func(t *testing.T) {
+		err := db.Exec("INSERT INTO users (id, email, age) VALUES (1, 'a', 10), (2, 'b', 20)")
+		require.NoError(t, err)
+
+		issues, err := Lint(db, strings.NewReader(`
+			DROP TABLE users;
+			SELECT * FROM users WHERE age > 10;
+		`), &LintOptions{Disabled: []string{LintSelectStar}})
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		require.Equal(t, 3, issues[0].Line)
+		require.Equal(t, LintMissingIndex, issues[0].Rule)
+		require.True(t, strings.HasSuffix(issues[0].Message, "(2 rows)"))
+	})
+}
@@ -0,0 +1,61 @@
+package dbutil
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/chaisql/chai"
+)
+
+// RetentionReport describes the rows of a table purged,
+// or that would be purged, by its retention policy.
+type RetentionReport struct {
+	Table string `json:"table"`
+	// Rows whose retention column is before the cutoff are purged.
+	Cutoff time.Time `json:"cutoff"`
+	Rows   int       `json:"rows"`
+}
+
+// ApplyRetention applies the retention policies of all tables.
+// If dryRun is true, it only reports how many rows would be purged.
+func ApplyRetention(db *chai.DB, dryRun bool) ([]RetentionReport, error) {
+	reports, err := db.DB.ApplyRetentionPolicies(dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	l := make([]RetentionReport, 0, len(reports))
+	for _, r := range reports {
+		l = append(l, RetentionReport{
+			Table:  r.Table,
+			Cutoff: r.Cutoff,
+			Rows:   r.Rows,
+		})
+	}
+
+	return l, nil
+}
+
+// DumpRetention applies the retention policies of all tables
+// and writes the report of each table to w, as JSON.
+// If dryRun is true, no row is purged.
+func DumpRetention(db *chai.DB, w io.Writer, dryRun bool) error {
+	reports, err := ApplyRetention(db, dryRun)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	for _, r := range reports {
+		err = enc.Encode(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
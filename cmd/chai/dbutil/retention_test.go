@@ -0,0 +1,40 @@
+package dbutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetention(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{TTLInterval: -1})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE logs (id INTEGER PRIMARY KEY, ts TIMESTAMP);
+		CREATE TABLE other (id INTEGER PRIMARY KEY);
+		INSERT INTO logs (id, ts) VALUES (1, '2000-01-01T00:00:00Z'), (2, NOW());
+		ALTER TABLE logs SET RETENTION (older_than = '1 day', column = ts);
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = DumpRetention(db, &buf, true)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `"table": "logs"`)
+	require.Contains(t, buf.String(), `"rows": 1`)
+	require.NotContains(t, buf.String(), `"table": "other"`)
+
+	reports, err := ApplyRetention(db, false)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, 1, reports[0].Rows)
+
+	reports, err = ApplyRetention(db, true)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Zero(t, reports[0].Rows)
+}
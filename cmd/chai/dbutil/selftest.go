@@ -0,0 +1,177 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/chaisql/chai"
+	"github.com/cockroachdb/errors"
+)
+
+// selfTestRows is the number of rows inserted by the self-test.
+const selfTestRows = 1000
+
+// SelfTest exercises the database engine on an in-memory database
+// and on an on-disk database created in a temporary directory.
+// It writes the result of each step to w and returns an error
+// as soon as a step fails.
+func SelfTest(ctx context.Context, w io.Writer) error {
+	db, err := chai.Open(":memory:")
+	if err != nil {
+		return err
+	}
+	err = selfTestQueries(db.WithContext(ctx))
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "in-memory database")
+	}
+	fmt.Fprintln(w, "in-memory database: ok")
+
+	dir, err := os.MkdirTemp("", "chai-self-test")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	err = selfTestOnDisk(ctx, filepath.Join(dir, "db"))
+	if err != nil {
+		return errors.Wrap(err, "on-disk database")
+	}
+	fmt.Fprintln(w, "on-disk database: ok")
+
+	return nil
+}
+
+// selfTestOnDisk runs the self-test queries on a database stored at path,
+// then reopens it to ensure the data was persisted.
+func selfTestOnDisk(ctx context.Context, path string) error {
+	db, err := chai.Open(path)
+	if err != nil {
+		return err
+	}
+	err = selfTestQueries(db.WithContext(ctx))
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	db, err = chai.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "reopen")
+	}
+	defer db.Close()
+
+	return expectCount(db.WithContext(ctx), "SELECT COUNT(*) FROM test", selfTestRows-selfTestRows/10)
+}
+
+func selfTestQueries(db *chai.DB) error {
+	err := db.Exec(`
+		CREATE TABLE test(id INTEGER PRIMARY KEY, name TEXT NOT NULL, score DOUBLE, created_at TIMESTAMP);
+		CREATE INDEX test_name_idx ON test(name);
+		CREATE UNIQUE INDEX test_score_idx ON test(score);
+	`)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = conn.Update(func(tx *chai.Tx) error {
+		for i := 0; i < selfTestRows; i++ {
+			err := tx.Exec("INSERT INTO test (id, name, score, created_at) VALUES (?, ?, ?, NOW())", i, fmt.Sprintf("name-%d", i%100), float64(i)/2)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	checks := []struct {
+		query string
+		want  int
+	}{
+		{"SELECT COUNT(*) FROM test", selfTestRows},
+		{"SELECT COUNT(*) FROM test WHERE name = 'name-42'", selfTestRows / 100},
+		{"SELECT COUNT(*) FROM test WHERE score >= 100 AND score < 200", 200},
+		{"SELECT COUNT(*) FROM test WHERE id > 990", 9},
+		{"SELECT COUNT(*) FROM test WHERE name LIKE 'NAME-9%'", selfTestRows / 100 * 11},
+	}
+	for _, c := range checks {
+		if err := expectCount(db, c.query, c.want); err != nil {
+			return err
+		}
+	}
+
+	// unique constraint violations must be reported
+	err = db.Exec("INSERT INTO test (id, name, score) VALUES (?, 'dup', 0)", selfTestRows)
+	if err == nil {
+		return errors.New("expected unique constraint violation")
+	}
+
+	// rolled back changes must not be visible
+	tx, err := conn.Begin(true)
+	if err != nil {
+		return err
+	}
+	err = tx.Exec("DELETE FROM test")
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Rollback()
+	if err != nil {
+		return err
+	}
+	if err := expectCount(db, "SELECT COUNT(*) FROM test", selfTestRows); err != nil {
+		return err
+	}
+
+	err = db.Exec("UPDATE test SET name = 'updated' WHERE id % 2 = 0")
+	if err != nil {
+		return err
+	}
+	if err := expectCount(db, "SELECT COUNT(*) FROM test WHERE name = 'updated'", selfTestRows/2); err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM test WHERE id % 10 = 0")
+	if err != nil {
+		return err
+	}
+
+	return expectCount(db, "SELECT COUNT(*) FROM test", selfTestRows-selfTestRows/10)
+}
+
+func expectCount(db *chai.DB, query string, want int) error {
+	r, err := db.QueryRow(query)
+	if err != nil {
+		return errors.Wrap(err, query)
+	}
+
+	var n int
+	err = r.Scan(&n)
+	if err != nil {
+		return errors.Wrap(err, query)
+	}
+
+	if n != want {
+		return errors.Errorf("%s: expected %d, got %d", query, want, n)
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package dbutil
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest(t *testing.T) {
+	var buf bytes.Buffer
+	err := SelfTest(context.Background(), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "in-memory database: ok\non-disk database: ok\n", buf.String())
+}
@@ -0,0 +1,211 @@
+package dbutil
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// WriteCost describes how much data is written to the database
+// when inserting a row into a table, given its current indexes.
+// Sizes are averages measured on the rows already stored in the table,
+// they are zero if the table is empty.
+type WriteCost struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+	// Average size in bytes of a row, key included.
+	RowBytes float64 `json:"row_bytes"`
+	// Cost of each index of the table.
+	Indexes []IndexWriteCost `json:"indexes"`
+	// Number of entries written per row, the row included.
+	EntriesPerRow int `json:"entries_per_row"`
+	// Number of bytes written per row, the row and its index entries included.
+	BytesPerRow float64 `json:"bytes_per_row"`
+	// Number of keys looked up per row to enforce the primary key
+	// and unique indexes.
+	LookupsPerRow int `json:"lookups_per_row"`
+	// Ratio of the bytes written per row to the size of the row.
+	WriteAmplification float64 `json:"write_amplification"`
+}
+
+// IndexWriteCost describes the cost of maintaining an index.
+type IndexWriteCost struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	// Average size in bytes of the entry written per row, key included.
+	BytesPerRow float64 `json:"bytes_per_row"`
+}
+
+// WriteCosts measures the write costs of the given tables.
+// If no table is provided, it measures the write costs of all tables.
+func WriteCosts(db *chai.DB, tables ...string) ([]WriteCost, error) {
+	tx, err := db.DB.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if len(tables) == 0 {
+		for _, name := range tx.Catalog.Cache.ListObjects(database.RelationTableType) {
+			if !database.IsSystemTable(name) {
+				tables = append(tables, name)
+			}
+		}
+		sort.Strings(tables)
+	}
+
+	costs := make([]WriteCost, 0, len(tables))
+	for _, name := range tables {
+		wc, err := tableWriteCost(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		costs = append(costs, *wc)
+	}
+
+	return costs, nil
+}
+
+// DumpWriteCosts writes the write costs of the given tables to w, as JSON.
+// If no table is provided, it writes the write costs of all tables.
+func DumpWriteCosts(db *chai.DB, w io.Writer, tables ...string) error {
+	costs, err := WriteCosts(db, tables...)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	for _, wc := range costs {
+		err = enc.Encode(wc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func tableWriteCost(tx *database.Transaction, tableName string) (*WriteCost, error) {
+	t, err := tx.Catalog.GetTable(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, rowBytes, err := measureTree(t.Tree)
+	if err != nil {
+		return nil, err
+	}
+
+	wc := WriteCost{
+		Table:         tableName,
+		Rows:          rows,
+		RowBytes:      average(rowBytes, rows),
+		Indexes:       []IndexWriteCost{},
+		EntriesPerRow: 1,
+		// inserting a row checks that its key doesn't exist
+		LookupsPerRow: 1,
+	}
+
+	totalBytes := rowBytes
+	for _, name := range tx.Catalog.ListIndexes(tableName) {
+		idx, err := tx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return nil, err
+		}
+
+		index, err := tx.Catalog.GetIndex(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		_, n, err := measureTree(index.Tree)
+		if err != nil {
+			return nil, err
+		}
+
+		wc.Indexes = append(wc.Indexes, IndexWriteCost{
+			Name:        name,
+			Columns:     idx.Columns,
+			Unique:      idx.Unique,
+			BytesPerRow: average(n, rows),
+		})
+		wc.EntriesPerRow++
+		if idx.Unique {
+			wc.LookupsPerRow++
+		}
+		totalBytes += n
+	}
+
+	wc.BytesPerRow = average(totalBytes, rows)
+	if rowBytes > 0 {
+		wc.WriteAmplification = round(float64(totalBytes) / float64(rowBytes))
+	}
+
+	return &wc, nil
+}
+
+// measureTree returns the number of entries stored in the tree,
+// or in its partitions, and their total size in bytes.
+func measureTree(tr *tree.Tree) (count int64, size int64, err error) {
+	for _, sub := range tr.Subtrees() {
+		n, sz, err := measureNamespace(sub.Session, sub.Namespace)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		count += n
+		size += sz
+	}
+
+	return count, size, nil
+}
+
+// measureNamespace returns the number of entries stored in the namespace
+// and their total size in bytes.
+func measureNamespace(session engine.Session, ns tree.Namespace) (count int64, size int64, err error) {
+	it, err := session.Iterator(&engine.IterOptions{
+		LowerBound: encoding.EncodeInt(nil, int64(ns)),
+		UpperBound: encoding.EncodeInt(nil, int64(ns)+1),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer it.Close()
+
+	for it.First(); it.Valid(); it.Next() {
+		v, err := it.Value()
+		if err != nil {
+			return 0, 0, err
+		}
+
+		count++
+		size += int64(len(it.Key()) + len(v))
+	}
+
+	return count, size, errors.WithStack(it.Error())
+}
+
+func average(total, n int64) float64 {
+	if n == 0 {
+		return 0
+	}
+
+	return round(float64(total) / float64(n))
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
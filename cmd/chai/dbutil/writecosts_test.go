@@ -0,0 +1,63 @@
+package dbutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCosts(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT UNIQUE, c INTEGER);
+		CREATE INDEX foo_c_idx ON foo (c);
+		CREATE TABLE bar (a INTEGER);
+		INSERT INTO foo (a, b, c) VALUES (1, 'a', 10), (2, 'b', 20), (3, 'c', 30);
+	`)
+	require.NoError(t, err)
+
+	costs, err := WriteCosts(db)
+	require.NoError(t, err)
+	require.Len(t, costs, 2)
+
+	bar := costs[0]
+	require.Equal(t, "bar", bar.Table)
+	require.EqualValues(t, 0, bar.Rows)
+	require.Equal(t, 1, bar.EntriesPerRow)
+	require.Empty(t, bar.Indexes)
+	require.Zero(t, bar.WriteAmplification)
+
+	foo := costs[1]
+	require.Equal(t, "foo", foo.Table)
+	require.EqualValues(t, 3, foo.Rows)
+	require.Equal(t, 3, foo.EntriesPerRow)
+	require.Equal(t, 2, foo.LookupsPerRow)
+	require.Len(t, foo.Indexes, 2)
+	require.Equal(t, "foo_b_idx", foo.Indexes[0].Name)
+	require.True(t, foo.Indexes[0].Unique)
+	require.Equal(t, "foo_c_idx", foo.Indexes[1].Name)
+	require.Equal(t, []string{"c"}, foo.Indexes[1].Columns)
+
+	require.Greater(t, foo.RowBytes, 0.0)
+	for _, idx := range foo.Indexes {
+		require.Greater(t, idx.BytesPerRow, 0.0)
+	}
+	require.InDelta(t, foo.RowBytes+foo.Indexes[0].BytesPerRow+foo.Indexes[1].BytesPerRow, foo.BytesPerRow, 0.02)
+	require.Greater(t, foo.WriteAmplification, 1.0)
+
+	t.Run("selected tables", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := DumpWriteCosts(db, &buf, "foo")
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), `"table": "foo"`)
+		require.NotContains(t, buf.String(), `"table": "bar"`)
+
+		err = DumpWriteCosts(db, &buf, "unknown")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,279 @@
+// Package release builds the binaries of the Chai CLI distributed to users.
+//
+// Binaries are built without cgo, with paths, build ids and VCS stamping
+// removed, so that building the same source with the same Go toolchain
+// always produces the same files, whatever the host platform.
+package release
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Package receiving the version and commit of the build.
+const versionPackage = "github.com/chaisql/chai/cmd/chai/commands"
+
+// Target is a platform the CLI is built for.
+type Target struct {
+	OS   string
+	Arch string
+}
+
+// DefaultTargets are the platforms for which binaries are distributed.
+var DefaultTargets = []Target{
+	{"darwin", "amd64"},
+	{"darwin", "arm64"},
+	{"linux", "amd64"},
+	{"linux", "arm64"},
+	{"windows", "amd64"},
+	{"windows", "arm64"},
+}
+
+// ParseTarget parses a target of the form os/arch.
+func ParseTarget(s string) (Target, error) {
+	goos, goarch, ok := strings.Cut(s, "/")
+	if !ok || goos == "" || goarch == "" {
+		return Target{}, errors.Errorf("invalid target %q, expected os/arch", s)
+	}
+
+	return Target{OS: goos, Arch: goarch}, nil
+}
+
+func (t Target) String() string {
+	return t.OS + "/" + t.Arch
+}
+
+// BinaryName returns the name of the binary built for the target.
+func (t Target) BinaryName(version string) string {
+	name := fmt.Sprintf("chai-%s-%s-%s", version, t.OS, t.Arch)
+	if t.OS == "windows" {
+		name += ".exe"
+	}
+
+	return name
+}
+
+// Options of a release build.
+type Options struct {
+	// Directory of the CLI module. Defaults to the current directory.
+	Dir string
+	// Directory where binaries are written.
+	Output string
+	// Version and commit embedded in the binaries.
+	Version string
+	Commit  string
+	// Targets to build. Defaults to DefaultTargets.
+	Targets []Target
+	// Run the engine self-test with each binary, using an emulator
+	// if the target is not the host platform.
+	SelfTest bool
+	// Progress is written to Log, if not nil.
+	Log io.Writer
+}
+
+// Artifact is a binary built for a target.
+type Artifact struct {
+	Target Target
+	Path   string
+	SHA256 string
+	// Result of the self-test: "passed", or the reason why it was skipped.
+	// Empty if the self-test was not requested.
+	SelfTest string
+}
+
+// Build builds the CLI for every target and writes a SHA256SUMS file
+// listing the checksums of the binaries in the output directory.
+// It stops at the first build or self-test failure.
+func Build(ctx context.Context, opts *Options) ([]Artifact, error) {
+	if opts.Version == "" || opts.Commit == "" {
+		return nil, errors.New("version and commit are required")
+	}
+
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = DefaultTargets
+	}
+
+	out, err := filepath.Abs(opts.Output)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(out, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]Artifact, 0, len(targets))
+	for _, t := range targets {
+		a := Artifact{
+			Target: t,
+			Path:   filepath.Join(out, t.BinaryName(opts.Version)),
+		}
+
+		logf(opts.Log, "building %s\n", t)
+		cmd := exec.CommandContext(ctx, "go", BuildArgs(a.Path, opts.Version, opts.Commit)...)
+		cmd.Dir = opts.Dir
+		cmd.Env = append(os.Environ(), BuildEnv(t)...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return nil, errors.Wrapf(err, "build %s: %s", t, output)
+		}
+
+		a.SHA256, err = checksum(a.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.SelfTest {
+			a.SelfTest, err = selfTest(ctx, a)
+			if err != nil {
+				return nil, err
+			}
+			logf(opts.Log, "self-test %s: %s\n", t, a.SelfTest)
+		}
+
+		artifacts = append(artifacts, a)
+	}
+
+	err = writeChecksums(filepath.Join(out, "SHA256SUMS"), artifacts)
+	if err != nil {
+		return nil, err
+	}
+
+	return artifacts, nil
+}
+
+// BuildArgs returns the arguments passed to the go command
+// to build a reproducible binary at path.
+func BuildArgs(path, version, commit string) []string {
+	ldflags := fmt.Sprintf("-s -w -buildid= -X %s.version=%s -X %s.commit=%s", versionPackage, version, versionPackage, commit)
+
+	return []string{"build", "-trimpath", "-buildvcs=false", "-ldflags", ldflags, "-o", path, "."}
+}
+
+// BuildEnv returns the environment variables used to build the target.
+// Cgo is disabled and the instruction set levels are fixed so that the
+// result doesn't depend on the host platform or the user configuration.
+func BuildEnv(t Target) []string {
+	return []string{
+		"CGO_ENABLED=0",
+		"GOOS=" + t.OS,
+		"GOARCH=" + t.Arch,
+		"GOAMD64=v1",
+		"GOARM64=v8.0",
+	}
+}
+
+// Emulator returns the command used to run binaries of the target
+// on the host platform. It returns nil if the target is the host platform,
+// and an error if no emulator is available.
+func Emulator(t Target) ([]string, error) {
+	if t.OS == runtime.GOOS && t.Arch == runtime.GOARCH {
+		return nil, nil
+	}
+
+	var candidates [][]string
+	switch {
+	case t.OS == "linux" && runtime.GOOS == "linux":
+		candidates = [][]string{{"qemu-" + qemuArch(t.Arch)}, {"qemu-" + qemuArch(t.Arch) + "-static"}}
+	case t.OS == "windows" && t.Arch == "amd64" && runtime.GOOS != "windows":
+		candidates = [][]string{{"wine64"}, {"wine"}}
+	case t.OS == "darwin" && runtime.GOOS == "darwin" && t.Arch == "amd64":
+		// Rosetta
+		candidates = [][]string{{"arch", "-x86_64"}}
+	case t.OS == "windows" && runtime.GOOS == "windows" && t.Arch == "amd64":
+		// windows on arm64 emulates amd64 binaries natively
+		return nil, nil
+	}
+
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c, nil
+		}
+	}
+
+	return nil, errors.Errorf("no emulator available for %s on %s/%s", t, runtime.GOOS, runtime.GOARCH)
+}
+
+func qemuArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "i386"
+	}
+
+	return arch
+}
+
+// selfTest runs the self-test command of the binary.
+func selfTest(ctx context.Context, a Artifact) (string, error) {
+	emulator, err := Emulator(a.Target)
+	if err != nil {
+		return "skipped: " + err.Error(), nil
+	}
+
+	args := append(emulator, a.Path, "self-test")
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if err != nil {
+		return "", errors.Wrapf(err, "self-test %s: %s", a.Target, output.Bytes())
+	}
+
+	return "passed", nil
+}
+
+func checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeChecksums writes the checksums of the artifacts
+// in the format of the sha256sum command.
+func writeChecksums(path string, artifacts []Artifact) error {
+	sorted := append([]Artifact(nil), artifacts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return filepath.Base(sorted[i].Path) < filepath.Base(sorted[j].Path)
+	})
+
+	var buf bytes.Buffer
+	for _, a := range sorted {
+		fmt.Fprintf(&buf, "%s  %s\n", a.SHA256, filepath.Base(a.Path))
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func logf(w io.Writer, format string, args ...any) {
+	if w != nil {
+		fmt.Fprintf(w, format, args...)
+	}
+}
@@ -0,0 +1,72 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Target
+		fail bool
+	}{
+		{"linux/amd64", Target{"linux", "amd64"}, false},
+		{"windows/arm64", Target{"windows", "arm64"}, false},
+		{"linux", Target{}, true},
+		{"/amd64", Target{}, true},
+		{"linux/", Target{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			got, err := ParseTarget(test.s)
+			if test.fail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+			require.Equal(t, test.s, got.String())
+		})
+	}
+}
+
+func TestBinaryName(t *testing.T) {
+	require.Equal(t, "chai-v1.0.0-linux-arm64", Target{"linux", "arm64"}.BinaryName("v1.0.0"))
+	require.Equal(t, "chai-v1.0.0-windows-amd64.exe", Target{"windows", "amd64"}.BinaryName("v1.0.0"))
+}
+
+func TestBuildArgs(t *testing.T) {
+	args := BuildArgs("out/chai", "v1.0.0", "abc")
+	require.Equal(t, []string{
+		"build", "-trimpath", "-buildvcs=false",
+		"-ldflags", "-s -w -buildid= -X github.com/chaisql/chai/cmd/chai/commands.version=v1.0.0 -X github.com/chaisql/chai/cmd/chai/commands.commit=abc",
+		"-o", "out/chai", ".",
+	}, args)
+
+	require.Contains(t, BuildEnv(Target{"darwin", "arm64"}), "CGO_ENABLED=0")
+}
+
+func TestEmulator(t *testing.T) {
+	emulator, err := Emulator(Target{runtime.GOOS, runtime.GOARCH})
+	require.NoError(t, err)
+	require.Nil(t, emulator)
+}
+
+func TestWriteChecksums(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+	err := writeChecksums(path, []Artifact{
+		{Path: "/dist/chai-v1-linux-arm64", SHA256: "bb"},
+		{Path: "/dist/chai-v1-darwin-amd64", SHA256: "aa"},
+	})
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "aa  chai-v1-darwin-amd64\nbb  chai-v1-linux-arm64\n", string(b))
+}
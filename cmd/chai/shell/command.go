@@ -3,10 +3,9 @@ package shell
 import (
 	"bytes"
 	"context"
-	"encoding/csv"
+	"flag"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"github.com/cockroachdb/errors"
@@ -14,7 +13,6 @@ import (
 	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/cmd/chai/dbutil"
 	errs "github.com/chaisql/chai/internal/errors"
-	"github.com/chaisql/chai/internal/row"
 )
 
 type command struct {
@@ -71,11 +69,23 @@ var commands = []command{
 		DisplayName: ".schema",
 		Description: "Show the CREATE statements of all tables or of the selected ones.",
 	},
+	{
+		Name:        ".write_costs",
+		Options:     "[table_name]",
+		DisplayName: ".write_costs",
+		Description: "Show the number of index entries and bytes written per inserted row, for all tables or the selected ones.",
+	},
+	{
+		Name:        ".retention",
+		Options:     "[purge]",
+		DisplayName: ".retention",
+		Description: "Show the number of rows the retention policies would purge, per table. With purge, delete them.",
+	},
 	{
 		Name:        ".import",
-		Options:     "TYPE FILE table",
+		Options:     "[--format csv|json] [--no-header] [--delimiter C] [--no-infer] [--batch-size N] FILE table",
 		DisplayName: ".import",
-		Description: "Import data from a file. Only supported type is 'csv'",
+		Description: "Import a CSV or JSON file into a table, creating it if needed.",
 	},
 	{
 		Name:        ".timer",
@@ -83,6 +93,12 @@ var commands = []command{
 		DisplayName: ".timer",
 		Description: "Display the execution time after each query or hide it.",
 	},
+	{
+		Name:        ".mode",
+		Options:     "[pretty|json|table|csv|markdown]",
+		DisplayName: ".mode",
+		Description: "Set the output format of the query results or display the current one.",
+	},
 	{
 		Name:        ".restore",
 		Options:     "[dumpFile]",
@@ -188,108 +204,49 @@ func runSaveCmd(ctx context.Context, db *chai.DB, dbPath string) error {
 	return otherDB.Exec(dbDump.String())
 }
 
-const csvBatchSize = 1000
-
-func runImportCmd(db *chai.DB, fileType, path, table string) error {
-	if strings.ToLower(fileType) != "csv" {
-		return errors.New("TYPE should be csv")
-	}
-
-	f, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	conn, err := db.Connect()
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-
-	tx, err := conn.Begin(true)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	r := csv.NewReader(f)
-
-	err = tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s", table))
+// runImportCmd parses the arguments of the .import command
+// and imports the file into the table.
+// The legacy form ".import TYPE FILE table" is still supported.
+func runImportCmd(ctx context.Context, db *chai.DB, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(".import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var opts dbutil.ImportOptions
+	var delimiter string
+	fs.StringVar(&opts.Format, "format", "", "")
+	fs.BoolVar(&opts.NoHeader, "no-header", false, "")
+	fs.StringVar(&delimiter, "delimiter", "", "")
+	fs.BoolVar(&opts.NoInfer, "no-infer", false, "")
+	fs.IntVar(&opts.BatchSize, "batch-size", dbutil.DefaultImportBatchSize, "")
+
+	err := fs.Parse(args)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w\n%s", err, getUsage(".import"))
 	}
 
-	headers, err := r.Read()
-	if err != nil {
-		return err
+	args = fs.Args()
+	if len(args) == 3 && opts.Format == "" {
+		opts.Format = strings.ToLower(args[0])
+		args = args[1:]
 	}
-
-	baseQ := fmt.Sprintf("INSERT INTO %s VALUES ", table)
-
-	buf := make([][]string, csvBatchSize)
-	fbs := make([]*row.ColumnBuffer, csvBatchSize)
-	for i := range fbs {
-		fbs[i] = row.NewColumnBuffer()
+	if len(args) != 2 {
+		return errors.New(getUsage(".import"))
 	}
-	args := make([]any, csvBatchSize)
-	for i := range args {
-		args[i] = fbs[i]
+	if opts.BatchSize <= 0 {
+		return errors.New("batch size must be positive")
 	}
-
-	var sb strings.Builder
-	var stop bool
-	var stmt *chai.Statement
-
-	for !stop {
-		sb.Reset()
-		n, err := csvReadN(r, csvBatchSize, buf)
-		if errors.Is(err, io.EOF) {
-			stop = true
-		} else if err != nil {
-			return err
-		}
-
-		if n == 0 {
-			break
-		}
-
-		for i := 0; i < n; i++ {
-			fbs[i].Reset()
-			fbs[i].ScanCSV(headers, buf[i])
-		}
-
-		if stmt == nil || n < csvBatchSize {
-			sb.WriteString(baseQ)
-			for i := 0; i < n; i++ {
-				if i > 0 {
-					sb.WriteString(",")
-				}
-				sb.WriteString("?")
-			}
-
-			stmt, err = tx.Prepare(sb.String())
-			if err != nil {
-				return err
-			}
-		}
-
-		err = stmt.Exec(args[:n]...)
+	if delimiter != "" {
+		opts.Delimiter, err = dbutil.ParseDelimiter(delimiter)
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
-}
-
-func csvReadN(r *csv.Reader, n int, dst [][]string) (int, error) {
-	for i := 0; i < n; i++ {
-		record, err := r.Read()
-		if err != nil {
-			return i, err
-		}
-		dst[i] = record
+	n, err := dbutil.ImportFile(ctx, db, args[0], args[1], opts)
+	if err != nil {
+		return errors.Wrapf(err, "imported %d rows before failing", n)
 	}
-	return n, nil
+
+	_, err = fmt.Fprintf(out, "Imported %d rows into %s.\n", n, args[1])
+	return err
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -160,7 +161,7 @@ func BenchmarkImportCSV(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		err = runImportCmd(db, "csv", fp, "foo")
+		err = runImportCmd(context.Background(), db, []string{fp, "foo"}, io.Discard)
 		require.NoError(b, err)
 
 		b.StopTimer()
@@ -169,3 +170,56 @@ func BenchmarkImportCSV(b *testing.B) {
 		b.StartTimer()
 	}
 }
+
+func TestModeCmd(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	sh := Shell{db: db, format: dbutil.FormatPretty}
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	err = sh.runCommand(ctx, ".mode", &buf)
+	require.NoError(t, err)
+	require.Equal(t, "pretty\n", buf.String())
+
+	err = sh.runCommand(ctx, ".mode csv", &buf)
+	require.NoError(t, err)
+	require.Equal(t, dbutil.FormatCSV, sh.format)
+
+	buf.Reset()
+	err = sh.runQuery(ctx, "SELECT 1 AS a, 'b' AS b;", &buf)
+	require.NoError(t, err)
+	require.Equal(t, "a,b\n1,b\n", buf.String())
+
+	err = sh.runCommand(ctx, ".mode xml", &buf)
+	require.Error(t, err)
+	require.Equal(t, dbutil.FormatCSV, sh.format)
+}
+
+func TestImportCmd(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	fp := filepath.Join(t.TempDir(), "data.txt")
+	err = os.WriteFile(fp, []byte("1|a\n2|b\n"), 0644)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = runImportCmd(context.Background(), db, []string{"--format", "csv", "--no-header", "--delimiter", "|", fp, "foo"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "Imported 2 rows into foo.\n", buf.String())
+
+	// legacy form
+	buf.Reset()
+	err = runImportCmd(context.Background(), db, []string{"csv", fp, "bar"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "Imported 1 rows into bar.\n", buf.String())
+
+	err = runImportCmd(context.Background(), db, []string{fp}, &buf)
+	require.Error(t, err)
+	err = runImportCmd(context.Background(), db, []string{"--batch-size", "0", fp, "foo"}, &buf)
+	require.Error(t, err)
+}
@@ -0,0 +1,252 @@
+package shell
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// commands whose arguments are table names.
+var tableCommands = map[string]bool{
+	".indexes":     true,
+	".dump":        true,
+	".schema":      true,
+	".write_costs": true,
+}
+
+// complete returns the completions of the word before the cursor,
+// which is an offset in runes in the input, along with that word.
+// Dot-commands are completed at the beginning of the input,
+// table names after FROM, INTO, UPDATE, TABLE and in the
+// arguments of the commands expecting a table, column names after
+// a table name or alias followed by a dot, and SQL keywords and the
+// columns of the tables referenced by the current statement anywhere else.
+// Candidates are sorted and keep the case of the word.
+func complete(db *chai.DB, input string, cursor int) (word string, candidates []string) {
+	runes := []rune(input)
+	if cursor > len(runes) {
+		cursor = len(runes)
+	}
+	before := string(runes[:cursor])
+
+	// the current statement starts after the last semicolon before the cursor
+	// and ends at the next one
+	start := strings.LastIndexByte(before, ';') + 1
+	end := len(input)
+	if i := strings.IndexByte(input[len(before):], ';'); i >= 0 {
+		end = len(before) + i
+	}
+	stmt := input[start:end]
+	before = before[start:]
+
+	i := len(before)
+	for i > 0 && isIdentChar(rune(before[i-1])) {
+		i--
+	}
+	word = before[i:]
+	prefix := before[:i]
+
+	// dot-commands
+	if trimmed := strings.TrimLeft(prefix, " \t\n"); strings.HasPrefix(trimmed, ".") {
+		fields := strings.Fields(trimmed)
+		switch {
+		case len(fields) == 1 && !strings.ContainsAny(trimmed, " \t\n"):
+			// the name of the command itself
+			word = trimmed + word
+			var names []string
+			for _, c := range commands {
+				if strings.HasPrefix(c.Name, ".") {
+					names = append(names, c.Name)
+				}
+			}
+			return word, matching(word, names, false)
+		case len(fields) > 0 && tableCommands[fields[0]]:
+			return word, matching(word, listTables(db), false)
+		}
+
+		return word, nil
+	}
+
+	// columns of a table or an alias followed by a dot
+	if strings.HasSuffix(prefix, ".") {
+		j := len(prefix) - 1
+		for j > 0 && isIdentChar(rune(prefix[j-1])) {
+			j--
+		}
+		name := prefix[j : len(prefix)-1]
+		if table, ok := referencedTables(stmt)[strings.ToLower(name)]; ok {
+			name = table
+		}
+
+		return word, matching(word, listColumns(db, name), false)
+	}
+
+	switch lastToken(prefix) {
+	case scanner.FROM, scanner.INTO, scanner.UPDATE, scanner.TABLE:
+		return word, matching(word, listTables(db), false)
+	}
+
+	// keywords are only suggested once the word is started
+	if word == "" {
+		return word, nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, table := range referencedTables(stmt) {
+		if seen[table] {
+			continue
+		}
+		seen[table] = true
+		names = append(names, listColumns(db, table)...)
+	}
+	candidates = matching(word, names, false)
+
+	var keywords []string
+	for _, tok := range scanner.AllKeywords() {
+		keywords = append(keywords, tok.String())
+	}
+
+	return word, append(candidates, matching(word, keywords, true)...)
+}
+
+// matching returns the sorted names starting with the word, ignoring case.
+// If keepCase is true, names are converted to lower case
+// when the word is in lower case.
+func matching(word string, names []string, keepCase bool) []string {
+	lower := word == strings.ToLower(word)
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if len(name) < len(word) || !strings.EqualFold(name[:len(word)], word) {
+			continue
+		}
+		if keepCase && lower {
+			name = strings.ToLower(name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		matches = append(matches, name)
+	}
+
+	sort.Strings(matches)
+	return matches
+}
+
+// lastToken returns the last token of the text, ignoring whitespaces.
+func lastToken(text string) scanner.Token {
+	last := scanner.ILLEGAL
+
+	s := scanner.NewScanner(strings.NewReader(text))
+	for {
+		tok, _, _ := s.Scan()
+		switch tok {
+		case scanner.EOF:
+			return last
+		case scanner.WS, scanner.COMMENT:
+		default:
+			last = tok
+		}
+	}
+}
+
+// referencedTables returns the tables following FROM, INTO, UPDATE and TABLE
+// in the statement, by lower case name and alias.
+func referencedTables(stmt string) map[string]string {
+	tables := make(map[string]string)
+
+	var tokens []scanner.Token
+	var lits []string
+	s := scanner.NewScanner(strings.NewReader(stmt))
+	for {
+		tok, _, lit := s.Scan()
+		if tok == scanner.EOF {
+			break
+		}
+		if tok == scanner.WS || tok == scanner.COMMENT {
+			continue
+		}
+		tokens = append(tokens, tok)
+		lits = append(lits, lit)
+	}
+
+	for i := 0; i+1 < len(tokens); i++ {
+		switch tokens[i] {
+		case scanner.FROM, scanner.INTO, scanner.UPDATE, scanner.TABLE:
+		default:
+			continue
+		}
+		if tokens[i+1] != scanner.IDENT {
+			continue
+		}
+
+		table := lits[i+1]
+		tables[strings.ToLower(table)] = table
+
+		// alias, with or without AS
+		j := i + 2
+		if j < len(tokens) && tokens[j] == scanner.AS {
+			j++
+		}
+		if j < len(tokens) && tokens[j] == scanner.IDENT {
+			tables[strings.ToLower(lits[j])] = table
+		}
+	}
+
+	return tables
+}
+
+// listTables returns the names of the tables of the database,
+// excluding the internal ones.
+func listTables(db *chai.DB) []string {
+	var tables []string
+	for _, name := range db.DB.Catalog().Cache.ListObjects(database.RelationTableType) {
+		if !database.IsSystemTable(name) {
+			tables = append(tables, name)
+		}
+	}
+
+	return tables
+}
+
+// listColumns returns the names of the columns of a table,
+// or nothing if it doesn't exist.
+func listColumns(db *chai.DB, table string) []string {
+	info, err := db.DB.Catalog().GetTableInfo(table)
+	if err != nil {
+		return nil
+	}
+
+	columns := make([]string, len(info.ColumnConstraints.Ordered))
+	for i, cc := range info.ColumnConstraints.Ordered {
+		columns[i] = cc.Column
+	}
+
+	return columns
+}
+
+// longestCommonPrefix returns the longest prefix shared by all the candidates.
+func longestCommonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
+
+func isIdentChar(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
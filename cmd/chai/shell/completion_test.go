@@ -0,0 +1,67 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplete(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(a INT, bar TEXT, baz TEXT);
+		CREATE TABLE foobar(c INT);
+		CREATE TABLE other(qty INT);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		input      string
+		word       string
+		candidates []string
+	}{
+		{".ta", ".ta", []string{".tables"}},
+		{".", ".", []string{".dump", ".exit", ".help", ".import", ".indexes", ".mode", ".restore", ".retention", ".save", ".schema", ".tables", ".timer", ".write_costs"}},
+		{".schema fo", "fo", []string{"foo", "foobar"}},
+		{".timer o", "o", nil},
+		{"SELECT * FROM fo", "fo", []string{"foo", "foobar"}},
+		{"SELECT * FROM ", "", []string{"foo", "foobar", "other"}},
+		{"INSERT INTO oth", "oth", []string{"other"}},
+		{"SELECT ba", "ba", nil},
+		{"SELECT * FROM foo WHERE ba", "ba", []string{"bar", "baz"}},
+		{"SELECT * FROM foo WHERE a > 1 OR", "OR", []string{"OR", "ORDER"}},
+		{"select * from foo wh", "wh", []string{"where"}},
+		{"SELECT f.b| FROM foo AS f", "b", []string{"bar", "baz"}},
+		{"SELECT ba| FROM foo", "ba", []string{"bar", "baz"}},
+		{"SELECT foo.b", "b", []string{"bar", "baz"}},
+		{"SELECT * FROM foo; SELECT * FROM other WHERE q", "q", []string{"qty"}},
+		{"SELECT ", "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			// the cursor is at the end of the input or at the "|"
+			input := strings.Replace(test.input, "|", "", 1)
+			cursor := strings.Index(test.input, "|")
+			if cursor < 0 {
+				cursor = len(input)
+			}
+
+			word, candidates := complete(db, input, cursor)
+			require.Equal(t, test.word, word)
+			require.Equal(t, test.candidates, candidates)
+		})
+	}
+}
+
+func TestLongestCommonPrefix(t *testing.T) {
+	require.Equal(t, "", longestCommonPrefix(nil))
+	require.Equal(t, "foo", longestCommonPrefix([]string{"foo"}))
+	require.Equal(t, "foo", longestCommonPrefix([]string{"foobar", "foo", "foobaz"}))
+	require.Equal(t, "", longestCommonPrefix([]string{"foo", "bar"}))
+}
@@ -39,6 +39,7 @@ type Shell struct {
 	opts *Options
 
 	displayTime bool
+	format      dbutil.Format
 
 	history []string
 
@@ -55,6 +56,9 @@ type Options struct {
 	// Path of the database directory that will be created.
 	// If empty, the database will be in-memory.
 	DBPath string
+
+	// Format of the query results. Defaults to dbutil.FormatPretty.
+	Format dbutil.Format
 }
 
 type queryTask struct {
@@ -72,6 +76,10 @@ func Run(ctx context.Context, opts *Options) error {
 	var sh Shell
 
 	sh.opts = opts
+	sh.format = opts.Format
+	if sh.format == "" {
+		sh.format = dbutil.FormatPretty
+	}
 
 	db, err := dbutil.OpenDB(ctx, sh.opts.DBPath)
 	if err != nil {
@@ -314,6 +322,23 @@ func (sh *Shell) runCommand(ctx context.Context, in string, out io.Writer) error
 
 		sh.displayTime = cmd[1] == "on"
 		return nil
+	case ".mode":
+		if len(cmd) > 2 {
+			return fmt.Errorf(getUsage(".mode"))
+		}
+
+		if len(cmd) == 1 {
+			_, err := fmt.Fprintln(out, sh.format)
+			return err
+		}
+
+		f, err := dbutil.ParseFormat(cmd[1])
+		if err != nil {
+			return err
+		}
+
+		sh.format = f
+		return nil
 	case ".help":
 		return runHelpCmd(out)
 	case ".tables":
@@ -342,12 +367,16 @@ func (sh *Shell) runCommand(ctx context.Context, in string, out io.Writer) error
 		return runSaveCmd(ctx, sh.db, cmd[1])
 	case ".schema":
 		return dbutil.DumpSchema(sh.db, out, cmd[1:]...)
-	case ".import":
-		if len(cmd) != 4 {
-			return fmt.Errorf(getUsage(".import"))
+	case ".write_costs":
+		return dbutil.DumpWriteCosts(sh.db, out, cmd[1:]...)
+	case ".retention":
+		if len(cmd) > 2 || (len(cmd) == 2 && cmd[1] != "purge") {
+			return fmt.Errorf(getUsage(".retention"))
 		}
 
-		return runImportCmd(sh.db, cmd[1], cmd[2], cmd[3])
+		return dbutil.DumpRetention(sh.db, out, len(cmd) == 1)
+	case ".import":
+		return runImportCmd(ctx, sh.db, cmd[1:], out)
 	case ".restore":
 		if len(cmd) != 2 {
 			return fmt.Errorf(getUsage(".restore"))
@@ -359,7 +388,7 @@ func (sh *Shell) runCommand(ctx context.Context, in string, out io.Writer) error
 }
 
 func (sh *Shell) runQuery(ctx context.Context, q string, out io.Writer) error {
-	err := dbutil.ExecSQL(ctx, sh.db, strings.NewReader(q), out)
+	err := dbutil.ExecSQLWithFormat(ctx, sh.db, strings.NewReader(q), out, sh.format)
 	if errors.Is(err, context.Canceled) {
 		return errors.New("interrupted")
 	}
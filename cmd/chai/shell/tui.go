@@ -72,6 +72,9 @@ type queryInputModel struct {
 	err           error
 	historyOffset int
 	currentQuery  *string
+	// completions displayed below the input
+	// after pressing tab.
+	completions []string
 }
 
 func newQueryInputModel(shell *Shell) queryInputModel {
@@ -125,7 +128,12 @@ func (m queryInputModel) Update(msg tea.Msg) (queryInputModel, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.textArea.SetWidth(msg.Width - 1)
 	case tea.KeyMsg:
+		m.completions = nil
+
 		switch msg.Type {
+		case tea.KeyTab:
+			m.complete()
+			return m, nil
 		case tea.KeyCtrlC:
 			freeze := m.freezeAndReset()
 			return m, tea.Println(freeze)
@@ -218,10 +226,38 @@ func (m queryInputModel) Update(msg tea.Msg) (queryInputModel, tea.Cmd) {
 	return m, cmd
 }
 
+// complete inserts the completion of the word before the cursor.
+// If there are several candidates, their common prefix is inserted
+// and they are displayed below the input.
+func (m *queryInputModel) complete() {
+	lines := strings.Split(m.textArea.Value(), "\n")
+	li := m.textArea.LineInfo()
+	offset := li.StartColumn + li.ColumnOffset
+	for _, l := range lines[:m.textArea.Line()] {
+		offset += len([]rune(l)) + 1
+	}
+
+	word, candidates := complete(m.shell.db, m.textArea.Value(), offset)
+	if len(candidates) == 0 {
+		return
+	}
+
+	completion := longestCommonPrefix(candidates)
+	if len(candidates) > 1 {
+		m.completions = candidates
+	}
+	if len(completion) > len(word) {
+		m.textArea.InsertString(completion[len(word):])
+	}
+}
+
 func (m queryInputModel) View() string {
 	if m.err != nil {
 		return "Error: " + m.err.Error() + "\n" + m.textArea.View() + "\n"
 	}
+	if len(m.completions) > 0 {
+		return m.textArea.View() + "\n" + strings.Join(m.completions, "  ") + "\n"
+	}
 	if !m.debug {
 		return m.textArea.View() + "\n"
 	}
@@ -249,6 +285,7 @@ func (m *queryInputModel) freezeAndReset() string {
 	m.textArea.SetHeight(1)
 	m.textArea.Cursor.SetMode(cursor.CursorStatic)
 	m.err = nil
+	m.completions = nil
 
 	return freeze
 }
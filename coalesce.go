@@ -0,0 +1,252 @@
+package chai
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/cockroachdb/errors"
+)
+
+// Default limits of the shared transactions of coalesced writes.
+const (
+	defaultCoalescingMaxDelay  = time.Millisecond
+	defaultCoalescingMaxWrites = 128
+)
+
+// CoalescingOptions configure the coalescing of small writes.
+//
+// When enabled, the INSERT, UPDATE and DELETE statements run one by one with
+// DB.Exec are not committed in their own transaction but in a transaction
+// shared with the other writes run at the same time, which is committed once
+// it holds MaxWrites writes or MaxDelay after its first write.
+// This saves the cost of a commit per write for applications issuing many
+// tiny independent writes.
+//
+// Each write still applies entirely or not at all: if it fails, the error is
+// returned to its caller only and the other writes of the shared transaction
+// are not affected. Writes run with a Connection, within a transaction or
+// with queries of more than one statement are never coalesced.
+//
+// By default, DB.Exec waits for the shared transaction to be committed,
+// which makes it as durable as without coalescing but adds up to MaxDelay
+// to its latency. See Async to return earlier.
+type CoalescingOptions struct {
+	// MaxDelay is the maximum amount of time between the first write
+	// of a shared transaction and its commit. Defaults to 1ms.
+	MaxDelay time.Duration
+
+	// MaxWrites is the maximum number of writes of a shared transaction.
+	// Defaults to 128.
+	MaxWrites int
+
+	// Async makes DB.Exec return as soon as the write has run in the shared
+	// transaction, before it is committed. Constraint violations and other
+	// errors raised by the statement are still returned, but the write is
+	// lost if the process stops before the commit, and it is not visible
+	// to the other transactions until then, including those of the caller.
+	// If the shared transaction fails to commit, for example because it
+	// conflicts with a concurrent transaction, each of its writes is run
+	// again in its own transaction and the errors are reported to OnError.
+	// Close commits the pending writes.
+	Async bool
+
+	// OnError is called with the writes run asynchronously that failed
+	// to be committed, and their error.
+	OnError func(query string, err error)
+}
+
+// coalescer runs the writes of DB.Exec in shared transactions.
+type coalescer struct {
+	db   *DB
+	opts CoalescingOptions
+
+	mu     sync.Mutex
+	conn   *Connection
+	tx     *Tx
+	writes []*coalescedWrite
+	// incremented for each shared transaction, to prevent the timer
+	// of a transaction from committing the next one.
+	gen    uint64
+	closed bool
+}
+
+type coalescedWrite struct {
+	query string
+	args  []any
+	// receives the result of the commit, nil for asynchronous writes.
+	done chan error
+}
+
+func newCoalescer(db *DB, opts *CoalescingOptions) *coalescer {
+	if opts == nil {
+		return nil
+	}
+
+	c := coalescer{
+		db:   db,
+		opts: *opts,
+	}
+	if c.opts.MaxDelay <= 0 {
+		c.opts.MaxDelay = defaultCoalescingMaxDelay
+	}
+	if c.opts.MaxWrites <= 0 {
+		c.opts.MaxWrites = defaultCoalescingMaxWrites
+	}
+
+	return &c
+}
+
+// canCoalesce returns whether the query is a single write that can be
+// run in a shared transaction.
+func canCoalesce(q string) bool {
+	pq, err := parser.ParseQuery(q)
+	if err != nil || len(pq.Statements) != 1 {
+		return false
+	}
+
+	switch pq.Statements[0].(type) {
+	case *statement.InsertStmt, *statement.UpdateStmt, *statement.DeleteStmt:
+		return true
+	}
+
+	return false
+}
+
+// exec runs the write in the current shared transaction
+// and waits for it to be committed, unless the mode is asynchronous.
+func (c *coalescer) exec(q string, args []any) error {
+	w := coalescedWrite{
+		query: q,
+		// the arguments are kept until the commit
+		args: append([]any(nil), args...),
+	}
+	if !c.opts.Async {
+		w.done = make(chan error, 1)
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return errors.New("database is closed")
+	}
+
+	err := c.run(&w)
+	if err == nil && len(c.writes) >= c.opts.MaxWrites {
+		c.commit()
+	}
+	c.mu.Unlock()
+
+	if err != nil || w.done == nil {
+		return err
+	}
+
+	return <-w.done
+}
+
+// run runs the write in the shared transaction, starting one if needed.
+// The changes of a failed write cannot be undone alone: the shared
+// transaction is rolled back and the previous writes are run again
+// in a new one.
+func (c *coalescer) run(w *coalescedWrite) error {
+	if c.tx == nil {
+		err := c.begin()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := c.conn.Exec(w.query, w.args...)
+	if err == nil {
+		c.writes = append(c.writes, w)
+		return nil
+	}
+
+	prev := c.writes
+	c.abort()
+	for _, p := range prev {
+		if err := c.run(p); err != nil {
+			c.done(p, err)
+		}
+	}
+
+	return err
+}
+
+func (c *coalescer) begin() error {
+	conn, err := c.db.Connect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := conn.Begin(true)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	c.conn, c.tx = conn, tx
+	c.gen++
+
+	gen := c.gen
+	time.AfterFunc(c.opts.MaxDelay, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.gen == gen && c.tx != nil {
+			c.commit()
+		}
+	})
+
+	return nil
+}
+
+func (c *coalescer) abort() {
+	_ = c.tx.Rollback()
+	_ = c.conn.Close()
+	c.conn, c.tx, c.writes = nil, nil, nil
+}
+
+// commit commits the shared transaction and reports the result to its writes.
+func (c *coalescer) commit() {
+	writes := c.writes
+	err := c.tx.Commit()
+	_ = c.conn.Close()
+	c.conn, c.tx, c.writes = nil, nil, nil
+
+	if err == nil {
+		for _, w := range writes {
+			c.done(w, nil)
+		}
+		return
+	}
+
+	// the error can't be attributed to a single write,
+	// run each of them in its own transaction.
+	for _, w := range writes {
+		c.done(w, c.db.exec(w.query, w.args...))
+	}
+}
+
+func (c *coalescer) done(w *coalescedWrite, err error) {
+	if w.done != nil {
+		w.done <- err
+		return
+	}
+
+	if err != nil && c.opts.OnError != nil {
+		c.opts.OnError(w.query, err)
+	}
+}
+
+// close commits the pending writes. Writes run afterwards fail.
+func (c *coalescer) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.tx != nil {
+		c.commit()
+	}
+}
@@ -10,7 +10,11 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"io"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/chaisql/chai/engine"
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/database/catalogstore"
 	"github.com/chaisql/chai/internal/environment"
@@ -28,22 +32,247 @@ import (
 type DB struct {
 	DB  *database.Database
 	ctx context.Context
+
+	// maximum duration of the queries, overriding
+	// the statement_timeout setting if non-zero.
+	timeout time.Duration
+
+	// called every time a query scans a row, if not nil.
+	onProgress func(rowsScanned int64) error
+
+	// runs the writes of Exec in shared transactions, if enabled.
+	coalescer *coalescer
+
+	// logs the queries, if Options.QueryLogger is set.
+	queryLogger *queryLogger
+
+	// counts the queries, see Metrics.
+	metrics *queryMetrics
+}
+
+// Options are used to configure the database opened by OpenWith.
+type Options struct {
+	// LockTimeout is the maximum amount of time to wait for another
+	// process to release the database. If zero, opening a database
+	// that is already in use fails immediately with ErrDatabaseLocked.
+	LockTimeout time.Duration
+
+	// TTLInterval is the amount of time between two deletions of the
+	// expired rows of tables created with a ttl_field.
+	// If zero, expired rows are deleted every minute. If negative,
+	// they are never deleted but remain invisible to queries.
+	// Retention policies set with ALTER TABLE ... SET RETENTION
+	// are applied at the same interval.
+	TTLInterval time.Duration
+
+	// VacuumInterval is the amount of time between two compactions of
+	// the database, which reclaim the space of deleted and updated rows.
+	// If zero, the database is compacted every hour. If negative, it is
+	// only compacted when requested with DB.RunJob("vacuum").
+	VacuumInterval time.Duration
+
+	// HistoryRetention is the amount of time during which past versions
+	// of the database can be read, using Connection.BeginAsOf or
+	// SELECT ... FROM table AS OF TIMESTAMP.
+	// Versions are kept in memory and are lost when the database is closed:
+	// the history starts when the database is opened and doesn't survive
+	// a restart. If zero, past versions are not kept.
+	HistoryRetention time.Duration
+
+	// HistoryMaxVersions is the maximum number of past versions kept,
+	// each commit creating one. Since each version prevents the data it
+	// reads from being reclaimed, the oldest versions are released when
+	// it is exceeded, even if they are within the HistoryRetention period.
+	// If zero, at most 1000 versions are kept. If negative, the number
+	// of versions is not limited.
+	HistoryMaxVersions int
+
+	// CaseSensitiveLike makes LIKE and NOT LIKE compare characters exactly.
+	// By default, they ignore the case of characters, using Unicode
+	// case folding.
+	CaseSensitiveLike bool
+
+	// StrictTyping makes the queries comparing values of incompatible types,
+	// such as a TEXT column with an integer, fail when they are planned
+	// instead of silently evaluating to false or NULL.
+	// It also rejects the inserts and updates whose values lose information
+	// when converted to the type of their column, such as 1.5 stored
+	// in an INTEGER column.
+	// Connections can change it with SET strict_typing.
+	StrictTyping bool
+
+	// SkipCorruptRows makes the queries ignore the rows that cannot be
+	// decoded, for example after a disk corruption, instead of failing
+	// with an error for which IsCorruptRowError returns true.
+	// Connections can change it with SET skip_corrupt_rows.
+	// Use DB.Check to find the corrupted rows.
+	SkipCorruptRows bool
+
+	// WorkMem is the amount of memory, in bytes, that each sort, grouping
+	// and temporary tree of a query uses before spilling its data to disk.
+	// If zero, 512KB are used for sorts and temporary trees and 4MB for
+	// the DISTINCT and ordered aggregates of each group.
+	// Connections can change it with SET work_mem.
+	WorkMem int
+
+	// ParallelWorkers is the number of goroutines reading each table
+	// scanned by the queries of read-only transactions, in key order.
+	// Zero or one reads the tables sequentially.
+	// Connections can change it with SET parallel_workers.
+	ParallelWorkers int
+
+	// Synchronous defines when the commits are synchronized to disk,
+	// as SQLite's synchronous pragma. Connections can change it with
+	// SET synchronous. In every mode, the database remains consistent
+	// after a crash, only the most recent commits can be lost:
+	//   - engine.SyncFull, the default, waits for each commit to reach
+	//     the disk. No committed transaction is lost, but each commit
+	//     costs an fsync.
+	//   - engine.SyncNormal returns before the commits reach the disk,
+	//     which are synchronized every SyncInterval. The commits of the
+	//     last interval can be lost if the process or the machine crashes.
+	//   - engine.SyncOff never waits for the disk. The commits are written
+	//     when the engine or the operating system decides to, an unbounded
+	//     number of recent commits can be lost after a crash.
+	// Custom engines synchronize the commits as they decide if their
+	// sessions don't implement engine.SyncSession.
+	Synchronous engine.Synchronous
+
+	// SyncInterval is the interval at which the commits made with
+	// engine.SyncNormal are synchronized to disk. If zero, they are
+	// synchronized every second.
+	// It is ignored by the databases opened with OpenEngine.
+	SyncInterval time.Duration
+
+	// TransientDir is the directory in which the sorts, groupings and
+	// temporary trees spill their data once they exceed WorkMem.
+	// The database creates its own subdirectory, removed when it is closed,
+	// and removes the subdirectories left by databases that crashed.
+	// If empty, the data is spilled to the database itself.
+	// It is ignored by the databases opened with OpenEngine.
+	TransientDir string
+
+	// TransientDiskQuota is the maximum number of bytes that the temporary
+	// data of a query can spill to disk. Queries exceeding it fail with
+	// ErrDiskQuotaExceeded. If zero, the temporary data is not limited.
+	TransientDiskQuota int64
+
+	// EncryptionKeys are the keys of the columns declared ENCRYPTED, by key id.
+	// The id of the key of a column is the one given with ENCRYPTED WITH KEY 'id',
+	// or table.column by default. Keys must be 16, 24 or 32 bytes long,
+	// to encrypt the values with AES-128, AES-192 or AES-256.
+	// Without its key, an encrypted column can be neither read nor written.
+	EncryptionKeys map[string][]byte
+
+	// KeyProvider is called to get the keys missing from EncryptionKeys,
+	// for example from a key management service. Keys are requested
+	// the first time they are used, then kept in memory.
+	KeyProvider func(keyID string) ([]byte, error)
+
+	// Coalescing enables the coalescing of the writes run with DB.Exec
+	// into shared transactions. If nil, each write is committed in its
+	// own transaction. See CoalescingOptions for the durability guarantees.
+	Coalescing *CoalescingOptions
+
+	// QueryLogger is called every time a query is done, with its text,
+	// arguments, duration, number of rows and plan, once its result
+	// is closed. It is called by the goroutine running the query and
+	// must return quickly.
+	QueryLogger func(*QueryLog)
+
+	// SlowQueryThreshold restricts the queries passed to QueryLogger
+	// to the ones running for at least this duration.
+	// If zero, every query is logged.
+	SlowQueryThreshold time.Duration
+
+	// RedactQueryParams hides the arguments of the queries from QueryLogger,
+	// for example when they contain personal data.
+	RedactQueryParams bool
 }
 
 // Open creates a Chai database at the given path.
 // If path is equal to ":memory:" it will open an in-memory database,
 // otherwise it will create an on-disk database.
+// On-disk databases can only be opened by one process at a time,
+// Open returns ErrDatabaseLocked if the database is already in use.
 func Open(path string) (*DB, error) {
+	return OpenWith(path, nil)
+}
+
+// OpenWith creates a Chai database at the given path, configured
+// with the given options. If opts is nil, default options are used.
+func OpenWith(path string, opts *Options) (*DB, error) {
+	return openWith(path, nil, opts, nil)
+}
+
+// OpenEngine creates a Chai database storing its data in the given engine,
+// configured with the given options. If opts is nil, default options are used.
+// The engine is closed when the database is closed, or if OpenEngine fails.
+// See the engine package for the requirements of the engines.
+func OpenEngine(ng engine.Engine, opts *Options) (*DB, error) {
+	return openWith("", ng, opts, nil)
+}
+
+// OpenSnapshot opens an in-memory database holding the content
+// of the snapshot file at path, written by SaveSnapshot or WriteSnapshot.
+// Changes made to the database are not written to the file,
+// use SaveSnapshot to persist them. If opts is nil, default options are used.
+// It returns ErrInvalidSnapshot if the file is not a valid snapshot.
+func OpenSnapshot(path string, opts *Options) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return openWith(":memory:", nil, opts, f)
+}
+
+func openWith(path string, ng engine.Engine, opts *Options, snapshot io.Reader) (*DB, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
 	db, err := database.Open(path, &database.Options{
-		CatalogLoader: catalogstore.LoadCatalog,
+		CatalogLoader:      catalogstore.LoadCatalog,
+		LockTimeout:        opts.LockTimeout,
+		TTLInterval:        opts.TTLInterval,
+		VacuumInterval:     opts.VacuumInterval,
+		HistoryRetention:   opts.HistoryRetention,
+		HistoryMaxVersions: opts.HistoryMaxVersions,
+		CaseSensitiveLike:  opts.CaseSensitiveLike,
+		StrictTyping:       opts.StrictTyping,
+		SkipCorruptRows:    opts.SkipCorruptRows,
+		WorkMem:            opts.WorkMem,
+		ParallelWorkers:    opts.ParallelWorkers,
+		Synchronous:        opts.Synchronous,
+		SyncInterval:       opts.SyncInterval,
+		TransientDir:       opts.TransientDir,
+		TransientDiskQuota: opts.TransientDiskQuota,
+		Keyring:            newKeyring(opts),
+		Engine:             ng,
+		Snapshot:           snapshot,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &DB{
-		DB: db,
-	}, nil
+	chaidb := DB{
+		DB:          db,
+		queryLogger: newQueryLogger(opts),
+		metrics:     new(queryMetrics),
+	}
+	chaidb.coalescer = newCoalescer(&chaidb, opts.Coalescing)
+
+	return &chaidb, nil
+}
+
+func newKeyring(opts *Options) *database.Keyring {
+	if opts.EncryptionKeys == nil && opts.KeyProvider == nil {
+		return nil
+	}
+
+	return database.NewKeyring(opts.EncryptionKeys, opts.KeyProvider)
 }
 
 func (db *DB) Connect() (*Connection, error) {
@@ -58,12 +287,47 @@ func (db *DB) Connect() (*Connection, error) {
 	}, nil
 }
 
+// ConnectAs authenticates the user created with CREATE USER and returns a connection
+// running every statement as that user, with the privileges granted to it with GRANT.
+// It returns ErrAuthenticationFailed if the user doesn't exist or if the password is wrong.
+// Connections created with Connect have every privilege.
+func (db *DB) ConnectAs(user, password string) (*Connection, error) {
+	conn, err := db.DB.ConnectAs(user, password)
+	if err != nil {
+		return nil, newError(err, "")
+	}
+
+	return &Connection{
+		db:   db,
+		Conn: conn,
+	}, nil
+}
+
 // WithContext creates a new database handle using the given context for every operation.
 func (db DB) WithContext(ctx context.Context) *DB {
 	db.ctx = ctx
 	return &db
 }
 
+// WithTimeout creates a new database handle on which every query
+// is canceled with ErrQueryTimeout if it runs for longer than d,
+// including the time spent iterating over its result.
+// It overrides the statement_timeout setting of the connections.
+func (db DB) WithTimeout(d time.Duration) *DB {
+	db.timeout = d
+	return &db
+}
+
+// WithProgress creates a new database handle on which every query
+// calls fn with the number of rows it scanned so far, every time
+// it reads a row from a table or an index. fn is called by the goroutine
+// running the query and must return quickly. If it returns an error,
+// the query stops with that error, which can be used to cancel it.
+func (db DB) WithProgress(fn func(rowsScanned int64) error) *DB {
+	db.onProgress = fn
+	return &db
+}
+
 func (db *DB) withConn(fn func(*Connection) error) error {
 	conn, err := db.Connect()
 	if err != nil {
@@ -83,15 +347,277 @@ func (db *DB) QueryRow(q string, args ...any) (r *Row, err error) {
 	return
 }
 
+// QueryOne runs the query and returns its only row.
+// If the query returns no row, it returns a NotFoundError,
+// and if it returns more than one row, ErrTooManyRows.
+func (db *DB) QueryOne(q string, args ...any) (r *Row, err error) {
+	err = db.withConn(func(c *Connection) error {
+		r, err = c.QueryOne(q, args...)
+		return err
+	})
+	return
+}
+
+// QueryValue runs a query returning a single row with a single column
+// and scans that column into dest, with the same conversions as Row.Scan.
+// If the query returns no row, it returns a NotFoundError,
+// and if it returns more than one row, ErrTooManyRows.
+func (db *DB) QueryValue(dest any, q string, args ...any) error {
+	return db.withConn(func(c *Connection) error {
+		return c.QueryValue(dest, q, args...)
+	})
+}
+
 // Exec a query against the database without returning the result.
+// If coalescing is enabled, single INSERT, UPDATE and DELETE statements
+// are committed in transactions shared with other writes.
 func (db *DB) Exec(q string, args ...any) error {
+	if db.coalescer != nil && db.timeout == 0 && db.onProgress == nil && canCoalesce(q) {
+		if db.ctx != nil && db.ctx.Err() != nil {
+			return db.ctx.Err()
+		}
+
+		return db.coalescer.exec(q, args)
+	}
+
+	return db.exec(q, args...)
+}
+
+// QueryRowContext runs the query with the given context and returns the first row.
+// The execution stops as soon as the context is canceled.
+func (db *DB) QueryRowContext(ctx context.Context, q string, args ...any) (*Row, error) {
+	return db.WithContext(ctx).QueryRow(q, args...)
+}
+
+// ExecContext runs the query with the given context without returning the result.
+// The execution stops as soon as the context is canceled.
+func (db *DB) ExecContext(ctx context.Context, q string, args ...any) error {
+	return db.WithContext(ctx).Exec(q, args...)
+}
+
+func (db *DB) exec(q string, args ...any) error {
 	return db.withConn(func(c *Connection) error {
 		return c.Exec(q, args...)
 	})
 }
 
+// TableStorageStats describes how a table and its indexes are stored:
+// number of rows, average row size, size on disk, compression ratio
+// and deletion markers not yet removed by compactions.
+type TableStorageStats = database.TableStorageStats
+
+// IndexStorageStats describes how an index is stored.
+type IndexStorageStats = database.IndexStorageStats
+
+// TableStorageStats returns the storage statistics of a table and its indexes.
+// Rows and index entries are counted by scanning them, which can take time
+// on large tables. Sizes on disk are estimated and don't include the latest
+// writes until they are flushed to disk by the storage engine.
+func (db *DB) TableStorageStats(table string) (*TableStorageStats, error) {
+	return db.DB.TableStorageStats(table)
+}
+
+// VacuumStats reports the size on disk of the data compacted by Compact.
+type VacuumStats = database.VacuumStats
+
+// Compact compacts the data of the database on disk, removing the rows
+// deleted or overwritten since the last compaction, and reclaims the space
+// of the dropped tables and indexes, which otherwise remains used until
+// the storage engine compacts their files. It returns the size of the data
+// on disk before and after. The database is also compacted periodically,
+// see Options.VacuumInterval, and with the VACUUM statement.
+func (db *DB) Compact() (*VacuumStats, error) {
+	return db.DB.Vacuum()
+}
+
+// WriteSnapshot writes a copy of the whole database to w, as seen by
+// a read-only transaction: writes committed while the copy is written
+// are not included. It works with both in-memory and on-disk databases,
+// the copy can be opened as an in-memory database with OpenSnapshot.
+func (db *DB) WriteSnapshot(w io.Writer) error {
+	return db.DB.WriteSnapshot(w)
+}
+
+// SaveSnapshot writes a copy of the whole database to the file at path,
+// see WriteSnapshot. The file is replaced atomically if it exists.
+func (db *DB) SaveSnapshot(path string) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	err = db.DB.WriteSnapshot(f)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// BulkInsertOptions controls how BulkInsert writes rows.
+type BulkInsertOptions = database.BulkInsertOptions
+
+// BulkInsert inserts the rows received from the channel into the table,
+// until the channel is closed, and returns the number of inserted rows.
+// Rows are either maps of column names to values, of type map[string]any,
+// or structs, or pointers to structs, whose fields are the columns,
+// with the same mapping as StructScan.
+//
+// Rows are written in batches of opts.BatchSize rows, each in its own
+// transaction, sorted by key, and the entries of the indexes are built
+// after each batch. This is much faster than running an INSERT per row
+// when loading a large number of rows. Constraints are enforced like with INSERT,
+// except deferred unique constraints which are checked immediately.
+// If a batch fails, the rows of the previous batches remain inserted.
+// opts may be nil to use the default options.
+func (db *DB) BulkInsert(table string, rows <-chan any, opts *BulkInsertOptions) (int, error) {
+	ctx := db.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	next := func() (row.Row, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case v, ok := <-rows:
+			if !ok {
+				return nil, nil
+			}
+			if v == nil {
+				return nil, errors.New("cannot insert nil row")
+			}
+			if m, ok := v.(map[string]any); ok {
+				return row.NewFromMap(m), nil
+			}
+			return row.NewFromStruct(v)
+		}
+	}
+
+	return db.DB.BulkInsert(ctx, table, next, opts)
+}
+
+// CheckOptions controls how Check verifies the database.
+type CheckOptions = database.CheckOptions
+
+// CheckReport lists the problems found by Check.
+type CheckReport = database.CheckReport
+
+// IntegrityProblem is an inconsistency found by Check.
+type IntegrityProblem = database.IntegrityProblem
+
+// Check verifies the integrity of the database: the catalog, the rows of every
+// table, the entries of every index and the values of the sequences, and
+// looks for keys that don't belong to any table or index.
+// Rows that cannot be decoded are reported instead of causing a panic.
+// If opts.Quarantine is true, they are moved to the __chai_quarantine table,
+// with their raw key and value, so that the rest of the table can be read.
+// Indexes with missing or orphaned entries can be rebuilt with REINDEX.
+// opts may be nil to use the default options.
+func (db *DB) Check(ctx context.Context, opts *CheckOptions) (*CheckReport, error) {
+	return db.DB.Check(ctx, opts)
+}
+
+// JobStatus describes the state of a maintenance job
+// run in the background by the database.
+type JobStatus = database.JobStatus
+
+// Jobs returns the status of the maintenance jobs run in the background:
+// "ttl" deletes expired rows, "retention" applies retention policies
+// and "vacuum" compacts the database. Jobs due at the same time run
+// one at a time, from the highest priority to the lowest.
+// The statuses are also stored in the __chai_jobs table after each run.
+func (db *DB) Jobs() []JobStatus {
+	return db.DB.Jobs()
+}
+
+// RunJob runs a maintenance job immediately, even if it is paused,
+// and returns its error.
+func (db *DB) RunJob(name string) error {
+	return db.DB.RunJob(name)
+}
+
+// PauseJob stops running a maintenance job automatically until it is
+// resumed with ResumeJob, including after the database is reopened.
+func (db *DB) PauseJob(name string) error {
+	return db.DB.PauseJob(name)
+}
+
+// ResumeJob resumes a maintenance job paused with PauseJob.
+func (db *DB) ResumeJob(name string) error {
+	return db.DB.ResumeJob(name)
+}
+
+// Snapshot is a consistent, read-only view of the database, pinned
+// when it is created. Any number of transactions can read it, concurrently
+// or one after the other, while other transactions keep writing to the database,
+// for example to export large tables in several steps.
+// The data it sees is retained until it is closed.
+type Snapshot struct {
+	db   *DB
+	snap *database.Snapshot
+}
+
+// Snapshot pins the current version of the database. Unlike BeginAsOf,
+// it doesn't require Options.HistoryRetention.
+// The snapshot must be closed after usage, the space of the rows deleted
+// or updated after its creation cannot be reclaimed until then.
+func (db *DB) Snapshot() (*Snapshot, error) {
+	snap, err := db.DB.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{db: db, snap: snap}, nil
+}
+
+// Time returns the time at which the snapshot was created.
+func (s *Snapshot) Time() time.Time {
+	return s.snap.Time()
+}
+
+// View starts a read-only transaction reading the snapshot on a new
+// connection, runs fn and automatically rolls it back.
+func (s *Snapshot) View(fn func(tx *Tx) error) error {
+	return s.db.withConn(func(c *Connection) error {
+		tx, err := c.BeginSnapshot(s)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		return fn(tx)
+	})
+}
+
+// Close releases the snapshot. The transactions reading it remain valid
+// until they are rolled back, but no new transaction can read it.
+// Open snapshots are closed when the database is closed.
+func (s *Snapshot) Close() error {
+	return s.snap.Release()
+}
+
 // Close the database.
+// Pending coalesced writes are committed first.
 func (db *DB) Close() error {
+	if db.coalescer != nil {
+		db.coalescer.close()
+	}
+
 	return db.DB.Close()
 }
 
@@ -102,12 +628,57 @@ type Connection struct {
 
 // Begin starts a new transaction.
 // The returned transaction must be closed either by calling Rollback or Commit.
+// Transactions read from a snapshot of the database taken when they start.
+// Multiple read/write transactions can run concurrently. If one of them
+// modifies data that was modified by another one that committed first,
+// writes the same value of a unique column as another one that committed first,
+// or uses a table, index or sequence whose schema was changed by another one
+// that committed first, its Commit returns ErrTxConflict and it can be retried.
+// A transaction writing more data than the engine keeps in memory
+// prevents the other ones from committing until it ends.
 func (c *Connection) Begin(writable bool) (*Tx, error) {
 	_, err := c.Conn.BeginTx(&database.TxOptions{
 		ReadOnly: !writable,
 	})
 	if err != nil {
-		return nil, err
+		return nil, newError(err, "")
+	}
+
+	return &Tx{
+		conn: c,
+	}, nil
+}
+
+// BeginAsOf starts a read-only transaction reading the database
+// as it was at the given time. The time must be within the
+// history retention period configured with Options.HistoryRetention,
+// after the database was opened, and its version must not have been
+// released because of Options.HistoryMaxVersions.
+// The returned transaction must be closed by calling Rollback.
+func (c *Connection) BeginAsOf(ts time.Time) (*Tx, error) {
+	_, err := c.Conn.BeginTx(&database.TxOptions{
+		ReadOnly: true,
+		AsOf:     ts,
+	})
+	if err != nil {
+		return nil, newError(err, "")
+	}
+
+	return &Tx{
+		conn: c,
+	}, nil
+}
+
+// BeginSnapshot starts a read-only transaction reading the database
+// as it was when the snapshot was created.
+// The returned transaction must be closed by calling Rollback.
+func (c *Connection) BeginSnapshot(s *Snapshot) (*Tx, error) {
+	_, err := c.Conn.BeginTx(&database.TxOptions{
+		ReadOnly: true,
+		Snapshot: s.snap,
+	})
+	if err != nil {
+		return nil, newError(err, "")
 	}
 
 	return &Tx{
@@ -142,6 +713,17 @@ func (c *Connection) Update(fn func(tx *Tx) error) error {
 	return tx.Commit()
 }
 
+// WithProgress returns a copy of the statement that reports
+// its progress to fn. See DB.WithProgress for details.
+func (s *Statement) WithProgress(fn func(rowsScanned int64) error) *Statement {
+	return &Statement{
+		q:    s.q,
+		pq:   s.pq,
+		conn: s.conn.WithProgress(fn),
+		tx:   s.tx,
+	}
+}
+
 // Query the database and return the result.
 // The returned result must always be closed after usage.
 func (c *Connection) Query(q string, args ...any) (*Result, error) {
@@ -170,6 +752,28 @@ func (c *Connection) QueryRow(q string, args ...any) (*Row, error) {
 	return stmt.QueryRow(args...)
 }
 
+// QueryOne runs the query and returns its only row.
+// See DB.QueryOne.
+func (c *Connection) QueryOne(q string, args ...any) (*Row, error) {
+	stmt, err := c.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.QueryOne(args...)
+}
+
+// QueryValue runs the query and scans its only value into dest.
+// See DB.QueryValue.
+func (c *Connection) QueryValue(dest any, q string, args ...any) error {
+	stmt, err := c.Prepare(q)
+	if err != nil {
+		return err
+	}
+
+	return stmt.QueryValue(dest, args...)
+}
+
 // Exec a query against the database without returning the result.
 func (c *Connection) Exec(q string, args ...any) error {
 	stmt, err := c.Prepare(q)
@@ -180,24 +784,81 @@ func (c *Connection) Exec(q string, args ...any) error {
 	return stmt.Exec(args...)
 }
 
+// QueryContext runs the query with the given context and returns the result.
+// The execution stops as soon as the context is canceled.
+// The returned result must always be closed after usage.
+func (c *Connection) QueryContext(ctx context.Context, q string, args ...any) (*Result, error) {
+	return c.WithContext(ctx).Query(q, args...)
+}
+
+// QueryRowContext runs the query with the given context and returns the first row.
+func (c *Connection) QueryRowContext(ctx context.Context, q string, args ...any) (*Row, error) {
+	return c.WithContext(ctx).QueryRow(q, args...)
+}
+
+// ExecContext runs the query with the given context without returning the result.
+func (c *Connection) ExecContext(ctx context.Context, q string, args ...any) error {
+	return c.WithContext(ctx).Exec(q, args...)
+}
+
 // Prepare parses the query and returns a prepared statement.
 func (c *Connection) Prepare(q string) (*Statement, error) {
 	pq, err := parser.ParseQuery(q)
 	if err != nil {
-		return nil, err
+		return nil, newError(err, q)
 	}
 
 	err = pq.Prepare(newQueryContext(c, nil))
 	if err != nil {
-		return nil, err
+		return nil, newError(err, q)
 	}
 
 	return &Statement{
+		q:    q,
 		pq:   pq,
 		conn: c,
 	}, nil
 }
 
+// WithContext returns a handle on the same connection that uses
+// the given context for every operation. Both handles share
+// the settings and the transaction of the connection.
+func (c *Connection) WithContext(ctx context.Context) *Connection {
+	return &Connection{
+		db:   c.db.WithContext(ctx),
+		Conn: c.Conn,
+	}
+}
+
+// WithTimeout returns a handle on the same connection on which every
+// query is canceled with ErrQueryTimeout if it runs for longer than d.
+// It overrides the statement_timeout setting of the connection.
+func (c *Connection) WithTimeout(d time.Duration) *Connection {
+	return &Connection{
+		db:   c.db.WithTimeout(d),
+		Conn: c.Conn,
+	}
+}
+
+// WithProgress returns a handle on the same connection on which every
+// query reports its progress to fn. See DB.WithProgress for details.
+func (c *Connection) WithProgress(fn func(rowsScanned int64) error) *Connection {
+	return &Connection{
+		db:   c.db.WithProgress(fn),
+		Conn: c.Conn,
+	}
+}
+
+// statementTimeout returns the maximum duration of the queries
+// run by the connection, or zero if they have no timeout.
+func (c *Connection) statementTimeout() time.Duration {
+	if c.db.timeout > 0 {
+		return c.db.timeout
+	}
+
+	return c.Conn.StatementTimeout()
+}
+
 func (c *Connection) Close() error {
 	return c.Conn.Close()
 }
@@ -217,7 +878,7 @@ func (tx *Tx) Rollback() error {
 		return errors.New("transaction has already been committed or rolled back")
 	}
 
-	return t.Rollback()
+	return newError(t.Rollback(), "")
 }
 
 // Commit the transaction. Calling this method on read-only transactions
@@ -228,7 +889,7 @@ func (tx *Tx) Commit() error {
 		return errors.New("transaction has already been committed or rolled back")
 	}
 
-	return t.Commit()
+	return newError(t.Commit(), "")
 }
 
 // Query the database withing the transaction and returns the result.
@@ -252,6 +913,28 @@ func (tx *Tx) QueryRow(q string, args ...any) (*Row, error) {
 	return stmt.QueryRow(args...)
 }
 
+// QueryOne runs the query within the transaction and returns its only row.
+// See DB.QueryOne.
+func (tx *Tx) QueryOne(q string, args ...any) (*Row, error) {
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.QueryOne(args...)
+}
+
+// QueryValue runs the query within the transaction and scans its only value into dest.
+// See DB.QueryValue.
+func (tx *Tx) QueryValue(dest any, q string, args ...any) error {
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+
+	return stmt.QueryValue(dest, args...)
+}
+
 // Exec a query against the database within tx and without returning the result.
 func (tx *Tx) Exec(q string, args ...any) (err error) {
 	stmt, err := tx.Prepare(q)
@@ -262,19 +945,53 @@ func (tx *Tx) Exec(q string, args ...any) (err error) {
 	return stmt.Exec(args...)
 }
 
+// QueryContext runs the query within the transaction with the given context
+// and returns the result. The execution stops as soon as the context is canceled.
+func (tx *Tx) QueryContext(ctx context.Context, q string, args ...any) (*Result, error) {
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.WithContext(ctx).Query(args...)
+}
+
+// QueryRowContext runs the query within the transaction with the given context
+// and returns the first row.
+func (tx *Tx) QueryRowContext(ctx context.Context, q string, args ...any) (*Row, error) {
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.WithContext(ctx).QueryRow(args...)
+}
+
+// ExecContext runs the query within the transaction with the given context
+// without returning the result.
+func (tx *Tx) ExecContext(ctx context.Context, q string, args ...any) error {
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+
+	return stmt.WithContext(ctx).Exec(args...)
+}
+
 // Prepare parses the query and returns a prepared statement.
 func (tx *Tx) Prepare(q string) (*Statement, error) {
 	pq, err := parser.ParseQuery(q)
 	if err != nil {
-		return nil, err
+		return nil, newError(err, q)
 	}
 
 	err = pq.Prepare(newQueryContext(tx.conn, nil))
 	if err != nil {
-		return nil, err
+		return nil, newError(err, q)
 	}
 
 	return &Statement{
+		q:    q,
 		pq:   pq,
 		conn: tx.conn,
 		tx:   tx,
@@ -284,25 +1001,75 @@ func (tx *Tx) Prepare(q string) (*Statement, error) {
 // Statement is a prepared statement. If Statement has been created on a Tx,
 // it will only be valid until Tx closes. If it has been created on a DB, it
 // is valid until the DB closes.
+// If the schema changes after it is prepared, for example when another
+// connection creates an index on a table it uses, it is prepared again
+// the next time it runs.
 // It's safe for concurrent use by multiple goroutines.
 type Statement struct {
+	q    string
 	pq   query.Query
 	conn *Connection
 	tx   *Tx
 }
 
+// WithContext returns a copy of the statement that runs
+// with the given context.
+func (s *Statement) WithContext(ctx context.Context) *Statement {
+	return &Statement{
+		q:    s.q,
+		pq:   s.pq,
+		conn: s.conn.WithContext(ctx),
+		tx:   s.tx,
+	}
+}
+
+// WithTimeout returns a copy of the statement that is canceled
+// with ErrQueryTimeout if it runs for longer than d.
+func (s *Statement) WithTimeout(d time.Duration) *Statement {
+	return &Statement{
+		q:    s.q,
+		pq:   s.pq,
+		conn: s.conn.WithTimeout(d),
+		tx:   s.tx,
+	}
+}
+
 // Query the database and return the result.
 // The returned result must always be closed after usage.
+// If the connection has a statement timeout, the query is canceled
+// with ErrQueryTimeout once it expires, and the transaction
+// it created is rolled back when the result is closed.
 func (s *Statement) Query(args ...any) (*Result, error) {
-	var r *statement.Result
-	var err error
+	conn := s.conn
 
-	r, err = s.pq.Run(newQueryContext(s.conn, argsToParams(args)))
+	var cancel context.CancelFunc
+	if timeout := conn.statementTimeout(); timeout > 0 {
+		ctx := conn.db.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, ErrQueryTimeout)
+		conn = conn.WithContext(ctx)
+	}
+
+	params := argsToParams(args)
+	progress := &environment.Progress{OnProgress: conn.db.onProgress}
+	run := conn.db.startQuery(s.q, params, progress)
+	qctx := newQueryContext(conn, params)
+	qctx.Progress = progress
+
+	r, err := s.pq.Run(qctx)
 	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
+		err = newError(err, "")
+		run.setErr(err)
+		run.done(nil)
 		return nil, err
 	}
 
-	return &Result{result: r, ctx: s.conn.db.ctx}, nil
+	return &Result{result: r, ctx: conn.db.ctx, cancel: cancel, progress: progress, run: run}, nil
 }
 
 func argsToParams(args []interface{}) []environment.Param {
@@ -349,6 +1116,42 @@ func (s *Statement) QueryRow(args ...any) (r *Row, err error) {
 	return res.GetFirst()
 }
 
+// QueryOne runs the query and returns its only row.
+// See DB.QueryOne.
+func (s *Statement) QueryOne(args ...any) (r *Row, err error) {
+	res, err := s.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		er := res.Close()
+		if err == nil {
+			err = er
+		}
+	}()
+
+	return res.getOne()
+}
+
+// QueryValue runs the query and scans its only value into dest.
+// See DB.QueryValue.
+func (s *Statement) QueryValue(dest any, args ...any) error {
+	r, err := s.QueryOne(args...)
+	if err != nil {
+		return err
+	}
+
+	columns, err := r.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != 1 {
+		return errors.Errorf("query returned %d columns, expected 1", len(columns))
+	}
+
+	return r.Scan(dest)
+}
+
 // Exec a query against the database without returning the result.
 func (s *Statement) Exec(args ...any) (err error) {
 	res, err := s.Query(args...)
@@ -369,28 +1172,37 @@ func (s *Statement) Exec(args ...any) (err error) {
 
 // Result of a query.
 type Result struct {
-	result *statement.Result
-	ctx    context.Context
-	conn   *Connection
+	result   *statement.Result
+	ctx      context.Context
+	cancel   context.CancelFunc
+	progress *environment.Progress
+	conn     *Connection
+	// tracks the query for the metrics and the query logger.
+	run *queryRun
 }
 
+// Iterate calls fn for each row of the result. The errors returned
+// by fn are returned as is, the others are returned as an *Error.
 func (r *Result) Iterate(fn func(r *Row) error) error {
 	var row Row
-	if r.ctx == nil {
-		return r.result.Iterate(func(dr database.Row) error {
-			row.Row = dr
-			return fn(&row)
-		})
-	}
-
-	return r.result.Iterate(func(dr database.Row) error {
-		if err := r.ctx.Err(); err != nil {
-			return err
+	var ferr error
+	err := r.result.Iterate(func(dr database.Row) error {
+		if r.ctx != nil && r.ctx.Err() != nil {
+			return context.Cause(r.ctx)
 		}
 
+		r.run.addRow()
 		row.Row = dr
-		return fn(&row)
+		ferr = fn(&row)
+		return ferr
 	})
+	if err != nil && ferr != nil && errors.Is(err, ferr) {
+		return err
+	}
+
+	err = newError(err, "")
+	r.run.setErr(err)
+	return err
 }
 
 func (r *Result) GetFirst() (*Row, error) {
@@ -410,6 +1222,30 @@ func (r *Result) GetFirst() (*Row, error) {
 	return rr, nil
 }
 
+// getOne returns the only row of the result. If there is more than
+// one row, the iteration stops with ErrTooManyRows, which rolls back
+// the writes of the statement when the result is closed.
+func (r *Result) getOne() (*Row, error) {
+	var rr *Row
+	err := r.Iterate(func(row *Row) error {
+		if rr != nil {
+			return errors.WithStack(ErrTooManyRows)
+		}
+
+		rr = row.Clone()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rr == nil {
+		return nil, errors.WithStack(errs.NewRowNotFoundError())
+	}
+
+	return rr, nil
+}
+
 func (r *Result) Columns() ([]string, error) {
 	if r.result.Iterator == nil {
 		return nil, nil
@@ -425,7 +1261,27 @@ func (r *Result) Columns() ([]string, error) {
 	env.Tx = stmt.Context.Tx
 	env.SetParams(stmt.Context.Params)
 
-	return stmt.Stream.Columns(&env)
+	cols, err := stmt.Stream.Columns(&env)
+	return cols, newError(err, "")
+}
+
+// RowsAffected returns the number of rows inserted, updated or deleted
+// by the statement, once the result was iterated.
+// Statements returning rows, like SELECT or INSERT ... RETURNING,
+// report 0.
+func (r *Result) RowsAffected() int64 {
+	return r.result.RowsAffected()
+}
+
+// RowsScanned returns the number of rows read from tables and indexes
+// by the query so far. It can be called by other goroutines while
+// the result is iterated, to report the progress of large scans.
+func (r *Result) RowsScanned() int64 {
+	if r.progress == nil {
+		return 0
+	}
+
+	return r.progress.RowsScanned()
 }
 
 // Close the result stream.
@@ -434,7 +1290,14 @@ func (r *Result) Close() (err error) {
 		return nil
 	}
 
-	err = r.result.Close()
+	err = newError(r.result.Close(), "")
+	if r.cancel != nil {
+		r.cancel()
+	}
+
+	r.run.setErr(err)
+	r.run.done(r.result)
+	r.run = nil
 
 	return err
 }
@@ -454,8 +1317,10 @@ func (r *Result) MarshalJSONTo(w io.Writer) error {
 
 	buf.WriteByte('[')
 
+	run := r.run
 	first := true
 	err := r.result.Iterate(func(r database.Row) error {
+		run.addRow()
 		if !first {
 			buf.WriteString(", ")
 		} else {
@@ -471,6 +1336,7 @@ func (r *Result) MarshalJSONTo(w io.Writer) error {
 		return err
 	})
 	if err != nil {
+		run.setErr(err)
 		return err
 	}
 
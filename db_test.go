@@ -1,13 +1,26 @@
 package chai_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/kv"
 	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/chaisql/chai/plan"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -146,6 +159,746 @@ func TestOpen(t *testing.T) {
 	testutil.RequireJSONEq(t, d, `{"name": "seqD", "seq": 500}`)
 }
 
+func TestOpenLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdb")
+
+	db, err := chai.Open(path)
+	require.NoError(t, err)
+
+	t.Run("fails immediately", func(t *testing.T) {
+		_, err := chai.Open(path)
+		require.ErrorIs(t, err, chai.ErrDatabaseLocked)
+	})
+
+	t.Run("fails after timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := chai.OpenWith(path, &chai.Options{LockTimeout: 50 * time.Millisecond})
+		require.ErrorIs(t, err, chai.ErrDatabaseLocked)
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("waits for the lock", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			db.Close()
+		}()
+
+		db2, err := chai.OpenWith(path, &chai.Options{LockTimeout: 5 * time.Second})
+		require.NoError(t, err)
+		require.NoError(t, db2.Close())
+	})
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	// registered first so that it runs after the connections are closed
+	t.Cleanup(func() { db.Close() })
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+
+	begin := func() *chai.Tx {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		return tx
+	}
+
+	count := func(q string) int {
+		var n int
+		r, err := db.QueryRow(q)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	t.Run("disjoint writes", func(t *testing.T) {
+		tx1 := begin()
+		tx2 := begin()
+
+		require.NoError(t, tx1.Exec("INSERT INTO test (a, b) VALUES (1, 'tx1')"))
+		require.NoError(t, tx2.Exec("INSERT INTO test (a, b) VALUES (2, 'tx2')"))
+
+		// each transaction only sees its own writes
+		r, err := tx1.QueryRow("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 1, n)
+
+		require.NoError(t, tx1.Commit())
+		require.NoError(t, tx2.Commit())
+
+		require.Equal(t, 2, count("SELECT COUNT(*) FROM test"))
+	})
+
+	t.Run("snapshot reads", func(t *testing.T) {
+		tx := begin()
+		defer tx.Rollback()
+
+		require.NoError(t, db.Exec("INSERT INTO test (a, b) VALUES (3, 'db')"))
+
+		r, err := tx.QueryRow("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("write conflict", func(t *testing.T) {
+		tx1 := begin()
+		tx2 := begin()
+
+		require.NoError(t, tx1.Exec("UPDATE test SET b = 'tx1' WHERE a = 3"))
+		require.NoError(t, tx2.Exec("UPDATE test SET b = 'tx2' WHERE a = 3"))
+
+		require.NoError(t, tx1.Commit())
+		require.ErrorIs(t, tx2.Commit(), chai.ErrTxConflict)
+
+		// the conflicting transaction was rolled back
+		require.Error(t, tx2.Rollback())
+		require.Equal(t, 1, count("SELECT COUNT(*) FROM test WHERE a = 3 AND b = 'tx1'"))
+
+		// retrying succeeds
+		tx2 = begin()
+		require.NoError(t, tx2.Exec("UPDATE test SET b = 'tx2' WHERE a = 3"))
+		require.NoError(t, tx2.Commit())
+		require.Equal(t, 1, count("SELECT COUNT(*) FROM test WHERE a = 3 AND b = 'tx2'"))
+	})
+
+	t.Run("unique values", func(t *testing.T) {
+		err := db.Exec(`
+			CREATE TABLE uq(a INTEGER PRIMARY KEY, b TEXT UNIQUE);
+			CREATE TABLE uqd(a INTEGER PRIMARY KEY, b TEXT UNIQUE DEFERRABLE INITIALLY DEFERRED);
+		`)
+		require.NoError(t, err)
+
+		for _, table := range []string{"uq", "uqd"} {
+			tx1 := begin()
+			tx2 := begin()
+
+			// the keys of the rows and of the index entries are different
+			require.NoError(t, tx1.Exec("INSERT INTO "+table+" (a, b) VALUES (1, 'a')"))
+			require.NoError(t, tx2.Exec("INSERT INTO "+table+" (a, b) VALUES (2, 'a')"))
+
+			require.NoError(t, tx1.Commit())
+			require.ErrorIs(t, tx2.Commit(), chai.ErrTxConflict, table)
+			require.Equal(t, 1, count("SELECT COUNT(*) FROM "+table+" WHERE b = 'a'"))
+
+			// different values don't conflict
+			tx1 = begin()
+			tx2 = begin()
+			require.NoError(t, tx1.Exec("INSERT INTO "+table+" (a, b) VALUES (3, 'b')"))
+			require.NoError(t, tx2.Exec("INSERT INTO "+table+" (a, b) VALUES (4, 'c')"))
+			require.NoError(t, tx1.Commit())
+			require.NoError(t, tx2.Commit())
+		}
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		err := db.Exec(`
+			CREATE TABLE tr(a INTEGER PRIMARY KEY);
+			INSERT INTO tr (a) VALUES (1), (2);
+		`)
+		require.NoError(t, err)
+
+		// the rows inserted by a concurrent transaction
+		// belong to the truncated range
+		tx1 := begin()
+		tx2 := begin()
+		require.NoError(t, tx1.Exec("TRUNCATE TABLE tr"))
+		require.NoError(t, tx2.Exec("INSERT INTO tr (a) VALUES (3)"))
+		r, err := tx1.QueryRow("SELECT COUNT(*) FROM tr")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 0, n)
+
+		require.NoError(t, tx2.Commit())
+		require.ErrorIs(t, tx1.Commit(), chai.ErrTxConflict)
+		require.Equal(t, 3, count("SELECT COUNT(*) FROM tr"))
+	})
+
+	t.Run("concurrent schema change", func(t *testing.T) {
+		tx := begin()
+		require.NoError(t, tx.Exec("INSERT INTO test (a, b) VALUES (4, 'tx')"))
+
+		require.NoError(t, db.Exec("CREATE INDEX test_b ON test(b)"))
+
+		require.ErrorIs(t, tx.Commit(), chai.ErrTxConflict)
+		require.Equal(t, 0, count("SELECT COUNT(*) FROM test WHERE a = 4"))
+	})
+
+	t.Run("unrelated schema change", func(t *testing.T) {
+		tx := begin()
+		require.NoError(t, tx.Exec("INSERT INTO test (a, b) VALUES (5, 'tx')"))
+
+		require.NoError(t, db.Exec("CREATE TABLE u(a INT)"))
+		require.NoError(t, db.Exec("CREATE SEQUENCE s"))
+
+		require.NoError(t, tx.Commit())
+		require.Equal(t, 1, count("SELECT COUNT(*) FROM test WHERE a = 5"))
+	})
+
+	t.Run("concurrent schema changes", func(t *testing.T) {
+		tx := begin()
+		require.NoError(t, tx.Exec("CREATE SEQUENCE s2"))
+
+		require.NoError(t, db.Exec("CREATE INDEX u_a ON u(a)"))
+
+		require.NoError(t, tx.Commit())
+
+		// both changes are kept
+		require.Equal(t, 1, count("SELECT NEXT VALUE FOR s2"))
+		require.Equal(t, 1, count("SELECT COUNT(*) FROM __chai_catalog WHERE name = 'u_a'"))
+
+		tx = begin()
+		require.NoError(t, tx.Exec("INSERT INTO u (a) VALUES (1)"))
+
+		require.NoError(t, db.Exec("DROP INDEX u_a"))
+
+		require.ErrorIs(t, tx.Commit(), chai.ErrTxConflict)
+	})
+
+	t.Run("parallel inserts", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				conn, err := db.Connect()
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer conn.Close()
+
+				for j := 0; j < 10; j++ {
+					err := conn.Exec("INSERT INTO test (a, b) VALUES (?, 'parallel')", 100+i*10+j)
+					assert.NoError(t, err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		require.Equal(t, 100, count("SELECT COUNT(*) FROM test WHERE b = 'parallel'"))
+	})
+}
+
+func TestCoalescing(t *testing.T) {
+	count := func(db *chai.DB, q string) int {
+		var n int
+		r, err := db.QueryRow(q)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	t.Run("parallel writes", func(t *testing.T) {
+		db, err := chai.OpenWith(":memory:", &chai.Options{
+			Coalescing: &chai.CoalescingOptions{MaxDelay: 10 * time.Millisecond, MaxWrites: 16},
+		})
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT)"))
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var failed []int
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				for j := 0; j < 10; j++ {
+					// every goroutine inserts 1000 once, only the first one succeeds
+					a := i*10 + j
+					if j == 5 {
+						a = 1000
+					}
+
+					err := db.Exec("INSERT INTO test (a, b) VALUES (?, 'b')", a)
+					if err != nil {
+						assert.True(t, chai.IsAlreadyExistsError(err))
+						mu.Lock()
+						failed = append(failed, a)
+						mu.Unlock()
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		// the failed writes didn't affect the others
+		require.Len(t, failed, 19)
+		for _, a := range failed {
+			require.Equal(t, 1000, a)
+		}
+		require.Equal(t, 181, count(db, "SELECT COUNT(*) FROM test"))
+
+		// writes are visible once Exec returns
+		require.NoError(t, db.Exec("UPDATE test SET b = 'c' WHERE a < 5"))
+		require.Equal(t, 5, count(db, "SELECT COUNT(*) FROM test WHERE b = 'c'"))
+		require.NoError(t, db.Exec("DELETE FROM test WHERE b = 'c'"))
+		require.Equal(t, 176, count(db, "SELECT COUNT(*) FROM test"))
+
+		// a failed write is rolled back entirely
+		err = db.Exec("INSERT INTO test (a, b) VALUES (2000, 'b'), (1000, 'b')")
+		require.True(t, chai.IsAlreadyExistsError(err))
+		require.Equal(t, 0, count(db, "SELECT COUNT(*) FROM test WHERE a = 2000"))
+	})
+
+	t.Run("async", func(t *testing.T) {
+		dir := t.TempDir()
+		var errs []error
+		opts := chai.Options{
+			Coalescing: &chai.CoalescingOptions{
+				MaxDelay: time.Hour,
+				Async:    true,
+				OnError: func(query string, err error) {
+					errs = append(errs, err)
+				},
+			},
+		}
+
+		db, err := chai.OpenWith(dir, &opts)
+		require.NoError(t, err)
+
+		require.NoError(t, db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT)"))
+		require.NoError(t, db.Exec("INSERT INTO test (a, b) VALUES (1, 'a')"))
+
+		// statement errors are still returned
+		err = db.Exec("INSERT INTO test (a, b) VALUES (1, 'a')")
+		require.True(t, chai.IsAlreadyExistsError(err))
+
+		// the write is not committed yet
+		require.Equal(t, 0, count(db, "SELECT COUNT(*) FROM test"))
+
+		// the shared transaction conflicts with this one,
+		// its writes are run again when it is committed
+		require.NoError(t, db.Exec("UPDATE test SET b = 'coalesced' WHERE a = 1"))
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		require.NoError(t, conn.Exec("BEGIN; INSERT INTO test (a, b) VALUES (1, 'conn'); COMMIT;"))
+		require.NoError(t, conn.Close())
+
+		// pending writes are committed on close
+		require.NoError(t, db.Close())
+		require.Len(t, errs, 1)
+		require.True(t, chai.IsAlreadyExistsError(errs[0]))
+
+		db, err = chai.Open(dir)
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.Equal(t, 1, count(db, "SELECT COUNT(*) FROM test WHERE b = 'coalesced'"))
+	})
+}
+
+func TestTTL(t *testing.T) {
+	setup := func(t *testing.T, opts *chai.Options) *chai.DB {
+		db, err := chai.OpenWith(":memory:", opts)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		err = db.Exec(`
+			CREATE TABLE sessions(id INTEGER PRIMARY KEY, name TEXT UNIQUE, expires_at TIMESTAMP) WITH (ttl_field = expires_at);
+			INSERT INTO sessions (id, name, expires_at) VALUES
+				(1, 'a', '2000-01-01T00:00:00Z'),
+				(2, 'b', '2999-01-01T00:00:00Z'),
+				(3, 'c', NULL);
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	t.Run("manual deletion", func(t *testing.T) {
+		db := setup(t, &chai.Options{TTLInterval: -1})
+
+		n, err := db.DB.DeleteExpiredRows()
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		n, err = db.DB.DeleteExpiredRows()
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		// the primary key and the unique index entries must have been removed
+		err = db.Exec("INSERT INTO sessions (id, name) VALUES (1, 'a')")
+		require.NoError(t, err)
+	})
+
+	t.Run("background deletion", func(t *testing.T) {
+		db := setup(t, &chai.Options{TTLInterval: 10 * time.Millisecond})
+
+		require.Eventually(t, func() bool {
+			return db.Exec("INSERT INTO sessions (id, name) VALUES (1, 'a')") == nil
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestRetention(t *testing.T) {
+	setup := func(t *testing.T, opts *chai.Options) *chai.DB {
+		db, err := chai.OpenWith(":memory:", opts)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		// logs is purged using an index, events using its primary key
+		// and audit by scanning the table.
+		err = db.Exec(`
+			CREATE TABLE logs(id INTEGER PRIMARY KEY, msg TEXT UNIQUE, ts TIMESTAMP);
+			CREATE INDEX logs_ts_idx ON logs(ts);
+			CREATE TABLE events(ts TIMESTAMP PRIMARY KEY, msg TEXT);
+			CREATE TABLE audit(id INTEGER PRIMARY KEY, ts TIMESTAMP);
+			INSERT INTO logs (id, msg, ts) VALUES
+				(1, 'a', '2000-01-01T00:00:00Z'),
+				(2, 'b', '2001-01-01T00:00:00Z'),
+				(3, 'c', NOW()),
+				(4, 'd', NULL);
+			INSERT INTO events (ts, msg) VALUES ('2000-01-01T00:00:00Z', 'a'), (NOW(), 'b');
+			INSERT INTO audit (id, ts) VALUES (1, '2000-01-01T00:00:00Z'), (2, NOW()), (3, NULL);
+			ALTER TABLE logs SET RETENTION (older_than = '30 days', column = 'ts');
+			ALTER TABLE events SET RETENTION (older_than = '1 week', column = ts);
+			ALTER TABLE audit SET RETENTION (older_than = '12 hours', column = ts);
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	count := func(t *testing.T, db *chai.DB, q string) int {
+		r, err := db.QueryRow(q)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		db := setup(t, &chai.Options{TTLInterval: -1})
+
+		reports, err := db.DB.ApplyRetentionPolicies(true)
+		require.NoError(t, err)
+		require.Len(t, reports, 3)
+		require.Equal(t, "audit", reports[0].Table)
+		require.Equal(t, 1, reports[0].Rows)
+		require.Equal(t, "events", reports[1].Table)
+		require.Equal(t, 1, reports[1].Rows)
+		require.Equal(t, "logs", reports[2].Table)
+		require.Equal(t, 2, reports[2].Rows)
+		require.WithinDuration(t, time.Now().Add(-30*24*time.Hour), reports[2].Cutoff, time.Minute)
+
+		require.Equal(t, 4, count(t, db, "SELECT COUNT(*) FROM logs"))
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		db := setup(t, &chai.Options{TTLInterval: -1})
+
+		reports, err := db.DB.ApplyRetentionPolicies(false)
+		require.NoError(t, err)
+		require.Len(t, reports, 3)
+
+		require.Equal(t, 2, count(t, db, "SELECT COUNT(*) FROM logs"))
+		require.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM events"))
+		require.Equal(t, 2, count(t, db, "SELECT COUNT(*) FROM audit"))
+
+		// the index entries must have been removed
+		require.Equal(t, 0, count(t, db, "SELECT COUNT(*) FROM logs WHERE ts < '2010-01-01T00:00:00Z'"))
+		err = db.Exec("INSERT INTO logs (id, msg) VALUES (1, 'a')")
+		require.NoError(t, err)
+
+		reports, err = db.DB.ApplyRetentionPolicies(true)
+		require.NoError(t, err)
+		for _, r := range reports {
+			require.Zero(t, r.Rows)
+		}
+	})
+
+	t.Run("drop retention", func(t *testing.T) {
+		db := setup(t, &chai.Options{TTLInterval: -1})
+
+		err := db.Exec("ALTER TABLE logs DROP RETENTION")
+		require.NoError(t, err)
+
+		reports, err := db.DB.ApplyRetentionPolicies(false)
+		require.NoError(t, err)
+		require.Len(t, reports, 2)
+		require.Equal(t, 4, count(t, db, "SELECT COUNT(*) FROM logs"))
+	})
+
+	t.Run("background purge", func(t *testing.T) {
+		db := setup(t, &chai.Options{TTLInterval: 10 * time.Millisecond})
+
+		require.Eventually(t, func() bool {
+			return count(t, db, "SELECT COUNT(*) FROM logs") == 2
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestJobs(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := chai.OpenWith(filepath.Join(dir, "testdb"), &chai.Options{TTLInterval: -1})
+	require.NoError(t, err)
+
+	var names []string
+	for _, j := range db.Jobs() {
+		names = append(names, j.Name)
+		require.Zero(t, j.Runs)
+	}
+	require.Equal(t, []string{"ttl", "retention", "vacuum"}, names)
+
+	// jobs without interval are not scheduled
+	require.True(t, db.Jobs()[0].NextRun.IsZero())
+	require.False(t, db.Jobs()[2].NextRun.IsZero())
+
+	err = db.RunJob("vacuum")
+	require.NoError(t, err)
+	err = db.PauseJob("vacuum")
+	require.NoError(t, err)
+	require.True(t, db.Jobs()[2].NextRun.IsZero())
+
+	err = db.RunJob("foo")
+	require.Error(t, err)
+
+	r, err := db.QueryRow("SELECT runs, paused, last_error FROM __chai_jobs WHERE name = 'vacuum'")
+	require.NoError(t, err)
+	var runs int
+	var paused bool
+	var lastError *string
+	require.NoError(t, r.Scan(&runs, &paused, &lastError))
+	require.Equal(t, 1, runs)
+	require.True(t, paused)
+	require.Nil(t, lastError)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	// ensure the state of the jobs is restored
+	db, err = chai.OpenWith(filepath.Join(dir, "testdb"), &chai.Options{TTLInterval: -1})
+	require.NoError(t, err)
+	defer db.Close()
+
+	vacuum := db.Jobs()[2]
+	require.Equal(t, int64(1), vacuum.Runs)
+	require.True(t, vacuum.Paused)
+	require.True(t, vacuum.NextRun.IsZero())
+
+	err = db.ResumeJob("vacuum")
+	require.NoError(t, err)
+	require.False(t, db.Jobs()[2].NextRun.IsZero())
+}
+
+func TestPinnedSnapshot(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)"))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, db.Exec("INSERT INTO test (a) VALUES (?)", i))
+	}
+
+	snap, err := db.Snapshot()
+	require.NoError(t, err)
+
+	// write concurrently to the database, including a schema change
+	done := make(chan error)
+	go func() {
+		for i := 100; i < 200; i++ {
+			if err := db.Exec("INSERT INTO test (a) VALUES (?)", i); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- db.Exec("CREATE TABLE other(a INTEGER PRIMARY KEY)")
+	}()
+
+	count := func(tx *chai.Tx) int {
+		var n int
+		r, err := tx.QueryRow("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	// each transaction reading the snapshot sees the same data
+	for i := 0; i < 10; i++ {
+		err = snap.View(func(tx *chai.Tx) error {
+			require.Equal(t, 100, count(tx))
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, <-done)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tx, err := conn.BeginSnapshot(snap)
+	require.NoError(t, err)
+	require.Equal(t, 100, count(tx))
+	_, err = tx.QueryRow("SELECT COUNT(*) FROM other")
+	require.Error(t, err)
+	require.Error(t, tx.Exec("INSERT INTO test (a) VALUES (1000)"))
+
+	// the transaction remains valid after the snapshot is closed
+	require.NoError(t, snap.Close())
+	require.Equal(t, 100, count(tx))
+	require.NoError(t, tx.Rollback())
+
+	_, err = conn.BeginSnapshot(snap)
+	require.Error(t, err)
+
+	err = conn.View(func(tx *chai.Tx) error {
+		require.Equal(t, 200, count(tx))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// open snapshots are released when the database is closed
+	_, err = db.Snapshot()
+	require.NoError(t, err)
+}
+
+func TestAsOf(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{HistoryRetention: time.Hour})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	opened := time.Now()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+	t1 := time.Now()
+
+	err = db.Exec("INSERT INTO test (a) VALUES (2)")
+	require.NoError(t, err)
+	t2 := time.Now()
+
+	err = db.Exec("DROP TABLE test")
+	require.NoError(t, err)
+
+	count := func(t *testing.T, ts time.Time) int {
+		var n int
+		r, err := db.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", ts)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	t.Run("SQL", func(t *testing.T) {
+		require.Equal(t, 1, count(t, t1))
+		require.Equal(t, 2, count(t, t2))
+
+		_, err := db.QueryRow("SELECT COUNT(*) FROM test")
+		require.Error(t, err)
+	})
+
+	t.Run("BeginAsOf", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.BeginAsOf(t1)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		var n int
+		r, err := tx.QueryRow("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 1, n)
+
+		err = tx.Exec("INSERT INTO test (a) VALUES (3)")
+		require.Error(t, err)
+
+		// AS OF cannot be used within a transaction
+		_, err = tx.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", t2)
+		require.Error(t, err)
+	})
+
+	t.Run("outside of the retention period", func(t *testing.T) {
+		_, err := db.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", opened.Add(-time.Hour))
+		require.Error(t, err)
+
+		_, err = db.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", time.Now().Add(time.Hour))
+		require.Error(t, err)
+	})
+
+	t.Run("pruning", func(t *testing.T) {
+		db, err := chai.OpenWith(":memory:", &chai.Options{HistoryRetention: time.Second})
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+		require.NoError(t, err)
+		created := time.Now()
+
+		time.Sleep(1100 * time.Millisecond)
+		beforeInsert := time.Now()
+		err = db.Exec("INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+
+		_, err = db.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", created)
+		require.Error(t, err)
+
+		// the version at the start of the retention period is kept
+		var n int
+		r, err := db.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", beforeInsert)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 0, n)
+	})
+
+	t.Run("maximum number of versions", func(t *testing.T) {
+		db, err := chai.OpenWith(":memory:", &chai.Options{HistoryRetention: time.Hour, HistoryMaxVersions: 2})
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+		require.NoError(t, err)
+		created := time.Now()
+
+		err = db.Exec("INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+		t1 := time.Now()
+
+		err = db.Exec("INSERT INTO test (a) VALUES (2)")
+		require.NoError(t, err)
+
+		// the version created by CREATE TABLE was released
+		_, err = db.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", created)
+		require.Error(t, err)
+
+		var n int
+		r, err := db.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", t1)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 1, n)
+	})
+
+	t.Run("history disabled", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+		require.NoError(t, err)
+
+		_, err = db.QueryRow("SELECT COUNT(*) FROM test AS OF TIMESTAMP ?", time.Now())
+		require.Error(t, err)
+	})
+}
+
 func TestQueryRow(t *testing.T) {
 	db, err := chai.Open(":memory:")
 	require.NoError(t, err)
@@ -155,102 +908,1877 @@ func TestQueryRow(t *testing.T) {
 	require.NoError(t, err)
 	defer conn.Close()
 
-	tx, err := conn.Begin(true)
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+
+	err = tx.Exec(`
+			CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT NOT NULL);
+			INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar')
+		`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	t.Run("Should return the first row", func(t *testing.T) {
+		var a int
+		var b string
+
+		r, err := db.QueryRow("SELECT * FROM test")
+		require.NoError(t, err)
+		err = r.Scan(&a, &b)
+		require.NoError(t, err)
+		require.Equal(t, 1, a)
+		require.Equal(t, "foo", b)
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		r, err = tx.QueryRow("SELECT * FROM test")
+		require.NoError(t, err)
+		err = r.Scan(&a, &b)
+		require.NoError(t, err)
+		require.Equal(t, 1, a)
+		require.Equal(t, "foo", b)
+	})
+
+	t.Run("Should return an error if no row", func(t *testing.T) {
+		r, err := db.QueryRow("SELECT * FROM test WHERE a > 100")
+		require.True(t, chai.IsNotFoundError(err))
+		require.Nil(t, r)
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		r, err = tx.QueryRow("SELECT * FROM test WHERE a > 100")
+		require.True(t, chai.IsNotFoundError(err))
+		require.Nil(t, r)
+	})
+}
+
+func TestQueryOne(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT NOT NULL);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+
+	t.Run("Should return the only row", func(t *testing.T) {
+		r, err := db.QueryOne("SELECT * FROM test WHERE a = ?", 2)
+		require.NoError(t, err)
+
+		var s struct {
+			A int
+			B string
+		}
+		err = r.StructScan(&s)
+		require.NoError(t, err)
+		require.Equal(t, 2, s.A)
+		require.Equal(t, "bar", s.B)
+	})
+
+	t.Run("Should return an error if no row", func(t *testing.T) {
+		r, err := db.QueryOne("SELECT * FROM test WHERE a > 100")
+		require.True(t, chai.IsNotFoundError(err))
+		require.Nil(t, r)
+	})
+
+	t.Run("Should return an error if more than one row", func(t *testing.T) {
+		r, err := db.QueryOne("SELECT * FROM test")
+		require.ErrorIs(t, err, chai.ErrTooManyRows)
+		require.Nil(t, r)
+	})
+
+	t.Run("Should roll back the writes if more than one row", func(t *testing.T) {
+		_, err := db.QueryOne("INSERT INTO test (a, b) VALUES (10, 'baz'), (11, 'qux') RETURNING a")
+		require.ErrorIs(t, err, chai.ErrTooManyRows)
+
+		var n int
+		err = db.QueryValue(&n, "SELECT COUNT(*) FROM test WHERE a >= 10")
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+	})
+
+	t.Run("Should scan the value", func(t *testing.T) {
+		var b string
+		err := db.QueryValue(&b, "SELECT b FROM test WHERE a = ?", 1)
+		require.NoError(t, err)
+		require.Equal(t, "foo", b)
+
+		var n int64
+		err = db.QueryValue(&n, "SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+
+		err = db.QueryValue(&b, "SELECT b FROM test WHERE a > 100")
+		require.True(t, chai.IsNotFoundError(err))
+
+		err = db.QueryValue(&b, "SELECT b FROM test")
+		require.ErrorIs(t, err, chai.ErrTooManyRows)
+
+		err = db.QueryValue(&b, "SELECT * FROM test WHERE a = 1")
+		require.EqualError(t, err, "query returned 2 columns, expected 1")
+	})
+
+	t.Run("Should work within a transaction", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.Exec("INSERT INTO test (a, b) VALUES (3, 'baz')")
+		require.NoError(t, err)
+
+		var b string
+		err = tx.QueryValue(&b, "SELECT b FROM test WHERE a = 3")
+		require.NoError(t, err)
+		require.Equal(t, "baz", b)
+
+		_, err = tx.QueryOne("SELECT * FROM test")
+		require.ErrorIs(t, err, chai.ErrTooManyRows)
+	})
+}
+
+func TestPreparedInsert(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT INTO test (a, b) VALUES (?, ?)")
+	require.NoError(t, err)
+
+	// each execution must use its own parameters
+	for i, b := range []string{"foo", "bar", "baz"} {
+		err = stmt.Exec(i, b)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tx.Commit())
+
+	var b string
+	r, err := db.QueryRow("SELECT b FROM test WHERE a = 2")
+	require.NoError(t, err)
+	err = r.Scan(&b)
+	require.NoError(t, err)
+	require.Equal(t, "baz", b)
+}
+
+func TestBulkInsert(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT UNIQUE, c DOUBLE DEFAULT 1.5);
+		CREATE INDEX test_c_idx ON test (c);
+	`)
+	require.NoError(t, err)
+
+	count := func(q string) int {
+		t.Helper()
+
+		var n int
+		r, err := db.QueryRow(q)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	type T struct {
+		A int
+		B string
+		C *float64
+	}
+
+	ch := make(chan any)
+	go func() {
+		// unordered keys, over several batches
+		for i := 99; i >= 0; i-- {
+			if i%2 == 0 {
+				ch <- map[string]any{"a": i, "b": fmt.Sprintf("foo%d", i)}
+			} else {
+				c := float64(i)
+				ch <- &T{A: i, B: fmt.Sprintf("foo%d", i), C: &c}
+			}
+		}
+		close(ch)
+	}()
+
+	n, err := db.BulkInsert("test", ch, &chai.BulkInsertOptions{BatchSize: 7})
+	require.NoError(t, err)
+	require.Equal(t, 100, n)
+
+	require.Equal(t, 100, count("SELECT COUNT(*) FROM test"))
+	require.Equal(t, 50, count("SELECT COUNT(*) FROM test WHERE c = 1.5"))
+	require.Equal(t, 42, count("SELECT a FROM test WHERE b = 'foo42'"))
+	require.Equal(t, 43, count("SELECT a FROM test WHERE c = 43.0"))
+
+	ch = make(chan any, 1)
+	ch <- nil
+	close(ch)
+	_, err = db.BulkInsert("test", ch, nil)
+	require.EqualError(t, err, "cannot insert nil row")
+}
+
+func TestIterateDeepCopy(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+	CREATE TABLE foo (
+		a integer primary key,
+		b text not null
+	);
+
+	INSERT INTO foo (a, b) VALUES
+		(1, 'sample text 1'),
+		(2, 'sample text 2');
+	`)
+	require.NoError(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	res, err := conn.Query(`SELECT * FROM foo ORDER BY a DESC`)
+	require.NoError(t, err)
+
+	type item struct {
+		A int
+		B string
+	}
+
+	var items []*item
+	err = res.Iterate(func(r *chai.Row) error {
+		var i item
+		err := r.StructScan(&i)
+		require.NoError(t, err)
+
+		items = append(items, &i)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, len(items), 2)
+	require.Equal(t, &item{A: 2, B: "sample text 2"}, items[0])
+	require.Equal(t, &item{A: 1, B: "sample text 1"}, items[1])
+}
+
+func TestStructs(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, `order` INTEGER, " +
+		"created_at TIMESTAMP, address TEXT, tags TEXT, nickname TEXT DEFAULT 'none')")
+	require.NoError(t, err)
+
+	type Address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip"`
+	}
+
+	type Base struct {
+		ID int64 `chai:"id"`
+	}
+
+	type User struct {
+		Base
+		Name      string
+		Order     int       `chai:"order"`
+		CreatedAt time.Time `chai:"created_at"`
+		Address   Address
+		Tags      []string
+		Nickname  *string
+		Password  string `chai:"-"`
+	}
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	alice := User{
+		Base:      Base{ID: 1},
+		Name:      "alice",
+		Order:     2,
+		CreatedAt: now,
+		Address:   Address{City: "Lyon", Zip: "69001"},
+		Tags:      []string{"a", "b"},
+		Password:  "secret",
+	}
+	require.NoError(t, db.InsertStruct("users", &alice))
+
+	nick := "bobby"
+	require.NoError(t, db.InsertStruct("users", []User{
+		{Base: Base{ID: 2}, Name: "bob", Nickname: &nick},
+		{Base: Base{ID: 3}, Name: "carol", CreatedAt: now.Add(time.Hour)},
+	}))
+
+	r, err := db.QueryRow("SELECT address, tags, nickname FROM users WHERE id = 1")
+	require.NoError(t, err)
+	var address, tags, nickname string
+	require.NoError(t, r.Scan(&address, &tags, &nickname))
+	require.Equal(t, `{"city":"Lyon","zip":"69001"}`, address)
+	require.Equal(t, `["a","b"]`, tags)
+	require.Equal(t, "none", nickname)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Run("ScanStructs", func(t *testing.T) {
+		res, err := conn.Query("SELECT * FROM users ORDER BY id")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var users []User
+		require.NoError(t, res.ScanStructs(&users))
+		require.Len(t, users, 3)
+
+		alice.Password = ""
+		alice.Nickname = &[]string{"none"}[0]
+		require.Equal(t, alice, users[0])
+		require.Equal(t, "bobby", *users[1].Nickname)
+		require.Equal(t, Address{}, users[1].Address)
+		require.Nil(t, users[1].Tags)
+		require.True(t, now.Add(time.Hour).Equal(users[2].CreatedAt))
+	})
+
+	t.Run("ScanStructs pointers", func(t *testing.T) {
+		res, err := conn.Query("SELECT id, name FROM users WHERE id > 1 ORDER BY id")
+		require.NoError(t, err)
+		defer res.Close()
+
+		users := []*User{{Name: "existing"}}
+		require.NoError(t, res.ScanStructs(&users))
+		require.Len(t, users, 3)
+		require.Equal(t, "bob", users[1].Name)
+		require.Equal(t, int64(3), users[2].ID)
+	})
+
+	t.Run("ScanStructs invalid target", func(t *testing.T) {
+		res, err := conn.Query("SELECT * FROM users")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var users []User
+		require.Error(t, res.ScanStructs(users))
+		var names []string
+		require.Error(t, res.ScanStructs(&names))
+	})
+
+	t.Run("Tx", func(t *testing.T) {
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		require.NoError(t, tx.InsertStruct("users", User{Base: Base{ID: 4}, Name: "dan"}))
+		// the batch is inserted entirely or not at all
+		err = conn.InsertStruct("users", []User{{Base: Base{ID: 5}, Name: "eve"}, {Base: Base{ID: 1}, Name: "dup"}})
+		require.Error(t, err)
+	})
+
+	t.Run("Batch rollback", func(t *testing.T) {
+		err := db.InsertStruct("users", []User{{Base: Base{ID: 5}, Name: "eve"}, {Base: Base{ID: 1}, Name: "dup"}})
+		require.Error(t, err)
+
+		r, err := db.QueryRow("SELECT COUNT(*) FROM users")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 3, n)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		require.Error(t, db.InsertStruct("users", 1))
+		require.Error(t, db.InsertStruct("", &alice))
+		require.NoError(t, db.InsertStruct("users", []User{}))
+	})
+}
+
+func TestContext(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, db.ExecContext(ctx, "CREATE TABLE test(a INT PRIMARY KEY, b INT); CREATE INDEX test_b_idx ON test(b)"))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, db.ExecContext(ctx, "INSERT INTO test (a, b) VALUES (?, ?)", i, i))
+	}
+
+	r, err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, r.Scan(&n))
+	require.Equal(t, 100, n)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	require.ErrorIs(t, db.ExecContext(canceled, "DELETE FROM test"), context.Canceled)
+	_, err = db.QueryRowContext(canceled, "SELECT * FROM test")
+	require.ErrorIs(t, err, context.Canceled)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the scans stop even if no row is returned
+	for _, q := range []string{
+		"SELECT a FROM test WHERE a + 1 < 0",
+		"SELECT a FROM test WHERE b > 0 AND a + 1 < 0",
+		"UPDATE test SET b = b + 1",
+	} {
+		t.Run(q, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			res, err := conn.QueryContext(ctx, q)
+			require.NoError(t, err)
+			cancel()
+
+			err = res.Iterate(func(r *chai.Row) error { return nil })
+			require.ErrorIs(t, err, context.Canceled)
+			require.NoError(t, res.Close())
+		})
+	}
+
+	r, err = db.QueryRow("SELECT COUNT(*) FROM test WHERE a = b")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&n))
+	require.Equal(t, 100, n)
+
+	t.Run("Tx", func(t *testing.T) {
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		require.NoError(t, tx.ExecContext(ctx, "INSERT INTO test (a, b) VALUES (100, 100)"))
+		require.ErrorIs(t, tx.ExecContext(canceled, "DELETE FROM test"), context.Canceled)
+		_, err = tx.QueryContext(canceled, "SELECT * FROM test")
+		require.ErrorIs(t, err, context.Canceled)
+		_, err = tx.QueryRowContext(canceled, "SELECT * FROM test")
+		require.ErrorIs(t, err, context.Canceled)
+
+		r, err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 101, n)
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b INT)"))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, i))
+	}
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.Exec("SET statement_timeout = '10ms'"))
+
+	// the timeout includes the iteration of the result
+	res, err := conn.Query("SELECT * FROM test")
+	require.NoError(t, err)
+	err = res.Iterate(func(r *chai.Row) error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	require.ErrorIs(t, err, chai.ErrQueryTimeout)
+	require.NoError(t, res.Close())
+
+	// the transaction of a statement that timed out is rolled back
+	res, err = conn.Query("UPDATE test SET b = b + 1")
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	require.ErrorIs(t, res.Iterate(func(r *chai.Row) error { return nil }), chai.ErrQueryTimeout)
+	require.NoError(t, res.Close())
+
+	require.NoError(t, conn.Exec("UPDATE test SET b = b + 2 WHERE a = 0"))
+	r, err := conn.QueryRow("SELECT COUNT(*) FROM test WHERE a = b")
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, r.Scan(&n))
+	require.Equal(t, 9, n)
+
+	// WithTimeout overrides the setting
+	res, err = conn.WithTimeout(time.Hour).Query("SELECT * FROM test")
+	require.NoError(t, err)
+	require.NoError(t, res.Iterate(func(r *chai.Row) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}))
+	require.NoError(t, res.Close())
+
+	// the cancellation of the context is not reported as a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, conn.ExecContext(ctx, "SELECT * FROM test"), context.Canceled)
+
+	require.NoError(t, conn.Exec("SET statement_timeout = DEFAULT"))
+	res, err = conn.Query("SELECT * FROM test")
+	require.NoError(t, err)
+	require.NoError(t, res.Iterate(func(r *chai.Row) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}))
+	require.NoError(t, res.Close())
+
+	t.Run("Statement", func(t *testing.T) {
+		stmt, err := conn.Prepare("SELECT * FROM test")
+		require.NoError(t, err)
+
+		res, err := stmt.WithTimeout(time.Millisecond).Query()
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		require.ErrorIs(t, res.Iterate(func(r *chai.Row) error { return nil }), chai.ErrQueryTimeout)
+		require.NoError(t, res.Close())
+
+		require.NoError(t, db.WithTimeout(time.Hour).Exec("DELETE FROM test WHERE a = 9"))
+	})
+}
+
+func TestProgress(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b INT); CREATE INDEX test_b_idx ON test(b)"))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, i%10))
+	}
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var calls []int64
+	res, err := conn.WithProgress(func(rowsScanned int64) error {
+		calls = append(calls, rowsScanned)
+		return nil
+	}).Query("SELECT COUNT(*) FROM test WHERE a + 1 > 0")
+	require.NoError(t, err)
+	require.NoError(t, res.Iterate(func(r *chai.Row) error { return nil }))
+	require.EqualValues(t, 100, res.RowsScanned())
+	require.NoError(t, res.Close())
+	require.Len(t, calls, 100)
+	require.EqualValues(t, 1, calls[0])
+	require.EqualValues(t, 100, calls[99])
+
+	// index scans are reported too
+	res, err = conn.Query("SELECT a FROM test WHERE b = 3")
+	require.NoError(t, err)
+	require.Zero(t, res.RowsScanned())
+	var n int
+	require.NoError(t, res.Iterate(func(r *chai.Row) error {
+		n++
+		return nil
+	}))
+	require.Equal(t, 10, n)
+	require.EqualValues(t, 10, res.RowsScanned())
+	require.NoError(t, res.Close())
+
+	// the callback can stop the query
+	errStop := errors.New("stop")
+	err = conn.WithProgress(func(rowsScanned int64) error {
+		if rowsScanned == 50 {
+			return errStop
+		}
+		return nil
+	}).Exec("UPDATE test SET b = b + 1")
+	require.ErrorIs(t, err, errStop)
+
+	r, err := db.QueryRow("SELECT COUNT(*) FROM test WHERE b < 10")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&n))
+	require.Equal(t, 100, n)
+}
+
+func TestTransientDir(t *testing.T) {
+	dir := t.TempDir()
+
+	// directory left by a database that crashed
+	orphan := filepath.Join(dir, "chai-transient-orphan")
+	require.NoError(t, os.Mkdir(orphan, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(orphan, "LOCK"), nil, 0600))
+
+	db, err := chai.OpenWith(":memory:", &chai.Options{
+		WorkMem:            1 << 10,
+		TransientDir:       dir,
+		TransientDiskQuota: 32 << 10,
+	})
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.NotEqual(t, "chai-transient-orphan", entries[0].Name())
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+
+	require.NoError(t, conn.Exec("CREATE TABLE test(a INT PRIMARY KEY, b INT, c TEXT)"))
+	err = conn.Update(func(tx *chai.Tx) error {
+		for i := 0; i < 1000; i++ {
+			err := tx.Exec("INSERT INTO test (a, b, c) VALUES (?, ?, ?)", i, i%10, strings.Repeat("x", 50))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the sorted rows fit in the quota
+	r, err := conn.QueryRow("SELECT a FROM test WHERE a < 100 ORDER BY b DESC")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 99}`)
+
+	// the sorted rows exceed the quota
+	res, err := conn.Query("SELECT * FROM test ORDER BY b DESC")
+	require.NoError(t, err)
+	err = res.Iterate(func(r *chai.Row) error { return nil })
+	require.ErrorIs(t, err, chai.ErrDiskQuotaExceeded)
+	require.NoError(t, res.Close())
+
+	// the quota is released at the end of the queries
+	r, err = conn.QueryRow("SELECT a FROM test WHERE a < 100 ORDER BY b DESC")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 99}`)
+
+	require.NoError(t, conn.Close())
+	require.NoError(t, db.Close())
+
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestSynchronous(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := chai.OpenWith(dir, &chai.Options{Synchronous: engine.SyncNormal, SyncInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+
+	require.NoError(t, conn.Exec("CREATE TABLE test(a INT PRIMARY KEY)"))
+	for i, setting := range []string{"OFF", "NORMAL", "FULL", "0", "DEFAULT"} {
+		require.NoError(t, conn.Exec("SET synchronous = "+setting))
+		require.NoError(t, conn.Exec("INSERT INTO test (a) VALUES (?)", i))
+	}
+
+	err = conn.Exec("SET synchronous = 'extra'")
+	require.Error(t, err)
+
+	require.NoError(t, conn.Close())
+	require.NoError(t, db.Close())
+
+	// the commits that were not synchronized are
+	// written when the database is closed.
+	db, err = chai.Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err := db.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, r.Scan(&count))
+	require.Equal(t, 5, count)
+}
+
+func TestQueryPage(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b INT);
+		CREATE TABLE names(name TEXT PRIMARY KEY);
+		CREATE TABLE events(ts TIMESTAMP PRIMARY KEY);
+		CREATE TABLE composite(a INT, b INT, PRIMARY KEY (a, b));
+	`))
+	for i := -5; i < 20; i++ {
+		require.NoError(t, db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, i%2))
+	}
+	for _, name := range []string{"a'b", "c", "d", "e"} {
+		require.NoError(t, db.Exec("INSERT INTO names (name) VALUES (?)", name))
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.Exec("INSERT INTO events (ts) VALUES (?)", start.Add(time.Duration(i)*time.Hour)))
+	}
+
+	// readAll reads every page and returns the values of the column
+	readAll := func(t *testing.T, table, column string, opts *chai.PageOptions) (values []any, pages int) {
+		t.Helper()
+
+		var token string
+		for {
+			p, err := db.QueryPage(table, token, opts)
+			require.NoError(t, err)
+			pages++
+
+			for _, r := range p.Rows {
+				var v any
+				require.NoError(t, r.ScanColumn(column, &v))
+				values = append(values, v)
+			}
+
+			if p.Next == "" {
+				return values, pages
+			}
+			token = p.Next
+		}
+	}
+
+	values, pages := readAll(t, "test", "a", &chai.PageOptions{Limit: 3})
+	require.Len(t, values, 25)
+	require.Equal(t, 9, pages)
+	for i, v := range values {
+		require.EqualValues(t, i-5, v)
+	}
+
+	values, pages = readAll(t, "test", "a", &chai.PageOptions{Limit: 4, Where: "b != ?", Args: []any{0}})
+	require.Equal(t, []any{int32(-5), int32(-3), int32(-1), int32(1), int32(3), int32(5), int32(7), int32(9), int32(11), int32(13), int32(15), int32(17), int32(19)}, values)
+	require.Equal(t, 4, pages)
+
+	values, pages = readAll(t, "names", "name", &chai.PageOptions{Limit: 2})
+	require.Equal(t, []any{"a'b", "c", "d", "e"}, values)
+	require.Equal(t, 2, pages)
+
+	values, _ = readAll(t, "events", "ts", &chai.PageOptions{Limit: 2})
+	require.Len(t, values, 5)
+	for i, v := range values {
+		require.True(t, start.Add(time.Duration(i)*time.Hour).Equal(v.(time.Time)))
+	}
+
+	// rows inserted before the current page are not returned
+	p, err := db.QueryPage("test", "", &chai.PageOptions{Limit: 5})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("INSERT INTO test (a, b) VALUES (-10, 0)"))
+	p, err = db.QueryPage("test", p.Next, &chai.PageOptions{Limit: 5})
+	require.NoError(t, err)
+	var a int
+	require.NoError(t, p.Rows[0].ScanColumn("a", &a))
+	require.Equal(t, 0, a)
+
+	p, err = db.QueryPage("test", "", &chai.PageOptions{Where: "a > 100"})
+	require.NoError(t, err)
+	require.Empty(t, p.Rows)
+	require.Empty(t, p.Next)
+
+	_, err = db.QueryPage("composite", "", nil)
+	require.Error(t, err)
+	_, err = db.QueryPage("test", "not a token", nil)
+	require.Error(t, err)
+	_, err = db.QueryPage("unknown", "", nil)
+	require.Error(t, err)
+}
+
+func TestFeatures(t *testing.T) {
+	features := chai.Features()
+	require.Contains(t, features, chai.FeatureUpsert)
+	require.Contains(t, features, chai.FeatureCompatMode)
+	require.NotContains(t, features, chai.FeatureJoins)
+	require.True(t, sort.SliceIsSorted(features, func(i, j int) bool {
+		return features[i] < features[j]
+	}))
+
+	require.True(t, chai.Supports(chai.FeatureSchemas))
+	require.False(t, chai.Supports(chai.FeatureWindowFunctions))
+	require.False(t, chai.Supports("unknown"))
+}
+
+func TestCompatMode(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	like := func() bool {
+		r, err := conn.QueryRow("SELECT 'ABC' LIKE 'abc'")
+		require.NoError(t, err)
+		var ok bool
+		require.NoError(t, r.Scan(&ok))
+		return ok
+	}
+
+	require.True(t, like())
+
+	err = conn.Exec("SET compat_mode = 'postgres'")
+	require.NoError(t, err)
+	require.False(t, like())
+
+	// the mode applies to the transactions of the connection
+	tx, err := conn.Begin(false)
+	require.NoError(t, err)
+	r, err := tx.QueryRow("SELECT 'ABC' LIKE 'abc'")
+	require.NoError(t, err)
+	var ok bool
+	require.NoError(t, r.Scan(&ok))
+	require.False(t, ok)
+	require.NoError(t, tx.Rollback())
+
+	err = conn.Exec("SET compat_mode = 'sqlite'")
+	require.NoError(t, err)
+	require.True(t, like())
+}
+
+func TestStrictTyping(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{StrictTyping: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(id INT PRIMARY KEY, name TEXT)")
+	require.NoError(t, err)
+
+	_, err = db.QueryRow("SELECT id FROM test WHERE name = ?", 1)
+	require.ErrorContains(t, err, "strict typing: cannot compare name of type text with ? of type bigint")
+
+	_, err = db.Plan("SELECT id FROM test WHERE name = ?", 1)
+	require.Error(t, err)
+
+	_, err = db.Plan("SELECT id FROM test WHERE name = ?", "a")
+	require.NoError(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Exec("SET strict_typing = off")
+	require.NoError(t, err)
+	_, err = conn.Plan("SELECT id FROM test WHERE name = ?", 1)
+	require.NoError(t, err)
+
+	err = conn.Exec("SET strict_typing TO DEFAULT")
+	require.NoError(t, err)
+	_, err = conn.Plan("SELECT id FROM test WHERE name = ?", 1)
+	require.Error(t, err)
+}
+
+func TestCreateFunction(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := chai.Open(filepath.Join(dir, "testdb"))
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE FUNCTION area(w, h) RETURNS (w * h);
+		CREATE FUNCTION volume(w, h, d) RETURNS (area(w, h) * d);
+	`)
+	require.NoError(t, err)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	// ensure functions are loaded properly
+	db, err = chai.Open(filepath.Join(dir, "testdb"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err := db.QueryRow("SELECT volume(2, 3, ?)", 4)
+	require.NoError(t, err)
+	var v int
+	require.NoError(t, r.Scan(&v))
+	require.Equal(t, 24, v)
+}
+
+func TestCaseSensitiveLike(t *testing.T) {
+	tests := []struct {
+		caseSensitive bool
+		query         string
+		want          int
+	}{
+		{false, "SELECT COUNT(*) FROM test WHERE name LIKE 'é%'", 2},
+		{false, "SELECT COUNT(*) FROM test WHERE name NOT LIKE 'É%'", 1},
+		{false, "SELECT COUNT(*) FROM test WHERE 'ABC' LIKE 'abc'", 3},
+		{true, "SELECT COUNT(*) FROM test WHERE name LIKE 'é%'", 1},
+		{true, "SELECT COUNT(*) FROM test WHERE name NOT LIKE 'É%'", 2},
+		{true, "SELECT COUNT(*) FROM test WHERE 'ABC' LIKE 'abc'", 0},
+		{true, "SELECT COUNT(*) FROM test WHERE name LIKE '_T%'", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v/%s", test.caseSensitive, test.query), func(t *testing.T) {
+			db, err := chai.OpenWith(":memory:", &chai.Options{CaseSensitiveLike: test.caseSensitive})
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE test(id INTEGER PRIMARY KEY, name TEXT);
+				INSERT INTO test (id, name) VALUES (1, 'été'), (2, 'ÉTÉ'), (3, 'hiver');
+			`)
+			require.NoError(t, err)
+
+			var n int
+			r, err := db.QueryRow(test.query)
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&n))
+			require.Equal(t, test.want, n)
+		})
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "testdb")
+
+	keys := map[string][]byte{
+		"users.ssn": []byte("0123456789abcdef"),
+	}
+	var requested []string
+	provider := func(keyID string) ([]byte, error) {
+		requested = append(requested, keyID)
+		if keyID == "email-key" {
+			return []byte("fedcba9876543210fedcba9876543210"), nil
+		}
+		return nil, nil
+	}
+
+	db, err := chai.OpenWith(path, &chai.Options{EncryptionKeys: keys, KeyProvider: provider})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE users(
+			id INTEGER PRIMARY KEY,
+			ssn TEXT ENCRYPTED,
+			email TEXT ENCRYPTED WITH KEY 'email-key' ALLOW INDEX UNIQUE,
+			age INTEGER
+		);
+		INSERT INTO users (id, ssn, email, age) VALUES (1, '123-45-6789', 'a@example.com', 30), (2, NULL, 'b@example.com', 40);
+	`)
+	require.NoError(t, err)
+
+	var ssn, email string
+	r, err := db.QueryRow("SELECT ssn, email FROM users WHERE email = 'a@example.com'")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&ssn, &email))
+	require.Equal(t, "123-45-6789", ssn)
+	require.Equal(t, "a@example.com", email)
+	// keys are requested once
+	require.Equal(t, []string{"email-key"}, requested)
+
+	// the unique index stores encrypted values
+	err = db.Exec("INSERT INTO users (id, email) VALUES (3, 'a@example.com')")
+	require.True(t, chai.IsAlreadyExistsError(err))
+	err = db.Exec("UPDATE users SET email = 'c@example.com' WHERE id = 1")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO users (id, email) VALUES (3, 'a@example.com')")
+	require.NoError(t, err)
+
+	// encrypted columns can't be indexed without ALLOW INDEX, nor be part of the primary key
+	err = db.Exec("CREATE INDEX ON users(ssn)")
+	require.ErrorContains(t, err, `cannot index encrypted column "ssn"`)
+	err = db.Exec("CREATE TABLE test(a TEXT ENCRYPTED PRIMARY KEY)")
+	require.ErrorContains(t, err, `encrypted column "a" cannot be part of the primary key`)
+
+	require.NoError(t, db.Close())
+
+	// without the keys, only the other columns can be read
+	db, err = chai.Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var age int
+	r, err = db.QueryRow("SELECT age FROM users WHERE id = 1")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&age))
+	require.Equal(t, 30, age)
+
+	r, err = db.QueryRow("SELECT ssn FROM users WHERE id = 2")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&ssn))
+	require.Equal(t, "", ssn)
+
+	_, err = db.QueryRow("SELECT ssn FROM users WHERE id = 1")
+	require.ErrorIs(t, err, chai.ErrEncryptionKeyUnavailable)
+	err = db.Exec("INSERT INTO users (id, ssn) VALUES (4, 'x')")
+	require.ErrorIs(t, err, chai.ErrEncryptionKeyUnavailable)
+
+	// rows can be deleted without the keys
+	err = db.Exec("DELETE FROM users WHERE id = 3")
+	require.NoError(t, err)
+}
+
+func TestTableStorageStats(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT, c TEXT UNIQUE);
+		CREATE INDEX test_b_idx ON test(b);
+		CREATE TABLE other(a INTEGER);
+		INSERT INTO other (a) VALUES (1);
+	`)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		err = db.Exec("INSERT INTO test (a, b, c) VALUES (?, ?, ?)", i, strings.Repeat("b", 100), fmt.Sprintf("c%d", i))
+		require.NoError(t, err)
+	}
+
+	stats, err := db.TableStorageStats("test")
+	require.NoError(t, err)
+	require.Equal(t, "test", stats.Table)
+	require.EqualValues(t, 100, stats.Rows)
+	require.Greater(t, stats.AvgRowSize, 100.0)
+	require.Len(t, stats.Indexes, 2)
+	require.Equal(t, "test_b_idx", stats.Indexes[0].Name)
+	require.Equal(t, "test_c_idx", stats.Indexes[1].Name)
+	for _, idx := range stats.Indexes {
+		require.EqualValues(t, 100, idx.Entries)
+		require.Positive(t, idx.Size)
+	}
+
+	// the rows are not on disk yet
+	require.Zero(t, stats.DiskSize)
+	require.Zero(t, stats.CompressionRatio)
+
+	flush := func() {
+		err := db.DB.Engine.(*kv.PebbleEngine).DB().Flush()
+		require.NoError(t, err)
+	}
+	flush()
+
+	stats, err = db.TableStorageStats("test")
+	require.NoError(t, err)
+	require.Positive(t, stats.DiskSize)
+	// the repeated texts are compressed
+	require.Greater(t, stats.CompressionRatio, 1.0)
+	require.Positive(t, stats.Indexes[0].DiskSize)
+
+	// keep the deleted rows visible to a transaction,
+	// to prevent compactions from removing the tombstones
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+	tx, err := conn.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = db.Exec("DELETE FROM test WHERE a < 10")
+	require.NoError(t, err)
+	flush()
+
+	stats, err = db.TableStorageStats("test")
+	require.NoError(t, err)
+	require.EqualValues(t, 90, stats.Rows)
+	require.Positive(t, stats.Tombstones)
+	require.EqualValues(t, 90, stats.Indexes[0].Entries)
+
+	_, err = db.TableStorageStats("unknown")
+	require.True(t, chai.IsNotFoundError(err))
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snapshot")
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+		CREATE SEQUENCE seq;
+		CREATE TABLE ids(id INTEGER);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+		INSERT INTO ids VALUES (NEXT VALUE FOR seq);
+	`)
+	require.NoError(t, err)
+
+	err = db.SaveSnapshot(path)
+	require.NoError(t, err)
+
+	// changes made after the snapshot are not saved
+	err = db.Exec(`INSERT INTO test (a, b) VALUES (4, 'd')`)
+	require.NoError(t, err)
+
+	other, err := chai.OpenSnapshot(path, nil)
+	require.NoError(t, err)
+	defer other.Close()
+
+	r, err := other.QueryRow(`SELECT COUNT(*) AS n FROM test`)
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 3}`)
+
+	r, err = other.QueryRow(`SELECT a FROM test WHERE b = 'c'`)
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 3}`)
+
+	report, err := other.Check(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, report.Problems)
+
+	// the loaded database can be modified and saved again
+	err = other.Exec(`INSERT INTO test (a, b) VALUES (5, 'e')`)
+	require.NoError(t, err)
+	err = other.Exec(`INSERT INTO ids VALUES (NEXT VALUE FOR seq)`)
+	require.NoError(t, err)
+	r, err = other.QueryRow(`SELECT MAX(id) AS n FROM ids`)
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, r.Scan(&n))
+	require.Greater(t, n, 1)
+
+	err = other.SaveSnapshot(path)
+	require.NoError(t, err)
+
+	// snapshots of on-disk databases can be loaded too
+	disk, err := chai.Open(filepath.Join(dir, "db"))
+	require.NoError(t, err)
+	defer disk.Close()
+	err = disk.Exec(`CREATE TABLE foo(a INTEGER); INSERT INTO foo (a) VALUES (1)`)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	err = disk.WriteSnapshot(&buf)
+	require.NoError(t, err)
+
+	diskPath := filepath.Join(dir, "disk.snapshot")
+	require.NoError(t, os.WriteFile(diskPath, buf.Bytes(), 0600))
+	fromDisk, err := chai.OpenSnapshot(diskPath, nil)
+	require.NoError(t, err)
+	r, err = fromDisk.QueryRow(`SELECT a FROM foo`)
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 1}`)
+	require.NoError(t, fromDisk.Close())
+
+	reloaded, err := chai.OpenSnapshot(path, nil)
+	require.NoError(t, err)
+	r, err = reloaded.QueryRow(`SELECT COUNT(*) AS n FROM test`)
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 4}`)
+	require.NoError(t, reloaded.Close())
+
+	t.Run("invalid", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		for name, content := range map[string][]byte{
+			"empty":     nil,
+			"header":    []byte("not a snapshot"),
+			"truncated": data[:len(data)/2],
+			"checksum":  append(bytes.Clone(data[:len(data)-1]), data[len(data)-1]+1),
+			"trailing":  append(bytes.Clone(data), 0),
+		} {
+			p := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(p, content, 0600))
+
+			_, err = chai.OpenSnapshot(p, nil)
+			require.ErrorIs(t, err, chai.ErrInvalidSnapshot, name)
+		}
+	})
+}
+
+func TestCompact(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+	`)
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, fmt.Sprintf("%d-%s", i, strings.Repeat("b", 100)))
+		require.NoError(t, err)
+	}
+
+	stats, err := db.Compact()
+	require.NoError(t, err)
+	require.Positive(t, stats.SizeBefore)
+	require.Positive(t, stats.SizeAfter)
+
+	err = db.Exec("DROP TABLE test")
+	require.NoError(t, err)
+
+	// the space of the dropped table is reclaimed
+	before := stats.SizeAfter
+	stats, err = db.Compact()
+	require.NoError(t, err)
+	require.Less(t, stats.SizeAfter, before/10)
+}
+
+func TestPlan(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b INTEGER, c TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+	`)
+	require.NoError(t, err)
+
+	explain := func(q string, args ...any) string {
+		t.Helper()
+
+		r, err := db.QueryRow("EXPLAIN "+q, args...)
+		require.NoError(t, err)
+		var s string
+		err = r.ScanColumn("plan", &s)
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("select", func(t *testing.T) {
+		q := "SELECT c FROM test WHERE b = ? AND c > 'x' ORDER BY a DESC LIMIT 10"
+		n, err := db.Plan(q, 1)
+		require.NoError(t, err)
+		require.Equal(t, explain(q, 1), n.String())
+
+		var types []string
+		n.Walk(func(n *plan.Node) bool {
+			types = append(types, n.Type)
+			return true
+		})
+		require.Equal(t, []string{plan.IndexScan, plan.RowsFilter, plan.RowsProject, plan.RowsSort, plan.RowsTake}, types)
+
+		require.Equal(t, plan.RowsTake, n.Type)
+		require.Equal(t, []string{"10"}, n.Exprs)
+
+		sort := n.Input
+		require.True(t, sort.Reverse)
+		require.Equal(t, []string{"a"}, sort.Exprs)
+
+		scan := sort.Input.Input.Input
+		require.Nil(t, scan.Input)
+		require.Equal(t, "test_b_idx", scan.Index)
+		require.Len(t, scan.Ranges, 1)
+		require.Equal(t, []string{`c > "x"`}, sort.Input.Input.Exprs)
+
+		// the sort reads the rows twice
+		require.Equal(t, scan.Cost*2, sort.Cost)
+		require.Equal(t, sort.Cost, n.Cost)
+	})
+
+	t.Run("costs", func(t *testing.T) {
+		pk, err := db.Plan("SELECT * FROM test WHERE a = 1")
+		require.NoError(t, err)
+		idx, err := db.Plan("SELECT * FROM test WHERE b = 1")
+		require.NoError(t, err)
+		full, err := db.Plan("SELECT * FROM test WHERE c = 'x'")
+		require.NoError(t, err)
+
+		require.Less(t, pk.Cost, idx.Cost)
+		require.Less(t, idx.Cost, full.Cost)
+	})
+
+	t.Run("union", func(t *testing.T) {
+		n, err := db.Plan("SELECT a FROM test WHERE a = 1 UNION ALL SELECT a FROM test WHERE b = 2")
+		require.NoError(t, err)
+		require.Equal(t, plan.StreamConcat, n.Type)
+		require.Len(t, n.Streams, 2)
+		require.Equal(t, n.Streams[0].Cost+n.Streams[1].Cost, n.Cost)
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		n, err := db.Plan("INSERT INTO test (a, b) VALUES (1, 2), (3, 4) ON CONFLICT DO NOTHING")
+		require.NoError(t, err)
+
+		var tables, indexes []string
+		n.Walk(func(n *plan.Node) bool {
+			if n.Table != "" {
+				tables = append(tables, n.Type+" "+n.Table)
+			}
+			if n.Index != "" {
+				indexes = append(indexes, n.Type+" "+n.Index)
+			}
+			return true
+		})
+		require.Equal(t, []string{"table.Validate test", "table.Insert test"}, tables)
+		require.Equal(t, []string{"index.Insert test_b_idx"}, indexes)
+	})
+
+	t.Run("walk stops", func(t *testing.T) {
+		n, err := db.Plan("SELECT * FROM test WHERE c = 'x'")
+		require.NoError(t, err)
+
+		var count int
+		n.Walk(func(n *plan.Node) bool {
+			count++
+			return n.Type != plan.TableScan
+		})
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := db.Plan("CREATE TABLE foo(a INT)")
+		require.Error(t, err)
+		_, err = db.Plan("SELECT 1; SELECT 2")
+		require.Error(t, err)
+		_, err = db.Plan("SELECT * FROM unknown")
+		require.Error(t, err)
+	})
+}
+
+func benchmarkTable(b *testing.B, rows int) (*chai.Connection, *chai.Statement) {
+	b.Helper()
+
+	db, err := chai.Open(":memory:")
+	require.NoError(b, err)
+	b.Cleanup(func() { db.Close() })
+
+	conn, err := db.Connect()
+	require.NoError(b, err)
+	b.Cleanup(func() { conn.Close() })
+
+	require.NoError(b, conn.Exec("CREATE TABLE test(id BIGINT PRIMARY KEY, age INT, score DOUBLE, name TEXT, active BOOL, status TEXT DEFAULT 'new')"))
+	stmt, err := conn.Prepare("INSERT INTO test (id, age, score, name, active) VALUES (?, ?, ?, ?, ?)")
+	require.NoError(b, err)
+
+	for i := 0; i < rows; i++ {
+		require.NoError(b, stmt.Exec(i, 1000+i%100, 1.5, "the quick brown fox jumps over the lazy dog", true))
+	}
+
+	return conn, stmt
+}
+
+func BenchmarkInsert(b *testing.B) {
+	_, stmt := benchmarkTable(b, 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := stmt.Exec(i, 1000+i%100, 1.5, "the quick brown fox jumps over the lazy dog", true)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	conn, _ := benchmarkTable(b, 1000)
+
+	for _, q := range []string{
+		"SELECT * FROM test",
+		"SELECT id FROM test WHERE age = 1050",
+	} {
+		b.Run(q, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				res, err := conn.Query(q)
+				if err != nil {
+					b.Fatal(err)
+				}
+				err = res.Iterate(func(r *chai.Row) error {
+					return r.Row.Iterate(func(string, types.Value) error { return nil })
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				res.Close()
+			}
+		})
+	}
+}
+
+func TestPinPlan(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b INTEGER, c INTEGER);
+		CREATE INDEX test_b_idx ON test(b);
+		INSERT INTO test (a, b, c) VALUES (1, 1, 1), (2, 2, 2), (3, 3, 3);
+	`)
+	require.NoError(t, err)
+
+	explain := func(q string, args ...any) string {
+		t.Helper()
+
+		r, err := db.QueryRow("EXPLAIN "+q, args...)
+		require.NoError(t, err)
+		var s string
+		err = r.ScanColumn("plan", &s)
+		require.NoError(t, err)
+		return s
+	}
+
+	q := "SELECT a FROM test WHERE b = ? AND c = ?"
+
+	b, err := db.PinPlan(q, 1, 1)
+	require.NoError(t, err)
+	fp, err := chai.PlanFingerprint("select a from test where b = 10 and c = 20")
+	require.NoError(t, err)
+	require.Equal(t, fp, b.Fingerprint)
+	require.Equal(t, q, b.Query)
+	require.Equal(t, map[string]string{"test": "test_b_idx"}, b.Indexes)
+	require.Equal(t, explain(q, 1, 1), b.Plan)
+
+	_, err = db.PinPlan("DELETE FROM test WHERE c > 10")
+	require.NoError(t, err)
+
+	// a better index doesn't change the plan of the statement
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX test_c_idx ON test(c)"))
+	require.Equal(t, `index.Scan("test_b_idx", [{"min": (2), "exact": true}]) | rows.Filter(c = 2) | rows.Project(a)`, explain("SELECT a FROM test WHERE b = 2 AND c = 2"))
+	require.Equal(t, `index.Scan("test_c_idx", [{"min": (2), "exact": true}]) | rows.Filter(b = 2) | rows.Project(a)`, explain("SELECT a FROM test WHERE c = 2 AND b = 2"))
+
+	// prepared statements use it as well
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+	stmt, err := conn.Prepare(q)
+	require.NoError(t, err)
+	r, err := stmt.QueryRow(2, 2)
+	require.NoError(t, err)
+	var a int
+	require.NoError(t, r.Scan(&a))
+	require.Equal(t, 2, a)
+
+	// optimizer hints take precedence
+	require.Equal(t, `index.Scan("test_c_idx", [{"min": (2), "exact": true}]) | rows.Filter(b = 2) | rows.Project(a)`, explain("SELECT /*+ INDEX(test test_c_idx) */ a FROM test WHERE b = 2 AND c = 2"))
+
+	// tables read without index
+	require.Equal(t, `table.Scan("test") | rows.Filter(c > 20) | index.Delete("test_b_idx") | index.Delete("test_c_idx") | table.Delete('test') | discard()`, explain("DELETE FROM test WHERE c > 20"))
+
+	l, err := db.PlanBaselines()
 	require.NoError(t, err)
+	require.Len(t, l, 2)
 
-	err = tx.Exec(`
-			CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT NOT NULL);
-			INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar')
-		`)
+	// the plan is planned as usual once its index is dropped
+	require.NoError(t, db.Exec("DROP INDEX test_b_idx"))
+	require.Equal(t, `index.Scan("test_c_idx", [{"min": (2), "exact": true}]) | rows.Filter(b = 2) | rows.Project(a)`, explain("SELECT a FROM test WHERE b = 2 AND c = 2"))
+
+	// the baselines can be read with SQL
+	r, err = db.QueryRow("SELECT COUNT(*) FROM __chai_plan_baselines WHERE fingerprint = ?", fp)
 	require.NoError(t, err)
-	require.NoError(t, tx.Commit())
+	var n int
+	require.NoError(t, r.Scan(&n))
+	require.Equal(t, 1, n)
 
-	t.Run("Should return the first row", func(t *testing.T) {
-		var a int
-		var b string
+	require.NoError(t, db.UnpinPlan(fp))
+	require.True(t, chai.IsNotFoundError(db.UnpinPlan(fp)))
+	l, err = db.PlanBaselines()
+	require.NoError(t, err)
+	require.Len(t, l, 1)
+	require.Equal(t, "DELETE FROM test WHERE c > 10", l[0].Query)
 
-		r, err := db.QueryRow("SELECT * FROM test")
-		require.NoError(t, err)
-		err = r.Scan(&a, &b)
+	// baselines survive restarts
+	dir := t.TempDir()
+	disk, err := chai.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, disk.Exec("CREATE TABLE test(a INT PRIMARY KEY, b INT); CREATE INDEX test_b_idx ON test(b)"))
+	_, err = disk.PinPlan("SELECT * FROM test WHERE b > 1")
+	require.NoError(t, err)
+	require.NoError(t, disk.Close())
+
+	disk, err = chai.Open(dir)
+	require.NoError(t, err)
+	defer disk.Close()
+	l, err = disk.PlanBaselines()
+	require.NoError(t, err)
+	require.Len(t, l, 1)
+	require.Equal(t, map[string]string{"test": "test_b_idx"}, l[0].Indexes)
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := db.PinPlan("INSERT INTO test (a) VALUES (10)")
+		require.Error(t, err)
+		_, err = db.PinPlan("SELECT 1")
+		require.EqualError(t, err, "the plan doesn't read any table")
+		_, err = db.PinPlan("SELECT a FROM test WHERE c = 1 UNION SELECT a FROM test WHERE a = 1")
+		require.EqualError(t, err, "table test is read in different ways by the plan")
+
+		require.NoError(t, db.Exec("CREATE USER alice WITH PASSWORD 'secret'; GRANT SELECT ON test TO alice"))
+		alice, err := db.ConnectAs("alice", "secret")
 		require.NoError(t, err)
-		require.Equal(t, 1, a)
-		require.Equal(t, "foo", b)
+		defer alice.Close()
+		_, err = alice.PinPlan("SELECT * FROM test")
+		var perr *chai.PermissionDeniedError
+		require.ErrorAs(t, err, &perr)
+		require.ErrorAs(t, alice.UnpinPlan("x"), &perr)
+	})
+}
+
+func TestPreparedStatementSchemaChange(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b INT)"))
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	insert, err := conn.Prepare("INSERT INTO test (a, b) VALUES (?, ?)")
+	require.NoError(t, err)
+	sel, err := conn.Prepare("SELECT * FROM test WHERE a = ?")
+	require.NoError(t, err)
+
+	require.NoError(t, insert.Exec(1, 10))
+
+	// change the schema from another connection
+	other, err := db.Connect()
+	require.NoError(t, err)
+	defer other.Close()
+	require.NoError(t, other.Exec("CREATE INDEX test_b ON test(b)"))
+	require.NoError(t, other.Exec("ALTER TABLE test ADD COLUMN c INT DEFAULT 100"))
+
+	// the prepared statements use the new schema
+	require.NoError(t, insert.Exec(2, 20))
+
+	r, err := sel.QueryRow(2)
+	require.NoError(t, err)
+	var a, b, c int
+	require.NoError(t, r.Scan(&a, &b, &c))
+	require.Equal(t, []int{2, 20, 100}, []int{a, b, c})
+
+	// the new index was updated by the insert
+	report, err := db.Check(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, report.Problems)
+
+	r, err = conn.QueryRow("SELECT a FROM test WHERE b = 20")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&a))
+	require.Equal(t, 2, a)
+
+	// schema changes made by the transaction running the statement
+	// are taken into account before it commits
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec("DROP INDEX test_b"))
+	require.NoError(t, tx.Exec("CREATE UNIQUE INDEX test_b ON test(b)"))
+	require.Error(t, insert.Exec(3, 20))
+	require.NoError(t, tx.Rollback())
+
+	require.NoError(t, insert.Exec(3, 20))
+
+	// the statements fail once the table is dropped
+	require.NoError(t, other.Exec("DROP TABLE test"))
+	require.Error(t, insert.Exec(4, 40))
+	_, err = sel.QueryRow(1)
+	require.Error(t, err)
+}
+
+func TestErrors(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{StrictTyping: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b TEXT NOT NULL, c INT CHECK (c > 0))"))
+	require.NoError(t, db.Exec("INSERT INTO test (a, b, c) VALUES (1, 'x', 1)"))
+
+	tests := []struct {
+		q        string
+		code     chai.ErrorCode
+		category error
+	}{
+		{"INSERT INTO test (a, b, c) VALUES (1, 'y', 1)", chai.CodeUniqueViolation, chai.ConstraintViolation},
+		{"INSERT INTO test (a, c) VALUES (2, 1)", chai.CodeNotNullViolation, chai.ConstraintViolation},
+		{"INSERT INTO test (a, b, c) VALUES (2, 'y', -1)", chai.CodeCheckViolation, chai.ConstraintViolation},
+		{"SELECT * FROM test WHERE b = 1", chai.CodeDatatypeMismatch, chai.TypeMismatch},
+		{"INSERT INTO test (a, b, c) VALUES ('foo', 'y', 1)", chai.CodeDatatypeMismatch, chai.TypeMismatch},
+		{"SELECT * FROM unknown", chai.CodeUndefinedObject, nil},
+		{"CREATE TABLE test(a INT)", chai.CodeDuplicateObject, nil},
+		{"SELECT * FROM", chai.CodeSyntaxError, chai.SyntaxError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.q, func(t *testing.T) {
+			err := db.Exec(test.q)
+			require.Error(t, err)
+
+			var e *chai.Error
+			require.True(t, errors.As(err, &e))
+			require.Equal(t, test.code, e.Code)
+			require.Equal(t, e.Message, err.Error())
+			if test.category != nil {
+				require.ErrorIs(t, err, test.category)
+			}
+			for _, c := range []error{chai.SyntaxError, chai.ConstraintViolation, chai.TypeMismatch} {
+				if c != test.category {
+					require.False(t, errors.Is(err, c))
+				}
+			}
+		})
+	}
+
+	t.Run("position", func(t *testing.T) {
+		q := "SELECT a\nFROM test WHERE a = = 1"
+		err := db.Exec(q)
+
+		var e *chai.Error
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, chai.CodeSyntaxError, e.Code)
+		require.Equal(t, "=", e.Token)
+		require.Equal(t, strings.LastIndex(q, "="), e.Offset)
+
+		err = db.Exec("SELECT a FROM test WHERE")
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, "EOF", e.Token)
+		require.NotEmpty(t, e.Hint)
+	})
 
+	t.Run("constraint", func(t *testing.T) {
+		require.NoError(t, db.Exec("CREATE TABLE uq(a INT, b INT, CONSTRAINT uq_a_b UNIQUE (a, b))"))
+		require.NoError(t, db.Exec("INSERT INTO uq (a, b) VALUES (1, 1)"))
+
+		err := db.Exec("INSERT INTO uq (a, b) VALUES (1, 1)")
+		var e *chai.Error
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, chai.CodeUniqueViolation, e.Code)
+		require.Equal(t, "uq_a_b", e.Constraint)
+		require.Contains(t, e.Message, `"uq_a_b"`)
+
+		err = db.Exec("INSERT INTO test (a, b, c) VALUES (2, 'y', -1)")
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, "test_check", e.Constraint)
+	})
+
+	t.Run("wrapped errors", func(t *testing.T) {
 		conn, err := db.Connect()
 		require.NoError(t, err)
 		defer conn.Close()
 
-		tx, err := conn.Begin(false)
+		tx1, err := conn.Begin(true)
 		require.NoError(t, err)
-		defer tx.Rollback()
+		require.NoError(t, tx1.Exec("UPDATE test SET c = 2"))
 
-		r, err = tx.QueryRow("SELECT * FROM test")
+		err = db.Exec("UPDATE test SET c = 3")
 		require.NoError(t, err)
-		err = r.Scan(&a, &b)
+
+		err = tx1.Commit()
+		require.ErrorIs(t, err, chai.ErrTxConflict)
+		var e *chai.Error
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, chai.CodeSerializationFailure, e.Code)
+
+		// the errors of the callbacks are returned as is
+		stop := errors.New("stop")
+		res, err := conn.Query("SELECT * FROM test")
 		require.NoError(t, err)
-		require.Equal(t, 1, a)
-		require.Equal(t, "foo", b)
+		defer res.Close()
+		err = res.Iterate(func(r *chai.Row) error { return stop })
+		require.Equal(t, stop, err)
 	})
+}
 
-	t.Run("Should return an error if no row", func(t *testing.T) {
-		r, err := db.QueryRow("SELECT * FROM test WHERE a > 100")
-		require.True(t, chai.IsNotFoundError(err))
-		require.Nil(t, r)
+func TestQueryLogger(t *testing.T) {
+	var logs []*chai.QueryLog
+	open := func(t *testing.T, opts chai.Options) *chai.DB {
+		logs = nil
+		opts.QueryLogger = func(l *chai.QueryLog) {
+			logs = append(logs, l)
+		}
+		db, err := chai.OpenWith(":memory:", &opts)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		require.NoError(t, db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b TEXT)"))
+		require.NoError(t, db.Exec("INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c')"))
+		return db
+	}
+
+	t.Run("every query", func(t *testing.T) {
+		db := open(t, chai.Options{})
+		require.Len(t, logs, 2)
+		require.Equal(t, "CREATE TABLE test(a INT PRIMARY KEY, b TEXT)", logs[0].SQL)
+		require.Nil(t, logs[0].Plan)
+		require.EqualValues(t, 3, logs[1].RowsAffected)
+		require.NotNil(t, logs[1].Plan)
 
 		conn, err := db.Connect()
 		require.NoError(t, err)
 		defer conn.Close()
 
-		tx, err := conn.Begin(false)
+		res, err := conn.Query("SELECT * FROM test WHERE a > ?", 1)
 		require.NoError(t, err)
-		defer tx.Rollback()
-		r, err = tx.QueryRow("SELECT * FROM test WHERE a > 100")
-		require.True(t, chai.IsNotFoundError(err))
-		require.Nil(t, r)
+		require.NoError(t, res.Iterate(func(r *chai.Row) error { return nil }))
+		require.Len(t, logs, 2)
+		require.NoError(t, res.Close())
+		require.Len(t, logs, 3)
+
+		l := logs[2]
+		require.Equal(t, "SELECT * FROM test WHERE a > ?", l.SQL)
+		require.Equal(t, []any{1}, l.Params)
+		require.EqualValues(t, 2, l.RowsReturned)
+		require.Zero(t, l.RowsAffected)
+		require.Positive(t, l.Duration)
+		require.NoError(t, l.Err)
+		require.Equal(t, `table.Scan("test", [{"min": (1), "exclusive": true}])`, l.Plan.String())
+
+		_, err = db.QueryRow("SELECT * FROM test WHERE a = 10")
+		require.Error(t, err)
+		require.Len(t, logs, 4)
+		require.EqualValues(t, 0, logs[3].RowsReturned)
+
+		err = db.Exec("INSERT INTO test (a, b) VALUES (1, 'a')")
+		require.Error(t, err)
+		require.Len(t, logs, 5)
+		require.ErrorIs(t, logs[4].Err, chai.ConstraintViolation)
+	})
+
+	t.Run("redacted params", func(t *testing.T) {
+		db := open(t, chai.Options{RedactQueryParams: true})
+
+		require.NoError(t, db.Exec("UPDATE test SET b = ? WHERE a = ?", "secret", 1))
+		require.Len(t, logs, 3)
+		require.Nil(t, logs[2].Params)
+		require.EqualValues(t, 1, logs[2].RowsAffected)
+	})
+
+	t.Run("slow queries", func(t *testing.T) {
+		db := open(t, chai.Options{SlowQueryThreshold: 50 * time.Millisecond})
+		require.Empty(t, logs)
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		res, err := conn.Query("SELECT * FROM test")
+		require.NoError(t, err)
+		time.Sleep(60 * time.Millisecond)
+		require.NoError(t, res.Close())
+		require.Len(t, logs, 1)
+		require.Equal(t, "SELECT * FROM test", logs[0].SQL)
+		require.GreaterOrEqual(t, logs[0].Duration, 50*time.Millisecond)
 	})
 }
 
-func TestIterateDeepCopy(t *testing.T) {
-	db, err := chai.Open(":memory:")
+func TestMetrics(t *testing.T) {
+	db, err := chai.Open(t.TempDir())
 	require.NoError(t, err)
 	defer db.Close()
 
-	err = db.Exec(`
-	CREATE TABLE foo (
-		a integer primary key,
-		b text not null
-	);
+	m0 := db.Metrics()
+	require.Zero(t, m0.Queries)
 
-	INSERT INTO foo (a, b) VALUES
-		(1, 'sample text 1'),
-		(2, 'sample text 2');
-	`)
+	require.NoError(t, db.Exec("CREATE TABLE test(a INT PRIMARY KEY)"))
+	require.NoError(t, db.Exec("INSERT INTO test (a) VALUES (1), (2), (3)"))
+	_, err = db.QueryRow("SELECT * FROM test WHERE a > 1")
 	require.NoError(t, err)
+	require.Error(t, db.Exec("INSERT INTO test (a) VALUES (1)"))
 
 	conn, err := db.Connect()
 	require.NoError(t, err)
 	defer conn.Close()
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec("UPDATE test SET a = 10 WHERE a = 3"))
+	require.NoError(t, db.Exec("DELETE FROM test WHERE a = 3"))
+	require.ErrorIs(t, tx.Commit(), chai.ErrTxConflict)
 
-	res, err := conn.Query(`SELECT * FROM foo ORDER BY a DESC`)
+	m := db.Metrics()
+	// the statements are prepared in their own read-only transactions
+	require.Equal(t, m.TxStarted, m.TxCommitted+m.TxRolledBack)
+	require.EqualValues(t, 3, m.TxCommitted-m0.TxCommitted)
+	require.EqualValues(t, 1, m.TxConflicts)
+	require.EqualValues(t, 6, m.Queries)
+	require.EqualValues(t, 1, m.QueryErrors)
+	require.EqualValues(t, 5, m.RowsWritten)
+	require.Positive(t, m.RowsRead)
+	require.EqualValues(t, 6, m.QueryLatency.Count)
+	require.Len(t, m.QueryLatency.Counts, len(m.QueryLatency.Bounds)+1)
+	require.Positive(t, m.QueryLatency.Sum)
+	require.Positive(t, m.Engine.DiskSize)
+
+	var v map[string]any
+	require.NoError(t, json.Unmarshal([]byte(db.Expvar().String()), &v))
+	require.EqualValues(t, 6, v["Queries"])
+}
+
+func TestUsers(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := chai.Open(dir)
 	require.NoError(t, err)
 
-	type item struct {
-		A int
-		B string
+	err = db.Exec(`
+		CREATE TABLE t(a INT PRIMARY KEY, b INT);
+		CREATE USER alice WITH PASSWORD 'secret';
+		GRANT INSERT ON t TO alice;
+	`)
+	require.NoError(t, err)
+
+	_, err = db.ConnectAs("alice", "wrong")
+	require.ErrorIs(t, err, chai.ErrAuthenticationFailed)
+	require.ErrorIs(t, err, chai.PermissionDenied)
+	_, err = db.ConnectAs("bob", "secret")
+	require.ErrorIs(t, err, chai.ErrAuthenticationFailed)
+
+	alice, err := db.ConnectAs("alice", "secret")
+	require.NoError(t, err)
+
+	denied := func(q string) {
+		t.Helper()
+
+		err := alice.Exec(q)
+		require.ErrorIs(t, err, chai.PermissionDenied, q)
+		var perr *chai.PermissionDeniedError
+		require.ErrorAs(t, err, &perr)
+		require.Equal(t, "alice", perr.User)
 	}
 
-	var items []*item
-	err = res.Iterate(func(r *chai.Row) error {
-		var i item
-		err := r.StructScan(&i)
-		require.NoError(t, err)
+	require.NoError(t, alice.Exec("INSERT INTO t VALUES (1, 1)"))
+	denied("SELECT * FROM t")
 
-		items = append(items, &i)
-		return nil
-	})
+	require.NoError(t, alice.Close())
+	require.NoError(t, db.Close())
+
+	// the users and their privileges are stored in the catalog
+	db, err = chai.Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.ConnectAs("alice", "wrong")
+	require.ErrorIs(t, err, chai.ErrAuthenticationFailed)
+	alice, err = db.ConnectAs("alice", "secret")
 	require.NoError(t, err)
+	defer alice.Close()
 
-	require.Equal(t, len(items), 2)
-	require.Equal(t, &item{A: 2, B: "sample text 2"}, items[0])
-	require.Equal(t, &item{A: 1, B: "sample text 1"}, items[1])
+	require.NoError(t, alice.Exec("INSERT INTO t VALUES (2, 2)"))
+	denied("SELECT * FROM t")
+
+	require.NoError(t, db.Exec("ALTER USER alice WITH PASSWORD 'other'"))
+	_, err = db.ConnectAs("alice", "secret")
+	require.ErrorIs(t, err, chai.ErrAuthenticationFailed)
+	other, err := db.ConnectAs("alice", "other")
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+
+	require.NoError(t, db.Exec("DROP USER alice"))
+	_, err = db.ConnectAs("alice", "other")
+	require.ErrorIs(t, err, chai.ErrAuthenticationFailed)
 }
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"io"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -79,6 +81,18 @@ func (c *connector) Close() error {
 	return err
 }
 
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+)
+
 // conn represents a connection to the Chai database.
 // It implements the database/sql/driver.Conn interface.
 type conn struct {
@@ -93,7 +107,7 @@ func (c *conn) Prepare(q string) (driver.Stmt, error) {
 
 // PrepareContext returns a prepared statement, bound to this connection.
 func (c *conn) PrepareContext(ctx context.Context, q string) (driver.Stmt, error) {
-	s, err := c.conn.Prepare(q)
+	s, err := c.conn.WithContext(ctx).Prepare(q)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +117,70 @@ func (c *conn) PrepareContext(ctx context.Context, q string) (driver.Stmt, error
 	}, nil
 }
 
+// ExecContext runs a query that doesn't return rows, such
+// as an INSERT or UPDATE, without preparing it first.
+func (c *conn) ExecContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	st, err := c.conn.WithContext(ctx).Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return exec(st, args)
+}
+
+// QueryContext runs a query that may return rows, such as a
+// SELECT, without preparing it first.
+func (c *conn) QueryContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res, err := c.conn.WithContext(ctx).Query(q, namedValueToParams(args)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRows(res)
+}
+
+// Ping verifies that the database is still open.
+func (c *conn) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if c.conn.Conn.IsClosed() {
+		return driver.ErrBadConn
+	}
+
+	return nil
+}
+
+// IsValid reports whether the connection can be reused by the pool.
+func (c *conn) IsValid() bool {
+	return !c.conn.Conn.IsClosed()
+}
+
+// CheckNamedValue accepts the arguments that can be converted to values,
+// in addition to the types supported by database/sql, such as slices
+// of float32 for vectors or arrays of 16 bytes for UUIDs.
+// The other arguments are converted by database/sql.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return driver.ErrSkip
+	}
+
+	if _, err := row.NewValue(nv.Value); err != nil {
+		return driver.ErrSkip
+	}
+
+	return nil
+}
+
 // Close closes any ongoing transaction.
 func (c *conn) Close() error {
 	return c.conn.Close()
@@ -113,7 +191,15 @@ func (c *conn) Begin() (driver.Tx, error) {
 	return c.BeginTx(context.Background(), driver.TxOptions{})
 }
 
+// ResetSession is called by the pool before the connection is reused.
+// It restores the settings changed with SET to their default value.
+// Connections with a transaction that was not closed, or whose database
+// was closed, are discarded.
 func (c *conn) ResetSession(ctx context.Context) error {
+	if c.conn.Conn.IsClosed() {
+		return driver.ErrBadConn
+	}
+
 	err := c.conn.Conn.Reset()
 	if err != nil {
 		return driver.ErrBadConn
@@ -124,10 +210,19 @@ func (c *conn) ResetSession(ctx context.Context) error {
 
 // BeginTx starts and returns a new transaction.
 // It uses the ReadOnly option to determine whether to start a read-only or read/write transaction.
-// If the Isolation option is non zero, an error is returned.
+// Transactions run with snapshot isolation: the isolation levels up to
+// sql.LevelSnapshot are supported, while sql.LevelSerializable and
+// sql.LevelLinearizable return an error.
 func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	if opts.Isolation != 0 {
-		return nil, errors.New("isolation levels are not supported")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault, sql.LevelReadUncommitted, sql.LevelReadCommitted,
+		sql.LevelWriteCommitted, sql.LevelRepeatableRead, sql.LevelSnapshot:
+	default:
+		return nil, errors.Errorf("isolation level %s is not supported", sql.IsolationLevel(opts.Isolation))
 	}
 
 	// if the ReadOnly flag is explicitly specified, create a read-only transaction,
@@ -147,7 +242,7 @@ func (s stmt) NumInput() int { return -1 }
 // Exec executes a query that doesn't return rows, such
 // as an INSERT or UPDATE.
 func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
-	return nil, errors.New("not implemented")
+	return s.ExecContext(context.Background(), valuesToNamedValues(args))
 }
 
 // ExecContext executes a query that doesn't return rows, such
@@ -159,23 +254,48 @@ func (s stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver
 	default:
 	}
 
-	return execResult{}, s.stmt.Exec(namedValueToParams(args)...)
+	return exec(s.stmt.WithContext(ctx), args)
+}
+
+// exec runs the statement and reports the number of rows it modified.
+func exec(s *chai.Statement, args []driver.NamedValue) (driver.Result, error) {
+	res, err := s.Query(namedValueToParams(args)...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = res.Iterate(func(*chai.Row) error { return nil })
+	if err != nil {
+		_ = res.Close()
+		return nil, err
+	}
+
+	err = res.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return execResult{rowsAffected: res.RowsAffected()}, nil
 }
 
-type execResult struct{}
+type execResult struct {
+	rowsAffected int64
+}
 
 // LastInsertId is not supported and returns an error.
 func (r execResult) LastInsertId() (int64, error) {
 	return 0, errors.New("not supported")
 }
 
-// RowsAffected is not supported and returns an error.
+// RowsAffected returns the number of rows inserted, updated or deleted.
 func (r execResult) RowsAffected() (int64, error) {
-	return 0, errors.New("not supported")
+	return r.rowsAffected, nil
 }
 
+// Query executes a query that may return rows, such as a
+// SELECT.
 func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
-	return nil, errors.New("not implemented")
+	return s.QueryContext(context.Background(), valuesToNamedValues(args))
 }
 
 // QueryContext executes a query that may return rows, such as a
@@ -187,7 +307,7 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 	default:
 	}
 
-	res, err := s.stmt.Query(namedValueToParams(args)...)
+	res, err := s.stmt.WithContext(ctx).Query(namedValueToParams(args)...)
 	if err != nil {
 		return nil, err
 	}
@@ -207,6 +327,16 @@ func namedValueToParams(args []driver.NamedValue) []any {
 	return params
 }
 
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	nv := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		nv[i].Ordinal = i + 1
+		nv[i].Value = arg
+	}
+
+	return nv
+}
+
 // Close does nothing.
 func (s stmt) Close() error {
 	return nil
@@ -214,12 +344,24 @@ func (s stmt) Close() error {
 
 var errStop = errors.New("stop")
 
+var (
+	_ driver.Rows                           = (*Rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*Rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*Rows)(nil)
+)
+
 type Rows struct {
 	res      *chai.Result
 	cancelFn func()
 	c        chan Row
 	wg       sync.WaitGroup
 	columns  []string
+
+	// the first row, read ahead to determine the types of the columns
+	peeked *Row
+	types  []types.Type
+	// set once all the rows were read
+	done bool
 }
 
 type Row struct {
@@ -299,11 +441,103 @@ func (rs *Rows) Close() error {
 	return rs.res.Close()
 }
 
-func (rs *Rows) Next(dest []driver.Value) error {
+// next returns the next row, or nil if there are no more rows.
+func (rs *Rows) next() *Row {
+	if r := rs.peeked; r != nil {
+		rs.peeked = nil
+		return r
+	}
+	if rs.done {
+		return nil
+	}
+
 	rs.c <- Row{}
 
 	r, ok := <-rs.c
 	if !ok {
+		rs.done = true
+		return nil
+	}
+
+	return &r
+}
+
+// columnTypes returns the types of the values of the first row.
+// The first row is read, if it wasn't already, and kept for Next.
+// If there are no rows, or if the value of a column is NULL,
+// the type of the column is types.TypeNull.
+func (rs *Rows) columnTypes() []types.Type {
+	if rs.types != nil {
+		return rs.types
+	}
+
+	rs.types = make([]types.Type, len(rs.columns))
+	for i := range rs.types {
+		rs.types[i] = types.TypeNull
+	}
+
+	r := rs.next()
+	if r == nil {
+		return rs.types
+	}
+	rs.peeked = r
+	if r.err != nil {
+		return rs.types
+	}
+
+	var i int
+	_ = r.r.Row.Iterate(func(column string, v types.Value) error {
+		if i < len(rs.types) {
+			rs.types[i] = v.Type()
+		}
+		i++
+		return nil
+	})
+
+	return rs.types
+}
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// ColumnTypeScanType returns the Go type of the values of the column
+// returned by Next. The type is determined by the value of the column
+// in the first row. It is any if there are no rows or if the value is NULL.
+func (rs *Rows) ColumnTypeScanType(index int) reflect.Type {
+	switch rs.columnTypes()[index] {
+	case types.TypeBoolean:
+		return reflect.TypeOf(false)
+	case types.TypeInteger:
+		return reflect.TypeOf(int32(0))
+	case types.TypeBigint:
+		return reflect.TypeOf(int64(0))
+	case types.TypeDouble:
+		return reflect.TypeOf(float64(0))
+	case types.TypeTimestamp, types.TypeDate:
+		return reflect.TypeOf(time.Time{})
+	case types.TypeText, types.TypeDecimal, types.TypeInterval, types.TypeUUID, types.TypeVector:
+		return reflect.TypeOf("")
+	case types.TypeBlob:
+		return reflect.TypeOf([]byte(nil))
+	}
+
+	return anyType
+}
+
+// ColumnTypeDatabaseTypeName returns the name of the type of the column,
+// such as "INTEGER" or "TEXT". The type is determined by the value of the column
+// in the first row. It is empty if there are no rows or if the value is NULL.
+func (rs *Rows) ColumnTypeDatabaseTypeName(index int) string {
+	tp := rs.columnTypes()[index]
+	if tp == types.TypeNull {
+		return ""
+	}
+
+	return strings.ToUpper(tp.String())
+}
+
+func (rs *Rows) Next(dest []driver.Value) error {
+	r := rs.next()
+	if r == nil {
 		return io.EOF
 	}
 
@@ -346,14 +580,14 @@ func (rs *Rows) Next(dest []driver.Value) error {
 				return err
 			}
 			dest[i] = d
-		case types.TypeTimestamp:
+		case types.TypeTimestamp, types.TypeDate:
 			var t time.Time
 			err = row.ScanValue(v, &t)
 			if err != nil {
 				return err
 			}
 			dest[i] = t
-		case types.TypeText:
+		case types.TypeText, types.TypeDecimal, types.TypeInterval, types.TypeUUID, types.TypeVector:
 			var s string
 			err = row.ScanValue(v, &s)
 			if err != nil {
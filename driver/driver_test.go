@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -24,7 +25,7 @@ func TestDriver(t *testing.T) {
 	res, err := db.Exec("CREATE TABLE test(a INT, b TEXT, c BOOL)")
 	require.NoError(t, err)
 	n, err := res.RowsAffected()
-	require.Error(t, err)
+	require.NoError(t, err)
 	require.EqualValues(t, 0, n)
 
 	for i := 0; i < 10; i++ {
@@ -32,6 +33,30 @@ func TestDriver(t *testing.T) {
 		require.NoError(t, err)
 	}
 
+	t.Run("RowsAffected", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		res, err := tx.Exec("UPDATE test SET b = 'bar' WHERE a > 6")
+		require.NoError(t, err)
+		n, err := res.RowsAffected()
+		require.NoError(t, err)
+		require.EqualValues(t, 3, n)
+
+		res, err = tx.Exec("DELETE FROM test WHERE a < 2")
+		require.NoError(t, err)
+		n, err = res.RowsAffected()
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+
+		res, err = tx.Exec("INSERT INTO test (a) VALUES (20), (21)")
+		require.NoError(t, err)
+		n, err = res.RowsAffected()
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+	})
+
 	t.Run("Wildcard", func(t *testing.T) {
 		rows, err := db.Query("SELECT * FROM test")
 		require.NoError(t, err)
@@ -281,3 +306,166 @@ func TestDriverWithTimeValues(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, now, tt)
 }
+
+func TestDriverContext(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, db.PingContext(ctx))
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE test(a INT PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+
+	stmt, err := db.PrepareContext(ctx, "INSERT INTO test (a, b) VALUES (?, ?)")
+	require.NoError(t, err)
+	defer stmt.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err = stmt.ExecContext(ctx, i, fmt.Sprintf("foo%d", i))
+		require.NoError(t, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = db.ExecContext(canceled, "INSERT INTO test (a, b) VALUES (10, 'foo10')")
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = stmt.ExecContext(canceled, 10, "foo10")
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = db.QueryContext(canceled, "SELECT * FROM test")
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = db.BeginTx(canceled, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	t.Run("Cancel while iterating", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		rows, err := db.QueryContext(ctx, "SELECT a FROM test")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		require.True(t, rows.Next())
+		cancel()
+		for rows.Next() {
+		}
+		require.ErrorIs(t, rows.Err(), context.Canceled)
+	})
+
+	t.Run("Isolation levels", func(t *testing.T) {
+		for _, level := range []sql.IsolationLevel{sql.LevelDefault, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSnapshot} {
+			tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: level})
+			require.NoError(t, err)
+			require.NoError(t, tx.Rollback())
+		}
+
+		_, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+		require.EqualError(t, err, "isolation level Serializable is not supported")
+	})
+
+	t.Run("Session reset", func(t *testing.T) {
+		db.SetMaxOpenConns(1)
+		defer db.SetMaxOpenConns(0)
+
+		c, err := db.Conn(ctx)
+		require.NoError(t, err)
+
+		// the setting applies to the whole session
+		_, err = c.ExecContext(ctx, "SET search_path TO foo")
+		require.NoError(t, err)
+		_, err = c.ExecContext(ctx, "CREATE TABLE bar(a INT)")
+		require.EqualError(t, err, "schema foo does not exist")
+		require.NoError(t, c.Close())
+
+		// but not to the next use of the connection
+		_, err = db.ExecContext(ctx, "CREATE TABLE bar(a INT)")
+		require.NoError(t, err)
+	})
+}
+
+func TestDriverArgs(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b TEXT, v VECTOR(3), u UUID)")
+	require.NoError(t, err)
+
+	u := [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	_, err = db.Exec("INSERT INTO test (a, b, v, u) VALUES ($1, $2, $3, $4)", 1, "foo", []float32{1, 2, 3}, u)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO test (a, b) VALUES ($a, $b)", sql.Named("b", "bar"), sql.Named("a", 2))
+	require.NoError(t, err)
+
+	var b string
+	err = db.QueryRow("SELECT b FROM test WHERE a = $2 OR b = $1", "none", 2).Scan(&b)
+	require.NoError(t, err)
+	require.Equal(t, "bar", b)
+
+	var v, uu string
+	err = db.QueryRow("SELECT v, u FROM test WHERE a = ?", 1).Scan(&v, &uu)
+	require.NoError(t, err)
+	require.Equal(t, "[1, 2, 3]", v)
+	require.Equal(t, "01020304-0506-0708-090a-0b0c0d0e0f10", uu)
+
+	_, err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", 3, struct{}{})
+	require.Error(t, err)
+}
+
+func TestDriverColumnTypes(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b BIGINT, c TEXT, d DOUBLE, e BOOL, f TIMESTAMP, g BLOB);
+		INSERT INTO test (a, b, c, d, e, f) VALUES (1, 2, 'c', 1.5, true, '2024-01-01');
+		INSERT INTO test (a) VALUES (2);
+	`)
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT * FROM test ORDER BY a")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	cts, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	var names []string
+	var scanTypes []reflect.Type
+	for _, ct := range cts {
+		names = append(names, ct.DatabaseTypeName())
+		scanTypes = append(scanTypes, ct.ScanType())
+	}
+	require.Equal(t, []string{"INTEGER", "BIGINT", "TEXT", "DOUBLE", "BOOLEAN", "TIMESTAMP", ""}, names)
+	require.Equal(t, []reflect.Type{
+		reflect.TypeOf(int32(0)),
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf(""),
+		reflect.TypeOf(float64(0)),
+		reflect.TypeOf(false),
+		reflect.TypeOf(time.Time{}),
+		reflect.TypeOf((*any)(nil)).Elem(),
+	}, scanTypes)
+
+	// the first row, read to determine the types, is still returned
+	var count int
+	for rows.Next() {
+		count++
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, 2, count)
+
+	rows, err = db.Query("SELECT * FROM test WHERE a > 10")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	cts, err = rows.ColumnTypes()
+	require.NoError(t, err)
+	require.Len(t, cts, 7)
+	require.Equal(t, "", cts[0].DatabaseTypeName())
+	require.False(t, rows.Next())
+	require.NoError(t, rows.Err())
+}
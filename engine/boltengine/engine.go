@@ -0,0 +1,383 @@
+// Package boltengine provides an engine storing the data of a Chai
+// database in a single file with bbolt, for the deployments which
+// prefer a pure-Go, single-file store without background compactions
+// to the default engine.
+//
+//	ng, err := boltengine.Open("data.db", nil)
+//	if err != nil {
+//		return err
+//	}
+//	db, err := chai.OpenEngine(ng, nil)
+//
+// The temporary data of the queries, used by sorts for example,
+// is kept in memory.
+//
+// bbolt maps the file in memory and cannot grow the mapping while
+// read transactions are open: commits which need a larger mapping wait until
+// the read-only transactions are closed, including the ones used by the
+// sessions of the same goroutine and the versions retained by
+// Options.HistoryRetention, which can deadlock. The mapping reserves
+// address space without growing the file, it is large by default
+// and can be set with Options.InitialMmapSize to a value larger
+// than the expected size of the database.
+package boltengine
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/cockroachdb/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// all the keys are stored in a single bucket.
+var bucketName = []byte("chai")
+
+// Options configures the engine.
+type Options struct {
+	// LockTimeout is the maximum amount of time to wait
+	// for another process to release the file.
+	// If zero, Open returns engine.ErrDatabaseLocked immediately.
+	LockTimeout time.Duration
+
+	// NoSync skips the fsync of the file after each commit.
+	// Committed data can be lost if the machine crashes,
+	// but the file remains consistent.
+	NoSync bool
+
+	// InitialMmapSize is the initial size, in bytes, of the memory map
+	// of the file. See the package documentation.
+	// If zero, it defaults to 64 GiB on 64-bit platforms
+	// and 512 MiB on others.
+	InitialMmapSize int
+}
+
+func defaultMmapSize() int {
+	if strconv.IntSize == 64 {
+		return 1 << 36
+	}
+
+	return 1 << 29
+}
+
+// Engine is an engine.Engine storing its data in a bbolt file.
+type Engine struct {
+	db *bolt.DB
+
+	// write sets of recently committed optimistic sessions.
+	log commitLog
+}
+
+var _ engine.Engine = (*Engine)(nil)
+
+// Open opens the bbolt file at the given path, creating it if it doesn't exist.
+// If opts is nil, default options are used.
+func Open(path string, opts *Options) (*Engine, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	timeout := opts.LockTimeout
+	if timeout <= 0 {
+		// bbolt waits forever if the timeout is zero
+		timeout = time.Nanosecond
+	}
+
+	mmapSize := opts.InitialMmapSize
+	if mmapSize <= 0 {
+		mmapSize = defaultMmapSize()
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout:         timeout,
+		NoSync:          opts.NoSync,
+		InitialMmapSize: mmapSize,
+	})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, errors.WithStack(engine.ErrDatabaseLocked)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.WithStack(err)
+	}
+
+	return &Engine{db: db}, nil
+}
+
+// DB returns the underlying bbolt database.
+func (e *Engine) DB() *bolt.DB {
+	return e.db
+}
+
+// Close the bbolt file.
+func (e *Engine) Close() error {
+	return errors.WithStack(e.db.Close())
+}
+
+// Recover does nothing, bbolt files remain consistent after a crash.
+func (e *Engine) Recover() error {
+	return nil
+}
+
+// CleanupTransientNamespaces does nothing,
+// transient sessions are kept in memory.
+func (e *Engine) CleanupTransientNamespaces() error {
+	return nil
+}
+
+// SpanStats returns zero values, bbolt doesn't track
+// the size of ranges of keys.
+func (e *Engine) SpanStats(start, end []byte) (engine.SpanStats, error) {
+	return engine.SpanStats{}, nil
+}
+
+// Compact does nothing, bbolt reuses the pages freed by deletions.
+func (e *Engine) Compact(start, end []byte) error {
+	return nil
+}
+
+// Flush does nothing, commits are written to the file directly.
+func (e *Engine) Flush() error {
+	return nil
+}
+
+// NewSnapshotSession returns a read-only session reading
+// the data committed when it is created.
+func (e *Engine) NewSnapshotSession() engine.Session {
+	rtx, err := e.beginRead()
+	if err != nil {
+		return &errSession{err: err}
+	}
+
+	return &snapshotSession{rtx: rtx}
+}
+
+// NewVersion returns a view of the data committed when it is created.
+func (e *Engine) NewVersion() engine.Version {
+	rtx, err := e.beginRead()
+	if err != nil {
+		return &version{err: err}
+	}
+
+	return &version{rtx: rtx}
+}
+
+// NewOptimisticSession returns a session reading the data committed
+// when it is created and buffering its writes in memory until commit.
+func (e *Engine) NewOptimisticSession() engine.Session {
+	e.log.Lock()
+	defer e.log.Unlock()
+
+	// the read transaction is started with the lock held
+	// so that no commit can be missed by the conflict detection.
+	rtx, err := e.beginRead()
+	if err != nil {
+		return &errSession{err: err}
+	}
+
+	start := uint64(rtx.tx.ID())
+	if e.log.active == nil {
+		e.log.active = make(map[uint64]int)
+	}
+	e.log.active[start]++
+
+	return &optimisticSession{
+		ng:     e,
+		rtx:    rtx,
+		start:  start,
+		writes: newMemtable(),
+	}
+}
+
+// NewTransientSession returns a session whose data is kept in memory.
+// maxMemory is ignored.
+func (e *Engine) NewTransientSession(maxMemory int) engine.Session {
+	return &transientSession{
+		writes: newMemtable(),
+	}
+}
+
+func (e *Engine) beginRead() (*readTx, error) {
+	tx, err := e.db.Begin(false)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &readTx{tx: tx, refs: 1}, nil
+}
+
+// commit writes the pending writes of a session in a bbolt transaction
+// and returns the id of the transaction.
+func (e *Engine) commit(writes *memtable) (uint64, error) {
+	var id uint64
+	err := e.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+
+		for _, w := range writes.writes {
+			var err error
+			if w.deleted {
+				err = b.Delete(w.key)
+			} else {
+				err = b.Put(w.key, encodeStored(w.orig, w.value))
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		id = uint64(tx.ID())
+		return nil
+	})
+
+	return id, errors.WithStack(err)
+}
+
+// commitLog keeps track of the keys written by recently committed
+// optimistic sessions, in order to detect write-write conflicts.
+type commitLog struct {
+	sync.Mutex
+
+	// number of open sessions per id of the transaction they read.
+	active map[uint64]int
+	// write sets of the commits that happened after
+	// the oldest open session started.
+	entries []commitLogEntry
+}
+
+type commitLogEntry struct {
+	id   uint64
+	keys map[string]struct{}
+}
+
+// conflicts returns whether any of the keys written by a session
+// that started at the given id was written by a later commit.
+// It must be called with the lock held.
+func (l *commitLog) conflicts(start uint64, writes *memtable) bool {
+	for _, e := range l.entries {
+		if e.id <= start {
+			continue
+		}
+
+		for _, w := range writes.writes {
+			if _, ok := e.keys[string(w.key)]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// release unregisters a session that started at the given id
+// and drops the entries that can no longer conflict with any open session.
+// It must be called with the lock held.
+func (l *commitLog) release(start uint64) {
+	l.active[start]--
+	if l.active[start] <= 0 {
+		delete(l.active, start)
+	}
+
+	if len(l.active) == 0 {
+		l.entries = nil
+		return
+	}
+
+	min := uint64(0)
+	for s := range l.active {
+		if min == 0 || s < min {
+			min = s
+		}
+	}
+
+	i := sort.Search(len(l.entries), func(i int) bool {
+		return l.entries[i].id > min
+	})
+	l.entries = l.entries[i:]
+}
+
+// readTx is a read-only bbolt transaction shared by the sessions
+// of a version and by the iterators of a session.
+// bbolt transactions are not safe for concurrent use, mu
+// protects the transaction and its cursors.
+type readTx struct {
+	mu   sync.Mutex
+	tx   *bolt.Tx
+	refs int
+}
+
+func (r *readTx) acquire() {
+	r.mu.Lock()
+	r.refs++
+	r.mu.Unlock()
+}
+
+// release the transaction once it is not used anymore.
+func (r *readTx) release() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refs--
+	if r.refs > 0 {
+		return nil
+	}
+
+	return errors.WithStack(r.tx.Rollback())
+}
+
+// get returns a copy of the value of a key.
+func (r *readTx) get(k []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	raw := r.tx.Bucket(bucketName).Get(sortKey(nil, k))
+	if raw == nil {
+		return nil, errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	_, v, err := decodeStored(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), v...), nil
+}
+
+func (r *readTx) exists(k []byte) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.tx.Bucket(bucketName).Get(sortKey(nil, k)) != nil
+}
+
+type version struct {
+	rtx *readTx
+	err error
+}
+
+func (v *version) NewSession() engine.Session {
+	if v.err != nil {
+		return &errSession{err: v.err}
+	}
+
+	v.rtx.acquire()
+	return &snapshotSession{rtx: v.rtx}
+}
+
+func (v *version) Release() error {
+	if v.err != nil {
+		return nil
+	}
+
+	return v.rtx.release()
+}
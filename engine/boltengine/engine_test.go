@@ -0,0 +1,51 @@
+package boltengine_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/engine/boltengine"
+	"github.com/chaisql/chai/engine/enginetest"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine(t *testing.T) {
+	enginetest.TestSuite(t, func(t testing.TB) engine.Engine {
+		ng, err := boltengine.Open(filepath.Join(t.TempDir(), "test.db"), &boltengine.Options{
+			NoSync: true,
+		})
+		require.NoError(t, err)
+		return ng
+	})
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	ng, err := boltengine.Open(path, nil)
+	require.NoError(t, err)
+
+	k := encoding.EncodeText(encoding.EncodeInt(nil, 10), "hello")
+	s := ng.NewOptimisticSession()
+	require.NoError(t, s.Put(k, []byte("world")))
+	require.NoError(t, s.Commit())
+
+	// the file is locked while the engine is open
+	_, err = boltengine.Open(path, nil)
+	require.ErrorIs(t, err, engine.ErrDatabaseLocked)
+
+	require.NoError(t, ng.Close())
+
+	ng, err = boltengine.Open(path, nil)
+	require.NoError(t, err)
+	defer ng.Close()
+
+	s = ng.NewSnapshotSession()
+	defer s.Close()
+
+	v, err := s.Get(k)
+	require.NoError(t, err)
+	require.Equal(t, []byte("world"), v)
+}
@@ -0,0 +1,346 @@
+package boltengine
+
+import (
+	"bytes"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltCursor iterates over the keys of a read transaction
+// between two sort keys.
+type boltCursor struct {
+	rtx          *readTx
+	c            *bolt.Cursor
+	lower, upper []byte
+	// current sort key and stored value, nil if the cursor is not valid
+	k, v []byte
+}
+
+func newBoltCursor(rtx *readTx, lower, upper []byte) *boltCursor {
+	rtx.mu.Lock()
+	defer rtx.mu.Unlock()
+
+	rtx.refs++
+	return &boltCursor{
+		rtx:   rtx,
+		c:     rtx.tx.Bucket(bucketName).Cursor(),
+		lower: lower,
+		upper: upper,
+	}
+}
+
+func (c *boltCursor) valid() bool {
+	return c.k != nil
+}
+
+func (c *boltCursor) first() {
+	if c.lower != nil {
+		c.seekGE(c.lower)
+		return
+	}
+
+	c.rtx.mu.Lock()
+	defer c.rtx.mu.Unlock()
+	c.set(c.c.First())
+}
+
+func (c *boltCursor) last() {
+	if c.upper != nil {
+		c.seekLT(c.upper)
+		return
+	}
+
+	c.rtx.mu.Lock()
+	defer c.rtx.mu.Unlock()
+	c.set(c.c.Last())
+}
+
+func (c *boltCursor) seekGE(sk []byte) {
+	if c.lower != nil && bytes.Compare(sk, c.lower) < 0 {
+		sk = c.lower
+	}
+
+	c.rtx.mu.Lock()
+	defer c.rtx.mu.Unlock()
+	c.set(c.c.Seek(sk))
+}
+
+func (c *boltCursor) seekLT(sk []byte) {
+	if c.upper != nil && bytes.Compare(sk, c.upper) > 0 {
+		sk = c.upper
+	}
+
+	c.rtx.mu.Lock()
+	defer c.rtx.mu.Unlock()
+
+	if k, _ := c.c.Seek(sk); k == nil {
+		c.set(c.c.Last())
+	} else {
+		c.set(c.c.Prev())
+	}
+}
+
+func (c *boltCursor) next() {
+	if c.k == nil {
+		return
+	}
+
+	c.rtx.mu.Lock()
+	defer c.rtx.mu.Unlock()
+	c.set(c.c.Next())
+}
+
+func (c *boltCursor) prev() {
+	if c.k == nil {
+		return
+	}
+
+	c.rtx.mu.Lock()
+	defer c.rtx.mu.Unlock()
+	c.set(c.c.Prev())
+}
+
+// set the current key, if it is within the bounds.
+func (c *boltCursor) set(k, v []byte) {
+	if k != nil && (c.lower != nil && bytes.Compare(k, c.lower) < 0 ||
+		c.upper != nil && bytes.Compare(k, c.upper) >= 0) {
+		k, v = nil, nil
+	}
+
+	c.k, c.v = k, v
+}
+
+func (c *boltCursor) close() error {
+	c.k, c.v = nil, nil
+	return c.rtx.release()
+}
+
+// mergeIterator iterates over the keys of a read transaction and
+// a list of pending writes, the latter taking precedence.
+// Both sources are ordered by sort key.
+type mergeIterator struct {
+	// nil for transient sessions
+	base   *boltCursor
+	writes []*entry
+	// position in writes
+	pos int
+	// true when iterating backward
+	reverse bool
+	// current pending write, nil if the current key
+	// comes from the transaction
+	cur   *entry
+	valid bool
+	// key and value of the current key of the transaction
+	k, v []byte
+	err  error
+}
+
+func newMergeIterator(base *boltCursor, writes []*entry) *mergeIterator {
+	return &mergeIterator{
+		base:   base,
+		writes: writes,
+	}
+}
+
+func (it *mergeIterator) baseValid() bool {
+	return it.base != nil && it.base.valid()
+}
+
+func (it *mergeIterator) First() bool {
+	it.reverse = false
+	if it.base != nil {
+		it.base.first()
+	}
+	it.pos = 0
+	return it.settle()
+}
+
+func (it *mergeIterator) Last() bool {
+	it.reverse = true
+	if it.base != nil {
+		it.base.last()
+	}
+	it.pos = len(it.writes) - 1
+	return it.settle()
+}
+
+func (it *mergeIterator) SeekGE(k []byte) bool {
+	sk := sortKey(nil, k)
+
+	it.reverse = false
+	if it.base != nil {
+		it.base.seekGE(sk)
+	}
+	it.pos = sort.Search(len(it.writes), func(i int) bool {
+		return bytes.Compare(it.writes[i].key, sk) >= 0
+	})
+	return it.settle()
+}
+
+func (it *mergeIterator) Next() bool {
+	if !it.valid {
+		return false
+	}
+
+	if it.reverse {
+		// change direction: position both sources after the current key
+		sk := append([]byte(nil), it.sortKey()...)
+		it.reverse = false
+		if it.base != nil {
+			it.base.seekGE(sk)
+			if it.base.valid() && bytes.Equal(it.base.k, sk) {
+				it.base.next()
+			}
+		}
+		it.pos = sort.Search(len(it.writes), func(i int) bool {
+			return bytes.Compare(it.writes[i].key, sk) > 0
+		})
+		return it.settle()
+	}
+
+	it.advance()
+	return it.settle()
+}
+
+func (it *mergeIterator) Prev() bool {
+	if !it.valid {
+		return false
+	}
+
+	if !it.reverse {
+		// change direction: position both sources before the current key
+		sk := append([]byte(nil), it.sortKey()...)
+		it.reverse = true
+		if it.base != nil {
+			it.base.seekLT(sk)
+		}
+		it.pos = sort.Search(len(it.writes), func(i int) bool {
+			return bytes.Compare(it.writes[i].key, sk) >= 0
+		}) - 1
+		return it.settle()
+	}
+
+	it.advance()
+	return it.settle()
+}
+
+// sortKey returns the sort key of the current key.
+func (it *mergeIterator) sortKey() []byte {
+	if it.cur != nil {
+		return it.cur.key
+	}
+
+	return it.base.k
+}
+
+// advance moves past the current key in the current direction.
+func (it *mergeIterator) advance() {
+	if it.cur == nil {
+		it.step()
+		return
+	}
+
+	if it.baseValid() && bytes.Equal(it.base.k, it.cur.key) {
+		it.step()
+	}
+	if it.reverse {
+		it.pos--
+	} else {
+		it.pos++
+	}
+}
+
+func (it *mergeIterator) step() {
+	if it.reverse {
+		it.base.prev()
+	} else {
+		it.base.next()
+	}
+}
+
+// settle selects the current key among the two sources,
+// skipping deleted keys.
+func (it *mergeIterator) settle() bool {
+	for {
+		it.cur = nil
+		it.valid = false
+
+		var w *entry
+		if it.pos >= 0 && it.pos < len(it.writes) {
+			w = it.writes[it.pos]
+		}
+
+		if it.baseValid() {
+			cmp := -1
+			if w != nil {
+				cmp = bytes.Compare(it.base.k, w.key)
+				if it.reverse {
+					cmp = -cmp
+				}
+			}
+			if cmp < 0 {
+				return it.setBase()
+			}
+		}
+
+		if w == nil {
+			return false
+		}
+
+		it.cur = w
+		if !w.deleted {
+			it.valid = true
+			return true
+		}
+
+		// skip deleted keys
+		it.advance()
+	}
+}
+
+// setBase makes the current key of the transaction the current key.
+func (it *mergeIterator) setBase() bool {
+	k, v, err := decodeStored(it.base.v)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.k, it.v = k, v
+	it.valid = true
+	return true
+}
+
+func (it *mergeIterator) Valid() bool {
+	return it.valid
+}
+
+func (it *mergeIterator) Key() []byte {
+	if it.cur != nil {
+		return it.cur.orig
+	}
+
+	return it.k
+}
+
+func (it *mergeIterator) Value() ([]byte, error) {
+	if it.cur != nil {
+		return it.cur.value, nil
+	}
+
+	return it.v, nil
+}
+
+func (it *mergeIterator) Error() error {
+	return it.err
+}
+
+func (it *mergeIterator) Close() error {
+	it.valid = false
+	if it.base == nil {
+		return nil
+	}
+
+	return it.base.close()
+}
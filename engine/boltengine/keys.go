@@ -0,0 +1,138 @@
+package boltengine
+
+import (
+	"encoding/binary"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+// bbolt orders the keys bytewise, which is not the order of the keys
+// encoded by Chai: texts and blobs are prefixed by their length,
+// the values of DESC columns are stored as ASC values and arrays
+// are compared element by element.
+// Keys are stored in a form where the bytewise order follows the order
+// of the values: texts and blobs are escaped and terminated, the elements
+// of arrays and objects are preceded by a marker and followed by
+// a terminator, and the values of DESC columns are complemented.
+// The original key is stored in the value.
+
+// sortKey appends to dst the form of k stored in bbolt.
+func sortKey(dst, k []byte) []byte {
+	for len(k) > 0 {
+		var n int
+		dst, n = appendSortValue(dst, k)
+		if n == 0 {
+			// not a value, bounds can end with arbitrary bytes
+			return append(dst, k...)
+		}
+		k = k[n:]
+	}
+
+	return dst
+}
+
+// appendSortValue appends the sortable form of the first value of b
+// and returns the number of bytes read, or zero if b doesn't
+// start with a valid value.
+func appendSortValue(dst, b []byte) ([]byte, int) {
+	t := b[0]
+	// a type without value sorts before the values of the type
+	if len(b) == 1 {
+		return append(dst, t), 1
+	}
+
+	desc := t > 128
+	if desc {
+		t = 255 - t
+	}
+
+	switch {
+	case t >= encoding.IntSmallValue && t < encoding.Uint8Value,
+		t == encoding.NullValue, t == encoding.FalseValue, t == encoding.TrueValue:
+		return append(dst, b[0]), 1
+	}
+
+	start := len(dst)
+	dst = append(dst, b[0])
+
+	var n int
+	switch t {
+	case encoding.Int64Value, encoding.Uint64Value, encoding.Float64Value,
+		encoding.Int32Value, encoding.Uint32Value,
+		encoding.Int16Value, encoding.Uint16Value,
+		encoding.Int8Value, encoding.Uint8Value:
+		// fixed size numbers are stored big-endian
+		n = encoding.Skip(b[:1])
+		if n > len(b) {
+			return dst[:start], 0
+		}
+		dst = append(dst, b[1:n]...)
+	case encoding.TextValue, encoding.BlobValue:
+		l, m := binary.Uvarint(b[1:])
+		if m <= 0 || uint64(len(b)-1-m) < l {
+			return dst[:start], 0
+		}
+		n = 1 + m + int(l)
+		for _, c := range b[1+m : n] {
+			if c == 0 {
+				dst = append(dst, 0, 0xFF)
+			} else {
+				dst = append(dst, c)
+			}
+		}
+		dst = append(dst, 0, 1)
+	case encoding.ArrayValue, encoding.ObjectValue:
+		l, m := binary.Uvarint(b[1:])
+		if m <= 0 {
+			return dst[:start], 0
+		}
+		n = 1 + m
+		if t == encoding.ObjectValue {
+			// fields and values
+			l *= 2
+		}
+		for i := uint64(0); i < l; i++ {
+			if n >= len(b) {
+				return dst[:start], 0
+			}
+
+			var vn int
+			dst = append(dst, 1)
+			dst, vn = appendSortValue(dst, b[n:])
+			if vn == 0 {
+				return dst[:start], 0
+			}
+			n += vn
+		}
+		dst = append(dst, 0)
+	default:
+		return dst[:start], 0
+	}
+
+	if desc {
+		for i := start + 1; i < len(dst); i++ {
+			dst[i] = ^dst[i]
+		}
+	}
+
+	return dst, n
+}
+
+// encodeStored returns the value stored in bbolt for a key and its value.
+func encodeStored(k, v []byte) []byte {
+	buf := make([]byte, 0, binary.MaxVarintLen64+len(k)+len(v))
+	buf = binary.AppendUvarint(buf, uint64(len(k)))
+	buf = append(buf, k...)
+	return append(buf, v...)
+}
+
+// decodeStored returns the key and the value stored in a bbolt value.
+func decodeStored(raw []byte) (k, v []byte, err error) {
+	l, n := binary.Uvarint(raw)
+	if n <= 0 || uint64(len(raw)-n) < l {
+		return nil, nil, errors.New("corrupted value: invalid key length")
+	}
+
+	return raw[n : n+int(l)], raw[n+int(l):], nil
+}
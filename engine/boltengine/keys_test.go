@@ -0,0 +1,102 @@
+package boltengine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func desc(b []byte) []byte {
+	b, _ = encoding.Desc(b, len(b))
+	return b
+}
+
+func array(values ...[]byte) []byte {
+	b := []byte{encoding.ArrayValue}
+	b = binary.AppendUvarint(b, uint64(len(values)))
+	for _, v := range values {
+		b = append(b, v...)
+	}
+	return b
+}
+
+func TestSortKey(t *testing.T) {
+	values := [][]byte{
+		encoding.EncodeNull(nil),
+		encoding.EncodeBoolean(nil, false),
+		encoding.EncodeBoolean(nil, true),
+		encoding.EncodeInt(nil, math.MinInt64),
+		encoding.EncodeInt(nil, -100000),
+		encoding.EncodeInt(nil, -200),
+		encoding.EncodeInt(nil, -1),
+		encoding.EncodeInt(nil, 0),
+		encoding.EncodeInt(nil, 10),
+		encoding.EncodeInt(nil, 200),
+		encoding.EncodeInt(nil, 100000),
+		encoding.EncodeInt(nil, math.MaxInt64),
+		encoding.EncodeFloat64(nil, -1.5),
+		encoding.EncodeFloat64(nil, 0),
+		encoding.EncodeFloat64(nil, 1.5),
+		encoding.EncodeText(nil, ""),
+		encoding.EncodeText(nil, "a"),
+		encoding.EncodeText(nil, "a\x00"),
+		encoding.EncodeText(nil, "a\x00b"),
+		encoding.EncodeText(nil, "ab"),
+		encoding.EncodeText(nil, "b"),
+		encoding.EncodeBlob(nil, []byte{0}),
+		encoding.EncodeBlob(nil, []byte{0, 0}),
+		encoding.EncodeBlob(nil, []byte{1}),
+		desc(encoding.EncodeInt(nil, 100000)),
+		desc(encoding.EncodeInt(nil, -3)),
+		desc(encoding.EncodeText(nil, "b")),
+		desc(encoding.EncodeText(nil, "ab")),
+		desc(encoding.EncodeText(nil, "a")),
+		array(),
+		array(encoding.EncodeInt(nil, 1)),
+		array(encoding.EncodeInt(nil, 1), encoding.EncodeText(nil, "a")),
+		array(encoding.EncodeInt(nil, 1), encoding.EncodeText(nil, "b")),
+		array(encoding.EncodeInt(nil, 2)),
+	}
+
+	// keys made of a namespace followed by one or two values
+	var keys [][]byte
+	for _, v := range values {
+		k := append(encoding.EncodeInt(nil, 10), v...)
+		keys = append(keys, k)
+		for _, v2 := range []int64{-1, 1} {
+			keys = append(keys, encoding.EncodeInt(append([]byte(nil), k...), v2))
+		}
+	}
+
+	for _, a := range keys {
+		for _, b := range keys {
+			want := sign(encoding.Compare(a, b))
+			got := bytes.Compare(sortKey(nil, a), sortKey(nil, b))
+			require.Equal(t, want, got, "%x %x", a, b)
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestEncodeStored(t *testing.T) {
+	k, v, err := decodeStored(encodeStored([]byte("key"), []byte("value")))
+	require.NoError(t, err)
+	require.Equal(t, []byte("key"), k)
+	require.Equal(t, []byte("value"), v)
+
+	_, _, err = decodeStored([]byte{10, 1})
+	require.Error(t, err)
+}
@@ -0,0 +1,496 @@
+package boltengine
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// entry is a key written by a session and not committed yet.
+type entry struct {
+	// key in its sortable form
+	key []byte
+	// key as given by the session
+	orig    []byte
+	value   []byte
+	deleted bool
+}
+
+// memtable holds the writes of a session, sorted by key.
+type memtable struct {
+	writes []*entry
+	index  map[string]*entry
+	// true when writes is read by an iterator
+	// and must be copied before being modified.
+	shared bool
+}
+
+func newMemtable() *memtable {
+	return &memtable{
+		index: make(map[string]*entry),
+	}
+}
+
+func (m *memtable) get(k []byte) (*entry, bool) {
+	e, ok := m.index[string(k)]
+	return e, ok
+}
+
+// set records a write, keeping the writes sorted by key.
+func (m *memtable) set(k, v []byte, deleted bool) {
+	e := entry{
+		orig:    append([]byte(nil), k...),
+		deleted: deleted,
+	}
+	e.key = sortKey(nil, e.orig)
+	if !deleted {
+		e.value = append([]byte(nil), v...)
+	}
+
+	m.unshare()
+
+	i := m.search(e.key)
+	if i < len(m.writes) && bytes.Equal(m.writes[i].key, e.key) {
+		// replace the entry instead of modifying it, it might be read by an iterator.
+		m.writes[i] = &e
+	} else {
+		m.writes = append(m.writes, nil)
+		copy(m.writes[i+1:], m.writes[i:])
+		m.writes[i] = &e
+	}
+
+	m.index[string(e.orig)] = &e
+}
+
+// remove the writes whose sort keys are between i and j (exclusive).
+func (m *memtable) remove(i, j int) {
+	m.unshare()
+
+	for _, e := range m.writes[i:j] {
+		delete(m.index, string(e.orig))
+	}
+	m.writes = append(m.writes[:i], m.writes[j:]...)
+}
+
+func (m *memtable) unshare() {
+	if m.shared {
+		m.writes = append([]*entry(nil), m.writes...)
+		m.shared = false
+	}
+}
+
+// search returns the position of the first write whose sort key is >= sk.
+func (m *memtable) search(sk []byte) int {
+	// fast path for keys written in order
+	if n := len(m.writes); n > 0 && bytes.Compare(m.writes[n-1].key, sk) < 0 {
+		return n
+	}
+
+	return sort.Search(len(m.writes), func(i int) bool {
+		return bytes.Compare(m.writes[i].key, sk) >= 0
+	})
+}
+
+// span returns the writes between the bounds of the options.
+// The returned slice is not modified by later writes.
+func (m *memtable) span(lower, upper []byte) []*entry {
+	lo, hi := 0, len(m.writes)
+	if lower != nil {
+		lo = m.search(lower)
+	}
+	if upper != nil {
+		hi = m.search(upper)
+	}
+
+	m.shared = true
+	return m.writes[lo:hi:hi]
+}
+
+// sortBounds returns the sortable form of the bounds of the options.
+func sortBounds(opts *engine.IterOptions) (lower, upper []byte) {
+	if opts == nil {
+		return nil, nil
+	}
+	if opts.LowerBound != nil {
+		lower = sortKey(nil, opts.LowerBound)
+	}
+	if opts.UpperBound != nil {
+		upper = sortKey(nil, opts.UpperBound)
+	}
+
+	return lower, upper
+}
+
+// snapshotSession is a read-only session.
+type snapshotSession struct {
+	rtx    *readTx
+	closed bool
+}
+
+func (s *snapshotSession) Commit() error {
+	return errors.New("cannot commit in read-only mode")
+}
+
+func (s *snapshotSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+
+	return s.rtx.release()
+}
+
+func (s *snapshotSession) Insert(k, v []byte) error {
+	return errors.New("cannot insert in read-only mode")
+}
+
+func (s *snapshotSession) Put(k, v []byte) error {
+	return errors.New("cannot put in read-only mode")
+}
+
+func (s *snapshotSession) Get(k []byte) ([]byte, error) {
+	return s.rtx.get(k)
+}
+
+func (s *snapshotSession) Exists(k []byte) (bool, error) {
+	return s.rtx.exists(k), nil
+}
+
+func (s *snapshotSession) Delete(k []byte) error {
+	return errors.New("cannot delete in read-only mode")
+}
+
+func (s *snapshotSession) DeleteRange(start []byte, end []byte) error {
+	return errors.New("cannot delete range in read-only mode")
+}
+
+func (s *snapshotSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	lower, upper := sortBounds(opts)
+
+	return newMergeIterator(newBoltCursor(s.rtx, lower, upper), nil), nil
+}
+
+// optimisticSession reads a read-only transaction and keeps
+// its writes in memory until they are committed in a single
+// read-write transaction.
+type optimisticSession struct {
+	ng *Engine
+	// nil once the session is committed or closed
+	rtx *readTx
+	// id of the transaction read by the session
+	start  uint64
+	writes *memtable
+	closed bool
+}
+
+// Commit checks for conflicts and atomically writes the changes to the file.
+// If the session conflicts with another one, it returns engine.ErrTxConflict
+// and the session can only be closed.
+func (s *optimisticSession) Commit() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	if s.rtx == nil {
+		return errors.New("cannot commit twice")
+	}
+
+	// the read transaction is released first,
+	// the commit might need to wait for all of them to be closed.
+	err := s.releaseTx()
+	if err != nil {
+		return err
+	}
+
+	l := &s.ng.log
+	l.Lock()
+	defer l.Unlock()
+
+	if len(s.writes.writes) > 0 {
+		if l.conflicts(s.start, s.writes) {
+			return errors.WithStack(engine.ErrTxConflict)
+		}
+
+		id, err := s.ng.commit(s.writes)
+		if err != nil {
+			return err
+		}
+
+		// only keep track of the write set if another session
+		// could conflict with it.
+		if len(l.active) > 1 || l.active[s.start] > 1 {
+			keys := make(map[string]struct{}, len(s.writes.writes))
+			for _, w := range s.writes.writes {
+				keys[string(w.key)] = struct{}{}
+			}
+			l.entries = append(l.entries, commitLogEntry{id: id, keys: keys})
+		}
+	}
+
+	s.closeLocked()
+	return nil
+}
+
+// Close discards the pending writes and releases the read transaction.
+func (s *optimisticSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+
+	err := s.releaseTx()
+
+	s.ng.log.Lock()
+	defer s.ng.log.Unlock()
+	s.closeLocked()
+
+	return err
+}
+
+func (s *optimisticSession) releaseTx() error {
+	if s.rtx == nil {
+		return nil
+	}
+
+	err := s.rtx.release()
+	s.rtx = nil
+	return err
+}
+
+func (s *optimisticSession) closeLocked() {
+	s.closed = true
+	s.ng.log.release(s.start)
+	s.writes = nil
+}
+
+func (s *optimisticSession) checkOpen() error {
+	if s.rtx == nil {
+		return errors.New("session is closed")
+	}
+
+	return nil
+}
+
+// Insert inserts a key-value pair. If it already exists, it returns ErrKeyAlreadyExists.
+func (s *optimisticSession) Insert(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	if len(v) == 0 {
+		return errors.New("cannot store empty value")
+	}
+
+	ok, err := s.Exists(k)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return engine.ErrKeyAlreadyExists
+	}
+
+	s.writes.set(k, v, false)
+	return nil
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+func (s *optimisticSession) Put(k, v []byte) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	if len(v) == 0 {
+		return errors.New("cannot store empty value")
+	}
+
+	s.writes.set(k, v, false)
+	return nil
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *optimisticSession) Get(k []byte) ([]byte, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	if w, ok := s.writes.get(k); ok {
+		if w.deleted {
+			return nil, errors.WithStack(engine.ErrKeyNotFound)
+		}
+
+		return append([]byte(nil), w.value...), nil
+	}
+
+	return s.rtx.get(k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *optimisticSession) Exists(k []byte) (bool, error) {
+	if err := s.checkOpen(); err != nil {
+		return false, err
+	}
+
+	if w, ok := s.writes.get(k); ok {
+		return !w.deleted, nil
+	}
+
+	return s.rtx.exists(k), nil
+}
+
+// Delete a record by key. If the key doesn't exist, it doesn't do anything.
+func (s *optimisticSession) Delete(k []byte) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+
+	s.writes.set(k, nil, true)
+	return nil
+}
+
+// DeleteRange deletes all keys in the given range.
+func (s *optimisticSession) DeleteRange(start []byte, end []byte) error {
+	it, err := s.Iterator(&engine.IterOptions{
+		LowerBound: start,
+		UpperBound: end,
+	})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.First(); it.Valid(); it.Next() {
+		err := s.Delete(it.Key())
+		if err != nil {
+			return err
+		}
+	}
+
+	return it.Error()
+}
+
+// Iterator returns an iterator that merges the read transaction with the pending writes.
+// The iterator doesn't see writes made after its creation.
+func (s *optimisticSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	lower, upper := sortBounds(opts)
+
+	return newMergeIterator(newBoltCursor(s.rtx, lower, upper), s.writes.span(lower, upper)), nil
+}
+
+// transientSession keeps its data in memory.
+type transientSession struct {
+	writes *memtable
+	closed bool
+}
+
+func (s *transientSession) Commit() error {
+	return errors.New("cannot commit in transient mode")
+}
+
+func (s *transientSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+	s.writes = nil
+
+	return nil
+}
+
+func (s *transientSession) Insert(k, v []byte) error {
+	return errors.New("cannot insert in transient mode")
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+func (s *transientSession) Put(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	if len(v) == 0 {
+		return errors.New("cannot store empty value")
+	}
+
+	s.writes.set(k, v, false)
+	return nil
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *transientSession) Get(k []byte) ([]byte, error) {
+	w, ok := s.writes.get(k)
+	if !ok {
+		return nil, errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	return append([]byte(nil), w.value...), nil
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *transientSession) Exists(k []byte) (bool, error) {
+	_, ok := s.writes.get(k)
+	return ok, nil
+}
+
+// Delete a record by key. If not found, returns ErrKeyNotFound.
+func (s *transientSession) Delete(k []byte) error {
+	w, ok := s.writes.get(k)
+	if !ok {
+		return errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	i := s.writes.search(w.key)
+	s.writes.remove(i, i+1)
+	return nil
+}
+
+// DeleteRange deletes all keys in the given range.
+func (s *transientSession) DeleteRange(start []byte, end []byte) error {
+	lower, upper := sortBounds(&engine.IterOptions{
+		LowerBound: start,
+		UpperBound: end,
+	})
+
+	i, j := 0, len(s.writes.writes)
+	if lower != nil {
+		i = s.writes.search(lower)
+	}
+	if upper != nil {
+		j = s.writes.search(upper)
+	}
+	if i < j {
+		s.writes.remove(i, j)
+	}
+
+	return nil
+}
+
+// Iterator returns an iterator over the keys of the session.
+// The iterator doesn't see writes made after its creation.
+func (s *transientSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	lower, upper := sortBounds(opts)
+
+	return newMergeIterator(nil, s.writes.span(lower, upper)), nil
+}
+
+// errSession is returned when a session cannot be created,
+// all its methods return the error.
+type errSession struct {
+	err error
+}
+
+func (s *errSession) Commit() error                                         { return s.err }
+func (s *errSession) Close() error                                          { return nil }
+func (s *errSession) Insert(k, v []byte) error                              { return s.err }
+func (s *errSession) Put(k, v []byte) error                                 { return s.err }
+func (s *errSession) Get(k []byte) ([]byte, error)                          { return nil, s.err }
+func (s *errSession) Exists(k []byte) (bool, error)                         { return false, s.err }
+func (s *errSession) Delete(k []byte) error                                 { return s.err }
+func (s *errSession) DeleteRange(start, end []byte) error                   { return s.err }
+func (s *errSession) Iterator(*engine.IterOptions) (engine.Iterator, error) { return nil, s.err }
@@ -0,0 +1,290 @@
+// Package engine defines the interface of the key-value stores
+// in which Chai databases store their data.
+//
+// The default engine, used by chai.Open, is based on Pebble.
+// Other stores can be plugged with chai.OpenEngine by implementing
+// Engine, and validated with the enginetest package.
+//
+// Keys are byte slices encoded by Chai, which are not ordered bytewise:
+// engines must order them with Compare. Keys and values are never empty.
+// Sessions don't retain the slices passed to their methods.
+package engine
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+// Common errors returned by the engine.
+var (
+	// ErrKeyNotFound is returned when the targeted key doesn't exist.
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrKeyAlreadyExists is returned when the targeted key already exists.
+	ErrKeyAlreadyExists = errors.New("key already exists")
+
+	// ErrDatabaseLocked is returned when opening a database
+	// that is already in use by another process.
+	ErrDatabaseLocked = errors.New("database is locked")
+
+	// ErrTxConflict is returned when committing a transaction that
+	// modified data also modified by a concurrent transaction
+	// that committed first. The transaction can be retried.
+	ErrTxConflict = errors.New("transaction conflict, please retry")
+)
+
+// Compare returns -1, 0 or +1 depending on whether a is lower than,
+// equal to or greater than b. It defines the order of the keys
+// of the engines.
+func Compare(a, b []byte) int {
+	return encoding.Compare(a, b)
+}
+
+// An Engine is a transactional key-value store. All its methods
+// and the ones of its sessions may be called concurrently,
+// except the methods of a given session or iterator.
+type Engine interface {
+	// Close the engine. It is called once, by the database
+	// that uses the engine, after all the sessions are closed.
+	Close() error
+	// Recover is called once when the database is opened,
+	// to bring the engine back to a consistent state if the
+	// previous process crashed in the middle of a commit.
+	Recover() error
+	// CleanupTransientNamespaces is called once when the database is opened,
+	// to delete the data left by the transient sessions of a previous process.
+	CleanupTransientNamespaces() error
+	// NewSnapshotSession returns a read-only session reading the data committed
+	// when the session is created. Its write methods return an error.
+	NewSnapshotSession() Session
+	// NewOptimisticSession returns a read-write session reading the data committed
+	// when the session is created, plus its own writes, which are not visible
+	// to other sessions until Commit. Commit returns ErrTxConflict, and writes nothing,
+	// if any of the keys written by the session was also written by another session
+	// which committed after this one was created.
+	// Closing the session without committing discards its writes.
+	NewOptimisticSession() Session
+	// NewTransientSession returns a session for temporary data, which is
+	// kept in memory until it exceeds maxMemory bytes, then spilled to disk.
+	// If maxMemory is zero, the engine uses its default.
+	// Its keys belong to namespaces which are not used by other sessions,
+	// and may be kept after the session is closed, until they are deleted
+	// by CleanupTransientNamespaces. Its Insert and Commit methods aren't called.
+	NewTransientSession(maxMemory int) Session
+	// NewVersion returns a view of the data committed when the version
+	// is created, used to read the database as of a past time.
+	NewVersion() Version
+	// SpanStats returns statistics about the keys between start and end
+	// (exclusive) that are stored on disk. Engines which cannot estimate them
+	// return zero values.
+	SpanStats(start, end []byte) (SpanStats, error)
+	// Compact rewrites the files holding keys between start and end
+	// (exclusive), removing deleted and overwritten keys.
+	// Engines without compaction return nil.
+	Compact(start, end []byte) error
+	// Flush writes the keys held in memory to disk.
+	// Engines which don't buffer writes return nil.
+	Flush() error
+}
+
+// SpanStats are statistics about the keys of a span.
+// Sizes are estimated from the properties of the files overlapping the span,
+// in proportion of the part of each file holding keys of the span,
+// and don't count keys that were not flushed to disk yet.
+type SpanStats struct {
+	// DiskSize is the size of the keys and values on disk, once compressed.
+	DiskSize uint64
+	// RawSize is the size of the keys and values before compression.
+	RawSize uint64
+	// Tombstones is the number of deleted keys whose deletion markers
+	// were not removed by compactions yet.
+	Tombstones uint64
+}
+
+// A Version is a read-only view of the database at the time it was created.
+// The data it sees is retained until it is released, even if it is
+// modified or deleted afterwards.
+type Version interface {
+	// NewSession returns a read-only session reading the version.
+	// The session remains valid after the version is released.
+	NewSession() Session
+	// Release the version.
+	Release() error
+}
+
+// A Session reads and writes the data of an engine.
+type Session interface {
+	// Commit makes the writes of the session visible to the sessions
+	// created afterwards, then closes the session.
+	Commit() error
+	// Close the session. The writes of a session closed
+	// without being committed are discarded.
+	Close() error
+	// Insert inserts a key-value pair. If it already exists, it returns ErrKeyAlreadyExists.
+	Insert(k, v []byte) error
+	// Put stores a key-value pair. If it already exists, it overrides it.
+	Put(k, v []byte) error
+	// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+	// The returned slice belongs to the caller.
+	Get(k []byte) ([]byte, error)
+	// Exists returns whether a key exists and is visible by the current session.
+	Exists(k []byte) (bool, error)
+	// Delete a record by key. If not found, sessions either
+	// return ErrKeyNotFound or do nothing.
+	Delete(k []byte) error
+	// DeleteRange deletes the keys between start (inclusive) and end (exclusive).
+	DeleteRange(start []byte, end []byte) error
+	// Iterator returns an iterator over the keys visible by the session,
+	// in the order defined by Compare. It is not required to see
+	// the writes made by the session after its creation.
+	Iterator(opts *IterOptions) (Iterator, error)
+}
+
+// Synchronous defines when the commits are synchronized to disk,
+// trading durability for write throughput. In every mode, a crash
+// never leaves the database in an inconsistent state:
+// only the most recent commits can be lost.
+type Synchronous uint8
+
+const (
+	// SyncFull synchronizes each commit to disk before it returns.
+	// Committed transactions survive a crash of the process or of the machine.
+	SyncFull Synchronous = iota
+	// SyncNormal writes the commits without waiting for the disk, and
+	// synchronizes them periodically in the background. The commits of
+	// the last interval can be lost if the process or the machine crashes.
+	SyncNormal
+	// SyncOff leaves the synchronization to the engine and the operating system.
+	// An unbounded number of recent commits can be lost after a crash.
+	SyncOff
+)
+
+// ParseSynchronous parses the name of a mode, as returned by String,
+// or its number, as used by SQLite: 0 for OFF, 1 for NORMAL and 2 for FULL.
+// The comparison is case-insensitive.
+func ParseSynchronous(s string) (Synchronous, error) {
+	switch strings.ToUpper(s) {
+	case "FULL", "2":
+		return SyncFull, nil
+	case "NORMAL", "1":
+		return SyncNormal, nil
+	case "OFF", "0":
+		return SyncOff, nil
+	}
+
+	return 0, errors.Errorf("unknown synchronous mode %q", s)
+}
+
+func (s Synchronous) String() string {
+	switch s {
+	case SyncFull:
+		return "FULL"
+	case SyncNormal:
+		return "NORMAL"
+	case SyncOff:
+		return "OFF"
+	}
+
+	return "Synchronous(" + strconv.Itoa(int(s)) + ")"
+}
+
+// A SyncSession is a session whose commit can be synchronized
+// to disk according to a Synchronous mode. Sessions which don't
+// implement it synchronize their commits as their engine decides.
+type SyncSession interface {
+	Session
+	// SetSynchronous sets the mode used by Commit. It defaults to SyncFull.
+	SetSynchronous(Synchronous)
+}
+
+// A CommitLockSession is a session whose Commit may have to wait for
+// other sessions to end, for example because a session writing more data
+// than the engine keeps in memory prevents the others from committing until
+// it ends. LockCommit waits for them without committing, so that the database
+// can wait before acquiring the locks it holds while committing.
+// The lock is released by Commit or Close.
+type CommitLockSession interface {
+	Session
+	LockCommit()
+}
+
+// A ConflictSession is an optimistic session which can record keys it
+// relied on without writing them, like the values of a unique index
+// checked before inserting a row. Commit returns ErrTxConflict if one of
+// them was also written or recorded by another session which committed after
+// this one was created, and the keys are recorded as written by the session,
+// so that the sessions which recorded them later conflict with it.
+type ConflictSession interface {
+	Session
+	AddConflictKey(k []byte)
+}
+
+// An Iterator iterates over the keys of a session. Its positioning methods
+// return whether the iterator is positioned on a key, as Valid.
+// The slices returned by Key and Value are only valid until
+// the iterator is moved or closed.
+type Iterator interface {
+	Close() error
+	// First moves the iterator to the first key.
+	First() bool
+	// Last moves the iterator to the last key.
+	Last() bool
+	// SeekGE moves the iterator to the first key greater than or equal to k.
+	SeekGE(k []byte) bool
+	// Valid returns whether the iterator is positioned on a key.
+	Valid() bool
+	// Next moves the iterator to the next key.
+	Next() bool
+	// Prev moves the iterator to the previous key.
+	Prev() bool
+	// Error returns the error which stopped the iteration, if any.
+	Error() error
+	Key() []byte
+	Value() ([]byte, error)
+}
+
+type IterOptions struct {
+	// LowerBound specifies the smallest key (inclusive) that the iterator will
+	// return during iteration. If the iterator is seeked or iterated past this
+	// boundary the iterator will return Valid()==false. Setting LowerBound
+	// effectively truncates the key space visible to the iterator.
+	LowerBound []byte
+	// UpperBound specifies the largest key (exclusive) that the iterator will
+	// return during iteration. If the iterator is seeked or iterated past this
+	// boundary the iterator will return Valid()==false. Setting UpperBound
+	// effectively truncates the key space visible to the iterator.
+	UpperBound []byte
+}
+
+// Metrics are statistics about the storage of an engine,
+// reported by the engines implementing MetricsEngine.
+// Counters are cumulative since the engine was opened.
+type Metrics struct {
+	// CacheSize is the number of bytes held by the block cache.
+	CacheSize int64
+	// CacheHits and CacheMisses are the numbers of reads
+	// of the block cache which found or missed their block.
+	CacheHits   int64
+	CacheMisses int64
+	// Compactions is the number of compactions.
+	Compactions int64
+	// CompactionDuration is the total time spent compacting.
+	CompactionDuration time.Duration
+	// CompactedBytes is the number of bytes written by compactions.
+	CompactedBytes uint64
+	// Flushes is the number of flushes of the memory tables to disk.
+	Flushes int64
+	// DiskSize is the number of bytes used on disk.
+	DiskSize uint64
+}
+
+// A MetricsEngine is an engine reporting statistics about its storage.
+type MetricsEngine interface {
+	Engine
+	// Metrics returns the current statistics of the engine.
+	Metrics() Metrics
+}
@@ -0,0 +1,519 @@
+// Package enginetest provides a test suite validating
+// the implementations of engine.Engine.
+package enginetest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+// Builder returns a new, empty engine.
+// Engines are closed by the tests which use them.
+type Builder func(t testing.TB) engine.Engine
+
+// TestSuite runs the tests validating the engines returned by builder.
+func TestSuite(t *testing.T, builder Builder) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, Builder)
+	}{
+		{"Session/ReadWrite", testReadWrite},
+		{"Session/Commit", testCommit},
+		{"Session/Conflicts", testConflicts},
+		{"Session/Snapshot", testSnapshot},
+		{"Session/Iterator", testIterator},
+		{"Session/DeleteRange", testDeleteRange},
+		{"Session/Transient", testTransient},
+		{"Version", testVersion},
+		{"Concurrency", testConcurrency},
+		{"Database", testDatabase},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fn(t, builder)
+		})
+	}
+}
+
+// newEngine returns an engine closed at the end of the test.
+func newEngine(t *testing.T, builder Builder) engine.Engine {
+	ng := builder(t)
+	t.Cleanup(func() {
+		require.NoError(t, ng.Close())
+	})
+
+	return ng
+}
+
+// key returns a key of the namespace 10.
+func key(i int64) []byte {
+	return encoding.EncodeInt(encoding.EncodeInt(nil, 10), i)
+}
+
+var namespaceRange = &engine.IterOptions{
+	LowerBound: encoding.EncodeInt(nil, 10),
+	UpperBound: encoding.EncodeInt(nil, 11),
+}
+
+// keys returns the keys of the namespace 10 seen by the session.
+func keys(t *testing.T, s engine.Session, reverse bool) []int64 {
+	t.Helper()
+
+	it, err := s.Iterator(namespaceRange)
+	require.NoError(t, err)
+	defer it.Close()
+
+	var got []int64
+	move, next := it.First, it.Next
+	if reverse {
+		move, next = it.Last, it.Prev
+	}
+	for ok := move(); ok; ok = next() {
+		require.True(t, it.Valid())
+		v, _ := encoding.DecodeInt(it.Key()[1:])
+		got = append(got, v)
+	}
+	require.NoError(t, it.Error())
+	return got
+}
+
+func commit(t *testing.T, ng engine.Engine, fn func(s engine.Session)) {
+	t.Helper()
+
+	s := ng.NewOptimisticSession()
+	fn(s)
+	require.NoError(t, s.Commit())
+}
+
+func get(t *testing.T, s engine.Session, k []byte) []byte {
+	t.Helper()
+
+	v, err := s.Get(k)
+	require.NoError(t, err)
+	return v
+}
+
+func testReadWrite(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	s := ng.NewOptimisticSession()
+	defer s.Close()
+
+	k := key(1)
+	require.NoError(t, s.Put(k, []byte("a")))
+	// the session doesn't retain the slices
+	k[len(k)-1]++
+	require.Equal(t, []byte("a"), get(t, s, key(1)))
+	_, err := s.Get(k)
+	require.ErrorIs(t, err, engine.ErrKeyNotFound)
+
+	// Put overrides existing keys
+	require.NoError(t, s.Put(key(1), []byte("b")))
+	v := get(t, s, key(1))
+	require.Equal(t, []byte("b"), v)
+	// the returned value belongs to the caller
+	v[0] = 'z'
+	require.Equal(t, []byte("b"), get(t, s, key(1)))
+
+	ok, err := s.Exists(key(1))
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.Exists(key(2))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.ErrorIs(t, s.Insert(key(1), []byte("c")), engine.ErrKeyAlreadyExists)
+	require.NoError(t, s.Insert(key(2), []byte("c")))
+	require.Equal(t, []byte("c"), get(t, s, key(2)))
+
+	require.NoError(t, s.Delete(key(1)))
+	_, err = s.Get(key(1))
+	require.ErrorIs(t, err, engine.ErrKeyNotFound)
+	ok, err = s.Exists(key(1))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// a deleted key can be inserted again
+	require.NoError(t, s.Insert(key(1), []byte("d")))
+	require.Equal(t, []byte("d"), get(t, s, key(1)))
+
+	// empty keys and values are rejected
+	require.Error(t, s.Put(nil, []byte("a")))
+	require.Error(t, s.Put(key(3), nil))
+	require.Error(t, s.Put(key(3), []byte{}))
+}
+
+func testCommit(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	commit(t, ng, func(s engine.Session) {
+		for i := int64(0); i < 10; i++ {
+			require.NoError(t, s.Put(key(i), encoding.EncodeInt(nil, i)))
+		}
+	})
+
+	// writes of sessions closed without commit are discarded
+	s := ng.NewOptimisticSession()
+	require.NoError(t, s.Put(key(20), []byte("a")))
+	require.NoError(t, s.Delete(key(0)))
+	require.NoError(t, s.Close())
+
+	// writes of the other sessions are not visible until they commit
+	w := ng.NewOptimisticSession()
+	require.NoError(t, w.Put(key(30), []byte("a")))
+
+	r := ng.NewSnapshotSession()
+	require.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, keys(t, r, false))
+	require.Equal(t, encoding.EncodeInt(nil, 9), get(t, r, key(9)))
+	require.NoError(t, r.Close())
+
+	require.NoError(t, w.Commit())
+
+	r = ng.NewSnapshotSession()
+	defer r.Close()
+	require.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 30}, keys(t, r, false))
+}
+
+func testConflicts(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	s1 := ng.NewOptimisticSession()
+	s2 := ng.NewOptimisticSession()
+	s3 := ng.NewOptimisticSession()
+
+	require.NoError(t, s1.Put(key(1), []byte{1}))
+	require.NoError(t, s2.Put(key(1), []byte{2}))
+	require.NoError(t, s3.Put(key(2), []byte{2}))
+
+	require.NoError(t, s1.Commit())
+	require.ErrorIs(t, s2.Commit(), engine.ErrTxConflict)
+	require.NoError(t, s2.Close())
+	// sessions writing other keys don't conflict
+	require.NoError(t, s3.Commit())
+
+	// deletions conflict too
+	s4 := ng.NewOptimisticSession()
+	s5 := ng.NewOptimisticSession()
+	require.NoError(t, s4.Delete(key(2)))
+	require.NoError(t, s5.Put(key(2), []byte{5}))
+	require.NoError(t, s4.Commit())
+	require.ErrorIs(t, s5.Commit(), engine.ErrTxConflict)
+	require.NoError(t, s5.Close())
+
+	// sessions created after the commit don't conflict
+	s6 := ng.NewOptimisticSession()
+	require.NoError(t, s6.Put(key(1), []byte{6}))
+	require.NoError(t, s6.Commit())
+
+	r := ng.NewSnapshotSession()
+	defer r.Close()
+	require.Equal(t, []byte{6}, get(t, r, key(1)))
+	_, err := r.Get(key(2))
+	require.ErrorIs(t, err, engine.ErrKeyNotFound)
+}
+
+func testSnapshot(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	commit(t, ng, func(s engine.Session) {
+		require.NoError(t, s.Put(key(1), []byte{1}))
+	})
+
+	r := ng.NewSnapshotSession()
+	defer r.Close()
+
+	// optimistic sessions read from a snapshot too
+	o := ng.NewOptimisticSession()
+	defer o.Close()
+
+	commit(t, ng, func(s engine.Session) {
+		require.NoError(t, s.Put(key(1), []byte{2}))
+		require.NoError(t, s.Put(key(2), []byte{2}))
+	})
+
+	for _, s := range []engine.Session{r, o} {
+		require.Equal(t, []byte{1}, get(t, s, key(1)))
+		ok, err := s.Exists(key(2))
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.Equal(t, []int64{1}, keys(t, s, false))
+	}
+
+	// snapshot sessions are read-only
+	require.Error(t, r.Put(key(3), []byte{3}))
+	require.Error(t, r.Insert(key(3), []byte{3}))
+	require.Error(t, r.Delete(key(1)))
+	require.Error(t, r.DeleteRange(key(0), key(10)))
+}
+
+func testIterator(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	// keys that are not ordered bytewise
+	values := []int64{-70000, -300, -1, 0, 5, 31, 32, 255, 256, 70000, 1 << 40}
+	commit(t, ng, func(s engine.Session) {
+		for i, v := range values {
+			if i%2 == 0 {
+				require.NoError(t, s.Put(key(v), encoding.EncodeInt(nil, v)))
+			}
+		}
+		// keys of other namespaces
+		require.NoError(t, s.Put(encoding.EncodeInt(encoding.EncodeInt(nil, 9), 1), []byte{9}))
+		require.NoError(t, s.Put(encoding.EncodeInt(encoding.EncodeInt(nil, 11), 1), []byte{11}))
+	})
+
+	s := ng.NewOptimisticSession()
+	defer s.Close()
+	for i, v := range values {
+		if i%2 == 1 {
+			require.NoError(t, s.Put(key(v), encoding.EncodeInt(nil, v)))
+		}
+	}
+
+	require.Equal(t, values, keys(t, s, false))
+	reversed := append([]int64(nil), values...)
+	sort.Slice(reversed, func(i, j int) bool { return reversed[i] > reversed[j] })
+	require.Equal(t, reversed, keys(t, s, true))
+
+	// all the keys, in the order of engine.Compare
+	it, err := s.Iterator(nil)
+	require.NoError(t, err)
+	var all [][]byte
+	for it.First(); it.Valid(); it.Next() {
+		v, err := it.Value()
+		require.NoError(t, err)
+		require.NotEmpty(t, v)
+		all = append(all, bytes.Clone(it.Key()))
+	}
+	require.NoError(t, it.Error())
+	require.NoError(t, it.Close())
+	require.Len(t, all, len(values)+2)
+	require.True(t, sort.SliceIsSorted(all, func(i, j int) bool { return engine.Compare(all[i], all[j]) < 0 }))
+
+	it, err = s.Iterator(namespaceRange)
+	require.NoError(t, err)
+	defer it.Close()
+
+	require.True(t, it.SeekGE(key(6)))
+	require.Equal(t, key(31), it.Key())
+	v, err := it.Value()
+	require.NoError(t, err)
+	require.Equal(t, encoding.EncodeInt(nil, 31), v)
+
+	// change direction in the middle of the iteration
+	require.True(t, it.Prev())
+	require.Equal(t, key(5), it.Key())
+	require.True(t, it.Next())
+	require.Equal(t, key(31), it.Key())
+	require.True(t, it.Next())
+	require.Equal(t, key(32), it.Key())
+
+	// the bounds are respected
+	require.True(t, it.SeekGE(key(-1<<50)))
+	require.Equal(t, key(-70000), it.Key())
+	require.False(t, it.Prev())
+	require.False(t, it.Valid())
+	require.True(t, it.Last())
+	require.Equal(t, key(1<<40), it.Key())
+	require.False(t, it.Next())
+	require.False(t, it.SeekGE(key(1<<41)))
+
+	// the keys deleted by the session are skipped
+	require.NoError(t, s.Delete(key(0)))
+	require.NoError(t, s.Delete(key(5)))
+	require.Equal(t, []int64{-70000, -300, -1, 31, 32, 255, 256, 70000, 1 << 40}, keys(t, s, false))
+}
+
+func testDeleteRange(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	commit(t, ng, func(s engine.Session) {
+		for i := int64(0); i < 10; i++ {
+			require.NoError(t, s.Put(key(i), []byte{byte(i)}))
+		}
+	})
+
+	commit(t, ng, func(s engine.Session) {
+		require.NoError(t, s.Put(key(10), []byte{10}))
+		require.NoError(t, s.DeleteRange(key(3), key(7)))
+		require.Equal(t, []int64{0, 1, 2, 7, 8, 9, 10}, keys(t, s, false))
+	})
+
+	r := ng.NewSnapshotSession()
+	defer r.Close()
+	require.Equal(t, []int64{0, 1, 2, 7, 8, 9, 10}, keys(t, r, false))
+}
+
+func testTransient(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	// namespaces of transient sessions are not used by other sessions
+	tkey := func(i int64) []byte {
+		return encoding.EncodeInt(encoding.EncodeInt(nil, 1<<40), i)
+	}
+
+	s := ng.NewTransientSession(256)
+	value := bytes.Repeat([]byte{'v'}, 100)
+	for i := int64(99); i >= 0; i-- {
+		require.NoError(t, s.Put(tkey(i), value))
+	}
+	require.Equal(t, value, get(t, s, tkey(42)))
+
+	it, err := s.Iterator(&engine.IterOptions{
+		LowerBound: encoding.EncodeInt(nil, 1<<40),
+		UpperBound: encoding.EncodeInt(nil, 1<<40+1),
+	})
+	require.NoError(t, err)
+	var i int64
+	for it.First(); it.Valid(); it.Next() {
+		require.Equal(t, tkey(i), it.Key())
+		v, err := it.Value()
+		require.NoError(t, err)
+		require.Equal(t, value, v)
+		i++
+	}
+	require.NoError(t, it.Error())
+	require.NoError(t, it.Close())
+	require.EqualValues(t, 100, i)
+
+	require.NoError(t, s.DeleteRange(tkey(0), tkey(50)))
+	_, err = s.Get(tkey(42))
+	require.ErrorIs(t, err, engine.ErrKeyNotFound)
+
+	require.NoError(t, s.Close())
+}
+
+func testVersion(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	commit(t, ng, func(s engine.Session) {
+		require.NoError(t, s.Put(key(1), []byte{1}))
+	})
+
+	v := ng.NewVersion()
+
+	commit(t, ng, func(s engine.Session) {
+		require.NoError(t, s.Put(key(1), []byte{2}))
+		require.NoError(t, s.Put(key(2), []byte{2}))
+	})
+
+	s1 := v.NewSession()
+	s2 := v.NewSession()
+	require.NoError(t, v.Release())
+
+	// sessions remain valid after the version is released
+	for _, s := range []engine.Session{s1, s2} {
+		require.Equal(t, []byte{1}, get(t, s, key(1)))
+		require.Equal(t, []int64{1}, keys(t, s, false))
+		require.Error(t, s.Put(key(3), []byte{3}))
+		require.NoError(t, s.Close())
+	}
+}
+
+func testConcurrency(t *testing.T, builder Builder) {
+	ng := newEngine(t, builder)
+
+	const workers, writes = 8, 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := int64(0); w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := int64(0); i < writes; i++ {
+				s := ng.NewOptimisticSession()
+				err := s.Put(key(w*writes+i), []byte{byte(w)})
+				if err == nil {
+					err = s.Commit()
+				} else {
+					_ = s.Close()
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				r := ng.NewSnapshotSession()
+				_, err = r.Get(key(w*writes + i))
+				_ = r.Close()
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	r := ng.NewSnapshotSession()
+	defer r.Close()
+	require.Len(t, keys(t, r, false), workers*writes)
+}
+
+func testDatabase(t *testing.T, builder Builder) {
+	db, err := chai.OpenEngine(builder(t), nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT, c DOUBLE);
+		CREATE INDEX test_b_idx ON test(b);
+		CREATE SEQUENCE seq;
+	`)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		err = db.Exec(`INSERT INTO test (a, b, c) VALUES (?, ?, ?)`, i, fmt.Sprintf("b%02d", i%10), float64(i)/2)
+		require.NoError(t, err)
+	}
+
+	err = db.Exec(`UPDATE test SET c = 0 WHERE a >= 50`)
+	require.NoError(t, err)
+	err = db.Exec(`DELETE FROM test WHERE b = 'b03'`)
+	require.NoError(t, err)
+
+	// rolled back writes are discarded
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec(`DELETE FROM test`))
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, conn.Close())
+
+	count := func(q string) int {
+		t.Helper()
+
+		r, err := db.QueryRow(q)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+	require.Equal(t, 90, count(`SELECT COUNT(*) FROM test`))
+	require.Equal(t, 10, count(`SELECT COUNT(*) FROM test WHERE b = 'b05'`))
+	require.Equal(t, 44, count(`SELECT COUNT(*) FROM test WHERE c > 0`))
+	require.Equal(t, 99, count(`SELECT a FROM test WHERE b = 'b09' ORDER BY a DESC LIMIT 1`))
+
+	report, err := db.Check(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, report.Problems)
+}
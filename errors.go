@@ -1,11 +1,54 @@
 package chai
 
 import (
+	"context"
+
+	"github.com/chaisql/chai/engine"
 	"github.com/chaisql/chai/internal/database"
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/sql/parser"
 	"github.com/cockroachdb/errors"
 )
 
+// ErrDatabaseLocked is returned by Open when the database
+// is already opened by another process.
+var ErrDatabaseLocked = engine.ErrDatabaseLocked
+
+// ErrTxConflict is returned by Commit when the transaction modified
+// rows that were also modified by a concurrent transaction that committed first,
+// wrote the same value of a unique column as such a transaction,
+// or used a table, index or sequence whose schema it changed.
+// The transaction is rolled back and can be retried.
+var ErrTxConflict = engine.ErrTxConflict
+
+// ErrEncryptionKeyUnavailable is returned when reading or writing
+// an encrypted column whose key was not provided to OpenWith.
+var ErrEncryptionKeyUnavailable = database.ErrEncryptionKeyUnavailable
+
+// ErrInvalidSnapshot is returned by OpenSnapshot when the file
+// is not a snapshot or is corrupted.
+var ErrInvalidSnapshot = database.ErrInvalidSnapshot
+
+// ErrDiskQuotaExceeded is returned when the temporary data of a query
+// exceeds the TransientDiskQuota of the database.
+var ErrDiskQuotaExceeded = database.ErrDiskQuotaExceeded
+
+// ErrAuthenticationFailed is returned by ConnectAs when the user doesn't
+// exist or the password is wrong.
+var ErrAuthenticationFailed = database.ErrAuthenticationFailed
+
+// PermissionDeniedError is returned when the user of a connection
+// opened with ConnectAs runs a statement it is not allowed to.
+type PermissionDeniedError = database.PermissionDeniedError
+
+// ErrQueryTimeout is returned when a query runs for longer than its
+// timeout, set with WithTimeout or the statement_timeout setting.
+var ErrQueryTimeout = errors.New("query timeout")
+
+// ErrTooManyRows is returned by QueryOne and QueryValue when
+// the query returns more than one row.
+var ErrTooManyRows = errors.New("query returned more than one row")
+
 // IsNotFoundError determines if the given error is a NotFoundError.
 // NotFoundError is returned when the requested table, index, object or sequence
 // doesn't exist.
@@ -34,3 +77,204 @@ func IsAlreadyExistsError(err error) bool {
 
 	return false
 }
+
+// CorruptRowError is returned when a stored row cannot be decoded,
+// for example after a disk corruption. It reports the table and
+// the key of the row when they are known.
+type CorruptRowError = database.CorruptRowError
+
+// IsCorruptRowError determines if the error is returned because
+// a stored row cannot be decoded.
+var IsCorruptRowError = database.IsCorruptRowError
+
+// ErrorCode is the code of an Error. Codes are the SQLSTATE
+// codes used by PostgreSQL for the same conditions.
+type ErrorCode string
+
+// Codes of the errors returned by the queries.
+const (
+	CodeSyntaxError          ErrorCode = "42601"
+	CodeDatatypeMismatch     ErrorCode = "42804"
+	CodeUndefinedObject      ErrorCode = "42704"
+	CodeDuplicateObject      ErrorCode = "42710"
+	CodeIntegrityViolation   ErrorCode = "23000"
+	CodeNotNullViolation     ErrorCode = "23502"
+	CodeUniqueViolation      ErrorCode = "23505"
+	CodeCheckViolation       ErrorCode = "23514"
+	CodeSerializationFailure ErrorCode = "40001"
+	CodeQueryCanceled        ErrorCode = "57014"
+	CodeDiskFull             ErrorCode = "53100"
+	CodeDataCorrupted        ErrorCode = "XX001"
+
+	// Codes of the errors of access control.
+	CodeInsufficientPrivilege ErrorCode = "42501"
+	CodeInvalidPassword       ErrorCode = "28P01"
+
+	// CodeInternalError is the code of the errors
+	// that don't fall in any other category.
+	CodeInternalError ErrorCode = "XX000"
+)
+
+// Error is the error returned by the statements, the transactions and their
+// results. It wraps the error that caused it and has the same message.
+// Its category can be tested with errors.Is:
+//
+//	if errors.Is(err, chai.ConstraintViolation) {
+//		// ...
+//	}
+//
+// and its details read with errors.As:
+//
+//	var e *chai.Error
+//	if errors.As(err, &e) {
+//		fmt.Println(e.Code, e.Offset, e.Hint)
+//	}
+type Error struct {
+	Code    ErrorCode
+	Message string
+	// Offset is the byte offset, in the SQL text, of the token
+	// at which the error was detected, or -1 if it is unknown.
+	Offset int
+	// Token is the text of that token, if known.
+	Token string
+	// Hint is a suggestion to fix the error, if any.
+	Hint string
+	// Constraint is the name of the violated constraint, if known.
+	Constraint string
+
+	err error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether the error belongs to the target ErrorCategory.
+func (e *Error) Is(target error) bool {
+	c, ok := target.(*ErrorCategory)
+	if !ok {
+		return false
+	}
+
+	for _, code := range c.codes {
+		if code == e.Code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrorCategory is a group of error codes, to be used with errors.Is.
+type ErrorCategory struct {
+	name  string
+	codes []ErrorCode
+}
+
+func (c *ErrorCategory) Error() string {
+	return c.name
+}
+
+// Categories of the errors.
+var (
+	// SyntaxError is the category of the statements that cannot be parsed.
+	SyntaxError = &ErrorCategory{name: "syntax error", codes: []ErrorCode{CodeSyntaxError}}
+
+	// ConstraintViolation is the category of the writes that violate
+	// a constraint of a table.
+	ConstraintViolation = &ErrorCategory{name: "constraint violation", codes: []ErrorCode{
+		CodeIntegrityViolation,
+		CodeNotNullViolation,
+		CodeUniqueViolation,
+		CodeCheckViolation,
+	}}
+
+	// TypeMismatch is the category of the values and expressions that
+	// cannot be used with or converted to the expected type.
+	TypeMismatch = &ErrorCategory{name: "type mismatch", codes: []ErrorCode{CodeDatatypeMismatch}}
+
+	// PermissionDenied is the category of the statements the user
+	// is not allowed to run, and of the failed authentications.
+	PermissionDenied = &ErrorCategory{name: "permission denied", codes: []ErrorCode{
+		CodeInsufficientPrivilege,
+		CodeInvalidPassword,
+	}}
+)
+
+// newError returns err as an Error, classifying it from its cause.
+// q is the SQL text of the statement, if known.
+func newError(err error, q string) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	e = &Error{Code: CodeInternalError, Message: err.Error(), Offset: -1, err: err}
+
+	var (
+		perr  *parser.ParseError
+		cverr *database.ConstraintViolationError
+		ckerr *database.CheckViolationError
+		pderr *database.PermissionDeniedError
+	)
+	switch {
+	case errors.As(err, &perr):
+		e.Code = CodeSyntaxError
+		e.Token = perr.Found
+		if q != "" {
+			e.Offset = perr.Pos.Offset(q)
+		}
+		if perr.Found == "EOF" {
+			e.Hint = "the statement is incomplete"
+		}
+	case errors.As(err, &cverr):
+		e.Constraint = cverr.Name
+		switch cverr.Constraint {
+		case "NOT NULL":
+			e.Code = CodeNotNullViolation
+		case "UNIQUE", "PRIMARY KEY":
+			e.Code = CodeUniqueViolation
+			e.Hint = "use ON CONFLICT to update or ignore the existing row"
+		default:
+			e.Code = CodeIntegrityViolation
+		}
+	case errors.As(err, &ckerr):
+		e.Code = CodeCheckViolation
+		e.Constraint = ckerr.Name
+	case errs.IsTypeMismatchError(err):
+		e.Code = CodeDatatypeMismatch
+		e.Hint = "use CAST to convert the value explicitly"
+	case errs.IsNotFoundError(err):
+		e.Code = CodeUndefinedObject
+	case errs.IsAlreadyExistsError(err):
+		e.Code = CodeDuplicateObject
+	case errors.Is(err, ErrTxConflict):
+		e.Code = CodeSerializationFailure
+		e.Hint = "retry the transaction"
+	case errors.Is(err, ErrQueryTimeout), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		e.Code = CodeQueryCanceled
+	case errors.Is(err, ErrDiskQuotaExceeded):
+		e.Code = CodeDiskFull
+		e.Hint = "increase TransientDiskQuota or work_mem"
+	case errors.As(err, &pderr):
+		e.Code = CodeInsufficientPrivilege
+		if pderr.Privilege != 0 {
+			e.Hint = "a superuser can grant the privilege with GRANT"
+		}
+	case errors.Is(err, ErrAuthenticationFailed):
+		e.Code = CodeInvalidPassword
+	case IsCorruptRowError(err):
+		e.Code = CodeDataCorrupted
+		e.Hint = "use DB.Check to find the corrupted rows"
+	}
+
+	return e
+}
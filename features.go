@@ -0,0 +1,95 @@
+package chai
+
+import "sort"
+
+// Feature is an SQL capability that may or may not be supported
+// by this version of the database.
+type Feature string
+
+// SQL capabilities. Some of them are not supported yet,
+// they are listed so that frameworks can check for them.
+const (
+	FeatureJoins               Feature = "joins"
+	FeatureCTE                 Feature = "cte"
+	FeatureWindowFunctions     Feature = "window_functions"
+	FeatureFullTextSearch      Feature = "full_text_search"
+	FeatureSubqueries          Feature = "subqueries"
+	FeatureUnion               Feature = "union"
+	FeatureIntersect           Feature = "intersect"
+	FeatureReturning           Feature = "returning"
+	FeatureUpsert              Feature = "upsert"
+	FeatureSchemas             Feature = "schemas"
+	FeatureSequences           Feature = "sequences"
+	FeatureCheckConstraints    Feature = "check_constraints"
+	FeatureDeferredConstraints Feature = "deferred_constraints"
+	FeatureAggregateFilter     Feature = "aggregate_filter"
+	FeatureAggregateDistinct   Feature = "aggregate_distinct"
+	FeatureOrderedSetAggregate Feature = "ordered_set_aggregates"
+	FeatureJSONFunctions       Feature = "json_functions"
+	FeatureRegexp              Feature = "regexp"
+	FeatureUUID                Feature = "uuid"
+	FeatureDecimal             Feature = "decimal"
+	FeatureInterval            Feature = "interval"
+	FeatureEncryptedColumns    Feature = "encrypted_columns"
+	FeatureTimeTravel          Feature = "time_travel"
+	FeatureCompatMode          Feature = "compat_mode"
+	FeatureStrictTyping        Feature = "strict_typing"
+	FeatureMatchRecognize      Feature = "match_recognize"
+	FeatureVector              Feature = "vector"
+	FeatureCreateFunction      Feature = "create_function"
+	FeatureTryCast             Feature = "try_cast"
+)
+
+var features = map[Feature]bool{
+	FeatureJoins:               false,
+	FeatureCTE:                 false,
+	FeatureWindowFunctions:     false,
+	FeatureFullTextSearch:      false,
+	FeatureSubqueries:          false,
+	FeatureUnion:               true,
+	FeatureIntersect:           true,
+	FeatureReturning:           true,
+	FeatureUpsert:              true,
+	FeatureSchemas:             true,
+	FeatureSequences:           true,
+	FeatureCheckConstraints:    true,
+	FeatureDeferredConstraints: true,
+	FeatureAggregateFilter:     true,
+	FeatureAggregateDistinct:   true,
+	FeatureOrderedSetAggregate: true,
+	FeatureJSONFunctions:       true,
+	FeatureRegexp:              true,
+	FeatureUUID:                true,
+	FeatureDecimal:             true,
+	FeatureInterval:            true,
+	FeatureEncryptedColumns:    true,
+	FeatureTimeTravel:          true,
+	FeatureCompatMode:          true,
+	FeatureStrictTyping:        true,
+	FeatureMatchRecognize:      true,
+	FeatureVector:              true,
+	FeatureCreateFunction:      true,
+	FeatureTryCast:             true,
+}
+
+// Features returns the SQL capabilities supported by the database,
+// sorted by name.
+func Features() []Feature {
+	l := make([]Feature, 0, len(features))
+	for f, ok := range features {
+		if ok {
+			l = append(l, f)
+		}
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		return l[i] < l[j]
+	})
+	return l
+}
+
+// Supports returns true if the database supports the given capability.
+// It returns false for capabilities it doesn't know about.
+func Supports(f Feature) bool {
+	return features[f]
+}
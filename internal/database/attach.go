@@ -0,0 +1,293 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// An attachedDatabase is a database opened by ATTACH DATABASE.
+// It is shared by the connections attaching the same path
+// and closed when the last of them detaches it.
+// Nothing writes to it, its catalog never changes once opened.
+type attachedDatabase struct {
+	path string
+	db   *Database
+	refs int
+}
+
+// openAttached opens the database stored at the given path,
+// or returns the one already attached by another connection.
+func (db *Database) openAttached(path string) (*attachedDatabase, error) {
+	if db.catalogLoader == nil {
+		return nil, errors.New("this database cannot attach other databases")
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db.attached.Lock()
+	defer db.attached.Unlock()
+
+	if a, ok := db.attached.m[path]; ok {
+		a.refs++
+		return a, nil
+	}
+
+	// opening a path that doesn't exist would create an empty database
+	_, err = os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Errorf("database %s does not exist", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	adb, err := Open(path, &Options{
+		CatalogLoader: db.catalogLoader,
+		Keyring:       db.keys,
+		// the maintenance jobs would write to the database
+		TTLInterval:    -1,
+		VacuumInterval: -1,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot attach database %s", path)
+	}
+
+	a := attachedDatabase{
+		path: path,
+		db:   adb,
+		refs: 1,
+	}
+	if db.attached.m == nil {
+		db.attached.m = make(map[string]*attachedDatabase)
+	}
+	db.attached.m[path] = &a
+
+	return &a, nil
+}
+
+// releaseAttached closes the attached database once
+// no connection uses it anymore.
+func (db *Database) releaseAttached(a *attachedDatabase) error {
+	db.attached.Lock()
+	defer db.attached.Unlock()
+
+	a.refs--
+	if a.refs > 0 {
+		return nil
+	}
+
+	delete(db.attached.m, a.path)
+	return a.db.Close()
+}
+
+// errAttachedReadOnly is returned when modifying the relations
+// of the database attached under the given name.
+func errAttachedReadOnly(name string) error {
+	return errors.Errorf("database %s is attached read-only", name)
+}
+
+// Attach opens the database stored at the given path so that the queries of
+// the connection can read its tables and their indexes as name.table and name.index.
+// The tables of the default schema of the database are attached, they cannot
+// be modified. Each transaction of the connection reads the attached databases
+// as they were when it started.
+// The name must not be the name of a schema of the database.
+// Only superusers can attach databases.
+// It fails if a transaction is attached to the connection.
+func (c *Connection) Attach(path, name string) error {
+	if c.tx != nil {
+		return errors.New("cannot attach a database within a transaction")
+	}
+
+	// attaching reads any file the process can read
+	if c.user != "" {
+		u, err := c.db.Catalog().GetUser(c.user)
+		if err != nil || !u.Superuser {
+			return errors.WithStack(&PermissionDeniedError{User: c.user})
+		}
+	}
+
+	if name == "" || strings.ContainsRune(name, '.') {
+		return errors.Errorf("invalid database name %q", name)
+	}
+
+	if _, ok := c.attached[name]; ok {
+		return errors.Errorf("database %s is already attached", name)
+	}
+
+	if _, err := c.db.Catalog().GetSchema(name); err == nil {
+		return errors.Errorf("cannot attach database as %s: schema %s already exists", name, name)
+	}
+
+	a, err := c.db.openAttached(path)
+	if err != nil {
+		return err
+	}
+
+	if c.attached == nil {
+		c.attached = make(map[string]*attachedDatabase)
+		c.attachedCache = newCatalogCache()
+	}
+	c.attached[name] = a
+	c.attachedCache.load(name, a.db.Catalog())
+
+	return nil
+}
+
+// Detach closes the database attached under the given name,
+// unless other connections attached it too.
+// It fails if a transaction is attached to the connection.
+func (c *Connection) Detach(name string) error {
+	if c.tx != nil {
+		return errors.New("cannot detach a database within a transaction")
+	}
+
+	a, ok := c.attached[name]
+	if !ok {
+		return errors.Errorf("database %s is not attached", name)
+	}
+
+	delete(c.attached, name)
+	c.attachedCache.unload(name)
+
+	return c.db.releaseAttached(a)
+}
+
+// AttachedDatabases returns the names of the databases attached to the connection
+// and their paths, by name.
+func (c *Connection) AttachedDatabases() map[string]string {
+	m := make(map[string]string, len(c.attached))
+	for name, a := range c.attached {
+		m[name] = a.path
+	}
+
+	return m
+}
+
+// detachAll detaches every database attached to the connection.
+func (c *Connection) detachAll() error {
+	var err error
+	for name := range c.attached {
+		err = errors.CombineErrors(err, c.Detach(name))
+	}
+
+	return err
+}
+
+// beginAttached starts a read-only transaction on each database
+// attached to the connection, which is rolled back with tx.
+func (c *Connection) beginAttached(tx *Transaction) error {
+	tx.attached = make(map[string]*Transaction, len(c.attached))
+	release := func() {
+		for _, atx := range tx.attached {
+			_ = atx.Rollback()
+		}
+	}
+
+	for name, a := range c.attached {
+		atx, err := a.db.Begin(false)
+		if err != nil {
+			release()
+			return err
+		}
+
+		tx.attached[name] = atx
+	}
+
+	tx.Catalog = tx.Catalog.withAttached(c.attachedCache)
+	tx.OnRollbackHooks = append(tx.OnRollbackHooks, release)
+	tx.OnCommitHooks = append(tx.OnCommitHooks, release)
+
+	return nil
+}
+
+// session returns the session reading the database holding
+// a relation, given the name under which it is attached.
+// The relations of the database of the transaction have no database name.
+func (tx *Transaction) session(database string) (engine.Session, error) {
+	if database == "" {
+		return tx.Session, nil
+	}
+
+	atx, ok := tx.attached[database]
+	if !ok {
+		return nil, errors.Errorf("database %s is not attached", database)
+	}
+
+	return atx.Session, nil
+}
+
+// load adds the tables of the default schema of the catalog, and their indexes,
+// named after the database, and a schema named after the database.
+func (c *catalogCache) load(database string, catalog *Catalog) {
+	attached := func(name string) bool {
+		return !strings.ContainsRune(name, '.') && !strings.HasPrefix(name, InternalPrefix)
+	}
+
+	for name, r := range catalog.Cache.tables {
+		ti := r.(*TableInfoRelation).Info
+		if !attached(name) || ti.Virtual != nil {
+			continue
+		}
+
+		ti = ti.Clone()
+		ti.TableName = QualifiedName(database, name)
+		ti.ReadOnly = true
+		ti.Database = database
+		c.tables[ti.TableName] = &TableInfoRelation{Info: ti}
+	}
+
+	for name, r := range catalog.Cache.indexes {
+		info := r.(*IndexInfoRelation).Info
+		if !attached(name) || !attached(info.Owner.TableName) {
+			continue
+		}
+
+		info = info.Clone()
+		info.IndexName = QualifiedName(database, name)
+		info.Owner.TableName = QualifiedName(database, info.Owner.TableName)
+		info.Database = database
+		c.indexes[info.IndexName] = &IndexInfoRelation{Info: info}
+	}
+
+	c.schemas[database] = &SchemaInfoRelation{Info: &SchemaInfo{Name: database}}
+	c.version++
+}
+
+// unload removes the relations added by load.
+func (c *catalogCache) unload(database string) {
+	for _, m := range []map[string]Relation{c.tables, c.indexes} {
+		for name := range m {
+			if SchemaOf(name) == database {
+				delete(m, name)
+			}
+		}
+	}
+
+	delete(c.schemas, database)
+	c.version++
+}
+
+// checkAttached returns an error if the relation belongs
+// to an attached database.
+func (c *catalogCache) checkAttached(tp, name string) error {
+	if c.attached == nil {
+		return nil
+	}
+
+	if _, ok := c.attached.getMapByType(tp)[name]; ok {
+		if tp == RelationSchemaType {
+			return errAttachedReadOnly(name)
+		}
+		return errAttachedReadOnly(SchemaOf(name))
+	}
+
+	return nil
+}
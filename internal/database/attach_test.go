@@ -0,0 +1,115 @@
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachDatabase(t *testing.T) {
+	dir := t.TempDir()
+	otherPath := filepath.Join(dir, "other.chai")
+
+	other, err := chai.Open(otherPath)
+	require.NoError(t, err)
+	err = other.Exec(`
+		CREATE TABLE users (id INT PRIMARY KEY, name TEXT);
+		CREATE INDEX users_name_idx ON users (name);
+		INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+
+	db, err := chai.Open(filepath.Join(dir, "main.chai"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	count := func(conn *chai.Connection, q string) int {
+		t.Helper()
+
+		row, err := conn.QueryRow(q)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, row.Scan(&n))
+		return n
+	}
+
+	err = conn.Exec(`
+		CREATE TABLE orders (id INT PRIMARY KEY, user_id INT);
+		INSERT INTO orders (id, user_id) VALUES (1, 1), (2, 1), (3, 3);
+		ATTACH DATABASE '` + otherPath + `' AS other;
+	`)
+	require.NoError(t, err)
+
+	require.Equal(t, 3, count(conn, "SELECT COUNT(*) FROM other.users"))
+	require.Equal(t, 1, count(conn, "SELECT COUNT(*) FROM other.users WHERE name = 'b'"))
+
+	// the attached tables can be read by write transactions
+	err = conn.Exec("DELETE FROM orders USING other.users WHERE orders.user_id = users.id AND name = 'a'")
+	require.NoError(t, err)
+	require.Equal(t, 1, count(conn, "SELECT COUNT(*) FROM orders"))
+
+	err = conn.Exec("CREATE TABLE names (name TEXT); INSERT INTO names (name) SELECT name FROM other.users")
+	require.NoError(t, err)
+	require.Equal(t, 3, count(conn, "SELECT COUNT(*) FROM names"))
+
+	// but not modified
+	for _, q := range []string{
+		"INSERT INTO other.users (id, name) VALUES (4, 'd')",
+		"UPDATE other.users SET name = 'z'",
+		"DELETE FROM other.users",
+		"TRUNCATE other.users",
+		"DROP TABLE other.users",
+		"DROP INDEX other.users_name_idx",
+		"CREATE TABLE other.t (a INT)",
+		"CREATE INDEX ON other.users (id)",
+		"ALTER TABLE other.users RENAME TO people",
+	} {
+		err = conn.Exec(q)
+		require.ErrorContains(t, err, "attached read-only", q)
+	}
+
+	err = conn.Exec("CREATE SCHEMA other")
+	require.Error(t, err)
+
+	// the databases are attached outside of transactions
+	err = conn.Exec("BEGIN; DETACH DATABASE other")
+	require.ErrorContains(t, err, "within a transaction")
+
+	err = conn.Exec("ATTACH DATABASE '" + filepath.Join(dir, "missing.chai") + "' AS missing")
+	require.ErrorContains(t, err, "does not exist")
+
+	err = conn.Exec("ATTACH DATABASE '" + otherPath + "' AS other")
+	require.ErrorContains(t, err, "already attached")
+
+	// the attached databases belong to the connection
+	conn2, err := db.Connect()
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	_, err = conn2.QueryRow("SELECT COUNT(*) FROM other.users")
+	require.Error(t, err)
+
+	err = conn2.Exec("ATTACH '" + otherPath + "' AS o")
+	require.NoError(t, err)
+	require.Equal(t, 3, count(conn2, "SELECT COUNT(*) FROM o.users"))
+
+	err = conn.Exec("DETACH DATABASE other")
+	require.NoError(t, err)
+	_, err = conn.QueryRow("SELECT COUNT(*) FROM other.users")
+	require.Error(t, err)
+
+	// the database stays open until every connection detaches it
+	require.Equal(t, 3, count(conn2, "SELECT COUNT(*) FROM o.users"))
+	require.NoError(t, conn2.Close())
+
+	other, err = chai.Open(otherPath)
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"sort"
+	"time"
+
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The plan baselines are stored in the __chai_plan_baselines table,
+// one row per table read by the statement.
+var planBaselinesTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName:      PlanBaselinesTableName,
+		StoreNamespace: PlanBaselinesTableNamespace,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "fingerprint", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "table_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 2, Column: "index_name", Type: types.TypeText},
+			&ColumnConstraint{Position: 3, Column: "query", Type: types.TypeText},
+			&ColumnConstraint{Position: 4, Column: "plan", Type: types.TypeText},
+			&ColumnConstraint{Position: 5, Column: "pinned_at", Type: types.TypeTimestamp},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       PlanBaselinesTableName + "_pk",
+				Columns:    []string{"fingerprint", "table_name"},
+				PrimaryKey: true,
+			},
+		},
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// A PlanBaseline pins the way the planner reads the tables of the
+// statements with the same fingerprint: each table is read with the
+// index it was read with when the plan was captured, or without index.
+type PlanBaseline struct {
+	Fingerprint string
+	// Query is the statement the plan was captured from.
+	Query string
+	// Indexes used to read each table, by table name.
+	// Tables read without index are mapped to an empty string.
+	Indexes map[string]string
+	// Plan is the plan captured, as displayed by EXPLAIN.
+	Plan     string
+	PinnedAt time.Time
+}
+
+func (b *PlanBaseline) rows() []row.Row {
+	tables := make([]string, 0, len(b.Indexes))
+	for t := range b.Indexes {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+
+	rows := make([]row.Row, 0, len(tables))
+	for _, t := range tables {
+		var idx types.Value = types.NewNullValue()
+		if b.Indexes[t] != "" {
+			idx = types.NewTextValue(b.Indexes[t])
+		}
+
+		rows = append(rows, row.NewColumnBuffer().
+			Add("fingerprint", types.NewTextValue(b.Fingerprint)).
+			Add("table_name", types.NewTextValue(t)).
+			Add("index_name", idx).
+			Add("query", types.NewTextValue(b.Query)).
+			Add("plan", types.NewTextValue(b.Plan)).
+			Add("pinned_at", types.NewTimestampValue(b.PinnedAt)))
+	}
+
+	return rows
+}
+
+// GetPlanBaseline returns the baseline pinned for the fingerprint,
+// or nil if there is none.
+func GetPlanBaseline(tx *Transaction, fingerprint string) (*PlanBaseline, error) {
+	tb, err := tx.Catalog.GetTable(tx, PlanBaselinesTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	baselines, err := readPlanBaselines(tb, &Range{
+		Min:   Pivot{types.NewTextValue(fingerprint)},
+		Exact: true,
+	})
+	if err != nil || len(baselines) == 0 {
+		return nil, err
+	}
+
+	return baselines[0], nil
+}
+
+// ListPlanBaselines returns the pinned baselines sorted by fingerprint.
+func ListPlanBaselines(tx *Transaction) ([]*PlanBaseline, error) {
+	tb, err := tx.Catalog.GetTable(tx, PlanBaselinesTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return readPlanBaselines(tb, nil)
+}
+
+func readPlanBaselines(tb *Table, rng *Range) ([]*PlanBaseline, error) {
+	var baselines []*PlanBaseline
+
+	err := tb.IterateOnRange(rng, false, func(key *tree.Key, r Row) error {
+		var b PlanBaseline
+		var table, index string
+
+		err := r.Iterate(func(column string, v types.Value) error {
+			if v.Type() == types.TypeNull {
+				return nil
+			}
+
+			switch column {
+			case "fingerprint":
+				b.Fingerprint = types.AsString(v)
+			case "table_name":
+				table = types.AsString(v)
+			case "index_name":
+				index = types.AsString(v)
+			case "query":
+				b.Query = types.AsString(v)
+			case "plan":
+				b.Plan = types.AsString(v)
+			case "pinned_at":
+				b.PinnedAt = types.AsTime(v)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// rows of the same baseline are stored next to each other
+		if n := len(baselines); n > 0 && baselines[n-1].Fingerprint == b.Fingerprint {
+			baselines[n-1].Indexes[table] = index
+			return nil
+		}
+
+		b.Indexes = map[string]string{table: index}
+		baselines = append(baselines, &b)
+		return nil
+	})
+
+	return baselines, err
+}
+
+// PinPlanBaseline stores the baseline, replacing the one pinned
+// for the same fingerprint, if any.
+// The __chai_plan_baselines table is created if it doesn't exist.
+func PinPlanBaseline(tx *Transaction, b *PlanBaseline) error {
+	if len(b.Indexes) == 0 {
+		return errors.New("the plan doesn't read any table")
+	}
+
+	tb, err := tx.Catalog.GetTable(tx, PlanBaselinesTableName)
+	if errs.IsNotFoundError(err) {
+		err = tx.CatalogWriter().CreateTable(tx, PlanBaselinesTableName, planBaselinesTableInfo.Clone())
+		if err != nil {
+			return err
+		}
+
+		tb, err = tx.Catalog.GetTable(tx, PlanBaselinesTableName)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = deletePlanBaseline(tb, b.Fingerprint)
+	if err != nil && !errs.IsNotFoundError(err) {
+		return err
+	}
+
+	for _, r := range b.rows() {
+		_, _, err = tb.Insert(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnpinPlanBaseline deletes the baseline pinned for the fingerprint.
+// If there is none, it returns errs.NotFoundError.
+func UnpinPlanBaseline(tx *Transaction, fingerprint string) error {
+	tb, err := tx.Catalog.GetTable(tx, PlanBaselinesTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return errs.NewNotFoundError(fingerprint)
+		}
+
+		return err
+	}
+
+	err = deletePlanBaseline(tb, fingerprint)
+	if errs.IsNotFoundError(err) {
+		return errs.NewNotFoundError(fingerprint)
+	}
+	return err
+}
+
+// deletePlanBaseline deletes the rows of the baseline pinned for the fingerprint.
+// If there are none, it returns errs.NotFoundError.
+func deletePlanBaseline(tb *Table, fingerprint string) error {
+	var keys []*tree.Key
+
+	err := tb.IterateOnRange(&Range{
+		Min:   Pivot{types.NewTextValue(fingerprint)},
+		Exact: true,
+	}, false, func(key *tree.Key, _ Row) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errs.NewNotFoundError(fingerprint)
+	}
+
+	for _, k := range keys {
+		err = tb.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,360 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultBulkInsertBatchSize is the default number of rows
+// written per transaction by BulkInsert.
+const DefaultBulkInsertBatchSize = 10000
+
+// BulkInsertOptions controls how BulkInsert writes rows.
+type BulkInsertOptions struct {
+	// Number of rows written per transaction.
+	// Defaults to DefaultBulkInsertBatchSize.
+	BatchSize int
+}
+
+// BulkInsert inserts the rows returned by next into the table, until next
+// returns a nil row, and returns the number of inserted rows.
+//
+// Rows are inserted in batches, each in its own transaction.
+// The rows of a batch are validated first, then written in key order,
+// followed by the entries of each index, built in parallel and written in index order.
+// Constraints are checked like with INSERT, except deferred unique constraints
+// which are checked immediately. If a batch fails, the rows of the previous
+// batches remain inserted.
+func (db *Database) BulkInsert(ctx context.Context, tableName string, next func() (row.Row, error), opts *BulkInsertOptions) (int, error) {
+	size := DefaultBulkInsertBatchSize
+	if opts != nil && opts.BatchSize > 0 {
+		size = opts.BatchSize
+	}
+
+	var n int
+	batch := make([]row.Row, 0, size)
+	for {
+		batch = batch[:0]
+
+		var done bool
+		for len(batch) < size {
+			r, err := next()
+			if err != nil {
+				return n, err
+			}
+			if r == nil {
+				done = true
+				break
+			}
+			batch = append(batch, r)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		if len(batch) > 0 {
+			err := db.bulkInsertBatch(tableName, batch)
+			if err != nil {
+				return n, err
+			}
+			n += len(batch)
+		}
+
+		if done {
+			return n, nil
+		}
+	}
+}
+
+// bulkRow is a validated row of a batch, along with its encoded key.
+type bulkRow struct {
+	key *tree.Key
+	row BasicRow
+	enc []byte
+}
+
+// bulkIndexEntry is an entry of an index, along with its values.
+type bulkIndexEntry struct {
+	key *tree.Key
+	vs  []types.Value
+	// encoded primary key of the row.
+	pk []byte
+	// encoded values, or nil if one of them is NULL.
+	prefix []byte
+}
+
+func (db *Database) bulkInsertBatch(tableName string, batch []row.Row) error {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tableName, err = tx.Catalog.ResolveName(tx, RelationTableType, tableName)
+	if err != nil {
+		return err
+	}
+
+	t, err := tx.Catalog.GetTable(tx, tableName)
+	if err != nil {
+		return err
+	}
+	if err := t.Info.CheckWritable(); err != nil {
+		return err
+	}
+
+	rows := make([]bulkRow, len(batch))
+	for i, r := range batch {
+		br := &rows[i]
+
+		// generate default values, validate and encode row
+		br.enc, err = t.Info.EncodeRow(tx, nil, r)
+		if err != nil {
+			return err
+		}
+		br.row.ResetWith(tableName, nil, NewEncodedRow(&t.Info.ColumnConstraints, tx.Keyring(), br.enc))
+
+		// validate CHECK constraints if any
+		err = t.Info.TableConstraints.ValidateRow(tx, &br.row)
+		if err != nil {
+			return err
+		}
+
+		br.key, _, err = t.generateKey(t.Info, br.row.Row)
+		if err != nil {
+			return err
+		}
+		_, err = t.Info.EncodeKey(br.key)
+		if err != nil {
+			return err
+		}
+		br.row.key = br.key
+	}
+
+	slices.SortFunc(rows, func(a, b bulkRow) int {
+		return bytes.Compare(a.key.Encoded, b.key.Encoded)
+	})
+
+	// rowids are unique, primary keys must be checked
+	// within the batch and against the table.
+	// all the checks are done before writing, as reads
+	// may flush the pending writes of the session.
+	if pk := t.Info.PrimaryKey; pk != nil {
+		key, err := duplicateBulkKey(t.Tree, rows)
+		if err != nil {
+			return err
+		}
+		if key != nil {
+			return &ConstraintViolationError{
+				Constraint: "PRIMARY KEY",
+				Columns:    pk.Columns,
+				Key:        key,
+			}
+		}
+	}
+
+	infos, idxs, err := tableIndexes(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	entries, err := bulkIndexEntries(tx, t.Info, infos, idxs, rows)
+	if err != nil {
+		return err
+	}
+
+	for i, info := range infos {
+		if !info.Unique {
+			continue
+		}
+
+		err = checkBulkUnique(tx, t.Info, info, idxs[i], entries[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range rows {
+		err = t.Tree.Put(rows[i].key, t.Info.Compression.compress(rows[i].enc))
+		if err != nil {
+			return errors.Wrapf(err, "failed to insert row %q", rows[i].key)
+		}
+	}
+
+	for i, idx := range idxs {
+		for _, e := range entries[i] {
+			if idx.IVF != nil {
+				err = idx.Set(e.vs, e.pk)
+			} else {
+				err = idx.Tree.Put(e.key, nil)
+			}
+			if err != nil {
+				return errors.Wrap(err, "error while inserting index value")
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// duplicateBulkKey returns the first key of the sorted rows that
+// is duplicated within the rows or already present in the tree, or nil if there is none.
+// The tree is searched with a single iterator per partition, which is faster than
+// looking up every key when the keys are sorted.
+func duplicateBulkKey(tr *tree.Tree, rows []bulkRow) (*tree.Key, error) {
+	for i := 1; i < len(rows); i++ {
+		if bytes.Equal(rows[i-1].key.Encoded, rows[i].key.Encoded) {
+			return rows[i].key, nil
+		}
+	}
+
+	for _, sub := range tr.Subtrees() {
+		start, end := sub.Bounds()
+
+		// the keys of the rows of each partition are contiguous
+		lo := sort.Search(len(rows), func(i int) bool {
+			return bytes.Compare(rows[i].key.Encoded, start) >= 0
+		})
+		hi := sort.Search(len(rows), func(i int) bool {
+			return bytes.Compare(rows[i].key.Encoded, end) >= 0
+		})
+		if lo >= hi {
+			continue
+		}
+
+		key, err := existingBulkKey(sub, rows[lo:hi])
+		if key != nil || err != nil {
+			return key, err
+		}
+	}
+
+	return nil, nil
+}
+
+// existingBulkKey returns the first key of the sorted rows already present in the tree.
+func existingBulkKey(tr *tree.Tree, rows []bulkRow) (*tree.Key, error) {
+	start, end := tr.Bounds()
+	it, err := tr.Session.Iterator(&engine.IterOptions{
+		LowerBound: start,
+		UpperBound: end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	for i := range rows {
+		k := rows[i].key.Encoded
+		if !it.Valid() || bytes.Compare(it.Key(), k) < 0 {
+			if !it.SeekGE(k) {
+				// no more keys in the tree
+				break
+			}
+		}
+		if bytes.Equal(it.Key(), k) {
+			return rows[i].key, nil
+		}
+	}
+
+	return nil, it.Error()
+}
+
+// bulkIndexEntries builds the entries of every index for the rows, in parallel.
+// The entries are sorted in index order, except for those of vector indexes,
+// which are not written directly but added with Index.Set.
+func bulkIndexEntries(tx *Transaction, info *TableInfo, infos []*IndexInfo, idxs []*Index, rows []bulkRow) ([][]bulkIndexEntry, error) {
+	entries := make([][]bulkIndexEntry, len(idxs))
+	errs := make([]error, len(idxs))
+
+	var wg sync.WaitGroup
+	for i := range idxs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entries[i], errs[i] = buildBulkIndexEntries(tx.Keyring(), info, infos[i], idxs[i], rows)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+func buildBulkIndexEntries(keys *Keyring, info *TableInfo, ii *IndexInfo, idx *Index, rows []bulkRow) ([]bulkIndexEntry, error) {
+	entries := make([]bulkIndexEntry, len(rows))
+	for i := range rows {
+		vs, err := info.IndexValues(keys, ii.Columns, &rows[i].row)
+		if err != nil {
+			return nil, err
+		}
+		e := &entries[i]
+		e.vs = vs
+		e.pk = rows[i].key.Encoded
+
+		if idx.IVF != nil {
+			continue
+		}
+
+		e.key = tree.NewKey(append(slices.Clip(vs), types.NewBlobValue(e.pk))...)
+		_, err = e.key.Encode(idx.Tree.Namespace, idx.Tree.Order)
+		if err != nil {
+			return nil, err
+		}
+
+		if ii.Unique && !slices.ContainsFunc(vs, func(v types.Value) bool { return v.Type() == types.TypeNull }) {
+			e.prefix, err = tree.NewKey(vs...).Encode(idx.Tree.Namespace, idx.Tree.Order)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if idx.IVF == nil {
+		slices.SortFunc(entries, func(a, b bulkIndexEntry) int {
+			return bytes.Compare(a.key.Encoded, b.key.Encoded)
+		})
+	}
+
+	return entries, nil
+}
+
+// checkBulkUnique returns an error if the values of the sorted entries
+// are duplicated within the entries or already present in the index.
+// If the index values contain NULL, the unicity is not checked.
+func checkBulkUnique(tx *Transaction, ti *TableInfo, info *IndexInfo, idx *Index, entries []bulkIndexEntry) error {
+	for i, e := range entries {
+		if e.prefix == nil {
+			continue
+		}
+		tx.recordUniquePrefix(e.prefix)
+
+		if i > 0 && bytes.Equal(entries[i-1].prefix, e.prefix) {
+			return NewUniqueViolationError(ti, info, tree.NewEncodedKey(e.pk))
+		}
+
+		duplicate, key, err := idx.Exists(e.vs)
+		if err != nil {
+			return err
+		}
+		if duplicate {
+			return NewUniqueViolationError(ti, info, key)
+		}
+	}
+
+	return nil
+}
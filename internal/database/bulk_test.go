@@ -0,0 +1,125 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkInsert(t *testing.T) {
+	rows := func(maps ...map[string]any) func() (row.Row, error) {
+		return func() (row.Row, error) {
+			if len(maps) == 0 {
+				return nil, nil
+			}
+			r := row.NewFromMap(maps[0])
+			maps = maps[1:]
+			return r, nil
+		}
+	}
+
+	setup := func(t *testing.T) *chai.DB {
+		t.Helper()
+
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT UNIQUE, c DOUBLE DEFAULT 1.5, CHECK (a >= 0));
+			CREATE INDEX test_c_idx ON test (c);
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	count := func(t *testing.T, db *chai.DB, q string) int {
+		t.Helper()
+
+		var n int
+		r, err := db.QueryRow(q)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	ctx := context.Background()
+
+	t.Run("Rowid", func(t *testing.T) {
+		db := setup(t)
+
+		err := db.Exec("CREATE TABLE norowid(a INT); INSERT INTO norowid (a) VALUES (1)")
+		require.NoError(t, err)
+
+		n, err := db.DB.BulkInsert(ctx, "norowid", rows(map[string]any{"a": 2}, map[string]any{"a": 3}), nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, 6, count(t, db, "SELECT SUM(a) FROM norowid"))
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		db := setup(t)
+
+		err := db.Exec("CREATE TABLE strict(a INT, b TEXT) WITH (strict = true)")
+		require.NoError(t, err)
+
+		// undeclared columns are ignored by other tables
+		n, err := db.DB.BulkInsert(ctx, "test", rows(map[string]any{"a": 1, "d": 1}), nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		n, err = db.DB.BulkInsert(ctx, "strict", rows(map[string]any{"a": 1, "d": 1}), nil)
+		require.EqualError(t, err, "table has no column d")
+		require.Equal(t, 0, n)
+	})
+
+	t.Run("Constraints", func(t *testing.T) {
+		db := setup(t)
+
+		err := db.Exec("INSERT INTO test (a, b) VALUES (1, 'foo')")
+		require.NoError(t, err)
+
+		tests := []struct {
+			name string
+			rows []map[string]any
+		}{
+			{"existing primary key", []map[string]any{{"a": 1, "b": "bar"}}},
+			{"duplicate primary key", []map[string]any{{"a": 2, "b": "bar"}, {"a": 2, "b": "baz"}}},
+			{"existing unique value", []map[string]any{{"a": 2, "b": "foo"}}},
+			{"duplicate unique value", []map[string]any{{"a": 2, "b": "bar"}, {"a": 3, "b": "bar"}}},
+			{"check", []map[string]any{{"a": -1, "b": "bar"}}},
+			{"type", []map[string]any{{"a": "x", "b": "bar"}}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				n, err := db.DB.BulkInsert(ctx, "test", rows(test.rows...), nil)
+				require.Error(t, err)
+				require.Equal(t, 0, n)
+				require.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM test"))
+			})
+		}
+
+		// NULL values are not unique
+		n, err := db.DB.BulkInsert(ctx, "test", rows(map[string]any{"a": 2}, map[string]any{"a": 3}), nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("Batches", func(t *testing.T) {
+		db := setup(t)
+
+		// the second batch fails, the first one remains inserted
+		n, err := db.DB.BulkInsert(ctx, "test", rows(
+			map[string]any{"a": 1}, map[string]any{"a": 2},
+			map[string]any{"a": 3}, map[string]any{"a": 1},
+		), &database.BulkInsertOptions{BatchSize: 2})
+		require.Error(t, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, 2, count(t, db, "SELECT COUNT(*) FROM test"))
+	})
+}
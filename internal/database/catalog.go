@@ -5,6 +5,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"sync"
 
 	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/chaisql/chai/internal/pkg/atomic"
@@ -18,6 +19,23 @@ import (
 const (
 	CatalogTableName  = InternalPrefix + "catalog"
 	SequenceTableName = InternalPrefix + "sequence"
+	JobsTableName     = InternalPrefix + "jobs"
+	// IndexBuildsTableName is a read-only table reporting
+	// the progress of the index builds.
+	IndexBuildsTableName = InternalPrefix + "index_builds"
+	// QuarantineTableName is the table where Check moves
+	// the rows that cannot be decoded.
+	QuarantineTableName = InternalPrefix + "quarantine"
+	// Read-only virtual tables reporting the size of the tables,
+	// of the indexes and the state of the sequences.
+	TableStatsTableName    = InternalPrefix + "table_stats"
+	IndexStatsTableName    = InternalPrefix + "index_stats"
+	SequenceStatsTableName = InternalPrefix + "sequence_stats"
+	// UsersTableName is the table storing the users.
+	// It is not listed in the catalog.
+	UsersTableName = InternalPrefix + "users"
+	// PlanBaselinesTableName is the table storing the pinned plans.
+	PlanBaselinesTableName = InternalPrefix + "plan_baselines"
 )
 
 // Relation types
@@ -25,6 +43,15 @@ const (
 	RelationTableType    = "table"
 	RelationIndexType    = "index"
 	RelationSequenceType = "sequence"
+	RelationSchemaType   = "schema"
+	RelationFunctionType = "function"
+	// Users are stored in the catalog but their names
+	// don't conflict with the names of the other relations.
+	RelationUserType = "user"
+	// The namespace of each partition of a partitioned table
+	// and of its indexes is stored in the catalog, in a row
+	// named after the table or index and the partition.
+	RelationPartitionType = "partition"
 )
 
 // System sequences
@@ -34,14 +61,19 @@ const (
 
 // System namespaces
 const (
-	CatalogTableNamespace    tree.Namespace = 1
-	SequenceTableNamespace   tree.Namespace = 2
-	RollbackSegmentNamespace tree.Namespace = 3
-	MinTransientNamespace    tree.Namespace = math.MaxInt64 - 1<<24
-	MaxTransientNamespace    tree.Namespace = math.MaxInt64
+	CatalogTableNamespace       tree.Namespace = 1
+	SequenceTableNamespace      tree.Namespace = 2
+	RollbackSegmentNamespace    tree.Namespace = 3
+	JobsTableNamespace          tree.Namespace = 4
+	IndexBuildsTableNamespace   tree.Namespace = 5
+	QuarantineTableNamespace    tree.Namespace = 6
+	UsersTableNamespace         tree.Namespace = 7
+	PlanBaselinesTableNamespace tree.Namespace = 8
+	MinTransientNamespace       tree.Namespace = math.MaxInt64 - 1<<24
+	MaxTransientNamespace       tree.Namespace = math.MaxInt64
 )
 
-// Catalog manages all database objects such as tables, indexes and sequences.
+// Catalog manages all database objects such as tables, indexes, sequences and functions.
 // It stores all these objects in memory for fast access. Any modification
 // is persisted into the __chai_catalog table.
 type Catalog struct {
@@ -49,6 +81,10 @@ type Catalog struct {
 	CatalogTable *CatalogStore
 
 	TransientNamespaces *atomic.Counter
+
+	// relations looked up by the read/write transaction
+	// reading the catalog, nil for the other catalogs.
+	used *usedRelations
 }
 
 func NewCatalog() *Catalog {
@@ -67,7 +103,66 @@ func (c *Catalog) Clone() *Catalog {
 	}
 }
 
+// Version returns the number of changes made to the schema since the
+// database was opened. It increases with every schema change, including
+// the ones made by the transaction reading the catalog before it commits
+// and the changes made to the temporary relations and to the attached
+// databases of its connection, and is not persisted.
+func (c *Catalog) Version() uint64 {
+	v := c.Cache.version
+	if c.Cache.temp != nil {
+		v += c.Cache.temp.version
+	}
+	if c.Cache.attached != nil {
+		v += c.Cache.attached.version
+	}
+
+	return v
+}
+
+// withTemporary returns a copy of the catalog in which the temporary
+// relations of a connection are looked up before the other relations.
+func (c *Catalog) withTemporary(temp *catalogCache) *Catalog {
+	cache := *c.Cache
+	cache.temp = temp
+
+	return &Catalog{
+		Cache:               &cache,
+		CatalogTable:        c.CatalogTable,
+		TransientNamespaces: c.TransientNamespaces,
+		used:                c.used,
+	}
+}
+
+// withAttached returns a copy of the catalog in which the relations
+// of the databases attached to a connection are looked up after
+// the other relations.
+func (c *Catalog) withAttached(attached *catalogCache) *Catalog {
+	cache := *c.Cache
+	cache.attached = attached
+
+	return &Catalog{
+		Cache:               &cache,
+		CatalogTable:        c.CatalogTable,
+		TransientNamespaces: c.TransientNamespaces,
+		used:                c.used,
+	}
+}
+
+// IsTemporaryTable returns whether the table with the given name
+// is a temporary table of the connection reading the catalog.
+func (c *Catalog) IsTemporaryTable(tableName string) bool {
+	if c.Cache.temp == nil {
+		return false
+	}
+
+	_, ok := c.Cache.temp.tables[tableName]
+	return ok
+}
+
 func (c *Catalog) GetTable(tx *Transaction, tableName string) (*Table, error) {
+	c.used.add(RelationTableType, tableName)
+
 	o, err := c.Cache.Get(RelationTableType, tableName)
 	if err != nil {
 		return nil, err
@@ -75,15 +170,22 @@ func (c *Catalog) GetTable(tx *Transaction, tableName string) (*Table, error) {
 
 	ti := o.(*TableInfoRelation).Info
 
+	s, err := tx.session(ti.Database)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Table{
 		Tx:   tx,
-		Tree: tree.New(tx.Session, ti.StoreNamespace, ti.PrimaryKeySortOrder()),
+		Tree: ti.newTree(s),
 		Info: ti,
 	}, nil
 }
 
 // GetTableInfo returns the table info for the given table name.
 func (c *Catalog) GetTableInfo(tableName string) (*TableInfo, error) {
+	c.used.add(RelationTableType, tableName)
+
 	r, err := c.Cache.Get(RelationTableType, tableName)
 	if err != nil {
 		return nil, err
@@ -99,11 +201,27 @@ func (c *Catalog) GetIndex(tx *Transaction, indexName string) (*Index, error) {
 		return nil, err
 	}
 
-	return NewIndex(tree.New(tx.Session, info.StoreNamespace, info.KeySortOrder), *info), nil
+	s, err := tx.session(info.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Partitions == nil {
+		return NewIndex(tree.New(s, info.StoreNamespace, info.KeySortOrder), *info), nil
+	}
+
+	ti, err := c.GetTableInfo(info.Owner.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewIndex(info.newTree(s, ti), *info), nil
 }
 
 // GetIndexInfo returns an index info by name.
 func (c *Catalog) GetIndexInfo(indexName string) (*IndexInfo, error) {
+	c.used.add(RelationIndexType, indexName)
+
 	r, err := c.Cache.Get(RelationIndexType, indexName)
 	if err != nil {
 		return nil, err
@@ -120,6 +238,7 @@ func (c *Catalog) ListIndexes(tableName string) []string {
 		sort.Strings(list)
 		return list
 	}
+	c.used.add(RelationTableType, tableName)
 	idxs := c.Cache.GetTableIndexes(tableName)
 	list := make([]string, 0, len(idxs))
 	for _, idx := range idxs {
@@ -131,6 +250,8 @@ func (c *Catalog) ListIndexes(tableName string) []string {
 }
 
 func (c *Catalog) GetSequence(name string) (*Sequence, error) {
+	c.used.add(RelationSequenceType, name)
+
 	r, err := c.Cache.Get(RelationSequenceType, name)
 	if err != nil {
 		return nil, err
@@ -155,6 +276,98 @@ func (c *Catalog) GetFreeTransientNamespace() tree.Namespace {
 	return tree.Namespace(c.TransientNamespaces.Incr())
 }
 
+// withUsedRelations returns a copy of the catalog recording
+// the relations looked up through it.
+func (c *Catalog) withUsedRelations() *Catalog {
+	return &Catalog{
+		Cache:               c.Cache,
+		CatalogTable:        c.CatalogTable,
+		TransientNamespaces: c.TransientNamespaces,
+		used:                &usedRelations{m: make(map[relationKey]struct{})},
+	}
+}
+
+// withChanges returns a copy of the catalog to which the given
+// changes are applied.
+func (c *Catalog) withChanges(changes []relationChange) *Catalog {
+	clone := c.Clone()
+	for _, ch := range changes {
+		m := clone.Cache.getMapByType(ch.tp)
+		if ch.new == nil {
+			delete(m, ch.name)
+		} else {
+			m[ch.name] = ch.new
+		}
+	}
+	clone.Cache.version += uint64(len(changes))
+
+	return clone
+}
+
+// relationKey identifies a relation of the catalog.
+type relationKey struct {
+	tp, name string
+}
+
+// usedRelations records the relations looked up by a transaction.
+type usedRelations struct {
+	mu sync.Mutex
+	m  map[relationKey]struct{}
+}
+
+func (u *usedRelations) add(tp, name string) {
+	if u == nil {
+		return
+	}
+
+	u.mu.Lock()
+	u.m[relationKey{tp, name}] = struct{}{}
+	u.mu.Unlock()
+}
+
+func (u *usedRelations) has(tp, name string) bool {
+	if u == nil {
+		return false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	_, ok := u.m[relationKey{tp, name}]
+	return ok
+}
+
+// A relationChange is a relation added, replaced or deleted.
+// old is nil if it was added and new is nil if it was deleted.
+type relationChange struct {
+	tp, name string
+	old, new Relation
+}
+
+// changesSince returns the relations added, replaced or deleted
+// since the base catalog, from which the catalog was cloned.
+// The temporary relations and the attached databases are ignored.
+func (c *catalogCache) changesSince(base *catalogCache) []relationChange {
+	var changes []relationChange
+
+	for _, tp := range []string{RelationSchemaType, RelationTableType, RelationIndexType, RelationSequenceType, RelationFunctionType, RelationUserType} {
+		m, bm := c.getMapByType(tp), base.getMapByType(tp)
+
+		for name, r := range m {
+			if old, ok := bm[name]; !ok || old != r {
+				changes = append(changes, relationChange{tp: tp, name: name, old: bm[name], new: r})
+			}
+		}
+		for name, old := range bm {
+			if _, ok := m[name]; !ok {
+				changes = append(changes, relationChange{tp: tp, name: name, old: old})
+			}
+		}
+	}
+
+	return changes
+}
+
 // A CatalogWriter is used to apply modifications to the catalog
 // in a thread-safe manner.
 // All the updates are only visible to the current transaction
@@ -215,6 +428,17 @@ func (c *CatalogWriter) ensureSequenceExists(tx *Transaction, seq *SequenceInfo)
 	return nil
 }
 
+// generateNamespace returns the namespace of the store of a new table or index.
+// The rows of the temporary relations are stored in transient namespaces, which
+// are deleted when the database is opened if they were left by a crash.
+func (c *CatalogWriter) generateNamespace(tx *Transaction, temporary bool) (tree.Namespace, error) {
+	if temporary {
+		return c.GetFreeTransientNamespace(), nil
+	}
+
+	return c.generateStoreNamespace(tx)
+}
+
 func (c *CatalogWriter) generateStoreNamespace(tx *Transaction) (tree.Namespace, error) {
 	seq, err := c.Catalog.GetSequence(StoreSequence)
 	if err != nil {
@@ -248,8 +472,17 @@ func (c *CatalogWriter) CreateTable(tx *Transaction, tableName string, info *Tab
 		return errors.WithStack(errs.AlreadyExistsError{Name: tableName})
 	}
 
+	info.initEncryptedColumns()
+
+	if info.Partitioning != nil {
+		err = info.Partitioning.Validate(info)
+		if err != nil {
+			return err
+		}
+	}
+
 	if info.StoreNamespace == 0 {
-		info.StoreNamespace, err = c.generateStoreNamespace(tx)
+		info.StoreNamespace, err = c.generateNamespace(tx, info.Temporary)
 		if err != nil {
 			return err
 		}
@@ -261,6 +494,15 @@ func (c *CatalogWriter) CreateTable(tx *Transaction, tableName string, info *Tab
 		return err
 	}
 
+	if info.Partitioning != nil {
+		for i := range info.Partitioning.Partitions {
+			err = c.createPartitionStores(tx, info, &info.Partitioning.Partitions[i], nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return c.Catalog.Cache.Add(tx, &rel)
 }
 
@@ -271,8 +513,9 @@ func (c *CatalogWriter) DropTable(tx *Transaction, tableName string) error {
 		return err
 	}
 
-	if ti.ReadOnly {
-		return errors.New("cannot write to read-only table")
+	err = ti.CheckWritable()
+	if err != nil {
+		return err
 	}
 
 	for _, idx := range c.Cache.GetTableIndexes(tableName) {
@@ -292,12 +535,30 @@ func (c *CatalogWriter) DropTable(tx *Transaction, tableName string) error {
 		return err
 	}
 
-	err = c.CatalogTable.Delete(tx, tableName)
-	if err != nil {
-		return err
+	// temporary tables are not stored in the catalog
+	// and privileges cannot be granted on them
+	if !ti.Temporary {
+		err = c.CatalogTable.Delete(tx, tableName)
+		if err != nil {
+			return err
+		}
+
+		err = c.moveTablePrivileges(tx, tableName, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	if ti.Partitioning != nil {
+		for _, part := range ti.Partitioning.Partitions {
+			err = c.CatalogTable.deletePartition(tx, tableName, part.Name)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	return tree.New(tx.Session, ti.StoreNamespace, ti.PrimaryKeySortOrder()).Truncate()
+	return ti.newTree(tx.Session).Truncate()
 }
 
 // CreateIndex creates an index with the given name.
@@ -309,6 +570,10 @@ func (c *CatalogWriter) CreateIndex(tx *Transaction, info *IndexInfo) (*IndexInf
 		return nil, err
 	}
 
+	if ti.Database != "" {
+		return nil, errAttachedReadOnly(ti.Database)
+	}
+
 	// check if the indexed columns exist
 	for _, p := range info.Columns {
 		fc := ti.GetColumnConstraint(p)
@@ -317,7 +582,20 @@ func (c *CatalogWriter) CreateIndex(tx *Transaction, info *IndexInfo) (*IndexInf
 		}
 	}
 
-	info.StoreNamespace, err = c.generateStoreNamespace(tx)
+	err = ti.ensureIndexable(info.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IVF != nil {
+		err = ti.ensureVectorIndexable(info)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	info.Temporary = ti.Temporary
+	info.StoreNamespace, err = c.generateNamespace(tx, info.Temporary)
 	if err != nil {
 		return nil, err
 	}
@@ -333,6 +611,22 @@ func (c *CatalogWriter) CreateIndex(tx *Transaction, info *IndexInfo) (*IndexInf
 		return nil, err
 	}
 
+	if ti.Partitioning != nil {
+		info.Partitions = make(map[string]tree.Namespace, len(ti.Partitioning.Partitions))
+		for _, part := range ti.Partitioning.Partitions {
+			ns, err := c.generateStoreNamespace(tx)
+			if err != nil {
+				return nil, err
+			}
+
+			info.Partitions[part.Name] = ns
+			err = c.CatalogTable.insertPartition(tx, info.IndexName, part.Name, ns)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return info, nil
 }
 
@@ -363,6 +657,17 @@ func (c *CatalogWriter) dropIndex(tx *Transaction, info *IndexInfo) error {
 		return err
 	}
 
+	for name, ns := range info.Partitions {
+		err = c.dropPartitionStore(tx, info.IndexName, name, ns)
+		if err != nil {
+			return err
+		}
+	}
+
+	if info.Temporary {
+		return nil
+	}
+
 	return c.CatalogTable.Delete(tx, info.IndexName)
 }
 
@@ -380,6 +685,7 @@ func (c *CatalogWriter) AddColumnConstraint(tx *Transaction, tableName string, c
 		if err != nil {
 			return err
 		}
+		clone.initEncryptedColumns()
 	}
 
 	for _, tc := range tcs {
@@ -398,11 +704,39 @@ func (c *CatalogWriter) AddColumnConstraint(tx *Transaction, tableName string, c
 	return c.CatalogTable.Replace(tx, tableName, cloneRel)
 }
 
+// SetRetention sets the retention policy of a table.
+// If policy is nil, the retention policy is removed.
+func (c *CatalogWriter) SetRetention(tx *Transaction, tableName string, policy *RetentionPolicy) error {
+	r, err := c.Cache.Get(RelationTableType, tableName)
+	if err != nil {
+		return err
+	}
+	ti := r.(*TableInfoRelation).Info
+
+	if policy != nil {
+		err = policy.Validate(ti)
+		if err != nil {
+			return err
+		}
+	}
+
+	clone := ti.Clone()
+	clone.Retention = policy
+
+	cloneRel := &TableInfoRelation{Info: clone}
+	err = c.Cache.Replace(tx, cloneRel)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Replace(tx, tableName, cloneRel)
+}
+
 // RenameTable renames a table.
 // If it doesn't exist, it returns errs.ErrTableNotFound.
 func (c *CatalogWriter) RenameTable(tx *Transaction, oldName, newName string) error {
 	// Delete the old table info.
-	err := c.CatalogTable.Delete(tx, oldName)
+	o, err := c.Cache.Delete(tx, RelationTableType, oldName)
 	if errs.IsNotFoundError(err) {
 		return errors.Wrapf(err, "table %s does not exist", oldName)
 	}
@@ -410,13 +744,15 @@ func (c *CatalogWriter) RenameTable(tx *Transaction, oldName, newName string) er
 		return err
 	}
 
-	o, err := c.Cache.Delete(tx, RelationTableType, oldName)
-	if err != nil {
-		return err
-	}
-
 	ti := o.(*TableInfoRelation).Info
 
+	if !ti.Temporary {
+		err = c.CatalogTable.Delete(tx, oldName)
+		if err != nil {
+			return err
+		}
+	}
+
 	clone := ti.Clone()
 	clone.TableName = newName
 
@@ -433,6 +769,20 @@ func (c *CatalogWriter) RenameTable(tx *Transaction, oldName, newName string) er
 		return err
 	}
 
+	if ti.Partitioning != nil {
+		for _, part := range ti.Partitioning.Partitions {
+			err = c.CatalogTable.deletePartition(tx, oldName, part.Name)
+			if err != nil {
+				return err
+			}
+
+			err = c.CatalogTable.insertPartition(tx, newName, part.Name, part.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	for _, idx := range c.Cache.GetTableIndexes(oldName) {
 		r, err := c.Cache.Delete(tx, RelationIndexType, idx.IndexName)
 		if err != nil {
@@ -483,7 +833,11 @@ func (c *CatalogWriter) RenameTable(tx *Transaction, oldName, newName string) er
 		}
 	}
 
-	return nil
+	if ti.Temporary {
+		return nil
+	}
+
+	return c.moveTablePrivileges(tx, oldName, newName)
 }
 
 // CreateSequence creates a sequence with the given name.
@@ -526,9 +880,43 @@ func (c *CatalogWriter) DropSequence(tx *Transaction, name string) error {
 		return err
 	}
 
+	if seq.Info.Temporary {
+		return nil
+	}
+
 	return c.CatalogTable.Delete(tx, name)
 }
 
+// AlterSequence replaces the information of an existing sequence.
+// Values already returned by the sequence are kept, unless restart is not nil,
+// in which case the next value returned by the sequence is *restart.
+func (c *CatalogWriter) AlterSequence(tx *Transaction, info *SequenceInfo, restart *int64) error {
+	seq, err := c.Catalog.GetSequence(info.Name)
+	if err != nil {
+		return err
+	}
+
+	clone := seq.Clone().(*Sequence)
+	clone.Info = info
+	if restart != nil {
+		err = clone.restart(tx, *restart)
+	} else {
+		// store the current value so that the next call to Next
+		// takes a new lease using the new information.
+		err = clone.Release(tx)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = c.Cache.Replace(tx, clone)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Replace(tx, info.Name, clone)
+}
+
 type Relation interface {
 	Type() string
 	Name() string
@@ -594,20 +982,43 @@ func columnsToIndexName(columns []string) string {
 }
 
 type catalogCache struct {
+	// incremented every time a relation is added, replaced or deleted.
+	version uint64
+
+	schemas   map[string]Relation
 	tables    map[string]Relation
 	indexes   map[string]Relation
 	sequences map[string]Relation
+	functions map[string]Relation
+	users     map[string]Relation
+
+	// temporary relations of the connection reading the catalog,
+	// looked up before the other relations. Their names don't
+	// conflict with the names of the other relations.
+	temp *catalogCache
+
+	// relations of the databases attached to the connection reading
+	// the catalog, named after the database, which is also the name
+	// of a schema. They are looked up after the other relations.
+	attached *catalogCache
 }
 
 func newCatalogCache() *catalogCache {
 	return &catalogCache{
+		schemas:   make(map[string]Relation),
 		tables:    make(map[string]Relation),
 		indexes:   make(map[string]Relation),
 		sequences: make(map[string]Relation),
+		functions: make(map[string]Relation),
+		users:     make(map[string]Relation),
 	}
 }
 
-func (c *catalogCache) Load(tables []TableInfo, indexes []IndexInfo, sequences []Sequence) {
+func (c *catalogCache) Load(schemas []SchemaInfo, tables []TableInfo, indexes []IndexInfo, sequences []Sequence, functions []FunctionInfo, users []UserInfo) {
+	for i := range schemas {
+		c.schemas[schemas[i].Name] = &SchemaInfoRelation{Info: &schemas[i]}
+	}
+
 	for i := range tables {
 		c.tables[tables[i].TableName] = &TableInfoRelation{Info: &tables[i]}
 	}
@@ -619,11 +1030,23 @@ func (c *catalogCache) Load(tables []TableInfo, indexes []IndexInfo, sequences [
 	for i := range sequences {
 		c.sequences[sequences[i].Info.Name] = &sequences[i]
 	}
+
+	for i := range functions {
+		c.functions[functions[i].Name] = &FunctionInfoRelation{Info: &functions[i]}
+	}
+
+	for i := range users {
+		c.users[users[i].Name] = &UserInfoRelation{Info: &users[i]}
+	}
 }
 
 func (c *catalogCache) Clone() *catalogCache {
 	clone := newCatalogCache()
+	clone.version = c.version
 
+	for k, v := range c.schemas {
+		clone.schemas[k] = v
+	}
 	for k, v := range c.tables {
 		clone.tables[k] = v
 	}
@@ -633,11 +1056,27 @@ func (c *catalogCache) Clone() *catalogCache {
 	for k, v := range c.sequences {
 		clone.sequences[k] = v
 	}
+	for k, v := range c.functions {
+		clone.functions[k] = v
+	}
+	for k, v := range c.users {
+		clone.users[k] = v
+	}
+
+	// the temporary relations and the attached databases
+	// belong to the connection
+	clone.temp = c.temp
+	clone.attached = c.attached
 
 	return clone
 }
 
 func (c *catalogCache) objectExists(name string) bool {
+	// checking if schema exists with the same name
+	if _, ok := c.schemas[name]; ok {
+		return true
+	}
+
 	// checking if table exists with the same name
 	if _, ok := c.tables[name]; ok {
 		return true
@@ -653,7 +1092,16 @@ func (c *catalogCache) objectExists(name string) bool {
 		return true
 	}
 
-	return false
+	// checking if function exists with the same name
+	if _, ok := c.functions[name]; ok {
+		return true
+	}
+
+	if c.attached != nil && c.attached.objectExists(name) {
+		return true
+	}
+
+	return c.temp != nil && c.temp.objectExists(name)
 }
 
 func (c *catalogCache) generateUnusedName(baseName string) string {
@@ -679,6 +1127,12 @@ func (c *catalogCache) getMapByType(tp string) map[string]Relation {
 		return c.indexes
 	case RelationSequenceType:
 		return c.sequences
+	case RelationSchemaType:
+		return c.schemas
+	case RelationFunctionType:
+		return c.functions
+	case RelationUserType:
+		return c.users
 	}
 
 	panic(fmt.Sprintf("unknown catalog object type %q", tp))
@@ -687,8 +1141,13 @@ func (c *catalogCache) getMapByType(tp string) map[string]Relation {
 func (c *catalogCache) Add(tx *Transaction, o Relation) error {
 	name := o.Name()
 
-	// if name is provided, ensure it's not duplicated
-	if name != "" {
+	// users have their own namespace
+	if o.Type() == RelationUserType {
+		if _, ok := c.users[name]; ok {
+			return errors.WithStack(errs.AlreadyExistsError{Name: name})
+		}
+	} else if name != "" {
+		// if name is provided, ensure it's not duplicated
 		if c.objectExists(name) {
 			return errors.WithStack(errs.AlreadyExistsError{Name: name})
 		}
@@ -698,8 +1157,18 @@ func (c *catalogCache) Add(tx *Transaction, o Relation) error {
 		o.SetName(name)
 	}
 
-	m := c.getMapByType(o.Type())
+	cc := c
+	if isTemporary(o) {
+		var err error
+		cc, err = c.tempCache(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	m := cc.getMapByType(o.Type())
 	m[name] = o
+	cc.version++
 
 	tx.OnRollbackHooks = append(tx.OnRollbackHooks, func() {
 		delete(m, name)
@@ -708,15 +1177,55 @@ func (c *catalogCache) Add(tx *Transaction, o Relation) error {
 	return nil
 }
 
+// tempCache returns the cache of the temporary relations of the connection
+// of the transaction, creating it with the first temporary relation.
+func (c *catalogCache) tempCache(tx *Transaction) (*catalogCache, error) {
+	if c.temp != nil {
+		return c.temp, nil
+	}
+
+	if tx.conn == nil {
+		return nil, errors.New("temporary relations can only be created by a connection")
+	}
+	if tx.conn.temp == nil {
+		tx.conn.temp = newCatalogCache()
+	}
+
+	c.temp = tx.conn.temp
+	return c.temp, nil
+}
+
+// isTemporary returns whether the relation belongs to a temporary table.
+func isTemporary(r Relation) bool {
+	switch t := r.(type) {
+	case *TableInfoRelation:
+		return t.Info.Temporary
+	case *IndexInfoRelation:
+		return t.Info.Temporary
+	case *Sequence:
+		return t.Info.Temporary
+	}
+
+	return false
+}
+
 func (c *catalogCache) Replace(tx *Transaction, o Relation) error {
+	if isTemporary(o) && c.temp != nil {
+		return c.temp.Replace(tx, o)
+	}
+
 	m := c.getMapByType(o.Type())
 
 	old, ok := m[o.Name()]
 	if !ok {
+		if err := c.checkAttached(o.Type(), o.Name()); err != nil {
+			return err
+		}
 		return errs.NewNotFoundError(o.Name())
 	}
 
 	m[o.Name()] = o
+	c.version++
 
 	tx.OnRollbackHooks = append(tx.OnRollbackHooks, func() {
 		m[o.Name()] = old
@@ -726,14 +1235,24 @@ func (c *catalogCache) Replace(tx *Transaction, o Relation) error {
 }
 
 func (c *catalogCache) Delete(tx *Transaction, tp, name string) (Relation, error) {
+	if c.temp != nil {
+		if _, ok := c.temp.getMapByType(tp)[name]; ok {
+			return c.temp.Delete(tx, tp, name)
+		}
+	}
+
 	m := c.getMapByType(tp)
 
 	o, ok := m[name]
 	if !ok {
+		if err := c.checkAttached(tp, name); err != nil {
+			return nil, err
+		}
 		return nil, errs.NewNotFoundError(name)
 	}
 
 	delete(m, name)
+	c.version++
 
 	tx.OnRollbackHooks = append(tx.OnRollbackHooks, func() {
 		m[name] = o
@@ -743,14 +1262,26 @@ func (c *catalogCache) Delete(tx *Transaction, tp, name string) (Relation, error
 }
 
 func (c *catalogCache) Get(tp, name string) (Relation, error) {
+	if c.temp != nil {
+		if o, ok := c.temp.getMapByType(tp)[name]; ok {
+			return o, nil
+		}
+	}
+
 	m := c.getMapByType(tp)
 
 	o, ok := m[name]
-	if !ok {
-		return nil, errs.NewNotFoundError(name)
+	if ok {
+		return o, nil
 	}
 
-	return o, nil
+	if c.attached != nil {
+		if o, ok := c.attached.getMapByType(tp)[name]; ok {
+			return o, nil
+		}
+	}
+
+	return nil, errs.NewNotFoundError(name)
 }
 
 func (c *catalogCache) ListObjects(tp string) []string {
@@ -761,11 +1292,32 @@ func (c *catalogCache) ListObjects(tp string) []string {
 		list = append(list, name)
 	}
 
+	if c.temp != nil {
+		for name := range c.temp.getMapByType(tp) {
+			// the temporary relations hide the relations
+			// created with the same name by other connections
+			if _, ok := m[name]; !ok {
+				list = append(list, name)
+			}
+		}
+	}
+
 	sort.Strings(list)
 	return list
 }
 
 func (c *catalogCache) GetTableIndexes(tableName string) []*IndexInfo {
+	if c.temp != nil {
+		if _, ok := c.temp.tables[tableName]; ok {
+			return c.temp.GetTableIndexes(tableName)
+		}
+	}
+	if c.attached != nil {
+		if _, ok := c.attached.tables[tableName]; ok {
+			return c.attached.GetTableIndexes(tableName)
+		}
+	}
+
 	var indexes []*IndexInfo
 	for _, o := range c.indexes {
 		idx := o.(*IndexInfoRelation).Info
@@ -855,7 +1407,12 @@ func (s *CatalogStore) Table(tx *Transaction) *Table {
 }
 
 // Insert a catalog object to the table.
+// Temporary relations are not stored.
 func (s *CatalogStore) Insert(tx *Transaction, r Relation) error {
+	if isTemporary(r) {
+		return nil
+	}
+
 	tb := s.Table(tx)
 
 	_, _, err := tb.Insert(relationToRow(r))
@@ -867,7 +1424,12 @@ func (s *CatalogStore) Insert(tx *Transaction, r Relation) error {
 }
 
 // Replace a catalog object with another.
+// Temporary relations are not stored.
 func (s *CatalogStore) Replace(tx *Transaction, name string, r Relation) error {
+	if isTemporary(r) {
+		return nil
+	}
+
 	tb := s.Table(tx)
 
 	key := tree.NewKey(types.NewTextValue(name))
@@ -883,6 +1445,17 @@ func (s *CatalogStore) Delete(tx *Transaction, name string) error {
 	return tb.Delete(key)
 }
 
+// insertPartition stores the namespace of a partition of a table or an index.
+func (s *CatalogStore) insertPartition(tx *Transaction, owner, partition string, ns tree.Namespace) error {
+	_, _, err := s.Table(tx).Insert(partitionToRow(owner, partition, ns))
+	return err
+}
+
+// deletePartition removes the namespace of a partition of a table or an index.
+func (s *CatalogStore) deletePartition(tx *Transaction, owner, partition string) error {
+	return s.Delete(tx, partitionRowName(owner, partition))
+}
+
 func relationToRow(r Relation) row.Row {
 	switch t := r.(type) {
 	case *TableInfoRelation:
@@ -891,6 +1464,10 @@ func relationToRow(r Relation) row.Row {
 		return indexInfoToRow(t.Info)
 	case *Sequence:
 		return sequenceInfoToRow(t.Info)
+	case *SchemaInfoRelation:
+		return schemaInfoToRow(t.Info)
+	case *FunctionInfoRelation:
+		return functionInfoToRow(t.Info)
 	}
 
 	panic(fmt.Sprintf("relationToObject: unknown type %q", r.Type()))
@@ -940,3 +1517,21 @@ func sequenceInfoToRow(seq *SequenceInfo) row.Row {
 
 	return buf
 }
+
+func schemaInfoToRow(s *SchemaInfo) row.Row {
+	buf := row.NewColumnBuffer()
+	buf.Add("name", types.NewTextValue(s.Name))
+	buf.Add("type", types.NewTextValue(RelationSchemaType))
+	buf.Add("sql", types.NewTextValue(s.String()))
+
+	return buf
+}
+
+func functionInfoToRow(f *FunctionInfo) row.Row {
+	buf := row.NewColumnBuffer()
+	buf.Add("name", types.NewTextValue(f.Name))
+	buf.Add("type", types.NewTextValue(RelationFunctionType))
+	buf.Add("sql", types.NewTextValue(f.String()))
+
+	return buf
+}
@@ -564,6 +564,35 @@ func TestCatalogCreateSequence(t *testing.T) {
 	})
 }
 
+func TestCatalogVersion(t *testing.T) {
+	db := testutil.NewTestDB(t)
+
+	v := db.Catalog().Version()
+
+	updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+		require.NoError(t, catalog.CreateTable(tx, "test", nil))
+		require.Equal(t, v+1, tx.Catalog.Version())
+
+		// the catalog of the database doesn't change until the commit
+		require.Equal(t, v, db.Catalog().Version())
+		return nil
+	})
+	require.Equal(t, v+1, db.Catalog().Version())
+
+	updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+		require.NoError(t, catalog.DropTable(tx, "test"))
+		return errDontCommit
+	})
+	require.Equal(t, v+1, db.Catalog().Version())
+
+	// reads don't change the version
+	updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+		_, err := catalog.GetTable(tx, "test")
+		return err
+	})
+	require.Equal(t, v+1, db.Catalog().Version())
+}
+
 func TestCatalogConcurrency(t *testing.T) {
 	db, err := chai.Open(":memory:")
 	require.NoError(t, err)
@@ -636,3 +665,76 @@ func TestCatalogConcurrency(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "CREATE TABLE test (a INTEGER)", s)
 }
+
+func TestTemporaryTables(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn1, err := db.Connect()
+	require.NoError(t, err)
+	defer conn1.Close()
+
+	err = conn1.Exec(`
+		CREATE TEMP TABLE test (a int UNIQUE);
+		INSERT INTO test (a) VALUES (1), (2);
+	`)
+	require.NoError(t, err)
+
+	// the table is visible to the connection that created it
+	row, err := conn1.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	var i int
+	require.NoError(t, row.Scan(&i))
+	require.Equal(t, 2, i)
+
+	// but not to other connections
+	conn2, err := db.Connect()
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	_, err = conn2.QueryRow("SELECT COUNT(*) FROM test")
+	require.Error(t, err)
+
+	// and it is dropped when the connection is closed
+	require.NoError(t, conn1.Close())
+
+	conn3, err := db.Connect()
+	require.NoError(t, err)
+	defer conn3.Close()
+
+	err = conn3.Exec("CREATE TABLE test (a int)")
+	require.NoError(t, err)
+	row, err = conn3.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	require.NoError(t, row.Scan(&i))
+	require.Equal(t, 0, i)
+}
+
+func TestCatalogDefaultExpressions(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := chai.Open(dir)
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE t(
+		a INT PRIMARY KEY,
+		b UUID NOT NULL DEFAULT uuid(),
+		c TIMESTAMP NOT NULL DEFAULT now(),
+		d TEXT DEFAULT upper('a') || 'b'
+	)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// the default values are parsed again from the catalog
+	db, err = chai.Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`INSERT INTO t (a) VALUES (1), (2)`)
+	require.NoError(t, err)
+
+	r, err := db.QueryRow(`SELECT COUNT(DISTINCT b) AS b, COUNT(c) AS c, MIN(d) AS d FROM t`)
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"b": 2, "c": 2, "d": "Ab"}`)
+}
@@ -19,7 +19,7 @@ func LoadCatalog(tx *database.Transaction) error {
 		return err
 	}
 
-	tables, indexes, sequences, err := loadCatalogStore(tx, tx.Catalog.CatalogTable)
+	schemas, tables, indexes, sequences, functions, err := loadCatalogStore(tx, tx.Catalog.CatalogTable)
 	if err != nil {
 		return errors.Wrap(err, "failed to load catalog store")
 	}
@@ -31,8 +31,21 @@ func LoadCatalog(tx *database.Transaction) error {
 	ti.ReadOnly = true
 	tables = append(tables, *ti)
 
-	// load tables and indexes first
-	tx.Catalog.Cache.Load(tables, indexes, nil)
+	// the progress of the index builds is reported in a read-only table
+	// which is written directly by the builds.
+	tables = append(tables, *database.IndexBuildsTableInfo())
+
+	// the statistics tables and the information schema
+	// compute their rows when they are read.
+	tables = append(tables, database.VirtualTables()...)
+
+	users, err := loadUsers(tx)
+	if err != nil {
+		return errors.Wrap(err, "failed to load users")
+	}
+
+	// load schemas, tables, indexes, functions and users first
+	tx.Catalog.Cache.Load(schemas, tables, indexes, nil, functions, users)
 
 	if len(sequences) > 0 {
 		var seqList []database.Sequence
@@ -41,7 +54,7 @@ func LoadCatalog(tx *database.Transaction) error {
 			return errors.Wrap(err, "failed to load sequences")
 		}
 
-		tx.Catalog.Cache.Load(nil, nil, seqList)
+		tx.Catalog.Cache.Load(nil, nil, nil, seqList, nil, nil)
 	}
 
 	return nil
@@ -78,9 +91,60 @@ func loadSequences(tx *database.Transaction, info []database.SequenceInfo) ([]da
 	return sequences, nil
 }
 
-func loadCatalogStore(tx *database.Transaction, s *database.CatalogStore) (tables []database.TableInfo, indexes []database.IndexInfo, sequences []database.SequenceInfo, err error) {
+// loadUsers reads the users from their table. Each user is stored
+// as a CREATE USER statement followed by the GRANT statements
+// of its privileges.
+func loadUsers(tx *database.Transaction) ([]database.UserInfo, error) {
+	var users []database.UserInfo
+
+	err := database.UsersTable(tx).IterateOnRange(nil, false, func(key *tree.Key, r database.Row) error {
+		s, err := r.Get("sql")
+		if err != nil {
+			return err
+		}
+
+		var u *database.UserInfo
+		err = parser.NewParser(strings.NewReader(types.AsString(s))).Parse(func(stmt statement.Statement) error {
+			switch t := stmt.(type) {
+			case *statement.CreateUserStmt:
+				u = &t.Info
+				u.Privileges = make(map[string]database.Privilege)
+				if t.Password != nil {
+					u.PasswordHash = *t.Password
+				}
+			case *statement.GrantStmt:
+				if u == nil {
+					return errors.New("GRANT before CREATE USER")
+				}
+				for _, tb := range t.Tables {
+					u.Privileges[tb] |= t.Privileges
+				}
+			default:
+				return errors.Errorf("unexpected statement %T", stmt)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to decode user")
+		}
+		if u == nil {
+			return errors.New("failed to decode user: missing CREATE USER statement")
+		}
+
+		users = append(users, *u)
+		return nil
+	})
+
+	return users, err
+}
+
+func loadCatalogStore(tx *database.Transaction, s *database.CatalogStore) (schemas []database.SchemaInfo, tables []database.TableInfo, indexes []database.IndexInfo, sequences []database.SequenceInfo, functions []database.FunctionInfo, err error) {
 	tb := s.Table(tx)
 
+	// namespaces of the partitions, by owner and partition name
+	partitions := make(map[string]map[string]tree.Namespace)
+
 	err = tb.IterateOnRange(nil, false, func(key *tree.Key, r database.Row) error {
 		tp, err := r.Get("type")
 		if err != nil {
@@ -88,6 +152,12 @@ func loadCatalogStore(tx *database.Transaction, s *database.CatalogStore) (table
 		}
 
 		switch types.AsString(tp) {
+		case database.RelationSchemaType:
+			name, err := r.Get("name")
+			if err != nil {
+				return errors.Wrap(err, "failed to decode schema info")
+			}
+			schemas = append(schemas, database.SchemaInfo{Name: types.AsString(name)})
 		case database.RelationTableType:
 			ti, err := tableInfoFromRow(r)
 			if err != nil {
@@ -107,13 +177,103 @@ func loadCatalogStore(tx *database.Transaction, s *database.CatalogStore) (table
 				return errors.Wrap(err, "failed to decode sequence info")
 			}
 			sequences = append(sequences, *i)
+		case database.RelationFunctionType:
+			f, err := functionInfoFromRow(r)
+			if err != nil {
+				return errors.Wrap(err, "failed to decode function info")
+			}
+			functions = append(functions, *f)
+		case database.RelationPartitionType:
+			owner, name, ns, err := partitionFromRow(r)
+			if err != nil {
+				return errors.Wrap(err, "failed to decode partition")
+			}
+			if partitions[owner] == nil {
+				partitions[owner] = make(map[string]tree.Namespace)
+			}
+			partitions[owner][name] = ns
 		}
 
 		return nil
 	})
+	if err != nil {
+		return
+	}
+
+	err = attachPartitions(partitions, tables, indexes)
 	return
 }
 
+func partitionFromRow(r database.Row) (owner, name string, ns tree.Namespace, err error) {
+	v, err := r.Get("name")
+	if err != nil {
+		return
+	}
+	name = types.AsString(v)
+
+	v, err = r.Get("owner_table_name")
+	if err != nil {
+		return
+	}
+	owner = types.AsString(v)
+	if !strings.HasPrefix(name, owner+"/") {
+		err = errors.Errorf("invalid partition name %q", name)
+		return
+	}
+	name = name[len(owner)+1:]
+
+	v, err = r.Get("namespace")
+	if err != nil {
+		return
+	}
+	ns = tree.Namespace(types.AsInt64(v))
+	if ns <= 0 {
+		err = errors.Errorf("invalid store namespace: %v", ns)
+	}
+
+	return
+}
+
+// attachPartitions sets the namespaces of the partitions
+// of the partitioned tables and of their indexes.
+func attachPartitions(partitions map[string]map[string]tree.Namespace, tables []database.TableInfo, indexes []database.IndexInfo) error {
+	byTable := make(map[string]*database.TablePartitioning)
+
+	for i := range tables {
+		p := tables[i].Partitioning
+		if p == nil {
+			continue
+		}
+		byTable[tables[i].TableName] = p
+
+		for j := range p.Partitions {
+			ns, ok := partitions[tables[i].TableName][p.Partitions[j].Name]
+			if !ok {
+				return errors.Errorf("missing namespace of partition %s of table %s", p.Partitions[j].Name, tables[i].TableName)
+			}
+			p.Partitions[j].Namespace = ns
+		}
+	}
+
+	for i := range indexes {
+		p := byTable[indexes[i].Owner.TableName]
+		if p == nil {
+			continue
+		}
+
+		indexes[i].Partitions = make(map[string]tree.Namespace, len(p.Partitions))
+		for _, part := range p.Partitions {
+			ns, ok := partitions[indexes[i].IndexName][part.Name]
+			if !ok {
+				return errors.Errorf("missing namespace of partition %s of index %s", part.Name, indexes[i].IndexName)
+			}
+			indexes[i].Partitions[part.Name] = ns
+		}
+	}
+
+	return nil
+}
+
 func tableInfoFromRow(r database.Row) (*database.TableInfo, error) {
 	s, err := r.Get("sql")
 	if err != nil {
@@ -211,6 +371,21 @@ func sequenceInfoFromRow(r database.Row) (*database.SequenceInfo, error) {
 	return &i, nil
 }
 
+func functionInfoFromRow(r database.Row) (*database.FunctionInfo, error) {
+	s, err := r.Get("sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get sql field")
+	}
+
+	stmt, err := parser.NewParser(strings.NewReader(types.AsString(s))).ParseStatement()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse sql")
+	}
+
+	i := stmt.(*statement.CreateFunctionStmt).Info
+	return &i, nil
+}
+
 func ownerFromRow(r database.Row) (*database.Owner, error) {
 	var owner database.Owner
 
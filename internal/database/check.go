@@ -0,0 +1,606 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Kinds of problems found by Check
+const (
+	// An object of the catalog references an object that doesn't exist,
+	// or is stored in the same namespace as another object.
+	CheckCatalog = "catalog"
+	// The key of a row cannot be decoded.
+	CheckInvalidKey = "invalid-key"
+	// The value of a row cannot be decoded with the columns of its table.
+	CheckUndecodableRow = "undecodable-row"
+	// Keys are stored in a namespace that doesn't belong to any table or index,
+	// for example because a table was dropped while its keys were being deleted.
+	CheckOrphanedKeys = "orphaned-keys"
+	// The value of a sequence is stored but the sequence doesn't exist.
+	CheckOrphanedSequence = "orphaned-sequence"
+	// An index doesn't match its table. See CheckIndex.
+	CheckIndexMismatch = "index-mismatch"
+)
+
+var quarantineTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName:      QuarantineTableName,
+		StoreNamespace: QuarantineTableNamespace,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "table_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "key", Type: types.TypeBlob, IsNotNull: true},
+			&ColumnConstraint{Position: 2, Column: "value", Type: types.TypeBlob},
+			&ColumnConstraint{Position: 3, Column: "problem", Type: types.TypeText},
+			&ColumnConstraint{Position: 4, Column: "quarantined_at", Type: types.TypeTimestamp},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       QuarantineTableName + "_pk",
+				Columns:    []string{"table_name", "key"},
+				PrimaryKey: true,
+			},
+		},
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// CheckOptions controls how Check verifies the database.
+type CheckOptions struct {
+	// If true, the rows that cannot be decoded are removed from their table
+	// and stored in the __chai_quarantine table, with their raw key and value.
+	// The rows of the system tables are never quarantined.
+	Quarantine bool
+}
+
+// An IntegrityProblem is an inconsistency found by Check.
+type IntegrityProblem struct {
+	Kind string
+	// Name of the table, index or sequence, if any.
+	Object string
+	// Raw key of the row or entry, if any.
+	Key     []byte
+	Message string
+	// True if the row was moved to the __chai_quarantine table.
+	Quarantined bool
+}
+
+func (p IntegrityProblem) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(p.Kind)
+	if p.Object != "" {
+		sb.WriteString(": ")
+		sb.WriteString(p.Object)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(p.Message)
+	if p.Quarantined {
+		sb.WriteString(" (quarantined)")
+	}
+
+	return sb.String()
+}
+
+// A CheckReport lists the problems found by Check.
+type CheckReport struct {
+	Tables    int
+	Indexes   int
+	Sequences int
+	// Rows is the number of rows read, in every table.
+	Rows     int64
+	Problems []IntegrityProblem
+}
+
+// Check verifies the integrity of the database: the catalog, the rows of every
+// table, the entries of every index and the values of the sequences.
+// It also looks for keys that don't belong to any table or index.
+// Every row is decoded, without panicking if it is invalid.
+//
+// The indexes of a table with rows that cannot be decoded, and that are not
+// quarantined, are not checked.
+// Check reads the whole database in a single transaction.
+func (db *Database) Check(ctx context.Context, opts *CheckOptions) (*CheckReport, error) {
+	if opts == nil {
+		opts = &CheckOptions{}
+	}
+
+	tx, err := db.Begin(opts.Quarantine)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	c := checker{
+		ctx:  ctx,
+		tx:   tx,
+		opts: opts,
+	}
+
+	err = c.check()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.quarantined {
+		err = tx.Commit()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &c.report, nil
+}
+
+type checker struct {
+	ctx    context.Context
+	tx     *Transaction
+	opts   *CheckOptions
+	report CheckReport
+
+	// tables with rows that cannot be decoded and were not quarantined
+	broken      map[string]bool
+	quarantined bool
+}
+
+func (c *checker) problem(kind, object string, key []byte, format string, args ...any) {
+	c.report.Problems = append(c.report.Problems, IntegrityProblem{
+		Kind:    kind,
+		Object:  object,
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) check() error {
+	// virtual tables are not stored
+	tables := slices.DeleteFunc(c.tx.Catalog.Cache.ListObjects(RelationTableType), func(name string) bool {
+		info, err := c.tx.Catalog.GetTableInfo(name)
+		return err == nil && info.Virtual != nil
+	})
+	slices.Sort(tables)
+	indexes := c.tx.Catalog.ListIndexes("")
+	sequences := c.tx.Catalog.ListSequences()
+	slices.Sort(sequences)
+
+	c.report.Tables = len(tables)
+	c.report.Indexes = len(indexes)
+	c.report.Sequences = len(sequences)
+
+	namespaces, err := c.checkCatalog(tables, indexes, sequences)
+	if err != nil {
+		return err
+	}
+
+	c.broken = make(map[string]bool)
+	for _, name := range tables {
+		err = c.checkTable(name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check table %q", name)
+		}
+	}
+
+	err = c.checkSequences(sequences)
+	if err != nil {
+		return errors.Wrap(err, "failed to check sequences")
+	}
+
+	for _, name := range indexes {
+		err = c.checkIndex(name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check index %q", name)
+		}
+	}
+
+	return c.checkNamespaces(namespaces)
+}
+
+// checkCatalog verifies that the objects of the catalog reference existing
+// objects and returns the namespaces used by the tables and the indexes.
+func (c *checker) checkCatalog(tables, indexes, sequences []string) (map[tree.Namespace]string, error) {
+	namespaces := map[tree.Namespace]string{
+		CatalogTableNamespace:       CatalogTableName,
+		SequenceTableNamespace:      SequenceTableName,
+		RollbackSegmentNamespace:    "rollback segment",
+		JobsTableNamespace:          JobsTableName,
+		IndexBuildsTableNamespace:   IndexBuildsTableName,
+		QuarantineTableNamespace:    QuarantineTableName,
+		UsersTableNamespace:         UsersTableName,
+		PlanBaselinesTableNamespace: PlanBaselinesTableName,
+	}
+
+	use := func(ns tree.Namespace, name string) {
+		if other, ok := namespaces[ns]; ok && other != name {
+			c.problem(CheckCatalog, name, nil, "namespace %d is also used by %s", ns, other)
+			return
+		}
+		namespaces[ns] = name
+	}
+
+	for _, name := range tables {
+		info, err := c.tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		use(info.StoreNamespace, name)
+		if info.Partitioning != nil {
+			for _, part := range info.Partitioning.Partitions {
+				use(part.Namespace, name)
+			}
+		}
+	}
+
+	for _, name := range indexes {
+		info, err := c.tx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		use(info.StoreNamespace, name)
+		for _, ns := range info.Partitions {
+			use(ns, name)
+		}
+
+		_, err = c.tx.Catalog.GetTableInfo(info.Owner.TableName)
+		if errs.IsNotFoundError(err) {
+			c.problem(CheckCatalog, name, nil, "index of table %s which doesn't exist", info.Owner.TableName)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range sequences {
+		seq, err := c.tx.Catalog.GetSequence(name)
+		if err != nil {
+			return nil, err
+		}
+
+		owner := seq.Info.Owner.TableName
+		if owner == "" {
+			continue
+		}
+		_, err = c.tx.Catalog.GetTableInfo(owner)
+		if errs.IsNotFoundError(err) {
+			c.problem(CheckCatalog, name, nil, "sequence owned by table %s which doesn't exist", owner)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return namespaces, nil
+}
+
+// quarantinedRow is a row that cannot be decoded.
+type quarantinedRow struct {
+	key, value []byte
+	problem    string
+}
+
+// checkTable decodes every row of the table.
+func (c *checker) checkTable(name string) error {
+	t, err := c.tx.Catalog.GetTable(c.tx, name)
+	if err != nil {
+		return err
+	}
+
+	bad, err := c.scanTable(t)
+	if err != nil {
+		return err
+	}
+
+	if len(bad) == 0 {
+		return nil
+	}
+
+	if !c.opts.Quarantine || strings.HasPrefix(name, InternalPrefix) {
+		c.broken[name] = true
+		return nil
+	}
+
+	for i := range bad {
+		err = c.quarantine(t, &bad[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanTable reports the rows of the table that cannot be decoded and returns them.
+func (c *checker) scanTable(t *Table) ([]quarantinedRow, error) {
+	quarantine := c.opts.Quarantine && !strings.HasPrefix(t.Info.TableName, InternalPrefix)
+
+	var bad []quarantinedRow
+	// the rows of a partitioned table are stored in its partitions
+	for _, tr := range t.Tree.Subtrees() {
+		var err error
+		bad, err = c.scanTree(t, tr, quarantine, bad)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bad, nil
+}
+
+// scanTree decodes every row of a tree of the table
+// and appends those that cannot be decoded to bad.
+func (c *checker) scanTree(t *Table, tr *tree.Tree, quarantine bool, bad []quarantinedRow) ([]quarantinedRow, error) {
+	start, end := tr.Bounds()
+	it, err := c.tx.Session.Iterator(&engine.IterOptions{
+		LowerBound: start,
+		UpperBound: end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	for it.First(); it.Valid(); it.Next() {
+		c.report.Rows++
+		if c.report.Rows%1000 == 0 {
+			if err := c.ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		v, err := it.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		k := it.Key()
+		err = validateStoredKey(t.Info, k)
+		if err != nil {
+			c.problem(CheckInvalidKey, t.Info.TableName, bytes.Clone(k), "%x: %v", k, err)
+		} else {
+			v, err = t.Info.Compression.decompress(v)
+			if err == nil {
+				err = validateStoredRow(t.Info, v)
+			}
+			if err != nil {
+				err = errors.Unwrap(err)
+				c.problem(CheckUndecodableRow, t.Info.TableName, bytes.Clone(k), "%s: %v", keyString(k), err)
+			}
+		}
+		if err == nil {
+			continue
+		}
+
+		c.report.Problems[len(c.report.Problems)-1].Quarantined = quarantine
+		bad = append(bad, quarantinedRow{key: bytes.Clone(k), value: bytes.Clone(v), problem: err.Error()})
+	}
+
+	return bad, it.Error()
+}
+
+// quarantine moves a row from its table to the __chai_quarantine table,
+// creating the table if it doesn't exist.
+func (c *checker) quarantine(t *Table, r *quarantinedRow) error {
+	qt, err := c.tx.Catalog.GetTable(c.tx, QuarantineTableName)
+	if errs.IsNotFoundError(err) {
+		err = c.tx.CatalogWriter().CreateTable(c.tx, QuarantineTableName, quarantineTableInfo.Clone())
+		if err != nil {
+			return err
+		}
+
+		qt, err = c.tx.Catalog.GetTable(c.tx, QuarantineTableName)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = qt.Put(tree.NewKey(types.NewTextValue(t.Info.TableName), types.NewBlobValue(r.key)), row.NewColumnBuffer().
+		Add("table_name", types.NewTextValue(t.Info.TableName)).
+		Add("key", types.NewBlobValue(r.key)).
+		Add("value", types.NewBlobValue(r.value)).
+		Add("problem", types.NewTextValue(r.problem)).
+		Add("quarantined_at", types.NewTimestampValue(time.Now())))
+	if err != nil {
+		return err
+	}
+
+	err = t.Tree.Delete(tree.NewEncodedKey(r.key))
+	if err != nil {
+		return err
+	}
+
+	c.quarantined = true
+	return nil
+}
+
+// checkSequences looks for values of the __chai_sequence table
+// which don't belong to any sequence.
+func (c *checker) checkSequences(sequences []string) error {
+	t, err := c.tx.Catalog.GetTable(c.tx, SequenceTableName)
+	if errs.IsNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return t.IterateOnRange(nil, false, func(k *tree.Key, _ Row) error {
+		if c.broken[SequenceTableName] {
+			// some keys cannot be decoded
+			return nil
+		}
+
+		values, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		name := types.AsString(values[0])
+		if _, ok := slices.BinarySearch(sequences, name); !ok {
+			c.problem(CheckOrphanedSequence, name, bytes.Clone(k.Encoded), "value %s stored for a sequence which doesn't exist", keyString(k.Encoded))
+		}
+
+		return nil
+	})
+}
+
+func (c *checker) checkIndex(name string) error {
+	info, err := c.tx.Catalog.GetIndexInfo(name)
+	if err != nil {
+		return err
+	}
+
+	if c.broken[info.Owner.TableName] {
+		return nil
+	}
+	if _, err := c.tx.Catalog.GetTableInfo(info.Owner.TableName); err != nil {
+		// already reported
+		return nil
+	}
+
+	return CheckIndex(c.tx, name, func(m *IndexMismatch) error {
+		c.problem(CheckIndexMismatch, name, m.Key.Encoded, "%s entry for row %s with values %s", m.Problem, m.Key, tree.NewKey(m.Values...))
+
+		return c.ctx.Err()
+	})
+}
+
+// checkNamespaces looks for keys stored in namespaces
+// which are not used by any table or index.
+func (c *checker) checkNamespaces(namespaces map[tree.Namespace]string) error {
+	it, err := c.tx.Session.Iterator(nil)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	var orphan tree.Namespace
+	var n int
+	report := func() {
+		if n > 0 {
+			c.problem(CheckOrphanedKeys, "", nil, "%d keys in namespace %d, which doesn't belong to any table or index", n, orphan)
+		}
+		n = 0
+	}
+
+	valid := it.First()
+	for valid {
+		if err := c.ctx.Err(); err != nil {
+			return err
+		}
+
+		k := it.Key()
+		ns, ok := decodeNamespace(k)
+		if !ok {
+			report()
+			c.problem(CheckOrphanedKeys, "", bytes.Clone(k), "key %x doesn't start with a namespace", k)
+			valid = it.Next()
+			continue
+		}
+
+		_, known := namespaces[ns]
+		if known || (ns >= MinTransientNamespace && ns <= MaxTransientNamespace) {
+			report()
+			if ns == MaxTransientNamespace {
+				break
+			}
+			// skip the keys of the namespace
+			valid = it.SeekGE(encoding.EncodeInt(nil, int64(ns)+1))
+			continue
+		}
+
+		if ns != orphan {
+			report()
+			orphan = ns
+		}
+		n++
+		valid = it.Next()
+	}
+	report()
+
+	return it.Error()
+}
+
+// decodeNamespace returns the namespace of a key.
+func decodeNamespace(k []byte) (ns tree.Namespace, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+
+	if len(k) == 0 {
+		return 0, false
+	}
+
+	v, n := encoding.DecodeInt(k)
+	if v <= 0 || n > len(k) || !bytes.Equal(encoding.EncodeInt(nil, v), k[:n]) {
+		return 0, false
+	}
+
+	return tree.Namespace(v), true
+}
+
+// validateStoredKey verifies that the stored key of a row can be decoded and
+// has as many values as the primary key of its table.
+func validateStoredKey(info *TableInfo, k []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("invalid key: %v", r)
+		}
+	}()
+
+	b := k[encoding.Skip(k):]
+	var count int
+	for len(b) > 0 {
+		n := encoding.Skip(b)
+		if n == 0 || n > len(b) {
+			return errors.New("invalid key")
+		}
+
+		_, m := types.DecodeValue(b)
+		if m != n {
+			return errors.New("invalid key")
+		}
+
+		b = b[n:]
+		count++
+	}
+
+	want := 1
+	if info.PrimaryKey != nil {
+		want = len(info.PrimaryKey.Columns)
+	}
+	if count != want {
+		return errors.Errorf("invalid key: expected %d values, got %d", want, count)
+	}
+
+	return nil
+}
+
+// keyString returns the values of a stored key, or its bytes
+// if it cannot be decoded.
+func keyString(k []byte) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("%x", k)
+		}
+	}()
+
+	values, err := tree.NewEncodedKey(k).Decode()
+	if err != nil {
+		return fmt.Sprintf("%x", k)
+	}
+
+	return tree.NewKey(values...).String()
+}
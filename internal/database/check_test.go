@@ -0,0 +1,97 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+		CREATE SEQUENCE seq;
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+	`)
+	require.NoError(t, err)
+
+	report, err := db.DB.Check(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, report.Problems)
+	require.Equal(t, 1, report.Indexes)
+	require.Positive(t, report.Rows)
+
+	// corrupt the database
+	tx, err := db.DB.Begin(true)
+	require.NoError(t, err)
+	tb, err := tx.Catalog.GetTable(tx, "test")
+	require.NoError(t, err)
+	// a row with a truncated value
+	err = tb.Tree.Put(tree.NewKey(types.NewIntegerValue(4)), []byte{0xff})
+	require.NoError(t, err)
+	// a row without index entry
+	idx, err := tx.Catalog.GetIndex(tx, "test_b_idx")
+	require.NoError(t, err)
+	pk, err := tb.Info.EncodeKey(tree.NewKey(types.NewIntegerValue(2)))
+	require.NoError(t, err)
+	err = idx.Delete([]types.Value{types.NewTextValue("b")}, pk)
+	require.NoError(t, err)
+	// keys of a dropped table
+	for i := 0; i < 3; i++ {
+		k, err := tree.NewKey(types.NewIntegerValue(int32(i))).Encode(1000, 0)
+		require.NoError(t, err)
+		require.NoError(t, tx.Session.Put(k, []byte{1}))
+	}
+	require.NoError(t, tx.Commit())
+
+	problems := func(r *database.CheckReport) []string {
+		var list []string
+		for _, p := range r.Problems {
+			list = append(list, p.String())
+		}
+		return list
+	}
+
+	report, err = db.DB.Check(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"undecodable-row: test: (4): cannot decode column a",
+		"orphaned-keys: 3 keys in namespace 1000, which doesn't belong to any table or index",
+	}, problems(report))
+
+	// the row is moved to the quarantine table
+	report, err = db.DB.Check(context.Background(), &database.CheckOptions{Quarantine: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"undecodable-row: test: (4): cannot decode column a (quarantined)",
+		"index-mismatch: test_b_idx: missing entry for row (2) with values (\"b\")",
+		"orphaned-keys: 3 keys in namespace 1000, which doesn't belong to any table or index",
+	}, problems(report))
+
+	r, err := db.QueryRow("SELECT COUNT(*) AS n FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 3}`)
+
+	r, err = db.QueryRow("SELECT table_name, problem FROM __chai_quarantine")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"table_name": "test", "problem": "cannot decode column a"}`)
+
+	err = db.Exec("REINDEX test_b_idx")
+	require.NoError(t, err)
+
+	report, err = db.DB.Check(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"orphaned-keys: 3 keys in namespace 1000, which doesn't belong to any table or index",
+	}, problems(report))
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chaisql/chai/internal/types"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// A Collation defines how TEXT values are compared and sorted.
+// Texts are compared by their collation key, which is also
+// what the indexes of collated columns store.
+//
+// The builtin collations are:
+//
+//	BINARY: the bytes of the texts are compared, this is the default
+//	NOCASE: the texts are compared after folding their case
+//	RTRIM: the trailing spaces of the texts are ignored
+//
+// Any other name is parsed as a BCP 47 language tag, like "fr" or
+// "de-u-ks-level2", and sorts the texts following the rules of that language.
+type Collation struct {
+	// Name of the collation, as stored in the catalog.
+	Name string
+
+	// key returns the collation key of a text.
+	// It is nil for the binary collation.
+	key func(s string) []byte
+	// locale collation keys are not texts and are stored as blobs.
+	locale bool
+}
+
+// BinaryCollation compares the bytes of the texts.
+var BinaryCollation = &Collation{Name: "BINARY"}
+
+var builtinCollations = map[string]*Collation{
+	"BINARY": BinaryCollation,
+	"NOCASE": {
+		Name: "NOCASE",
+		key: func(s string) []byte {
+			return []byte(strings.ToLower(s))
+		},
+	},
+	"RTRIM": {
+		Name: "RTRIM",
+		key: func(s string) []byte {
+			return []byte(strings.TrimRight(s, " "))
+		},
+	},
+}
+
+var localeCollations sync.Map
+
+// GetCollation returns the collation with the given name.
+// The names of the builtin collations are case insensitive.
+func GetCollation(name string) (*Collation, error) {
+	if c, ok := builtinCollations[strings.ToUpper(name)]; ok {
+		return c, nil
+	}
+
+	tag, err := language.Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown collation %q", name)
+	}
+
+	if c, ok := localeCollations.Load(tag.String()); ok {
+		return c.(*Collation), nil
+	}
+
+	// collators are not safe for concurrent use
+	pool := sync.Pool{
+		New: func() any {
+			return collate.New(tag)
+		},
+	}
+
+	c, _ := localeCollations.LoadOrStore(tag.String(), &Collation{
+		Name: tag.String(),
+		key: func(s string) []byte {
+			col := pool.Get().(*collate.Collator)
+			defer pool.Put(col)
+
+			var buf collate.Buffer
+			return col.KeyFromString(&buf, s)
+		},
+		locale: true,
+	})
+	return c.(*Collation), nil
+}
+
+// IsBinary returns whether the collation compares the bytes of the texts.
+func (c *Collation) IsBinary() bool {
+	return c == nil || c.key == nil
+}
+
+// Key returns the value stored in the indexes for the given value.
+// Only TEXT values are converted.
+func (c *Collation) Key(v types.Value) types.Value {
+	if c.IsBinary() || v.Type() != types.TypeText {
+		return v
+	}
+
+	k := c.key(types.AsString(v))
+	if c.locale {
+		return types.NewBlobValue(k)
+	}
+
+	return types.NewTextValue(string(k))
+}
+
+// Compare returns an integer comparing two texts.
+// The result is 0 if a == b, -1 if a < b, and +1 if a > b.
+func (c *Collation) Compare(a, b string) int {
+	if c.IsBinary() {
+		return strings.Compare(a, b)
+	}
+
+	return bytes.Compare(c.key(a), c.key(b))
+}
+
+// String returns the name of the collation, as written after COLLATE.
+func (c *Collation) String() string {
+	if c.locale {
+		return types.NewTextValue(c.Name).String()
+	}
+
+	return c.Name
+}
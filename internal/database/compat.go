@@ -0,0 +1,33 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// CompatMode adjusts minor semantic differences to match
+// the behavior of another database.
+type CompatMode string
+
+// Compatibility modes.
+const (
+	// CompatDefault uses the options of the database.
+	CompatDefault CompatMode = ""
+	// CompatSQLite makes LIKE ignore the case of characters
+	// and divisions by zero return NULL.
+	CompatSQLite CompatMode = "sqlite"
+	// CompatPostgres makes LIKE compare characters exactly
+	// and divisions by zero fail, including for doubles.
+	CompatPostgres CompatMode = "postgres"
+)
+
+// ParseCompatMode returns the compatibility mode with the given name.
+func ParseCompatMode(name string) (CompatMode, error) {
+	switch mode := CompatMode(strings.ToLower(name)); mode {
+	case CompatDefault, CompatSQLite, CompatPostgres:
+		return mode, nil
+	}
+
+	return CompatDefault, errors.Errorf("unknown compatibility mode %q", name)
+}
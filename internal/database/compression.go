@@ -0,0 +1,140 @@
+package database
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the codec applied to the rows of a table
+// before they are stored.
+type Compression uint8
+
+const (
+	// CompressionNone stores the rows as they are encoded.
+	CompressionNone Compression = iota
+	// CompressionSnappy compresses the rows with Snappy,
+	// which is fast but compresses less than Zstandard.
+	CompressionSnappy
+	// CompressionZstd compresses the rows with Zstandard.
+	CompressionZstd
+)
+
+// ParseCompression returns the codec with the given name,
+// among "none", "snappy" and "zstd".
+func ParseCompression(s string) (Compression, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return CompressionNone, nil
+	case "snappy":
+		return CompressionSnappy, nil
+	case "zstd":
+		return CompressionZstd, nil
+	}
+
+	return 0, errors.Errorf("unknown compression %q", s)
+}
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionSnappy:
+		return "snappy"
+	case CompressionZstd:
+		return "zstd"
+	}
+
+	return "none"
+}
+
+// Each row of a compressed table starts with one of these bytes.
+// Rows that compression wouldn't make smaller are stored raw.
+// Zero is not used since the trees store empty values as a zero byte.
+const (
+	storedRaw        byte = 1
+	storedCompressed byte = 2
+)
+
+// zstd encoders and decoders are safe for concurrent use
+// and expensive to create, they are shared by all the tables.
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		var err error
+		zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			panic(err)
+		}
+		zstdDecoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+		if err != nil {
+			panic(err)
+		}
+	})
+}
+
+// compress returns the value stored for an encoded row.
+func (c Compression) compress(enc []byte) []byte {
+	if c == CompressionNone {
+		return enc
+	}
+
+	var dst []byte
+	switch c {
+	case CompressionSnappy:
+		dst = make([]byte, 1+snappy.MaxEncodedLen(len(enc)))
+		dst = dst[:1+len(snappy.Encode(dst[1:], enc))]
+	case CompressionZstd:
+		initZstd()
+		dst = zstdEncoder.EncodeAll(enc, make([]byte, 1, 1+len(enc)))
+	}
+	dst[0] = storedCompressed
+
+	if len(dst) >= 1+len(enc) {
+		dst = append(dst[:0], storedRaw)
+		dst = append(dst, enc...)
+	}
+
+	return dst
+}
+
+// decompress returns the encoded row of a stored value.
+// It returns a CorruptRowError if the value cannot be decompressed.
+func (c Compression) decompress(v []byte) ([]byte, error) {
+	if c == CompressionNone {
+		return v, nil
+	}
+
+	if len(v) == 0 {
+		return nil, &CorruptRowError{Err: errors.New("missing compression header")}
+	}
+
+	switch v[0] {
+	case storedRaw:
+		return v[1:], nil
+	case storedCompressed:
+	default:
+		return nil, &CorruptRowError{Err: errors.Errorf("invalid compression header %d", v[0])}
+	}
+
+	var dec []byte
+	var err error
+	switch c {
+	case CompressionSnappy:
+		dec, err = snappy.Decode(nil, v[1:])
+	case CompressionZstd:
+		initZstd()
+		dec, err = zstdDecoder.DecodeAll(v[1:], nil)
+	}
+	if err != nil {
+		return nil, &CorruptRowError{Err: errors.Wrapf(err, "cannot decompress row with %s", c)}
+	}
+
+	return dec, nil
+}
@@ -0,0 +1,103 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	for _, codec := range []string{"none", "snappy", "zstd"} {
+		t.Run(codec, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "testdb")
+			db, err := chai.Open(path)
+			require.NoError(t, err)
+
+			err = db.Exec(fmt.Sprintf(`CREATE TABLE test(id INTEGER PRIMARY KEY, a TEXT, b INTEGER) WITH (compression = '%s')`, codec))
+			require.NoError(t, err)
+			err = db.Exec(`CREATE INDEX test_b_idx ON test(b)`)
+			require.NoError(t, err)
+
+			// odd rows are compressible, even rows are too small to be compressed
+			for i := 1; i <= 100; i++ {
+				a := "x"
+				if i%2 == 1 {
+					a = strings.Repeat("abcdef", 50)
+				}
+				err = db.Exec(`INSERT INTO test (id, a, b) VALUES (?, ?, ?)`, i, a, i%10)
+				require.NoError(t, err)
+			}
+
+			tx, err := db.DB.Begin(false)
+			require.NoError(t, err)
+			tb, err := tx.Catalog.GetTable(tx, "test")
+			require.NoError(t, err)
+			v, err := tb.Tree.Get(tree.NewKey(types.NewIntegerValue(1)))
+			require.NoError(t, err)
+			if codec == "none" {
+				require.Greater(t, len(v), 300)
+			} else {
+				require.Less(t, len(v), 100)
+			}
+			require.NoError(t, tx.Rollback())
+
+			err = db.Exec(`UPDATE test SET a = 'y' WHERE id = 1`)
+			require.NoError(t, err)
+			err = db.Exec(`UPDATE test SET a = ? WHERE id = 2`, strings.Repeat("ghijkl", 50))
+			require.NoError(t, err)
+			err = db.Exec(`DELETE FROM test WHERE id = 100`)
+			require.NoError(t, err)
+
+			check := func(t *testing.T, db *chai.DB) {
+				t.Helper()
+
+				r, err := db.QueryRow(`SELECT a FROM test WHERE id = 1`)
+				require.NoError(t, err)
+				testutil.RequireJSONEq(t, r, `{"a": "y"}`)
+
+				r, err = db.QueryRow(`SELECT LEN(a) AS n FROM test WHERE id = 2`)
+				require.NoError(t, err)
+				testutil.RequireJSONEq(t, r, `{"n": 300}`)
+
+				r, err = db.QueryRow(`SELECT COUNT(*) AS n, SUM(LEN(a)) AS l FROM test`)
+				require.NoError(t, err)
+				testutil.RequireJSONEq(t, r, `{"n": 99, "l": 15049}`)
+
+				r, err = db.QueryRow(`SELECT COUNT(*) AS n FROM test WHERE b = 3`)
+				require.NoError(t, err)
+				testutil.RequireJSONEq(t, r, `{"n": 10}`)
+
+				conn, err := db.Connect()
+				require.NoError(t, err)
+				defer conn.Close()
+				err = conn.Exec(`SET parallel_workers = 4`)
+				require.NoError(t, err)
+				r, err = conn.QueryRow(`SELECT COUNT(*) AS n, SUM(LEN(a)) AS l FROM test`)
+				require.NoError(t, err)
+				testutil.RequireJSONEq(t, r, `{"n": 99, "l": 15049}`)
+
+				report, err := db.Check(context.Background(), nil)
+				require.NoError(t, err)
+				require.Empty(t, report.Problems)
+			}
+
+			check(t, db)
+
+			// the codec is kept in the catalog
+			require.NoError(t, db.Close())
+			db, err = chai.Open(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			check(t, db)
+		})
+	}
+}
@@ -2,14 +2,46 @@ package database
 
 import (
 	"context"
+	"time"
 
+	"github.com/chaisql/chai/engine"
 	"github.com/cockroachdb/errors"
 )
 
 type Connection struct {
-	db  *Database
-	ctx context.Context
-	tx  *Transaction
+	db         *Database
+	ctx        context.Context
+	tx         *Transaction
+	searchPath []string
+	compatMode CompatMode
+
+	// user running the statements, empty if the connection
+	// has every privilege.
+	user string
+
+	strictTyping    bool
+	skipCorruptRows bool
+
+	statementTimeout time.Duration
+	workMem          int
+	parallelWorkers  int
+	synchronous      engine.Synchronous
+
+	// values of the registered settings kept by the connection,
+	// by name.
+	vars map[string]any
+
+	// temporary relations created by the connection,
+	// nil until the first one is created.
+	temp *catalogCache
+
+	// databases attached with Attach, by name, and their relations
+	// named after the database. Both are nil until the first one
+	// is attached.
+	attached      map[string]*attachedDatabase
+	attachedCache *catalogCache
+
+	closed bool
 }
 
 // BeginTx starts a new transaction with the given options.
@@ -29,22 +61,209 @@ func (c *Connection) BeginTx(opts *TxOptions) (*Transaction, error) {
 		return nil, err
 	}
 
+	// the temporary tables and the attached databases
+	// don't exist in the past versions of the database
+	current := opts == nil || (opts.AsOf.IsZero() && opts.Snapshot == nil)
+	if c.attachedCache != nil && current {
+		err = c.beginAttached(tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
 	c.tx = tx
 	tx.conn = c
+	if c.temp != nil && current {
+		tx.Catalog = tx.Catalog.withTemporary(c.temp)
+	}
+	tx.User = c.user
+	tx.SearchPath = c.searchPath
+	tx.CompatMode = c.compatMode
+	tx.StrictTyping = c.strictTyping
+	tx.SkipCorruptRows = c.skipCorruptRows
+	tx.WorkMem = c.workMem
+	tx.ParallelWorkers = c.parallelWorkers
+	tx.Synchronous = c.synchronous
 	tx.OnRollbackHooks = append(tx.OnRollbackHooks, c.releaseAttachedTx)
 	tx.OnCommitHooks = append(tx.OnCommitHooks, c.releaseAttachedTx)
 
 	return tx, nil
 }
 
+// Reset restores the settings of the connection to the options
+// of the database, so that the connection can be reused.
+// It fails if a transaction is attached to the connection.
 func (c *Connection) Reset() error {
 	if c.tx != nil {
 		return errors.New("cannot reset a connection with an attached transaction")
 	}
 
+	c.searchPath = nil
+	c.compatMode = CompatDefault
+	c.strictTyping = c.db.StrictTyping
+	c.skipCorruptRows = c.db.SkipCorruptRows
+	c.statementTimeout = 0
+	c.workMem = c.db.WorkMem
+	c.parallelWorkers = c.db.ParallelWorkers
+	c.synchronous = c.db.Synchronous
+	c.vars = nil
 	return nil
 }
 
+// IsClosed returns true if the connection or its database was closed.
+func (c *Connection) IsClosed() bool {
+	return c.closed || c.ctx.Err() != nil
+}
+
+// SearchPath returns the schemas in which the relations referred to
+// without schema are looked up.
+func (c *Connection) SearchPath() []string {
+	return c.searchPath
+}
+
+// SetSearchPath sets the schemas in which the relations referred to
+// without schema are looked up, in order. The first schema is the one
+// in which relations are created. An empty search path means the
+// default schema. It also applies to the attached transaction, if any.
+func (c *Connection) SetSearchPath(path []string) {
+	c.searchPath = path
+	if c.tx != nil {
+		c.tx.SearchPath = path
+	}
+}
+
+// CompatMode returns the compatibility mode of the connection.
+func (c *Connection) CompatMode() CompatMode {
+	return c.compatMode
+}
+
+// SetCompatMode sets the compatibility mode of the connection.
+// It also applies to the attached transaction, if any.
+func (c *Connection) SetCompatMode(mode CompatMode) {
+	c.compatMode = mode
+	if c.tx != nil {
+		c.tx.CompatMode = mode
+	}
+}
+
+// StrictTyping returns whether the queries run by the connection
+// reject comparisons between values of incompatible types.
+func (c *Connection) StrictTyping() bool {
+	return c.strictTyping
+}
+
+// SetStrictTyping enables or disables strict typing for the connection.
+// It also applies to the attached transaction, if any.
+func (c *Connection) SetStrictTyping(enabled bool) {
+	c.strictTyping = enabled
+	if c.tx != nil {
+		c.tx.StrictTyping = enabled
+	}
+}
+
+// ResetStrictTyping sets strict typing back to the option of the database.
+func (c *Connection) ResetStrictTyping() {
+	c.SetStrictTyping(c.db.StrictTyping)
+}
+
+// SkipCorruptRows returns whether the queries run by the connection
+// ignore the rows that cannot be decoded.
+func (c *Connection) SkipCorruptRows() bool {
+	return c.skipCorruptRows
+}
+
+// SetSkipCorruptRows makes the queries run by the connection ignore,
+// or report, the rows that cannot be decoded.
+// It also applies to the attached transaction, if any.
+func (c *Connection) SetSkipCorruptRows(enabled bool) {
+	c.skipCorruptRows = enabled
+	if c.tx != nil {
+		c.tx.SkipCorruptRows = enabled
+	}
+}
+
+// ResetSkipCorruptRows sets the setting back to the option of the database.
+func (c *Connection) ResetSkipCorruptRows() {
+	c.SetSkipCorruptRows(c.db.SkipCorruptRows)
+}
+
+// StatementTimeout returns the maximum duration of the queries
+// run by the connection. Zero means no timeout.
+func (c *Connection) StatementTimeout() time.Duration {
+	return c.statementTimeout
+}
+
+// SetStatementTimeout sets the maximum duration of the queries
+// run by the connection. Zero disables the timeout.
+func (c *Connection) SetStatementTimeout(d time.Duration) {
+	c.statementTimeout = d
+}
+
+// WorkMem returns the amount of memory, in bytes, used by each
+// temporary tree of the queries run by the connection before
+// spilling to disk. Zero means the default of the engine.
+func (c *Connection) WorkMem() int {
+	return c.workMem
+}
+
+// SetWorkMem sets the amount of memory, in bytes, used by each
+// temporary tree of the queries run by the connection before
+// spilling to disk. It also applies to the attached transaction, if any.
+func (c *Connection) SetWorkMem(n int) {
+	c.workMem = n
+	if c.tx != nil {
+		c.tx.WorkMem = n
+	}
+}
+
+// ResetWorkMem sets the setting back to the option of the database.
+func (c *Connection) ResetWorkMem() {
+	c.SetWorkMem(c.db.WorkMem)
+}
+
+// ParallelWorkers returns the number of goroutines reading each table
+// scanned by the read-only queries of the connection.
+func (c *Connection) ParallelWorkers() int {
+	return c.parallelWorkers
+}
+
+// SetParallelWorkers sets the number of goroutines reading each table
+// scanned by the read-only queries of the connection. Zero or one reads
+// the tables sequentially. It also applies to the attached transaction, if any.
+func (c *Connection) SetParallelWorkers(n int) {
+	c.parallelWorkers = n
+	if c.tx != nil {
+		c.tx.ParallelWorkers = n
+	}
+}
+
+// ResetParallelWorkers sets the setting back to the option of the database.
+func (c *Connection) ResetParallelWorkers() {
+	c.SetParallelWorkers(c.db.ParallelWorkers)
+}
+
+// Synchronous returns when the commits of the transactions
+// of the connection are synchronized to disk.
+func (c *Connection) Synchronous() engine.Synchronous {
+	return c.synchronous
+}
+
+// SetSynchronous sets when the commits of the transactions of the
+// connection are synchronized to disk. It also applies to the attached
+// transaction, if any.
+func (c *Connection) SetSynchronous(mode engine.Synchronous) {
+	c.synchronous = mode
+	if c.tx != nil {
+		c.tx.Synchronous = mode
+	}
+}
+
+// ResetSynchronous sets the setting back to the option of the database.
+func (c *Connection) ResetSynchronous() {
+	c.SetSynchronous(c.db.Synchronous)
+}
+
 func (c *Connection) releaseAttachedTx() {
 	if c.tx != nil {
 		c.tx = nil
@@ -58,11 +277,18 @@ func (c *Connection) GetTx() *Transaction {
 }
 
 func (c *Connection) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
 	defer c.db.connectionWg.Done()
 
 	if c.tx != nil {
-		return c.tx.Rollback()
+		err := c.tx.Rollback()
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return errors.CombineErrors(c.dropTemporaryTables(), c.detachAll())
 }
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/stringutil"
@@ -13,15 +14,46 @@ import (
 
 // ColumnConstraint describes constraints on a particular column.
 type ColumnConstraint struct {
-	Position     int
-	Column       string
-	Type         types.Type
+	Position int
+	Column   string
+	Type     types.Type
+	// DecimalSpec is the precision and the scale
+	// of DECIMAL(precision, scale) columns.
+	DecimalSpec types.DecimalSpec
+	// VectorSpec is the dimension of VECTOR(dimension) columns.
+	VectorSpec   types.VectorSpec
 	IsNotNull    bool
 	DefaultValue TableExpression
+	// AutoIncrement is set by the parser when the column is declared
+	// with AUTOINCREMENT or SERIAL. The statement creating the column
+	// replaces it with a default value backed by a sequence owned by
+	// the column, so it is never persisted.
+	AutoIncrement bool
+	// Encryption is set if the values of the column are
+	// encrypted when they are stored.
+	Encryption *ColumnEncryption
+	// Collation is the name of the collation used to compare and index
+	// the values of the column. It is empty for the binary collation.
+	Collation string
+}
+
+// GetCollation returns the collation of the column,
+// or nil if the column uses the binary collation.
+func (f *ColumnConstraint) GetCollation() *Collation {
+	if f.Collation == "" {
+		return nil
+	}
+
+	c, err := GetCollation(f.Collation)
+	if err != nil {
+		return nil
+	}
+
+	return c
 }
 
 func (f *ColumnConstraint) IsEmpty() bool {
-	return f.Column == "" && f.Type.IsAny() && !f.IsNotNull && f.DefaultValue == nil
+	return f.Column == "" && f.Type.IsAny() && !f.IsNotNull && f.DefaultValue == nil && f.Collation == ""
 }
 
 func (f *ColumnConstraint) String() string {
@@ -30,6 +62,8 @@ func (f *ColumnConstraint) String() string {
 	s.WriteString(f.Column)
 	s.WriteString(" ")
 	s.WriteString(strings.ToUpper(f.Type.String()))
+	s.WriteString(f.DecimalSpec.String())
+	s.WriteString(f.VectorSpec.String())
 
 	if f.IsNotNull {
 		s.WriteString(" NOT NULL")
@@ -40,6 +74,16 @@ func (f *ColumnConstraint) String() string {
 		s.WriteString(f.DefaultValue.String())
 	}
 
+	if c := f.GetCollation(); c != nil {
+		s.WriteString(" COLLATE ")
+		s.WriteString(c.String())
+	}
+
+	if f.Encryption != nil {
+		s.WriteString(" ")
+		s.WriteString(f.Encryption.String())
+	}
+
 	return s.String()
 }
 
@@ -80,8 +124,13 @@ func (f *ColumnConstraints) Add(newCc *ColumnConstraint) error {
 
 	// ensure default value type is compatible
 	if newCc.DefaultValue != nil {
-		// first, try to evaluate the default value
+		// first, try to evaluate the default value.
+		// Functions like NOW() need a transaction, to which only
+		// a start time is given here.
 		v, err := newCc.DefaultValue.Eval(nil, nil)
+		if err != nil {
+			v, err = newCc.DefaultValue.Eval(&Transaction{TxStart: time.Now()}, nil)
+		}
 		// if there is no error, check if the default value can be converted to the type of the constraint
 		if err == nil {
 			_, err = v.CastAs(newCc.Type)
@@ -101,6 +150,15 @@ func (f *ColumnConstraints) Add(newCc *ColumnConstraint) error {
 		}
 	}
 
+	if newCc.Collation != "" {
+		if newCc.Type != types.TypeText {
+			return fmt.Errorf("collation %s cannot be used with column %q of type %q", newCc.Collation, newCc.Column, newCc.Type)
+		}
+		if newCc.Encryption != nil {
+			return fmt.Errorf("encrypted column %q cannot have a collation", newCc.Column)
+		}
+	}
+
 	newCc.Position = len(f.Ordered)
 	f.Ordered = append(f.Ordered, newCc)
 	f.ByColumn[newCc.Column] = newCc
@@ -126,6 +184,12 @@ type TableConstraint struct {
 	Unique     bool
 	PrimaryKey bool
 	SortOrder  tree.SortOrder
+	// Only for UNIQUE constraints.
+	// If Deferrable is set, the constraint is declared DEFERRABLE.
+	// If Deferred is set, the constraint is checked when the transaction
+	// commits instead of after each row.
+	Deferrable bool
+	Deferred   bool
 }
 
 func (t *TableConstraint) String() string {
@@ -165,6 +229,13 @@ func (t *TableConstraint) String() string {
 			}
 		}
 		sb.WriteString(")")
+
+		if t.Deferrable {
+			sb.WriteString(" DEFERRABLE")
+		}
+		if t.Deferred {
+			sb.WriteString(" INITIALLY DEFERRED")
+		}
 	}
 
 	return sb.String()
@@ -197,7 +268,7 @@ func (t *TableConstraints) ValidateRow(tx *Transaction, r row.Row) error {
 		}
 
 		if !ok {
-			return fmt.Errorf("row violates check constraint %q", tc.Name)
+			return errors.WithStack(newCheckViolationError(tc, r))
 		}
 	}
 
@@ -206,14 +277,88 @@ func (t *TableConstraints) ValidateRow(tx *Transaction, r row.Row) error {
 
 type ConstraintViolationError struct {
 	Constraint string
-	Columns    []string
-	Key        *tree.Key
+	// Name of the violated constraint, if known.
+	Name    string
+	Columns []string
+	Key     *tree.Key
 }
 
 func (c ConstraintViolationError) Error() string {
+	if c.Name != "" {
+		return fmt.Sprintf("%s constraint %q error: %s", c.Constraint, c.Name, c.Columns)
+	}
+
 	return fmt.Sprintf("%s constraint error: %s", c.Constraint, c.Columns)
 }
 
+// NewUniqueViolationError returns the error reported when the values of the key
+// are already present in the unique index of the table.
+// The error is named after the constraint enforced by the index, or after the index
+// if it was created with CREATE UNIQUE INDEX.
+func NewUniqueViolationError(ti *TableInfo, idx *IndexInfo, key *tree.Key) *ConstraintViolationError {
+	name := idx.IndexName
+	if tc := ti.UniqueConstraint(idx); tc != nil {
+		name = tc.Name
+	}
+
+	return &ConstraintViolationError{
+		Constraint: "UNIQUE",
+		Name:       name,
+		Columns:    idx.Columns,
+		Key:        key,
+	}
+}
+
 func IsConstraintViolationError(err error) bool {
 	return errors.Is(err, (*ConstraintViolationError)(nil))
 }
+
+// CheckViolationError is returned when a row doesn't satisfy
+// a CHECK constraint of its table. Unlike ConstraintViolationError,
+// it is not handled by ON CONFLICT clauses.
+type CheckViolationError struct {
+	Name string
+	// Expression of the constraint.
+	Check string
+	// Columns referenced by the expression and their values in the row.
+	Columns []string
+	Values  []types.Value
+}
+
+func newCheckViolationError(tc *TableConstraint, r row.Row) *CheckViolationError {
+	e := CheckViolationError{
+		Name:  tc.Name,
+		Check: tc.Check.String(),
+	}
+
+	for _, c := range tc.Columns {
+		v, err := r.Get(c)
+		if err != nil {
+			continue
+		}
+
+		e.Columns = append(e.Columns, c)
+		e.Values = append(e.Values, v)
+	}
+
+	return &e
+}
+
+func (c *CheckViolationError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "row violates check constraint %q", c.Name)
+	if c.Check != "" {
+		fmt.Fprintf(&sb, " (%s)", c.Check)
+	}
+	for i := range c.Columns {
+		if i == 0 {
+			sb.WriteString(": ")
+		} else {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s = %s", stringutil.NormalizeIdentifier(c.Columns[i], '`'), c.Values[i])
+	}
+
+	return sb.String()
+}
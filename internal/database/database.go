@@ -3,11 +3,12 @@ package database
 
 import (
 	"context"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/engine"
 	"github.com/chaisql/chai/internal/kv"
 	"github.com/cockroachdb/errors"
 )
@@ -27,21 +28,85 @@ type Database struct {
 	// waitgroup to wait for all connections to be closed.
 	connectionWg sync.WaitGroup
 
+	// runs the maintenance jobs in the background.
+	scheduler scheduler
+
+	// versions of the database kept for point-in-time reads.
+	history history
+
+	// snapshots pinned with NewSnapshot and not released yet.
+	snapshots struct {
+		sync.Mutex
+
+		m map[*Snapshot]struct{}
+	}
+
 	// This is used to prevent creating a new transaction
 	// during certain operations (commit, close, etc.)
 	txmu sync.RWMutex
 
-	// This limits the number of write transactions to 1.
-	writetxmu sync.Mutex
-
 	// transactionIDs is used to assign transaction an ID at runtime.
 	// Since transaction IDs are not persisted and not used for concurrent
 	// access, we can use 8 bytes ids that will be reset every time
 	// the database restarts.
 	transactionIDs atomic.Uint64
 
+	// counts the transactions, see Metrics.
+	txMetrics txMetrics
+
 	closeOnce sync.Once
 
+	// CaseSensitiveLike makes the LIKE operator compare characters exactly
+	// instead of ignoring their case.
+	CaseSensitiveLike bool
+
+	// StrictTyping makes comparisons between values of incompatible types
+	// fail when the query is planned, instead of evaluating to false or NULL,
+	// and rejects the values losing information when stored in a column.
+	// It is the default of the connections, which can change it with
+	// SET strict_typing.
+	StrictTyping bool
+
+	// SkipCorruptRows makes the reads ignore the rows that cannot be decoded,
+	// instead of failing with a CorruptRowError. It is the default of the
+	// connections, which can change it with SET skip_corrupt_rows.
+	SkipCorruptRows bool
+
+	// WorkMem is the amount of memory, in bytes, used by each sort and
+	// each temporary tree of a query before spilling its data to disk.
+	// It is the default of the connections, which can change it with
+	// SET work_mem. If zero, the default of the engine is used.
+	WorkMem int
+
+	// ParallelWorkers is the number of goroutines reading each table
+	// scanned by the read-only queries. It is the default of the
+	// connections, which can change it with SET parallel_workers.
+	// Zero or one reads the tables sequentially.
+	ParallelWorkers int
+
+	// Synchronous defines when the commits are synchronized to disk.
+	// It is the default of the connections, which can change it with
+	// SET synchronous.
+	Synchronous engine.Synchronous
+
+	// TransientDiskQuota is the maximum number of bytes that the temporary
+	// trees of a transaction can spill to disk. Zero means unlimited.
+	TransientDiskQuota int64
+
+	// keys of the encrypted columns.
+	keys *Keyring
+
+	// loads the catalog of the database, and of the databases
+	// it attaches.
+	catalogLoader func(tx *Transaction) error
+
+	// databases attached by the connections, by absolute path.
+	attached struct {
+		sync.Mutex
+
+		m map[string]*attachedDatabase
+	}
+
 	// Underlying kv store.
 	Engine engine.Engine
 }
@@ -50,6 +115,85 @@ type Database struct {
 // how the database is loaded.
 type Options struct {
 	CatalogLoader func(tx *Transaction) error
+
+	// LockTimeout is the maximum amount of time to wait
+	// for another process to release the database.
+	// If zero, Open returns engine.ErrDatabaseLocked immediately.
+	LockTimeout time.Duration
+
+	// TTLInterval is the amount of time between two deletions
+	// of the expired rows of tables with a TTL column, and of the
+	// rows purged by retention policies.
+	// If zero, DefaultTTLInterval is used. If negative,
+	// these rows are never deleted automatically.
+	TTLInterval time.Duration
+
+	// VacuumInterval is the amount of time between two compactions
+	// of the database, which reclaim the space of deleted rows.
+	// If zero, DefaultVacuumInterval is used. If negative,
+	// the database is never compacted automatically.
+	VacuumInterval time.Duration
+
+	// HistoryRetention is the amount of time during which the versions
+	// of the database are kept in order to be read by transactions
+	// started with TxOptions.AsOf. The versions are kept in memory,
+	// the history doesn't survive a restart.
+	// If zero, no version is kept.
+	HistoryRetention time.Duration
+
+	// HistoryMaxVersions is the maximum number of versions kept
+	// for the history retention period, one per commit. The oldest
+	// versions are released when it is exceeded.
+	// If zero, DefaultHistoryMaxVersions is used. If negative,
+	// the number of versions is not limited.
+	HistoryMaxVersions int
+
+	// CaseSensitiveLike makes the LIKE operator case sensitive.
+	CaseSensitiveLike bool
+
+	// StrictTyping rejects comparisons between values of incompatible types
+	// and lossy conversions of the values stored in columns.
+	StrictTyping bool
+
+	// SkipCorruptRows ignores the rows that cannot be decoded when reading tables.
+	SkipCorruptRows bool
+
+	// WorkMem is the amount of memory, in bytes, used by the temporary
+	// trees of the queries before spilling to disk.
+	WorkMem int
+
+	// ParallelWorkers is the number of goroutines reading each table
+	// scanned by the read-only queries.
+	ParallelWorkers int
+
+	// Synchronous defines when the commits are synchronized to disk.
+	Synchronous engine.Synchronous
+
+	// SyncInterval is the interval at which the commits made with
+	// engine.SyncNormal are synchronized to disk.
+	SyncInterval time.Duration
+
+	// TransientDir is the directory in which the temporary data
+	// of the queries is spilled. If empty, it is spilled to the database.
+	TransientDir string
+
+	// TransientDiskQuota is the maximum number of bytes that the
+	// temporary trees of a transaction can spill to disk.
+	TransientDiskQuota int64
+
+	// Keyring holds the keys of the encrypted columns.
+	// If nil, encrypted columns can be neither read nor written,
+	// unless they are NULL.
+	Keyring *Keyring
+
+	// Engine, if set, stores the data of the database instead of
+	// the default engine, in which case the path given to Open is ignored.
+	// It is closed when the database is closed.
+	Engine engine.Engine
+
+	// Snapshot, if set, is read to load a snapshot written
+	// by WriteSnapshot into the database, which must be empty.
+	Snapshot io.Reader
 }
 
 // CatalogLoader loads the catalog from the disk.
@@ -63,20 +207,56 @@ type CatalogLoader interface {
 type TxOptions struct {
 	// Open a read-only transaction.
 	ReadOnly bool
+
+	// If set, the read-only transaction reads the database
+	// as it was at the given time. The time must be within
+	// the history retention period.
+	AsOf time.Time
+
+	// If set, the read-only transaction reads the version
+	// of the database pinned by the snapshot.
+	Snapshot *Snapshot
 }
 
-func Open(path string, opts *Options) (*Database, error) {
-	store, err := kv.NewEngine(path, kv.Options{
-		RollbackSegmentNamespace: int64(RollbackSegmentNamespace),
-		MinTransientNamespace:    uint64(MinTransientNamespace),
-		MaxTransientNamespace:    uint64(MaxTransientNamespace),
-	})
-	if err != nil {
-		return nil, err
+func Open(path string, opts *Options) (_ *Database, err error) {
+	store := opts.Engine
+	if store == nil {
+		store, err = kv.NewEngine(path, kv.Options{
+			RollbackSegmentNamespace: int64(RollbackSegmentNamespace),
+			MinTransientNamespace:    uint64(MinTransientNamespace),
+			MaxTransientNamespace:    uint64(MaxTransientNamespace),
+			LockTimeout:              opts.LockTimeout,
+			TransientDir:             opts.TransientDir,
+			SyncInterval:             opts.SyncInterval,
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
+	defer func() {
+		// release the database directory if the database couldn't be loaded
+		if err != nil {
+			_ = store.Close()
+		}
+	}()
 
 	db := Database{
-		Engine: store,
+		Engine:            store,
+		CaseSensitiveLike: opts.CaseSensitiveLike,
+		StrictTyping:      opts.StrictTyping,
+		SkipCorruptRows:   opts.SkipCorruptRows,
+		WorkMem:           opts.WorkMem,
+		ParallelWorkers:   opts.ParallelWorkers,
+		Synchronous:       opts.Synchronous,
+		keys:              opts.Keyring,
+		catalogLoader:     opts.CatalogLoader,
+
+		TransientDiskQuota: opts.TransientDiskQuota,
+	}
+	db.history.retention = opts.HistoryRetention
+	db.history.maxVersions = opts.HistoryMaxVersions
+	if db.history.maxVersions == 0 {
+		db.history.maxVersions = DefaultHistoryMaxVersions
 	}
 
 	// create a context that will be cancelled when the database is closed.
@@ -95,15 +275,21 @@ func Open(path string, opts *Options) (*Database, error) {
 		return nil, err
 	}
 
+	db.catalog = NewCatalog()
+
+	if opts.Snapshot != nil {
+		err = db.loadSnapshot(opts.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tx, err := db.Begin(true)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	db.catalog = NewCatalog()
-	tx.Catalog = db.catalog
-
 	if opts.CatalogLoader != nil {
 		err = opts.CatalogLoader(tx)
 		if err != nil {
@@ -121,6 +307,11 @@ func Open(path string, opts *Options) (*Database, error) {
 		return nil, err
 	}
 
+	err = db.startScheduler(builtinJobs(opts))
+	if err != nil {
+		return nil, err
+	}
+
 	return &db, nil
 }
 
@@ -131,6 +322,7 @@ func (db *Database) Close() error {
 	db.closeOnce.Do(func() {
 		db.closeCancel()
 
+		db.scheduler.wg.Wait()
 		db.connectionWg.Wait()
 		err = db.closeDatabase()
 	})
@@ -139,6 +331,11 @@ func (db *Database) Close() error {
 }
 
 func (db *Database) closeDatabase() error {
+	// release the versions first, some engines cannot
+	// commit while they are retained.
+	db.history.releaseAll()
+	db.releaseSnapshots()
+
 	// release all sequences
 	tx, err := db.beginTxUnlocked(nil)
 	if err != nil {
@@ -176,8 +373,13 @@ func (db *Database) Connect() (*Connection, error) {
 
 	db.connectionWg.Add(1)
 	return &Connection{
-		db:  db,
-		ctx: db.closeContext,
+		db:              db,
+		ctx:             db.closeContext,
+		strictTyping:    db.StrictTyping,
+		skipCorruptRows: db.SkipCorruptRows,
+		workMem:         db.WorkMem,
+		parallelWorkers: db.ParallelWorkers,
+		synchronous:     db.Synchronous,
 	}, nil
 }
 
@@ -204,14 +406,6 @@ func (db *Database) beginTx(opts *TxOptions) (*Transaction, error) {
 	db.txmu.RLock()
 	defer db.txmu.RUnlock()
 
-	if opts == nil {
-		opts = new(TxOptions)
-	}
-
-	if !opts.ReadOnly {
-		db.writetxmu.Lock()
-	}
-
 	return db.beginTxUnlocked(opts)
 }
 
@@ -221,27 +415,65 @@ func (db *Database) beginTxUnlocked(opts *TxOptions) (*Transaction, error) {
 		opts = &TxOptions{}
 	}
 
+	now := time.Now()
+	catalog := db.Catalog()
+
 	var sess engine.Session
-	if opts.ReadOnly {
+	switch {
+	case !opts.AsOf.IsZero():
+		if !opts.ReadOnly {
+			return nil, errors.New("cannot write to the database as of a past time")
+		}
+
+		var err error
+		sess, catalog, err = db.history.acquire(opts.AsOf, now)
+		if err != nil {
+			return nil, err
+		}
+	case opts.Snapshot != nil:
+		if !opts.ReadOnly {
+			return nil, errors.New("cannot write to a snapshot of the database")
+		}
+
+		var err error
+		sess, err = opts.Snapshot.newSession()
+		if err != nil {
+			return nil, err
+		}
+		catalog = opts.Snapshot.catalog
+	case opts.ReadOnly:
 		sess = db.Engine.NewSnapshotSession()
-	} else {
-		sess = db.Engine.NewBatchSession()
+	default:
+		sess = db.Engine.NewOptimisticSession()
 	}
 
 	tx := Transaction{
-		db:       db,
-		Engine:   db.Engine,
-		Session:  sess,
-		Writable: !opts.ReadOnly,
-		ID:       db.transactionIDs.Add(1),
-		Catalog:  db.Catalog(),
-		TxStart:  time.Now(),
+		db:          db,
+		Engine:      db.Engine,
+		Session:     sess,
+		Writable:    !opts.ReadOnly,
+		ID:          db.transactionIDs.Add(1),
+		Catalog:     catalog,
+		baseCatalog: catalog,
+		TxStart:     now,
+
+		StrictTyping:    db.StrictTyping,
+		SkipCorruptRows: db.SkipCorruptRows,
+		WorkMem:         db.WorkMem,
+		ParallelWorkers: db.ParallelWorkers,
+		Synchronous:     db.Synchronous,
 	}
-
-	if !opts.ReadOnly {
-		tx.WriteTxMu = &db.writetxmu
+	if tx.Writable {
+		// the schema changes committed concurrently conflict with the
+		// transaction if they changed the relations it used.
+		tx.Catalog = catalog.withUsedRelations()
+	}
+	if db.TransientDiskQuota > 0 {
+		tx.transientQuota = &transientQuota{limit: db.TransientDiskQuota}
 	}
 
+	db.txMetrics.started.Add(1)
+
 	return &tx, nil
 }
 
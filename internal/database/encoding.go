@@ -1,8 +1,12 @@
 package database
 
 import (
+	"fmt"
+
 	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
@@ -14,6 +18,18 @@ func (t *TableInfo) EncodeRow(tx *Transaction, dst []byte, r row.Row) ([]byte, e
 		return ed.encoded, nil
 	}
 
+	if t.Strict {
+		err := r.Iterate(func(column string, _ types.Value) error {
+			if _, ok := t.ColumnConstraints.ByColumn[column]; !ok {
+				return errors.Errorf("table has no column %s", column)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return encodeRow(tx, dst, &t.ColumnConstraints, r)
 }
 
@@ -48,11 +64,26 @@ func encodeRow(tx *Transaction, dst []byte, ccs *ColumnConstraints, r row.Row) (
 		}
 
 		// ensure the value is of the correct type
-		v, err = v.CastAs(cc.Type)
+		// and round decimals to the scale of the column
+		v, err = convertValue(tx, cc, v)
 		if err != nil {
 			return nil, err
 		}
 
+		// ensure vectors have the dimension of the column
+		v, err = cc.VectorSpec.Apply(v)
+		if err != nil {
+			return nil, err
+		}
+
+		// encrypted values are stored as blobs
+		if cc.Encryption != nil && v.Type() != types.TypeNull {
+			v, err = tx.Keyring().seal(cc, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		dst, err = v.Encode(dst)
 		if err != nil {
 			return nil, err
@@ -62,35 +93,137 @@ func encodeRow(tx *Transaction, dst []byte, ccs *ColumnConstraints, r row.Row) (
 	return dst, nil
 }
 
+// convertValue converts v to the type of the column.
+// With strict typing, conversions losing information are rejected,
+// including the rounding of decimals to the scale of the column.
+func convertValue(tx *Transaction, cc *ColumnConstraint, v types.Value) (types.Value, error) {
+	// values already of the type of the column are kept as is,
+	// casting them would allocate a copy. Decimals are still
+	// rounded to the scale of the column.
+	if v.Type() == cc.Type && cc.Type != types.TypeDecimal {
+		return v, nil
+	}
+
+	if tx == nil || !tx.StrictTyping {
+		v, err := v.CastAs(cc.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		return cc.DecimalSpec.Apply(v)
+	}
+
+	c, err := types.CastLossless(v, cc.Type)
+	if errors.Is(err, types.ErrLossyConversion) {
+		return nil, lossyConversionError(cc, v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Type() != types.TypeDecimal {
+		return c, nil
+	}
+
+	rounded, err := cc.DecimalSpec.Apply(c)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := rounded.EQ(c)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, lossyConversionError(cc, v)
+	}
+
+	return rounded, nil
+}
+
+func lossyConversionError(cc *ColumnConstraint, v types.Value) error {
+	return errs.TypeMismatchf("strict typing: cannot convert %s of type %s to %s%s for column %s without loss", v, v.Type(), cc.Type, cc.DecimalSpec, cc.Column)
+}
+
 type EncodedRow struct {
 	encoded           []byte
 	columnConstraints *ColumnConstraints
+	// keys used to decrypt the encrypted columns.
+	keys *Keyring
 }
 
-func NewEncodedRow(ccs *ColumnConstraints, data []byte) *EncodedRow {
+func NewEncodedRow(ccs *ColumnConstraints, keys *Keyring, data []byte) *EncodedRow {
 	e := EncodedRow{
 		columnConstraints: ccs,
+		keys:              keys,
 		encoded:           data,
 	}
 
 	return &e
 }
 
-func (e *EncodedRow) ResetWith(ccs *ColumnConstraints, data []byte) {
+func (e *EncodedRow) ResetWith(ccs *ColumnConstraints, keys *Keyring, data []byte) {
 	e.columnConstraints = ccs
+	e.keys = keys
 	e.encoded = data
 }
 
 func (e *EncodedRow) decodeValue(fc *ColumnConstraint, b []byte) (types.Value, int, error) {
+	if len(b) == 0 {
+		return nil, 0, &CorruptRowError{Err: errors.Errorf("missing column %s", fc.Column)}
+	}
+
 	if b[0] == encoding.NullValue {
 		return types.NewNullValue(), 1, nil
 	}
 
-	v, n := fc.Type.Def().Decode(b)
+	if fc.Encryption != nil {
+		n, err := skipColumn(fc, b)
+		if err != nil {
+			return nil, 0, err
+		}
+		return e.keys.open(fc, b[:n])
+	}
+
+	return decodeColumn(fc, b)
+}
+
+// decodeColumn decodes the value of a column. It returns a CorruptRowError
+// instead of panicking if the value is invalid.
+func decodeColumn(fc *ColumnConstraint, b []byte) (v types.Value, n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			v, n, err = nil, 0, &CorruptRowError{Err: errors.Errorf("cannot decode column %s: %v", fc.Column, r)}
+		}
+	}()
+
+	v, n = fc.Type.Def().Decode(b)
+	if n <= 0 || n > len(b) {
+		return nil, 0, &CorruptRowError{Err: errors.Errorf("cannot decode column %s as %s", fc.Column, fc.Type)}
+	}
 
 	return v, n, nil
 }
 
+// skipColumn returns the size of the encoded value of a column.
+func skipColumn(fc *ColumnConstraint, b []byte) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			n, err = 0, &CorruptRowError{Err: errors.Errorf("cannot decode column %s: %v", fc.Column, r)}
+		}
+	}()
+
+	if len(b) == 0 {
+		return 0, &CorruptRowError{Err: errors.Errorf("missing column %s", fc.Column)}
+	}
+
+	n = encoding.Skip(b)
+	if n <= 0 || n > len(b) {
+		return 0, &CorruptRowError{Err: errors.Errorf("cannot decode column %s", fc.Column)}
+	}
+
+	return n, nil
+}
+
 // Get decodes the selected column from the buffer.
 func (e *EncodedRow) Get(column string) (v types.Value, err error) {
 	b := e.encoded
@@ -98,12 +231,16 @@ func (e *EncodedRow) Get(column string) (v types.Value, err error) {
 	// get the column from the list of column constraints
 	cc, ok := e.columnConstraints.ByColumn[column]
 	if !ok {
-		return nil, errors.Wrapf(types.ErrColumnNotFound, "%s not found", column)
+		// no stack trace is recorded, like ColumnBuffer.Get
+		return nil, errors.WithMessagef(types.ErrColumnNotFound, "%s not found", column)
 	}
 
 	// skip all columns before the selected column
 	for i := 0; i < cc.Position; i++ {
-		n := encoding.Skip(b)
+		n, err := skipColumn(e.columnConstraints.Ordered[i], b)
+		if err != nil {
+			return nil, err
+		}
 		b = b[n:]
 	}
 
@@ -111,6 +248,32 @@ func (e *EncodedRow) Get(column string) (v types.Value, err error) {
 	return
 }
 
+// encryptedValue returns the value of an encrypted column as stored,
+// without decrypting it.
+func (e *EncodedRow) encryptedValue(cc *ColumnConstraint) (types.Value, error) {
+	b := e.encoded
+
+	for i := 0; i < cc.Position; i++ {
+		n, err := skipColumn(e.columnConstraints.Ordered[i], b)
+		if err != nil {
+			return nil, err
+		}
+		b = b[n:]
+	}
+
+	n, err := skipColumn(cc, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if b[0] == encoding.NullValue {
+		return types.NewNullValue(), nil
+	}
+
+	v, _ := types.TypeBlob.Def().Decode(b[:n])
+	return v, nil
+}
+
 // Iterate decodes each columns one by one and passes them to fn
 // until the end of the row or until fn returns an error.
 func (e *EncodedRow) Iterate(fn func(column string, value types.Value) error) error {
@@ -133,6 +296,76 @@ func (e *EncodedRow) Iterate(fn func(column string, value types.Value) error) er
 	return nil
 }
 
+// validateStoredRow verifies that a stored row can be decoded with the columns
+// of its table. Encrypted columns are checked without being decrypted.
+func validateStoredRow(info *TableInfo, v []byte) error {
+	b := v
+	for _, cc := range info.ColumnConstraints.Ordered {
+		n, err := skipColumn(cc, b)
+		if err != nil {
+			return err
+		}
+
+		if b[0] != encoding.NullValue && cc.Encryption == nil {
+			_, m, err := decodeColumn(cc, b)
+			if err != nil {
+				return err
+			}
+			if m != n {
+				return &CorruptRowError{Err: errors.Errorf("cannot decode column %s as %s", cc.Column, cc.Type)}
+			}
+		}
+
+		b = b[n:]
+	}
+
+	if len(b) > 0 {
+		return &CorruptRowError{Err: errors.Errorf("%d unexpected bytes after the last column", len(b))}
+	}
+
+	return nil
+}
+
+// A CorruptRowError is returned when a stored row cannot be decoded.
+type CorruptRowError struct {
+	// Table and Key are empty if the row was decoded
+	// without knowing where it is stored.
+	Table string
+	Key   *tree.Key
+	Err   error
+}
+
+func (e *CorruptRowError) Error() string {
+	if e.Table == "" {
+		return fmt.Sprintf("corrupted row: %v", e.Err)
+	}
+
+	return fmt.Sprintf("corrupted row %s in table %s: %v", e.Key, e.Table, e.Err)
+}
+
+func (e *CorruptRowError) Unwrap() error {
+	return e.Err
+}
+
+// IsCorruptRowError returns true if the error, or one of the errors
+// it wraps, is a CorruptRowError.
+func IsCorruptRowError(err error) bool {
+	var ce *CorruptRowError
+	return errors.As(err, &ce)
+}
+
+// withRowLocation sets the table and the key of a CorruptRowError,
+// if they are unknown.
+func withRowLocation(err error, table string, key *tree.Key) error {
+	var ce *CorruptRowError
+	if errors.As(err, &ce) && ce.Table == "" {
+		ce.Table = table
+		ce.Key = key
+	}
+
+	return err
+}
+
 func (e *EncodedRow) MarshalJSON() ([]byte, error) {
 	return row.MarshalJSON(e)
 }
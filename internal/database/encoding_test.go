@@ -3,10 +3,12 @@ package database_test
 import (
 	"testing"
 
+	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/stretchr/testify/require"
 )
@@ -62,7 +64,7 @@ func TestEncoding(t *testing.T) {
 	buf, err = ti.EncodeRow(nil, buf, r)
 	require.NoError(t, err)
 
-	er := database.NewEncodedRow(&ti.ColumnConstraints, buf)
+	er := database.NewEncodedRow(&ti.ColumnConstraints, nil, buf)
 	require.NoError(t, err)
 
 	want := row.NewFromMap(map[string]any{
@@ -75,3 +77,189 @@ func TestEncoding(t *testing.T) {
 
 	testutil.RequireRowEqual(t, want, er)
 }
+
+func TestEncodingCorrupted(t *testing.T) {
+	var ti database.TableInfo
+
+	err := ti.AddColumnConstraint(&database.ColumnConstraint{
+		Position: 0,
+		Column:   "a",
+		Type:     types.TypeInteger,
+	})
+	require.NoError(t, err)
+
+	err = ti.AddColumnConstraint(&database.ColumnConstraint{
+		Position: 1,
+		Column:   "b",
+		Type:     types.TypeText,
+	})
+	require.NoError(t, err)
+
+	buf, err := ti.EncodeRow(nil, nil, row.NewFromMap(map[string]any{
+		"a": int64(1),
+		"b": "hello",
+	}))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated", buf[:len(buf)-2]},
+		{"invalid type", append([]byte{0xff}, buf[1:]...)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			er := database.NewEncodedRow(&ti.ColumnConstraints, nil, test.data)
+
+			_, err := er.Get("b")
+			require.True(t, database.IsCorruptRowError(err), err)
+
+			err = er.Iterate(func(string, types.Value) error { return nil })
+			require.True(t, database.IsCorruptRowError(err), err)
+		})
+	}
+}
+
+// benchmarkTableInfo returns a table with a column of each common type
+// and a row filling them.
+func benchmarkTableInfo(b *testing.B) (*database.TableInfo, row.Row) {
+	b.Helper()
+
+	var ti database.TableInfo
+	columns := []struct {
+		name string
+		tp   types.Type
+		v    types.Value
+	}{
+		{"id", types.TypeBigint, types.NewBigintValue(1 << 40)},
+		{"age", types.TypeInteger, types.NewIntegerValue(10_000)},
+		{"score", types.TypeDouble, types.NewDoubleValue(3.14)},
+		{"name", types.TypeText, types.NewTextValue("the quick brown fox jumps over the lazy dog")},
+		{"data", types.TypeBlob, types.NewBlobValue([]byte("the quick brown fox jumps over the lazy dog"))},
+		{"active", types.TypeBoolean, types.NewBooleanValue(true)},
+		{"nothing", types.TypeText, types.NewNullValue()},
+	}
+
+	var cb row.ColumnBuffer
+	for i, c := range columns {
+		err := ti.AddColumnConstraint(&database.ColumnConstraint{
+			Position: i,
+			Column:   c.name,
+			Type:     c.tp,
+		})
+		require.NoError(b, err)
+		cb.Add(c.name, c.v)
+	}
+
+	return &ti, &cb
+}
+
+func BenchmarkEncodeRow(b *testing.B) {
+	ti, r := benchmarkTableInfo(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		var err error
+		buf, err = ti.EncodeRow(nil, buf[:0], r)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeRow(b *testing.B) {
+	ti, r := benchmarkTableInfo(b)
+
+	enc, err := ti.EncodeRow(nil, nil, r)
+	require.NoError(b, err)
+	er := database.NewEncodedRow(&ti.ColumnConstraints, nil, enc)
+
+	b.Run("Iterate", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			err := er.Iterate(func(string, types.Value) error { return nil })
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := er.Get("name")
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestCorruptRows(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+	`)
+	require.NoError(t, err)
+
+	// replace the value of the second row by a truncated one
+	tx, err := db.DB.Begin(true)
+	require.NoError(t, err)
+	tb, err := tx.Catalog.GetTable(tx, "test")
+	require.NoError(t, err)
+	err = tb.Tree.Put(tree.NewKey(types.NewIntegerValue(2)), []byte{0x80 + 32 + 2, 0xff, 0x05})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	count := func(conn *chai.Connection, q string) (int, error) {
+		var n int
+		rows, err := conn.Query(q)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		err = rows.Iterate(func(r *chai.Row) error {
+			n++
+			return r.MapScan(map[string]any{})
+		})
+		return n, err
+	}
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, q := range []string{"SELECT * FROM test", "SELECT a FROM test WHERE b > 'a'"} {
+		_, err = count(conn, q)
+		require.True(t, database.IsCorruptRowError(err), err)
+		require.ErrorContains(t, err, "corrupted row (2) in table test")
+	}
+
+	err = conn.Exec("SET skip_corrupt_rows = on")
+	require.NoError(t, err)
+
+	n, err := count(conn, "SELECT * FROM test")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// the index entry of the row is ignored
+	n, err = count(conn, "SELECT a FROM test WHERE b > 'a'")
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	err = conn.Exec("SET skip_corrupt_rows TO DEFAULT")
+	require.NoError(t, err)
+	_, err = count(conn, "SELECT * FROM test")
+	require.True(t, database.IsCorruptRowError(err))
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrEncryptionKeyUnavailable is returned when reading or writing
+// an encrypted column without the key of the column.
+var ErrEncryptionKeyUnavailable = errors.New("encryption key not available")
+
+// ColumnEncryption describes how the values of an ENCRYPTED column are encrypted.
+// NULL values are never encrypted.
+type ColumnEncryption struct {
+	// KeyID identifies the key used to encrypt the column.
+	// If empty when the column is created, it is set to table.column.
+	KeyID string
+	// Indexable allows the column to be part of an index.
+	// The values of indexable columns are encrypted deterministically,
+	// equal values having the same encrypted form, which is stored
+	// in the index. Indexes on encrypted columns are only used to enforce
+	// UNIQUE constraints and are never used to speed up queries.
+	Indexable bool
+}
+
+func (e *ColumnEncryption) String() string {
+	s := "ENCRYPTED"
+	if e.KeyID != "" {
+		s += " WITH KEY " + types.NewTextValue(e.KeyID).String()
+	}
+	if e.Indexable {
+		s += " ALLOW INDEX"
+	}
+
+	return s
+}
+
+// A KeyProvider returns the key identified by keyID, for example
+// by fetching it from a key management service.
+type KeyProvider func(keyID string) ([]byte, error)
+
+// A Keyring holds the keys of the encrypted columns.
+// The keys are looked up in a static list, then requested from a KeyProvider.
+// A nil Keyring has no key.
+type Keyring struct {
+	keys     map[string][]byte
+	provider KeyProvider
+
+	mu      sync.Mutex
+	ciphers map[string]*columnCipher
+}
+
+// NewKeyring creates a keyring using the given keys, by key id,
+// and requesting the other keys from provider, if not nil.
+// Keys must be 16, 24 or 32 bytes long to use AES-128, AES-192 or AES-256.
+func NewKeyring(keys map[string][]byte, provider KeyProvider) *Keyring {
+	return &Keyring{
+		keys:     keys,
+		provider: provider,
+		ciphers:  make(map[string]*columnCipher),
+	}
+}
+
+// cipher returns the cipher using the key identified by keyID.
+// Keys returned by the provider are cached, failures are not.
+func (k *Keyring) cipher(keyID string) (*columnCipher, error) {
+	if k == nil {
+		return nil, errors.Wrapf(ErrEncryptionKeyUnavailable, "key %q", keyID)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if c, ok := k.ciphers[keyID]; ok {
+		return c, nil
+	}
+
+	key, ok := k.keys[keyID]
+	if !ok {
+		if k.provider == nil {
+			return nil, errors.Wrapf(ErrEncryptionKeyUnavailable, "key %q", keyID)
+		}
+
+		var err error
+		key, err = k.provider(keyID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get key %q", keyID)
+		}
+		if key == nil {
+			return nil, errors.Wrapf(ErrEncryptionKeyUnavailable, "key %q", keyID)
+		}
+	}
+
+	c, err := newColumnCipher(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid key %q", keyID)
+	}
+	k.ciphers[keyID] = c
+
+	return c, nil
+}
+
+// seal encodes and encrypts the value of an encrypted column.
+func (k *Keyring) seal(cc *ColumnConstraint, v types.Value) (types.Value, error) {
+	c, err := k.cipher(cc.Encryption.KeyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot encrypt column %q", cc.Column)
+	}
+
+	plaintext, err := v.Encode(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewBlobValue(c.seal(plaintext, []byte(cc.Encryption.KeyID), cc.Encryption.Indexable)), nil
+}
+
+// open decrypts and decodes the value of an encrypted column,
+// stored as a BLOB. It returns the value and the number of bytes read from b.
+func (k *Keyring) open(cc *ColumnConstraint, b []byte) (types.Value, int, error) {
+	sealed, n := types.TypeBlob.Def().Decode(b)
+
+	c, err := k.cipher(cc.Encryption.KeyID)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot decrypt column %q", cc.Column)
+	}
+
+	plaintext, err := c.open(types.AsByteSlice(sealed), []byte(cc.Encryption.KeyID))
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot decrypt column %q", cc.Column)
+	}
+
+	v, _ := cc.Type.Def().Decode(plaintext)
+	return v, n, nil
+}
+
+// columnCipher encrypts values with AES-GCM.
+// Encrypted values are stored as the nonce followed by the ciphertext.
+type columnCipher struct {
+	aead cipher.AEAD
+	// key used to derive the nonce of deterministic encryptions.
+	nonceKey []byte
+}
+
+func newColumnCipher(key []byte) (*columnCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("chai column nonce"))
+
+	return &columnCipher{
+		aead:     aead,
+		nonceKey: mac.Sum(nil),
+	}, nil
+}
+
+// seal encrypts plaintext. If deterministic is true, the nonce is derived
+// from the plaintext so that equal plaintexts produce equal ciphertexts.
+// Otherwise, the nonce is random.
+func (c *columnCipher) seal(plaintext, additionalData []byte, deterministic bool) []byte {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if deterministic {
+		mac := hmac.New(sha256.New, c.nonceKey)
+		mac.Write(plaintext)
+		copy(nonce, mac.Sum(nil))
+	} else {
+		_, _ = rand.Read(nonce)
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData)
+}
+
+func (c *columnCipher) open(sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, errors.New("invalid encrypted value")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil || len(plaintext) == 0 {
+		return nil, errors.New("invalid encrypted value or wrong key")
+	}
+
+	return plaintext, nil
+}
+
+// initEncryptedColumns sets the key id of the encrypted columns
+// created without one.
+func (ti *TableInfo) initEncryptedColumns() {
+	for _, cc := range ti.ColumnConstraints.Ordered {
+		if cc.Encryption != nil && cc.Encryption.KeyID == "" {
+			cc.Encryption.KeyID = ti.TableName + "." + cc.Column
+		}
+	}
+}
+
+// ensureIndexable returns an error if one of the columns is encrypted
+// without allowing indexes.
+func (ti *TableInfo) ensureIndexable(columns []string) error {
+	for _, c := range columns {
+		cc := ti.GetColumnConstraint(c)
+		if cc != nil && cc.Encryption != nil && !cc.Encryption.Indexable {
+			return fmt.Errorf("cannot index encrypted column %q, declare it with ENCRYPTED ALLOW INDEX", c)
+		}
+	}
+
+	return nil
+}
+
+// HasEncryptedColumn returns whether one of the columns is encrypted.
+func (ti *TableInfo) HasEncryptedColumn(columns []string) bool {
+	for _, c := range columns {
+		cc := ti.GetColumnConstraint(c)
+		if cc != nil && cc.Encryption != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IndexValues returns the values of the given columns of r, as stored in an index.
+// Missing columns are returned as NULL. Encrypted columns are returned
+// in their encrypted form, and collated columns as their collation key.
+func (ti *TableInfo) IndexValues(keys *Keyring, columns []string, r row.Row) ([]types.Value, error) {
+	ed, encoded := RowIsEncoded(r, &ti.ColumnConstraints)
+
+	vs := make([]types.Value, 0, len(columns))
+	for _, column := range columns {
+		cc := ti.GetColumnConstraint(column)
+		if cc != nil && cc.Encryption != nil && encoded {
+			// use the encrypted value stored in the row,
+			// which doesn't require the key
+			v, err := ed.encryptedValue(cc)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+			continue
+		}
+
+		v, err := r.Get(column)
+		if err != nil {
+			v = types.NewNullValue()
+		}
+
+		if cc != nil && cc.Encryption != nil && v.Type() != types.TypeNull {
+			v, err = keys.seal(cc, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if cc != nil {
+			v = cc.GetCollation().Key(v)
+		}
+
+		vs = append(vs, v)
+	}
+
+	return vs, nil
+}
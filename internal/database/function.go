@@ -0,0 +1,128 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/cockroachdb/errors"
+)
+
+// FunctionInfo holds the definition of a function created
+// with CREATE FUNCTION. Such a function is a macro: each call
+// evaluates its body, in which the parameters are columns set
+// to the values of the arguments.
+type FunctionInfo struct {
+	Name   string
+	Params []string
+	Body   FunctionBody
+}
+
+// A FunctionBody is the expression returned by a function.
+// It is always an expr.Expr, which cannot be referred to
+// from this package.
+type FunctionBody interface {
+	String() string
+}
+
+// String returns a SQL representation.
+func (f *FunctionInfo) String() string {
+	var s strings.Builder
+
+	s.WriteString("CREATE FUNCTION ")
+	s.WriteString(stringutil.NormalizeIdentifier(f.Name, '`'))
+	s.WriteByte('(')
+	for i, p := range f.Params {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString(stringutil.NormalizeIdentifier(p, '`'))
+	}
+	s.WriteString(") RETURNS (")
+	s.WriteString(f.Body.String())
+	s.WriteByte(')')
+
+	return s.String()
+}
+
+type FunctionInfoRelation struct {
+	Info *FunctionInfo
+}
+
+func (r *FunctionInfoRelation) Type() string {
+	return RelationFunctionType
+}
+
+func (r *FunctionInfoRelation) Name() string {
+	return r.Info.Name
+}
+
+func (r *FunctionInfoRelation) SetName(name string) {
+	r.Info.Name = name
+}
+
+func (r *FunctionInfoRelation) GenerateBaseName() string {
+	return r.Info.Name
+}
+
+func (r *FunctionInfoRelation) Clone() Relation {
+	info := *r.Info
+	info.Params = append([]string(nil), r.Info.Params...)
+	return &FunctionInfoRelation{Info: &info}
+}
+
+// GetFunction returns a function created with CREATE FUNCTION by name.
+// Function names are case insensitive.
+func (c *Catalog) GetFunction(name string) (*FunctionInfo, error) {
+	r, err := c.Cache.Get(RelationFunctionType, strings.ToLower(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.(*FunctionInfoRelation).Info, nil
+}
+
+// ListFunctions returns all the function names sorted lexicographically.
+func (c *Catalog) ListFunctions() []string {
+	return c.Cache.ListObjects(RelationFunctionType)
+}
+
+// CreateFunction creates a function.
+// If it already exists, returns errs.AlreadyExistsError.
+func (c *CatalogWriter) CreateFunction(tx *Transaction, info *FunctionInfo) error {
+	if info.Name == "" {
+		return errors.New("function name required")
+	}
+	if info.Body == nil {
+		return errors.New("function body required")
+	}
+
+	info.Name = strings.ToLower(info.Name)
+
+	seen := make(map[string]struct{}, len(info.Params))
+	for _, p := range info.Params {
+		if _, ok := seen[p]; ok {
+			return errors.Errorf("parameter %s specified more than once", p)
+		}
+		seen[p] = struct{}{}
+	}
+
+	rel := FunctionInfoRelation{Info: info}
+	err := c.Cache.Add(tx, &rel)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Insert(tx, &rel)
+}
+
+// DropFunction deletes a function from the catalog.
+func (c *CatalogWriter) DropFunction(tx *Transaction, name string) error {
+	name = strings.ToLower(name)
+
+	_, err := c.Cache.Delete(tx, RelationFunctionType, name)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Delete(tx, name)
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultHistoryMaxVersions is the default maximum number
+// of versions kept by the history.
+const DefaultHistoryMaxVersions = 1000
+
+// history keeps the versions of the database created by the commits
+// of the last retention period, in order to read the database
+// as it was at a given point in time.
+// Each version pins the data it reads until it is released: when the
+// number of versions exceeds maxVersions, the oldest ones are released,
+// which shortens the period that can be read.
+// Versions are kept in memory and are lost when the database is closed,
+// the history of a database starts when it is opened.
+type history struct {
+	sync.Mutex
+
+	retention time.Duration
+	// maximum number of versions, unlimited if negative.
+	maxVersions int
+	// versions sorted by creation time.
+	versions []historyVersion
+}
+
+type historyVersion struct {
+	ts      time.Time
+	catalog *Catalog
+	version engine.Version
+}
+
+// record adds a version of the database to the history.
+func (h *history) record(ts time.Time, catalog *Catalog, v engine.Version) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.versions = append(h.versions, historyVersion{
+		ts:      ts,
+		catalog: catalog,
+		version: v,
+	})
+
+	h.prune(ts)
+
+	if h.maxVersions > 0 && len(h.versions) > h.maxVersions {
+		n := len(h.versions) - h.maxVersions
+		for _, v := range h.versions[:n] {
+			_ = v.version.Release()
+		}
+		h.versions = h.versions[n:]
+	}
+}
+
+// prune releases the versions that are not needed anymore to read
+// the database at any time of the retention period.
+// It must be called with the lock held.
+func (h *history) prune(now time.Time) {
+	min := now.Add(-h.retention)
+
+	// keep the latest version created before the retention period,
+	// it represents the state of the database at the start of the period.
+	var i int
+	for i+1 < len(h.versions) && !h.versions[i+1].ts.After(min) {
+		_ = h.versions[i].version.Release()
+		i++
+	}
+
+	h.versions = h.versions[i:]
+}
+
+// acquire returns a session reading the database as it was at the given time,
+// along with the catalog of that time.
+func (h *history) acquire(ts, now time.Time) (engine.Session, *Catalog, error) {
+	if h.retention <= 0 {
+		return nil, nil, errors.New("cannot read the database as of a past time: history retention is disabled")
+	}
+
+	if ts.After(now) {
+		return nil, nil, errors.Errorf("cannot read the database as of %s: time is in the future", ts.Format(time.RFC3339Nano))
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	h.prune(now)
+
+	if len(h.versions) == 0 || ts.Before(h.versions[0].ts) || ts.Before(now.Add(-h.retention)) {
+		return nil, nil, errors.Errorf("cannot read the database as of %s: time is outside of the history retention period", ts.Format(time.RFC3339Nano))
+	}
+
+	// find the latest version created before ts
+	i := len(h.versions) - 1
+	for i > 0 && h.versions[i].ts.After(ts) {
+		i--
+	}
+
+	return h.versions[i].version.NewSession(), h.versions[i].catalog, nil
+}
+
+// releaseAll releases all the versions of the history.
+func (h *history) releaseAll() {
+	h.Lock()
+	defer h.Unlock()
+
+	for _, v := range h.versions {
+		_ = v.version.Release()
+	}
+
+	h.versions = nil
+}
+
+// A Snapshot pins a version of the database, read by the transactions
+// started with TxOptions.Snapshot until it is released. Unlike the
+// versions of the history, it doesn't depend on the retention period.
+type Snapshot struct {
+	db      *Database
+	ts      time.Time
+	catalog *Catalog
+
+	mu      sync.Mutex
+	version engine.Version
+}
+
+// NewSnapshot pins the current version of the database.
+// The snapshot must be released by calling Release, the data
+// it reads cannot be reclaimed by compactions until then.
+func (db *Database) NewSnapshot() (*Snapshot, error) {
+	if db.closeContext.Err() != nil {
+		return nil, errors.New("database is closed")
+	}
+
+	// prevent commits from changing the catalog
+	// while the version is created.
+	db.txmu.RLock()
+	defer db.txmu.RUnlock()
+
+	s := Snapshot{
+		db:      db,
+		ts:      time.Now(),
+		catalog: db.Catalog(),
+		version: db.Engine.NewVersion(),
+	}
+
+	db.snapshots.Lock()
+	defer db.snapshots.Unlock()
+
+	if db.snapshots.m == nil {
+		db.snapshots.m = make(map[*Snapshot]struct{})
+	}
+	db.snapshots.m[&s] = struct{}{}
+
+	return &s, nil
+}
+
+// Time returns the time at which the snapshot was created.
+func (s *Snapshot) Time() time.Time {
+	return s.ts
+}
+
+// newSession returns a session reading the version of the snapshot.
+func (s *Snapshot) newSession() (engine.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.version == nil {
+		return nil, errors.New("snapshot is released")
+	}
+
+	return s.version.NewSession(), nil
+}
+
+// Release the snapshot. The transactions that are reading it
+// remain valid, but no new transaction can be started with it.
+// It is released automatically when the database is closed.
+func (s *Snapshot) Release() error {
+	s.db.snapshots.Lock()
+	delete(s.db.snapshots.m, s)
+	s.db.snapshots.Unlock()
+
+	return s.release()
+}
+
+func (s *Snapshot) release() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.version == nil {
+		return nil
+	}
+
+	err := s.version.Release()
+	s.version = nil
+	return err
+}
+
+// releaseSnapshots releases the snapshots that are still pinned.
+func (db *Database) releaseSnapshots() {
+	db.snapshots.Lock()
+	defer db.snapshots.Unlock()
+
+	for s := range db.snapshots.m {
+		_ = s.release()
+	}
+
+	db.snapshots.m = nil
+}
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"fmt"
 
-	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/engine"
 	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
@@ -25,6 +25,10 @@ type Index struct {
 	// For example, an index created with `CREATE INDEX idx_a_b ON foo (a, b)` has an arity of 2.
 	Arity int
 	Tree  *tree.Tree
+	// IVF is set for vector indexes, which associate keys
+	// with the lists of their nearest centroids instead of
+	// their values.
+	IVF *IVFOptions
 }
 
 // NewIndex creates an index that associates values with a list of keys.
@@ -32,6 +36,7 @@ func NewIndex(tr *tree.Tree, opts IndexInfo) *Index {
 	return &Index{
 		Tree:  tr,
 		Arity: len(opts.Columns),
+		IVF:   opts.IVF,
 	}
 }
 
@@ -59,6 +64,10 @@ func (idx *Index) Set(vs []types.Value, key []byte) error {
 		return fmt.Errorf("cannot index %d values on an index of arity %d", len(vs), idx.Arity)
 	}
 
+	if idx.IVF != nil {
+		return idx.ivfSet(vs[0], key)
+	}
+
 	// append the key to the values
 	values := append(vs, types.NewBlobValue(key))
 
@@ -95,8 +104,34 @@ func (idx *Index) Exists(vs []types.Value) (bool, *tree.Key, error) {
 	return found, dKey, err
 }
 
+// duplicate returns the key of the second entry of the index
+// whose values are encoded as prefix, or nil if there is at most one.
+func (idx *Index) duplicate(prefix []byte) (*tree.Key, error) {
+	k := tree.NewEncodedKey(prefix)
+
+	var n int
+	var dKey *tree.Key
+	err := idx.iterateOnRange(&tree.Range{Min: k, Max: k}, false, func(_ *tree.Key, key *tree.Key) error {
+		n++
+		if n > 1 {
+			dKey = tree.NewEncodedKey(bytes.Clone(key.Encoded))
+			return errStop
+		}
+
+		return nil
+	})
+	if err == errStop {
+		err = nil
+	}
+	return dKey, err
+}
+
 // Delete all the references to the key from the index.
 func (idx *Index) Delete(vs []types.Value, key []byte) error {
+	if idx.IVF != nil {
+		return idx.ivfDelete(vs[0], key)
+	}
+
 	vk := tree.NewKey(vs...)
 	rng := tree.Range{
 		Min: vk,
@@ -0,0 +1,408 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// IndexBuildBatchSize is the number of rows scanned, or of entries
+// written, between two updates of the progress of an index build.
+const IndexBuildBatchSize = 10000
+
+// Phases of an index build
+const (
+	IndexBuildScanning = "scanning"
+	IndexBuildWriting  = "writing"
+	IndexBuildDone     = "done"
+	IndexBuildFailed   = "failed"
+)
+
+var indexBuildsTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName:      IndexBuildsTableName,
+		StoreNamespace: IndexBuildsTableNamespace,
+		ReadOnly:       true,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "index_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "table_name", Type: types.TypeText},
+			&ColumnConstraint{Position: 2, Column: "phase", Type: types.TypeText},
+			&ColumnConstraint{Position: 3, Column: "rows_scanned", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 4, Column: "entries_written", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 5, Column: "started_at", Type: types.TypeTimestamp},
+			&ColumnConstraint{Position: 6, Column: "updated_at", Type: types.TypeTimestamp},
+			&ColumnConstraint{Position: 7, Column: "error", Type: types.TypeText},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       IndexBuildsTableName + "_pk",
+				Columns:    []string{"index_name"},
+				PrimaryKey: true,
+			},
+		},
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// IndexBuildsTableInfo returns the information of the __chai_index_builds table.
+// The table is not stored in the catalog: it is added to the catalog
+// when it is loaded, as a read-only table.
+func IndexBuildsTableInfo() *TableInfo {
+	return indexBuildsTableInfo.Clone()
+}
+
+// An IndexBuilder populates an empty index with the rows of its table.
+//
+// Instead of writing the entries as the rows are scanned, in the order of the table,
+// the builder stores them in a transient tree which sorts them, and writes them
+// in the order of the index once every row has been added.
+// Writing the entries in order is much faster on large tables, and the duplicate
+// values of unique indexes are found as the entries are written.
+// Vector indexes are populated as the rows are added.
+//
+// The progress of the build is stored in the __chai_index_builds table every
+// IndexBuildBatchSize rows, from other transactions, so that it can be followed
+// while the build is running.
+type IndexBuilder struct {
+	tx    *Transaction
+	info  *IndexInfo
+	tinfo *TableInfo
+	idx   *Index
+
+	// nil for vector indexes
+	entries *indexEntries
+
+	status indexBuildStatus
+}
+
+// indexBuildStatus is a row of the __chai_index_builds table.
+type indexBuildStatus struct {
+	IndexName      string
+	TableName      string
+	Phase          string
+	RowsScanned    int64
+	EntriesWritten int64
+	StartedAt      time.Time
+	Error          string
+}
+
+func (s *indexBuildStatus) row(now time.Time) row.Row {
+	r := row.NewColumnBuffer().
+		Add("index_name", types.NewTextValue(s.IndexName)).
+		Add("table_name", types.NewTextValue(s.TableName)).
+		Add("phase", types.NewTextValue(s.Phase)).
+		Add("rows_scanned", types.NewBigintValue(s.RowsScanned)).
+		Add("entries_written", types.NewBigintValue(s.EntriesWritten)).
+		Add("started_at", timeValue(s.StartedAt)).
+		Add("updated_at", timeValue(now))
+
+	if s.Error != "" {
+		r.Add("error", types.NewTextValue(s.Error))
+	} else {
+		r.Add("error", types.NewNullValue())
+	}
+
+	return r
+}
+
+// NewIndexBuilder returns a builder populating the given index.
+// The existing entries of the index are removed first.
+// The builder must be closed after use.
+func NewIndexBuilder(tx *Transaction, indexName string) (*IndexBuilder, error) {
+	info, err := tx.Catalog.GetIndexInfo(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := tx.Catalog.GetIndex(tx, indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	tinfo, err := tx.Catalog.GetTableInfo(info.Owner.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = idx.Truncate()
+	if err != nil {
+		return nil, err
+	}
+
+	b := IndexBuilder{
+		tx:    tx,
+		info:  info,
+		tinfo: tinfo,
+		idx:   idx,
+		status: indexBuildStatus{
+			IndexName: indexName,
+			TableName: tinfo.TableName,
+			Phase:     IndexBuildScanning,
+			StartedAt: time.Now(),
+		},
+	}
+
+	if idx.IVF == nil {
+		b.entries, err = newIndexEntries(tx, info, tinfo, idx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = b.saveStatus()
+	if err != nil {
+		_ = b.Close()
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// Add the entry of a row of the table to the index.
+func (b *IndexBuilder) Add(r Row) error {
+	if b.entries != nil {
+		err := b.entries.add(r)
+		if err != nil {
+			return b.fail(err)
+		}
+	} else {
+		vs, err := b.tinfo.IndexValues(b.tx.Keyring(), b.info.Columns, r)
+		if err != nil {
+			return b.fail(err)
+		}
+
+		pk, err := b.tinfo.EncodeKey(r.Key())
+		if err != nil {
+			return b.fail(err)
+		}
+
+		err = b.idx.Set(vs, pk)
+		if err != nil {
+			return b.fail(errors.Wrap(err, "error while inserting index value"))
+		}
+	}
+
+	b.status.RowsScanned++
+	if b.status.RowsScanned%IndexBuildBatchSize == 0 {
+		return b.saveStatus()
+	}
+
+	return nil
+}
+
+// Finish writes the sorted entries to the index.
+// If the index is unique, it returns an error if two rows have the same values.
+func (b *IndexBuilder) Finish() error {
+	if b.entries != nil {
+		b.status.Phase = IndexBuildWriting
+		err := b.saveStatus()
+		if err != nil {
+			return err
+		}
+
+		err = b.write()
+		if err != nil {
+			return b.fail(err)
+		}
+	} else {
+		b.status.EntriesWritten = b.status.RowsScanned
+	}
+
+	b.status.Phase = IndexBuildDone
+	return b.saveStatus()
+}
+
+func (b *IndexBuilder) write() error {
+	prefix := encoding.EncodeUint(nil, uint64(b.idx.Tree.Namespace))
+
+	return b.entries.iterate(func(entry []byte, duplicate bool, k *tree.Key) error {
+		if duplicate {
+			pk, _, err := decodeIndexEntry(k)
+			if err != nil {
+				return err
+			}
+
+			return NewUniqueViolationError(b.tinfo, b.info, pk)
+		}
+
+		key := make([]byte, 0, len(prefix)+len(entry))
+		key = append(append(key, prefix...), entry...)
+		err := b.idx.Tree.Put(tree.NewEncodedKey(key), nil)
+		if err != nil {
+			return errors.Wrap(err, "error while inserting index value")
+		}
+
+		b.status.EntriesWritten++
+		if b.status.EntriesWritten%IndexBuildBatchSize == 0 {
+			return b.saveStatus()
+		}
+
+		return nil
+	})
+}
+
+// Close releases the transient tree. If the build didn't finish,
+// it is reported as failed.
+func (b *IndexBuilder) Close() error {
+	if b.status.Phase == IndexBuildScanning || b.status.Phase == IndexBuildWriting {
+		_ = b.fail(nil)
+	}
+
+	if b.entries == nil {
+		return nil
+	}
+
+	return b.entries.close()
+}
+
+// fail reports the build as failed and returns err.
+func (b *IndexBuilder) fail(err error) error {
+	b.status.Phase = IndexBuildFailed
+	if err != nil {
+		b.status.Error = err.Error()
+	}
+	_ = b.saveStatus()
+
+	return err
+}
+
+// saveStatus stores the status of the build in the __chai_index_builds table,
+// in its own transaction. Conflicting updates of the status of an index
+// built concurrently by another transaction are ignored.
+func (b *IndexBuilder) saveStatus() error {
+	if b.tx.db == nil {
+		return nil
+	}
+
+	tx, err := b.tx.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tb, err := tx.Catalog.GetTable(tx, IndexBuildsTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	enc, err := tb.Info.EncodeRow(tx, nil, b.status.row(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	err = tb.Tree.Put(tree.NewKey(types.NewTextValue(b.status.IndexName)), enc)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if errors.Is(err, engine.ErrTxConflict) {
+		return nil
+	}
+
+	return err
+}
+
+// indexEntries sorts the entries of an index in a transient tree.
+// The entries are stored with the same encoding as in the index,
+// except for the namespace. Their value is the length of the encoded
+// values of the entry if their unicity must be checked, or 0.
+type indexEntries struct {
+	tx      *Transaction
+	info    *IndexInfo
+	tinfo   *TableInfo
+	order   tree.SortOrder
+	temp    *tree.Tree
+	cleanup func() error
+}
+
+func newIndexEntries(tx *Transaction, info *IndexInfo, tinfo *TableInfo, idx *Index) (*indexEntries, error) {
+	temp, cleanup, err := tree.NewTransient(tx.NewTransientSession(), tx.Catalog.GetFreeTransientNamespace(), idx.Tree.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &indexEntries{
+		tx:      tx,
+		info:    info,
+		tinfo:   tinfo,
+		order:   idx.Tree.Order,
+		temp:    temp,
+		cleanup: cleanup,
+	}, nil
+}
+
+// add the entry of the row.
+func (e *indexEntries) add(r Row) error {
+	vs, err := e.tinfo.IndexValues(e.tx.Keyring(), e.info.Columns, r)
+	if err != nil {
+		return err
+	}
+
+	pk, err := e.tinfo.EncodeKey(r.Key())
+	if err != nil {
+		return err
+	}
+
+	// NULL values are not checked for unicity
+	var n int
+	if e.info.Unique && !slices.ContainsFunc(vs, func(v types.Value) bool { return v.Type() == types.TypeNull }) {
+		enc, err := tree.NewKey(vs...).Encode(0, e.order)
+		if err != nil {
+			return err
+		}
+		n = len(enc)
+	}
+
+	return e.temp.Put(tree.NewKey(append(vs, types.NewBlobValue(pk))...), binary.AppendUvarint(nil, uint64(n)))
+}
+
+// iterate calls fn for every entry in the order of the index, with the encoded
+// entry without namespace. duplicate is true if the values of a unique index
+// are the same as those of the previous entry.
+func (e *indexEntries) iterate(fn func(entry []byte, duplicate bool, k *tree.Key) error) error {
+	tempPrefix := encoding.EncodeUint(nil, uint64(e.temp.Namespace))
+
+	var last []byte
+	return e.temp.IterateOnRange(nil, false, func(k *tree.Key, v []byte) error {
+		entry := k.Encoded[len(tempPrefix):]
+
+		var duplicate bool
+		n, _ := binary.Uvarint(v)
+		if n > 0 {
+			duplicate = last != nil && bytes.Equal(last, entry[:n])
+			last = append(last[:0], entry[:n]...)
+		}
+
+		return fn(entry, duplicate, k)
+	})
+}
+
+func (e *indexEntries) close() error {
+	return e.cleanup()
+}
+
+// decodeIndexEntry returns the primary key and the values of an entry of an index.
+func decodeIndexEntry(k *tree.Key) (*tree.Key, []types.Value, error) {
+	values, err := k.Decode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tree.NewEncodedKey(types.AsByteSlice(values[len(values)-1])), values[:len(values)-1], nil
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"bytes"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// Problems found by CheckIndex
+const (
+	// A row has no entry in the index.
+	IndexEntryMissing = "missing"
+	// An entry of the index doesn't match any row,
+	// or has values that differ from those of the row.
+	IndexEntryOrphan = "orphan"
+	// A row has the same values as another one in a unique index.
+	IndexEntryDuplicate = "duplicate"
+)
+
+// An IndexMismatch is an inconsistency between an index and its table.
+type IndexMismatch struct {
+	Problem string
+	// Primary key of the row.
+	Key *tree.Key
+	// Indexed values.
+	Values []types.Value
+}
+
+// CheckIndex verifies that an index has exactly one entry for every row
+// of its table, with the values of the row, and calls fn for every mismatch.
+// The expected entries are sorted in a transient tree and compared
+// with those of the index, in order.
+// Vector indexes are not checked.
+func CheckIndex(tx *Transaction, indexName string, fn func(m *IndexMismatch) error) error {
+	info, err := tx.Catalog.GetIndexInfo(indexName)
+	if err != nil {
+		return err
+	}
+
+	idx, err := tx.Catalog.GetIndex(tx, indexName)
+	if err != nil {
+		return err
+	}
+	if idx.IVF != nil {
+		return nil
+	}
+
+	t, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+
+	entries, err := newIndexEntries(tx, info, t.Info, idx)
+	if err != nil {
+		return err
+	}
+	defer entries.close()
+
+	err = t.IterateOnRange(nil, false, func(_ *tree.Key, r Row) error {
+		return entries.add(r)
+	})
+	if err != nil {
+		return err
+	}
+
+	it, err := newIndexIterator(idx.Tree)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	report := func(problem string, k *tree.Key) error {
+		pk, vs, err := decodeIndexEntry(k)
+		if err != nil {
+			return err
+		}
+
+		return fn(&IndexMismatch{
+			Problem: problem,
+			Key:     pk,
+			Values:  vs,
+		})
+	}
+
+	// entries of the index that come before the expected entry
+	// don't match any row
+	orphans := func(entry []byte) error {
+		for ; it.Valid(); it.Next() {
+			if entry != nil && bytes.Compare(it.Entry(), entry) >= 0 {
+				return nil
+			}
+
+			err := report(IndexEntryOrphan, tree.NewEncodedKey(bytes.Clone(it.Key())))
+			if err != nil {
+				return err
+			}
+		}
+
+		return it.Error()
+	}
+
+	it.First()
+	err = entries.iterate(func(entry []byte, duplicate bool, k *tree.Key) error {
+		if duplicate {
+			err := report(IndexEntryDuplicate, k)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := orphans(entry)
+		if err != nil {
+			return err
+		}
+
+		if it.Valid() && bytes.Equal(it.Entry(), entry) {
+			it.Next()
+			return nil
+		}
+
+		return report(IndexEntryMissing, k)
+	})
+	if err != nil {
+		return err
+	}
+
+	return orphans(nil)
+}
+
+// indexIterator iterates on the entries of an index in order.
+// The entries of a partitioned index are merged from its partitions.
+type indexIterator struct {
+	its []engine.Iterator
+	// position of the iterator on the smallest entry, or -1
+	cur int
+}
+
+func newIndexIterator(tr *tree.Tree) (*indexIterator, error) {
+	var it indexIterator
+
+	for _, sub := range tr.Subtrees() {
+		start, end := sub.Bounds()
+		i, err := sub.Session.Iterator(&engine.IterOptions{
+			LowerBound: start,
+			UpperBound: end,
+		})
+		if err != nil {
+			_ = it.Close()
+			return nil, err
+		}
+
+		it.its = append(it.its, i)
+	}
+
+	return &it, nil
+}
+
+func (it *indexIterator) First() {
+	for _, i := range it.its {
+		i.First()
+	}
+	it.next()
+}
+
+func (it *indexIterator) Next() {
+	it.its[it.cur].Next()
+	it.next()
+}
+
+// next selects the iterator positioned on the smallest entry.
+func (it *indexIterator) next() {
+	it.cur = -1
+	for n, i := range it.its {
+		if !i.Valid() {
+			continue
+		}
+
+		if it.cur < 0 || bytes.Compare(entryOf(i.Key()), it.Entry()) < 0 {
+			it.cur = n
+		}
+	}
+}
+
+func (it *indexIterator) Valid() bool {
+	return it.cur >= 0
+}
+
+func (it *indexIterator) Key() []byte {
+	return it.its[it.cur].Key()
+}
+
+// Entry returns the current entry without namespace.
+func (it *indexIterator) Entry() []byte {
+	return entryOf(it.Key())
+}
+
+func (it *indexIterator) Error() error {
+	for _, i := range it.its {
+		if err := i.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (it *indexIterator) Close() error {
+	var err error
+	for _, i := range it.its {
+		if cerr := i.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+func entryOf(k []byte) []byte {
+	return k[encoding.Skip(k):]
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// inferableTypes are the types InferColumnTypes can infer for a TEXT column.
+// When several of them match the same number of values,
+// the first one is inferred.
+var inferableTypes = []types.Type{
+	types.TypeBoolean,
+	types.TypeBigint,
+	types.TypeDouble,
+	types.TypeTimestamp,
+}
+
+// maxNonconformingKeys is the maximum number of keys
+// of nonconforming rows reported per column.
+const maxNonconformingKeys = 10
+
+// An InferredColumn is the type inferred for a TEXT column
+// from the values stored in it.
+type InferredColumn struct {
+	Column string
+	// Type is the type to which the largest number of values of the column
+	// can be converted without loss. It is TEXT if none of them can.
+	Type types.Type
+	// NonNull is the number of values of the column that are not NULL.
+	NonNull int64
+	// Nonconforming is the number of values that cannot be converted to Type.
+	Nonconforming int64
+	// NonconformingKeys are the primary keys of the first nonconforming rows,
+	// at most 10 of them.
+	NonconformingKeys []string
+}
+
+// Conforms returns true if the column has values
+// and all of them can be converted to the inferred type.
+func (c *InferredColumn) Conforms() bool {
+	return c.Type != types.TypeText && c.NonNull > 0 && c.Nonconforming == 0
+}
+
+// inferredCandidate counts the values of a column that can be
+// converted to one of the inferable types.
+type inferredCandidate struct {
+	matches int64
+	// keys of the first rows whose value doesn't match
+	keys []string
+}
+
+// InferColumnTypes scans the table and infers the type of each of its TEXT columns
+// from the values stored in it: integers, floating point numbers, booleans
+// written as true or false, and timestamps.
+// Columns with a default value, a collation or encrypted values are ignored,
+// and so is the column a table is partitioned by.
+func (t *Table) InferColumnTypes() ([]InferredColumn, error) {
+	var columns []*ColumnConstraint
+	for _, cc := range t.Info.ColumnConstraints.Ordered {
+		if !isInferable(t.Info, cc) {
+			continue
+		}
+
+		columns = append(columns, cc)
+	}
+
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	inferred := make([]InferredColumn, len(columns))
+	candidates := make([][]inferredCandidate, len(columns))
+	for i, cc := range columns {
+		inferred[i].Column = cc.Column
+		candidates[i] = make([]inferredCandidate, len(inferableTypes))
+	}
+
+	err := t.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+		for i, cc := range columns {
+			v, err := r.Get(cc.Column)
+			if err != nil {
+				return err
+			}
+			if types.IsNull(v) {
+				continue
+			}
+
+			inferred[i].NonNull++
+			s := types.AsString(v)
+			for j, tp := range inferableTypes {
+				c := &candidates[i][j]
+				if textConformsTo(s, tp) {
+					c.matches++
+				} else if len(c.keys) < maxNonconformingKeys {
+					c.keys = append(c.keys, key.String())
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read table %q", t.Info.TableName)
+	}
+
+	for i := range inferred {
+		ic := &inferred[i]
+		ic.Type = types.TypeText
+
+		best := -1
+		for j, c := range candidates[i] {
+			if c.matches > 0 && (best < 0 || c.matches > candidates[i][best].matches) {
+				best = j
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		ic.Type = inferableTypes[best]
+		ic.Nonconforming = ic.NonNull - candidates[i][best].matches
+		ic.NonconformingKeys = candidates[i][best].keys
+	}
+
+	return inferred, nil
+}
+
+// isInferable returns true if the type of the column can be inferred
+// from its values and changed without changing any other constraint.
+func isInferable(ti *TableInfo, cc *ColumnConstraint) bool {
+	if cc.Type != types.TypeText {
+		return false
+	}
+	if cc.DefaultValue != nil || cc.Collation != "" || cc.Encryption != nil {
+		return false
+	}
+	// the partitions are bounded by values of the type of the column
+	if ti.Partitioning != nil && ti.Partitioning.Column == cc.Column {
+		return false
+	}
+
+	return true
+}
+
+// textConformsTo returns true if the text can be converted
+// to the given type and back without loss.
+func textConformsTo(s string, tp types.Type) bool {
+	switch tp {
+	case types.TypeBoolean:
+		// unlike strconv.ParseBool, 1 and 0 are integers
+		_, err := strconv.ParseBool(s)
+		return err == nil && s != "" && strings.ContainsAny(s[:1], "tTfF")
+	case types.TypeBigint:
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	case types.TypeDouble:
+		// NaN and infinities are not numbers written in text files
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil && strings.ContainsAny(s, "0123456789")
+	case types.TypeTimestamp:
+		// words like "now" or "today" are relative to the time they are read
+		if s == "" || s[0] < '0' || s[0] > '9' {
+			return false
+		}
+		_, err := types.ParseTimestamp(s)
+		return err == nil
+	}
+
+	return false
+}
+
+// AlterColumnTypes changes the type of columns of a table.
+// It doesn't convert the values of the columns, the rows
+// must be rewritten with the new types.
+func (c *CatalogWriter) AlterColumnTypes(tx *Transaction, tableName string, columnTypes map[string]types.Type) error {
+	r, err := c.Cache.Get(RelationTableType, tableName)
+	if err != nil {
+		return err
+	}
+	ti := r.(*TableInfoRelation).Info
+
+	err = ti.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	clone := ti.Clone()
+	var altered int
+	for i, cc := range clone.ColumnConstraints.Ordered {
+		tp, ok := columnTypes[cc.Column]
+		if !ok {
+			continue
+		}
+
+		// the column constraints are shared with the original table info
+		cp := *cc
+		cp.Type = tp
+		clone.ColumnConstraints.Ordered[i] = &cp
+		clone.ColumnConstraints.ByColumn[cc.Column] = &cp
+		altered++
+	}
+	if altered != len(columnTypes) {
+		return errors.Errorf("cannot alter columns of table %q: unknown column", tableName)
+	}
+	clone.BuildPrimaryKey()
+
+	cloneRel := &TableInfoRelation{Info: clone}
+	err = c.Cache.Replace(tx, cloneRel)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Replace(tx, tableName, cloneRel)
+}
@@ -2,11 +2,14 @@ package database
 
 import (
 	"fmt"
+	"maps"
 	"math"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/stringutil"
 	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
@@ -28,6 +31,66 @@ type TableInfo struct {
 	TableConstraints  TableConstraints
 
 	PrimaryKey *PrimaryKey
+
+	// Name of the TIMESTAMP column holding the expiration time
+	// of each row, if any. Expired rows are ignored by reads and
+	// periodically deleted.
+	TTLColumn string
+
+	// Policy describing the old rows periodically purged, if any.
+	Retention *RetentionPolicy
+
+	// Codec compressing the rows before they are stored.
+	Compression Compression
+
+	// Strict tables reject the rows with columns that are not declared,
+	// which are otherwise ignored.
+	Strict bool
+
+	// Virtual returns the rows of a virtual table, which are computed
+	// when the table is read instead of being stored.
+	// Virtual tables are read-only, must have a primary key
+	// and are not stored in the catalog.
+	Virtual func(tx *Transaction, fn func(r row.Row) error) error
+
+	// Temporary tables are only visible to the connection that created them
+	// and are dropped when it is closed. They are not stored in the catalog
+	// and their rows are stored in transient namespaces.
+	Temporary bool
+	// What happens to a temporary table when a transaction commits.
+	OnCommit OnCommitAction
+
+	// How the rows are split into partitions, if the table is partitioned.
+	Partitioning *TablePartitioning
+
+	// Name under which the database holding the table is attached
+	// to the connection, empty for the tables of the database itself.
+	// The tables of attached databases are read-only.
+	Database string
+}
+
+// OnCommitAction is what happens to a temporary table
+// when a transaction of its connection commits.
+type OnCommitAction uint8
+
+const (
+	// OnCommitPreserveRows keeps the table and its rows.
+	OnCommitPreserveRows OnCommitAction = iota
+	// OnCommitDeleteRows deletes all the rows of the table.
+	OnCommitDeleteRows
+	// OnCommitDrop drops the table.
+	OnCommitDrop
+)
+
+func (a OnCommitAction) String() string {
+	switch a {
+	case OnCommitDeleteRows:
+		return "DELETE ROWS"
+	case OnCommitDrop:
+		return "DROP"
+	}
+
+	return "PRESERVE ROWS"
 }
 
 func (ti *TableInfo) AddColumnConstraint(newCc *ColumnConstraint) error {
@@ -67,6 +130,12 @@ func (ti *TableInfo) AddTableConstraint(newTc *TableConstraint) error {
 		// add NOT NULL constraint to columns
 		for _, p := range newTc.Columns {
 			fc := ti.GetColumnConstraint(p)
+			if fc.Encryption != nil {
+				return fmt.Errorf("encrypted column %q cannot be part of the primary key", p)
+			}
+			if fc.Collation != "" {
+				return fmt.Errorf("collated column %q cannot be part of the primary key", p)
+			}
 			fc.IsNotNull = true
 		}
 
@@ -177,20 +246,61 @@ func (ti *TableInfo) GetColumnConstraint(column string) *ColumnConstraint {
 	return ti.ColumnConstraints.GetColumnConstraint(column)
 }
 
+// IsDeferred returns whether the unique constraint enforced by the given index
+// is checked when the transaction commits.
+func (ti *TableInfo) IsDeferred(idx *IndexInfo) bool {
+	tc := ti.UniqueConstraint(idx)
+	return tc != nil && tc.Deferred
+}
+
+// UniqueConstraint returns the unique constraint enforced by the given index,
+// or nil if the index wasn't created for a constraint of the table.
+func (ti *TableInfo) UniqueConstraint(idx *IndexInfo) *TableConstraint {
+	if !idx.Unique || idx.Owner.TableName != ti.TableName {
+		return nil
+	}
+
+	for _, tc := range ti.TableConstraints {
+		if tc.Unique && slices.Equal(tc.Columns, idx.Owner.Columns) {
+			return tc
+		}
+	}
+
+	return nil
+}
+
 func (ti *TableInfo) EncodeKey(key *tree.Key) ([]byte, error) {
 	var order tree.SortOrder
 	if ti.PrimaryKey != nil {
 		order = ti.PrimaryKey.SortOrder
 	}
 
-	return key.Encode(ti.StoreNamespace, order)
+	ns := ti.StoreNamespace
+	if p := ti.Partitioning; p != nil && key.Encoded == nil {
+		values, err := key.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		i, err := p.locate(ti, values[0])
+		if err != nil {
+			return nil, err
+		}
+		ns = p.Partitions[i].Namespace
+	}
+
+	return key.Encode(ns, order)
 }
 
 // String returns a SQL representation.
 func (ti *TableInfo) String() string {
 	var s strings.Builder
 
-	fmt.Fprintf(&s, "CREATE TABLE %s (", stringutil.NormalizeIdentifier(ti.TableName, '`'))
+	s.WriteString("CREATE ")
+	if ti.Temporary {
+		s.WriteString("TEMPORARY ")
+	}
+	fmt.Fprintf(&s, "TABLE %s (", stringutil.NormalizeIdentifier(ti.TableName, '`'))
 
 	for i, fc := range ti.ColumnConstraints.Ordered {
 		if i > 0 {
@@ -213,9 +323,70 @@ func (ti *TableInfo) String() string {
 
 	s.WriteString(")")
 
+	var opts []string
+	if ti.TTLColumn != "" {
+		opts = append(opts, "ttl_field = "+stringutil.NormalizeIdentifier(ti.TTLColumn, '`'))
+	}
+	if ti.Retention != nil {
+		opts = append(opts, "retention = "+ti.Retention.String())
+	}
+	if ti.Compression != CompressionNone {
+		opts = append(opts, "compression = '"+ti.Compression.String()+"'")
+	}
+	if ti.Strict {
+		opts = append(opts, "strict = true")
+	}
+	if len(opts) > 0 {
+		fmt.Fprintf(&s, " WITH (%s)", strings.Join(opts, ", "))
+	}
+
+	if ti.Partitioning != nil {
+		s.WriteString(" ")
+		s.WriteString(ti.Partitioning.String())
+	}
+
+	if ti.OnCommit != OnCommitPreserveRows {
+		s.WriteString(" ON COMMIT ")
+		s.WriteString(ti.OnCommit.String())
+	}
+
 	return s.String()
 }
 
+// IsExpired returns whether the row is expired at the given time,
+// according to the TTL column of the table.
+// Rows whose TTL column is NULL never expire.
+func (ti *TableInfo) IsExpired(r row.Row, now time.Time) (bool, error) {
+	if ti.TTLColumn == "" {
+		return false, nil
+	}
+
+	v, err := r.Get(ti.TTLColumn)
+	if err != nil {
+		if errors.Is(err, types.ErrColumnNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if v.Type() != types.TypeTimestamp {
+		return false, nil
+	}
+
+	return !types.AsTime(v).After(now), nil
+}
+
+// CheckWritable returns an error if the rows of the table cannot be written.
+func (ti *TableInfo) CheckWritable() error {
+	if ti.Database != "" {
+		return errors.Errorf("cannot write to table %s: %v", ti.TableName, errAttachedReadOnly(ti.Database))
+	}
+	if ti.ReadOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	return nil
+}
+
 // Clone creates another tableInfo with the same values.
 func (ti *TableInfo) Clone() *TableInfo {
 	cp := *ti
@@ -227,6 +398,7 @@ func (ti *TableInfo) Clone() *TableInfo {
 		cp.ColumnConstraints.ByColumn[ti.ColumnConstraints.Ordered[i].Column] = ti.ColumnConstraints.Ordered[i]
 	}
 	cp.TableConstraints = append(cp.TableConstraints, ti.TableConstraints...)
+	cp.Partitioning = ti.Partitioning.Clone()
 	return &cp
 }
 
@@ -249,10 +421,25 @@ type IndexInfo struct {
 	// If set to true, values will be associated with at most one key. False by default.
 	Unique bool
 
+	// If set, this is a vector index used to search
+	// the nearest neighbors of a vector.
+	IVF *IVFOptions
+
 	// If set, this index has been created from a table constraint
 	// i.e CREATE TABLE tbl(a INT UNIQUE)
 	// The path refers to the path this index is related to.
 	Owner Owner
+
+	// Set if the index belongs to a temporary table.
+	Temporary bool
+
+	// Namespace of the store associated with each partition
+	// of the index, if its table is partitioned.
+	Partitions map[string]tree.Namespace
+
+	// Name under which the database holding the index is attached
+	// to the connection, empty for the indexes of the database itself.
+	Database string
 }
 
 // String returns a SQL representation.
@@ -264,7 +451,11 @@ func (idx *IndexInfo) String() string {
 		s.WriteString("UNIQUE ")
 	}
 
-	fmt.Fprintf(&s, "INDEX %s ON %s (", stringutil.NormalizeIdentifier(idx.IndexName, '`'), stringutil.NormalizeIdentifier(idx.Owner.TableName, '`'))
+	fmt.Fprintf(&s, "INDEX %s ON %s ", stringutil.NormalizeIdentifier(idx.IndexName, '`'), stringutil.NormalizeIdentifier(idx.Owner.TableName, '`'))
+	if idx.IVF != nil {
+		s.WriteString("USING ivf ")
+	}
+	s.WriteString("(")
 
 	for i, p := range idx.Columns {
 		if i > 0 {
@@ -281,6 +472,11 @@ func (idx *IndexInfo) String() string {
 
 	s.WriteString(")")
 
+	if idx.IVF != nil {
+		s.WriteString(" ")
+		s.WriteString(idx.IVF.String())
+	}
+
 	return s.String()
 }
 
@@ -291,6 +487,13 @@ func (i IndexInfo) Clone() *IndexInfo {
 	c.Columns = make([]string, len(i.Columns))
 	copy(c.Columns, i.Columns)
 
+	if i.IVF != nil {
+		ivf := *i.IVF
+		c.IVF = &ivf
+	}
+
+	c.Partitions = maps.Clone(i.Partitions)
+
 	return &c
 }
 
@@ -303,6 +506,10 @@ type SequenceInfo struct {
 	Cache       uint64
 	Cycle       bool
 	Owner       Owner
+
+	// Sequences of temporary tables keep their current value
+	// in memory instead of storing it in the sequence table.
+	Temporary bool
 }
 
 // String returns a SQL representation.
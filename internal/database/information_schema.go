@@ -0,0 +1,260 @@
+package database
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// InformationSchema is the schema of the read-only virtual tables
+// describing the tables of the database, in the format of
+// the INFORMATION_SCHEMA of the SQL standard, so that tools
+// written for other databases can introspect the database.
+// The internal tables are not listed.
+const InformationSchema = "information_schema"
+
+// IsSystemTable returns true if the table is managed by the database:
+// the internal tables and the tables of the information schema.
+func IsSystemTable(name string) bool {
+	return strings.HasPrefix(name, InternalPrefix) || SchemaOf(name) == InformationSchema
+}
+
+// Tables of the information schema
+const (
+	InformationSchemaTablesTableName         = InformationSchema + ".tables"
+	InformationSchemaColumnsTableName        = InformationSchema + ".columns"
+	InformationSchemaKeyColumnUsageTableName = InformationSchema + ".key_column_usage"
+)
+
+var informationSchemaTablesTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName: InformationSchemaTablesTableName,
+		ReadOnly:  true,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "table_schema", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "table_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 2, Column: "table_type", Type: types.TypeText},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       "tables_pk",
+				Columns:    []string{"table_schema", "table_name"},
+				PrimaryKey: true,
+			},
+		},
+		Virtual: informationSchemaTablesRows,
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// informationSchemaTables returns the tables listed in the information schema,
+// sorted by name.
+func informationSchemaTables(tx *Transaction) ([]*TableInfo, error) {
+	var tables []*TableInfo
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		if strings.HasPrefix(name, InternalPrefix) {
+			continue
+		}
+
+		info, err := tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		if info.Virtual != nil {
+			continue
+		}
+
+		tables = append(tables, info)
+	}
+
+	return tables, nil
+}
+
+// informationSchemaTablesRows returns a row per table.
+func informationSchemaTablesRows(tx *Transaction, fn func(r row.Row) error) error {
+	tables, err := informationSchemaTables(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range tables {
+		_, name := SplitQualifiedName(info.TableName)
+
+		tableType := "BASE TABLE"
+		if info.Temporary {
+			tableType = "LOCAL TEMPORARY"
+		}
+
+		err = fn(row.NewColumnBuffer().
+			Add("table_schema", types.NewTextValue(SchemaOf(info.TableName))).
+			Add("table_name", types.NewTextValue(name)).
+			Add("table_type", types.NewTextValue(tableType)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var informationSchemaColumnsTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName: InformationSchemaColumnsTableName,
+		ReadOnly:  true,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "table_schema", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "table_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 2, Column: "column_name", Type: types.TypeText},
+			&ColumnConstraint{Position: 3, Column: "ordinal_position", Type: types.TypeInteger, IsNotNull: true},
+			&ColumnConstraint{Position: 4, Column: "column_default", Type: types.TypeText},
+			&ColumnConstraint{Position: 5, Column: "is_nullable", Type: types.TypeText},
+			&ColumnConstraint{Position: 6, Column: "data_type", Type: types.TypeText},
+			&ColumnConstraint{Position: 7, Column: "numeric_precision", Type: types.TypeInteger},
+			&ColumnConstraint{Position: 8, Column: "numeric_scale", Type: types.TypeInteger},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       "columns_pk",
+				Columns:    []string{"table_schema", "table_name", "ordinal_position"},
+				PrimaryKey: true,
+			},
+		},
+		Virtual: informationSchemaColumnsRows,
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// informationSchemaColumnsRows returns a row per column of each table.
+// The columns of the primary key are not nullable, even if they
+// are not declared NOT NULL.
+func informationSchemaColumnsRows(tx *Transaction, fn func(r row.Row) error) error {
+	tables, err := informationSchemaTables(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range tables {
+		schema := SchemaOf(info.TableName)
+		_, name := SplitQualifiedName(info.TableName)
+
+		var pk []string
+		if info.PrimaryKey != nil {
+			pk = info.PrimaryKey.Columns
+		}
+
+		for _, cc := range info.ColumnConstraints.Ordered {
+			nullable := "YES"
+			if cc.IsNotNull || slices.Contains(pk, cc.Column) {
+				nullable = "NO"
+			}
+
+			cb := row.NewColumnBuffer().
+				Add("table_schema", types.NewTextValue(schema)).
+				Add("table_name", types.NewTextValue(name)).
+				Add("column_name", types.NewTextValue(cc.Column)).
+				Add("ordinal_position", types.NewIntegerValue(int32(cc.Position+1)))
+			if cc.DefaultValue != nil {
+				cb.Add("column_default", types.NewTextValue(cc.DefaultValue.String()))
+			} else {
+				cb.Add("column_default", types.NewNullValue())
+			}
+			cb.Add("is_nullable", types.NewTextValue(nullable))
+			if !cc.Type.IsAny() {
+				cb.Add("data_type", types.NewTextValue(cc.Type.String()))
+			} else {
+				cb.Add("data_type", types.NewNullValue())
+			}
+			if cc.Type == types.TypeDecimal && cc.DecimalSpec.Precision > 0 {
+				cb.Add("numeric_precision", types.NewIntegerValue(int32(cc.DecimalSpec.Precision))).
+					Add("numeric_scale", types.NewIntegerValue(int32(cc.DecimalSpec.Scale)))
+			} else {
+				cb.Add("numeric_precision", types.NewNullValue()).
+					Add("numeric_scale", types.NewNullValue())
+			}
+
+			err = fn(cb)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+var informationSchemaKeyColumnUsageTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName: InformationSchemaKeyColumnUsageTableName,
+		ReadOnly:  true,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "constraint_schema", Type: types.TypeText},
+			&ColumnConstraint{Position: 1, Column: "constraint_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 2, Column: "table_schema", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 3, Column: "table_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 4, Column: "column_name", Type: types.TypeText},
+			&ColumnConstraint{Position: 5, Column: "ordinal_position", Type: types.TypeInteger, IsNotNull: true},
+			&ColumnConstraint{Position: 6, Column: "constraint_type", Type: types.TypeText},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       "key_column_usage_pk",
+				Columns:    []string{"table_schema", "table_name", "constraint_name", "ordinal_position"},
+				PrimaryKey: true,
+			},
+		},
+		Virtual: informationSchemaKeyColumnUsageRows,
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// informationSchemaKeyColumnUsageRows returns a row per column
+// of each primary key and unique constraint.
+// The constraint_type column is not part of the standard: since joins
+// are not supported, it replaces the table_constraints table.
+func informationSchemaKeyColumnUsageRows(tx *Transaction, fn func(r row.Row) error) error {
+	tables, err := informationSchemaTables(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range tables {
+		schema := SchemaOf(info.TableName)
+		_, name := SplitQualifiedName(info.TableName)
+
+		for _, tc := range info.TableConstraints {
+			var tp string
+			switch {
+			case tc.PrimaryKey:
+				tp = "PRIMARY KEY"
+			case tc.Unique:
+				tp = "UNIQUE"
+			default:
+				continue
+			}
+
+			for i, c := range tc.Columns {
+				err = fn(row.NewColumnBuffer().
+					Add("constraint_schema", types.NewTextValue(schema)).
+					Add("constraint_name", types.NewTextValue(tc.Name)).
+					Add("table_schema", types.NewTextValue(schema)).
+					Add("table_name", types.NewTextValue(name)).
+					Add("column_name", types.NewTextValue(c)).
+					Add("ordinal_position", types.NewIntegerValue(int32(i+1))).
+					Add("constraint_type", types.NewTextValue(tp)))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
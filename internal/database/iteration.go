@@ -1,6 +1,8 @@
 package database
 
 import (
+	"slices"
+
 	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
 )
@@ -13,15 +15,17 @@ type Range struct {
 	Exact     bool
 }
 
+// ToTreeRange converts the range to a range of the tree storing the given columns.
+// The texts of collated columns are replaced by their collation key.
 func (r *Range) ToTreeRange(constraints *ColumnConstraints, columns []string) (*tree.Range, error) {
 	var rng tree.Range
 
 	if len(r.Min) > 0 {
-		rng.Min = tree.NewKey(r.Min...)
+		rng.Min = tree.NewKey(collationKeys(constraints, columns, r.Min)...)
 	}
 
 	if len(r.Max) > 0 {
-		rng.Max = tree.NewKey(r.Max...)
+		rng.Max = tree.NewKey(collationKeys(constraints, columns, r.Max)...)
 	}
 
 	if r.Exclusive && r.Exact {
@@ -74,3 +78,30 @@ func (r *Range) IsEqual(other *Range) bool {
 
 	return true
 }
+
+// collationKeys returns the values with the texts of
+// collated columns replaced by their collation key.
+func collationKeys(constraints *ColumnConstraints, columns []string, values []types.Value) []types.Value {
+	var keys []types.Value
+	for i, v := range values {
+		if i >= len(columns) || constraints == nil {
+			break
+		}
+
+		cc := constraints.GetColumnConstraint(columns[i])
+		if cc == nil || cc.Collation == "" {
+			continue
+		}
+
+		if keys == nil {
+			keys = slices.Clone(values)
+		}
+		keys[i] = cc.GetCollation().Key(v)
+	}
+
+	if keys == nil {
+		return values
+	}
+
+	return keys
+}
@@ -0,0 +1,329 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Default options of IVF indexes.
+const (
+	DefaultIVFLists  = 16
+	DefaultIVFProbes = 4
+)
+
+// IVFOptions configures an inverted file index, used to find
+// the approximate nearest neighbors of a vector.
+// The indexed vectors are partitioned into lists, each associated with
+// a centroid, and a search only reads the lists of the centroids nearest
+// to the searched vector.
+type IVFOptions struct {
+	// Metric used to measure the distance between vectors.
+	Metric types.VectorMetric
+	// Lists is the number of partitions of the vectors.
+	Lists int
+	// Probes is the number of lists read by a search.
+	// The more lists are read, the more accurate and the slower
+	// the search is.
+	Probes int
+}
+
+// NewIVFOptions returns the default options of IVF indexes.
+func NewIVFOptions() *IVFOptions {
+	return &IVFOptions{
+		Metric: types.VectorL2,
+		Lists:  DefaultIVFLists,
+		Probes: DefaultIVFProbes,
+	}
+}
+
+// Validate returns an error if the options are invalid.
+func (o *IVFOptions) Validate() error {
+	if o.Lists < 1 {
+		return errors.Errorf("ivf lists must be greater than 0, got %d", o.Lists)
+	}
+	if o.Probes < 1 || o.Probes > o.Lists {
+		return errors.Errorf("ivf probes must be between 1 and lists (%d), got %d", o.Lists, o.Probes)
+	}
+
+	return nil
+}
+
+func (o *IVFOptions) String() string {
+	return fmt.Sprintf("WITH (metric = '%s', lists = %d, probes = %d)", o.Metric, o.Lists, o.Probes)
+}
+
+// ensureVectorIndexable returns an error if the index cannot be
+// an IVF index on the table.
+func (ti *TableInfo) ensureVectorIndexable(info *IndexInfo) error {
+	if info.Unique {
+		return errors.New("ivf indexes cannot be unique")
+	}
+	if len(info.Columns) != 1 {
+		return errors.New("ivf indexes must have exactly one column")
+	}
+
+	cc := ti.GetColumnConstraint(info.Columns[0])
+	if cc.Type != types.TypeVector {
+		return errors.Errorf("cannot create ivf index on column %q of type %s", cc.Column, cc.Type)
+	}
+	if cc.Encryption != nil {
+		return errors.Errorf("cannot create ivf index on encrypted column %q", cc.Column)
+	}
+	if ti.Partitioning != nil {
+		return errors.Errorf("cannot create ivf index on partitioned table %q", ti.TableName)
+	}
+
+	return info.IVF.Validate()
+}
+
+// Prefixes of the entries of an IVF index. An IVF index stores:
+//
+//	k: ivfCentroid, <list>                 v: centroid of the list
+//	k: ivfPosting, <list>, <primary key>    v: indexed vector
+//	k: ivfAssignment, <primary key>         v: list of the vector
+//	k: ivfNull, <primary key>               v: none
+//
+// The centroids are the first vectors indexed. Once all the lists have
+// a centroid, vectors are added to the list of the nearest centroid.
+// Rebuilding the index with REINDEX chooses new centroids.
+// The keys of the rows whose vector is NULL are kept apart, since their
+// distance to any vector is NULL, which sorts before the other distances.
+var (
+	ivfCentroid   = types.NewBigintValue(0)
+	ivfPosting    = types.NewBigintValue(1)
+	ivfAssignment = types.NewBigintValue(2)
+	ivfNull       = types.NewBigintValue(3)
+)
+
+type ivfCentroidEntry struct {
+	list   int64
+	vector types.VectorValue
+}
+
+func (idx *Index) ivfCentroids() ([]ivfCentroidEntry, error) {
+	prefix := tree.NewKey(ivfCentroid)
+
+	var centroids []ivfCentroidEntry
+	err := idx.Tree.IterateOnRange(&tree.Range{Min: prefix, Max: prefix}, false, func(k *tree.Key, d []byte) error {
+		values, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		v, _ := types.VectorTypeDef{}.Decode(d)
+		centroids = append(centroids, ivfCentroidEntry{
+			list:   types.AsInt64(values[1]),
+			vector: v.(types.VectorValue),
+		})
+		return nil
+	})
+
+	return centroids, err
+}
+
+// ivfSet adds the vector v to the list of its nearest centroid.
+// The keys of NULL values are added to the NULL entries.
+func (idx *Index) ivfSet(v types.Value, key []byte) error {
+	if v.Type() == types.TypeNull {
+		return idx.Tree.Put(tree.NewKey(ivfNull, types.NewBlobValue(key)), nil)
+	}
+	if v.Type() != types.TypeVector {
+		return errors.Errorf("cannot index value of type %s in an ivf index", v.Type())
+	}
+	vec := v.(types.VectorValue)
+
+	centroids, err := idx.ivfCentroids()
+	if err != nil {
+		return err
+	}
+
+	var list int64
+	if len(centroids) < idx.IVF.Lists {
+		// the vector becomes the centroid of a new list
+		list = int64(len(centroids))
+		enc, err := vec.Encode(nil)
+		if err != nil {
+			return err
+		}
+		err = idx.Tree.Put(tree.NewKey(ivfCentroid, types.NewBigintValue(list)), enc)
+		if err != nil {
+			return err
+		}
+	} else {
+		best := -1.0
+		for _, c := range centroids {
+			d, err := idx.IVF.Metric.Distance(vec, c.vector)
+			if err != nil {
+				return err
+			}
+			if best < 0 || d < best {
+				best, list = d, c.list
+			}
+		}
+	}
+
+	enc, err := vec.Encode(nil)
+	if err != nil {
+		return err
+	}
+	pk := types.NewBlobValue(key)
+	err = idx.Tree.Put(tree.NewKey(ivfPosting, types.NewBigintValue(list), pk), enc)
+	if err != nil {
+		return err
+	}
+
+	enc, err = types.NewBigintValue(list).Encode(nil)
+	if err != nil {
+		return err
+	}
+	return idx.Tree.Put(tree.NewKey(ivfAssignment, pk), enc)
+}
+
+// ivfDelete removes the vector associated with the key from its list.
+func (idx *Index) ivfDelete(v types.Value, key []byte) error {
+	if v.Type() == types.TypeNull {
+		return idx.Tree.Delete(tree.NewKey(ivfNull, types.NewBlobValue(key)))
+	}
+
+	pk := types.NewBlobValue(key)
+	ak := tree.NewKey(ivfAssignment, pk)
+	d, err := idx.Tree.Get(ak)
+	if err != nil {
+		return err
+	}
+	list, _ := types.BigintTypeDef{}.Decode(d)
+
+	err = idx.Tree.Delete(tree.NewKey(ivfPosting, list, pk))
+	if err != nil {
+		return err
+	}
+
+	return idx.Tree.Delete(ak)
+}
+
+// A VectorMatch is a vector found by a nearest neighbor search.
+type VectorMatch struct {
+	Key *tree.Key
+	// Distance is NaN if the vector is NULL.
+	Distance float64
+}
+
+// SearchNearest returns the keys of the n vectors nearest to v,
+// sorted by distance, or all the vectors found if n is negative.
+// As when sorting by distance, the NULL vectors, whose distance is NULL,
+// come first, in the order of their keys.
+// The search only reads the lists of the nearest centroids, as
+// configured by the probes option, so the result is approximate.
+func (idx *Index) SearchNearest(v types.VectorValue, n int64) ([]VectorMatch, error) {
+	if idx.IVF == nil {
+		return nil, errors.New("index is not a vector index")
+	}
+
+	nulls, err := idx.ivfNulls(n)
+	if err != nil {
+		return nil, err
+	}
+	if n >= 0 && int64(len(nulls)) >= n {
+		return nulls, nil
+	}
+
+	centroids, err := idx.ivfCentroids()
+	if err != nil {
+		return nil, err
+	}
+
+	distances := make([]float64, len(centroids))
+	for i, c := range centroids {
+		distances[i], err = idx.IVF.Metric.Distance(v, c.vector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Sort(byDistance{centroids, distances})
+
+	var matches []VectorMatch
+	for i := 0; i < len(centroids) && i < idx.IVF.Probes; i++ {
+		prefix := tree.NewKey(ivfPosting, types.NewBigintValue(centroids[i].list))
+		err = idx.Tree.IterateOnRange(&tree.Range{Min: prefix, Max: prefix}, false, func(k *tree.Key, d []byte) error {
+			values, err := k.Decode()
+			if err != nil {
+				return err
+			}
+
+			x, _ := types.VectorTypeDef{}.Decode(d)
+			dist, err := idx.IVF.Metric.Distance(v, x.(types.VectorValue))
+			if err != nil {
+				return err
+			}
+
+			// the key is only valid during the call
+			pk := bytes.Clone(types.AsByteSlice(values[2]))
+			matches = append(matches, VectorMatch{Key: tree.NewEncodedKey(pk), Distance: dist})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// sort by distance, then by key to return the same
+	// order for vectors at the same distance
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return bytes.Compare(matches[i].Key.Encoded, matches[j].Key.Encoded) < 0
+	})
+
+	matches = append(nulls, matches...)
+	if n >= 0 && int64(len(matches)) > n {
+		matches = matches[:n]
+	}
+
+	return matches, nil
+}
+
+// ivfNulls returns the keys of the first n NULL vectors,
+// or of all of them if n is negative.
+func (idx *Index) ivfNulls(n int64) ([]VectorMatch, error) {
+	var matches []VectorMatch
+
+	prefix := tree.NewKey(ivfNull)
+	err := idx.Tree.IterateOnRange(&tree.Range{Min: prefix, Max: prefix}, false, func(k *tree.Key, _ []byte) error {
+		if n >= 0 && int64(len(matches)) >= n {
+			return errStop
+		}
+
+		values, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		pk := bytes.Clone(types.AsByteSlice(values[1]))
+		matches = append(matches, VectorMatch{Key: tree.NewEncodedKey(pk), Distance: math.NaN()})
+		return nil
+	})
+	if err == errStop {
+		err = nil
+	}
+
+	return matches, err
+}
+
+type byDistance struct {
+	centroids []ivfCentroidEntry
+	distances []float64
+}
+
+func (b byDistance) Len() int           { return len(b.centroids) }
+func (b byDistance) Less(i, j int) bool { return b.distances[i] < b.distances[j] }
+func (b byDistance) Swap(i, j int) {
+	b.centroids[i], b.centroids[j] = b.centroids[j], b.centroids[i]
+	b.distances[i], b.distances[j] = b.distances[j], b.distances[i]
+}
@@ -0,0 +1,524 @@
+package database
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultVacuumInterval is the default amount of time between two
+// compactions of the database.
+const DefaultVacuumInterval = time.Hour
+
+// Builtin jobs
+const (
+	JobTTL       = "ttl"
+	JobRetention = "retention"
+	JobVacuum    = "vacuum"
+)
+
+var jobsTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName:      JobsTableName,
+		StoreNamespace: JobsTableNamespace,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "priority", Type: types.TypeInteger},
+			&ColumnConstraint{Position: 2, Column: "interval", Type: types.TypeInterval},
+			&ColumnConstraint{Position: 3, Column: "paused", Type: types.TypeBoolean},
+			&ColumnConstraint{Position: 4, Column: "runs", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 5, Column: "failures", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 6, Column: "last_run", Type: types.TypeTimestamp},
+			&ColumnConstraint{Position: 7, Column: "last_duration", Type: types.TypeInterval},
+			&ColumnConstraint{Position: 8, Column: "last_error", Type: types.TypeText},
+			&ColumnConstraint{Position: 9, Column: "next_run", Type: types.TypeTimestamp},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       JobsTableName + "_pk",
+				Columns:    []string{"name"},
+				PrimaryKey: true,
+			},
+		},
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// A Job is a maintenance task run in the background by the database.
+type Job struct {
+	Name string
+	// Jobs due at the same time run from the highest
+	// priority to the lowest.
+	Priority int
+	// Interval is the amount of time between two runs.
+	// If zero or negative, the job only runs when requested with RunJob.
+	Interval time.Duration
+	Run      func(db *Database) error
+}
+
+// JobStatus describes the state of a job.
+// It is also stored in the __chai_jobs table, to be queried with SQL.
+type JobStatus struct {
+	Name     string
+	Priority int
+	Interval time.Duration
+	// Paused jobs are not run automatically,
+	// but can still be run with RunJob.
+	Paused   bool
+	Runs     int64
+	Failures int64
+	LastRun  time.Time
+	// LastDuration is the amount of time taken by the last run.
+	LastDuration time.Duration
+	// LastError is the error returned by the last run, if any.
+	LastError string
+	// NextRun is the time of the next automatic run.
+	// It is zero if the job is paused or has no interval.
+	NextRun time.Time
+}
+
+func (s *JobStatus) row() row.Row {
+	r := row.NewColumnBuffer().
+		Add("name", types.NewTextValue(s.Name)).
+		Add("priority", types.NewIntegerValue(int32(s.Priority))).
+		Add("interval", durationValue(s.Interval)).
+		Add("paused", types.NewBooleanValue(s.Paused)).
+		Add("runs", types.NewBigintValue(s.Runs)).
+		Add("failures", types.NewBigintValue(s.Failures)).
+		Add("last_run", timeValue(s.LastRun)).
+		Add("last_duration", durationValue(s.LastDuration)).
+		Add("next_run", timeValue(s.NextRun))
+
+	if s.LastError != "" {
+		r.Add("last_error", types.NewTextValue(s.LastError))
+	} else {
+		r.Add("last_error", types.NewNullValue())
+	}
+
+	return r
+}
+
+func durationValue(d time.Duration) types.Value {
+	if d <= 0 {
+		return types.NewNullValue()
+	}
+
+	return types.NewIntervalValue(0, 0, d.Microseconds())
+}
+
+func timeValue(t time.Time) types.Value {
+	if t.IsZero() {
+		return types.NewNullValue()
+	}
+
+	return types.NewTimestampValue(t)
+}
+
+// scheduler runs the jobs of the database, one at a time,
+// in a single goroutine.
+type scheduler struct {
+	// protects jobs
+	mu   sync.Mutex
+	jobs []*scheduledJob
+
+	// ensures jobs run one at a time, whether they are
+	// run by the scheduler or with RunJob.
+	runMu sync.Mutex
+
+	// notifies the goroutine that the schedule changed.
+	wake chan struct{}
+
+	// waitgroup to wait for the goroutine to stop.
+	wg sync.WaitGroup
+}
+
+type scheduledJob struct {
+	job    Job
+	status JobStatus
+}
+
+func (s *scheduler) get(name string) (*scheduledJob, error) {
+	for _, j := range s.jobs {
+		if j.job.Name == name {
+			return j, nil
+		}
+	}
+
+	return nil, errors.Errorf("job %q not found", name)
+}
+
+// schedule sets the time of the next run of a job.
+func (j *scheduledJob) schedule(now time.Time) {
+	if j.status.Paused || j.job.Interval <= 0 {
+		j.status.NextRun = time.Time{}
+		return
+	}
+
+	j.status.NextRun = now.Add(j.job.Interval)
+}
+
+// builtinJobs returns the maintenance jobs of the database.
+func builtinJobs(opts *Options) []Job {
+	ttl := opts.TTLInterval
+	if ttl == 0 {
+		ttl = DefaultTTLInterval
+	}
+
+	vacuum := opts.VacuumInterval
+	if vacuum == 0 {
+		vacuum = DefaultVacuumInterval
+	}
+
+	return []Job{
+		{
+			Name:     JobTTL,
+			Priority: 30,
+			Interval: ttl,
+			Run: func(db *Database) error {
+				_, err := db.DeleteExpiredRows()
+				return err
+			},
+		},
+		{
+			Name:     JobRetention,
+			Priority: 20,
+			Interval: ttl,
+			Run: func(db *Database) error {
+				_, err := db.ApplyRetentionPolicies(false)
+				return err
+			},
+		},
+		{
+			// compact after the other jobs, which delete rows.
+			Name:     JobVacuum,
+			Priority: 10,
+			Interval: vacuum,
+			Run: func(db *Database) error {
+				_, err := db.Vacuum()
+				return err
+			},
+		},
+	}
+}
+
+// startScheduler restores the state of the jobs stored in the __chai_jobs table
+// and starts a goroutine that runs the jobs when they are due,
+// until the database is closed.
+func (db *Database) startScheduler(jobs []Job) error {
+	s := &db.scheduler
+	s.wake = make(chan struct{}, 1)
+
+	saved, err := db.loadJobStatuses()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, job := range jobs {
+		j := scheduledJob{job: job}
+		if st, ok := saved[job.Name]; ok {
+			j.status = st
+		}
+		j.status.Name = job.Name
+		j.status.Priority = job.Priority
+		j.status.Interval = job.Interval
+		j.schedule(now)
+
+		s.jobs = append(s.jobs, &j)
+	}
+
+	// highest priority first, then by name
+	slices.SortFunc(s.jobs, func(a, b *scheduledJob) int {
+		if a.job.Priority != b.job.Priority {
+			return b.job.Priority - a.job.Priority
+		}
+
+		return strings.Compare(a.job.Name, b.job.Name)
+	})
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			next := db.nextJobRun()
+			if !next.IsZero() {
+				timer.Reset(time.Until(next))
+			}
+
+			select {
+			case <-db.closeContext.Done():
+				return
+			case <-s.wake:
+				continue
+			case <-timer.C:
+				db.runDueJobs()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// nextJobRun returns the time of the next automatic run of a job,
+// or a zero time if no job is scheduled.
+func (db *Database) nextJobRun() time.Time {
+	s := &db.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, j := range s.jobs {
+		if j.status.NextRun.IsZero() {
+			continue
+		}
+		if next.IsZero() || j.status.NextRun.Before(next) {
+			next = j.status.NextRun
+		}
+	}
+
+	return next
+}
+
+// runDueJobs runs all the jobs whose next run is due, by priority.
+func (db *Database) runDueJobs() {
+	s := &db.scheduler
+
+	now := time.Now()
+	var due []string
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if !j.status.NextRun.IsZero() && !j.status.NextRun.After(now) {
+			due = append(due, j.job.Name)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, name := range due {
+		if db.closeContext.Err() != nil {
+			return
+		}
+
+		// errors, including conflicts with concurrent transactions,
+		// are recorded in the status of the job, which will run again
+		// at its next interval.
+		_ = db.runJob(name)
+	}
+}
+
+func (db *Database) runJob(name string) error {
+	s := &db.scheduler
+
+	s.mu.Lock()
+	j, err := s.get(name)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	start := time.Now()
+	err = j.job.Run(db)
+	end := time.Now()
+
+	// the database was closed during the run
+	if db.closeContext.Err() != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	j.status.Runs++
+	j.status.LastRun = start
+	j.status.LastDuration = end.Sub(start)
+	j.status.LastError = ""
+	if err != nil {
+		j.status.Failures++
+		j.status.LastError = err.Error()
+	}
+	j.schedule(end)
+	status := j.status
+	s.mu.Unlock()
+
+	perr := db.saveJobStatus(&status)
+	if err != nil {
+		return err
+	}
+
+	return perr
+}
+
+// Jobs returns the status of the jobs of the database,
+// from the highest priority to the lowest.
+func (db *Database) Jobs() []JobStatus {
+	s := &db.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, len(s.jobs))
+	for i, j := range s.jobs {
+		statuses[i] = j.status
+	}
+
+	return statuses
+}
+
+// RunJob runs a job immediately and waits for it to finish,
+// even if it is paused. It returns the error returned by the job.
+func (db *Database) RunJob(name string) error {
+	if db.closeContext.Err() != nil {
+		return errors.New("database is closed")
+	}
+
+	return db.runJob(name)
+}
+
+// PauseJob prevents a job from running automatically,
+// until it is resumed with ResumeJob.
+// The state of the job is persisted across restarts.
+func (db *Database) PauseJob(name string) error {
+	return db.setJobPaused(name, true)
+}
+
+// ResumeJob resumes a job paused with PauseJob.
+// Its next run is scheduled one interval from now.
+func (db *Database) ResumeJob(name string) error {
+	return db.setJobPaused(name, false)
+}
+
+func (db *Database) setJobPaused(name string, paused bool) error {
+	if db.closeContext.Err() != nil {
+		return errors.New("database is closed")
+	}
+
+	s := &db.scheduler
+
+	s.mu.Lock()
+	j, err := s.get(name)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if j.status.Paused == paused {
+		s.mu.Unlock()
+		return nil
+	}
+	j.status.Paused = paused
+	j.schedule(time.Now())
+	status := j.status
+	s.mu.Unlock()
+
+	// notify the goroutine that the next run changed
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return db.saveJobStatus(&status)
+}
+
+// saveJobStatus stores the status of a job in the __chai_jobs table,
+// creating the table if it doesn't exist.
+func (db *Database) saveJobStatus(status *JobStatus) error {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tb, err := tx.Catalog.GetTable(tx, JobsTableName)
+	if errs.IsNotFoundError(err) {
+		err = tx.CatalogWriter().CreateTable(tx, JobsTableName, jobsTableInfo)
+		if err != nil {
+			return err
+		}
+
+		tb, err = tx.Catalog.GetTable(tx, JobsTableName)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tb.Put(tree.NewKey(types.NewTextValue(status.Name)), status.row())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loadJobStatuses returns the statuses stored in the __chai_jobs table,
+// by job name.
+func (db *Database) loadJobStatuses() (map[string]JobStatus, error) {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	statuses := make(map[string]JobStatus)
+
+	tb, err := tx.Catalog.GetTable(tx, JobsTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return statuses, nil
+		}
+
+		return nil, err
+	}
+
+	err = tb.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+		var st JobStatus
+
+		return r.Iterate(func(column string, v types.Value) error {
+			if v.Type() == types.TypeNull {
+				return nil
+			}
+
+			switch column {
+			case "name":
+				st.Name = types.AsString(v)
+			case "paused":
+				st.Paused = types.AsBool(v)
+			case "runs":
+				st.Runs = types.AsInt64(v)
+			case "failures":
+				st.Failures = types.AsInt64(v)
+			case "last_run":
+				st.LastRun = types.AsTime(v)
+			case "last_duration":
+				st.LastDuration = time.Duration(v.(types.IntervalValue).Micros) * time.Microsecond
+			case "last_error":
+				st.LastError = types.AsString(v)
+			}
+
+			statuses[st.Name] = st
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"sync/atomic"
+
+	"github.com/chaisql/chai/engine"
+)
+
+// Metrics are counters of the activity of the database
+// since it was opened.
+type Metrics struct {
+	// TxStarted is the number of transactions started.
+	TxStarted int64
+	// TxCommitted is the number of transactions committed.
+	TxCommitted int64
+	// TxRolledBack is the number of transactions rolled back,
+	// including the read-only transactions, which always end
+	// with a rollback, and the transactions which failed to commit.
+	TxRolledBack int64
+	// TxConflicts is the number of transactions which failed
+	// to commit because of a concurrent transaction.
+	TxConflicts int64
+
+	// Engine holds the statistics of the engine, if it implements
+	// engine.MetricsEngine.
+	Engine engine.Metrics
+}
+
+type txMetrics struct {
+	started    atomic.Int64
+	committed  atomic.Int64
+	rolledBack atomic.Int64
+	conflicts  atomic.Int64
+}
+
+// Metrics returns the counters of the activity of the database.
+func (db *Database) Metrics() Metrics {
+	m := Metrics{
+		TxStarted:    db.txMetrics.started.Load(),
+		TxCommitted:  db.txMetrics.committed.Load(),
+		TxRolledBack: db.txMetrics.rolledBack.Load(),
+		TxConflicts:  db.txMetrics.conflicts.Load(),
+	}
+
+	if ng, ok := db.Engine.(engine.MetricsEngine); ok {
+		m.Engine = ng.Metrics()
+	}
+
+	return m
+}
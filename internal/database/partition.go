@@ -0,0 +1,451 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// PartitionMethod is how the rows of a partitioned table
+// are assigned to its partitions.
+type PartitionMethod uint8
+
+const (
+	// PartitionByRange assigns each row to the first partition whose bound
+	// is greater than the value of the partition column of the row.
+	PartitionByRange PartitionMethod = iota + 1
+	// PartitionByHash assigns each row to a partition according
+	// to the hash of the value of the partition column of the row.
+	PartitionByHash
+)
+
+func (m PartitionMethod) String() string {
+	if m == PartitionByHash {
+		return "HASH"
+	}
+
+	return "RANGE"
+}
+
+// TablePartitioning describes how the rows of a table are split into partitions,
+// each stored in its own namespace, along with the entries of the indexes of its rows.
+// The partition column is the first column of the primary key, so that the partition
+// of a row can be found from its key and so that the partitions of a table
+// partitioned by range hold contiguous ranges of keys.
+type TablePartitioning struct {
+	Method     PartitionMethod
+	Column     string
+	Partitions []TablePartition
+}
+
+// A TablePartition is a partition of a table.
+type TablePartition struct {
+	Name string
+	// Exclusive upper bound of the partition column of the rows
+	// of a partition by range. It is nil for MAXVALUE and for
+	// the partitions by hash.
+	Bound types.Value
+	// namespace of the store associated with the partition.
+	Namespace tree.Namespace
+}
+
+// Clone returns a copy of the partitioning.
+func (p *TablePartitioning) Clone() *TablePartitioning {
+	if p == nil {
+		return nil
+	}
+
+	cp := *p
+	cp.Partitions = slices.Clone(p.Partitions)
+	return &cp
+}
+
+// Partition returns the position of the partition with the given name, or -1.
+func (p *TablePartitioning) Partition(name string) int {
+	return slices.IndexFunc(p.Partitions, func(part TablePartition) bool {
+		return part.Name == name
+	})
+}
+
+// String returns a SQL representation.
+func (p *TablePartitioning) String() string {
+	var s strings.Builder
+
+	fmt.Fprintf(&s, "PARTITION BY %s (%s)", p.Method, stringutil.NormalizeIdentifier(p.Column, '`'))
+	if p.Method == PartitionByHash {
+		fmt.Fprintf(&s, " PARTITIONS %d", len(p.Partitions))
+		return s.String()
+	}
+
+	s.WriteString(" (")
+	for i, part := range p.Partitions {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+
+		fmt.Fprintf(&s, "PARTITION %s VALUES LESS THAN (", stringutil.NormalizeIdentifier(part.Name, '`'))
+		if part.Bound == nil {
+			s.WriteString("MAXVALUE")
+		} else {
+			s.WriteString(part.Bound.String())
+		}
+		s.WriteString(")")
+	}
+	s.WriteString(")")
+
+	return s.String()
+}
+
+// HashPartitionName returns the name of the i-th partition of a table partitioned by hash.
+func HashPartitionName(i int) string {
+	return fmt.Sprintf("p%d", i)
+}
+
+// Validate ensures the table can be partitioned this way.
+func (p *TablePartitioning) Validate(ti *TableInfo) error {
+	if ti.Temporary {
+		return errors.New("temporary tables cannot be partitioned")
+	}
+
+	pk := ti.PrimaryKey
+	if pk == nil || pk.Columns[0] != p.Column {
+		return errors.Errorf("partition column %s must be the first column of the primary key", p.Column)
+	}
+
+	if len(p.Partitions) == 0 {
+		return errors.Errorf("table %s must have at least one partition", ti.TableName)
+	}
+
+	for i, part := range p.Partitions {
+		if p.Partition(part.Name) != i {
+			return errors.Errorf("duplicate partition name %s", part.Name)
+		}
+	}
+
+	if p.Method == PartitionByHash {
+		return nil
+	}
+
+	if pk.SortOrder.IsDesc(0) {
+		return errors.Errorf("partition column %s must be sorted in ascending order", p.Column)
+	}
+
+	for i := 1; i < len(p.Partitions); i++ {
+		prev, part := p.Partitions[i-1], p.Partitions[i]
+		if prev.Bound == nil {
+			return errors.Errorf("partition %s must be the last partition since its bound is MAXVALUE", prev.Name)
+		}
+		if part.Bound == nil {
+			continue
+		}
+
+		gt, err := part.Bound.GT(prev.Bound)
+		if err != nil {
+			return err
+		}
+		if !gt {
+			return errors.Errorf("bound of partition %s must be greater than the bound of partition %s", part.Name, prev.Name)
+		}
+	}
+
+	return nil
+}
+
+// locate returns the position of the partition holding the rows whose
+// partition column has the value v.
+func (p *TablePartitioning) locate(ti *TableInfo, v types.Value) (int, error) {
+	// the value is encoded like in the keys of the table
+	if tp := ti.PrimaryKey.Types[0]; v.Type() != tp {
+		var err error
+		v, err = v.CastAs(tp)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	enc, err := types.EncodeValueAsKey(nil, v, false)
+	if err != nil {
+		return 0, err
+	}
+
+	if p.Method == PartitionByHash {
+		h := fnv.New64a()
+		_, _ = h.Write(enc)
+		return int(h.Sum64() % uint64(len(p.Partitions))), nil
+	}
+
+	i := sort.Search(len(p.Partitions), func(i int) bool {
+		bound := p.Partitions[i].Bound
+		if bound == nil || err != nil {
+			return true
+		}
+
+		var b []byte
+		b, err = types.EncodeValueAsKey(nil, bound, false)
+		return bytes.Compare(enc, b) < 0
+	})
+	if err != nil {
+		return 0, err
+	}
+	if i == len(p.Partitions) {
+		return 0, errors.Errorf("no partition of table %s for value %s", ti.TableName, v)
+	}
+
+	return i, nil
+}
+
+// newTree returns the tree storing the rows of the table.
+func (ti *TableInfo) newTree(session engine.Session) *tree.Tree {
+	tr := tree.New(session, ti.StoreNamespace, ti.PrimaryKeySortOrder())
+
+	p := ti.Partitioning
+	if p == nil {
+		return tr
+	}
+
+	nss := make([]tree.Namespace, len(p.Partitions))
+	for i := range p.Partitions {
+		nss[i] = p.Partitions[i].Namespace
+	}
+
+	tr.Partitioning = &tree.Partitioning{
+		Namespaces: nss,
+		Locate: func(values []types.Value) (int, error) {
+			return p.locate(ti, values[0])
+		},
+		ByFirstValue: true,
+		Ordered:      p.Method == PartitionByRange,
+	}
+
+	return tr
+}
+
+// newTree returns the tree storing the entries of the index,
+// whose table is described by ti.
+// The entries of the rows of each partition of a partitioned table
+// are stored in a partition of the index.
+func (idx *IndexInfo) newTree(session engine.Session, ti *TableInfo) *tree.Tree {
+	tr := tree.New(session, idx.StoreNamespace, idx.KeySortOrder)
+
+	p := ti.Partitioning
+	if p == nil {
+		return tr
+	}
+
+	nss := make([]tree.Namespace, len(p.Partitions))
+	for i := range p.Partitions {
+		nss[i] = idx.Partitions[p.Partitions[i].Name]
+	}
+
+	tr.Partitioning = &tree.Partitioning{
+		Namespaces: nss,
+		// entries are located by the namespace of the key
+		// of their row, which is their last value
+		Locate: func(values []types.Value) (int, error) {
+			key := types.AsByteSlice(values[len(values)-1])
+			ns, ok := decodeNamespace(key)
+			if ok {
+				for i := range p.Partitions {
+					if p.Partitions[i].Namespace == ns {
+						return i, nil
+					}
+				}
+			}
+
+			return 0, errors.Errorf("key %s doesn't belong to any partition of table %s", tree.NewEncodedKey(key), ti.TableName)
+		},
+	}
+
+	return tr
+}
+
+// partitionRowName returns the name of the row of the catalog
+// holding the namespace of a partition of a table or an index.
+func partitionRowName(owner, partition string) string {
+	return owner + "/" + partition
+}
+
+func partitionToRow(owner, partition string, ns tree.Namespace) row.Row {
+	buf := row.NewColumnBuffer()
+	buf.Add("name", types.NewTextValue(partitionRowName(owner, partition)))
+	buf.Add("type", types.NewTextValue(RelationPartitionType))
+	buf.Add("namespace", types.NewBigintValue(int64(ns)))
+	buf.Add("owner_table_name", types.NewTextValue(owner))
+
+	return buf
+}
+
+// createPartitionStores generates the namespace of the partition of the table,
+// and of the partition of each of its indexes, and stores them in the catalog.
+func (c *CatalogWriter) createPartitionStores(tx *Transaction, ti *TableInfo, part *TablePartition, indexes []*IndexInfo) error {
+	var err error
+
+	part.Namespace, err = c.generateStoreNamespace(tx)
+	if err != nil {
+		return err
+	}
+
+	err = c.CatalogTable.insertPartition(tx, ti.TableName, part.Name, part.Namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		ns, err := c.generateStoreNamespace(tx)
+		if err != nil {
+			return err
+		}
+
+		idx.Partitions[part.Name] = ns
+		err = c.CatalogTable.insertPartition(tx, idx.IndexName, part.Name, ns)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropPartitionStore deletes the rows stored in the namespace of a partition
+// with a range deletion, which the transaction writes as a single tombstone,
+// then removes the partition from the catalog.
+func (c *CatalogWriter) dropPartitionStore(tx *Transaction, owner, partition string, ns tree.Namespace) error {
+	err := tree.New(tx.Session, ns, 0).Truncate()
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.deletePartition(tx, owner, partition)
+}
+
+// AddPartition adds a partition to a table partitioned by range.
+// Its bound must be greater than the bounds of the other partitions.
+func (c *CatalogWriter) AddPartition(tx *Transaction, tableName string, part TablePartition) error {
+	ti, err := c.GetTableInfo(tableName)
+	if err != nil {
+		return err
+	}
+
+	if ti.Partitioning == nil {
+		return errors.Errorf("table %s is not partitioned", tableName)
+	}
+	if ti.Partitioning.Method != PartitionByRange {
+		return errors.Errorf("cannot add a partition to table %s, partitioned by %s", tableName, ti.Partitioning.Method)
+	}
+
+	if part.Bound != nil {
+		part.Bound, err = part.Bound.CastAs(ti.PrimaryKey.Types[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	clone := ti.Clone()
+	clone.Partitioning.Partitions = append(clone.Partitioning.Partitions, part)
+	err = clone.Partitioning.Validate(clone)
+	if err != nil {
+		return err
+	}
+
+	indexes := c.cloneTableIndexes(tableName)
+	err = c.createPartitionStores(tx, clone, &clone.Partitioning.Partitions[len(clone.Partitioning.Partitions)-1], indexes)
+	if err != nil {
+		return err
+	}
+
+	return c.replacePartitioning(tx, clone, indexes)
+}
+
+// DropPartition removes a partition from a table partitioned by range,
+// along with its rows and their index entries.
+// Since each partition is stored in its own namespaces, they are deleted
+// with a range deletion per store, whose cost doesn't depend on the number
+// of rows, unless the transaction wrote more than the engine keeps in memory
+// and has to delete them one by one to be able to roll them back.
+func (c *CatalogWriter) DropPartition(tx *Transaction, tableName, name string) error {
+	ti, err := c.GetTableInfo(tableName)
+	if err != nil {
+		return err
+	}
+
+	if ti.Partitioning == nil {
+		return errors.Errorf("table %s is not partitioned", tableName)
+	}
+	if ti.Partitioning.Method != PartitionByRange {
+		return errors.Errorf("cannot drop a partition of table %s, partitioned by %s", tableName, ti.Partitioning.Method)
+	}
+
+	i := ti.Partitioning.Partition(name)
+	if i < 0 {
+		return errors.Errorf("partition %s of table %s does not exist", name, tableName)
+	}
+	if len(ti.Partitioning.Partitions) == 1 {
+		return errors.Errorf("cannot drop partition %s, the only partition of table %s", name, tableName)
+	}
+
+	clone := ti.Clone()
+	clone.Partitioning.Partitions = slices.Delete(clone.Partitioning.Partitions, i, i+1)
+
+	err = c.dropPartitionStore(tx, tableName, name, ti.Partitioning.Partitions[i].Namespace)
+	if err != nil {
+		return err
+	}
+
+	indexes := c.cloneTableIndexes(tableName)
+	for _, idx := range indexes {
+		err = c.dropPartitionStore(tx, idx.IndexName, name, idx.Partitions[name])
+		if err != nil {
+			return err
+		}
+		delete(idx.Partitions, name)
+	}
+
+	return c.replacePartitioning(tx, clone, indexes)
+}
+
+// cloneTableIndexes returns a copy of the information of the indexes of the table.
+func (c *CatalogWriter) cloneTableIndexes(tableName string) []*IndexInfo {
+	infos := c.Cache.GetTableIndexes(tableName)
+	clones := make([]*IndexInfo, len(infos))
+	for i := range infos {
+		clones[i] = infos[i].Clone()
+	}
+
+	return clones
+}
+
+// replacePartitioning replaces the information of a table whose partitions
+// changed, along with the information of its indexes.
+func (c *CatalogWriter) replacePartitioning(tx *Transaction, ti *TableInfo, indexes []*IndexInfo) error {
+	rel := &TableInfoRelation{Info: ti}
+	err := c.Cache.Replace(tx, rel)
+	if err != nil {
+		return err
+	}
+
+	err = c.CatalogTable.Replace(tx, ti.TableName, rel)
+	if err != nil {
+		return err
+	}
+
+	// the partitions of the indexes are not part
+	// of their SQL representation
+	for _, idx := range indexes {
+		err = c.Cache.Replace(tx, &IndexInfoRelation{Info: idx})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
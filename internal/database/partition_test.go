@@ -0,0 +1,129 @@
+package database_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionedTables(t *testing.T) {
+	path := t.TempDir()
+
+	db, err := chai.Open(path)
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT, b TEXT, PRIMARY KEY (a)) PARTITION BY RANGE (a) (
+			PARTITION p1 VALUES LESS THAN (10),
+			PARTITION p2 VALUES LESS THAN (20)
+		);
+		CREATE UNIQUE INDEX test_b_idx ON test (b);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (11, 'b');
+	`)
+	require.NoError(t, err)
+
+	count := func(db *chai.DB, q string) int {
+		t.Helper()
+
+		row, err := db.QueryRow(q)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, row.Scan(&n))
+		return n
+	}
+
+	rows := make(chan any, 3)
+	rows <- map[string]any{"a": 2, "b": "c"}
+	rows <- map[string]any{"a": 12, "b": "d"}
+	rows <- map[string]any{"a": 19, "b": "e"}
+	close(rows)
+	n, err := db.BulkInsert("test", rows, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	// the primary key is checked in every partition
+	rows = make(chan any, 1)
+	rows <- map[string]any{"a": 11, "b": "f"}
+	close(rows)
+	_, err = db.BulkInsert("test", rows, nil)
+	require.Error(t, err)
+
+	stats, err := db.TableStorageStats("test")
+	require.NoError(t, err)
+	require.EqualValues(t, 5, stats.Rows)
+	require.EqualValues(t, 5, stats.Indexes[0].Entries)
+
+	require.NoError(t, db.Close())
+
+	// the partitions are loaded from the catalog
+	db, err = chai.Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.Equal(t, 3, count(db, "SELECT COUNT(*) FROM test WHERE a < 10 OR b = 'e'"))
+
+	err = db.Exec("INSERT INTO test (a, b) VALUES (3, 'b')")
+	require.Error(t, err)
+
+	err = db.Exec(`
+		ALTER TABLE test ADD PARTITION p3 VALUES LESS THAN (MAXVALUE);
+		INSERT INTO test (a, b) VALUES (100, 'f');
+		ALTER TABLE test DROP PARTITION p1;
+	`)
+	require.NoError(t, err)
+	require.Equal(t, 4, count(db, "SELECT COUNT(*) FROM test"))
+	require.Equal(t, 0, count(db, "SELECT COUNT(*) FROM test WHERE b = 'a'"))
+
+	report, err := db.Check(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, report.Problems)
+}
+
+func TestDropPartitionRangeDeletion(t *testing.T) {
+	db, err := chai.Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT, b TEXT, PRIMARY KEY (a)) PARTITION BY RANGE (a) (
+			PARTITION p1 VALUES LESS THAN (1000),
+			PARTITION p2 VALUES LESS THAN (MAXVALUE)
+		);
+		CREATE INDEX test_b_idx ON test (b);
+	`)
+	require.NoError(t, err)
+	for i := 0; i < 200; i++ {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i*10, "x")
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.DB.Engine.Flush())
+
+	// keep the dropped rows visible to a transaction,
+	// to prevent compactions from removing the tombstones
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+	tx, err := conn.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = db.Exec("ALTER TABLE test DROP PARTITION p1")
+	require.NoError(t, err)
+	require.NoError(t, db.DB.Engine.Flush())
+
+	// the rows and the index entries of the partition are deleted with
+	// a range deletion per store instead of a tombstone per key
+	stats, err := db.DB.Engine.SpanStats(encoding.EncodeInt(nil, 0), encoding.EncodeInt(nil, math.MaxInt64))
+	require.NoError(t, err)
+	require.Less(t, stats.Tombstones, uint64(100))
+
+	row, err := db.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, row.Scan(&n))
+	require.Equal(t, 100, n)
+}
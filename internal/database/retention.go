@@ -0,0 +1,281 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A RetentionPolicy describes the rows of a table that are periodically purged:
+// the rows whose retention column is older than the retention period.
+// Rows whose retention column is NULL are never purged.
+type RetentionPolicy struct {
+	// Name of the TIMESTAMP column compared to the retention period.
+	Column string
+	// Retention period.
+	OlderThan time.Duration
+}
+
+// String returns a SQL representation of the policy, as used in
+// the WITH clause of a CREATE TABLE statement.
+func (p *RetentionPolicy) String() string {
+	return fmt.Sprintf("(older_than = '%s', column = %s)", FormatRetentionPeriod(p.OlderThan), stringutil.NormalizeIdentifier(p.Column, '`'))
+}
+
+// Cutoff returns the time before which the rows are purged.
+func (p *RetentionPolicy) Cutoff(now time.Time) time.Time {
+	return now.Add(-p.OlderThan)
+}
+
+// Validate ensures the policy can be applied to the given table.
+func (p *RetentionPolicy) Validate(info *TableInfo) error {
+	cc := info.GetColumnConstraint(p.Column)
+	if cc == nil {
+		return errors.Errorf("column %q does not exist for table %q", p.Column, info.TableName)
+	}
+	if cc.Type != types.TypeTimestamp {
+		return errors.Errorf("retention column %q must be of type TIMESTAMP", p.Column)
+	}
+	if cc.Encryption != nil {
+		return errors.Errorf("retention column %q cannot be encrypted", p.Column)
+	}
+	if p.OlderThan <= 0 {
+		return errors.New("retention period must be positive")
+	}
+
+	return nil
+}
+
+// Units of the retention periods. Plural forms are accepted.
+var retentionUnits = []struct {
+	name string
+	d    time.Duration
+}{
+	{"week", 7 * 24 * time.Hour},
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+	{"second", time.Second},
+}
+
+// ParseRetentionPeriod parses a retention period of the form
+// '<number> <unit>', where unit is one of second, minute, hour, day or week.
+func ParseRetentionPeriod(s string) (time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, errors.Errorf("invalid retention period %q, expected '<number> <unit>'", s)
+	}
+
+	n, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errors.Errorf("invalid retention period %q, expected a positive number", s)
+	}
+
+	unit := strings.TrimSuffix(strings.ToLower(fields[1]), "s")
+	for _, u := range retentionUnits {
+		if u.name != unit {
+			continue
+		}
+
+		if n > int64(1<<63-1)/int64(u.d) {
+			return 0, errors.Errorf("retention period %q is too long", s)
+		}
+
+		return time.Duration(n) * u.d, nil
+	}
+
+	return 0, errors.Errorf("invalid retention period unit %q", fields[1])
+}
+
+// FormatRetentionPeriod returns the representation of a retention period
+// using the largest unit that divides it.
+func FormatRetentionPeriod(d time.Duration) string {
+	// weeks are reported in days
+	for _, u := range retentionUnits[1:] {
+		if d%u.d != 0 {
+			continue
+		}
+
+		n := int64(d / u.d)
+		if n == 1 {
+			return "1 " + u.name
+		}
+		return strconv.FormatInt(n, 10) + " " + u.name + "s"
+	}
+
+	return d.String()
+}
+
+// A RetentionReport describes the rows purged, or that would be purged,
+// by the retention policy of a table.
+type RetentionReport struct {
+	Table string
+	// Rows whose retention column is before the cutoff are purged.
+	Cutoff time.Time
+	Rows   int
+}
+
+// ApplyRetentionPolicies deletes the rows of every table with a retention policy
+// that are older than the retention period, along with their index entries,
+// in a single transaction.
+// If dryRun is true, the rows are counted but not deleted.
+// It returns a report for each table with a retention policy, sorted by table name.
+func (db *Database) ApplyRetentionPolicies(dryRun bool) ([]RetentionReport, error) {
+	var reports []RetentionReport
+	if !hasRetentionPolicies(db.Catalog()) {
+		return reports, nil
+	}
+
+	tx, err := db.Begin(!dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := tx.TxStart.UTC()
+
+	var n int
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		info, err := tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		if info.Retention == nil {
+			continue
+		}
+
+		cutoff := info.Retention.Cutoff(now)
+		purged, err := purgeTable(tx, info, cutoff, dryRun)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, RetentionReport{
+			Table:  name,
+			Cutoff: cutoff,
+			Rows:   purged,
+		})
+		n += purged
+	}
+
+	if dryRun || n == 0 {
+		return reports, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func hasRetentionPolicies(c *Catalog) bool {
+	for _, name := range c.Cache.ListObjects(RelationTableType) {
+		info, err := c.GetTableInfo(name)
+		if err == nil && info.Retention != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// purgeTable deletes the rows of the table whose retention column
+// is before cutoff and returns their number.
+// If dryRun is true, the rows are only counted.
+func purgeTable(tx *Transaction, info *TableInfo, cutoff time.Time, dryRun bool) (int, error) {
+	t, err := tx.Catalog.GetTable(tx, info.TableName)
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := purgeableKeys(tx, t, cutoff)
+	if err != nil || dryRun {
+		return len(keys), err
+	}
+
+	infos, idxs, err := tableIndexes(tx, info.TableName)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		r, err := t.GetRow(key)
+		if err != nil {
+			return 0, err
+		}
+
+		err = deleteRow(t, infos, idxs, key, r)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+// purgeableKeys returns the keys of the rows whose retention column is before cutoff.
+// If the retention column is the first column of the primary key or of an index,
+// only the range of the purged rows is read. Otherwise, the whole table is scanned.
+func purgeableKeys(tx *Transaction, t *Table, cutoff time.Time) ([]*tree.Key, error) {
+	column := t.Info.Retention.Column
+	rng := &tree.Range{
+		Max:       tree.NewKey(types.NewTimestampValue(cutoff)),
+		Exclusive: true,
+	}
+
+	var keys []*tree.Key
+	collect := func(key *tree.Key) {
+		keys = append(keys, tree.NewEncodedKey(bytes.Clone(key.Encoded)))
+	}
+
+	if pk := t.Info.PrimaryKey; pk != nil && pk.Columns[0] == column {
+		err := t.Tree.IterateOnRange(rng, false, func(key *tree.Key, _ []byte) error {
+			collect(key)
+			return nil
+		})
+		return keys, err
+	}
+
+	for _, info := range tx.Catalog.Cache.GetTableIndexes(t.Info.TableName) {
+		if info.Columns[0] != column {
+			continue
+		}
+
+		idx, err := tx.Catalog.GetIndex(tx, info.IndexName)
+		if err != nil {
+			return nil, err
+		}
+
+		err = idx.IterateOnRange(rng, false, func(key *tree.Key) error {
+			collect(key)
+			return nil
+		})
+		return keys, err
+	}
+
+	err := t.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+		v, err := r.Get(column)
+		if err != nil {
+			if errors.Is(err, types.ErrColumnNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if v.Type() == types.TypeTimestamp && types.AsTime(v).Before(cutoff) {
+			collect(key)
+		}
+		return nil
+	})
+
+	return keys, err
+}
@@ -105,6 +105,28 @@ func (r *BasicRow) ResetWith(tableName string, key *tree.Key, rr row.Row) {
 	r.Row = rr
 }
 
+// Iterate calls fn for every column of the row. If the row cannot be decoded,
+// the returned CorruptRowError reports its table and its key.
+func (r *BasicRow) Iterate(fn func(column string, value types.Value) error) error {
+	err := r.Row.Iterate(fn)
+	if err != nil {
+		return withRowLocation(err, r.tableName, r.key)
+	}
+
+	return nil
+}
+
+// Get returns the value of a column. If the row cannot be decoded,
+// the returned CorruptRowError reports its table and its key.
+func (r *BasicRow) Get(column string) (types.Value, error) {
+	v, err := r.Row.Get(column)
+	if err != nil {
+		return nil, withRowLocation(err, r.tableName, r.key)
+	}
+
+	return v, nil
+}
+
 func (r *BasicRow) Key() *tree.Key {
 	return r.key
 }
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultSchema is the schema of the relations created without
+// specifying a schema when no search path is set.
+// The names of its relations are stored without prefix.
+const DefaultSchema = "public"
+
+// SchemaInfo holds the configuration of a schema.
+// A schema is a namespace for tables, indexes and sequences.
+// The relations of a schema are stored in the catalog with
+// a name of the form schema.name.
+type SchemaInfo struct {
+	Name string
+}
+
+// String returns a SQL representation.
+func (s *SchemaInfo) String() string {
+	return "CREATE SCHEMA " + stringutil.NormalizeIdentifier(s.Name, '`')
+}
+
+type SchemaInfoRelation struct {
+	Info *SchemaInfo
+}
+
+func (r *SchemaInfoRelation) Type() string {
+	return RelationSchemaType
+}
+
+func (r *SchemaInfoRelation) Name() string {
+	return r.Info.Name
+}
+
+func (r *SchemaInfoRelation) SetName(name string) {
+	r.Info.Name = name
+}
+
+func (r *SchemaInfoRelation) GenerateBaseName() string {
+	return r.Info.Name
+}
+
+func (r *SchemaInfoRelation) Clone() Relation {
+	info := *r.Info
+	return &SchemaInfoRelation{Info: &info}
+}
+
+// QualifiedName returns the name under which a relation of the given schema
+// is stored in the catalog.
+func QualifiedName(schema, name string) string {
+	if schema == "" || schema == DefaultSchema {
+		return name
+	}
+
+	return schema + "." + name
+}
+
+// SplitQualifiedName returns the schema and the name of a relation.
+// If the name is not qualified, the schema is empty.
+func SplitQualifiedName(name string) (schema, rel string) {
+	schema, rel, ok := strings.Cut(name, ".")
+	if !ok {
+		return "", name
+	}
+
+	return schema, rel
+}
+
+// SchemaOf returns the schema of the relation stored under the given name.
+func SchemaOf(name string) string {
+	schema, _ := SplitQualifiedName(name)
+	if schema == "" {
+		return DefaultSchema
+	}
+
+	return schema
+}
+
+// GetSchema returns a schema by name.
+func (c *Catalog) GetSchema(name string) (*SchemaInfo, error) {
+	if name == DefaultSchema || name == InformationSchema {
+		return &SchemaInfo{Name: name}, nil
+	}
+
+	r, err := c.Cache.Get(RelationSchemaType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.(*SchemaInfoRelation).Info, nil
+}
+
+// ListSchemas returns all the schema names sorted lexicographically,
+// excluding the default schema.
+func (c *Catalog) ListSchemas() []string {
+	return c.Cache.ListObjects(RelationSchemaType)
+}
+
+// ResolveName returns the name under which the relation of type tp
+// referred to by name is stored in the catalog.
+// Qualified names refer to the relation of the given schema, while
+// other names are looked up in the schemas of the search path
+// of the transaction, in order.
+// Internal relations always belong to the default schema, and the temporary
+// relations of the connection are looked up before the search path.
+func (c *Catalog) ResolveName(tx *Transaction, tp, name string) (string, error) {
+	schema, rel := SplitQualifiedName(name)
+	if schema != "" {
+		return QualifiedName(schema, rel), nil
+	}
+
+	if c.Cache.temp != nil {
+		if _, ok := c.Cache.temp.getMapByType(tp)[name]; ok {
+			return name, nil
+		}
+	}
+
+	if len(tx.SearchPath) == 0 || strings.HasPrefix(name, InternalPrefix) {
+		return name, nil
+	}
+
+	for _, schema := range tx.SearchPath {
+		qn := QualifiedName(schema, name)
+		if _, err := c.Cache.Get(tp, qn); err == nil {
+			return qn, nil
+		}
+	}
+
+	return "", errs.NewNotFoundError(name)
+}
+
+// NewRelationName returns the name under which a relation that is about
+// to be created must be stored in the catalog.
+// Names that are not qualified belong to the first schema of the search path
+// of the transaction.
+// It returns an error if the schema doesn't exist.
+func (c *Catalog) NewRelationName(tx *Transaction, name string) (string, error) {
+	schema, rel := SplitQualifiedName(name)
+	if schema == "" {
+		if len(tx.SearchPath) == 0 || strings.HasPrefix(name, InternalPrefix) {
+			return name, nil
+		}
+
+		schema = tx.SearchPath[0]
+	}
+
+	if schema == InformationSchema {
+		return "", errors.Errorf("cannot create relations in schema %s", schema)
+	}
+
+	if err := c.Cache.checkAttached(RelationSchemaType, schema); err != nil {
+		return "", err
+	}
+
+	_, err := c.GetSchema(schema)
+	if errs.IsNotFoundError(err) {
+		return "", errors.Errorf("schema %s does not exist", schema)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return QualifiedName(schema, rel), nil
+}
+
+// CreateSchema creates a schema.
+// If it already exists, returns errs.AlreadyExistsError.
+func (c *CatalogWriter) CreateSchema(tx *Transaction, info *SchemaInfo) error {
+	if info.Name == "" {
+		return errors.New("schema name required")
+	}
+
+	if info.Name == DefaultSchema || info.Name == InformationSchema {
+		return errors.WithStack(errs.AlreadyExistsError{Name: info.Name})
+	}
+
+	if strings.Contains(info.Name, ".") {
+		return fmt.Errorf("invalid schema name %q", info.Name)
+	}
+
+	rel := SchemaInfoRelation{Info: info}
+	err := c.Cache.Add(tx, &rel)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Insert(tx, &rel)
+}
+
+// DropSchema deletes a schema from the catalog.
+// The schema must not contain any relation.
+func (c *CatalogWriter) DropSchema(tx *Transaction, name string) error {
+	if name == DefaultSchema {
+		return errors.New("cannot drop the default schema")
+	}
+	if name == InformationSchema {
+		return errors.New("cannot drop the information schema")
+	}
+
+	_, err := c.GetSchema(name)
+	if err != nil {
+		return err
+	}
+
+	for _, tp := range []string{RelationTableType, RelationIndexType, RelationSequenceType} {
+		for _, rel := range c.Cache.ListObjects(tp) {
+			if schema, _ := SplitQualifiedName(rel); schema == name {
+				return fmt.Errorf("cannot drop schema %s because %s %s depends on it", name, tp, rel)
+			}
+		}
+	}
+
+	_, err = c.Cache.Delete(tx, RelationSchemaType, name)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Delete(tx, name)
+}
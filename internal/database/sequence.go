@@ -2,7 +2,9 @@ package database
 
 import (
 	"fmt"
+	"math/bits"
 	"strings"
+	"sync"
 
 	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/chaisql/chai/internal/row"
@@ -44,10 +46,12 @@ var sequenceTableInfo = func() *TableInfo {
 }()
 
 // A Sequence manages a sequence of numbers.
-// It is not thread safe.
+// It is safe for concurrent use by multiple transactions.
 type Sequence struct {
 	Info *SequenceInfo
 
+	// protects CurrentValue and Cached
+	mu           sync.Mutex
 	CurrentValue *int64
 	Cached       uint64
 	Key          *tree.Key
@@ -56,18 +60,19 @@ type Sequence struct {
 // NewSequence creates a new or existing sequence. If currentValue is not nil
 // next call to Next will increase the lease.
 func NewSequence(info *SequenceInfo, currentValue *int64) Sequence {
-	seq := Sequence{
-		Info:         info,
-		CurrentValue: currentValue,
-	}
+	var cached uint64
 
 	// currentValue is not nil, the sequence already exists in the database
 	// and the lease needs to be extended.
 	if currentValue != nil {
-		seq.Cached = seq.Info.Cache
+		cached = info.Cache
 	}
 
-	return seq
+	return Sequence{
+		Info:         info,
+		CurrentValue: currentValue,
+		Cached:       cached,
+	}
 }
 
 func (s *Sequence) key() *tree.Key {
@@ -80,6 +85,10 @@ func (s *Sequence) key() *tree.Key {
 }
 
 func (s *Sequence) Init(tx *Transaction) error {
+	if s.Info.Temporary {
+		return nil
+	}
+
 	tb, err := s.GetOrCreateTable(tx)
 	if err != nil {
 		return err
@@ -90,6 +99,10 @@ func (s *Sequence) Init(tx *Transaction) error {
 }
 
 func (s *Sequence) Drop(tx *Transaction, catalog *Catalog) error {
+	if s.Info.Temporary {
+		return nil
+	}
+
 	tb, err := catalog.GetTable(tx, SequenceTableName)
 	if err != nil {
 		if errs.IsNotFoundError(err) {
@@ -109,35 +122,43 @@ func (s *Sequence) Next(tx *Transaction) (int64, error) {
 		return 0, errors.New("cannot increment sequence on read-only transaction")
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	var newValue int64
+	var wrapped bool
 	if s.CurrentValue == nil {
 		newValue = s.Info.Start
 	} else {
-		newValue = *s.CurrentValue + s.Info.IncrementBy
-	}
-
-	if newValue < s.Info.Min {
-		if !s.Info.Cycle {
-			return 0, fmt.Errorf("reached minimum value of sequence %s", s.Info.Name)
-		}
-
-		newValue = s.Info.Max
-	}
-	if newValue > s.Info.Max {
-		if !s.Info.Cycle {
-			return 0, fmt.Errorf("reached maximum value of sequence %s", s.Info.Name)
+		cur, inc := *s.CurrentValue, s.Info.IncrementBy
+
+		// the bounds are checked before adding the increment
+		// to avoid overflowing int64.
+		switch {
+		case inc > 0 && (cur > s.Info.Max || uint64(s.Info.Max-cur) < uint64(inc)):
+			if !s.Info.Cycle {
+				return 0, fmt.Errorf("reached maximum value of sequence %s", s.Info.Name)
+			}
+
+			newValue, wrapped = s.Info.Min, true
+		case inc < 0 && (cur < s.Info.Min || uint64(cur-s.Info.Min) < uint64(-inc)):
+			if !s.Info.Cycle {
+				return 0, fmt.Errorf("reached minimum value of sequence %s", s.Info.Name)
+			}
+
+			newValue, wrapped = s.Info.Max, true
+		default:
+			newValue = cur + inc
 		}
-
-		newValue = s.Info.Min
 	}
 
-	var newLease int64
-
 	s.Cached++
 
 	// if the number of cached values is less than or equal to the cache,
 	// we don't increase the lease.
-	if s.CurrentValue != nil && s.Cached <= s.Info.Cache {
+	// when the sequence cycles, the lease must be moved back
+	// to the start of the new cycle.
+	if s.CurrentValue != nil && s.Cached <= s.Info.Cache && !wrapped {
 		s.CurrentValue = &newValue
 		return newValue, nil
 	}
@@ -147,19 +168,7 @@ func (s *Sequence) Next(tx *Transaction) (int64, error) {
 		s.Cached = 1
 	}
 
-	// calculate the new lease depending on the direction
-	// of the sequence
-	if s.Info.IncrementBy > 0 {
-		newLease = newValue + int64(s.Info.Cache) - 1
-		if newLease > s.Info.Max {
-			newLease = s.Info.Max
-		}
-	} else {
-		newLease = newValue - int64(s.Info.Cache) + 1
-		if newLease < s.Info.Min {
-			newLease = s.Info.Min
-		}
-	}
+	newLease := s.lease(newValue)
 
 	// store the new lease
 	err := s.SetLease(tx, s.Info.Name, newLease)
@@ -171,7 +180,42 @@ func (s *Sequence) Next(tx *Transaction) (int64, error) {
 	return newValue, nil
 }
 
+// lease returns the last value that can be returned without updating
+// the sequence table, when caching Info.Cache values starting with v.
+// The lease never goes past the bounds of the sequence.
+func (s *Sequence) lease(v int64) int64 {
+	if s.Info.Cache <= 1 {
+		return v
+	}
+
+	inc := uint64(s.Info.IncrementBy)
+	if s.Info.IncrementBy < 0 {
+		inc = uint64(-s.Info.IncrementBy)
+	}
+
+	hi, span := bits.Mul64(s.Info.Cache-1, inc)
+	if s.Info.IncrementBy > 0 {
+		if hi != 0 || span > uint64(s.Info.Max-v) {
+			return s.Info.Max
+		}
+
+		return v + int64(span)
+	}
+
+	if hi != 0 || span > uint64(v-s.Info.Min) {
+		return s.Info.Min
+	}
+
+	return v - int64(span)
+}
+
+// SetLease stores the lease of the sequence in the sequence table.
+// Temporary sequences only keep their lease in memory.
 func (s *Sequence) SetLease(tx *Transaction, name string, v int64) error {
+	if s.Info.Temporary {
+		return nil
+	}
+
 	tb, err := s.GetOrCreateTable(tx)
 	if err != nil {
 		return err
@@ -224,9 +268,27 @@ func (s *Sequence) GenerateBaseName() string {
 	return sb.String()
 }
 
+// Current returns the current value of the sequence, or nil if the sequence
+// was never used. After the database is reopened, the current value of
+// a sequence with a cache is the end of its last lease.
+func (s *Sequence) Current() *int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentValue == nil {
+		return nil
+	}
+
+	v := *s.CurrentValue
+	return &v
+}
+
 // Release the sequence by storing the actual current value to the sequence table.
 // If the sequence has cache, the cached value is overwritten.
 func (s *Sequence) Release(tx *Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if s.CurrentValue == nil {
 		return nil
 	}
@@ -240,7 +302,48 @@ func (s *Sequence) Release(tx *Transaction) error {
 	return nil
 }
 
+// restart resets the sequence so that the next value it returns is v.
+func (s *Sequence) restart(tx *Transaction, v int64) error {
+	if v < s.Info.Min || v > s.Info.Max {
+		return errors.Errorf("RESTART value (%d) must be between MINVALUE (%d) and MAXVALUE (%d)", v, s.Info.Min, s.Info.Max)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Cached = s.Info.Cache
+
+	// a sequence that was never used returns its start value
+	if v == s.Info.Start {
+		s.CurrentValue = nil
+		if s.Info.Temporary {
+			return nil
+		}
+
+		tb, err := s.GetOrCreateTable(tx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tb.Put(s.key(), row.NewColumnBuffer().Add("name", types.NewTextValue(s.Info.Name)))
+		return err
+	}
+
+	// otherwise, store the value preceding v, as if it had been returned
+	// by the sequence.
+	prev := v - s.Info.IncrementBy
+	if (s.Info.IncrementBy > 0 && prev > v) || (s.Info.IncrementBy < 0 && prev < v) {
+		return errors.Errorf("cannot restart sequence %s with %d", s.Info.Name, v)
+	}
+
+	s.CurrentValue = &prev
+	return s.SetLease(tx, s.Info.Name, prev)
+}
+
 func (s *Sequence) Clone() Relation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	return &Sequence{
 		Info:         s.Info.Clone(),
 		CurrentValue: s.CurrentValue,
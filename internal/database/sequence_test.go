@@ -1,6 +1,7 @@
 package database_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/chaisql/chai/internal/database"
@@ -392,4 +393,133 @@ func TestSequence(t *testing.T) {
 
 		next(seq, tx, tx.Catalog, 5, 9)
 	})
+
+	t.Run("cache with increment", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.CatalogWriter().CreateSequence(tx, &database.SequenceInfo{
+			Name:        "a",
+			IncrementBy: 10,
+			Min:         1, Max: 45,
+			Start: 1,
+			Cache: 3,
+		})
+		require.NoError(t, err)
+
+		seq, err := tx.Catalog.GetSequence("a")
+		require.NoError(t, err)
+
+		// the lease must cover the 3 cached values
+		next(seq, tx, tx.Catalog, 1, 21)
+		next(seq, tx, tx.Catalog, 11, 21)
+		next(seq, tx, tx.Catalog, 21, 21)
+		// the lease must not be greater than the max value
+		next(seq, tx, tx.Catalog, 31, 45)
+	})
+
+	t.Run("cycle with cache", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.CatalogWriter().CreateSequence(tx, &database.SequenceInfo{
+			Name:        "a",
+			IncrementBy: 1,
+			Min:         1, Max: 3,
+			Start: 1,
+			Cache: 5,
+			Cycle: true,
+		})
+		require.NoError(t, err)
+
+		seq, err := tx.Catalog.GetSequence("a")
+		require.NoError(t, err)
+
+		next(seq, tx, tx.Catalog, 1, 3)
+		next(seq, tx, tx.Catalog, 2, 3)
+		next(seq, tx, tx.Catalog, 3, 3)
+		// cycling must move the lease back to the start of the cycle
+		next(seq, tx, tx.Catalog, 1, 3)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.CatalogWriter().CreateSequence(tx, &database.SequenceInfo{
+			Name:        "a",
+			IncrementBy: 5,
+			Min:         1, Max: math.MaxInt64,
+			Start: math.MaxInt64 - 6,
+			Cache: 10,
+		})
+		require.NoError(t, err)
+
+		seq, err := tx.Catalog.GetSequence("a")
+		require.NoError(t, err)
+
+		next(seq, tx, tx.Catalog, math.MaxInt64-6, math.MaxInt64)
+		next(seq, tx, tx.Catalog, math.MaxInt64-1, math.MaxInt64)
+		_, err = seq.Next(tx)
+		require.ErrorContains(t, err, "reached maximum value")
+	})
+
+	t.Run("alter", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.CatalogWriter().CreateSequence(tx, &database.SequenceInfo{
+			Name:        "a",
+			IncrementBy: 1,
+			Min:         1, Max: 100,
+			Start: 1,
+			Cache: 5,
+		})
+		require.NoError(t, err)
+
+		seq, err := tx.Catalog.GetSequence("a")
+		require.NoError(t, err)
+
+		next(seq, tx, tx.Catalog, 1, 5)
+		next(seq, tx, tx.Catalog, 2, 5)
+
+		// altering the sequence releases the cached values
+		info := seq.Info.Clone()
+		info.IncrementBy = 10
+		err = tx.CatalogWriter().AlterSequence(tx, info, nil)
+		require.NoError(t, err)
+
+		got, err := getLease(t, tx, tx.Catalog, "a")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), *got)
+
+		seq, err = tx.Catalog.GetSequence("a")
+		require.NoError(t, err)
+		next(seq, tx, tx.Catalog, 12, 52)
+
+		// restart must survive reloading the catalog
+		err = tx.CatalogWriter().AlterSequence(tx, seq.Info.Clone(), testutil.Int64Ptr(30))
+		require.NoError(t, err)
+
+		tx.Catalog = database.NewCatalog()
+		err = catalogstore.LoadCatalog(tx)
+		require.NoError(t, err)
+
+		seq, err = tx.Catalog.GetSequence("a")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), seq.Info.IncrementBy)
+		next(seq, tx, tx.Catalog, 30, 70)
+	})
 }
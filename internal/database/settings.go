@@ -0,0 +1,466 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// LiteralKind is the kind of a literal of the value given to SET.
+type LiteralKind uint8
+
+// Kinds of literals.
+const (
+	// LiteralIdent is an identifier, or one of the keywords
+	// ON, TRUE and FALSE, in lower case.
+	LiteralIdent LiteralKind = iota + 1
+	// LiteralString is a quoted string.
+	LiteralString
+	// LiteralInteger is an integer.
+	LiteralInteger
+)
+
+// A SettingLiteral is one of the literals of the value given to SET,
+// which is a comma separated list of literals.
+type SettingLiteral struct {
+	Kind LiteralKind
+	Text string
+}
+
+// ErrUnexpectedSettingValue is returned by the Parse function of a setting
+// when the kind of the value is not accepted by the setting.
+// The parser reports it with the list of the expected values.
+var ErrUnexpectedSettingValue = errors.New("unexpected setting value")
+
+// A Setting is a variable of the connections, set with SET and read with SHOW.
+// The settings are registered with RegisterSetting.
+type Setting struct {
+	// Name of the setting, in lower case.
+	Name string
+	// Description of the setting, returned by SHOW ALL.
+	Description string
+	// Expected describes the accepted values, in parse errors.
+	Expected []string
+	// Parse converts the literals given to SET to the value of the setting.
+	Parse func(lits []SettingLiteral) (any, error)
+	// Get returns the value of the setting for the connection.
+	// If nil, the value is kept by the connection, see Default.
+	Get func(c *Connection) any
+	// Set sets the value of the setting for the connection.
+	// A nil value restores the default value.
+	// If nil, the value is kept by the connection, see Default.
+	Set func(c *Connection, v any)
+	// Default is the value of the settings kept by the connections,
+	// before they are set.
+	Default any
+	// Format returns the value as text, for SHOW.
+	// If nil, the value is formatted with fmt.
+	Format func(v any) string
+}
+
+var settings = struct {
+	sync.RWMutex
+
+	m map[string]*Setting
+}{
+	m: make(map[string]*Setting),
+}
+
+// RegisterSetting makes a setting available to SET and SHOW.
+// It panics if a setting with the same name is already registered.
+func RegisterSetting(s *Setting) {
+	settings.Lock()
+	defer settings.Unlock()
+
+	if _, ok := settings.m[s.Name]; ok {
+		panic(fmt.Sprintf("setting %q already registered", s.Name))
+	}
+
+	settings.m[s.Name] = s
+}
+
+// LookupSetting returns the setting with the given name,
+// ignoring the case, or nil if it doesn't exist.
+func LookupSetting(name string) *Setting {
+	settings.RLock()
+	defer settings.RUnlock()
+
+	return settings.m[strings.ToLower(name)]
+}
+
+// ListSettings returns the registered settings, sorted by name.
+func ListSettings() []*Setting {
+	settings.RLock()
+	defer settings.RUnlock()
+
+	l := make([]*Setting, 0, len(settings.m))
+	for _, s := range settings.m {
+		l = append(l, s)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Name < l[j].Name
+	})
+
+	return l
+}
+
+// FormatValue returns the value of the setting as text.
+func (s *Setting) FormatValue(v any) string {
+	if s.Format != nil {
+		return s.Format(v)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// Setting returns the value of a setting for the connection.
+func (c *Connection) Setting(name string) (any, error) {
+	s := LookupSetting(name)
+	if s == nil {
+		return nil, errors.Errorf("unknown setting %q", name)
+	}
+
+	if s.Get != nil {
+		return s.Get(c), nil
+	}
+
+	if v, ok := c.vars[s.Name]; ok {
+		return v, nil
+	}
+
+	return s.Default, nil
+}
+
+// SetSetting sets the value of a setting for the connection.
+// A nil value restores the default value.
+func (c *Connection) SetSetting(name string, v any) error {
+	s := LookupSetting(name)
+	if s == nil {
+		return errors.Errorf("unknown setting %q", name)
+	}
+
+	if s.Set != nil {
+		s.Set(c, v)
+		return nil
+	}
+
+	if v == nil {
+		delete(c.vars, s.Name)
+		return nil
+	}
+
+	if c.vars == nil {
+		c.vars = make(map[string]any)
+	}
+	c.vars[s.Name] = v
+	return nil
+}
+
+func init() {
+	RegisterSetting(&Setting{
+		Name:        "search_path",
+		Description: "Schemas in which the relations referred to without schema are looked up.",
+		Expected:    []string{"schema", "DEFAULT"},
+		Parse: func(lits []SettingLiteral) (any, error) {
+			path := make([]string, 0, len(lits))
+			for _, l := range lits {
+				if l.Kind != LiteralIdent && l.Kind != LiteralString {
+					return nil, ErrUnexpectedSettingValue
+				}
+				path = append(path, l.Text)
+			}
+			return path, nil
+		},
+		Get: func(c *Connection) any { return c.SearchPath() },
+		Set: func(c *Connection, v any) {
+			path, _ := v.([]string)
+			c.SetSearchPath(path)
+		},
+		Format: func(v any) string {
+			path := v.([]string)
+			if len(path) == 0 {
+				return DefaultSchema
+			}
+			return strings.Join(path, ", ")
+		},
+	})
+
+	RegisterSetting(&Setting{
+		Name:        "compat_mode",
+		Description: "Compatibility mode of the queries: sqlite or postgres.",
+		Expected:    []string{"'sqlite'", "'postgres'", "DEFAULT"},
+		Parse: func(lits []SettingLiteral) (any, error) {
+			l, err := singleLiteral(lits, LiteralString, LiteralIdent)
+			if err != nil {
+				return nil, err
+			}
+			mode, err := ParseCompatMode(l.Text)
+			if err != nil || mode == CompatDefault {
+				return nil, errors.Errorf("unknown compatibility mode %q", l.Text)
+			}
+			return mode, nil
+		},
+		Get: func(c *Connection) any { return c.CompatMode() },
+		Set: func(c *Connection, v any) {
+			mode, _ := v.(CompatMode)
+			c.SetCompatMode(mode)
+		},
+		Format: func(v any) string {
+			if v.(CompatMode) == CompatDefault {
+				return "default"
+			}
+			return string(v.(CompatMode))
+		},
+	})
+
+	RegisterSetting(&Setting{
+		Name:        "strict_typing",
+		Description: "Reject the comparisons between incompatible types and the lossy conversions.",
+		Expected:    []string{"ON", "OFF", "DEFAULT"},
+		Parse:       parseBoolSetting,
+		Get:         func(c *Connection) any { return c.StrictTyping() },
+		Set: func(c *Connection, v any) {
+			if v == nil {
+				c.ResetStrictTyping()
+				return
+			}
+			c.SetStrictTyping(v.(bool))
+		},
+		Format: formatBoolSetting,
+	})
+
+	RegisterSetting(&Setting{
+		Name:        "skip_corrupt_rows",
+		Description: "Ignore the rows that cannot be decoded.",
+		Expected:    []string{"ON", "OFF", "DEFAULT"},
+		Parse:       parseBoolSetting,
+		Get:         func(c *Connection) any { return c.SkipCorruptRows() },
+		Set: func(c *Connection, v any) {
+			if v == nil {
+				c.ResetSkipCorruptRows()
+				return
+			}
+			c.SetSkipCorruptRows(v.(bool))
+		},
+		Format: formatBoolSetting,
+	})
+
+	RegisterSetting(&Setting{
+		Name:        "statement_timeout",
+		Description: "Maximum duration of the queries, 0 if unlimited.",
+		Expected:    []string{"interval", "milliseconds", "DEFAULT"},
+		Parse: func(lits []SettingLiteral) (any, error) {
+			l, err := singleLiteral(lits, LiteralString, LiteralInteger)
+			if err != nil {
+				return nil, err
+			}
+
+			var timeout time.Duration
+			if l.Kind == LiteralString {
+				iv, err := types.ParseInterval(l.Text)
+				if err != nil {
+					return nil, errors.Errorf("invalid statement timeout %q", l.Text)
+				}
+				timeout = time.Duration(iv.Length()) * time.Microsecond
+			} else {
+				ms, err := strconv.ParseInt(l.Text, 10, 64)
+				if err != nil {
+					return nil, errors.Errorf("invalid statement timeout %q", l.Text)
+				}
+				timeout = time.Duration(ms) * time.Millisecond
+			}
+			if timeout < 0 {
+				return nil, errors.Errorf("invalid statement timeout %q", l.Text)
+			}
+
+			return timeout, nil
+		},
+		Get: func(c *Connection) any { return c.StatementTimeout() },
+		Set: func(c *Connection, v any) {
+			d, _ := v.(time.Duration)
+			c.SetStatementTimeout(d)
+		},
+		Format: func(v any) string {
+			if v.(time.Duration) == 0 {
+				return "0"
+			}
+			return v.(time.Duration).String()
+		},
+	})
+
+	RegisterSetting(&Setting{
+		Name:        "work_mem",
+		Description: "Memory used by each sort and temporary tree before spilling to disk, 0 for the default.",
+		Expected:    []string{"size", "kilobytes", "DEFAULT"},
+		Parse: func(lits []SettingLiteral) (any, error) {
+			l, err := singleLiteral(lits, LiteralString, LiteralInteger)
+			if err != nil {
+				return nil, err
+			}
+			size, err := ParseMemorySize(l.Text)
+			if err != nil {
+				return nil, errors.Errorf("invalid memory size %q", l.Text)
+			}
+			return size, nil
+		},
+		Get: func(c *Connection) any { return c.WorkMem() },
+		Set: func(c *Connection, v any) {
+			if v == nil {
+				c.ResetWorkMem()
+				return
+			}
+			c.SetWorkMem(v.(int))
+		},
+		Format: func(v any) string { return formatMemorySize(v.(int)) },
+	})
+
+	RegisterSetting(&Setting{
+		Name:        "parallel_workers",
+		Description: "Number of goroutines reading each table scanned by the read-only queries.",
+		Expected:    []string{"workers", "DEFAULT"},
+		Parse: func(lits []SettingLiteral) (any, error) {
+			l, err := singleLiteral(lits, LiteralInteger)
+			if err != nil {
+				return nil, err
+			}
+			n, err := strconv.Atoi(l.Text)
+			if err != nil || n < 0 || n > MaxParallelWorkers {
+				return nil, errors.Errorf("invalid number of parallel workers %q", l.Text)
+			}
+			return n, nil
+		},
+		Get: func(c *Connection) any { return c.ParallelWorkers() },
+		Set: func(c *Connection, v any) {
+			if v == nil {
+				c.ResetParallelWorkers()
+				return
+			}
+			c.SetParallelWorkers(v.(int))
+		},
+	})
+
+	RegisterSetting(&Setting{
+		Name:        "synchronous",
+		Description: "When the commits are synchronized to disk: OFF, NORMAL or FULL.",
+		Expected:    []string{"OFF", "NORMAL", "FULL", "DEFAULT"},
+		Parse: func(lits []SettingLiteral) (any, error) {
+			l, err := singleLiteral(lits, LiteralString, LiteralIdent, LiteralInteger)
+			if err != nil {
+				return nil, err
+			}
+			return engine.ParseSynchronous(l.Text)
+		},
+		Get: func(c *Connection) any { return c.Synchronous() },
+		Set: func(c *Connection, v any) {
+			if v == nil {
+				c.ResetSynchronous()
+				return
+			}
+			c.SetSynchronous(v.(engine.Synchronous))
+		},
+	})
+}
+
+// MaxParallelWorkers is the maximum value of the parallel_workers setting.
+const MaxParallelWorkers = 1024
+
+// singleLiteral returns the literal of a value made of a single literal
+// of one of the given kinds.
+func singleLiteral(lits []SettingLiteral, kinds ...LiteralKind) (SettingLiteral, error) {
+	if len(lits) != 1 {
+		return SettingLiteral{}, ErrUnexpectedSettingValue
+	}
+
+	for _, k := range kinds {
+		if lits[0].Kind == k {
+			return lits[0], nil
+		}
+	}
+
+	return SettingLiteral{}, ErrUnexpectedSettingValue
+}
+
+func parseBoolSetting(lits []SettingLiteral) (any, error) {
+	l, err := singleLiteral(lits, LiteralIdent)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(l.Text) {
+	case "on", "true":
+		return true, nil
+	case "off", "false":
+		return false, nil
+	}
+
+	return nil, ErrUnexpectedSettingValue
+}
+
+func formatBoolSetting(v any) string {
+	if v.(bool) {
+		return "on"
+	}
+
+	return "off"
+}
+
+// ParseMemorySize parses a positive number of bytes followed by
+// an optional unit among B, kB, MB and GB. Without unit, the size
+// is in kilobytes.
+func ParseMemorySize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(s)
+	}
+
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, err
+	}
+
+	var unit int
+	switch strings.ToLower(strings.TrimSpace(s[i:])) {
+	case "b":
+		unit = 1
+	case "", "kb":
+		unit = 1 << 10
+	case "mb":
+		unit = 1 << 20
+	case "gb":
+		unit = 1 << 30
+	default:
+		return 0, fmt.Errorf("unknown unit %q", s[i:])
+	}
+
+	if n <= 0 || n > math.MaxInt/unit {
+		return 0, fmt.Errorf("invalid size %d", n)
+	}
+
+	return n * unit, nil
+}
+
+// formatMemorySize formats a number of bytes with the largest unit
+// dividing it.
+func formatMemorySize(n int) string {
+	switch {
+	case n == 0:
+		return "0"
+	case n%(1<<30) == 0:
+		return strconv.Itoa(n>>30) + "GB"
+	case n%(1<<20) == 0:
+		return strconv.Itoa(n>>20) + "MB"
+	case n%(1<<10) == 0:
+		return strconv.Itoa(n>>10) + "kB"
+	}
+
+	return strconv.Itoa(n) + "B"
+}
@@ -0,0 +1,93 @@
+package database_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterSetting(t *testing.T) {
+	database.RegisterSetting(&database.Setting{
+		Name:        "test_output_format",
+		Description: "Format of the test output.",
+		Expected:    []string{"'text'", "'json'", "DEFAULT"},
+		Parse: func(lits []database.SettingLiteral) (any, error) {
+			if len(lits) != 1 || lits[0].Kind != database.LiteralString {
+				return nil, database.ErrUnexpectedSettingValue
+			}
+			return lits[0].Text, nil
+		},
+		Default: "text",
+	})
+	require.Panics(t, func() {
+		database.RegisterSetting(&database.Setting{Name: "test_output_format"})
+	})
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	show := func() string {
+		t.Helper()
+
+		r, err := conn.QueryRow("SHOW test_output_format")
+		require.NoError(t, err)
+		var v string
+		require.NoError(t, r.Scan(&v))
+		return v
+	}
+
+	require.Equal(t, "text", show())
+	require.NoError(t, conn.Exec("SET test_output_format = 'json'"))
+	require.Equal(t, "json", show())
+
+	v, err := conn.Conn.Setting("test_output_format")
+	require.NoError(t, err)
+	require.Equal(t, "json", v)
+
+	require.Error(t, conn.Exec("SET test_output_format = json"))
+	require.NoError(t, conn.Exec("SET test_output_format = DEFAULT"))
+	require.Equal(t, "text", show())
+
+	require.NoError(t, conn.Exec("SET test_output_format = 'json'"))
+	require.NoError(t, conn.Conn.Reset())
+	require.Equal(t, "text", show())
+}
+
+func TestShowAll(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{WorkMem: 8 << 20})
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	r, err := conn.QueryRow("SHOW work_mem")
+	require.NoError(t, err)
+	var v string
+	require.NoError(t, r.Scan(&v))
+	require.Equal(t, "8MB", v)
+
+	res, err := conn.Query("SHOW ALL")
+	require.NoError(t, err)
+	defer res.Close()
+	var names []string
+	err = res.Iterate(func(r *chai.Row) error {
+		var name, setting, description string
+		require.NoError(t, r.Scan(&name, &setting, &description))
+		require.NotEmpty(t, description)
+		names = append(names, name)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Contains(t, names, "parallel_workers")
+	require.True(t, sort.StringsAreSorted(names))
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+// A snapshot file starts with a header, followed by the keys and
+// values of the database, each prefixed by its length as a uvarint.
+// An empty key marks the end of the entries and is followed
+// by the CRC-32C of everything written before it.
+var snapshotHeader = []byte("chaisnap\x01")
+
+var snapshotTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrInvalidSnapshot is returned when loading a snapshot
+// which was not written by WriteSnapshot or is corrupted.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// WriteSnapshot writes a copy of the database, as seen by a read-only
+// transaction, to w. The copy can be loaded into an empty database
+// with Options.Snapshot. Temporary data and the rollback segment are
+// not copied.
+func (db *Database) WriteSnapshot(w io.Writer) error {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return writeSnapshot(tx.Session, w)
+}
+
+func writeSnapshot(sess engine.Session, w io.Writer) error {
+	it, err := sess.Iterator(&engine.IterOptions{
+		UpperBound: encoding.EncodeInt(nil, int64(MinTransientNamespace)),
+	})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	crc := crc32.New(snapshotTable)
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	if _, err := bw.Write(snapshotHeader); err != nil {
+		return errors.WithStack(err)
+	}
+
+	var lbuf [binary.MaxVarintLen64]byte
+	writeBytes := func(b []byte) error {
+		n := binary.PutUvarint(lbuf[:], uint64(len(b)))
+		if _, err := bw.Write(lbuf[:n]); err != nil {
+			return err
+		}
+		_, err := bw.Write(b)
+		return err
+	}
+
+	valid := it.First()
+	for valid {
+		k := it.Key()
+		if ns, ok := decodeNamespace(k); ok && ns == RollbackSegmentNamespace {
+			valid = it.SeekGE(encoding.EncodeInt(nil, int64(ns)+1))
+			continue
+		}
+
+		v, err := it.Value()
+		if err != nil {
+			return err
+		}
+		if err := writeBytes(k); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := writeBytes(v); err != nil {
+			return errors.WithStack(err)
+		}
+
+		valid = it.Next()
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	if err := writeBytes(nil); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := bw.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = w.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+	return errors.WithStack(err)
+}
+
+// loadSnapshot writes the content of a snapshot to the database,
+// which must be empty. Nothing is written if the snapshot is invalid.
+func (db *Database) loadSnapshot(r io.Reader) error {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	it, err := tx.Session.Iterator(nil)
+	if err != nil {
+		return err
+	}
+	empty := !it.First()
+	if err := it.Close(); err != nil {
+		return err
+	}
+	if !empty {
+		return errors.New("cannot load a snapshot into a non-empty database")
+	}
+
+	err = readSnapshot(r, tx.Session.Put)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// readSnapshot calls fn with every key and value of the snapshot.
+// The checksum is only verified once all the entries are read,
+// fn must not make its writes visible before readSnapshot returns.
+func readSnapshot(r io.Reader, fn func(k, v []byte) error) error {
+	br := bufio.NewReader(r)
+	hr := hashingReader{r: br, crc: crc32.New(snapshotTable)}
+
+	header := make([]byte, len(snapshotHeader))
+	if _, err := io.ReadFull(&hr, header); err != nil || !bytes.Equal(header, snapshotHeader) {
+		return errors.Wrap(ErrInvalidSnapshot, "unknown header")
+	}
+
+	readBytes := func(buf []byte) ([]byte, error) {
+		n, err := binary.ReadUvarint(&hr)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidSnapshot, "truncated file")
+		}
+		if n > math.MaxUint32 {
+			return nil, errors.Wrapf(ErrInvalidSnapshot, "invalid length %d", n)
+		}
+		if uint64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		if _, err := io.ReadFull(&hr, buf); err != nil {
+			return nil, errors.Wrap(ErrInvalidSnapshot, "truncated file")
+		}
+		return buf, nil
+	}
+
+	var k, v []byte
+	var err error
+	for {
+		k, err = readBytes(k)
+		if err != nil {
+			return err
+		}
+		if len(k) == 0 {
+			break
+		}
+		v, err = readBytes(v)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+
+	var sum [4]byte
+	if _, err := io.ReadFull(br, sum[:]); err != nil {
+		return errors.Wrap(ErrInvalidSnapshot, "missing checksum")
+	}
+	if binary.BigEndian.Uint32(sum[:]) != hr.crc.Sum32() {
+		return errors.Wrap(ErrInvalidSnapshot, "checksum mismatch")
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return errors.Wrap(ErrInvalidSnapshot, "unexpected data after the checksum")
+	}
+
+	return nil
+}
+
+// hashingReader computes the checksum of the bytes it reads.
+type hashingReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.crc.Write(p[:n])
+	return n, err
+}
+
+func (h *hashingReader) ReadByte() (byte, error) {
+	c, err := h.r.ReadByte()
+	if err == nil {
+		h.crc.Write([]byte{c})
+	}
+	return c, err
+}
@@ -0,0 +1,345 @@
+package database
+
+import (
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// TableStorageStats describes how a table and its indexes are stored.
+// Sizes on disk are estimated from the properties of the files of the
+// storage engine and don't include the data that was not flushed to disk yet,
+// such as the most recent writes of small databases.
+type TableStorageStats struct {
+	Table string
+	// Rows is the number of rows of the table.
+	Rows int64
+	// AvgRowSize is the average size of the encoded rows, primary key included.
+	AvgRowSize float64
+	// DiskSize is the size of the rows on disk, excluding the indexes.
+	DiskSize uint64
+	// CompressionRatio is the size of the rows on disk before compression
+	// divided by their size once compressed. It is zero if no row is on disk.
+	CompressionRatio float64
+	// Tombstones is the number of deleted rows, or of rows overwritten,
+	// whose deletion markers were not removed by compactions yet.
+	Tombstones uint64
+	// Indexes are the statistics of the indexes of the table,
+	// sorted by name.
+	Indexes []IndexStorageStats
+}
+
+// IndexStorageStats describes how an index is stored.
+type IndexStorageStats struct {
+	Name string
+	// Entries is the number of entries of the index.
+	Entries int64
+	// Size is the size of the encoded entries.
+	Size int64
+	// DiskSize is the size of the entries on disk.
+	DiskSize uint64
+	// CompressionRatio is the size of the entries on disk before compression
+	// divided by their size once compressed. It is zero if no entry is on disk.
+	CompressionRatio float64
+	// Tombstones is the number of deleted entries whose deletion markers
+	// were not removed by compactions yet.
+	Tombstones uint64
+}
+
+// treeStats returns the number of keys of a tree and their size, scanning it,
+// and its statistics on disk.
+func treeStats(tx *Transaction, tr *tree.Tree) (n int64, size int64, disk engine.SpanStats, err error) {
+	// the keys of a partitioned tree are stored in its partitions
+	for _, sub := range tr.Subtrees() {
+		start, end := sub.Bounds()
+
+		it, err := tx.Session.Iterator(&engine.IterOptions{
+			LowerBound: start,
+			UpperBound: end,
+		})
+		if err != nil {
+			return 0, 0, disk, err
+		}
+
+		for it.First(); it.Valid(); it.Next() {
+			v, err := it.Value()
+			if err != nil {
+				_ = it.Close()
+				return 0, 0, disk, err
+			}
+
+			n++
+			size += int64(len(it.Key()) + len(v))
+		}
+		err = it.Error()
+		_ = it.Close()
+		if err != nil {
+			return 0, 0, disk, err
+		}
+
+		stats, err := tx.db.Engine.SpanStats(start, end)
+		if err != nil {
+			return 0, 0, disk, err
+		}
+		disk.DiskSize += stats.DiskSize
+		disk.RawSize += stats.RawSize
+		disk.Tombstones += stats.Tombstones
+	}
+
+	return n, size, disk, nil
+}
+
+func compressionRatio(s engine.SpanStats) float64 {
+	if s.DiskSize == 0 {
+		return 0
+	}
+
+	return float64(s.RawSize) / float64(s.DiskSize)
+}
+
+// TableStorageStats returns the storage statistics of a table and its indexes.
+// The rows and the index entries are counted by scanning them.
+func (db *Database) TableStorageStats(tableName string) (*TableStorageStats, error) {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return tableStorageStats(tx, tableName)
+}
+
+func tableStorageStats(tx *Transaction, tableName string) (*TableStorageStats, error) {
+	t, err := tx.Catalog.GetTable(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	n, size, disk, err := treeStats(tx, t.Tree)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read table %q", tableName)
+	}
+
+	stats := TableStorageStats{
+		Table:            tableName,
+		Rows:             n,
+		DiskSize:         disk.DiskSize,
+		CompressionRatio: compressionRatio(disk),
+		Tombstones:       disk.Tombstones,
+	}
+	if n > 0 {
+		stats.AvgRowSize = float64(size) / float64(n)
+	}
+
+	for _, name := range tx.Catalog.ListIndexes(tableName) {
+		idx, err := tx.Catalog.GetIndex(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		n, size, disk, err := treeStats(tx, idx.Tree)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read index %q", name)
+		}
+
+		stats.Indexes = append(stats.Indexes, IndexStorageStats{
+			Name:             name,
+			Entries:          n,
+			Size:             size,
+			DiskSize:         disk.DiskSize,
+			CompressionRatio: compressionRatio(disk),
+			Tombstones:       disk.Tombstones,
+		})
+	}
+
+	return &stats, nil
+}
+
+var tableStatsTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName: TableStatsTableName,
+		ReadOnly:  true,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "table_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "rows", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 2, Column: "avg_row_size", Type: types.TypeDouble},
+			&ColumnConstraint{Position: 3, Column: "disk_size", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 4, Column: "compression_ratio", Type: types.TypeDouble},
+			&ColumnConstraint{Position: 5, Column: "tombstones", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 6, Column: "indexes", Type: types.TypeInteger},
+			&ColumnConstraint{Position: 7, Column: "indexes_disk_size", Type: types.TypeBigint},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       TableStatsTableName + "_pk",
+				Columns:    []string{"table_name"},
+				PrimaryKey: true,
+			},
+		},
+		Virtual: tableStatsRows,
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// tableStatsRows returns a row per table, virtual tables excluded.
+func tableStatsRows(tx *Transaction, fn func(r row.Row) error) error {
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		info, err := tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return err
+		}
+		if info.Virtual != nil {
+			continue
+		}
+
+		stats, err := tableStorageStats(tx, name)
+		if err != nil {
+			return err
+		}
+
+		var indexesSize uint64
+		for _, idx := range stats.Indexes {
+			indexesSize += idx.DiskSize
+		}
+
+		err = fn(row.NewColumnBuffer().
+			Add("table_name", types.NewTextValue(name)).
+			Add("rows", types.NewBigintValue(stats.Rows)).
+			Add("avg_row_size", types.NewDoubleValue(stats.AvgRowSize)).
+			Add("disk_size", types.NewBigintValue(int64(stats.DiskSize))).
+			Add("compression_ratio", types.NewDoubleValue(stats.CompressionRatio)).
+			Add("tombstones", types.NewBigintValue(int64(stats.Tombstones))).
+			Add("indexes", types.NewIntegerValue(int32(len(stats.Indexes)))).
+			Add("indexes_disk_size", types.NewBigintValue(int64(indexesSize))))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var indexStatsTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName: IndexStatsTableName,
+		ReadOnly:  true,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "index_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "table_name", Type: types.TypeText},
+			&ColumnConstraint{Position: 2, Column: "entries", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 3, Column: "size", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 4, Column: "disk_size", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 5, Column: "compression_ratio", Type: types.TypeDouble},
+			&ColumnConstraint{Position: 6, Column: "tombstones", Type: types.TypeBigint},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       IndexStatsTableName + "_pk",
+				Columns:    []string{"index_name"},
+				PrimaryKey: true,
+			},
+		},
+		Virtual: indexStatsRows,
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// indexStatsRows returns a row per index.
+func indexStatsRows(tx *Transaction, fn func(r row.Row) error) error {
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationIndexType) {
+		info, err := tx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return err
+		}
+
+		idx, err := tx.Catalog.GetIndex(tx, name)
+		if err != nil {
+			return err
+		}
+
+		n, size, disk, err := treeStats(tx, idx.Tree)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read index %q", name)
+		}
+
+		err = fn(row.NewColumnBuffer().
+			Add("index_name", types.NewTextValue(name)).
+			Add("table_name", types.NewTextValue(info.Owner.TableName)).
+			Add("entries", types.NewBigintValue(n)).
+			Add("size", types.NewBigintValue(size)).
+			Add("disk_size", types.NewBigintValue(int64(disk.DiskSize))).
+			Add("compression_ratio", types.NewDoubleValue(compressionRatio(disk))).
+			Add("tombstones", types.NewBigintValue(int64(disk.Tombstones))))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var sequenceStatsTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName: SequenceStatsTableName,
+		ReadOnly:  true,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "sequence_name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "current_value", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 2, Column: "increment_by", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 3, Column: "min_value", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 4, Column: "max_value", Type: types.TypeBigint},
+			&ColumnConstraint{Position: 5, Column: "owner_table", Type: types.TypeText},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       SequenceStatsTableName + "_pk",
+				Columns:    []string{"sequence_name"},
+				PrimaryKey: true,
+			},
+		},
+		Virtual: sequenceStatsRows,
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// sequenceStatsRows returns a row per sequence. The current value
+// is NULL if the sequence was never used.
+func sequenceStatsRows(tx *Transaction, fn func(r row.Row) error) error {
+	for _, name := range tx.Catalog.ListSequences() {
+		seq, err := tx.Catalog.GetSequence(name)
+		if err != nil {
+			return err
+		}
+
+		cb := row.NewColumnBuffer().
+			Add("sequence_name", types.NewTextValue(name))
+		if cur := seq.Current(); cur != nil {
+			cb.Add("current_value", types.NewBigintValue(*cur))
+		} else {
+			cb.Add("current_value", types.NewNullValue())
+		}
+		cb.Add("increment_by", types.NewBigintValue(seq.Info.IncrementBy)).
+			Add("min_value", types.NewBigintValue(seq.Info.Min)).
+			Add("max_value", types.NewBigintValue(seq.Info.Max))
+		if owner := seq.Info.Owner.TableName; owner != "" {
+			cb.Add("owner_table", types.NewTextValue(owner))
+		} else {
+			cb.Add("owner_table", types.NewNullValue())
+		}
+
+		err = fn(cb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,9 +1,12 @@
 package database
 
 import (
+	"bytes"
 	"fmt"
+	"sync"
 
-	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
 	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/tree"
@@ -32,8 +35,8 @@ func (t *Table) Truncate() error {
 // If no primary key has been selected, a monotonic autoincremented integer key will be generated.
 // It returns the inserted object alongside its key.
 func (t *Table) Insert(r row.Row) (*tree.Key, Row, error) {
-	if t.Info.ReadOnly {
-		return nil, nil, errors.New("cannot write to read-only table")
+	if err := t.Info.CheckWritable(); err != nil {
+		return nil, nil, err
 	}
 
 	key, isRowid, err := t.generateKey(t.Info, r)
@@ -47,6 +50,7 @@ func (t *Table) Insert(r row.Row) (*tree.Key, Row, error) {
 	}
 
 	// insert into the table
+	enc = t.Info.Compression.compress(enc)
 	if !isRowid {
 		// if the key is not a rowid, make sure it doesn't exist
 		// by using Insert instead of Put
@@ -80,18 +84,30 @@ func (t *Table) encodeRow(r row.Row) (row.Row, []byte, error) {
 		return r, ed.encoded, nil
 	}
 
-	dst, err := t.Info.EncodeRow(t.Tx, nil, r)
+	// rows wrapping a row encoded for the table are stored as is
+	if ed, ok := RowIsEncoded(r, &t.Info.ColumnConstraints); ok {
+		return NewEncodedRow(&t.Info.ColumnConstraints, t.Tx.Keyring(), ed.encoded), ed.encoded, nil
+	}
+
+	// the row is encoded in a pooled buffer, then copied
+	// once its size is known
+	buf := encoding.GetBuffer()
+	defer encoding.PutBuffer(buf)
+
+	var err error
+	*buf, err = t.Info.EncodeRow(t.Tx, *buf, r)
 	if err != nil {
 		return nil, nil, err
 	}
+	dst := bytes.Clone(*buf)
 
-	return NewEncodedRow(&t.Info.ColumnConstraints, dst), dst, nil
+	return NewEncodedRow(&t.Info.ColumnConstraints, t.Tx.Keyring(), dst), dst, nil
 }
 
 // Delete a object by key.
 func (t *Table) Delete(key *tree.Key) error {
-	if t.Info.ReadOnly {
-		return errors.New("cannot write to read-only table")
+	if err := t.Info.CheckWritable(); err != nil {
+		return err
 	}
 
 	err := t.Tree.Delete(key)
@@ -105,8 +121,8 @@ func (t *Table) Delete(key *tree.Key) error {
 // Replace a row by key.
 // An error is returned if the key doesn't exist.
 func (t *Table) Replace(key *tree.Key, r row.Row) (Row, error) {
-	if t.Info.ReadOnly {
-		return nil, errors.New("cannot write to read-only table")
+	if err := t.Info.CheckWritable(); err != nil {
+		return nil, err
 	}
 
 	// make sure key exists
@@ -123,8 +139,8 @@ func (t *Table) Replace(key *tree.Key, r row.Row) (Row, error) {
 
 // Put a row by key. If the key doesn't exist, it is created.
 func (t *Table) Put(key *tree.Key, r row.Row) (Row, error) {
-	if t.Info.ReadOnly {
-		return nil, errors.New("cannot write to read-only table")
+	if err := t.Info.CheckWritable(); err != nil {
+		return nil, err
 	}
 
 	r, enc, err := t.encodeRow(r)
@@ -133,7 +149,7 @@ func (t *Table) Put(key *tree.Key, r row.Row) (Row, error) {
 	}
 
 	// replace old row with new row
-	err = t.Tree.Put(key, enc)
+	err = t.Tree.Put(key, t.Info.Compression.compress(enc))
 	return &BasicRow{
 		tableName: t.Info.TableName,
 		Row:       r,
@@ -142,6 +158,10 @@ func (t *Table) Put(key *tree.Key, r row.Row) (Row, error) {
 }
 
 func (t *Table) IterateOnRange(rng *Range, reverse bool, fn func(key *tree.Key, r Row) error) error {
+	if t.Info.Virtual != nil {
+		return t.iterateVirtual(rng, reverse, fn)
+	}
+
 	var columns []string
 
 	pk := t.Info.PrimaryKey
@@ -161,6 +181,7 @@ func (t *Table) IterateOnRange(rng *Range, reverse bool, fn func(key *tree.Key,
 
 	e := EncodedRow{
 		columnConstraints: &t.Info.ColumnConstraints,
+		keys:              t.Tx.Keyring(),
 	}
 	row := BasicRow{
 		tableName: t.Info.TableName,
@@ -168,12 +189,245 @@ func (t *Table) IterateOnRange(rng *Range, reverse bool, fn func(key *tree.Key,
 	}
 
 	return t.Tree.IterateOnRange(r, reverse, func(k *tree.Key, enc []byte) error {
+		enc, err := t.Info.Compression.decompress(enc)
+		if err != nil {
+			if t.Tx.SkipCorruptRows {
+				return nil
+			}
+			return withRowLocation(err, t.Info.TableName, k)
+		}
+		if t.Tx.SkipCorruptRows && validateStoredRow(t.Info, enc) != nil {
+			return nil
+		}
+
 		row.key = k
 		e.encoded = enc
 		return fn(k, &row)
 	})
 }
 
+// parallelScanBatchSize is the number of rows sent at once
+// by the goroutines of a parallel scan.
+const parallelScanBatchSize = 128
+
+// a scannedRow is a row read by a goroutine of a parallel scan.
+// Its key and value are copied since the buffers of the
+// iterator are reused.
+type scannedRow struct {
+	key []byte
+	enc []byte
+}
+
+// a scannedBatch is a set of rows read by a goroutine of a parallel scan,
+// or the error that stopped it.
+type scannedBatch struct {
+	rows []scannedRow
+	err  error
+}
+
+// IterateOnRangeParallel iterates over the rows of the range, in key order,
+// like IterateOnRange. The range is split into at most workers partitions,
+// each read by its own goroutine, while fn is called from the calling
+// goroutine, one partition after the other.
+// Only the tables of read-only transactions are read in parallel,
+// the others are read by the calling goroutine.
+func (t *Table) IterateOnRangeParallel(rng *Range, workers int, fn func(key *tree.Key, r Row) error) error {
+	if workers <= 1 || t.Tx.Writable || t.Info.Virtual != nil {
+		return t.IterateOnRange(rng, false, fn)
+	}
+
+	var columns []string
+	if pk := t.Info.PrimaryKey; pk != nil {
+		columns = pk.Columns
+	}
+
+	var r *tree.Range
+	var err error
+	if rng != nil {
+		r, err = rng.ToTreeRange(&t.Info.ColumnConstraints, columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	parts, err := t.Tree.Partition(r, workers)
+	if err != nil {
+		return err
+	}
+	if len(parts) <= 1 {
+		return t.IterateOnRange(rng, false, fn)
+	}
+
+	// closed when fn returns an error or when all the rows are read,
+	// to stop the goroutines that are still running
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	batches := make([]chan scannedBatch, len(parts))
+	for i := range parts {
+		batches[i] = make(chan scannedBatch, 2)
+
+		wg.Add(1)
+		go func(p tree.Partition, out chan<- scannedBatch) {
+			defer wg.Done()
+			defer close(out)
+
+			send := func(b scannedBatch) bool {
+				select {
+				case out <- b:
+					return true
+				case <-done:
+					return false
+				}
+			}
+
+			var b scannedBatch
+			err := t.Tree.IterateOnPartition(p, false, func(k *tree.Key, enc []byte) error {
+				// rows are decompressed by the goroutines too
+				dec, err := t.Info.Compression.decompress(enc)
+				if err != nil {
+					if t.Tx.SkipCorruptRows {
+						return nil
+					}
+					return withRowLocation(err, t.Info.TableName, tree.NewEncodedKey(bytes.Clone(k.Encoded)))
+				}
+				if t.Tx.SkipCorruptRows && validateStoredRow(t.Info, dec) != nil {
+					return nil
+				}
+
+				b.rows = append(b.rows, scannedRow{
+					key: bytes.Clone(k.Encoded),
+					enc: bytes.Clone(dec),
+				})
+				if len(b.rows) < parallelScanBatchSize {
+					return nil
+				}
+				if !send(b) {
+					return errParallelScanStopped
+				}
+				b = scannedBatch{}
+				return nil
+			})
+			if errors.Is(err, errParallelScanStopped) {
+				return
+			}
+			b.err = err
+			if len(b.rows) > 0 || b.err != nil {
+				send(b)
+			}
+		}(parts[i], batches[i])
+	}
+
+	e := EncodedRow{
+		columnConstraints: &t.Info.ColumnConstraints,
+		keys:              t.Tx.Keyring(),
+	}
+	row := BasicRow{
+		tableName: t.Info.TableName,
+		Row:       &e,
+	}
+
+	for _, ch := range batches {
+		for b := range ch {
+			for _, sr := range b.rows {
+				k := tree.NewEncodedKey(sr.key)
+				row.key = k
+				e.encoded = sr.enc
+				if err := fn(k, &row); err != nil {
+					return err
+				}
+			}
+			if b.err != nil {
+				return b.err
+			}
+		}
+	}
+
+	return nil
+}
+
+// errParallelScanStopped stops the goroutines of a parallel scan
+// whose rows are not needed anymore.
+var errParallelScanStopped = errors.New("parallel scan stopped")
+
+// CheckRow returns a CorruptRowError if the row with the given key
+// cannot be decoded.
+func (t *Table) CheckRow(key *tree.Key) error {
+	enc, err := t.Tree.Get(key)
+	if err != nil {
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return errs.NewNotFoundError(key.String())
+		}
+		return fmt.Errorf("failed to fetch row %q: %w", key, err)
+	}
+
+	enc, err = t.Info.Compression.decompress(enc)
+	if err == nil {
+		err = validateStoredRow(t.Info, enc)
+	}
+
+	return withRowLocation(err, t.Info.TableName, key)
+}
+
+// VirtualTables returns the information of the read-only tables
+// whose rows are computed when they are read.
+// They are added to the catalog when it is loaded.
+func VirtualTables() []TableInfo {
+	return []TableInfo{
+		*tableStatsTableInfo.Clone(),
+		*indexStatsTableInfo.Clone(),
+		*sequenceStatsTableInfo.Clone(),
+		*informationSchemaTablesTableInfo.Clone(),
+		*informationSchemaColumnsTableInfo.Clone(),
+		*informationSchemaKeyColumnUsageTableInfo.Clone(),
+	}
+}
+
+// iterateVirtual computes the rows of a virtual table and sorts them
+// in a transient tree, which is then read like a regular table.
+func (t *Table) iterateVirtual(rng *Range, reverse bool, fn func(key *tree.Key, r Row) error) error {
+	temp, cleanup, err := tree.NewTransient(t.Tx.NewTransientSession(), t.Tx.Catalog.GetFreeTransientNamespace(), t.Info.PrimaryKeySortOrder())
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	info := *t.Info
+	info.Virtual = nil
+
+	// the buffer is reused for every row since
+	// the transient tree copies the values
+	var enc []byte
+	err = t.Info.Virtual(t.Tx, func(r row.Row) error {
+		var err error
+		enc, err = info.EncodeRow(t.Tx, enc[:0], r)
+		if err != nil {
+			return err
+		}
+
+		key, _, err := t.generateKey(&info, NewEncodedRow(&info.ColumnConstraints, t.Tx.Keyring(), enc))
+		if err != nil {
+			return err
+		}
+
+		return temp.Put(key, enc)
+	})
+	if err != nil {
+		return err
+	}
+
+	vt := Table{
+		Tx:   t.Tx,
+		Tree: temp,
+		Info: &info,
+	}
+	return vt.IterateOnRange(rng, reverse, fn)
+}
+
 // GetRow returns one row by key.
 func (t *Table) GetRow(key *tree.Key) (Row, error) {
 	enc, err := t.Tree.Get(key)
@@ -184,9 +438,14 @@ func (t *Table) GetRow(key *tree.Key) (Row, error) {
 		return nil, fmt.Errorf("failed to fetch row %q: %w", key, err)
 	}
 
+	enc, err = t.Info.Compression.decompress(enc)
+	if err != nil {
+		return nil, withRowLocation(err, t.Info.TableName, key)
+	}
+
 	return &BasicRow{
 		tableName: t.Info.TableName,
-		Row:       NewEncodedRow(&t.Info.ColumnConstraints, enc),
+		Row:       NewEncodedRow(&t.Info.ColumnConstraints, t.Tx.Keyring(), enc),
 		key:       key,
 	}, nil
 }
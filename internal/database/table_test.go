@@ -2,8 +2,10 @@ package database_test
 
 import (
 	"fmt"
+	"sort"
 	"testing"
 
+	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/database/catalogstore"
 	errs "github.com/chaisql/chai/internal/errors"
@@ -380,3 +382,101 @@ func BenchmarkTableScan(b *testing.B) {
 		})
 	}
 }
+
+func TestParallelWorkers(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{ParallelWorkers: 4})
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.Exec("CREATE TABLE test(a INT PRIMARY KEY, b INT)"))
+	require.NoError(t, conn.Exec("CREATE TABLE norowid(a INT, b TEXT)"))
+	err = conn.Update(func(tx *chai.Tx) error {
+		for i := 0; i < 2000; i++ {
+			err := tx.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i*7, i%10)
+			if err != nil {
+				return err
+			}
+			err = tx.Exec("INSERT INTO norowid (a, b) VALUES (?, ?)", i, "x")
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	query := func(t *testing.T, q string) []int {
+		t.Helper()
+
+		res, err := conn.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var got []int
+		err = res.Iterate(func(r *chai.Row) error {
+			var a int
+			err := r.Scan(&a)
+			got = append(got, a)
+			return err
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	for _, setting := range []string{"DEFAULT", "0", "16"} {
+		t.Run(setting, func(t *testing.T) {
+			require.NoError(t, conn.Exec("SET parallel_workers = "+setting))
+
+			got := query(t, "SELECT a FROM test")
+			require.Len(t, got, 2000)
+			require.True(t, sort.IntsAreSorted(got))
+
+			got = query(t, "SELECT a FROM test WHERE a >= 700 AND a < 1400 AND b = 3")
+			require.Equal(t, []int{721, 791, 861, 931, 1001, 1071, 1141, 1211, 1281, 1351}, got)
+
+			got = query(t, "SELECT a FROM test LIMIT 3 OFFSET 1000")
+			require.Equal(t, []int{7000, 7007, 7014}, got)
+
+			got = query(t, "SELECT a FROM norowid")
+			require.Len(t, got, 2000)
+			require.True(t, sort.IntsAreSorted(got))
+
+			got = query(t, "SELECT COUNT(*) FROM test")
+			require.Equal(t, []int{2000}, got)
+		})
+	}
+
+	t.Run("Writable transaction", func(t *testing.T) {
+		require.NoError(t, conn.Exec("SET parallel_workers = 8"))
+		err := conn.Update(func(tx *chai.Tx) error {
+			return tx.Exec("UPDATE test SET b = b + 1 WHERE a < 70")
+		})
+		require.NoError(t, err)
+
+		got := query(t, "SELECT b FROM test WHERE a < 70")
+		require.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, got)
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		require.NoError(t, conn.Exec("SET parallel_workers = 8"))
+		res, err := conn.Query("SELECT a FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		stop := errors.New("stop")
+		var n int
+		err = res.Iterate(func(r *chai.Row) error {
+			n++
+			if n == 500 {
+				return stop
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, stop)
+		require.Equal(t, 500, n)
+	})
+}
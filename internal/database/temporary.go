@@ -0,0 +1,116 @@
+package database
+
+import (
+	"sort"
+
+	"github.com/chaisql/chai/internal/tree"
+)
+
+// applyOnCommitActions deletes the rows of the temporary tables created
+// with ON COMMIT DELETE ROWS and drops the ones created with ON COMMIT DROP.
+func (tx *Transaction) applyOnCommitActions() error {
+	temp := tx.Catalog.Cache.temp
+	if temp == nil || len(temp.tables) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(temp.tables))
+	for name := range temp.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		info := temp.tables[name].(*TableInfoRelation).Info
+
+		var err error
+		switch info.OnCommit {
+		case OnCommitDeleteRows:
+			err = tx.truncateTemporaryTable(info)
+		case OnCommitDrop:
+			err = tx.dropTemporaryTable(name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// truncateTemporaryTable deletes the rows of the table and of its indexes.
+func (tx *Transaction) truncateTemporaryTable(info *TableInfo) error {
+	err := tree.New(tx.Session, info.StoreNamespace, info.PrimaryKeySortOrder()).Truncate()
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range tx.Catalog.Cache.GetTableIndexes(info.TableName) {
+		err = tree.New(tx.Session, idx.StoreNamespace, idx.KeySortOrder).Truncate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropTemporaryTable drops the table and the sequences it owns.
+func (tx *Transaction) dropTemporaryTable(name string) error {
+	cw := tx.CatalogWriter()
+
+	err := cw.DropTable(tx, name)
+	if err != nil {
+		return err
+	}
+
+	for _, seqName := range cw.ListSequences() {
+		seq, err := cw.GetSequence(seqName)
+		if err != nil {
+			return err
+		}
+
+		if !seq.Info.Temporary || seq.Info.Owner.TableName != name {
+			continue
+		}
+
+		err = cw.DropSequence(tx, seqName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropTemporaryTables deletes the rows of the temporary tables of the connection,
+// whose relations are discarded with the connection.
+func (c *Connection) dropTemporaryTables() error {
+	temp := c.temp
+	c.temp = nil
+	if temp == nil || len(temp.tables) == 0 {
+		return nil
+	}
+
+	s := c.db.Engine.NewOptimisticSession()
+
+	for _, r := range temp.tables {
+		info := r.(*TableInfoRelation).Info
+		err := tree.New(s, info.StoreNamespace, info.PrimaryKeySortOrder()).Truncate()
+		if err != nil {
+			_ = s.Close()
+			return err
+		}
+	}
+
+	for _, r := range temp.indexes {
+		info := r.(*IndexInfoRelation).Info
+		err := tree.New(s, info.StoreNamespace, info.KeySortOrder).Truncate()
+		if err != nil {
+			_ = s.Close()
+			return err
+		}
+	}
+
+	return s.Commit()
+}
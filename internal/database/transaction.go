@@ -1,10 +1,13 @@
 package database
 
 import (
-	"sync"
+	"sort"
 	"time"
 
-	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/engine"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -20,24 +23,107 @@ type Transaction struct {
 	// The timestamp must use the local timezone.
 	TxStart time.Time
 
-	Session   engine.Session
-	Engine    engine.Engine
-	ID        uint64
-	Writable  bool
-	WriteTxMu *sync.Mutex
+	Session  engine.Session
+	Engine   engine.Engine
+	ID       uint64
+	Writable bool
 	// these functions are run after a successful rollback.
 	OnRollbackHooks []func()
 	// these functions are run after a successful commit.
 	OnCommitHooks []func()
 
+	// name of the user running the transaction, whose privileges
+	// are checked by the statements. Empty means every privilege.
+	User string
+
+	// schemas in which the relations referred to without schema
+	// are looked up, in order. Empty means the default schema.
+	SearchPath []string
+
+	// compatibility mode of the connection that started the transaction.
+	CompatMode CompatMode
+
+	// reject comparisons between values of incompatible types
+	// when the queries are planned, and lossy conversions of the
+	// values stored in columns.
+	StrictTyping bool
+
+	// ignore the rows that cannot be decoded when reading tables,
+	// instead of returning a CorruptRowError.
+	SkipCorruptRows bool
+
+	// memory used by each temporary tree before spilling to disk,
+	// in bytes. Zero means the default of the engine.
+	WorkMem int
+
+	// number of goroutines reading each table scanned by the queries
+	// of read-only transactions. Zero or one reads the tables sequentially.
+	ParallelWorkers int
+
+	// defines when the commit is synchronized to disk,
+	// if the session supports it.
+	Synchronous engine.Synchronous
+
+	// limits the data spilled to disk by the temporary trees,
+	// nil if unlimited.
+	transientQuota *transientQuota
+
+	// entries of unique indexes whose unicity is checked
+	// when the transaction commits, by index name.
+	deferredChecks map[string]map[string]struct{}
+
 	Catalog       *Catalog
 	catalogWriter *CatalogWriter
+	// catalog of the database when the transaction started.
+	// The schema changes committed since conflict with the transaction
+	// if they changed a relation it used or changed.
+	baseCatalog *Catalog
+
+	// read-only transactions of the databases attached
+	// to the connection, by name.
+	attached map[string]*Transaction
+
+	// set once the transaction is committed or rolled back.
+	done bool
 }
 
 func (tx *Transaction) Connection() *Connection {
 	return tx.conn
 }
 
+// NewTransientSession returns a session for the temporary trees
+// of the transaction, which spill to disk once they use more
+// than WorkMem bytes of memory. If the database has a TransientDiskQuota,
+// writes fail with ErrDiskQuotaExceeded once the sessions of the
+// transaction spilled more data than the quota.
+func (tx *Transaction) NewTransientSession() engine.Session {
+	s := tx.Engine.NewTransientSession(tx.WorkMem)
+	if tx.transientQuota == nil {
+		return s
+	}
+
+	memory := int64(tx.WorkMem)
+	if memory <= 0 {
+		memory = defaultTransientMemory
+	}
+
+	return &quotaSession{
+		Session: s,
+		quota:   tx.transientQuota,
+		memory:  memory,
+	}
+}
+
+// Keyring returns the keys used to encrypt and decrypt
+// the encrypted columns.
+func (tx *Transaction) Keyring() *Keyring {
+	if tx == nil || tx.db == nil {
+		return nil
+	}
+
+	return tx.db.keys
+}
+
 // Rollback the transaction. Can be used safely after commit.
 func (tx *Transaction) Rollback() error {
 	err := tx.Session.Close()
@@ -45,15 +131,11 @@ func (tx *Transaction) Rollback() error {
 		return err
 	}
 
-	if tx.Writable {
-		err = tx.Engine.Rollback()
-		if err != nil {
-			return err
+	if !tx.done {
+		tx.done = true
+		if tx.db != nil {
+			tx.db.txMetrics.rolledBack.Add(1)
 		}
-
-		defer func() {
-			tx.WriteTxMu.Unlock()
-		}()
 	}
 
 	for i := len(tx.OnRollbackHooks) - 1; i >= 0; i-- {
@@ -65,46 +147,221 @@ func (tx *Transaction) Rollback() error {
 
 // Commit the transaction. Calling this method on read-only transactions
 // will return an error.
+// If the transaction conflicts with another transaction that committed first,
+// it is rolled back and engine.ErrTxConflict is returned. It can then be retried.
 func (tx *Transaction) Commit() error {
 	if !tx.Writable {
 		return errors.New("cannot commit read-only transaction")
 	}
 
+	err := tx.checkDeferredConstraints()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.applyOnCommitActions()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// wait for the sessions that prevent this one from committing
+	// before preventing the other transactions from being created.
+	if s, ok := tx.Session.(engine.CommitLockSession); ok {
+		s.LockCommit()
+	}
+
 	// lock the transaction mutex to prevent any other transaction
 	// from being created while the commit is in progress.
 	tx.db.txmu.Lock()
 	defer tx.db.txmu.Unlock()
 
-	err := tx.Session.Commit()
+	// the changes of this transaction might not be valid anymore
+	// if the schema of the relations it used changed since it started.
+	current := tx.db.Catalog()
+	if current != tx.baseCatalog && tx.conflictsWith(current.Cache.changesSince(tx.baseCatalog.Cache)) {
+		tx.db.txMetrics.conflicts.Add(1)
+		_ = tx.Rollback()
+		return errors.WithStack(engine.ErrTxConflict)
+	}
+
+	if s, ok := tx.Session.(engine.SyncSession); ok {
+		s.SetSynchronous(tx.Synchronous)
+	}
+
+	err = tx.Session.Commit()
 	if err != nil {
+		if errors.Is(err, engine.ErrTxConflict) {
+			tx.db.txMetrics.conflicts.Add(1)
+		}
+		_ = tx.Rollback()
 		return err
 	}
 
-	_ = tx.Session.Close()
-
-	defer func() {
-		tx.WriteTxMu.Unlock()
-	}()
+	tx.done = true
+	tx.db.txMetrics.committed.Add(1)
 
 	for i := len(tx.OnCommitHooks) - 1; i >= 0; i-- {
 		tx.OnCommitHooks[i]()
 	}
 
-	// if the catalog has been modified, update the database catalog
-	if tx.catalogWriter != nil {
-		tx.db.SetCatalog(tx.Catalog)
+	// if the catalog has been modified, apply the changes to the database catalog,
+	// which contains the schema changes committed since the transaction started.
+	// the temporary relations and the attached databases are kept by the connection.
+	if tx.catalogWriter != nil && tx.Catalog.Cache.version != tx.baseCatalog.Cache.version {
+		tx.db.SetCatalog(current.withChanges(tx.Catalog.Cache.changesSince(tx.baseCatalog.Cache)))
+	}
+
+	// keep the new version of the database for point-in-time reads.
+	// timestamps are stored with a microsecond precision, truncating the
+	// time of the version ensures that it is visible when reading as of
+	// any stored timestamp taken after the commit.
+	if tx.db.history.retention > 0 {
+		tx.db.history.record(time.Now().Truncate(time.Microsecond), tx.db.Catalog(), tx.Engine.NewVersion())
+	}
+
+	return nil
+}
+
+// RecordUniqueCheck records that the transaction checked that the values vs
+// were not present in the given unique index. The commit conflicts with the
+// transactions which committed after this one started and which checked the
+// same values, otherwise both could insert them, with different keys.
+func (tx *Transaction) RecordUniqueCheck(idx *Index, vs []types.Value) error {
+	prefix, err := tree.NewKey(vs...).Encode(idx.Tree.Namespace, idx.Tree.Order)
+	if err != nil {
+		return err
+	}
+
+	tx.recordUniquePrefix(prefix)
+	return nil
+}
+
+func (tx *Transaction) recordUniquePrefix(prefix []byte) {
+	if s, ok := tx.Session.(engine.ConflictSession); ok {
+		s.AddConflictKey(prefix)
+	}
+}
+
+// DeferUniqueCheck records that the values vs must be associated with
+// at most one key of the given unique index when the transaction commits.
+// As with RecordUniqueCheck, the commit conflicts with the transactions
+// which committed the same values after this one started, so that the check
+// made before the commit can read the snapshot of the transaction.
+func (tx *Transaction) DeferUniqueCheck(indexName string, idx *Index, vs []types.Value) error {
+	prefix, err := tree.NewKey(vs...).Encode(idx.Tree.Namespace, idx.Tree.Order)
+	if err != nil {
+		return err
+	}
+	tx.recordUniquePrefix(prefix)
+
+	if tx.deferredChecks == nil {
+		tx.deferredChecks = make(map[string]map[string]struct{})
+	}
+	if tx.deferredChecks[indexName] == nil {
+		tx.deferredChecks[indexName] = make(map[string]struct{})
 	}
 
+	tx.deferredChecks[indexName][string(prefix)] = struct{}{}
 	return nil
 }
 
+// checkDeferredConstraints ensures the entries recorded by DeferUniqueCheck
+// are still unique.
+func (tx *Transaction) checkDeferredConstraints() error {
+	indexNames := make([]string, 0, len(tx.deferredChecks))
+	for name := range tx.deferredChecks {
+		indexNames = append(indexNames, name)
+	}
+	sort.Strings(indexNames)
+
+	for _, name := range indexNames {
+		info, err := tx.Catalog.GetIndexInfo(name)
+		if errs.IsNotFoundError(err) {
+			// the index was dropped by the transaction
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		idx, err := tx.Catalog.GetIndex(tx, name)
+		if err != nil {
+			return err
+		}
+
+		ti, err := tx.Catalog.GetTableInfo(info.Owner.TableName)
+		if err != nil {
+			return err
+		}
+
+		prefixes := make([]string, 0, len(tx.deferredChecks[name]))
+		for prefix := range tx.deferredChecks[name] {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+
+		for _, prefix := range prefixes {
+			key, err := idx.duplicate([]byte(prefix))
+			if err != nil {
+				return err
+			}
+			if key != nil {
+				return NewUniqueViolationError(ti, info, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// conflictsWith returns whether the schema changes committed since the
+// transaction started changed a relation used or changed by the transaction,
+// or an index of a table it used or changed.
+func (tx *Transaction) conflictsWith(changes []relationChange) bool {
+	var changed map[relationKey]struct{}
+	if tx.catalogWriter != nil {
+		changed = make(map[relationKey]struct{})
+		for _, ch := range tx.Catalog.Cache.changesSince(tx.baseCatalog.Cache) {
+			changed[relationKey{ch.tp, ch.name}] = struct{}{}
+		}
+	}
+
+	uses := func(tp, name string) bool {
+		_, ok := changed[relationKey{tp, name}]
+		return ok || tx.Catalog.used.has(tp, name)
+	}
+
+	for _, ch := range changes {
+		if uses(ch.tp, ch.name) {
+			return true
+		}
+
+		if ch.tp != RelationIndexType {
+			continue
+		}
+		for _, r := range []Relation{ch.old, ch.new} {
+			if r != nil && uses(RelationTableType, r.(*IndexInfoRelation).Info.Owner.TableName) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func (tx *Transaction) CatalogWriter() *CatalogWriter {
 	if !tx.Writable {
 		panic("cannot get catalog writer from read-only transaction")
 	}
 
 	if tx.catalogWriter == nil {
-		tx.Catalog = tx.db.Catalog().Clone()
+		temp, attached, used := tx.Catalog.Cache.temp, tx.Catalog.Cache.attached, tx.Catalog.used
+		tx.Catalog = tx.baseCatalog.Clone()
+		tx.Catalog.Cache.temp = temp
+		tx.Catalog.Cache.attached = attached
+		tx.Catalog.used = used
 		// clone the catalog so that it can be modified without affecting the original one.
 		tx.catalogWriter = NewCatalogWriter(tx.Catalog)
 	}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"sync/atomic"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrDiskQuotaExceeded is returned when the temporary data
+// spilled to disk by a query exceeds its quota.
+var ErrDiskQuotaExceeded = errors.New("temporary data exceeds the disk quota")
+
+// default memory of the transient sessions of the kv engine.
+const defaultTransientMemory = 1 << 19
+
+// transientQuota limits the data spilled to disk by
+// the temporary trees of a transaction.
+type transientQuota struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// quotaSession counts the data written to a transient session
+// beyond its memory, which the engine spills to disk,
+// against the quota of the transaction.
+type quotaSession struct {
+	engine.Session
+
+	quota  *transientQuota
+	memory int64
+	// bytes written to the session
+	written int64
+	// bytes counted against the quota
+	charged int64
+}
+
+func (s *quotaSession) Put(k, v []byte) error {
+	written := s.written + int64(len(k)+len(v))
+	if extra := written - s.memory - s.charged; extra > 0 {
+		if s.quota.used.Add(extra) > s.quota.limit {
+			s.quota.used.Add(-extra)
+			return errors.WithStack(ErrDiskQuotaExceeded)
+		}
+		s.charged += extra
+	}
+	s.written = written
+
+	return s.Session.Put(k, v)
+}
+
+func (s *quotaSession) Close() error {
+	s.quota.used.Add(-s.charged)
+	s.charged = 0
+
+	return s.Session.Close()
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"time"
+
+	"github.com/chaisql/chai/internal/tree"
+)
+
+// DefaultTTLInterval is the default amount of time between two
+// runs of the jobs deleting expired rows and applying retention policies.
+const DefaultTTLInterval = time.Minute
+
+// DeleteExpiredRows deletes the expired rows of every table with a TTL column,
+// along with their index entries, in a single transaction.
+// It returns the number of deleted rows.
+func (db *Database) DeleteExpiredRows() (int, error) {
+	if !hasTTLTables(db.Catalog()) {
+		return 0, nil
+	}
+
+	tx, err := db.Begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := tx.TxStart.UTC()
+
+	var n int
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		info, err := tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return 0, err
+		}
+		if info.TTLColumn == "" {
+			continue
+		}
+
+		deleted, err := deleteExpiredRows(tx, info, now)
+		if err != nil {
+			return 0, err
+		}
+		n += deleted
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func hasTTLTables(c *Catalog) bool {
+	for _, name := range c.Cache.ListObjects(RelationTableType) {
+		info, err := c.GetTableInfo(name)
+		if err == nil && info.TTLColumn != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deleteExpiredRows deletes the rows of the table that are expired at the given time.
+func deleteExpiredRows(tx *Transaction, info *TableInfo, now time.Time) (int, error) {
+	t, err := tx.Catalog.GetTable(tx, info.TableName)
+	if err != nil {
+		return 0, err
+	}
+
+	infos, idxs, err := tableIndexes(tx, info.TableName)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	err = t.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+		expired, err := info.IsExpired(r, now)
+		if err != nil || !expired {
+			return err
+		}
+
+		n++
+		return deleteRow(t, infos, idxs, key, r)
+	})
+
+	return n, err
+}
+
+// tableIndexes returns the indexes of the table, along with their information.
+func tableIndexes(tx *Transaction, tableName string) ([]*IndexInfo, []*Index, error) {
+	infos := tx.Catalog.Cache.GetTableIndexes(tableName)
+	idxs := make([]*Index, 0, len(infos))
+	for _, ii := range infos {
+		idx, err := tx.Catalog.GetIndex(tx, ii.IndexName)
+		if err != nil {
+			return nil, nil, err
+		}
+		idxs = append(idxs, idx)
+	}
+
+	return infos, idxs, nil
+}
+
+// deleteRow deletes the row r stored under key, along with its index entries.
+func deleteRow(t *Table, infos []*IndexInfo, idxs []*Index, key *tree.Key, r Row) error {
+	if len(idxs) > 0 {
+		enc, err := t.Info.EncodeKey(key)
+		if err != nil {
+			return err
+		}
+
+		for i, idx := range idxs {
+			vs, err := t.Info.IndexValues(t.Tx.Keyring(), infos[i].Columns, r)
+			if err != nil {
+				return err
+			}
+
+			err = idx.Delete(vs, enc)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return t.Delete(key)
+}
@@ -0,0 +1,567 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrAuthenticationFailed is returned when a user cannot be authenticated.
+// It doesn't tell whether the user doesn't exist or the password is wrong.
+var ErrAuthenticationFailed = errors.New("authentication failed")
+
+// A Privilege is a set of operations a user is allowed to run on a table.
+type Privilege uint8
+
+// Privileges
+const (
+	PrivilegeSelect Privilege = 1 << iota
+	PrivilegeInsert
+	PrivilegeUpdate
+	PrivilegeDelete
+	// PrivilegeDDL allows altering, dropping and indexing a table.
+	// Granted on all tables, it also allows creating and dropping tables,
+	// sequences, schemas and functions.
+	PrivilegeDDL
+
+	AllPrivileges = PrivilegeSelect | PrivilegeInsert | PrivilegeUpdate | PrivilegeDelete | PrivilegeDDL
+)
+
+var privilegeNames = []struct {
+	p    Privilege
+	name string
+}{
+	{PrivilegeSelect, "SELECT"},
+	{PrivilegeInsert, "INSERT"},
+	{PrivilegeUpdate, "UPDATE"},
+	{PrivilegeDelete, "DELETE"},
+	{PrivilegeDDL, "DDL"},
+}
+
+// ParsePrivilege returns the privilege with the given name.
+func ParsePrivilege(name string) (Privilege, bool) {
+	for _, pn := range privilegeNames {
+		if strings.EqualFold(pn.name, name) {
+			return pn.p, true
+		}
+	}
+
+	return 0, false
+}
+
+// String returns the names of the privileges, separated by commas.
+func (p Privilege) String() string {
+	if p == AllPrivileges {
+		return "ALL"
+	}
+
+	var names []string
+	for _, pn := range privilegeNames {
+		if p&pn.p != 0 {
+			names = append(names, pn.name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// AllTables is the name under which the privileges granted
+// on all the tables are stored, including the tables created later.
+const AllTables = "*"
+
+// UserInfo holds the configuration of a user.
+// Users are only used by the connections created with Database.ConnectAs,
+// the other connections have every privilege.
+type UserInfo struct {
+	Name string
+	// PasswordHash is the hash of the password, computed by HashPassword.
+	// A user without password cannot be authenticated.
+	PasswordHash string
+	// A Superuser has every privilege and can manage the users.
+	Superuser bool
+	// Privileges granted on the tables, by table name,
+	// or AllTables for the privileges granted on every table.
+	Privileges map[string]Privilege
+}
+
+// HasPrivilege returns true if the user was granted the privileges on the table.
+// Everyone can read the system tables, but only the superusers can write them.
+func (u *UserInfo) HasPrivilege(p Privilege, tableName string) bool {
+	if u.Superuser {
+		return true
+	}
+
+	if tableName != AllTables && IsSystemTable(tableName) {
+		return p == PrivilegeSelect
+	}
+
+	granted := u.Privileges[AllTables]
+	if tableName != AllTables {
+		granted |= u.Privileges[tableName]
+	}
+
+	return granted&p == p
+}
+
+// CheckPassword returns true if the password matches the hash of the password of the user.
+func (u *UserInfo) CheckPassword(password string) bool {
+	if u.PasswordHash == "" {
+		return false
+	}
+
+	iter, salt, key, ok := splitPasswordHash(u.PasswordHash)
+	if !ok {
+		return false
+	}
+
+	return hmac.Equal(key, pbkdf2SHA256([]byte(password), salt, iter, len(key)))
+}
+
+// String returns a SQL representation: the CREATE USER statement
+// followed by a GRANT statement for each table.
+func (u *UserInfo) String() string {
+	var s strings.Builder
+
+	name := stringutil.NormalizeIdentifier(u.Name, '`')
+	s.WriteString("CREATE USER ")
+	s.WriteString(name)
+	if u.PasswordHash != "" {
+		s.WriteString(" WITH PASSWORD ")
+		s.WriteString(types.NewTextValue(u.PasswordHash).String())
+	}
+	if u.Superuser {
+		s.WriteString(" SUPERUSER")
+	}
+
+	tables := make([]string, 0, len(u.Privileges))
+	for t := range u.Privileges {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+
+	for _, t := range tables {
+		fmt.Fprintf(&s, "; GRANT %s ON ", u.Privileges[t])
+		if t == AllTables {
+			s.WriteString("ALL TABLES")
+		} else {
+			s.WriteString(stringutil.NormalizeIdentifier(t, '`'))
+		}
+		s.WriteString(" TO ")
+		s.WriteString(name)
+	}
+
+	return s.String()
+}
+
+func (u *UserInfo) Clone() *UserInfo {
+	clone := *u
+	clone.Privileges = make(map[string]Privilege, len(u.Privileges))
+	for t, p := range u.Privileges {
+		clone.Privileges[t] = p
+	}
+
+	return &clone
+}
+
+type UserInfoRelation struct {
+	Info *UserInfo
+}
+
+func (r *UserInfoRelation) Type() string {
+	return RelationUserType
+}
+
+func (r *UserInfoRelation) Name() string {
+	return r.Info.Name
+}
+
+func (r *UserInfoRelation) SetName(name string) {
+	r.Info.Name = name
+}
+
+func (r *UserInfoRelation) GenerateBaseName() string {
+	return r.Info.Name
+}
+
+func (r *UserInfoRelation) Clone() Relation {
+	return &UserInfoRelation{Info: r.Info.Clone()}
+}
+
+// number of iterations of PBKDF2 used to hash the passwords.
+const passwordIterations = 4096
+
+const passwordHashPrefix = "pbkdf2-sha256$"
+
+// HashPassword returns the hash of the password to be stored in UserInfo.PasswordHash,
+// computed with PBKDF2-HMAC-SHA256 and a random salt.
+// A password that is already hashed is returned as is, so that
+// users can be created again from their SQL representation.
+func HashPassword(password string) (string, error) {
+	if IsPasswordHash(password) {
+		return password, nil
+	}
+
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := pbkdf2SHA256([]byte(password), salt, passwordIterations, sha256.Size)
+
+	return passwordHashPrefix + strconv.Itoa(passwordIterations) + "$" +
+		base64.RawStdEncoding.EncodeToString(salt) + "$" +
+		base64.RawStdEncoding.EncodeToString(key), nil
+}
+
+// IsPasswordHash returns true if s was returned by HashPassword.
+func IsPasswordHash(s string) bool {
+	_, _, _, ok := splitPasswordHash(s)
+	return ok
+}
+
+func splitPasswordHash(s string) (iter int, salt, key []byte, ok bool) {
+	rest, ok := strings.CutPrefix(s, passwordHashPrefix)
+	if !ok {
+		return 0, nil, nil, false
+	}
+
+	parts := strings.Split(rest, "$")
+	if len(parts) != 3 {
+		return 0, nil, nil, false
+	}
+
+	iter, err := strconv.Atoi(parts[0])
+	if err != nil || iter <= 0 {
+		return 0, nil, nil, false
+	}
+	salt, err = base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, nil, false
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil || len(key) == 0 {
+		return 0, nil, nil, false
+	}
+
+	return iter, salt, key, true
+}
+
+// pbkdf2SHA256 derives a key from the password, as defined by RFC 8018.
+func pbkdf2SHA256(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+
+	return dk[:keyLen]
+}
+
+// PermissionDeniedError is returned when the user running a statement
+// wasn't granted the privileges it requires.
+type PermissionDeniedError struct {
+	User string
+	// Privilege is zero if the statement can only be run by superusers.
+	Privilege Privilege
+	Table     string
+}
+
+func (e *PermissionDeniedError) Error() string {
+	switch {
+	case e.Privilege == 0:
+		return fmt.Sprintf("permission denied: user %s must be superuser", e.User)
+	case e.Table == AllTables:
+		return fmt.Sprintf("permission denied: user %s cannot %s on all tables", e.User, e.Privilege)
+	}
+
+	return fmt.Sprintf("permission denied: user %s cannot %s on table %s", e.User, e.Privilege, e.Table)
+}
+
+// currentUser returns the user running the transaction, as defined
+// by the catalog of the database, or nil if the transaction has
+// every privilege.
+// Privileges granted or revoked by other transactions apply
+// once they are committed.
+func (tx *Transaction) currentUser() *UserInfo {
+	if tx.User == "" {
+		return nil
+	}
+
+	u, err := tx.db.Catalog().GetUser(tx.User)
+	if err != nil {
+		// the user was dropped: it has no privilege left
+		return &UserInfo{Name: tx.User}
+	}
+
+	return u
+}
+
+// Restricted returns true if the transaction runs as a user,
+// whose privileges must be checked.
+func (tx *Transaction) Restricted() bool {
+	return tx.User != ""
+}
+
+// CheckPrivilege returns a PermissionDeniedError if the user running
+// the transaction wasn't granted the privileges on the table.
+// tableName can be AllTables to check the privileges granted on every table.
+func (tx *Transaction) CheckPrivilege(p Privilege, tableName string) error {
+	u := tx.currentUser()
+	if u == nil || u.HasPrivilege(p, tableName) {
+		return nil
+	}
+
+	// users have every privilege on the temporary tables they created
+	if tx.Catalog.IsTemporaryTable(tableName) {
+		return nil
+	}
+
+	return errors.WithStack(&PermissionDeniedError{User: u.Name, Privilege: p, Table: tableName})
+}
+
+// CheckSuperuser returns a PermissionDeniedError if the transaction
+// is run by a user that is not a superuser.
+func (tx *Transaction) CheckSuperuser() error {
+	u := tx.currentUser()
+	if u == nil || u.Superuser {
+		return nil
+	}
+
+	return errors.WithStack(&PermissionDeniedError{User: u.Name})
+}
+
+// Authenticate returns the user with the given name if the password is correct.
+// Otherwise, it returns ErrAuthenticationFailed.
+func (db *Database) Authenticate(name, password string) (*UserInfo, error) {
+	u, err := db.Catalog().GetUser(name)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			// spend the same time as with an existing user
+			(&UserInfo{PasswordHash: dummyPasswordHash}).CheckPassword(password)
+			return nil, errors.WithStack(ErrAuthenticationFailed)
+		}
+		return nil, err
+	}
+
+	if !u.CheckPassword(password) {
+		return nil, errors.WithStack(ErrAuthenticationFailed)
+	}
+
+	return u, nil
+}
+
+var dummyPasswordHash, _ = HashPassword("")
+
+// ConnectAs authenticates the user and returns a connection
+// running every statement as that user.
+func (db *Database) ConnectAs(name, password string) (*Connection, error) {
+	_, err := db.Authenticate(name, password)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		return nil, err
+	}
+	conn.user = name
+
+	return conn, nil
+}
+
+// User returns the name of the user running the statements of the connection,
+// or an empty string if the connection has every privilege.
+func (c *Connection) User() string {
+	return c.user
+}
+
+// GetUser returns a user by name.
+func (c *Catalog) GetUser(name string) (*UserInfo, error) {
+	r, err := c.Cache.Get(RelationUserType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.(*UserInfoRelation).Info, nil
+}
+
+// ListUsers returns all the user names sorted lexicographically.
+func (c *Catalog) ListUsers() []string {
+	return c.Cache.ListObjects(RelationUserType)
+}
+
+// CreateUser creates a user.
+// If it already exists, returns errs.AlreadyExistsError.
+func (c *CatalogWriter) CreateUser(tx *Transaction, info *UserInfo) error {
+	if info.Name == "" {
+		return errors.New("user name required")
+	}
+
+	if info.Privileges == nil {
+		info.Privileges = make(map[string]Privilege)
+	}
+
+	rel := UserInfoRelation{Info: info}
+	err := c.Cache.Add(tx, &rel)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = UsersTable(tx).Insert(userInfoToRow(info))
+	return err
+}
+
+// ReplaceUser replaces the configuration of a user.
+func (c *CatalogWriter) ReplaceUser(tx *Transaction, info *UserInfo) error {
+	err := c.Cache.Replace(tx, &UserInfoRelation{Info: info})
+	if err != nil {
+		return err
+	}
+
+	_, err = UsersTable(tx).Put(tree.NewKey(types.NewTextValue(info.Name)), userInfoToRow(info))
+	return err
+}
+
+// DropUser deletes a user from the catalog.
+func (c *CatalogWriter) DropUser(tx *Transaction, name string) error {
+	_, err := c.Cache.Delete(tx, RelationUserType, name)
+	if err != nil {
+		return err
+	}
+
+	return UsersTable(tx).Delete(tree.NewKey(types.NewTextValue(name)))
+}
+
+// Grant grants the privileges on the table to the user.
+// tableName can be AllTables.
+func (c *CatalogWriter) Grant(tx *Transaction, userName string, p Privilege, tableName string) error {
+	u, err := c.GetUser(userName)
+	if err != nil {
+		return err
+	}
+
+	clone := u.Clone()
+	clone.Privileges[tableName] |= p
+	return c.ReplaceUser(tx, clone)
+}
+
+// Revoke revokes the privileges on the table from the user.
+// The privileges granted on all tables can only be revoked
+// by passing AllTables.
+func (c *CatalogWriter) Revoke(tx *Transaction, userName string, p Privilege, tableName string) error {
+	u, err := c.GetUser(userName)
+	if err != nil {
+		return err
+	}
+
+	clone := u.Clone()
+	clone.Privileges[tableName] &^= p
+	if clone.Privileges[tableName] == 0 {
+		delete(clone.Privileges, tableName)
+	}
+	return c.ReplaceUser(tx, clone)
+}
+
+// moveTablePrivileges moves the privileges granted on a table to its new name,
+// or revokes them if newName is empty.
+func (c *CatalogWriter) moveTablePrivileges(tx *Transaction, oldName, newName string) error {
+	for _, name := range c.ListUsers() {
+		u, err := c.GetUser(name)
+		if err != nil {
+			return err
+		}
+
+		p, ok := u.Privileges[oldName]
+		if !ok {
+			continue
+		}
+
+		clone := u.Clone()
+		delete(clone.Privileges, oldName)
+		if newName != "" {
+			clone.Privileges[newName] = p
+		}
+
+		err = c.ReplaceUser(tx, clone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The users are stored in their own table, which is not part of
+// the catalog and cannot be read with SQL, to protect the hashes
+// of the passwords.
+var usersTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName:      UsersTableName,
+		StoreNamespace: UsersTableNamespace,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{Position: 0, Column: "name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Position: 1, Column: "sql", Type: types.TypeText, IsNotNull: true},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       UsersTableName + "_pk",
+				Columns:    []string{"name"},
+				PrimaryKey: true,
+			},
+		},
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// UsersTable returns the table storing the users.
+func UsersTable(tx *Transaction) *Table {
+	return &Table{
+		Tx:   tx,
+		Tree: tree.New(tx.Session, UsersTableNamespace, usersTableInfo.PrimaryKeySortOrder()),
+		Info: usersTableInfo,
+	}
+}
+
+func userInfoToRow(u *UserInfo) row.Row {
+	buf := row.NewColumnBuffer()
+	buf.Add("name", types.NewTextValue(u.Name))
+	buf.Add("sql", types.NewTextValue(u.String()))
+
+	return buf
+}
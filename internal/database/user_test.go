@@ -0,0 +1,60 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := database.HashPassword("secret")
+	require.NoError(t, err)
+	require.True(t, database.IsPasswordHash(hash))
+	require.False(t, database.IsPasswordHash("secret"))
+
+	// hashes are salted
+	other, err := database.HashPassword("secret")
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other)
+
+	// hashes are kept as is
+	same, err := database.HashPassword(hash)
+	require.NoError(t, err)
+	require.Equal(t, hash, same)
+
+	u := database.UserInfo{Name: "alice", PasswordHash: hash}
+	require.True(t, u.CheckPassword("secret"))
+	require.False(t, u.CheckPassword("Secret"))
+	require.False(t, u.CheckPassword(""))
+
+	u.PasswordHash = ""
+	require.False(t, u.CheckPassword(""))
+}
+
+func TestUserPrivileges(t *testing.T) {
+	u := database.UserInfo{
+		Name: "alice",
+		Privileges: map[string]database.Privilege{
+			"t":                database.PrivilegeSelect | database.PrivilegeInsert,
+			database.AllTables: database.PrivilegeUpdate,
+		},
+	}
+
+	require.True(t, u.HasPrivilege(database.PrivilegeSelect, "t"))
+	require.True(t, u.HasPrivilege(database.PrivilegeSelect|database.PrivilegeUpdate, "t"))
+	require.False(t, u.HasPrivilege(database.PrivilegeDelete, "t"))
+	require.True(t, u.HasPrivilege(database.PrivilegeUpdate, "u"))
+	require.False(t, u.HasPrivilege(database.PrivilegeSelect, "u"))
+	require.False(t, u.HasPrivilege(database.PrivilegeSelect, database.AllTables))
+
+	// system tables are read-only
+	require.True(t, u.HasPrivilege(database.PrivilegeSelect, database.CatalogTableName))
+	require.False(t, u.HasPrivilege(database.PrivilegeUpdate, database.CatalogTableName))
+
+	u.Superuser = true
+	require.True(t, u.HasPrivilege(database.AllPrivileges, "u"))
+
+	require.Equal(t, "SELECT, INSERT", (database.PrivilegeSelect | database.PrivilegeInsert).String())
+	require.Equal(t, "ALL", database.AllPrivileges.String())
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// VacuumStats reports the size on disk of the data compacted by a vacuum.
+// The data held in memory is written to disk before the size is measured.
+type VacuumStats struct {
+	SizeBefore uint64
+	SizeAfter  uint64
+	Duration   time.Duration
+}
+
+// Vacuum compacts the data of the database on disk, removing
+// the keys deleted or overwritten since the last compaction,
+// including those of the dropped tables and indexes,
+// and reclaiming their space.
+func (db *Database) Vacuum() (*VacuumStats, error) {
+	if db.closeContext.Err() != nil {
+		return nil, errors.New("database is closed")
+	}
+
+	start := encoding.EncodeInt(nil, int64(CatalogTableNamespace))
+	end := encoding.EncodeInt(nil, int64(MinTransientNamespace))
+	return db.compact([][2][]byte{{start, end}})
+}
+
+// VacuumTable compacts the rows of a table and the entries of its indexes.
+func (db *Database) VacuumTable(tableName string) (*VacuumStats, error) {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	t, err := tx.Catalog.GetTable(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	trees := []*tree.Tree{t.Tree}
+	for _, name := range tx.Catalog.ListIndexes(tableName) {
+		idx, err := tx.Catalog.GetIndex(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		trees = append(trees, idx.Tree)
+	}
+
+	// the keys of partitioned trees are stored in their partitions
+	var spans [][2][]byte
+	for _, tr := range trees {
+		for _, sub := range tr.Subtrees() {
+			start, end := sub.Bounds()
+			spans = append(spans, [2][]byte{start, end})
+		}
+	}
+
+	return db.compact(spans)
+}
+
+// compact compacts every span and measures their size before and after.
+func (db *Database) compact(spans [][2][]byte) (*VacuumStats, error) {
+	begin := time.Now()
+
+	err := db.Engine.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	size := func() (uint64, error) {
+		var n uint64
+		for _, s := range spans {
+			stats, err := db.Engine.SpanStats(s[0], s[1])
+			if err != nil {
+				return 0, err
+			}
+			n += stats.DiskSize
+		}
+		return n, nil
+	}
+
+	var stats VacuumStats
+	stats.SizeBefore, err = size()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range spans {
+		err = db.Engine.Compact(s[0], s[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stats.SizeAfter, err = size()
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Duration = time.Since(begin)
+	return &stats, nil
+}
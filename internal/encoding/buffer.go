@@ -0,0 +1,32 @@
+package encoding
+
+import "sync"
+
+// maxPooledBufferSize is the capacity above which buffers are not
+// put back in the pool, so that a few large rows don't keep
+// their memory allocated.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// GetBuffer returns an empty buffer from the pool, to encode values
+// without growing a new buffer every time. It must be given back with
+// PutBuffer once its content is not used anymore.
+func GetBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+// PutBuffer puts a buffer returned by GetBuffer back in the pool.
+func PutBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
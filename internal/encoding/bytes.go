@@ -5,12 +5,9 @@ import (
 )
 
 func EncodeBlob(dst []byte, x []byte) []byte {
+	dst = append(dst, BlobValue)
 	// encode the length as a varint
-	buf := make([]byte, binary.MaxVarintLen64+1)
-	buf[0] = BlobValue
-	n := binary.PutUvarint(buf[1:], uint64(len(x)))
-
-	dst = append(dst, buf[:n+1]...)
+	dst = binary.AppendUvarint(dst, uint64(len(x)))
 	return append(dst, x...)
 }
 
@@ -23,12 +20,9 @@ func DecodeBlob(b []byte) ([]byte, int) {
 }
 
 func EncodeText(dst []byte, x string) []byte {
+	dst = append(dst, TextValue)
 	// encode the length as a varint
-	buf := make([]byte, binary.MaxVarintLen64+1)
-	buf[0] = TextValue
-	n := binary.PutUvarint(buf[1:], uint64(len(x)))
-
-	dst = append(dst, buf[:n+1]...)
+	dst = binary.AppendUvarint(dst, uint64(len(x)))
 	return append(dst, x...)
 }
 
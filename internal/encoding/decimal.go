@@ -0,0 +1,120 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strings"
+)
+
+// Markers of the sign of encoded decimals.
+const (
+	decimalNegative byte = 0x01
+	decimalZero     byte = 0x02
+	decimalPositive byte = 0x03
+)
+
+// Markers of the end of the digits of encoded decimals.
+// They sort after all digits of negative decimals and before
+// all digits of positive decimals.
+const (
+	decimalNegativeEnd byte = 0xFE
+	decimalPositiveEnd byte = 0x01
+)
+
+// EncodeDecimal encodes the decimal unscaled * 10^-scale as a blob
+// whose bytes are sorted in the order of the decimals. The blob holds:
+//   - the sign of the decimal;
+//   - its exponent e, such as the decimal is 0.d1d2d3... * 10^e;
+//   - its significant digits, without trailing zeros, two per byte;
+//   - its scale, which sorts equal decimals by number of fractional digits.
+//
+// The exponent and the digits of negative decimals are inverted,
+// so that larger absolute values sort first.
+func EncodeDecimal(dst []byte, unscaled *big.Int, scale int) []byte {
+	digits := new(big.Int).Abs(unscaled).String()
+	exp := len(digits) - scale
+	digits = strings.TrimRight(digits, "0")
+
+	buf := make([]byte, 0, 8+len(digits)/2)
+	switch unscaled.Sign() {
+	case 0:
+		buf = append(buf, decimalZero, decimalPositiveEnd)
+	case 1:
+		buf = append(buf, decimalPositive)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(int32(exp))^(1<<31))
+		buf = appendDecimalDigits(buf, digits, false)
+		buf = append(buf, decimalPositiveEnd)
+	default:
+		buf = append(buf, decimalNegative)
+		buf = binary.BigEndian.AppendUint32(buf, ^(uint32(int32(exp)) ^ (1 << 31)))
+		buf = appendDecimalDigits(buf, digits, true)
+		buf = append(buf, decimalNegativeEnd)
+	}
+	buf = binary.BigEndian.AppendUint16(buf, uint16(scale))
+
+	return EncodeBlob(dst, buf)
+}
+
+// appendDecimalDigits appends the digits two by two, as bytes
+// between 2 and 101, or between 154 and 253 if inverted.
+func appendDecimalDigits(dst []byte, digits string, invert bool) []byte {
+	for i := 0; i < len(digits); i += 2 {
+		b := (digits[i] - '0') * 10
+		if i+1 < len(digits) {
+			b += digits[i+1] - '0'
+		}
+		b += 2
+		if invert {
+			b = 255 - b
+		}
+		dst = append(dst, b)
+	}
+
+	return dst
+}
+
+// DecodeDecimal decodes a decimal encoded by EncodeDecimal.
+func DecodeDecimal(b []byte) (unscaled *big.Int, scale int, n int) {
+	buf, n := DecodeBlob(b)
+
+	sign := buf[0]
+	buf = buf[1:]
+	scale = int(binary.BigEndian.Uint16(buf[len(buf)-2:]))
+	if sign == decimalZero {
+		return new(big.Int), scale, n
+	}
+
+	u := binary.BigEndian.Uint32(buf)
+	if sign == decimalNegative {
+		u = ^u
+	}
+	exp := int(int32(u ^ (1 << 31)))
+
+	var digits strings.Builder
+	for _, d := range buf[4 : len(buf)-3] {
+		if sign == decimalNegative {
+			d = 255 - d
+		}
+		d -= 2
+		digits.WriteByte('0' + d/10)
+		digits.WriteByte('0' + d%10)
+	}
+
+	// the decimal is digits * 10^(exp - len(digits)),
+	// shift it by scale digits to get the unscaled value.
+	s := digits.String()
+	shift := exp - len(s) + scale
+	if shift < 0 {
+		// only trailing zeros are dropped
+		s = s[:len(s)+shift]
+	} else {
+		s += strings.Repeat("0", shift)
+	}
+
+	unscaled, _ = new(big.Int).SetString(s, 10)
+	if sign == decimalNegative {
+		unscaled.Neg(unscaled)
+	}
+
+	return unscaled, scale, n
+}
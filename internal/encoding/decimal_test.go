@@ -0,0 +1,59 @@
+package encoding_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecimal(t *testing.T) {
+	// sorted by value, then by scale
+	decimals := []struct {
+		unscaled string
+		scale    int
+	}{
+		{"-123456789012345678901234567890", 0},
+		{"-1000", 0},
+		{"-999", 0},
+		{"-1005", 1},
+		{"-100", 0},
+		{"-1", 0},
+		{"-10", 1},
+		{"-105", 3},
+		{"-1", 1},
+		{"-1", 5},
+		{"0", 0},
+		{"0", 2},
+		{"1", 5},
+		{"1", 1},
+		{"105", 3},
+		{"1", 0},
+		{"100", 2},
+		{"15", 1},
+		{"150", 2},
+		{"151", 2},
+		{"2", 0},
+		{"99", 0},
+		{"100", 0},
+		{"1005", 1},
+		{"123456789012345678901234567890", 0},
+	}
+
+	var prev []byte
+	for _, d := range decimals {
+		unscaled, _ := new(big.Int).SetString(d.unscaled, 10)
+		enc := encoding.EncodeDecimal(nil, unscaled, d.scale)
+
+		dec, scale, n := encoding.DecodeDecimal(enc)
+		require.Equal(t, len(enc), n)
+		require.Equal(t, d.unscaled, dec.String())
+		require.Equal(t, d.scale, scale)
+
+		if prev != nil {
+			require.Negative(t, encoding.Compare(prev, enc), "%v", d)
+		}
+		prev = enc
+	}
+}
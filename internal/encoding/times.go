@@ -1,6 +1,7 @@
 package encoding
 
 import (
+	"encoding/binary"
 	"math"
 	"time"
 )
@@ -30,3 +31,70 @@ func DecodeTimestamp(b []byte) (time.Time, int) {
 func ConvertToTimestamp(x int64) time.Time {
 	return time.UnixMicro(Epoch + x).UTC()
 }
+
+// EncodeDate encodes the date of t as the number of days
+// since 2000-01-01. The time of the day is ignored.
+func EncodeDate(dst []byte, t time.Time) []byte {
+	y, m, d := t.Date()
+	days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix()/86400 - Epoch/86400_000_000
+
+	return EncodeInt(dst, days)
+}
+
+func DecodeDate(b []byte) (time.Time, int) {
+	x, n := DecodeInt(b)
+	return time.Date(2000, 1, 1+int(x), 0, 0, 0, 0, time.UTC), n
+}
+
+// Length of the units of intervals, in microseconds.
+const (
+	DayMicros   = 24 * 60 * 60 * 1_000_000
+	MonthMicros = 30 * DayMicros
+)
+
+// IntervalLength returns the length of an interval in microseconds,
+// counting 30 days per month and 24 hours per day. Lengths that
+// don't fit in an int64 are clamped.
+func IntervalLength(months, days int32, micros int64) int64 {
+	d := int64(months)*30 + int64(days)
+	if d > math.MaxInt64/DayMicros {
+		return math.MaxInt64
+	}
+	if d < math.MinInt64/DayMicros {
+		return math.MinInt64
+	}
+
+	l := d * DayMicros
+	if micros > 0 && l > math.MaxInt64-micros {
+		return math.MaxInt64
+	}
+	if micros < 0 && l < math.MinInt64-micros {
+		return math.MinInt64
+	}
+
+	return l + micros
+}
+
+// EncodeInterval encodes an interval as a blob of 24 bytes holding
+// its length, its months, its days and its microseconds, so that
+// encoded intervals are sorted by length.
+func EncodeInterval(dst []byte, months, days int32, micros int64) []byte {
+	var buf [24]byte
+
+	binary.BigEndian.PutUint64(buf[0:], uint64(IntervalLength(months, days, micros))^(1<<63))
+	binary.BigEndian.PutUint32(buf[8:], uint32(months)^(1<<31))
+	binary.BigEndian.PutUint32(buf[12:], uint32(days)^(1<<31))
+	binary.BigEndian.PutUint64(buf[16:], uint64(micros)^(1<<63))
+
+	return EncodeBlob(dst, buf[:])
+}
+
+func DecodeInterval(b []byte) (months, days int32, micros int64, n int) {
+	buf, n := DecodeBlob(b)
+
+	months = int32(binary.BigEndian.Uint32(buf[8:]) ^ (1 << 31))
+	days = int32(binary.BigEndian.Uint32(buf[12:]) ^ (1 << 31))
+	micros = int64(binary.BigEndian.Uint64(buf[16:]) ^ (1 << 63))
+
+	return months, days, micros, n
+}
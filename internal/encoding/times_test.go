@@ -69,3 +69,61 @@ func TestEncodeTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeDate(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		dec  time.Time
+		days int64
+	}{
+		{"epoch", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+		{"time-ignored", time.Date(2000, 1, 2, 23, 59, 59, 0, time.UTC), time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), 1},
+		{"before-epoch", time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), -1},
+		{"unix-epoch", time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), -10957},
+		{"far", time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), 2921939},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := encoding.EncodeDate(nil, test.t)
+			require.Equal(t, encoding.EncodeInt(nil, test.days), got)
+
+			dec, n := encoding.DecodeDate(got)
+			require.Equal(t, len(got), n)
+			require.Equal(t, test.dec, dec)
+		})
+	}
+}
+
+func TestEncodeInterval(t *testing.T) {
+	const day = encoding.DayMicros
+
+	// sorted by length
+	intervals := [][3]int64{
+		{-1, 0, 0},
+		{0, -1, 0},
+		{0, 0, -1},
+		{0, 0, 0},
+		{0, 0, day - 1},
+		{0, 1, 0},
+		{0, 0, day + 1},
+		{1, 0, 0},
+		{1, 0, 1},
+		{math.MaxInt32, math.MaxInt32, math.MaxInt64},
+	}
+
+	var prev []byte
+	for _, iv := range intervals {
+		enc := encoding.EncodeInterval(nil, int32(iv[0]), int32(iv[1]), iv[2])
+
+		months, days, micros, n := encoding.DecodeInterval(enc)
+		require.Equal(t, len(enc), n)
+		require.Equal(t, iv, [3]int64{int64(months), int64(days), micros})
+
+		if prev != nil {
+			require.Negative(t, encoding.Compare(prev, enc), "%v", iv)
+		}
+		prev = enc
+	}
+}
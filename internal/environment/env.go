@@ -1,6 +1,7 @@
 package environment
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/chaisql/chai/internal/database"
@@ -22,8 +23,15 @@ type Param struct {
 type Environment struct {
 	Params []Param
 	Row    row.Row
+	// Joined is a row of another table joined to the current row,
+	// whose columns are read by the columns of that table.
+	Joined database.Row
 	DB     *database.Database
 	Tx     *database.Transaction
+	// Ctx is used to cancel the execution of the stream, if not nil.
+	Ctx context.Context
+	// Progress counts the rows scanned by the stream, if not nil.
+	Progress *Progress
 
 	Outer *Environment
 }
@@ -70,6 +78,18 @@ func (e *Environment) GetDatabaseRow() (database.Row, bool) {
 	return nil, false
 }
 
+// GetJoinedRow returns the row of the given table joined
+// to the current row, if any.
+func (e *Environment) GetJoinedRow(tableName string) (database.Row, bool) {
+	for ; e != nil; e = e.Outer {
+		if e.Joined != nil && e.Joined.TableName() == tableName {
+			return e.Joined, true
+		}
+	}
+
+	return nil, false
+}
+
 func (e *Environment) SetRow(r row.Row) {
 	e.Row = r
 }
@@ -109,6 +129,64 @@ func (e *Environment) GetParamByIndex(pos int) (types.Value, error) {
 	return row.NewValue(e.Params[idx].Value)
 }
 
+// GetContext returns the context of the environment or of
+// its outer environments, or nil if there is none.
+func (e *Environment) GetContext() context.Context {
+	if e.Ctx != nil {
+		return e.Ctx
+	}
+
+	if outer := e.GetOuter(); outer != nil {
+		return outer.GetContext()
+	}
+
+	return nil
+}
+
+// Err returns the cause of the cancellation of the context of the
+// environment if it was canceled or its deadline exceeded, nil otherwise.
+// Operators reading many rows call it for each row so that
+// the execution stops promptly.
+func (e *Environment) Err() error {
+	ctx := e.GetContext()
+	if ctx == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	default:
+		return nil
+	}
+}
+
+// GetProgress returns the progress of the environment or of
+// its outer environments, or nil if there is none.
+func (e *Environment) GetProgress() *Progress {
+	if e.Progress != nil {
+		return e.Progress
+	}
+
+	if outer := e.GetOuter(); outer != nil {
+		return outer.GetProgress()
+	}
+
+	return nil
+}
+
+// RowScanned reports that a row was read from a table or an index.
+// Operators reading many rows call it for each row, along with Err.
+// It returns the error of the progress callback, if any.
+func (e *Environment) RowScanned() error {
+	p := e.GetProgress()
+	if p == nil {
+		return nil
+	}
+
+	return p.add()
+}
+
 func (e *Environment) GetTx() *database.Transaction {
 	if e.Tx != nil {
 		return e.Tx
@@ -0,0 +1,28 @@
+package environment
+
+import "sync/atomic"
+
+// Progress counts the rows scanned by a query.
+// It can be read by other goroutines while the query runs.
+type Progress struct {
+	rowsScanned atomic.Int64
+
+	// OnProgress is called with the number of rows scanned so far
+	// every time a row is scanned, if not nil. If it returns an error,
+	// the query stops with that error.
+	OnProgress func(rowsScanned int64) error
+}
+
+// RowsScanned returns the number of rows scanned so far.
+func (p *Progress) RowsScanned() int64 {
+	return p.rowsScanned.Load()
+}
+
+func (p *Progress) add() error {
+	n := p.rowsScanned.Add(1)
+	if p.OnProgress == nil {
+		return nil
+	}
+
+	return p.OnProgress(n)
+}
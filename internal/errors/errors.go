@@ -61,3 +61,28 @@ func IsNotFoundError(err error) bool {
 
 	return false
 }
+
+// TypeMismatchError is returned when a value or an expression
+// cannot be used as, or converted to, the type expected by an operation.
+type TypeMismatchError struct {
+	Err error
+}
+
+// TypeMismatchf returns a TypeMismatchError whose message is formatted
+// with errors.Errorf, which supports wrapping a cause with %w.
+func TypeMismatchf(format string, args ...any) error {
+	return errors.WithStack(&TypeMismatchError{Err: errors.Errorf(format, args...)})
+}
+
+func (e *TypeMismatchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TypeMismatchError) Unwrap() error {
+	return e.Err
+}
+
+func IsTypeMismatchError(err error) bool {
+	var e *TypeMismatchError
+	return errors.As(err, &e)
+}
@@ -1,9 +1,13 @@
 package expr
 
 import (
+	"time"
+
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
 )
 
 // IsArithmeticOperator returns true if e is one of
@@ -25,6 +29,10 @@ func (op *arithmeticOperator) Clone() Expr {
 
 func (op *arithmeticOperator) Eval(env *environment.Environment) (types.Value, error) {
 	return op.simpleOperator.eval(env, func(va, vb types.Value) (types.Value, error) {
+		if isTemporal(va) || isTemporal(vb) {
+			return evalTemporal(op.simpleOperator.Tok, va, vb)
+		}
+
 		a, ok := va.(types.Numeric)
 		if !ok {
 			return NullLiteral, nil
@@ -35,6 +43,13 @@ func (op *arithmeticOperator) Eval(env *environment.Environment) (types.Value, e
 			return NullLiteral, nil
 		}
 
+		if op.simpleOperator.Tok == scanner.DIV || op.simpleOperator.Tok == scanner.MOD {
+			v, err := divideByZero(env, b)
+			if v != nil || err != nil {
+				return v, err
+			}
+		}
+
 		switch op.simpleOperator.Tok {
 		case scanner.ADD:
 			return a.Add(b)
@@ -71,6 +86,127 @@ func (op *arithmeticOperator) Eval(env *environment.Environment) (types.Value, e
 	})
 }
 
+func isTemporal(v types.Value) bool {
+	switch v.Type() {
+	case types.TypeTimestamp, types.TypeDate, types.TypeInterval:
+		return true
+	}
+
+	return false
+}
+
+// evalTemporal evaluates the arithmetic operators on timestamps, dates and intervals:
+//
+//	timestamp + interval -> timestamp    interval + interval -> interval
+//	timestamp - interval -> timestamp    interval - interval -> interval
+//	timestamp - timestamp -> interval    interval * number -> interval
+//	date + integer -> date               interval / number -> interval
+//	date - integer -> date               date - date -> integer
+//
+// Dates are used as timestamps at midnight, and texts added to or
+// subtracted from an interval are parsed as timestamps.
+// Any other combination returns NULL.
+func evalTemporal(tok scanner.Token, a, b types.Value) (types.Value, error) {
+	ta, tb := a.Type(), b.Type()
+
+	switch tok {
+	case scanner.ADD:
+		if ta == types.TypeInterval && tb != types.TypeInterval {
+			// addition is commutative
+			a, b, ta, tb = b, a, tb, ta
+		}
+		if ta == types.TypeDate && tb.IsInteger() {
+			return addDays(a, types.AsInt64(b))
+		}
+		if ta.IsInteger() && tb == types.TypeDate {
+			return addDays(b, types.AsInt64(a))
+		}
+		if tb != types.TypeInterval {
+			return NullLiteral, nil
+		}
+		if ta == types.TypeInterval {
+			return a.(types.IntervalValue).Add(b.(types.IntervalValue))
+		}
+		return addInterval(a, b.(types.IntervalValue))
+	case scanner.SUB:
+		if ta == types.TypeDate && tb.IsInteger() {
+			return addDays(a, -types.AsInt64(b))
+		}
+		if ta == types.TypeDate && tb == types.TypeDate {
+			return types.NewIntegerValue(types.NewIntervalBetween(types.AsTime(b), types.AsTime(a)).Days), nil
+		}
+		if tb == types.TypeInterval {
+			if ta == types.TypeInterval {
+				return a.(types.IntervalValue).Sub(b.(types.IntervalValue))
+			}
+
+			iv, err := b.(types.IntervalValue).Mul(-1)
+			if err != nil {
+				return nil, err
+			}
+			return addInterval(a, iv)
+		}
+		if (ta == types.TypeTimestamp || ta == types.TypeDate) && (tb == types.TypeTimestamp || tb == types.TypeDate) {
+			return types.NewIntervalBetween(types.AsTime(b), types.AsTime(a)), nil
+		}
+	case scanner.MUL:
+		if tb == types.TypeInterval {
+			a, b, ta, tb = b, a, tb, ta
+		}
+		if ta == types.TypeInterval && tb.IsNumber() {
+			f, err := b.CastAs(types.TypeDouble)
+			if err != nil {
+				return nil, err
+			}
+			return a.(types.IntervalValue).Mul(types.AsFloat64(f))
+		}
+	case scanner.DIV:
+		if ta == types.TypeInterval && tb.IsNumber() {
+			f, err := b.CastAs(types.TypeDouble)
+			if err != nil {
+				return nil, err
+			}
+			return a.(types.IntervalValue).Div(types.AsFloat64(f))
+		}
+	}
+
+	return NullLiteral, nil
+}
+
+// addInterval returns the timestamp t + iv.
+func addInterval(t types.Value, iv types.IntervalValue) (types.Value, error) {
+	var ts time.Time
+	switch t.Type() {
+	case types.TypeTimestamp, types.TypeDate:
+		ts = types.AsTime(t)
+	case types.TypeText:
+		var err error
+		ts, err = types.ParseTimestamp(types.AsString(t))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return NullLiteral, nil
+	}
+
+	ts, err := iv.AddTo(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewTimestampValue(ts), nil
+}
+
+// addDays returns the date d + days.
+func addDays(d types.Value, days int64) (types.Value, error) {
+	t := types.AsTime(d).AddDate(0, 0, int(days))
+	if err := types.ValidateTimestamp(t); err != nil {
+		return nil, errors.New("date out of range")
+	}
+
+	return types.NewDateValue(t), nil
+}
+
 // Add creates an expression thats evaluates to the result of a + b.
 func Add(a, b Expr) Expr {
 	return &arithmeticOperator{&simpleOperator{a, b, scanner.ADD}}
@@ -86,6 +222,32 @@ func Mul(a, b Expr) Expr {
 	return &arithmeticOperator{&simpleOperator{a, b, scanner.MUL}}
 }
 
+// divideByZero returns the result of a division or a modulo by b
+// if b is zero and the compatibility mode of the transaction
+// defines one: NULL for SQLite and an error for PostgreSQL.
+// It returns nil otherwise.
+func divideByZero(env *environment.Environment, b types.Numeric) (types.Value, error) {
+	if env == nil {
+		return nil, nil
+	}
+
+	tx := env.GetTx()
+	if tx == nil || tx.CompatMode == database.CompatDefault {
+		return nil, nil
+	}
+
+	zero, err := b.IsZero()
+	if err != nil || !zero {
+		return nil, err
+	}
+
+	if tx.CompatMode == database.CompatSQLite {
+		return NullLiteral, nil
+	}
+
+	return nil, errors.New("division by zero")
+}
+
 // Div creates an expression thats evaluates to the result of a / b.
 func Div(a, b Expr) Expr {
 	return &arithmeticOperator{&simpleOperator{a, b, scanner.DIV}}
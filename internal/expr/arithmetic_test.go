@@ -96,6 +96,7 @@ func TestValueMult(t *testing.T) {
 		{"integer(10)*integer(80)", types.NewIntegerValue(10), types.NewIntegerValue(80), types.NewIntegerValue(800), false},
 		{"integer(10)*float64(80)", types.NewIntegerValue(10), types.NewDoubleValue(80), types.NewDoubleValue(800), false},
 		{"int64(max)*int64(max)", types.NewBigintValue(math.MaxInt64), types.NewBigintValue(math.MaxInt64), nil, true},
+		{"float64(max)*integer(2)", types.NewDoubleValue(math.MaxFloat64), types.NewIntegerValue(2), nil, true},
 		{"integer(120)*text('120')", types.NewIntegerValue(120), types.NewTextValue("120"), types.NewNullValue(), false},
 		{"text('120')*text('120')", types.NewTextValue("120"), types.NewTextValue("120"), types.NewNullValue(), false},
 	}
@@ -130,6 +131,9 @@ func TestValueDiv(t *testing.T) {
 		{"integer(10)/integer(10)", types.NewIntegerValue(10), types.NewIntegerValue(10), types.NewIntegerValue(1), false},
 		{"integer(10)/integer(8)", types.NewIntegerValue(10), types.NewIntegerValue(8), types.NewIntegerValue(1), false},
 		{"integer(10)/float64(8)", types.NewIntegerValue(10), types.NewDoubleValue(8), types.NewDoubleValue(1.25), false},
+		{"int64(10)/int64(0)", types.NewBigintValue(10), types.NewBigintValue(0), nil, true},
+		{"int64(min)/int64(-1)", types.NewBigintValue(math.MinInt64), types.NewBigintValue(-1), nil, true},
+		{"float64(max)/float64(0.5)", types.NewDoubleValue(math.MaxFloat64), types.NewDoubleValue(0.5), nil, true},
 		{"int64(maxint)/float64(maxint)", types.NewBigintValue(math.MaxInt64), types.NewDoubleValue(math.MaxInt64), types.NewDoubleValue(1), false},
 		{"integer(120)/text('120')", types.NewIntegerValue(120), types.NewTextValue("120"), types.NewNullValue(), false},
 		{"text('120')/text('120')", types.NewTextValue("120"), types.NewTextValue("120"), types.NewNullValue(), false},
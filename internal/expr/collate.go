@@ -0,0 +1,94 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// Collate sets the collation used to compare and sort
+// the texts returned by an expression:
+//
+//	name COLLATE NOCASE = 'foo'
+//	ORDER BY name COLLATE "fr"
+//
+// It doesn't modify the value of the expression.
+type Collate struct {
+	Expr      Expr
+	Collation *database.Collation
+}
+
+// Eval returns the value of the expression.
+func (c *Collate) Eval(env *environment.Environment) (types.Value, error) {
+	return c.Expr.Eval(env)
+}
+
+func (c *Collate) Clone() Expr {
+	return &Collate{
+		Expr:      Clone(c.Expr),
+		Collation: c.Collation,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c *Collate) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Collate)
+	if !ok {
+		return false
+	}
+
+	return c.Collation == o.Collation && Equal(c.Expr, o.Expr)
+}
+
+func (c *Collate) String() string {
+	return fmt.Sprintf("%v COLLATE %s", c.Expr, c.Collation)
+}
+
+// CollationOf returns the collation used to compare the values of the expressions:
+// the collation given with COLLATE to the first expression that has one, or else
+// the collation of the first column that has one.
+// It returns nil if the values are compared with the binary collation.
+func CollationOf(exprs ...Expr) *database.Collation {
+	var col *database.Collation
+	for _, e := range exprs {
+		for {
+			p, ok := e.(Parentheses)
+			if !ok {
+				break
+			}
+			e = p.E
+		}
+
+		switch t := e.(type) {
+		case *Collate:
+			if t.Collation.IsBinary() {
+				return nil
+			}
+			return t.Collation
+		case *Column:
+			if col == nil {
+				col = t.Collation
+			}
+		}
+	}
+
+	return col
+}
+
+// compareCollated compares two texts with the collation.
+// It returns false if one of the values is not a text,
+// or if the collation is binary.
+func compareCollated(c *database.Collation, a, b types.Value) (int, bool) {
+	if c == nil || a.Type() != types.TypeText || b.Type() != types.TypeText {
+		return 0, false
+	}
+
+	return c.Compare(types.AsString(a), types.AsString(b)), true
+}
@@ -1,6 +1,7 @@
 package expr
 
 import (
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
@@ -9,6 +10,9 @@ import (
 type Column struct {
 	Name  string
 	Table string
+	// Collation of the column, set when the expression is bound.
+	// It is nil if the column uses the binary collation.
+	Collation *database.Collation
 }
 
 func (c *Column) String() string {
@@ -29,6 +33,13 @@ func (c *Column) Eval(env *environment.Environment) (types.Value, error) {
 		return NullLiteral, errors.New("no table specified")
 	}
 
+	// the column might belong to a joined table
+	if c.Table != "" {
+		if jr, ok := env.GetJoinedRow(c.Table); ok {
+			r = jr
+		}
+	}
+
 	v, err := r.Get(c.Name)
 	if err != nil {
 		return NullLiteral, err
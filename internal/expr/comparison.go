@@ -37,6 +37,23 @@ func (op *cmpOp) Eval(env *environment.Environment) (types.Value, error) {
 }
 
 func (op *cmpOp) compare(l, r types.Value) (bool, error) {
+	if cmp, ok := compareCollated(CollationOf(op.a, op.b), l, r); ok {
+		switch op.Tok {
+		case scanner.EQ:
+			return cmp == 0, nil
+		case scanner.NEQ:
+			return cmp != 0, nil
+		case scanner.GT:
+			return cmp > 0, nil
+		case scanner.GTE:
+			return cmp >= 0, nil
+		case scanner.LT:
+			return cmp < 0, nil
+		case scanner.LTE:
+			return cmp <= 0, nil
+		}
+	}
+
 	switch op.Tok {
 	case scanner.EQ:
 		return l.EQ(r)
@@ -124,6 +141,18 @@ func (op *BetweenOperator) Eval(env *environment.Environment) (types.Value, erro
 			return NullLiteral, nil
 		}
 
+		if c := CollationOf(op.X, op.a, op.b); c != nil {
+			lo, ok := compareCollated(c, x, a)
+			if ok {
+				if hi, ok := compareCollated(c, x, b); ok {
+					if lo >= 0 && hi <= 0 {
+						return TrueLiteral, nil
+					}
+					return FalseLiteral, nil
+				}
+			}
+		}
+
 		ok, err := x.Between(a, b)
 		if err != nil {
 			return NullLiteral, err
@@ -217,12 +246,20 @@ func (op *InOperator) Eval(env *environment.Environment) (types.Value, error) {
 		return NullLiteral, nil
 	}
 
+	c := CollationOf(append([]Expr{a}, b...)...)
 	for _, bb := range b {
 		v, err := bb.Eval(env)
 		if err != nil {
 			return NullLiteral, err
 		}
 
+		if cmp, ok := compareCollated(c, va, v); ok {
+			if cmp == 0 {
+				return TrueLiteral, nil
+			}
+			continue
+		}
+
 		ok, err := va.EQ(v)
 		if err != nil {
 			return NullLiteral, err
@@ -244,6 +281,11 @@ func (op *InOperator) validateLeftExpression(a Expr) (Expr, error) {
 		return a, nil
 	case LiteralValue:
 		return a, nil
+	case *Collate:
+		if _, err := op.validateLeftExpression(t.Expr); err != nil {
+			return nil, err
+		}
+		return a, nil
 	}
 
 	return nil, fmt.Errorf("invalid left expression for IN operator: %v", a)
@@ -289,7 +331,7 @@ type IsOperator struct {
 
 // Is creates an expression that evaluates to the result of a IS b.
 func Is(a, b Expr) Expr {
-	return &IsOperator{&simpleOperator{a, b, scanner.IN}}
+	return &IsOperator{&simpleOperator{a, b, scanner.IS}}
 }
 
 func (op *IsOperator) Clone() Expr {
@@ -3,6 +3,7 @@ package expr
 import (
 	"fmt"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/types"
 )
@@ -140,8 +141,17 @@ func Walk(e Expr, fn func(Expr) bool) bool {
 		if !Walk(t.RightHand(), fn) {
 			return false
 		}
+		if l, ok := t.(interface{ EscapeExpr() Expr }); ok {
+			return Walk(l.EscapeExpr(), fn)
+		}
 	case *NamedExpr:
 		return Walk(t.Expr, fn)
+	case Parentheses:
+		return Walk(t.E, fn)
+	case *Cast:
+		return Walk(t.Expr, fn)
+	case *Collate:
+		return Walk(t.Expr, fn)
 	case Function:
 		for _, p := range t.Params() {
 			if !Walk(p, fn) {
@@ -161,11 +171,16 @@ type NextValueFor struct {
 func (n NextValueFor) Eval(env *environment.Environment) (types.Value, error) {
 	tx := env.GetTx()
 
-	if tx == nil {
+	if tx == nil || tx.Catalog == nil {
 		return NullLiteral, fmt.Errorf(`NEXT VALUE FOR cannot be evaluated`)
 	}
 
-	seq, err := tx.Catalog.GetSequence(n.SeqName)
+	name, err := tx.Catalog.ResolveName(tx, database.RelationSequenceType, n.SeqName)
+	if err != nil {
+		return NullLiteral, err
+	}
+
+	seq, err := tx.Catalog.GetSequence(name)
 	if err != nil {
 		return NullLiteral, err
 	}
@@ -252,8 +267,11 @@ func Clone(e Expr) Expr {
 		}
 	case *Cast:
 		return &Cast{
-			Expr:   Clone(e.Expr),
-			CastAs: e.CastAs,
+			Expr:        Clone(e.Expr),
+			CastAs:      e.CastAs,
+			DecimalSpec: e.DecimalSpec,
+			VectorSpec:  e.VectorSpec,
+			Try:         e.Try,
 		}
 	case LiteralValue,
 		*Column,
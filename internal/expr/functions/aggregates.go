@@ -0,0 +1,466 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+func newVarianceDefinition(name string, pop, sqrt bool) *definition {
+	return &definition{
+		name:  name,
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0], Name: strings.ToUpper(name), Pop: pop, Sqrt: sqrt}, nil
+		},
+	}
+}
+
+// Variance is the aggregate function computing the variance or the
+// standard deviation of the non-null numeric values of a group.
+type Variance struct {
+	Expr expr.Expr
+	// Name of the function, as displayed by String.
+	Name string
+	// If true, the population variance is computed,
+	// otherwise the sample variance.
+	Pop bool
+	// If true, the square root of the variance is returned,
+	// i.e. the standard deviation.
+	Sqrt bool
+}
+
+func (v *Variance) Clone() expr.Expr {
+	return &Variance{
+		Expr: expr.Clone(v.Expr),
+		Name: v.Name,
+		Pop:  v.Pop,
+		Sqrt: v.Sqrt,
+	}
+}
+
+// Eval extracts the result of the aggregation from the given object and returns it.
+func (v *Variance) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", v.Name)
+	}
+
+	return r.Get(v.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (v *Variance) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Variance)
+	if !ok {
+		return false
+	}
+
+	return v.Name == o.Name && expr.Equal(v.Expr, o.Expr)
+}
+
+func (v *Variance) Params() []expr.Expr { return []expr.Expr{v.Expr} }
+
+// String returns the literal representation of the function.
+func (v *Variance) String() string {
+	return fmt.Sprintf("%s(%v)", v.Name, v.Expr)
+}
+
+// Aggregator returns a VarianceAggregator. It implements the AggregatorBuilder interface.
+func (v *Variance) Aggregator() expr.Aggregator {
+	return &VarianceAggregator{
+		Fn: v,
+	}
+}
+
+// VarianceAggregator computes the variance of a group with
+// Welford's online algorithm, which is numerically stable.
+type VarianceAggregator struct {
+	Fn      *Variance
+	Counter int64
+	Mean    float64
+	// sum of the squares of the differences from the mean
+	M2 float64
+}
+
+// Aggregate updates the mean and the sum of squares with the value.
+// Non-numeric values are ignored.
+func (s *VarianceAggregator) Aggregate(env *environment.Environment) error {
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+
+	var x float64
+	switch v.Type() {
+	case types.TypeInteger, types.TypeBigint:
+		x = float64(types.AsInt64(v))
+	case types.TypeDouble:
+		x = types.AsFloat64(v)
+	case types.TypeDecimal:
+		x = v.(types.DecimalValue).Float64()
+	default:
+		return nil
+	}
+
+	s.Counter++
+	delta := x - s.Mean
+	s.Mean += delta / float64(s.Counter)
+	s.M2 += delta * (x - s.Mean)
+
+	return nil
+}
+
+// Eval returns the variance or the standard deviation as a double.
+// It returns NULL if the group has no values, or only one value
+// for the sample variance.
+func (s *VarianceAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	n := s.Counter
+	if !s.Fn.Pop {
+		n--
+	}
+	if n <= 0 {
+		return types.NewNullValue(), nil
+	}
+
+	res := s.M2 / float64(n)
+	if s.Fn.Sqrt {
+		res = math.Sqrt(res)
+	}
+
+	return types.NewDoubleValue(res), nil
+}
+
+func (s *VarianceAggregator) String() string {
+	return s.Fn.String()
+}
+
+func newPercentileDefinition(name string, discrete bool) *definition {
+	return &definition{
+		name:  name,
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Percentile{Fraction: args[0], Discrete: discrete}, nil
+		},
+	}
+}
+
+// Percentile is the PERCENTILE_CONT and PERCENTILE_DISC aggregate functions.
+// They return the value found at the given fraction of the values of
+// a group, ordered by the expression of the WITHIN GROUP clause:
+//
+//	PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY score)
+//
+// PERCENTILE_CONT interpolates between the two nearest numeric values,
+// PERCENTILE_DISC returns the first value whose position is greater
+// or equal to the fraction.
+type Percentile struct {
+	Fraction expr.Expr
+	// Expr is the expression of the WITHIN GROUP clause.
+	// It is set by the parser.
+	Expr     expr.Expr
+	Desc     bool
+	Discrete bool
+}
+
+func (p *Percentile) Clone() expr.Expr {
+	return &Percentile{
+		Fraction: expr.Clone(p.Fraction),
+		Expr:     expr.Clone(p.Expr),
+		Desc:     p.Desc,
+		Discrete: p.Discrete,
+	}
+}
+
+func (p *Percentile) name() string {
+	if p.Discrete {
+		return "PERCENTILE_DISC"
+	}
+
+	return "PERCENTILE_CONT"
+}
+
+// Eval extracts the result of the aggregation from the given object and returns it.
+func (p *Percentile) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", p.name())
+	}
+
+	return r.Get(p.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p *Percentile) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Percentile)
+	if !ok {
+		return false
+	}
+
+	return p.Discrete == o.Discrete && p.Desc == o.Desc &&
+		expr.Equal(p.Fraction, o.Fraction) && expr.Equal(p.Expr, o.Expr)
+}
+
+func (p *Percentile) Params() []expr.Expr {
+	if p.Expr == nil {
+		return []expr.Expr{p.Fraction}
+	}
+
+	return []expr.Expr{p.Fraction, p.Expr}
+}
+
+// String returns the literal representation of the function.
+func (p *Percentile) String() string {
+	s := fmt.Sprintf("%s(%v) WITHIN GROUP (ORDER BY %v", p.name(), p.Fraction, p.Expr)
+	if p.Desc {
+		s += " DESC"
+	}
+
+	return s + ")"
+}
+
+// Aggregator returns a PercentileAggregator. It implements the AggregatorBuilder interface.
+func (p *Percentile) Aggregator() expr.Aggregator {
+	var order tree.SortOrder
+	if p.Desc {
+		order = order.SetDesc(0)
+	}
+
+	return &PercentileAggregator{
+		Fn:     p,
+		values: orderedBuffer{order: order},
+	}
+}
+
+// PercentileAggregator buffers the non-null values of a group in order,
+// in memory until they exceed the work_mem of the transaction, then
+// in a temporary tree. They are replayed when it is evaluated.
+type PercentileAggregator struct {
+	Fn       *Percentile
+	Fraction float64
+
+	started bool
+	count   int
+	values  orderedBuffer
+}
+
+// Aggregate evaluates the fraction on the first row and stores the value.
+func (p *PercentileAggregator) Aggregate(env *environment.Environment) error {
+	if !p.started {
+		f, err := p.Fn.Fraction.Eval(env)
+		if err != nil {
+			return err
+		}
+		if !f.Type().IsNumber() {
+			return errors.Errorf("%s: fraction must be a number, got %s", p.Fn.name(), f.Type())
+		}
+		f, err = f.CastAs(types.TypeDouble)
+		if err != nil {
+			return err
+		}
+		p.Fraction = types.AsFloat64(f)
+		if p.Fraction < 0 || p.Fraction > 1 {
+			return errors.Errorf("%s: fraction %v is not between 0 and 1", p.Fn.name(), p.Fraction)
+		}
+		p.started = true
+	}
+
+	v, err := p.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil || v.Type() == types.TypeNull {
+		return nil
+	}
+
+	if !p.Fn.Discrete {
+		if !v.Type().IsNumber() {
+			return errors.Errorf("%s: cannot interpolate values of type %s", p.Fn.name(), v.Type())
+		}
+		v, err = v.CastAs(types.TypeDouble)
+		if err != nil {
+			return err
+		}
+	}
+
+	value, err := types.EncodeValuesAsKey(nil, v)
+	if err != nil {
+		return err
+	}
+
+	p.count++
+	return p.values.add(env, []types.Value{v}, value)
+}
+
+// Eval returns the percentile, or NULL if the group has no values.
+func (p *PercentileAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if p.count == 0 {
+		return types.NewNullValue(), nil
+	}
+
+	// positions of the values to return or to interpolate
+	var lo, hi int
+	var pos float64
+	if p.Fn.Discrete {
+		lo = max(int(math.Ceil(p.Fraction*float64(p.count)))-1, 0)
+		hi = lo
+	} else {
+		pos = p.Fraction * float64(p.count-1)
+		lo, hi = int(math.Floor(pos)), int(math.Ceil(pos))
+	}
+
+	var a, b types.Value
+	var i int
+	err := p.values.replay(func(value []byte) error {
+		switch i {
+		case lo:
+			a = types.DecodeValues(value)[0]
+			b = a
+		case hi:
+			b = types.DecodeValues(value)[0]
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Fn.Discrete {
+		return a, nil
+	}
+
+	x, y := types.AsFloat64(a), types.AsFloat64(b)
+	return types.NewDoubleValue(x + (y-x)*(pos-float64(lo))), nil
+}
+
+func (p *PercentileAggregator) String() string {
+	return p.Fn.String()
+}
+
+// ArrayAgg is the ARRAY_AGG aggregate function.
+// It returns the values of a group, NULLs included, as a JSON array.
+type ArrayAgg struct {
+	Expr expr.Expr
+}
+
+func (a *ArrayAgg) Clone() expr.Expr {
+	return &ArrayAgg{
+		Expr: expr.Clone(a.Expr),
+	}
+}
+
+// Eval extracts the aggregated array from the given object and returns it.
+func (a *ArrayAgg) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function ARRAY_AGG()")
+	}
+
+	return r.Get(a.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayAgg) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArrayAgg)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(a.Expr, o.Expr)
+}
+
+func (a *ArrayAgg) Params() []expr.Expr { return []expr.Expr{a.Expr} }
+
+// String returns the literal representation of the function.
+func (a *ArrayAgg) String() string {
+	return fmt.Sprintf("ARRAY_AGG(%v)", a.Expr)
+}
+
+// Aggregator returns an ArrayAggAggregator. It implements the AggregatorBuilder interface.
+func (a *ArrayAgg) Aggregator() expr.Aggregator {
+	return &ArrayAggAggregator{
+		Fn: a,
+	}
+}
+
+// ArrayAggAggregator encodes the values of a group as a JSON array.
+type ArrayAggAggregator struct {
+	Fn  *ArrayAgg
+	Buf bytes.Buffer
+}
+
+// Aggregate appends the value to the array.
+func (a *ArrayAggAggregator) Aggregate(env *environment.Environment) error {
+	v, err := a.Fn.Expr.Eval(env)
+	if err != nil {
+		if !errors.Is(err, types.ErrColumnNotFound) {
+			return err
+		}
+		v = types.NewNullValue()
+	}
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	if a.Buf.Len() == 0 {
+		a.Buf.WriteByte('[')
+	} else {
+		a.Buf.WriteByte(',')
+	}
+	a.Buf.Write(data)
+
+	return nil
+}
+
+// Eval returns the JSON array as a text, or NULL if the group is empty.
+func (a *ArrayAggAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if a.Buf.Len() == 0 {
+		return types.NewNullValue(), nil
+	}
+
+	return types.NewTextValue(a.Buf.String() + "]"), nil
+}
+
+func (a *ArrayAggAggregator) String() string {
+	return a.Fn.String()
+}
+
+// aggregateBufferSize returns the memory budget of an aggregate buffering
+// the rows of a group: size if set, or else the work_mem of the transaction,
+// or else def.
+func aggregateBufferSize(env *environment.Environment, size, def int) int {
+	if size > 0 {
+		return size
+	}
+
+	if tx := env.GetTx(); tx != nil && tx.WorkMem > 0 {
+		return tx.WorkMem
+	}
+
+	return def
+}
@@ -0,0 +1,67 @@
+package functions_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name    string
+		workMem int
+	}{
+		{"in memory", 0},
+		{"spill", 64},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+			tx.WorkMem = test.workMem
+
+			eval := func(fn *functions.Percentile) types.Value {
+				var env environment.Environment
+				env.DB = db
+				env.Tx = tx
+
+				agg := fn.Aggregator()
+
+				add := func(v types.Value) {
+					var inner environment.Environment
+					inner.SetOuter(&env)
+					inner.SetRow(row.NewColumnBuffer().Add("a", v))
+					require.NoError(t, agg.Aggregate(&inner))
+				}
+
+				// values from 99 to 0, and a NULL every 10 values
+				for i := 0; i < 100; i++ {
+					add(types.NewIntegerValue(int32(99 - i)))
+					if i%10 == 0 {
+						add(types.NewNullValue())
+					}
+				}
+
+				v, err := agg.Eval(&env)
+				require.NoError(t, err)
+				return v
+			}
+
+			v := eval(&functions.Percentile{Fraction: testutil.DoubleValue(0.25), Expr: &expr.Column{Name: "a"}})
+			require.Equal(t, types.NewDoubleValue(24.75), v)
+
+			v = eval(&functions.Percentile{Fraction: testutil.DoubleValue(0.25), Expr: &expr.Column{Name: "a"}, Desc: true})
+			require.Equal(t, types.NewDoubleValue(74.25), v)
+
+			v = eval(&functions.Percentile{Fraction: testutil.DoubleValue(0.5), Expr: &expr.Column{Name: "a"}, Discrete: true})
+			require.Equal(t, types.NewIntegerValue(49), v)
+		})
+	}
+}
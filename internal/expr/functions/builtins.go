@@ -53,6 +53,28 @@ var builtinFunctions = Definitions{
 			return &Avg{Expr: args[0]}, nil
 		},
 	},
+	"string_agg": &definition{
+		name:  "string_agg",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &StringAgg{Expr: args[0], Sep: args[1]}, nil
+		},
+	},
+	"array_agg": &definition{
+		name:  "array_agg",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &ArrayAgg{Expr: args[0]}, nil
+		},
+	},
+	"variance":        newVarianceDefinition("variance", false, false),
+	"var_samp":        newVarianceDefinition("var_samp", false, false),
+	"var_pop":         newVarianceDefinition("var_pop", true, false),
+	"stddev":          newVarianceDefinition("stddev", false, true),
+	"stddev_samp":     newVarianceDefinition("stddev_samp", false, true),
+	"stddev_pop":      newVarianceDefinition("stddev_pop", true, true),
+	"percentile_cont": newPercentileDefinition("percentile_cont", false),
+	"percentile_disc": newPercentileDefinition("percentile_disc", true),
 	"len": &definition{
 		name:  "len",
 		arity: 1,
@@ -121,6 +143,48 @@ var builtinFunctions = Definitions{
 	"atan2":  atan2,
 	"random": random,
 	"sqrt":   sqrt,
+	"power":  power,
+	"ln":     ln,
+	"log":    log,
+	"exp":    exp,
+	"sign":   sign,
+	"mod":    mod,
+	"round":  round,
+	"trunc":  trunc,
+
+	"date_add":     dateAdd,
+	"date_diff":    dateDiff,
+	"date_trunc":   dateTrunc,
+	"date_part":    datePart,
+	"strftime":     strftime,
+	"strptime":     strptime,
+	"to_timestamp": toTimestamp,
+	"to_date":      toDate,
+	"age": &definition{
+		name:  "age",
+		arity: variadicArity,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Age{Exprs: args}, nil
+		},
+	},
+
+	"regexp_matches": regexpMatches,
+	"regexp_replace": regexpReplace,
+
+	"json_extract":      jsonExtract,
+	"json_set":          jsonSet,
+	"json_remove":       jsonRemove,
+	"json_array_length": jsonArrayLength,
+	"json_type":         jsonType,
+	"json_contains":     jsonContains,
+
+	"uuid":            uuid,
+	"gen_random_uuid": genRandomUUID,
+
+	"vector_distance": vectorDistance,
+
+	"match_number": matchNumber,
+	"classifier":   classifier,
 }
 
 type TypeOf struct {
@@ -530,11 +594,13 @@ type SumAggregator struct {
 	Fn   *Sum
 	SumI *int64
 	SumF *float64
+	SumD *types.DecimalValue
 }
 
 // Aggregate stores the sum of all non-NULL numeric values in the group.
 // The result is an integer value if all summed values are integers.
 // If any of the value is a double, the returned result will be a double.
+// Otherwise, if any of the value is a decimal, the result will be a decimal.
 func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 	v, err := s.Fn.Expr.Eval(env)
 	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
@@ -548,6 +614,8 @@ func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 		switch v.Type() {
 		case types.TypeInteger, types.TypeBigint:
 			*s.SumF += float64(types.AsInt64(v))
+		case types.TypeDecimal:
+			*s.SumF += v.(types.DecimalValue).Float64()
 		default:
 			*s.SumF += float64(types.AsFloat64(v))
 		}
@@ -560,12 +628,29 @@ func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 		if s.SumI != nil {
 			sumF = float64(*s.SumI)
 		}
+		if s.SumD != nil {
+			sumF += s.SumD.Float64()
+		}
 		s.SumF = &sumF
 		*s.SumF += float64(types.AsFloat64(v))
 
 		return nil
 	}
 
+	if v.Type() == types.TypeDecimal {
+		d := v.(types.DecimalValue)
+		if s.SumD != nil {
+			sum, err := s.SumD.Add(d)
+			if err != nil {
+				return err
+			}
+			d = sum.(types.DecimalValue)
+		}
+		s.SumD = &d
+
+		return nil
+	}
+
 	if s.SumI == nil {
 		var sumI int64
 		s.SumI = &sumI
@@ -580,6 +665,12 @@ func (s *SumAggregator) Eval(_ *environment.Environment) (types.Value, error) {
 	if s.SumF != nil {
 		return types.NewDoubleValue(*s.SumF), nil
 	}
+	if s.SumD != nil {
+		if s.SumI != nil {
+			return s.SumD.Add(types.NewBigintValue(*s.SumI))
+		}
+		return *s.SumD, nil
+	}
 	if s.SumI != nil {
 		return types.NewBigintValue(*s.SumI), nil
 	}
@@ -661,6 +752,8 @@ func (s *AvgAggregator) Aggregate(env *environment.Environment) error {
 		s.Avg += float64(types.AsInt64(v))
 	case types.TypeDouble:
 		s.Avg += types.AsFloat64(v)
+	case types.TypeDecimal:
+		s.Avg += v.(types.DecimalValue).Float64()
 	default:
 		return nil
 	}
@@ -682,6 +775,113 @@ func (s *AvgAggregator) String() string {
 	return s.Fn.String()
 }
 
+// StringAgg is the STRING_AGG aggregator function.
+// It concatenates the non-null values of a group, separated by a delimiter.
+type StringAgg struct {
+	Expr expr.Expr
+	Sep  expr.Expr
+}
+
+func (s *StringAgg) Clone() expr.Expr {
+	return &StringAgg{
+		Expr: expr.Clone(s.Expr),
+		Sep:  expr.Clone(s.Sep),
+	}
+}
+
+// Eval extracts the concatenated value from the given object and returns it.
+func (s *StringAgg) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function STRING_AGG()")
+	}
+
+	return r.Get(s.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *StringAgg) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*StringAgg)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(s.Expr, o.Expr) && expr.Equal(s.Sep, o.Sep)
+}
+
+func (s *StringAgg) Params() []expr.Expr { return []expr.Expr{s.Expr, s.Sep} }
+
+// String returns the literal representation of the function.
+func (s *StringAgg) String() string {
+	return fmt.Sprintf("STRING_AGG(%v, %v)", s.Expr, s.Sep)
+}
+
+// Aggregator returns a StringAggAggregator. It implements the AggregatorBuilder interface.
+func (s *StringAgg) Aggregator() expr.Aggregator {
+	return &StringAggAggregator{
+		Fn: s,
+	}
+}
+
+// StringAggAggregator is an aggregator that concatenates non-null values.
+type StringAggAggregator struct {
+	Fn    *StringAgg
+	Buf   strings.Builder
+	Valid bool
+}
+
+// Aggregate appends the value to the buffer, preceded by the delimiter
+// if it is not the first value. Non-text values are converted to text.
+func (s *StringAggAggregator) Aggregate(env *environment.Environment) error {
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil || v.Type() == types.TypeNull {
+		return nil
+	}
+
+	if v.Type() != types.TypeText {
+		v, err = v.CastAs(types.TypeText)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Valid {
+		sep, err := s.Fn.Sep.Eval(env)
+		if err != nil {
+			return err
+		}
+		if sep.Type() == types.TypeText {
+			s.Buf.WriteString(types.AsString(sep))
+		}
+	}
+
+	s.Valid = true
+	s.Buf.WriteString(types.AsString(v))
+	return nil
+}
+
+// Eval returns the concatenated text, or NULL if the group didn't
+// contain any non-null value.
+func (s *StringAggAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if !s.Valid {
+		return types.NewNullValue(), nil
+	}
+
+	return types.NewTextValue(s.Buf.String()), nil
+}
+
+func (s *StringAggAggregator) String() string {
+	return s.Fn.String()
+}
+
 // Len represents the len() function.
 // It returns the length of string, array or row.
 // For other types len() returns NULL.
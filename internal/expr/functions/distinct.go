@@ -0,0 +1,208 @@
+package functions
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultDistinctAggregateBufferSize is the number of bytes a distinct aggregate
+// uses in memory, per group, to remember the values it has seen, before
+// moving them to a temporary tree.
+const DefaultDistinctAggregateBufferSize = 4 << 20
+
+var _ expr.AggregatorBuilder = (*DistinctAggregate)(nil)
+
+// DistinctAggregate wraps an aggregate function to only aggregate
+// the rows whose first argument has not been seen before in the group:
+//
+//	COUNT(DISTINCT name)
+type DistinctAggregate struct {
+	Fn expr.AggregatorBuilder
+
+	// BufferSize is the memory budget of each group, in bytes.
+	// If zero, the work_mem of the transaction is used, or
+	// DefaultDistinctAggregateBufferSize if it is not set.
+	BufferSize int
+}
+
+func (d *DistinctAggregate) Clone() expr.Expr {
+	return &DistinctAggregate{
+		Fn:         expr.Clone(d.Fn).(expr.AggregatorBuilder),
+		BufferSize: d.BufferSize,
+	}
+}
+
+// Eval extracts the result of the aggregation from the row.
+func (d *DistinctAggregate) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s", d.Fn)
+	}
+
+	return r.Get(d.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (d *DistinctAggregate) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*DistinctAggregate)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(d.Fn, o.Fn)
+}
+
+// Params returns the parameters of the wrapped function.
+func (d *DistinctAggregate) Params() []expr.Expr {
+	if fn, ok := d.Fn.(expr.Function); ok {
+		return fn.Params()
+	}
+
+	return nil
+}
+
+// String returns the literal representation of the function,
+// with DISTINCT before its arguments.
+func (d *DistinctAggregate) String() string {
+	s := d.Fn.String()
+	i := strings.IndexByte(s, '(')
+	if i < 0 {
+		return s
+	}
+
+	return s[:i+1] + "DISTINCT " + s[i+1:]
+}
+
+// Aggregator returns a DistinctAggregator. It implements the AggregatorBuilder interface.
+func (d *DistinctAggregate) Aggregator() expr.Aggregator {
+	return &DistinctAggregator{
+		Fn:         d,
+		Aggregator: d.Fn.Aggregator(),
+		seen:       make(map[string]*tree.Key),
+	}
+}
+
+// DistinctAggregator delegates the aggregation of the rows of a group
+// to the wrapped aggregator, skipping the rows whose value was already aggregated.
+// The values are remembered in memory until they exceed the budget,
+// after which they are moved to a temporary tree.
+type DistinctAggregator struct {
+	Fn         *DistinctAggregate
+	Aggregator expr.Aggregator
+
+	seen       map[string]*tree.Key
+	bufferSize int
+	size       int
+
+	temp    *tree.Tree
+	cleanup func() error
+}
+
+// Aggregate calls the wrapped aggregator if the value of the first argument
+// of the function hasn't been seen before.
+func (d *DistinctAggregator) Aggregate(env *environment.Environment) error {
+	params := d.Fn.Params()
+	if len(params) == 0 {
+		return d.Aggregator.Aggregate(env)
+	}
+
+	v, err := params[0].Eval(env)
+	if err != nil {
+		if !errors.Is(err, types.ErrColumnNotFound) {
+			return err
+		}
+		v = types.NewNullValue()
+	}
+
+	k := tree.NewKey(v)
+
+	if d.temp != nil {
+		ok, err := d.temp.Exists(k)
+		if err != nil || ok {
+			return err
+		}
+
+		err = d.temp.Put(k, nil)
+		if err != nil {
+			return err
+		}
+
+		return d.Aggregator.Aggregate(env)
+	}
+
+	enc, err := k.Encode(0, 0)
+	if err != nil {
+		return err
+	}
+	if _, ok := d.seen[string(enc)]; ok {
+		return nil
+	}
+
+	if d.bufferSize == 0 {
+		d.bufferSize = aggregateBufferSize(env, d.Fn.BufferSize, DefaultDistinctAggregateBufferSize)
+	}
+
+	d.seen[string(enc)] = k
+	d.size += len(enc)
+	if d.size > d.bufferSize {
+		err = d.spill(env)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.Aggregator.Aggregate(env)
+}
+
+// spill moves the values seen so far to a temporary tree.
+func (d *DistinctAggregator) spill(env *environment.Environment) error {
+	tx := env.GetTx()
+	if tx == nil {
+		return errors.New("distinct aggregate exceeded its memory budget and cannot spill outside of a transaction")
+	}
+
+	temp, cleanup, err := tree.NewTransient(tx.NewTransientSession(), tx.Catalog.GetFreeTransientNamespace(), 0)
+	if err != nil {
+		return err
+	}
+	d.temp, d.cleanup = temp, cleanup
+
+	for _, k := range d.seen {
+		// the key was encoded without namespace, encode it again for the tree
+		k.Encoded = nil
+		err = d.temp.Put(k, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.seen = nil
+	d.size = 0
+	return nil
+}
+
+// Eval returns the result of the wrapped aggregator.
+func (d *DistinctAggregator) Eval(env *environment.Environment) (types.Value, error) {
+	if d.temp != nil {
+		defer func() {
+			_ = d.cleanup()
+			d.temp, d.cleanup = nil, nil
+		}()
+	}
+
+	return d.Aggregator.Eval(env)
+}
+
+func (d *DistinctAggregator) String() string {
+	return d.Fn.String()
+}
@@ -0,0 +1,62 @@
+package functions_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistinctAggregate(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+	}{
+		{"in memory", 0},
+		{"spill", 64},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			fn := &functions.DistinctAggregate{
+				Fn:         &functions.Sum{Expr: &expr.Column{Name: "a"}},
+				BufferSize: test.bufferSize,
+			}
+
+			var env environment.Environment
+			env.DB = db
+			env.Tx = tx
+
+			agg := fn.Aggregator()
+
+			// each value from 0 to 49 is aggregated 3 times
+			for i := 0; i < 150; i++ {
+				var inner environment.Environment
+				inner.SetOuter(&env)
+				inner.SetRow(row.NewColumnBuffer().
+					Add("a", types.NewIntegerValue(int32(i%50))))
+				require.NoError(t, agg.Aggregate(&inner))
+			}
+
+			v, err := agg.Eval(&env)
+			require.NoError(t, err)
+			require.Equal(t, types.NewBigintValue(49*50/2), v)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		fn := &functions.DistinctAggregate{
+			Fn: &functions.StringAgg{Expr: &expr.Column{Name: "a"}, Sep: testutil.TextValue(",")},
+		}
+
+		require.Equal(t, `STRING_AGG(DISTINCT a, ",")`, fn.String())
+	})
+}
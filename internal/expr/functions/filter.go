@@ -0,0 +1,110 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var _ expr.AggregatorBuilder = (*FilterAggregate)(nil)
+
+// FilterAggregate wraps an aggregate function to only aggregate
+// the rows that satisfy the FILTER clause:
+//
+//	COUNT(*) FILTER (WHERE status = 'x')
+type FilterAggregate struct {
+	Fn     expr.AggregatorBuilder
+	Filter expr.Expr
+}
+
+func (f *FilterAggregate) Clone() expr.Expr {
+	return &FilterAggregate{
+		Fn:     expr.Clone(f.Fn).(expr.AggregatorBuilder),
+		Filter: expr.Clone(f.Filter),
+	}
+}
+
+// Eval extracts the result of the aggregation from the row.
+func (f *FilterAggregate) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s", f.Fn)
+	}
+
+	return r.Get(f.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *FilterAggregate) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*FilterAggregate)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(f.Fn, o.Fn) && expr.Equal(f.Filter, o.Filter)
+}
+
+// Params returns the parameters of the wrapped function and the filter.
+func (f *FilterAggregate) Params() []expr.Expr {
+	var params []expr.Expr
+	if fn, ok := f.Fn.(expr.Function); ok {
+		params = append(params, fn.Params()...)
+	}
+
+	return append(params, f.Filter)
+}
+
+func (f *FilterAggregate) String() string {
+	return fmt.Sprintf("%s FILTER (WHERE %s)", f.Fn, f.Filter)
+}
+
+// Aggregator returns an aggregator that only aggregates rows
+// for which the filter is truthy. It implements the AggregatorBuilder interface.
+func (f *FilterAggregate) Aggregator() expr.Aggregator {
+	return &FilterAggregator{
+		Fn:         f,
+		Aggregator: f.Fn.Aggregator(),
+	}
+}
+
+// FilterAggregator evaluates the filter of a FilterAggregate for every row
+// and delegates the aggregation of matching rows to the wrapped aggregator.
+type FilterAggregator struct {
+	Fn         *FilterAggregate
+	Aggregator expr.Aggregator
+}
+
+// Aggregate calls the wrapped aggregator if the filter evaluates to a truthy value.
+func (f *FilterAggregator) Aggregate(env *environment.Environment) error {
+	v, err := f.Fn.Filter.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+
+	ok, err := types.IsTruthy(v)
+	if err != nil || !ok {
+		return err
+	}
+
+	return f.Aggregator.Aggregate(env)
+}
+
+// Eval returns the result of the wrapped aggregator.
+func (f *FilterAggregator) Eval(env *environment.Environment) (types.Value, error) {
+	return f.Aggregator.Eval(env)
+}
+
+func (f *FilterAggregator) String() string {
+	return f.Fn.String()
+}
@@ -0,0 +1,699 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The JSON functions operate on texts holding JSON values.
+// Elements are designated by paths starting with $, the whole value,
+// followed by .key or ."key" to select the member of an object
+// and by [n] to select the nth element of an array:
+//
+//	$.name
+//	$.tags[0]
+//	$."first name"
+//
+// json_set also accepts [#] to append an element to an array.
+// json_extract and json_contains also accept the wildcards [*], every
+// element of an array, and .*, every member of an object, which expand
+// the path into all the elements it matches:
+//
+//	$.items[*].price
+//	$.*.name
+
+// jsonExtract returns the element of arg1 designated by the path arg2,
+// or NULL if there is none. Strings, numbers and booleans are returned
+// as SQL values, objects and arrays as JSON texts.
+// If the path has wildcards, the elements it matches are returned
+// as a JSON array, or NULL if there is none.
+//
+//	json_extract('{"a": [1, 2]}', '$.a[1]') -> 2
+//	json_extract('{"a": [1, 2]}', '$.a') -> '[1,2]'
+//	json_extract('{"a": [{"b": 1}, {"b": 2}]}', '$.a[*].b') -> '[1,2]'
+var jsonExtract = &ScalarDefinition{
+	name:  "json_extract",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		doc, path, err := jsonArgs("json_extract", args[0], args[1], true)
+		if err != nil {
+			return nil, err
+		}
+
+		if path.hasWildcard() {
+			matches := path.getAll(doc)
+			if len(matches) == 0 {
+				return types.NewNullValue(), nil
+			}
+			return types.NewTextValue(encodeJSON(matches)), nil
+		}
+
+		v, ok := path.get(doc)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		return jsonToValue(v), nil
+	},
+}
+
+// jsonSet returns arg1 with the element designated by the path arg2
+// replaced by arg3, or added if the path designates a missing member
+// of an object or the end of an array. Texts are set as JSON strings.
+// arg1 is returned unchanged if the parent of the element doesn't exist.
+//
+//	json_set('{"a": 1}', '$.b', 'x') -> '{"a":1,"b":"x"}'
+//	json_set('[1, 2]', '$[#]', 3) -> '[1,2,3]'
+var jsonSet = &ScalarDefinition{
+	name:  "json_set",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() == types.TypeNull || args[1].Type() == types.TypeNull {
+			return types.NewNullValue(), nil
+		}
+
+		doc, path, err := jsonArgs("json_set", args[0], args[1], false)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := valueToJSON(args[2])
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewTextValue(encodeJSON(path.set(doc, v))), nil
+	},
+}
+
+// jsonRemove returns arg1 without the elements designated by the
+// following paths. Missing elements are ignored.
+//
+//	json_remove('{"a": 1, "b": 2}', '$.a') -> '{"b":2}'
+var jsonRemove = &ScalarDefinition{
+	name:  "json_remove",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) < 2 {
+			return nil, errors.New("json_remove() takes at least 2 arguments")
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		doc, err := jsonDocArg("json_remove", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, arg := range args[1:] {
+			path, err := jsonPathArg("json_remove", arg, false)
+			if err != nil {
+				return nil, err
+			}
+			if len(path) == 0 {
+				return nil, errors.New("json_remove() cannot remove the whole value")
+			}
+
+			doc = path.remove(doc)
+		}
+
+		return types.NewTextValue(encodeJSON(doc)), nil
+	},
+}
+
+// jsonArrayLength returns the number of elements of the array arg1,
+// or of the array designated by the optional path arg2.
+// It returns 0 if the value is not an array and NULL if the path
+// designates no element.
+//
+//	json_array_length('[1, 2, 3]') -> 3
+//	json_array_length('{"a": [1]}', '$.a') -> 1
+var jsonArrayLength = &ScalarDefinition{
+	name:  "json_array_length",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		v, ok, err := jsonOptionalPathArgs("json_array_length", args)
+		if err != nil || !ok {
+			return types.NewNullValue(), err
+		}
+
+		a, _ := v.([]any)
+		return types.NewBigintValue(int64(len(a))), nil
+	},
+}
+
+// jsonType returns the type of arg1, or of the element designated by the
+// optional path arg2: 'object', 'array', 'integer', 'real', 'text', 'true',
+// 'false' or 'null'. It returns NULL if the path designates no element.
+//
+//	json_type('{"a": [1, 2.5]}', '$.a[1]') -> 'real'
+var jsonType = &ScalarDefinition{
+	name:  "json_type",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		v, ok, err := jsonOptionalPathArgs("json_type", args)
+		if err != nil || !ok {
+			return types.NewNullValue(), err
+		}
+
+		var t string
+		switch x := v.(type) {
+		case nil:
+			t = "null"
+		case bool:
+			t = strconv.FormatBool(x)
+		case json.Number:
+			t = "real"
+			if _, err := x.Int64(); err == nil {
+				t = "integer"
+			}
+		case string:
+			t = "text"
+		case []any:
+			t = "array"
+		case jsonObject:
+			t = "object"
+		}
+
+		return types.NewTextValue(t), nil
+	},
+}
+
+// jsonContains returns whether one of the elements of arg1 designated by
+// the path arg2, usually with wildcards, is equal to arg3. Strings, numbers
+// and booleans are compared as SQL values, objects and arrays as JSON texts.
+//
+//	json_contains('{"tags": ["red", "blue"]}', '$.tags[*]', 'red') -> true
+//	json_contains('{"items": [{"price": 5}]}', '$.items[*].price', 10) -> false
+var jsonContains = &ScalarDefinition{
+	name:  "json_contains",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		doc, path, err := jsonArgs("json_contains", args[0], args[1], true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range path.getAll(doc) {
+			ok, err := jsonToValue(e).EQ(args[2])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return types.NewBooleanValue(true), nil
+			}
+		}
+
+		return types.NewBooleanValue(false), nil
+	},
+}
+
+// jsonArgs returns the JSON value and the path passed to a function.
+// Paths with wildcards are rejected unless allowed.
+func jsonArgs(name string, doc, path types.Value, wildcards bool) (any, jsonPath, error) {
+	d, err := jsonDocArg(name, doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := jsonPathArg(name, path, wildcards)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return d, p, nil
+}
+
+// jsonOptionalPathArgs returns the element designated by the
+// optional path of a function taking a JSON text and a path.
+func jsonOptionalPathArgs(name string, args []types.Value) (any, bool, error) {
+	if len(args) > 2 {
+		return nil, false, fmt.Errorf("%s() takes 1 or 2 arguments, not %d", name, len(args))
+	}
+	if hasNull(args...) {
+		return nil, false, nil
+	}
+
+	doc, err := jsonDocArg(name, args[0])
+	if err != nil || len(args) == 1 {
+		return doc, err == nil, err
+	}
+
+	path, err := jsonPathArg(name, args[1], false)
+	if err != nil {
+		return nil, false, err
+	}
+
+	v, ok := path.get(doc)
+	return v, ok, nil
+}
+
+func jsonDocArg(name string, v types.Value) (any, error) {
+	if v.Type() != types.TypeText {
+		return nil, fmt.Errorf("%s() expects a JSON text, got %s", name, v.Type())
+	}
+
+	return parseJSON(types.AsString(v))
+}
+
+func jsonPathArg(name string, v types.Value, wildcards bool) (jsonPath, error) {
+	if v.Type() != types.TypeText {
+		return nil, fmt.Errorf("%s() expects a text path, got %s", name, v.Type())
+	}
+
+	p, err := parseJSONPath(types.AsString(v))
+	if err != nil {
+		return nil, err
+	}
+	if !wildcards && p.hasWildcard() {
+		return nil, fmt.Errorf("%s() doesn't accept wildcards in paths", name)
+	}
+
+	return p, nil
+}
+
+// jsonObject is a JSON object whose members are kept in order.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	Key   string
+	Value any
+}
+
+func (o jsonObject) index(key string) int {
+	for i := range o {
+		if o[i].Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// parseJSON parses s into nil, bool, json.Number, string,
+// []any or jsonObject values.
+func parseJSON(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	v, err := decodeJSON(dec)
+	if err != nil {
+		return nil, errors.New("malformed JSON")
+	}
+
+	// nothing must follow the value
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("malformed JSON")
+	}
+
+	return v, nil
+}
+
+func decodeJSON(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('['):
+		a := []any{}
+		for dec.More() {
+			v, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err = dec.Token()
+		return a, err
+	case json.Delim('{'):
+		o := jsonObject{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			// the last duplicate key wins
+			if i := o.index(k.(string)); i >= 0 {
+				o[i].Value = v
+			} else {
+				o = append(o, jsonMember{Key: k.(string), Value: v})
+			}
+		}
+		_, err = dec.Token()
+		return o, err
+	}
+
+	return tok, nil
+}
+
+// encodeJSON returns the compact JSON representation of v.
+func encodeJSON(v any) string {
+	var buf bytes.Buffer
+	writeJSON(&buf, v)
+	return buf.String()
+}
+
+func writeJSON(buf *bytes.Buffer, v any) {
+	switch x := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(x))
+	case json.Number:
+		buf.WriteString(x.String())
+	case string:
+		writeJSONString(buf, x)
+	case []any:
+		buf.WriteByte('[')
+		for i, e := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSON(buf, e)
+		}
+		buf.WriteByte(']')
+	case jsonObject:
+		buf.WriteByte('{')
+		for i, m := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, m.Key)
+			buf.WriteByte(':')
+			writeJSON(buf, m.Value)
+		}
+		buf.WriteByte('}')
+	}
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	// remove the newline added by Encode
+	buf.Truncate(buf.Len() - 1)
+}
+
+// jsonToValue converts a JSON value to a SQL value.
+func jsonToValue(v any) types.Value {
+	switch x := v.(type) {
+	case nil:
+		return types.NewNullValue()
+	case bool:
+		return types.NewBooleanValue(x)
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return types.NewBigintValue(i)
+		}
+		f, _ := x.Float64()
+		return types.NewDoubleValue(f)
+	case string:
+		return types.NewTextValue(x)
+	}
+
+	return types.NewTextValue(encodeJSON(v))
+}
+
+// valueToJSON converts a SQL value to a JSON value.
+// Values other than numbers and booleans are converted to strings.
+func valueToJSON(v types.Value) (any, error) {
+	switch v.Type() {
+	case types.TypeNull:
+		return nil, nil
+	case types.TypeBoolean:
+		return types.AsBool(v), nil
+	case types.TypeInteger, types.TypeBigint:
+		return json.Number(strconv.FormatInt(types.AsInt64(v), 10)), nil
+	case types.TypeDouble:
+		f := types.AsFloat64(v)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("cannot convert %v to JSON", f)
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	case types.TypeDecimal:
+		s, err := v.CastAs(types.TypeText)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(types.AsString(s)), nil
+	}
+
+	s, err := v.CastAs(types.TypeText)
+	if err != nil {
+		return nil, err
+	}
+	return types.AsString(s), nil
+}
+
+// jsonPath designates an element of a JSON value.
+type jsonPath []jsonPathStep
+
+// jsonPathStep selects the member key of an object,
+// or the element index of an array if key is empty.
+// An index of -1 designates the end of the array.
+// Wildcard steps select every member of an object,
+// or every element of an array if key is empty.
+type jsonPathStep struct {
+	key      string
+	index    int
+	wildcard bool
+}
+
+func (s jsonPathStep) isIndex() bool {
+	return s.key == ""
+}
+
+// parseJSONPath parses paths of the form $.a."b c"[0][*].*.
+func parseJSONPath(s string) (jsonPath, error) {
+	bad := fmt.Errorf("bad JSON path: %q", s)
+	if !strings.HasPrefix(s, "$") {
+		return nil, bad
+	}
+
+	var path jsonPath
+	rest := s[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			var key string
+			quoted := strings.HasPrefix(rest, `"`)
+			if quoted {
+				end := strings.IndexByte(rest[1:], '"')
+				if end < 0 {
+					return nil, bad
+				}
+				key, rest = rest[1:end+1], rest[end+2:]
+			} else {
+				end := strings.IndexAny(rest, ".[")
+				if end < 0 {
+					end = len(rest)
+				}
+				key, rest = rest[:end], rest[end:]
+			}
+			if key == "" {
+				return nil, bad
+			}
+			path = append(path, jsonPathStep{key: key, wildcard: key == "*" && !quoted})
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, bad
+			}
+			idx := rest[1:end]
+			rest = rest[end+1:]
+			if idx == "#" {
+				path = append(path, jsonPathStep{index: -1})
+				continue
+			}
+			if idx == "*" {
+				path = append(path, jsonPathStep{wildcard: true})
+				continue
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, bad
+			}
+			path = append(path, jsonPathStep{index: n})
+		default:
+			return nil, bad
+		}
+	}
+
+	return path, nil
+}
+
+// get returns the element designated by the path.
+func (p jsonPath) get(v any) (any, bool) {
+	for _, s := range p {
+		var ok bool
+		v, ok = s.get(v)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func (s jsonPathStep) get(v any) (any, bool) {
+	if s.wildcard {
+		return nil, false
+	}
+
+	if s.isIndex() {
+		a, ok := v.([]any)
+		if !ok || s.index < 0 || s.index >= len(a) {
+			return nil, false
+		}
+		return a[s.index], true
+	}
+
+	o, ok := v.(jsonObject)
+	if !ok {
+		return nil, false
+	}
+	i := o.index(s.key)
+	if i < 0 {
+		return nil, false
+	}
+	return o[i].Value, true
+}
+
+// hasWildcard returns whether the path designates several elements.
+func (p jsonPath) hasWildcard() bool {
+	for _, s := range p {
+		if s.wildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getAll returns the elements designated by a path with wildcards,
+// in the order in which they appear in v.
+func (p jsonPath) getAll(v any) []any {
+	if len(p) == 0 {
+		return []any{v}
+	}
+
+	s := p[0]
+	if !s.wildcard {
+		v, ok := s.get(v)
+		if !ok {
+			return nil
+		}
+		return p[1:].getAll(v)
+	}
+
+	var all []any
+	switch x := v.(type) {
+	case []any:
+		if s.isIndex() {
+			for _, e := range x {
+				all = append(all, p[1:].getAll(e)...)
+			}
+		}
+	case jsonObject:
+		if !s.isIndex() {
+			for _, m := range x {
+				all = append(all, p[1:].getAll(m.Value)...)
+			}
+		}
+	}
+
+	return all
+}
+
+// set returns doc with the element designated by the path set to v.
+func (p jsonPath) set(doc, v any) any {
+	if len(p) == 0 {
+		return v
+	}
+
+	parent, ok := p[:len(p)-1].get(doc)
+	if !ok {
+		return doc
+	}
+
+	s := p[len(p)-1]
+	switch x := parent.(type) {
+	case []any:
+		if !s.isIndex() || s.index > len(x) {
+			return doc
+		}
+		if s.index < 0 || s.index == len(x) {
+			x = append(x, v)
+		} else {
+			x[s.index] = v
+		}
+		parent = x
+	case jsonObject:
+		if s.isIndex() {
+			return doc
+		}
+		if i := x.index(s.key); i >= 0 {
+			x[i].Value = v
+		} else {
+			x = append(x, jsonMember{Key: s.key, Value: v})
+		}
+		parent = x
+	default:
+		return doc
+	}
+
+	// slices may have been reallocated, replace the parent
+	return p[:len(p)-1].set(doc, parent)
+}
+
+// remove returns doc without the element designated by the path.
+func (p jsonPath) remove(doc any) any {
+	parent, ok := p[:len(p)-1].get(doc)
+	if !ok {
+		return doc
+	}
+
+	s := p[len(p)-1]
+	switch x := parent.(type) {
+	case []any:
+		if !s.isIndex() || s.index < 0 || s.index >= len(x) {
+			return doc
+		}
+		parent = append(x[:s.index:s.index], x[s.index+1:]...)
+	case jsonObject:
+		i := x.index(s.key)
+		if s.isIndex() || i < 0 {
+			return doc
+		}
+		parent = append(x[:i:i], x[i+1:]...)
+	default:
+		return doc
+	}
+
+	return p[:len(p)-1].set(doc, parent)
+}
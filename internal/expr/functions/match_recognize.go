@@ -0,0 +1,70 @@
+package functions
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	matchNumber = newMatchRecognizeDefinition("match_number", rows.MatchNumberColumn)
+	classifier  = newMatchRecognizeDefinition("classifier", rows.ClassifierColumn)
+)
+
+func newMatchRecognizeDefinition(name, column string) *definition {
+	return &definition{
+		name:  name,
+		arity: 0,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &MatchRecognizeValue{Name: name, Column: column}, nil
+		},
+	}
+}
+
+// MatchRecognizeValue is a function returning a value computed for the rows
+// returned by MATCH_RECOGNIZE:
+//
+//	MATCH_NUMBER(): the number of the match of the row in its partition
+//	CLASSIFIER(): the symbol of the pattern that matched the row
+type MatchRecognizeValue struct {
+	Name string
+	// Column of the row holding the value.
+	Column string
+}
+
+func (m *MatchRecognizeValue) Clone() expr.Expr {
+	return &MatchRecognizeValue{Name: m.Name, Column: m.Column}
+}
+
+func (m *MatchRecognizeValue) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of %s", m)
+	}
+
+	v, err := r.Get(m.Column)
+	if errors.Is(err, types.ErrColumnNotFound) {
+		return nil, errors.Errorf("%s can only be used with MATCH_RECOGNIZE", m)
+	}
+
+	return v, err
+}
+
+func (m *MatchRecognizeValue) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*MatchRecognizeValue)
+	return ok && o.Name == m.Name
+}
+
+func (m *MatchRecognizeValue) Params() []expr.Expr { return nil }
+
+func (m *MatchRecognizeValue) String() string {
+	return strings.ToUpper(m.Name) + "()"
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"strings"
 
 	"github.com/chaisql/chai/internal/types"
 )
@@ -30,6 +31,12 @@ var abs = &ScalarDefinition{
 		if args[0].Type() == types.TypeNull {
 			return types.NewNullValue(), nil
 		}
+		if d, ok := args[0].(types.DecimalValue); ok {
+			if neg, _ := d.LT(types.NewIntegerValue(0)); neg {
+				return d.Mul(types.NewIntegerValue(-1))
+			}
+			return d, nil
+		}
 		v, err := args[0].CastAs(types.TypeDouble)
 		if err != nil {
 			return nil, err
@@ -176,3 +183,223 @@ var sqrt = &ScalarDefinition{
 		return types.NewDoubleValue(res), nil
 	},
 }
+
+var power = &ScalarDefinition{
+	name:  "power",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		vA, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || vA.Type() == types.TypeNull {
+			return vA, err
+		}
+		vB, err := args[1].CastAs(types.TypeDouble)
+		if err != nil || vB.Type() == types.TypeNull {
+			return vB, err
+		}
+		res := math.Pow(types.AsFloat64(vA), types.AsFloat64(vB))
+		return types.NewDoubleResult(res)
+	},
+}
+
+var ln = &ScalarDefinition{
+	name:  "ln",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		v, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		vv := types.AsFloat64(v)
+		if vv <= 0 {
+			return nil, fmt.Errorf("out of range, ln(arg1) expects arg1 > 0")
+		}
+		return types.NewDoubleValue(math.Log(vv)), nil
+	},
+}
+
+// log returns the base 10 logarithm of its argument when called
+// with one argument, or the logarithm of arg2 in base arg1 when
+// called with two.
+var log = &ScalarDefinition{
+	name:  "log",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) > 2 {
+			return nil, fmt.Errorf("log() takes 1 or 2 arguments, not %d", len(args))
+		}
+
+		v, err := args[len(args)-1].CastAs(types.TypeDouble)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		vv := types.AsFloat64(v)
+		if vv <= 0 {
+			return nil, fmt.Errorf("out of range, log() expects its argument to be > 0")
+		}
+
+		if len(args) == 1 {
+			return types.NewDoubleValue(math.Log10(vv)), nil
+		}
+
+		b, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || b.Type() == types.TypeNull {
+			return b, err
+		}
+		bb := types.AsFloat64(b)
+		if bb <= 0 || bb == 1 {
+			return nil, fmt.Errorf("out of range, log(arg1, arg2) expects arg1 > 0 and arg1 != 1")
+		}
+
+		return types.NewDoubleValue(math.Log(vv) / math.Log(bb)), nil
+	},
+}
+
+var exp = &ScalarDefinition{
+	name:  "exp",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		v, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		return types.NewDoubleResult(math.Exp(types.AsFloat64(v)))
+	},
+}
+
+var sign = &ScalarDefinition{
+	name:  "sign",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		switch args[0].Type() {
+		case types.TypeNull:
+			return args[0], nil
+		case types.TypeInteger, types.TypeBigint:
+			x := types.AsInt64(args[0])
+			var s int64
+			switch {
+			case x > 0:
+				s = 1
+			case x < 0:
+				s = -1
+			}
+			return types.NewBigintValue(s).CastAs(args[0].Type())
+		}
+
+		v, err := args[0].CastAs(types.TypeDouble)
+		if err != nil {
+			return nil, err
+		}
+		x := types.AsFloat64(v)
+		switch {
+		case x > 0:
+			return types.NewDoubleValue(1), nil
+		case x < 0:
+			return types.NewDoubleValue(-1), nil
+		}
+		return types.NewDoubleValue(0), nil
+	},
+}
+
+// mod returns the remainder of arg1 divided by arg2.
+// It follows the same rules as the % operator.
+var mod = &ScalarDefinition{
+	name:  "mod",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		a, ok := args[0].(types.Numeric)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+		b, ok := args[1].(types.Numeric)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+		return a.Mod(b)
+	},
+}
+
+// round rounds arg1 to arg2 decimal places (0 by default).
+// Halfway values are rounded away from zero, unless the third argument
+// is 'half_even', in which case they are rounded to the nearest even digit.
+var round = &ScalarDefinition{
+	name:  "round",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) > 3 {
+			return nil, fmt.Errorf("round() takes 1 to 3 arguments, not %d", len(args))
+		}
+
+		roundFn := math.Round
+		if len(args) == 3 {
+			if args[2].Type() != types.TypeText {
+				return nil, fmt.Errorf("round(arg1, arg2, arg3) expects arg3 to be a text")
+			}
+			switch strings.ToLower(types.AsString(args[2])) {
+			case "half_away_from_zero":
+			case "half_even":
+				roundFn = math.RoundToEven
+			default:
+				return nil, fmt.Errorf("unknown rounding mode %q", types.AsString(args[2]))
+			}
+		}
+
+		return roundNumber("round", roundFn, args[:min(len(args), 2)]...)
+	},
+}
+
+// trunc truncates arg1 toward zero to arg2 decimal places (0 by default).
+var trunc = &ScalarDefinition{
+	name:  "trunc",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) > 2 {
+			return nil, fmt.Errorf("trunc() takes 1 or 2 arguments, not %d", len(args))
+		}
+
+		return roundNumber("trunc", math.Trunc, args...)
+	},
+}
+
+// roundNumber applies fn to args[0] scaled to args[1] decimal places.
+// Integers are returned unchanged unless the number of decimal places
+// is negative.
+func roundNumber(name string, fn func(float64) float64, args ...types.Value) (types.Value, error) {
+	if args[0].Type() == types.TypeNull {
+		return args[0], nil
+	}
+
+	var places int64
+	if len(args) == 2 {
+		if args[1].Type() == types.TypeNull {
+			return args[1], nil
+		}
+		if args[1].Type() != types.TypeInteger && args[1].Type() != types.TypeBigint {
+			return nil, fmt.Errorf("%s(arg1, arg2) expects arg2 to be an integer", name)
+		}
+		places = types.AsInt64(args[1])
+	}
+
+	isInt := args[0].Type() == types.TypeInteger || args[0].Type() == types.TypeBigint
+	if isInt && places >= 0 {
+		return args[0], nil
+	}
+
+	v, err := args[0].CastAs(types.TypeDouble)
+	if err != nil {
+		return nil, err
+	}
+	x := types.AsFloat64(v)
+
+	scale := math.Pow10(int(places))
+	res := fn(x*scale) / scale
+	if math.IsInf(x*scale, 0) {
+		// too many decimal places for the value to change
+		res = x
+	}
+
+	if isInt {
+		return types.NewDoubleValue(res).CastAs(args[0].Type())
+	}
+
+	return types.NewDoubleResult(res)
+}
@@ -0,0 +1,296 @@
+package functions
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultOrderedAggregateBufferSize is the number of bytes an ordered aggregate
+// buffers in memory, per group, before spilling its rows to a temporary tree.
+const DefaultOrderedAggregateBufferSize = 4 << 20
+
+// OrderedAggregate wraps an aggregate function and feeds it the rows
+// of each group sorted by a list of expressions:
+//
+//	STRING_AGG(name, ',' ORDER BY name DESC)
+type OrderedAggregate struct {
+	Fn      expr.AggregatorBuilder
+	OrderBy []expr.Expr
+	Desc    []bool
+
+	// BufferSize is the memory budget of each group, in bytes.
+	// If zero, the work_mem of the transaction is used, or
+	// DefaultOrderedAggregateBufferSize if it is not set.
+	BufferSize int
+}
+
+func (o *OrderedAggregate) Clone() expr.Expr {
+	orderBy := make([]expr.Expr, len(o.OrderBy))
+	for i := range o.OrderBy {
+		orderBy[i] = expr.Clone(o.OrderBy[i])
+	}
+
+	return &OrderedAggregate{
+		Fn:         expr.Clone(o.Fn).(expr.AggregatorBuilder),
+		OrderBy:    orderBy,
+		Desc:       append([]bool(nil), o.Desc...),
+		BufferSize: o.BufferSize,
+	}
+}
+
+// Eval extracts the result of the aggregation from the given object and returns it.
+func (o *OrderedAggregate) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function with ORDER BY")
+	}
+
+	return r.Get(o.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (o *OrderedAggregate) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	oo, ok := other.(*OrderedAggregate)
+	if !ok {
+		return false
+	}
+
+	if !expr.Equal(o.Fn, oo.Fn) || len(o.OrderBy) != len(oo.OrderBy) {
+		return false
+	}
+
+	for i := range o.OrderBy {
+		if o.Desc[i] != oo.Desc[i] || !expr.Equal(o.OrderBy[i], oo.OrderBy[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Params returns the parameters of the wrapped function followed by
+// the ORDER BY expressions.
+func (o *OrderedAggregate) Params() []expr.Expr {
+	var params []expr.Expr
+	if f, ok := o.Fn.(expr.Function); ok {
+		params = append(params, f.Params()...)
+	}
+
+	return append(params, o.OrderBy...)
+}
+
+// String returns the literal representation of the function.
+func (o *OrderedAggregate) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(strings.TrimSuffix(o.Fn.String(), ")"))
+	sb.WriteString(" ORDER BY ")
+	for i, e := range o.OrderBy {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(e.String())
+		if o.Desc[i] {
+			sb.WriteString(" DESC")
+		}
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// Aggregator returns an OrderedAggregator. It implements the AggregatorBuilder interface.
+func (o *OrderedAggregate) Aggregator() expr.Aggregator {
+	var order tree.SortOrder
+	for i, desc := range o.Desc {
+		if desc {
+			order = order.SetDesc(i)
+		}
+	}
+
+	return &OrderedAggregator{
+		Fn:   o,
+		rows: orderedBuffer{order: order},
+	}
+}
+
+// OrderedAggregator buffers the rows of a group along with their sort key.
+// Upon evaluation, the rows are replayed in order to the wrapped aggregator.
+type OrderedAggregator struct {
+	Fn *OrderedAggregate
+
+	rows orderedBuffer
+}
+
+// Aggregate evaluates the ORDER BY expressions and buffers the row.
+func (o *OrderedAggregator) Aggregate(env *environment.Environment) error {
+	r, ok := env.GetRow()
+	if !ok {
+		return errors.New("missing row")
+	}
+
+	values := make([]types.Value, 0, len(o.Fn.OrderBy)+1)
+	for _, e := range o.Fn.OrderBy {
+		v, err := e.Eval(env)
+		if err != nil {
+			if !errors.Is(err, types.ErrColumnNotFound) {
+				return err
+			}
+			v = types.NewNullValue()
+		}
+		values = append(values, v)
+	}
+
+	value, err := types.EncodeValuesAsKey(nil, row.Flatten(r)...)
+	if err != nil {
+		return err
+	}
+
+	if o.rows.bufferSize == 0 {
+		o.rows.bufferSize = aggregateBufferSize(env, o.Fn.BufferSize, DefaultOrderedAggregateBufferSize)
+	}
+
+	return o.rows.add(env, values, value)
+}
+
+// Eval replays the buffered rows in order to a new instance of the
+// wrapped aggregator and returns its result.
+func (o *OrderedAggregator) Eval(env *environment.Environment) (types.Value, error) {
+	agg := o.Fn.Fn.Aggregator()
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(env)
+
+	err := o.rows.replay(func(value []byte) error {
+		newEnv.SetRow(row.Unflatten(types.DecodeValues(value)))
+		return agg.Aggregate(&newEnv)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return agg.Eval(env)
+}
+
+type orderedEntry struct {
+	key   *tree.Key
+	enc   []byte
+	value []byte
+}
+
+// orderedBuffer buffers values along with their sort key.
+// Values are kept in memory until the buffer exceeds its budget, after
+// which they are written to a temporary tree.
+type orderedBuffer struct {
+	order tree.SortOrder
+	// memory budget, in bytes. If zero, the work_mem of the
+	// transaction is used, or DefaultOrderedAggregateBufferSize
+	// if it is not set.
+	bufferSize int
+	size       int
+	counter    int64
+	entries    []orderedEntry
+
+	temp    *tree.Tree
+	cleanup func() error
+}
+
+// add buffers the value, sorted by the given key values.
+func (b *orderedBuffer) add(env *environment.Environment, values []types.Value, value []byte) error {
+	// the counter keeps keys unique and preserves insertion order for ties
+	values = append(values, types.NewBigintValue(b.counter))
+	b.counter++
+
+	k := tree.NewKey(values...)
+
+	if b.temp != nil {
+		return b.temp.Put(k, value)
+	}
+
+	enc, err := k.Encode(0, b.order)
+	if err != nil {
+		return err
+	}
+
+	if b.bufferSize == 0 {
+		b.bufferSize = aggregateBufferSize(env, 0, DefaultOrderedAggregateBufferSize)
+	}
+
+	b.entries = append(b.entries, orderedEntry{key: k, enc: enc, value: value})
+	b.size += len(enc) + len(value)
+	if b.size <= b.bufferSize {
+		return nil
+	}
+
+	return b.spill(env)
+}
+
+// spill moves the buffered values to a temporary tree.
+func (b *orderedBuffer) spill(env *environment.Environment) error {
+	tx := env.GetTx()
+	if tx == nil {
+		return errors.New("ordered aggregate exceeded its memory budget and cannot spill outside of a transaction")
+	}
+
+	temp, cleanup, err := tree.NewTransient(tx.NewTransientSession(), tx.Catalog.GetFreeTransientNamespace(), b.order)
+	if err != nil {
+		return err
+	}
+	b.temp, b.cleanup = temp, cleanup
+
+	for _, e := range b.entries {
+		// the key was encoded without namespace, encode it again for the tree
+		e.key.Encoded = nil
+		err = b.temp.Put(e.key, e.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	b.entries = nil
+	b.size = 0
+	return nil
+}
+
+// replay calls fn with the buffered values, in order.
+func (b *orderedBuffer) replay(fn func(value []byte) error) error {
+	if b.temp != nil {
+		defer func() {
+			_ = b.cleanup()
+			b.temp, b.cleanup = nil, nil
+		}()
+
+		return b.temp.IterateOnRange(nil, false, func(_ *tree.Key, value []byte) error {
+			return fn(value)
+		})
+	}
+
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		return encoding.Compare(b.entries[i].enc, b.entries[j].enc) < 0
+	})
+
+	for _, e := range b.entries {
+		if err := fn(e.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *OrderedAggregator) String() string {
+	return o.Fn.String()
+}
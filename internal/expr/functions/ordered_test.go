@@ -0,0 +1,71 @@
+package functions_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedAggregate(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+	}{
+		{"in memory", 0},
+		{"spill", 64},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			fn := &functions.OrderedAggregate{
+				Fn:         &functions.StringAgg{Expr: &expr.Column{Name: "a"}, Sep: testutil.TextValue(",")},
+				OrderBy:    []expr.Expr{&expr.Column{Name: "b"}},
+				Desc:       []bool{true},
+				BufferSize: test.bufferSize,
+			}
+
+			var env environment.Environment
+			env.DB = db
+			env.Tx = tx
+
+			agg := fn.Aggregator()
+
+			var want []string
+			for i := 0; i < 100; i++ {
+				want = append(want, fmt.Sprintf("v%d", 99-i))
+
+				var inner environment.Environment
+				inner.SetOuter(&env)
+				inner.SetRow(row.NewColumnBuffer().
+					Add("a", types.NewTextValue(fmt.Sprintf("v%d", i))).
+					Add("b", types.NewIntegerValue(int32(i))))
+				require.NoError(t, agg.Aggregate(&inner))
+			}
+
+			v, err := agg.Eval(&env)
+			require.NoError(t, err)
+			require.Equal(t, types.NewTextValue(strings.Join(want, ",")), v)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		fn := &functions.OrderedAggregate{
+			Fn:      &functions.StringAgg{Expr: &expr.Column{Name: "a"}, Sep: testutil.TextValue(",")},
+			OrderBy: []expr.Expr{&expr.Column{Name: "b"}, &expr.Column{Name: "c"}},
+			Desc:    []bool{true, false},
+		}
+
+		require.Equal(t, `STRING_AGG(a, "," ORDER BY b DESC, c)`, fn.String())
+	})
+}
@@ -0,0 +1,90 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// regexpMatches returns the first substring of arg1 matching the regular expression arg2,
+// or NULL if there is no match.
+// If the regular expression contains capture groups, the text matched
+// by the first group is returned instead.
+//
+//	regexp_matches('order-123', '[0-9]+') -> '123'
+//	regexp_matches('key=value', '(\w+)=') -> 'key'
+var regexpMatches = &ScalarDefinition{
+	name:  "regexp_matches",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		s, pattern, err := regexpArgs("regexp_matches", args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		re, err := expr.CompileRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		m := re.FindStringSubmatchIndex(s)
+		if m == nil {
+			return types.NewNullValue(), nil
+		}
+
+		// use the first group if any, unless it didn't participate in the match
+		if len(m) > 2 {
+			if m[2] < 0 {
+				return types.NewNullValue(), nil
+			}
+			return types.NewTextValue(s[m[2]:m[3]]), nil
+		}
+
+		return types.NewTextValue(s[m[0]:m[1]]), nil
+	},
+}
+
+// regexpReplace replaces every substring of arg1 matching the regular expression arg2
+// with arg3. Inside arg3, $1 or ${1} is replaced by the text matched by the first group.
+//
+//	regexp_replace('a1b22c', '[0-9]+', '#') -> 'a#b#c'
+var regexpReplace = &ScalarDefinition{
+	name:  "regexp_replace",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		s, pattern, err := regexpArgs("regexp_replace", args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		if args[2].Type() != types.TypeText {
+			return nil, fmt.Errorf("regexp_replace() expects a text replacement, got %s", args[2].Type())
+		}
+
+		re, err := expr.CompileRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewTextValue(re.ReplaceAllString(s, types.AsString(args[2]))), nil
+	},
+}
+
+func regexpArgs(fname string, s, pattern types.Value) (string, string, error) {
+	if s.Type() != types.TypeText {
+		return "", "", fmt.Errorf("%s() expects a text, got %s", fname, s.Type())
+	}
+	if pattern.Type() != types.TypeText {
+		return "", "", fmt.Errorf("%s() expects a text pattern, got %s", fname, pattern.Type())
+	}
+
+	return types.AsString(s), types.AsString(pattern), nil
+}
@@ -31,6 +31,10 @@ func (fd *ScalarDefinition) Name() string {
 
 // String returns the defined function name and its arguments.
 func (fd *ScalarDefinition) String() string {
+	if fd.arity == variadicArity {
+		return fmt.Sprintf("%s(...)", fd.name)
+	}
+
 	args := make([]string, 0, fd.arity)
 	for i := 0; i < fd.arity; i++ {
 		args = append(args, fmt.Sprintf("arg%d", i+1))
@@ -39,8 +43,13 @@ func (fd *ScalarDefinition) String() string {
 }
 
 // Function returns a Function expr node.
+// If the definition is variadic, it is up to the callFn function to validate
+// the number of arguments it receives.
 func (fd *ScalarDefinition) Function(args ...expr.Expr) (expr.Function, error) {
-	if len(args) != fd.arity {
+	if fd.arity == variadicArity && len(args) == 0 {
+		return nil, fmt.Errorf("%s() requires at least one argument", fd.name)
+	}
+	if fd.arity != variadicArity && len(args) != fd.arity {
 		return nil, fmt.Errorf("%s takes %d argument(s), not %d", fd.String(), fd.arity, len(args))
 	}
 	return &ScalarFunction{
@@ -93,7 +102,12 @@ func (sf *ScalarFunction) evalParams(env *environment.Environment) ([]types.Valu
 
 // String returns a string represention of the function expression and its arguments.
 func (sf *ScalarFunction) String() string {
-	return fmt.Sprintf("%s(%v)", sf.def.name, sf.params)
+	params := make([]string, 0, len(sf.params))
+	for _, p := range sf.params {
+		params = append(params, p.String())
+	}
+
+	return fmt.Sprintf("%s(%s)", sf.def.name, strings.Join(params, ", "))
 }
 
 // Params return the function arguments.
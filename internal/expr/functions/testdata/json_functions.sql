@@ -0,0 +1,169 @@
+-- test: json_extract
+> json_extract('{"a": 1, "b": [true, 2.5, "x", null]}', '$.a')
+1
+> json_extract('{"a": 1, "b": [true, 2.5, "x", null]}', '$.b[0]')
+true
+> json_extract('{"a": 1, "b": [true, 2.5, "x", null]}', '$.b[1]')
+2.5
+> json_extract('{"a": 1, "b": [true, 2.5, "x", null]}', '$.b[2]')
+'x'
+> json_extract('{"a": 1, "b": [true, 2.5, "x", null]}', '$.b[3]')
+NULL
+> json_extract('{"a": 1, "b": [true, 2.5, "x", null]}', '$.b')
+'[true,2.5,"x",null]'
+> json_extract('{"a": {"c d": {"e": 1}}}', '$.a."c d"')
+'{"e":1}'
+> json_extract('{"a": 1}', '$')
+'{"a":1}'
+> json_extract('{"a": 1}', '$.b')
+NULL
+> json_extract('{"a": 1}', '$.a.b')
+NULL
+> json_extract('[1, 2]', '$[2]')
+NULL
+> json_extract('"<é>"', '$')
+'<é>'
+> json_extract(NULL, '$.a')
+NULL
+! json_extract('{"a": 1', '$.a')
+'malformed JSON'
+! json_extract('{"a": 1} x', '$.a')
+'malformed JSON'
+! json_extract('{"a": 1}', 'a')
+'bad JSON path: "a"'
+! json_extract('{"a": 1}', '$.a[x]')
+'bad JSON path: "$.a[x]"'
+! json_extract('{"a": 1}', '$..a')
+'bad JSON path: "$..a"'
+! json_extract(1, '$.a')
+'json_extract() expects a JSON text, got integer'
+
+-- test: json_extract wildcards
+> json_extract('{"items": [{"price": 5}, {"price": 2.5}, {"name": "x"}]}', '$.items[*].price')
+'[5,2.5]'
+> json_extract('{"tags": ["red", "blue"]}', '$.tags[*]')
+'["red","blue"]'
+> json_extract('{"a": {"x": 1}, "b": {"x": 2}, "c": 3}', '$.*.x')
+'[1,2]'
+> json_extract('{"a": [[1, 2], [3]]}', '$.a[*][*]')
+'[1,2,3]'
+> json_extract('{"a": [1]}', '$.a[*]')
+'[1]'
+> json_extract('{"a": []}', '$.a[*]')
+NULL
+> json_extract('{"a": {"b": 1}}', '$.a[*]')
+NULL
+> json_extract('[{"b": 1}]', '$.*')
+NULL
+> json_extract('{"*": 1, "b": 2}', '$."*"')
+1
+! json_extract('{"a": 1}', '$.a[*')
+'bad JSON path: "$.a[*"'
+
+-- test: json_set
+> json_set('{"a": 1}', '$.a', 2)
+'{"a":2}'
+> json_set('{"a": 1}', '$.b', 'x')
+'{"a":1,"b":"x"}'
+> json_set('{"b": 1, "a": 2}', '$.c', 1.5)
+'{"b":1,"a":2,"c":1.5}'
+> json_set('{"a": [1, 2]}', '$.a[0]', NULL)
+'{"a":[null,2]}'
+> json_set('{"a": [1, 2]}', '$.a[2]', true)
+'{"a":[1,2,true]}'
+> json_set('{"a": [1, 2]}', '$.a[#]', 3)
+'{"a":[1,2,3]}'
+> json_set('{"a": [1, 2]}', '$.a[5]', 3)
+'{"a":[1,2]}'
+> json_set('{"a": 1}', '$.b.c', 1)
+'{"a":1}'
+> json_set('{"a": 1}', '$', 'x')
+'"x"'
+> json_set(NULL, '$.a', 1)
+NULL
+
+-- test: json_remove
+> json_remove('{"a": 1, "b": 2}', '$.a')
+'{"b":2}'
+> json_remove('{"a": 1, "b": 2}', '$.a', '$.b')
+'{}'
+> json_remove('[1, 2, 3]', '$[1]')
+'[1,3]'
+> json_remove('{"a": [1, 2, 3]}', '$.a[0]', '$.a[0]')
+'{"a":[3]}'
+> json_remove('{"a": 1}', '$.c')
+'{"a":1}'
+! json_remove('{"a": 1}')
+'json_remove() takes at least 2 arguments'
+! json_remove('{"a": 1}', '$')
+'json_remove() cannot remove the whole value'
+! json_remove('{"a": [1]}', '$.a[*]')
+'json_remove() doesn't accept wildcards in paths'
+! json_set('{"a": [1]}', '$.a[*]', 2)
+'json_set() doesn't accept wildcards in paths'
+! json_type('{"a": [1]}', '$.a[*]')
+'json_type() doesn't accept wildcards in paths'
+
+-- test: json_array_length
+> json_array_length('[1, 2, 3]')
+3
+> json_array_length('[]')
+0
+> json_array_length('{"a": [1, [2, 3]]}', '$.a')
+2
+> json_array_length('{"a": [1, [2, 3]]}', '$.a[1]')
+2
+> json_array_length('{"a": 1}')
+0
+> json_array_length('{"a": 1}', '$.b')
+NULL
+> json_array_length(NULL)
+NULL
+! json_array_length('[1]', '$', '$')
+'json_array_length() takes 1 or 2 arguments, not 3'
+
+-- test: json_contains
+> json_contains('{"tags": ["red", "blue"]}', '$.tags[*]', 'red')
+true
+> json_contains('{"tags": ["red", "blue"]}', '$.tags[*]', 'green')
+false
+> json_contains('{"items": [{"price": 5}, {"price": 2.5}]}', '$.items[*].price', 2.5)
+true
+> json_contains('{"items": [{"price": 5}, {"price": 2.5}]}', '$.items[*].price', 5)
+true
+> json_contains('{"items": [{"price": 5}]}', '$.items[*].price', '5')
+false
+> json_contains('{"a": [1, 2]}', '$.a', '[1,2]')
+true
+> json_contains('{"a": 1}', '$.a', 1)
+true
+> json_contains('{"a": 1}', '$.b[*]', 1)
+false
+> json_contains('{"a": 1}', '$.a', NULL)
+NULL
+> json_contains(NULL, '$.a', 1)
+NULL
+! json_contains('{"a": 1}', 'a', 1)
+'bad JSON path: "a"'
+
+-- test: json_type
+> json_type('{"a": 1}')
+'object'
+> json_type('[1]')
+'array'
+> json_type('{"a": [1, 2.5, 1e3, "x", true, false, null]}', '$.a[0]')
+'integer'
+> json_type('{"a": [1, 2.5, 1e3, "x", true, false, null]}', '$.a[1]')
+'real'
+> json_type('{"a": [1, 2.5, 1e3, "x", true, false, null]}', '$.a[2]')
+'real'
+> json_type('{"a": [1, 2.5, 1e3, "x", true, false, null]}', '$.a[3]')
+'text'
+> json_type('{"a": [1, 2.5, 1e3, "x", true, false, null]}', '$.a[4]')
+'true'
+> json_type('{"a": [1, 2.5, 1e3, "x", true, false, null]}', '$.a[5]')
+'false'
+> json_type('{"a": [1, 2.5, 1e3, "x", true, false, null]}', '$.a[6]')
+'null'
+> json_type('{"a": 1}', '$.b')
+NULL
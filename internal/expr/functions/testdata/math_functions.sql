@@ -134,4 +134,146 @@ NULL
 > sqrt(1.1)
 1.0488088481701516
 > sqrt('foo')
-NULL
\ No newline at end of file
+NULL
+
+-- test: power
+> power(NULL, 2)
+NULL
+> power(2, NULL)
+NULL
+> power(2, 10)
+1024.0
+> power(2.5, 2)
+6.25
+> power(4, 0.5)
+2.0
+> power(2, -1)
+0.5
+! power(0, -1)
+'double out of range'
+! power(-8, 0.5)
+'double out of range'
+! power(10, 400)
+'double out of range'
+! power('foo', 2)
+'cannot cast "foo" as double'
+
+-- test: ln
+> ln(NULL)
+NULL
+> ln(1)
+0.0
+> ln(2.718281828459045)
+1.0
+! ln(0)
+'out of range'
+! ln(-1)
+'out of range'
+
+-- test: log
+> log(NULL)
+NULL
+> log(100)
+2.0
+> log(2, 8)
+3.0
+> log(NULL, 8)
+NULL
+! log(0)
+'out of range'
+! log(1, 8)
+'out of range'
+! log(2, 8, 1)
+'log() takes 1 or 2 arguments, not 3'
+
+-- test: exp
+> exp(NULL)
+NULL
+> exp(0)
+1.0
+> exp(1)
+2.718281828459045
+! exp(1000)
+'double out of range'
+
+-- test: sign
+> sign(NULL)
+NULL
+> sign(-10)
+-1
+> sign(0)
+0
+> sign(10000000000)
+1
+> sign(-2.5)
+-1.0
+> sign(0.0)
+0.0
+> typeof(sign(10000000000))
+'bigint'
+
+-- test: mod
+> mod(NULL, 2)
+NULL
+> mod(10, 3)
+1
+> mod(-10, 3)
+-1
+> mod(5.5, 2)
+1.5
+> mod(10, 2.5)
+0.0
+> mod(10, 0)
+NULL
+> mod(10.5, 0.0)
+NULL
+> mod('a', 2)
+NULL
+
+-- test: round
+> round(NULL)
+NULL
+> round(2.5)
+3.0
+> round(-2.5)
+-3.0
+> round(2.4)
+2.0
+> round(2)
+2
+> round(1.2345, 2)
+1.23
+> round(1.235, 2, 'half_even')
+1.24
+> round(2.5, 0, 'half_even')
+2.0
+> round(3.5, 0, 'half_even')
+4.0
+> round(-2.5, 0, 'half_even')
+-2.0
+> round(1234, -2)
+1200
+> round(1250.0, -2)
+1300.0
+> round(1.5, NULL)
+NULL
+! round(2.5, 0, 'foo')
+'unknown rounding mode "foo"'
+! round(2.5, 1.5)
+'round(arg1, arg2) expects arg2 to be an integer'
+
+-- test: trunc
+> trunc(NULL)
+NULL
+> trunc(2.7)
+2.0
+> trunc(-2.7)
+-2.0
+> trunc(5)
+5
+> trunc(1.2399, 2)
+1.23
+> trunc(-1.2399, 2)
+-1.23
+> trunc(1299, -2)
+1200
@@ -0,0 +1,36 @@
+-- test: regexp_matches
+> regexp_matches('order-123', '[0-9]+')
+'123'
+> regexp_matches('key=value', '(\\w+)=')
+'key'
+> regexp_matches('abc', '[0-9]+')
+NULL
+> regexp_matches('abc', '(x)?abc')
+NULL
+> regexp_matches(NULL, '[0-9]+')
+NULL
+> regexp_matches('abc', NULL)
+NULL
+> regexp_matches('ABC', '(?i)b')
+'B'
+! regexp_matches(1, '[0-9]+')
+'regexp_matches() expects a text, got integer'
+! regexp_matches('abc', 1)
+'regexp_matches() expects a text pattern, got integer'
+! regexp_matches('abc', '(')
+'missing closing )'
+! regexp_matches('abc')
+
+-- test: regexp_replace
+> regexp_replace('a1b22c', '[0-9]+', '#')
+'a#b#c'
+> regexp_replace('john smith', '(\\w+) (\\w+)', '$2 $1')
+'smith john'
+> regexp_replace('abc', 'x', 'y')
+'abc'
+> regexp_replace('abc', 'b', NULL)
+NULL
+! regexp_replace('abc', 'b', 1)
+'regexp_replace() expects a text replacement, got integer'
+! regexp_replace('abc', '[', 'x')
+'missing closing ]'
@@ -0,0 +1,217 @@
+-- test: date_add
+> date_add('day', 1, NULL)
+NULL
+> date_add('second', 90, '2023-01-01T00:00:00Z')
+'2023-01-01T00:01:30Z'
+> date_add('hours', -2, '2023-01-01T00:00:00Z')
+'2022-12-31T22:00:00Z'
+> date_add('microsecond', 1, '2023-01-01T00:00:00Z')
+'2023-01-01T00:00:00.000001Z'
+> date_add('month', 1, '2023-01-15T10:00:00Z')
+'2023-02-15T10:00:00Z'
+> date_add('quarter', 1, '2023-01-15T10:00:00Z')
+'2023-04-15T10:00:00Z'
+> date_add('year', -1, '2024-06-01T00:00:00Z')
+'2023-06-01T00:00:00Z'
+> date_add('week', 2, '2023-01-01T00:00:00Z')
+'2023-01-15T00:00:00Z'
+> date_add('day', 1, '2023-03-25T10:00:00Z')
+'2023-03-26T10:00:00Z'
+> date_add('day', 1, '2023-03-25T10:00:00Z', 'Europe/Paris')
+'2023-03-26T09:00:00Z'
+> date_add('DAY', 1, now())
+'2020-01-02T00:00:00Z'
+! date_add('fortnight', 1, '2023-01-01')
+'unknown time unit "fortnight"'
+! date_add('day', 1.5, '2023-01-01')
+'expects arg2 to be an integer'
+! date_add('day', 1, '2023-01-01', 'Mars/Olympus')
+'unknown timezone "Mars/Olympus"'
+! date_add('day', 1, 10)
+'date_add() expects a timestamp, got integer'
+! date_add('hour', 9223372036854775807, '2023-01-01')
+'timestamp out of range'
+
+-- test: date_diff
+> date_diff('day', NULL, '2023-01-01')
+NULL
+> date_diff('second', '2023-01-01T00:00:00Z', '2023-01-01T00:01:30Z')
+90
+> date_diff('minute', '2023-01-01T00:01:30Z', '2023-01-01T00:00:00Z')
+-1
+> date_diff('day', '2023-01-01T10:00:00Z', '2023-01-03T09:00:00Z')
+1
+> date_diff('day', '2023-01-01T10:00:00Z', '2023-01-03T10:00:00Z')
+2
+> date_diff('day', '2023-01-03T10:00:00Z', '2023-01-01T10:00:01Z')
+-1
+> date_diff('week', '2023-01-01', '2023-01-22')
+3
+> date_diff('month', '2023-01-31', '2023-03-30')
+1
+> date_diff('month', '2023-01-15', '2023-03-15')
+2
+> date_diff('quarter', '2023-01-01', '2023-12-31')
+3
+> date_diff('year', '2000-06-01', '2023-05-31')
+22
+> date_diff('day', '2023-03-25T12:00:00Z', '2023-03-26T11:30:00Z')
+0
+> date_diff('day', '2023-03-25T12:00:00Z', '2023-03-26T11:30:00Z', 'Europe/Paris')
+1
+
+-- test: date_trunc
+> date_trunc('year', '2023-05-17T10:11:12.131415Z')
+'2023-01-01T00:00:00Z'
+> date_trunc('quarter', '2023-05-17T10:11:12.131415Z')
+'2023-04-01T00:00:00Z'
+> date_trunc('month', '2023-05-17T10:11:12.131415Z')
+'2023-05-01T00:00:00Z'
+> date_trunc('week', '2023-05-17T10:11:12.131415Z')
+'2023-05-15T00:00:00Z'
+> date_trunc('day', '2023-05-17T10:11:12.131415Z')
+'2023-05-17T00:00:00Z'
+> date_trunc('hour', '2023-05-17T10:11:12.131415Z')
+'2023-05-17T10:00:00Z'
+> date_trunc('minute', '2023-05-17T10:11:12.131415Z')
+'2023-05-17T10:11:00Z'
+> date_trunc('second', '2023-05-17T10:11:12.131415Z')
+'2023-05-17T10:11:12Z'
+> date_trunc('millisecond', '2023-05-17T10:11:12.131415Z')
+'2023-05-17T10:11:12.131Z'
+> date_trunc('day', '2023-05-17T23:00:00Z', 'Europe/Paris')
+'2023-05-17T22:00:00Z'
+
+-- test: date_part
+> date_part('year', '2023-05-17T10:11:12.131415Z')
+2023
+> date_part('quarter', '2023-05-17T10:11:12.131415Z')
+2
+> date_part('month', '2023-05-17T10:11:12.131415Z')
+5
+> date_part('week', '2023-05-17T10:11:12.131415Z')
+20
+> date_part('day', '2023-05-17T10:11:12.131415Z')
+17
+> date_part('hour', '2023-05-17T10:11:12.131415Z')
+10
+> date_part('hour', '2023-05-17T10:11:12.131415Z', 'Asia/Tokyo')
+19
+> date_part('minute', '2023-05-17T10:11:12.131415Z')
+11
+> date_part('second', '2023-05-17T10:11:12.131415Z')
+12
+> date_part('millisecond', '2023-05-17T10:11:12.131415Z')
+131
+> date_part('microsecond', '2023-05-17T10:11:12.131415Z')
+131415
+> date_part('dow', '2023-05-17T10:11:12.131415Z')
+3
+> date_part('doy', '2023-05-17T10:11:12.131415Z')
+137
+> date_part('epoch', '2023-05-17T10:11:12.131415Z')
+1684318272
+! date_part('century', '2023-05-17')
+'unknown time unit "century"'
+
+-- test: strftime
+> strftime('%Y-%m-%d', NULL)
+NULL
+> strftime('%Y-%m-%d %H:%M:%S', '2023-05-07T08:09:10.123456Z')
+'2023-05-07 08:09:10'
+> strftime('%F %T.%f', '2023-05-07T08:09:10.123456Z')
+'2023-05-07 08:09:10.123456'
+> strftime('%y %e %j %I%p %L', '2023-05-07T20:09:10.123456Z')
+'23  7 127 08PM 123'
+> strftime('%a %A %b %B %u %w', '2023-05-07T08:09:10Z')
+'Sun Sunday May May 7 0'
+> strftime('%s %%', '2023-05-07T08:09:10Z')
+'1683446950 %'
+> strftime('%H:%M %z %Z', '2023-05-07T08:09:10Z', 'Europe/Paris')
+'10:09 +0200 CEST'
+! strftime('%Q', '2023-05-07')
+'unknown format specifier %Q'
+! strftime('%', '2023-05-07')
+'unterminated format specifier'
+
+-- test: strptime
+> strptime(NULL, '%Y')
+NULL
+> strptime('2023-05-07 08:09:10', '%Y-%m-%d %H:%M:%S')
+'2023-05-07T08:09:10Z'
+> strptime('07/05/2023 8:09 PM', '%d/%m/%Y %I:%M %p')
+'2023-05-07T20:09:00Z'
+> strptime('2023-05-07T08:09:10.5', '%FT%T.%f')
+'2023-05-07T08:09:10.5Z'
+> strptime('May 7, 23', '%b %e, %y')
+'2023-05-07T00:00:00Z'
+> strptime('2023-05-07 08:09', '%Y-%m-%d %H:%M', 'Europe/Paris')
+'2023-05-07T06:09:00Z'
+> strptime('2023-05-07 08:09 +0100', '%Y-%m-%d %H:%M %z', 'Europe/Paris')
+'2023-05-07T07:09:00Z'
+> strptime('2023 127', '%Y %j')
+'2023-05-07T00:00:00Z'
+> strptime('1683446950', '%s')
+'2023-05-07T08:09:10Z'
+! strptime('2023-02-30', '%Y-%m-%d')
+'day out of range'
+! strptime('2023-05-07 foo', '%Y-%m-%d')
+'unexpected trailing text'
+! strptime('2023/05/07', '%Y-%m-%d')
+'expected'
+
+-- test: to_timestamp
+> to_timestamp(NULL)
+NULL
+> to_timestamp(1700000000)
+'2023-11-14T22:13:20Z'
+> to_timestamp(1700000000.5)
+'2023-11-14T22:13:20.5Z'
+> to_timestamp(-86400)
+'1969-12-31T00:00:00Z'
+> to_timestamp('14/11/2023 22:13', '%d/%m/%Y %H:%M')
+'2023-11-14T22:13:00Z'
+> to_timestamp('14/11/2023 23:13', '%d/%m/%Y %H:%M', 'Europe/Paris')
+'2023-11-14T22:13:00Z'
+! to_timestamp('2023')
+'expects arg1 to be a number'
+! to_timestamp(1e300)
+'timestamp out of range'
+! to_timestamp('2023/11/14', '%Y-%m-%d')
+'to_timestamp(): cannot parse'
+
+-- test: to_date
+> to_date(NULL)
+NULL
+> to_date('2023-11-14T22:13:20Z')
+'2023-11-14'
+> typeof(to_date('2023-11-14'))
+'date'
+> to_date('14/11/2023', '%d/%m/%Y')
+'2023-11-14'
+> to_date(to_timestamp(1700000000))
+'2023-11-14'
+! to_date(10)
+'to_date() expects a timestamp, got integer'
+! to_date('2023-02-30', '%Y-%m-%d')
+'to_date(): cannot parse'
+
+-- test: age
+> age(NULL, '2021-01-01')
+NULL
+> age('2023-03-04T12:00:00Z', '2021-01-01')
+'2 years 2 mons 3 days 12:00:00'
+> age('2021-01-01', '2023-03-04T12:00:00Z')
+'-2 years -2 mons -3 days -12:00:00'
+> age('2023-01-31', '2023-01-01')
+'30 days'
+> age('2023-02-01T00:00:01.5Z', '2023-01-01')
+'1 mon 00:00:01.500000'
+> age('2023-01-01', '2023-01-01')
+'00:00:00'
+> age('2019-06-01')
+'7 mons'
+> age(to_date('2019-01-01'))
+'1 year'
+! age('2023-01-01', 10)
+'age() expects a timestamp, got integer'
@@ -0,0 +1,973 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Time units accepted by the date functions.
+// Units are case insensitive and can be written in plural form.
+const (
+	unitMicrosecond = "microsecond"
+	unitMillisecond = "millisecond"
+	unitSecond      = "second"
+	unitMinute      = "minute"
+	unitHour        = "hour"
+	unitDay         = "day"
+	unitWeek        = "week"
+	unitMonth       = "month"
+	unitQuarter     = "quarter"
+	unitYear        = "year"
+)
+
+var unitDurations = map[string]time.Duration{
+	unitMicrosecond: time.Microsecond,
+	unitMillisecond: time.Millisecond,
+	unitSecond:      time.Second,
+	unitMinute:      time.Minute,
+	unitHour:        time.Hour,
+}
+
+// dateAdd adds arg2 units to the timestamp arg3.
+// Calendar units (day, week, month, quarter, year) are added
+// in the optional timezone arg4, UTC by default, so that adding a day
+// always lands on the same wall clock time.
+//
+//	date_add('day', 1, '2023-03-25 10:00:00', 'Europe/Paris')
+var dateAdd = &ScalarDefinition{
+	name:  "date_add",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) < 3 || len(args) > 4 {
+			return nil, fmt.Errorf("date_add() takes 3 or 4 arguments, not %d", len(args))
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		unit, err := timeUnit("date_add", args[0])
+		if err != nil {
+			return nil, err
+		}
+		if args[1].Type() != types.TypeInteger && args[1].Type() != types.TypeBigint {
+			return nil, fmt.Errorf("date_add(arg1, arg2, arg3) expects arg2 to be an integer")
+		}
+		n := types.AsInt64(args[1])
+		ts, err := timestampArg("date_add", args[2])
+		if err != nil {
+			return nil, err
+		}
+		loc, err := locationArg("date_add", args[3:]...)
+		if err != nil {
+			return nil, err
+		}
+
+		var res time.Time
+		if d, ok := unitDurations[unit]; ok {
+			if n > 0 && n > int64(1<<63-1)/int64(d) || n < 0 && n < -int64(1<<63-1)/int64(d) {
+				return nil, fmt.Errorf("timestamp out of range")
+			}
+			res = ts.Add(time.Duration(n) * d)
+		} else {
+			if n > 1_000_000 || n < -1_000_000 {
+				return nil, fmt.Errorf("timestamp out of range")
+			}
+			t := ts.In(loc)
+			switch unit {
+			case unitDay:
+				t = t.AddDate(0, 0, int(n))
+			case unitWeek:
+				t = t.AddDate(0, 0, int(n)*7)
+			case unitMonth:
+				t = t.AddDate(0, int(n), 0)
+			case unitQuarter:
+				t = t.AddDate(0, int(n)*3, 0)
+			case unitYear:
+				t = t.AddDate(int(n), 0, 0)
+			}
+			res = t
+		}
+
+		if err := types.ValidateTimestamp(res); err != nil {
+			return nil, err
+		}
+		return types.NewTimestampValue(res), nil
+	},
+}
+
+// dateDiff returns the number of complete units between
+// the timestamps arg2 and arg3. The result is negative if arg3 is
+// before arg2.
+// Calendar units are computed in the optional timezone arg4.
+var dateDiff = &ScalarDefinition{
+	name:  "date_diff",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) < 3 || len(args) > 4 {
+			return nil, fmt.Errorf("date_diff() takes 3 or 4 arguments, not %d", len(args))
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		unit, err := timeUnit("date_diff", args[0])
+		if err != nil {
+			return nil, err
+		}
+		start, err := timestampArg("date_diff", args[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := timestampArg("date_diff", args[2])
+		if err != nil {
+			return nil, err
+		}
+		loc, err := locationArg("date_diff", args[3:]...)
+		if err != nil {
+			return nil, err
+		}
+
+		if d, ok := unitDurations[unit]; ok {
+			diff := end.UnixMicro() - start.UnixMicro()
+			return types.NewBigintValue(diff / d.Microseconds()), nil
+		}
+
+		start, end = start.In(loc), end.In(loc)
+		var n int64
+		switch unit {
+		case unitDay, unitWeek:
+			n = diffDays(start, end)
+			if unit == unitWeek {
+				n /= 7
+			}
+		case unitMonth, unitQuarter, unitYear:
+			n = diffMonths(start, end)
+			if unit == unitQuarter {
+				n /= 3
+			} else if unit == unitYear {
+				n /= 12
+			}
+		}
+
+		return types.NewBigintValue(n), nil
+	},
+}
+
+// diffDays returns the number of complete calendar days between start and end.
+func diffDays(start, end time.Time) int64 {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+	n := int64(time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+
+	return adjustDiff(n, end, func(n int) time.Time { return start.AddDate(0, 0, n) })
+}
+
+// diffMonths returns the number of complete calendar months between start and end.
+func diffMonths(start, end time.Time) int64 {
+	y1, m1, _ := start.Date()
+	y2, m2, _ := end.Date()
+	n := int64(y2-y1)*12 + int64(m2-m1)
+
+	return adjustDiff(n, end, func(n int) time.Time { return start.AddDate(0, n, 0) })
+}
+
+// adjustDiff removes the last unit from n if it is not complete.
+func adjustDiff(n int64, end time.Time, add func(n int) time.Time) int64 {
+	if n > 0 && add(int(n)).After(end) {
+		n--
+	} else if n < 0 && add(int(n)).Before(end) {
+		n++
+	}
+
+	return n
+}
+
+// dateTrunc truncates the timestamp arg2 to the given unit.
+// Weeks start on monday. Truncation to calendar units happens in the
+// optional timezone arg3.
+var dateTrunc = &ScalarDefinition{
+	name:  "date_trunc",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("date_trunc() takes 2 or 3 arguments, not %d", len(args))
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		unit, err := timeUnit("date_trunc", args[0])
+		if err != nil {
+			return nil, err
+		}
+		ts, err := timestampArg("date_trunc", args[1])
+		if err != nil {
+			return nil, err
+		}
+		loc, err := locationArg("date_trunc", args[2:]...)
+		if err != nil {
+			return nil, err
+		}
+
+		t := ts.In(loc)
+		y, m, d := t.Date()
+		switch unit {
+		case unitYear:
+			t = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+		case unitQuarter:
+			t = time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc)
+		case unitMonth:
+			t = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		case unitWeek:
+			offset := (int(t.Weekday()) + 6) % 7
+			t = time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+		case unitDay:
+			t = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		case unitHour:
+			t = time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+		default:
+			t = t.Truncate(unitDurations[unit])
+		}
+
+		return types.NewTimestampValue(t), nil
+	},
+}
+
+// datePart extracts a field from the timestamp arg2, in the optional
+// timezone arg3. In addition to the time units, the following fields
+// are supported: dow (day of the week, 0 is sunday), doy (day of the year)
+// and epoch (number of seconds since 1970-01-01 00:00:00 UTC).
+var datePart = &ScalarDefinition{
+	name:  "date_part",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("date_part() takes 2 or 3 arguments, not %d", len(args))
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		if args[0].Type() != types.TypeText {
+			return nil, fmt.Errorf("date_part(arg1, arg2) expects arg1 to be a text")
+		}
+		field := strings.ToLower(types.AsString(args[0]))
+		ts, err := timestampArg("date_part", args[1])
+		if err != nil {
+			return nil, err
+		}
+		loc, err := locationArg("date_part", args[2:]...)
+		if err != nil {
+			return nil, err
+		}
+
+		t := ts.In(loc)
+		var n int64
+		switch field {
+		case "dow":
+			n = int64(t.Weekday())
+		case "doy":
+			n = int64(t.YearDay())
+		case "epoch":
+			n = t.Unix()
+		default:
+			unit, err := timeUnit("date_part", args[0])
+			if err != nil {
+				return nil, err
+			}
+
+			switch unit {
+			case unitYear:
+				n = int64(t.Year())
+			case unitQuarter:
+				n = int64(t.Month()-1)/3 + 1
+			case unitMonth:
+				n = int64(t.Month())
+			case unitWeek:
+				_, w := t.ISOWeek()
+				n = int64(w)
+			case unitDay:
+				n = int64(t.Day())
+			case unitHour:
+				n = int64(t.Hour())
+			case unitMinute:
+				n = int64(t.Minute())
+			case unitSecond:
+				n = int64(t.Second())
+			case unitMillisecond:
+				n = int64(t.Nanosecond() / int(time.Millisecond))
+			case unitMicrosecond:
+				n = int64(t.Nanosecond() / int(time.Microsecond))
+			}
+		}
+
+		return types.NewBigintValue(n), nil
+	},
+}
+
+// strftime formats the timestamp arg2 according to the format arg1,
+// in the optional timezone arg3. See formatTime for the list of
+// supported specifiers.
+var strftime = &ScalarDefinition{
+	name:  "strftime",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("strftime() takes 2 or 3 arguments, not %d", len(args))
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		if args[0].Type() != types.TypeText {
+			return nil, fmt.Errorf("strftime(arg1, arg2) expects arg1 to be a text")
+		}
+		ts, err := timestampArg("strftime", args[1])
+		if err != nil {
+			return nil, err
+		}
+		loc, err := locationArg("strftime", args[2:]...)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := formatTime(types.AsString(args[0]), ts.In(loc))
+		if err != nil {
+			return nil, err
+		}
+		return types.NewTextValue(s), nil
+	},
+}
+
+// strptime parses the text arg1 according to the format arg2 and returns
+// a timestamp. If the format doesn't contain a timezone offset, the
+// text is interpreted in the optional timezone arg3.
+var strptime = &ScalarDefinition{
+	name:  "strptime",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("strptime() takes 2 or 3 arguments, not %d", len(args))
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		if args[0].Type() != types.TypeText || args[1].Type() != types.TypeText {
+			return nil, fmt.Errorf("strptime(arg1, arg2) expects arg1 and arg2 to be texts")
+		}
+		loc, err := locationArg("strptime", args[2:]...)
+		if err != nil {
+			return nil, err
+		}
+
+		ts, err := parseTime("strptime", types.AsString(args[0]), types.AsString(args[1]), loc)
+		if err != nil {
+			return nil, err
+		}
+		if err := types.ValidateTimestamp(ts); err != nil {
+			return nil, err
+		}
+		return types.NewTimestampValue(ts), nil
+	},
+}
+
+// toTimestamp converts the number of seconds since the unix epoch arg1
+// to a timestamp. If a format is given, the text arg1 is parsed according
+// to the format arg2, in the optional timezone arg3, like strptime.
+//
+//	to_timestamp(1700000000) -> '2023-11-14T22:13:20Z'
+//	to_timestamp('14/11/2023', '%d/%m/%Y')
+var toTimestamp = &ScalarDefinition{
+	name:  "to_timestamp",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) > 3 {
+			return nil, fmt.Errorf("to_timestamp() takes 1 to 3 arguments, not %d", len(args))
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		var ts time.Time
+		if len(args) == 1 {
+			if !args[0].Type().IsNumber() {
+				return nil, fmt.Errorf("to_timestamp(arg1) expects arg1 to be a number")
+			}
+
+			var sec float64
+			switch args[0].Type() {
+			case types.TypeDouble:
+				sec = types.AsFloat64(args[0])
+			case types.TypeDecimal:
+				sec = args[0].(types.DecimalValue).Float64()
+			default:
+				sec = float64(types.AsInt64(args[0]))
+			}
+			if math.IsNaN(sec) || math.Abs(sec) > math.MaxInt64/1e6 {
+				return nil, fmt.Errorf("timestamp out of range")
+			}
+			ts = time.UnixMicro(int64(math.Round(sec * 1e6))).UTC()
+		} else {
+			if args[0].Type() != types.TypeText || args[1].Type() != types.TypeText {
+				return nil, fmt.Errorf("to_timestamp(arg1, arg2) expects arg1 and arg2 to be texts")
+			}
+			loc, err := locationArg("to_timestamp", args[2:]...)
+			if err != nil {
+				return nil, err
+			}
+
+			ts, err = parseTime("to_timestamp", types.AsString(args[0]), types.AsString(args[1]), loc)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := types.ValidateTimestamp(ts); err != nil {
+			return nil, err
+		}
+		return types.NewTimestampValue(ts), nil
+	},
+}
+
+// toDate converts the text or timestamp arg1 to a date. If a format is given,
+// the text arg1 is parsed according to the format arg2, like strptime.
+//
+//	to_date('2023-11-14T22:13:20Z') -> '2023-11-14'
+//	to_date('14/11/2023', '%d/%m/%Y') -> '2023-11-14'
+var toDate = &ScalarDefinition{
+	name:  "to_date",
+	arity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) > 2 {
+			return nil, fmt.Errorf("to_date() takes 1 or 2 arguments, not %d", len(args))
+		}
+		if hasNull(args...) {
+			return types.NewNullValue(), nil
+		}
+
+		if len(args) == 1 {
+			ts, err := timestampArg("to_date", args[0])
+			if err != nil {
+				return nil, err
+			}
+			return types.NewDateValue(ts), nil
+		}
+
+		if args[0].Type() != types.TypeText || args[1].Type() != types.TypeText {
+			return nil, fmt.Errorf("to_date(arg1, arg2) expects arg1 and arg2 to be texts")
+		}
+		ts, err := parseTime("to_date", types.AsString(args[0]), types.AsString(args[1]), time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		if err := types.ValidateTimestamp(ts); err != nil {
+			return nil, err
+		}
+		return types.NewDateValue(ts), nil
+	},
+}
+
+// Age returns the interval between two timestamps, in years, months, days
+// and time of the day. With one argument, the interval
+// between midnight of the current day and the argument is returned.
+//
+//	age('2023-03-04 12:00:00', '2021-01-01') -> '2 years 2 mons 3 days 12:00:00'
+type Age struct {
+	Exprs []expr.Expr
+}
+
+func (a *Age) Clone() expr.Expr {
+	exprs := make([]expr.Expr, len(a.Exprs))
+	for i := range a.Exprs {
+		exprs[i] = expr.Clone(a.Exprs[i])
+	}
+
+	return &Age{Exprs: exprs}
+}
+
+func (a *Age) Eval(env *environment.Environment) (types.Value, error) {
+	if len(a.Exprs) > 2 {
+		return nil, fmt.Errorf("age() takes 1 or 2 arguments, not %d", len(a.Exprs))
+	}
+
+	args := make([]types.Value, len(a.Exprs))
+	for i, e := range a.Exprs {
+		v, err := e.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	if hasNull(args...) {
+		return types.NewNullValue(), nil
+	}
+
+	var end time.Time
+	if len(args) == 1 {
+		tx := env.GetTx()
+		if tx == nil {
+			return nil, errors.New("misuse of AGE()")
+		}
+		end = time.Time(types.NewDateValue(tx.TxStart))
+	} else {
+		var err error
+		end, err = timestampArg("age", args[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start, err := timestampArg("age", args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	return ageInterval(start, end), nil
+}
+
+func (a *Age) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+	o, ok := other.(*Age)
+	if !ok || len(a.Exprs) != len(o.Exprs) {
+		return false
+	}
+
+	for i := range a.Exprs {
+		if !expr.Equal(a.Exprs[i], o.Exprs[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *Age) Params() []expr.Expr {
+	return a.Exprs
+}
+
+func (a *Age) String() string {
+	if len(a.Exprs) == 1 {
+		return fmt.Sprintf("AGE(%v)", a.Exprs[0])
+	}
+	return fmt.Sprintf("AGE(%v, %v)", a.Exprs[0], a.Exprs[1])
+}
+
+// ageInterval returns the calendar interval between start and end,
+// in months, days and microseconds. If end is before start,
+// every field is negative.
+func ageInterval(start, end time.Time) types.IntervalValue {
+	sign := int64(1)
+	if end.Before(start) {
+		start, end = end, start
+		sign = -1
+	}
+
+	months := diffMonths(start, end)
+	t := start.AddDate(0, int(months), 0)
+	days := diffDays(t, end)
+	rest := end.Sub(t.AddDate(0, 0, int(days)))
+
+	return types.NewIntervalValue(int32(sign*months), int32(sign*days), sign*rest.Microseconds())
+}
+
+func hasNull(args ...types.Value) bool {
+	for _, a := range args {
+		if a.Type() == types.TypeNull {
+			return true
+		}
+	}
+
+	return false
+}
+
+// timeUnit returns the normalized time unit stored in v.
+func timeUnit(fname string, v types.Value) (string, error) {
+	if v.Type() != types.TypeText {
+		return "", fmt.Errorf("%s() expects the unit to be a text", fname)
+	}
+
+	unit := strings.TrimSuffix(strings.ToLower(types.AsString(v)), "s")
+	switch unit {
+	case unitMicrosecond, unitMillisecond, unitSecond, unitMinute, unitHour,
+		unitDay, unitWeek, unitMonth, unitQuarter, unitYear:
+		return unit, nil
+	}
+
+	return "", fmt.Errorf("%s(): unknown time unit %q", fname, types.AsString(v))
+}
+
+// timestampArg converts v to a time.Time. Text values are parsed
+// the same way they are when inserted in a TIMESTAMP column.
+func timestampArg(fname string, v types.Value) (time.Time, error) {
+	switch v.Type() {
+	case types.TypeTimestamp, types.TypeDate:
+		return types.AsTime(v), nil
+	case types.TypeText:
+		return types.ParseTimestamp(types.AsString(v))
+	}
+
+	return time.Time{}, fmt.Errorf("%s() expects a timestamp, got %s", fname, v.Type())
+}
+
+// locationArg returns the timezone stored in the optional argument,
+// or UTC.
+func locationArg(fname string, args ...types.Value) (*time.Location, error) {
+	if len(args) == 0 {
+		return time.UTC, nil
+	}
+
+	if args[0].Type() != types.TypeText {
+		return nil, fmt.Errorf("%s() expects the timezone to be a text", fname)
+	}
+
+	loc, err := time.LoadLocation(types.AsString(args[0]))
+	if err != nil {
+		return nil, fmt.Errorf("%s(): unknown timezone %q", fname, types.AsString(args[0]))
+	}
+
+	return loc, nil
+}
+
+// formatTime formats t using strftime-style specifiers:
+//
+//	%Y  year (2006)              %y  two digits year (06)
+//	%m  month (01-12)            %d  day of the month (01-31)
+//	%e  day of the month ( 1-31) %j  day of the year (001-366)
+//	%H  hour (00-23)             %I  hour (01-12)
+//	%M  minute (00-59)           %S  second (00-59)
+//	%f  microseconds (000000)    %L  milliseconds (000)
+//	%p  AM or PM                 %s  seconds since the unix epoch
+//	%a  abbreviated weekday      %A  weekday
+//	%b  abbreviated month        %B  month
+//	%u  weekday (1-7, monday=1)  %w  weekday (0-6, sunday=0)
+//	%z  offset (+0100)           %Z  timezone abbreviation
+//	%F  same as %Y-%m-%d         %T  same as %H:%M:%S
+//	%%  a literal %
+func formatTime(format string, t time.Time) (string, error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(format) {
+			return "", fmt.Errorf("strftime(): unterminated format specifier")
+		}
+
+		switch format[i] {
+		case 'Y':
+			sb.WriteString(t.Format("2006"))
+		case 'y':
+			sb.WriteString(t.Format("06"))
+		case 'm':
+			sb.WriteString(t.Format("01"))
+		case 'd':
+			sb.WriteString(t.Format("02"))
+		case 'e':
+			sb.WriteString(t.Format("_2"))
+		case 'j':
+			sb.WriteString(t.Format("002"))
+		case 'H':
+			sb.WriteString(t.Format("15"))
+		case 'I':
+			sb.WriteString(t.Format("03"))
+		case 'M':
+			sb.WriteString(t.Format("04"))
+		case 'S':
+			sb.WriteString(t.Format("05"))
+		case 'f':
+			fmt.Fprintf(&sb, "%06d", t.Nanosecond()/int(time.Microsecond))
+		case 'L':
+			fmt.Fprintf(&sb, "%03d", t.Nanosecond()/int(time.Millisecond))
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'b':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'u':
+			sb.WriteString(strconv.Itoa((int(t.Weekday())+6)%7 + 1))
+		case 'w':
+			sb.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case 'F':
+			sb.WriteString(t.Format("2006-01-02"))
+		case 'T':
+			sb.WriteString(t.Format("15:04:05"))
+		case '%':
+			sb.WriteByte('%')
+		default:
+			return "", fmt.Errorf("strftime(): unknown format specifier %%%c", format[i])
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// parseTime parses s using the following strftime-style specifiers:
+// %Y, %y, %m, %d, %e, %j, %H, %I, %M, %S, %f, %p, %b, %B, %z, %s, %F, %T and %%.
+// Missing fields default to their zero value (January 1st, 00:00:00).
+func parseTime(fname, s, format string, loc *time.Location) (time.Time, error) {
+	p := timeParser{fname: fname, s: s, loc: loc, year: 1970, month: 1, day: 1}
+
+	format = strings.NewReplacer("%F", "%Y-%m-%d", "%T", "%H:%M:%S").Replace(format)
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			if err := p.literal(c); err != nil {
+				return time.Time{}, err
+			}
+			continue
+		}
+
+		i++
+		if i == len(format) {
+			return time.Time{}, fmt.Errorf("%s(): unterminated format specifier", fname)
+		}
+
+		var err error
+		switch format[i] {
+		case 'Y':
+			p.year, err = p.number(4, true)
+		case 'y':
+			p.year, err = p.number(2, false)
+			p.year += 1900
+			if p.year < 1969 {
+				p.year += 100
+			}
+		case 'm':
+			p.month, err = p.number(2, false)
+		case 'd', 'e':
+			p.skipSpaces()
+			p.day, err = p.number(2, false)
+		case 'j':
+			p.yday, err = p.number(3, false)
+		case 'H', 'I':
+			p.hour, err = p.number(2, false)
+		case 'M':
+			p.minute, err = p.number(2, false)
+		case 'S':
+			p.second, err = p.number(2, false)
+		case 'f':
+			err = p.fraction()
+		case 'p':
+			err = p.meridiem()
+		case 'b', 'B':
+			err = p.monthName()
+		case 'z':
+			err = p.offset()
+		case 's':
+			var sec int
+			sec, err = p.number(19, true)
+			p.unix = &sec
+		case '%':
+			err = p.literal('%')
+		default:
+			return time.Time{}, fmt.Errorf("%s(): unknown format specifier %%%c", fname, format[i])
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if p.pos != len(p.s) {
+		return time.Time{}, fmt.Errorf("%s(): unexpected trailing text %q", fname, p.s[p.pos:])
+	}
+
+	return p.time()
+}
+
+type timeParser struct {
+	// name of the function, used in errors
+	fname string
+	s     string
+	pos   int
+	loc   *time.Location
+
+	year, month, day, yday     int
+	hour, minute, second, nsec int
+	pm, hasMeridiem            bool
+	unix                       *int
+}
+
+func (p *timeParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s(): cannot parse %q: %s", p.fname, p.s, fmt.Sprintf(format, args...))
+}
+
+func (p *timeParser) literal(c byte) error {
+	if p.pos >= len(p.s) || p.s[p.pos] != c {
+		return p.errorf("expected %q at position %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *timeParser) skipSpaces() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// number reads up to max digits.
+func (p *timeParser) number(max int, signed bool) (int, error) {
+	start := p.pos
+	if signed && p.pos < len(p.s) && (p.s[p.pos] == '-' || p.s[p.pos] == '+') {
+		p.pos++
+	}
+	digits := p.pos
+	for p.pos < len(p.s) && p.pos-digits < max && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == digits {
+		return 0, p.errorf("expected a number at position %d", start)
+	}
+
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		return 0, p.errorf("%v", err)
+	}
+	return n, nil
+}
+
+func (p *timeParser) fraction() error {
+	start := p.pos
+	for p.pos < len(p.s) && p.pos-start < 9 && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return p.errorf("expected a fraction of second at position %d", start)
+	}
+
+	frac := p.s[start:p.pos] + strings.Repeat("0", 9-(p.pos-start))
+	n, err := strconv.Atoi(frac)
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	p.nsec = n
+	return nil
+}
+
+func (p *timeParser) meridiem() error {
+	if p.pos+2 > len(p.s) {
+		return p.errorf("expected AM or PM at position %d", p.pos)
+	}
+	switch strings.ToUpper(p.s[p.pos : p.pos+2]) {
+	case "AM":
+	case "PM":
+		p.pm = true
+	default:
+		return p.errorf("expected AM or PM at position %d", p.pos)
+	}
+	p.hasMeridiem = true
+	p.pos += 2
+	return nil
+}
+
+func (p *timeParser) monthName() error {
+	rest := strings.ToLower(p.s[p.pos:])
+	for m := time.December; m >= time.January; m-- {
+		name := strings.ToLower(m.String())
+		for _, n := range []string{name, name[:3]} {
+			if strings.HasPrefix(rest, n) {
+				p.month = int(m)
+				p.pos += len(n)
+				return nil
+			}
+		}
+	}
+
+	return p.errorf("expected a month name at position %d", p.pos)
+}
+
+func (p *timeParser) offset() error {
+	if p.pos < len(p.s) && p.s[p.pos] == 'Z' {
+		p.pos++
+		p.loc = time.UTC
+		return nil
+	}
+
+	if p.pos >= len(p.s) || (p.s[p.pos] != '+' && p.s[p.pos] != '-') {
+		return p.errorf("expected a timezone offset at position %d", p.pos)
+	}
+	sign := 1
+	if p.s[p.pos] == '-' {
+		sign = -1
+	}
+	p.pos++
+
+	h, err := p.number(2, false)
+	if err != nil {
+		return err
+	}
+	if p.pos < len(p.s) && p.s[p.pos] == ':' {
+		p.pos++
+	}
+	m, err := p.number(2, false)
+	if err != nil {
+		return err
+	}
+
+	p.loc = time.FixedZone("", sign*(h*3600+m*60))
+	return nil
+}
+
+func (p *timeParser) time() (time.Time, error) {
+	if p.unix != nil {
+		return time.Unix(int64(*p.unix), int64(p.nsec)).UTC(), nil
+	}
+
+	if p.hasMeridiem {
+		if p.hour < 1 || p.hour > 12 {
+			return time.Time{}, p.errorf("hour out of range")
+		}
+		p.hour %= 12
+		if p.pm {
+			p.hour += 12
+		}
+	}
+
+	if p.month < 1 || p.month > 12 {
+		return time.Time{}, p.errorf("month out of range")
+	}
+	if p.hour > 23 || p.minute > 59 || p.second > 59 {
+		return time.Time{}, p.errorf("time out of range")
+	}
+
+	if p.yday != 0 {
+		if p.yday > 366 {
+			return time.Time{}, p.errorf("day of the year out of range")
+		}
+		return time.Date(p.year, 1, p.yday, p.hour, p.minute, p.second, p.nsec, p.loc), nil
+	}
+
+	t := time.Date(p.year, time.Month(p.month), p.day, p.hour, p.minute, p.second, p.nsec, p.loc)
+	if t.Day() != p.day {
+		return time.Time{}, p.errorf("day out of range")
+	}
+
+	return t, nil
+}
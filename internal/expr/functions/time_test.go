@@ -0,0 +1,12 @@
+package functions_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai/internal/testutil"
+)
+
+func TestTimeFunctions(t *testing.T) {
+	testutil.ExprRunner(t, filepath.Join("testdata", "time_functions.sql"))
+}
@@ -0,0 +1,122 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A UserFunction is a call to a function created with CREATE FUNCTION.
+// The parser returns a UserFunction for every call to a function
+// that is not builtin. The definition of the function is looked up
+// in the catalog when the statement is bound, or when the call is
+// evaluated if it wasn't bound.
+//
+//	CREATE FUNCTION area(w, h) RETURNS (w * h)
+//	area(2, 3) -> 6
+type UserFunction struct {
+	Name string
+	Args []expr.Expr
+
+	// Info is the definition of the function,
+	// set when the call is bound.
+	Info *database.FunctionInfo
+}
+
+// Bind looks up the definition of the function in the catalog
+// and ensures it is called with the right number of arguments.
+func (f *UserFunction) Bind(catalog *database.Catalog) error {
+	info, err := catalog.GetFunction(f.Name)
+	if err != nil {
+		return fmt.Errorf("no such function: %q", f.Name)
+	}
+
+	if len(f.Args) != len(info.Params) {
+		return fmt.Errorf("%s() takes %d argument(s), not %d", info.Name, len(info.Params), len(f.Args))
+	}
+
+	f.Info = info
+	return nil
+}
+
+func (f *UserFunction) Clone() expr.Expr {
+	args := make([]expr.Expr, len(f.Args))
+	for i, a := range f.Args {
+		args[i] = expr.Clone(a)
+	}
+
+	return &UserFunction{
+		Name: f.Name,
+		Args: args,
+		Info: f.Info,
+	}
+}
+
+// Eval evaluates the body of the function, with each parameter
+// set to the value of the corresponding argument.
+func (f *UserFunction) Eval(env *environment.Environment) (types.Value, error) {
+	tx := env.GetTx()
+
+	if f.Info == nil {
+		if tx == nil {
+			return nil, errors.Errorf("%s() cannot be evaluated", f.Name)
+		}
+
+		err := f.Bind(tx.Catalog)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	args := row.NewColumnBuffer()
+	for i, a := range f.Args {
+		v, err := a.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		args.Add(f.Info.Params[i], v)
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(env)
+	newEnv.SetRow(args)
+
+	return f.Info.Body.(expr.Expr).Eval(&newEnv)
+}
+
+func (f *UserFunction) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*UserFunction)
+	if !ok || !strings.EqualFold(f.Name, o.Name) || len(f.Args) != len(o.Args) {
+		return false
+	}
+
+	for i := range f.Args {
+		if !expr.Equal(f.Args[i], o.Args[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *UserFunction) Params() []expr.Expr { return f.Args }
+
+func (f *UserFunction) String() string {
+	args := make([]string, len(f.Args))
+	for i, a := range f.Args {
+		args[i] = a.String()
+	}
+
+	return fmt.Sprintf("%s(%s)", f.Name, strings.Join(args, ", "))
+}
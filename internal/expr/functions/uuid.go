@@ -0,0 +1,21 @@
+package functions
+
+import (
+	"github.com/chaisql/chai/internal/types"
+)
+
+// uuid and gen_random_uuid return a random UUID (version 4).
+var (
+	uuid          = newRandomUUIDDefinition("uuid")
+	genRandomUUID = newRandomUUIDDefinition("gen_random_uuid")
+)
+
+func newRandomUUIDDefinition(name string) *ScalarDefinition {
+	return &ScalarDefinition{
+		name:  name,
+		arity: 0,
+		callFn: func(args ...types.Value) (types.Value, error) {
+			return types.NewRandomUUIDValue()
+		},
+	}
+}
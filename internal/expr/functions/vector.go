@@ -0,0 +1,95 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var vectorDistance = &definition{
+	name:  "vector_distance",
+	arity: 3,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		return &VectorDistance{A: args[0], B: args[1], Metric: args[2]}, nil
+	},
+}
+
+// VectorDistance returns the distance between two vectors,
+// measured with the metric 'l2' or 'cosine'.
+// Texts are converted to vectors.
+//
+//	vector_distance('[0, 0]', '[3, 4]', 'l2') -> 5.0
+//	vector_distance('[1, 0]', '[0, 1]', 'cosine') -> 1.0
+type VectorDistance struct {
+	A, B   expr.Expr
+	Metric expr.Expr
+}
+
+func (v *VectorDistance) Clone() expr.Expr {
+	return &VectorDistance{
+		A:      expr.Clone(v.A),
+		B:      expr.Clone(v.B),
+		Metric: expr.Clone(v.Metric),
+	}
+}
+
+func (v *VectorDistance) Eval(env *environment.Environment) (types.Value, error) {
+	var vectors [2]types.VectorValue
+	for i, e := range []expr.Expr{v.A, v.B} {
+		x, err := e.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if x.Type() == types.TypeNull {
+			return types.NewNullValue(), nil
+		}
+
+		x, err = x.CastAs(types.TypeVector)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = x.(types.VectorValue)
+	}
+
+	m, err := v.Metric.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if m.Type() != types.TypeText {
+		return nil, errors.Errorf("vector_distance metric must be a text, got %s", m.Type())
+	}
+
+	metric, err := types.ParseVectorMetric(types.AsString(m))
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := metric.Distance(vectors[0], vectors[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewDoubleValue(d), nil
+}
+
+func (v *VectorDistance) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*VectorDistance)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(v.A, o.A) && expr.Equal(v.B, o.B) && expr.Equal(v.Metric, o.Metric)
+}
+
+func (v *VectorDistance) Params() []expr.Expr { return []expr.Expr{v.A, v.B, v.Metric} }
+
+func (v *VectorDistance) String() string {
+	return fmt.Sprintf("vector_distance(%v, %v, %v)", v.A, v.B, v.Metric)
+}
@@ -58,9 +58,18 @@ func equalFold(sr, tr rune) bool {
 // MatchLike reports whether string s matches the SQL LIKE-style glob pattern.
 // Supported wildcards are '_' (match any one character) and '%' (match zero
 // or more characters). They can be escaped by '\' (escape character).
+// Characters are compared using Unicode simple case folding.
 //
 // MatchLike requires pattern to match whole string, not just a substring.
 func MatchLike(pattern, s string) bool {
+	return Match(pattern, s, matchEsc, false)
+}
+
+// Match is like MatchLike but uses esc as the escape character,
+// or no escape character if esc is 0, and compares characters
+// exactly if caseSensitive is true.
+// The escape character must not be a wildcard.
+func Match(pattern, s string, esc rune, caseSensitive bool) bool {
 	var prevEscape bool
 
 	var w, t string // backtracking state
@@ -78,7 +87,7 @@ func MatchLike(pattern, s string) bool {
 		//
 		// 1. p is an unescaped matchAll character “%”,
 		// 2. p is an unescaped matchOne character “_”,
-		// 3. p is an unescaped matchEsc character, or
+		// 3. p is an unescaped escape character, or
 		// 4. p is to be handled as an ordinary character
 		//
 		if p == matchAll && !prevEscape {
@@ -126,7 +135,7 @@ func MatchLike(pattern, s string) bool {
 			// That is, we are guaranteed to have input at this point.
 			//
 			s = skipRune(s)
-		} else if p == matchEsc && !prevEscape {
+		} else if esc != 0 && p == esc && !prevEscape {
 			// Case 3.
 			//
 			// We can’t reach this case from backtracking to matchAll.
@@ -144,7 +153,7 @@ func MatchLike(pattern, s string) bool {
 
 			var r rune
 			r, s = readRune(s)
-			if !equalFold(p, r) {
+			if p != r && (caseSensitive || !equalFold(p, r)) {
 				goto backtrack
 			}
 		}
@@ -169,16 +178,16 @@ func MatchLike(pattern, s string) bool {
 	}
 
 	// Check that the rest of the pattern is matchAll.
-	for i := 0; i < len(pattern); i++ {
-		if pattern[i] == matchAll {
+	for len(pattern) != 0 {
+		var p rune
+		p, pattern = readRune(pattern)
+		if p == matchAll {
 			continue
 		}
 
 		// Allow escaping end of string.
-		if i+1 == len(pattern) {
-			if pattern[i] == matchEsc {
-				return true
-			}
+		if esc != 0 && p == esc && len(pattern) == 0 {
+			return true
 		}
 
 		return false
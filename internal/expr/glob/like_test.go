@@ -200,3 +200,55 @@ func TestMatchLike(t *testing.T) {
 		}
 	}
 }
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		s, pattern    string
+		esc           rune
+		caseSensitive bool
+		want          bool
+	}{
+		// Custom escape
+		{"%", "!%", '!', false, true},
+		{"x", "!%", '!', false, false},
+		{"a_c", "a!_c", '!', false, true},
+		{"abc", "a!_c", '!', false, false},
+		{"!", "!!", '!', false, true},
+		{"x", "_!", '!', false, true},
+		{"\\", "\\", '!', false, true},
+		{"\\x", "\\_", '!', false, true},
+
+		// Non-ASCII escape
+		{"%", "é%", 'é', false, true},
+		{"ab", "a%é", 'é', false, true},
+		{"é", "éé", 'é', false, true},
+
+		// No escape
+		{"\\", "\\", 0, false, true},
+		{"\\x", "\\_", 0, false, true},
+		{"x", "\\x", 0, false, false},
+
+		// Case sensitive
+		{"abc", "abc", 0, true, true},
+		{"aBc", "AbC", 0, true, false},
+		{"aBc", "a_c", 0, true, true},
+		{"ÉTÉ", "été", 0, true, false},
+		{"K", "K", 0, true, false},
+
+		// Case insensitive, non-ASCII
+		{"ÉTÉ", "été", 0, false, true},
+		{"Straße", "STRAßE", 0, false, true},
+		{"ΣΊΣΥΦΟΣ", "σίσυφος", 0, false, true},
+		{"ΣΊΣΥΦΟΣ", "%ς", 0, false, true},
+		{"Ǆ", "ǅ", 0, false, true},
+	}
+
+	for _, test := range tests {
+		if got := Match(test.pattern, test.s, test.esc, test.caseSensitive); got != test.want {
+			t.Errorf(
+				"Match(%#v, %#v, %q, %v): expected %#v, got %#v",
+				test.pattern, test.s, test.esc, test.caseSensitive, test.want, got,
+			)
+		}
+	}
+}
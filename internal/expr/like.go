@@ -2,39 +2,103 @@ package expr
 
 import (
 	"fmt"
+	"unicode/utf8"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr/glob"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
 )
 
-func like(pattern, text string) bool {
-	return glob.MatchLike(pattern, text)
-}
+// defaultLikeEscape is the escape character used when
+// no ESCAPE clause is specified.
+const defaultLikeEscape = '\\'
 
 type LikeOperator struct {
 	*simpleOperator
+
+	// Escape is the optional expression of the ESCAPE clause.
+	// It must evaluate to a text value of one character,
+	// or to an empty text to disable escaping.
+	Escape Expr
 }
 
 // Like creates an expression that evaluates to the result of a LIKE b.
 func Like(a, b Expr) Expr {
-	return &LikeOperator{&simpleOperator{a, b, scanner.LIKE}}
+	return &LikeOperator{simpleOperator: &simpleOperator{a, b, scanner.LIKE}}
+}
+
+// LikeEscape creates an expression that evaluates to the result of a LIKE b ESCAPE esc.
+func LikeEscape(a, b, esc Expr) Expr {
+	return &LikeOperator{simpleOperator: &simpleOperator{a, b, scanner.LIKE}, Escape: esc}
 }
 
 func (op *LikeOperator) Clone() Expr {
 	return &LikeOperator{
 		simpleOperator: op.simpleOperator.Clone(),
+		Escape:         Clone(op.Escape),
+	}
+}
+
+// EscapeExpr returns the expression of the ESCAPE clause, if any.
+func (op *LikeOperator) EscapeExpr() Expr {
+	return op.Escape
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (op *LikeOperator) IsEqual(other Expr) bool {
+	if !op.simpleOperator.IsEqual(other) {
+		return false
+	}
+
+	o, ok := other.(interface{ EscapeExpr() Expr })
+	if !ok {
+		return false
 	}
+
+	return Equal(op.Escape, o.EscapeExpr())
 }
 
 func (op *LikeOperator) Eval(env *environment.Environment) (types.Value, error) {
+	esc := rune(defaultLikeEscape)
+	if op.Escape != nil {
+		v, err := op.Escape.Eval(env)
+		if err != nil {
+			return NullLiteral, err
+		}
+
+		if v.Type() == types.TypeNull {
+			return NullLiteral, nil
+		}
+
+		esc, err = likeEscapeChar(v)
+		if err != nil {
+			return NullLiteral, err
+		}
+	}
+
+	var caseSensitive bool
+	if db := env.GetDB(); db != nil {
+		caseSensitive = db.CaseSensitiveLike
+	}
+	if tx := env.GetTx(); tx != nil {
+		switch tx.CompatMode {
+		case database.CompatSQLite:
+			caseSensitive = false
+		case database.CompatPostgres:
+			caseSensitive = true
+		}
+	}
+
 	return op.simpleOperator.eval(env, func(a, b types.Value) (types.Value, error) {
 		if a.Type() != types.TypeText || b.Type() != types.TypeText {
 			return NullLiteral, nil
 		}
 
-		if like(types.AsString(b), types.AsString(a)) {
+		if glob.Match(types.AsString(b), types.AsString(a), esc, caseSensitive) {
 			return TrueLiteral, nil
 		}
 
@@ -42,7 +106,35 @@ func (op *LikeOperator) Eval(env *environment.Environment) (types.Value, error)
 	})
 }
 
+// likeEscapeChar returns the escape character represented by v,
+// or 0 if v is an empty text.
+func likeEscapeChar(v types.Value) (rune, error) {
+	if v.Type() != types.TypeText {
+		return 0, errors.Errorf("invalid escape string: expected text, got %s", v.Type())
+	}
+
+	s := types.AsString(v)
+	if s == "" {
+		return 0, nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == utf8.RuneError {
+		return 0, errors.Errorf("invalid escape string %q: must be empty or one character long", s)
+	}
+
+	if r == '%' || r == '_' {
+		return 0, errors.Errorf("invalid escape string %q: cannot be a wildcard", s)
+	}
+
+	return r, nil
+}
+
 func (op *LikeOperator) String() string {
+	if op.Escape != nil {
+		return fmt.Sprintf("%v LIKE %v ESCAPE %v", op.a, op.b, op.Escape)
+	}
+
 	return fmt.Sprintf("%v LIKE %v", op.a, op.b)
 }
 
@@ -52,7 +144,12 @@ type NotLikeOperator struct {
 
 // NotLike creates an expression that evaluates to the result of a NOT LIKE b.
 func NotLike(a, b Expr) Expr {
-	return &NotLikeOperator{&LikeOperator{&simpleOperator{a, b, scanner.NLIKE}}}
+	return &NotLikeOperator{&LikeOperator{simpleOperator: &simpleOperator{a, b, scanner.NLIKE}}}
+}
+
+// NotLikeEscape creates an expression that evaluates to the result of a NOT LIKE b ESCAPE esc.
+func NotLikeEscape(a, b, esc Expr) Expr {
+	return &NotLikeOperator{&LikeOperator{simpleOperator: &simpleOperator{a, b, scanner.NLIKE}, Escape: esc}}
 }
 
 func (op *NotLikeOperator) Clone() Expr {
@@ -66,5 +163,9 @@ func (op *NotLikeOperator) Eval(env *environment.Environment) (types.Value, erro
 }
 
 func (op *NotLikeOperator) String() string {
+	if op.Escape != nil {
+		return fmt.Sprintf("%v NOT LIKE %v ESCAPE %v", op.a, op.b, op.Escape)
+	}
+
 	return fmt.Sprintf("%v NOT LIKE %v", op.a, op.b)
 }
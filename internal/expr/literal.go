@@ -26,6 +26,11 @@ func (v LiteralValue) IsEqual(other Expr) bool {
 
 // String implements the fmt.Stringer interface.
 func (v LiteralValue) String() string {
+	// intervals would be parsed as texts otherwise
+	if v.Value.Type() == types.TypeInterval {
+		return "INTERVAL " + v.Value.String()
+	}
+
 	return v.Value.String()
 }
 
@@ -122,16 +122,43 @@ func (op *ConcatOperator) Eval(env *environment.Environment) (types.Value, error
 type Cast struct {
 	Expr   Expr
 	CastAs types.Type
+	// DecimalSpec is the precision and the scale
+	// of casts to DECIMAL(precision, scale).
+	DecimalSpec types.DecimalSpec
+	// VectorSpec is the dimension of casts to VECTOR(dimension).
+	VectorSpec types.VectorSpec
+	// Try makes the cast return NULL instead of an error
+	// when the value cannot be converted, as with TRY_CAST.
+	Try bool
 }
 
-// Eval returns the primary key of the current row.
+// Eval converts the result of the expression to the target type.
 func (c *Cast) Eval(env *environment.Environment) (types.Value, error) {
 	v, err := c.Expr.Eval(env)
 	if err != nil {
 		return v, err
 	}
 
-	return v.CastAs(c.CastAs)
+	v, err = c.convert(v)
+	if err != nil && c.Try {
+		return types.NewNullValue(), nil
+	}
+
+	return v, err
+}
+
+func (c *Cast) convert(v types.Value) (types.Value, error) {
+	v, err := v.CastAs(c.CastAs)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err = c.DecimalSpec.Apply(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.VectorSpec.Apply(v)
 }
 
 // IsEqual compares this expression with the other expression and returns
@@ -146,7 +173,7 @@ func (c *Cast) IsEqual(other Expr) bool {
 		return false
 	}
 
-	if c.CastAs != o.CastAs {
+	if c.CastAs != o.CastAs || c.DecimalSpec != o.DecimalSpec || c.VectorSpec != o.VectorSpec || c.Try != o.Try {
 		return false
 	}
 
@@ -160,5 +187,10 @@ func (c *Cast) IsEqual(other Expr) bool {
 func (c *Cast) Params() []Expr { return []Expr{c.Expr} }
 
 func (c *Cast) String() string {
-	return fmt.Sprintf("CAST(%v AS %v)", c.Expr, c.CastAs)
+	name := "CAST"
+	if c.Try {
+		name = "TRY_CAST"
+	}
+
+	return fmt.Sprintf("%s(%v AS %v%v%v)", name, c.Expr, c.CastAs, c.DecimalSpec, c.VectorSpec)
 }
@@ -0,0 +1,104 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// maxCachedRegexps is the number of compiled regular expressions
+// kept by CompileRegexp.
+const maxCachedRegexps = 256
+
+var regexpCache = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+// CompileRegexp compiles a regular expression using the syntax of Go's regexp package.
+// Compiled expressions are cached, as the same pattern is usually
+// matched against every row of a query.
+func CompileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+
+	if re, ok := regexpCache.m[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the cache is small, it is simply emptied when full
+	if len(regexpCache.m) >= maxCachedRegexps {
+		clear(regexpCache.m)
+	}
+	regexpCache.m[pattern] = re
+
+	return re, nil
+}
+
+type RegexpOperator struct {
+	*simpleOperator
+}
+
+// Regexp creates an expression that evaluates to the result of a ~ b,
+// that is whether the text a matches the regular expression b.
+func Regexp(a, b Expr) Expr {
+	return &RegexpOperator{&simpleOperator{a, b, scanner.EQREGEX}}
+}
+
+func (op *RegexpOperator) Clone() Expr {
+	return &RegexpOperator{op.simpleOperator.Clone()}
+}
+
+func (op *RegexpOperator) Eval(env *environment.Environment) (types.Value, error) {
+	return op.simpleOperator.eval(env, func(a, b types.Value) (types.Value, error) {
+		if a.Type() != types.TypeText || b.Type() != types.TypeText {
+			return NullLiteral, nil
+		}
+
+		re, err := CompileRegexp(types.AsString(b))
+		if err != nil {
+			return NullLiteral, err
+		}
+
+		if re.MatchString(types.AsString(a)) {
+			return TrueLiteral, nil
+		}
+
+		return FalseLiteral, nil
+	})
+}
+
+func (op *RegexpOperator) String() string {
+	return fmt.Sprintf("%v ~ %v", op.a, op.b)
+}
+
+type NotRegexpOperator struct {
+	*RegexpOperator
+}
+
+// NotRegexp creates an expression that evaluates to the result of a !~ b.
+func NotRegexp(a, b Expr) Expr {
+	return &NotRegexpOperator{&RegexpOperator{&simpleOperator{a, b, scanner.NEQREGEX}}}
+}
+
+func (op *NotRegexpOperator) Clone() Expr {
+	return &NotRegexpOperator{op.RegexpOperator.Clone().(*RegexpOperator)}
+}
+
+func (op *NotRegexpOperator) Eval(env *environment.Environment) (types.Value, error) {
+	return invertBoolResult(op.RegexpOperator.Eval)(env)
+}
+
+func (op *NotRegexpOperator) String() string {
+	return fmt.Sprintf("%v !~ %v", op.a, op.b)
+}
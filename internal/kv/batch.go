@@ -1,7 +1,7 @@
 package kv
 
 import (
-	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/engine"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/pebble"
 )
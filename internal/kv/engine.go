@@ -1,12 +1,17 @@
 package kv
 
 import (
+	"context"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/chaisql/chai/engine"
 	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/pkg/atomic"
 	"github.com/chaisql/chai/internal/pkg/pebbleutil"
@@ -35,16 +40,46 @@ type PebbleEngine struct {
 		snapshot *snapshot
 	}
 
-	minTransientNamespace uint64
-	maxTransientNamespace uint64
+	// write sets of recently committed optimistic sessions.
+	commitLog commitLog
+
+	// held by the optimistic sessions while they commit, and by
+	// the one that spilled its writes to the store until it ends.
+	writeMu sync.Mutex
+
+	// store in which the transient sessions spill their data,
+	// if Options.TransientDir is set.
+	transient *transientStore
+
+	// synchronizes the commits made with engine.SyncNormal.
+	syncer *walSyncer
+
+	// lock held on the database directory, if any.
+	lock io.Closer
 }
 
+var _ engine.MetricsEngine = (*PebbleEngine)(nil)
+
 type Options struct {
 	RollbackSegmentNamespace int64
 	MaxBatchSize             int
 	MaxTransientBatchSize    int
 	MinTransientNamespace    uint64
 	MaxTransientNamespace    uint64
+	// LockTimeout is the maximum amount of time to wait
+	// for another process to release the database directory.
+	LockTimeout time.Duration
+	// TransientDir is the directory in which the transient sessions
+	// spill their data, in a subdirectory created by the engine and
+	// removed when it is closed. The subdirectories left by engines
+	// that were not closed, after a crash, are removed when another
+	// engine is created with the same TransientDir.
+	// If empty, the data is spilled to the database.
+	TransientDir string
+	// SyncInterval is the interval at which the commits made
+	// with engine.SyncNormal are synchronized to disk.
+	// If zero, it defaults to one second.
+	SyncInterval time.Duration
 }
 
 func NewEngineWith(path string, opts Options, popts *pebble.Options) (*PebbleEngine, error) {
@@ -65,7 +100,16 @@ func NewEngineWith(path string, opts Options, popts *pebble.Options) (*PebbleEng
 		return nil, err
 	}
 
-	return NewStore(db, opts), nil
+	e := NewStore(db, opts)
+	if opts.TransientDir != "" {
+		e.transient, err = openTransientStore(opts.TransientDir)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	return e, nil
 }
 
 func NewEngine(path string, opts Options) (*PebbleEngine, error) {
@@ -102,7 +146,25 @@ func NewEngine(path string, opts Options) (*PebbleEngine, error) {
 
 	popts.FormatMajorVersion = pebble.FormatVirtualSSTables
 
-	return NewEngineWith(pbpath, opts, &popts)
+	var lock io.Closer
+	if path != ":memory:" {
+		var err error
+		lock, err = lockDir(path, opts.LockTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e, err := NewEngineWith(pbpath, opts, &popts)
+	if err != nil {
+		if lock != nil {
+			_ = lock.Close()
+		}
+		return nil, err
+	}
+	e.lock = lock
+
+	return e, nil
 }
 
 // DefaultComparer is the default implementation of the Comparer interface for chai.
@@ -125,6 +187,9 @@ func NewStore(db *pebble.DB, opts Options) *PebbleEngine {
 	if opts.MaxTransientBatchSize <= 0 {
 		opts.MaxTransientBatchSize = defaultMaxTransientBatchSize
 	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = defaultSyncInterval
+	}
 	if opts.MinTransientNamespace == 0 {
 		panic("min transient namespace cannot be 0")
 	}
@@ -136,11 +201,27 @@ func NewStore(db *pebble.DB, opts Options) *PebbleEngine {
 		db:              db,
 		opts:            opts,
 		rollbackSegment: NewRollbackSegment(db, opts.RollbackSegmentNamespace),
+		syncer:          &walSyncer{db: db, interval: opts.SyncInterval},
 	}
 }
 
 func (s *PebbleEngine) Close() error {
-	return s.db.Close()
+	err := s.syncer.close()
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	if s.transient != nil {
+		if terr := s.transient.close(); err == nil {
+			err = terr
+		}
+	}
+	if s.lock != nil {
+		if lerr := s.lock.Close(); err == nil {
+			err = lerr
+		}
+	}
+
+	return err
 }
 
 func (s *PebbleEngine) Rollback() error {
@@ -161,6 +242,24 @@ func (s *PebbleEngine) LockSharedSnapshot() {
 	s.sharedSnapshot.Unlock()
 }
 
+// acquireSnapshot returns the shared snapshot if there is one,
+// or a new snapshot otherwise.
+func (s *PebbleEngine) acquireSnapshot() *snapshot {
+	s.sharedSnapshot.RLock()
+	defer s.sharedSnapshot.RUnlock()
+
+	sn := s.sharedSnapshot.snapshot
+	if sn == nil {
+		sn = &snapshot{
+			snapshot: s.db.NewSnapshot(),
+			refCount: atomic.NewCounter(0, math.MaxInt64, false),
+		}
+	}
+	sn.Incr()
+
+	return sn
+}
+
 func (s *PebbleEngine) UnlockSharedSnapshot() {
 	s.sharedSnapshot.Lock()
 	s.sharedSnapshot.snapshot.Done()
@@ -172,10 +271,79 @@ func (s *PebbleEngine) DB() *pebble.DB {
 	return s.db
 }
 
+func (s *PebbleEngine) Compact(start, end []byte) error {
+	return errors.WithStack(s.db.Compact(start, end, true))
+}
+
+func (s *PebbleEngine) Flush() error {
+	return errors.WithStack(s.db.Flush())
+}
+
+// Metrics returns the statistics of the block cache,
+// the compactions and the flushes of Pebble.
+func (s *PebbleEngine) Metrics() engine.Metrics {
+	m := s.db.Metrics()
+
+	return engine.Metrics{
+		CacheSize:          m.BlockCache.Size,
+		CacheHits:          m.BlockCache.Hits,
+		CacheMisses:        m.BlockCache.Misses,
+		Compactions:        m.Compact.Count,
+		CompactionDuration: m.Compact.Duration,
+		CompactedBytes:     m.Total().BytesCompacted,
+		Flushes:            m.Flush.Count,
+		DiskSize:           m.DiskSpaceUsage(),
+	}
+}
+
+func (s *PebbleEngine) SpanStats(start, end []byte) (engine.SpanStats, error) {
+	var stats engine.SpanStats
+
+	levels, err := s.db.SSTables(pebble.WithProperties(), pebble.WithKeyRangeFilter(start, end), pebble.WithApproximateSpanBytes())
+	if err != nil {
+		return stats, errors.WithStack(err)
+	}
+
+	for _, tables := range levels {
+		for _, t := range tables {
+			if t.Size == 0 {
+				continue
+			}
+
+			spanBytes, err := strconv.ParseUint(t.Properties.UserProperties["approximate-span-bytes"], 10, 64)
+			if err != nil {
+				return stats, errors.WithStack(err)
+			}
+
+			// part of the file holding keys of the span
+			f := float64(spanBytes) / float64(t.Size)
+			stats.DiskSize += spanBytes
+			stats.RawSize += uint64(f * float64(t.Properties.RawKeySize+t.Properties.RawValueSize))
+		}
+	}
+
+	// the properties of the files only give the number of deletions
+	// of the whole file, count the tombstones of the span exactly.
+	ks, err := s.db.ScanStatistics(context.Background(), start, end, pebble.ScanStatisticsOptions{})
+	if err != nil {
+		return stats, errors.WithStack(err)
+	}
+	for _, kind := range []pebble.InternalKeyKind{
+		pebble.InternalKeyKindDelete,
+		pebble.InternalKeyKindSingleDelete,
+		pebble.InternalKeyKindDeleteSized,
+		pebble.InternalKeyKindRangeDelete,
+	} {
+		stats.Tombstones += uint64(ks.Accumulated.KindsCount[kind])
+	}
+
+	return stats, nil
+}
+
 func (s *PebbleEngine) CleanupTransientNamespaces() error {
 	return s.db.DeleteRange(
-		encoding.EncodeUint(nil, uint64(s.minTransientNamespace)),
-		encoding.EncodeUint(nil, uint64(s.maxTransientNamespace)),
+		encoding.EncodeUint(nil, s.opts.MinTransientNamespace),
+		encoding.EncodeUint(nil, s.opts.MaxTransientNamespace),
 		pebble.NoSync,
 	)
 }
@@ -0,0 +1,98 @@
+package kv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/engine/enginetest"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine(t *testing.T) {
+	enginetest.TestSuite(t, func(t testing.TB) engine.Engine {
+		ng, err := kv.NewEngine(":memory:", kv.Options{
+			RollbackSegmentNamespace: int64(database.RollbackSegmentNamespace),
+			MinTransientNamespace:    uint64(database.MinTransientNamespace),
+			MaxTransientNamespace:    uint64(database.MaxTransientNamespace),
+		})
+		require.NoError(t, err)
+		return ng
+	})
+}
+
+// TestSynchronous simulates a crash of the machine, which loses the writes
+// that were not synchronized, after commits made with each mode.
+func TestSynchronous(t *testing.T) {
+	opts := kv.Options{
+		RollbackSegmentNamespace: int64(database.RollbackSegmentNamespace),
+		MinTransientNamespace:    uint64(database.MinTransientNamespace),
+		MaxTransientNamespace:    uint64(database.MaxTransientNamespace),
+		SyncInterval:             10 * time.Millisecond,
+	}
+
+	key := encoding.EncodeInt(encoding.EncodeInt(nil, 100), 1)
+
+	tests := []struct {
+		mode engine.Synchronous
+		// time to wait after the commit, before the crash
+		wait time.Duration
+		kept bool
+	}{
+		{engine.SyncFull, 0, true},
+		{engine.SyncNormal, 100 * time.Millisecond, true},
+		{engine.SyncOff, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			fs := vfs.NewStrictMem()
+			require.NoError(t, fs.MkdirAll("db", 0755))
+			// make the directory of the database survive the crash
+			dir, err := fs.OpenDir("")
+			require.NoError(t, err)
+			require.NoError(t, dir.Sync())
+			require.NoError(t, dir.Close())
+
+			ng, err := kv.NewEngineWith("db", opts, &pebble.Options{FS: fs})
+			require.NoError(t, err)
+
+			s := ng.NewOptimisticSession().(engine.SyncSession)
+			require.NoError(t, s.Put(key, []byte("a")))
+			require.NoError(t, s.Commit())
+
+			s = ng.NewOptimisticSession().(engine.SyncSession)
+			s.SetSynchronous(test.mode)
+			require.NoError(t, s.Put(key, []byte("b")))
+			require.NoError(t, s.Commit())
+			time.Sleep(test.wait)
+
+			// crash: discard everything written after the last sync
+			fs.SetIgnoreSyncs(true)
+			require.NoError(t, ng.Close())
+			fs.ResetToSyncedState()
+			fs.SetIgnoreSyncs(false)
+
+			ng, err = kv.NewEngineWith("db", opts, &pebble.Options{FS: fs})
+			require.NoError(t, err)
+			defer ng.Close()
+
+			// the database remains consistent: it either has
+			// the last commit or the previous one.
+			snap := ng.NewSnapshotSession()
+			v, err := snap.Get(key)
+			require.NoError(t, err)
+			if test.kept {
+				require.Equal(t, []byte("b"), v)
+			} else {
+				require.Equal(t, []byte("a"), v)
+			}
+			require.NoError(t, snap.Close())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package kv
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// lockRetryInterval is the delay between two attempts
+// to acquire the database lock.
+const lockRetryInterval = 10 * time.Millisecond
+
+// lockDir acquires an advisory lock on the database directory,
+// preventing other processes from opening it at the same time.
+// If the lock is held by someone else, it retries until timeout is reached
+// and returns engine.ErrDatabaseLocked.
+func lockDir(dir string, timeout time.Duration) (io.Closer, error) {
+	name := filepath.Join(dir, "LOCK")
+
+	// make sure the lock file can be created, to distinguish
+	// permission errors from locking errors.
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		l, err := vfs.Default.Lock(name)
+		if err == nil {
+			return l, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, errors.Wrapf(engine.ErrDatabaseLocked, "%s: %v", dir, err)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,852 @@
+package kv
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+var (
+	_ engine.SyncSession     = (*OptimisticSession)(nil)
+	_ engine.ConflictSession = (*OptimisticSession)(nil)
+)
+
+// commitLog keeps track of the keys written by recently committed
+// optimistic sessions, in order to detect write-write conflicts.
+type commitLog struct {
+	sync.Mutex
+
+	// sequence number of the last commit.
+	seq uint64
+	// number of open sessions per start sequence.
+	active map[uint64]int
+	// write sets of the commits that happened after
+	// the oldest open session started.
+	entries []commitLogEntry
+}
+
+type commitLogEntry struct {
+	seq    uint64
+	keys   map[string]struct{}
+	ranges []keyRange
+}
+
+// keyRange is a range of keys deleted by a session,
+// from start (inclusive) to end (exclusive).
+type keyRange struct {
+	start, end []byte
+	// sequence of the writes at the time of the deletion.
+	seq uint64
+}
+
+func (r *keyRange) contains(k []byte) bool {
+	return encoding.Compare(k, r.start) >= 0 && encoding.Compare(k, r.end) < 0
+}
+
+func (r *keyRange) overlaps(o *keyRange) bool {
+	return encoding.Compare(r.start, o.end) < 0 && encoding.Compare(o.start, r.end) < 0
+}
+
+// release unregisters a session that started at the given sequence
+// and drops the entries that can no longer conflict with any open session.
+// It must be called with the lock held.
+func (l *commitLog) release(start uint64) {
+	l.active[start]--
+	if l.active[start] <= 0 {
+		delete(l.active, start)
+	}
+
+	if len(l.active) == 0 {
+		l.entries = nil
+		return
+	}
+
+	min := l.seq
+	for s := range l.active {
+		if s < min {
+			min = s
+		}
+	}
+
+	i := sort.Search(len(l.entries), func(i int) bool {
+		return l.entries[i].seq > min
+	})
+	l.entries = l.entries[i:]
+}
+
+// An OptimisticSession reads from a snapshot taken when the session is created
+// and buffers its writes in memory until commit.
+// Multiple optimistic sessions can be open at the same time.
+// Upon commit, if any key written by the session, or recorded with
+// AddConflictKey, was also written or recorded by a session that committed
+// after this one started, the commit fails with engine.ErrTxConflict
+// and nothing is written.
+//
+// DeleteRange doesn't delete the keys one by one: the range is kept
+// as a tombstone which hides the keys of the snapshot it contains,
+// and is written with a single range deletion on commit.
+// A key written by another session conflicts with the ranges it belongs to.
+//
+// When the size of its writes exceeds the maximum batch size of the engine,
+// the session spills them to the store with a batch session, which keeps a
+// rollback segment to undo them if the session is not committed, and only
+// keeps their keys in memory. The other sessions can't commit until a session
+// that spilled its writes is committed or closed.
+type OptimisticSession struct {
+	Store    *PebbleEngine
+	Snapshot *snapshot
+	closed   bool
+	start    uint64
+	sync     engine.Synchronous
+
+	writes writeSet
+	// keys the session relied on without writing them,
+	// which conflict like the keys it wrote.
+	conflictKeys map[string]struct{}
+	// ranges deleted by the session, in the order of the deletions.
+	ranges []keyRange
+	// sequence of the writes, incremented when an iterator is created
+	// so that iterators don't see the writes made after their creation.
+	seq uint64
+	// number of open iterators per sequence.
+	iterators map[uint64]int
+	// size of the keys and values kept in memory.
+	size int
+
+	// batch to which the writes are spilled, nil until they are.
+	batch *BatchSession
+	// true if the session holds the write lock of the engine.
+	locked bool
+}
+
+var _ engine.CommitLockSession = (*OptimisticSession)(nil)
+
+func (s *PebbleEngine) NewOptimisticSession() engine.Session {
+	s.commitLog.Lock()
+	defer s.commitLog.Unlock()
+
+	if s.commitLog.active == nil {
+		s.commitLog.active = make(map[uint64]int)
+	}
+	s.commitLog.active[s.commitLog.seq]++
+
+	return &OptimisticSession{
+		Store:    s,
+		Snapshot: s.acquireSnapshot(),
+		start:    s.commitLog.seq,
+	}
+}
+
+// LockCommit waits until no session spilled its writes to the store.
+// The lock is released by Commit or Close.
+func (s *OptimisticSession) LockCommit() {
+	if !s.locked {
+		s.Store.writeMu.Lock()
+		s.locked = true
+	}
+}
+
+// Commit checks for conflicts and atomically writes the changes to the database.
+// If the session conflicts with another one, it returns engine.ErrTxConflict
+// and the session remains open.
+func (s *OptimisticSession) Commit() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+
+	s.LockCommit()
+
+	l := &s.Store.commitLog
+	l.Lock()
+	defer l.Unlock()
+
+	if s.writes.len > 0 || len(s.ranges) > 0 {
+		for i := range l.entries {
+			if l.entries[i].seq <= s.start {
+				continue
+			}
+
+			if s.conflicts(&l.entries[i]) {
+				return errors.WithStack(engine.ErrTxConflict)
+			}
+		}
+
+		opts := pebble.Sync
+		if s.sync != engine.SyncFull {
+			opts = pebble.NoSync
+		}
+
+		var err error
+		if s.batch != nil {
+			err = s.commitSpilled(opts)
+		} else {
+			err = s.commitBatch(opts)
+		}
+		if err != nil {
+			return err
+		}
+		if s.sync == engine.SyncNormal {
+			s.Store.syncer.markPending()
+		}
+
+		l.seq++
+
+		// only keep track of the write set if another session
+		// could conflict with it.
+		if len(l.active) > 1 || l.active[s.start] > 1 {
+			keys := make(map[string]struct{}, s.writes.len+len(s.conflictKeys))
+			for n := s.writes.first(); n != nil; n = n.next[0] {
+				keys[string(n.key)] = struct{}{}
+			}
+			for k := range s.conflictKeys {
+				keys[k] = struct{}{}
+			}
+			l.entries = append(l.entries, commitLogEntry{seq: l.seq, keys: keys, ranges: s.ranges})
+		}
+	}
+
+	return s.closeLocked()
+}
+
+// conflicts returns whether the keys or the ranges written by the
+// session intersect the ones of a commit log entry.
+func (s *OptimisticSession) conflicts(e *commitLogEntry) bool {
+	for n := s.writes.first(); n != nil; n = n.next[0] {
+		if _, ok := e.keys[string(n.key)]; ok {
+			return true
+		}
+		for i := range e.ranges {
+			if e.ranges[i].contains(n.key) {
+				return true
+			}
+		}
+	}
+
+	for k := range s.conflictKeys {
+		if _, ok := e.keys[k]; ok {
+			return true
+		}
+		for i := range e.ranges {
+			if e.ranges[i].contains([]byte(k)) {
+				return true
+			}
+		}
+	}
+
+	for i := range s.ranges {
+		for k := range e.keys {
+			if s.ranges[i].contains([]byte(k)) {
+				return true
+			}
+		}
+		for j := range e.ranges {
+			if s.ranges[i].overlaps(&e.ranges[j]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// commitBatch writes the pending writes kept in memory.
+func (s *OptimisticSession) commitBatch(opts *pebble.WriteOptions) error {
+	b := s.Store.db.NewBatch()
+	defer b.Close()
+
+	// the ranges are deleted first, the pending writes made
+	// after their deletion overwrite them.
+	for _, r := range s.ranges {
+		err := b.DeleteRange(r.start, r.end, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	for n := s.writes.first(); n != nil; n = n.next[0] {
+		var err error
+		if w := n.latest(); w.deleted {
+			err = b.Delete(n.key, nil)
+		} else {
+			err = b.Set(n.key, w.value, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.Commit(opts)
+}
+
+// commitSpilled commits the batch the writes were spilled to,
+// with the deletion of the rollback segment.
+func (s *OptimisticSession) commitSpilled(opts *pebble.WriteOptions) error {
+	err := s.Store.rollbackSegment.Clear(s.batch.Batch)
+	if err != nil {
+		return err
+	}
+
+	return s.batch.Batch.Commit(opts)
+}
+
+// SetSynchronous sets when Commit synchronizes the writes to disk.
+func (s *OptimisticSession) SetSynchronous(mode engine.Synchronous) {
+	s.sync = mode
+}
+
+// Close discards the pending writes and releases the snapshot.
+func (s *OptimisticSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+
+	s.Store.commitLog.Lock()
+	defer s.Store.commitLog.Unlock()
+
+	return s.closeLocked()
+}
+
+func (s *OptimisticSession) closeLocked() error {
+	s.closed = true
+	s.Store.commitLog.release(s.start)
+	s.writes = writeSet{}
+	s.conflictKeys = nil
+	s.ranges = nil
+
+	var err error
+	if s.batch != nil {
+		// undo the spilled writes if they were not committed,
+		// before the other sessions can see them.
+		err = s.Store.rollbackSegment.Rollback()
+		if cerr := s.batch.Close(); err == nil {
+			err = cerr
+		}
+		s.batch = nil
+	}
+
+	if s.locked {
+		s.locked = false
+		s.Store.writeMu.Unlock()
+	}
+
+	if serr := s.Snapshot.Done(); err == nil {
+		err = serr
+	}
+
+	return err
+}
+
+// set records a pending write.
+func (s *OptimisticSession) set(k, v []byte, deleted bool) error {
+	if s.batch == nil && s.size >= s.Store.opts.MaxBatchSize {
+		err := s.spill()
+		if err != nil {
+			return err
+		}
+	}
+
+	w := write{seq: s.seq, deleted: deleted}
+	switch {
+	case s.batch != nil:
+		var err error
+		if deleted {
+			err = s.batch.Delete(k)
+		} else {
+			err = s.batch.Put(k, v)
+		}
+		if err != nil {
+			return err
+		}
+		w.spilled = true
+	case !deleted:
+		w.value = append([]byte(nil), v...)
+		s.size += len(v)
+	}
+
+	n, created := s.writes.insert(k)
+	if created && s.batch == nil {
+		s.size += len(k)
+	}
+	s.addVersion(n, w)
+
+	return nil
+}
+
+// addVersion records a new version of the value of a key,
+// keeping the previous ones that can be read by the open iterators.
+func (s *OptimisticSession) addVersion(n *node, w write) {
+	var minSeq uint64
+	for seq := range s.iterators {
+		if len(s.iterators) == 1 || seq < minSeq {
+			minSeq = seq
+		}
+	}
+
+	n.add(w, len(s.iterators) > 0, minSeq)
+}
+
+// spill writes the pending writes kept in memory to a batch session,
+// once the session holds the write lock of the engine, and only keeps their keys.
+// The batch session takes a shared snapshot read by the sessions created
+// until this one ends, which don't see the spilled writes.
+func (s *OptimisticSession) spill() error {
+	s.LockCommit()
+
+	s.batch = s.Store.NewBatchSession().(*BatchSession)
+
+	// the batch session deletes the keys of the ranges one by one,
+	// to keep their values in its rollback segment.
+	for _, r := range s.ranges {
+		err := s.batch.DeleteRange(r.start, r.end)
+		if err != nil {
+			return err
+		}
+	}
+
+	for n := s.writes.first(); n != nil; n = n.next[0] {
+		w := n.latest()
+
+		var err error
+		if w.deleted {
+			err = s.batch.Delete(n.key)
+		} else {
+			err = s.batch.Put(n.key, w.value)
+		}
+		if err != nil {
+			return err
+		}
+
+		s.addVersion(n, write{seq: s.seq, deleted: w.deleted, spilled: true})
+	}
+
+	s.size = 0
+	return nil
+}
+
+// Insert inserts a key-value pair. If it already exists, it returns ErrKeyAlreadyExists.
+func (s *OptimisticSession) Insert(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	if len(v) == 0 {
+		return errors.New("cannot store empty value")
+	}
+
+	ok, err := s.Exists(k)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return engine.ErrKeyAlreadyExists
+	}
+
+	return s.set(k, v, false)
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+func (s *OptimisticSession) Put(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	if len(v) == 0 {
+		return errors.New("cannot store empty value")
+	}
+
+	return s.set(k, v, false)
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *OptimisticSession) Get(k []byte) ([]byte, error) {
+	if n := s.writes.get(k); n != nil {
+		w := n.latest()
+		switch {
+		case w.deleted:
+			return nil, errors.WithStack(engine.ErrKeyNotFound)
+		case w.spilled:
+			return s.batch.Get(k)
+		}
+
+		return append([]byte(nil), w.value...), nil
+	}
+
+	if s.rangeAt(k, s.seq) != nil {
+		return nil, errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	return get(s.Snapshot.snapshot, k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *OptimisticSession) Exists(k []byte) (bool, error) {
+	if n := s.writes.get(k); n != nil {
+		return !n.latest().deleted, nil
+	}
+
+	if s.rangeAt(k, s.seq) != nil {
+		return false, nil
+	}
+
+	return exists(s.Snapshot.snapshot, k)
+}
+
+// Delete a record by key. If the key doesn't exist, it doesn't do anything.
+func (s *OptimisticSession) Delete(k []byte) error {
+	return s.set(k, nil, true)
+}
+
+// AddConflictKey records a key the session relied on without writing it.
+// The commit fails if another session which committed after this one was
+// created wrote or recorded the same key.
+func (s *OptimisticSession) AddConflictKey(k []byte) {
+	if s.conflictKeys == nil {
+		s.conflictKeys = make(map[string]struct{})
+	}
+	s.conflictKeys[string(k)] = struct{}{}
+}
+
+// DeleteRange deletes all keys in the given range. Its cost doesn't depend
+// on the number of keys of the snapshot within the range, only on the number
+// of pending writes it deletes, unless the writes were spilled: the batch
+// session deletes the keys one by one to be able to roll them back.
+func (s *OptimisticSession) DeleteRange(start []byte, end []byte) error {
+	if s.batch != nil {
+		err := s.batch.DeleteRange(start, end)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.ranges = append(s.ranges, keyRange{
+		start: append([]byte(nil), start...),
+		end:   append([]byte(nil), end...),
+		seq:   s.seq,
+	})
+
+	for n := s.writes.seekGE(start); n != nil && encoding.Compare(n.key, end) < 0; n = n.next[0] {
+		if !n.latest().deleted {
+			s.addVersion(n, write{seq: s.seq, deleted: true, spilled: s.batch != nil})
+		}
+	}
+
+	return nil
+}
+
+// rangeAt returns the last range deleted by the session containing k,
+// which can be seen by the writes of the given sequence, or nil.
+func (s *OptimisticSession) rangeAt(k []byte, seq uint64) *keyRange {
+	for i := len(s.ranges) - 1; i >= 0; i-- {
+		if s.ranges[i].seq <= seq && s.ranges[i].contains(k) {
+			return &s.ranges[i]
+		}
+	}
+
+	return nil
+}
+
+// Iterator returns an iterator that merges the snapshot with the pending writes.
+// The iterator doesn't see writes made after its creation.
+func (s *OptimisticSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	var popts *pebble.IterOptions
+	if opts != nil {
+		popts = &pebble.IterOptions{
+			LowerBound: opts.LowerBound,
+			UpperBound: opts.UpperBound,
+		}
+	}
+
+	it, err := s.Snapshot.snapshot.NewIter(popts)
+	if err != nil {
+		return nil, err
+	}
+
+	mit := mergeIterator{
+		session:  s,
+		snapshot: it,
+		seq:      s.seq,
+	}
+	if popts != nil {
+		mit.lower, mit.upper = popts.LowerBound, popts.UpperBound
+	}
+
+	// the values of the spilled writes are read from the store,
+	// as they were when the iterator was created.
+	if s.batch != nil {
+		mit.spilled, err = s.batch.Iterator(opts)
+		if err != nil {
+			_ = it.Close()
+			return nil, err
+		}
+	}
+
+	if s.iterators == nil {
+		s.iterators = make(map[uint64]int)
+	}
+	s.iterators[s.seq]++
+	s.seq++
+
+	return &mit, nil
+}
+
+// mergeIterator iterates over the keys of a snapshot and
+// the pending writes of a session, the latter taking precedence.
+type mergeIterator struct {
+	session  *OptimisticSession
+	snapshot *pebble.Iterator
+	// iterator reading the spilled writes from the store, if any.
+	spilled engine.Iterator
+	// sequence of the writes the iterator can see.
+	seq          uint64
+	lower, upper []byte
+	// next pending write in the current direction
+	node *node
+	// true when iterating backward
+	reverse bool
+	// current pending write, nil if the current key
+	// comes from the snapshot
+	cur   *node
+	valid bool
+}
+
+// forward returns the first pending write from n that
+// the iterator can see, or nil if there is none.
+func (it *mergeIterator) forward(n *node) *node {
+	for n != nil && n.at(it.seq) == nil {
+		n = n.next[0]
+	}
+	if n != nil && it.upper != nil && encoding.Compare(n.key, it.upper) >= 0 {
+		return nil
+	}
+
+	return n
+}
+
+// backward returns the first pending write from n, going backward,
+// that the iterator can see, or nil if there is none.
+func (it *mergeIterator) backward(n *node) *node {
+	for n != nil && n.at(it.seq) == nil {
+		n = it.session.writes.seekLT(n.key)
+	}
+	if n != nil && it.lower != nil && encoding.Compare(n.key, it.lower) < 0 {
+		return nil
+	}
+
+	return n
+}
+
+// seekGE returns the first visible pending write whose key is >= k.
+func (it *mergeIterator) seekGE(k []byte) *node {
+	if k == nil || (it.lower != nil && encoding.Compare(k, it.lower) < 0) {
+		k = it.lower
+	}
+	if k == nil {
+		return it.forward(it.session.writes.first())
+	}
+
+	return it.forward(it.session.writes.seekGE(k))
+}
+
+// seekLT returns the last visible pending write whose key is < k.
+func (it *mergeIterator) seekLT(k []byte) *node {
+	if k == nil || (it.upper != nil && encoding.Compare(k, it.upper) > 0) {
+		k = it.upper
+	}
+	if k == nil {
+		return it.backward(it.session.writes.last())
+	}
+
+	return it.backward(it.session.writes.seekLT(k))
+}
+
+func (it *mergeIterator) First() bool {
+	it.reverse = false
+	it.snapshot.First()
+	it.node = it.seekGE(nil)
+	return it.settle()
+}
+
+func (it *mergeIterator) Last() bool {
+	it.reverse = true
+	it.snapshot.Last()
+	it.node = it.seekLT(nil)
+	return it.settle()
+}
+
+func (it *mergeIterator) SeekGE(k []byte) bool {
+	it.reverse = false
+	it.snapshot.SeekGE(k)
+	it.node = it.seekGE(k)
+	return it.settle()
+}
+
+func (it *mergeIterator) Next() bool {
+	if !it.valid {
+		return false
+	}
+
+	if it.reverse {
+		// change direction: position both sources after the current key
+		k := append([]byte(nil), it.Key()...)
+		it.reverse = false
+		if it.snapshot.SeekGE(k) && encoding.Compare(it.snapshot.Key(), k) == 0 {
+			it.snapshot.Next()
+		}
+		it.node = it.seekGE(k)
+		if it.node != nil && encoding.Equal(it.node.key, k) {
+			it.node = it.forward(it.node.next[0])
+		}
+		return it.settle()
+	}
+
+	it.advance()
+	return it.settle()
+}
+
+func (it *mergeIterator) Prev() bool {
+	if !it.valid {
+		return false
+	}
+
+	if !it.reverse {
+		// change direction: position both sources before the current key
+		k := append([]byte(nil), it.Key()...)
+		it.reverse = true
+		it.snapshot.SeekLT(k)
+		it.node = it.seekLT(k)
+		return it.settle()
+	}
+
+	it.advance()
+	return it.settle()
+}
+
+// advance moves past the current key in the current direction.
+func (it *mergeIterator) advance() {
+	if it.cur == nil {
+		it.step()
+		return
+	}
+
+	if it.snapshot.Valid() && encoding.Compare(it.snapshot.Key(), it.cur.key) == 0 {
+		it.step()
+	}
+	if it.reverse {
+		it.node = it.backward(it.session.writes.seekLT(it.cur.key))
+	} else {
+		it.node = it.forward(it.cur.next[0])
+	}
+}
+
+func (it *mergeIterator) step() {
+	if it.reverse {
+		it.snapshot.Prev()
+	} else {
+		it.snapshot.Next()
+	}
+}
+
+// settle selects the current key among the two sources,
+// skipping deleted keys and the keys of the snapshot within deleted ranges.
+func (it *mergeIterator) settle() bool {
+	for {
+		it.cur = nil
+
+		n := it.node
+		if it.snapshot.Valid() {
+			cmp := -1
+			if n != nil {
+				cmp = encoding.Compare(it.snapshot.Key(), n.key)
+				if it.reverse {
+					cmp = -cmp
+				}
+			}
+			if cmp < 0 {
+				r := it.session.rangeAt(it.snapshot.Key(), it.seq)
+				if r == nil {
+					it.valid = true
+					return true
+				}
+
+				// skip the whole range
+				if it.reverse {
+					it.snapshot.SeekLT(r.start)
+				} else {
+					it.snapshot.SeekGE(r.end)
+				}
+				continue
+			}
+		}
+
+		if n == nil {
+			it.valid = false
+			return false
+		}
+
+		it.cur = n
+		if !n.at(it.seq).deleted {
+			it.valid = true
+			return true
+		}
+
+		// skip deleted keys
+		it.advance()
+	}
+}
+
+func (it *mergeIterator) Valid() bool {
+	return it.valid
+}
+
+func (it *mergeIterator) Key() []byte {
+	if it.cur != nil {
+		return it.cur.key
+	}
+
+	return it.snapshot.Key()
+}
+
+func (it *mergeIterator) Value() ([]byte, error) {
+	if it.cur == nil {
+		return it.snapshot.ValueAndErr()
+	}
+
+	w := it.cur.at(it.seq)
+	if !w.spilled {
+		return w.value, nil
+	}
+
+	if !it.spilled.SeekGE(it.cur.key) || !encoding.Equal(it.spilled.Key(), it.cur.key) {
+		if err := it.spilled.Error(); err != nil {
+			return nil, err
+		}
+		return nil, errors.Errorf("spilled key %v not found", it.cur.key)
+	}
+
+	return it.spilled.Value()
+}
+
+func (it *mergeIterator) Error() error {
+	return it.snapshot.Error()
+}
+
+func (it *mergeIterator) Close() error {
+	s := it.session
+	s.iterators[it.seq]--
+	if s.iterators[it.seq] <= 0 {
+		delete(s.iterators, it.seq)
+	}
+
+	err := it.snapshot.Close()
+	if it.spilled != nil {
+		if serr := it.spilled.Close(); err == nil {
+			err = serr
+		}
+	}
+
+	return err
+}
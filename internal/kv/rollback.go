@@ -136,7 +136,13 @@ func (s *RollbackSegment) Rollback() error {
 	// we don't need to sync here.
 	// in case of a crash, the rollback segment will be rolled back
 	// during the next recovery phase.
-	return b.Commit(pebble.NoSync)
+	err = b.Commit(pebble.NoSync)
+	if err != nil {
+		return err
+	}
+
+	s.reset()
+	return nil
 }
 
 func (s *RollbackSegment) Reset() error {
@@ -160,4 +166,5 @@ func (s *RollbackSegment) Clear(b *pebble.Batch) error {
 func (s *RollbackSegment) reset() {
 	s.buf = s.buf[:len(s.nsStart)]
 	s.segmentCommitted = false
+	clear(s.seen)
 }
@@ -4,10 +4,11 @@ import (
 	"bytes"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/engine"
 	"github.com/chaisql/chai/internal/encoding"
-	"github.com/chaisql/chai/internal/engine"
 	"github.com/chaisql/chai/internal/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -124,6 +125,354 @@ func TestRollback(t *testing.T) {
 	}
 }
 
+func TestOptimisticSession(t *testing.T) {
+	key := func(i int64) []byte {
+		return encoding.EncodeInt(encoding.EncodeInt(nil, 10), i)
+	}
+
+	keys := func(t *testing.T, s engine.Session, reverse bool) []int64 {
+		it, err := s.Iterator(&engine.IterOptions{
+			LowerBound: encoding.EncodeInt(nil, 10),
+			UpperBound: encoding.EncodeInt(nil, 11),
+		})
+		require.NoError(t, err)
+		defer it.Close()
+
+		var got []int64
+		if reverse {
+			it.Last()
+		} else {
+			it.First()
+		}
+		for it.Valid() {
+			k := it.Key()
+			n := encoding.Skip(k)
+			x, _ := encoding.DecodeInt(k[n:])
+			got = append(got, x)
+			if reverse {
+				it.Prev()
+			} else {
+				it.Next()
+			}
+		}
+		require.NoError(t, it.Error())
+		return got
+	}
+
+	t.Run("merges pending writes with the snapshot", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s := ng.NewOptimisticSession()
+		for _, i := range []int64{1, 3, 5, 7} {
+			require.NoError(t, s.Put(key(i), []byte{byte(i)}))
+		}
+		require.NoError(t, s.Commit())
+
+		s = ng.NewOptimisticSession()
+		defer s.Close()
+
+		require.NoError(t, s.Put(key(2), []byte{2}))
+		require.NoError(t, s.Put(key(5), []byte{50}))
+		require.NoError(t, s.Delete(key(3)))
+		require.NoError(t, s.Put(key(8), []byte{8}))
+		require.ErrorIs(t, s.Insert(key(1), []byte{1}), engine.ErrKeyAlreadyExists)
+		require.NoError(t, s.Insert(key(3), []byte{30}))
+		require.NoError(t, s.Delete(key(7)))
+
+		require.Equal(t, []int64{1, 2, 3, 5, 8}, keys(t, s, false))
+		require.Equal(t, []int64{8, 5, 3, 2, 1}, keys(t, s, true))
+		require.Equal(t, []byte{50}, getValue(t, s, key(5)))
+		_, err := s.Get(key(7))
+		require.ErrorIs(t, err, engine.ErrKeyNotFound)
+
+		// change direction in the middle of the iteration
+		it, err := s.Iterator(nil)
+		require.NoError(t, err)
+		defer it.Close()
+		it.First()
+		it.Next()
+		it.Next()
+		require.Equal(t, key(3), it.Key())
+		it.Prev()
+		require.Equal(t, key(2), it.Key())
+		it.Next()
+		require.Equal(t, key(3), it.Key())
+		v, err := it.Value()
+		require.NoError(t, err)
+		require.Equal(t, []byte{30}, v)
+
+		// seek to pending writes, deleted and committed keys
+		require.True(t, it.SeekGE(key(2)))
+		require.Equal(t, key(2), it.Key())
+		require.True(t, it.SeekGE(key(6)))
+		require.Equal(t, key(8), it.Key())
+		require.True(t, it.SeekGE(key(4)))
+		require.Equal(t, key(5), it.Key())
+		it.Prev()
+		require.Equal(t, key(3), it.Key())
+		require.False(t, it.SeekGE(key(9)))
+	})
+
+	t.Run("isolation", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s1 := ng.NewOptimisticSession()
+		defer s1.Close()
+
+		s2 := ng.NewOptimisticSession()
+		require.NoError(t, s2.Put(key(1), []byte{1}))
+
+		_, err := s1.Get(key(1))
+		require.ErrorIs(t, err, engine.ErrKeyNotFound)
+
+		require.NoError(t, s2.Commit())
+
+		// s1 still reads from its snapshot
+		_, err = s1.Get(key(1))
+		require.ErrorIs(t, err, engine.ErrKeyNotFound)
+
+		ss := ng.NewSnapshotSession()
+		defer ss.Close()
+		require.Equal(t, []byte{1}, getValue(t, ss, key(1)))
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s1 := ng.NewOptimisticSession()
+		s2 := ng.NewOptimisticSession()
+		s3 := ng.NewOptimisticSession()
+
+		require.NoError(t, s1.Put(key(1), []byte{1}))
+		require.NoError(t, s2.Delete(key(1)))
+		require.NoError(t, s3.Put(key(2), []byte{2}))
+
+		require.NoError(t, s1.Commit())
+		require.ErrorIs(t, s2.Commit(), engine.ErrTxConflict)
+		require.NoError(t, s2.Close())
+		require.NoError(t, s3.Commit())
+
+		// sessions started after the commit don't conflict
+		s4 := ng.NewOptimisticSession()
+		require.NoError(t, s4.Delete(key(1)))
+		require.NoError(t, s4.Commit())
+	})
+
+	t.Run("conflict keys", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s1 := ng.NewOptimisticSession()
+		s2 := ng.NewOptimisticSession()
+		s3 := ng.NewOptimisticSession()
+
+		// the recorded keys conflict with each other and with the written ones
+		s1.(engine.ConflictSession).AddConflictKey(key(1))
+		require.NoError(t, s1.Put(key(2), []byte{2}))
+		s2.(engine.ConflictSession).AddConflictKey(key(1))
+		require.NoError(t, s2.Put(key(3), []byte{3}))
+		require.NoError(t, s3.Put(key(1), []byte{1}))
+
+		require.NoError(t, s1.Commit())
+		require.ErrorIs(t, s2.Commit(), engine.ErrTxConflict)
+		require.NoError(t, s2.Close())
+		require.ErrorIs(t, s3.Commit(), engine.ErrTxConflict)
+		require.NoError(t, s3.Close())
+
+		// the recorded keys are not written
+		ss := ng.NewSnapshotSession()
+		defer ss.Close()
+		_, err := ss.Get(key(1))
+		require.ErrorIs(t, err, engine.ErrKeyNotFound)
+	})
+
+	t.Run("range deletions", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s := ng.NewOptimisticSession()
+		for i := int64(1); i <= 8; i++ {
+			require.NoError(t, s.Put(key(i), []byte{byte(i)}))
+		}
+		require.NoError(t, s.Commit())
+
+		s1 := ng.NewOptimisticSession()
+		s2 := ng.NewOptimisticSession()
+		s3 := ng.NewOptimisticSession()
+		s4 := ng.NewOptimisticSession()
+
+		// the writes made after the deletion of a range are kept,
+		// the iterators created before it don't see it
+		require.NoError(t, s1.Put(key(4), []byte{40}))
+		before, err := s1.Iterator(nil)
+		require.NoError(t, err)
+		require.NoError(t, s1.DeleteRange(key(3), key(7)))
+		require.NoError(t, s1.Put(key(5), []byte{50}))
+
+		require.Equal(t, []int64{1, 2, 5, 7, 8}, keys(t, s1, false))
+		require.Equal(t, []int64{8, 7, 5, 2, 1}, keys(t, s1, true))
+		_, err = s1.Get(key(4))
+		require.ErrorIs(t, err, engine.ErrKeyNotFound)
+		ok, err := s1.Exists(key(6))
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.NoError(t, s1.Insert(key(6), []byte{60}))
+		require.NoError(t, s1.Delete(key(6)))
+		require.Equal(t, []byte{50}, getValue(t, s1, key(5)))
+
+		var got []int64
+		for before.First(); before.Valid(); before.Next() {
+			k := before.Key()
+			x, _ := encoding.DecodeInt(k[encoding.Skip(k):])
+			got = append(got, x)
+		}
+		require.NoError(t, before.Close())
+		require.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8}, got)
+
+		it, err := s1.Iterator(nil)
+		require.NoError(t, err)
+		require.True(t, it.SeekGE(key(3)))
+		require.Equal(t, key(5), it.Key())
+		require.True(t, it.Prev())
+		require.Equal(t, key(2), it.Key())
+		require.True(t, it.Next())
+		require.Equal(t, key(5), it.Key())
+		require.NoError(t, it.Close())
+
+		// the keys within the range and the overlapping ranges conflict
+		require.NoError(t, s2.Put(key(6), []byte{6}))
+		require.NoError(t, s3.DeleteRange(key(0), key(4)))
+		require.NoError(t, s4.Put(key(8), []byte{80}))
+
+		require.NoError(t, s1.Commit())
+		require.ErrorIs(t, s2.Commit(), engine.ErrTxConflict)
+		require.NoError(t, s2.Close())
+		require.ErrorIs(t, s3.Commit(), engine.ErrTxConflict)
+		require.NoError(t, s3.Close())
+		require.NoError(t, s4.Commit())
+
+		ss := ng.NewSnapshotSession()
+		require.Equal(t, []int64{1, 2, 5, 7, 8}, keys(t, ss, false))
+		require.Equal(t, []byte{50}, getValue(t, ss, key(5)))
+		require.NoError(t, ss.Close())
+
+		// sessions spilling their writes delete the ranges in their batch
+		for _, commit := range []bool{false, true} {
+			s := ng.NewOptimisticSession()
+			require.NoError(t, s.DeleteRange(key(0), key(3)))
+			for i := int64(10); i < 100; i++ {
+				require.NoError(t, s.Put(key(i), bytes.Repeat([]byte{byte(i)}, 8)))
+			}
+			require.NoError(t, s.DeleteRange(key(50), key(60)))
+
+			want := []int64{5, 7, 8}
+			for i := int64(10); i < 100; i++ {
+				if i < 50 || i >= 60 {
+					want = append(want, i)
+				}
+			}
+			require.Equal(t, want, keys(t, s, false))
+
+			if commit {
+				require.NoError(t, s.Commit())
+			} else {
+				require.NoError(t, s.Close())
+				want = []int64{1, 2, 5, 7, 8}
+			}
+
+			ss := ng.NewSnapshotSession()
+			require.Equal(t, want, keys(t, ss, false))
+			require.NoError(t, ss.Close())
+		}
+	})
+
+	t.Run("spills large write sets", func(t *testing.T) {
+		// the engine spills the writes exceeding 128 bytes
+		ng := testutil.NewEngine(t)
+
+		s := ng.NewOptimisticSession()
+		for i := int64(0); i < 5; i++ {
+			require.NoError(t, s.Put(key(i), []byte{byte(i)}))
+		}
+		require.NoError(t, s.Commit())
+
+		write := func(s engine.Session) {
+			for i := int64(10); i < 100; i++ {
+				require.NoError(t, s.Put(key(i), bytes.Repeat([]byte{byte(i)}, 8)))
+			}
+			require.NoError(t, s.Delete(key(3)))
+			require.NoError(t, s.Put(key(4), []byte{40}))
+		}
+
+		// keys committed before each session
+		base := []int64{0, 1, 2, 3, 4}
+
+		for i, commit := range []bool{false, true} {
+			other := ng.NewOptimisticSession()
+			require.NoError(t, other.Put(key(int64(200+i)), []byte{1}))
+
+			s := ng.NewOptimisticSession()
+			before, err := s.Iterator(nil)
+			require.NoError(t, err)
+			write(s)
+
+			// the iterator created before the writes doesn't see them
+			var got []int64
+			for before.First(); before.Valid(); before.Next() {
+				k := before.Key()
+				x, _ := encoding.DecodeInt(k[encoding.Skip(k):])
+				got = append(got, x)
+			}
+			require.NoError(t, before.Close())
+			require.Equal(t, base, got)
+
+			want := []int64{0, 1, 2, 4}
+			for i := int64(10); i < 100; i++ {
+				want = append(want, i)
+			}
+			want = append(want, base[5:]...)
+
+			require.Equal(t, want, keys(t, s, false))
+			require.Equal(t, []byte{40}, getValue(t, s, key(4)))
+			require.Equal(t, bytes.Repeat([]byte{50}, 8), getValue(t, s, key(50)))
+			it, err := s.Iterator(&engine.IterOptions{LowerBound: key(50)})
+			require.NoError(t, err)
+			require.True(t, it.First())
+			v, err := it.Value()
+			require.NoError(t, err)
+			require.Equal(t, bytes.Repeat([]byte{50}, 8), v)
+			require.NoError(t, it.Close())
+
+			// the other sessions don't see the spilled writes
+			ss := ng.NewSnapshotSession()
+			require.Equal(t, base, keys(t, ss, false))
+			require.NoError(t, ss.Close())
+
+			// and can't commit until the session ends
+			done := make(chan error)
+			go func() {
+				done <- other.Commit()
+			}()
+			select {
+			case <-done:
+				t.Fatal("commit didn't wait for the session that spilled its writes")
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			if commit {
+				require.NoError(t, s.Commit())
+				base = want
+			} else {
+				require.NoError(t, s.Close())
+			}
+			require.NoError(t, <-done)
+			base = append(base, int64(200+i))
+
+			ss = ng.NewSnapshotSession()
+			require.Equal(t, base, keys(t, ss, false))
+			require.NoError(t, ss.Close())
+		}
+	})
+}
+
 func TestStorePut(t *testing.T) {
 	key := encoding.EncodeText(nil, "foo")
 
@@ -472,3 +821,53 @@ func TestQueriesSameTransaction(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestTransientSession(t *testing.T) {
+	ng := testutil.NewEngine(t)
+
+	// the writes exceed the memory of the session and are spilled to disk
+	s := ng.NewTransientSession(256)
+	defer s.Close()
+
+	value := bytes.Repeat([]byte{'v'}, 100)
+	for i := 99; i >= 0; i-- {
+		require.NoError(t, s.Put(encoding.EncodeInt(nil, int64(i)), value))
+	}
+
+	it, err := s.Iterator(nil)
+	require.NoError(t, err)
+	defer it.Close()
+
+	var i int64
+	for it.First(); it.Valid(); it.Next() {
+		require.Equal(t, encoding.EncodeInt(nil, i), it.Key())
+		v, err := it.Value()
+		require.NoError(t, err)
+		require.Equal(t, value, v)
+		i++
+	}
+	require.NoError(t, it.Error())
+	require.EqualValues(t, 100, i)
+}
+
+func TestTransientSessionClose(t *testing.T) {
+	ng := testutil.NewEngine(t)
+
+	s := ng.NewTransientSession(256)
+	value := bytes.Repeat([]byte{'v'}, 100)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, s.Put(encoding.EncodeInt(nil, int64(i)), value))
+	}
+	require.NoError(t, s.Close())
+
+	// the data spilled to disk is deleted
+	r := ng.NewSnapshotSession()
+	defer r.Close()
+
+	it, err := r.Iterator(nil)
+	require.NoError(t, err)
+	defer it.Close()
+
+	require.False(t, it.First())
+	require.NoError(t, it.Error())
+}
@@ -1,9 +1,7 @@
 package kv
 
 import (
-	"math"
-
-	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/engine"
 	"github.com/chaisql/chai/internal/pkg/atomic"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/pebble"
@@ -25,6 +23,36 @@ func (s *snapshot) Done() error {
 	return nil
 }
 
+// version is a snapshot retained until it is released.
+type version struct {
+	store    *PebbleEngine
+	snapshot *snapshot
+}
+
+var _ engine.Version = (*version)(nil)
+
+// NewVersion creates a snapshot of the database that can be read
+// by multiple sessions until it is released.
+func (s *PebbleEngine) NewVersion() engine.Version {
+	return &version{
+		store:    s,
+		snapshot: s.acquireSnapshot(),
+	}
+}
+
+func (v *version) NewSession() engine.Session {
+	v.snapshot.Incr()
+
+	return &SnapshotSession{
+		Store:    v.store,
+		Snapshot: v.snapshot,
+	}
+}
+
+func (v *version) Release() error {
+	return v.snapshot.Done()
+}
+
 type SnapshotSession struct {
 	Store    *PebbleEngine
 	Snapshot *snapshot
@@ -34,26 +62,9 @@ type SnapshotSession struct {
 var _ engine.Session = (*SnapshotSession)(nil)
 
 func (s *PebbleEngine) NewSnapshotSession() engine.Session {
-	var sn *snapshot
-
-	// if there is a shared snapshot, use it.
-	s.sharedSnapshot.RLock()
-	sn = s.sharedSnapshot.snapshot
-
-	// if there is no shared snapshot, create one.
-	if sn == nil {
-		sn = &snapshot{
-			snapshot: s.db.NewSnapshot(),
-			refCount: atomic.NewCounter(0, math.MaxInt64, false),
-		}
-	}
-	sn.Incr()
-
-	s.sharedSnapshot.RUnlock()
-
 	return &SnapshotSession{
 		Store:    s,
-		Snapshot: sn,
+		Snapshot: s.acquireSnapshot(),
 	}
 }
 
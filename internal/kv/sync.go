@@ -0,0 +1,89 @@
+package kv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+)
+
+const defaultSyncInterval = time.Second
+
+// walSyncer synchronizes the WAL periodically when commits
+// were written without being synchronized, for the sessions
+// using engine.SyncNormal.
+type walSyncer struct {
+	db       *pebble.DB
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending bool
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// markPending records an unsynchronized commit and starts
+// the background goroutine on first use.
+func (w *walSyncer) markPending() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = true
+	if w.started {
+		return
+	}
+
+	w.started = true
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go w.run()
+}
+
+func (w *walSyncer) run() {
+	defer close(w.done)
+
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+			_ = w.sync()
+		}
+	}
+}
+
+// sync synchronizes the WAL if there are pending commits.
+func (w *walSyncer) sync() error {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = false
+	w.mu.Unlock()
+
+	if !pending {
+		return nil
+	}
+
+	// an empty synchronized write flushes the WAL, including
+	// the commits written before it.
+	return w.db.LogData(nil, pebble.Sync)
+}
+
+// close stops the background goroutine and synchronizes
+// the pending commits.
+func (w *walSyncer) close() error {
+	w.mu.Lock()
+	started := w.started
+	w.started = false
+	w.mu.Unlock()
+
+	if started {
+		close(w.stop)
+		<-w.done
+	}
+
+	return w.sync()
+}
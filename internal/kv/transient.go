@@ -1,9 +1,16 @@
 package kv
 
 import (
-	"github.com/chaisql/chai/internal/engine"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/pkg/pebbleutil"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
 )
 
 var _ engine.Session = (*TransientSession)(nil)
@@ -14,12 +21,29 @@ type TransientSession struct {
 	store        *PebbleEngine
 	maxBatchSize int
 	closed       bool
+
+	// smallest and largest keys written by the session,
+	// used to delete the data spilled to disk on close.
+	lower, upper []byte
+	spilled      bool
 }
 
-func (s *PebbleEngine) NewTransientSession() engine.Session {
+// NewTransientSession returns a session whose writes are kept in memory
+// until they exceed maxMemory bytes, then written to disk.
+// If maxMemory is zero, the MaxTransientBatchSize option is used.
+func (s *PebbleEngine) NewTransientSession(maxMemory int) engine.Session {
+	if maxMemory <= 0 {
+		maxMemory = s.opts.MaxTransientBatchSize
+	}
+
+	db := s.db
+	if s.transient != nil {
+		db = s.transient.db
+	}
+
 	return &TransientSession{
-		db:           s.db,
-		maxBatchSize: s.opts.MaxTransientBatchSize,
+		db:           db,
+		maxBatchSize: maxMemory,
 		store:        s,
 	}
 }
@@ -28,13 +52,28 @@ func (s *TransientSession) Commit() error {
 	return errors.New("cannot commit in transient mode")
 }
 
+// Close discards the data of the session, including
+// the data spilled to disk.
 func (s *TransientSession) Close() error {
 	if s.closed {
 		return errors.New("already closed")
 	}
 	s.closed = true
 
-	return s.batch.Close()
+	if s.batch == nil {
+		return nil
+	}
+
+	err := s.batch.Close()
+	if s.spilled {
+		// the upper bound is exclusive, append a byte to include the largest key
+		derr := s.db.DeleteRange(s.lower, append(s.upper, 0), pebble.NoSync)
+		if err == nil {
+			err = derr
+		}
+	}
+
+	return err
 }
 
 func (s *TransientSession) Insert(k, v []byte) error {
@@ -62,6 +101,14 @@ func (s *TransientSession) Put(k, v []byte) error {
 		}
 
 		s.batch.Reset()
+		s.spilled = true
+	}
+
+	if s.lower == nil || encoding.Compare(k, s.lower) < 0 {
+		s.lower = append(s.lower[:0], k...)
+	}
+	if s.upper == nil || encoding.Compare(k, s.upper) > 0 {
+		s.upper = append(s.upper[:0], k...)
 	}
 
 	return s.batch.Set(k, v, nil)
@@ -139,3 +186,95 @@ func (s *TransientSession) Iterator(opts *engine.IterOptions) (engine.Iterator,
 		Iterator: it,
 	}, nil
 }
+
+// transientDirPrefix is the prefix of the directories
+// created by the engines in Options.TransientDir.
+const transientDirPrefix = "chai-transient-"
+
+// transientStore is a Pebble database used by the transient sessions
+// of an engine. It is deleted when the engine is closed.
+type transientStore struct {
+	db  *pebble.DB
+	dir string
+}
+
+// openTransientStore removes the directories left in parent by the engines
+// which were not closed, then creates a transient store in a new directory.
+func openTransientStore(parent string) (*transientStore, error) {
+	err := os.MkdirAll(parent, 0700)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	err = removeOrphanTransientDirs(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(parent, transientDirPrefix+"*")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	popts := &pebble.Options{
+		FormatMajorVersion: pebble.FormatPrePebblev1MarkedCompacted,
+		Comparer:           DefaultComparer,
+		Logger:             pebbleutil.NoopLoggerAndTracer{},
+		// the data doesn't need to survive a crash
+		DisableWAL: true,
+	}
+
+	db, err := pebble.Open(dir, popts.EnsureDefaults())
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &transientStore{db: db, dir: dir}, nil
+}
+
+func (t *transientStore) close() error {
+	err := t.db.Close()
+	if rerr := os.RemoveAll(t.dir); err == nil {
+		err = errors.WithStack(rerr)
+	}
+
+	return err
+}
+
+// removeOrphanTransientDirs removes the transient stores of parent whose
+// lock file is not held, which were left by processes that crashed.
+func removeOrphanTransientDirs(parent string) error {
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), transientDirPrefix) {
+			continue
+		}
+
+		dir := filepath.Join(parent, e.Name())
+
+		// stores without lock file are being created by another engine
+		lockFile := filepath.Join(dir, "LOCK")
+		if _, err := os.Stat(lockFile); err != nil {
+			continue
+		}
+
+		l, err := vfs.Default.Lock(lockFile)
+		if err != nil {
+			// the store is used by another engine
+			continue
+		}
+		_ = l.Close()
+
+		err = os.RemoveAll(dir)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
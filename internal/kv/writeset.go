@@ -0,0 +1,215 @@
+package kv
+
+import (
+	"math/rand/v2"
+
+	"github.com/chaisql/chai/internal/encoding"
+)
+
+// maximum height of the nodes of a writeSet,
+// enough for billions of keys.
+const maxHeight = 20
+
+// A writeSet holds the pending writes of an optimistic session
+// in a skiplist ordered by key, so that writes and lookups
+// take a logarithmic time, whatever the order of the keys.
+// Nodes are never removed: deleting a key records a deletion.
+type writeSet struct {
+	head   node
+	height int
+	len    int
+	// last node of each level, to append the keys written in order.
+	tail [maxHeight]*node
+}
+
+// A node holds the versions of the value of a key which can still be read
+// by the session or its iterators, the most recent first.
+type node struct {
+	key      []byte
+	versions []write
+	next     []*node
+	// backing array of the versions of most keys.
+	inline [1]write
+}
+
+// A write is a version of the value of a key.
+type write struct {
+	// sequence of the session when the value was written.
+	seq     uint64
+	value   []byte
+	deleted bool
+	// spilled is true if the value was written to the store
+	// instead of being kept in memory.
+	spilled bool
+}
+
+// at returns the most recent version written at or before seq,
+// or nil if the key was written afterwards.
+func (n *node) at(seq uint64) *write {
+	for i := range n.versions {
+		if n.versions[i].seq <= seq {
+			return &n.versions[i]
+		}
+	}
+
+	return nil
+}
+
+// latest returns the most recent version.
+func (n *node) latest() *write {
+	return &n.versions[0]
+}
+
+// add records a new version of the value. The previous versions are dropped,
+// unless they can be read by an iterator created after they were written
+// and before w, the oldest of which was created at minSeq.
+func (n *node) add(w write, iterators bool, minSeq uint64) {
+	switch {
+	case len(n.versions) > 0 && n.versions[0].seq == w.seq:
+		// no iterator was created since the last version was written
+		n.versions[0] = w
+	case !iterators:
+		n.versions = append(n.versions[:0], w)
+	default:
+		// the slice is copied, the previous one can be read by an iterator
+		versions := make([]write, 1, len(n.versions)+1)
+		versions[0] = w
+		for _, v := range n.versions {
+			versions = append(versions, v)
+			if v.seq <= minSeq {
+				break
+			}
+		}
+		n.versions = versions
+	}
+}
+
+// get returns the node of the key, or nil if it was never written.
+func (ws *writeSet) get(k []byte) *node {
+	n := ws.seekGE(k)
+	if n != nil && encoding.Equal(n.key, k) {
+		return n
+	}
+
+	return nil
+}
+
+// insert returns the node of the key, creating it if needed.
+// The key is copied.
+func (ws *writeSet) insert(k []byte) (n *node, created bool) {
+	var prev [maxHeight]*node
+
+	if last := ws.tail[0]; last != nil && encoding.Compare(last.key, k) < 0 {
+		// fast path for keys written in order
+		for l := 0; l < ws.height; l++ {
+			prev[l] = ws.tail[l]
+			if prev[l] == nil {
+				prev[l] = &ws.head
+			}
+		}
+	} else {
+		x := &ws.head
+		for l := ws.height - 1; l >= 0; l-- {
+			for x.next[l] != nil && encoding.Compare(x.next[l].key, k) < 0 {
+				x = x.next[l]
+			}
+			prev[l] = x
+		}
+		if n := x.nextAt(0); n != nil && encoding.Equal(n.key, k) {
+			return n, false
+		}
+	}
+
+	h := randomHeight()
+	if h > ws.height {
+		if ws.head.next == nil {
+			ws.head.next = make([]*node, maxHeight)
+		}
+		for l := ws.height; l < h; l++ {
+			prev[l] = &ws.head
+		}
+		ws.height = h
+	}
+
+	n = &node{
+		key:  append([]byte(nil), k...),
+		next: make([]*node, h),
+	}
+	n.versions = n.inline[:0]
+	for l := 0; l < h; l++ {
+		n.next[l] = prev[l].next[l]
+		prev[l].next[l] = n
+		if n.next[l] == nil {
+			ws.tail[l] = n
+		}
+	}
+	ws.len++
+
+	return n, true
+}
+
+func (n *node) nextAt(l int) *node {
+	if l >= len(n.next) {
+		return nil
+	}
+
+	return n.next[l]
+}
+
+// first returns the node with the lowest key, or nil if the set is empty.
+func (ws *writeSet) first() *node {
+	return ws.head.nextAt(0)
+}
+
+// last returns the node with the greatest key, or nil if the set is empty.
+func (ws *writeSet) last() *node {
+	x := &ws.head
+	for l := ws.height - 1; l >= 0; l-- {
+		for x.next[l] != nil {
+			x = x.next[l]
+		}
+	}
+	if x == &ws.head {
+		return nil
+	}
+
+	return x
+}
+
+// seekGE returns the first node whose key is >= k, or nil if there is none.
+func (ws *writeSet) seekGE(k []byte) *node {
+	x := &ws.head
+	for l := ws.height - 1; l >= 0; l-- {
+		for x.next[l] != nil && encoding.Compare(x.next[l].key, k) < 0 {
+			x = x.next[l]
+		}
+	}
+
+	return x.nextAt(0)
+}
+
+// seekLT returns the last node whose key is < k, or nil if there is none.
+func (ws *writeSet) seekLT(k []byte) *node {
+	x := &ws.head
+	for l := ws.height - 1; l >= 0; l-- {
+		for x.next[l] != nil && encoding.Compare(x.next[l].key, k) < 0 {
+			x = x.next[l]
+		}
+	}
+	if x == &ws.head {
+		return nil
+	}
+
+	return x
+}
+
+// randomHeight returns the height of a new node: each level
+// is kept with a probability of 1/4.
+func randomHeight() int {
+	h := 1
+	for h < maxHeight && rand.Uint32()&3 == 0 {
+		h++
+	}
+
+	return h
+}
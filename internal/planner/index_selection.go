@@ -154,15 +154,69 @@ func (i *indexSelector) selectIndex() error {
 		}
 	}
 
-	// select the cheapest plan
-	var selected *candidate
-	var cost int
-
-	// start with the primary key of the table
 	tb, err := i.sctx.Catalog.GetTableInfo(i.tableScan.TableName)
 	if err != nil {
 		return err
 	}
+
+	// the index given by an optimizer hint is selected if it can be used,
+	// the other indexes are not considered
+	var selected *candidate
+	if hint := i.tableScan.Hint; hint != nil && hint.Index != "" {
+		selected, err = i.indexCandidate(tb, hint.Index, nodes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if selected == nil {
+		selected, err = i.cheapestCandidate(tb, nodes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if selected == nil {
+		return nil
+	}
+
+	// remove the filter nodes from the tree
+	for _, f := range selected.nodes {
+		switch tp := f.node.(type) {
+		case *rows.FilterOperator:
+			i.sctx.removeFilterNode(tp)
+		case *rows.TempTreeSortOperator:
+			i.sctx.removeTempTreeNodeNode(tp)
+		}
+	}
+
+	// remove the TempSort node whose order is given by the scan
+	if selected.sorter != nil {
+		i.sctx.removeTempTreeNodeNode(selected.sorter.node.(*rows.TempTreeSortOperator))
+	}
+
+	// we replace the seq scan node by the selected root
+	s := i.sctx.Stream
+	s.Remove(s.First())
+	for i := len(selected.replaceRootBy) - 1; i >= 0; i-- {
+		if s.Op == nil {
+			s.Op = selected.replaceRootBy[i]
+			continue
+		}
+		stream.InsertBefore(s.First(), selected.replaceRootBy[i])
+	}
+	i.sctx.Stream = s
+
+	return nil
+}
+
+// cheapestCandidate returns the cheapest plan reading the table
+// with its primary key or one of its indexes, if any.
+func (i *indexSelector) cheapestCandidate(tb *database.TableInfo, nodes indexableNodes) (*candidate, error) {
+	var selected *candidate
+	var cost int
+
+	// start with the primary key of the table
 	pk := tb.PrimaryKey
 	if pk != nil {
 		selected = i.associateIndexWithNodes(tb.TableName, false, false, pk.Columns, pk.SortOrder, nodes)
@@ -171,16 +225,19 @@ func (i *indexSelector) selectIndex() error {
 		}
 	}
 
+	// the optimizer hints can prevent the use of the indexes
+	if hint := i.tableScan.Hint; hint != nil && (hint.NoIndex || hint.Index != "") {
+		return selected, nil
+	}
+
 	// get all the indexes for this table and associate them
 	// with compatible candidates
 	for _, idxName := range i.sctx.Catalog.ListIndexes(i.tableScan.TableName) {
-		idxInfo, err := i.sctx.Catalog.GetIndexInfo(idxName)
+		candidate, err := i.indexCandidate(tb, idxName, nodes)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		candidate := i.associateIndexWithNodes(idxInfo.IndexName, true, idxInfo.Unique, idxInfo.Columns, idxInfo.KeySortOrder, nodes)
-
 		if candidate == nil {
 			continue
 		}
@@ -199,36 +256,30 @@ func (i *indexSelector) selectIndex() error {
 		}
 	}
 
-	if selected == nil {
-		return nil
+	return selected, nil
+}
+
+// indexCandidate returns the plan reading the table with the given index,
+// or nil if the index cannot be used.
+func (i *indexSelector) indexCandidate(tb *database.TableInfo, idxName string, nodes indexableNodes) (*candidate, error) {
+	idxInfo, err := i.sctx.Catalog.GetIndexInfo(idxName)
+	if err != nil {
+		return nil, err
 	}
 
-	// remove the filter nodes from the tree
-	for _, f := range selected.nodes {
-		switch tp := f.node.(type) {
-		case *rows.FilterOperator:
-			i.sctx.removeFilterNode(tp)
-			if f.orderBy != nil {
-				i.sctx.removeTempTreeNodeNode(f.orderBy.node.(*rows.TempTreeSortOperator))
-			}
-		case *rows.TempTreeSortOperator:
-			i.sctx.removeTempTreeNodeNode(tp)
-		}
+	// indexes on encrypted columns contain encrypted values
+	// and can't be used to filter or sort plain values
+	if tb.HasEncryptedColumn(idxInfo.Columns) {
+		return nil, nil
 	}
 
-	// we replace the seq scan node by the selected root
-	s := i.sctx.Stream
-	s.Remove(s.First())
-	for i := len(selected.replaceRootBy) - 1; i >= 0; i-- {
-		if s.Op == nil {
-			s.Op = selected.replaceRootBy[i]
-			continue
-		}
-		stream.InsertBefore(s.First(), selected.replaceRootBy[i])
+	// vector indexes don't store the values in order,
+	// they are only used by the SelectVectorIndex rule
+	if idxInfo.IVF != nil {
+		return nil, nil
 	}
-	i.sctx.Stream = s
 
-	return nil
+	return i.associateIndexWithNodes(idxInfo.IndexName, true, idxInfo.Unique, idxInfo.Columns, idxInfo.KeySortOrder, nodes), nil
 }
 
 func (i *indexSelector) isFilterIndexable(f *rows.FilterOperator) (*indexableNode, error) {
@@ -260,20 +311,56 @@ func (i *indexSelector) isFilterIndexable(f *rows.FilterOperator) (*indexableNod
 }
 
 func (i *indexSelector) isTempTreeSortIndexable(n *rows.TempTreeSortOperator) *indexableNode {
-	// only columns can be associated with an index
-	col, ok := n.Expr.(*expr.Column)
-	if !ok {
-		return nil
+	cols := make([]string, 0, len(n.Keys))
+	desc := make([]bool, 0, len(n.Keys))
+	for _, k := range n.Keys {
+		// only columns can be associated with an index
+		col, ok := k.Expr.(*expr.Column)
+		if !ok {
+			return nil
+		}
+
+		// indexes sort the NULL values as the smallest values
+		if k.NullsFirst() == k.Desc {
+			return nil
+		}
+
+		cols = append(cols, col.Name)
+		desc = append(desc, k.Desc)
 	}
 
 	return &indexableNode{
 		node:     n,
-		col:      col.Name,
-		desc:     n.Desc,
+		col:      cols[0],
+		sortCols: cols,
+		sortDesc: desc,
 		operator: scanner.ORDER,
 	}
 }
 
+// sortedBy returns whether the columns, starting at position pos, are sorted
+// by the keys of the TempSort node, and whether they must be read in reverse.
+func (n *indexableNode) sortedBy(columns []string, sortOrder tree.SortOrder, pos int) (desc bool, ok bool) {
+	if len(columns)-pos < len(n.sortCols) {
+		return false, false
+	}
+
+	for i, c := range n.sortCols {
+		if columns[pos+i] != c {
+			return false, false
+		}
+
+		// in case the indexed column is descending, we need to reverse the order
+		d := n.sortDesc[i] != sortOrder.IsDesc(pos+i)
+		if i > 0 && d != desc {
+			return false, false
+		}
+		desc = d
+	}
+
+	return desc, true
+}
+
 // for a given index, select all filter nodes that match according to the following rules:
 // - from left to right, associate each indexed path to a filter node and stop when there is no
 // node available or the node is not compatible
@@ -295,7 +382,8 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 
 	var hasIn bool
 	var sorter *indexableNode
-	for _, p := range columns {
+	var sorterPos int
+	for pos, p := range columns {
 		ns := nodes.getByColumn(p)
 		if len(ns) == 0 {
 			break
@@ -306,7 +394,7 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 		for i, n := range ns {
 			if n.operator == scanner.ORDER && sorter == nil {
 				sorter = ns[i]
-				desc = sorter.desc
+				sorterPos = pos
 				continue
 			}
 			if filter == nil {
@@ -322,12 +410,6 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 			break
 		}
 
-		// if we have both a filter and a TempSort node, we can merge them
-		if filter != nil && sorter != nil {
-			filter.orderBy = sorter
-			sorter = nil
-		}
-
 		if filter.operator == scanner.IN {
 			hasIn = true
 		}
@@ -345,6 +427,20 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 		}
 	}
 
+	// the TempSort node can only be removed if the following columns
+	// are sorted by its keys, in the same direction.
+	// The ranges generated by IN operators are not sorted.
+	if sorter != nil && hasIn {
+		sorter = nil
+	}
+	if sorter != nil {
+		var ok bool
+		desc, ok = sorter.sortedBy(columns, sortOrder, sorterPos)
+		if !ok {
+			sorter = nil
+		}
+	}
+
 	if len(found) == 0 && sorter == nil {
 		return nil
 	}
@@ -358,11 +454,6 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 			isUnique:   isUnique,
 		}
 
-		// in case the primary key or index is descending, we need to use a reverse the order
-		if sortOrder.IsDesc(0) {
-			desc = !desc
-		}
-
 		if !isIndex {
 			if !desc {
 				c.replaceRootBy = []stream.Operator{
@@ -388,12 +479,6 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 		return &c
 	}
 
-	// in case we found an orphan sorter node and we need to assign it to the first filter node
-	// for deletion
-	if sorter != nil {
-		found[0].orderBy = sorter
-	}
-
 	// in case there is an IN operator in the list, we need to generate multiple ranges.
 	// If not, we only need one range.
 	var ranges stream.Ranges
@@ -406,18 +491,12 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 
 	c := candidate{
 		nodes:      found,
+		sorter:     sorter,
 		rangesCost: ranges.Cost(),
 		isIndex:    isIndex,
 		isUnique:   isUnique,
 	}
 
-	// in case the indexed path is descending, we need to reverse the order
-	if found[len(found)-1].orderBy != nil {
-		if sortOrder.IsDesc(len(found) - 1) {
-			desc = !desc
-		}
-	}
-
 	if !isIndex {
 		if !desc {
 			c.replaceRootBy = []stream.Operator{
@@ -583,21 +662,19 @@ type indexableNode struct {
 	// - operator: scanner.GT
 	// - operand: 5 + 5
 	// For TempTreeSort nodes
-	// the expression of the node
-	// has been broken into
-	// <col> <direction>
-	// Ex:  ORDER BY a.b[0] ASC
+	// the keys of the node
+	// have been broken into
+	// <col> <direction>, ...
+	// Ex:  ORDER BY a ASC, b DESC
 	// Gives:
-	// - col: a.b[0]
-	// - desc: false
+	// - col: a
+	// - sortCols: a, b
+	// - sortDesc: false, true
 	col      string
 	operator scanner.Token
 	operand  expr.Expr
-	desc     bool
-
-	// merged TempTreeSort node to remove
-	// from the stream
-	orderBy *indexableNode
+	sortCols []string
+	sortDesc []bool
 }
 
 type indexableNodes []*indexableNode
@@ -621,6 +698,10 @@ type candidate struct {
 	// or pkScan operators.
 	nodes indexableNodes
 
+	// TempSort node to remove, if the order of the rows
+	// is given by the filtered scan.
+	sorter *indexableNode
+
 	// replace the table.Scan by these nodes
 	replaceRootBy []stream.Operator
 
@@ -19,7 +19,9 @@ var optimizerRules = []func(sctx *StreamContext) error{
 	RemoveUnnecessaryProjection,
 	RemoveUnnecessaryFilterNodesRule,
 	RemoveUnnecessaryTempSortNodesRule,
+	SelectVectorIndex,
 	SelectIndex,
+	PushDownLimitRule,
 }
 
 // Optimize takes a tree, applies a list of optimization rules
@@ -40,14 +42,24 @@ func Optimize(s *stream.Stream, catalog *database.Catalog, params []environment.
 		return s, nil
 	}
 
-	if firstNode, ok := s.First().(*stream.UnionOperator); ok {
-		// If the first operation is a union, optimize all streams individually.
-		for i, st := range firstNode.Streams {
+	var streams []*stream.Stream
+	switch t := s.First().(type) {
+	case *stream.UnionOperator:
+		streams = t.Streams
+	case *stream.IntersectOperator:
+		streams = t.Streams
+	case *stream.ExceptOperator:
+		streams = t.Streams
+	}
+
+	if streams != nil {
+		// If the first operation is a set operation, optimize all streams individually.
+		for i, st := range streams {
 			ss, err := Optimize(st, catalog, params)
 			if err != nil {
 				return nil, err
 			}
-			firstNode.Streams[i] = ss
+			streams[i] = ss
 		}
 
 		return s, nil
@@ -75,6 +87,9 @@ func NewStreamContext(s *stream.Stream, catalog *database.Catalog) *StreamContex
 	n := s.First()
 
 	prevIsFilter := false
+	// rows are reordered by MATCH_RECOGNIZE, the sorts following it
+	// cannot be replaced by the order of an index.
+	afterMatch := false
 
 	for n != nil {
 		switch t := n.(type) {
@@ -95,7 +110,12 @@ func NewStreamContext(s *stream.Stream, catalog *database.Catalog) *StreamContex
 			sctx.Projections = append(sctx.Projections, t)
 			prevIsFilter = false
 		case *rows.TempTreeSortOperator:
-			sctx.TempTreeSorts = append(sctx.TempTreeSorts, t)
+			if !afterMatch {
+				sctx.TempTreeSorts = append(sctx.TempTreeSorts, t)
+			}
+			prevIsFilter = false
+		case *rows.MatchRecognizeOperator:
+			afterMatch = true
 			prevIsFilter = false
 		}
 
@@ -242,7 +262,12 @@ func PrecalculateExprRule(sctx *StreamContext) error {
 				}
 			}
 		case *rows.TempTreeSortOperator:
-			t.Expr, err = precalculateExpr(sctx, t.Expr)
+			for i := range t.Keys {
+				t.Keys[i].Expr, err = precalculateExpr(sctx, t.Keys[i].Expr)
+				if err != nil {
+					return err
+				}
+			}
 		case *path.SetOperator:
 			t.Expr, err = precalculateExpr(sctx, t.Expr)
 		case *rows.EmitOperator:
@@ -323,8 +348,9 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 
 		lv, leftIsLit := lh.(expr.LiteralValue)
 		rv, rightIsLit := rh.(expr.LiteralValue)
-		// if both operands are literals, we can precalculate them now
-		if leftIsLit && rightIsLit {
+		// if both operands are literals, we can precalculate them now,
+		// except for LIKE whose result depends on the configuration of the database.
+		if leftIsLit && rightIsLit && tok != scanner.LIKE && tok != scanner.NLIKE {
 			v, err := t.Eval(&environment.Environment{})
 			if err != nil {
 				return nil, err
@@ -334,11 +360,14 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 		}
 
 		// if one operand is a column and the other is a literal
-		// we can check if the types are compatible
+		// we can check if the types are compatible.
+		// NULL can be compared with any type.
 		lc, leftIsCol := lh.(*expr.Column)
 		rc, rightIsCol := rh.(*expr.Column)
+		leftIsLit = leftIsLit && lv.Value.Type() != types.TypeNull
+		rightIsLit = rightIsLit && rv.Value.Type() != types.TypeNull
 
-		if leftIsCol && rightIsLit {
+		if leftIsCol && rightIsLit && !isTemporalArithmetic(t, lc, rv, sctx) {
 			tp := sctx.TableInfo.ColumnConstraints.GetColumnConstraint(lc.Name).Type
 			if !tp.Def().IsComparableWith(rv.Value.Type()) {
 				return nil, errors.Errorf("invalid input syntax for type %s: %s", tp, rh)
@@ -353,7 +382,7 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 			}
 		}
 
-		if leftIsLit && rightIsCol {
+		if leftIsLit && rightIsCol && !isTemporalArithmetic(t, rc, lv, sctx) {
 			tp := sctx.TableInfo.ColumnConstraints.GetColumnConstraint(rc.Name).Type
 			if !tp.Def().IsComparableWith(lv.Value.Type()) {
 				return nil, errors.Errorf("invalid input syntax for type %s: %s", tp, lh)
@@ -374,6 +403,25 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 	return e, nil
 }
 
+// isTemporalArithmetic returns whether op is an arithmetic operator
+// between a column and a literal where one of them is a timestamp,
+// a date or an interval. Their types don't have to be comparable,
+// as in a timestamp plus an interval or an interval times a number.
+func isTemporalArithmetic(op expr.Operator, c *expr.Column, lit expr.LiteralValue, sctx *StreamContext) bool {
+	if !expr.IsArithmeticOperator(op) {
+		return false
+	}
+
+	for _, tp := range []types.Type{sctx.TableInfo.ColumnConstraints.GetColumnConstraint(c.Name).Type, lit.Value.Type()} {
+		switch tp {
+		case types.TypeTimestamp, types.TypeDate, types.TypeInterval:
+			return true
+		}
+	}
+
+	return false
+}
+
 func CheckExprTypeRule(sctx *StreamContext) error {
 	n := sctx.Stream.Op
 	var err error
@@ -390,7 +438,12 @@ func CheckExprTypeRule(sctx *StreamContext) error {
 				}
 			}
 		case *rows.TempTreeSortOperator:
-			err = checkExprType(sctx, t.Expr)
+			for _, k := range t.Keys {
+				err = checkExprType(sctx, k.Expr)
+				if err != nil {
+					return err
+				}
+			}
 		case *path.SetOperator:
 			err = checkExprType(sctx, t.Expr)
 		case *rows.EmitOperator:
@@ -431,7 +484,7 @@ func checkExprType(sctx *StreamContext, e expr.Expr) (err error) {
 		return nil
 	}
 
-	if leftIsCol && rightIsLit {
+	if leftIsCol && rightIsLit && !isTemporalArithmetic(op, lc, rv, sctx) {
 		tp := sctx.TableInfo.ColumnConstraints.GetColumnConstraint(lc.Name).Type
 		_, err := rv.Value.CastAs(tp)
 		if err != nil {
@@ -441,7 +494,7 @@ func checkExprType(sctx *StreamContext, e expr.Expr) (err error) {
 		return nil
 	}
 
-	if leftIsLit && rightIsCol {
+	if leftIsLit && rightIsCol && !isTemporalArithmetic(op, rc, lv, sctx) {
 		tp := sctx.TableInfo.ColumnConstraints.GetColumnConstraint(rc.Name).Type
 		_, err := lv.Value.CastAs(tp)
 		if err != nil {
@@ -505,6 +558,7 @@ func RemoveUnnecessaryProjection(sctx *StreamContext) error {
 //	table.Scan('foo') | docs.TempSort(a) | docs.GroupBy(a) | docs.TempSort(a)
 //
 // This only works if both temp sort nodes use the same path
+// and the second one has a single key.
 func RemoveUnnecessaryTempSortNodesRule(sctx *StreamContext) error {
 	if len(sctx.TempTreeSorts) > 2 {
 		panic("unexpected number of TempSort nodes")
@@ -514,12 +568,16 @@ func RemoveUnnecessaryTempSortNodesRule(sctx *StreamContext) error {
 		return nil
 	}
 
-	lcol, ok := sctx.TempTreeSorts[0].Expr.(*expr.Column)
+	if len(sctx.TempTreeSorts[0].Keys) != 1 || len(sctx.TempTreeSorts[1].Keys) != 1 {
+		return nil
+	}
+
+	lcol, ok := sctx.TempTreeSorts[0].Keys[0].Expr.(*expr.Column)
 	if !ok {
 		return nil
 	}
 
-	rcol, ok := sctx.TempTreeSorts[1].Expr.(*expr.Column)
+	rcol, ok := sctx.TempTreeSorts[1].Keys[0].Expr.(*expr.Column)
 	if !ok {
 		return nil
 	}
@@ -530,8 +588,40 @@ func RemoveUnnecessaryTempSortNodesRule(sctx *StreamContext) error {
 
 	// we remove the rightmost one
 	// and we override the direction of the first one
-	sctx.TempTreeSorts[0].Desc = sctx.TempTreeSorts[1].Desc
+	sctx.TempTreeSorts[0].Keys[0].Desc = sctx.TempTreeSorts[1].Keys[0].Desc
+	sctx.TempTreeSorts[0].Keys[0].Nulls = sctx.TempTreeSorts[1].Keys[0].Nulls
 	sctx.removeTempTreeNodeNode(sctx.TempTreeSorts[1])
 
 	return nil
 }
+
+// PushDownLimitRule moves the Skip and Take operators following
+// a projection before it, so that the rows that are skipped or
+// not returned are never projected.
+// It runs after the sorts were replaced by the order of an index,
+// so that LIMIT also stops the scan of the index as early as possible.
+//
+//	this:
+//	  table.Scan('foo') | rows.Project(a + 1) | rows.Skip(10) | rows.Take(5)
+//	becomes this:
+//	  table.Scan('foo') | rows.Skip(10) | rows.Take(5) | rows.Project(a + 1)
+func PushDownLimitRule(sctx *StreamContext) error {
+	for _, p := range sctx.Projections {
+		for {
+			next := p.GetNext()
+			switch next.(type) {
+			case *rows.SkipOperator, *rows.TakeOperator:
+			default:
+				next = nil
+			}
+			if next == nil {
+				break
+			}
+
+			sctx.Stream.Remove(next)
+			stream.InsertBefore(p, next)
+		}
+	}
+
+	return nil
+}
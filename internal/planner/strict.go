@@ -0,0 +1,291 @@
+package planner
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/path"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// CheckStrictTypes returns an error if the stream compares values
+// whose types are incompatible, such as a text column with an integer.
+// Without strict typing, these comparisons silently evaluate to false or NULL.
+// It must be called before the stream is optimized.
+//
+// Casts between types that cannot be converted, such as a date cast as an integer,
+// are rejected as well.
+//
+// Only the operands whose type is known before running the query are checked:
+// columns, literals, parameters and casts.
+// Text literals can be compared with timestamps, dates, intervals and UUIDs,
+// as they are the only way to write them.
+func CheckStrictTypes(s *stream.Stream, catalog *database.Catalog, params []environment.Param) error {
+	c := strictChecker{
+		catalog: catalog,
+		env:     environment.Environment{Params: params},
+	}
+
+	return c.checkStream(s)
+}
+
+type strictChecker struct {
+	catalog *database.Catalog
+	env     environment.Environment
+	info    *database.TableInfo
+}
+
+func (c *strictChecker) checkStream(s *stream.Stream) error {
+	if s == nil {
+		return nil
+	}
+
+	for op := s.First(); op != nil; op = op.GetNext() {
+		var err error
+
+		switch t := op.(type) {
+		case *stream.UnionOperator:
+			err = c.checkStreams(t.Streams)
+		case *stream.ConcatOperator:
+			err = c.checkStreams(t.Streams)
+		case *stream.IntersectOperator:
+			err = c.checkStreams(t.Streams)
+		case *stream.ExceptOperator:
+			err = c.checkStreams(t.Streams)
+		case *table.ScanOperator:
+			if c.catalog != nil {
+				c.info, err = c.catalog.GetTableInfo(t.TableName)
+			}
+		case *rows.FilterOperator:
+			err = c.checkExpr(t.Expr)
+		case *rows.ProjectOperator:
+			for _, e := range t.Exprs {
+				err = c.checkExpr(e)
+				if err != nil {
+					break
+				}
+			}
+		case *rows.TempTreeSortOperator:
+			for _, k := range t.Keys {
+				err = c.checkExpr(k.Expr)
+				if err != nil {
+					break
+				}
+			}
+		case *path.SetOperator:
+			err = c.checkExpr(t.Expr)
+		case *stream.JoinOperator:
+			err = c.checkExpr(t.Cond)
+		case *rows.MatchRecognizeOperator:
+			for _, d := range t.Define {
+				err = c.checkExpr(d.Expr)
+				if err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *strictChecker) checkStreams(streams []*stream.Stream) error {
+	for _, s := range streams {
+		cc := strictChecker{catalog: c.catalog, env: c.env}
+		err := cc.checkStream(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkExpr checks the comparisons of the expression and of its operands.
+func (c *strictChecker) checkExpr(e expr.Expr) error {
+	switch t := e.(type) {
+	case nil:
+		return nil
+	case expr.Parentheses:
+		return c.checkExpr(t.E)
+	case *expr.NamedExpr:
+		return c.checkExpr(t.Expr)
+	case *expr.Cast:
+		if tp, ok := c.typeOf(t.Expr); ok && !tp.CanCastAs(t.CastAs) {
+			return errs.TypeMismatchf("strict typing: cannot cast %s of type %s as %s", t.Expr, tp, t.CastAs)
+		}
+		return c.checkExpr(t.Expr)
+	case *expr.Collate:
+		return c.checkExpr(t.Expr)
+	case expr.LiteralExprList:
+		for _, e := range t {
+			if err := c.checkExpr(e); err != nil {
+				return err
+			}
+		}
+	case expr.Function:
+		for _, p := range t.Params() {
+			if err := c.checkExpr(p); err != nil {
+				return err
+			}
+		}
+	case expr.Operator:
+		if err := c.checkExpr(t.LeftHand()); err != nil {
+			return err
+		}
+		if err := c.checkExpr(t.RightHand()); err != nil {
+			return err
+		}
+
+		return c.checkOperator(t)
+	}
+
+	return nil
+}
+
+func (c *strictChecker) checkOperator(op expr.Operator) error {
+	if !expr.IsComparisonOperator(op) {
+		return nil
+	}
+
+	switch op.Token() {
+	case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+		return c.checkComparison(op.LeftHand(), op.RightHand())
+	case scanner.IN, scanner.NIN:
+		l, ok := op.RightHand().(expr.LiteralExprList)
+		if !ok {
+			return c.checkComparison(op.LeftHand(), op.RightHand())
+		}
+		for _, e := range l {
+			if err := c.checkComparison(op.LeftHand(), e); err != nil {
+				return err
+			}
+		}
+	case scanner.BETWEEN:
+		b := op.(*expr.BetweenOperator)
+		if err := c.checkExpr(b.X); err != nil {
+			return err
+		}
+		if err := c.checkComparison(b.X, op.LeftHand()); err != nil {
+			return err
+		}
+		return c.checkComparison(b.X, op.RightHand())
+	case scanner.LIKE, scanner.NLIKE:
+		for _, e := range []expr.Expr{op.LeftHand(), op.RightHand()} {
+			tp, ok := c.typeOf(e)
+			if ok && tp != types.TypeText && tp != types.TypeNull {
+				return errs.TypeMismatchf("strict typing: cannot use %s of type %s with %s", e, tp, op.Token())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *strictChecker) checkComparison(a, b expr.Expr) error {
+	ta, ok := c.typeOf(a)
+	if !ok {
+		return nil
+	}
+	tb, ok := c.typeOf(b)
+	if !ok {
+		return nil
+	}
+
+	if areStrictlyComparable(ta, tb, isTextLiteral(a), isTextLiteral(b)) {
+		return nil
+	}
+
+	return errs.TypeMismatchf("strict typing: cannot compare %s of type %s with %s of type %s", a, ta, b, tb)
+}
+
+// typeOf returns the type of the expression, if it is known
+// before running the query.
+func (c *strictChecker) typeOf(e expr.Expr) (types.Type, bool) {
+	switch t := e.(type) {
+	case expr.LiteralValue:
+		return t.Value.Type(), true
+	case expr.NamedParam, expr.PositionalParam:
+		v, err := e.Eval(&c.env)
+		if err != nil {
+			return 0, false
+		}
+		return v.Type(), true
+	case *expr.Column:
+		info := c.info
+		if info == nil {
+			return 0, false
+		}
+		// the column might belong to a joined table
+		if t.Table != "" && t.Table != info.TableName {
+			var err error
+			info, err = c.catalog.GetTableInfo(t.Table)
+			if err != nil {
+				return 0, false
+			}
+		}
+		cc := info.ColumnConstraints.GetColumnConstraint(t.Name)
+		if cc == nil || cc.Type.IsAny() {
+			return 0, false
+		}
+		return cc.Type, true
+	case *expr.Cast:
+		return t.CastAs, true
+	case *expr.Collate:
+		return c.typeOf(t.Expr)
+	case expr.Parentheses:
+		return c.typeOf(t.E)
+	}
+
+	return 0, false
+}
+
+// isTextLiteral returns true for text literals and parameters,
+// which are used to write the values of types without literals.
+func isTextLiteral(e expr.Expr) bool {
+	switch t := e.(type) {
+	case expr.LiteralValue:
+		return t.Value.Type() == types.TypeText
+	case expr.NamedParam, expr.PositionalParam:
+		return true
+	}
+
+	return false
+}
+
+// areStrictlyComparable returns true if values of types a and b
+// can be compared without converting one of them to another type.
+func areStrictlyComparable(a, b types.Type, aIsText, bIsText bool) bool {
+	if a == b || a == types.TypeNull || b == types.TypeNull {
+		return true
+	}
+
+	if a.IsNumber() && b.IsNumber() {
+		return true
+	}
+
+	isTime := func(t types.Type) bool {
+		return t == types.TypeTimestamp || t == types.TypeDate
+	}
+	if isTime(a) && isTime(b) {
+		return true
+	}
+
+	isWrittenAsText := func(t types.Type) bool {
+		return isTime(t) || t == types.TypeInterval || t == types.TypeUUID
+	}
+	if (aIsText && a == types.TypeText && isWrittenAsText(b)) || (bIsText && b == types.TypeText && isWrittenAsText(a)) {
+		return true
+	}
+
+	return false
+}
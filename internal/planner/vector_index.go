@@ -0,0 +1,150 @@
+package planner
+
+import (
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// SelectVectorIndex replaces the sort of the rows of a table by their distance
+// to a vector by a search in a vector index of the same metric,
+// if the number of rows returned is limited.
+//
+//	SELECT * FROM foo ORDER BY vector_distance(v, '[1, 2]', 'l2') LIMIT 10
+//	table.Scan('foo') | rows.TempTreeSort(vector_distance(v, "[1, 2]", "l2")) | rows.Take(10)
+//
+// becomes
+//
+//	index.VectorScan("idx_foo_v", "[1, 2]", 10) | rows.Take(10)
+//
+// As the search is approximate, the index is not used if the rows are filtered,
+// as filtering the nearest rows could return fewer rows than the limit.
+func SelectVectorIndex(sctx *StreamContext) error {
+	seq, ok := sctx.Stream.First().(*table.ScanOperator)
+	if !ok || len(seq.Ranges) > 0 || seq.Reverse {
+		return nil
+	}
+
+	// the optimizer hints can prevent the use of the indexes
+	if seq.Hint != nil && seq.Hint.NoIndex {
+		return nil
+	}
+
+	if len(sctx.Filters) > 0 || len(sctx.TempTreeSorts) != 1 {
+		return nil
+	}
+
+	sort := sctx.TempTreeSorts[0]
+	if len(sort.Keys) != 1 || sort.Keys[0].Desc {
+		return nil
+	}
+
+	vd, ok := sort.Keys[0].Expr.(*functions.VectorDistance)
+	if !ok {
+		return nil
+	}
+
+	col, v := vectorDistanceOperands(vd)
+	if col == nil {
+		return nil
+	}
+
+	limit := vectorSearchLimit(sort)
+	if limit == nil {
+		return nil
+	}
+
+	env := environment.Environment{Params: sctx.Params}
+	m, err := vd.Metric.Eval(&env)
+	if err != nil || m.Type() != types.TypeText {
+		return nil
+	}
+	metric, err := types.ParseVectorMetric(types.AsString(m))
+	if err != nil {
+		return nil
+	}
+
+	for _, idxName := range sctx.Catalog.ListIndexes(seq.TableName) {
+		info, err := sctx.Catalog.GetIndexInfo(idxName)
+		if err != nil {
+			return err
+		}
+
+		if info.IVF == nil || info.IVF.Metric != metric || info.Columns[0] != col.Name {
+			continue
+		}
+
+		// or require another index
+		if seq.Hint != nil && seq.Hint.Index != "" && seq.Hint.Index != info.IndexName {
+			continue
+		}
+
+		sctx.removeTempTreeNodeNode(sort)
+
+		s := sctx.Stream
+		s.Remove(s.First())
+		scan := index.VectorScan(info.IndexName, v, limit)
+		if s.Op == nil {
+			s.Op = scan
+		} else {
+			stream.InsertBefore(s.First(), scan)
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// vectorDistanceOperands returns the column and the vector whose distance
+// is computed by vd, or nil if vd doesn't compare a column with a constant.
+func vectorDistanceOperands(vd *functions.VectorDistance) (*expr.Column, expr.Expr) {
+	if col, ok := vd.A.(*expr.Column); ok && !hasColumn(vd.B) {
+		return col, vd.B
+	}
+	if col, ok := vd.B.(*expr.Column); ok && !hasColumn(vd.A) {
+		return col, vd.A
+	}
+
+	return nil, nil
+}
+
+func hasColumn(e expr.Expr) bool {
+	var found bool
+	expr.Walk(e, func(e expr.Expr) bool {
+		if _, ok := e.(*expr.Column); ok {
+			found = true
+			return false
+		}
+		return true
+	})
+
+	return found
+}
+
+// vectorSearchLimit returns the number of rows read after the sort,
+// including the skipped ones, or nil if the sort is not directly
+// followed by a limit.
+func vectorSearchLimit(sort *rows.TempTreeSortOperator) expr.Expr {
+	var skip expr.Expr
+	for op := sort.GetNext(); op != nil; op = op.GetNext() {
+		switch t := op.(type) {
+		case *rows.SkipOperator:
+			skip = t.E
+		case *rows.TakeOperator:
+			if skip != nil {
+				return expr.Add(t.E, skip)
+			}
+			return t.E
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
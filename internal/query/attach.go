@@ -0,0 +1,56 @@
+package query
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query/statement"
+)
+
+var _ queryAlterer = AttachStmt{}
+var _ queryAlterer = DetachStmt{}
+
+// AttachStmt is a statement that attaches the database stored at Path
+// to the connection, under the given name.
+// It doesn't implement the Preparer interface, so that the statements
+// following it are prepared with the tables of the attached database.
+type AttachStmt struct {
+	Path string
+	Name string
+}
+
+func (stmt AttachStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+func (stmt AttachStmt) alterQuery(conn *database.Connection, q *Query) error {
+	return conn.Attach(stmt.Path, stmt.Name)
+}
+
+func (stmt AttachStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt AttachStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, ctx.Conn.Attach(stmt.Path, stmt.Name)
+}
+
+// DetachStmt is a statement that detaches the database
+// attached to the connection under the given name.
+type DetachStmt struct {
+	Name string
+}
+
+func (stmt DetachStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+func (stmt DetachStmt) alterQuery(conn *database.Connection, q *Query) error {
+	return conn.Detach(stmt.Name)
+}
+
+func (stmt DetachStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt DetachStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, ctx.Conn.Detach(stmt.Name)
+}
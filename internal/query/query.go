@@ -2,10 +2,12 @@ package query
 
 import (
 	"context"
+	"sync"
 
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/cockroachdb/errors"
 )
 
 // A Query can execute statements against the database. It can read or write data
@@ -15,6 +17,15 @@ type Query struct {
 	Statements []statement.Statement
 	tx         *database.Transaction
 	autoCommit bool
+
+	// Reparse returns the statements of the query as parsed.
+	// If set, the statements prepared by Prepare are prepared again
+	// when they are run with a catalog that changed since,
+	// instead of running with a stale plan.
+	Reparse func() ([]statement.Statement, error)
+
+	// statements prepared by Prepare, shared by the copies of the query.
+	plans *plans
 }
 
 // New creates a new query with the given statements.
@@ -23,10 +34,11 @@ func New(statements ...statement.Statement) Query {
 }
 
 type Context struct {
-	Ctx    context.Context
-	DB     *database.Database
-	Conn   *database.Connection
-	Params []environment.Param
+	Ctx      context.Context
+	Progress *environment.Progress
+	DB       *database.Database
+	Conn     *database.Connection
+	Params   []environment.Param
 }
 
 func (c *Context) GetTx() *database.Transaction {
@@ -42,12 +54,14 @@ func (q *Query) Prepare(context *Context) error {
 	ctx := context.Ctx
 
 	for i, stmt := range q.Statements {
-		if ctx != nil {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
+		if err := ctxErr(ctx); err != nil {
+			return err
+		}
+
+		// statements reading the past use their own transaction,
+		// created when they are run.
+		if a, ok := stmt.(statement.AsOfStatement); ok && a.AsOfExpr() != nil {
+			return nil
 		}
 
 		if tx == nil {
@@ -85,6 +99,13 @@ func (q *Query) Prepare(context *Context) error {
 		}
 
 		q.Statements[i] = stmt
+
+		if q.Reparse != nil {
+			if q.plans == nil {
+				q.plans = &plans{reparse: q.Reparse}
+			}
+			q.plans.entries = append(q.plans.entries, newPlan(stmt, tx.Catalog))
+		}
 	}
 
 	return nil
@@ -103,12 +124,8 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 	ctx := context.Ctx
 
 	for i, stmt := range q.Statements {
-		if ctx != nil {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			default:
-			}
+		if err := ctxErr(ctx); err != nil {
+			return nil, err
 		}
 		// reinitialize the result
 		res = statement.Result{}
@@ -125,21 +142,51 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 			continue
 		}
 
+		asOf, err := statement.AsOf(stmt, context.Params)
+		if err != nil {
+			return nil, err
+		}
+
 		if q.tx == nil {
 			q.tx, err = context.Conn.BeginTx(&database.TxOptions{
 				ReadOnly: stmt.IsReadOnly(),
+				AsOf:     asOf,
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else if !asOf.IsZero() {
+			return nil, errors.New("AS OF cannot be used within a transaction")
+		}
+
+		if q.plans != nil {
+			stmt, err = q.plans.get(i, stmt, &statement.Context{
+				DB:   context.DB,
+				Conn: context.Conn,
+				Tx:   q.tx,
 			})
 			if err != nil {
+				if q.autoCommit {
+					q.tx.Rollback()
+				}
+
 				return nil, err
 			}
 		}
 
-		res, err = stmt.Run(&statement.Context{
-			DB:     context.DB,
-			Conn:   context.Conn,
-			Tx:     q.tx,
-			Params: context.Params,
-		})
+		sctx := &statement.Context{
+			Ctx:      ctx,
+			Progress: context.Progress,
+			DB:       context.DB,
+			Conn:     context.Conn,
+			Tx:       q.tx,
+			Params:   context.Params,
+		}
+
+		err = statement.CheckPrivileges(sctx, stmt)
+		if err == nil {
+			res, err = stmt.Run(sctx)
+		}
 		if err != nil {
 			if q.autoCommit {
 				q.tx.Rollback()
@@ -192,3 +239,81 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 type queryAlterer interface {
 	alterQuery(conn *database.Connection, q *Query) error
 }
+
+// ctxErr returns the cause of the cancellation of the context,
+// or nil if the context is nil or was not canceled.
+func ctxErr(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	default:
+		return nil
+	}
+}
+
+// plans keeps track of the catalog with which each statement of a query
+// was prepared. A statement run with a different catalog, after a schema
+// change made by any connection, is parsed and prepared again, and the new
+// plan replaces the stale one.
+type plans struct {
+	mu      sync.Mutex
+	reparse func() ([]statement.Statement, error)
+	entries []plan
+}
+
+type plan struct {
+	stmt    statement.Statement
+	catalog *database.Catalog
+	version uint64
+}
+
+func newPlan(stmt statement.Statement, catalog *database.Catalog) plan {
+	return plan{
+		stmt:    stmt,
+		catalog: catalog,
+		version: catalog.Version(),
+	}
+}
+
+// get returns the plan of the i-th statement of the query for the transaction
+// of the context. stmt is returned as is if the statement wasn't prepared.
+func (p *plans) get(i int, stmt statement.Statement, ctx *statement.Context) (statement.Statement, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i >= len(p.entries) {
+		return stmt, nil
+	}
+
+	catalog := ctx.Tx.Catalog
+	e := p.entries[i]
+	if e.catalog == catalog && e.version == catalog.Version() {
+		return e.stmt, nil
+	}
+
+	statements, err := p.reparse()
+	if err != nil {
+		return nil, err
+	}
+	if i >= len(statements) {
+		return nil, errors.New("cannot prepare the statement again")
+	}
+
+	stmt = statements[i]
+	err = stmt.Bind(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prepared, err := stmt.(statement.Preparer).Prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.entries[i] = newPlan(prepared, catalog)
+	return prepared, nil
+}
@@ -0,0 +1,113 @@
+package query
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/types"
+)
+
+var _ queryAlterer = SetStmt{}
+
+// SetStmt is a statement that sets a setting of the connection,
+// registered with database.RegisterSetting.
+// Value is the value returned by the Parse function of the setting.
+// If Default is true, the setting is restored to its default value.
+// It doesn't implement the Preparer interface, so that the statements
+// following it are prepared with the new value.
+type SetStmt struct {
+	Name    string
+	Value   any
+	Default bool
+}
+
+func (stmt SetStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+func (stmt SetStmt) alterQuery(conn *database.Connection, q *Query) error {
+	return stmt.apply(conn)
+}
+
+func (stmt SetStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt SetStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, stmt.apply(ctx.Conn)
+}
+
+func (stmt SetStmt) apply(conn *database.Connection) error {
+	if stmt.Default {
+		return conn.SetSetting(stmt.Name, nil)
+	}
+
+	return conn.SetSetting(stmt.Name, stmt.Value)
+}
+
+// ShowStmt is a statement that returns the value of a setting
+// of the connection, in a column named after the setting.
+// If All is true, it returns the name, the value and the description
+// of every setting instead.
+type ShowStmt struct {
+	Name string
+	All  bool
+}
+
+func (stmt ShowStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+func (stmt ShowStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt ShowStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	var columns []string
+	var list []expr.Row
+
+	if stmt.All {
+		columns = []string{"name", "setting", "description"}
+		for _, s := range database.ListSettings() {
+			v, err := ctx.Conn.Setting(s.Name)
+			if err != nil {
+				return statement.Result{}, err
+			}
+
+			list = append(list, expr.Row{
+				Columns: columns,
+				Exprs: []expr.Expr{
+					expr.LiteralValue{Value: types.NewTextValue(s.Name)},
+					expr.LiteralValue{Value: types.NewTextValue(s.FormatValue(v))},
+					expr.LiteralValue{Value: types.NewTextValue(s.Description)},
+				},
+			})
+		}
+	} else {
+		v, err := ctx.Conn.Setting(stmt.Name)
+		if err != nil {
+			return statement.Result{}, err
+		}
+
+		s := database.LookupSetting(stmt.Name)
+		columns = []string{s.Name}
+		list = append(list, expr.Row{
+			Columns: columns,
+			Exprs:   []expr.Expr{expr.LiteralValue{Value: types.NewTextValue(s.FormatValue(v))}},
+		})
+	}
+
+	// the rows are projected to be returned as database rows
+	projected := make([]expr.Expr, len(columns))
+	for i, c := range columns {
+		projected[i] = &expr.NamedExpr{ExprName: c, Expr: &expr.Column{Name: c}}
+	}
+
+	st := statement.PreparedStreamStmt{
+		Stream:   stream.New(rows.Emit(columns, list...)).Pipe(rows.Project(projected...)),
+		ReadOnly: true,
+	}
+	return st.Run(ctx)
+}
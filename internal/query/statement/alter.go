@@ -1,16 +1,28 @@
 package statement
 
 import (
+	"fmt"
+	"math"
+	"strings"
+
 	"github.com/chaisql/chai/internal/database"
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/index"
+	"github.com/chaisql/chai/internal/stream/rows"
 	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
 var _ Statement = (*AlterTableRenameStmt)(nil)
 var _ Statement = (*AlterTableAddColumnStmt)(nil)
+var _ Statement = (*AlterSequenceStmt)(nil)
+var _ Statement = (*AlterTableSetRetentionStmt)(nil)
+var _ Statement = (*AlterTableAddPartitionStmt)(nil)
+var _ Statement = (*AlterTableDropPartitionStmt)(nil)
+var _ Statement = (*AlterTableInferSchemaStmt)(nil)
 
 // AlterTableRenameStmt is a DSL that allows creating a full ALTER TABLE query.
 type AlterTableRenameStmt struct {
@@ -40,11 +52,24 @@ func (stmt *AlterTableRenameStmt) Run(ctx *Context) (Result, error) {
 		return res, errors.New("missing new table name")
 	}
 
-	if stmt.TableName == stmt.NewTableName {
-		return res, errs.AlreadyExistsError{Name: stmt.NewTableName}
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	// the table stays in its schema
+	schema := database.SchemaOf(tableName)
+	newSchema, newName := database.SplitQualifiedName(stmt.NewTableName)
+	if newSchema != "" && newSchema != schema {
+		return res, errors.Errorf("cannot move table %s to schema %s", tableName, newSchema)
+	}
+	newTableName := database.QualifiedName(schema, newName)
+
+	if tableName == newTableName {
+		return res, errs.AlreadyExistsError{Name: newTableName}
 	}
 
-	err := ctx.Tx.CatalogWriter().RenameTable(ctx.Tx, stmt.TableName, stmt.NewTableName)
+	err = ctx.Tx.CatalogWriter().RenameTable(ctx.Tx, tableName, newTableName)
 	return res, err
 }
 
@@ -67,24 +92,33 @@ func (stmt *AlterTableAddColumnStmt) Bind(ctx *Context) error {
 // It implements the Statement interface.
 // The statement rebuilds the table.
 func (stmt *AlterTableAddColumnStmt) Run(ctx *Context) (Result, error) {
-	var err error
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return Result{}, err
+	}
 
 	// get the table before adding the column constraint
 	// and assign the table to the table.Scan operator
 	// so that it can decode the records properly
-	scan := table.Scan(stmt.TableName)
-	scan.Table, err = ctx.Tx.Catalog.GetTable(ctx.Tx, stmt.TableName)
+	scan := table.Scan(tableName)
+	scan.Table, err = ctx.Tx.Catalog.GetTable(ctx.Tx, tableName)
 	if err != nil {
 		return Result{}, errors.Wrap(err, "failed to get table")
 	}
 
 	// get the current list of indexes
-	indexNames := ctx.Tx.Catalog.ListIndexes(stmt.TableName)
+	indexNames := ctx.Tx.Catalog.ListIndexes(tableName)
+
+	// existing rows are rebuilt with the next values of the sequence
+	err = createAutoIncrementSequence(ctx, scan.Table.Info, stmt.ColumnConstraint)
+	if err != nil {
+		return Result{}, err
+	}
 
 	// add the column constraint to the table
 	err = ctx.Tx.CatalogWriter().AddColumnConstraint(
 		ctx.Tx,
-		stmt.TableName,
+		tableName,
 		stmt.ColumnConstraint,
 		stmt.TableConstraints)
 	if err != nil {
@@ -100,7 +134,7 @@ func (stmt *AlterTableAddColumnStmt) Run(ctx *Context) (Result, error) {
 				Columns: tc.Columns,
 				Unique:  true,
 				Owner: database.Owner{
-					TableName: stmt.TableName,
+					TableName: tableName,
 					Columns:   tc.Columns,
 				},
 			})
@@ -129,16 +163,16 @@ func (stmt *AlterTableAddColumnStmt) Run(ctx *Context) (Result, error) {
 			s = s.Pipe(index.Delete(indexName))
 		}
 		// delete the old records from the table
-		s = s.Pipe(table.Delete(stmt.TableName))
+		s = s.Pipe(table.Delete(tableName))
 
 		// validate the record against the new schema
-		s = s.Pipe(table.Validate(stmt.TableName))
+		s = s.Pipe(table.Validate(tableName))
 
 		// insert the record with the new primary key
-		s = s.Pipe(table.Insert(stmt.TableName))
+		s = s.Pipe(table.Insert(tableName))
 
 		// insert the record into the all the indexes
-		indexNames = ctx.Tx.Catalog.ListIndexes(stmt.TableName)
+		indexNames = ctx.Tx.Catalog.ListIndexes(tableName)
 		for _, indexName := range indexNames {
 			info, err := ctx.Tx.Catalog.GetIndexInfo(indexName)
 			if err != nil {
@@ -154,10 +188,10 @@ func (stmt *AlterTableAddColumnStmt) Run(ctx *Context) (Result, error) {
 		// otherwise, we can just replace the old records with the new ones
 
 		// validate the record against the new schema
-		s = s.Pipe(table.Validate(stmt.TableName))
+		s = s.Pipe(table.Validate(tableName))
 
 		// replace the old record with the new one
-		s = s.Pipe(table.Replace(stmt.TableName))
+		s = s.Pipe(table.Replace(tableName))
 
 		// update the new indexes only
 		for _, idx := range newIdxs {
@@ -180,3 +214,375 @@ func (stmt *AlterTableAddColumnStmt) Run(ctx *Context) (Result, error) {
 		},
 	}, nil
 }
+
+// AlterTableSetRetentionStmt sets or removes the retention policy of a table.
+type AlterTableSetRetentionStmt struct {
+	TableName string
+	// If nil, the retention policy is removed.
+	Policy *database.RetentionPolicy
+}
+
+func (stmt *AlterTableSetRetentionStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *AlterTableSetRetentionStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *AlterTableSetRetentionStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	err = ctx.Tx.CatalogWriter().SetRetention(ctx.Tx, tableName, stmt.Policy)
+	return res, err
+}
+
+// AlterTableAddPartitionStmt adds a partition to a table partitioned by range.
+type AlterTableAddPartitionStmt struct {
+	TableName string
+	Partition database.TablePartition
+}
+
+func (stmt *AlterTableAddPartitionStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *AlterTableAddPartitionStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *AlterTableAddPartitionStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	err = ctx.Tx.CatalogWriter().AddPartition(ctx.Tx, tableName, stmt.Partition)
+	return res, err
+}
+
+// AlterTableDropPartitionStmt drops a partition of a table partitioned by range,
+// along with its rows.
+type AlterTableDropPartitionStmt struct {
+	TableName     string
+	PartitionName string
+}
+
+func (stmt *AlterTableDropPartitionStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *AlterTableDropPartitionStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *AlterTableDropPartitionStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	err = ctx.Tx.CatalogWriter().DropPartition(ctx.Tx, tableName, stmt.PartitionName)
+	return res, err
+}
+
+// AlterTableInferSchemaStmt infers the type of the TEXT columns of a table
+// from their values and, unless DryRun is set, changes the type of the
+// columns whose values can all be converted to the inferred type.
+// It returns a row per column describing the inferred type and the rows
+// that don't conform to it.
+type AlterTableInferSchemaStmt struct {
+	TableName string
+	DryRun    bool
+}
+
+func (stmt *AlterTableInferSchemaStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// IsReadOnly returns true if the table is not modified.
+// It implements the Statement interface.
+func (stmt *AlterTableInferSchemaStmt) IsReadOnly() bool {
+	return stmt.DryRun
+}
+
+// Run runs the ALTER TABLE INFER SCHEMA statement in the given transaction.
+// It implements the Statement interface.
+// If the type of a column changes, the statement rebuilds the table.
+func (stmt *AlterTableInferSchemaStmt) Run(ctx *Context) (Result, error) {
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return Result{}, err
+	}
+
+	tb, err := ctx.Tx.Catalog.GetTable(ctx.Tx, tableName)
+	if err != nil {
+		return Result{}, err
+	}
+	if tb.Info.Virtual != nil {
+		return Result{}, errors.New("cannot infer the schema of a virtual table")
+	}
+	if !stmt.DryRun {
+		err = tb.Info.CheckWritable()
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	inferred, err := tb.InferColumnTypes()
+	if err != nil {
+		return Result{}, err
+	}
+
+	columnTypes := make(map[string]types.Type)
+	for i := range inferred {
+		if inferred[i].Conforms() {
+			columnTypes[inferred[i].Column] = inferred[i].Type
+		}
+	}
+
+	altered := !stmt.DryRun && len(columnTypes) > 0
+	if altered {
+		err = rebuildWithColumnTypes(ctx, tb, columnTypes)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	columns := []string{"column_name", "inferred_type", "non_null", "nonconforming", "nonconforming_keys", "altered"}
+	list := make([]expr.Row, 0, len(inferred))
+	for _, ic := range inferred {
+		var keys types.Value = types.NewNullValue()
+		if len(ic.NonconformingKeys) > 0 {
+			keys = types.NewTextValue(strings.Join(ic.NonconformingKeys, ", "))
+		}
+
+		_, ok := columnTypes[ic.Column]
+		list = append(list, expr.Row{
+			Columns: columns,
+			Exprs: []expr.Expr{
+				expr.LiteralValue{Value: types.NewTextValue(ic.Column)},
+				expr.LiteralValue{Value: types.NewTextValue(strings.ToUpper(ic.Type.String()))},
+				expr.LiteralValue{Value: types.NewBigintValue(ic.NonNull)},
+				expr.LiteralValue{Value: types.NewBigintValue(ic.Nonconforming)},
+				expr.LiteralValue{Value: keys},
+				expr.LiteralValue{Value: types.NewBooleanValue(altered && ok)},
+			},
+		})
+	}
+
+	// the rows are projected to be returned as database rows
+	projected := make([]expr.Expr, len(columns))
+	for i, c := range columns {
+		projected[i] = &expr.NamedExpr{ExprName: c, Expr: &expr.Column{Name: c}}
+	}
+
+	st := PreparedStreamStmt{
+		Stream:   stream.New(rows.Emit(columns, list...)).Pipe(rows.Project(projected...)),
+		ReadOnly: true,
+	}
+	return st.Run(ctx)
+}
+
+// rebuildWithColumnTypes changes the type of columns of the table
+// and rewrites its rows and the entries of its indexes.
+func rebuildWithColumnTypes(ctx *Context, tb *database.Table, columnTypes map[string]types.Type) error {
+	tableName := tb.Info.TableName
+
+	// the keys of the rows change with the type of the primary key
+	pkAltered := false
+	if pk := tb.Info.PrimaryKey; pk != nil {
+		for _, c := range pk.Columns {
+			if _, ok := columnTypes[c]; ok {
+				pkAltered = true
+			}
+		}
+	}
+
+	// the entries of the indexes are rebuilt from the new rows,
+	// the old ones cannot be decoded once the catalog is modified
+	indexNames := ctx.Tx.Catalog.ListIndexes(tableName)
+	for _, indexName := range indexNames {
+		idx, err := ctx.Tx.Catalog.GetIndex(ctx.Tx, indexName)
+		if err != nil {
+			return err
+		}
+
+		err = idx.Truncate()
+		if err != nil {
+			return err
+		}
+	}
+
+	// scan the table with the old schema
+	scan := table.Scan(tableName)
+	scan.Table = tb
+
+	err := ctx.Tx.CatalogWriter().AlterColumnTypes(ctx.Tx, tableName, columnTypes)
+	if err != nil {
+		return err
+	}
+
+	s := stream.New(scan)
+	if pkAltered {
+		s = s.Pipe(table.Delete(tableName))
+	}
+
+	// convert the values to the new types
+	s = s.Pipe(table.Validate(tableName))
+
+	if pkAltered {
+		s = s.Pipe(table.Insert(tableName))
+	} else {
+		s = s.Pipe(table.Replace(tableName))
+	}
+
+	for _, indexName := range indexNames {
+		info, err := ctx.Tx.Catalog.GetIndexInfo(indexName)
+		if err != nil {
+			return err
+		}
+		if info.Unique {
+			s = s.Pipe(index.Validate(indexName))
+		}
+
+		s = s.Pipe(index.Insert(indexName))
+	}
+
+	it := StreamStmtIterator{
+		Stream:  s.Pipe(stream.Discard()),
+		Context: ctx,
+	}
+	return it.Iterate(func(database.Row) error {
+		return nil
+	})
+}
+
+// AlterSequenceStmt is a DSL that allows creating a full ALTER SEQUENCE query.
+// Options that are not set keep their current value.
+type AlterSequenceStmt struct {
+	SequenceName string
+	IfExists     bool
+
+	IncrementBy *int64
+	Min, Max    *int64
+	// NoMin and NoMax reset the bounds to their default value,
+	// which depends on the direction of the sequence.
+	NoMin, NoMax bool
+	Start        *int64
+	Cache        *uint64
+	Cycle        *bool
+
+	// Restart the sequence at RestartWith, or at its start value if nil.
+	Restart     bool
+	RestartWith *int64
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *AlterSequenceStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *AlterSequenceStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run runs the ALTER SEQUENCE statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *AlterSequenceStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.SequenceName == "" {
+		return res, errors.New("missing sequence name")
+	}
+
+	var seq *database.Sequence
+	seqName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationSequenceType, stmt.SequenceName)
+	if err == nil {
+		seq, err = ctx.Tx.Catalog.GetSequence(seqName)
+	}
+	if err != nil {
+		if errs.IsNotFoundError(err) && stmt.IfExists {
+			err = nil
+		}
+		return res, err
+	}
+
+	if seq.Info.Owner.TableName != "" {
+		return res, fmt.Errorf("cannot alter sequence %s because it is owned by table %s", seq.Info.Name, seq.Info.Owner.TableName)
+	}
+
+	info := seq.Info.Clone()
+	if stmt.IncrementBy != nil {
+		info.IncrementBy = *stmt.IncrementBy
+	}
+	asc := info.IncrementBy > 0
+
+	switch {
+	case stmt.Min != nil:
+		info.Min = *stmt.Min
+	case stmt.NoMin && asc:
+		info.Min = 1
+	case stmt.NoMin:
+		info.Min = math.MinInt64
+	}
+
+	switch {
+	case stmt.Max != nil:
+		info.Max = *stmt.Max
+	case stmt.NoMax && asc:
+		info.Max = math.MaxInt64
+	case stmt.NoMax:
+		info.Max = -1
+	}
+
+	if stmt.Start != nil {
+		info.Start = *stmt.Start
+	}
+	if stmt.Cache != nil {
+		info.Cache = *stmt.Cache
+	}
+	if stmt.Cycle != nil {
+		info.Cycle = *stmt.Cycle
+	}
+
+	if info.Min > info.Max {
+		return res, fmt.Errorf("MINVALUE (%d) must be less than MAXVALUE (%d)", info.Min, info.Max)
+	}
+	if info.Start < info.Min {
+		return res, fmt.Errorf("START value (%d) cannot be less than MINVALUE (%d)", info.Start, info.Min)
+	}
+	if info.Start > info.Max {
+		return res, fmt.Errorf("START value (%d) cannot be greater than MAXVALUE (%d)", info.Start, info.Max)
+	}
+
+	var restart *int64
+	if stmt.Restart {
+		v := info.Start
+		if stmt.RestartWith != nil {
+			v = *stmt.RestartWith
+		}
+		restart = &v
+	}
+
+	err = ctx.Tx.CatalogWriter().AlterSequence(ctx.Tx, info, restart)
+	return res, err
+}
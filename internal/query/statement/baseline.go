@@ -0,0 +1,121 @@
+package statement
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
+	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/cockroachdb/errors"
+)
+
+// PlanIndexes returns the index used to read each table by an optimized stream,
+// by table name. Tables read without index are mapped to an empty string.
+// It returns an error if a table is read with different indexes.
+func PlanIndexes(ctx *Context, s *stream.Stream) (map[string]string, error) {
+	indexes := make(map[string]string)
+	err := planIndexes(ctx, s, indexes)
+	return indexes, err
+}
+
+func planIndexes(ctx *Context, s *stream.Stream, indexes map[string]string) error {
+	if s == nil {
+		return nil
+	}
+
+	read := func(tableName, indexName string) error {
+		if other, ok := indexes[tableName]; ok && other != indexName {
+			return errors.Errorf("table %s is read in different ways by the plan", tableName)
+		}
+		indexes[tableName] = indexName
+		return nil
+	}
+
+	readIndex := func(indexName string) error {
+		info, err := ctx.Tx.Catalog.GetIndexInfo(indexName)
+		if err != nil {
+			return err
+		}
+		return read(info.Owner.TableName, indexName)
+	}
+
+	for op := s.First(); op != nil; op = op.GetNext() {
+		var err error
+
+		switch t := op.(type) {
+		case *table.ScanOperator:
+			err = read(t.TableName, "")
+		case *index.ScanOperator:
+			err = readIndex(t.IndexName)
+		case *index.VectorScanOperator:
+			err = readIndex(t.IndexName)
+		default:
+			for _, sub := range subStreams(op) {
+				if err = planIndexes(ctx, sub, indexes); err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyBaseline sets the hints of the scans of the stream so that
+// the tables are read as pinned by the baseline.
+// Scans with optimizer hints are left unchanged, and so are the tables
+// whose pinned index was dropped since: they are planned as usual.
+func applyBaseline(ctx *Context, b *database.PlanBaseline, s *stream.Stream) {
+	if s == nil {
+		return
+	}
+
+	for op := s.First(); op != nil; op = op.GetNext() {
+		scan, ok := op.(*table.ScanOperator)
+		if !ok {
+			for _, sub := range subStreams(op) {
+				applyBaseline(ctx, b, sub)
+			}
+			continue
+		}
+
+		indexName, ok := b.Indexes[scan.TableName]
+		if !ok || scan.Hint != nil {
+			continue
+		}
+
+		if indexName == "" {
+			scan.Hint = &table.IndexHint{NoIndex: true}
+			continue
+		}
+
+		info, err := ctx.Tx.Catalog.GetIndexInfo(indexName)
+		if err != nil || info.Owner.TableName != scan.TableName {
+			continue
+		}
+		scan.Hint = &table.IndexHint{Index: indexName}
+	}
+}
+
+// subStreams returns the streams read by an operator, if any.
+func subStreams(op stream.Operator) []*stream.Stream {
+	switch t := op.(type) {
+	case *stream.UnionOperator:
+		return t.Streams
+	case *stream.ConcatOperator:
+		return t.Streams
+	case *stream.IntersectOperator:
+		return t.Streams
+	case *stream.ExceptOperator:
+		return t.Streams
+	case *stream.JoinOperator:
+		return []*stream.Stream{t.Stream}
+	case *stream.OnConflictOperator:
+		return []*stream.Stream{t.OnConflict}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package statement
+
+import (
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
+)
+
+var _ Statement = (*CheckIndexStmt)(nil)
+
+// CheckIndexStmt is a DSL that allows creating a full CHECK INDEX statement.
+// It verifies that the indexes match the rows of their tables
+// and returns a row for every mismatch.
+type CheckIndexStmt struct {
+	basePreparedStatement
+
+	TableOrIndexName string
+}
+
+func NewCheckIndexStatement() *CheckIndexStmt {
+	var p CheckIndexStmt
+
+	p.basePreparedStatement = basePreparedStatement{
+		Preparer: &p,
+		ReadOnly: true,
+	}
+
+	return &p
+}
+
+func (stmt *CheckIndexStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Prepare implements the Preparer interface.
+func (stmt *CheckIndexStmt) Prepare(ctx *Context) (Statement, error) {
+	indexNames, err := resolveIndexNames(ctx, stmt.TableOrIndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	streams := make([]*stream.Stream, len(indexNames))
+	for i, indexName := range indexNames {
+		streams[i] = stream.New(index.Check(indexName))
+	}
+
+	st := StreamStmt{
+		Stream:   stream.New(stream.Concat(streams...)),
+		ReadOnly: true,
+	}
+
+	return st.Prepare(ctx)
+}
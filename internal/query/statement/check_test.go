@@ -0,0 +1,87 @@
+package statement_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIndex(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test(a INT PRIMARY KEY, b INT, c TEXT);
+		CREATE INDEX idx_b ON test(b);
+		CREATE INDEX idx_c ON test(c);
+		CREATE TABLE other(a INT);
+		CREATE INDEX idx_other_a ON other(a);
+
+		INSERT INTO test(a, b, c) VALUES (1, 10, 'x'), (2, 20, 'y'), (3, 30, 'z');
+		INSERT INTO other(a) VALUES (1);
+	`)
+
+	check := func(q string) []string {
+		t.Helper()
+
+		res := testutil.MustQuery(t, db, tx, q)
+		defer res.Close()
+
+		var got []string
+		err := res.Iterate(func(r database.Row) error {
+			var s string
+			for _, c := range []string{"index_name", "problem", "key", "values"} {
+				v, err := r.Get(c)
+				if err != nil {
+					return err
+				}
+				s += types.AsString(v) + " "
+			}
+			got = append(got, s[:len(s)-1])
+			return nil
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	require.Empty(t, check("CHECK INDEX"))
+
+	// remove the entry of the second row
+	// and add an entry without row
+	idx, err := tx.Catalog.GetIndex(tx, "idx_b")
+	require.NoError(t, err)
+	info, err := tx.Catalog.GetTableInfo("test")
+	require.NoError(t, err)
+	pk := func(a int64) []byte {
+		enc, err := info.EncodeKey(tree.NewKey(types.NewBigintValue(a)))
+		require.NoError(t, err)
+		return enc
+	}
+	require.NoError(t, idx.Delete([]types.Value{types.NewBigintValue(20)}, pk(2)))
+	require.NoError(t, idx.Set([]types.Value{types.NewBigintValue(25)}, pk(4)))
+	// stale value
+	require.NoError(t, idx.Delete([]types.Value{types.NewBigintValue(30)}, pk(3)))
+	require.NoError(t, idx.Set([]types.Value{types.NewBigintValue(5)}, pk(3)))
+
+	want := []string{
+		"idx_b orphan (3) (5)",
+		"idx_b missing (2) (20)",
+		"idx_b orphan (4) (25)",
+		"idx_b missing (3) (30)",
+	}
+	require.Equal(t, want, check("CHECK INDEX"))
+	require.Equal(t, want, check("CHECK INDEX test"))
+	require.Equal(t, want, check("CHECK INDEX idx_b"))
+	require.Empty(t, check("CHECK INDEX idx_c"))
+	require.Empty(t, check("CHECK INDEX other"))
+
+	err = testutil.Exec(db, tx, "CHECK INDEX doesntexist")
+	require.Error(t, err)
+
+	testutil.MustExec(t, db, tx, "REINDEX idx_b")
+	require.Empty(t, check("CHECK INDEX"))
+}
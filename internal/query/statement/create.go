@@ -2,17 +2,24 @@ package statement
 
 import (
 	"math"
+	"slices"
 
 	"github.com/chaisql/chai/internal/database"
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/index"
 	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
 )
 
 var _ Statement = (*CreateTableStmt)(nil)
 var _ Statement = (*CreateIndexStmt)(nil)
 var _ Statement = (*CreateSequenceStmt)(nil)
+var _ Statement = (*CreateSchemaStmt)(nil)
+var _ Statement = (*CreateFunctionStmt)(nil)
 
 // CreateTableStmt represents a parsed CREATE TABLE statement.
 type CreateTableStmt struct {
@@ -34,6 +41,34 @@ func (stmt *CreateTableStmt) Bind(ctx *Context) error {
 func (stmt *CreateTableStmt) Run(ctx *Context) (Result, error) {
 	var res Result
 
+	// temporary tables don't belong to any schema
+	if stmt.Info.Temporary {
+		if schema, _ := database.SplitQualifiedName(stmt.Info.TableName); schema != "" {
+			return res, errors.Errorf("cannot create temporary table %s in schema %s", stmt.Info.TableName, schema)
+		}
+	} else {
+		name, err := ctx.Tx.Catalog.NewRelationName(ctx.Tx, stmt.Info.TableName)
+		if err != nil {
+			return res, err
+		}
+		stmt.Info.TableName = name
+	}
+
+	// ensure no sequence is created if the table already exists
+	if stmt.IfNotExists {
+		if _, err := ctx.Tx.Catalog.GetTableInfo(stmt.Info.TableName); err == nil {
+			return res, nil
+		}
+	}
+
+	// create a sequence for every auto-incremented column
+	for _, cc := range stmt.Info.ColumnConstraints.Ordered {
+		err := createAutoIncrementSequence(ctx, &stmt.Info, cc)
+		if err != nil {
+			return res, err
+		}
+	}
+
 	// if there is no primary key, create a rowid sequence
 	if stmt.Info.PrimaryKey == nil {
 		seq := database.SequenceInfo{
@@ -44,6 +79,7 @@ func (stmt *CreateTableStmt) Run(ctx *Context) (Result, error) {
 			Owner: database.Owner{
 				TableName: stmt.Info.TableName,
 			},
+			Temporary: stmt.Info.Temporary,
 		}
 		err := ctx.Tx.CatalogWriter().CreateSequence(ctx.Tx, &seq)
 		if err != nil {
@@ -81,6 +117,39 @@ func (stmt *CreateTableStmt) Run(ctx *Context) (Result, error) {
 	return res, err
 }
 
+// createAutoIncrementSequence creates the sequence generating the values
+// of an auto-incremented column and sets it as the default value of the column.
+// The sequence is owned by the column and dropped with the table.
+func createAutoIncrementSequence(ctx *Context, info *database.TableInfo, cc *database.ColumnConstraint) error {
+	if !cc.AutoIncrement || cc.DefaultValue != nil {
+		return nil
+	}
+
+	var max int64 = math.MaxInt64
+	if cc.Type == types.TypeInteger {
+		max = math.MaxInt32
+	}
+
+	seq := database.SequenceInfo{
+		IncrementBy: 1,
+		Min:         1, Max: max,
+		Start: 1,
+		Cache: 64,
+		Owner: database.Owner{
+			TableName: info.TableName,
+			Columns:   []string{cc.Column},
+		},
+		Temporary: info.Temporary,
+	}
+	err := ctx.Tx.CatalogWriter().CreateSequence(ctx.Tx, &seq)
+	if err != nil {
+		return err
+	}
+
+	cc.DefaultValue = expr.Constraint(expr.NextValueFor{SeqName: seq.Name})
+	return nil
+}
+
 // CreateIndexStmt represents a parsed CREATE INDEX statement.
 type CreateIndexStmt struct {
 	IfNotExists bool
@@ -101,7 +170,12 @@ func (stmt *CreateIndexStmt) Bind(ctx *Context) error {
 func (stmt *CreateIndexStmt) Run(ctx *Context) (Result, error) {
 	var res Result
 
-	_, err := ctx.Tx.CatalogWriter().CreateIndex(ctx.Tx, &stmt.Info)
+	err := stmt.resolveNames(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	_, err = ctx.Tx.CatalogWriter().CreateIndex(ctx.Tx, &stmt.Info)
 	if stmt.IfNotExists {
 		if errs.IsAlreadyExistsError(err) {
 			return res, nil
@@ -112,7 +186,7 @@ func (stmt *CreateIndexStmt) Run(ctx *Context) (Result, error) {
 	}
 
 	s := stream.New(table.Scan(stmt.Info.Owner.TableName)).
-		Pipe(index.Insert(stmt.Info.IndexName)).
+		Pipe(index.Build(stmt.Info.IndexName)).
 		Pipe(stream.Discard())
 
 	ss := PreparedStreamStmt{
@@ -123,6 +197,29 @@ func (stmt *CreateIndexStmt) Run(ctx *Context) (Result, error) {
 	return ss.Run(ctx)
 }
 
+// resolveNames resolves the name of the indexed table.
+// Indexes are always created in the schema of their table.
+func (stmt *CreateIndexStmt) resolveNames(ctx *Context) error {
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.Info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+	stmt.Info.Owner.TableName = tableName
+
+	if stmt.Info.IndexName == "" {
+		return nil
+	}
+
+	schema, name := database.SplitQualifiedName(stmt.Info.IndexName)
+	tableSchema := database.SchemaOf(tableName)
+	if schema != "" && schema != tableSchema {
+		return errors.Errorf("index %s must be created in the schema of table %s", stmt.Info.IndexName, tableName)
+	}
+
+	stmt.Info.IndexName = database.QualifiedName(tableSchema, name)
+	return nil
+}
+
 // CreateSequenceStmt represents a parsed CREATE SEQUENCE statement.
 type CreateSequenceStmt struct {
 	IfNotExists bool
@@ -143,7 +240,42 @@ func (stmt *CreateSequenceStmt) Bind(ctx *Context) error {
 func (stmt *CreateSequenceStmt) Run(ctx *Context) (Result, error) {
 	var res Result
 
-	err := ctx.Tx.CatalogWriter().CreateSequence(ctx.Tx, &stmt.Info)
+	name, err := ctx.Tx.Catalog.NewRelationName(ctx.Tx, stmt.Info.Name)
+	if err != nil {
+		return res, err
+	}
+	stmt.Info.Name = name
+
+	err = ctx.Tx.CatalogWriter().CreateSequence(ctx.Tx, &stmt.Info)
+	if stmt.IfNotExists {
+		if errs.IsAlreadyExistsError(err) {
+			return res, nil
+		}
+	}
+	return res, err
+}
+
+// CreateSchemaStmt represents a parsed CREATE SCHEMA statement.
+type CreateSchemaStmt struct {
+	IfNotExists bool
+	Info        database.SchemaInfo
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *CreateSchemaStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *CreateSchemaStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run the statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *CreateSchemaStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	err := ctx.Tx.CatalogWriter().CreateSchema(ctx.Tx, &stmt.Info)
 	if stmt.IfNotExists {
 		if errs.IsAlreadyExistsError(err) {
 			return res, nil
@@ -151,3 +283,72 @@ func (stmt *CreateSequenceStmt) Run(ctx *Context) (Result, error) {
 	}
 	return res, err
 }
+
+// CreateFunctionStmt represents a parsed CREATE FUNCTION statement.
+type CreateFunctionStmt struct {
+	IfNotExists bool
+	Info        database.FunctionInfo
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *CreateFunctionStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *CreateFunctionStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run the statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *CreateFunctionStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if _, err := functions.GetFunc(stmt.Info.Name); err == nil {
+		return res, errors.Errorf("cannot redefine builtin function %s", stmt.Info.Name)
+	}
+
+	if stmt.IfNotExists {
+		if _, err := ctx.Tx.Catalog.GetFunction(stmt.Info.Name); err == nil {
+			return res, nil
+		}
+	}
+
+	err := validateFunctionBody(ctx, &stmt.Info)
+	if err != nil {
+		return res, err
+	}
+
+	err = ctx.Tx.CatalogWriter().CreateFunction(ctx.Tx, &stmt.Info)
+	if stmt.IfNotExists {
+		if errs.IsAlreadyExistsError(err) {
+			return res, nil
+		}
+	}
+	return res, err
+}
+
+// validateFunctionBody ensures that the body of the function only refers
+// to its parameters and to existing functions.
+// As a function can only call functions created before it,
+// calls can never be recursive.
+func validateFunctionBody(ctx *Context, info *database.FunctionInfo) (err error) {
+	expr.Walk(info.Body.(expr.Expr), func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case *expr.Column:
+			if !slices.Contains(info.Params, t.Name) {
+				err = errors.Errorf("column %s is not a parameter of function %s", t.Name, info.Name)
+			}
+		case expr.PositionalParam, expr.NamedParam:
+			err = errors.Errorf("parameters are not allowed in the body of function %s", info.Name)
+		case expr.AggregatorBuilder:
+			err = errors.Errorf("aggregate functions are not allowed in the body of function %s", info.Name)
+		case *functions.UserFunction:
+			err = t.Bind(ctx.Tx.Catalog)
+		}
+
+		return err == nil
+	})
+
+	return err
+}
@@ -1,12 +1,13 @@
 package statement
 
 import (
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
-	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/index"
 	"github.com/chaisql/chai/internal/stream/rows"
 	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/cockroachdb/errors"
 )
 
 var _ Statement = (*DeleteStmt)(nil)
@@ -15,12 +16,21 @@ var _ Statement = (*DeleteStmt)(nil)
 type DeleteStmt struct {
 	basePreparedStatement
 
-	TableName        string
-	WhereExpr        expr.Expr
-	OffsetExpr       expr.Expr
-	OrderBy          *expr.Column
-	LimitExpr        expr.Expr
-	OrderByDirection scanner.Token
+	TableName string
+	// UsingTable is the table joined with the table
+	// in the USING clause, if any.
+	UsingTable string
+	WhereExpr  expr.Expr
+	OffsetExpr expr.Expr
+	OrderBy    []rows.SortKey
+	LimitExpr  expr.Expr
+
+	// Hints override the choices of the planner.
+	Hints []OptimizerHint
+
+	// Fingerprint identifies the statements that only differ by their
+	// literals and parameters, which are run with the same plan baseline.
+	Fingerprint string
 }
 
 func NewDeleteStatement() *DeleteStmt {
@@ -35,7 +45,12 @@ func NewDeleteStatement() *DeleteStmt {
 }
 
 func (stmt *DeleteStmt) Bind(ctx *Context) error {
-	err := BindExpr(ctx, stmt.TableName, stmt.WhereExpr)
+	tables := []string{stmt.TableName}
+	if stmt.UsingTable != "" {
+		tables = append(tables, stmt.UsingTable)
+	}
+
+	err := bindExpr(ctx, stmt.WhereExpr, tables...)
 	if err != nil {
 		return err
 	}
@@ -45,9 +60,11 @@ func (stmt *DeleteStmt) Bind(ctx *Context) error {
 		return err
 	}
 
-	err = BindExpr(ctx, stmt.TableName, stmt.OrderBy)
-	if err != nil {
-		return err
+	for _, k := range stmt.OrderBy {
+		err = BindExpr(ctx, stmt.TableName, k.Expr)
+		if err != nil {
+			return err
+		}
 	}
 
 	err = BindExpr(ctx, stmt.TableName, stmt.LimitExpr)
@@ -59,18 +76,42 @@ func (stmt *DeleteStmt) Bind(ctx *Context) error {
 }
 
 func (stmt *DeleteStmt) Prepare(c *Context) (Statement, error) {
-	s := stream.New(table.Scan(stmt.TableName))
+	tableName, err := c.Tx.Catalog.ResolveName(c.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	ti, err := c.Tx.Catalog.GetTableInfo(tableName)
+	if err != nil {
+		return nil, err
+	}
 
-	if stmt.WhereExpr != nil {
-		s = s.Pipe(rows.Filter(stmt.WhereExpr))
+	scan, err := scanWithHints(c, stmt.Hints, tableName)
+	if err != nil {
+		return nil, err
 	}
+	s := stream.New(scan)
 
-	if stmt.OrderBy != nil {
-		if stmt.OrderByDirection == scanner.DESC {
-			s = s.Pipe(rows.TempTreeSortReverse(stmt.OrderBy))
-		} else {
-			s = s.Pipe(rows.TempTreeSort(stmt.OrderBy))
+	if stmt.UsingTable != "" {
+		// the joined row is not kept by the sort
+		if len(stmt.OrderBy) > 0 || stmt.OffsetExpr != nil || stmt.LimitExpr != nil {
+			return nil, errors.New("ORDER BY, LIMIT and OFFSET cannot be used with USING")
+		}
+
+		s, err = pipeJoin(c, s, ti, stmt.UsingTable, stmt.WhereExpr, stmt.Hints)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if stmt.WhereExpr != nil {
+			s = s.Pipe(rows.Filter(stmt.WhereExpr))
 		}
+
+		s = pipeTTLFilter(s, ti)
+	}
+
+	if len(stmt.OrderBy) > 0 {
+		s = s.Pipe(rows.TempTreeSortBy(stmt.OrderBy...))
 	}
 
 	if stmt.OffsetExpr != nil {
@@ -81,18 +122,19 @@ func (stmt *DeleteStmt) Prepare(c *Context) (Statement, error) {
 		s = s.Pipe(rows.Take(stmt.LimitExpr))
 	}
 
-	indexNames := c.Tx.Catalog.ListIndexes(stmt.TableName)
+	indexNames := c.Tx.Catalog.ListIndexes(tableName)
 	for _, indexName := range indexNames {
 		s = s.Pipe(index.Delete(indexName))
 	}
 
-	s = s.Pipe(table.Delete(stmt.TableName))
+	s = s.Pipe(table.Delete(tableName))
 
 	s = s.Pipe(stream.Discard())
 
 	st := StreamStmt{
-		Stream:   s,
-		ReadOnly: false,
+		Stream:      s,
+		ReadOnly:    false,
+		Fingerprint: stmt.Fingerprint,
 	}
 
 	return st.Prepare(c)
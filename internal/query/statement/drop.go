@@ -2,14 +2,20 @@ package statement
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/chaisql/chai/internal/database"
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/cockroachdb/errors"
 )
 
 var _ Statement = (*DropTableStmt)(nil)
 var _ Statement = (*DropIndexStmt)(nil)
 var _ Statement = (*DropSequenceStmt)(nil)
+var _ Statement = (*DropSchemaStmt)(nil)
+var _ Statement = (*DropFunctionStmt)(nil)
 
 // DropTableStmt is a DSL that allows creating a DROP TABLE query.
 type DropTableStmt struct {
@@ -35,7 +41,11 @@ func (stmt *DropTableStmt) Run(ctx *Context) (Result, error) {
 		return res, errors.New("missing table name")
 	}
 
-	tb, err := ctx.Tx.Catalog.GetTable(ctx.Tx, stmt.TableName)
+	var tb *database.Table
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.TableName)
+	if err == nil {
+		tb, err = ctx.Tx.Catalog.GetTable(ctx.Tx, tableName)
+	}
 	if err != nil {
 		if errs.IsNotFoundError(err) && stmt.IfExists {
 			err = nil
@@ -44,20 +54,42 @@ func (stmt *DropTableStmt) Run(ctx *Context) (Result, error) {
 		return res, err
 	}
 
-	err = ctx.Tx.CatalogWriter().DropTable(ctx.Tx, stmt.TableName)
+	return res, dropTable(ctx, tb.Info)
+}
+
+// dropTable drops the table, its indexes and the sequences it owns.
+func dropTable(ctx *Context, info *database.TableInfo) error {
+	err := ctx.Tx.CatalogWriter().DropTable(ctx.Tx, info.TableName)
 	if err != nil {
-		return res, err
+		return err
 	}
 
 	// if there is no primary key, drop the rowid sequence
-	if tb.Info.PrimaryKey == nil {
-		err = ctx.Tx.CatalogWriter().DropSequence(ctx.Tx, tb.Info.RowidSequenceName)
+	if info.PrimaryKey == nil {
+		err = ctx.Tx.CatalogWriter().DropSequence(ctx.Tx, info.RowidSequenceName)
 		if err != nil {
-			return res, err
+			return err
 		}
 	}
 
-	return res, err
+	// drop the sequences of the auto-incremented columns
+	for _, name := range ctx.Tx.Catalog.ListSequences() {
+		seq, err := ctx.Tx.Catalog.GetSequence(name)
+		if err != nil {
+			return err
+		}
+
+		if seq.Info.Owner.TableName != info.TableName || len(seq.Info.Owner.Columns) == 0 {
+			continue
+		}
+
+		err = ctx.Tx.CatalogWriter().DropSequence(ctx.Tx, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // DropIndexStmt is a DSL that allows creating a DROP INDEX query.
@@ -84,7 +116,10 @@ func (stmt *DropIndexStmt) Run(ctx *Context) (Result, error) {
 		return res, errors.New("missing index name")
 	}
 
-	err := ctx.Tx.CatalogWriter().DropIndex(ctx.Tx, stmt.IndexName)
+	indexName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationIndexType, stmt.IndexName)
+	if err == nil {
+		err = ctx.Tx.CatalogWriter().DropIndex(ctx.Tx, indexName)
+	}
 	if errs.IsNotFoundError(err) && stmt.IfExists {
 		err = nil
 	}
@@ -116,7 +151,11 @@ func (stmt *DropSequenceStmt) Run(ctx *Context) (Result, error) {
 		return res, errors.New("missing index name")
 	}
 
-	seq, err := ctx.Tx.Catalog.GetSequence(stmt.SequenceName)
+	var seq *database.Sequence
+	seqName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationSequenceType, stmt.SequenceName)
+	if err == nil {
+		seq, err = ctx.Tx.Catalog.GetSequence(seqName)
+	}
 	if err != nil {
 		if errs.IsNotFoundError(err) && stmt.IfExists {
 			err = nil
@@ -128,10 +167,159 @@ func (stmt *DropSequenceStmt) Run(ctx *Context) (Result, error) {
 		return res, fmt.Errorf("cannot drop sequence %s because constraint of table %s requires it", seq.Info.Name, seq.Info.Owner.TableName)
 	}
 
-	err = ctx.Tx.CatalogWriter().DropSequence(ctx.Tx, stmt.SequenceName)
+	err = ctx.Tx.CatalogWriter().DropSequence(ctx.Tx, seqName)
+	if errs.IsNotFoundError(err) && stmt.IfExists {
+		err = nil
+	}
+
+	return res, err
+}
+
+// DropSchemaStmt is a DSL that allows creating a DROP SCHEMA query.
+// If Cascade is true, the tables and the sequences of the schema
+// are dropped with it, otherwise the schema must be empty.
+type DropSchemaStmt struct {
+	SchemaName string
+	IfExists   bool
+	Cascade    bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *DropSchemaStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *DropSchemaStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run runs the DropSchema statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *DropSchemaStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.SchemaName == "" {
+		return res, errors.New("missing schema name")
+	}
+
+	if stmt.Cascade {
+		err := stmt.dropRelations(ctx)
+		if err != nil {
+			if errs.IsNotFoundError(err) && stmt.IfExists {
+				err = nil
+			}
+			return res, err
+		}
+	}
+
+	err := ctx.Tx.CatalogWriter().DropSchema(ctx.Tx, stmt.SchemaName)
 	if errs.IsNotFoundError(err) && stmt.IfExists {
 		err = nil
 	}
 
 	return res, err
 }
+
+// dropRelations drops the tables of the schema, with their indexes,
+// then the sequences left in the schema.
+func (stmt *DropSchemaStmt) dropRelations(ctx *Context) error {
+	// the default schema cannot be dropped, don't empty it
+	if stmt.SchemaName == database.DefaultSchema || stmt.SchemaName == database.InformationSchema {
+		return nil
+	}
+
+	_, err := ctx.Tx.Catalog.GetSchema(stmt.SchemaName)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range ctx.Tx.Catalog.Cache.ListObjects(database.RelationTableType) {
+		if schema, _ := database.SplitQualifiedName(name); schema != stmt.SchemaName {
+			continue
+		}
+
+		info, err := ctx.Tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return err
+		}
+
+		err = dropTable(ctx, info)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range ctx.Tx.Catalog.ListSequences() {
+		if schema, _ := database.SplitQualifiedName(name); schema != stmt.SchemaName {
+			continue
+		}
+
+		err = ctx.Tx.CatalogWriter().DropSequence(ctx.Tx, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DropFunctionStmt is a DSL that allows creating a DROP FUNCTION query.
+type DropFunctionStmt struct {
+	FunctionName string
+	IfExists     bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *DropFunctionStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *DropFunctionStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run runs the DropFunction statement in the given transaction.
+// It implements the Statement interface.
+// A function cannot be dropped while other functions call it.
+func (stmt *DropFunctionStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.FunctionName == "" {
+		return res, errors.New("missing function name")
+	}
+
+	info, err := ctx.Tx.Catalog.GetFunction(stmt.FunctionName)
+	if err != nil {
+		if errs.IsNotFoundError(err) && stmt.IfExists {
+			err = nil
+		}
+		return res, err
+	}
+
+	for _, name := range ctx.Tx.Catalog.ListFunctions() {
+		other, err := ctx.Tx.Catalog.GetFunction(name)
+		if err != nil {
+			return res, err
+		}
+
+		if callsFunction(other.Body.(expr.Expr), info.Name) {
+			return res, fmt.Errorf("cannot drop function %s because function %s depends on it", info.Name, other.Name)
+		}
+	}
+
+	err = ctx.Tx.CatalogWriter().DropFunction(ctx.Tx, info.Name)
+	return res, err
+}
+
+// callsFunction returns whether e calls the function with the given name.
+func callsFunction(e expr.Expr, name string) bool {
+	var found bool
+	expr.Walk(e, func(e expr.Expr) bool {
+		if f, ok := e.(*functions.UserFunction); ok && strings.EqualFold(f.Name, name) {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
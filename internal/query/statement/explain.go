@@ -1,10 +1,17 @@
 package statement
 
 import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/chaisql/chai/internal/expr"
-	"github.com/chaisql/chai/internal/planner"
 	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
+	"github.com/chaisql/chai/internal/stream/path"
 	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/stream/table"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
@@ -15,34 +22,47 @@ var _ Statement = &ExplainStmt{}
 // displays information about how a statement
 // is going to be executed, without executing it.
 type ExplainStmt struct {
-	Statement Preparer
+	Statement Statement
+
+	// Analyze runs the part of the plan that reads from the database
+	// to count the rows returned or written by the statement.
+	Analyze bool
 }
 
 func (stmt *ExplainStmt) Bind(ctx *Context) error {
-	if s, ok := stmt.Statement.(Statement); ok {
-		return s.Bind(ctx)
-	}
-
-	return nil
+	return stmt.Statement.Bind(ctx)
 }
 
 // Run analyses the inner statement and displays its execution plan.
 // If the statement is a stream, Optimize will be called prior to
 // displaying all the operations.
-// Explain currently only works on SELECT, UPDATE, INSERT and DELETE statements.
+// Explain currently only works on SELECT, UPDATE, INSERT, DELETE and CREATE INDEX statements.
+// With ANALYZE, it also displays the number of rows returned or written by the statement,
+// obtained by running the part of the plan that reads from the database, and for
+// statements that write to the database, the table being written, the indexes
+// that will be maintained and the constraints that will be checked.
 func (stmt *ExplainStmt) Run(ctx *Context) (Result, error) {
-	st, err := stmt.Statement.Prepare(ctx)
+	if ci, ok := stmt.Statement.(*CreateIndexStmt); ok {
+		return stmt.explainCreateIndex(ctx, ci)
+	}
+
+	p, ok := stmt.Statement.(Preparer)
+	if !ok {
+		return Result{}, errors.New("EXPLAIN only works on INSERT, SELECT, UPDATE, DELETE and CREATE INDEX statements")
+	}
+
+	st, err := p.Prepare(ctx)
 	if err != nil {
 		return Result{}, err
 	}
 
 	s, ok := st.(*PreparedStreamStmt)
 	if !ok {
-		return Result{}, errors.New("EXPLAIN only works on INSERT, SELECT, UPDATE AND DELETE statements")
+		return Result{}, errors.New("EXPLAIN only works on INSERT, SELECT, UPDATE, DELETE and CREATE INDEX statements")
 	}
 
 	// Optimize the stream.
-	s.Stream, err = planner.Optimize(s.Stream, ctx.Tx.Catalog, ctx.Params)
+	s.Stream, err = s.Optimize(ctx)
 	if err != nil {
 		return Result{}, err
 	}
@@ -54,19 +74,228 @@ func (stmt *ExplainStmt) Run(ctx *Context) (Result, error) {
 		plan = "<no exec>"
 	}
 
+	if !stmt.Analyze {
+		return explainResult(ctx, plan, nil, nil)
+	}
+
+	n, err := countRows(ctx, s.Stream)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var w *writeInfo
+	switch t := stmt.Statement.(type) {
+	case *InsertStmt:
+		w = &writeInfo{table: t.TableName, checked: true}
+	case *UpdateStmt:
+		w = &writeInfo{table: t.TableName, checked: true}
+	case *DeleteStmt:
+		w = &writeInfo{table: t.TableName}
+	}
+
+	if w == nil {
+		return explainResult(ctx, plan, nil, &n)
+	}
+
+	w.table, err = ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, w.table)
+	if err != nil {
+		return Result{}, err
+	}
+
+	info, err := ctx.Tx.Catalog.GetTableInfo(w.table)
+	if err != nil {
+		return Result{}, err
+	}
+	w.indexes = ctx.Tx.Catalog.ListIndexes(w.table)
+	if w.checked {
+		w.constraints = tableConstraints(info)
+	}
+
+	return explainResult(ctx, plan, w, &n)
+}
+
+// explainCreateIndex displays how the index would be built, without creating it.
+func (stmt *ExplainStmt) explainCreateIndex(ctx *Context, ci *CreateIndexStmt) (Result, error) {
+	err := ci.resolveNames(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	info, err := ctx.Tx.Catalog.GetTableInfo(ci.Info.Owner.TableName)
+	if err != nil {
+		return Result{}, err
+	}
+
+	for _, c := range ci.Info.Columns {
+		if info.GetColumnConstraint(c) == nil {
+			return Result{}, errors.Errorf("field %q does not exist for table %q", c, info.TableName)
+		}
+	}
+
+	name := ci.Info.IndexName
+	if name == "" {
+		name = (&database.IndexInfoRelation{Info: &ci.Info}).GenerateBaseName()
+	} else if _, err := ctx.Tx.Catalog.GetIndexInfo(name); err == nil {
+		if ci.IfNotExists {
+			return explainResult(ctx, "<no exec>", nil, nil)
+		}
+
+		return Result{}, errors.WithStack(errs.AlreadyExistsError{Name: name})
+	}
+
+	s := stream.New(table.Scan(info.TableName)).
+		Pipe(index.Build(name)).
+		Pipe(stream.Discard())
+
+	if !stmt.Analyze {
+		return explainResult(ctx, s.String(), nil, nil)
+	}
+
+	n, err := countRows(ctx, s)
+	if err != nil {
+		return Result{}, err
+	}
+
+	w := writeInfo{
+		table:   info.TableName,
+		indexes: []string{name},
+	}
+	if ci.Info.Unique {
+		w.constraints = []string{"UNIQUE (" + strings.Join(ci.Info.Columns, ", ") + ")"}
+	}
+
+	return explainResult(ctx, s.String(), &w, &n)
+}
+
+// writeInfo describes the effects of a statement that writes to a table.
+type writeInfo struct {
+	table       string
+	indexes     []string
+	checked     bool
+	constraints []string
+}
+
+// explainResult returns a result containing a single row describing the plan,
+// the writes of the statement if w is not nil and the number of rows
+// it returns or writes if n is not nil.
+func explainResult(ctx *Context, plan string, w *writeInfo, n *int64) (Result, error) {
+	exprs := []expr.Expr{
+		&expr.NamedExpr{
+			ExprName: "plan",
+			Expr:     expr.LiteralValue{Value: types.NewTextValue(plan)},
+		},
+	}
+
+	if w != nil {
+		exprs = append(exprs,
+			&expr.NamedExpr{ExprName: "table", Expr: expr.LiteralValue{Value: types.NewTextValue(w.table)}},
+			&expr.NamedExpr{ExprName: "indexes", Expr: expr.LiteralValue{Value: types.NewTextValue(strings.Join(w.indexes, ", "))}},
+			&expr.NamedExpr{ExprName: "constraints", Expr: expr.LiteralValue{Value: types.NewTextValue(strings.Join(w.constraints, ", "))}},
+		)
+	}
+
+	if n != nil {
+		exprs = append(exprs, &expr.NamedExpr{ExprName: "rows", Expr: expr.LiteralValue{Value: types.NewBigintValue(*n)}})
+	}
+
 	newStatement := PreparedStreamStmt{
 		Stream: &stream.Stream{
-			Op: rows.Project(
-				&expr.NamedExpr{
-					ExprName: "plan",
-					Expr:     expr.LiteralValue{Value: types.NewTextValue(plan)},
-				}),
+			Op: rows.Project(exprs...),
 		},
 		ReadOnly: true,
 	}
 	return newStatement.Run(ctx)
 }
 
+// tableConstraints returns a description of the constraints checked
+// when writing rows to the table.
+func tableConstraints(info *database.TableInfo) []string {
+	var l []string
+
+	for _, tc := range info.TableConstraints {
+		switch {
+		case tc.PrimaryKey:
+			l = append(l, "PRIMARY KEY ("+strings.Join(tc.Columns, ", ")+")")
+		case tc.Unique && tc.Deferred:
+			l = append(l, "UNIQUE ("+strings.Join(tc.Columns, ", ")+") INITIALLY DEFERRED")
+		case tc.Unique:
+			l = append(l, "UNIQUE ("+strings.Join(tc.Columns, ", ")+")")
+		case tc.Check != nil:
+			l = append(l, "CHECK ("+tc.Check.String()+")")
+		}
+	}
+
+	for _, cc := range info.ColumnConstraints.Ordered {
+		if cc.IsNotNull {
+			l = append(l, "NOT NULL ("+cc.Column+")")
+		}
+	}
+
+	return l
+}
+
+// countRows runs the operators of the stream that precede
+// the first operator modifying rows or writing to the database,
+// and returns the number of rows they output.
+func countRows(ctx *Context, s *stream.Stream) (int64, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	var ops []stream.Operator
+	for op := s.First(); op != nil; op = op.GetNext() {
+		if isWriteOperator(op) {
+			break
+		}
+
+		ops = append(ops, op.Clone())
+	}
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	last := stream.Pipe(ops...)
+	last.SetNext(nil)
+
+	var env environment.Environment
+	env.DB = ctx.DB
+	env.Tx = ctx.Tx
+	env.SetParams(ctx.Params)
+
+	var n int64
+	err := stream.New(last).Iterate(&env, func(*environment.Environment) error {
+		n++
+		return nil
+	})
+	if errors.Is(err, stream.ErrStreamClosed) {
+		err = nil
+	}
+
+	return n, err
+}
+
+func isWriteOperator(op stream.Operator) bool {
+	switch op.(type) {
+	case *path.SetOperator,
+		*table.ValidateOperator, *table.InsertOperator, *table.ReplaceOperator, *table.DeleteOperator,
+		*index.ValidateOperator, *index.InsertOperator, *index.DeleteOperator, *index.BuildOperator,
+		*stream.OnConflictOperator, *stream.DiscardOperator:
+		return true
+	}
+
+	return false
+}
+
+// AsOfExpr returns the expression of the AS OF clause of the
+// explained statement, if any.
+func (stmt *ExplainStmt) AsOfExpr() expr.Expr {
+	if a, ok := stmt.Statement.(AsOfStatement); ok {
+		return a.AsOfExpr()
+	}
+
+	return nil
+}
+
 // IsReadOnly indicates that this statement doesn't write anything into
 // the database.
 func (s *ExplainStmt) IsReadOnly() bool {
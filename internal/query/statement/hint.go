@@ -0,0 +1,90 @@
+package statement
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/cockroachdb/errors"
+)
+
+// Names of the optimizer hints.
+const (
+	// HintIndex requires a table to be read with an index, if it can be used.
+	HintIndex = "INDEX"
+	// HintNoIndex requires tables to be read without their indexes.
+	HintNoIndex = "NO_INDEX"
+)
+
+// An OptimizerHint overrides a choice of the planner.
+// Hints are written in a comment starting with a +, which follows
+// the first keyword of SELECT, UPDATE and DELETE statements:
+//
+//	SELECT /*+ INDEX(foo foo_a_idx) */ * FROM foo WHERE a > 1
+type OptimizerHint struct {
+	Name string
+	// Table the hint applies to.
+	// NO_INDEX hints without a table apply to every table.
+	Table string
+	// Index used by INDEX hints.
+	Index string
+}
+
+// indexHint returns the hint given by the optimizer hints of a statement
+// to choose how the table is read, or nil if there is none.
+func indexHint(ctx *Context, hints []OptimizerHint, tableName string) (*table.IndexHint, error) {
+	var hint *table.IndexHint
+
+	for _, h := range hints {
+		if h.Table != "" {
+			name, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, h.Table)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s hint", h.Name)
+			}
+
+			// the hint applies to another table of the statement
+			if name != tableName {
+				continue
+			}
+		}
+
+		if hint != nil {
+			return nil, errors.Errorf("conflicting hints for table %s", tableName)
+		}
+
+		switch h.Name {
+		case HintIndex:
+			indexName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationIndexType, h.Index)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s hint", h.Name)
+			}
+
+			info, err := ctx.Tx.Catalog.GetIndexInfo(indexName)
+			if err != nil {
+				return nil, err
+			}
+			if info.Owner.TableName != tableName {
+				return nil, errors.Errorf("invalid %s hint: %s is not an index of table %s", h.Name, indexName, tableName)
+			}
+
+			hint = &table.IndexHint{Index: indexName}
+		case HintNoIndex:
+			hint = &table.IndexHint{NoIndex: true}
+		default:
+			return nil, errors.Errorf("unknown hint %s", h.Name)
+		}
+	}
+
+	return hint, nil
+}
+
+// scanWithHints returns an operator scanning the table,
+// with the hint given by the optimizer hints of the statement.
+func scanWithHints(ctx *Context, hints []OptimizerHint, tableName string) (*table.ScanOperator, error) {
+	hint, err := indexHint(ctx, hints, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	scan := table.Scan(tableName)
+	scan.Hint = hint
+	return scan, nil
+}
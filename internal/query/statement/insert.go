@@ -64,11 +64,16 @@ func (stmt *InsertStmt) Bind(ctx *Context) error {
 }
 
 func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
+	tableName, err := c.Tx.Catalog.ResolveName(c.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+
 	var s *stream.Stream
 
 	var columns []string
 	if stmt.Values != nil {
-		ti, err := c.Tx.Catalog.GetTableInfo(stmt.TableName)
+		ti, err := c.Tx.Catalog.GetTableInfo(tableName)
 		if err != nil {
 			return nil, err
 		}
@@ -87,6 +92,10 @@ func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
 					continue
 				}
 
+				if len(r.Exprs) > len(ti.ColumnConstraints.Ordered) {
+					return nil, errors.Errorf("table has %d columns, got %d values", len(ti.ColumnConstraints.Ordered), len(r.Exprs))
+				}
+
 				for i := range r.Exprs {
 					r.Columns = append(r.Columns, ti.ColumnConstraints.Ordered[i].Column)
 				}
@@ -132,10 +141,11 @@ func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
 
 		s = selectStream.(*PreparedStreamStmt).Stream
 
-		// ensure we are not reading and writing to the same table.
-		// TODO(asdine): if same table, write content to a temp table.
-		if tableScan, ok := s.First().(*table.ScanOperator); ok && tableScan.TableName == stmt.TableName {
-			return nil, errors.New("cannot read and write to the same table")
+		// the rows are streamed to the table, unless they are read from it:
+		// they are then all read before being inserted, to avoid reading
+		// the inserted rows.
+		if readsTable(s, tableName) {
+			s = s.Pipe(rows.Materialize())
 		}
 
 		if len(stmt.Columns) > 0 {
@@ -144,33 +154,43 @@ func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
 	}
 
 	// validate object
-	s = s.Pipe(table.Validate(stmt.TableName))
+	s = s.Pipe(table.Validate(tableName))
 
 	if stmt.OnConflict != 0 {
 		switch stmt.OnConflict {
 		case database.OnConflictDoNothing:
 			s = s.Pipe(stream.OnConflict(nil))
 		case database.OnConflictDoReplace:
-			s = s.Pipe(stream.OnConflict(stream.New(table.Replace(stmt.TableName))))
+			s = s.Pipe(stream.OnConflict(stream.New(table.Replace(tableName))))
 		default:
 			panic("unreachable")
 		}
 	}
 
-	// check unique constraints
-	indexNames := c.Tx.Catalog.ListIndexes(stmt.TableName)
+	ti, err := c.Tx.Catalog.GetTableInfo(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// check unique constraints.
+	// deferred constraints are checked immediately when ON CONFLICT is used
+	// so that conflicts can be handled.
+	indexNames := c.Tx.Catalog.ListIndexes(tableName)
 	for _, indexName := range indexNames {
 		info, err := c.Tx.Catalog.GetIndexInfo(indexName)
 		if err != nil {
 			return nil, err
 		}
 
-		if info.Unique {
+		switch {
+		case info.Unique && ti.IsDeferred(info) && stmt.OnConflict == 0:
+			s = s.Pipe(index.DeferredValidate(indexName))
+		case info.Unique:
 			s = s.Pipe(index.Validate(indexName))
 		}
 	}
 
-	s = s.Pipe(table.Insert(stmt.TableName))
+	s = s.Pipe(table.Insert(tableName))
 
 	for _, indexName := range indexNames {
 		s = s.Pipe(index.Insert(indexName))
@@ -189,3 +209,37 @@ func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
 
 	return st.Prepare(c)
 }
+
+// readsTable returns whether the stream reads the rows of the table.
+func readsTable(s *stream.Stream, tableName string) bool {
+	if s == nil {
+		return false
+	}
+
+	for op := s.First(); op != nil; op = op.GetNext() {
+		var streams []*stream.Stream
+
+		switch t := op.(type) {
+		case *table.ScanOperator:
+			if t.TableName == tableName {
+				return true
+			}
+		case *stream.UnionOperator:
+			streams = t.Streams
+		case *stream.ConcatOperator:
+			streams = t.Streams
+		case *stream.IntersectOperator:
+			streams = t.Streams
+		case *stream.ExceptOperator:
+			streams = t.Streams
+		}
+
+		for _, st := range streams {
+			if readsTable(st, tableName) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
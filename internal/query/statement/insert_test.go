@@ -21,7 +21,7 @@ func TestInsertStmt(t *testing.T) {
 		{"Values / Positional Params", "INSERT INTO test (a, b, c) VALUES (?, 'e', ?)", false, `[{"a":"d","b":"e","c":"f"}]`, []interface{}{"d", "f"}},
 		{"Values / Named Params", "INSERT INTO test (a, b, c) VALUES ($d, 'e', $f)", false, `[{"a":"d","b":"e","c":"f"}]`, []interface{}{sql.Named("f", "f"), sql.Named("d", "d")}},
 		{"Values / Invalid params", "INSERT INTO test (a, b, c) VALUES ('d', ?)", true, "", []interface{}{'e'}},
-		{"Select / same table", "INSERT INTO test SELECT * FROM test", true, ``, nil},
+		{"Select / same table", "INSERT INTO test (a, b, c) VALUES ('a', 'b', 'c'); INSERT INTO test SELECT * FROM test", false, `[{"a":"a","b":"b","c":"c"},{"a":"a","b":"b","c":"c"}]`, nil},
 	}
 
 	for _, test := range tests {
@@ -143,7 +143,7 @@ func TestInsertSelect(t *testing.T) {
 		expected string
 		params   []interface{}
 	}{
-		{"Same table", `INSERT INTO foo SELECT * FROM foo`, true, ``, nil},
+		{"Same table", `INSERT INTO foo SELECT * FROM bar; INSERT INTO foo SELECT * FROM foo`, false, `[{"a":1, "b":10, "c":null, "d":null, "e":null}, {"a":1, "b":10, "c":null, "d":null, "e":null}]`, nil},
 		{"No columns / No projection", `INSERT INTO foo SELECT * FROM bar`, false, `[{"a":1, "b":10, "c":null, "d":null, "e":null}]`, nil},
 		{"No columns / Projection", `INSERT INTO foo SELECT a FROM bar`, false, `[{"a":1, "b":null, "c":null, "d":null, "e":null}]`, nil},
 		{"With columns / No Projection", `INSERT INTO foo (a, b) SELECT * FROM bar`, true, ``, nil},
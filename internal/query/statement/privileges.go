@@ -0,0 +1,220 @@
+package statement
+
+import (
+	"sort"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
+	"github.com/chaisql/chai/internal/stream/table"
+)
+
+// CheckPrivileges returns an error if the user running the transaction
+// of the context is not allowed to run the statement.
+// The privileges required by the statements reading or writing rows
+// are checked when their streams are optimized, from their operators.
+func CheckPrivileges(ctx *Context, stmt Statement) error {
+	tx := ctx.Tx
+	if !tx.Restricted() {
+		return nil
+	}
+
+	switch t := stmt.(type) {
+	case *CreateUserStmt, *AlterUserStmt, *DropUserStmt, *GrantStmt, *RevokeStmt:
+		return tx.CheckSuperuser()
+	case *CreateTableStmt:
+		// every user can create temporary tables
+		if t.Info.Temporary {
+			return nil
+		}
+		return tx.CheckPrivilege(database.PrivilegeDDL, database.AllTables)
+	case *CreateSequenceStmt, *CreateSchemaStmt, *CreateFunctionStmt,
+		*DropSequenceStmt, *DropSchemaStmt, *DropFunctionStmt, *AlterSequenceStmt:
+		return tx.CheckPrivilege(database.PrivilegeDDL, database.AllTables)
+	case *CreateIndexStmt:
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.Info.Owner.TableName)
+	case *DropTableStmt:
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.TableName)
+	case *AlterTableRenameStmt:
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.TableName)
+	case *AlterTableAddColumnStmt:
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.TableName)
+	case *AlterTableSetRetentionStmt:
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.TableName)
+	case *AlterTableAddPartitionStmt:
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.TableName)
+	case *AlterTableDropPartitionStmt:
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.TableName)
+	case *AlterTableInferSchemaStmt:
+		if t.DryRun {
+			return checkTablePrivilege(ctx, database.PrivilegeSelect, t.TableName)
+		}
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.TableName)
+	case *DropIndexStmt:
+		indexName, err := tx.Catalog.ResolveName(tx, database.RelationIndexType, t.IndexName)
+		if err != nil {
+			return nil
+		}
+		info, err := tx.Catalog.GetIndexInfo(indexName)
+		if err != nil {
+			return nil
+		}
+		return tx.CheckPrivilege(database.PrivilegeDDL, info.Owner.TableName)
+	case *TruncateStmt:
+		for _, name := range t.TableNames {
+			err := checkTablePrivilege(ctx, database.PrivilegeDelete, name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case *VacuumStmt:
+		if t.TableName == "" {
+			return tx.CheckPrivilege(database.PrivilegeDDL, database.AllTables)
+		}
+		return checkTablePrivilege(ctx, database.PrivilegeDDL, t.TableName)
+	case *ExplainStmt:
+		return CheckPrivileges(ctx, t.Statement)
+	}
+
+	return nil
+}
+
+// checkTablePrivilege checks the privileges on the table with the given name,
+// as written in the statement. Tables that don't exist are reported by
+// the statement itself.
+func checkTablePrivilege(ctx *Context, p database.Privilege, name string) error {
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, name)
+	if err != nil {
+		return nil
+	}
+
+	return ctx.Tx.CheckPrivilege(p, tableName)
+}
+
+// checkStreamPrivileges checks the privileges on the tables read and written
+// by the stream and by the streams it contains.
+// Scanning a table to modify its rows doesn't require the SELECT privilege.
+func checkStreamPrivileges(tx *database.Transaction, s *stream.Stream) error {
+	if !tx.Restricted() {
+		return nil
+	}
+
+	required := make(map[string]database.Privilege)
+	err := streamPrivileges(tx, s, required)
+	if err != nil {
+		return err
+	}
+
+	// check the tables in order, to always report the same error
+	tables := make([]string, 0, len(required))
+	for t := range required {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+
+	for _, t := range tables {
+		err = tx.CheckPrivilege(required[t], t)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func streamPrivileges(tx *database.Transaction, s *stream.Stream, required map[string]database.Privilege) error {
+	if s == nil {
+		return nil
+	}
+
+	var read []string
+	written := make(map[string]database.Privilege)
+
+	indexOwner := func(name string) (string, error) {
+		info, err := tx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return "", err
+		}
+		return info.Owner.TableName, nil
+	}
+
+	for op := s.First(); op != nil; op = op.GetNext() {
+		var err error
+
+		switch t := op.(type) {
+		case *stream.UnionOperator:
+			for _, s := range t.Streams {
+				if err = streamPrivileges(tx, s, required); err != nil {
+					break
+				}
+			}
+		case *stream.ConcatOperator:
+			for _, s := range t.Streams {
+				if err = streamPrivileges(tx, s, required); err != nil {
+					break
+				}
+			}
+		case *stream.IntersectOperator:
+			for _, s := range t.Streams {
+				if err = streamPrivileges(tx, s, required); err != nil {
+					break
+				}
+			}
+		case *stream.ExceptOperator:
+			for _, s := range t.Streams {
+				if err = streamPrivileges(tx, s, required); err != nil {
+					break
+				}
+			}
+		case *stream.OnConflictOperator:
+			err = streamPrivileges(tx, t.OnConflict, required)
+		case *stream.JoinOperator:
+			err = streamPrivileges(tx, t.Stream, required)
+		case *table.ScanOperator:
+			read = append(read, t.TableName)
+		case *index.ScanOperator:
+			var tb string
+			tb, err = indexOwner(t.IndexName)
+			read = append(read, tb)
+		case *index.VectorScanOperator:
+			var tb string
+			tb, err = indexOwner(t.IndexName)
+			read = append(read, tb)
+		case *index.CheckOperator:
+			var tb string
+			tb, err = indexOwner(t.IndexName)
+			read = append(read, tb)
+		case *index.BuildOperator:
+			var tb string
+			tb, err = indexOwner(t.IndexName)
+			written[tb] |= database.PrivilegeDDL
+		case *table.InsertOperator:
+			written[t.Name] |= database.PrivilegeInsert
+		case *table.ReplaceOperator:
+			written[t.Name] |= database.PrivilegeUpdate
+		case *table.DeleteOperator:
+			written[t.Name] |= database.PrivilegeDelete
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for t, p := range written {
+		// rows whose primary key is updated are deleted and inserted again
+		if p&(database.PrivilegeInsert|database.PrivilegeDelete) == database.PrivilegeInsert|database.PrivilegeDelete {
+			p = p&^(database.PrivilegeInsert|database.PrivilegeDelete) | database.PrivilegeUpdate
+		}
+		required[t] |= p
+	}
+
+	for _, t := range read {
+		if written[t] == 0 {
+			required[t] |= database.PrivilegeSelect
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package statement_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivileges(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE t(a INT PRIMARY KEY, b INT);
+		INSERT INTO t VALUES (1, 1);
+		CREATE USER alice WITH PASSWORD 'secret';
+		CREATE USER admin PASSWORD 'root' SUPERUSER;
+	`)
+	require.NoError(t, err)
+
+	alice, err := db.ConnectAs("alice", "secret")
+	require.NoError(t, err)
+	defer alice.Close()
+
+	admin, err := db.ConnectAs("admin", "root")
+	require.NoError(t, err)
+	defer admin.Close()
+
+	denied := func(q string) {
+		t.Helper()
+
+		err := alice.Exec(q)
+		require.ErrorIs(t, err, chai.PermissionDenied, q)
+	}
+
+	denied("SELECT * FROM t")
+	denied("INSERT INTO t VALUES (2, 2)")
+	denied("CREATE TABLE u(a INT)")
+	denied("GRANT SELECT ON t TO alice")
+	denied("CREATE USER eve")
+
+	require.NoError(t, admin.Exec("GRANT SELECT ON t TO alice"))
+	require.NoError(t, alice.Exec("SELECT * FROM t WHERE a = 1"))
+	denied("INSERT INTO t VALUES (2, 2)")
+	denied("UPDATE t SET b = 2")
+	denied("DELETE FROM t")
+	denied("TRUNCATE t")
+	denied("CREATE INDEX ON t(b)")
+	denied("EXPLAIN DELETE FROM t")
+
+	// the system tables can be read by everyone
+	require.NoError(t, alice.Exec("SELECT * FROM __chai_catalog"))
+
+	require.NoError(t, admin.Exec("GRANT INSERT, UPDATE ON TABLE t TO alice"))
+	require.NoError(t, alice.Exec("INSERT INTO t VALUES (2, 2)"))
+	require.NoError(t, alice.Exec("UPDATE t SET b = 3 WHERE a = 2"))
+	// updating the primary key deletes and inserts the rows again
+	require.NoError(t, alice.Exec("UPDATE t SET a = 3 WHERE a = 2"))
+	require.NoError(t, alice.Exec("INSERT INTO t VALUES (3, 4) ON CONFLICT DO REPLACE"))
+	denied("DELETE FROM t")
+
+	require.NoError(t, admin.Exec("REVOKE SELECT ON t FROM alice"))
+	denied("SELECT * FROM t")
+
+	require.NoError(t, admin.Exec("GRANT DDL ON ALL TABLES TO alice"))
+	require.NoError(t, alice.Exec("CREATE TABLE u(a INT PRIMARY KEY); CREATE INDEX ON t(b)"))
+	denied("INSERT INTO u VALUES (1)")
+
+	// the joined tables are read
+	denied("UPDATE t SET b = 1 FROM u WHERE u.a = t.a")
+	require.NoError(t, admin.Exec("GRANT SELECT ON u TO alice"))
+	require.NoError(t, alice.Exec("UPDATE t SET b = 1 FROM u WHERE u.a = t.a"))
+
+	// truncating a table requires the DELETE privilege
+	require.NoError(t, admin.Exec("GRANT DELETE ON u TO alice"))
+	denied("TRUNCATE u, t")
+	require.NoError(t, alice.Exec("TRUNCATE u"))
+
+	// the privileges are revoked when the table is dropped
+	require.NoError(t, admin.Exec("GRANT INSERT ON t TO alice"))
+	require.NoError(t, db.Exec("DROP TABLE t; CREATE TABLE t(a INT)"))
+	denied("INSERT INTO t VALUES (1)")
+}
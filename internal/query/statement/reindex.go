@@ -34,36 +34,21 @@ func (stmt *ReIndexStmt) Bind(ctx *Context) error {
 
 // Prepare implements the Preparer interface.
 func (stmt *ReIndexStmt) Prepare(ctx *Context) (Statement, error) {
-	var indexNames []string
-
-	if stmt.TableOrIndexName == "" {
-		indexNames = ctx.Tx.Catalog.Cache.ListObjects(database.RelationIndexType)
-	} else if _, err := ctx.Tx.Catalog.GetTable(ctx.Tx, stmt.TableOrIndexName); err == nil {
-		indexNames = ctx.Tx.Catalog.ListIndexes(stmt.TableOrIndexName)
-	} else if !errs.IsNotFoundError(err) {
+	indexNames, err := resolveIndexNames(ctx, stmt.TableOrIndexName)
+	if err != nil {
 		return nil, err
-	} else {
-		indexNames = []string{stmt.TableOrIndexName}
 	}
 
 	var streams []*stream.Stream
 
 	for _, indexName := range indexNames {
-		idx, err := ctx.Tx.Catalog.GetIndex(ctx.Tx, indexName)
-		if err != nil {
-			return nil, err
-		}
 		info, err := ctx.Tx.Catalog.GetIndexInfo(indexName)
 		if err != nil {
 			return nil, err
 		}
 
-		err = idx.Truncate()
-		if err != nil {
-			return nil, err
-		}
-
-		s := stream.New(table.Scan(info.Owner.TableName)).Pipe(index.Insert(info.IndexName))
+		// the index is truncated by the build, when the statement is run
+		s := stream.New(table.Scan(info.Owner.TableName)).Pipe(index.Build(info.IndexName))
 		streams = append(streams, s)
 	}
 
@@ -74,3 +59,30 @@ func (stmt *ReIndexStmt) Prepare(ctx *Context) (Statement, error) {
 
 	return st.Prepare(ctx)
 }
+
+// resolveIndexNames returns the indexes of the table, or the index, with the given name,
+// or every index if the name is empty.
+func resolveIndexNames(ctx *Context, tableOrIndexName string) ([]string, error) {
+	if tableOrIndexName == "" {
+		return ctx.Tx.Catalog.Cache.ListObjects(database.RelationIndexType), nil
+	}
+
+	tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, tableOrIndexName)
+	if err == nil {
+		_, err = ctx.Tx.Catalog.GetTableInfo(tableName)
+	}
+
+	switch {
+	case err == nil:
+		return ctx.Tx.Catalog.ListIndexes(tableName), nil
+	case !errs.IsNotFoundError(err):
+		return nil, err
+	}
+
+	indexName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationIndexType, tableOrIndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{indexName}, nil
+}
@@ -3,11 +3,11 @@ package statement
 import (
 	"fmt"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
-	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/rows"
-	"github.com/chaisql/chai/internal/stream/table"
 	"github.com/cockroachdb/errors"
 )
 
@@ -19,6 +19,13 @@ type SelectCoreStmt struct {
 	WhereExpr       expr.Expr
 	GroupByExpr     expr.Expr
 	ProjectionExprs []expr.Expr
+
+	// MatchRecognize finds the sequences of rows of the table
+	// matching a pattern, if set. Only the rows of the matches are selected.
+	MatchRecognize *rows.MatchRecognizeOperator
+
+	// Hints override the choices of the planner.
+	Hints []OptimizerHint
 }
 
 func (stmt *SelectCoreStmt) Bind(ctx *Context) error {
@@ -39,27 +46,75 @@ func (stmt *SelectCoreStmt) Bind(ctx *Context) error {
 		}
 	}
 
+	if mr := stmt.MatchRecognize; mr != nil {
+		for _, e := range []expr.Expr{mr.PartitionBy, mr.OrderBy} {
+			err = BindExpr(ctx, stmt.TableName, e)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, d := range mr.Define {
+			err = BindExpr(ctx, stmt.TableName, d.Expr)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
 func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
+	tableName := stmt.TableName
+	if tableName != "" {
+		var err error
+		tableName, err = ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, tableName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	isReadOnly := true
 
 	var s *stream.Stream
 
-	if stmt.TableName != "" {
-		_, err := ctx.Tx.Catalog.GetTableInfo(stmt.TableName)
+	if tableName != "" {
+		info, err := ctx.Tx.Catalog.GetTableInfo(tableName)
 		if err != nil {
 			return nil, err
 		}
 
-		s = s.Pipe(table.Scan(stmt.TableName))
-	}
+		scan, err := scanWithHints(ctx, stmt.Hints, tableName)
+		if err != nil {
+			return nil, err
+		}
+		s = s.Pipe(scan)
+
+		if stmt.WhereExpr != nil {
+			s = s.Pipe(rows.Filter(stmt.WhereExpr))
+		}
+
+		s = pipeTTLFilter(s, info)
 
-	if stmt.WhereExpr != nil {
+		if stmt.MatchRecognize != nil {
+			for _, c := range []string{rows.MatchNumberColumn, rows.ClassifierColumn} {
+				if info.GetColumnConstraint(c) != nil {
+					return nil, errors.Errorf("cannot use MATCH_RECOGNIZE on table %s: column %s is reserved", tableName, c)
+				}
+			}
+
+			s = s.Pipe(stmt.MatchRecognize.Clone())
+		}
+	} else if stmt.WhereExpr != nil {
 		s = s.Pipe(rows.Filter(stmt.WhereExpr))
 	}
 
+	err := stmt.checkMatchRecognizeFunctions()
+	if err != nil {
+		return nil, err
+	}
+
 	// when using GROUP BY, only aggregation functions or GroupByExpr can be selected
 	if stmt.GroupByExpr != nil {
 		var invalidProjectedField expr.Expr
@@ -86,7 +141,7 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 					ExprName: ne.ExprName,
 					Expr: &expr.Column{
 						Name:  e.String(),
-						Table: stmt.TableName,
+						Table: tableName,
 					},
 				}
 				continue
@@ -103,7 +158,7 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 		// add Aggregation node
 		s = s.Pipe(rows.TempTreeSort(stmt.GroupByExpr))
 		s = s.Pipe(rows.GroupAggregate(stmt.GroupByExpr, aggregators...))
-	} else if stmt.TableName != "" {
+	} else if tableName != "" {
 		// if there is no GROUP BY clause, check if there are any aggregation function
 		// and if so add an aggregation node
 		var aggregators []expr.AggregatorBuilder
@@ -127,7 +182,7 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 	}
 
 	// If there is no FROM clause ensure there is no wildcard or path
-	if stmt.TableName == "" {
+	if tableName == "" {
 		var err error
 
 		for _, e := range stmt.ProjectionExprs {
@@ -171,16 +226,61 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 	}, nil
 }
 
+// checkMatchRecognizeFunctions returns an error if the functions returning
+// the values computed by MATCH_RECOGNIZE are used without it, or in the
+// WHERE clause, which is evaluated before the rows are matched.
+func (stmt *SelectCoreStmt) checkMatchRecognizeFunctions() error {
+	var err error
+	check := func(e expr.Expr, allowed bool) {
+		expr.Walk(e, func(e expr.Expr) bool {
+			if f, ok := e.(*functions.MatchRecognizeValue); ok && !allowed {
+				err = errors.Errorf("%s can only be used in the projection or the GROUP BY clause of a SELECT with MATCH_RECOGNIZE", f)
+				return false
+			}
+			return true
+		})
+	}
+
+	allowed := stmt.MatchRecognize != nil
+	check(stmt.WhereExpr, false)
+	check(stmt.GroupByExpr, allowed)
+	for _, e := range stmt.ProjectionExprs {
+		check(e, allowed)
+	}
+
+	return err
+}
+
+// CompoundOperator combines the rows of two SELECT statements.
+type CompoundOperator uint8
+
+// Compound operators.
+const (
+	Union CompoundOperator = iota + 1
+	UnionAll
+	Intersect
+	IntersectAll
+	Except
+	ExceptAll
+)
+
 // SelectStmt holds SELECT configuration.
 type SelectStmt struct {
 	basePreparedStatement
 
 	CompoundSelect    []*SelectCoreStmt
-	CompoundOperators []scanner.Token
-	OrderBy           *expr.Column
-	OrderByDirection  scanner.Token
+	CompoundOperators []CompoundOperator
+	OrderBy           []rows.SortKey
 	OffsetExpr        expr.Expr
 	LimitExpr         expr.Expr
+
+	// If set, the statement reads the database
+	// as it was at the time this expression evaluates to.
+	AsOf expr.Expr
+
+	// Fingerprint identifies the statements that only differ by their
+	// literals and parameters, which are run with the same plan baseline.
+	Fingerprint string
 }
 
 func NewSelectStatement() *SelectStmt {
@@ -202,12 +302,14 @@ func (stmt *SelectStmt) Bind(ctx *Context) error {
 		}
 	}
 
-	err := BindExpr(ctx, stmt.CompoundSelect[0].TableName, stmt.OrderBy)
-	if err != nil {
-		return err
+	for _, k := range stmt.OrderBy {
+		err := BindExpr(ctx, stmt.CompoundSelect[0].TableName, k.Expr)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = BindExpr(ctx, stmt.CompoundSelect[0].TableName, stmt.OffsetExpr)
+	err := BindExpr(ctx, stmt.CompoundSelect[0].TableName, stmt.OffsetExpr)
 	if err != nil {
 		return err
 	}
@@ -224,7 +326,7 @@ func (stmt *SelectStmt) Bind(ctx *Context) error {
 func (stmt *SelectStmt) Prepare(ctx *Context) (Statement, error) {
 	var s *stream.Stream
 
-	var prev scanner.Token
+	var prev CompoundOperator
 
 	var coreStmts []*stream.Stream
 	var readOnly bool = true
@@ -247,31 +349,33 @@ func (stmt *SelectStmt) Prepare(ctx *Context) (Statement, error) {
 			readOnly = false
 		}
 
-		var tok scanner.Token
+		var op CompoundOperator
 		if i < len(stmt.CompoundOperators) {
-			tok = stmt.CompoundOperators[i]
+			op = stmt.CompoundOperators[i]
 		}
 
-		if prev != 0 && prev != tok {
+		// operators have the same precedence and are applied from left to right,
+		// consecutive identical operators are merged into a single one.
+		if prev != 0 && prev != op {
 			switch prev {
-			case scanner.UNION:
+			case Union:
 				s = stream.New(stream.Union(coreStmts...))
-			case scanner.ALL:
+			case UnionAll:
 				s = stream.New(stream.Concat(coreStmts...))
+			case Intersect, IntersectAll:
+				s = stream.New(stream.Intersect(prev == IntersectAll, coreStmts...))
+			case Except, ExceptAll:
+				s = stream.New(stream.Except(prev == ExceptAll, coreStmts...))
 			}
 
 			coreStmts = []*stream.Stream{s}
 		}
 
-		prev = tok
+		prev = op
 	}
 
-	if stmt.OrderBy != nil {
-		if stmt.OrderByDirection == scanner.DESC {
-			s = s.Pipe(rows.TempTreeSortReverse(stmt.OrderBy))
-		} else {
-			s = s.Pipe(rows.TempTreeSort(stmt.OrderBy))
-		}
+	if len(stmt.OrderBy) > 0 {
+		s = s.Pipe(rows.TempTreeSortBy(stmt.OrderBy...))
 	}
 
 	if stmt.OffsetExpr != nil {
@@ -282,10 +386,21 @@ func (stmt *SelectStmt) Prepare(ctx *Context) (Statement, error) {
 		s = s.Pipe(rows.Take(stmt.LimitExpr))
 	}
 
+	if stmt.AsOf != nil && !readOnly {
+		return nil, errors.New("AS OF cannot be used in statements that write to the database")
+	}
+
 	st := StreamStmt{
-		Stream:   s,
-		ReadOnly: readOnly,
+		Stream:      s,
+		ReadOnly:    readOnly,
+		AsOf:        stmt.AsOf,
+		Fingerprint: stmt.Fingerprint,
 	}
 
 	return st.Prepare(ctx)
 }
+
+// AsOfExpr returns the expression of the AS OF clause, if any.
+func (stmt *SelectStmt) AsOfExpr() expr.Expr {
+	return stmt.AsOf
+}
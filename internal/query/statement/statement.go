@@ -1,9 +1,16 @@
 package statement
 
 import (
+	"context"
+	"time"
+
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -24,6 +31,15 @@ func (stmt *basePreparedStatement) IsReadOnly() bool {
 }
 
 func (stmt *basePreparedStatement) Run(ctx *Context) (Result, error) {
+	// statements that were not prepared beforehand,
+	// like those following a schema change in the same query,
+	// are bound to the current catalog.
+	if b, ok := stmt.Preparer.(Statement); ok {
+		if err := b.Bind(ctx); err != nil {
+			return Result{}, err
+		}
+	}
+
 	s, err := stmt.Preparer.Prepare(ctx)
 	if err != nil {
 		return Result{}, err
@@ -33,16 +49,55 @@ func (stmt *basePreparedStatement) Run(ctx *Context) (Result, error) {
 }
 
 type Context struct {
-	DB     *database.Database
-	Conn   *database.Connection
-	Tx     *database.Transaction
-	Params []environment.Param
+	// Ctx is used to cancel the execution of the statement, if not nil.
+	Ctx context.Context
+	// Progress counts the rows scanned by the statement, if not nil.
+	Progress *environment.Progress
+	DB       *database.Database
+	Conn     *database.Connection
+	Tx       *database.Transaction
+	Params   []environment.Param
 }
 
 type Preparer interface {
 	Prepare(*Context) (Statement, error)
 }
 
+// An AsOfStatement is a statement that can read the database
+// as it was at a point in time, using an AS OF clause.
+type AsOfStatement interface {
+	// AsOfExpr returns the expression of the AS OF clause, if any.
+	AsOfExpr() expr.Expr
+}
+
+// AsOf evaluates the AS OF clause of the statement and returns the
+// time at which the statement must read the database.
+// It returns the zero time if the statement doesn't have an AS OF clause.
+func AsOf(stmt Statement, params []environment.Param) (time.Time, error) {
+	a, ok := stmt.(AsOfStatement)
+	if !ok || a.AsOfExpr() == nil {
+		return time.Time{}, nil
+	}
+
+	var env environment.Environment
+	env.SetParams(params)
+
+	v, err := a.AsOfExpr().Eval(&env)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if v.Type() == types.TypeNull {
+		return time.Time{}, errors.New("AS OF timestamp cannot be NULL")
+	}
+
+	v, err = v.CastAs(types.TypeTimestamp)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid AS OF timestamp")
+	}
+
+	return types.AsTime(v), nil
+}
+
 // Result of a query.
 type Result struct {
 	Iterator database.RowIterator
@@ -60,6 +115,17 @@ func (r *Result) Iterate(fn func(database.Row) error) error {
 	return r.err
 }
 
+// RowsAffected returns the number of rows inserted, updated or deleted
+// by the statement, once the result was iterated.
+// It returns 0 if the statement returns rows.
+func (r *Result) RowsAffected() int64 {
+	if ra, ok := r.Iterator.(interface{ RowsAffected() int64 }); ok {
+		return ra.RowsAffected()
+	}
+
+	return 0
+}
+
 // Close the result stream.
 // After closing the result, Stream is not supposed to be used.
 // If the result stream was already closed, it returns an error.
@@ -85,14 +151,50 @@ func (r *Result) Close() (err error) {
 	return err
 }
 
-func BindExpr(ctx *Context, tableName string, e expr.Expr) (err error) {
+func BindExpr(ctx *Context, tableName string, e expr.Expr) error {
+	if tableName == "" {
+		return bindExpr(ctx, e)
+	}
+
+	return bindExpr(ctx, e, tableName)
+}
+
+// boundTable is a table whose columns can be referenced by an expression.
+type boundTable struct {
+	// name of the table as written in the statement
+	alias string
+	info  *database.TableInfo
+}
+
+// references returns whether the name refers to the table,
+// as written in the statement or with its schema.
+func (t *boundTable) references(name string) bool {
+	if name == t.alias || name == t.info.TableName {
+		return true
+	}
+
+	_, rel := database.SplitQualifiedName(t.info.TableName)
+	return name == rel
+}
+
+// bindExpr binds the columns of the expression to the given tables.
+// Columns that are not prefixed by the name of a table must belong
+// to only one of them.
+func bindExpr(ctx *Context, e expr.Expr, tableNames ...string) (err error) {
 	if e == nil {
 		return nil
 	}
 
-	var info *database.TableInfo
-	if tableName != "" {
-		info, err = ctx.Tx.Catalog.GetTableInfo(tableName)
+	tables := make([]boundTable, len(tableNames))
+	for i, name := range tableNames {
+		tables[i].alias = name
+
+		name, err = ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, name)
+		if err != nil {
+			return err
+		}
+
+		tables[i].info, err = ctx.Tx.Catalog.GetTableInfo(name)
 		if err != nil {
 			return err
 		}
@@ -105,17 +207,45 @@ func BindExpr(ctx *Context, tableName string, e expr.Expr) (err error) {
 				return true
 			}
 
-			if info == nil {
+			if len(tables) == 0 {
 				err = errors.New("no table specified")
 				return false
 			}
 
-			cc := info.ColumnConstraints.GetColumnConstraint(t.Name)
-			if cc == nil {
+			var tb *boundTable
+			var cc *database.ColumnConstraint
+			var referenced bool
+			for i := range tables {
+				if t.Table != "" && !tables[i].references(t.Table) {
+					continue
+				}
+				referenced = true
+
+				c := tables[i].info.ColumnConstraints.GetColumnConstraint(t.Name)
+				if c == nil {
+					continue
+				}
+				if tb != nil {
+					err = errors.Newf("column reference %s is ambiguous", t)
+					return false
+				}
+				tb, cc = &tables[i], c
+			}
+
+			if !referenced {
+				err = errors.Newf("table %s is not referenced by the statement", t.Table)
+				return false
+			}
+			if tb == nil {
 				err = errors.Newf("column %s does not exist", t)
 				return false
 			}
-			t.Table = tableName
+
+			t.Table = tb.info.TableName
+			t.Collation = cc.GetCollation()
+		case *functions.UserFunction:
+			err = t.Bind(ctx.Tx.Catalog)
+			return err == nil
 		}
 
 		return true
@@ -123,3 +253,97 @@ func BindExpr(ctx *Context, tableName string, e expr.Expr) (err error) {
 
 	return err
 }
+
+// pipeTTLFilter adds a filter removing the expired rows of the table
+// to the stream, if the table has a TTL column.
+// Expired rows are filtered out until they are physically deleted.
+func pipeTTLFilter(s *stream.Stream, info *database.TableInfo) *stream.Stream {
+	if info.TTLColumn == "" {
+		return s
+	}
+
+	c := &expr.Column{Name: info.TTLColumn, Table: info.TableName}
+	return s.Pipe(rows.Filter(expr.Or(
+		expr.Is(c, expr.LiteralValue{Value: types.NewNullValue()}),
+		expr.Gt(c, &functions.Now{}),
+	)))
+}
+
+// pipeJoin joins the rows of the table read by the stream with the first
+// row of the joined table matching the condition.
+// The parts of the condition that only read the rows of the stream
+// filter them before they are joined.
+func pipeJoin(c *Context, s *stream.Stream, ti *database.TableInfo, joinedTable string, cond expr.Expr, hints []OptimizerHint) (*stream.Stream, error) {
+	tableName := ti.TableName
+
+	joinedTable, err := c.Tx.Catalog.ResolveName(c.Tx, database.RelationTableType, joinedTable)
+	if err != nil {
+		return nil, err
+	}
+	if joinedTable == tableName {
+		return nil, errors.Errorf("table %s is specified more than once", tableName)
+	}
+
+	info, err := c.Tx.Catalog.GetTableInfo(joinedTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter, joinCond expr.Expr
+	for _, e := range splitAND(cond) {
+		if readsOnly(e, tableName) {
+			filter = andExpr(filter, e)
+		} else {
+			joinCond = andExpr(joinCond, e)
+		}
+	}
+
+	if filter != nil {
+		s = s.Pipe(rows.Filter(filter))
+	}
+
+	s = pipeTTLFilter(s, ti)
+
+	scan, err := scanWithHints(c, hints, joinedTable)
+	if err != nil {
+		return nil, err
+	}
+
+	joined := pipeTTLFilter(stream.New(scan), info)
+	return s.Pipe(stream.Join(joined, joinCond)), nil
+}
+
+// splitAND splits the condition by AND operator.
+func splitAND(cond expr.Expr) []expr.Expr {
+	if cond == nil {
+		return nil
+	}
+
+	if op, ok := cond.(*expr.AndOp); ok {
+		return append(splitAND(op.LeftHand()), splitAND(op.RightHand())...)
+	}
+
+	return []expr.Expr{cond}
+}
+
+func andExpr(a, b expr.Expr) expr.Expr {
+	if a == nil {
+		return b
+	}
+
+	return expr.And(a, b)
+}
+
+// readsOnly returns whether the bound expression only reads
+// the columns of the given table.
+func readsOnly(e expr.Expr, tableName string) bool {
+	ok := true
+	expr.Walk(e, func(e expr.Expr) bool {
+		if c, isCol := e.(*expr.Column); isCol && c != nil && c.Table != tableName {
+			ok = false
+		}
+		return ok
+	})
+
+	return ok
+}
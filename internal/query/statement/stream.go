@@ -3,6 +3,7 @@ package statement
 import (
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/planner"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/cockroachdb/errors"
@@ -14,13 +15,18 @@ var _ Statement = (*PreparedStreamStmt)(nil)
 type StreamStmt struct {
 	Stream   *stream.Stream
 	ReadOnly bool
+	AsOf     expr.Expr
+	// Fingerprint of the statement, if its plan can be pinned.
+	Fingerprint string
 }
 
 // Prepare implements the Preparer interface.
 func (s *StreamStmt) Prepare(ctx *Context) (Statement, error) {
 	return &PreparedStreamStmt{
-		Stream:   s.Stream,
-		ReadOnly: s.ReadOnly,
+		Stream:      s.Stream,
+		ReadOnly:    s.ReadOnly,
+		AsOf:        s.AsOf,
+		Fingerprint: s.Fingerprint,
 	}, nil
 }
 
@@ -28,6 +34,10 @@ func (s *StreamStmt) Prepare(ctx *Context) (Statement, error) {
 type PreparedStreamStmt struct {
 	Stream   *stream.Stream
 	ReadOnly bool
+	// AS OF clause of the statement, if any.
+	AsOf expr.Expr
+	// Fingerprint of the statement, if its plan can be pinned.
+	Fingerprint string
 }
 
 func (s *PreparedStreamStmt) Bind(ctx *Context) error {
@@ -37,7 +47,7 @@ func (s *PreparedStreamStmt) Bind(ctx *Context) error {
 // Run returns a result containing the stream. The stream will be executed by calling the Iterate method of
 // the result.
 func (s *PreparedStreamStmt) Run(ctx *Context) (Result, error) {
-	st, err := planner.Optimize(s.Stream.Clone(), ctx.Tx.Catalog, ctx.Params)
+	st, err := s.Optimize(ctx)
 	if err != nil {
 		return Result{}, err
 	}
@@ -50,6 +60,51 @@ func (s *PreparedStreamStmt) Run(ctx *Context) (Result, error) {
 	}, nil
 }
 
+// Optimize returns the stream optimized by the planner.
+// The privileges of the user running the transaction on the tables
+// of the stream are checked first.
+// If the transaction uses strict typing, the types of the expressions
+// of the stream are checked as well.
+func Optimize(ctx *Context, s *stream.Stream) (*stream.Stream, error) {
+	err := checkStreamPrivileges(ctx.Tx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.Tx.StrictTyping {
+		err = planner.CheckStrictTypes(s, ctx.Tx.Catalog, ctx.Params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return planner.Optimize(s, ctx.Tx.Catalog, ctx.Params)
+}
+
+// Optimize returns a copy of the stream optimized by the planner.
+// If a plan baseline is pinned for the statement, its tables are
+// read as they were when the plan was captured.
+func (s *PreparedStreamStmt) Optimize(ctx *Context) (*stream.Stream, error) {
+	st := s.Stream.Clone()
+
+	if s.Fingerprint != "" {
+		b, err := database.GetPlanBaseline(ctx.Tx, s.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			applyBaseline(ctx, b, st)
+		}
+	}
+
+	return Optimize(ctx, st)
+}
+
+// AsOfExpr returns the expression of the AS OF clause, if any.
+func (s *PreparedStreamStmt) AsOfExpr() expr.Expr {
+	return s.AsOf
+}
+
 // IsReadOnly reports whether the stream will modify the database or only read it.
 func (s *PreparedStreamStmt) IsReadOnly() bool {
 	return s.ReadOnly
@@ -69,6 +124,8 @@ func (s *StreamStmtIterator) Iterate(fn func(r database.Row) error) error {
 	var env environment.Environment
 	env.DB = s.Context.DB
 	env.Tx = s.Context.Tx
+	env.Ctx = s.Context.Ctx
+	env.Progress = s.Context.Progress
 	env.SetParams(s.Context.Params)
 
 	err := s.Stream.Iterate(&env, func(env *environment.Environment) error {
@@ -86,3 +143,13 @@ func (s *StreamStmtIterator) Iterate(fn func(r database.Row) error) error {
 	}
 	return err
 }
+
+// RowsAffected returns the number of rows written by the stream,
+// if its rows are discarded, after it was iterated.
+func (s *StreamStmtIterator) RowsAffected() int64 {
+	if d, ok := s.Stream.Op.(*stream.DiscardOperator); ok {
+		return d.Count()
+	}
+
+	return 0
+}
@@ -0,0 +1,121 @@
+package statement
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/cockroachdb/errors"
+)
+
+var _ Statement = (*TruncateStmt)(nil)
+
+// TruncateStmt is a statement that deletes all the rows of tables.
+// Instead of deleting the rows one by one, the store of each table
+// and the stores of its indexes are cleared with range deletions.
+// If RestartIdentity is true, the sequences owned by the tables
+// are restarted.
+type TruncateStmt struct {
+	TableNames      []string
+	RestartIdentity bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *TruncateStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *TruncateStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run truncates the tables in the given transaction.
+// It implements the Statement interface.
+func (stmt *TruncateStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if len(stmt.TableNames) == 0 {
+		return res, errors.New("missing table name")
+	}
+
+	// resolve all the tables before truncating any of them
+	tables := make([]*database.Table, 0, len(stmt.TableNames))
+	for _, name := range stmt.TableNames {
+		tableName, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, name)
+		if err != nil {
+			return res, err
+		}
+
+		tb, err := ctx.Tx.Catalog.GetTable(ctx.Tx, tableName)
+		if err != nil {
+			return res, err
+		}
+
+		if err := tb.Info.CheckWritable(); err != nil {
+			return res, err
+		}
+		if tb.Info.Virtual != nil {
+			return res, errors.New("cannot write to read-only table")
+		}
+
+		tables = append(tables, tb)
+	}
+
+	for _, tb := range tables {
+		err := truncateTable(ctx, tb)
+		if err != nil {
+			return res, err
+		}
+
+		if stmt.RestartIdentity {
+			err = restartOwnedSequences(ctx, tb.Info.TableName)
+			if err != nil {
+				return res, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// truncateTable clears the store of the table and the stores of its indexes.
+func truncateTable(ctx *Context, tb *database.Table) error {
+	err := tb.Truncate()
+	if err != nil {
+		return err
+	}
+
+	for _, indexName := range ctx.Tx.Catalog.ListIndexes(tb.Info.TableName) {
+		idx, err := ctx.Tx.Catalog.GetIndex(ctx.Tx, indexName)
+		if err != nil {
+			return err
+		}
+
+		err = idx.Truncate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restartOwnedSequences restarts the rowid sequence of the table and
+// the sequences of its auto-incremented columns from their start value.
+func restartOwnedSequences(ctx *Context, tableName string) error {
+	for _, name := range ctx.Tx.Catalog.ListSequences() {
+		seq, err := ctx.Tx.Catalog.GetSequence(name)
+		if err != nil {
+			return err
+		}
+
+		if seq.Info.Owner.TableName != tableName {
+			continue
+		}
+
+		start := seq.Info.Start
+		err = ctx.Tx.CatalogWriter().AlterSequence(ctx.Tx, seq.Info.Clone(), &start)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,12 +1,14 @@
 package statement
 
 import (
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/index"
 	"github.com/chaisql/chai/internal/stream/path"
 	"github.com/chaisql/chai/internal/stream/rows"
 	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/cockroachdb/errors"
 )
 
 var _ Statement = (*UpdateStmt)(nil)
@@ -17,12 +19,26 @@ type UpdateStmt struct {
 
 	TableName string
 
+	// FromTable is the table joined with the updated table
+	// in the FROM clause, if any.
+	FromTable string
+
+	// Hints override the choices of the planner.
+	Hints []OptimizerHint
+
 	// SetPairs is used along with the Set clause. It holds
 	// each column with its corresponding value that
 	// should be set in the row.
 	SetPairs []UpdateSetPair
 
-	WhereExpr expr.Expr
+	WhereExpr  expr.Expr
+	OrderBy    []rows.SortKey
+	OffsetExpr expr.Expr
+	LimitExpr  expr.Expr
+
+	// Fingerprint identifies the statements that only differ by their
+	// literals and parameters, which are run with the same plan baseline.
+	Fingerprint string
 }
 
 func NewUpdateStatement() *UpdateStmt {
@@ -42,7 +58,12 @@ type UpdateSetPair struct {
 }
 
 func (stmt *UpdateStmt) Bind(ctx *Context) error {
-	err := BindExpr(ctx, stmt.TableName, stmt.WhereExpr)
+	tables := []string{stmt.TableName}
+	if stmt.FromTable != "" {
+		tables = append(tables, stmt.FromTable)
+	}
+
+	err := bindExpr(ctx, stmt.WhereExpr, tables...)
 	if err != nil {
 		return err
 	}
@@ -53,27 +74,80 @@ func (stmt *UpdateStmt) Bind(ctx *Context) error {
 			return err
 		}
 
-		err = BindExpr(ctx, stmt.TableName, stmt.SetPairs[i].E)
+		err = bindExpr(ctx, stmt.SetPairs[i].E, tables...)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, k := range stmt.OrderBy {
+		err = BindExpr(ctx, stmt.TableName, k.Expr)
 		if err != nil {
 			return err
 		}
 	}
 
+	err = BindExpr(ctx, stmt.TableName, stmt.OffsetExpr)
+	if err != nil {
+		return err
+	}
+
+	err = BindExpr(ctx, stmt.TableName, stmt.LimitExpr)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // Prepare implements the Preparer interface.
 func (stmt *UpdateStmt) Prepare(c *Context) (Statement, error) {
-	ti, err := c.Tx.Catalog.GetTableInfo(stmt.TableName)
+	tableName, err := c.Tx.Catalog.ResolveName(c.Tx, database.RelationTableType, stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	ti, err := c.Tx.Catalog.GetTableInfo(tableName)
 	if err != nil {
 		return nil, err
 	}
 	pk := ti.PrimaryKey
 
-	s := stream.New(table.Scan(stmt.TableName))
+	scan, err := scanWithHints(c, stmt.Hints, tableName)
+	if err != nil {
+		return nil, err
+	}
+	s := stream.New(scan)
+
+	if stmt.FromTable != "" {
+		// the joined row is not kept by the sort
+		if len(stmt.OrderBy) > 0 || stmt.OffsetExpr != nil || stmt.LimitExpr != nil {
+			return nil, errors.New("ORDER BY, LIMIT and OFFSET cannot be used with FROM")
+		}
+
+		s, err = pipeJoin(c, s, ti, stmt.FromTable, stmt.WhereExpr, stmt.Hints)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if stmt.WhereExpr != nil {
+			s = s.Pipe(rows.Filter(stmt.WhereExpr))
+		}
+
+		s = pipeTTLFilter(s, ti)
+	}
+
+	// the rows are sorted and limited before being updated
+	if len(stmt.OrderBy) > 0 {
+		s = s.Pipe(rows.TempTreeSortBy(stmt.OrderBy...))
+	}
 
-	if stmt.WhereExpr != nil {
-		s = s.Pipe(rows.Filter(stmt.WhereExpr))
+	if stmt.OffsetExpr != nil {
+		s = s.Pipe(rows.Skip(stmt.OffsetExpr))
+	}
+
+	if stmt.LimitExpr != nil {
+		s = s.Pipe(rows.Take(stmt.LimitExpr))
 	}
 
 	var pkModified bool
@@ -94,21 +168,21 @@ func (stmt *UpdateStmt) Prepare(c *Context) (Statement, error) {
 	}
 
 	// validate row
-	s = s.Pipe(table.Validate(stmt.TableName))
+	s = s.Pipe(table.Validate(tableName))
 
 	// TODO(asdine): This removes ALL indexed fields for each row
 	// even if the update modified a single field. We should only
 	// update the indexed fields that were modified.
-	indexNames := c.Tx.Catalog.ListIndexes(stmt.TableName)
+	indexNames := c.Tx.Catalog.ListIndexes(tableName)
 	for _, indexName := range indexNames {
 		s = s.Pipe(index.Delete(indexName))
 	}
 
 	if pkModified {
-		s = s.Pipe(table.Delete(stmt.TableName))
-		s = s.Pipe(table.Insert(stmt.TableName))
+		s = s.Pipe(table.Delete(tableName))
+		s = s.Pipe(table.Insert(tableName))
 	} else {
-		s = s.Pipe(table.Replace(stmt.TableName))
+		s = s.Pipe(table.Replace(tableName))
 	}
 
 	for _, indexName := range indexNames {
@@ -116,7 +190,10 @@ func (stmt *UpdateStmt) Prepare(c *Context) (Statement, error) {
 		if err != nil {
 			return nil, err
 		}
-		if info.Unique {
+		switch {
+		case info.Unique && ti.IsDeferred(info):
+			s = s.Pipe(index.DeferredValidate(indexName))
+		case info.Unique:
 			s = s.Pipe(index.Validate(indexName))
 		}
 
@@ -126,8 +203,9 @@ func (stmt *UpdateStmt) Prepare(c *Context) (Statement, error) {
 	s = s.Pipe(stream.Discard())
 
 	st := StreamStmt{
-		Stream:   s,
-		ReadOnly: false,
+		Stream:      s,
+		ReadOnly:    false,
+		Fingerprint: stmt.Fingerprint,
 	}
 
 	return st.Prepare(c)
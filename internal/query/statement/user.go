@@ -0,0 +1,249 @@
+package statement
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/cockroachdb/errors"
+)
+
+var _ Statement = (*CreateUserStmt)(nil)
+var _ Statement = (*AlterUserStmt)(nil)
+var _ Statement = (*DropUserStmt)(nil)
+var _ Statement = (*GrantStmt)(nil)
+var _ Statement = (*RevokeStmt)(nil)
+
+// CreateUserStmt represents a parsed CREATE USER statement.
+type CreateUserStmt struct {
+	IfNotExists bool
+	Info        database.UserInfo
+	// Password of the user, or its hash. Nil if the user has no password.
+	Password *string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *CreateUserStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *CreateUserStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run the statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *CreateUserStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.IfNotExists {
+		if _, err := ctx.Tx.Catalog.GetUser(stmt.Info.Name); err == nil {
+			return res, nil
+		}
+	}
+
+	info := stmt.Info
+	if stmt.Password != nil {
+		var err error
+		info.PasswordHash, err = database.HashPassword(*stmt.Password)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	err := ctx.Tx.CatalogWriter().CreateUser(ctx.Tx, &info)
+	if stmt.IfNotExists {
+		if errs.IsAlreadyExistsError(err) {
+			return res, nil
+		}
+	}
+	return res, err
+}
+
+// AlterUserStmt represents a parsed ALTER USER statement.
+type AlterUserStmt struct {
+	UserName string
+	// SetPassword is true if the password is changed
+	// to Password, or removed if Password is nil.
+	SetPassword bool
+	Password    *string
+	// Superuser is nil if unchanged.
+	Superuser *bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *AlterUserStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *AlterUserStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run runs the AlterUser statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *AlterUserStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	u, err := ctx.Tx.Catalog.GetUser(stmt.UserName)
+	if err != nil {
+		return res, err
+	}
+
+	clone := u.Clone()
+	if stmt.SetPassword {
+		clone.PasswordHash = ""
+		if stmt.Password != nil {
+			clone.PasswordHash, err = database.HashPassword(*stmt.Password)
+			if err != nil {
+				return res, err
+			}
+		}
+	}
+	if stmt.Superuser != nil {
+		clone.Superuser = *stmt.Superuser
+	}
+
+	return res, ctx.Tx.CatalogWriter().ReplaceUser(ctx.Tx, clone)
+}
+
+// DropUserStmt is a DSL that allows creating a DROP USER query.
+type DropUserStmt struct {
+	UserName string
+	IfExists bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *DropUserStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *DropUserStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run runs the DropUser statement in the given transaction.
+// It implements the Statement interface.
+// A user cannot drop itself.
+func (stmt *DropUserStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.UserName == "" {
+		return res, errors.New("missing user name")
+	}
+
+	if stmt.UserName == ctx.Tx.User {
+		return res, errors.New("current user cannot be dropped")
+	}
+
+	err := ctx.Tx.CatalogWriter().DropUser(ctx.Tx, stmt.UserName)
+	if errs.IsNotFoundError(err) && stmt.IfExists {
+		err = nil
+	}
+
+	return res, err
+}
+
+// GrantStmt represents a parsed GRANT statement.
+type GrantStmt struct {
+	Privileges database.Privilege
+	// Tables on which the privileges are granted,
+	// or database.AllTables.
+	Tables []string
+	Users  []string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *GrantStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *GrantStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run grants the privileges to the users.
+// It implements the Statement interface.
+func (stmt *GrantStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	tables, err := resolvePrivilegeTables(ctx, stmt.Tables)
+	if err != nil {
+		return res, err
+	}
+
+	for _, u := range stmt.Users {
+		for _, t := range tables {
+			err = ctx.Tx.CatalogWriter().Grant(ctx.Tx, u, stmt.Privileges, t)
+			if err != nil {
+				return res, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// RevokeStmt represents a parsed REVOKE statement.
+type RevokeStmt struct {
+	Privileges database.Privilege
+	// Tables on which the privileges are revoked,
+	// or database.AllTables.
+	Tables []string
+	Users  []string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *RevokeStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *RevokeStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run revokes the privileges from the users.
+// It implements the Statement interface.
+func (stmt *RevokeStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	tables, err := resolvePrivilegeTables(ctx, stmt.Tables)
+	if err != nil {
+		return res, err
+	}
+
+	for _, u := range stmt.Users {
+		for _, t := range tables {
+			err = ctx.Tx.CatalogWriter().Revoke(ctx.Tx, u, stmt.Privileges, t)
+			if err != nil {
+				return res, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// resolvePrivilegeTables returns the names of the tables as stored in the catalog.
+// The tables must exist.
+func resolvePrivilegeTables(ctx *Context, tables []string) ([]string, error) {
+	resolved := make([]string, len(tables))
+	for i, t := range tables {
+		if t == database.AllTables {
+			resolved[i] = t
+			continue
+		}
+
+		name, err := ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, t)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = ctx.Tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved[i] = name
+	}
+
+	return resolved, nil
+}
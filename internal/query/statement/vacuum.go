@@ -0,0 +1,59 @@
+package statement
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/types"
+)
+
+var _ Statement = (*VacuumStmt)(nil)
+
+// VacuumStmt is a statement that compacts the data of the database on disk,
+// or of a table and its indexes, and returns their size before and after.
+// Compacting the whole database reclaims the space of the dropped tables
+// and indexes.
+type VacuumStmt struct {
+	TableName string
+}
+
+func (stmt *VacuumStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// IsReadOnly returns true: the compaction doesn't change the data.
+func (stmt *VacuumStmt) IsReadOnly() bool {
+	return true
+}
+
+// Run compacts the database, or the table.
+func (stmt *VacuumStmt) Run(ctx *Context) (Result, error) {
+	var stats *database.VacuumStats
+	var err error
+
+	if stmt.TableName == "" {
+		stats, err = ctx.DB.Vacuum()
+	} else {
+		var tableName string
+		tableName, err = ctx.Tx.Catalog.ResolveName(ctx.Tx, database.RelationTableType, stmt.TableName)
+		if err != nil {
+			return Result{}, err
+		}
+		stats, err = ctx.DB.VacuumTable(tableName)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	newStatement := PreparedStreamStmt{
+		Stream: &stream.Stream{
+			Op: rows.Project(
+				&expr.NamedExpr{ExprName: "size_before", Expr: expr.LiteralValue{Value: types.NewBigintValue(int64(stats.SizeBefore))}},
+				&expr.NamedExpr{ExprName: "size_after", Expr: expr.LiteralValue{Value: types.NewBigintValue(int64(stats.SizeAfter))}},
+			),
+		},
+		ReadOnly: true,
+	}
+	return newStatement.Run(ctx)
+}
@@ -0,0 +1,37 @@
+package statement_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVacuum(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b');
+	`)
+	require.NoError(t, err)
+
+	r, err := db.QueryRow("VACUUM")
+	require.NoError(t, err)
+	var sizeBefore, sizeAfter int64
+	require.NoError(t, r.Scan(&sizeBefore, &sizeAfter))
+	require.Positive(t, sizeBefore)
+	require.Positive(t, sizeAfter)
+
+	r, err = db.QueryRow("VACUUM test")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&sizeBefore, &sizeAfter))
+	require.Positive(t, sizeAfter)
+
+	_, err = db.QueryRow("VACUUM unknown")
+	require.True(t, chai.IsNotFoundError(err))
+}
@@ -113,9 +113,21 @@ func marshalText(dst *bytes.Buffer, v types.Value, prefix, indent string, depth
 		}
 		dst.WriteString(strconv.FormatFloat(types.AsFloat64(v), fmt, prec, 64))
 		return nil
+	case types.TypeDecimal:
+		dst.WriteString(v.String())
+		return nil
 	case types.TypeTimestamp:
 		dst.WriteString(strconv.Quote(types.AsTime(v).Format(time.RFC3339Nano)))
 		return nil
+	case types.TypeDate:
+		dst.WriteString(strconv.Quote(types.AsTime(v).Format(time.DateOnly)))
+		return nil
+	case types.TypeInterval, types.TypeUUID:
+		dst.WriteString(v.String())
+		return nil
+	case types.TypeVector:
+		dst.WriteString(v.(types.VectorValue).Format())
+		return nil
 	case types.TypeText:
 		dst.WriteString(strconv.Quote(types.AsString(v)))
 		return nil
@@ -1,6 +1,7 @@
 package row
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
@@ -125,6 +126,8 @@ func (m reflectMapObject) MarshalJSON() ([]byte, error) {
 }
 
 // NewFromStruct creates an object from a struct using reflection.
+// Nested structs, maps and slices, other than byte and float32 slices,
+// are encoded as JSON texts.
 func NewFromStruct(s any) (Row, error) {
 	ref := reflect.Indirect(reflect.ValueOf(s))
 
@@ -178,7 +181,13 @@ func newFromStruct(ref reflect.Value) (Row, error) {
 			continue
 		}
 
-		v, err := NewValue(f.Interface())
+		var v types.Value
+		var err error
+		if isJSONType(f.Type()) {
+			v, err = newJSONValue(f.Interface())
+		} else {
+			v, err = NewValue(f.Interface())
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -197,10 +206,41 @@ func newFromStruct(ref reflect.Value) (Row, error) {
 	return &cb, nil
 }
 
+var timeType = reflect.TypeOf(time.Time{})
+
+// isJSONType reports whether the struct fields of type t are stored
+// as JSON texts: nested structs, maps and slices without
+// a type of their own.
+func isJSONType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return t != timeType
+	case reflect.Map:
+		return true
+	case reflect.Slice:
+		k := t.Elem().Kind()
+		return k != reflect.Uint8 && k != reflect.Float32
+	}
+
+	return false
+}
+
+// newJSONValue returns x encoded as a JSON text.
+func newJSONValue(x any) (types.Value, error) {
+	b, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewTextValue(string(b)), nil
+}
+
 // NewValue creates a value whose type is infered from x.
 func NewValue(x any) (types.Value, error) {
 	// Attempt exact matches first:
 	switch v := x.(type) {
+	case types.Value:
+		return v, nil
 	case time.Duration:
 		return types.NewBigintValue(v.Nanoseconds()), nil
 	case time.Time:
@@ -235,7 +275,23 @@ func NewValue(x any) (types.Value, error) {
 		if reflect.TypeOf(v.Interface()).Elem().Kind() == reflect.Uint8 {
 			return types.NewBlobValue(v.Bytes()), nil
 		}
+		// slices of float32, such as embeddings
+		if v.Type().Elem().Kind() == reflect.Float32 {
+			vec := make([]float32, v.Len())
+			for i := range vec {
+				vec[i] = float32(v.Index(i).Float())
+			}
+			return types.NewVectorValue(vec), nil
+		}
 		return nil, errors.Errorf("unsupported slice type: %T", x)
+	case reflect.Array:
+		// arrays of 16 bytes, such as the UUID types of most libraries
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == 16 {
+			var u [16]byte
+			reflect.Copy(reflect.ValueOf(&u).Elem(), v)
+			return types.NewUUIDValue(u), nil
+		}
+		return nil, errors.Errorf("unsupported array type: %T", x)
 	case reflect.Interface:
 		if v.IsNil() {
 			return types.NewNullValue(), nil
@@ -315,21 +371,21 @@ func (cb ColumnBuffer) Get(column string) (types.Value, error) {
 		}
 	}
 
-	return nil, errors.Wrapf(types.ErrColumnNotFound, "%s not found", column)
+	// no stack trace is recorded: looking up missing columns is common,
+	// for instance when inserting rows without their default values
+	return nil, errors.WithMessagef(types.ErrColumnNotFound, "%s not found", column)
 }
 
 // Set replaces a column if it already exists or creates one if not.
 func (cb *ColumnBuffer) Set(column string, v types.Value) error {
-	_, err := cb.Get(column)
-	if errors.Is(err, types.ErrColumnNotFound) {
-		cb.Add(column, v)
-		return nil
-	}
-	if err != nil {
-		return err
+	for i := range cb.columns {
+		if cb.columns[i].Name == column {
+			cb.columns[i].Value = v
+			return nil
+		}
 	}
 
-	_ = cb.Replace(column, v)
+	cb.Add(column, v)
 	return nil
 }
 
@@ -459,6 +515,16 @@ func Flatten(r Row) []types.Value {
 	return values
 }
 
+// Values returns the values of the row, in the order of its columns.
+func Values(r Row) []types.Value {
+	var values []types.Value
+	r.Iterate(func(column string, v types.Value) error {
+		values = append(values, v)
+		return nil
+	})
+	return values
+}
+
 func Unflatten(values []types.Value) Row {
 	cb := NewColumnBuffer()
 	for i := 0; i < len(values); i += 2 {
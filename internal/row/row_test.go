@@ -22,6 +22,7 @@ func TestNewValue(t *testing.T) {
 	type myInt16 int16
 	type myInt64 int64
 	type myFloat64 float64
+	type myUUID [16]byte
 
 	now := time.Now()
 
@@ -56,6 +57,7 @@ func TestNewValue(t *testing.T) {
 		{"myInt16", myInt16(500), int64(500)},
 		{"myInt64", myInt64(10), int64(10)},
 		{"myFloat64", myFloat64(10.1), float64(10.1)},
+		{"myUUID", myUUID{1, 2, 3}, [16]byte{1, 2, 3}},
 	}
 
 	for _, test := range tests {
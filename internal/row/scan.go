@@ -2,6 +2,7 @@ package row
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -219,6 +220,12 @@ func scanValue(v types.Value, ref reflect.Value) error {
 		return nil
 	}
 
+	// intervals are scanned into durations using their length
+	if v.Type() == types.TypeInterval && ref.Type() == reflect.TypeOf(time.Duration(0)) {
+		ref.SetInt(v.(types.IntervalValue).Length() * int64(time.Microsecond))
+		return nil
+	}
+
 	switch ref.Kind() {
 	case reflect.String:
 		v, err := v.CastAs(types.TypeText)
@@ -284,20 +291,38 @@ func scanValue(v types.Value, ref reflect.Value) error {
 		return nil
 	case reflect.Slice:
 		if ref.Type().Elem().Kind() == reflect.Uint8 {
-			if v.Type() != types.TypeText && v.Type() != types.TypeBlob {
-				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
-			}
-			if v.Type() == types.TypeText {
+			switch v.Type() {
+			case types.TypeText:
 				ref.SetBytes([]byte(types.AsString(v)))
-			} else {
+			case types.TypeBlob:
 				ref.SetBytes(types.AsByteSlice(v))
+			case types.TypeUUID:
+				u := v.V().([16]byte)
+				ref.SetBytes(u[:])
+			default:
+				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
 			}
 			return nil
 		}
-		return NewErrUnsupportedType(ref.Interface(), "Invalid type")
+		if ref.Type().Elem().Kind() == reflect.Float32 {
+			v, err := v.CastAs(types.TypeVector)
+			if err != nil {
+				return err
+			}
+			vec := v.V().([]float32)
+			s := reflect.MakeSlice(ref.Type(), len(vec), len(vec))
+			for i, x := range vec {
+				s.Index(i).SetFloat(float64(x))
+			}
+			ref.Set(s)
+			return nil
+		}
+		return scanJSON(v, ref)
+	case reflect.Map:
+		return scanJSON(v, ref)
 	case reflect.Array:
 		if ref.Type().Elem().Kind() == reflect.Uint8 {
-			if v.Type() != types.TypeText && v.Type() != types.TypeBlob {
+			if v.Type() != types.TypeText && v.Type() != types.TypeBlob && v.Type() != types.TypeUUID {
 				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
 			}
 			reflect.Copy(ref, reflect.ValueOf(v.V()))
@@ -318,15 +343,29 @@ func scanValue(v types.Value, ref reflect.Value) error {
 
 			ref.Set(reflect.ValueOf(parsed))
 			return nil
-		case types.TypeTimestamp:
+		case types.TypeTimestamp, types.TypeDate:
 			ref.Set(reflect.ValueOf(types.AsTime(v)))
 			return nil
 		}
 	}
 
+	if ref.Kind() == reflect.Struct {
+		return scanJSON(v, ref)
+	}
+
 	return NewErrUnsupportedType(ref.Interface(), "Invalid type")
 }
 
+// scanJSON decodes the JSON text v into ref. Nested structs, maps
+// and slices are stored as JSON texts by NewFromStruct.
+func scanJSON(v types.Value, ref reflect.Value) error {
+	if v.Type() != types.TypeText || !ref.CanAddr() {
+		return NewErrUnsupportedType(ref.Interface(), "Invalid type")
+	}
+
+	return json.Unmarshal([]byte(types.AsString(v)), ref.Addr().Interface())
+}
+
 // ScanRow scans a row into dest which must be either a struct pointer, a map or a map pointer.
 func ScanRow(r Row, t any) error {
 	ref := reflect.ValueOf(t)
@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/cockroachdb/errors"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
 )
@@ -17,7 +20,7 @@ func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ *statement.
 	}
 
 	// Parse new table name.
-	stmt.NewTableName, err = p.parseIdent()
+	stmt.NewTableName, err = p.parseQualifiedIdent()
 	if err != nil {
 		return nil, err
 	}
@@ -48,30 +51,238 @@ func (p *Parser) parseAlterTableAddColumnStatement(tableName string) (*statement
 	return &stmt, nil
 }
 
+// parseAlterSequenceStatement parses an ALTER SEQUENCE statement.
+// This function assumes the ALTER SEQUENCE tokens have already been consumed.
+func (p *Parser) parseAlterSequenceStatement() (*statement.AlterSequenceStmt, error) {
+	var stmt statement.AlterSequenceStmt
+	var err error
+
+	// Parse IF EXISTS
+	stmt.IfExists, err = p.parseOptional(scanner.IF, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse sequence name
+	stmt.SequenceName, err = p.parseQualifiedIdent()
+	if err != nil {
+		pErr := errors.Unwrap(err).(*ParseError)
+		pErr.Expected = []string{"sequence_name"}
+		return nil, pErr
+	}
+
+	opts, err := p.parseSequenceOptions(true)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.IncrementBy = opts.incrementBy
+	stmt.Min, stmt.NoMin = opts.min, opts.noMin
+	stmt.Max, stmt.NoMax = opts.max, opts.noMax
+	stmt.Start = opts.start
+	stmt.Cache = opts.cache
+	stmt.Cycle = opts.cycle
+	stmt.Restart, stmt.RestartWith = opts.restart, opts.restartWith
+
+	return &stmt, nil
+}
+
 // parseAlterStatement parses a Alter query string and returns a Statement AST row.
 func (p *Parser) parseAlterStatement() (statement.Statement, error) {
 	var err error
 
-	// Parse "TABLE".
-	if err := p.ParseTokens(scanner.ALTER, scanner.TABLE); err != nil {
+	if err := p.ParseTokens(scanner.ALTER); err != nil {
 		return nil, err
 	}
 
+	// Parse "TABLE", "SEQUENCE" or "USER".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.TABLE:
+	case tok == scanner.SEQUENCE:
+		return p.parseAlterSequenceStatement()
+	case tok == scanner.IDENT && strings.EqualFold(lit, "USER"):
+		return p.parseAlterUserStatement()
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "SEQUENCE", "USER"}, pos)
+	}
+
 	// Parse table name.
-	tableName, err := p.parseIdent()
+	tableName, err := p.parseQualifiedIdent()
 	if err != nil {
 		pErr := errors.Unwrap(err).(*ParseError)
 		pErr.Expected = []string{"table_name"}
 		return nil, pErr
 	}
 
-	tok, pos, lit := p.ScanIgnoreWhitespace()
+	tok, pos, lit = p.ScanIgnoreWhitespace()
 	switch tok {
 	case scanner.RENAME:
 		return p.parseAlterTableRenameStatement(tableName)
 	case scanner.ADD_KEYWORD:
+		if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "PARTITION") {
+			p.Unscan()
+			return p.parseAlterTableAddPartitionStatement(tableName)
+		}
+		p.Unscan()
+
 		return p.parseAlterTableAddColumnStatement(tableName)
+	case scanner.SET:
+		return p.parseAlterTableSetRetentionStatement(tableName)
+	case scanner.DROP:
+		if p.parseOptionalIdent("PARTITION") {
+			name, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+
+			return &statement.AlterTableDropPartitionStmt{TableName: tableName, PartitionName: name}, nil
+		}
+
+		if err := p.parseRetentionKeyword(); err != nil {
+			return nil, err
+		}
+
+		return &statement.AlterTableSetRetentionStmt{TableName: tableName}, nil
+	case scanner.IDENT:
+		if strings.EqualFold(lit, "INFER") {
+			return p.parseAlterTableInferSchemaStatement(tableName)
+		}
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "RENAME", "SET", "DROP", "INFER"}, pos)
+}
+
+// parseAlterTableInferSchemaStatement parses:
+//
+//	ALTER TABLE table_name INFER SCHEMA [DRY RUN]
+//
+// This function assumes the ALTER TABLE table_name INFER tokens have already been consumed.
+func (p *Parser) parseAlterTableInferSchemaStatement(tableName string) (*statement.AlterTableInferSchemaStmt, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "SCHEMA") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SCHEMA"}, pos)
+	}
+
+	stmt := statement.AlterTableInferSchemaStmt{TableName: tableName}
+
+	if p.parseOptionalIdent("DRY") {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "RUN") {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"RUN"}, pos)
+		}
+		stmt.DryRun = true
+	}
+
+	return &stmt, nil
+}
+
+// parseAlterTableAddPartitionStatement parses:
+//
+//	ALTER TABLE table_name ADD PARTITION name VALUES LESS THAN (expr | MAXVALUE)
+//
+// This function assumes the ALTER TABLE table_name ADD tokens have already been consumed.
+func (p *Parser) parseAlterTableAddPartitionStatement(tableName string) (*statement.AlterTableAddPartitionStmt, error) {
+	part, err := p.parsePartitionDefinition()
+	if err != nil {
+		return nil, err
+	}
+
+	return &statement.AlterTableAddPartitionStmt{TableName: tableName, Partition: *part}, nil
+}
+
+// parseAlterTableSetRetentionStatement parses:
+//
+//	ALTER TABLE table_name SET RETENTION (older_than = 'period', column = 'column_name')
+//
+// This function assumes the ALTER TABLE table_name SET tokens have already been consumed.
+func (p *Parser) parseAlterTableSetRetentionStatement(tableName string) (*statement.AlterTableSetRetentionStmt, error) {
+	if err := p.parseRetentionKeyword(); err != nil {
+		return nil, err
+	}
+
+	policy, err := p.parseRetentionPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	return &statement.AlterTableSetRetentionStmt{TableName: tableName, Policy: policy}, nil
+}
+
+func (p *Parser) parseRetentionKeyword() error {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "RETENTION") {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"RETENTION"}, pos)
+	}
+
+	return nil
+}
+
+// parseRetentionPolicy parses the options of a retention policy:
+//
+//	(older_than = 'period', column = column_name)
+//
+// The column name can be quoted.
+func (p *Parser) parseRetentionPolicy() (*database.RetentionPolicy, error) {
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	var policy database.RetentionPolicy
+	for {
+		// COLUMN is a keyword
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.IDENT && tok != scanner.COLUMN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"older_than", "column"}, pos)
+		}
+		option := strings.ToLower(lit)
+		if tok == scanner.COLUMN {
+			option = "column"
+		}
+		if err := p.ParseTokens(scanner.EQ); err != nil {
+			return nil, err
+		}
+
+		switch option {
+		case "older_than":
+			if policy.OlderThan != 0 {
+				return nil, &ParseError{Message: "duplicate retention option older_than"}
+			}
+
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			if tok != scanner.STRING {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"STRING"}, pos)
+			}
+
+			d, err := database.ParseRetentionPeriod(lit)
+			if err != nil {
+				return nil, &ParseError{Message: err.Error()}
+			}
+			policy.OlderThan = d
+		case "column":
+			if policy.Column != "" {
+				return nil, &ParseError{Message: "duplicate retention option column"}
+			}
+
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			if tok != scanner.STRING && tok != scanner.IDENT {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"IDENT", "STRING"}, pos)
+			}
+			policy.Column = lit
+		default:
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"older_than", "column"}, pos)
+		}
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	if policy.OlderThan == 0 || policy.Column == "" {
+		return nil, &ParseError{Message: "retention policy requires the older_than and column options"}
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "RENAME"}, pos)
+	return &policy, nil
 }
@@ -2,11 +2,13 @@ package parser_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/testutil"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/stretchr/testify/require"
 )
@@ -38,6 +40,105 @@ func TestParserAlterTable(t *testing.T) {
 	}
 }
 
+func TestParserAlterTableRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Set", "ALTER TABLE foo SET RETENTION (older_than = '30 days', column = 'ts')", &statement.AlterTableSetRetentionStmt{
+			TableName: "foo",
+			Policy:    &database.RetentionPolicy{Column: "ts", OlderThan: 30 * 24 * time.Hour},
+		}, false},
+		{"Set / identifier", "ALTER TABLE foo SET retention (column = ts, older_than = '1 hour')", &statement.AlterTableSetRetentionStmt{
+			TableName: "foo",
+			Policy:    &database.RetentionPolicy{Column: "ts", OlderThan: time.Hour},
+		}, false},
+		{"Drop", "ALTER TABLE foo DROP RETENTION", &statement.AlterTableSetRetentionStmt{TableName: "foo"}, false},
+		{"With error / missing options", "ALTER TABLE foo SET RETENTION", nil, true},
+		{"With error / missing column", "ALTER TABLE foo SET RETENTION (older_than = '30 days')", nil, true},
+		{"With error / duplicate option", "ALTER TABLE foo SET RETENTION (older_than = '30 days', older_than = '1 day', column = ts)", nil, true},
+		{"With error / unknown option", "ALTER TABLE foo SET RETENTION (older_than = '30 days', column = ts, foo = 1)", nil, true},
+		{"With error / bad period", "ALTER TABLE foo SET RETENTION (older_than = 'thirty days', column = ts)", nil, true},
+		{"With error / period not a string", "ALTER TABLE foo SET RETENTION (older_than = 30, column = ts)", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserAlterTableInferSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Basic", "ALTER TABLE foo INFER SCHEMA", &statement.AlterTableInferSchemaStmt{TableName: "foo"}, false},
+		{"Dry run", "ALTER TABLE foo infer schema dry run", &statement.AlterTableInferSchemaStmt{TableName: "foo", DryRun: true}, false},
+		{"With error / missing SCHEMA", "ALTER TABLE foo INFER", nil, true},
+		{"With error / missing RUN", "ALTER TABLE foo INFER SCHEMA DRY", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserAlterTablePartition(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Add", "ALTER TABLE foo ADD PARTITION p3 VALUES LESS THAN (30)", &statement.AlterTableAddPartitionStmt{
+			TableName: "foo",
+			Partition: database.TablePartition{Name: "p3", Bound: types.NewIntegerValue(30)},
+		}, false},
+		{"Add / MAXVALUE", "ALTER TABLE foo ADD PARTITION pmax VALUES LESS THAN (MAXVALUE)", &statement.AlterTableAddPartitionStmt{
+			TableName: "foo",
+			Partition: database.TablePartition{Name: "pmax"},
+		}, false},
+		{"Drop", "ALTER TABLE foo DROP PARTITION p1", &statement.AlterTableDropPartitionStmt{TableName: "foo", PartitionName: "p1"}, false},
+		{"With error / missing bound", "ALTER TABLE foo ADD PARTITION p3", nil, true},
+		{"With error / missing name", "ALTER TABLE foo DROP PARTITION", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
 func TestParserAlterTableAddColumn(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -100,3 +201,51 @@ func TestParserAlterTableAddColumn(t *testing.T) {
 		})
 	}
 }
+
+func TestParserAlterSequence(t *testing.T) {
+	u64 := func(v uint64) *uint64 { return &v }
+	boolp := func(v bool) *bool { return &v }
+
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"No options", "ALTER SEQUENCE seq", &statement.AlterSequenceStmt{SequenceName: "seq"}, false},
+		{"If exists", "ALTER SEQUENCE IF EXISTS seq CYCLE", &statement.AlterSequenceStmt{SequenceName: "seq", IfExists: true, Cycle: boolp(true)}, false},
+		{"All options", "ALTER SEQUENCE seq AS BIGINT INCREMENT BY -2 MINVALUE -10 MAXVALUE 10 START WITH 5 CACHE 20 NO CYCLE RESTART WITH 3",
+			&statement.AlterSequenceStmt{
+				SequenceName: "seq",
+				IncrementBy:  testutil.Int64Ptr(-2),
+				Min:          testutil.Int64Ptr(-10),
+				Max:          testutil.Int64Ptr(10),
+				Start:        testutil.Int64Ptr(5),
+				Cache:        u64(20),
+				Cycle:        boolp(false),
+				Restart:      true,
+				RestartWith:  testutil.Int64Ptr(3),
+			}, false},
+		{"No min and max", "ALTER SEQUENCE seq NO MINVALUE NO MAXVALUE", &statement.AlterSequenceStmt{SequenceName: "seq", NoMin: true, NoMax: true}, false},
+		{"Restart", "ALTER SEQUENCE seq RESTART", &statement.AlterSequenceStmt{SequenceName: "seq", Restart: true}, false},
+		{"Restart without with", "ALTER SEQUENCE seq RESTART -4 INCREMENT 1", &statement.AlterSequenceStmt{SequenceName: "seq", Restart: true, RestartWith: testutil.Int64Ptr(-4), IncrementBy: testutil.Int64Ptr(1)}, false},
+		{"With error / restart with missing value", "ALTER SEQUENCE seq RESTART WITH", nil, true},
+		{"With error / redundant options", "ALTER SEQUENCE seq CYCLE NO CYCLE", nil, true},
+		{"With error / zero increment", "ALTER SEQUENCE seq INCREMENT BY 0", nil, true},
+		{"With error / negative cache", "ALTER SEQUENCE seq CACHE -1", nil, true},
+		{"With error / missing name", "ALTER SEQUENCE", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// parseAttachStatement parses an attach statement.
+//
+//	ATTACH [DATABASE] 'path' AS name
+func (p *Parser) parseAttachStatement() (query.AttachStmt, error) {
+	var stmt query.AttachStmt
+
+	// Parse "ATTACH".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "ATTACH") {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"ATTACH"}, pos)
+	}
+
+	// Parse optional "DATABASE".
+	p.parseOptionalIdent("DATABASE")
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING || lit == "" {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"path"}, pos)
+	}
+	stmt.Path = lit
+
+	if err := p.ParseTokens(scanner.AS); err != nil {
+		return stmt, err
+	}
+
+	var err error
+	stmt.Name, err = p.parseIdent()
+	return stmt, err
+}
+
+// parseDetachStatement parses a detach statement.
+//
+//	DETACH [DATABASE] name
+func (p *Parser) parseDetachStatement() (query.DetachStmt, error) {
+	var stmt query.DetachStmt
+
+	// Parse "DETACH".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "DETACH") {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"DETACH"}, pos)
+	}
+
+	// Parse optional "DATABASE".
+	p.parseOptionalIdent("DATABASE")
+
+	var err error
+	stmt.Name, err = p.parseIdent()
+	return stmt, err
+}
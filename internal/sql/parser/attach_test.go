@@ -0,0 +1,43 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserAttach(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Attach", "ATTACH DATABASE 'other.chai' AS other", query.AttachStmt{Path: "other.chai", Name: "other"}, false},
+		{"Without DATABASE", "attach '/tmp/db' as `my db`", query.AttachStmt{Path: "/tmp/db", Name: "my db"}, false},
+		{"No path", "ATTACH DATABASE AS other", nil, true},
+		{"Empty path", "ATTACH DATABASE '' AS other", nil, true},
+		{"Identifier path", "ATTACH DATABASE other AS other", nil, true},
+		{"No AS", "ATTACH DATABASE 'other.chai' other", nil, true},
+		{"No name", "ATTACH DATABASE 'other.chai' AS", nil, true},
+		{"Detach", "DETACH DATABASE other", query.DetachStmt{Name: "other"}, false},
+		{"Detach without DATABASE", "detach other", query.DetachStmt{Name: "other"}, false},
+		{"Detach no name", "DETACH DATABASE", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
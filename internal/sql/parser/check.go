@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// parseCheckIndexStatement parses a CHECK INDEX statement.
+func (p *Parser) parseCheckIndexStatement() (statement.Statement, error) {
+	stmt := statement.NewCheckIndexStatement()
+
+	// Parse "CHECK INDEX".
+	if err := p.ParseTokens(scanner.CHECK, scanner.INDEX); err != nil {
+		return nil, err
+	}
+
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	if tok == scanner.IDENT {
+		var err error
+		stmt.TableOrIndexName, err = p.parseQualifiedIdent()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
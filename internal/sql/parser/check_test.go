@@ -0,0 +1,39 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserCheckIndex(t *testing.T) {
+	c1 := statement.NewCheckIndexStatement()
+	c2 := statement.NewCheckIndexStatement()
+	c2.TableOrIndexName = "tableOrIndex"
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"All", "CHECK INDEX", c1, false},
+		{"With ident", "CHECK INDEX tableOrIndex", c2, false},
+		{"Without INDEX", "CHECK tableOrIndex", nil, true},
+		{"With extra", "CHECK INDEX tableOrIndex tableOrIndex", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -3,12 +3,16 @@ package parser
 import (
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
 )
 
 // parseCreateStatement parses a create string and returns a Statement AST row.
@@ -32,9 +36,144 @@ func (p *Parser) parseCreateStatement() (statement.Statement, error) {
 		return p.parseCreateIndexStatement(false)
 	case scanner.SEQUENCE:
 		return p.parseCreateSequenceStatement()
+	case scanner.IDENT:
+		// SCHEMA is not a keyword, to allow using it as an identifier.
+		if strings.EqualFold(lit, "SCHEMA") {
+			return p.parseCreateSchemaStatement()
+		}
+		// FUNCTION is not a keyword either.
+		if strings.EqualFold(lit, "FUNCTION") {
+			return p.parseCreateFunctionStatement()
+		}
+		// nor USER.
+		if strings.EqualFold(lit, "USER") {
+			return p.parseCreateUserStatement()
+		}
+		// nor TEMP and TEMPORARY.
+		if strings.EqualFold(lit, "TEMP") || strings.EqualFold(lit, "TEMPORARY") {
+			if err := p.ParseTokens(scanner.TABLE); err != nil {
+				return nil, err
+			}
+
+			return p.parseCreateTemporaryTableStatement()
+		}
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE", "SCHEMA", "FUNCTION", "USER", "TEMPORARY"}, pos)
+}
+
+// parseCreateTemporaryTableStatement parses a create temporary table string and returns a Statement AST row.
+// This function assumes the CREATE TEMPORARY TABLE tokens have already been consumed.
+//
+//	CREATE { TEMP | TEMPORARY } TABLE [IF NOT EXISTS] name (...) [WITH (...)] [ON COMMIT { PRESERVE ROWS | DELETE ROWS | DROP }]
+func (p *Parser) parseCreateTemporaryTableStatement() (*statement.CreateTableStmt, error) {
+	stmt, err := p.parseCreateTableStatement()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Info.Temporary = true
+
+	// Parse "ON COMMIT".
+	if ok, err := p.parseOptional(scanner.ON, scanner.COMMIT); !ok || err != nil {
+		return stmt, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.DROP:
+		stmt.Info.OnCommit = database.OnCommitDrop
+		return stmt, nil
+	case tok == scanner.DELETE:
+		stmt.Info.OnCommit = database.OnCommitDeleteRows
+	case tok == scanner.IDENT && strings.EqualFold(lit, "PRESERVE"):
+		stmt.Info.OnCommit = database.OnCommitPreserveRows
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"PRESERVE", "DELETE", "DROP"}, pos)
+	}
+
+	if !p.parseOptionalIdent("ROWS") {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ROWS"}, pos)
+	}
+
+	return stmt, nil
+}
+
+// parseCreateFunctionStatement parses a create function string and returns a Statement AST row.
+// This function assumes the CREATE FUNCTION tokens have already been consumed.
+//
+//	CREATE FUNCTION [IF NOT EXISTS] name([param, ...]) RETURNS (expr)
+func (p *Parser) parseCreateFunctionStatement() (*statement.CreateFunctionStmt, error) {
+	var stmt statement.CreateFunctionStmt
+	var err error
+
+	// Parse IF NOT EXISTS
+	stmt.IfNotExists, err = p.parseOptional(scanner.IF, scanner.NOT, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse function name
+	stmt.Info.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse parameters
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		p.Unscan()
+
+		stmt.Info.Params, err = p.parseIdentList()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.ParseTokens(scanner.RPAREN); err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse RETURNS (expr)
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "RETURNS") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"RETURNS"}, pos)
+	}
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+	body, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+	stmt.Info.Body = body
+
+	return &stmt, nil
+}
+
+// parseCreateSchemaStatement parses a create schema string and returns a Statement AST row.
+// This function assumes the CREATE SCHEMA tokens have already been consumed.
+func (p *Parser) parseCreateSchemaStatement() (*statement.CreateSchemaStmt, error) {
+	var stmt statement.CreateSchemaStmt
+	var err error
+
+	// Parse IF NOT EXISTS
+	stmt.IfNotExists, err = p.parseOptional(scanner.IF, scanner.NOT, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse schema name
+	stmt.Info.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE"}, pos)
+	return &stmt, nil
 }
 
 // parseCreateTableStatement parses a create table string and returns a Statement AST row.
@@ -50,7 +189,7 @@ func (p *Parser) parseCreateTableStatement() (*statement.CreateTableStmt, error)
 	}
 
 	// Parse table name
-	stmt.Info.TableName, err = p.parseIdent()
+	stmt.Info.TableName, err = p.parseQualifiedIdent()
 	if err != nil {
 		return nil, err
 	}
@@ -61,9 +200,269 @@ func (p *Parser) parseCreateTableStatement() (*statement.CreateTableStmt, error)
 		return nil, err
 	}
 
+	// parse table options
+	err = p.parseTableOptions(&stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse partitioning
+	err = p.parsePartitioning(&stmt)
+	if err != nil {
+		return nil, err
+	}
+
 	return &stmt, err
 }
 
+// parseTableOptions parses the optional WITH clause of a CREATE TABLE statement:
+//
+//	WITH (ttl_field = expires_at, retention = (older_than = '30 days', column = created_at), compression = 'zstd', strict = true)
+func (p *Parser) parseTableOptions(stmt *statement.CreateTableStmt) error {
+	if ok, err := p.parseOptional(scanner.WITH, scanner.LPAREN); !ok || err != nil {
+		return err
+	}
+
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.IDENT {
+			return newParseError(scanner.Tokstr(tok, lit), []string{"ttl_field", "retention", "compression", "strict"}, pos)
+		}
+
+		switch strings.ToLower(lit) {
+		case "ttl_field":
+			if err := p.ParseTokens(scanner.EQ); err != nil {
+				return err
+			}
+
+			column, err := p.parseIdent()
+			if err != nil {
+				return err
+			}
+
+			cc := stmt.Info.GetColumnConstraint(column)
+			if cc == nil {
+				return &ParseError{Message: fmt.Sprintf("column %q does not exist for table %q", column, stmt.Info.TableName)}
+			}
+			if cc.Type != types.TypeTimestamp {
+				return &ParseError{Message: fmt.Sprintf("ttl_field %q must be of type TIMESTAMP", column)}
+			}
+
+			stmt.Info.TTLColumn = column
+		case "retention":
+			if err := p.ParseTokens(scanner.EQ); err != nil {
+				return err
+			}
+
+			policy, err := p.parseRetentionPolicy()
+			if err != nil {
+				return err
+			}
+
+			err = policy.Validate(&stmt.Info)
+			if err != nil {
+				return &ParseError{Message: err.Error()}
+			}
+
+			stmt.Info.Retention = policy
+		case "compression":
+			if err := p.ParseTokens(scanner.EQ); err != nil {
+				return err
+			}
+
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			if tok != scanner.STRING {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"'none'", "'snappy'", "'zstd'"}, pos)
+			}
+
+			c, err := database.ParseCompression(lit)
+			if err != nil {
+				return &ParseError{Message: err.Error(), Pos: pos}
+			}
+
+			stmt.Info.Compression = c
+		case "strict":
+			if err := p.ParseTokens(scanner.EQ); err != nil {
+				return err
+			}
+
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			switch tok {
+			case scanner.TRUE:
+				stmt.Info.Strict = true
+			case scanner.FALSE:
+				stmt.Info.Strict = false
+			default:
+				return newParseError(scanner.Tokstr(tok, lit), []string{"TRUE", "FALSE"}, pos)
+			}
+		default:
+			return newParseError(scanner.Tokstr(tok, lit), []string{"ttl_field", "retention", "compression", "strict"}, pos)
+		}
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	return p.ParseTokens(scanner.RPAREN)
+}
+
+// parsePartitioning parses the optional PARTITION BY clause of a CREATE TABLE statement:
+//
+//	PARTITION BY RANGE (column) (PARTITION name VALUES LESS THAN (expr | MAXVALUE), ...)
+//	PARTITION BY HASH (column) PARTITIONS n
+func (p *Parser) parsePartitioning(stmt *statement.CreateTableStmt) error {
+	if !p.parseOptionalIdent("PARTITION") {
+		return nil
+	}
+
+	if err := p.ParseTokens(scanner.BY); err != nil {
+		return err
+	}
+
+	var partitioning database.TablePartitioning
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "RANGE"):
+		partitioning.Method = database.PartitionByRange
+	case tok == scanner.IDENT && strings.EqualFold(lit, "HASH"):
+		partitioning.Method = database.PartitionByHash
+	default:
+		return newParseError(scanner.Tokstr(tok, lit), []string{"RANGE", "HASH"}, pos)
+	}
+
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return err
+	}
+	column, err := p.parseIdent()
+	if err != nil {
+		return err
+	}
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return err
+	}
+
+	cc := stmt.Info.GetColumnConstraint(column)
+	if cc == nil {
+		return &ParseError{Message: fmt.Sprintf("column %q does not exist for table %q", column, stmt.Info.TableName)}
+	}
+	partitioning.Column = column
+
+	if partitioning.Method == database.PartitionByHash {
+		if !p.parseOptionalIdent("PARTITIONS") {
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			return newParseError(scanner.Tokstr(tok, lit), []string{"PARTITIONS"}, pos)
+		}
+
+		n, err := p.parseInteger()
+		if err != nil {
+			return err
+		}
+		if n < 1 || n > 1024 {
+			return &ParseError{Message: "the number of partitions must be between 1 and 1024"}
+		}
+
+		for i := range int(n) {
+			partitioning.Partitions = append(partitioning.Partitions, database.TablePartition{Name: database.HashPartitionName(i)})
+		}
+
+		stmt.Info.Partitioning = &partitioning
+		return nil
+	}
+
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return err
+	}
+
+	for {
+		part, err := p.parsePartitionDefinition()
+		if err != nil {
+			return err
+		}
+
+		if part.Bound != nil {
+			part.Bound, err = part.Bound.CastAs(cc.Type)
+			if err != nil {
+				return &ParseError{Message: fmt.Sprintf("invalid bound for partition %q: %v", part.Name, err)}
+			}
+		}
+
+		partitioning.Partitions = append(partitioning.Partitions, *part)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return err
+	}
+
+	stmt.Info.Partitioning = &partitioning
+	return nil
+}
+
+// parsePartitionDefinition parses a partition of a table partitioned by range:
+//
+//	PARTITION name VALUES LESS THAN (expr | MAXVALUE)
+//
+// The bound is nil for MAXVALUE.
+func (p *Parser) parsePartitionDefinition() (*database.TablePartition, error) {
+	if !p.parseOptionalIdent("PARTITION") {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"PARTITION"}, pos)
+	}
+
+	var part database.TablePartition
+	var err error
+
+	part.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.ParseTokens(scanner.VALUES); err != nil {
+		return nil, err
+	}
+	for _, kw := range []string{"LESS", "THAN"} {
+		if !p.parseOptionalIdent(kw) {
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{kw}, pos)
+		}
+	}
+
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.parseOptional(scanner.MAXVALUE); err != nil {
+		return nil, err
+	} else if !ok {
+		e, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		// bounds are constant expressions
+		part.Bound, err = e.Eval(&environment.Environment{})
+		if err != nil {
+			return nil, &ParseError{Message: fmt.Sprintf("invalid bound for partition %q: %v", part.Name, err)}
+		}
+		if part.Bound.Type() == types.TypeNull {
+			return nil, &ParseError{Message: fmt.Sprintf("bound of partition %q cannot be NULL", part.Name)}
+		}
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return &part, nil
+}
+
 func (p *Parser) parseConstraints(stmt *statement.CreateTableStmt) error {
 	// Parse ( token.
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -146,9 +545,26 @@ func (p *Parser) parseColumnDefinition() (*database.ColumnConstraint, []*databas
 		return nil, nil, err
 	}
 
-	cc.Type, err = p.parseType()
-	if err != nil {
-		return nil, nil, err
+	// SERIAL and BIGSERIAL are shorthands for auto-incremented integers
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "SERIAL") {
+		cc.Type, cc.AutoIncrement = types.TypeInteger, true
+	} else if tok == scanner.IDENT && strings.EqualFold(lit, "BIGSERIAL") {
+		cc.Type, cc.AutoIncrement = types.TypeBigint, true
+	} else {
+		p.Unscan()
+
+		var spec typeSpec
+		cc.Type, spec, err = p.parseTypeWithSpec()
+		if err != nil {
+			return nil, nil, err
+		}
+		cc.DecimalSpec, cc.VectorSpec = spec.decimal, spec.vector
+
+		// vectors of a column must all have the same dimension
+		// so that the distance between them can be computed
+		if cc.Type == types.TypeVector && cc.VectorSpec.Dimension == 0 {
+			return nil, nil, &ParseError{Message: fmt.Sprintf("missing dimension of vector column %q", cc.Column)}
+		}
 	}
 
 	var tcs []*database.TableConstraint
@@ -208,7 +624,7 @@ LOOP:
 			}
 
 			// Parse default value expression.
-			// Only a few tokens are allowed.
+			// Only a few tokens are allowed, and function calls.
 			e, err := p.parseExprWithMinPrecedence(scanner.EQ.Precedence(),
 				scanner.EQ,
 				scanner.NEQ,
@@ -234,11 +650,18 @@ LOOP:
 				scanner.LPAREN,   // only opening parenthesis are necessary
 				scanner.LBRACKET, // only opening brackets are necessary
 				scanner.NEXT,
+				scanner.IDENT,
+				scanner.CAST,
 			)
 			if err != nil {
 				return nil, nil, err
 			}
 
+			err = validateDefaultValue(e)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			cc.DefaultValue = expr.Constraint(e)
 
 			if withParentheses {
@@ -248,10 +671,17 @@ LOOP:
 				}
 			}
 		case scanner.UNIQUE:
-			tcs = append(tcs, &database.TableConstraint{
+			tc := database.TableConstraint{
 				Unique:  true,
 				Columns: []string{cc.Column},
-			})
+			}
+
+			err = p.parseDeferrable(&tc)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			tcs = append(tcs, &tc)
 		case scanner.CHECK:
 			e, cols, err := p.parseCheckConstraint()
 			if err != nil {
@@ -262,15 +692,78 @@ LOOP:
 				Check:   expr.Constraint(e),
 				Columns: cols,
 			})
-		default:
-			p.Unscan()
+		case scanner.IDENT:
+			switch {
+			case strings.EqualFold(lit, "AUTOINCREMENT") && !cc.AutoIncrement:
+				cc.AutoIncrement = true
+			case strings.EqualFold(lit, "ENCRYPTED") && cc.Encryption == nil:
+				cc.Encryption, err = p.parseEncryption()
+				if err != nil {
+					return nil, nil, err
+				}
+			case strings.EqualFold(lit, "COLLATE") && cc.Collation == "":
+				c, err := p.parseCollationName()
+				if err != nil {
+					return nil, nil, err
+				}
+				cc.Collation = c.Name
+			case strings.EqualFold(lit, "AUTOINCREMENT"), strings.EqualFold(lit, "ENCRYPTED"), strings.EqualFold(lit, "COLLATE"):
+				return nil, nil, newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			default:
+				p.Unscan()
+				break LOOP
+			}
+		default:
+			p.Unscan()
 			break LOOP
 		}
 	}
 
+	if cc.AutoIncrement {
+		if cc.Type != types.TypeInteger && cc.Type != types.TypeBigint {
+			return nil, nil, &ParseError{Message: fmt.Sprintf("auto-incremented column %q must be of type INTEGER or BIGINT", cc.Column)}
+		}
+
+		if cc.DefaultValue != nil {
+			return nil, nil, &ParseError{Message: fmt.Sprintf("auto-incremented column %q cannot have a default value", cc.Column)}
+		}
+
+		cc.IsNotNull = true
+	}
+
 	return &cc, tcs, nil
 }
 
+// parseEncryption parses the options following the ENCRYPTED keyword:
+//
+//	ENCRYPTED [WITH KEY 'key_id'] [ALLOW INDEX]
+func (p *Parser) parseEncryption() (*database.ColumnEncryption, error) {
+	var enc database.ColumnEncryption
+
+	ok, err := p.parseOptional(scanner.WITH, scanner.KEY)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.STRING || lit == "" {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"key id"}, pos)
+		}
+		enc.KeyID = lit
+	}
+
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "ALLOW") {
+		if err := p.ParseTokens(scanner.INDEX); err != nil {
+			return nil, err
+		}
+		enc.Indexable = true
+	} else {
+		p.Unscan()
+	}
+
+	return &enc, nil
+}
+
 func (p *Parser) parseTableConstraint(stmt *statement.CreateTableStmt) (*database.TableConstraint, error) {
 	var err error
 
@@ -321,6 +814,11 @@ func (p *Parser) parseTableConstraint(stmt *statement.CreateTableStmt) (*databas
 			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"PATHS"}, pos)
 		}
 		tc.SortOrder = order
+
+		err = p.parseDeferrable(&tc)
+		if err != nil {
+			return nil, err
+		}
 	case scanner.CHECK:
 		e, columns, err := p.parseCheckConstraint()
 		if err != nil {
@@ -341,6 +839,35 @@ func (p *Parser) parseTableConstraint(stmt *statement.CreateTableStmt) (*databas
 	return &tc, nil
 }
 
+// parseDeferrable parses the optional clause controlling when
+// a UNIQUE constraint is checked:
+//
+//	DEFERRABLE [ INITIALLY { DEFERRED | IMMEDIATE } ]
+func (p *Parser) parseDeferrable(tc *database.TableConstraint) error {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "DEFERRABLE") {
+		p.Unscan()
+		return nil
+	}
+
+	tc.Deferrable = true
+
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "INITIALLY") {
+		p.Unscan()
+		return nil
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "DEFERRED"):
+		tc.Deferred = true
+	case tok == scanner.IDENT && strings.EqualFold(lit, "IMMEDIATE"):
+	default:
+		return newParseError(scanner.Tokstr(tok, lit), []string{"DEFERRED", "IMMEDIATE"}, pos)
+	}
+
+	return nil
+}
+
 // parseCreateIndexStatement parses a create index string and returns a Statement AST row.
 // This function assumes the CREATE INDEX or CREATE UNIQUE INDEX tokens have already been consumed.
 func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexStmt, error) {
@@ -355,7 +882,7 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexS
 	}
 
 	// Parse optional index name
-	stmt.Info.IndexName, err = p.parseIdent()
+	stmt.Info.IndexName, err = p.parseQualifiedIdent()
 	if err != nil {
 		// if IF NOT EXISTS is set, index name is mandatory
 		if stmt.IfNotExists {
@@ -371,11 +898,26 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexS
 	}
 
 	// Parse table name
-	stmt.Info.Owner.TableName, err = p.parseIdent()
+	stmt.Info.Owner.TableName, err = p.parseQualifiedIdent()
 	if err != nil {
 		return nil, err
 	}
 
+	// Parse optional USING method
+	// USING is not a keyword so that it can still be used as a column name
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "USING") {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch {
+		case tok == scanner.IDENT && strings.EqualFold(lit, "btree"):
+		case tok == scanner.IDENT && strings.EqualFold(lit, "ivf"):
+			stmt.Info.IVF = database.NewIVFOptions()
+		default:
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"btree", "ivf"}, pos)
+		}
+	} else {
+		p.Unscan()
+	}
+
 	columns, order, err := p.parseColumnList()
 	if err != nil {
 		return nil, err
@@ -388,9 +930,89 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexS
 	stmt.Info.Columns = columns
 	stmt.Info.KeySortOrder = order
 
+	if stmt.Info.IVF != nil {
+		if order != 0 {
+			return nil, &ParseError{Message: "ivf indexes cannot be sorted"}
+		}
+
+		err = p.parseIVFOptions(stmt.Info.IVF)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &stmt, nil
 }
 
+// parseIVFOptions parses the optional WITH clause of an IVF index:
+//
+//	WITH (metric = 'cosine', lists = 100, probes = 10)
+func (p *Parser) parseIVFOptions(opts *database.IVFOptions) error {
+	if ok, err := p.parseOptional(scanner.WITH, scanner.LPAREN); !ok || err != nil {
+		return err
+	}
+
+	expected := []string{"metric", "lists", "probes"}
+	var probesSet bool
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.IDENT {
+			return newParseError(scanner.Tokstr(tok, lit), expected, pos)
+		}
+
+		if err := p.ParseTokens(scanner.EQ); err != nil {
+			return err
+		}
+
+		switch strings.ToLower(lit) {
+		case "metric":
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			if tok != scanner.STRING && tok != scanner.IDENT {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"metric"}, pos)
+			}
+			m, err := types.ParseVectorMetric(lit)
+			if err != nil {
+				return &ParseError{Message: err.Error(), Pos: pos}
+			}
+			opts.Metric = m
+		case "lists":
+			n, err := p.parseInteger()
+			if err != nil {
+				return err
+			}
+			opts.Lists = int(n)
+		case "probes":
+			n, err := p.parseInteger()
+			if err != nil {
+				return err
+			}
+			opts.Probes, probesSet = int(n), true
+		default:
+			return newParseError(scanner.Tokstr(tok, lit), expected, pos)
+		}
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return err
+	}
+
+	// with few lists, read them all by default
+	if !probesSet && opts.Probes > opts.Lists {
+		opts.Probes = opts.Lists
+	}
+
+	if err := opts.Validate(); err != nil {
+		return &ParseError{Message: err.Error()}
+	}
+
+	return nil
+}
+
 // This function assumes the CREATE SEQUENCE tokens have already been consumed.
 func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt, error) {
 	var stmt statement.CreateSequenceStmt
@@ -403,13 +1025,145 @@ func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt,
 	}
 
 	// Parse sequence name
-	stmt.Info.Name, err = p.parseIdent()
+	stmt.Info.Name, err = p.parseQualifiedIdent()
 	if err != nil {
 		return nil, err
 	}
 
-	var hasAsInt, hasNoMin, hasNoMax, hasNoCycle bool
-	var min, max, incrementBy, start, cache *int64
+	opts, err := p.parseSequenceOptions(false)
+	if err != nil {
+		return nil, err
+	}
+	incrementBy, min, max, start, cache := opts.incrementBy, opts.min, opts.max, opts.start, opts.cache
+	if opts.cycle != nil {
+		stmt.Info.Cycle = *opts.cycle
+	}
+
+	// default value for increment is 1
+	if incrementBy != nil {
+		stmt.Info.IncrementBy = *incrementBy
+	} else {
+		stmt.Info.IncrementBy = 1
+	}
+
+	// determine if the sequence is ascending or descending
+	asc := stmt.Info.IncrementBy > 0
+
+	// default value for min is 1 if ascending
+	// or the minimum value of ints if descending
+	if min != nil {
+		stmt.Info.Min = *min
+	} else if asc {
+		stmt.Info.Min = 1
+	} else {
+		stmt.Info.Min = math.MinInt64
+	}
+
+	// default value for max is the maximum value of ints if ascending
+	// or the -1 if descending
+	if max != nil {
+		stmt.Info.Max = *max
+	} else if asc {
+		stmt.Info.Max = math.MaxInt64
+	} else {
+		stmt.Info.Max = -1
+	}
+
+	// check if min > max
+	if stmt.Info.Min > stmt.Info.Max {
+		return nil, &ParseError{Message: fmt.Sprintf("MINVALUE (%d) must be less than MAXVALUE (%d)", stmt.Info.Min, stmt.Info.Max)}
+	}
+
+	// default value for start is min if ascending
+	// or max if descending
+	if start != nil {
+		stmt.Info.Start = *start
+	} else if asc {
+		stmt.Info.Start = stmt.Info.Min
+	} else {
+		stmt.Info.Start = stmt.Info.Max
+	}
+
+	// check if min < start < max
+	if stmt.Info.Start < stmt.Info.Min {
+		return nil, &ParseError{Message: fmt.Sprintf("START value (%d) cannot be less than MINVALUE (%d)", stmt.Info.Start, stmt.Info.Min)}
+	}
+	if stmt.Info.Start > stmt.Info.Max {
+		return nil, &ParseError{Message: fmt.Sprintf("START value (%d) cannot be greater than MAXVALUE (%d)", stmt.Info.Start, stmt.Info.Max)}
+	}
+
+	// default for cache is 1
+	if cache != nil {
+		stmt.Info.Cache = *cache
+	} else {
+		stmt.Info.Cache = 1
+	}
+	return &stmt, err
+}
+
+// parseCheckConstraint parses a check constraint.
+// it assumes the CHECK token has already been parsed.
+func (p *Parser) parseCheckConstraint() (expr.Expr, []string, error) {
+	// Parse "("
+	err := p.ParseTokens(scanner.LPAREN)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var columns []string
+	// extract all the paths from the expression
+	expr.Walk(e, func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case *expr.Column:
+			scol := t.Name
+			// ensure that the path is not already in the list
+			found := false
+			for _, c := range columns {
+				if c == scol {
+					found = true
+					break
+				}
+			}
+			if !found {
+				columns = append(columns, scol)
+			}
+		}
+
+		return true
+	})
+
+	// Parse ")"
+	err = p.ParseTokens(scanner.RPAREN)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return e, columns, nil
+}
+
+// sequenceOptions are the options of the CREATE SEQUENCE
+// and ALTER SEQUENCE statements. Options that were not specified are nil.
+type sequenceOptions struct {
+	incrementBy, min, max, start *int64
+	noMin, noMax                 bool
+	cache                        *uint64
+	cycle                        *bool
+
+	// RESTART [WITH integer], only allowed by ALTER SEQUENCE
+	restart     bool
+	restartWith *int64
+}
+
+// parseSequenceOptions parses the options of a sequence, in any order.
+// If alter is true, it also parses the RESTART option.
+func (p *Parser) parseSequenceOptions(alter bool) (*sequenceOptions, error) {
+	var opts sequenceOptions
+	var hasAsInt bool
 
 	for {
 		// Parse AS [any int type]
@@ -436,7 +1190,7 @@ func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt,
 			// parse optional BY token
 			_, _ = p.parseOptional(scanner.BY)
 
-			if incrementBy != nil {
+			if opts.incrementBy != nil {
 				return nil, &ParseError{Message: "conflicting or redundant options"}
 			}
 
@@ -447,7 +1201,7 @@ func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt,
 			if i == 0 {
 				return nil, &ParseError{Message: "INCREMENT must not be zero"}
 			}
-			incrementBy = &i
+			opts.incrementBy = &i
 
 			continue
 		}
@@ -457,26 +1211,27 @@ func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt,
 			tok, pos, lit := p.ScanIgnoreWhitespace()
 
 			if tok == scanner.MINVALUE {
-				if hasNoMin {
+				if opts.noMin || opts.min != nil {
 					return nil, &ParseError{Message: "conflicting or redundant options"}
 				}
-				hasNoMin = true
+				opts.noMin = true
 				continue
 			}
 
 			if tok == scanner.MAXVALUE {
-				if hasNoMax {
+				if opts.noMax || opts.max != nil {
 					return nil, &ParseError{Message: "conflicting or redundant options"}
 				}
-				hasNoMax = true
+				opts.noMax = true
 				continue
 			}
 
 			if tok == scanner.CYCLE {
-				if hasNoCycle {
+				if opts.cycle != nil {
 					return nil, &ParseError{Message: "conflicting or redundant options"}
 				}
-				hasNoCycle = true
+				cycle := false
+				opts.cycle = &cycle
 				continue
 			}
 
@@ -485,27 +1240,27 @@ func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt,
 
 		// Parse MINVALUE integer
 		if ok, _ := p.parseOptional(scanner.MINVALUE); ok {
-			if hasNoMin || min != nil {
+			if opts.noMin || opts.min != nil {
 				return nil, &ParseError{Message: "conflicting or redundant options"}
 			}
 			i, err := p.parseInteger()
 			if err != nil {
 				return nil, err
 			}
-			min = &i
+			opts.min = &i
 			continue
 		}
 
 		// Parse MAXVALUE integer
 		if ok, _ := p.parseOptional(scanner.MAXVALUE); ok {
-			if hasNoMax || max != nil {
+			if opts.noMax || opts.max != nil {
 				return nil, &ParseError{Message: "conflicting or redundant options"}
 			}
 			i, err := p.parseInteger()
 			if err != nil {
 				return nil, err
 			}
-			max = &i
+			opts.max = &i
 			continue
 		}
 
@@ -514,7 +1269,7 @@ func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt,
 			// parse optional WITH token
 			_, _ = p.parseOptional(scanner.WITH)
 
-			if start != nil {
+			if opts.start != nil {
 				return nil, &ParseError{Message: "conflicting or redundant options"}
 			}
 
@@ -522,13 +1277,13 @@ func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt,
 			if err != nil {
 				return nil, err
 			}
-			start = &i
+			opts.start = &i
 			continue
 		}
 
 		// Parse CACHE integer
 		if ok, _ := p.parseOptional(scanner.CACHE); ok {
-			if cache != nil {
+			if opts.cache != nil {
 				return nil, &ParseError{Message: "conflicting or redundant options"}
 			}
 
@@ -539,127 +1294,85 @@ func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt,
 			if v < 0 {
 				return nil, &ParseError{Message: "cache value must be positive"}
 			}
-			cache = &v
+			c := uint64(v)
+			opts.cache = &c
 
 			continue
 		}
 
 		// Parse CYCLE
 		if ok, _ := p.parseOptional(scanner.CYCLE); ok {
-			if hasNoCycle || stmt.Info.Cycle {
+			if opts.cycle != nil {
 				return nil, &ParseError{Message: "conflicting or redundant options"}
 			}
 
-			stmt.Info.Cycle = true
+			cycle := true
+			opts.cycle = &cycle
 			continue
 		}
 
-		break
-	}
-
-	// default value for increment is 1
-	if incrementBy != nil {
-		stmt.Info.IncrementBy = *incrementBy
-	} else {
-		stmt.Info.IncrementBy = 1
-	}
-
-	// determine if the sequence is ascending or descending
-	asc := stmt.Info.IncrementBy > 0
-
-	// default value for min is 1 if ascending
-	// or the minimum value of ints if descending
-	if min != nil {
-		stmt.Info.Min = *min
-	} else if asc {
-		stmt.Info.Min = 1
-	} else {
-		stmt.Info.Min = math.MinInt64
-	}
-
-	// default value for max is the maximum value of ints if ascending
-	// or the -1 if descending
-	if max != nil {
-		stmt.Info.Max = *max
-	} else if asc {
-		stmt.Info.Max = math.MaxInt64
-	} else {
-		stmt.Info.Max = -1
-	}
-
-	// check if min > max
-	if stmt.Info.Min > stmt.Info.Max {
-		return nil, &ParseError{Message: fmt.Sprintf("MINVALUE (%d) must be less than MAXVALUE (%d)", stmt.Info.Min, stmt.Info.Max)}
-	}
-
-	// default value for start is min if ascending
-	// or max if descending
-	if start != nil {
-		stmt.Info.Start = *start
-	} else if asc {
-		stmt.Info.Start = stmt.Info.Min
-	} else {
-		stmt.Info.Start = stmt.Info.Max
-	}
+		// Parse RESTART [[WITH] integer]
+		if alter {
+			if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "RESTART") {
+				if opts.restart {
+					return nil, &ParseError{Message: "conflicting or redundant options"}
+				}
+				opts.restart = true
+
+				hasWith, _ := p.parseOptional(scanner.WITH)
+				if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.INTEGER || tok == scanner.ADD || tok == scanner.SUB {
+					p.Unscan()
+					i, err := p.parseInteger()
+					if err != nil {
+						return nil, err
+					}
+					opts.restartWith = &i
+				} else {
+					p.Unscan()
+					if hasWith {
+						return nil, &ParseError{Message: "missing RESTART value"}
+					}
+				}
+				continue
+			}
+			p.Unscan()
+		}
 
-	// check if min < start < max
-	if stmt.Info.Start < stmt.Info.Min {
-		return nil, &ParseError{Message: fmt.Sprintf("START value (%d) cannot be less than MINVALUE (%d)", stmt.Info.Start, stmt.Info.Min)}
-	}
-	if stmt.Info.Start > stmt.Info.Max {
-		return nil, &ParseError{Message: fmt.Sprintf("START value (%d) cannot be greater than MAXVALUE (%d)", stmt.Info.Start, stmt.Info.Max)}
+		break
 	}
 
-	// default for cache is 1
-	if cache != nil {
-		stmt.Info.Cache = uint64(*cache)
-	} else {
-		stmt.Info.Cache = 1
-	}
-	return &stmt, err
+	return &opts, nil
 }
 
-// parseCheckConstraint parses a check constraint.
-// it assumes the CHECK token has already been parsed.
-func (p *Parser) parseCheckConstraint() (expr.Expr, []string, error) {
-	// Parse "("
-	err := p.ParseTokens(scanner.LPAREN)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	e, err := p.ParseExpr()
-	if err != nil {
-		return nil, nil, err
-	}
-
-	var columns []string
-	// extract all the paths from the expression
-	expr.Walk(e, func(e expr.Expr) bool {
+// validateDefaultValue ensures the default value of a column
+// can be evaluated for each inserted row, without reading the row
+// or the arguments of the statement.
+func validateDefaultValue(e expr.Expr) error {
+	var err error
+	var validate func(e expr.Expr) bool
+	validate = func(e expr.Expr) bool {
 		switch t := e.(type) {
 		case *expr.Column:
-			scol := t.Name
-			// ensure that the path is not already in the list
-			found := false
-			for _, c := range columns {
-				if c == scol {
-					found = true
-					break
+			err = &ParseError{Message: fmt.Sprintf("default value cannot reference column %q", t.Name)}
+		case expr.NamedParam, expr.PositionalParam:
+			err = &ParseError{Message: "default value cannot contain parameters"}
+		case expr.AggregatorBuilder:
+			err = &ParseError{Message: "aggregate functions are not allowed in default values"}
+		case *functions.UserFunction:
+			err = &ParseError{Message: fmt.Sprintf("no such function: %q", t.Name)}
+		case *functions.MatchRecognizeValue:
+			err = &ParseError{Message: fmt.Sprintf("%s is not allowed in default values", t)}
+		case expr.LiteralExprList:
+			for _, e := range t {
+				if !expr.Walk(e, validate) {
+					return false
 				}
 			}
-			if !found {
-				columns = append(columns, scol)
-			}
 		}
 
-		return true
-	})
-
-	// Parse ")"
-	err = p.ParseTokens(scanner.RPAREN)
-	if err != nil {
-		return nil, nil, err
+		return err == nil
 	}
 
-	return e, columns, nil
+	expr.Walk(e, validate)
+	return err
 }
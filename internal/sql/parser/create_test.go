@@ -5,8 +5,12 @@ import (
 	"testing"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,6 +49,24 @@ func TestParserCreateIndex(t *testing.T) {
 			},
 			false},
 		{"No fields", "CREATE INDEX idx ON test", nil, true},
+		{"Using btree", "CREATE INDEX idx ON test USING btree (foo)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"},
+			}}, false},
+		{"Using ivf", "CREATE INDEX idx ON test USING ivf (foo)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"},
+				IVF: &database.IVFOptions{Metric: types.VectorL2, Lists: 16, Probes: 4},
+			}}, false},
+		{"Using ivf with options", "CREATE INDEX idx ON test USING ivf (foo) WITH (metric = 'cosine', lists = 2)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"},
+				IVF: &database.IVFOptions{Metric: types.VectorCosine, Lists: 2, Probes: 2},
+			}}, false},
+		{"Using ivf with unknown metric", "CREATE INDEX idx ON test USING ivf (foo) WITH (metric = 'l1')", nil, true},
+		{"Using ivf with too many probes", "CREATE INDEX idx ON test USING ivf (foo) WITH (lists = 2, probes = 3)", nil, true},
+		{"Using ivf sorted", "CREATE INDEX idx ON test USING ivf (foo DESC)", nil, true},
+		{"Using unknown method", "CREATE INDEX idx ON test USING hash (foo)", nil, true},
 	}
 
 	for _, test := range tests {
@@ -201,3 +223,163 @@ func TestParserCreateSequence(t *testing.T) {
 		})
 	}
 }
+
+func TestParserCreateTemporaryTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		onCommit database.OnCommitAction
+		errored  bool
+	}{
+		{"Temp", "CREATE TEMP TABLE test(a INT)", database.OnCommitPreserveRows, false},
+		{"Temporary", "CREATE TEMPORARY TABLE IF NOT EXISTS test(a INT)", database.OnCommitPreserveRows, false},
+		{"Preserve rows", "CREATE TEMP TABLE test(a INT) ON COMMIT PRESERVE ROWS", database.OnCommitPreserveRows, false},
+		{"Delete rows", "CREATE TEMP TABLE test(a INT) ON COMMIT DELETE ROWS", database.OnCommitDeleteRows, false},
+		{"Drop", "CREATE TEMP TABLE test(a INT) ON COMMIT DROP", database.OnCommitDrop, false},
+		{"Missing ROWS", "CREATE TEMP TABLE test(a INT) ON COMMIT DELETE", 0, true},
+		{"Unknown action", "CREATE TEMP TABLE test(a INT) ON COMMIT TRUNCATE", 0, true},
+		{"Missing TABLE", "CREATE TEMP test(a INT)", 0, true},
+		{"Regular table", "CREATE TABLE test(a INT) ON COMMIT DROP", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			stmt := q.Statements[0].(*statement.CreateTableStmt)
+			require.Equal(t, "test", stmt.Info.TableName)
+			require.True(t, stmt.Info.Temporary)
+			require.Equal(t, test.onCommit, stmt.Info.OnCommit)
+		})
+	}
+}
+
+func TestParserCreateTablePartitioning(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected *database.TablePartitioning
+		errored  bool
+	}{
+		{"Range", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (10), PARTITION p2 VALUES LESS THAN (MAXVALUE))", &database.TablePartitioning{
+			Method: database.PartitionByRange,
+			Column: "a",
+			Partitions: []database.TablePartition{
+				{Name: "p1", Bound: types.NewIntegerValue(10)},
+				{Name: "p2"},
+			},
+		}, false},
+		{"Range / cast", "CREATE TABLE test(a BIGINT PRIMARY KEY, b TEXT) WITH (compression = 'none') PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (-1))", &database.TablePartitioning{
+			Method: database.PartitionByRange,
+			Column: "a",
+			Partitions: []database.TablePartition{
+				{Name: "p1", Bound: types.NewBigintValue(-1)},
+			},
+		}, false},
+		{"Hash", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY HASH (a) PARTITIONS 2", &database.TablePartitioning{
+			Method: database.PartitionByHash,
+			Column: "a",
+			Partitions: []database.TablePartition{
+				{Name: "p0"},
+				{Name: "p1"},
+			},
+		}, false},
+		{"Unknown column", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY RANGE (b) (PARTITION p1 VALUES LESS THAN (10))", nil, true},
+		{"Unknown method", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY LIST (a)", nil, true},
+		{"Missing partitions", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY RANGE (a)", nil, true},
+		{"Missing count", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY HASH (a)", nil, true},
+		{"Zero partitions", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY HASH (a) PARTITIONS 0", nil, true},
+		{"Bad bound", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN ('foo'))", nil, true},
+		{"NULL bound", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (NULL))", nil, true},
+		{"Column bound", "CREATE TABLE test(a INT PRIMARY KEY) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (a))", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			stmt := q.Statements[0].(*statement.CreateTableStmt)
+			require.Equal(t, test.expected, stmt.Info.Partitioning)
+		})
+	}
+}
+
+func TestParserCreateSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Basic", "CREATE SCHEMA app", &statement.CreateSchemaStmt{Info: database.SchemaInfo{Name: "app"}}, false},
+		{"If not exists", "CREATE SCHEMA IF NOT EXISTS app", &statement.CreateSchemaStmt{Info: database.SchemaInfo{Name: "app"}, IfNotExists: true}, false},
+		{"No name", "CREATE SCHEMA", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserCreateFunction(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Basic", "CREATE FUNCTION area(w, h) RETURNS (w * h)",
+			&statement.CreateFunctionStmt{Info: database.FunctionInfo{
+				Name:   "area",
+				Params: []string{"w", "h"},
+				Body:   expr.Mul(&expr.Column{Name: "w"}, &expr.Column{Name: "h"}),
+			}}, false},
+		{"If not exists", "CREATE FUNCTION IF NOT EXISTS one() RETURNS (1)",
+			&statement.CreateFunctionStmt{IfNotExists: true, Info: database.FunctionInfo{
+				Name: "one",
+				Body: testutil.IntegerValue(1),
+			}}, false},
+		{"Calls", "CREATE FUNCTION f(a) RETURNS (g(a))",
+			&statement.CreateFunctionStmt{Info: database.FunctionInfo{
+				Name:   "f",
+				Params: []string{"a"},
+				Body:   &functions.UserFunction{Name: "g", Args: []expr.Expr{&expr.Column{Name: "a"}}},
+			}}, false},
+		{"No parentheses", "CREATE FUNCTION f(a) RETURNS a", nil, true},
+		{"No RETURNS", "CREATE FUNCTION f(a) (a)", nil, true},
+		{"No parameter list", "CREATE FUNCTION f RETURNS (1)", nil, true},
+		{"Invalid parameter", "CREATE FUNCTION f(1) RETURNS (1)", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -12,27 +12,48 @@ func (p *Parser) parseDeleteStatement() (statement.Statement, error) {
 	stmt := statement.NewDeleteStatement()
 	var err error
 
-	// Parse "DELETE FROM".
-	if err := p.ParseTokens(scanner.DELETE, scanner.FROM); err != nil {
+	// Parse "DELETE".
+	if err := p.ParseTokens(scanner.DELETE); err != nil {
+		return nil, err
+	}
+
+	stmt.Hints, err = p.parseOptimizerHints()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse "FROM".
+	if err := p.ParseTokens(scanner.FROM); err != nil {
 		return nil, err
 	}
 
 	// Parse table name
-	stmt.TableName, err = p.parseIdent()
+	stmt.TableName, err = p.parseQualifiedIdent()
 	if err != nil {
 		pErr := errors.Unwrap(err).(*ParseError)
 		pErr.Expected = []string{"table_name"}
 		return nil, pErr
 	}
 
+	// Parse joined table: "USING table_name".
+	// USING is not a keyword so that it can still be used as a column name
+	if p.parseOptionalIdent("USING") {
+		stmt.UsingTable, err = p.parseQualifiedIdent()
+		if err != nil {
+			pErr := errors.Unwrap(err).(*ParseError)
+			pErr.Expected = []string{"table_name"}
+			return nil, pErr
+		}
+	}
+
 	// Parse condition: "WHERE EXPR".
 	stmt.WhereExpr, err = p.parseCondition()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	stmt.OrderBy, stmt.OrderByDirection, err = p.parseOrderBy()
+	// Parse order by: "ORDER BY expr [ASC|DESC] [NULLS {FIRST|LAST}], ..."
+	stmt.OrderBy, err = p.parseOrderBy()
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +19,7 @@ func TestParserDelete(t *testing.T) {
 	db, tx, cleanup := testutil.NewTestTx(t)
 	defer cleanup()
 
-	testutil.MustExec(t, db, tx, "CREATE TABLE test(age int)")
+	testutil.MustExec(t, db, tx, "CREATE TABLE test(age int); CREATE TABLE other(c int)")
 
 	parseExpr := func(s string) expr.Expr {
 		e := parser.MustParseExpr(s)
@@ -71,6 +71,15 @@ func TestParserDelete(t *testing.T) {
 				Pipe(table.Delete("test")).
 				Pipe(stream.Discard()),
 		},
+		{"WithUsing", "DELETE FROM test USING other WHERE age = c",
+			stream.New(table.Scan("test")).
+				Pipe(stream.Join(
+					stream.New(table.Scan("other")),
+					expr.Eq(&expr.Column{Name: "age", Table: "test"}, &expr.Column{Name: "c", Table: "other"}),
+				)).
+				Pipe(table.Delete("test")).
+				Pipe(stream.Discard()),
+		},
 	}
 
 	for _, test := range tests {
@@ -86,7 +95,7 @@ func TestParserDelete(t *testing.T) {
 			require.NoError(t, err)
 
 			require.Len(t, q.Statements, 1)
-			require.EqualValues(t, &statement.PreparedStreamStmt{Stream: test.expected}, q.Statements[0].(*statement.PreparedStreamStmt))
+			require.EqualValues(t, &statement.PreparedStreamStmt{Stream: test.expected, Fingerprint: mustFingerprint(t, test.s)}, q.Statements[0].(*statement.PreparedStreamStmt))
 		})
 	}
 }
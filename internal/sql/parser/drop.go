@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/cockroachdb/errors"
 
 	"github.com/chaisql/chai/internal/query/statement"
@@ -22,9 +24,22 @@ func (p *Parser) parseDropStatement() (statement.Statement, error) {
 		return p.parseDropIndexStatement()
 	case scanner.SEQUENCE:
 		return p.parseDropSequenceStatement()
+	case scanner.IDENT:
+		// SCHEMA is not a keyword, to allow using it as an identifier.
+		if strings.EqualFold(lit, "SCHEMA") {
+			return p.parseDropSchemaStatement()
+		}
+		// FUNCTION is not a keyword either.
+		if strings.EqualFold(lit, "FUNCTION") {
+			return p.parseDropFunctionStatement()
+		}
+		// nor USER.
+		if strings.EqualFold(lit, "USER") {
+			return p.parseDropUserStatement()
+		}
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE", "SCHEMA", "FUNCTION", "USER"}, pos)
 }
 
 // parseDropTableStatement parses a drop table string and returns a Statement AST row.
@@ -39,7 +54,7 @@ func (p *Parser) parseDropTableStatement() (*statement.DropTableStmt, error) {
 	}
 
 	// Parse table name
-	stmt.TableName, err = p.parseIdent()
+	stmt.TableName, err = p.parseQualifiedIdent()
 	if err != nil {
 		pErr := errors.Unwrap(err).(*ParseError)
 		pErr.Expected = []string{"table_name"}
@@ -61,7 +76,7 @@ func (p *Parser) parseDropIndexStatement() (*statement.DropIndexStmt, error) {
 	}
 
 	// Parse index name
-	stmt.IndexName, err = p.parseIdent()
+	stmt.IndexName, err = p.parseQualifiedIdent()
 	if err != nil {
 		pErr := errors.Unwrap(err).(*ParseError)
 		pErr.Expected = []string{"index_name"}
@@ -83,7 +98,7 @@ func (p *Parser) parseDropSequenceStatement() (*statement.DropSequenceStmt, erro
 	}
 
 	// Parse sequence name
-	stmt.SequenceName, err = p.parseIdent()
+	stmt.SequenceName, err = p.parseQualifiedIdent()
 	if err != nil {
 		pErr := errors.Unwrap(err).(*ParseError)
 		pErr.Expected = []string{"sequence_name"}
@@ -92,3 +107,52 @@ func (p *Parser) parseDropSequenceStatement() (*statement.DropSequenceStmt, erro
 
 	return &stmt, nil
 }
+
+// parseDropSchemaStatement parses a drop schema string and returns a Statement AST row.
+// This function assumes the DROP SCHEMA tokens have already been consumed.
+func (p *Parser) parseDropSchemaStatement() (*statement.DropSchemaStmt, error) {
+	var stmt statement.DropSchemaStmt
+	var err error
+
+	stmt.IfExists, err = p.parseOptional(scanner.IF, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse schema name
+	stmt.SchemaName, err = p.parseIdent()
+	if err != nil {
+		pErr := errors.Unwrap(err).(*ParseError)
+		pErr.Expected = []string{"schema_name"}
+		return nil, pErr
+	}
+
+	// Parse optional CASCADE or RESTRICT
+	if !p.parseOptionalIdent("RESTRICT") {
+		stmt.Cascade = p.parseOptionalIdent("CASCADE")
+	}
+
+	return &stmt, nil
+}
+
+// parseDropFunctionStatement parses a drop function string and returns a Statement AST row.
+// This function assumes the DROP FUNCTION tokens have already been consumed.
+func (p *Parser) parseDropFunctionStatement() (*statement.DropFunctionStmt, error) {
+	var stmt statement.DropFunctionStmt
+	var err error
+
+	stmt.IfExists, err = p.parseOptional(scanner.IF, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse function name
+	stmt.FunctionName, err = p.parseIdent()
+	if err != nil {
+		pErr := errors.Unwrap(err).(*ParseError)
+		pErr.Expected = []string{"function_name"}
+		return nil, pErr
+	}
+
+	return &stmt, nil
+}
@@ -21,6 +21,14 @@ func TestParserDrop(t *testing.T) {
 		{"Drop index if exists", "DROP INDEX IF EXISTS test", &statement.DropIndexStmt{IndexName: "test", IfExists: true}, false},
 		{"Drop index", "DROP SEQUENCE test", &statement.DropSequenceStmt{SequenceName: "test"}, false},
 		{"Drop index if exists", "DROP SEQUENCE IF EXISTS test", &statement.DropSequenceStmt{SequenceName: "test", IfExists: true}, false},
+		{"Drop qualified table", "DROP TABLE app.test", &statement.DropTableStmt{TableName: "app.test"}, false},
+		{"Drop schema", "DROP SCHEMA app", &statement.DropSchemaStmt{SchemaName: "app"}, false},
+		{"Drop schema if exists", "DROP SCHEMA IF EXISTS app", &statement.DropSchemaStmt{SchemaName: "app", IfExists: true}, false},
+		{"Drop schema cascade", "DROP SCHEMA app CASCADE", &statement.DropSchemaStmt{SchemaName: "app", Cascade: true}, false},
+		{"Drop schema restrict", "DROP SCHEMA IF EXISTS app restrict", &statement.DropSchemaStmt{SchemaName: "app", IfExists: true}, false},
+		{"Drop schema cascade restrict", "DROP SCHEMA app CASCADE RESTRICT", nil, true},
+		{"Drop function", "DROP FUNCTION area", &statement.DropFunctionStmt{FunctionName: "area"}, false},
+		{"Drop function if exists", "DROP FUNCTION IF EXISTS area", &statement.DropFunctionStmt{FunctionName: "area", IfExists: true}, false},
 	}
 
 	for _, test := range tests {
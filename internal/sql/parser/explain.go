@@ -1,22 +1,33 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
 )
 
 // parseExplainStatement parses any statement and returns an ExplainStmt row.
 // This function assumes the EXPLAIN token has already been consumed.
+//
+//	EXPLAIN [ANALYZE] statement
 func (p *Parser) parseExplainStatement() (statement.Statement, error) {
 	// Parse "EXPLAIN".
 	if err := p.ParseTokens(scanner.EXPLAIN); err != nil {
 		return nil, err
 	}
 
-	// ensure we don't have multiple EXPLAIN keywords
+	// ANALYZE is not a keyword, so that it can be used as an identifier
+	var analyze bool
 	tok, pos, lit := p.ScanIgnoreWhitespace()
-	if tok != scanner.SELECT && tok != scanner.UPDATE && tok != scanner.DELETE && tok != scanner.INSERT {
-		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INSERT", "SELECT", "UPDATE", "DELETE"}, pos)
+	if tok == scanner.IDENT && strings.EqualFold(lit, "ANALYZE") {
+		analyze = true
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+	}
+
+	// ensure we don't have multiple EXPLAIN keywords
+	if tok != scanner.SELECT && tok != scanner.UPDATE && tok != scanner.DELETE && tok != scanner.INSERT && tok != scanner.CREATE {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INSERT", "SELECT", "UPDATE", "DELETE", "CREATE"}, pos)
 	}
 	p.Unscan()
 
@@ -25,5 +36,11 @@ func (p *Parser) parseExplainStatement() (statement.Statement, error) {
 		return nil, err
 	}
 
-	return &statement.ExplainStmt{Statement: innerStmt.(statement.Preparer)}, nil
+	if tok == scanner.CREATE {
+		if _, ok := innerStmt.(*statement.CreateIndexStmt); !ok {
+			return nil, &ParseError{Message: "EXPLAIN only supports CREATE INDEX statements"}
+		}
+	}
+
+	return &statement.ExplainStmt{Statement: innerStmt, Analyze: analyze}, nil
 }
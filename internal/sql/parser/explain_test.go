@@ -3,6 +3,7 @@ package parser_test
 import (
 	"testing"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/parser"
@@ -14,6 +15,7 @@ func TestParserExplain(t *testing.T) {
 	slct.CompoundSelect = []*statement.SelectCoreStmt{
 		{TableName: "test", ProjectionExprs: []expr.Expr{expr.Wildcard{}}},
 	}
+	slct.Fingerprint = mustFingerprint(t, "SELECT * FROM test")
 
 	tests := []struct {
 		name     string
@@ -22,6 +24,12 @@ func TestParserExplain(t *testing.T) {
 		errored  bool
 	}{
 		{"Explain select", "EXPLAIN SELECT * FROM test", &statement.ExplainStmt{Statement: slct}, false},
+		{"Explain create index", "EXPLAIN CREATE INDEX idx ON test (foo)", &statement.ExplainStmt{Statement: &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"},
+			}}}, false},
+		{"Explain analyze", "EXPLAIN ANALYZE SELECT * FROM test", &statement.ExplainStmt{Statement: slct, Analyze: true}, false},
+		{"Explain create table", "EXPLAIN CREATE TABLE test(a INT)", nil, true},
 		{"Multiple Explains", "EXPLAIN EXPLAIN CREATE TABLE test", nil, true},
 	}
 
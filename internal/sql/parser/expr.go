@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/expr/functions"
@@ -46,6 +47,9 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int, allowed ...scanner.T
 	if err != nil {
 		return nil, err
 	}
+	if e, err = p.parseCollate(e, allowed...); err != nil {
+		return nil, err
+	}
 	root.SetRightHandExpr(e)
 
 	// Loop over operations and unary exprs and build a tree based on precedence.
@@ -64,6 +68,15 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int, allowed ...scanner.T
 		if rhs, err = p.parseUnaryExpr(allowed...); err != nil {
 			return nil, err
 		}
+		if rhs, err = p.parseCollate(rhs, allowed...); err != nil {
+			return nil, err
+		}
+
+		if tok == scanner.LIKE || tok == scanner.NLIKE {
+			if op, err = p.parseLikeEscape(tok, op, allowed...); err != nil {
+				return nil, err
+			}
+		}
 
 		// Find the right spot in the tree to add the new expression by
 		// descending the RHS of the expression tree until we reach the last
@@ -81,6 +94,63 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int, allowed ...scanner.T
 	}
 }
 
+// parseCollate parses the optional COLLATE clause following an expression.
+// It is not parsed in restricted expressions, like default values, where
+// COLLATE belongs to the column definition.
+func (p *Parser) parseCollate(e expr.Expr, allowed ...scanner.Token) (expr.Expr, error) {
+	if e == nil || allowed != nil {
+		return e, nil
+	}
+
+	if !p.parseOptionalIdent("COLLATE") {
+		return e, nil
+	}
+
+	c, err := p.parseCollationName()
+	if err != nil {
+		return nil, err
+	}
+
+	return &expr.Collate{Expr: e, Collation: c}, nil
+}
+
+// parseCollationName parses the name of a collation, as an identifier or a string.
+//
+//	NOCASE | "fr" | 'de-u-ks-level2'
+func (p *Parser) parseCollationName() (*database.Collation, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT && tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"collation name"}, pos)
+	}
+
+	c, err := database.GetCollation(lit)
+	if err != nil {
+		return nil, &ParseError{Message: err.Error(), Pos: pos}
+	}
+
+	return c, nil
+}
+
+// parseLikeEscape parses the optional ESCAPE clause of a LIKE or NOT LIKE operator
+// and returns the function creating the operator.
+func (p *Parser) parseLikeEscape(tok scanner.Token, op func(lhs, rhs expr.Expr) expr.Expr, allowed ...scanner.Token) (func(lhs, rhs expr.Expr) expr.Expr, error) {
+	if t, _, lit := p.ScanIgnoreWhitespace(); t != scanner.IDENT || !strings.EqualFold(lit, "ESCAPE") {
+		p.Unscan()
+		return op, nil
+	}
+
+	esc, err := p.parseUnaryExpr(allowed...)
+	if err != nil {
+		return nil, err
+	}
+
+	if tok == scanner.NLIKE {
+		return func(lhs, rhs expr.Expr) expr.Expr { return expr.NotLikeEscape(lhs, rhs, esc) }, nil
+	}
+
+	return func(lhs, rhs expr.Expr) expr.Expr { return expr.LikeEscape(lhs, rhs, esc) }, nil
+}
+
 func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (func(lhs, rhs expr.Expr) expr.Expr, scanner.Token, error) {
 	op, _, _ := p.ScanIgnoreWhitespace()
 	if !op.IsOperator() && op != scanner.NOT {
@@ -93,12 +163,6 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 		return nil, 0, nil
 	}
 
-	// Ignore currently unused operators.
-	if op == scanner.EQREGEX || op == scanner.NEQREGEX {
-		p.Unscan()
-		return nil, 0, nil
-	}
-
 	if op == scanner.NOT {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
 		if tok.Precedence() >= minPrecedence {
@@ -107,10 +171,12 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 				return expr.NotIn, scanner.NIN, nil
 			case tok == scanner.LIKE && tok.Precedence() >= minPrecedence:
 				return expr.NotLike, scanner.NLIKE, nil
+			case tok == scanner.REGEXP && tok.Precedence() >= minPrecedence:
+				return expr.NotRegexp, scanner.NEQREGEX, nil
 			}
 		}
 
-		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN, LIKE"}, pos)
+		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN, LIKE, REGEXP"}, pos)
 	}
 
 	if op.Precedence() < minPrecedence {
@@ -161,6 +227,10 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 		return expr.Is, op, nil
 	case scanner.LIKE:
 		return expr.Like, op, nil
+	case scanner.EQREGEX, scanner.REGEXP:
+		return expr.Regexp, scanner.EQREGEX, nil
+	case scanner.NEQREGEX:
+		return expr.NotRegexp, op, nil
 	case scanner.CONCAT:
 		return expr.Concat, op, nil
 	case scanner.BETWEEN:
@@ -195,7 +265,25 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		p.Unscan()
 		return p.parseCastExpression()
 	case scanner.IDENT:
-		tok1, _, _ := p.ScanIgnoreWhitespace()
+		tok1, pos1, lit1 := p.ScanIgnoreWhitespace()
+		// INTERVAL is not a keyword so that it can still be used as a column name,
+		// an identifier followed by a string can only be an interval literal
+		if tok1 == scanner.STRING && strings.EqualFold(lit, "INTERVAL") {
+			iv, err := types.ParseInterval(lit1)
+			if err != nil {
+				return nil, errors.WithStack(&ParseError{Message: fmt.Sprintf("invalid interval %q", lit1), Pos: pos1})
+			}
+			return expr.LiteralValue{Value: iv}, nil
+		}
+		// TRY_CAST is not a keyword either
+		if tok1 == scanner.LPAREN && strings.EqualFold(lit, "TRY_CAST") {
+			p.Unscan()
+			if tk, _, _ := p.s.Curr(); tk == scanner.WS {
+				p.Unscan()
+			}
+			p.Unscan()
+			return p.parseCastExpression()
+		}
 		// if the next token is a left parenthesis, this is a function
 		if tok1 == scanner.LPAREN {
 			p.Unscan()
@@ -217,15 +305,32 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		if len(lit) == 1 {
 			return nil, errors.WithStack(&ParseError{Message: "missing param name"})
 		}
+		// $1, $2, ... refer to the arguments by position
+		if n, err := strconv.Atoi(lit[1:]); err == nil {
+			if n < 1 {
+				return nil, errors.WithStack(&ParseError{Message: fmt.Sprintf("invalid param number %d", n)})
+			}
+			if p.orderedParams > 0 || p.namedParams > 0 {
+				return nil, errors.WithStack(&ParseError{Message: "cannot mix numbered arguments with other arguments"})
+			}
+			p.numberedParams++
+			return expr.PositionalParam(n), nil
+		}
 		if p.orderedParams > 0 {
 			return nil, errors.WithStack(&ParseError{Message: "cannot mix positional arguments with named arguments"})
 		}
+		if p.numberedParams > 0 {
+			return nil, errors.WithStack(&ParseError{Message: "cannot mix numbered arguments with other arguments"})
+		}
 		p.namedParams++
 		return expr.NamedParam(lit[1:]), nil
 	case scanner.POSITIONALPARAM:
 		if p.namedParams > 0 {
 			return nil, errors.WithStack(&ParseError{Message: "cannot mix positional arguments with named arguments"})
 		}
+		if p.numberedParams > 0 {
+			return nil, errors.WithStack(&ParseError{Message: "cannot mix numbered arguments with other arguments"})
+		}
 		p.orderedParams++
 		return expr.PositionalParam(p.orderedParams), nil
 	case scanner.STRING:
@@ -309,7 +414,7 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		seqName, err := p.parseIdent()
+		seqName, err := p.parseQualifiedIdent()
 		if err != nil {
 			return nil, err
 		}
@@ -354,6 +459,27 @@ func (p *Parser) parseIdent() (string, error) {
 	return lit, nil
 }
 
+// parseQualifiedIdent parses the name of a relation,
+// optionally prefixed by the name of its schema.
+func (p *Parser) parseQualifiedIdent() (string, error) {
+	ident, err := p.parseIdent()
+	if err != nil {
+		return "", err
+	}
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.DOT {
+		p.Unscan()
+		return ident, nil
+	}
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return "", err
+	}
+
+	return ident + "." + name, nil
+}
+
 // parseIdentList parses a comma delimited list of identifiers.
 func (p *Parser) parseIdentList() ([]string, error) {
 	// Parse first (required) identifier.
@@ -403,6 +529,23 @@ func (p *Parser) parseType() (types.Type, error) {
 		return types.TypeText, nil
 	case scanner.TYPETIMESTAMP:
 		return types.TypeTimestamp, nil
+	case scanner.IDENT:
+		// DATE, INTERVAL, DECIMAL, NUMERIC, UUID and VECTOR are not keywords so that they can still be used as column names
+		if strings.EqualFold(lit, "DATE") {
+			return types.TypeDate, nil
+		}
+		if strings.EqualFold(lit, "INTERVAL") {
+			return types.TypeInterval, nil
+		}
+		if strings.EqualFold(lit, "DECIMAL") || strings.EqualFold(lit, "NUMERIC") {
+			return types.TypeDecimal, nil
+		}
+		if strings.EqualFold(lit, "UUID") {
+			return types.TypeUUID, nil
+		}
+		if strings.EqualFold(lit, "VECTOR") {
+			return types.TypeVector, nil
+		}
 	case scanner.TYPEVARCHAR, scanner.TYPECHARACTER:
 		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
 			return 0, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
@@ -423,7 +566,65 @@ func (p *Parser) parseType() (types.Type, error) {
 	return 0, newParseError(scanner.Tokstr(tok, lit), []string{"type"}, pos)
 }
 
-// parsePath parses a path to a specific value.
+// typeSpec holds the modifiers of a type.
+type typeSpec struct {
+	decimal types.DecimalSpec
+	vector  types.VectorSpec
+}
+
+// parseTypeWithSpec parses a type. DECIMAL types can be followed
+// by their precision and their scale: DECIMAL(precision[, scale]),
+// and VECTOR types by their dimension: VECTOR(dimension).
+func (p *Parser) parseTypeWithSpec() (types.Type, typeSpec, error) {
+	tp, err := p.parseType()
+	if err != nil || (tp != types.TypeDecimal && tp != types.TypeVector) {
+		return tp, typeSpec{}, err
+	}
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		p.Unscan()
+		return tp, typeSpec{}, nil
+	}
+
+	maxModifiers := 2
+	if tp == types.TypeVector {
+		maxModifiers = 1
+	}
+
+	var modifiers []int64
+	for {
+		n, err := p.parseInteger()
+		if err != nil {
+			return 0, typeSpec{}, err
+		}
+		modifiers = append(modifiers, n)
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok == scanner.RPAREN {
+			break
+		}
+		if tok != scanner.COMMA || len(modifiers) == maxModifiers {
+			return 0, typeSpec{}, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+		}
+	}
+
+	var spec typeSpec
+	if tp == types.TypeVector {
+		spec.vector, err = types.NewVectorSpec(int(modifiers[0]))
+	} else {
+		// the scale is zero by default
+		modifiers = append(modifiers, 0)
+		spec.decimal, err = types.NewDecimalSpec(int(modifiers[0]), int(modifiers[1]))
+	}
+	if err != nil {
+		return 0, typeSpec{}, &ParseError{Message: err.Error()}
+	}
+
+	return tp, spec, nil
+}
+
+// parseColumn parses the name of a column, optionally prefixed
+// by the name of its table, itself optionally prefixed by the name of its schema.
 func (p *Parser) parseColumn() (*expr.Column, error) {
 	// parse first mandatory ident
 	col, err := p.parseIdent()
@@ -431,7 +632,26 @@ func (p *Parser) parseColumn() (*expr.Column, error) {
 		return nil, err
 	}
 
-	return &expr.Column{Name: col}, nil
+	var table string
+	for range 2 {
+		if tok, _, _ := p.Scan(); tok != scanner.DOT {
+			p.Unscan()
+			break
+		}
+
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		if table != "" {
+			table += "."
+		}
+		table += col
+		col = name
+	}
+
+	return &expr.Column{Name: col, Table: table}, nil
 }
 
 func (p *Parser) parseExprListUntil(rightToken scanner.Token) (expr.LiteralExprList, error) {
@@ -486,16 +706,27 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 		return nil, err
 	}
 
+	if strings.EqualFold(funcName, "extract") {
+		return p.parseExtract()
+	}
+
 	// Check if the function is called without arguments.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.RPAREN {
 		def, err := functions.GetFunc(funcName)
 		if err != nil {
-			return nil, err
+			// functions that are not builtin may be created with CREATE FUNCTION
+			return &functions.UserFunction{Name: funcName}, nil
 		}
 		return def.Function()
 	}
 	p.Unscan()
 
+	// Parse optional DISTINCT keyword.
+	distinct, err := p.parseOptional(scanner.DISTINCT)
+	if err != nil {
+		return nil, err
+	}
+
 	var exprs []expr.Expr
 
 	// Parse expressions.
@@ -513,22 +744,225 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 		}
 	}
 
+	// Parse optional ORDER BY clause.
+	orderBy, desc, err := p.parseAggregateOrderBy()
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse required ) token.
 	if err := p.ParseTokens(scanner.RPAREN); err != nil {
 		return nil, err
 	}
 
-	def, err := functions.GetFunc(funcName)
+	var fn expr.Function
+	if def, err := functions.GetFunc(funcName); err == nil {
+		fn, err = def.Function(exprs...)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		fn = &functions.UserFunction{Name: funcName, Args: exprs}
+	}
+
+	if distinct {
+		agg, ok := fn.(expr.AggregatorBuilder)
+		if !ok {
+			return nil, errors.Errorf("DISTINCT specified, but %s is not an aggregate function", fn)
+		}
+		if _, ok := fn.(*functions.Percentile); ok {
+			return nil, errors.Errorf("DISTINCT cannot be used with %s", funcName)
+		}
+		if _, ok := exprs[0].(expr.Wildcard); ok {
+			return nil, errors.Errorf("DISTINCT cannot be used with %s(*)", funcName)
+		}
+
+		fn = &functions.DistinctAggregate{Fn: agg}
+	}
+
+	if pc, ok := fn.(*functions.Percentile); ok {
+		if len(orderBy) > 0 {
+			return nil, errors.Errorf("ORDER BY specified in the arguments of %s, use WITHIN GROUP (ORDER BY ...)", funcName)
+		}
+
+		err = p.parseWithinGroup(pc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(orderBy) > 0 {
+		agg, ok := fn.(expr.AggregatorBuilder)
+		if !ok {
+			return nil, errors.Errorf("ORDER BY specified, but %s is not an aggregate function", fn)
+		}
+
+		fn = &functions.OrderedAggregate{Fn: agg, OrderBy: orderBy, Desc: desc}
+	}
+
+	return p.parseAggregateFilter(fn)
+}
+
+// parseExtract parses the arguments of EXTRACT(field FROM expr),
+// after the opening parenthesis, and returns the equivalent
+// date_part(field, expr) call.
+func (p *Parser) parseExtract() (expr.Expr, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT && tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"field"}, pos)
+	}
+	field := expr.LiteralValue{Value: types.NewTextValue(lit)}
+
+	if err := p.ParseTokens(scanner.FROM); err != nil {
+		return nil, err
+	}
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	def, err := functions.GetFunc("date_part")
+	if err != nil {
+		return nil, err
+	}
+	return def.Function(field, e)
+}
+
+// parseAggregateOrderBy parses an optional ORDER BY expr [ASC|DESC], ...
+// clause at the end of the arguments of an aggregate function.
+func (p *Parser) parseAggregateOrderBy() ([]expr.Expr, []bool, error) {
+	ok, err := p.parseOptional(scanner.ORDER, scanner.BY)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+
+	var exprs []expr.Expr
+	var desc []bool
+
+	for {
+		e, err := p.ParseExpr()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var isAgg bool
+		expr.Walk(e, func(e expr.Expr) bool {
+			_, isAgg = e.(expr.AggregatorBuilder)
+			return !isAgg
+		})
+		if isAgg {
+			return nil, nil, errors.New("aggregate functions are not allowed in ORDER BY")
+		}
+
+		exprs = append(exprs, e)
+
+		// parse optional ASC or DESC
+		tok, _, _ := p.ScanIgnoreWhitespace()
+		if tok != scanner.ASC && tok != scanner.DESC {
+			p.Unscan()
+		}
+		desc = append(desc, tok == scanner.DESC)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	return exprs, desc, nil
+}
+
+// parseWithinGroup parses the required WITHIN GROUP (ORDER BY expr [ASC|DESC])
+// clause of ordered-set aggregate functions.
+func (p *Parser) parseWithinGroup(pc *functions.Percentile) error {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT || !strings.EqualFold(lit, "WITHIN") {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"WITHIN GROUP"}, pos)
+	}
+
+	if err := p.ParseTokens(scanner.GROUP, scanner.LPAREN); err != nil {
+		return err
+	}
+
+	orderBy, desc, err := p.parseAggregateOrderBy()
+	if err != nil {
+		return err
+	}
+	if len(orderBy) == 0 {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		return newParseError(scanner.Tokstr(tok, lit), []string{"ORDER BY"}, pos)
+	}
+	if len(orderBy) > 1 {
+		return errors.New("WITHIN GROUP accepts a single ORDER BY expression")
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return err
+	}
+
+	pc.Expr, pc.Desc = orderBy[0], desc[0]
+	return nil
+}
+
+// parseAggregateFilter parses an optional FILTER (WHERE expr) clause
+// following an aggregate function.
+// FILTER is not a keyword, so that it can be used as an identifier.
+func (p *Parser) parseAggregateFilter(fn expr.Function) (expr.Expr, error) {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "FILTER") {
+		p.Unscan()
+		return fn, nil
+	}
+
+	agg, ok := fn.(expr.AggregatorBuilder)
+	if !ok {
+		return nil, errors.Errorf("FILTER specified, but %s is not an aggregate function", fn)
+	}
+
+	if err := p.ParseTokens(scanner.LPAREN, scanner.WHERE); err != nil {
+		return nil, err
+	}
+
+	filter, err := p.ParseExpr()
 	if err != nil {
 		return nil, err
 	}
-	return def.Function(exprs...)
+
+	var isAgg bool
+	expr.Walk(filter, func(e expr.Expr) bool {
+		_, isAgg = e.(expr.AggregatorBuilder)
+		return !isAgg
+	})
+	if isAgg {
+		return nil, errors.New("aggregate functions are not allowed in FILTER")
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return &functions.FilterAggregate{Fn: agg, Filter: filter}, nil
 }
 
 // parseCastExpression parses a string of the form CAST(expr AS type).
 func (p *Parser) parseCastExpression() (expr.Expr, error) {
-	// Parse required CAST and ( tokens.
-	if err := p.ParseTokens(scanner.CAST, scanner.LPAREN); err != nil {
+	// Parse required CAST or TRY_CAST token.
+	var try bool
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.CAST:
+	case tok == scanner.IDENT && strings.EqualFold(lit, "TRY_CAST"):
+		try = true
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"CAST", "TRY_CAST"}, pos)
+	}
+
+	// Parse required ( token.
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
 		return nil, err
 	}
 
@@ -544,7 +978,7 @@ func (p *Parser) parseCastExpression() (expr.Expr, error) {
 	}
 
 	// Parse required typename.
-	tp, err := p.parseType()
+	tp, spec, err := p.parseTypeWithSpec()
 	if err != nil {
 		return nil, err
 	}
@@ -554,7 +988,7 @@ func (p *Parser) parseCastExpression() (expr.Expr, error) {
 		return nil, err
 	}
 
-	return &expr.Cast{Expr: e, CastAs: tp}, nil
+	return &expr.Cast{Expr: e, CastAs: tp, DecimalSpec: spec.decimal, VectorSpec: spec.vector, Try: try}, nil
 }
 
 // tokenIsAllowed is a helper function that determines if a token is allowed.
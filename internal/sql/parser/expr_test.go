@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/sql/parser"
@@ -13,6 +14,9 @@ import (
 )
 
 func TestParserExpr(t *testing.T) {
+	nocase, err := database.GetCollation("NOCASE")
+	require.NoError(t, err)
+
 	tests := []struct {
 		name     string
 		s        string
@@ -39,6 +43,17 @@ func TestParserExpr(t *testing.T) {
 		{"blob as hex string", `'\xff'`, testutil.BlobValue([]byte{255}), false},
 		{"invalid blob hex string", `'\xzz'`, nil, true},
 
+		// intervals
+		{"interval", "INTERVAL '1 day 02:00:00'", expr.LiteralValue{Value: types.NewIntervalValue(0, 1, 2*3600_000_000)}, false},
+		{"interval arithmetic", "a + interval '1 mon'", expr.Add(&expr.Column{Name: "a"}, expr.LiteralValue{Value: types.NewIntervalValue(1, 0, 0)}), false},
+		{"invalid interval", "INTERVAL '1 fortnight'", nil, true},
+		{"interval column", "interval", &expr.Column{Name: "interval"}, false},
+
+		// columns
+		{"qualified column", "t.a", &expr.Column{Name: "a", Table: "t"}, false},
+		{"quoted qualified column", "`t`.`a`", &expr.Column{Name: "a", Table: "t"}, false},
+		{"schema qualified column", "s.t.a", &expr.Column{Name: "a", Table: "s.t"}, false},
+
 		// parentheses
 		{"parentheses: empty", "()", nil, true},
 		{"parentheses: values", `(1)`,
@@ -82,6 +97,18 @@ func TestParserExpr(t *testing.T) {
 		{"IS NOT", "age IS NOT NULL", expr.IsNot(&expr.Column{Name: "age"}, testutil.NullValue()), false},
 		{"LIKE", "name LIKE 'foo'", expr.Like(&expr.Column{Name: "name"}, testutil.TextValue("foo")), false},
 		{"NOT LIKE", "name NOT LIKE 'foo'", expr.NotLike(&expr.Column{Name: "name"}, testutil.TextValue("foo")), false},
+		{"LIKE ESCAPE", "name LIKE 'foo!%' ESCAPE '!'", expr.LikeEscape(&expr.Column{Name: "name"}, testutil.TextValue("foo!%"), testutil.TextValue("!")), false},
+		{"NOT LIKE ESCAPE", "name NOT LIKE 'foo!%' escape '!'", expr.NotLikeEscape(&expr.Column{Name: "name"}, testutil.TextValue("foo!%"), testutil.TextValue("!")), false},
+		{"LIKE ESCAPE AND", "name LIKE 'foo' ESCAPE '' AND a", expr.And(expr.LikeEscape(&expr.Column{Name: "name"}, testutil.TextValue("foo"), testutil.TextValue("")), &expr.Column{Name: "a"}), false},
+		{"LIKE ESCAPE missing", "name LIKE 'foo' ESCAPE", nil, true},
+		{"COLLATE", "name COLLATE nocase = 'foo'", expr.Eq(&expr.Collate{Expr: &expr.Column{Name: "name"}, Collation: nocase}, testutil.TextValue("foo")), false},
+		{"COLLATE right", "name = 'foo' COLLATE NOCASE AND a", expr.And(expr.Eq(&expr.Column{Name: "name"}, &expr.Collate{Expr: testutil.TextValue("foo"), Collation: nocase}), &expr.Column{Name: "a"}), false},
+		{"COLLATE unknown", "name COLLATE foo_bar", nil, true},
+		{"COLLATE missing", "name COLLATE", nil, true},
+		{"~", "name ~ '^fo+'", expr.Regexp(&expr.Column{Name: "name"}, testutil.TextValue("^fo+")), false},
+		{"!~", "name !~ '^fo+'", expr.NotRegexp(&expr.Column{Name: "name"}, testutil.TextValue("^fo+")), false},
+		{"REGEXP", "name REGEXP '^fo+' AND a", expr.And(expr.Regexp(&expr.Column{Name: "name"}, testutil.TextValue("^fo+")), &expr.Column{Name: "a"}), false},
+		{"NOT REGEXP", "name NOT REGEXP '^fo+'", expr.NotRegexp(&expr.Column{Name: "name"}, testutil.TextValue("^fo+")), false},
 		{"NOT =", "name NOT = 'foo'", nil, true},
 		{"precedence", "4 > 1 + 2", expr.Gt(
 			testutil.IntegerValue(4),
@@ -112,6 +139,19 @@ func TestParserExpr(t *testing.T) {
 
 		// unary operators
 		{"CAST", "CAST(a AS TEXT)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeText}, false},
+		{"CAST AS DATE", "CAST(a AS DATE)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeDate}, false},
+		{"CAST AS DECIMAL", "CAST(a AS DECIMAL)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeDecimal}, false},
+		{"CAST AS NUMERIC(p, s)", "CAST(a AS NUMERIC(10, 2))", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeDecimal, DecimalSpec: types.DecimalSpec{Precision: 10, Scale: 2}}, false},
+		{"CAST AS DECIMAL(p)", "CAST(a AS DECIMAL(10))", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeDecimal, DecimalSpec: types.DecimalSpec{Precision: 10}}, false},
+		{"CAST AS DECIMAL with too many modifiers", "CAST(a AS DECIMAL(10, 2, 1))", nil, true},
+		{"CAST AS UUID", "CAST(a AS UUID)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeUUID}, false},
+		{"CAST AS VECTOR", "CAST(a AS VECTOR)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeVector}, false},
+		{"CAST AS VECTOR(n)", "CAST(a AS VECTOR(3))", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeVector, VectorSpec: types.VectorSpec{Dimension: 3}}, false},
+		{"CAST AS VECTOR with too many modifiers", "CAST(a AS VECTOR(3, 2))", nil, true},
+		{"TRY_CAST", "TRY_CAST(a AS INT)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeInteger, Try: true}, false},
+		{"try_cast lowercase", "try_cast(a AS NUMERIC(10, 2))", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeDecimal, DecimalSpec: types.DecimalSpec{Precision: 10, Scale: 2}, Try: true}, false},
+		{"TRY_CAST without AS", "TRY_CAST(a)", nil, true},
+		{"CAST AS VECTOR(0)", "CAST(a AS VECTOR(0))", nil, true},
 		{"NOT", "NOT 10", expr.Not(testutil.IntegerValue(10)), false},
 		{"NOT", "NOT NOT", nil, true},
 		{"NOT", "NOT NOT 10", expr.Not(expr.Not(testutil.IntegerValue(10))), false},
@@ -122,7 +162,24 @@ func TestParserExpr(t *testing.T) {
 		// functions
 		{"count(expr) function", "count(a)", &functions.Count{Expr: &expr.Column{Name: "a"}}, false},
 		{"count(*) function", "count(*)", functions.NewCount(expr.Wildcard{}), false},
+		{"age function", "age(a)", &functions.Age{Exprs: []expr.Expr{&expr.Column{Name: "a"}}}, false},
+		{"EXTRACT without FROM", "EXTRACT(year a)", nil, true},
+		{"EXTRACT without field", "EXTRACT(FROM a)", nil, true},
 		{"count (*) function with spaces", "count      (*)", functions.NewCount(expr.Wildcard{}), false},
+		{"count(*) function with filter", "count(*) FILTER (WHERE a > 1)", &functions.FilterAggregate{Fn: functions.NewCount(expr.Wildcard{}), Filter: expr.Gt(&expr.Column{Name: "a"}, testutil.IntegerValue(1))}, false},
+		{"filter on scalar function", "typeof(a) FILTER (WHERE a > 1)", nil, true},
+		{"filter without where", "count(*) FILTER (a > 1)", nil, true},
+		{"string_agg with order by", "string_agg(a, ',' ORDER BY b DESC, c)", &functions.OrderedAggregate{Fn: &functions.StringAgg{Expr: &expr.Column{Name: "a"}, Sep: testutil.TextValue(",")}, OrderBy: []expr.Expr{&expr.Column{Name: "b"}, &expr.Column{Name: "c"}}, Desc: []bool{true, false}}, false},
+		{"order by on scalar function", "typeof(a ORDER BY a)", nil, true},
+		{"count distinct", "COUNT(DISTINCT a)", &functions.DistinctAggregate{Fn: functions.NewCount(&expr.Column{Name: "a"})}, false},
+		{"count distinct wildcard", "COUNT(DISTINCT *)", nil, true},
+		{"distinct on scalar function", "typeof(DISTINCT a)", nil, true},
+		{"string_agg distinct with order by", "string_agg(DISTINCT a, ',' ORDER BY a)", &functions.OrderedAggregate{Fn: &functions.DistinctAggregate{Fn: &functions.StringAgg{Expr: &expr.Column{Name: "a"}, Sep: testutil.TextValue(",")}}, OrderBy: []expr.Expr{&expr.Column{Name: "a"}}, Desc: []bool{false}}, false},
+		{"percentile_cont", "percentile_cont(0.5) WITHIN GROUP (ORDER BY a DESC)", &functions.Percentile{Fraction: testutil.DoubleValue(0.5), Expr: &expr.Column{Name: "a"}, Desc: true}, false},
+		{"percentile_disc", "percentile_disc(0.5) within group (order by a)", &functions.Percentile{Fraction: testutil.DoubleValue(0.5), Expr: &expr.Column{Name: "a"}, Discrete: true}, false},
+		{"percentile without within group", "percentile_cont(0.5)", nil, true},
+		{"percentile with multiple order by", "percentile_cont(0.5) WITHIN GROUP (ORDER BY a, b)", nil, true},
+		{"stddev", "stddev(a)", &functions.Variance{Expr: &expr.Column{Name: "a"}, Name: "STDDEV", Sqrt: true}, false},
 		{"packaged function", "floor(1.2)", testutil.FunctionExpr(t, "floor", testutil.DoubleValue(1.2)), false},
 	}
 
@@ -161,6 +218,17 @@ func TestParserParams(t *testing.T) {
 				expr.Eq(&expr.Column{Name: "age"}, expr.NamedParam("bar")),
 			), false},
 		{"mixed", "age >= ? AND age > $foo OR age < ?", nil, true},
+		{"numbered", "age >= $2 AND age < $1 OR age = $2",
+			expr.Or(
+				expr.And(
+					expr.Gte(&expr.Column{Name: "age"}, expr.PositionalParam(2)),
+					expr.Lt(&expr.Column{Name: "age"}, expr.PositionalParam(1)),
+				),
+				expr.Eq(&expr.Column{Name: "age"}, expr.PositionalParam(2)),
+			), false},
+		{"numbered and positional", "age >= $1 AND age < ?", nil, true},
+		{"numbered and named", "age >= $foo AND age < $1", nil, true},
+		{"zero", "age = $0", nil, true},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/cockroachdb/errors"
+)
+
+// Fingerprint returns the fingerprint of a single SELECT, UPDATE or DELETE statement,
+// which identifies the statements that only differ by their literals, their parameters,
+// their comments, their whitespaces and the case of their keywords.
+func Fingerprint(s string) (string, error) {
+	q, err := NewParser(strings.NewReader(s)).ParseQuery()
+	if err != nil {
+		return "", err
+	}
+	if len(q.Statements) != 1 {
+		return "", errors.New("Fingerprint only works on a single statement")
+	}
+
+	switch t := q.Statements[0].(type) {
+	case *statement.SelectStmt:
+		return t.Fingerprint, nil
+	case *statement.UpdateStmt:
+		return t.Fingerprint, nil
+	case *statement.DeleteStmt:
+		return t.Fingerprint, nil
+	}
+
+	return "", errors.New("Fingerprint only works on SELECT, UPDATE and DELETE statements")
+}
+
+type scannedToken struct {
+	tok scanner.Token
+	lit string
+}
+
+// setFingerprint sets the fingerprint of the statements
+// whose plan can be pinned, computed from their tokens.
+func setFingerprint(s statement.Statement, tokens []scannedToken) {
+	// the statement explained is read with the plan pinned for it
+	if e, ok := s.(*statement.ExplainStmt); ok && len(tokens) > 0 {
+		s, tokens = e.Statement, tokens[1:]
+
+		// the ANALYZE option is the first identifier
+		if e.Analyze {
+			i := slices.IndexFunc(tokens, func(t scannedToken) bool { return t.tok == scanner.IDENT })
+			tokens = tokens[i+1:]
+		}
+	}
+
+	switch t := s.(type) {
+	case *statement.SelectStmt:
+		t.Fingerprint = fingerprint(tokens)
+	case *statement.UpdateStmt:
+		t.Fingerprint = fingerprint(tokens)
+	case *statement.DeleteStmt:
+		t.Fingerprint = fingerprint(tokens)
+	}
+}
+
+// fingerprint returns a hash of the normalized tokens of a statement.
+func fingerprint(tokens []scannedToken) string {
+	var sb strings.Builder
+
+	for _, t := range tokens {
+		var s string
+
+		switch t.tok {
+		case scanner.WS, scanner.COMMENT:
+			continue
+		case scanner.IDENT:
+			s = stringutil.NormalizeIdentifier(t.lit, '`')
+		case scanner.NAMEDPARAM, scanner.POSITIONALPARAM, scanner.NUMBER, scanner.INTEGER,
+			scanner.STRING, scanner.TRUE, scanner.FALSE, scanner.REGEX:
+			s = "?"
+		default:
+			s = t.tok.String()
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(s)
+	}
+
+	h := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(h[:8])
+}
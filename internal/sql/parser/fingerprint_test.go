@@ -0,0 +1,69 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func mustFingerprint(t testing.TB, s string) string {
+	t.Helper()
+
+	fp, err := parser.Fingerprint(s)
+	require.NoError(t, err)
+	return fp
+}
+
+func TestParserFingerprint(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{
+		{"Literals", "SELECT * FROM foo WHERE a = 1 AND b = 'x'", "SELECT * FROM foo WHERE a = 20 AND b = 'y'", true},
+		{"Parameters", "SELECT * FROM foo WHERE a = ?", "SELECT * FROM foo WHERE a = $1", true},
+		{"Whitespaces and comments", "SELECT * FROM foo WHERE a = 1", "select  *\nfrom foo /* comment */ where a=1 -- comment", true},
+		{"Hints", "SELECT * FROM foo", "SELECT /*+ NO_INDEX */ * FROM foo", true},
+		{"Trailing semicolon", "DELETE FROM foo WHERE a > 1", "DELETE FROM foo WHERE a > 2;", true},
+		{"Columns", "SELECT * FROM foo WHERE a = 1", "SELECT * FROM foo WHERE b = 1", false},
+		{"Tables", "UPDATE foo SET a = 1", "UPDATE bar SET a = 1", false},
+		{"Operators", "SELECT * FROM foo WHERE a = 1", "SELECT * FROM foo WHERE a > 1", false},
+		{"Identifiers and literals", "SELECT a FROM foo", "SELECT 'a' FROM foo", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := mustFingerprint(t, test.a), mustFingerprint(t, test.b)
+			require.NotEmpty(t, a)
+			if test.equal {
+				require.Equal(t, a, b)
+			} else {
+				require.NotEqual(t, a, b)
+			}
+		})
+	}
+
+	t.Run("Explain", func(t *testing.T) {
+		q, err := parser.ParseQuery("EXPLAIN SELECT * FROM foo WHERE a = 1")
+		require.NoError(t, err)
+		require.Equal(t, mustFingerprint(t, "SELECT * FROM foo WHERE a = 2"), q.Statements[0].(*statement.ExplainStmt).Statement.(*statement.SelectStmt).Fingerprint)
+	})
+
+	t.Run("Explain analyze", func(t *testing.T) {
+		q, err := parser.ParseQuery("EXPLAIN ANALYZE DELETE FROM foo WHERE a = 1")
+		require.NoError(t, err)
+		require.Equal(t, mustFingerprint(t, "DELETE FROM foo WHERE a = 2"), q.Statements[0].(*statement.ExplainStmt).Statement.(*statement.DeleteStmt).Fingerprint)
+	})
+
+	t.Run("Multiple statements", func(t *testing.T) {
+		_, err := parser.Fingerprint("SELECT 1; SELECT 2")
+		require.Error(t, err)
+	})
+
+	t.Run("Other statements", func(t *testing.T) {
+		_, err := parser.Fingerprint("INSERT INTO foo (a) VALUES (1)")
+		require.Error(t, err)
+	})
+}
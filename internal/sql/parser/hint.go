@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// parseOptimizerHints parses the optimizer hints written in the comments
+// starting with a + that follow the current token. Other comments are ignored.
+func (p *Parser) parseOptimizerHints() ([]statement.OptimizerHint, error) {
+	var hints []statement.OptimizerHint
+
+	for {
+		tok, _, lit := p.Scan()
+		if tok == scanner.WS {
+			continue
+		}
+		if tok != scanner.COMMENT {
+			p.Unscan()
+			return hints, nil
+		}
+		if !strings.HasPrefix(lit, "+") {
+			continue
+		}
+
+		hp := NewParser(strings.NewReader(lit[1:]))
+		for {
+			tok, _, _ := hp.ScanIgnoreWhitespace()
+			if tok == scanner.EOF {
+				break
+			}
+			// hints can be separated by commas
+			if tok == scanner.COMMA {
+				continue
+			}
+			hp.Unscan()
+
+			h, err := hp.parseOptimizerHint()
+			if err != nil {
+				return nil, err
+			}
+			hints = append(hints, *h)
+		}
+	}
+}
+
+// parseOptimizerHint parses a single optimizer hint:
+//
+//	INDEX(table_name index_name)
+//	NO_INDEX [(table_name)]
+func (p *Parser) parseOptimizerHint() (*statement.OptimizerHint, error) {
+	var h statement.OptimizerHint
+	var err error
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.INDEX:
+		h.Name = statement.HintIndex
+
+		if err := p.ParseTokens(scanner.LPAREN); err != nil {
+			return nil, err
+		}
+		h.Table, err = p.parseQualifiedIdent()
+		if err != nil {
+			return nil, err
+		}
+		h.Index, err = p.parseQualifiedIdent()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.ParseTokens(scanner.RPAREN); err != nil {
+			return nil, err
+		}
+	case tok == scanner.IDENT && strings.EqualFold(lit, statement.HintNoIndex):
+		h.Name = statement.HintNoIndex
+
+		ok, err := p.parseOptional(scanner.LPAREN)
+		if err != nil || !ok {
+			return &h, err
+		}
+		h.Table, err = p.parseQualifiedIdent()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.ParseTokens(scanner.RPAREN); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{statement.HintIndex, statement.HintNoIndex}, pos)
+	}
+
+	return &h, nil
+}
@@ -0,0 +1,66 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserOptimizerHints(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected []statement.OptimizerHint
+		errored  bool
+	}{
+		{"No hint", "SELECT * FROM foo", nil, false},
+		{"Comment", "SELECT /* INDEX(foo idx) */ * FROM foo", nil, false},
+		{"INDEX", "SELECT /*+ INDEX(foo idx) */ * FROM foo", []statement.OptimizerHint{
+			{Name: "INDEX", Table: "foo", Index: "idx"},
+		}, false},
+		{"INDEX / qualified", "SELECT /*+ INDEX(s.foo s.idx) */ * FROM s.foo", []statement.OptimizerHint{
+			{Name: "INDEX", Table: "s.foo", Index: "s.idx"},
+		}, false},
+		{"NO_INDEX", "SELECT /*+ no_index */ * FROM foo", []statement.OptimizerHint{
+			{Name: "NO_INDEX"},
+		}, false},
+		{"NO_INDEX / table", "SELECT /*+ NO_INDEX(foo) */ * FROM foo", []statement.OptimizerHint{
+			{Name: "NO_INDEX", Table: "foo"},
+		}, false},
+		{"Several hints", "SELECT /*+ NO_INDEX(bar), INDEX(foo idx) */ /*+ NO_INDEX(baz) */ DISTINCT * FROM foo", []statement.OptimizerHint{
+			{Name: "NO_INDEX", Table: "bar"},
+			{Name: "INDEX", Table: "foo", Index: "idx"},
+			{Name: "NO_INDEX", Table: "baz"},
+		}, false},
+		{"With error / unknown hint", "SELECT /*+ FULL(foo) */ * FROM foo", nil, true},
+		{"With error / missing index", "SELECT /*+ INDEX(foo) */ * FROM foo", nil, true},
+		{"With error / missing parenthesis", "SELECT /*+ NO_INDEX(foo */ * FROM foo", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0].(*statement.SelectStmt).CompoundSelect[0].Hints)
+		})
+	}
+
+	t.Run("UPDATE", func(t *testing.T) {
+		q, err := parser.ParseQuery("UPDATE /*+ NO_INDEX */ foo SET a = 1")
+		require.NoError(t, err)
+		require.Equal(t, []statement.OptimizerHint{{Name: "NO_INDEX"}}, q.Statements[0].(*statement.UpdateStmt).Hints)
+	})
+
+	t.Run("DELETE", func(t *testing.T) {
+		q, err := parser.ParseQuery("DELETE /*+ INDEX(foo idx) */ FROM foo")
+		require.NoError(t, err)
+		require.Equal(t, []statement.OptimizerHint{{Name: "INDEX", Table: "foo", Index: "idx"}}, q.Statements[0].(*statement.DeleteStmt).Hints)
+	})
+}
@@ -21,7 +21,7 @@ func (p *Parser) parseInsertStatement() (*statement.InsertStmt, error) {
 	}
 
 	// Parse table name
-	stmt.TableName, err = p.parseIdent()
+	stmt.TableName, err = p.parseQualifiedIdent()
 	if err != nil {
 		pErr := errors.UnwrapAll(err).(*ParseError)
 		pErr.Expected = []string{"table_name"}
@@ -45,10 +45,14 @@ func (p *Parser) parseInsertStatement() (*statement.InsertStmt, error) {
 		}
 	case scanner.SELECT:
 		p.Unscan()
-		stmt.SelectStmt, err = p.parseSelectStatement()
+		slct, err := p.parseSelectStatement()
 		if err != nil {
 			return nil, err
 		}
+		if slct.AsOf != nil {
+			return nil, &ParseError{Message: "AS OF cannot be used in INSERT statements"}
+		}
+		stmt.SelectStmt = slct
 	default:
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VALUES", "SELECT"}, pos)
 	}
@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/stream/rows"
+)
+
+// parseMatchRecognize parses the optional MATCH_RECOGNIZE clause following the table name.
+// MATCH_RECOGNIZE, PARTITION, PATTERN and DEFINE are not keywords, to allow using them
+// as identifiers. Symbols are case insensitive.
+//
+//	MATCH_RECOGNIZE (
+//	  [ PARTITION BY expr ]
+//	  ORDER BY expr [ ASC | DESC ]
+//	  PATTERN ( symbol [ + | * | ? ] [...] )
+//	  [ DEFINE symbol AS expr [, ...] ]
+//	)
+func (p *Parser) parseMatchRecognize() (*rows.MatchRecognizeOperator, error) {
+	if !p.parseOptionalIdent("MATCH_RECOGNIZE") {
+		return nil, nil
+	}
+
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	var op rows.MatchRecognizeOperator
+	var err error
+
+	if p.parseOptionalIdent("PARTITION") {
+		if err := p.ParseTokens(scanner.BY); err != nil {
+			return nil, err
+		}
+
+		op.PartitionBy, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.ParseTokens(scanner.ORDER, scanner.BY); err != nil {
+		return nil, err
+	}
+
+	op.OrderBy, err = p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.DESC {
+		op.Desc = true
+	} else if tok != scanner.ASC {
+		p.Unscan()
+	}
+
+	op.Pattern, err = p.parsePattern()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.parseOptionalIdent("DEFINE") {
+		for {
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			if tok != scanner.IDENT {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"symbol"}, pos)
+			}
+			symbol := strings.ToUpper(lit)
+
+			if !patternHasSymbol(op.Pattern, symbol) {
+				return nil, &ParseError{Message: fmt.Sprintf("symbol %s is not part of the pattern", symbol), Pos: pos}
+			}
+			for _, d := range op.Define {
+				if d.Symbol == symbol {
+					return nil, &ParseError{Message: fmt.Sprintf("symbol %s is defined more than once", symbol), Pos: pos}
+				}
+			}
+
+			if err := p.ParseTokens(scanner.AS); err != nil {
+				return nil, err
+			}
+
+			e, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+
+			op.Define = append(op.Define, rows.PatternDefinition{Symbol: symbol, Expr: e})
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return &op, nil
+}
+
+// parsePattern parses the PATTERN clause of MATCH_RECOGNIZE.
+func (p *Parser) parsePattern() ([]rows.PatternElement, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT || !strings.EqualFold(lit, "PATTERN") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"PATTERN"}, pos)
+	}
+
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	var pattern []rows.PatternElement
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok == scanner.RPAREN && len(pattern) > 0 {
+			return pattern, nil
+		}
+		if tok != scanner.IDENT {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"symbol"}, pos)
+		}
+
+		el := rows.PatternElement{Symbol: strings.ToUpper(lit), Min: 1, Max: 1}
+
+		switch tok, _, _ := p.Scan(); tok {
+		case scanner.ADD:
+			el.Max = -1
+		case scanner.MUL:
+			el.Min, el.Max = 0, -1
+		case scanner.POSITIONALPARAM:
+			el.Min = 0
+		default:
+			p.Unscan()
+		}
+
+		pattern = append(pattern, el)
+	}
+}
+
+func patternHasSymbol(pattern []rows.PatternElement, symbol string) bool {
+	for _, el := range pattern {
+		if el.Symbol == symbol {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseOptionalIdent consumes the next token if it is the given
+// identifier, ignoring case, and reports whether it did.
+func (p *Parser) parseOptionalIdent(name string) bool {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.IDENT && strings.EqualFold(lit, name) {
+		return true
+	}
+
+	p.Unscan()
+	return false
+}
@@ -2,31 +2,98 @@ package parser
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/stream/rows"
 )
 
-func (p *Parser) parseOrderBy() (*expr.Column, scanner.Token, error) {
+// parseOrderBy parses an optional ORDER BY clause. Rows can be
+// sorted by columns or by the result of functions, such as
+// the distance between vectors.
+//
+//	ORDER BY expr [ASC | DESC] [NULLS { FIRST | LAST }] [, ...]
+func (p *Parser) parseOrderBy() ([]rows.SortKey, error) {
 	// parse ORDER token
 	ok, err := p.parseOptional(scanner.ORDER, scanner.BY)
 	if err != nil || !ok {
-		return nil, 0, err
+		return nil, err
+	}
+
+	var keys []rows.SortKey
+	for {
+		k, err := p.parseSortKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	return keys, nil
+}
+
+// parseSortKey parses an expression of an ORDER BY clause and its direction.
+func (p *Parser) parseSortKey() (rows.SortKey, error) {
+	var k rows.SortKey
+	var err error
+
+	// parse col or function
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	next, _, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	if tk, _, _ := p.s.Curr(); tk == scanner.WS {
+		p.Unscan()
+	}
+	p.Unscan()
+	if tok == scanner.IDENT && next == scanner.LPAREN {
+		k.Expr, err = p.parseFunction()
+		if err != nil {
+			return k, err
+		}
+		if _, ok := k.Expr.(expr.AggregatorBuilder); ok {
+			return k, errors.New("aggregator functions are not allowed in ORDER BY clause")
+		}
+	} else {
+		k.Expr, err = p.parseColumn()
+		if err != nil {
+			return k, err
+		}
 	}
 
-	// parse col
-	col, err := p.parseColumn()
+	k.Expr, err = p.parseCollate(k.Expr)
 	if err != nil {
-		return nil, 0, err
+		return k, err
 	}
 
 	// parse optional ASC or DESC
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
-		return col, tok, nil
+		k.Desc = tok == scanner.DESC
+	} else {
+		p.Unscan()
+	}
+
+	// parse optional NULLS FIRST or NULLS LAST
+	if !p.parseOptionalIdent("NULLS") {
+		return k, nil
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "FIRST"):
+		k.Nulls = rows.NullsFirst
+	case tok == scanner.IDENT && strings.EqualFold(lit, "LAST"):
+		k.Nulls = rows.NullsLast
+	default:
+		return k, newParseError(scanner.Tokstr(tok, lit), []string{"FIRST", "LAST"}, pos)
 	}
-	p.Unscan()
 
-	return col, 0, nil
+	return k, nil
 }
 
 func (p *Parser) parseLimit() (expr.Expr, error) {
@@ -15,9 +15,14 @@ import (
 
 // Parser represents an Chai SQL Parser.
 type Parser struct {
-	s             *scanner.Scanner
-	orderedParams int
-	namedParams   int
+	s              *scanner.Scanner
+	orderedParams  int
+	namedParams    int
+	numberedParams int
+
+	// tokens read since the beginning of the statement being parsed,
+	// from which its fingerprint is computed.
+	tokens []scannedToken
 }
 
 // NewParser returns a new instance of Parser.
@@ -26,8 +31,19 @@ func NewParser(r io.Reader) *Parser {
 }
 
 // ParseQuery parses a query string and returns its AST representation.
+// The statements of the returned query are parsed again from s
+// when they must be prepared again after a schema change.
 func ParseQuery(s string) (query.Query, error) {
-	return NewParser(strings.NewReader(s)).ParseQuery()
+	q, err := NewParser(strings.NewReader(s)).ParseQuery()
+	if err != nil {
+		return q, err
+	}
+
+	q.Reparse = func() ([]statement.Statement, error) {
+		q, err := NewParser(strings.NewReader(s)).ParseQuery()
+		return q.Statements, err
+	}
+	return q, nil
 }
 
 // ParseExpr parses an expression.
@@ -77,11 +93,15 @@ func (p *Parser) Parse(fn func(statement.Statement) error) error {
 			return nil
 		}
 
+		p.tokens = p.tokens[:0]
+
 		s, err := p.ParseStatement()
 		if err != nil {
 			return err
 		}
 
+		setFingerprint(s, p.tokens)
+
 		tok, pos, lit := p.ScanIgnoreWhitespace()
 		switch tok {
 		case scanner.EOF:
@@ -125,12 +145,43 @@ func (p *Parser) ParseStatement() (statement.Statement, error) {
 		return p.parseExplainStatement()
 	case scanner.REINDEX:
 		return p.parseReIndexStatement()
+	case scanner.CHECK:
+		return p.parseCheckIndexStatement()
 	case scanner.ROLLBACK:
 		return p.parseRollbackStatement()
+	case scanner.SET:
+		return p.parseSetStatement()
+	case scanner.IDENT:
+		// SHOW is not a keyword, so that it can be used as an identifier
+		if strings.EqualFold(lit, "show") {
+			return p.parseShowStatement()
+		}
+		// neither are GRANT and REVOKE
+		if strings.EqualFold(lit, "grant") {
+			return p.parseGrantStatement()
+		}
+		if strings.EqualFold(lit, "revoke") {
+			return p.parseRevokeStatement()
+		}
+		// nor is TRUNCATE
+		if strings.EqualFold(lit, "truncate") {
+			return p.parseTruncateStatement()
+		}
+		// nor are ATTACH and DETACH
+		if strings.EqualFold(lit, "attach") {
+			return p.parseAttachStatement()
+		}
+		if strings.EqualFold(lit, "detach") {
+			return p.parseDetachStatement()
+		}
+		// nor is VACUUM
+		if strings.EqualFold(lit, "vacuum") {
+			return p.parseVacuumStatement()
+		}
 	}
 
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{
-		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
+		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "CHECK", "ROLLBACK", "SET", "SHOW", "GRANT", "REVOKE", "TRUNCATE", "VACUUM", "ATTACH", "DETACH",
 	}, pos)
 }
 
@@ -236,7 +287,11 @@ func (p *Parser) parseColumnList() ([]string, tree.SortOrder, error) {
 }
 
 // Scan returns the next token from the underlying scanner.
-func (p *Parser) Scan() (tok scanner.Token, pos scanner.Pos, lit string) { return p.s.Scan() }
+func (p *Parser) Scan() (tok scanner.Token, pos scanner.Pos, lit string) {
+	tok, pos, lit = p.s.Scan()
+	p.tokens = append(p.tokens, scannedToken{tok: tok, lit: lit})
+	return
+}
 
 // ScanIgnoreWhitespace scans the next non-whitespace and non-comment token.
 func (p *Parser) ScanIgnoreWhitespace() (tok scanner.Token, pos scanner.Pos, lit string) {
@@ -252,6 +307,9 @@ func (p *Parser) ScanIgnoreWhitespace() (tok scanner.Token, pos scanner.Pos, lit
 // Unscan pushes the previously read token back onto the buffer.
 func (p *Parser) Unscan() {
 	p.s.Unscan()
+	if len(p.tokens) > 0 {
+		p.tokens = p.tokens[:len(p.tokens)-1]
+	}
 }
 
 // ParseTokens parses all the given tokens one after the other.
@@ -16,8 +16,11 @@ func TestParserMultiStatement(t *testing.T) {
 		{TableName: "foo", ProjectionExprs: []expr.Expr{expr.Wildcard{}}},
 	}
 
+	slct.Fingerprint = mustFingerprint(t, "SELECT * FROM foo")
+
 	dlt := statement.NewDeleteStatement()
 	dlt.TableName = "foo"
+	dlt.Fingerprint = mustFingerprint(t, "DELETE FROM foo")
 
 	tests := []struct {
 		name     string
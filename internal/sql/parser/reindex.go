@@ -14,11 +14,14 @@ func (p *Parser) parseReIndexStatement() (statement.Statement, error) {
 		return nil, err
 	}
 
-	tok, _, lit := p.ScanIgnoreWhitespace()
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
 	if tok == scanner.IDENT {
-		stmt.TableOrIndexName = lit
-	} else {
-		p.Unscan()
+		var err error
+		stmt.TableOrIndexName, err = p.parseQualifiedIdent()
+		if err != nil {
+			return nil, err
+		}
 	}
 	return stmt, nil
 }
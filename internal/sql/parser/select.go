@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
@@ -12,14 +14,14 @@ import (
 func (p *Parser) parseSelectStatement() (*statement.SelectStmt, error) {
 	stmt := statement.NewSelectStatement()
 
-	// Parse SELECT ... [UNION | UNION ALL | INTERSECT] SELECT ...
+	// Parse SELECT ... [{UNION | INTERSECT | EXCEPT} [ALL]] SELECT ...
 	err := p.parseCompoundSelectStatement(stmt)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	stmt.OrderBy, stmt.OrderByDirection, err = p.parseOrderBy()
+	// Parse order by: "ORDER BY expr [ASC|DESC] [NULLS {FIRST|LAST}], ..."
+	stmt.OrderBy, err = p.parseOrderBy()
 	if err != nil {
 		return nil, err
 	}
@@ -41,75 +43,125 @@ func (p *Parser) parseSelectStatement() (*statement.SelectStmt, error) {
 
 func (p *Parser) parseCompoundSelectStatement(stmt *statement.SelectStmt) error {
 	for {
-		core, err := p.parseSelectCore()
+		core, asOf, err := p.parseSelectCore()
 		if err != nil {
 			return err
 		}
 
-		// Parse optional compound operator
-		tok, _, _ := p.ScanIgnoreWhitespace()
-		if tok == scanner.UNION {
-			all, err := p.parseOptional(scanner.ALL)
-			if err != nil {
-				return err
-			}
-			if all {
-				tok = scanner.ALL
+		if asOf != nil {
+			if stmt.AsOf != nil {
+				return &ParseError{Message: "AS OF can only be specified once per statement"}
 			}
+			stmt.AsOf = asOf
 		}
 
 		stmt.CompoundSelect = append(stmt.CompoundSelect, core)
 
-		if tok != scanner.UNION && tok != scanner.ALL {
-			p.Unscan()
+		// Parse optional compound operator
+		op, err := p.parseCompoundOperator()
+		if err != nil {
+			return err
+		}
+		if op == 0 {
 			break
 		}
 
-		stmt.CompoundOperators = append(stmt.CompoundOperators, tok)
+		stmt.CompoundOperators = append(stmt.CompoundOperators, op)
 	}
 
 	return nil
 }
 
-func (p *Parser) parseSelectCore() (*statement.SelectCoreStmt, error) {
+// parseCompoundOperator parses "{UNION | INTERSECT | EXCEPT} [ALL]".
+// It returns 0 if the next token is not a compound operator.
+func (p *Parser) parseCompoundOperator() (statement.CompoundOperator, error) {
+	var op, all statement.CompoundOperator
+
+	switch {
+	case p.parseOptionalIdent("INTERSECT"):
+		op, all = statement.Intersect, statement.IntersectAll
+	case p.parseOptionalIdent("EXCEPT"):
+		op, all = statement.Except, statement.ExceptAll
+	default:
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.UNION {
+			p.Unscan()
+			return 0, nil
+		}
+		op, all = statement.Union, statement.UnionAll
+	}
+
+	ok, err := p.parseOptional(scanner.ALL)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return all, nil
+	}
+
+	return op, nil
+}
+
+// parseSelectCore parses a single SELECT statement and
+// returns the expression of its AS OF clause, if any.
+func (p *Parser) parseSelectCore() (*statement.SelectCoreStmt, expr.Expr, error) {
 	var stmt statement.SelectCoreStmt
 	var err error
 
 	// Parse "SELECT".
 	if err := p.ParseTokens(scanner.SELECT); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	stmt.Hints, err = p.parseOptimizerHints()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	stmt.Distinct, err = p.parseOptional(scanner.DISTINCT)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Parse path list or query.Wildcard
 	stmt.ProjectionExprs, err = p.parseProjectedExprs()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Parse "FROM".
 	stmt.TableName, err = p.parseFrom()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Parse "AS OF TIMESTAMP expr"
+	var asOf expr.Expr
+	if stmt.TableName != "" {
+		asOf, err = p.parseAsOf()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Parse "MATCH_RECOGNIZE (...)"
+		stmt.MatchRecognize, err = p.parseMatchRecognize()
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Parse condition: "WHERE expr".
 	stmt.WhereExpr, err = p.parseCondition()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Parse group by: "GROUP BY expr"
 	stmt.GroupByExpr, err = p.parseGroupBy()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &stmt, nil
+	return &stmt, asOf, nil
 }
 
 // parseProjectedExprs parses the list of projected fields.
@@ -174,7 +226,7 @@ func (p *Parser) parseFrom() (string, error) {
 	}
 
 	// Parse table name
-	ident, err := p.parseIdent()
+	ident, err := p.parseQualifiedIdent()
 	if err != nil {
 		pErr := errors.Unwrap(err).(*ParseError)
 		pErr.Expected = []string{"table_name"}
@@ -184,6 +236,26 @@ func (p *Parser) parseFrom() (string, error) {
 	return ident, nil
 }
 
+// parseAsOf parses the optional "AS OF TIMESTAMP expr" clause.
+// OF is not a keyword, to allow using it as an identifier.
+func (p *Parser) parseAsOf() (expr.Expr, error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.AS {
+		p.Unscan()
+		return nil, nil
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT || !strings.EqualFold(lit, "OF") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"OF"}, pos)
+	}
+
+	if err := p.ParseTokens(scanner.TYPETIMESTAMP); err != nil {
+		return nil, err
+	}
+
+	return p.ParseExpr()
+}
+
 func (p *Parser) parseGroupBy() (expr.Expr, error) {
 	ok, err := p.parseOptional(scanner.GROUP, scanner.BY)
 	if err != nil || !ok {
@@ -133,6 +133,20 @@ func TestParserSelect(t *testing.T) {
 				Pipe(rows.TempTreeSortReverse(parseExpr("a"))),
 			true, false,
 		},
+		{"WithOrderBy multiple keys", "SELECT * FROM test ORDER BY a DESC, b NULLS LAST, age desc nulls first",
+			stream.New(table.Scan("test")).
+				Pipe(rows.Project(expr.Wildcard{})).
+				Pipe(rows.TempTreeSortBy(
+					rows.SortKey{Expr: parseExpr("a"), Desc: true},
+					rows.SortKey{Expr: parseExpr("b"), Nulls: rows.NullsLast},
+					rows.SortKey{Expr: parseExpr("age"), Desc: true, Nulls: rows.NullsFirst},
+				)),
+			true, false,
+		},
+		{"WithOrderBy NULLS without FIRST or LAST", "SELECT * FROM test ORDER BY a NULLS",
+			nil,
+			true, true,
+		},
 		{"WithLimit", "SELECT * FROM test WHERE age = 10 LIMIT 20",
 			stream.New(table.Scan("test")).
 				Pipe(rows.Filter(parseExpr("age = 10"))).
@@ -385,6 +399,38 @@ func TestParserSelect(t *testing.T) {
 			)),
 			false, false,
 		},
+		{"WithIntersect", "SELECT * FROM test1 INTERSECT SELECT * FROM test2",
+			stream.New(stream.Intersect(false,
+				stream.New(table.Scan("test1")).Pipe(rows.Project(expr.Wildcard{})),
+				stream.New(table.Scan("test2")).Pipe(rows.Project(expr.Wildcard{})),
+			)),
+			true, false,
+		},
+		{"WithExceptAll", "SELECT * FROM test1 except all SELECT * FROM test2 ORDER BY a",
+			stream.New(stream.Except(true,
+				stream.New(table.Scan("test1")).Pipe(rows.Project(expr.Wildcard{})),
+				stream.New(table.Scan("test2")).Pipe(rows.Project(expr.Wildcard{})),
+			)).Pipe(rows.TempTreeSort(parseExpr("a", "test1"))),
+			true, false,
+		},
+		{"WithIntersectAfterLimit", "SELECT * FROM test1 LIMIT 10 INTERSECT SELECT * FROM test2",
+			nil,
+			true, true,
+		},
+		{"WithMultipleSetOps", "SELECT * FROM a EXCEPT SELECT * FROM b EXCEPT SELECT * FROM c INTERSECT ALL SELECT * FROM d UNION SELECT * FROM a",
+			stream.New(stream.Union(
+				stream.New(stream.Intersect(true,
+					stream.New(stream.Except(false,
+						stream.New(table.Scan("a")).Pipe(rows.Project(expr.Wildcard{})),
+						stream.New(table.Scan("b")).Pipe(rows.Project(expr.Wildcard{})),
+						stream.New(table.Scan("c")).Pipe(rows.Project(expr.Wildcard{})),
+					)),
+					stream.New(table.Scan("d")).Pipe(rows.Project(expr.Wildcard{})),
+				)),
+				stream.New(table.Scan("a")).Pipe(rows.Project(expr.Wildcard{})),
+			)),
+			true, false,
+		},
 	}
 
 	for _, test := range tests {
@@ -403,7 +449,7 @@ func TestParserSelect(t *testing.T) {
 			require.NoError(t, err)
 
 			require.Len(t, q.Statements, 1)
-			require.EqualValues(t, &statement.PreparedStreamStmt{ReadOnly: test.readOnly, Stream: test.expected}, q.Statements[0].(*statement.PreparedStreamStmt))
+			require.EqualValues(t, &statement.PreparedStreamStmt{ReadOnly: test.readOnly, Stream: test.expected, Fingerprint: mustFingerprint(t, test.s)}, q.Statements[0].(*statement.PreparedStreamStmt))
 		})
 	}
 }
@@ -413,3 +459,73 @@ func BenchmarkSelect(b *testing.B) {
 		_, _ = parser.ParseQuery("SELECT a, b AS `foo` FROM `some table` WHERE d.e[100] >= 12 AND c.d IN ([1, true], [2, false]) GROUP BY d.e[0] LIMIT 10 + 10 OFFSET 20 - 20 ORDER BY d DESC")
 	}
 }
+
+func TestParserSelectAsOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected expr.Expr
+		fails    bool
+	}{
+		{"No AS OF", "SELECT * FROM test", nil, false},
+		{"AS OF", "SELECT * FROM test AS OF TIMESTAMP '2024-01-01' WHERE a > 1", testutil.TextValue("2024-01-01"), false},
+		{"AS OF with param", "SELECT * FROM test AS OF TIMESTAMP ?", expr.PositionalParam(1), false},
+		{"AS OF with compound select", "SELECT * FROM test AS OF TIMESTAMP ? UNION ALL SELECT * FROM foo", expr.PositionalParam(1), false},
+		{"Multiple AS OF", "SELECT * FROM test AS OF TIMESTAMP ? UNION ALL SELECT * FROM foo AS OF TIMESTAMP ?", nil, true},
+		{"Missing OF", "SELECT * FROM test AS TIMESTAMP ?", nil, true},
+		{"Missing TIMESTAMP", "SELECT * FROM test AS OF ?", nil, true},
+		{"Within INSERT", "INSERT INTO foo SELECT * FROM test AS OF TIMESTAMP ?", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0].(*statement.SelectStmt).AsOf)
+		})
+	}
+}
+
+func TestParserSelectMatchRecognize(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected string
+		fails    bool
+	}{
+		{"No MATCH_RECOGNIZE", "SELECT * FROM test", "", false},
+		{"Full", "SELECT * FROM test MATCH_RECOGNIZE (PARTITION BY a ORDER BY b DESC PATTERN (x y+ z* w?) DEFINE x AS c > 1, Y AS c < 1) WHERE a > 1",
+			`rows.MatchRecognize(PARTITION BY a, ORDER BY b DESC, PATTERN (X Y+ Z* W?), DEFINE X AS c > 1, DEFINE Y AS c < 1)`, false},
+		{"Minimal", "select * from test match_recognize (order by b asc pattern (a))", `rows.MatchRecognize(ORDER BY b, PATTERN (A))`, false},
+		{"With AS OF", "SELECT * FROM test AS OF TIMESTAMP ? MATCH_RECOGNIZE (ORDER BY b PATTERN (a))", `rows.MatchRecognize(ORDER BY b, PATTERN (A))`, false},
+		{"Missing ORDER BY", "SELECT * FROM test MATCH_RECOGNIZE (PATTERN (a))", "", true},
+		{"Missing PATTERN", "SELECT * FROM test MATCH_RECOGNIZE (ORDER BY b)", "", true},
+		{"Empty pattern", "SELECT * FROM test MATCH_RECOGNIZE (ORDER BY b PATTERN ())", "", true},
+		{"Unknown symbol", "SELECT * FROM test MATCH_RECOGNIZE (ORDER BY b PATTERN (a) DEFINE b AS c > 1)", "", true},
+		{"Duplicate definition", "SELECT * FROM test MATCH_RECOGNIZE (ORDER BY b PATTERN (a) DEFINE a AS c > 1, A AS c < 1)", "", true},
+		{"Missing parenthesis", "SELECT * FROM test MATCH_RECOGNIZE (ORDER BY b PATTERN (a)", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			mr := q.Statements[0].(*statement.SelectStmt).CompoundSelect[0].MatchRecognize
+			if test.expected == "" {
+				require.Nil(t, mr)
+				return
+			}
+			require.Equal(t, test.expected, mr.String())
+		})
+	}
+}
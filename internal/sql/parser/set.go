@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/cockroachdb/errors"
+)
+
+// parseSetStatement parses a SET statement.
+// The value is parsed by the setting, registered with database.RegisterSetting.
+// The built-in settings are:
+//
+//	SET search_path { TO | = } { schema [, ...] | DEFAULT }
+//	SET compat_mode { TO | = } { 'sqlite' | 'postgres' | DEFAULT }
+//	SET strict_typing { TO | = } { ON | OFF | TRUE | FALSE | DEFAULT }
+//	SET skip_corrupt_rows { TO | = } { ON | OFF | TRUE | FALSE | DEFAULT }
+//	SET statement_timeout { TO | = } { 'interval' | milliseconds | DEFAULT }
+//	SET work_mem { TO | = } { 'size' | kilobytes | DEFAULT }
+//	SET parallel_workers { TO | = } { workers | DEFAULT }
+//	SET synchronous { TO | = } { OFF | NORMAL | FULL | 0 | 1 | 2 | DEFAULT }
+func (p *Parser) parseSetStatement() (statement.Statement, error) {
+	// Parse "SET".
+	if err := p.ParseTokens(scanner.SET); err != nil {
+		return nil, err
+	}
+
+	s, err := p.parseSettingName()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.TO && tok != scanner.EQ {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TO", "="}, pos)
+	}
+
+	if ok, err := p.parseOptional(scanner.DEFAULT); ok || err != nil {
+		return query.SetStmt{Name: s.Name, Default: true}, err
+	}
+
+	// parse a comma separated list of literals
+	var lits []database.SettingLiteral
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	first, firstPos := scanner.Tokstr(tok, lit), pos
+	for {
+		l, ok := settingLiteral(tok, lit)
+		if !ok {
+			return nil, newParseError(scanner.Tokstr(tok, lit), s.Expected, pos)
+		}
+		lits = append(lits, l)
+
+		if tok, _, _ = p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+	}
+
+	v, err := s.Parse(lits)
+	if errors.Is(err, database.ErrUnexpectedSettingValue) {
+		return nil, newParseError(first, s.Expected, firstPos)
+	}
+	if err != nil {
+		return nil, &ParseError{Message: err.Error(), Pos: firstPos}
+	}
+
+	return query.SetStmt{Name: s.Name, Value: v}, nil
+}
+
+// parseShowStatement parses a SHOW statement.
+//
+//	SHOW { setting | ALL }
+func (p *Parser) parseShowStatement() (statement.Statement, error) {
+	// Parse "SHOW".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "show") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SHOW"}, pos)
+	}
+
+	if ok, err := p.parseOptional(scanner.ALL); ok || err != nil {
+		return query.ShowStmt{All: true}, err
+	}
+
+	s, err := p.parseSettingName()
+	if err != nil {
+		return nil, err
+	}
+
+	return query.ShowStmt{Name: s.Name}, nil
+}
+
+// parseSettingName parses the name of a registered setting.
+func (p *Parser) parseSettingName() (*database.Setting, error) {
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	s := database.LookupSetting(name)
+	if s == nil {
+		return nil, &ParseError{Message: fmt.Sprintf("unknown setting %q", strings.ToLower(name)), Pos: pos}
+	}
+
+	return s, nil
+}
+
+// settingLiteral converts a token to a literal of the value of a setting.
+func settingLiteral(tok scanner.Token, lit string) (database.SettingLiteral, bool) {
+	switch tok {
+	case scanner.STRING:
+		return database.SettingLiteral{Kind: database.LiteralString, Text: lit}, true
+	case scanner.INTEGER:
+		return database.SettingLiteral{Kind: database.LiteralInteger, Text: lit}, true
+	case scanner.IDENT:
+		return database.SettingLiteral{Kind: database.LiteralIdent, Text: lit}, true
+	case scanner.ON, scanner.TRUE, scanner.FALSE:
+		return database.SettingLiteral{Kind: database.LiteralIdent, Text: strings.ToLower(tok.String())}, true
+	}
+
+	return database.SettingLiteral{}, false
+}
@@ -0,0 +1,114 @@
+package parser_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserSet(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"SET search_path TO app", query.SetStmt{Name: "search_path", Value: []string{"app"}}, false},
+		{"SET search_path = app, public", query.SetStmt{Name: "search_path", Value: []string{"app", "public"}}, false},
+		{"SET SEARCH_PATH TO DEFAULT", query.SetStmt{Name: "search_path", Default: true}, false},
+		{"SET search_path app", nil, true},
+		{"SET search_path TO", nil, true},
+		{"SET foo TO bar", nil, true},
+		{"SET compat_mode = 'sqlite'", query.SetStmt{Name: "compat_mode", Value: database.CompatSQLite}, false},
+		{"SET COMPAT_MODE TO postgres", query.SetStmt{Name: "compat_mode", Value: database.CompatPostgres}, false},
+		{"SET compat_mode TO 'Postgres'", query.SetStmt{Name: "compat_mode", Value: database.CompatPostgres}, false},
+		{"SET compat_mode TO DEFAULT", query.SetStmt{Name: "compat_mode", Default: true}, false},
+		{"SET compat_mode TO 'mysql'", nil, true},
+		{"SET compat_mode TO ''", nil, true},
+		{"SET compat_mode TO 1", nil, true},
+		{"SET strict_typing = on", query.SetStmt{Name: "strict_typing", Value: true}, false},
+		{"SET STRICT_TYPING TO true", query.SetStmt{Name: "strict_typing", Value: true}, false},
+		{"SET strict_typing TO OFF", query.SetStmt{Name: "strict_typing", Value: false}, false},
+		{"SET strict_typing = false", query.SetStmt{Name: "strict_typing", Value: false}, false},
+		{"SET strict_typing TO DEFAULT", query.SetStmt{Name: "strict_typing", Default: true}, false},
+		{"SET strict_typing TO 1", nil, true},
+		{"SET skip_corrupt_rows = on", query.SetStmt{Name: "skip_corrupt_rows", Value: true}, false},
+		{"SET skip_corrupt_rows TO off", query.SetStmt{Name: "skip_corrupt_rows", Value: false}, false},
+		{"SET skip_corrupt_rows TO DEFAULT", query.SetStmt{Name: "skip_corrupt_rows", Default: true}, false},
+		{"SET statement_timeout = '5s'", query.SetStmt{Name: "statement_timeout", Value: 5 * time.Second}, false},
+		{"SET statement_timeout TO '1 minute 30 seconds'", query.SetStmt{Name: "statement_timeout", Value: 90 * time.Second}, false},
+		{"SET statement_timeout = 250", query.SetStmt{Name: "statement_timeout", Value: 250 * time.Millisecond}, false},
+		{"SET statement_timeout = 0", query.SetStmt{Name: "statement_timeout", Value: time.Duration(0)}, false},
+		{"SET statement_timeout TO DEFAULT", query.SetStmt{Name: "statement_timeout", Default: true}, false},
+		{"SET statement_timeout = 'soon'", nil, true},
+		{"SET statement_timeout = '-5s'", nil, true},
+		{"SET statement_timeout = on", nil, true},
+		{"SET work_mem = '64MB'", query.SetStmt{Name: "work_mem", Value: 64 << 20}, false},
+		{"SET work_mem TO '512 kB'", query.SetStmt{Name: "work_mem", Value: 512 << 10}, false},
+		{"SET work_mem = '1gb'", query.SetStmt{Name: "work_mem", Value: 1 << 30}, false},
+		{"SET work_mem = '100B'", query.SetStmt{Name: "work_mem", Value: 100}, false},
+		{"SET work_mem = 4096", query.SetStmt{Name: "work_mem", Value: 4 << 20}, false},
+		{"SET work_mem TO DEFAULT", query.SetStmt{Name: "work_mem", Default: true}, false},
+		{"SET work_mem = 0", nil, true},
+		{"SET work_mem = '10TB'", nil, true},
+		{"SET work_mem = 'lots'", nil, true},
+		{"SET parallel_workers = 4", query.SetStmt{Name: "parallel_workers", Value: 4}, false},
+		{"SET parallel_workers TO 0", query.SetStmt{Name: "parallel_workers", Value: 0}, false},
+		{"SET parallel_workers = DEFAULT", query.SetStmt{Name: "parallel_workers", Default: true}, false},
+		{"SET parallel_workers = 5000", nil, true},
+		{"SET parallel_workers = '4'", nil, true},
+		{"SET synchronous = OFF", query.SetStmt{Name: "synchronous", Value: engine.SyncOff}, false},
+		{"SET synchronous TO normal", query.SetStmt{Name: "synchronous", Value: engine.SyncNormal}, false},
+		{"SET synchronous = 'full'", query.SetStmt{Name: "synchronous", Value: engine.SyncFull}, false},
+		{"SET synchronous = 1", query.SetStmt{Name: "synchronous", Value: engine.SyncNormal}, false},
+		{"SET synchronous = DEFAULT", query.SetStmt{Name: "synchronous", Default: true}, false},
+		{"SET synchronous = 3", nil, true},
+		{"SET synchronous = extra", nil, true},
+		{"SET skip_corrupt_rows = 'yes'", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserShow(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"SHOW work_mem", query.ShowStmt{Name: "work_mem"}, false},
+		{"show Statement_Timeout", query.ShowStmt{Name: "statement_timeout"}, false},
+		{"SHOW ALL", query.ShowStmt{All: true}, false},
+		{"SHOW foo", nil, true},
+		{"SHOW", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
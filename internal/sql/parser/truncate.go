@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// parseTruncateStatement parses a truncate statement.
+//
+//	TRUNCATE [TABLE] table_name [, ...] [RESTART IDENTITY | CONTINUE IDENTITY]
+func (p *Parser) parseTruncateStatement() (*statement.TruncateStmt, error) {
+	// Parse "TRUNCATE".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "TRUNCATE") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TRUNCATE"}, pos)
+	}
+
+	var stmt statement.TruncateStmt
+
+	// Parse optional "TABLE".
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.TABLE {
+		p.Unscan()
+	}
+
+	for {
+		name, err := p.parseQualifiedIdent()
+		if err != nil {
+			return nil, err
+		}
+		stmt.TableNames = append(stmt.TableNames, name)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	// Parse "RESTART IDENTITY" or "CONTINUE IDENTITY".
+	restart := p.parseOptionalIdent("RESTART")
+	if restart || p.parseOptionalIdent("CONTINUE") {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "IDENTITY") {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"IDENTITY"}, pos)
+		}
+		stmt.RestartIdentity = restart
+	}
+
+	return &stmt, nil
+}
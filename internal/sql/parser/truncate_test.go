@@ -0,0 +1,41 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Table", "TRUNCATE test", &statement.TruncateStmt{TableNames: []string{"test"}}, false},
+		{"With TABLE", "TRUNCATE TABLE test", &statement.TruncateStmt{TableNames: []string{"test"}}, false},
+		{"Multiple tables", "TRUNCATE a, b", &statement.TruncateStmt{TableNames: []string{"a", "b"}}, false},
+		{"Restart identity", "TRUNCATE TABLE test RESTART IDENTITY", &statement.TruncateStmt{TableNames: []string{"test"}, RestartIdentity: true}, false},
+		{"Continue identity", "truncate test continue identity", &statement.TruncateStmt{TableNames: []string{"test"}}, false},
+		{"No table", "TRUNCATE", nil, true},
+		{"No table after TABLE", "TRUNCATE TABLE", nil, true},
+		{"Missing IDENTITY", "TRUNCATE test RESTART", nil, true},
+		{"With extra", "TRUNCATE test test", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -16,8 +16,13 @@ func (p *Parser) parseUpdateStatement() (*statement.UpdateStmt, error) {
 		return nil, err
 	}
 
+	stmt.Hints, err = p.parseOptimizerHints()
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse table name
-	stmt.TableName, err = p.parseIdent()
+	stmt.TableName, err = p.parseQualifiedIdent()
 	if err != nil {
 		pErr := errors.Unwrap(err).(*ParseError)
 		pErr.Expected = []string{"table_name"}
@@ -36,12 +41,42 @@ func (p *Parser) parseUpdateStatement() (*statement.UpdateStmt, error) {
 		return nil, err
 	}
 
+	// Parse joined table: "FROM table_name".
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.FROM {
+		stmt.FromTable, err = p.parseQualifiedIdent()
+		if err != nil {
+			pErr := errors.Unwrap(err).(*ParseError)
+			pErr.Expected = []string{"table_name"}
+			return nil, pErr
+		}
+	} else {
+		p.Unscan()
+	}
+
 	// Parse condition: "WHERE EXPR".
 	stmt.WhereExpr, err = p.parseCondition()
 	if err != nil {
 		return nil, err
 	}
 
+	// Parse order by: "ORDER BY expr [ASC|DESC] [NULLS {FIRST|LAST}], ..."
+	stmt.OrderBy, err = p.parseOrderBy()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse limit: "LIMIT expr"
+	stmt.LimitExpr, err = p.parseLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse offset: "OFFSET expr"
+	stmt.OffsetExpr, err = p.parseOffset()
+	if err != nil {
+		return nil, err
+	}
+
 	return stmt, nil
 }
 
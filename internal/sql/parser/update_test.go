@@ -20,7 +20,7 @@ func TestParserUpdate(t *testing.T) {
 	db, tx, cleanup := testutil.NewTestTx(t)
 	defer cleanup()
 
-	testutil.MustExec(t, db, tx, "CREATE TABLE test(a INT, b TEXT)")
+	testutil.MustExec(t, db, tx, "CREATE TABLE test(a INT, b TEXT); CREATE TABLE other(c INT)")
 
 	parseExpr := func(s string, table ...string) expr.Expr {
 		e := parser.MustParseExpr(s)
@@ -57,6 +57,33 @@ func TestParserUpdate(t *testing.T) {
 				Pipe(stream.Discard()),
 			false,
 		},
+		{"With order by and limit", "UPDATE test SET a = 1 WHERE a > 10 ORDER BY b DESC LIMIT 10 OFFSET 20",
+			stream.New(table.Scan("test")).
+				Pipe(rows.Filter(parseExpr("a > 10"))).
+				Pipe(rows.TempTreeSortReverse(parseExpr("b"))).
+				Pipe(rows.Skip(parseExpr("20"))).
+				Pipe(rows.Take(parseExpr("10"))).
+				Pipe(path.Set("a", testutil.IntegerValue(1))).
+				Pipe(table.Validate("test")).
+				Pipe(table.Replace("test")).
+				Pipe(stream.Discard()),
+			false,
+		},
+		{"FROM", "UPDATE test SET a = c FROM other WHERE b = 'x' AND test.a < other.c",
+			stream.New(table.Scan("test")).
+				Pipe(rows.Filter(parseExpr("b = 'x'"))).
+				Pipe(stream.Join(
+					stream.New(table.Scan("other")),
+					expr.Lt(&expr.Column{Name: "a", Table: "test"}, &expr.Column{Name: "c", Table: "other"}),
+				)).
+				Pipe(path.Set("a", &expr.Column{Name: "c", Table: "other"})).
+				Pipe(table.Validate("test")).
+				Pipe(table.Replace("test")).
+				Pipe(stream.Discard()),
+			false,
+		},
+		{"FROM without table", "UPDATE test SET a = 1 FROM WHERE a = 10", nil, true},
+		{"Limit before where", "UPDATE test SET a = 1 LIMIT 10 WHERE a > 10", nil, true},
 		{"Trailing comma", "UPDATE test SET a = 1, WHERE a = 10", nil, true},
 		{"No SET", "UPDATE test WHERE a = 10", nil, true},
 		{"No pair", "UPDATE test SET WHERE a = 10", nil, true},
@@ -81,7 +108,7 @@ func TestParserUpdate(t *testing.T) {
 			require.NoError(t, err)
 
 			require.Len(t, q.Statements, 1)
-			require.EqualValues(t, &statement.PreparedStreamStmt{Stream: test.expected}, q.Statements[0].(*statement.PreparedStreamStmt))
+			require.EqualValues(t, &statement.PreparedStreamStmt{Stream: test.expected, Fingerprint: mustFingerprint(t, test.s)}, q.Statements[0].(*statement.PreparedStreamStmt))
 		})
 	}
 }
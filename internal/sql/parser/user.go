@@ -0,0 +1,246 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// parseCreateUserStatement parses a create user string and returns a Statement AST row.
+// This function assumes the CREATE USER tokens have already been consumed.
+//
+//	CREATE USER [IF NOT EXISTS] name [WITH] [PASSWORD { 'password' | NULL }] [SUPERUSER | NOSUPERUSER]
+func (p *Parser) parseCreateUserStatement() (*statement.CreateUserStmt, error) {
+	var stmt statement.CreateUserStmt
+	var err error
+
+	stmt.IfNotExists, err = p.parseOptional(scanner.IF, scanner.NOT, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Info.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	_, password, superuser, err := p.parseUserOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Password = password
+	if superuser != nil {
+		stmt.Info.Superuser = *superuser
+	}
+
+	return &stmt, nil
+}
+
+// parseAlterUserStatement parses an alter user string and returns a Statement AST row.
+// This function assumes the ALTER USER tokens have already been consumed.
+//
+//	ALTER USER name [WITH] [PASSWORD { 'password' | NULL }] [SUPERUSER | NOSUPERUSER]
+func (p *Parser) parseAlterUserStatement() (*statement.AlterUserStmt, error) {
+	var stmt statement.AlterUserStmt
+	var err error
+
+	stmt.UserName, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.SetPassword, stmt.Password, stmt.Superuser, err = p.parseUserOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
+// parseUserOptions parses the options of CREATE USER and ALTER USER, in any order.
+func (p *Parser) parseUserOptions() (setPassword bool, password *string, superuser *bool, err error) {
+	if _, err = p.parseOptional(scanner.WITH); err != nil {
+		return
+	}
+
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch {
+		case tok == scanner.IDENT && strings.EqualFold(lit, "PASSWORD") && !setPassword:
+			setPassword = true
+			tok, pos, lit = p.ScanIgnoreWhitespace()
+			switch tok {
+			case scanner.STRING:
+				password = &lit
+			case scanner.NULL:
+			default:
+				err = newParseError(scanner.Tokstr(tok, lit), []string{"password", "NULL"}, pos)
+				return
+			}
+		case tok == scanner.IDENT && strings.EqualFold(lit, "SUPERUSER") && superuser == nil:
+			v := true
+			superuser = &v
+		case tok == scanner.IDENT && strings.EqualFold(lit, "NOSUPERUSER") && superuser == nil:
+			v := false
+			superuser = &v
+		case tok == scanner.IDENT && (strings.EqualFold(lit, "PASSWORD") || strings.EqualFold(lit, "SUPERUSER") || strings.EqualFold(lit, "NOSUPERUSER")):
+			err = &ParseError{Message: "conflicting or redundant options", Pos: pos}
+			return
+		default:
+			p.Unscan()
+			return
+		}
+	}
+}
+
+// parseDropUserStatement parses a drop user string and returns a Statement AST row.
+// This function assumes the DROP USER tokens have already been consumed.
+//
+//	DROP USER [IF EXISTS] name
+func (p *Parser) parseDropUserStatement() (*statement.DropUserStmt, error) {
+	var stmt statement.DropUserStmt
+	var err error
+
+	stmt.IfExists, err = p.parseOptional(scanner.IF, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.UserName, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
+// parseGrantStatement parses a GRANT statement.
+//
+//	GRANT { ALL [PRIVILEGES] | privilege [, ...] } ON { [TABLE] table [, ...] | ALL TABLES } TO user [, ...]
+//
+// The privileges are SELECT, INSERT, UPDATE, DELETE and DDL.
+func (p *Parser) parseGrantStatement() (*statement.GrantStmt, error) {
+	// Parse "GRANT".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "GRANT") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"GRANT"}, pos)
+	}
+
+	var stmt statement.GrantStmt
+	var err error
+
+	stmt.Privileges, stmt.Tables, err = p.parsePrivilegesOn()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.ParseTokens(scanner.TO); err != nil {
+		return nil, err
+	}
+
+	stmt.Users, err = p.parseIdentList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
+// parseRevokeStatement parses a REVOKE statement.
+//
+//	REVOKE { ALL [PRIVILEGES] | privilege [, ...] } ON { [TABLE] table [, ...] | ALL TABLES } FROM user [, ...]
+func (p *Parser) parseRevokeStatement() (*statement.RevokeStmt, error) {
+	// Parse "REVOKE".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "REVOKE") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"REVOKE"}, pos)
+	}
+
+	var stmt statement.RevokeStmt
+	var err error
+
+	stmt.Privileges, stmt.Tables, err = p.parsePrivilegesOn()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.ParseTokens(scanner.FROM); err != nil {
+		return nil, err
+	}
+
+	stmt.Users, err = p.parseIdentList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
+// parsePrivilegesOn parses the privileges and the tables of GRANT and REVOKE.
+func (p *Parser) parsePrivilegesOn() (database.Privilege, []string, error) {
+	var privileges database.Privilege
+
+	if ok, err := p.parseOptional(scanner.ALL); err != nil {
+		return 0, nil, err
+	} else if ok {
+		privileges = database.AllPrivileges
+		p.parseOptionalIdent("PRIVILEGES")
+	} else {
+		for {
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			var name string
+			switch tok {
+			case scanner.SELECT, scanner.INSERT, scanner.UPDATE, scanner.DELETE:
+				name = tok.String()
+			case scanner.IDENT:
+				name = lit
+			}
+
+			priv, ok := database.ParsePrivilege(name)
+			if !ok {
+				return 0, nil, newParseError(scanner.Tokstr(tok, lit), []string{"ALL", "SELECT", "INSERT", "UPDATE", "DELETE", "DDL"}, pos)
+			}
+			privileges |= priv
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+	}
+
+	if err := p.ParseTokens(scanner.ON); err != nil {
+		return 0, nil, err
+	}
+
+	if ok, err := p.parseOptional(scanner.ALL); err != nil {
+		return 0, nil, err
+	} else if ok {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "TABLES") {
+			return 0, nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLES"}, pos)
+		}
+
+		return privileges, []string{database.AllTables}, nil
+	}
+
+	if _, err := p.parseOptional(scanner.TABLE); err != nil {
+		return 0, nil, err
+	}
+
+	var tables []string
+	for {
+		name, err := p.parseQualifiedIdent()
+		if err != nil {
+			return 0, nil, err
+		}
+		tables = append(tables, name)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	return privileges, tables, nil
+}
@@ -0,0 +1,84 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserUser(t *testing.T) {
+	password := "secret"
+	yes, no := true, false
+
+	tests := []struct {
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"CREATE USER alice", &statement.CreateUserStmt{Info: database.UserInfo{Name: "alice"}}, false},
+		{"CREATE USER IF NOT EXISTS alice WITH PASSWORD 'secret' SUPERUSER", &statement.CreateUserStmt{IfNotExists: true, Info: database.UserInfo{Name: "alice", Superuser: true}, Password: &password}, false},
+		{"CREATE USER alice SUPERUSER PASSWORD 'secret'", &statement.CreateUserStmt{Info: database.UserInfo{Name: "alice", Superuser: true}, Password: &password}, false},
+		{"CREATE USER alice PASSWORD 'a' PASSWORD 'b'", nil, true},
+		{"CREATE USER alice PASSWORD", nil, true},
+		{"CREATE USER", nil, true},
+		{"ALTER USER alice WITH PASSWORD NULL NOSUPERUSER", &statement.AlterUserStmt{UserName: "alice", SetPassword: true, Superuser: &no}, false},
+		{"ALTER USER alice SUPERUSER", &statement.AlterUserStmt{UserName: "alice", Superuser: &yes}, false},
+		{"DROP USER alice", &statement.DropUserStmt{UserName: "alice"}, false},
+		{"DROP USER IF EXISTS alice", &statement.DropUserStmt{UserName: "alice", IfExists: true}, false},
+		{"GRANT SELECT ON t TO alice", &statement.GrantStmt{Privileges: database.PrivilegeSelect, Tables: []string{"t"}, Users: []string{"alice"}}, false},
+		{"GRANT select, INSERT, ddl ON TABLE s.t, u TO alice, bob", &statement.GrantStmt{Privileges: database.PrivilegeSelect | database.PrivilegeInsert | database.PrivilegeDDL, Tables: []string{"s.t", "u"}, Users: []string{"alice", "bob"}}, false},
+		{"GRANT ALL PRIVILEGES ON ALL TABLES TO alice", &statement.GrantStmt{Privileges: database.AllPrivileges, Tables: []string{database.AllTables}, Users: []string{"alice"}}, false},
+		{"GRANT CREATE ON t TO alice", nil, true},
+		{"GRANT SELECT ON t", nil, true},
+		{"REVOKE UPDATE, DELETE ON t FROM alice", &statement.RevokeStmt{Privileges: database.PrivilegeUpdate | database.PrivilegeDelete, Tables: []string{"t"}, Users: []string{"alice"}}, false},
+		{"REVOKE ALL ON ALL TABLES FROM alice", &statement.RevokeStmt{Privileges: database.AllPrivileges, Tables: []string{database.AllTables}, Users: []string{"alice"}}, false},
+		{"REVOKE SELECT ON t TO alice", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserUserRoundTrip(t *testing.T) {
+	hash, err := database.HashPassword("secret")
+	require.NoError(t, err)
+
+	u := database.UserInfo{
+		Name:         "alice",
+		PasswordHash: hash,
+		Privileges: map[string]database.Privilege{
+			"t":                database.PrivilegeSelect | database.PrivilegeUpdate,
+			"s.u":              database.PrivilegeInsert,
+			database.AllTables: database.AllPrivileges,
+		},
+	}
+
+	q, err := parser.ParseQuery(u.String())
+	require.NoError(t, err)
+	require.Len(t, q.Statements, 4)
+
+	create := q.Statements[0].(*statement.CreateUserStmt)
+	require.Equal(t, "alice", create.Info.Name)
+	require.Equal(t, hash, *create.Password)
+
+	privileges := make(map[string]database.Privilege)
+	for _, stmt := range q.Statements[1:] {
+		g := stmt.(*statement.GrantStmt)
+		require.Equal(t, []string{"alice"}, g.Users)
+		privileges[g.Tables[0]] |= g.Privileges
+	}
+	require.Equal(t, u.Privileges, privileges)
+}
@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// parseVacuumStatement parses a vacuum statement.
+//
+//	VACUUM [table_name]
+func (p *Parser) parseVacuumStatement() (statement.Statement, error) {
+	var stmt statement.VacuumStmt
+
+	// Parse "VACUUM".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "VACUUM") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VACUUM"}, pos)
+	}
+
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	if tok == scanner.IDENT {
+		var err error
+		stmt.TableName, err = p.parseQualifiedIdent()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &stmt, nil
+}
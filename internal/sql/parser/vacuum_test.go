@@ -0,0 +1,37 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserVacuum(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"All", "VACUUM", &statement.VacuumStmt{}, false},
+		{"With table", "VACUUM test", &statement.VacuumStmt{TableName: "test"}, false},
+		{"Lowercase", "vacuum test", &statement.VacuumStmt{TableName: "test"}, false},
+		{"Table named vacuum", "VACUUM vacuum", &statement.VacuumStmt{TableName: "vacuum"}, false},
+		{"With extra", "VACUUM test test", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
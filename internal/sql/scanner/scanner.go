@@ -18,7 +18,7 @@ func init() {
 	for tok := keywordBeg + 1; tok < keywordEnd; tok++ {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
-	for _, tok := range []Token{AND, OR, TRUE, FALSE, NULL, IN, IS, LIKE, BETWEEN} {
+	for _, tok := range []Token{AND, OR, TRUE, FALSE, NULL, IN, IS, LIKE, REGEXP, BETWEEN} {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
 }
@@ -95,9 +95,15 @@ func (s *scanner) Scan() (tok Token, pos Pos, lit string) {
 	case '/':
 		ch1, _ := s.r.read()
 		if ch1 == '*' {
-			if err := s.skipUntilEndComment(); err != nil {
+			text, err := s.scanUntilEndComment()
+			if err != nil {
 				return ILLEGAL, pos, ""
 			}
+			// the text of the comments holding optimizer hints
+			// is returned, starting with the +
+			if strings.HasPrefix(text, "+") {
+				return COMMENT, pos, text
+			}
 			return COMMENT, pos, ""
 		}
 		s.r.unread()
@@ -115,6 +121,8 @@ func (s *scanner) Scan() (tok Token, pos Pos, lit string) {
 		return BITWISEOR, pos, ""
 	case '^':
 		return BITWISEXOR, pos, ""
+	case '~':
+		return EQREGEX, pos, ""
 	case '=':
 		ch1, _ := s.r.read()
 		if ch1 == '~' {
@@ -206,23 +214,31 @@ func (s *scanner) skipUntilNewline() {
 	}
 }
 
-// skipUntilEndComment skips characters until it reaches a '*/' symbol.
-func (s *scanner) skipUntilEndComment() error {
+// scanUntilEndComment reads characters until it reaches a '*/' symbol
+// and returns them, without the symbol.
+func (s *scanner) scanUntilEndComment() (string, error) {
+	var buf strings.Builder
+
 	for {
 		if ch1, _ := s.r.read(); ch1 == '*' {
 			// We might be at the end.
 		star:
 			ch2, _ := s.r.read()
 			if ch2 == '/' {
-				return nil
+				return buf.String(), nil
 			} else if ch2 == '*' {
 				// We are back in the state machine since we see a star.
+				buf.WriteRune(ch1)
 				goto star
 			} else if ch2 == eof {
-				return io.EOF
+				return "", io.EOF
 			}
+			buf.WriteRune(ch1)
+			buf.WriteRune(ch2)
 		} else if ch1 == eof {
-			return io.EOF
+			return "", io.EOF
+		} else {
+			buf.WriteRune(ch1)
 		}
 	}
 }
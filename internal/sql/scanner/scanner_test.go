@@ -52,6 +52,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `IN`, tok: IN},
 		{s: `IS`, tok: IS},
 		{s: `LIKE`, tok: LIKE},
+		{s: `REGEXP`, tok: REGEXP},
 		{s: `||`, tok: CONCAT},
 
 		// Misc tokens
@@ -65,11 +66,15 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `;`, tok: SEMICOLON},
 		{s: `.`, tok: DOT},
 		{s: `=~`, tok: EQREGEX},
+		{s: `~`, tok: EQREGEX},
 		{s: `!~`, tok: NEQREGEX},
 		{s: `:`, tok: COLON},
 		{s: `::`, tok: DOUBLECOLON},
 		{s: `--`, tok: COMMENT},
 		{s: `--10.3`, tok: COMMENT, lit: ``},
+		{s: `/* foo */`, tok: COMMENT, lit: ``},
+		{s: `/*+ INDEX(a b) */`, tok: COMMENT, lit: `+ INDEX(a b) `},
+		{s: `/*+ a**b */`, tok: COMMENT, lit: `+ a**b `},
 
 		// Identifiers
 		{s: `foo`, tok: IDENT, lit: `foo`},
@@ -327,3 +332,26 @@ func TestScanRegex(t *testing.T) {
 		}
 	}
 }
+
+func TestPosOffset(t *testing.T) {
+	tests := []struct {
+		s   string
+		pos Pos
+		off int
+	}{
+		{"SELECT a", Pos{Line: 0, Char: 0}, 0},
+		{"SELECT a", Pos{Line: 0, Char: 7}, 7},
+		{"SELECT a\nFROM b", Pos{Line: 1, Char: 5}, 14},
+		{"SELECT a\r\nFROM b", Pos{Line: 1, Char: 5}, 15},
+		{"SELECT 'é'\nFROM b", Pos{Line: 1, Char: 0}, 12},
+		{"SELECT a", Pos{Line: 3, Char: 0}, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if off := tt.pos.Offset(tt.s); off != tt.off {
+				t.Fatalf("expected offset %d, got %d", tt.off, off)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"strings"
+	"unicode/utf8"
 )
 
 // Token is a lexical token of the Chai SQL language.
@@ -47,7 +48,7 @@ const (
 
 	EQ       // =
 	NEQ      // !=
-	EQREGEX  // =~
+	EQREGEX  // ~
 	NEQREGEX // !~
 	LT       // <
 	LTE      // <=
@@ -59,6 +60,7 @@ const (
 	ISN      // IS NOT
 	LIKE     // LIKE
 	NLIKE    // NOT LIKE
+	REGEXP   // REGEXP
 	CONCAT   // ||
 	BETWEEN  // BETWEEN
 	operatorEnd
@@ -193,6 +195,7 @@ var tokens = [...]string{
 	BITWISEAND: "&",
 	BITWISEOR:  "|",
 	BITWISEXOR: "^",
+	CONCAT:     "||",
 	BETWEEN:    "BETWEEN",
 
 	AND: "AND",
@@ -200,7 +203,7 @@ var tokens = [...]string{
 
 	EQ:       "=",
 	NEQ:      "!=",
-	EQREGEX:  "=~",
+	EQREGEX:  "~",
 	NEQREGEX: "!~",
 	LT:       "<",
 	LTE:      "<=",
@@ -209,6 +212,7 @@ var tokens = [...]string{
 	IN:       "IN",
 	IS:       "IS",
 	LIKE:     "LIKE",
+	REGEXP:   "REGEXP",
 
 	LPAREN:      "(",
 	RPAREN:      ")",
@@ -330,7 +334,7 @@ func (tok Token) Precedence() int {
 		return 2
 	case NOT:
 		return 3
-	case EQ, NEQ, IS, ISN, IN, NIN, LIKE, NLIKE, EQREGEX, NEQREGEX, BETWEEN:
+	case EQ, NEQ, IS, ISN, IN, NIN, LIKE, NLIKE, EQREGEX, NEQREGEX, REGEXP, BETWEEN:
 		return 4
 	case LT, LTE, GT, GTE:
 		return 5
@@ -372,6 +376,35 @@ type Pos struct {
 	Char int
 }
 
+// Offset returns the byte offset of the position in s,
+// the text from which it was scanned, or len(s) if it is past its end.
+func (p Pos) Offset(s string) int {
+	var line, char int
+	for i := 0; i < len(s); {
+		if line == p.Line && char == p.Char {
+			return i
+		}
+
+		ch, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch ch {
+		case '\r':
+			// \r\n counts as a single newline
+			if i < len(s) && s[i] == '\n' {
+				i++
+			}
+			fallthrough
+		case '\n':
+			line++
+			char = 0
+		default:
+			char++
+		}
+	}
+
+	return len(s)
+}
+
 // AllKeywords returns all defined tokens corresponding to keywords.
 func AllKeywords() []Token {
 	tokens := make([]Token, 0, len(keywords))
@@ -0,0 +1,64 @@
+package index
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/cockroachdb/errors"
+)
+
+// BuildOperator reads the input stream and repopulates an index
+// with every row. The entries are sorted and written to the index
+// once the input stream is consumed.
+type BuildOperator struct {
+	stream.BaseOperator
+
+	IndexName string
+}
+
+func Build(indexName string) *BuildOperator {
+	return &BuildOperator{
+		IndexName: indexName,
+	}
+}
+
+func (op *BuildOperator) Clone() stream.Operator {
+	return &BuildOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		IndexName:    op.IndexName,
+	}
+}
+
+// Iterate implements the Operator interface.
+func (op *BuildOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	b, err := database.NewIndexBuilder(in.GetTx(), op.IndexName)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	err = op.Prev.Iterate(in, func(out *environment.Environment) error {
+		r, ok := out.GetDatabaseRow()
+		if !ok {
+			return errors.New("missing row")
+		}
+
+		err := b.Add(r)
+		if err != nil {
+			return err
+		}
+
+		return fn(out)
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.Finish()
+}
+
+func (op *BuildOperator) String() string {
+	return fmt.Sprintf("index.Build(%q)", op.IndexName)
+}
@@ -0,0 +1,70 @@
+package index
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// CheckOperator compares an index with the rows of its table
+// and emits a row for every mismatch.
+type CheckOperator struct {
+	stream.BaseOperator
+
+	IndexName string
+}
+
+// Check returns an operator that verifies the entries of an index.
+func Check(indexName string) *CheckOperator {
+	return &CheckOperator{
+		IndexName: indexName,
+	}
+}
+
+func (op *CheckOperator) Clone() stream.Operator {
+	return &CheckOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		IndexName:    op.IndexName,
+	}
+}
+
+// Iterate implements the Operator interface.
+func (op *CheckOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	tx := in.GetTx()
+
+	info, err := tx.Catalog.GetIndexInfo(op.IndexName)
+	if err != nil {
+		return err
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	var br database.BasicRow
+	cb := row.NewColumnBuffer()
+	return database.CheckIndex(tx, op.IndexName, func(m *database.IndexMismatch) error {
+		cb.Reset()
+		cb.Add("index_name", types.NewTextValue(op.IndexName))
+		cb.Add("table_name", types.NewTextValue(info.Owner.TableName))
+		cb.Add("problem", types.NewTextValue(m.Problem))
+		cb.Add("key", types.NewTextValue(m.Key.String()))
+		cb.Add("values", types.NewTextValue(tree.NewKey(m.Values...).String()))
+		br.ResetWith(info.Owner.TableName, nil, cb)
+		newEnv.SetRow(&br)
+
+		return fn(&newEnv)
+	})
+}
+
+func (op *CheckOperator) Columns(env *environment.Environment) ([]string, error) {
+	return []string{"index_name", "table_name", "problem", "key", "values"}, nil
+}
+
+func (op *CheckOperator) String() string {
+	return fmt.Sprintf("index.Check(%q)", op.IndexName)
+}
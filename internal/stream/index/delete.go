@@ -5,7 +5,6 @@ import (
 
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/stream"
-	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -13,26 +12,26 @@ import (
 type DeleteOperator struct {
 	stream.BaseOperator
 
-	indexName string
+	IndexName string
 }
 
 func Delete(indexName string) *DeleteOperator {
 	return &DeleteOperator{
-		indexName: indexName,
+		IndexName: indexName,
 	}
 }
 
 func (op *DeleteOperator) Clone() stream.Operator {
 	return &DeleteOperator{
 		BaseOperator: op.BaseOperator.Clone(),
-		indexName:    op.indexName,
+		IndexName:    op.IndexName,
 	}
 }
 
 func (op *DeleteOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
 	tx := in.GetTx()
 
-	info, err := tx.Catalog.GetIndexInfo(op.indexName)
+	info, err := tx.Catalog.GetIndexInfo(op.IndexName)
 	if err != nil {
 		return err
 	}
@@ -42,7 +41,12 @@ func (op *DeleteOperator) Iterate(in *environment.Environment, fn func(out *envi
 		return err
 	}
 
-	idx, err := tx.Catalog.GetIndex(tx, op.indexName)
+	err = table.Info.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	idx, err := tx.Catalog.GetIndex(tx, op.IndexName)
 	if err != nil {
 		return err
 	}
@@ -58,13 +62,9 @@ func (op *DeleteOperator) Iterate(in *environment.Environment, fn func(out *envi
 			return err
 		}
 
-		vs := make([]types.Value, 0, len(info.Columns))
-		for _, column := range info.Columns {
-			v, err := old.Get(column)
-			if err != nil {
-				v = types.NewNullValue()
-			}
-			vs = append(vs, v)
+		vs, err := table.Info.IndexValues(tx.Keyring(), info.Columns, old)
+		if err != nil {
+			return err
 		}
 
 		key, err := table.Info.EncodeKey(old.Key())
@@ -82,5 +82,5 @@ func (op *DeleteOperator) Iterate(in *environment.Environment, fn func(out *envi
 }
 
 func (op *DeleteOperator) String() string {
-	return fmt.Sprintf("index.Delete(%q)", op.indexName)
+	return fmt.Sprintf("index.Delete(%q)", op.IndexName)
 }
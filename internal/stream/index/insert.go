@@ -5,7 +5,6 @@ import (
 
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/stream"
-	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -13,31 +12,31 @@ import (
 type InsertOperator struct {
 	stream.BaseOperator
 
-	indexName string
+	IndexName string
 }
 
 func Insert(indexName string) *InsertOperator {
 	return &InsertOperator{
-		indexName: indexName,
+		IndexName: indexName,
 	}
 }
 
 func (op *InsertOperator) Clone() stream.Operator {
 	return &InsertOperator{
 		BaseOperator: op.BaseOperator.Clone(),
-		indexName:    op.indexName,
+		IndexName:    op.IndexName,
 	}
 }
 
 func (op *InsertOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
 	tx := in.GetTx()
 
-	idx, err := tx.Catalog.GetIndex(tx, op.indexName)
+	idx, err := tx.Catalog.GetIndex(tx, op.IndexName)
 	if err != nil {
 		return err
 	}
 
-	info, err := tx.Catalog.GetIndexInfo(op.indexName)
+	info, err := tx.Catalog.GetIndexInfo(op.IndexName)
 	if err != nil {
 		return err
 	}
@@ -53,13 +52,9 @@ func (op *InsertOperator) Iterate(in *environment.Environment, fn func(out *envi
 			return errors.New("missing row")
 		}
 
-		vs := make([]types.Value, 0, len(info.Columns))
-		for _, column := range info.Columns {
-			v, err := r.Get(column)
-			if err != nil {
-				v = types.NewNullValue()
-			}
-			vs = append(vs, v)
+		vs, err := tinfo.IndexValues(tx.Keyring(), info.Columns, r)
+		if err != nil {
+			return err
 		}
 
 		encKey, err := tinfo.EncodeKey(r.Key())
@@ -77,5 +72,5 @@ func (op *InsertOperator) Iterate(in *environment.Environment, fn func(out *envi
 }
 
 func (op *InsertOperator) String() string {
-	return fmt.Sprintf("index.Insert(%q)", op.indexName)
+	return fmt.Sprintf("index.Insert(%q)", op.IndexName)
 }
@@ -73,6 +73,17 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 
 	if len(it.Ranges) == 0 {
 		return index.IterateOnRange(nil, it.Reverse, func(key *tree.Key) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
+			if err := in.RowScanned(); err != nil {
+				return err
+			}
+
+			if skip, err := skipCorruptRow(tx, table, key); skip || err != nil {
+				return err
+			}
+
 			ptr.ResetWith(table, key)
 
 			return fn(&newEnv)
@@ -91,6 +102,17 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 		}
 
 		err = index.IterateOnRange(r, it.Reverse, func(key *tree.Key) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
+			if err := in.RowScanned(); err != nil {
+				return err
+			}
+
+			if skip, err := skipCorruptRow(tx, table, key); skip || err != nil {
+				return err
+			}
+
 			ptr.ResetWith(table, key)
 
 			return fn(&newEnv)
@@ -106,6 +128,21 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 	return nil
 }
 
+// skipCorruptRow returns true if the transaction ignores the rows
+// that cannot be decoded and the row of the key is one of them.
+func skipCorruptRow(tx *database.Transaction, table *database.Table, key *tree.Key) (bool, error) {
+	if !tx.SkipCorruptRows {
+		return false, nil
+	}
+
+	err := table.CheckRow(key)
+	if database.IsCorruptRowError(err) {
+		return true, nil
+	}
+
+	return false, err
+}
+
 func (it *ScanOperator) Columns(env *environment.Environment) ([]string, error) {
 	tx := env.GetTx()
 
@@ -14,26 +14,38 @@ import (
 type ValidateOperator struct {
 	stream.BaseOperator
 
-	indexName string
+	IndexName string
+	// if true, the unicity is checked when the transaction commits.
+	Deferred bool
 }
 
 func Validate(indexName string) *ValidateOperator {
 	return &ValidateOperator{
-		indexName: indexName,
+		IndexName: indexName,
+	}
+}
+
+// DeferredValidate returns an operator that records the values of every row
+// so that their unicity is checked when the transaction commits.
+func DeferredValidate(indexName string) *ValidateOperator {
+	return &ValidateOperator{
+		IndexName: indexName,
+		Deferred:  true,
 	}
 }
 
 func (op *ValidateOperator) Clone() stream.Operator {
 	return &ValidateOperator{
 		BaseOperator: op.BaseOperator.Clone(),
-		indexName:    op.indexName,
+		IndexName:    op.IndexName,
+		Deferred:     op.Deferred,
 	}
 }
 
 func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
 	tx := in.GetTx()
 
-	info, err := tx.Catalog.GetIndexInfo(op.indexName)
+	info, err := tx.Catalog.GetIndexInfo(op.IndexName)
 	if err != nil {
 		return err
 	}
@@ -42,7 +54,12 @@ func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *en
 		return errors.New("indexValidate can be used only on unique indexes")
 	}
 
-	idx, err := tx.Catalog.GetIndex(tx, op.indexName)
+	idx, err := tx.Catalog.GetIndex(tx, op.IndexName)
+	if err != nil {
+		return err
+	}
+
+	tinfo, err := tx.Catalog.GetTableInfo(info.Owner.TableName)
 	if err != nil {
 		return err
 	}
@@ -53,35 +70,38 @@ func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *en
 			return errors.New("missing row")
 		}
 
-		vs := make([]types.Value, 0, len(info.Columns))
+		vs, err := tinfo.IndexValues(tx.Keyring(), info.Columns, r)
+		if err != nil {
+			return err
+		}
 
 		// if the indexes values contain NULL somewhere,
 		// we don't check for unicity.
 		// cf: https://sqlite.org/lang_createindex.html#unique_indexes
 		var hasNull bool
-		for _, column := range info.Columns {
-			v, err := r.Get(column)
-			if err != nil {
-				hasNull = true
-				v = types.NewNullValue()
-			} else if v.Type() == types.TypeNull {
+		for _, v := range vs {
+			if v.Type() == types.TypeNull {
 				hasNull = true
 			}
-
-			vs = append(vs, v)
 		}
 
-		if !hasNull {
+		if !hasNull && op.Deferred {
+			err := tx.DeferUniqueCheck(op.IndexName, idx, vs)
+			if err != nil {
+				return err
+			}
+		} else if !hasNull {
+			err := tx.RecordUniqueCheck(idx, vs)
+			if err != nil {
+				return err
+			}
+
 			duplicate, key, err := idx.Exists(vs)
 			if err != nil {
 				return err
 			}
 			if duplicate {
-				return &database.ConstraintViolationError{
-					Constraint: "UNIQUE",
-					Columns:    info.Columns,
-					Key:        key,
-				}
+				return database.NewUniqueViolationError(tinfo, info, key)
 			}
 		}
 
@@ -90,5 +110,9 @@ func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *en
 }
 
 func (op *ValidateOperator) String() string {
-	return fmt.Sprintf("index.Validate(%q)", op.indexName)
+	if op.Deferred {
+		return fmt.Sprintf("index.DeferredValidate(%q)", op.IndexName)
+	}
+
+	return fmt.Sprintf("index.Validate(%q)", op.IndexName)
 }
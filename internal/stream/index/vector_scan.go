@@ -0,0 +1,172 @@
+package index
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// A VectorScanOperator returns the rows whose vectors are the
+// nearest to a vector, using a vector index.
+type VectorScanOperator struct {
+	stream.BaseOperator
+
+	// IndexName references the vector index used to perform the search.
+	IndexName string
+	// Vector is the searched vector.
+	Vector expr.Expr
+	// Limit is the maximum number of rows returned.
+	Limit expr.Expr
+}
+
+// VectorScan creates an iterator that returns the rows of the at most limit vectors
+// of the index nearest to v, sorted by distance.
+// As with a sort by distance, the rows whose vector is NULL come first,
+// and the rows are returned in the order of the table if v is NULL.
+// The search is approximate: depending on the options of the index,
+// some of the nearest vectors may be missing.
+func VectorScan(name string, v, limit expr.Expr) *VectorScanOperator {
+	return &VectorScanOperator{IndexName: name, Vector: v, Limit: limit}
+}
+
+func (op *VectorScanOperator) Clone() stream.Operator {
+	return &VectorScanOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		IndexName:    op.IndexName,
+		Vector:       expr.Clone(op.Vector),
+		Limit:        expr.Clone(op.Limit),
+	}
+}
+
+// Iterate over the rows of the nearest vectors, from the nearest to the farthest.
+func (op *VectorScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	tx := in.GetTx()
+
+	v, err := op.Vector.Eval(in)
+	if err != nil {
+		return err
+	}
+	l, err := op.Limit.Eval(in)
+	if err != nil {
+		return err
+	}
+	if !l.Type().IsNumber() {
+		return fmt.Errorf("limit expression must evaluate to a number, got %q", l.Type())
+	}
+	l, err = l.CastAs(types.TypeBigint)
+	if err != nil {
+		return err
+	}
+
+	index, err := tx.Catalog.GetIndex(tx, op.IndexName)
+	if err != nil {
+		return err
+	}
+
+	info, err := tx.Catalog.GetIndexInfo(op.IndexName)
+	if err != nil {
+		return err
+	}
+
+	table, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	// the distances to NULL are all NULL:
+	// the rows are returned in the order of the table, as when sorted
+	if v.Type() == types.TypeNull {
+		return iterateFirstRows(table, types.AsInt64(l), &newEnv, fn)
+	}
+
+	v, err = v.CastAs(types.TypeVector)
+	if err != nil {
+		return err
+	}
+
+	matches, err := index.SearchNearest(v.(types.VectorValue), types.AsInt64(l))
+	if err != nil {
+		return err
+	}
+
+	var ptr database.LazyRow
+
+	newEnv.SetRow(&ptr)
+
+	for _, m := range matches {
+		skip, err := skipCorruptRow(tx, table, m.Key)
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+
+		ptr.ResetWith(table, m.Key)
+
+		err = fn(&newEnv)
+		if errors.Is(err, stream.ErrStreamClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// iterateFirstRows calls fn with the first n rows of the table.
+func iterateFirstRows(table *database.Table, n int64, env *environment.Environment, fn func(out *environment.Environment) error) error {
+	var count int64
+	err := table.IterateOnRange(nil, false, func(_ *tree.Key, r database.Row) error {
+		if count >= n {
+			return errors.WithStack(stream.ErrStreamClosed)
+		}
+		count++
+
+		env.SetRow(r)
+		return fn(env)
+	})
+	if errors.Is(err, stream.ErrStreamClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func (op *VectorScanOperator) Columns(env *environment.Environment) ([]string, error) {
+	tx := env.GetTx()
+
+	idxInfo, err := tx.Catalog.GetIndexInfo(op.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := tx.Catalog.GetTableInfo(idxInfo.Owner.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(info.ColumnConstraints.Ordered))
+	for i, c := range info.ColumnConstraints.Ordered {
+		columns[i] = c.Column
+	}
+
+	return columns, nil
+}
+
+func (op *VectorScanOperator) String() string {
+	return fmt.Sprintf("index.VectorScan(%s, %s, %s)", strconv.Quote(op.IndexName), op.Vector, op.Limit)
+}
@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A JoinOperator joins each row of the stream with the first row
+// of another stream matching a condition.
+// Rows without a matching row are filtered out, and
+// rows with several matching rows are only returned once.
+// The columns of the joined row can be read by the next operators.
+type JoinOperator struct {
+	BaseOperator
+	// Stream returns the rows of the joined table.
+	Stream *Stream
+	Cond   expr.Expr
+}
+
+// Join returns a new JoinOperator.
+func Join(s *Stream, cond expr.Expr) *JoinOperator {
+	return &JoinOperator{Stream: s, Cond: cond}
+}
+
+func (op *JoinOperator) Clone() Operator {
+	return &JoinOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		Stream:       op.Stream.Clone(),
+		Cond:         expr.Clone(op.Cond),
+	}
+}
+
+// Iterate implements the Operator interface.
+// The joined stream is read once for each row, until a row matches.
+func (op *JoinOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	var newEnv environment.Environment
+
+	return op.Prev.Iterate(in, func(out *environment.Environment) error {
+		newEnv.SetOuter(out)
+
+		var fnErr error
+		err := op.Stream.Iterate(in, func(joined *environment.Environment) error {
+			r, ok := joined.GetDatabaseRow()
+			if !ok {
+				return errors.New("missing row")
+			}
+			newEnv.Joined = r
+
+			if op.Cond != nil {
+				v, err := op.Cond.Eval(&newEnv)
+				if err != nil {
+					return err
+				}
+
+				ok, err := types.IsTruthy(v)
+				if err != nil || !ok {
+					return err
+				}
+			}
+
+			// the joined row is only valid during the iteration
+			// of the joined stream: the row is returned right away,
+			// then the joined stream is closed.
+			fnErr = fn(&newEnv)
+			return ErrStreamClosed
+		})
+		newEnv.Joined = nil
+		if err != nil && !errors.Is(err, ErrStreamClosed) {
+			return err
+		}
+
+		return fnErr
+	})
+}
+
+func (op *JoinOperator) String() string {
+	if op.Cond == nil {
+		return fmt.Sprintf("stream.Join(%s)", op.Stream)
+	}
+
+	return fmt.Sprintf("stream.Join(%s, %s)", op.Stream, op.Cond)
+}
@@ -46,9 +46,21 @@ func (it *EmitOperator) Columns(env *environment.Environment) ([]string, error)
 }
 
 func (op *EmitOperator) Clone() stream.Operator {
+	// the rows are cloned because the planner replaces their parameters
+	// with the values of each execution
+	rows := make([]expr.Row, len(op.Rows))
+	for i, r := range op.Rows {
+		rows[i].Columns = r.Columns
+		rows[i].Exprs = make([]expr.Expr, len(r.Exprs))
+		for j, e := range r.Exprs {
+			rows[i].Exprs[j] = expr.Clone(e)
+		}
+	}
+
 	return &EmitOperator{
 		BaseOperator: op.BaseOperator.Clone(),
-		Rows:         op.Rows,
+		Rows:         rows,
+		columns:      op.columns,
 	}
 }
 
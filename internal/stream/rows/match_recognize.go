@@ -0,0 +1,426 @@
+package rows
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Columns added to the rows returned by the MatchRecognize operator.
+const (
+	MatchNumberColumn = "match_number"
+	ClassifierColumn  = "classifier"
+)
+
+// PatternElement is a symbol of a row pattern, with the number of
+// consecutive rows it must match.
+type PatternElement struct {
+	Symbol string
+	Min    int
+	// Max is the maximum number of rows, or -1 if there is no maximum.
+	Max int
+}
+
+func (e PatternElement) String() string {
+	switch {
+	case e.Min == 1 && e.Max == 1:
+		return e.Symbol
+	case e.Min == 1 && e.Max < 0:
+		return e.Symbol + "+"
+	case e.Min == 0 && e.Max < 0:
+		return e.Symbol + "*"
+	case e.Min == 0 && e.Max == 1:
+		return e.Symbol + "?"
+	}
+
+	return e.Symbol + "{" + strconv.Itoa(e.Min) + "," + strconv.Itoa(e.Max) + "}"
+}
+
+// PatternDefinition is the condition a row must satisfy
+// to be matched by a symbol.
+type PatternDefinition struct {
+	Symbol string
+	Expr   expr.Expr
+}
+
+// A MatchRecognizeOperator finds the sequences of rows matching a pattern.
+type MatchRecognizeOperator struct {
+	stream.BaseOperator
+	PartitionBy expr.Expr
+	OrderBy     expr.Expr
+	Desc        bool
+	Pattern     []PatternElement
+	Define      []PatternDefinition
+}
+
+// MatchRecognize consumes the incoming stream, sorts its rows by partition and order,
+// and outputs the rows of each partition that match the pattern, in order.
+// A row is matched by a symbol if the definition of the symbol evaluates to true,
+// symbols without definition match any row.
+// Quantifiers are greedy, and the search for the next match starts after the last
+// row of the previous one. The output rows have two additional columns: the number
+// of the match in its partition, starting at 1, and the symbol that matched the row.
+// The rows are sorted in a temporary tree, and the rows of a partition are kept in memory
+// while it is matched.
+func MatchRecognize(partitionBy, orderBy expr.Expr, desc bool, pattern []PatternElement, define []PatternDefinition) *MatchRecognizeOperator {
+	return &MatchRecognizeOperator{
+		PartitionBy: partitionBy,
+		OrderBy:     orderBy,
+		Desc:        desc,
+		Pattern:     pattern,
+		Define:      define,
+	}
+}
+
+func (op *MatchRecognizeOperator) Clone() stream.Operator {
+	define := make([]PatternDefinition, len(op.Define))
+	for i, d := range op.Define {
+		define[i] = PatternDefinition{Symbol: d.Symbol, Expr: expr.Clone(d.Expr)}
+	}
+
+	return &MatchRecognizeOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		PartitionBy:  expr.Clone(op.PartitionBy),
+		OrderBy:      expr.Clone(op.OrderBy),
+		Desc:         op.Desc,
+		Pattern:      append([]PatternElement(nil), op.Pattern...),
+		Define:       define,
+	}
+}
+
+// a partitionRow is a row of the partition being matched.
+type partitionRow struct {
+	tableName string
+	key       *tree.Key
+	r         row.Row
+}
+
+func (op *MatchRecognizeOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	tx := in.GetTx()
+	tr, cleanup, err := tree.NewTransient(tx.NewTransientSession(), tx.Catalog.GetFreeTransientNamespace(), 0)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var counter int64
+
+	var buf []byte
+	err = op.Prev.Iterate(in, func(out *environment.Environment) error {
+		buf = buf[:0]
+
+		partition, err := evalOrNull(op.PartitionBy, out)
+		if err != nil {
+			return err
+		}
+
+		order, err := evalOrNull(op.OrderBy, out)
+		if err != nil {
+			return err
+		}
+
+		r, ok := out.GetDatabaseRow()
+		if !ok {
+			return errors.New("missing row")
+		}
+
+		buf, err = encodeTempRow(buf, r)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode row")
+		}
+
+		var encKey []byte
+		key := r.Key()
+		if key != nil {
+			info, err := tx.Catalog.GetTableInfo(r.TableName())
+			if err != nil {
+				return err
+			}
+			encKey, err = info.EncodeKey(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		tk := tree.NewKey(partition, order, types.NewTextValue(r.TableName()), types.NewBlobValue(encKey), types.NewBigintValue(counter))
+
+		counter++
+
+		return tr.Put(tk, buf)
+	})
+	if err != nil {
+		return err
+	}
+
+	m := newPatternMatcher(op, in, fn)
+
+	var lastPartition types.Value
+	var rows []partitionRow
+	err = tr.IterateOnRange(nil, false, func(k *tree.Key, data []byte) error {
+		// the key and the data are only valid during the call,
+		// copy them to keep the rows of the partition.
+		k = tree.NewEncodedKey(bytes.Clone(k.Encoded))
+		data = bytes.Clone(data)
+
+		kv, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		if lastPartition != nil {
+			same, err := samePartition(lastPartition, kv[0])
+			if err != nil {
+				return err
+			}
+			if !same {
+				err = m.matchPartition(rows)
+				if err != nil {
+					return err
+				}
+				rows = rows[:0]
+			}
+		}
+		lastPartition = kv[0]
+
+		var pr partitionRow
+		if tf := kv[2]; tf.Type() != types.TypeNull {
+			pr.tableName = types.AsString(tf)
+		}
+		if kf := kv[3]; kf.Type() != types.TypeNull {
+			pr.key = tree.NewEncodedKey(types.AsByteSlice(kf))
+		}
+		pr.r = decodeTempRow(data)
+
+		rows = append(rows, pr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return m.matchPartition(rows)
+}
+
+// evalOrNull evaluates e, or returns NULL if e is nil
+// or refers to a column that doesn't exist.
+func evalOrNull(e expr.Expr, env *environment.Environment) (types.Value, error) {
+	if e == nil {
+		return types.NewNullValue(), nil
+	}
+
+	v, err := e.Eval(env)
+	if errors.Is(err, types.ErrColumnNotFound) {
+		return types.NewNullValue(), nil
+	}
+
+	return v, err
+}
+
+func samePartition(a, b types.Value) (bool, error) {
+	if a.Type() == types.TypeNull || b.Type() == types.TypeNull {
+		return a.Type() == b.Type(), nil
+	}
+
+	return a.EQ(b)
+}
+
+// a patternMatcher finds the matches of the pattern in the partitions
+// and outputs their rows.
+type patternMatcher struct {
+	op *MatchRecognizeOperator
+	fn func(out *environment.Environment) error
+
+	// index of the definition of each element of the pattern,
+	// or -1 if the symbol of the element is not defined.
+	definitions []int
+	// whether each row of the partition satisfies each definition.
+	matches [][]bool
+
+	env environment.Environment
+	br  database.BasicRow
+	cb  *row.ColumnBuffer
+}
+
+func newPatternMatcher(op *MatchRecognizeOperator, in *environment.Environment, fn func(out *environment.Environment) error) *patternMatcher {
+	m := patternMatcher{
+		op:          op,
+		fn:          fn,
+		definitions: make([]int, len(op.Pattern)),
+		cb:          row.NewColumnBuffer(),
+	}
+	m.env.SetOuter(in)
+
+	for i, el := range op.Pattern {
+		m.definitions[i] = -1
+		for j, d := range op.Define {
+			if d.Symbol == el.Symbol {
+				m.definitions[i] = j
+				break
+			}
+		}
+	}
+
+	return &m
+}
+
+func (m *patternMatcher) matchPartition(rows []partitionRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if m.op.Desc {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	// evaluate the definitions once per row
+	m.matches = m.matches[:0]
+	for _, pr := range rows {
+		m.br.ResetWith(pr.tableName, pr.key, pr.r)
+		m.env.SetRow(&m.br)
+
+		ok := make([]bool, len(m.op.Define))
+		for i, d := range m.op.Define {
+			v, err := d.Expr.Eval(&m.env)
+			if err != nil {
+				if !errors.Is(err, types.ErrColumnNotFound) {
+					return err
+				}
+				continue
+			}
+
+			ok[i], err = types.IsTruthy(v)
+			if err != nil {
+				return err
+			}
+		}
+		m.matches = append(m.matches, ok)
+	}
+
+	var matchNumber int64
+	var elements []int
+	for start := 0; start < len(rows); {
+		var ok bool
+		elements, ok = m.match(0, start, elements[:0])
+		if !ok || len(elements) == 0 {
+			start++
+			continue
+		}
+
+		matchNumber++
+		for i, el := range elements {
+			err := m.emit(rows[start+i], matchNumber, m.op.Pattern[el].Symbol)
+			if err != nil {
+				return err
+			}
+		}
+
+		start += len(elements)
+	}
+
+	return nil
+}
+
+// match returns the index of the pattern element matching each row of the longest
+// match of the elements of the pattern starting at pi, from the row ri.
+func (m *patternMatcher) match(pi, ri int, elements []int) ([]int, bool) {
+	if pi == len(m.op.Pattern) {
+		return elements, true
+	}
+
+	el := m.op.Pattern[pi]
+
+	// count the rows that can be matched by the element
+	n := 0
+	for ri+n < len(m.matches) && (el.Max < 0 || n < el.Max) && m.matchesElement(ri+n, pi) {
+		n++
+	}
+
+	// try the longest sequence first
+	for k := n; k >= el.Min; k-- {
+		els := elements
+		for i := 0; i < k; i++ {
+			els = append(els, pi)
+		}
+
+		if res, ok := m.match(pi+1, ri+k, els); ok {
+			return res, true
+		}
+	}
+
+	return nil, false
+}
+
+func (m *patternMatcher) matchesElement(ri, pi int) bool {
+	d := m.definitions[pi]
+	if d < 0 {
+		return true
+	}
+
+	return m.matches[ri][d]
+}
+
+func (m *patternMatcher) emit(pr partitionRow, matchNumber int64, symbol string) error {
+	m.cb.Reset()
+	err := m.cb.Copy(pr.r)
+	if err != nil {
+		return err
+	}
+	m.cb.Add(MatchNumberColumn, types.NewBigintValue(matchNumber))
+	m.cb.Add(ClassifierColumn, types.NewTextValue(symbol))
+
+	m.br.ResetWith(pr.tableName, pr.key, m.cb)
+	m.env.SetRow(&m.br)
+
+	return m.fn(&m.env)
+}
+
+func (op *MatchRecognizeOperator) Columns(env *environment.Environment) ([]string, error) {
+	columns, err := op.Prev.Columns(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(columns, MatchNumberColumn, ClassifierColumn), nil
+}
+
+// Clauses returns the clauses of the operator in their SQL form.
+func (op *MatchRecognizeOperator) Clauses() []string {
+	var clauses []string
+
+	if op.PartitionBy != nil {
+		clauses = append(clauses, "PARTITION BY "+op.PartitionBy.String())
+	}
+
+	orderBy := "ORDER BY " + op.OrderBy.String()
+	if op.Desc {
+		orderBy += " DESC"
+	}
+	clauses = append(clauses, orderBy)
+
+	pattern := make([]string, len(op.Pattern))
+	for i, el := range op.Pattern {
+		pattern[i] = el.String()
+	}
+	clauses = append(clauses, "PATTERN ("+strings.Join(pattern, " ")+")")
+
+	for _, d := range op.Define {
+		clauses = append(clauses, "DEFINE "+d.Symbol+" AS "+d.Expr.String())
+	}
+
+	return clauses
+}
+
+func (op *MatchRecognizeOperator) String() string {
+	return "rows.MatchRecognize(" + strings.Join(op.Clauses(), ", ") + ")"
+}
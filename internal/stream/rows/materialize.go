@@ -0,0 +1,107 @@
+package rows
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A MaterializeOperator consumes every row of the stream before
+// outputting them in the same order.
+// It is used when a statement writes to a table it reads from,
+// to avoid reading the rows it wrote.
+type MaterializeOperator struct {
+	stream.BaseOperator
+}
+
+// Materialize consumes every row of the stream and stores them in a temporary tree
+// before outputting them.
+func Materialize() *MaterializeOperator {
+	return &MaterializeOperator{}
+}
+
+func (op *MaterializeOperator) Clone() stream.Operator {
+	return &MaterializeOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+	}
+}
+
+func (op *MaterializeOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	tx := in.GetTx()
+	catalog := tx.Catalog
+
+	// the rows are kept in memory until they exceed the work_mem
+	// of the transaction, then spilled to disk.
+	tr, cleanup, err := tree.NewTransient(tx.NewTransientSession(), catalog.GetFreeTransientNamespace(), 0)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var counter int64
+
+	var buf []byte
+	err = op.Prev.Iterate(in, func(out *environment.Environment) error {
+		r, ok := out.GetDatabaseRow()
+		if !ok {
+			return errors.New("missing row")
+		}
+
+		var encKey []byte
+		key := r.Key()
+		if key != nil {
+			info, err := catalog.GetTableInfo(r.TableName())
+			if err != nil {
+				return err
+			}
+			encKey, err = info.EncodeKey(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the table name and the key of the row precede its columns
+		buf, err = types.EncodeValuesAsKey(buf[:0], types.NewTextValue(r.TableName()), types.NewBlobValue(encKey))
+		if err != nil {
+			return err
+		}
+
+		buf, err = encodeTempRow(buf, r)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode row")
+		}
+
+		counter++
+		return tr.Put(tree.NewKey(types.NewBigintValue(counter)), buf)
+	})
+	if err != nil {
+		return err
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+	var br database.BasicRow
+	return tr.IterateOnRange(nil, false, func(_ *tree.Key, data []byte) error {
+		tf, n := types.DecodeValue(data)
+		data = data[n:]
+		kf, n := types.DecodeValue(data)
+		data = data[n:]
+
+		var key *tree.Key
+		if b := types.AsByteSlice(kf); len(b) > 0 {
+			key = tree.NewEncodedKey(b)
+		}
+
+		br.ResetWith(types.AsString(tf), key, decodeTempRow(data))
+		newEnv.SetRow(&br)
+
+		return fn(&newEnv)
+	})
+}
+
+func (op *MaterializeOperator) String() string {
+	return "rows.Materialize()"
+}
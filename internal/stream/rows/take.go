@@ -45,14 +45,31 @@ func (op *TakeOperator) Iterate(in *environment.Environment, f func(out *environ
 	}
 
 	n := types.AsInt64(v)
+	if n <= 0 {
+		return nil
+	}
+
+	// the stream is closed as soon as the last value is returned,
+	// so that the previous operators don't read one more value.
+	// some operators, like the concatenation of streams, may still
+	// call the function afterwards.
 	var count int64
 	return op.Prev.Iterate(in, func(out *environment.Environment) error {
-		if count < n {
-			count++
-			return f(out)
+		if count >= n {
+			return errors.WithStack(stream.ErrStreamClosed)
+		}
+
+		err := f(out)
+		if err != nil {
+			return err
+		}
+
+		count++
+		if count == n {
+			return errors.WithStack(stream.ErrStreamClosed)
 		}
 
-		return errors.WithStack(stream.ErrStreamClosed)
+		return nil
 	})
 }
 
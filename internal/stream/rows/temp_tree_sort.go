@@ -2,6 +2,7 @@ package rows
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
@@ -13,38 +14,169 @@ import (
 	"github.com/cockroachdb/errors"
 )
 
+// NullsOrder is the position of the NULL values in a sort.
+type NullsOrder uint8
+
+const (
+	// NullsDefault sorts the NULL values as the smallest values:
+	// first in ascending order and last in descending order.
+	NullsDefault NullsOrder = iota
+	// NullsFirst sorts the NULL values before the other values.
+	NullsFirst
+	// NullsLast sorts the NULL values after the other values.
+	NullsLast
+)
+
+// A SortKey is an expression used to sort the rows, with its direction.
+type SortKey struct {
+	Expr  expr.Expr
+	Desc  bool
+	Nulls NullsOrder
+}
+
+// NullsFirst returns whether the NULL values are sorted before the other values.
+func (k SortKey) NullsFirst() bool {
+	switch k.Nulls {
+	case NullsFirst:
+		return true
+	case NullsLast:
+		return false
+	}
+
+	return !k.Desc
+}
+
+func (k SortKey) String() string {
+	var sb strings.Builder
+	sb.WriteString(k.Expr.String())
+	if k.Desc {
+		sb.WriteString(" DESC")
+	}
+	switch k.Nulls {
+	case NullsFirst:
+		sb.WriteString(" NULLS FIRST")
+	case NullsLast:
+		sb.WriteString(" NULLS LAST")
+	}
+
+	return sb.String()
+}
+
 // A TempTreeSortOperator consumes every value of the stream and outputs them in order.
 type TempTreeSortOperator struct {
 	stream.BaseOperator
-	Expr expr.Expr
-	Desc bool
+	// Keys used to sort the rows, rows with equal values
+	// for the first key are sorted by the second one, and so on.
+	Keys []SortKey
 }
 
 // TempTreeSort consumes every value of the stream, sorts them by the given expr and outputs them in order.
 // It creates a temporary index and uses it to sort the stream.
 func TempTreeSort(e expr.Expr) *TempTreeSortOperator {
-	return &TempTreeSortOperator{Expr: e}
+	return TempTreeSortBy(SortKey{Expr: e})
 }
 
 // TempTreeSortReverse does the same as TempTreeSort but in descending order.
 func TempTreeSortReverse(e expr.Expr) *TempTreeSortOperator {
-	return &TempTreeSortOperator{Expr: e, Desc: true}
+	return TempTreeSortBy(SortKey{Expr: e, Desc: true})
+}
+
+// TempTreeSortBy does the same as TempTreeSort but sorts the rows by multiple keys.
+func TempTreeSortBy(keys ...SortKey) *TempTreeSortOperator {
+	return &TempTreeSortOperator{Keys: keys}
+}
+
+// IsDesc returns whether every key is sorted in descending order.
+func (op *TempTreeSortOperator) IsDesc() bool {
+	for _, k := range op.Keys {
+		if !k.Desc {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (op *TempTreeSortOperator) Clone() stream.Operator {
+	keys := make([]SortKey, len(op.Keys))
+	for i, k := range op.Keys {
+		keys[i] = k
+		keys[i].Expr = expr.Clone(k.Expr)
+	}
+
 	return &TempTreeSortOperator{
 		BaseOperator: op.BaseOperator.Clone(),
-		Expr:         expr.Clone(op.Expr),
-		Desc:         op.Desc,
+		Keys:         keys,
+	}
+}
+
+// sortOrder returns the order of the columns of the temporary tree and whether
+// it must be read in reverse.
+// Keys with a non default order of NULL values are preceded by a column
+// indicating whether the value is NULL.
+// If every key is descending, the tree is read in reverse, which also
+// returns the rows with equal values in reverse order.
+func (op *TempTreeSortOperator) sortOrder() (tree.SortOrder, bool) {
+	reverse := op.IsDesc()
+
+	var order tree.SortOrder
+	var i int
+	for _, k := range op.Keys {
+		n := 1
+		if k.NullsFirst() == k.Desc {
+			n = 2
+		}
+
+		for ; n > 0; n-- {
+			if k.Desc != reverse {
+				order = order.SetDesc(i)
+			}
+			i++
+		}
+	}
+
+	return order, reverse
+}
+
+// eval evaluates the expression of a key.
+func (op *TempTreeSortOperator) eval(e expr.Expr, env *environment.Environment) (types.Value, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		if !errors.Is(err, types.ErrColumnNotFound) {
+			return nil, err
+		}
+
+		v = nil
+	}
+
+	if v == nil {
+		// the expression might be pointing to the original row.
+		v, err = e.Eval(env.GetOuter())
+		if err != nil {
+			// the only valid error here is a missing column.
+			if !errors.Is(err, types.ErrColumnNotFound) {
+				return nil, err
+			}
+		}
 	}
+
+	if v == nil {
+		return types.NewNullValue(), nil
+	}
+
+	// texts are sorted by their collation key
+	return expr.CollationOf(e).Key(v), nil
 }
 
 func (op *TempTreeSortOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
-	db := in.GetDB()
+	tx := in.GetTx()
+	catalog := tx.Catalog
+
+	order, reverse := op.sortOrder()
 
-	catalog := in.GetTx().Catalog
-	tns := catalog.GetFreeTransientNamespace()
-	tr, cleanup, err := tree.NewTransient(db.Engine.NewTransientSession(), tns, 0)
+	// the rows are kept in memory until they exceed the work_mem
+	// of the transaction, then spilled to disk.
+	tr, cleanup, err := tree.NewTransient(tx.NewTransientSession(), catalog.GetFreeTransientNamespace(), order)
 	if err != nil {
 		return err
 	}
@@ -53,28 +185,22 @@ func (op *TempTreeSortOperator) Iterate(in *environment.Environment, fn func(out
 	var counter int64
 
 	var buf []byte
+	var values []types.Value
 	err = op.Prev.Iterate(in, func(out *environment.Environment) error {
 		buf = buf[:0]
+		values = values[:0]
 
-		// evaluate the sort expression
-		v, err := op.Expr.Eval(out)
-		if err != nil {
-			if !errors.Is(err, types.ErrColumnNotFound) {
+		// evaluate the sort keys
+		for _, k := range op.Keys {
+			v, err := op.eval(k.Expr, out)
+			if err != nil {
 				return err
 			}
 
-			v = nil
-		}
-
-		if v == nil {
-			// the expression might be pointing to the original row.
-			v, err = op.Expr.Eval(out.GetOuter())
-			if err != nil {
-				// the only valid error here is a missing column.
-				if !errors.Is(err, types.ErrColumnNotFound) {
-					return err
-				}
+			if k.NullsFirst() == k.Desc {
+				values = append(values, types.NewBooleanValue(v.Type() == types.TypeNull))
 			}
+			values = append(values, v)
 		}
 
 		r, ok := out.GetDatabaseRow()
@@ -100,7 +226,8 @@ func (op *TempTreeSortOperator) Iterate(in *environment.Environment, fn func(out
 			}
 		}
 
-		tk := tree.NewKey(v, types.NewTextValue(r.TableName()), types.NewBlobValue(encKey), types.NewBigintValue(counter))
+		values = append(values, types.NewTextValue(r.TableName()), types.NewBlobValue(encKey), types.NewBigintValue(counter))
+		tk := tree.NewKey(values...)
 
 		counter++
 
@@ -113,20 +240,22 @@ func (op *TempTreeSortOperator) Iterate(in *environment.Environment, fn func(out
 	var newEnv environment.Environment
 	newEnv.SetOuter(in)
 	var br database.BasicRow
-	return tr.IterateOnRange(nil, op.Desc, func(k *tree.Key, data []byte) error {
+	return tr.IterateOnRange(nil, reverse, func(k *tree.Key, data []byte) error {
 		kv, err := k.Decode()
 		if err != nil {
 			return err
 		}
+		// the table name and the key of the row follow the sort keys
+		kv = kv[len(kv)-3:]
 
 		var tableName string
-		tf := kv[1]
+		tf := kv[0]
 		if tf.Type() != types.TypeNull {
 			tableName = types.AsString(tf)
 		}
 
 		var key *tree.Key
-		kf := kv[2]
+		kf := kv[1]
 		if kf.Type() != types.TypeNull {
 			key = tree.NewEncodedKey(types.AsByteSlice(kf))
 		}
@@ -142,11 +271,22 @@ func (op *TempTreeSortOperator) Iterate(in *environment.Environment, fn func(out
 }
 
 func (op *TempTreeSortOperator) String() string {
-	if op.Desc {
-		return fmt.Sprintf("rows.TempTreeSortReverse(%s)", op.Expr)
+	name := "rows.TempTreeSort"
+	reverse := op.IsDesc()
+	if reverse {
+		name = "rows.TempTreeSortReverse"
+	}
+
+	keys := make([]string, len(op.Keys))
+	for i, k := range op.Keys {
+		// the direction of the keys is given by the name of the operator
+		if reverse {
+			k.Desc = false
+		}
+		keys[i] = k.String()
 	}
 
-	return fmt.Sprintf("rows.TempTreeSort(%s)", op.Expr)
+	return fmt.Sprintf("%s(%s)", name, strings.Join(keys, ", "))
 }
 
 func encodeTempRow(buf []byte, r row.Row) ([]byte, error) {
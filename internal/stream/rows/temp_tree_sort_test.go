@@ -103,7 +103,46 @@ func TestTempTreeSort(t *testing.T) {
 		})
 	}
 
+	t.Run("NULLS LAST", func(t *testing.T) {
+		db, tx, cleanup := testutil.NewTestTx(t)
+		defer cleanup()
+
+		testutil.MustExec(t, db, tx, "CREATE TABLE test(a int, b int)")
+		testutil.MustExec(t, db, tx, "INSERT INTO test VALUES (1, 1), (NULL, 2), (1, NULL), (0, 3)")
+
+		var env environment.Environment
+		env.DB = db
+		env.Tx = tx
+
+		s := stream.New(table.Scan("test")).Pipe(rows.TempTreeSortBy(
+			rows.SortKey{Expr: parser.MustParseExpr("a"), Desc: true},
+			rows.SortKey{Expr: parser.MustParseExpr("b"), Nulls: rows.NullsLast},
+		))
+
+		var got []string
+		err := s.Iterate(&env, func(env *environment.Environment) error {
+			r, ok := env.GetRow()
+			require.True(t, ok)
+
+			d, err := r.MarshalJSON()
+			require.NoError(t, err)
+			got = append(got, string(d))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{`{"a": 1, "b": 1}`, `{"a": 1, "b": null}`, `{"a": 0, "b": 3}`, `{"a": null, "b": 2}`}, got)
+	})
+
 	t.Run("String", func(t *testing.T) {
 		require.Equal(t, `rows.TempTreeSort(a)`, rows.TempTreeSort(parser.MustParseExpr("a")).String())
+		require.Equal(t, `rows.TempTreeSortReverse(a)`, rows.TempTreeSortReverse(parser.MustParseExpr("a")).String())
+		require.Equal(t, `rows.TempTreeSort(a DESC, b NULLS LAST)`, rows.TempTreeSortBy(
+			rows.SortKey{Expr: parser.MustParseExpr("a"), Desc: true},
+			rows.SortKey{Expr: parser.MustParseExpr("b"), Nulls: rows.NullsLast},
+		).String())
+		require.Equal(t, `rows.TempTreeSortReverse(a, b NULLS FIRST)`, rows.TempTreeSortBy(
+			rows.SortKey{Expr: parser.MustParseExpr("a"), Desc: true},
+			rows.SortKey{Expr: parser.MustParseExpr("b"), Desc: true, Nulls: rows.NullsFirst},
+		).String())
 	})
 }
@@ -0,0 +1,327 @@
+package stream
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// IntersectOperator is an operator that returns the rows
+// returned by all of its streams.
+// If All is true, a row is returned as many times as
+// the minimum number of times it was returned by a stream.
+type IntersectOperator struct {
+	BaseOperator
+	All     bool
+	Streams []*Stream
+}
+
+// Intersect returns a new IntersectOperator.
+func Intersect(all bool, s ...*Stream) *IntersectOperator {
+	return &IntersectOperator{All: all, Streams: s}
+}
+
+func (it *IntersectOperator) Clone() Operator {
+	return &IntersectOperator{
+		BaseOperator: it.BaseOperator.Clone(),
+		All:          it.All,
+		Streams:      cloneStreams(it.Streams),
+	}
+}
+
+func (it *IntersectOperator) Columns(env *environment.Environment) ([]string, error) {
+	if len(it.Streams) == 0 {
+		return nil, nil
+	}
+
+	return it.Streams[0].Columns(env)
+}
+
+// Iterate iterates over all the streams and returns their intersection.
+func (it *IntersectOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	last := int64(len(it.Streams) - 1)
+
+	return iterateMultiset(in, it.Streams, func(i int64, c *rowCount) bool {
+		switch c.stream {
+		case i:
+			c.cur++
+		case i - 1:
+			// first occurrence of the row in this stream:
+			// the rows of the previous one are all counted.
+			c.n = min(c.n, c.cur)
+			c.stream = i
+			c.cur = 1
+		default:
+			// the row was missing from a previous stream
+			return false
+		}
+
+		return true
+	}, func(c *rowCount) int64 {
+		if c.stream != last {
+			return 0
+		}
+
+		n := min(c.n, c.cur)
+		if !it.All {
+			n = min(n, 1)
+		}
+		return n
+	}, fn)
+}
+
+func (it *IntersectOperator) String() string {
+	if it.All {
+		return streamsString("intersect_all(", it.Streams)
+	}
+
+	return streamsString("intersect(", it.Streams)
+}
+
+// ExceptOperator is an operator that returns the rows of
+// its first stream that are not returned by the other streams.
+// If All is true, each row returned by the other streams only
+// removes one occurrence of that row from the result.
+type ExceptOperator struct {
+	BaseOperator
+	All     bool
+	Streams []*Stream
+}
+
+// Except returns a new ExceptOperator.
+func Except(all bool, s ...*Stream) *ExceptOperator {
+	return &ExceptOperator{All: all, Streams: s}
+}
+
+func (it *ExceptOperator) Clone() Operator {
+	return &ExceptOperator{
+		BaseOperator: it.BaseOperator.Clone(),
+		All:          it.All,
+		Streams:      cloneStreams(it.Streams),
+	}
+}
+
+func (it *ExceptOperator) Columns(env *environment.Environment) ([]string, error) {
+	if len(it.Streams) == 0 {
+		return nil, nil
+	}
+
+	return it.Streams[0].Columns(env)
+}
+
+// Iterate iterates over all the streams and returns the difference
+// between the first one and the others.
+func (it *ExceptOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	return iterateMultiset(in, it.Streams, func(i int64, c *rowCount) bool {
+		if c.n <= 0 {
+			return false
+		}
+
+		if it.All {
+			c.n--
+		} else {
+			c.n = 0
+		}
+		return true
+	}, func(c *rowCount) int64 {
+		if !it.All {
+			return min(c.n, 1)
+		}
+		return c.n
+	}, fn)
+}
+
+func (it *ExceptOperator) String() string {
+	if it.All {
+		return streamsString("except_all(", it.Streams)
+	}
+
+	return streamsString("except(", it.Streams)
+}
+
+// rowCount is the number of times a row was returned by
+// the streams of an intersection or a difference.
+type rowCount struct {
+	// number of rows returned so far
+	n int64
+	// last stream that returned the row
+	// and the number of times it did
+	stream, cur int64
+}
+
+// iterateMultiset counts the rows returned by the first stream in a temporary tree,
+// which spills to disk if it gets too big, and calls update for each row of the other
+// streams that was returned by the first one. If update returns true, the count of the row
+// is stored. It then calls fn for each row, as many times as returned by result.
+// Rows are compared by the position of their values, regardless of the names of their
+// columns, and returned with the columns of the first stream, in the order of the tree.
+func iterateMultiset(in *environment.Environment, streams []*Stream, update func(i int64, c *rowCount) bool, result func(c *rowCount) int64, fn func(out *environment.Environment) error) (err error) {
+	if len(streams) == 0 {
+		return nil
+	}
+
+	tx := in.GetTx()
+	temp, cleanup, err := tree.NewTransient(tx.NewTransientSession(), tx.Catalog.GetFreeTransientNamespace(), 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := cleanup()
+		if err == nil {
+			err = e
+		}
+	}()
+
+	var buf []byte
+	var c rowCount
+	var encKey []byte
+	var tableName string
+	var columns []string
+
+	for i, s := range streams {
+		err := s.Iterate(in, func(out *environment.Environment) error {
+			r, ok := out.GetRow()
+			if !ok {
+				return errors.New("missing row")
+			}
+
+			if i == 0 && columns == nil {
+				var err error
+				columns, err = row.Columns(r)
+				if err != nil {
+					return err
+				}
+			}
+
+			key := tree.NewKey(row.Values(r)...)
+			v, err := temp.Get(key)
+			if err != nil && !errors.Is(err, engine.ErrKeyNotFound) {
+				return err
+			}
+
+			switch {
+			case v != nil:
+				c, encKey, tableName = decodeRowCount(v)
+				if i == 0 {
+					c.n++
+					c.cur++
+				} else if !update(int64(i), &c) {
+					return nil
+				}
+			case i > 0:
+				// the row was not returned by the first stream
+				return nil
+			default:
+				c = rowCount{n: 1, cur: 1}
+				encKey, tableName = nil, ""
+
+				if dr, ok := r.(database.Row); ok {
+					// keep the row key and table name of the first occurrence
+					tableName = dr.TableName()
+
+					info, err := tx.Catalog.GetTableInfo(tableName)
+					if err != nil {
+						return err
+					}
+
+					encKey, err = info.EncodeKey(dr.Key())
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			buf, err = types.EncodeValuesAsKey(buf[:0],
+				types.NewBigintValue(c.n),
+				types.NewBigintValue(c.stream),
+				types.NewBigintValue(c.cur),
+				types.NewBlobValue(encKey),
+				types.NewTextValue(tableName),
+			)
+			if err != nil {
+				return err
+			}
+
+			return temp.Put(key, buf)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	var basicRow database.BasicRow
+	return temp.IterateOnRange(nil, false, func(key *tree.Key, value []byte) error {
+		c, encKey, tableName := decodeRowCount(value)
+		n := result(&c)
+		if n <= 0 {
+			return nil
+		}
+
+		kv, err := key.Decode()
+		if err != nil {
+			return err
+		}
+
+		var pk *tree.Key
+		if len(encKey) > 0 {
+			pk = tree.NewEncodedKey(encKey)
+		}
+
+		cb := row.NewColumnBuffer()
+		for i, v := range kv {
+			cb.Add(columns[i], v)
+		}
+
+		basicRow.ResetWith(tableName, pk, cb)
+		newEnv.SetRow(&basicRow)
+
+		for range n {
+			if err := fn(&newEnv); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func decodeRowCount(value []byte) (c rowCount, encKey []byte, tableName string) {
+	ser := types.DecodeValues(value)
+	c.n = types.AsInt64(ser[0])
+	c.stream = types.AsInt64(ser[1])
+	c.cur = types.AsInt64(ser[2])
+	return c, types.AsByteSlice(ser[3]), types.AsString(ser[4])
+}
+
+func cloneStreams(streams []*Stream) []*Stream {
+	cloned := make([]*Stream, len(streams))
+	for i, s := range streams {
+		cloned[i] = s.Clone()
+	}
+
+	return cloned
+}
+
+func streamsString(prefix string, streams []*Stream) string {
+	var s strings.Builder
+
+	s.WriteString(prefix)
+	for i, st := range streams {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString(st.String())
+	}
+	s.WriteRune(')')
+
+	return s.String()
+}
@@ -143,6 +143,8 @@ func InsertAfter(op, newOp Operator) Operator {
 // DiscardOperator is an operator that doesn't do anything.
 type DiscardOperator struct {
 	BaseOperator
+
+	count int64
 }
 
 // Discard is an operator that doesn't produce any row.
@@ -159,11 +161,18 @@ func (it *DiscardOperator) Clone() Operator {
 
 // Iterate iterates over all the streams and returns their union.
 func (op *DiscardOperator) Iterate(in *environment.Environment, _ func(out *environment.Environment) error) (err error) {
+	op.count = 0
 	return op.Prev.Iterate(in, func(out *environment.Environment) error {
+		op.count++
 		return nil
 	})
 }
 
+// Count returns the number of rows discarded by the last iteration.
+func (op *DiscardOperator) Count() int64 {
+	return op.count
+}
+
 func (it *DiscardOperator) String() string {
 	return "discard()"
 }
@@ -110,6 +110,78 @@ func TestUnion(t *testing.T) {
 	})
 }
 
+func TestIntersectExcept(t *testing.T) {
+	first := testutil.MakeRowExprs(t, `{"a": 1}`, `{"a": 2}`, `{"a": 2}`, `{"a": 2}`, `{"a": 3}`)
+	second := testutil.MakeRowExprs(t, `{"a": 3}`, `{"a": 2}`, `{"a": 4}`, `{"a": 2}`)
+	third := testutil.MakeRowExprs(t, `{"a": 2}`, `{"a": 1}`)
+
+	tests := []struct {
+		name     string
+		op       func(...*stream.Stream) stream.Operator
+		expected testutil.Rows
+	}{
+		{"intersect", func(s ...*stream.Stream) stream.Operator { return stream.Intersect(false, s...) },
+			testutil.MakeRows(t, `{"a": 2}`)},
+		{"intersect all", func(s ...*stream.Stream) stream.Operator { return stream.Intersect(true, s...) },
+			testutil.MakeRows(t, `{"a": 2}`)},
+		{"except", func(s ...*stream.Stream) stream.Operator { return stream.Except(false, s...) },
+			nil},
+		{"except all", func(s ...*stream.Stream) stream.Operator { return stream.Except(true, s...) },
+			nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			st := stream.New(test.op(
+				stream.New(rows.Emit([]string{"a"}, first...)),
+				stream.New(rows.Emit([]string{"a"}, second...)),
+				stream.New(rows.Emit([]string{"a"}, third...)),
+			))
+			var env environment.Environment
+			env.Tx = tx
+			env.DB = db
+
+			test.expected.RequireEqualStream(t, &env, st)
+		})
+	}
+
+	t.Run("two streams", func(t *testing.T) {
+		db, tx, cleanup := testutil.NewTestTx(t)
+		defer cleanup()
+
+		var env environment.Environment
+		env.Tx = tx
+		env.DB = db
+
+		st := stream.New(stream.Intersect(true,
+			stream.New(rows.Emit([]string{"a"}, first...)),
+			stream.New(rows.Emit([]string{"a"}, second...)),
+		))
+		testutil.Rows(testutil.MakeRows(t, `{"a": 2}`, `{"a": 2}`, `{"a": 3}`)).RequireEqualStream(t, &env, st)
+
+		st = stream.New(stream.Except(true,
+			stream.New(rows.Emit([]string{"a"}, first...)),
+			stream.New(rows.Emit([]string{"a"}, second...)),
+		))
+		testutil.Rows(testutil.MakeRows(t, `{"a": 1}`, `{"a": 2}`)).RequireEqualStream(t, &env, st)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		st := stream.New(stream.Except(true,
+			stream.New(rows.Emit([]string{"a"}, testutil.MakeRowExprs(t, `{"a": 1}`)...)),
+			stream.New(stream.Intersect(false,
+				stream.New(rows.Emit([]string{"a"}, testutil.MakeRowExprs(t, `{"a": 2}`)...)),
+				stream.New(rows.Emit([]string{"a"}, testutil.MakeRowExprs(t, `{"a": 3}`)...)),
+			)),
+		))
+
+		require.Equal(t, `except_all(rows.Emit((1)), intersect(rows.Emit((2)), rows.Emit((3))))`, st.String())
+	})
+}
+
 func TestConcatOperator(t *testing.T) {
 	in1 := testutil.MakeRowExprs(t, `{"a": 10}`, `{"a": 11}`)
 	in2 := testutil.MakeRowExprs(t, `{"a": 12}`, `{"a": 13}`)
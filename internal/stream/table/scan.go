@@ -20,6 +20,20 @@ type ScanOperator struct {
 	// If set, the operator will scan this table.
 	// It not set, it will get the scan from the catalog.
 	Table *database.Table
+	// If set, overrides the choice of the index
+	// the planner replaces the scan with.
+	Hint *IndexHint
+}
+
+// An IndexHint is given by the optimizer hints of a statement
+// to choose how a table is read.
+type IndexHint struct {
+	// Name of the index to read the table with, if it can be used.
+	// The other indexes are not used.
+	Index string
+	// If true, the table is not read with an index,
+	// only its primary key can be used.
+	NoIndex bool
 }
 
 // Scan creates an iterator that iterates over each object of the given table that match the given ranges.
@@ -40,6 +54,7 @@ func (op *ScanOperator) Clone() stream.Operator {
 		Ranges:       op.Ranges.Clone(),
 		Reverse:      op.Reverse,
 		Table:        op.Table,
+		Hint:         op.Hint,
 	}
 }
 
@@ -69,8 +84,24 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 		}
 	}
 
+	// tables read in key order can be read by several goroutines
+	iterate := func(rng *database.Range, fn func(key *tree.Key, r database.Row) error) error {
+		if workers := in.GetTx().ParallelWorkers; workers > 1 && !it.Reverse {
+			return table.IterateOnRangeParallel(rng, workers, fn)
+		}
+
+		return table.IterateOnRange(rng, it.Reverse, fn)
+	}
+
 	for _, rng := range ranges {
-		err = table.IterateOnRange(rng, it.Reverse, func(key *tree.Key, r database.Row) error {
+		err = iterate(rng, func(key *tree.Key, r database.Row) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
+			if err := in.RowScanned(); err != nil {
+				return err
+			}
+
 			newEnv.SetRow(r)
 
 			return fn(&newEnv)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/cockroachdb/errors"
@@ -13,41 +14,47 @@ import (
 type ValidateOperator struct {
 	stream.BaseOperator
 
-	tableName string
+	TableName string
 }
 
 func Validate(tableName string) *ValidateOperator {
 	return &ValidateOperator{
-		tableName: tableName,
+		TableName: tableName,
 	}
 }
 
 func (op *ValidateOperator) Clone() stream.Operator {
 	return &ValidateOperator{
 		BaseOperator: op.BaseOperator.Clone(),
-		tableName:    op.tableName,
+		TableName:    op.TableName,
 	}
 }
 
 func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
 	tx := in.GetTx()
 
-	info, err := tx.Catalog.GetTableInfo(op.tableName)
+	info, err := tx.Catalog.GetTableInfo(op.TableName)
 	if err != nil {
 		return err
 	}
-	if info.ReadOnly {
-		return errors.New("cannot write to read-only table")
+	if err := info.CheckWritable(); err != nil {
+		return err
 	}
 
-	var buf []byte
+	// each encoded row is only used until the next one is read,
+	// the buffer is reused for all of them and then by other statements
+	bp := encoding.GetBuffer()
+	buf := *bp
+	defer func() {
+		*bp = buf
+		encoding.PutBuffer(bp)
+	}()
 
 	var newEnv environment.Environment
 
 	var br database.BasicRow
 	var eo database.EncodedRow
 	return op.Prev.Iterate(in, func(out *environment.Environment) error {
-		buf = buf[:0]
 		newEnv.SetOuter(out)
 
 		row, ok := out.GetRow()
@@ -56,24 +63,29 @@ func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *en
 		}
 
 		// generate default values, validate and encode row
-		buf, err = info.EncodeRow(tx, buf, row)
+		enc, err := info.EncodeRow(tx, buf[:0], row)
 		if err != nil {
 			return err
 		}
+		// rows that are already encoded are returned as is,
+		// their buffer must not be reused
+		if _, ok := database.RowIsEncoded(row, &info.ColumnConstraints); !ok {
+			buf = enc
+		}
 
 		// use the encoded row as the new row
-		eo.ResetWith(&info.ColumnConstraints, buf)
+		eo.ResetWith(&info.ColumnConstraints, tx.Keyring(), enc)
 
 		if dRow, ok := row.(database.Row); ok {
-			br.ResetWith(op.tableName, dRow.Key(), &eo)
+			br.ResetWith(op.TableName, dRow.Key(), &eo)
 			newEnv.SetRow(&br)
 		} else {
-			br.ResetWith(op.tableName, nil, &eo)
+			br.ResetWith(op.TableName, nil, &eo)
 			newEnv.SetRow(&br)
 		}
 
 		// validate CHECK constraints if any
-		err := info.TableConstraints.ValidateRow(tx, newEnv.Row)
+		err = info.TableConstraints.ValidateRow(tx, newEnv.Row)
 		if err != nil {
 			return err
 		}
@@ -83,5 +95,5 @@ func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *en
 }
 
 func (op *ValidateOperator) String() string {
-	return fmt.Sprintf("table.Validate(%q)", op.tableName)
+	return fmt.Sprintf("table.Validate(%q)", op.TableName)
 }
@@ -71,9 +71,8 @@ func (it *UnionOperator) Iterate(in *environment.Environment, fn func(out *envir
 
 			if temp == nil {
 				// create a temporary tree
-				db := in.GetDB()
-				tns := in.GetTx().Catalog.GetFreeTransientNamespace()
-				temp, cleanup, err = tree.NewTransient(db.Engine.NewTransientSession(), tns, 0)
+				tx := in.GetTx()
+				temp, cleanup, err = tree.NewTransient(tx.NewTransientSession(), tx.Catalog.GetFreeTransientNamespace(), 0)
 				if err != nil {
 					return err
 				}
@@ -0,0 +1,301 @@
+package tree
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/chaisql/chai/engine"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A Partitioning stores the keys of a tree in several namespaces,
+// one per partition, instead of the namespace of the tree.
+// Keys encoded with the namespace of the tree, by the callers that
+// build the encoded keys of the tree themselves, are located like
+// the keys that are not encoded yet.
+type Partitioning struct {
+	// Namespaces of the partitions.
+	Namespaces []Namespace
+	// Locate returns the position of the partition holding
+	// the key with the given values.
+	Locate func(values []types.Value) (int, error)
+	// ByFirstValue is true if Locate only depends on the first value
+	// of the keys. Ranges whose bounds have the same first value are
+	// then read from a single partition.
+	ByFirstValue bool
+	// Ordered is true if Locate only depends on the first value of the keys
+	// and the keys of each partition are greater than those of the previous
+	// partitions. The partitions overlapping a range are then read one after
+	// the other instead of being merged.
+	Ordered bool
+}
+
+// Subtrees returns a tree for each partition of the tree,
+// or the tree itself if it is not partitioned.
+func (t *Tree) Subtrees() []*Tree {
+	if t.Partitioning == nil {
+		return []*Tree{t}
+	}
+
+	trees := make([]*Tree, len(t.Partitioning.Namespaces))
+	for i, ns := range t.Partitioning.Namespaces {
+		trees[i] = New(t.Session, ns, t.Order)
+	}
+
+	return trees
+}
+
+// subtree returns the tree of the partition holding the key,
+// along with the key to use in that tree.
+func (t *Tree) subtree(key *Key) (*Tree, *Key, error) {
+	p := t.Partitioning
+	if p == nil {
+		return t, key, nil
+	}
+
+	if key.Encoded != nil {
+		ns := namespaceOf(key.Encoded)
+		if ns != t.Namespace {
+			if !slices.Contains(p.Namespaces, ns) {
+				return nil, nil, errors.Errorf("key %s doesn't belong to any partition", key)
+			}
+
+			return New(t.Session, ns, t.Order), key, nil
+		}
+	}
+
+	values, err := key.Decode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i, err := p.Locate(values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return New(t.Session, p.Namespaces[i], t.Order), key.withNamespace(p.Namespaces[i]), nil
+}
+
+// prune returns the trees of the partitions that may hold keys of the range,
+// along with the range to use in each of them.
+func (t *Tree) prune(rng *Range) ([]*Tree, []*Range) {
+	p := t.Partitioning
+	first, last := 0, len(p.Namespaces)-1
+
+	if rng != nil && p.ByFirstValue {
+		min, max := firstValue(rng.Min), firstValue(rng.Max)
+
+		switch {
+		case p.Ordered:
+			// values outside of every partition don't
+			// restrict the partitions to read
+			if min != nil {
+				if i, err := p.Locate([]types.Value{min}); err == nil {
+					first = i
+				}
+			}
+			if max != nil {
+				if i, err := p.Locate([]types.Value{max}); err == nil {
+					last = i
+				}
+			}
+		case min != nil && max != nil:
+			if eq, err := min.EQ(max); err == nil && eq {
+				if i, err := p.Locate([]types.Value{min}); err == nil {
+					first, last = i, i
+				}
+			}
+		}
+	}
+
+	var trees []*Tree
+	var rngs []*Range
+	for i := first; i <= last; i++ {
+		ns := p.Namespaces[i]
+		trees = append(trees, New(t.Session, ns, t.Order))
+
+		if rng == nil {
+			rngs = append(rngs, nil)
+			continue
+		}
+		rngs = append(rngs, &Range{
+			Min:       rng.Min.withNamespace(ns),
+			Max:       rng.Max.withNamespace(ns),
+			Exclusive: rng.Exclusive,
+		})
+	}
+
+	return trees, rngs
+}
+
+// iterateOnPartitions iterates on the keys of the range, in the order of the tree,
+// reading only the partitions that may hold them.
+func (t *Tree) iterateOnPartitions(rng *Range, reverse bool, fn func(*Key, []byte) error) error {
+	trees, rngs := t.prune(rng)
+
+	if !t.Partitioning.Ordered {
+		return iterateMerged(trees, rngs, reverse, fn)
+	}
+
+	for i := range trees {
+		if reverse {
+			i = len(trees) - 1 - i
+		}
+
+		err := trees[i].IterateOnRange(rngs[i], reverse, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// iterateMerged iterates on the keys of the ranges of several trees
+// in the order of their encoding, regardless of their namespace.
+func iterateMerged(trees []*Tree, rngs []*Range, reverse bool, fn func(*Key, []byte) error) error {
+	its := make([]engine.Iterator, 0, len(trees))
+	defer func() {
+		for _, it := range its {
+			_ = it.Close()
+		}
+	}()
+
+	for i, tr := range trees {
+		start, end, err := tr.rangeBounds(rngs[i])
+		if err != nil {
+			return err
+		}
+
+		it, err := tr.Session.Iterator(&engine.IterOptions{
+			LowerBound: start,
+			UpperBound: end,
+		})
+		if err != nil {
+			return err
+		}
+		its = append(its, it)
+
+		if !reverse {
+			it.First()
+		} else {
+			it.Last()
+		}
+	}
+
+	var k Key
+	for {
+		// select the iterator positioned on the smallest key,
+		// or on the largest one in reverse order
+		cur := -1
+		var curKey []byte
+		for i, it := range its {
+			if !it.Valid() {
+				if err := it.Error(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			key := it.Key()
+			key = key[encoding.Skip(key):]
+			if cur < 0 {
+				cur, curKey = i, key
+				continue
+			}
+
+			c := bytes.Compare(key, curKey)
+			if (!reverse && c < 0) || (reverse && c > 0) {
+				cur, curKey = i, key
+			}
+		}
+		if cur < 0 {
+			return nil
+		}
+
+		it := its[cur]
+		k.Encoded = it.Key()
+		k.values = nil
+
+		v, err := it.Value()
+		if err != nil {
+			return err
+		}
+		if len(v) == 0 || v[0] == 0 {
+			v = nil
+		}
+
+		err = fn(&k, v)
+		if err != nil {
+			return err
+		}
+
+		if !reverse {
+			it.Next()
+		} else {
+			it.Prev()
+		}
+	}
+}
+
+// partitions returns the bounds of the partitions overlapping the range,
+// in key order, or nil if the partitions are not ordered.
+func (t *Tree) partitions(rng *Range) ([]Partition, error) {
+	if !t.Partitioning.Ordered {
+		return nil, nil
+	}
+
+	trees, rngs := t.prune(rng)
+	parts := make([]Partition, 0, len(trees))
+	for i, tr := range trees {
+		start, end, err := tr.rangeBounds(rngs[i])
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, Partition{Start: start, End: end})
+	}
+
+	return parts, nil
+}
+
+// withNamespace returns a copy of the key to be encoded with the given namespace.
+// Encoded keys have their namespace replaced.
+func (k *Key) withNamespace(ns Namespace) *Key {
+	if k == nil {
+		return nil
+	}
+
+	if k.Encoded == nil {
+		return &Key{values: k.values}
+	}
+
+	enc := encoding.EncodeUint(nil, uint64(ns))
+	return &Key{
+		values:  k.values,
+		Encoded: append(enc, k.Encoded[encoding.Skip(k.Encoded):]...),
+	}
+}
+
+// firstValue returns the first value of the key, or nil if it has none.
+func firstValue(k *Key) types.Value {
+	if k == nil {
+		return nil
+	}
+
+	values, err := k.Decode()
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+
+	return values[0]
+}
+
+// namespaceOf returns the namespace of an encoded key.
+func namespaceOf(k []byte) Namespace {
+	ns, _ := encoding.DecodeInt(k)
+	return Namespace(ns)
+}
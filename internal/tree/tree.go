@@ -3,8 +3,9 @@ package tree
 import (
 	"fmt"
 
+	"github.com/chaisql/chai/engine"
 	"github.com/chaisql/chai/internal/encoding"
-	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -58,6 +59,9 @@ type Tree struct {
 	Session   engine.Session
 	Namespace Namespace
 	Order     SortOrder
+	// If set, the keys are stored in the namespaces
+	// of the partitions instead of Namespace.
+	Partitioning *Partitioning
 }
 
 func New(session engine.Session, ns Namespace, order SortOrder) *Tree {
@@ -68,6 +72,8 @@ func New(session engine.Session, ns Namespace, order SortOrder) *Tree {
 	}
 }
 
+// NewTransient returns a temporary tree stored in a transient session.
+// The returned function deletes the tree and closes the session.
 func NewTransient(session engine.Session, ns Namespace, order SortOrder) (*Tree, func() error, error) {
 	t := Tree{
 		Namespace: ns,
@@ -80,10 +86,19 @@ func NewTransient(session engine.Session, ns Namespace, order SortOrder) (*Tree,
 		return errors.Errorf("namespace %d is already in use", ns)
 	})
 	if err != nil {
+		_ = session.Close()
 		return nil, nil, err
 	}
 
-	return &t, t.Truncate, nil
+	cleanup := func() error {
+		err := t.Truncate()
+		if cerr := session.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	return &t, cleanup, nil
 }
 
 var defaultValue = []byte{0}
@@ -91,6 +106,11 @@ var defaultValue = []byte{0}
 // Insert adds a key-obj combination to the tree.
 // If the key already exists, it returns engine.ErrKeyAlreadyExists.
 func (t *Tree) Insert(key *Key, value []byte) error {
+	t, key, err := t.subtree(key)
+	if err != nil {
+		return err
+	}
+
 	if len(value) == 0 {
 		value = defaultValue
 	}
@@ -106,6 +126,11 @@ func (t *Tree) Insert(key *Key, value []byte) error {
 // If the key already exists, its value will be replaced by
 // the given value.
 func (t *Tree) Put(key *Key, value []byte) error {
+	t, key, err := t.subtree(key)
+	if err != nil {
+		return err
+	}
+
 	if len(value) == 0 {
 		value = defaultValue
 	}
@@ -120,6 +145,11 @@ func (t *Tree) Put(key *Key, value []byte) error {
 // Get a key from the tree. If the key doesn't exist,
 // it returns engine.ErrKeyNotFound.
 func (t *Tree) Get(key *Key) ([]byte, error) {
+	t, key, err := t.subtree(key)
+	if err != nil {
+		return nil, err
+	}
+
 	k, err := key.Encode(t.Namespace, t.Order)
 	if err != nil {
 		return nil, err
@@ -139,6 +169,11 @@ func (t *Tree) Get(key *Key) ([]byte, error) {
 
 // Exists returns true if the key exists in the tree.
 func (t *Tree) Exists(key *Key) (bool, error) {
+	t, key, err := t.subtree(key)
+	if err != nil {
+		return false, err
+	}
+
 	k, err := key.Encode(t.Namespace, t.Order)
 	if err != nil {
 		return false, err
@@ -150,6 +185,11 @@ func (t *Tree) Exists(key *Key) (bool, error) {
 // Delete a key from the tree. If the key doesn't exist,
 // it returns engine.ErrKeyNotFound.
 func (t *Tree) Delete(key *Key) error {
+	t, key, err := t.subtree(key)
+	if err != nil {
+		return err
+	}
+
 	k, err := key.Encode(t.Namespace, t.Order)
 	if err != nil {
 		return err
@@ -160,14 +200,159 @@ func (t *Tree) Delete(key *Key) error {
 
 // Truncate the tree.
 func (t *Tree) Truncate() error {
-	return t.Session.DeleteRange(encoding.EncodeInt(nil, int64(t.Namespace)), encoding.EncodeInt(nil, int64(t.Namespace)+1))
+	if t.Partitioning != nil {
+		for _, st := range t.Subtrees() {
+			err := st.Truncate()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return t.Session.DeleteRange(t.Bounds())
+}
+
+// Bounds returns the first key of the tree and the key following its last key
+// in the engine. The keys of a partitioned tree are within the bounds of its subtrees.
+func (t *Tree) Bounds() (start, end []byte) {
+	return encoding.EncodeInt(nil, int64(t.Namespace)), encoding.EncodeInt(nil, int64(t.Namespace)+1)
 }
 
 // IterateOnRange iterates on all keys that are in the given range.
 func (t *Tree) IterateOnRange(rng *Range, reverse bool, fn func(*Key, []byte) error) error {
-	var start, end []byte
-	var err error
+	if t.Partitioning != nil {
+		return t.iterateOnPartitions(rng, reverse, fn)
+	}
+
+	start, end, err := t.rangeBounds(rng)
+	if err != nil {
+		return err
+	}
+
+	return t.iterateOnBounds(start, end, reverse, fn)
+}
+
+// A Partition is a contiguous part of the keys of a tree,
+// from Start included to End excluded, as encoded in the engine.
+type Partition struct {
+	Start, End []byte
+}
+
+// Partition splits the keys of the range into at most n contiguous
+// partitions, in key order. The keys are split on their first value,
+// which must be an integer sorted in ascending order, assuming its values
+// are evenly distributed between the first and the last key of the range.
+// Otherwise, or if the range holds too few keys, the range is returned
+// as a single partition.
+// The keys of a partitioned tree are split on its partitions instead,
+// if they are ordered. Otherwise, no partition is returned.
+func (t *Tree) Partition(rng *Range, n int) ([]Partition, error) {
+	if t.Partitioning != nil {
+		return t.partitions(rng)
+	}
+
+	start, end, err := t.rangeBounds(rng)
+	if err != nil {
+		return nil, err
+	}
+
+	whole := []Partition{{Start: start, End: end}}
+	if n <= 1 || t.Order.IsDesc(0) {
+		return whole, nil
+	}
+
+	first, last, err := t.firstAndLastKeys(start, end)
+	if err != nil || first == nil {
+		return whole, err
+	}
+
+	lo, ok := firstIntegerValue(first)
+	if !ok {
+		return whole, nil
+	}
+	hi, ok := firstIntegerValue(last)
+	if !ok || hi <= lo {
+		return whole, nil
+	}
+
+	// the split points are computed on unsigned integers
+	// to avoid overflows on wide ranges
+	width := uint64(hi) - uint64(lo)
+	if width < uint64(n) {
+		n = int(width)
+	}
+
+	parts := make([]Partition, 0, n)
+	prev := start
+	for i := 1; i < n; i++ {
+		split := lo + int64(width/uint64(n)*uint64(i)+width%uint64(n)*uint64(i)/uint64(n))
+
+		var v types.Value
+		if first[0].Type() == types.TypeInteger {
+			v = types.NewIntegerValue(int32(split))
+		} else {
+			v = types.NewBigintValue(split)
+		}
+		k, err := NewKey(v).Encode(t.Namespace, t.Order)
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, Partition{Start: prev, End: k})
+		prev = k
+	}
+
+	return append(parts, Partition{Start: prev, End: end}), nil
+}
+
+// IterateOnPartition iterates on all keys of the partition.
+func (t *Tree) IterateOnPartition(p Partition, reverse bool, fn func(*Key, []byte) error) error {
+	return t.iterateOnBounds(p.Start, p.End, reverse, fn)
+}
+
+// firstAndLastKeys returns the values of the first and last keys between
+// start and end. They are nil if there is no key.
+func (t *Tree) firstAndLastKeys(start, end []byte) (first, last []types.Value, err error) {
+	it, err := t.Session.Iterator(&engine.IterOptions{
+		LowerBound: start,
+		UpperBound: end,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer it.Close()
+
+	if !it.First() {
+		return nil, nil, it.Error()
+	}
+	first, err = NewEncodedKey(it.Key()).Decode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	it.Last()
+	last, err = NewEncodedKey(it.Key()).Decode()
+	if err != nil {
+		return nil, nil, err
+	}
 
+	return first, last, it.Error()
+}
+
+// firstIntegerValue returns the first value of a key
+// if it is an integer.
+func firstIntegerValue(values []types.Value) (int64, bool) {
+	switch values[0].Type() {
+	case types.TypeInteger, types.TypeBigint:
+		return types.AsInt64(values[0]), true
+	}
+
+	return 0, false
+}
+
+func (t *Tree) rangeBounds(rng *Range) (start, end []byte, err error) {
 	if rng == nil {
 		rng = &Range{}
 	}
@@ -181,14 +366,13 @@ func (t *Tree) IterateOnRange(rng *Range, reverse bool, fn func(*Key, []byte) er
 	}
 
 	if !rng.Exclusive {
-		start, end, err = t.buildInclusiveBoundaries(min, max, desc)
-	} else {
-		start, end, err = t.buildExclusiveBoundaries(min, max, desc)
-	}
-	if err != nil {
-		return err
+		return t.buildInclusiveBoundaries(min, max, desc)
 	}
 
+	return t.buildExclusiveBoundaries(min, max, desc)
+}
+
+func (t *Tree) iterateOnBounds(start, end []byte, reverse bool, fn func(*Key, []byte) error) error {
 	opts := engine.IterOptions{
 		LowerBound: start,
 		UpperBound: end,
@@ -344,3 +344,216 @@ func TestTreeIterateOnRange(t *testing.T) {
 		}
 	}
 }
+
+func TestTreePartition(t *testing.T) {
+	collect := func(t *testing.T, tr *tree.Tree, parts []tree.Partition) []int64 {
+		t.Helper()
+
+		var got []int64
+		for _, p := range parts {
+			err := tr.IterateOnPartition(p, false, func(k *tree.Key, _ []byte) error {
+				values, err := k.Decode()
+				if err != nil {
+					return err
+				}
+				got = append(got, types.AsInt64(values[0]))
+				return nil
+			})
+			require.NoError(t, err)
+		}
+		return got
+	}
+
+	tr := testutil.NewTestTree(t, 10)
+	for i := int64(0); i < 1000; i++ {
+		require.NoError(t, tr.Put(tree.NewKey(types.NewBigintValue(i*3)), []byte{1}))
+	}
+
+	tests := []struct {
+		name     string
+		rng      *tree.Range
+		n        int
+		parts    int
+		from, to int64
+	}{
+		{"all", nil, 4, 4, 0, 2997},
+		{"one", nil, 1, 1, 0, 2997},
+		{">= 300", &tree.Range{Min: tree.NewKey(types.NewBigintValue(300))}, 8, 8, 300, 2997},
+		{"> 300 AND < 600", &tree.Range{Min: tree.NewKey(types.NewBigintValue(300)), Max: tree.NewKey(types.NewBigintValue(600)), Exclusive: true}, 3, 3, 303, 597},
+		{"narrow", &tree.Range{Min: tree.NewKey(types.NewBigintValue(300)), Max: tree.NewKey(types.NewBigintValue(306))}, 16, 6, 300, 306},
+		{"single key", &tree.Range{Min: tree.NewKey(types.NewBigintValue(300)), Max: tree.NewKey(types.NewBigintValue(300))}, 4, 1, 300, 300},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parts, err := tr.Partition(test.rng, test.n)
+			require.NoError(t, err)
+			require.Len(t, parts, test.parts)
+
+			var want []int64
+			for i := test.from; i <= test.to; i += 3 {
+				want = append(want, i)
+			}
+			require.Equal(t, want, collect(t, tr, parts))
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		tr := testutil.NewTestTree(t, 11)
+		parts, err := tr.Partition(nil, 4)
+		require.NoError(t, err)
+		require.Len(t, parts, 1)
+		require.Empty(t, collect(t, tr, parts))
+	})
+
+	t.Run("text keys", func(t *testing.T) {
+		tr := testutil.NewTestTree(t, 12)
+		for i := 0; i < 10; i++ {
+			require.NoError(t, tr.Put(tree.NewKey(types.NewTextValue(fmt.Sprintf("foo%d", i))), []byte{1}))
+		}
+		parts, err := tr.Partition(nil, 4)
+		require.NoError(t, err)
+		require.Len(t, parts, 1)
+	})
+}
+
+func TestTreePartitioning(t *testing.T) {
+	// collect returns the first value of the keys of the range
+	// and checks that they are stored in the expected namespace.
+	collect := func(t *testing.T, tr *tree.Tree, rng *tree.Range, reverse bool) []int64 {
+		t.Helper()
+
+		var got []int64
+		err := tr.IterateOnRange(rng, reverse, func(k *tree.Key, _ []byte) error {
+			values, err := k.Decode()
+			if err != nil {
+				return err
+			}
+			got = append(got, types.AsInt64(values[0]))
+
+			i, err := tr.Partitioning.Locate(values)
+			if err != nil {
+				return err
+			}
+			require.Equal(t, encoding.EncodeUint(nil, uint64(tr.Partitioning.Namespaces[i])), k.Encoded[:1])
+			return nil
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	ints := func(from, to, step int64) []int64 {
+		var l []int64
+		for i := from; (step > 0 && i <= to) || (step < 0 && i >= to); i += step {
+			l = append(l, i)
+		}
+		return l
+	}
+
+	// range partitions: < 10, < 20 and the rest
+	byRange := func(values []types.Value) (int, error) {
+		v := types.AsInt64(values[0])
+		switch {
+		case v < 10:
+			return 0, nil
+		case v < 20:
+			return 1, nil
+		}
+		return 2, nil
+	}
+	byHash := func(values []types.Value) (int, error) {
+		return int(types.AsInt64(values[0]) % 3), nil
+	}
+
+	tests := []struct {
+		name         string
+		partitioning tree.Partitioning
+	}{
+		{"range", tree.Partitioning{Namespaces: []tree.Namespace{11, 12, 13}, Locate: byRange, ByFirstValue: true, Ordered: true}},
+		{"hash", tree.Partitioning{Namespaces: []tree.Namespace{11, 12, 13}, Locate: byHash, ByFirstValue: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := testutil.NewTestTree(t, 10)
+			tr.Partitioning = &test.partitioning
+
+			for i := int64(0); i < 30; i++ {
+				require.NoError(t, tr.Put(tree.NewKey(types.NewBigintValue(i)), []byte{1}))
+			}
+
+			require.Equal(t, ints(0, 29, 1), collect(t, tr, nil, false))
+			require.Equal(t, ints(29, 0, -1), collect(t, tr, nil, true))
+
+			rng := &tree.Range{Min: tree.NewKey(types.NewBigintValue(8)), Max: tree.NewKey(types.NewBigintValue(21))}
+			require.Equal(t, ints(8, 21, 1), collect(t, tr, rng, false))
+			require.Equal(t, ints(21, 8, -1), collect(t, tr, rng, true))
+
+			rng = &tree.Range{Min: tree.NewKey(types.NewBigintValue(15)), Max: tree.NewKey(types.NewBigintValue(15))}
+			require.Equal(t, []int64{15}, collect(t, tr, rng, false))
+
+			// the keys of the partitions are stored in their own namespace
+			for _, st := range tr.Subtrees() {
+				var n int
+				err := st.IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+					n++
+					return nil
+				})
+				require.NoError(t, err)
+				require.Equal(t, 10, n)
+			}
+
+			// keys encoded with the namespace of the tree are located,
+			// other keys are used as is
+			k, err := tree.NewKey(types.NewBigintValue(5)).Encode(10, 0)
+			require.NoError(t, err)
+			v, err := tr.Get(tree.NewEncodedKey(k))
+			require.NoError(t, err)
+			require.Equal(t, []byte{1}, v)
+
+			k, err = tree.NewKey(types.NewBigintValue(5)).Encode(20, 0)
+			require.NoError(t, err)
+			_, err = tr.Get(tree.NewEncodedKey(k))
+			require.Error(t, err)
+
+			require.NoError(t, tr.Delete(tree.NewKey(types.NewBigintValue(5))))
+			ok, err := tr.Exists(tree.NewKey(types.NewBigintValue(5)))
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			require.NoError(t, tr.Truncate())
+			require.Empty(t, collect(t, tr, nil, false))
+		})
+	}
+
+	t.Run("parallel", func(t *testing.T) {
+		tr := testutil.NewTestTree(t, 10)
+		tr.Partitioning = &tests[0].partitioning
+		for i := int64(0); i < 30; i++ {
+			require.NoError(t, tr.Put(tree.NewKey(types.NewBigintValue(i)), []byte{1}))
+		}
+
+		parts, err := tr.Partition(&tree.Range{Min: tree.NewKey(types.NewBigintValue(12))}, 4)
+		require.NoError(t, err)
+		require.Len(t, parts, 2)
+
+		var got []int64
+		for _, p := range parts {
+			err := tr.IterateOnPartition(p, false, func(k *tree.Key, _ []byte) error {
+				values, err := k.Decode()
+				if err != nil {
+					return err
+				}
+				got = append(got, types.AsInt64(values[0]))
+				return nil
+			})
+			require.NoError(t, err)
+		}
+		require.Equal(t, ints(12, 29, 1), got)
+
+		tr.Partitioning = &tests[1].partitioning
+		parts, err = tr.Partition(nil, 4)
+		require.NoError(t, err)
+		require.Empty(t, parts)
+	})
+}
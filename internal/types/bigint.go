@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/cockroachdb/errors"
 )
 
@@ -26,7 +27,7 @@ func (BigintTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (BigintTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeBigint || other == TypeInteger || other == TypeDouble
+	return other == TypeBigint || other == TypeInteger || other == TypeDouble || other == TypeDecimal
 }
 
 func (BigintTypeDef) IsIndexComparableWith(other Type) bool {
@@ -84,6 +85,8 @@ func (v BigintValue) CastAs(target Type) (Value, error) {
 	switch target {
 	case TypeBigint:
 		return v, nil
+	case TypeBoolean:
+		return NewBooleanValue(int64(v) != 0), nil
 	case TypeInteger:
 		if int64(v) > math.MaxInt32 || int64(v) < math.MinInt32 {
 			return nil, errors.Errorf("integer out of range")
@@ -91,11 +94,13 @@ func (v BigintValue) CastAs(target Type) (Value, error) {
 		return NewIntegerValue(int32(v)), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v)), nil
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
 
-	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
 }
 
 func (v BigintValue) EQ(other Value) (bool, error) {
@@ -105,6 +110,8 @@ func (v BigintValue) EQ(other Value) (bool, error) {
 		return int64(v) == AsInt64(other), nil
 	case TypeDouble:
 		return float64(int64(v)) == AsFloat64(other), nil
+	case TypeDecimal:
+		return other.EQ(v)
 	default:
 		return false, nil
 	}
@@ -117,6 +124,8 @@ func (v BigintValue) GT(other Value) (bool, error) {
 		return int64(v) > AsInt64(other), nil
 	case TypeDouble:
 		return float64(int64(v)) > AsFloat64(other), nil
+	case TypeDecimal:
+		return other.LT(v)
 	default:
 		return false, nil
 	}
@@ -129,6 +138,8 @@ func (v BigintValue) GTE(other Value) (bool, error) {
 		return int64(v) >= AsInt64(other), nil
 	case TypeDouble:
 		return float64(int64(v)) >= AsFloat64(other), nil
+	case TypeDecimal:
+		return other.LTE(v)
 	default:
 		return false, nil
 	}
@@ -141,6 +152,8 @@ func (v BigintValue) LT(other Value) (bool, error) {
 		return int64(v) < AsInt64(other), nil
 	case TypeDouble:
 		return float64(int64(v)) <= AsFloat64(other), nil
+	case TypeDecimal:
+		return other.GT(v)
 	default:
 		return false, nil
 	}
@@ -153,6 +166,8 @@ func (v BigintValue) LTE(other Value) (bool, error) {
 		return int64(v) <= AsInt64(other), nil
 	case TypeDouble:
 		return float64(int64(v)) <= AsFloat64(other), nil
+	case TypeDecimal:
+		return other.GTE(v)
 	default:
 		return false, nil
 	}
@@ -182,7 +197,9 @@ func (v BigintValue) Add(other Numeric) (Value, error) {
 		xr := xa + xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
-		return NewDoubleValue(float64(int64(v)) + AsFloat64(other)), nil
+		return NewDoubleResult(float64(int64(v)) + AsFloat64(other))
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Add(other)
 	}
 
 	return NewNullValue(), nil
@@ -199,7 +216,9 @@ func (v BigintValue) Sub(other Numeric) (Value, error) {
 		xr := xa - xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
-		return NewDoubleValue(float64(int64(v)) - AsFloat64(other)), nil
+		return NewDoubleResult(float64(int64(v)) - AsFloat64(other))
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Sub(other)
 	}
 
 	return NewNullValue(), nil
@@ -219,7 +238,9 @@ func (v BigintValue) Mul(other Numeric) (Value, error) {
 		xr := xa * xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
-		return NewDoubleValue(float64(int64(v)) * AsFloat64(other)), nil
+		return NewDoubleResult(float64(int64(v)) * AsFloat64(other))
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Mul(other)
 	}
 
 	return NewNullValue(), nil
@@ -231,7 +252,10 @@ func (v BigintValue) Div(other Numeric) (Value, error) {
 		xa := int64(v)
 		xb := AsInt64(other)
 		if xb == 0 {
-			return NewNullValue(), nil
+			return nil, errors.New("division by zero")
+		}
+		if xa == math.MinInt64 && xb == -1 {
+			return nil, errors.New("bigint out of range")
 		}
 
 		return NewBigintValue(xa / xb), nil
@@ -242,7 +266,9 @@ func (v BigintValue) Div(other Numeric) (Value, error) {
 			return NewNullValue(), nil
 		}
 
-		return NewDoubleValue(xa / xb), nil
+		return NewDoubleResult(xa / xb)
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Div(other)
 	}
 
 	return NewNullValue(), nil
@@ -267,6 +293,8 @@ func (v BigintValue) Mod(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(mod), nil
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Mod(other)
 	}
 
 	return NewNullValue(), nil
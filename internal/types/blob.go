@@ -6,7 +6,7 @@ import (
 	"encoding/hex"
 
 	"github.com/chaisql/chai/internal/encoding"
-	"github.com/cockroachdb/errors"
+	errs "github.com/chaisql/chai/internal/errors"
 )
 
 var _ TypeDefinition = BlobTypeDef{}
@@ -94,9 +94,19 @@ func (v BlobValue) CastAs(target Type) (Value, error) {
 		return v, nil
 	case TypeText:
 		return NewTextValue(base64.StdEncoding.EncodeToString([]byte(v))), nil
+	case TypeUUID:
+		if len(v) != 16 {
+			return nil, errs.TypeMismatchf("cannot cast blob of %d bytes as uuid", len(v))
+		}
+		return NewUUIDValue([16]byte(v)), nil
+	case TypeVector:
+		if len(v)%4 != 0 {
+			return nil, errs.TypeMismatchf("cannot cast blob of %d bytes as vector", len(v))
+		}
+		return decodeVector(v), nil
 	}
 
-	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
 }
 
 func (v BlobValue) EQ(other Value) (bool, error) {
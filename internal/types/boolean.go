@@ -4,7 +4,7 @@ import (
 	"strconv"
 
 	"github.com/chaisql/chai/internal/encoding"
-	"github.com/cockroachdb/errors"
+	errs "github.com/chaisql/chai/internal/errors"
 )
 
 var _ TypeDefinition = BooleanTypeDef{}
@@ -87,11 +87,17 @@ func (v BooleanValue) CastAs(target Type) (Value, error) {
 		}
 
 		return NewIntegerValue(0), nil
+	case TypeBigint:
+		if bool(v) {
+			return NewBigintValue(1), nil
+		}
+
+		return NewBigintValue(0), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
 
-	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
 }
 
 func (v BooleanValue) ConvertToIndexedType(t Type) (Value, error) {
@@ -1,7 +1,9 @@
 package types_test
 
 import (
+	"fmt"
 	"math"
+	"math/big"
 	"testing"
 	"time"
 
@@ -46,6 +48,7 @@ func TestCastAs(t *testing.T) {
 			{boolV, boolV, false},
 			{integerV, boolV, false},
 			{types.NewIntegerValue(0), types.NewBooleanValue(false), false},
+			{types.NewBigintValue(10), boolV, false},
 			{doubleV, nil, true},
 			{textV, nil, true},
 			{types.NewTextValue("true"), boolV, false},
@@ -63,11 +66,27 @@ func TestCastAs(t *testing.T) {
 			{textV, nil, true},
 			{types.NewTextValue("10"), integerV, false},
 			{types.NewTextValue("10.5"), integerV, false},
+			{types.NewTextValue("3000000000"), nil, true},
+			{types.NewTextValue("3e9"), nil, true},
+			{types.NewTextValue("NaN"), nil, true},
 			{blobV, nil, true},
 			{types.NewDoubleValue(math.MaxInt64 + 1), nil, true},
 		})
 	})
 
+	t.Run("bigint", func(t *testing.T) {
+		check(t, types.TypeBigint, []test{
+			{boolV, types.NewBigintValue(1), false},
+			{types.NewBooleanValue(false), types.NewBigintValue(0), false},
+			{integerV, types.NewBigintValue(10), false},
+			{types.NewTextValue("3000000000"), types.NewBigintValue(3000000000), false},
+			{types.NewTextValue("3e9"), types.NewBigintValue(3000000000), false},
+			{types.NewTextValue("1e19"), nil, true},
+			{types.NewTextValue("99999999999999999999"), nil, true},
+			{blobV, nil, true},
+		})
+	})
+
 	t.Run("double", func(t *testing.T) {
 		check(t, types.TypeDouble, []test{
 			{boolV, nil, true},
@@ -111,3 +130,72 @@ func TestCastAs(t *testing.T) {
 		})
 	})
 }
+
+func TestCanCastAs(t *testing.T) {
+	values := []types.Value{
+		types.NewNullValue(),
+		types.NewBooleanValue(true),
+		types.NewIntegerValue(1),
+		types.NewBigintValue(1),
+		types.NewDoubleValue(1),
+		types.NewDecimalValue(big.NewInt(1), 0),
+		types.NewTimestampValue(time.Date(2023, 5, 7, 0, 0, 0, 0, time.UTC)),
+		types.NewDateValue(time.Date(2023, 5, 7, 0, 0, 0, 0, time.UTC)),
+		types.NewIntervalValue(0, 1, 0),
+		types.NewTextValue("1"),
+		types.NewBlobValue(make([]byte, 16)),
+		types.NewUUIDValue([16]byte{}),
+		types.NewVectorValue([]float32{1}),
+	}
+
+	// the matrix must match the conversions of CastAs
+	for _, v := range values {
+		for target := types.TypeNull; target <= types.TypeVector; target++ {
+			_, err := v.CastAs(target)
+			if v.Type().CanCastAs(target) {
+				// texts must be parsable
+				if v.Type() != types.TypeText {
+					require.NoError(t, err, "%s as %s", v.Type(), target)
+				}
+				continue
+			}
+
+			require.EqualError(t, err, fmt.Sprintf("cannot cast %s as %s", v.Type(), target))
+		}
+	}
+}
+
+func TestCastLossless(t *testing.T) {
+	tests := []struct {
+		v      types.Value
+		target types.Type
+		want   types.Value
+		lossy  bool
+	}{
+		{types.NewDoubleValue(10), types.TypeInteger, types.NewIntegerValue(10), false},
+		{types.NewDoubleValue(10.5), types.TypeInteger, nil, true},
+		{types.NewBigintValue(1<<53 + 1), types.TypeDouble, nil, true},
+		{types.NewBigintValue(1 << 53), types.TypeDouble, types.NewDoubleValue(1 << 53), false},
+		{types.NewIntegerValue(10), types.TypeBoolean, nil, true},
+		{types.NewIntegerValue(1), types.TypeBoolean, types.NewBooleanValue(true), false},
+		{types.NewTextValue("10"), types.TypeInteger, types.NewIntegerValue(10), false},
+		{types.NewTextValue("10.5"), types.TypeInteger, nil, true},
+		{types.NewTextValue("2023-05-07"), types.TypeTimestamp, types.NewTimestampValue(time.Date(2023, 5, 7, 0, 0, 0, 0, time.UTC)), false},
+		{types.NewTimestampValue(time.Date(2023, 5, 7, 10, 0, 0, 0, time.UTC)), types.TypeDate, nil, true},
+		{types.NewDoubleValue(10.5), types.TypeText, types.NewTextValue("10.5"), false},
+		{types.NewNullValue(), types.TypeInteger, types.NewNullValue(), false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s as %s", test.v, test.target), func(t *testing.T) {
+			got, err := types.CastLossless(test.v, test.target)
+			if test.lossy {
+				require.ErrorIs(t, err, types.ErrLossyConversion)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package types
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = DateTypeDef{}
+
+type DateTypeDef struct{}
+
+func (DateTypeDef) New(v any) Value {
+	return NewDateValue(v.(time.Time))
+}
+
+func (DateTypeDef) Type() Type {
+	return TypeDate
+}
+
+func (t DateTypeDef) Decode(src []byte) (Value, int) {
+	d, n := encoding.DecodeDate(src)
+	return DateValue(d), n
+}
+
+func (DateTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeDate || other == TypeTimestamp || other == TypeText
+}
+
+func (DateTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeDate
+}
+
+var _ Value = NewDateValue(time.Time{})
+
+// DateValue is a calendar date, without time of the day nor timezone.
+// It is stored as midnight UTC.
+type DateValue time.Time
+
+// NewDateValue returns a SQL DATE value holding the date of x in UTC.
+func NewDateValue(x time.Time) DateValue {
+	y, m, d := x.UTC().Date()
+	return DateValue(time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+}
+
+func (v DateValue) V() any {
+	return time.Time(v)
+}
+
+func (v DateValue) Type() Type {
+	return TypeDate
+}
+
+func (v DateValue) TypeDef() TypeDefinition {
+	return DateTypeDef{}
+}
+
+func (v DateValue) IsZero() (bool, error) {
+	return time.Time(v).IsZero(), nil
+}
+
+func (v DateValue) String() string {
+	return strconv.Quote(time.Time(v).Format(time.DateOnly))
+}
+
+func (v DateValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v DateValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v DateValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeDate(dst, time.Time(v)), nil
+}
+
+func (v DateValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v DateValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeDate:
+		return v, nil
+	case TypeTimestamp:
+		return NewTimestampValue(time.Time(v)), nil
+	case TypeText:
+		return NewTextValue(time.Time(v).Format(time.DateOnly)), nil
+	}
+
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns -1, 0 or 1 if v is before, equal or after other.
+// Dates are compared to timestamps as midnight UTC and texts are parsed as dates.
+// ok is false if other is not comparable with a date.
+func (v DateValue) compare(other Value) (cmp int, ok bool, err error) {
+	var t time.Time
+	switch other.Type() {
+	case TypeDate, TypeTimestamp:
+		t = AsTime(other)
+	case TypeText:
+		t, err = ParseDate(AsString(other))
+		if err != nil {
+			return 0, false, err
+		}
+	default:
+		return 0, false, nil
+	}
+
+	return time.Time(v).Compare(t), true, nil
+}
+
+func (v DateValue) EQ(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp == 0, err
+}
+
+func (v DateValue) GT(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp > 0, err
+}
+
+func (v DateValue) GTE(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp >= 0, err
+}
+
+func (v DateValue) LT(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp < 0, err
+}
+
+func (v DateValue) LTE(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp <= 0, err
+}
+
+func (v DateValue) Between(a, b Value) (bool, error) {
+	if !a.Type().IsTimestampCompatible() || !b.Type().IsTimestampCompatible() {
+		return false, nil
+	}
+
+	ok, err := a.LTE(v)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return b.GTE(v)
+}
+
+// ParseDate parses s as a timestamp and returns its date.
+func ParseDate(s string) (time.Time, error) {
+	ts, err := ParseTimestamp(s)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date")
+	}
+
+	return time.Time(NewDateValue(ts)), nil
+}
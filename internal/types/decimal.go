@@ -0,0 +1,474 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/cockroachdb/errors"
+)
+
+// Limits of the DECIMAL type.
+const (
+	// MaxDecimalPrecision is the maximum precision of a DECIMAL(precision, scale) column.
+	MaxDecimalPrecision = 1000
+	// maxDecimalScale is the maximum number of fractional digits of a decimal.
+	maxDecimalScale = 16383
+	// maxDecimalDigits is the maximum number of digits of a decimal.
+	maxDecimalDigits = 131072 + maxDecimalScale
+	// decimalDivScale is the minimum number of fractional digits of a division.
+	decimalDivScale = 16
+)
+
+var errDecimalOutOfRange = errors.New("decimal out of range")
+
+var _ TypeDefinition = DecimalTypeDef{}
+
+type DecimalTypeDef struct{}
+
+func (DecimalTypeDef) New(v any) Value {
+	return v.(DecimalValue)
+}
+
+func (DecimalTypeDef) Type() Type {
+	return TypeDecimal
+}
+
+func (DecimalTypeDef) Decode(src []byte) (Value, int) {
+	unscaled, scale, n := encoding.DecodeDecimal(src)
+	return NewDecimalValue(unscaled, scale), n
+}
+
+func (DecimalTypeDef) IsComparableWith(other Type) bool {
+	return other.IsNumber()
+}
+
+// IsIndexComparableWith always returns false: decimals are sorted by value
+// in indexes, but equal decimals with a different number of fractional
+// digits, such as 1.5 and 1.50, have different encodings, so they can't be
+// looked up by key.
+func (DecimalTypeDef) IsIndexComparableWith(other Type) bool {
+	return false
+}
+
+var _ Numeric = NewDecimalValue(new(big.Int), 0)
+
+// DecimalValue is an exact number with an arbitrary precision,
+// stored as an unscaled integer and a scale, the number of fractional digits:
+// 12.50 has an unscaled value of 1250 and a scale of 2.
+// Decimal values are immutable.
+type DecimalValue struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimalValue returns the SQL DECIMAL value unscaled * 10^-scale.
+func NewDecimalValue(unscaled *big.Int, scale int) DecimalValue {
+	return DecimalValue{unscaled: unscaled, scale: scale}
+}
+
+func newDecimalFromInt64(x int64) DecimalValue {
+	return NewDecimalValue(big.NewInt(x), 0)
+}
+
+// newDecimalFromFloat64 returns the decimal with the shortest
+// representation that converts back to x.
+func newDecimalFromFloat64(x float64) (DecimalValue, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return DecimalValue{}, errs.TypeMismatchf("cannot cast %v as decimal", x)
+	}
+
+	return ParseDecimal(strconv.FormatFloat(x, 'f', -1, 64))
+}
+
+// newDecimalFromRat returns r rounded to scale fractional digits,
+// rounding half away from zero.
+func newDecimalFromRat(r *big.Rat, scale int) DecimalValue {
+	n := new(big.Int).Mul(r.Num(), pow10(scale))
+	q, m := new(big.Int).QuoRem(n, r.Denom(), new(big.Int))
+
+	m.Abs(m).Lsh(m, 1)
+	if m.Cmp(r.Denom()) >= 0 {
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return NewDecimalValue(q, scale)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// ParseDecimal parses a decimal number, with an optional exponent,
+// such as 12.50, -3, .5 or 1.2e3. The scale of the decimal is the
+// number of fractional digits of the number.
+func ParseDecimal(s string) (DecimalValue, error) {
+	mant, exp := strings.TrimSpace(s), 0
+	if i := strings.IndexAny(mant, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.Atoi(mant[i+1:])
+		if err != nil || exp > maxDecimalDigits || exp < -maxDecimalDigits {
+			return DecimalValue{}, errors.Errorf("invalid decimal %q", s)
+		}
+		mant = mant[:i]
+	}
+
+	neg := strings.HasPrefix(mant, "-")
+	if neg || strings.HasPrefix(mant, "+") {
+		mant = mant[1:]
+	}
+
+	intPart, frac, _ := strings.Cut(mant, ".")
+	digits := intPart + frac
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return DecimalValue{}, errors.Errorf("invalid decimal %q", s)
+	}
+
+	scale := len(frac) - exp
+	if scale < 0 {
+		digits += strings.Repeat("0", -scale)
+		scale = 0
+	}
+
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		unscaled.Neg(unscaled)
+	}
+
+	return checkDecimal(NewDecimalValue(unscaled, scale))
+}
+
+// checkDecimal returns an error if v has too many digits.
+func checkDecimal(v DecimalValue) (DecimalValue, error) {
+	if v.scale > maxDecimalScale || len(v.unscaled.Text(10)) > maxDecimalDigits+1 {
+		return DecimalValue{}, errDecimalOutOfRange
+	}
+
+	return v, nil
+}
+
+func (v DecimalValue) V() any {
+	return v.String()
+}
+
+func (v DecimalValue) Type() Type {
+	return TypeDecimal
+}
+
+func (v DecimalValue) TypeDef() TypeDefinition {
+	return DecimalTypeDef{}
+}
+
+func (v DecimalValue) IsZero() (bool, error) {
+	return v.unscaled.Sign() == 0, nil
+}
+
+// Scale returns the number of fractional digits of v.
+func (v DecimalValue) Scale() int {
+	return v.scale
+}
+
+// Rat returns v as a fraction.
+func (v DecimalValue) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(v.unscaled, pow10(v.scale))
+}
+
+// Float64 returns the nearest double to v.
+func (v DecimalValue) Float64() float64 {
+	f, _ := strconv.ParseFloat(v.String(), 64)
+	return f
+}
+
+// Round returns v rounded to scale fractional digits,
+// rounding half away from zero, or padded with zeros.
+func (v DecimalValue) Round(scale int) DecimalValue {
+	if scale >= v.scale {
+		return NewDecimalValue(new(big.Int).Mul(v.unscaled, pow10(scale-v.scale)), scale)
+	}
+
+	return newDecimalFromRat(v.Rat(), scale)
+}
+
+func (v DecimalValue) String() string {
+	s := new(big.Int).Abs(v.unscaled).Text(10)
+	if v.scale > 0 {
+		if len(s) <= v.scale {
+			s = strings.Repeat("0", v.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-v.scale] + "." + s[len(s)-v.scale:]
+	}
+	if v.unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+
+	return s
+}
+
+func (v DecimalValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v DecimalValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v DecimalValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeDecimal(dst, v.unscaled, v.scale), nil
+}
+
+func (v DecimalValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v DecimalValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeDecimal:
+		return v, nil
+	case TypeInteger:
+		i := v.Round(0).unscaled
+		if !i.IsInt64() || i.Int64() > math.MaxInt32 || i.Int64() < math.MinInt32 {
+			return nil, errors.New("integer out of range")
+		}
+		return NewIntegerValue(int32(i.Int64())), nil
+	case TypeBigint:
+		i := v.Round(0).unscaled
+		if !i.IsInt64() {
+			return nil, errors.New("bigint out of range")
+		}
+		return NewBigintValue(i.Int64()), nil
+	case TypeDouble:
+		return NewDoubleResult(v.Float64())
+	case TypeText:
+		return NewTextValue(v.String()), nil
+	}
+
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns -1, 0 or 1 if v is lower than, equal to or greater than other.
+// ok is false if other is not a number.
+func (v DecimalValue) compare(other Value) (cmp int, ok bool) {
+	var r *big.Rat
+	switch other.Type() {
+	case TypeDecimal:
+		r = other.(DecimalValue).Rat()
+	case TypeInteger, TypeBigint:
+		r = new(big.Rat).SetInt64(AsInt64(other))
+	case TypeDouble:
+		// doubles are compared using their shortest representation,
+		// so that 0.1 is equal to the decimal 0.1
+		d, err := newDecimalFromFloat64(AsFloat64(other))
+		if err != nil {
+			return 0, false
+		}
+		r = d.Rat()
+	default:
+		return 0, false
+	}
+
+	return v.Rat().Cmp(r), true
+}
+
+func (v DecimalValue) EQ(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp == 0, nil
+}
+
+func (v DecimalValue) GT(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp > 0, nil
+}
+
+func (v DecimalValue) GTE(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp >= 0, nil
+}
+
+func (v DecimalValue) LT(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp < 0, nil
+}
+
+func (v DecimalValue) LTE(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp <= 0, nil
+}
+
+func (v DecimalValue) Between(a, b Value) (bool, error) {
+	if !a.Type().IsNumber() || !b.Type().IsNumber() {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+// decimalOperand returns other as a decimal, or false if it is not
+// an integer or a decimal.
+func decimalOperand(other Numeric) (DecimalValue, bool) {
+	switch other.Type() {
+	case TypeDecimal:
+		return other.(DecimalValue), true
+	case TypeInteger, TypeBigint:
+		return newDecimalFromInt64(AsInt64(other)), true
+	}
+
+	return DecimalValue{}, false
+}
+
+// Add returns v + other. The scale of the result is the largest
+// of the two scales. If other is a double, the result is a double.
+func (v DecimalValue) Add(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		return NewDoubleResult(v.Float64() + AsFloat64(other))
+	}
+
+	d, ok := decimalOperand(other)
+	if !ok {
+		return NewNullValue(), nil
+	}
+
+	scale := max(v.scale, d.scale)
+	x := new(big.Int).Add(v.Round(scale).unscaled, d.Round(scale).unscaled)
+	return checkDecimal(NewDecimalValue(x, scale))
+}
+
+// Sub returns v - other. The scale of the result is the largest
+// of the two scales. If other is a double, the result is a double.
+func (v DecimalValue) Sub(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		return NewDoubleResult(v.Float64() - AsFloat64(other))
+	}
+
+	d, ok := decimalOperand(other)
+	if !ok {
+		return NewNullValue(), nil
+	}
+
+	scale := max(v.scale, d.scale)
+	x := new(big.Int).Sub(v.Round(scale).unscaled, d.Round(scale).unscaled)
+	return checkDecimal(NewDecimalValue(x, scale))
+}
+
+// Mul returns v * other. The scale of the result is the sum
+// of the two scales. If other is a double, the result is a double.
+func (v DecimalValue) Mul(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		return NewDoubleResult(v.Float64() * AsFloat64(other))
+	}
+
+	d, ok := decimalOperand(other)
+	if !ok {
+		return NewNullValue(), nil
+	}
+
+	x := new(big.Int).Mul(v.unscaled, d.unscaled)
+	return checkDecimal(NewDecimalValue(x, v.scale+d.scale))
+}
+
+// Div returns v / other, rounded to at least 16 fractional digits,
+// or to the largest of the two scales. Dividing by zero returns an error.
+// If other is a double, the result is a double.
+func (v DecimalValue) Div(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		xb := AsFloat64(other)
+		if xb == 0 {
+			return NewNullValue(), nil
+		}
+		return NewDoubleResult(v.Float64() / xb)
+	}
+
+	d, ok := decimalOperand(other)
+	if !ok {
+		return NewNullValue(), nil
+	}
+	if d.unscaled.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+
+	r := new(big.Rat).Quo(v.Rat(), d.Rat())
+	return checkDecimal(newDecimalFromRat(r, max(decimalDivScale, v.scale, d.scale)))
+}
+
+// Mod returns the remainder of v / other, which has the sign of v.
+// The scale of the result is the largest of the two scales.
+// Computing a modulo by zero returns NULL.
+// If other is a double, the result is a double.
+func (v DecimalValue) Mod(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		xr := math.Mod(v.Float64(), AsFloat64(other))
+		if math.IsNaN(xr) {
+			return NewNullValue(), nil
+		}
+		return NewDoubleValue(xr), nil
+	}
+
+	d, ok := decimalOperand(other)
+	if !ok || d.unscaled.Sign() == 0 {
+		return NewNullValue(), nil
+	}
+
+	scale := max(v.scale, d.scale)
+	x := new(big.Int).Rem(v.Round(scale).unscaled, d.Round(scale).unscaled)
+	return NewDecimalValue(x, scale), nil
+}
+
+// DecimalSpec is the precision and the scale of a DECIMAL(precision, scale)
+// column or cast. The precision is the maximum number of digits and the
+// scale the number of fractional digits. If the precision is zero, decimals
+// are kept as is.
+type DecimalSpec struct {
+	Precision int
+	Scale     int
+}
+
+// NewDecimalSpec returns the spec of DECIMAL(precision, scale).
+func NewDecimalSpec(precision, scale int) (DecimalSpec, error) {
+	if precision < 1 || precision > MaxDecimalPrecision {
+		return DecimalSpec{}, errors.Errorf("decimal precision %d must be between 1 and %d", precision, MaxDecimalPrecision)
+	}
+	if scale < 0 || scale > precision {
+		return DecimalSpec{}, errors.Errorf("decimal scale %d must be between 0 and precision %d", scale, precision)
+	}
+
+	return DecimalSpec{Precision: precision, Scale: scale}, nil
+}
+
+// String returns the modifiers of the type, such as (10, 2),
+// or an empty string if the precision is zero.
+func (s DecimalSpec) String() string {
+	if s.Precision == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("(%d, %d)", s.Precision, s.Scale)
+}
+
+// Apply rounds a decimal to the scale of s and returns an error if
+// it has more digits than allowed by the precision.
+// Other values are returned as is.
+func (s DecimalSpec) Apply(v Value) (Value, error) {
+	d, ok := v.(DecimalValue)
+	if !ok || s.Precision == 0 {
+		return v, nil
+	}
+
+	d = d.Round(s.Scale)
+	if d.unscaled.Sign() != 0 && len(new(big.Int).Abs(d.unscaled).Text(10)) > s.Precision {
+		return nil, errors.Errorf("decimal field overflow: %s does not fit in decimal%s", d, s)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,105 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		scale int
+		fails bool
+	}{
+		{"12.50", "12.50", 2, false},
+		{"-3", "-3", 0, false},
+		{"+3.0", "3.0", 1, false},
+		{".5", "0.5", 1, false},
+		{"5.", "5", 0, false},
+		{" 0.001 ", "0.001", 3, false},
+		{"1.2e3", "1200", 0, false},
+		{"1.5E-3", "0.0015", 4, false},
+		{"-0.05", "-0.05", 2, false},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", 9, false},
+		{"", "", 0, true},
+		{".", "", 0, true},
+		{"-", "", 0, true},
+		{"1.2.3", "", 0, true},
+		{"1e", "", 0, true},
+		{"abc", "", 0, true},
+		{"1e999999999", "", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			d, err := types.ParseDecimal(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, d.String())
+			require.Equal(t, test.scale, d.Scale())
+		})
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		s     string
+		scale int
+		want  string
+	}{
+		{"2.675", 2, "2.68"},
+		{"2.665", 2, "2.67"},
+		{"-2.675", 2, "-2.68"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"2.4", 0, "2"},
+		{"0.004", 2, "0.00"},
+		{"7", 2, "7.00"},
+		{"1.5", 1, "1.5"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			d, err := types.ParseDecimal(test.s)
+			require.NoError(t, err)
+			require.Equal(t, test.want, d.Round(test.scale).String())
+		})
+	}
+}
+
+func TestDecimalSpec(t *testing.T) {
+	spec, err := types.NewDecimalSpec(5, 2)
+	require.NoError(t, err)
+	require.Equal(t, "(5, 2)", spec.String())
+
+	d, _ := types.ParseDecimal("123.456")
+	v, err := spec.Apply(d)
+	require.NoError(t, err)
+	require.Equal(t, "123.46", v.String())
+
+	d, _ = types.ParseDecimal("999.995")
+	_, err = spec.Apply(d)
+	require.Error(t, err)
+
+	// other values and unconstrained decimals are kept as is
+	v, err = spec.Apply(types.NewNullValue())
+	require.NoError(t, err)
+	require.Equal(t, types.NewNullValue(), v)
+
+	v, err = types.DecimalSpec{}.Apply(d)
+	require.NoError(t, err)
+	require.Equal(t, "999.995", v.String())
+
+	_, err = types.NewDecimalSpec(0, 0)
+	require.Error(t, err)
+	_, err = types.NewDecimalSpec(1001, 0)
+	require.Error(t, err)
+	_, err = types.NewDecimalSpec(2, 3)
+	require.Error(t, err)
+}
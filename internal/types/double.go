@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/cockroachdb/errors"
 )
 
@@ -26,7 +27,7 @@ func (DoubleTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (DoubleTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeDouble || other == TypeInteger || other == TypeBigint
+	return other == TypeDouble || other == TypeInteger || other == TypeBigint || other == TypeDecimal
 }
 
 func (DoubleTypeDef) IsIndexComparableWith(other Type) bool {
@@ -112,16 +113,18 @@ func (v DoubleValue) CastAs(target Type) (Value, error) {
 		return v, nil
 	case TypeInteger:
 		f := float64(v)
-		if f > 0 && (int32(f) < 0 || f >= math.MaxInt32) {
+		if math.IsNaN(f) || f >= math.MaxInt32 || f < math.MinInt32 {
 			return nil, errors.New("integer out of range")
 		}
 		return NewIntegerValue(int32(v)), nil
 	case TypeBigint:
 		f := float64(v)
-		if f > 0 && (int64(f) < 0 || f >= math.MaxInt64) {
+		if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
 			return nil, errors.New("integer out of range")
 		}
 		return NewBigintValue(int64(v)), nil
+	case TypeDecimal:
+		return newDecimalFromFloat64(float64(v))
 	case TypeText:
 		enc, err := v.MarshalJSON()
 		if err != nil {
@@ -130,7 +133,7 @@ func (v DoubleValue) CastAs(target Type) (Value, error) {
 		return NewTextValue(string(enc)), nil
 	}
 
-	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
 }
 
 func (v DoubleValue) EQ(other Value) (bool, error) {
@@ -140,6 +143,8 @@ func (v DoubleValue) EQ(other Value) (bool, error) {
 		return float64(v) == AsFloat64(other), nil
 	case TypeInteger, TypeBigint:
 		return float64(v) == float64(AsInt64(other)), nil
+	case TypeDecimal:
+		return other.EQ(v)
 	default:
 		return false, nil
 	}
@@ -152,6 +157,8 @@ func (v DoubleValue) GT(other Value) (bool, error) {
 		return float64(v) > AsFloat64(other), nil
 	case TypeInteger, TypeBigint:
 		return float64(v) > float64(AsInt64(other)), nil
+	case TypeDecimal:
+		return other.LT(v)
 	default:
 		return false, nil
 	}
@@ -164,6 +171,8 @@ func (v DoubleValue) GTE(other Value) (bool, error) {
 		return float64(v) >= AsFloat64(other), nil
 	case TypeInteger, TypeBigint:
 		return float64(v) >= float64(AsInt64(other)), nil
+	case TypeDecimal:
+		return other.LTE(v)
 	default:
 		return false, nil
 	}
@@ -176,6 +185,8 @@ func (v DoubleValue) LT(other Value) (bool, error) {
 		return float64(v) < AsFloat64(other), nil
 	case TypeInteger, TypeBigint:
 		return float64(v) < float64(AsInt64(other)), nil
+	case TypeDecimal:
+		return other.GT(v)
 	default:
 		return false, nil
 	}
@@ -188,6 +199,8 @@ func (v DoubleValue) LTE(other Value) (bool, error) {
 		return float64(v) <= AsFloat64(other), nil
 	case TypeInteger, TypeBigint:
 		return float64(v) <= float64(AsInt64(other)), nil
+	case TypeDecimal:
+		return other.GTE(v)
 	default:
 		return false, nil
 	}
@@ -209,9 +222,11 @@ func (v DoubleValue) Between(a, b Value) (bool, error) {
 func (v DoubleValue) Add(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeInteger, TypeBigint:
-		return NewDoubleValue(float64(v) + float64(AsInt64(other))), nil
+		return NewDoubleResult(float64(v) + float64(AsInt64(other)))
 	case TypeDouble:
-		return NewDoubleValue(float64(v) + AsFloat64(other)), nil
+		return NewDoubleResult(float64(v) + AsFloat64(other))
+	case TypeDecimal:
+		return v.Add(NewDoubleValue(other.(DecimalValue).Float64()))
 	}
 
 	return NewNullValue(), nil
@@ -220,9 +235,11 @@ func (v DoubleValue) Add(other Numeric) (Value, error) {
 func (v DoubleValue) Sub(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeInteger, TypeBigint:
-		return NewDoubleValue(float64(v) - float64(AsInt64(other))), nil
+		return NewDoubleResult(float64(v) - float64(AsInt64(other)))
 	case TypeDouble:
-		return NewDoubleValue(float64(v) - AsFloat64(other)), nil
+		return NewDoubleResult(float64(v) - AsFloat64(other))
+	case TypeDecimal:
+		return v.Sub(NewDoubleValue(other.(DecimalValue).Float64()))
 	}
 
 	return NewNullValue(), nil
@@ -231,9 +248,11 @@ func (v DoubleValue) Sub(other Numeric) (Value, error) {
 func (v DoubleValue) Mul(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeInteger, TypeBigint:
-		return NewDoubleValue(float64(v) * float64(AsInt64(other))), nil
+		return NewDoubleResult(float64(v) * float64(AsInt64(other)))
 	case TypeDouble:
-		return NewDoubleValue(float64(v) * AsFloat64(other)), nil
+		return NewDoubleResult(float64(v) * AsFloat64(other))
+	case TypeDecimal:
+		return v.Mul(NewDoubleValue(other.(DecimalValue).Float64()))
 	}
 
 	return NewNullValue(), nil
@@ -247,14 +266,16 @@ func (v DoubleValue) Div(other Numeric) (Value, error) {
 			return NewNullValue(), nil
 		}
 
-		return NewDoubleValue(float64(v) / xb), nil
+		return NewDoubleResult(float64(v) / xb)
 	case TypeDouble:
 		xb := AsFloat64(other)
 		if xb == 0 {
 			return NewNullValue(), nil
 		}
 
-		return NewDoubleValue(float64(v) / xb), nil
+		return NewDoubleResult(float64(v) / xb)
+	case TypeDecimal:
+		return v.Div(NewDoubleValue(other.(DecimalValue).Float64()))
 	}
 
 	return NewNullValue(), nil
@@ -278,6 +299,8 @@ func (v DoubleValue) Mod(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(xr), nil
+	case TypeDecimal:
+		return v.Mod(NewDoubleValue(other.(DecimalValue).Float64()))
 	}
 
 	return NewNullValue(), nil
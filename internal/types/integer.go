@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/cockroachdb/errors"
 )
 
@@ -30,7 +31,7 @@ func (IntegerTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (IntegerTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeInteger || other == TypeBigint || other == TypeDouble
+	return other == TypeInteger || other == TypeBigint || other == TypeDouble || other == TypeDecimal
 }
 
 func (IntegerTypeDef) IsIndexComparableWith(other Type) bool {
@@ -94,11 +95,13 @@ func (v IntegerValue) CastAs(target Type) (Value, error) {
 		return NewBigintValue(int64(v)), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v)), nil
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
 
-	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
 }
 
 func (v IntegerValue) EQ(other Value) (bool, error) {
@@ -110,6 +113,8 @@ func (v IntegerValue) EQ(other Value) (bool, error) {
 		return int64(v) == AsInt64(other), nil
 	case TypeDouble:
 		return float64(int32(v)) == AsFloat64(other), nil
+	case TypeDecimal:
+		return other.EQ(v)
 	default:
 		return false, nil
 	}
@@ -124,6 +129,8 @@ func (v IntegerValue) GT(other Value) (bool, error) {
 		return int64(v) > AsInt64(other), nil
 	case TypeDouble:
 		return float64(int32(v)) > AsFloat64(other), nil
+	case TypeDecimal:
+		return other.LT(v)
 	default:
 		return false, nil
 	}
@@ -138,6 +145,8 @@ func (v IntegerValue) GTE(other Value) (bool, error) {
 		return int64(v) >= AsInt64(other), nil
 	case TypeDouble:
 		return float64(int32(v)) >= AsFloat64(other), nil
+	case TypeDecimal:
+		return other.LTE(v)
 	default:
 		return false, nil
 	}
@@ -152,6 +161,8 @@ func (v IntegerValue) LT(other Value) (bool, error) {
 		return int64(v) < AsInt64(other), nil
 	case TypeDouble:
 		return float64(int32(v)) <= AsFloat64(other), nil
+	case TypeDecimal:
+		return other.GT(v)
 	default:
 		return false, nil
 	}
@@ -166,6 +177,8 @@ func (v IntegerValue) LTE(other Value) (bool, error) {
 		return int64(v) <= AsInt64(other), nil
 	case TypeDouble:
 		return float64(int32(v)) <= AsFloat64(other), nil
+	case TypeDecimal:
+		return other.GTE(v)
 	default:
 		return false, nil
 	}
@@ -205,7 +218,9 @@ func (v IntegerValue) Add(other Numeric) (Value, error) {
 		xr := xa + xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
-		return NewDoubleValue(float64(int32(v)) + AsFloat64(other)), nil
+		return NewDoubleResult(float64(int32(v)) + AsFloat64(other))
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Add(other)
 	}
 
 	return NewNullValue(), nil
@@ -231,7 +246,9 @@ func (v IntegerValue) Sub(other Numeric) (Value, error) {
 		xr := xa - xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
-		return NewDoubleValue(float64(int32(v)) - AsFloat64(other)), nil
+		return NewDoubleResult(float64(int32(v)) - AsFloat64(other))
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Sub(other)
 	}
 
 	return NewNullValue(), nil
@@ -258,7 +275,9 @@ func (v IntegerValue) Mul(other Numeric) (Value, error) {
 		xr := xa * xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
-		return NewDoubleValue(float64(int32(v)) * AsFloat64(other)), nil
+		return NewDoubleResult(float64(int32(v)) * AsFloat64(other))
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Mul(other)
 	}
 
 	return NewNullValue(), nil
@@ -272,6 +291,9 @@ func (v IntegerValue) Div(other Numeric) (Value, error) {
 		if xb == 0 {
 			return nil, errors.New("division by zero")
 		}
+		if xa == math.MinInt32 && xb == -1 {
+			return nil, errors.New("integer out of range")
+		}
 
 		return NewIntegerValue(xa / xb), nil
 	case TypeBigint:
@@ -289,7 +311,9 @@ func (v IntegerValue) Div(other Numeric) (Value, error) {
 			return NewNullValue(), nil
 		}
 
-		return NewDoubleValue(xa / xb), nil
+		return NewDoubleResult(xa / xb)
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Div(other)
 	}
 
 	return NewNullValue(), nil
@@ -322,6 +346,8 @@ func (v IntegerValue) Mod(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(mod), nil
+	case TypeDecimal:
+		return newDecimalFromInt64(int64(v)).Mod(other)
 	}
 
 	return NewNullValue(), nil
@@ -0,0 +1,474 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = IntervalTypeDef{}
+
+type IntervalTypeDef struct{}
+
+func (IntervalTypeDef) New(v any) Value {
+	return v.(IntervalValue)
+}
+
+func (IntervalTypeDef) Type() Type {
+	return TypeInterval
+}
+
+func (t IntervalTypeDef) Decode(src []byte) (Value, int) {
+	months, days, micros, n := encoding.DecodeInterval(src)
+	return NewIntervalValue(months, days, micros), n
+}
+
+func (IntervalTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeInterval || other == TypeText
+}
+
+// IsIndexComparableWith always returns false: intervals are sorted by length
+// in indexes, but equal intervals can have different encodings, such as
+// '1 mon' and '30 days', so they can't be looked up by key.
+func (IntervalTypeDef) IsIndexComparableWith(other Type) bool {
+	return false
+}
+
+var _ Value = NewIntervalValue(0, 0, 0)
+
+// IntervalValue is a duration made of months, days and microseconds.
+// Months and days are kept apart because their length depends on the
+// timestamp they are added to. Intervals are compared by length,
+// counting 30 days per month and 24 hours per day, so that
+// '1 mon' is equal to '30 days'.
+type IntervalValue struct {
+	Months int32
+	Days   int32
+	Micros int64
+}
+
+// NewIntervalValue returns a SQL INTERVAL value.
+func NewIntervalValue(months, days int32, micros int64) IntervalValue {
+	return IntervalValue{Months: months, Days: days, Micros: micros}
+}
+
+// NewIntervalBetween returns the interval end - start,
+// in days and microseconds.
+func NewIntervalBetween(start, end time.Time) IntervalValue {
+	secs := end.Unix() - start.Unix()
+	days := secs / 86400
+	micros := secs%86400*1_000_000 + int64(end.Nanosecond()-start.Nanosecond())/1000
+
+	// days and microseconds have the same sign
+	switch {
+	case days > 0 && micros < 0:
+		days--
+		micros += encoding.DayMicros
+	case days < 0 && micros > 0:
+		days++
+		micros -= encoding.DayMicros
+	}
+
+	return NewIntervalValue(0, int32(days), micros)
+}
+
+func (v IntervalValue) V() any {
+	return v
+}
+
+func (v IntervalValue) Type() Type {
+	return TypeInterval
+}
+
+func (v IntervalValue) TypeDef() TypeDefinition {
+	return IntervalTypeDef{}
+}
+
+func (v IntervalValue) IsZero() (bool, error) {
+	return v.Months == 0 && v.Days == 0 && v.Micros == 0, nil
+}
+
+// Length returns the length of the interval in microseconds.
+func (v IntervalValue) Length() int64 {
+	return encoding.IntervalLength(v.Months, v.Days, v.Micros)
+}
+
+func (v IntervalValue) String() string {
+	return strconv.Quote(v.Format())
+}
+
+// Format returns the interval using the PostgreSQL format:
+//
+//	1 year 2 mons -3 days 04:05:06.789
+func (v IntervalValue) Format() string {
+	var parts []string
+	add := func(n int64, unit string) {
+		if n == 0 {
+			return
+		}
+		if n != 1 {
+			unit += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, unit))
+	}
+	add(int64(v.Months/12), "year")
+	add(int64(v.Months%12), "mon")
+	add(int64(v.Days), "day")
+
+	if v.Micros != 0 || len(parts) == 0 {
+		sign := ""
+		us := uint64(v.Micros)
+		if v.Micros < 0 {
+			sign = "-"
+			us = -us
+		}
+
+		const s = 1_000_000
+		clock := fmt.Sprintf("%s%02d:%02d:%02d", sign, us/(3600*s), us/(60*s)%60, us/s%60)
+		if us%s != 0 {
+			clock += fmt.Sprintf(".%06d", us%s)
+		}
+		parts = append(parts, clock)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (v IntervalValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v IntervalValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v IntervalValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeInterval(dst, v.Months, v.Days, v.Micros), nil
+}
+
+func (v IntervalValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v IntervalValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeInterval:
+		return v, nil
+	case TypeText:
+		return NewTextValue(v.Format()), nil
+	}
+
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns -1, 0 or 1 if v is shorter, as long or longer than other.
+// Texts are parsed as intervals.
+// ok is false if other is not comparable with an interval.
+func (v IntervalValue) compare(other Value) (cmp int, ok bool, err error) {
+	var iv IntervalValue
+	switch other.Type() {
+	case TypeInterval:
+		iv = other.(IntervalValue)
+	case TypeText:
+		iv, err = ParseInterval(AsString(other))
+		if err != nil {
+			return 0, false, err
+		}
+	default:
+		return 0, false, nil
+	}
+
+	a, b := v.Length(), iv.Length()
+	switch {
+	case a < b:
+		return -1, true, nil
+	case a > b:
+		return 1, true, nil
+	}
+
+	return 0, true, nil
+}
+
+func (v IntervalValue) EQ(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp == 0, err
+}
+
+func (v IntervalValue) GT(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp > 0, err
+}
+
+func (v IntervalValue) GTE(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp >= 0, err
+}
+
+func (v IntervalValue) LT(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp < 0, err
+}
+
+func (v IntervalValue) LTE(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp <= 0, err
+}
+
+func (v IntervalValue) Between(a, b Value) (bool, error) {
+	if !v.TypeDef().IsComparableWith(a.Type()) || !v.TypeDef().IsComparableWith(b.Type()) {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+var errIntervalOutOfRange = errors.New("interval out of range")
+
+// Add returns v + other.
+func (v IntervalValue) Add(other IntervalValue) (IntervalValue, error) {
+	months := int64(v.Months) + int64(other.Months)
+	days := int64(v.Days) + int64(other.Days)
+	if months > math.MaxInt32 || months < math.MinInt32 || days > math.MaxInt32 || days < math.MinInt32 ||
+		isAddOverflow(v.Micros, other.Micros, math.MinInt64, math.MaxInt64) {
+		return IntervalValue{}, errIntervalOutOfRange
+	}
+
+	return NewIntervalValue(int32(months), int32(days), v.Micros+other.Micros), nil
+}
+
+// Sub returns v - other.
+func (v IntervalValue) Sub(other IntervalValue) (IntervalValue, error) {
+	if other.Micros == math.MinInt64 {
+		return IntervalValue{}, errIntervalOutOfRange
+	}
+
+	return v.Add(NewIntervalValue(-other.Months, -other.Days, -other.Micros))
+}
+
+// Mul returns v multiplied by f. The fractional parts of the months
+// and days are carried over to the days and microseconds,
+// counting 30 days per month and 24 hours per day.
+func (v IntervalValue) Mul(f float64) (IntervalValue, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return IntervalValue{}, errIntervalOutOfRange
+	}
+
+	var b intervalBuilder
+	b.addMonths(float64(v.Months) * f)
+	b.addDays(float64(v.Days) * f)
+	b.addMicros(float64(v.Micros) * f)
+
+	return b.interval()
+}
+
+// Div returns v divided by f.
+func (v IntervalValue) Div(f float64) (IntervalValue, error) {
+	if f == 0 {
+		return IntervalValue{}, errors.New("division by zero")
+	}
+
+	return v.Mul(1 / f)
+}
+
+// AddTo returns the timestamp t + v. Months are added first, then days,
+// then microseconds. If the day of the month of t doesn't exist in the
+// resulting month, the last day of that month is used:
+// '2023-01-31' + '1 mon' is '2023-02-28'.
+func (v IntervalValue) AddTo(t time.Time) (time.Time, error) {
+	if v.Months != 0 {
+		y, m, d := t.Date()
+		hh, mm, ss := t.Clock()
+		first := time.Date(y, m+time.Month(v.Months), 1, hh, mm, ss, t.Nanosecond(), t.Location())
+
+		// the day 0 of the next month is the last day of this month
+		if last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day(); d > last {
+			d = last
+		}
+
+		t = first.AddDate(0, 0, d-1)
+	}
+
+	t = t.AddDate(0, 0, int(v.Days))
+	t = t.Add(time.Duration(v.Micros%encoding.DayMicros) * time.Microsecond)
+	// add whole days separately to avoid overflowing time.Duration
+	t = t.AddDate(0, 0, int(v.Micros/encoding.DayMicros))
+
+	if err := ValidateTimestamp(t); err != nil {
+		return time.Time{}, err
+	}
+
+	return t, nil
+}
+
+// intervalBuilder accumulates fractional quantities of months,
+// days and microseconds.
+type intervalBuilder struct {
+	months, days, micros float64
+}
+
+func (b *intervalBuilder) addMonths(f float64) {
+	whole := math.Trunc(f)
+	b.months += whole
+	b.addDays((f - whole) * 30)
+}
+
+func (b *intervalBuilder) addDays(f float64) {
+	whole := math.Trunc(f)
+	b.days += whole
+	b.addMicros((f - whole) * encoding.DayMicros)
+}
+
+func (b *intervalBuilder) addMicros(f float64) {
+	b.micros += f
+}
+
+func (b *intervalBuilder) interval() (IntervalValue, error) {
+	micros := math.Round(b.micros)
+	if b.months > math.MaxInt32 || b.months < math.MinInt32 ||
+		b.days > math.MaxInt32 || b.days < math.MinInt32 ||
+		micros >= math.MaxInt64 || micros <= math.MinInt64 {
+		return IntervalValue{}, errIntervalOutOfRange
+	}
+
+	return NewIntervalValue(int32(b.months), int32(b.days), int64(micros)), nil
+}
+
+// ParseInterval parses an interval made of quantities followed by
+// their unit and an optional time of the day, optionally followed by
+// 'ago' to negate it:
+//
+//	1 day
+//	2 hours 30 minutes
+//	1 year 2 mons 3 days 04:05:06.5
+//	1.5 weeks ago
+//
+// Fractional quantities are carried over to the smaller units,
+// counting 30 days per month and 24 hours per day.
+func ParseInterval(s string) (IntervalValue, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) > 0 && fields[0] == "@" {
+		fields = fields[1:]
+	}
+
+	ago := false
+	if len(fields) > 0 && fields[len(fields)-1] == "ago" {
+		ago = true
+		fields = fields[:len(fields)-1]
+	}
+
+	if len(fields) == 0 {
+		return IntervalValue{}, errors.New("invalid interval")
+	}
+
+	var b intervalBuilder
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		if strings.Contains(field, ":") {
+			micros, err := parseClock(field)
+			if err != nil {
+				return IntervalValue{}, err
+			}
+			b.addMicros(micros)
+			continue
+		}
+
+		// the unit can be attached to the quantity
+		num, unit := field, ""
+		if j := strings.IndexFunc(field, func(r rune) bool { return r >= 'a' && r <= 'z' }); j >= 0 {
+			num, unit = field[:j], field[j:]
+		} else if i+1 < len(fields) {
+			i++
+			unit = fields[i]
+		}
+
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil || unit == "" || math.IsInf(f, 0) {
+			return IntervalValue{}, errors.New("invalid interval")
+		}
+
+		switch unit {
+		case "microsecond", "microseconds", "usec", "usecs", "us":
+			b.addMicros(f)
+		case "millisecond", "milliseconds", "msec", "msecs", "ms":
+			b.addMicros(f * 1_000)
+		case "second", "seconds", "sec", "secs", "s":
+			b.addMicros(f * 1_000_000)
+		case "minute", "minutes", "min", "mins", "m":
+			b.addMicros(f * 60_000_000)
+		case "hour", "hours", "hr", "hrs", "h":
+			b.addMicros(f * 3600_000_000)
+		case "day", "days", "d":
+			b.addDays(f)
+		case "week", "weeks", "w":
+			b.addDays(f * 7)
+		case "month", "months", "mon", "mons":
+			b.addMonths(f)
+		case "year", "years", "yr", "yrs", "y":
+			b.addMonths(f * 12)
+		case "decade", "decades":
+			b.addMonths(f * 120)
+		case "century", "centuries":
+			b.addMonths(f * 1200)
+		case "millennium", "millennia", "millenniums":
+			b.addMonths(f * 12000)
+		default:
+			return IntervalValue{}, fmt.Errorf("invalid interval unit %q", unit)
+		}
+	}
+
+	iv, err := b.interval()
+	if err != nil || !ago {
+		return iv, err
+	}
+
+	return iv.Mul(-1)
+}
+
+// parseClock parses a time of the day of the form [-]hh:mm[:ss[.ffffff]]
+// and returns it in microseconds. Hours can be greater than 23.
+func parseClock(s string) (float64, error) {
+	sign := 1.0
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, errors.New("invalid interval")
+	}
+
+	var micros float64
+	units := []float64{3600_000_000, 60_000_000, 1_000_000}
+	for i, p := range parts {
+		var f float64
+		var err error
+		if i == 2 {
+			f, err = strconv.ParseFloat(p, 64)
+		} else {
+			var n int64
+			n, err = strconv.ParseInt(p, 10, 64)
+			f = float64(n)
+		}
+		if err != nil || f < 0 {
+			return 0, errors.New("invalid interval")
+		}
+		micros += f * units[i]
+	}
+
+	return sign * micros, nil
+}
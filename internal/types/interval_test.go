@@ -0,0 +1,132 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	const hour = 3600_000_000
+
+	tests := []struct {
+		s     string
+		want  types.IntervalValue
+		fails bool
+	}{
+		{"1 day", types.NewIntervalValue(0, 1, 0), false},
+		{"1 DAY", types.NewIntervalValue(0, 1, 0), false},
+		{"2 hours 30 minutes", types.NewIntervalValue(0, 0, 2*hour+hour/2), false},
+		{"1 year 2 mons 3 days 04:05:06.5", types.NewIntervalValue(14, 3, 4*hour+5*60_000_000+6_500_000), false},
+		{"-1 days -12:00:00", types.NewIntervalValue(0, -1, -12*hour), false},
+		{"1.5 days", types.NewIntervalValue(0, 1, 12*hour), false},
+		{"1.5 months", types.NewIntervalValue(1, 15, 0), false},
+		{"2 weeks", types.NewIntervalValue(0, 14, 0), false},
+		{"1 week ago", types.NewIntervalValue(0, -7, 0), false},
+		{"@ 3 days", types.NewIntervalValue(0, 3, 0), false},
+		{"10s", types.NewIntervalValue(0, 0, 10_000_000), false},
+		{"100ms", types.NewIntervalValue(0, 0, 100_000), false},
+		{"36:00", types.NewIntervalValue(0, 0, 36*hour), false},
+		{"1 century", types.NewIntervalValue(1200, 0, 0), false},
+		{"", types.IntervalValue{}, true},
+		{"day", types.IntervalValue{}, true},
+		{"1", types.IntervalValue{}, true},
+		{"1 fortnight", types.IntervalValue{}, true},
+		{"1:2:3:4", types.IntervalValue{}, true},
+		{"9999999999 years", types.IntervalValue{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			got, err := types.ParseInterval(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestIntervalFormat(t *testing.T) {
+	tests := []struct {
+		v    types.IntervalValue
+		want string
+	}{
+		{types.NewIntervalValue(0, 0, 0), "00:00:00"},
+		{types.NewIntervalValue(1, 0, 0), "1 mon"},
+		{types.NewIntervalValue(14, 3, 0), "1 year 2 mons 3 days"},
+		{types.NewIntervalValue(-14, -1, 0), "-1 years -2 mons -1 days"},
+		{types.NewIntervalValue(0, 1, -3600_000_000), "1 day -01:00:00"},
+		{types.NewIntervalValue(0, 0, 1_500_000), "00:00:01.500000"},
+		{types.NewIntervalValue(0, 0, 100*3600_000_000), "100:00:00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			require.Equal(t, test.want, test.v.Format())
+
+			// the output can be parsed back
+			v, err := types.ParseInterval(test.want)
+			require.NoError(t, err)
+			require.Equal(t, test.v, v)
+		})
+	}
+}
+
+func TestIntervalAddTo(t *testing.T) {
+	date := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return ts
+	}
+
+	tests := []struct {
+		t    string
+		v    types.IntervalValue
+		want string
+	}{
+		{"2023-01-01T00:00:00Z", types.NewIntervalValue(0, 1, 3600_000_000), "2023-01-02T01:00:00Z"},
+		{"2023-01-31T10:00:00Z", types.NewIntervalValue(1, 0, 0), "2023-02-28T10:00:00Z"},
+		{"2024-01-31T00:00:00Z", types.NewIntervalValue(1, 0, 0), "2024-02-29T00:00:00Z"},
+		{"2023-03-31T00:00:00Z", types.NewIntervalValue(-1, 0, 0), "2023-02-28T00:00:00Z"},
+		{"2023-01-15T00:00:00Z", types.NewIntervalValue(-13, 0, 0), "2021-12-15T00:00:00Z"},
+		{"2023-01-01T00:00:00Z", types.NewIntervalValue(0, 0, 400*24*3600_000_000), "2024-02-05T00:00:00Z"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.t+" + "+test.v.Format(), func(t *testing.T) {
+			got, err := test.v.AddTo(date(test.t))
+			require.NoError(t, err)
+			require.Equal(t, date(test.want), got)
+		})
+	}
+
+	_, err := types.NewIntervalValue(12*300000, 0, 0).AddTo(date("2023-01-01T00:00:00Z"))
+	require.Error(t, err)
+}
+
+func TestIntervalCompare(t *testing.T) {
+	month := types.NewIntervalValue(1, 0, 0)
+	days30 := types.NewIntervalValue(0, 30, 0)
+	day := types.NewIntervalValue(0, 1, 0)
+
+	ok, err := month.EQ(days30)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = month.GT(day)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = day.LT(types.NewTextValue("25 hours"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = day.EQ(types.NewIntegerValue(1))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -1,5 +1,21 @@
 package types
 
+import (
+	"math"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Numeric is implemented by the INTEGER, BIGINT, DOUBLE and DECIMAL values.
+//
+// Arithmetic follows these rules:
+//   - if both operands are integers, the result is an integer of the
+//     widest of the two types and an overflow returns an error;
+//   - if any operand is a double, the result is a double. Results that
+//     would be NaN or an infinity return an error;
+//   - otherwise, if any operand is a decimal, the result is an exact decimal;
+//   - dividing an integer or a decimal by the integer or decimal zero returns
+//     an error, dividing by the double zero and computing a modulo by zero return NULL.
 type Numeric interface {
 	Value
 
@@ -16,7 +32,7 @@ type Numeric interface {
 	// Only numeric values and booleans can be calculated together.
 	// If both v and u are integers, the result will be an integer.
 	Div(other Numeric) (Value, error)
-	// Mod calculates v / u and returns the result.
+	// Mod calculates v % u and returns the result.
 	// Only numeric values and booleans can be calculated together.
 	// If both v and u are integers, the result will be an integer.
 	Mod(other Numeric) (Value, error)
@@ -37,6 +53,18 @@ type Integral interface {
 	BitwiseXor(other Numeric) (Value, error)
 }
 
+// NewDoubleResult returns x as a DOUBLE value.
+// Arithmetic on doubles never yields NaN or an infinity: if x overflowed
+// or is not a number, an error is returned instead, the same way integer
+// overflows are reported.
+func NewDoubleResult(x float64) (Value, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil, errors.New("double out of range")
+	}
+
+	return NewDoubleValue(x), nil
+}
+
 func isMulOverflow[T int32 | int64](left, right, min, max T) bool {
 	if right > 0 {
 		if left > max/right {
@@ -2,11 +2,12 @@ package types
 
 import (
 	"encoding/base64"
-	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
 	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/cockroachdb/errors"
 )
 
@@ -28,7 +29,7 @@ func (TextTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (TextTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeNull || other == TypeText || other == TypeBoolean || other == TypeInteger || other == TypeBigint || other == TypeDouble || other == TypeTimestamp || other == TypeBlob
+	return other == TypeNull || other == TypeText || other == TypeBoolean || other == TypeInteger || other == TypeBigint || other == TypeDouble || other == TypeDecimal || other == TypeTimestamp || other == TypeDate || other == TypeInterval || other == TypeBlob || other == TypeUUID || other == TypeVector
 }
 
 func (t TextTypeDef) IsIndexComparableWith(other Type) bool {
@@ -87,54 +88,101 @@ func (v TextValue) CastAs(target Type) (Value, error) {
 	case TypeBoolean:
 		b, err := strconv.ParseBool(string(v))
 		if err != nil {
-			return nil, errors.Errorf(`cannot cast %q as bool: %w`, v.V(), err)
+			return nil, errs.TypeMismatchf(`cannot cast %q as bool: %w`, v.V(), err)
 		}
 		return NewBooleanValue(b), nil
 	case TypeInteger:
-		i, err := strconv.ParseInt(string(v), 10, 32)
+		i, err := v.parseInteger(math.MinInt32, math.MaxInt32)
 		if err != nil {
-			intErr := err
-			f, err := strconv.ParseFloat(string(v), 64)
-			if err != nil {
-				return nil, errors.Errorf(`cannot cast %q as integer: %w`, v.V(), intErr)
-			}
-			i = int64(f)
+			return nil, errs.TypeMismatchf(`cannot cast %q as integer: %w`, v.V(), err)
 		}
 		return NewIntegerValue(int32(i)), nil
 	case TypeBigint:
-		i, err := strconv.ParseInt(string(v), 10, 64)
+		i, err := v.parseInteger(math.MinInt64, math.MaxInt64)
 		if err != nil {
-			intErr := err
-			f, err := strconv.ParseFloat(string(v), 64)
-			if err != nil {
-				return nil, fmt.Errorf(`cannot cast %q as bigint: %w`, v.V(), intErr)
-			}
-			i = int64(f)
+			return nil, errs.TypeMismatchf(`cannot cast %q as bigint: %w`, v.V(), err)
 		}
 		return NewBigintValue(i), nil
 	case TypeDouble:
 		f, err := strconv.ParseFloat(string(v), 64)
 		if err != nil {
-			return nil, fmt.Errorf(`cannot cast %q as double: %w`, v.V(), err)
+			return nil, errs.TypeMismatchf(`cannot cast %q as double: %w`, v.V(), err)
 		}
 		return NewDoubleValue(f), nil
+	case TypeDecimal:
+		d, err := ParseDecimal(string(v))
+		if err != nil {
+			return nil, errs.TypeMismatchf(`cannot cast %q as decimal: %w`, v.V(), err)
+		}
+		return d, nil
 	case TypeTimestamp:
 		t, err := ParseTimestamp(string(v))
 		if err != nil {
-			return nil, fmt.Errorf(`cannot cast %q as timestamp: %w`, v.V(), err)
+			return nil, errs.TypeMismatchf(`cannot cast %q as timestamp: %w`, v.V(), err)
 		}
 		return NewTimestampValue(t), nil
+	case TypeDate:
+		t, err := ParseDate(string(v))
+		if err != nil {
+			return nil, errs.TypeMismatchf(`cannot cast %q as date: %w`, v.V(), err)
+		}
+		return NewDateValue(t), nil
+	case TypeInterval:
+		iv, err := ParseInterval(string(v))
+		if err != nil {
+			return nil, errs.TypeMismatchf(`cannot cast %q as interval: %w`, v.V(), err)
+		}
+		return iv, nil
+	case TypeUUID:
+		u, err := ParseUUID(string(v))
+		if err != nil {
+			return nil, errs.TypeMismatchf(`cannot cast %q as uuid: %w`, v.V(), err)
+		}
+		return u, nil
+	case TypeVector:
+		vv, err := ParseVector(string(v))
+		if err != nil {
+			return nil, errs.TypeMismatchf(`cannot cast %q as vector: %w`, v.V(), err)
+		}
+		return vv, nil
 	case TypeBlob:
 		s := string(v)
 		b, err := base64.StdEncoding.DecodeString(s)
 		if err != nil {
-			return nil, err
+			return nil, errs.TypeMismatchf(`cannot cast %q as blob: %w`, v.V(), err)
 		}
 
 		return NewBlobValue(b), nil
 	}
 
-	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
+}
+
+// parseInteger parses the text as an integer between min and max.
+// Texts representing floating point numbers are truncated.
+func (v TextValue) parseInteger(min, max int64) (int64, error) {
+	i, err := strconv.ParseInt(string(v), 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, errors.New("out of range")
+		}
+
+		intErr := err
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return 0, intErr
+		}
+		if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+			return 0, errors.New("out of range")
+		}
+		i = int64(f)
+	}
+
+	if i < min || i > max {
+		return 0, errors.New("out of range")
+	}
+
+	return i, nil
 }
 
 func (v TextValue) EQ(other Value) (bool, error) {
@@ -148,6 +196,9 @@ func (v TextValue) EQ(other Value) (bool, error) {
 			return false, err
 		}
 		return ts.Equal(AsTime(other)), nil
+	case TypeDate, TypeInterval, TypeUUID, TypeVector:
+		// texts are compared to dates, intervals, UUIDs and vectors as such
+		return other.EQ(v)
 	default:
 		return false, nil
 	}
@@ -164,6 +215,8 @@ func (v TextValue) GT(other Value) (bool, error) {
 			return false, err
 		}
 		return ts.After(AsTime(other)), nil
+	case TypeDate, TypeInterval, TypeUUID:
+		return other.LT(v)
 	default:
 		return false, nil
 	}
@@ -181,6 +234,8 @@ func (v TextValue) GTE(other Value) (bool, error) {
 		}
 		t2 := AsTime(other)
 		return t1.After(t2) || t1.Equal(t2), nil
+	case TypeDate, TypeInterval, TypeUUID:
+		return other.LTE(v)
 	default:
 		return false, nil
 	}
@@ -197,6 +252,8 @@ func (v TextValue) LT(other Value) (bool, error) {
 			return false, err
 		}
 		return ts.Before(AsTime(other)), nil
+	case TypeDate, TypeInterval, TypeUUID:
+		return other.GT(v)
 	default:
 		return false, nil
 	}
@@ -214,6 +271,8 @@ func (v TextValue) LTE(other Value) (bool, error) {
 		}
 		t2 := AsTime(other)
 		return t1.Before(t2) || t1.Equal(t2), nil
+	case TypeDate, TypeInterval, TypeUUID:
+		return other.GTE(v)
 	default:
 		return false, nil
 	}
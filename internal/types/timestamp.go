@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/cockroachdb/errors"
 	"github.com/golang-module/carbon/v2"
 )
@@ -28,7 +29,7 @@ func (t TimestampTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (TimestampTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeTimestamp || other == TypeText
+	return other == TypeTimestamp || other == TypeDate || other == TypeText
 }
 
 func (TimestampTypeDef) IsIndexComparableWith(other Type) bool {
@@ -90,17 +91,19 @@ func (v TimestampValue) CastAs(target Type) (Value, error) {
 	switch target {
 	case TypeTimestamp:
 		return v, nil
+	case TypeDate:
+		return NewDateValue(time.Time(v)), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
 
-	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
 }
 
 func (v TimestampValue) EQ(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		return time.Time(v).Equal(AsTime(other)), nil
 	case TypeText:
 		ts, err := ParseTimestamp(AsString(other))
@@ -116,7 +119,7 @@ func (v TimestampValue) EQ(other Value) (bool, error) {
 func (v TimestampValue) GT(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		return time.Time(v).After(AsTime(other)), nil
 	case TypeText:
 		ts, err := ParseTimestamp(AsString(other))
@@ -132,7 +135,7 @@ func (v TimestampValue) GT(other Value) (bool, error) {
 func (v TimestampValue) GTE(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		ta := time.Time(v)
 		tb := AsTime(other)
 		return ta.After(tb) || ta.Equal(tb), nil
@@ -152,7 +155,7 @@ func (v TimestampValue) GTE(other Value) (bool, error) {
 func (v TimestampValue) LT(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		return time.Time(v).Before(AsTime(other)), nil
 	case TypeText:
 		ts, err := ParseTimestamp(AsString(other))
@@ -168,7 +171,7 @@ func (v TimestampValue) LT(other Value) (bool, error) {
 func (v TimestampValue) LTE(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		ta := time.Time(v)
 		tb := AsTime(other)
 		return ta.Before(tb) || ta.Equal(tb), nil
@@ -205,10 +208,25 @@ func ParseTimestamp(s string) (time.Time, error) {
 	}
 
 	ts := c.ToStdTime()
+	if err := ValidateTimestamp(ts); err != nil {
+		return time.Time{}, err
+	}
+
+	return ts, nil
+}
+
+// ValidateTimestamp returns an error if ts is outside of the range
+// of values that can be stored in a TIMESTAMP.
+func ValidateTimestamp(ts time.Time) error {
+	// guard against years for which UnixMicro overflows
+	if y := ts.Year(); y < -290000 || y > 294000 {
+		return errors.New("timestamp out of range")
+	}
+
 	m := ts.UnixMicro()
 	if m > maxTime || m < minTime {
-		return time.Time{}, errors.New("timestamp out of range")
+		return errors.New("timestamp out of range")
 	}
 
-	return ts, nil
+	return nil
 }
@@ -25,9 +25,14 @@ const (
 	TypeInteger
 	TypeBigint
 	TypeDouble
+	TypeDecimal
 	TypeTimestamp
+	TypeDate
+	TypeInterval
 	TypeText
 	TypeBlob
+	TypeUUID
+	TypeVector
 )
 
 func (t Type) Def() TypeDefinition {
@@ -42,12 +47,22 @@ func (t Type) Def() TypeDefinition {
 		return BigintTypeDef{}
 	case TypeDouble:
 		return DoubleTypeDef{}
+	case TypeDecimal:
+		return DecimalTypeDef{}
 	case TypeTimestamp:
 		return TimestampTypeDef{}
+	case TypeDate:
+		return DateTypeDef{}
+	case TypeInterval:
+		return IntervalTypeDef{}
 	case TypeText:
 		return TextTypeDef{}
 	case TypeBlob:
 		return BlobTypeDef{}
+	case TypeUUID:
+		return UUIDTypeDef{}
+	case TypeVector:
+		return VectorTypeDef{}
 	}
 
 	return nil
@@ -65,12 +80,22 @@ func (t Type) String() string {
 		return "bigint"
 	case TypeDouble:
 		return "double"
+	case TypeDecimal:
+		return "decimal"
 	case TypeTimestamp:
 		return "timestamp"
+	case TypeDate:
+		return "date"
+	case TypeInterval:
+		return "interval"
 	case TypeBlob:
 		return "blob"
 	case TypeText:
 		return "text"
+	case TypeUUID:
+		return "uuid"
+	case TypeVector:
+		return "vector"
 	}
 
 	panic(fmt.Sprintf("unsupported type %#v", t))
@@ -88,11 +113,11 @@ func (t Type) MinEnctype() byte {
 		return encoding.Int64Value
 	case TypeDouble:
 		return encoding.Float64Value
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		return encoding.Int64Value
 	case TypeText:
 		return encoding.TextValue
-	case TypeBlob:
+	case TypeBlob, TypeDecimal, TypeInterval, TypeUUID, TypeVector:
 		return encoding.BlobValue
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
@@ -111,11 +136,11 @@ func (t Type) MinEnctypeDesc() byte {
 		return encoding.DESC_Uint64Value
 	case TypeDouble:
 		return encoding.DESC_Float64Value
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		return encoding.DESC_Uint64Value
 	case TypeText:
 		return encoding.DESC_TextValue
-	case TypeBlob:
+	case TypeBlob, TypeDecimal, TypeInterval, TypeUUID, TypeVector:
 		return encoding.DESC_BlobValue
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
@@ -134,11 +159,11 @@ func (t Type) MaxEnctype() byte {
 		return encoding.Uint64Value + 1
 	case TypeDouble:
 		return encoding.Float64Value + 1
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		return encoding.Uint64Value + 1
 	case TypeText:
 		return encoding.TextValue + 1
-	case TypeBlob:
+	case TypeBlob, TypeDecimal, TypeInterval, TypeUUID, TypeVector:
 		return encoding.BlobValue + 1
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
@@ -155,29 +180,29 @@ func (t Type) MaxEnctypeDesc() byte {
 		return encoding.DESC_Int64Value + 1
 	case TypeDouble:
 		return encoding.DESC_Float64Value + 1
-	case TypeTimestamp:
+	case TypeTimestamp, TypeDate:
 		return encoding.DESC_Int64Value + 1
 	case TypeText:
 		return encoding.DESC_TextValue + 1
-	case TypeBlob:
+	case TypeBlob, TypeDecimal, TypeInterval, TypeUUID, TypeVector:
 		return encoding.DESC_BlobValue + 1
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
 }
 
-// IsNumber returns true if t is either an integer or a float.
+// IsNumber returns true if t is either an integer, a float or a decimal.
 func (t Type) IsNumber() bool {
-	return t == TypeInteger || t == TypeBigint || t == TypeDouble
+	return t == TypeInteger || t == TypeBigint || t == TypeDouble || t == TypeDecimal
 }
 
 func (t Type) IsInteger() bool {
 	return t == TypeInteger || t == TypeBigint
 }
 
-// IsTimestampCompatible returns true if t is either a timestamp, an integer, or a text.
+// IsTimestampCompatible returns true if t is either a timestamp, a date, or a text.
 func (t Type) IsTimestampCompatible() bool {
-	return t == TypeTimestamp || t == TypeText
+	return t == TypeTimestamp || t == TypeDate || t == TypeText
 }
 
 func (t Type) IsComparableWith(other Type) bool {
@@ -196,6 +221,53 @@ func (t Type) IsComparableWith(other Type) bool {
 	return false
 }
 
+// CanCastAs returns whether values of type t can be cast as the target type.
+// It doesn't guarantee that every value can be converted: texts must be
+// parsable and numbers must be within the range of the target type.
+// Values of other types fail with a "cannot cast" error.
+//
+//	from \ to  bool int bigint double decimal ts date interval text blob uuid vector
+//	boolean     x    x   x                                        x
+//	integer     x    x   x      x      x                          x
+//	bigint      x    x   x      x      x                          x
+//	double           x   x      x      x                          x
+//	decimal          x   x      x      x                          x
+//	timestamp                                 x  x                x
+//	date                                      x  x                x
+//	interval                                          x           x
+//	text        x    x   x      x      x      x  x    x           x    x    x    x
+//	blob                                                          x    x    x    x
+//	uuid                                                          x    x    x
+//	vector                                                        x    x         x
+//
+// NULL can be cast as any type.
+func (t Type) CanCastAs(target Type) bool {
+	if t == target || t == TypeNull || t == TypeAny || target == TypeAny {
+		return true
+	}
+
+	switch t {
+	case TypeBoolean:
+		return target == TypeInteger || target == TypeBigint || target == TypeText
+	case TypeInteger, TypeBigint:
+		return target == TypeBoolean || target.IsNumber() || target == TypeText
+	case TypeDouble, TypeDecimal:
+		return target.IsNumber() || target == TypeText
+	case TypeTimestamp, TypeDate:
+		return target == TypeTimestamp || target == TypeDate || target == TypeText
+	case TypeInterval:
+		return target == TypeText
+	case TypeText:
+		return target != TypeNull
+	case TypeBlob:
+		return target == TypeText || target == TypeUUID || target == TypeVector
+	case TypeUUID, TypeVector:
+		return target == TypeText || target == TypeBlob
+	}
+
+	return false
+}
+
 // IsAny returns whether this is type is Any or a real type
 func (t Type) IsAny() bool {
 	return t == TypeAny
@@ -0,0 +1,221 @@
+package types
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = UUIDTypeDef{}
+
+type UUIDTypeDef struct{}
+
+func (UUIDTypeDef) New(v any) Value {
+	return NewUUIDValue(v.([16]byte))
+}
+
+func (UUIDTypeDef) Type() Type {
+	return TypeUUID
+}
+
+func (UUIDTypeDef) Decode(src []byte) (Value, int) {
+	x, n := encoding.DecodeBlob(src)
+	return NewUUIDValue([16]byte(x)), n
+}
+
+func (UUIDTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeUUID || other == TypeText
+}
+
+func (UUIDTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeUUID
+}
+
+var _ Value = NewUUIDValue([16]byte{})
+
+// UUIDValue is a 16 bytes universally unique identifier.
+// UUIDs are sorted by their bytes, which is the order of their
+// textual representation.
+type UUIDValue [16]byte
+
+// NewUUIDValue returns a SQL UUID value.
+func NewUUIDValue(x [16]byte) UUIDValue {
+	return UUIDValue(x)
+}
+
+// NewRandomUUIDValue returns a random UUID, as defined by the version 4
+// of RFC 4122.
+func NewRandomUUIDValue() (UUIDValue, error) {
+	var u UUIDValue
+	_, err := rand.Read(u[:])
+	if err != nil {
+		return u, errors.WithStack(err)
+	}
+
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+func (v UUIDValue) V() any {
+	return [16]byte(v)
+}
+
+func (v UUIDValue) Type() Type {
+	return TypeUUID
+}
+
+func (v UUIDValue) TypeDef() TypeDefinition {
+	return UUIDTypeDef{}
+}
+
+func (v UUIDValue) IsZero() (bool, error) {
+	return v == UUIDValue{}, nil
+}
+
+func (v UUIDValue) String() string {
+	return strconv.Quote(v.Format())
+}
+
+// Format returns the UUID in its canonical form:
+//
+//	xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func (v UUIDValue) Format() string {
+	var dst [36]byte
+	hex.Encode(dst[:8], v[:4])
+	dst[8] = '-'
+	hex.Encode(dst[9:13], v[4:6])
+	dst[13] = '-'
+	hex.Encode(dst[14:18], v[6:8])
+	dst[18] = '-'
+	hex.Encode(dst[19:23], v[8:10])
+	dst[23] = '-'
+	hex.Encode(dst[24:], v[10:])
+	return string(dst[:])
+}
+
+func (v UUIDValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v UUIDValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v UUIDValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeBlob(dst, v[:]), nil
+}
+
+func (v UUIDValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v UUIDValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeUUID:
+		return v, nil
+	case TypeText:
+		return NewTextValue(v.Format()), nil
+	case TypeBlob:
+		return NewBlobValue(bytes.Clone(v[:])), nil
+	}
+
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns -1, 0 or 1 if v sorts before, equal or after other.
+// Texts are parsed as UUIDs.
+// ok is false if other is not comparable with a UUID.
+func (v UUIDValue) compare(other Value) (cmp int, ok bool, err error) {
+	var u UUIDValue
+	switch other.Type() {
+	case TypeUUID:
+		u = other.(UUIDValue)
+	case TypeText:
+		u, err = ParseUUID(AsString(other))
+		if err != nil {
+			return 0, false, err
+		}
+	default:
+		return 0, false, nil
+	}
+
+	return bytes.Compare(v[:], u[:]), true, nil
+}
+
+func (v UUIDValue) EQ(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp == 0, err
+}
+
+func (v UUIDValue) GT(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp > 0, err
+}
+
+func (v UUIDValue) GTE(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp >= 0, err
+}
+
+func (v UUIDValue) LT(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp < 0, err
+}
+
+func (v UUIDValue) LTE(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp <= 0, err
+}
+
+func (v UUIDValue) Between(a, b Value) (bool, error) {
+	if !v.TypeDef().IsComparableWith(a.Type()) || !v.TypeDef().IsComparableWith(b.Type()) {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+var errInvalidUUID = errors.New("invalid UUID")
+
+// ParseUUID parses a UUID made of 32 hexadecimal digits, in any case,
+// optionally grouped by hyphens as in the canonical form,
+// enclosed in braces or prefixed by urn:uuid:.
+func ParseUUID(s string) (UUIDValue, error) {
+	var u UUIDValue
+
+	if len(s) > 9 && strings.EqualFold(s[:9], "urn:uuid:") {
+		s = s[9:]
+	} else if len(s) > 2 && s[0] == '{' && s[len(s)-1] == '}' {
+		s = s[1 : len(s)-1]
+	}
+
+	switch len(s) {
+	case 32:
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, errInvalidUUID
+		}
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	default:
+		return u, errInvalidUUID
+	}
+
+	_, err := hex.Decode(u[:], []byte(s))
+	if err != nil {
+		return u, errInvalidUUID
+	}
+
+	return u, nil
+}
@@ -0,0 +1,83 @@
+package types_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUUID(t *testing.T) {
+	const want = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+	tests := []struct {
+		s     string
+		fails bool
+	}{
+		{"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", false},
+		{"A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11", false},
+		{"a0eebc999c0b4ef8bb6d6bb9bd380a11", false},
+		{"{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11}", false},
+		{"urn:uuid:a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", false},
+		{"", true},
+		{"{}", true},
+		{"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a1", true},
+		{"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a111", true},
+		{"a0eebc999-c0b-4ef8-bb6d-6bb9bd380a11", true},
+		{"g0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", true},
+		{"{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			u, err := types.ParseUUID(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, want, u.Format())
+		})
+	}
+}
+
+func TestNewRandomUUIDValue(t *testing.T) {
+	a, err := types.NewRandomUUIDValue()
+	require.NoError(t, err)
+	b, err := types.NewRandomUUIDValue()
+	require.NoError(t, err)
+	require.NotEqual(t, a, b)
+
+	// version 4, variant 10
+	s := a.Format()
+	require.Equal(t, byte('4'), s[14])
+	require.Contains(t, "89ab", string(s[19]))
+}
+
+func TestUUIDEncodingOrder(t *testing.T) {
+	uuids := []string{
+		"00000000-0000-0000-0000-000000000000",
+		"00000000-0000-0000-0000-000000000001",
+		"0fffffff-ffff-ffff-ffff-ffffffffffff",
+		"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		"ffffffff-ffff-ffff-ffff-ffffffffffff",
+	}
+
+	var prev []byte
+	for _, s := range uuids {
+		u, err := types.ParseUUID(s)
+		require.NoError(t, err)
+
+		enc, err := u.EncodeAsKey(nil)
+		require.NoError(t, err)
+		require.Positive(t, bytes.Compare(enc, prev), s)
+
+		v, n := types.TypeUUID.Def().Decode(enc)
+		require.Equal(t, len(enc), n)
+		require.Equal(t, u, v)
+
+		prev = enc
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"time"
+
+	"github.com/cockroachdb/errors"
 )
 
 type Value interface {
@@ -105,6 +107,53 @@ func IsTruthy(v Value) (bool, error) {
 	return !b, err
 }
 
+// ErrLossyConversion is returned by CastLossless
+// when a conversion loses information.
+var ErrLossyConversion = errors.New("lossy conversion")
+
+// CastLossless converts v to the target type like CastAs, but returns
+// ErrLossyConversion if the conversion loses information, such as the
+// fractional part of a double converted to an integer or the time
+// of a timestamp converted to a date.
+// A conversion is lossless if converting the result back to the type
+// of v gives a value equal to v. Texts are parsed rather than converted,
+// only the texts converted to numbers are checked, against the number
+// they represent.
+func CastLossless(v Value, target Type) (Value, error) {
+	c, err := v.CastAs(target)
+	if err != nil {
+		return nil, err
+	}
+
+	src := v
+	switch {
+	case v.Type() == target || c.Type() == TypeNull:
+		return c, nil
+	case v.Type() == TypeText && target.IsNumber():
+		src, err = v.CastAs(TypeDouble)
+		if err != nil {
+			return nil, err
+		}
+	case v.Type() == TypeText:
+		return c, nil
+	}
+
+	back, err := c.CastAs(src.Type())
+	if err != nil {
+		return nil, ErrLossyConversion
+	}
+
+	ok, err := back.EQ(src)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLossyConversion
+	}
+
+	return c, nil
+}
+
 // ValueScanner implements the sql.Scanner interface for Value.
 // The src value will be of one of the following types:
 //
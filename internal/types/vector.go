@@ -0,0 +1,318 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/cockroachdb/errors"
+)
+
+// MaxVectorDimension is the maximum number of components
+// of a vector.
+const MaxVectorDimension = 16000
+
+var _ TypeDefinition = VectorTypeDef{}
+
+type VectorTypeDef struct{}
+
+func (VectorTypeDef) New(v any) Value {
+	return NewVectorValue(v.([]float32))
+}
+
+func (VectorTypeDef) Type() Type {
+	return TypeVector
+}
+
+func (VectorTypeDef) Decode(src []byte) (Value, int) {
+	x, n := encoding.DecodeBlob(src)
+	return decodeVector(x), n
+}
+
+func (VectorTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeVector || other == TypeText
+}
+
+func (VectorTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeVector
+}
+
+var _ Value = NewVectorValue(nil)
+
+// VectorValue is a list of single precision floating point numbers.
+// Vectors can only be compared for equality, they are not ordered.
+type VectorValue []float32
+
+// NewVectorValue returns a SQL VECTOR value.
+func NewVectorValue(x []float32) VectorValue {
+	return VectorValue(x)
+}
+
+func (v VectorValue) V() any {
+	return []float32(v)
+}
+
+func (v VectorValue) Type() Type {
+	return TypeVector
+}
+
+func (v VectorValue) TypeDef() TypeDefinition {
+	return VectorTypeDef{}
+}
+
+func (v VectorValue) IsZero() (bool, error) {
+	for _, x := range v {
+		if x != 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (v VectorValue) String() string {
+	return strconv.Quote(v.Format())
+}
+
+// Format returns the components of the vector between brackets:
+//
+//	[1, 2.5, -3]
+func (v VectorValue) Format() string {
+	var s strings.Builder
+
+	s.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	s.WriteByte(']')
+
+	return s.String()
+}
+
+func (v VectorValue) MarshalText() ([]byte, error) {
+	return []byte(v.Format()), nil
+}
+
+// MarshalJSON returns the vector as a JSON array.
+func (v VectorValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+// Encode encodes the vector as a blob containing the
+// components in little endian order.
+func (v VectorValue) Encode(dst []byte) ([]byte, error) {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+
+	return encoding.EncodeBlob(dst, buf), nil
+}
+
+func (v VectorValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func decodeVector(b []byte) VectorValue {
+	v := make(VectorValue, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+
+	return v
+}
+
+func (v VectorValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeVector:
+		return v, nil
+	case TypeText:
+		return NewTextValue(v.Format()), nil
+	case TypeBlob:
+		b, err := v.Encode(nil)
+		if err != nil {
+			return nil, err
+		}
+		x, _ := encoding.DecodeBlob(b)
+		return NewBlobValue(x), nil
+	}
+
+	return nil, errs.TypeMismatchf("cannot cast %s as %s", v.Type(), target)
+}
+
+func (v VectorValue) EQ(other Value) (bool, error) {
+	var u VectorValue
+	switch other.Type() {
+	case TypeVector:
+		u = other.(VectorValue)
+	case TypeText:
+		var err error
+		u, err = ParseVector(AsString(other))
+		if err != nil {
+			return false, err
+		}
+	default:
+		return false, nil
+	}
+
+	if len(v) != len(u) {
+		return false, nil
+	}
+	for i := range v {
+		if v[i] != u[i] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (v VectorValue) GT(other Value) (bool, error) {
+	return false, nil
+}
+
+func (v VectorValue) GTE(other Value) (bool, error) {
+	return false, nil
+}
+
+func (v VectorValue) LT(other Value) (bool, error) {
+	return false, nil
+}
+
+func (v VectorValue) LTE(other Value) (bool, error) {
+	return false, nil
+}
+
+func (v VectorValue) Between(a, b Value) (bool, error) {
+	return false, nil
+}
+
+// ParseVector parses a list of numbers separated by commas
+// and enclosed in brackets, such as [1, 2.5, -3].
+func ParseVector(s string) (VectorValue, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, errors.New("vector must be enclosed in brackets")
+	}
+
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	if s == "" {
+		return nil, errors.New("vector must have at least one dimension")
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) > MaxVectorDimension {
+		return nil, errors.Errorf("vector cannot have more than %d dimensions", MaxVectorDimension)
+	}
+
+	v := make(VectorValue, len(parts))
+	for i, p := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, errors.Errorf("invalid vector component %q", strings.TrimSpace(p))
+		}
+		v[i] = float32(x)
+	}
+
+	return v, nil
+}
+
+// VectorSpec is the dimension of a VECTOR(dimension) column or cast.
+// If the dimension is zero, vectors of any dimension are accepted.
+type VectorSpec struct {
+	Dimension int
+}
+
+// NewVectorSpec returns the spec of VECTOR(dimension).
+func NewVectorSpec(dimension int) (VectorSpec, error) {
+	if dimension < 1 || dimension > MaxVectorDimension {
+		return VectorSpec{}, errors.Errorf("vector dimension %d must be between 1 and %d", dimension, MaxVectorDimension)
+	}
+
+	return VectorSpec{Dimension: dimension}, nil
+}
+
+// String returns the modifier of the type, such as (3),
+// or an empty string if the dimension is zero.
+func (s VectorSpec) String() string {
+	if s.Dimension == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("(%d)", s.Dimension)
+}
+
+// Apply returns an error if v is a vector whose dimension
+// is not the one of s. Other values are returned as is.
+func (s VectorSpec) Apply(v Value) (Value, error) {
+	vv, ok := v.(VectorValue)
+	if !ok || s.Dimension == 0 {
+		return v, nil
+	}
+
+	if len(vv) != s.Dimension {
+		return nil, errors.Errorf("expected %d dimensions, not %d", s.Dimension, len(vv))
+	}
+
+	return vv, nil
+}
+
+// VectorMetric is a function measuring the distance between two vectors.
+type VectorMetric string
+
+// Supported vector metrics.
+const (
+	// VectorL2 is the euclidean distance.
+	VectorL2 VectorMetric = "l2"
+	// VectorCosine is one minus the cosine similarity.
+	VectorCosine VectorMetric = "cosine"
+)
+
+// ParseVectorMetric returns the metric with the given name, in any case.
+func ParseVectorMetric(name string) (VectorMetric, error) {
+	switch m := VectorMetric(strings.ToLower(name)); m {
+	case VectorL2, VectorCosine:
+		return m, nil
+	}
+
+	return "", errors.Errorf("unknown vector metric %q, expected %q or %q", name, VectorL2, VectorCosine)
+}
+
+// Distance returns the distance between a and b.
+// The cosine distance between a zero vector and any other vector is 1,
+// as if they were orthogonal.
+func (m VectorMetric) Distance(a, b VectorValue) (float64, error) {
+	if len(a) != len(b) {
+		return 0, errors.Errorf("different vector dimensions %d and %d", len(a), len(b))
+	}
+
+	switch m {
+	case VectorL2:
+		var sum float64
+		for i := range a {
+			d := float64(a[i]) - float64(b[i])
+			sum += d * d
+		}
+		return math.Sqrt(sum), nil
+	case VectorCosine:
+		var dot, na, nb float64
+		for i := range a {
+			dot += float64(a[i]) * float64(b[i])
+			na += float64(a[i]) * float64(a[i])
+			nb += float64(b[i]) * float64(b[i])
+		}
+		if na == 0 || nb == 0 {
+			return 1, nil
+		}
+		return 1 - dot/math.Sqrt(na*nb), nil
+	}
+
+	return 0, errors.Errorf("unknown vector metric %q", string(m))
+}
@@ -0,0 +1,106 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVector(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		fails bool
+	}{
+		{"[1, 2.5, -3]", "[1, 2.5, -3]", false},
+		{" [1,2,3] ", "[1, 2, 3]", false},
+		{"[0.1]", "[0.1]", false},
+		{"[1e3, -1e-3]", "[1000, -0.001]", false},
+		{"", "", true},
+		{"[]", "", true},
+		{"1, 2", "", true},
+		{"[1, 2", "", true},
+		{"[1,, 2]", "", true},
+		{"[a]", "", true},
+		{"[NaN]", "", true},
+		{"[Inf]", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			v, err := types.ParseVector(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.want, v.Format())
+		})
+	}
+}
+
+func TestVectorEncoding(t *testing.T) {
+	v := types.NewVectorValue([]float32{1, -2.5, 0})
+
+	b, err := v.Encode(nil)
+	require.NoError(t, err)
+
+	got, n := types.VectorTypeDef{}.Decode(b)
+	require.Equal(t, len(b), n)
+	require.Equal(t, v, got)
+}
+
+func TestVectorSpec(t *testing.T) {
+	_, err := types.NewVectorSpec(0)
+	require.Error(t, err)
+	_, err = types.NewVectorSpec(types.MaxVectorDimension + 1)
+	require.Error(t, err)
+
+	spec, err := types.NewVectorSpec(2)
+	require.NoError(t, err)
+	require.Equal(t, "(2)", spec.String())
+
+	_, err = spec.Apply(types.NewVectorValue([]float32{1, 2}))
+	require.NoError(t, err)
+	_, err = spec.Apply(types.NewVectorValue([]float32{1, 2, 3}))
+	require.EqualError(t, err, "expected 2 dimensions, not 3")
+
+	// other values are not checked
+	_, err = spec.Apply(types.NewNullValue())
+	require.NoError(t, err)
+}
+
+func TestVectorDistance(t *testing.T) {
+	tests := []struct {
+		metric types.VectorMetric
+		a, b   []float32
+		want   float64
+	}{
+		{types.VectorL2, []float32{0, 0}, []float32{3, 4}, 5},
+		{types.VectorL2, []float32{1, 2}, []float32{1, 2}, 0},
+		{types.VectorCosine, []float32{1, 0}, []float32{0, 1}, 1},
+		{types.VectorCosine, []float32{1, 0}, []float32{2, 0}, 0},
+		{types.VectorCosine, []float32{1, 0}, []float32{-1, 0}, 2},
+		{types.VectorCosine, []float32{0, 0}, []float32{1, 1}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.metric), func(t *testing.T) {
+			d, err := test.metric.Distance(test.a, test.b)
+			require.NoError(t, err)
+			require.InDelta(t, test.want, d, 1e-9)
+		})
+	}
+
+	_, err := types.VectorL2.Distance([]float32{1}, []float32{1, 2})
+	require.Error(t, err)
+
+	m, err := types.ParseVectorMetric("COSINE")
+	require.NoError(t, err)
+	require.Equal(t, types.VectorCosine, m)
+
+	_, err = types.ParseVectorMetric("dot")
+	require.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package chai
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"github.com/chaisql/chai/engine"
+)
+
+// upper bounds of the buckets of the latency histograms.
+var latencyBounds = [...]time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Metrics are counters of the activity of a database since it was opened,
+// returned by DB.Metrics.
+type Metrics struct {
+	// TxStarted is the number of transactions started.
+	TxStarted int64
+	// TxCommitted is the number of transactions committed.
+	TxCommitted int64
+	// TxRolledBack is the number of transactions rolled back,
+	// including the read-only transactions, which always end
+	// with a rollback, and the transactions which failed to commit.
+	TxRolledBack int64
+	// TxConflicts is the number of transactions which failed
+	// to commit with ErrTxConflict.
+	TxConflicts int64
+
+	// Queries is the number of queries run.
+	Queries int64
+	// QueryErrors is the number of queries which failed.
+	QueryErrors int64
+	// RowsRead is the number of rows read from tables
+	// and indexes by the queries.
+	RowsRead int64
+	// RowsWritten is the number of rows inserted, updated or deleted
+	// by the queries.
+	RowsWritten int64
+	// QueryLatency is the distribution of the durations of the queries,
+	// including the iteration of their result.
+	QueryLatency Histogram
+
+	// Engine holds the statistics of the storage engine:
+	// block cache, compactions and size on disk.
+	// It is empty if the engine doesn't implement engine.MetricsEngine.
+	Engine engine.Metrics
+}
+
+// Histogram is a distribution of durations.
+type Histogram struct {
+	// Bounds are the upper bounds of the buckets, in increasing order.
+	Bounds []time.Duration
+	// Counts are the number of durations of each bucket, greater than the
+	// bound of the previous bucket and lower or equal to its own.
+	// The last count is the number of durations greater than the last bound.
+	Counts []uint64
+	// Count is the total number of durations.
+	Count uint64
+	// Sum is the sum of the durations.
+	Sum time.Duration
+}
+
+// histogram records durations concurrently.
+type histogram struct {
+	counts [len(latencyBounds) + 1]atomic.Uint64
+	sum    atomic.Int64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(latencyBounds) && d > latencyBounds[i] {
+		i++
+	}
+
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *histogram) snapshot() Histogram {
+	hs := Histogram{
+		Bounds: append([]time.Duration(nil), latencyBounds[:]...),
+		Counts: make([]uint64, len(h.counts)),
+		Sum:    time.Duration(h.sum.Load()),
+	}
+	for i := range h.counts {
+		hs.Counts[i] = h.counts[i].Load()
+		hs.Count += hs.Counts[i]
+	}
+
+	return hs
+}
+
+// queryMetrics counts the queries run by a database.
+type queryMetrics struct {
+	queries     atomic.Int64
+	errors      atomic.Int64
+	rowsRead    atomic.Int64
+	rowsWritten atomic.Int64
+	latency     histogram
+}
+
+func (m *queryMetrics) observe(d time.Duration, rowsRead, rowsWritten int64, err error) {
+	m.queries.Add(1)
+	if err != nil {
+		m.errors.Add(1)
+	}
+	m.rowsRead.Add(rowsRead)
+	m.rowsWritten.Add(rowsWritten)
+	m.latency.observe(d)
+}
+
+// Metrics returns the counters of the activity of the database.
+func (db *DB) Metrics() *Metrics {
+	dm := db.DB.Metrics()
+
+	return &Metrics{
+		TxStarted:    dm.TxStarted,
+		TxCommitted:  dm.TxCommitted,
+		TxRolledBack: dm.TxRolledBack,
+		TxConflicts:  dm.TxConflicts,
+		Queries:      db.metrics.queries.Load(),
+		QueryErrors:  db.metrics.errors.Load(),
+		RowsRead:     db.metrics.rowsRead.Load(),
+		RowsWritten:  db.metrics.rowsWritten.Load(),
+		QueryLatency: db.metrics.latency.snapshot(),
+		Engine:       dm.Engine,
+	}
+}
+
+// A Collector receives the metrics of a database from DB.CollectMetrics,
+// to export them to a monitoring system.
+// Names are prefixed with chai_ and follow the Prometheus conventions:
+// counters end with _total, and durations and sizes are in seconds and bytes.
+type Collector interface {
+	// Counter receives a value which only increases.
+	Counter(name, help string, value float64)
+	// Gauge receives a value which can go up and down.
+	Gauge(name, help string, value float64)
+	// Histogram receives a distribution of durations.
+	Histogram(name, help string, h *Histogram)
+}
+
+// CollectMetrics passes the current metrics of the database to c.
+// See the chaiprom package to export them to Prometheus.
+func (db *DB) CollectMetrics(c Collector) {
+	m := db.Metrics()
+
+	c.Counter("chai_transactions_started_total", "Number of transactions started.", float64(m.TxStarted))
+	c.Counter("chai_transactions_committed_total", "Number of transactions committed.", float64(m.TxCommitted))
+	c.Counter("chai_transactions_rolled_back_total", "Number of transactions rolled back.", float64(m.TxRolledBack))
+	c.Counter("chai_transactions_conflicts_total", "Number of transactions which failed to commit because of a conflict.", float64(m.TxConflicts))
+	c.Counter("chai_queries_total", "Number of queries run.", float64(m.Queries))
+	c.Counter("chai_query_errors_total", "Number of queries which failed.", float64(m.QueryErrors))
+	c.Counter("chai_rows_read_total", "Number of rows read from tables and indexes.", float64(m.RowsRead))
+	c.Counter("chai_rows_written_total", "Number of rows inserted, updated or deleted.", float64(m.RowsWritten))
+	c.Histogram("chai_query_duration_seconds", "Duration of the queries.", &m.QueryLatency)
+	c.Gauge("chai_engine_cache_size_bytes", "Size of the block cache.", float64(m.Engine.CacheSize))
+	c.Counter("chai_engine_cache_hits_total", "Number of reads of the block cache which found their block.", float64(m.Engine.CacheHits))
+	c.Counter("chai_engine_cache_misses_total", "Number of reads of the block cache which missed their block.", float64(m.Engine.CacheMisses))
+	c.Counter("chai_engine_compactions_total", "Number of compactions.", float64(m.Engine.Compactions))
+	c.Counter("chai_engine_compaction_seconds_total", "Time spent compacting.", m.Engine.CompactionDuration.Seconds())
+	c.Counter("chai_engine_compacted_bytes_total", "Number of bytes written by compactions.", float64(m.Engine.CompactedBytes))
+	c.Counter("chai_engine_flushes_total", "Number of flushes of the memory tables to disk.", float64(m.Engine.Flushes))
+	c.Gauge("chai_engine_disk_size_bytes", "Number of bytes used on disk.", float64(m.Engine.DiskSize))
+}
+
+// Expvar returns a variable holding the metrics of the database,
+// to publish with expvar.Publish.
+func (db *DB) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		return db.Metrics()
+	})
+}
@@ -0,0 +1,175 @@
+package chai
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultPageSize is the number of rows of a page
+// if PageOptions.Limit is not set.
+const DefaultPageSize = 100
+
+// PageOptions configure the pages returned by QueryPage.
+type PageOptions struct {
+	// Where is an optional condition selecting the rows, such as "age > ?".
+	Where string
+	// Args are the parameters of Where.
+	Args []any
+	// Limit is the maximum number of rows of a page.
+	// If zero, DefaultPageSize is used.
+	Limit int
+}
+
+// A Page is a set of rows returned by QueryPage.
+type Page struct {
+	Rows []*Row
+
+	// Next is the token of the following page,
+	// or an empty string if this page is the last one.
+	Next string
+}
+
+// QueryPage returns a page of the rows of the table, in primary key order.
+// The first page is returned if token is empty, the following ones
+// by passing the Next token of the previous page.
+//
+// Pages are read with keyset pagination: each page starts right after
+// the primary key of the last row of the previous one, so that reading
+// a page doesn't scan the rows of the previous pages and rows inserted
+// or deleted between two pages don't shift the following ones.
+// The table must have a primary key made of a single column.
+func (db *DB) QueryPage(table, token string, opts *PageOptions) (p *Page, err error) {
+	err = db.withConn(func(c *Connection) error {
+		p, err = c.QueryPage(table, token, opts)
+		return err
+	})
+	return
+}
+
+// QueryPage returns a page of the rows of the table.
+// See DB.QueryPage for details.
+func (c *Connection) QueryPage(table, token string, opts *PageOptions) (*Page, error) {
+	if opts == nil {
+		opts = &PageOptions{}
+	}
+	limit := opts.Limit
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	if limit < 0 {
+		return nil, errors.New("page limit must be positive")
+	}
+
+	var p Page
+	err := c.View(func(tx *Tx) error {
+		pk, err := primaryKeyColumn(tx, table)
+		if err != nil {
+			return err
+		}
+
+		var conds []string
+		var args []any
+		if opts.Where != "" {
+			conds = append(conds, "("+opts.Where+")")
+			args = append(args, opts.Args...)
+		}
+		if token != "" {
+			v, err := decodePageToken(token)
+			if err != nil {
+				return err
+			}
+			conds = append(conds, quoteIdent(pk)+" > ?")
+			args = append(args, v)
+		}
+
+		var q strings.Builder
+		q.WriteString("SELECT * FROM ")
+		q.WriteString(quoteTableName(table))
+		if len(conds) > 0 {
+			q.WriteString(" WHERE ")
+			q.WriteString(strings.Join(conds, " AND "))
+		}
+		q.WriteString(" ORDER BY ")
+		q.WriteString(quoteIdent(pk))
+		// one more row is read to know whether there is a next page
+		q.WriteString(" LIMIT ")
+		q.WriteString(strconv.Itoa(limit + 1))
+
+		res, err := tx.Query(q.String(), args...)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		return res.Iterate(func(r *Row) error {
+			if len(p.Rows) < limit {
+				p.Rows = append(p.Rows, r.Clone())
+				return nil
+			}
+
+			v, err := p.Rows[limit-1].Row.Get(pk)
+			if err != nil {
+				return err
+			}
+			p.Next = encodePageToken(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// primaryKeyColumn returns the column of the primary key of the table.
+func primaryKeyColumn(tx *Tx, table string) (string, error) {
+	t := tx.conn.Conn.GetTx()
+	name, err := t.Catalog.ResolveName(t, database.RelationTableType, table)
+	if err != nil {
+		return "", err
+	}
+	info, err := t.Catalog.GetTableInfo(name)
+	if err != nil {
+		return "", err
+	}
+
+	if info.PrimaryKey == nil || len(info.PrimaryKey.Columns) != 1 {
+		return "", errors.Errorf("table %s must have a primary key made of a single column to be paginated", table)
+	}
+
+	return info.PrimaryKey.Columns[0], nil
+}
+
+// encodePageToken returns a token holding the primary key of
+// the last row of a page, as an SQL literal.
+func encodePageToken(v types.Value) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(v.String()))
+}
+
+// decodePageToken returns the primary key held by a token.
+func decodePageToken(token string) (types.Value, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid page token")
+	}
+
+	e, err := parser.ParseExpr(string(b))
+	if err != nil {
+		return nil, errors.New("invalid page token")
+	}
+
+	lv, ok := e.(expr.LiteralValue)
+	if !ok {
+		return nil, errors.New("invalid page token")
+	}
+
+	return lv.Value, nil
+}
@@ -0,0 +1,451 @@
+package chai
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
+	"github.com/chaisql/chai/internal/stream/path"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/chaisql/chai/plan"
+	"github.com/cockroachdb/errors"
+)
+
+// cost of a scan without ranges, as estimated by the planner.
+const fullScanCost = 10_000
+
+// Plan returns the plan the query would be run with, without running it.
+// It only works on a single SELECT, INSERT, UPDATE or DELETE statement.
+// The arguments are used by the planner to select the indexes.
+// It returns nil if the query would not read or write anything.
+func (db *DB) Plan(q string, args ...any) (n *plan.Node, err error) {
+	err = db.withConn(func(c *Connection) error {
+		n, err = c.Plan(q, args...)
+		return err
+	})
+
+	return n, err
+}
+
+// Plan returns the plan the query would be run with on this connection,
+// without running it. See DB.Plan.
+func (c *Connection) Plan(q string, args ...any) (*plan.Node, error) {
+	tx, err := c.Conn.BeginTx(&database.TxOptions{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ctx, s, err := c.prepareStream(tx, q, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Stream, err = s.Optimize(ctx)
+	if err != nil {
+		return nil, newError(err, q)
+	}
+
+	return newPlanNode(s.Stream), nil
+}
+
+// prepareStream parses and prepares a single statement run as a stream.
+func (c *Connection) prepareStream(tx *database.Transaction, q string, args []any) (*statement.Context, *statement.PreparedStreamStmt, error) {
+	pq, err := parser.ParseQuery(q)
+	if err != nil {
+		return nil, nil, newError(err, q)
+	}
+
+	if len(pq.Statements) != 1 {
+		return nil, nil, errors.New("Plan only works on a single statement")
+	}
+
+	p, ok := pq.Statements[0].(statement.Preparer)
+	if !ok {
+		return nil, nil, errors.New("Plan only works on INSERT, SELECT, UPDATE and DELETE statements")
+	}
+
+	ctx := statement.Context{
+		DB:     c.db.DB,
+		Conn:   c.Conn,
+		Tx:     tx,
+		Params: argsToParams(args),
+	}
+
+	err = pq.Statements[0].Bind(&ctx)
+	if err != nil {
+		return nil, nil, newError(err, q)
+	}
+
+	st, err := p.Prepare(&ctx)
+	if err != nil {
+		return nil, nil, newError(err, q)
+	}
+
+	s, ok := st.(*statement.PreparedStreamStmt)
+	if !ok {
+		return nil, nil, errors.New("Plan only works on INSERT, SELECT, UPDATE and DELETE statements")
+	}
+
+	return &ctx, s, nil
+}
+
+// PinPlan captures the plan the statement is run with and pins it:
+// the statements with the same fingerprint, which only differ by their
+// literals and parameters, then read their tables as they do in this plan,
+// with the same index or without index, even after indexes are created
+// or after the data changes. A table whose pinned index was dropped is
+// planned as if there was no baseline.
+// It only works on a single SELECT, UPDATE or DELETE statement, whose
+// arguments are used to capture the plan, and replaces the baseline
+// previously pinned for its fingerprint. Only superusers can pin plans.
+func (db *DB) PinPlan(q string, args ...any) (b *plan.Baseline, err error) {
+	err = db.withConn(func(c *Connection) error {
+		b, err = c.PinPlan(q, args...)
+		return err
+	})
+
+	return b, err
+}
+
+// PinPlan captures the plan the statement is run with on this connection
+// and pins it. See DB.PinPlan.
+func (c *Connection) PinPlan(q string, args ...any) (*plan.Baseline, error) {
+	tx, err := c.Conn.BeginTx(&database.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.CheckSuperuser()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, s, err := c.prepareStream(tx, q, args)
+	if err != nil {
+		return nil, err
+	}
+	if s.Fingerprint == "" {
+		return nil, errors.New("PinPlan only works on SELECT, UPDATE and DELETE statements")
+	}
+
+	// the plan is captured as if no baseline was pinned
+	st, err := statement.Optimize(ctx, s.Stream)
+	if err != nil {
+		return nil, newError(err, q)
+	}
+
+	indexes, err := statement.PlanIndexes(ctx, st)
+	if err != nil {
+		return nil, newError(err, q)
+	}
+
+	b := database.PlanBaseline{
+		Fingerprint: s.Fingerprint,
+		Query:       q,
+		Indexes:     indexes,
+		Plan:        st.String(),
+		PinnedAt:    time.Now(),
+	}
+	err = database.PinPlanBaseline(tx, &b)
+	if err != nil {
+		return nil, newError(err, q)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return newPlanBaseline(&b), nil
+}
+
+// UnpinPlan deletes the baseline pinned for the fingerprint.
+// It returns a NotFoundError if there is none.
+// Only superusers can unpin plans.
+func (db *DB) UnpinPlan(fingerprint string) error {
+	return db.withConn(func(c *Connection) error {
+		return c.UnpinPlan(fingerprint)
+	})
+}
+
+// UnpinPlan deletes the baseline pinned for the fingerprint. See DB.UnpinPlan.
+func (c *Connection) UnpinPlan(fingerprint string) error {
+	tx, err := c.Conn.BeginTx(&database.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.CheckSuperuser()
+	if err != nil {
+		return err
+	}
+
+	err = database.UnpinPlanBaseline(tx, fingerprint)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PlanBaselines returns the pinned baselines, sorted by fingerprint.
+// They are also stored in the __chai_plan_baselines table, once a plan is pinned.
+func (db *DB) PlanBaselines() (l []plan.Baseline, err error) {
+	err = db.withConn(func(c *Connection) error {
+		l, err = c.PlanBaselines()
+		return err
+	})
+
+	return l, err
+}
+
+// PlanBaselines returns the pinned baselines. See DB.PlanBaselines.
+func (c *Connection) PlanBaselines() ([]plan.Baseline, error) {
+	tx, err := c.Conn.BeginTx(&database.TxOptions{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	baselines, err := database.ListPlanBaselines(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	l := make([]plan.Baseline, 0, len(baselines))
+	for _, b := range baselines {
+		l = append(l, *newPlanBaseline(b))
+	}
+
+	return l, nil
+}
+
+func newPlanBaseline(b *database.PlanBaseline) *plan.Baseline {
+	return &plan.Baseline{
+		Fingerprint: b.Fingerprint,
+		Query:       b.Query,
+		Indexes:     b.Indexes,
+		Plan:        b.Plan,
+		PinnedAt:    b.PinnedAt,
+	}
+}
+
+// PlanFingerprint returns the fingerprint of a single SELECT, UPDATE or DELETE
+// statement, which identifies the statements sharing a plan baseline.
+func PlanFingerprint(q string) (string, error) {
+	fp, err := parser.Fingerprint(q)
+	if err != nil {
+		return "", newError(err, q)
+	}
+
+	return fp, nil
+}
+
+// newPlanNode returns the node of the last operator of the stream.
+func newPlanNode(s *stream.Stream) *plan.Node {
+	if s == nil {
+		return nil
+	}
+
+	var n *plan.Node
+	for op := s.First(); op != nil; op = op.GetNext() {
+		n = newOperatorNode(op, n)
+	}
+
+	return n
+}
+
+func newOperatorNode(op stream.Operator, input *plan.Node) *plan.Node {
+	n := plan.Node{
+		Input: input,
+	}
+	if input != nil {
+		n.Cost = input.Cost
+	}
+
+	switch t := op.(type) {
+	case *table.ScanOperator:
+		n.Type, n.Table, n.Reverse = plan.TableScan, t.TableName, t.Reverse
+		n.Ranges = rangesToStrings(t.Ranges)
+		n.Cost += scanCost(t.Ranges)
+	case *table.InsertOperator:
+		n.Type, n.Table = plan.TableInsert, t.Name
+	case *table.ReplaceOperator:
+		n.Type, n.Table = plan.TableReplace, t.Name
+	case *table.DeleteOperator:
+		n.Type, n.Table = plan.TableDelete, t.Name
+	case *table.ValidateOperator:
+		n.Type, n.Table = plan.TableValidate, t.TableName
+	case *index.ScanOperator:
+		n.Type, n.Index, n.Reverse = plan.IndexScan, t.IndexName, t.Reverse
+		n.Ranges = rangesToStrings(t.Ranges)
+		// same penalty as the planner for reading the rows through an index
+		n.Cost += scanCost(t.Ranges) + 20
+	case *index.VectorScanOperator:
+		n.Type, n.Index = plan.IndexVectorScan, t.IndexName
+		n.Exprs = []string{t.Vector.String(), t.Limit.String()}
+		// only a few lists of the index are read
+		n.Cost += 200 + 20
+	case *index.InsertOperator:
+		n.Type, n.Index = plan.IndexInsert, t.IndexName
+	case *index.DeleteOperator:
+		n.Type, n.Index = plan.IndexDelete, t.IndexName
+	case *index.ValidateOperator:
+		n.Type, n.Index, n.Deferred = plan.IndexValidate, t.IndexName, t.Deferred
+	case *rows.EmitOperator:
+		n.Type = plan.RowsEmit
+		for _, r := range t.Rows {
+			n.Exprs = append(n.Exprs, r.String())
+		}
+		n.Cost += len(t.Rows)
+	case *rows.FilterOperator:
+		n.Type = plan.RowsFilter
+		n.Exprs = []string{t.Expr.String()}
+	case *rows.ProjectOperator:
+		n.Type = plan.RowsProject
+		for _, e := range t.Exprs {
+			n.Exprs = append(n.Exprs, e.(fmt.Stringer).String())
+		}
+	case *rows.TakeOperator:
+		n.Type = plan.RowsTake
+		n.Exprs = []string{t.E.String()}
+	case *rows.SkipOperator:
+		n.Type = plan.RowsSkip
+		n.Exprs = []string{t.E.String()}
+	case *rows.MaterializeOperator:
+		n.Type = plan.RowsMaterialize
+	case *rows.TempTreeSortOperator:
+		n.Type, n.Reverse = plan.RowsSort, t.IsDesc()
+		for _, k := range t.Keys {
+			// the direction of the keys is given by Reverse
+			if n.Reverse {
+				k.Desc = false
+			}
+			n.Exprs = append(n.Exprs, k.String())
+		}
+		// the rows are read twice, once to sort them
+		// and once to return them.
+		n.Cost *= 2
+	case *rows.GroupAggregateOperator:
+		n.Type = plan.RowsGroup
+		if t.E != nil {
+			n.Exprs = append(n.Exprs, t.E.String())
+		} else {
+			n.Exprs = append(n.Exprs, "NULL")
+		}
+		for _, b := range t.Builders {
+			n.Exprs = append(n.Exprs, b.(fmt.Stringer).String())
+		}
+	case *rows.MatchRecognizeOperator:
+		n.Type = plan.RowsMatch
+		n.Exprs = t.Clauses()
+		// the rows are sorted before being matched
+		n.Cost *= 2
+	case *path.SetOperator:
+		n.Type = plan.PathsSet
+		n.Exprs = []string{t.Column, t.Expr.String()}
+	case *path.RenameOperator:
+		n.Type = plan.PathsRename
+		n.Exprs = append([]string(nil), t.ColumnNames...)
+	case *stream.UnionOperator:
+		n.Type = plan.StreamUnion
+		n.Streams = newPlanNodes(t.Streams)
+		n.Cost += streamsCost(n.Streams)
+	case *stream.ConcatOperator:
+		n.Type = plan.StreamConcat
+		n.Streams = newPlanNodes(t.Streams)
+		n.Cost += streamsCost(n.Streams)
+	case *stream.IntersectOperator:
+		n.Type = plan.StreamIntersect
+		if t.All {
+			n.Type = plan.StreamIntersectAll
+		}
+		n.Streams = newPlanNodes(t.Streams)
+		n.Cost += streamsCost(n.Streams)
+	case *stream.ExceptOperator:
+		n.Type = plan.StreamExcept
+		if t.All {
+			n.Type = plan.StreamExceptAll
+		}
+		n.Streams = newPlanNodes(t.Streams)
+		n.Cost += streamsCost(n.Streams)
+	case *stream.JoinOperator:
+		n.Type = plan.StreamJoin
+		if t.Cond != nil {
+			n.Exprs = []string{t.Cond.String()}
+		}
+		n.Streams = []*plan.Node{newPlanNode(t.Stream)}
+		// the joined stream is read for each row
+		n.Cost *= 1 + streamsCost(n.Streams)
+	case *stream.OnConflictOperator:
+		n.Type = plan.StreamOnConflict
+		if t.OnConflict != nil {
+			// the stream is only run on conflicts,
+			// its cost is not added.
+			n.Streams = []*plan.Node{newPlanNode(t.OnConflict)}
+		}
+	case *stream.DiscardOperator:
+		n.Type = plan.StreamDiscard
+	default:
+		// operators unknown to this package are only described
+		// by their textual representation.
+		n.Type = op.String()
+	}
+
+	return &n
+}
+
+// newPlanNodes returns the nodes of streams combined by an operator.
+func newPlanNodes(streams []*stream.Stream) []*plan.Node {
+	nodes := make([]*plan.Node, 0, len(streams))
+	for _, s := range streams {
+		nodes = append(nodes, newPlanNode(s))
+	}
+
+	return nodes
+}
+
+func streamsCost(nodes []*plan.Node) int {
+	var cost int
+	for _, n := range nodes {
+		if n != nil {
+			cost += n.Cost
+		}
+	}
+
+	return cost
+}
+
+// scanCost returns the cost of reading the ranges.
+func scanCost(ranges stream.Ranges) int {
+	if len(ranges) == 0 {
+		return fullScanCost
+	}
+
+	return ranges.Cost()
+}
+
+func rangesToStrings(ranges stream.Ranges) []string {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	l := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		l = append(l, r.String())
+	}
+
+	return l
+}
@@ -0,0 +1,182 @@
+/*
+Package plan describes how the database executes a query.
+
+A plan is a tree of nodes, one per operator. The root of the tree
+is the last operator run by the query, whose rows are returned to
+the caller, and each node reads the rows produced by its input.
+Plans are obtained with the Plan method of chai.DB and are read-only:
+modifying them has no effect on the execution of queries. The indexes
+used by a plan can be pinned with the PinPlan method of chai.DB, to be
+reused by the next executions of the statement.
+*/
+package plan
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operator types.
+const (
+	TableScan          = "table.Scan"
+	TableInsert        = "table.Insert"
+	TableReplace       = "table.Replace"
+	TableDelete        = "table.Delete"
+	TableValidate      = "table.Validate"
+	IndexScan          = "index.Scan"
+	IndexVectorScan    = "index.VectorScan"
+	IndexInsert        = "index.Insert"
+	IndexDelete        = "index.Delete"
+	IndexValidate      = "index.Validate"
+	RowsEmit           = "rows.Emit"
+	RowsFilter         = "rows.Filter"
+	RowsProject        = "rows.Project"
+	RowsTake           = "rows.Take"
+	RowsSkip           = "rows.Skip"
+	RowsSort           = "rows.TempTreeSort"
+	RowsMaterialize    = "rows.Materialize"
+	RowsGroup          = "rows.GroupAggregate"
+	RowsMatch          = "rows.MatchRecognize"
+	PathsSet           = "paths.Set"
+	PathsRename        = "paths.Rename"
+	StreamUnion        = "union"
+	StreamConcat       = "concat"
+	StreamIntersect    = "intersect"
+	StreamIntersectAll = "intersect_all"
+	StreamExcept       = "except"
+	StreamExceptAll    = "except_all"
+	StreamOnConflict   = "stream.OnConflict"
+	StreamJoin         = "stream.Join"
+	StreamDiscard      = "discard"
+)
+
+// Node is an operator of a plan.
+type Node struct {
+	// Type of the operator, one of the constants of this package.
+	Type string
+
+	// Table read or written by the operator, if any.
+	Table string
+
+	// Index read or written by the operator, if any.
+	Index string
+
+	// Exprs are the expressions evaluated by the operator,
+	// in their SQL form.
+	Exprs []string
+
+	// Ranges are the boundaries of a scan, if any.
+	// A scan without ranges reads the whole table or index.
+	Ranges []string
+
+	// Reverse is true for scans and sorts run in descending order.
+	Reverse bool
+
+	// Deferred is true for index validations run when the transaction commits.
+	Deferred bool
+
+	// Cost is an estimate of the cost of running the operator and its inputs,
+	// in the unit used by the planner to choose between indexes.
+	// It is only meaningful when compared to the cost of another plan.
+	Cost int
+
+	// Input is the node whose rows are read by the operator.
+	// It is nil for operators producing rows, such as scans.
+	Input *Node
+
+	// Streams are the plans combined by union and concat operators,
+	// and the plan run on conflicts by the stream.OnConflict operator.
+	Streams []*Node
+}
+
+// Walk calls fn for the node and each node it reads from, inputs first.
+// Walk stops if fn returns false.
+func (n *Node) Walk(fn func(*Node) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	for _, s := range n.Streams {
+		if !s.Walk(fn) {
+			return false
+		}
+	}
+
+	if !n.Input.Walk(fn) {
+		return false
+	}
+
+	return fn(n)
+}
+
+// String returns the plan in a format close to the one used by EXPLAIN.
+func (n *Node) String() string {
+	if n == nil {
+		return "<no exec>"
+	}
+
+	var sb strings.Builder
+	n.writeTo(&sb)
+	return sb.String()
+}
+
+func (n *Node) writeTo(sb *strings.Builder) {
+	if n.Input != nil {
+		n.Input.writeTo(sb)
+		sb.WriteString(" | ")
+	}
+
+	typ := n.Type
+	switch {
+	case n.Reverse && (n.Type == TableScan || n.Type == IndexScan || n.Type == RowsSort):
+		typ += "Reverse"
+	case n.Deferred && n.Type == IndexValidate:
+		typ = "index.DeferredValidate"
+	}
+	sb.WriteString(typ)
+	sb.WriteByte('(')
+
+	var args []string
+	switch {
+	case n.Table != "":
+		args = append(args, strconv.Quote(n.Table))
+	case n.Index != "":
+		args = append(args, strconv.Quote(n.Index))
+	}
+	if len(n.Ranges) > 0 {
+		args = append(args, "["+strings.Join(n.Ranges, ", ")+"]")
+	}
+	args = append(args, n.Exprs...)
+	sb.WriteString(strings.Join(args, ", "))
+
+	for i, s := range n.Streams {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		s.writeTo(sb)
+	}
+	sb.WriteByte(')')
+}
+
+// Baseline is a plan pinned with the PinPlan method of chai.DB.
+// The statements with the same fingerprint read their tables
+// as they were read by the plan when it was pinned.
+type Baseline struct {
+	// Fingerprint identifies the statements that only differ
+	// by their literals and parameters.
+	Fingerprint string
+
+	// Query is the statement the plan was captured from.
+	Query string
+
+	// Indexes used to read each table, by table name.
+	// Tables read without index are mapped to an empty string.
+	Indexes map[string]string
+
+	// Plan is the plan captured, as displayed by EXPLAIN.
+	Plan string
+
+	// PinnedAt is the time the plan was pinned.
+	PinnedAt time.Time
+}
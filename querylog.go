@@ -0,0 +1,139 @@
+package chai
+
+import (
+	"time"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/plan"
+)
+
+// QueryLog describes a query run by the database.
+// It is passed to Options.QueryLogger once the query is done.
+type QueryLog struct {
+	// SQL is the text of the query, as given to Exec, Query or Prepare.
+	SQL string
+
+	// Params are the values of the arguments of the query,
+	// or nil if Options.RedactQueryParams is set.
+	Params []any
+
+	// Duration is the time spent running the query,
+	// including the iteration of its result.
+	Duration time.Duration
+
+	// RowsReturned is the number of rows read from the result.
+	RowsReturned int64
+
+	// RowsAffected is the number of rows inserted, updated or deleted.
+	RowsAffected int64
+
+	// Plan is the plan the query was run with, or nil if it doesn't
+	// read or write rows. For queries made of several statements,
+	// it is the plan of the last one.
+	Plan *plan.Node
+
+	// Err is the error returned while running the query, if any.
+	// The errors returned by the callbacks of Result.Iterate are not reported.
+	Err error
+}
+
+// queryLogger calls the logger of Options.QueryLogger
+// with the queries running for long enough.
+type queryLogger struct {
+	fn        func(*QueryLog)
+	threshold time.Duration
+	redact    bool
+}
+
+func newQueryLogger(opts *Options) *queryLogger {
+	if opts.QueryLogger == nil {
+		return nil
+	}
+
+	return &queryLogger{
+		fn:        opts.QueryLogger,
+		threshold: opts.SlowQueryThreshold,
+		redact:    opts.RedactQueryParams,
+	}
+}
+
+// queryRun tracks a query from the moment it starts running
+// until its result is closed, to update the metrics of the database
+// and call its query logger.
+type queryRun struct {
+	db       *DB
+	q        string
+	params   []environment.Param
+	progress *environment.Progress
+	start    time.Time
+	rows     int64
+	err      error
+}
+
+func (db *DB) startQuery(q string, params []environment.Param, progress *environment.Progress) *queryRun {
+	return &queryRun{
+		db:       db,
+		q:        q,
+		params:   params,
+		progress: progress,
+		start:    time.Now(),
+	}
+}
+
+// setErr records the first error returned by the query.
+func (r *queryRun) setErr(err error) {
+	if r != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *queryRun) addRow() {
+	if r != nil {
+		r.rows++
+	}
+}
+
+// done updates the metrics and calls the logger if the query ran
+// for at least the threshold.
+// res is nil if the query failed before returning a result.
+func (r *queryRun) done(res *statement.Result) {
+	if r == nil {
+		return
+	}
+
+	d := time.Since(r.start)
+	var rowsAffected int64
+	if res != nil {
+		rowsAffected = res.RowsAffected()
+	}
+	r.db.metrics.observe(d, r.progress.RowsScanned(), rowsAffected, r.err)
+
+	l := r.db.queryLogger
+	if l == nil || d < l.threshold {
+		return
+	}
+
+	ql := QueryLog{
+		SQL:          r.q,
+		Duration:     d,
+		RowsReturned: r.rows,
+		RowsAffected: rowsAffected,
+		Err:          r.err,
+	}
+
+	if !l.redact && len(r.params) > 0 {
+		ql.Params = make([]any, len(r.params))
+		for i := range r.params {
+			ql.Params[i] = r.params[i].Value
+		}
+	}
+
+	if res != nil {
+		if it, ok := res.Iterator.(*statement.StreamStmtIterator); ok {
+			ql.Plan = newPlanNode(it.Stream)
+		}
+	}
+
+	l.fn(&ql)
+}
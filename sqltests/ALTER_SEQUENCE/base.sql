@@ -0,0 +1,166 @@
+-- setup:
+CREATE TABLE t(a BIGINT);
+CREATE SEQUENCE seq;
+
+-- test: catalog
+ALTER SEQUENCE seq INCREMENT BY 5 MAXVALUE 100 CACHE 10 CYCLE;
+SELECT name, type, sql FROM __chai_catalog WHERE name = "seq";
+/* result:
+{
+  "name": "seq",
+  "type": "sequence",
+  "sql": "CREATE SEQUENCE seq INCREMENT BY 5 MAXVALUE 100 CACHE 10 CYCLE"
+}
+*/
+
+-- test: options are kept
+ALTER SEQUENCE seq CACHE 10;
+ALTER SEQUENCE seq INCREMENT BY 2;
+SELECT sql FROM __chai_catalog WHERE name = "seq";
+/* result:
+{
+  "sql": "CREATE SEQUENCE seq INCREMENT BY 2 CACHE 10"
+}
+*/
+
+-- test: NO MAXVALUE and NO CYCLE
+ALTER SEQUENCE seq MAXVALUE 10 CYCLE;
+ALTER SEQUENCE seq NO MAXVALUE NO CYCLE;
+SELECT sql FROM __chai_catalog WHERE name = "seq";
+/* result:
+{
+  "sql": "CREATE SEQUENCE seq"
+}
+*/
+
+-- test: increment
+INSERT INTO t VALUES (NEXT VALUE FOR seq), (NEXT VALUE FOR seq);
+ALTER SEQUENCE seq INCREMENT BY 10;
+INSERT INTO t VALUES (NEXT VALUE FOR seq), (NEXT VALUE FOR seq);
+SELECT a FROM t;
+/* result:
+{
+  a: 1
+}
+{
+  a: 2
+}
+{
+  a: 12
+}
+{
+  a: 22
+}
+*/
+
+-- test: restart
+INSERT INTO t VALUES (NEXT VALUE FOR seq), (NEXT VALUE FOR seq);
+ALTER SEQUENCE seq RESTART;
+INSERT INTO t VALUES (NEXT VALUE FOR seq);
+ALTER SEQUENCE seq RESTART WITH 42;
+INSERT INTO t VALUES (NEXT VALUE FOR seq), (NEXT VALUE FOR seq);
+SELECT a FROM t;
+/* result:
+{
+  a: 1
+}
+{
+  a: 2
+}
+{
+  a: 1
+}
+{
+  a: 42
+}
+{
+  a: 43
+}
+*/
+
+-- test: restart out of bounds
+ALTER SEQUENCE seq MAXVALUE 10 RESTART WITH 11;
+-- error: RESTART value (11) must be between MINVALUE (1) and MAXVALUE (10)
+
+-- test: cycle
+ALTER SEQUENCE seq MAXVALUE 3 CYCLE;
+INSERT INTO t VALUES (NEXT VALUE FOR seq), (NEXT VALUE FOR seq), (NEXT VALUE FOR seq), (NEXT VALUE FOR seq);
+SELECT a FROM t;
+/* result:
+{
+  a: 1
+}
+{
+  a: 2
+}
+{
+  a: 3
+}
+{
+  a: 1
+}
+*/
+
+-- test: no cycle
+ALTER SEQUENCE seq MAXVALUE 2;
+INSERT INTO t VALUES (NEXT VALUE FOR seq), (NEXT VALUE FOR seq), (NEXT VALUE FOR seq);
+-- error: reached maximum value of sequence seq
+
+-- test: descending
+ALTER SEQUENCE seq INCREMENT BY -3 MINVALUE -5 MAXVALUE 5 START WITH 5 RESTART CYCLE;
+INSERT INTO t VALUES (NEXT VALUE FOR seq), (NEXT VALUE FOR seq), (NEXT VALUE FOR seq), (NEXT VALUE FOR seq), (NEXT VALUE FOR seq);
+SELECT a FROM t;
+/* result:
+{
+  a: 5
+}
+{
+  a: 2
+}
+{
+  a: -1
+}
+{
+  a: -4
+}
+{
+  a: 5
+}
+*/
+
+-- test: cache
+ALTER SEQUENCE seq INCREMENT BY 10 CACHE 3;
+INSERT INTO t VALUES (NEXT VALUE FOR seq);
+SELECT name, seq FROM __chai_sequence WHERE name = "seq";
+/* result:
+{
+  "name": "seq",
+  "seq": 21
+}
+*/
+
+-- test: invalid bounds
+ALTER SEQUENCE seq MINVALUE 10 MAXVALUE 5;
+-- error: MINVALUE (10) must be less than MAXVALUE (5)
+
+-- test: start out of bounds
+ALTER SEQUENCE seq MINVALUE 10;
+-- error: START value (1) cannot be less than MINVALUE (10)
+
+-- test: unknown sequence
+ALTER SEQUENCE unknown CYCLE;
+-- error:
+
+-- test: IF EXISTS
+ALTER SEQUENCE IF EXISTS unknown CYCLE;
+SELECT COUNT(*) FROM __chai_catalog WHERE name = "unknown";
+/* result:
+{
+  "COUNT(*)": 0
+}
+*/
+
+-- test: sequence owned by a table
+CREATE TABLE u(a INTEGER);
+ALTER SEQUENCE u_seq CYCLE;
+-- error: cannot alter sequence u_seq because it is owned by table u
@@ -65,12 +65,12 @@ SELECT * FROM test;
 -- test: unique with default: with data
 INSERT INTO test VALUES (1), (2);
 ALTER TABLE test ADD COLUMN b int UNIQUE DEFAULT 10;
--- error: UNIQUE constraint error: [b]
+-- error: UNIQUE constraint "test_b_unique" error: [b]
 
 -- test: unique with default: without data
 ALTER TABLE test ADD COLUMN b int UNIQUE DEFAULT 10;
 INSERT INTO test VALUES (1), (2);
--- error: UNIQUE constraint error: [b]
+-- error: UNIQUE constraint "test_b_unique" error: [b]
 
 -- test: primary key: with data
 INSERT INTO test VALUES (1), (2);
@@ -128,4 +128,24 @@ ALTER TABLE test ADD COLUMN;
 
 -- test: bad syntax: missing column keyword
 ALTER TABLE test ADD a int;
--- error:
\ No newline at end of file
+-- error:
+
+-- test: auto-increment
+INSERT INTO test VALUES (1), (2);
+ALTER TABLE test ADD COLUMN id SERIAL;
+INSERT INTO test (a) VALUES (3);
+SELECT * FROM test;
+/* result:
+{
+  "a": 1,
+  "id": 1
+}
+{
+  "a": 2,
+  "id": 2
+}
+{
+  "a": 3,
+  "id": 3
+}
+*/
@@ -0,0 +1,165 @@
+-- setup:
+CREATE TABLE imported(id TEXT PRIMARY KEY, n TEXT, price TEXT, active TEXT, at TEXT, label TEXT, code TEXT);
+CREATE INDEX imported_n_idx ON imported(n);
+CREATE UNIQUE INDEX imported_code_idx ON imported(code);
+INSERT INTO imported VALUES
+    ('1', '10', '1.5', 'true', '2024-01-02 10:00:00', 'a', '001'),
+    ('2', '20', '2', 'false', '2024-01-03 11:30:00', 'b', '002'),
+    ('3', NULL, '3.25', 'TRUE', '2024-02-01', 'c', 'x03');
+
+-- test: dry run
+ALTER TABLE imported INFER SCHEMA DRY RUN;
+/* result:
+{
+  "column_name": "id",
+  "inferred_type": "BIGINT",
+  "non_null": 3,
+  "nonconforming": 0,
+  "nonconforming_keys": null,
+  "altered": false
+}
+{
+  "column_name": "n",
+  "inferred_type": "BIGINT",
+  "non_null": 2,
+  "nonconforming": 0,
+  "nonconforming_keys": null,
+  "altered": false
+}
+{
+  "column_name": "price",
+  "inferred_type": "DOUBLE",
+  "non_null": 3,
+  "nonconforming": 0,
+  "nonconforming_keys": null,
+  "altered": false
+}
+{
+  "column_name": "active",
+  "inferred_type": "BOOLEAN",
+  "non_null": 3,
+  "nonconforming": 0,
+  "nonconforming_keys": null,
+  "altered": false
+}
+{
+  "column_name": "at",
+  "inferred_type": "TIMESTAMP",
+  "non_null": 3,
+  "nonconforming": 0,
+  "nonconforming_keys": null,
+  "altered": false
+}
+{
+  "column_name": "label",
+  "inferred_type": "TEXT",
+  "non_null": 3,
+  "nonconforming": 0,
+  "nonconforming_keys": null,
+  "altered": false
+}
+{
+  "column_name": "code",
+  "inferred_type": "BIGINT",
+  "non_null": 3,
+  "nonconforming": 1,
+  "nonconforming_keys": "(\"3\")",
+  "altered": false
+}
+*/
+
+-- test: dry run doesn't alter the table
+ALTER TABLE imported INFER SCHEMA DRY RUN;
+SELECT sql FROM __chai_catalog WHERE name = "imported";
+/* result:
+{
+  "sql": "CREATE TABLE imported (id TEXT NOT NULL, n TEXT, price TEXT, active TEXT, at TEXT, label TEXT, code TEXT, CONSTRAINT imported_pk PRIMARY KEY (id))"
+}
+*/
+
+-- test: alter
+ALTER TABLE imported INFER SCHEMA;
+SELECT sql FROM __chai_catalog WHERE name = "imported";
+/* result:
+{
+  "sql": "CREATE TABLE imported (id BIGINT NOT NULL, n BIGINT, price DOUBLE, active BOOLEAN, at TIMESTAMP, label TEXT, code TEXT, CONSTRAINT imported_pk PRIMARY KEY (id))"
+}
+*/
+
+-- test: values are converted
+ALTER TABLE imported INFER SCHEMA;
+SELECT id, n + 1 AS n, price, active, at FROM imported WHERE id >= 2;
+/* result:
+{
+  "id": 2,
+  "n": 21,
+  "price": 2.0,
+  "active": false,
+  "at": "2024-01-03T11:30:00Z"
+}
+{
+  "id": 3,
+  "n": null,
+  "price": 3.25,
+  "active": true,
+  "at": "2024-02-01T00:00:00Z"
+}
+*/
+
+-- test: indexes are rebuilt
+ALTER TABLE imported INFER SCHEMA;
+SELECT id FROM imported WHERE n = 20;
+/* result:
+{
+  "id": 2
+}
+*/
+
+-- test: indexes are consistent
+ALTER TABLE imported INFER SCHEMA;
+CHECK INDEX imported;
+/* result:
+*/
+
+-- test: altered columns are reported
+ALTER TABLE imported INFER SCHEMA;
+ALTER TABLE imported INFER SCHEMA;
+/* result:
+{
+  "column_name": "label",
+  "inferred_type": "TEXT",
+  "non_null": 3,
+  "nonconforming": 0,
+  "nonconforming_keys": null,
+  "altered": false
+}
+{
+  "column_name": "code",
+  "inferred_type": "BIGINT",
+  "non_null": 3,
+  "nonconforming": 1,
+  "nonconforming_keys": "(3)",
+  "altered": false
+}
+*/
+
+-- test: table without primary key
+CREATE TABLE csv(a TEXT, b TEXT);
+CREATE INDEX csv_a_idx ON csv(a);
+INSERT INTO csv VALUES ('1', 'x'), ('2', NULL), ('3', 'y');
+ALTER TABLE csv INFER SCHEMA;
+SELECT a, b FROM csv WHERE a > 1;
+/* result:
+{
+  "a": 2,
+  "b": null
+}
+{
+  "a": 3,
+  "b": "y"
+}
+*/
+
+-- test: unknown table
+ALTER TABLE unknown INFER SCHEMA;
+-- error: "unknown" not found
@@ -0,0 +1,99 @@
+-- setup:
+CREATE TABLE metrics(day INT, host TEXT, v DOUBLE, PRIMARY KEY (day, host)) PARTITION BY RANGE (day) (
+    PARTITION p1 VALUES LESS THAN (10),
+    PARTITION p2 VALUES LESS THAN (20)
+);
+CREATE INDEX metrics_host_idx ON metrics(host);
+INSERT INTO metrics VALUES (1, 'a', 1.0), (5, 'b', 2.0), (12, 'a', 3.0), (15, 'b', 4.0);
+
+-- test: add partition
+ALTER TABLE metrics ADD PARTITION p3 VALUES LESS THAN (30);
+INSERT INTO metrics VALUES (25, 'a', 5.0);
+SELECT day FROM metrics WHERE host = 'a';
+/* result:
+{
+  "day": 1
+}
+{
+  "day": 12
+}
+{
+  "day": 25
+}
+*/
+
+-- test: add partition / catalog
+ALTER TABLE metrics ADD PARTITION pmax VALUES LESS THAN (MAXVALUE);
+SELECT sql FROM __chai_catalog WHERE type = "table" AND name = "metrics";
+/* result:
+{
+  "sql": "CREATE TABLE metrics (day INTEGER NOT NULL, host TEXT NOT NULL, v DOUBLE, CONSTRAINT metrics_pk PRIMARY KEY (day, host)) PARTITION BY RANGE (day) (PARTITION p1 VALUES LESS THAN (10), PARTITION p2 VALUES LESS THAN (20), PARTITION pmax VALUES LESS THAN (MAXVALUE))"
+}
+*/
+
+-- test: add partition / bound too low
+ALTER TABLE metrics ADD PARTITION p0 VALUES LESS THAN (5);
+-- error: bound of partition p0 must be greater than the bound of partition p2
+
+-- test: add partition / duplicate name
+ALTER TABLE metrics ADD PARTITION p1 VALUES LESS THAN (40);
+-- error: duplicate partition name p1
+
+-- test: drop partition
+ALTER TABLE metrics DROP PARTITION p1;
+SELECT day, host FROM metrics;
+/* result:
+{
+  "day": 12,
+  "host": "a"
+}
+{
+  "day": 15,
+  "host": "b"
+}
+*/
+
+-- test: drop partition / index entries
+ALTER TABLE metrics DROP PARTITION p1;
+SELECT day FROM metrics WHERE host = 'b';
+/* result:
+{
+  "day": 15
+}
+*/
+
+-- test: drop partition / no partition for dropped values
+ALTER TABLE metrics DROP PARTITION p2;
+INSERT INTO metrics VALUES (15, 'c', 1.0);
+-- error: failed to insert row "(15, \"c\")": no partition of table metrics for value 15
+
+-- test: drop partition / unknown
+ALTER TABLE metrics DROP PARTITION p9;
+-- error: partition p9 of table metrics does not exist
+
+-- test: drop partition / last one
+ALTER TABLE metrics DROP PARTITION p1;
+ALTER TABLE metrics DROP PARTITION p2;
+-- error: cannot drop partition p2, the only partition of table metrics
+
+-- test: not partitioned
+CREATE TABLE t(a INT PRIMARY KEY);
+ALTER TABLE t DROP PARTITION p1;
+-- error: table t is not partitioned
+
+-- test: hash
+CREATE TABLE t(a INT PRIMARY KEY) PARTITION BY HASH (a) PARTITIONS 2;
+ALTER TABLE t DROP PARTITION p0;
+-- error: cannot drop a partition of table t, partitioned by HASH
+
+-- test: rename
+ALTER TABLE metrics RENAME TO m;
+SELECT name FROM __chai_catalog WHERE type = "partition" AND owner_table_name = "m" ORDER BY name;
+/* result:
+{
+  "name": "m/p1"
+}
+{
+  "name": "m/p2"
+}
+*/
@@ -0,0 +1,68 @@
+-- setup:
+CREATE TABLE logs(id INT PRIMARY KEY, msg TEXT, ts TIMESTAMP);
+
+-- test: set retention
+ALTER TABLE logs SET RETENTION (older_than = '30 days', column = 'ts');
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "logs";
+/* result:
+{
+  "name": "logs",
+  "sql": "CREATE TABLE logs (id INTEGER NOT NULL, msg TEXT, ts TIMESTAMP, CONSTRAINT logs_pk PRIMARY KEY (id)) WITH (retention = (older_than = '30 days', column = ts))"
+}
+*/
+
+-- test: replace retention
+ALTER TABLE logs SET RETENTION (older_than = '30 days', column = 'ts');
+ALTER TABLE logs SET RETENTION (column = ts, older_than = '48 HOURS');
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "logs";
+/* result:
+{
+  "name": "logs",
+  "sql": "CREATE TABLE logs (id INTEGER NOT NULL, msg TEXT, ts TIMESTAMP, CONSTRAINT logs_pk PRIMARY KEY (id)) WITH (retention = (older_than = '2 days', column = ts))"
+}
+*/
+
+-- test: drop retention
+ALTER TABLE logs SET RETENTION (older_than = '1 week', column = 'ts');
+ALTER TABLE logs DROP RETENTION;
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "logs";
+/* result:
+{
+  "name": "logs",
+  "sql": "CREATE TABLE logs (id INTEGER NOT NULL, msg TEXT, ts TIMESTAMP, CONSTRAINT logs_pk PRIMARY KEY (id))"
+}
+*/
+
+-- test: create table with retention
+CREATE TABLE events(ts TIMESTAMP, expires_at TIMESTAMP) WITH (ttl_field = expires_at, retention = (older_than = '90 minutes', column = ts));
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "events";
+/* result:
+{
+  "name": "events",
+  "sql": "CREATE TABLE events (ts TIMESTAMP, expires_at TIMESTAMP) WITH (ttl_field = expires_at, retention = (older_than = '90 minutes', column = ts))"
+}
+*/
+
+-- test: unknown column
+ALTER TABLE logs SET RETENTION (older_than = '30 days', column = 'foo');
+-- error: column "foo" does not exist for table "logs"
+
+-- test: not a timestamp
+ALTER TABLE logs SET RETENTION (older_than = '30 days', column = 'msg');
+-- error: retention column "msg" must be of type TIMESTAMP
+
+-- test: invalid period
+ALTER TABLE logs SET RETENTION (older_than = '30 fortnights', column = 'ts');
+-- error: invalid retention period unit "fortnights" at line 1, char 1
+
+-- test: negative period
+ALTER TABLE logs SET RETENTION (older_than = '-1 day', column = 'ts');
+-- error:
+
+-- test: missing option
+ALTER TABLE logs SET RETENTION (older_than = '30 days');
+-- error: retention policy requires the older_than and column options at line 1, char 1
+
+-- test: unknown table
+ALTER TABLE foo SET RETENTION (older_than = '30 days', column = 'ts');
+-- error:
@@ -0,0 +1,82 @@
+-- setup:
+CREATE TABLE test (a int PRIMARY KEY, b int, c text);
+INSERT INTO test (a, b, c) VALUES (1, 30, 'x'), (2, 10, 'y'), (3, 20, 'x'), (4, NULL, NULL), (5, 10, NULL);
+
+-- test: entries are written in index order
+CREATE INDEX test_b_idx ON test(b DESC, a);
+SELECT a, b FROM test WHERE b > 0 ORDER BY b DESC;
+/* result:
+{
+  "a": 1,
+  "b": 30
+}
+{
+  "a": 3,
+  "b": 20
+}
+{
+  "a": 2,
+  "b": 10
+}
+{
+  "a": 5,
+  "b": 10
+}
+*/
+
+-- test: progress
+CREATE INDEX test_b_idx ON test(b);
+SELECT index_name, table_name, phase, rows_scanned, entries_written, error FROM __chai_index_builds;
+/* result:
+{
+  "index_name": "test_b_idx",
+  "table_name": "test",
+  "phase": "done",
+  "rows_scanned": 5,
+  "entries_written": 5,
+  "error": null
+}
+*/
+
+-- test: progress table is read-only
+INSERT INTO __chai_index_builds (index_name) VALUES ('foo');
+-- error:
+
+-- test: unique
+CREATE UNIQUE INDEX test_c_idx ON test(a, c);
+SELECT COUNT(*) FROM test WHERE a > 0 AND c = 'x';
+/* result:
+{
+  "COUNT(*)": 2
+}
+*/
+
+-- test: unique with duplicates
+CREATE UNIQUE INDEX test_b_idx ON test(b);
+-- error: UNIQUE constraint "test_b_idx" error: [b]
+
+-- test: unique with duplicate NULLs
+CREATE UNIQUE INDEX test_c_idx ON test(c, a);
+SELECT phase FROM __chai_index_builds WHERE index_name = 'test_c_idx';
+/* result:
+{
+  "phase": "done"
+}
+*/
+
+-- test: failed build
+CREATE UNIQUE INDEX test_c_idx ON test(c);
+-- error: UNIQUE constraint "test_c_idx" error: [c]
+
+-- test: REINDEX
+CREATE INDEX test_b_idx ON test(b);
+REINDEX test_b_idx;
+SELECT a FROM test WHERE b = 10 ORDER BY a;
+/* result:
+{
+  "a": 2
+}
+{
+  "a": 5
+}
+*/
@@ -0,0 +1,129 @@
+-- test: AUTOINCREMENT
+CREATE TABLE test(id INTEGER PRIMARY KEY AUTOINCREMENT, a TEXT);
+SELECT name, sql FROM __chai_catalog WHERE name = "test" OR name = "test_id_seq";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (id INTEGER NOT NULL DEFAULT NEXT VALUE FOR test_id_seq, a TEXT, CONSTRAINT test_pk PRIMARY KEY (id))"
+}
+{
+  "name": "test_id_seq",
+  "sql": "CREATE SEQUENCE test_id_seq MAXVALUE 2147483647 CACHE 64"
+}
+*/
+
+-- test: SERIAL
+CREATE TABLE test(id SERIAL PRIMARY KEY, a TEXT);
+SELECT name, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (id INTEGER NOT NULL DEFAULT NEXT VALUE FOR test_id_seq, a TEXT, CONSTRAINT test_pk PRIMARY KEY (id))"
+}
+*/
+
+-- test: BIGSERIAL
+CREATE TABLE test(id BIGSERIAL PRIMARY KEY, a TEXT);
+SELECT name, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (id BIGINT NOT NULL DEFAULT NEXT VALUE FOR test_id_seq, a TEXT, CONSTRAINT test_pk PRIMARY KEY (id))"
+}
+*/
+
+-- test: insert
+CREATE TABLE test(id INTEGER PRIMARY KEY AUTOINCREMENT, a TEXT);
+INSERT INTO test (a) VALUES ('a'), ('b');
+INSERT INTO test (id, a) VALUES (10, 'c');
+INSERT INTO test (a) VALUES ('d');
+SELECT * FROM test;
+/* result:
+{
+  "id": 1,
+  "a": "a"
+}
+{
+  "id": 2,
+  "a": "b"
+}
+{
+  "id": 3,
+  "a": "d"
+}
+{
+  "id": 10,
+  "a": "c"
+}
+*/
+
+-- test: not primary key
+CREATE TABLE test(a TEXT, b BIGSERIAL);
+INSERT INTO test (a) VALUES ('a'), ('b');
+SELECT * FROM test;
+/* result:
+{
+  "a": "a",
+  "b": 1
+}
+{
+  "a": "b",
+  "b": 2
+}
+*/
+
+-- test: indexes
+CREATE TABLE test(id SERIAL PRIMARY KEY, a TEXT, b INT, c DOUBLE);
+CREATE INDEX test_a_idx ON test(a);
+CREATE UNIQUE INDEX test_b_c_idx ON test(b, c);
+INSERT INTO test (a, b, c) VALUES ('a', 1, 1.5), ('b', 2, 2.5), ('a', 3, 3.5);
+UPDATE test SET b = 10 WHERE a = 'b';
+DELETE FROM test WHERE b = 3 AND c = 3.5;
+SELECT * FROM test WHERE a = 'a' OR b >= 10;
+/* result:
+{
+  "id": 1,
+  "a": "a",
+  "b": 1,
+  "c": 1.5
+}
+{
+  "id": 2,
+  "a": "b",
+  "b": 10,
+  "c": 2.5
+}
+*/
+
+-- test: drop table
+CREATE TABLE test(id SERIAL PRIMARY KEY, a TEXT);
+DROP TABLE test;
+SELECT COUNT(*) FROM __chai_catalog WHERE name = "test_id_seq";
+/* result:
+{
+  "COUNT(*)": 0
+}
+*/
+
+-- test: owned sequence
+CREATE TABLE test(id SERIAL PRIMARY KEY, a TEXT);
+DROP SEQUENCE test_id_seq;
+-- error: cannot drop sequence test_id_seq because constraint of table test requires it
+
+-- test: with default
+CREATE TABLE test(id INTEGER AUTOINCREMENT DEFAULT 10);
+-- error: auto-incremented column "id" cannot have a default value at line 1, char 1
+
+-- test: invalid type
+CREATE TABLE test(id TEXT AUTOINCREMENT);
+-- error: auto-incremented column "id" must be of type INTEGER or BIGINT at line 1, char 1
+
+-- test: if not exists
+CREATE TABLE test(id SERIAL PRIMARY KEY);
+CREATE TABLE IF NOT EXISTS test(id SERIAL PRIMARY KEY);
+SELECT name FROM __chai_catalog WHERE type = "sequence" AND name LIKE 'test%';
+/* result:
+{
+  "name": "test_id_seq"
+}
+*/
@@ -34,3 +34,13 @@ SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
   "sql": "CREATE TABLE test (a INTEGER)"
 }
 */
+
+-- test: non-reserved keyword as name
+CREATE TABLE vacuum(vacuum int);
+INSERT INTO vacuum (vacuum) VALUES (1);
+SELECT vacuum FROM vacuum;
+/* result:
+{
+  "vacuum": 1
+}
+*/
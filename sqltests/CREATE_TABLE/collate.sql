@@ -0,0 +1,39 @@
+-- test: COLLATE
+CREATE TABLE test(id INTEGER PRIMARY KEY, a TEXT COLLATE NOCASE NOT NULL, b TEXT COLLATE "fr" UNIQUE, c TEXT DEFAULT 'x' COLLATE rtrim);
+SELECT name, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (id INTEGER NOT NULL, a TEXT NOT NULL COLLATE NOCASE, b TEXT COLLATE \"fr\", c TEXT DEFAULT \"x\" COLLATE RTRIM, CONSTRAINT test_pk PRIMARY KEY (id), CONSTRAINT test_b_unique UNIQUE (b))"
+}
+*/
+
+-- test: BINARY
+CREATE TABLE test(a TEXT COLLATE BINARY);
+SELECT name, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a TEXT COLLATE BINARY)"
+}
+*/
+
+-- test: unknown collation
+CREATE TABLE test(a TEXT COLLATE foo_bar);
+-- error: unknown collation "foo_bar" at line 1, char 34
+
+-- test: non-text column
+CREATE TABLE test(a INTEGER COLLATE NOCASE);
+-- error: collation NOCASE cannot be used with column "a" of type "integer"
+
+-- test: primary key
+CREATE TABLE test(a TEXT COLLATE NOCASE PRIMARY KEY);
+-- error: collated column "a" cannot be part of the primary key
+
+-- test: encrypted
+CREATE TABLE test(a TEXT COLLATE NOCASE ENCRYPTED);
+-- error:
+
+-- test: duplicate COLLATE
+CREATE TABLE test(a TEXT COLLATE NOCASE COLLATE BINARY);
+-- error:
@@ -0,0 +1,51 @@
+-- test: compression
+CREATE TABLE test (
+    id INT PRIMARY KEY,
+    a TEXT
+) WITH (compression = 'zstd');
+SELECT name, type, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  name: "test",
+  type: "table",
+  sql: "CREATE TABLE test (id INTEGER NOT NULL, a TEXT, CONSTRAINT test_pk PRIMARY KEY (id)) WITH (compression = 'zstd')"
+}
+*/
+
+-- test: compression: none
+CREATE TABLE test (
+    id INT PRIMARY KEY
+) WITH (compression = 'none');
+SELECT name, type, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  name: "test",
+  type: "table",
+  sql: "CREATE TABLE test (id INTEGER NOT NULL, CONSTRAINT test_pk PRIMARY KEY (id))"
+}
+*/
+
+-- test: compression: with other options
+CREATE TABLE test (
+    id INT PRIMARY KEY,
+    expires_at TIMESTAMP
+) WITH (compression = 'SNAPPY', ttl_field = expires_at);
+INSERT INTO test (id, expires_at) VALUES (1, '2999-01-01T00:00:00Z');
+SELECT sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  sql: "CREATE TABLE test (id INTEGER NOT NULL, expires_at TIMESTAMP, CONSTRAINT test_pk PRIMARY KEY (id)) WITH (ttl_field = expires_at, compression = 'snappy')"
+}
+*/
+
+-- test: compression: unknown codec
+CREATE TABLE test (
+    id INT PRIMARY KEY
+) WITH (compression = 'lz4');
+-- error:
+
+-- test: compression: not a string
+CREATE TABLE test (
+    id INT PRIMARY KEY
+) WITH (compression = zstd);
+-- error:
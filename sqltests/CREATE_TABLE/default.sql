@@ -48,3 +48,39 @@ CREATE TABLE test(a BLOB DEFAULT 1 AND 1);
 CREATE TABLE test(a BLOB DEFAULT b);
 -- error:
 
+
+-- test: function
+CREATE TABLE test(a TIMESTAMP DEFAULT now(), b TEXT DEFAULT lower('HELLO') || '!');
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a TIMESTAMP DEFAULT NOW(), b TEXT DEFAULT LOWER(\"HELLO\") || \"!\")"
+}
+*/
+
+-- test: cast
+CREATE TABLE test(a TEXT DEFAULT CAST(10 AS TEXT));
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a TEXT DEFAULT CAST(10 AS text))"
+}
+*/
+
+-- test: incompatible function
+CREATE TABLE test(a INTEGER DEFAULT now());
+-- error:
+
+-- test: forbidden function: aggregate
+CREATE TABLE test(a INTEGER DEFAULT count(1));
+-- error: aggregate functions are not allowed in default values at line 1, char 1
+
+-- test: forbidden function argument: column
+CREATE TABLE test(a TEXT, b TEXT DEFAULT lower(a));
+-- error: default value cannot reference column "a" at line 1, char 1
+
+-- test: forbidden function argument: param
+CREATE TABLE test(a TEXT DEFAULT lower(?));
+-- error: default value cannot contain parameters at line 1, char 1
@@ -0,0 +1,47 @@
+-- test: ENCRYPTED
+CREATE TABLE test(id INTEGER PRIMARY KEY, a TEXT ENCRYPTED NOT NULL, b TEXT ENCRYPTED WITH KEY 'b-key' ALLOW INDEX UNIQUE);
+SELECT name, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (id INTEGER NOT NULL, a TEXT NOT NULL ENCRYPTED WITH KEY \"test.a\", b TEXT ENCRYPTED WITH KEY \"b-key\" ALLOW INDEX, CONSTRAINT test_pk PRIMARY KEY (id), CONSTRAINT test_b_unique UNIQUE (b))"
+}
+*/
+
+-- test: ENCRYPTED ALLOW INDEX
+CREATE TABLE test(a INTEGER ENCRYPTED ALLOW INDEX);
+CREATE INDEX test_a_idx ON test(a);
+SELECT name, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTEGER ENCRYPTED WITH KEY \"test.a\" ALLOW INDEX)"
+}
+*/
+
+-- test: index without ALLOW INDEX
+CREATE TABLE test(a INTEGER ENCRYPTED);
+CREATE INDEX test_a_idx ON test(a);
+-- error:
+
+-- test: UNIQUE without ALLOW INDEX
+CREATE TABLE test(a INTEGER ENCRYPTED UNIQUE);
+-- error:
+
+-- test: primary key
+CREATE TABLE test(a INTEGER ENCRYPTED PRIMARY KEY);
+-- error:
+
+-- test: duplicate ENCRYPTED
+CREATE TABLE test(a INTEGER ENCRYPTED ENCRYPTED);
+-- error:
+
+-- test: empty key id
+CREATE TABLE test(a INTEGER ENCRYPTED WITH KEY '');
+-- error:
+
+-- test: writing without key
+CREATE TABLE test(a INTEGER ENCRYPTED);
+INSERT INTO test (a) VALUES (NULL);
+INSERT INTO test (a) VALUES (1);
+-- error:
@@ -0,0 +1,235 @@
+-- setup:
+CREATE TABLE events(ts TIMESTAMP, id INT, msg TEXT, PRIMARY KEY (ts, id)) PARTITION BY RANGE (ts) (
+    PARTITION p2023 VALUES LESS THAN ('2024-01-01'),
+    PARTITION p2024 VALUES LESS THAN ('2025-01-01'),
+    PARTITION pmax VALUES LESS THAN (MAXVALUE)
+);
+CREATE INDEX events_msg_idx ON events(msg);
+INSERT INTO events VALUES
+    ('2023-06-01', 1, 'a'),
+    ('2024-03-01', 2, 'b'),
+    ('2024-12-31T23:59:59Z', 3, 'c'),
+    ('2025-01-01', 4, 'a'),
+    ('2030-01-01', 5, 'b');
+
+-- test: catalog
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "events";
+/* result:
+{
+  "name": "events",
+  "sql": "CREATE TABLE events (ts TIMESTAMP NOT NULL, id INTEGER NOT NULL, msg TEXT, CONSTRAINT events_pk PRIMARY KEY (ts, id)) PARTITION BY RANGE (ts) (PARTITION p2023 VALUES LESS THAN (\"2024-01-01T00:00:00Z\"), PARTITION p2024 VALUES LESS THAN (\"2025-01-01T00:00:00Z\"), PARTITION pmax VALUES LESS THAN (MAXVALUE))"
+}
+*/
+
+-- test: partitions in catalog
+SELECT name, owner_table_name FROM __chai_catalog WHERE type = "partition" ORDER BY name;
+/* result:
+{
+  "name": "events/p2023",
+  "owner_table_name": "events"
+}
+{
+  "name": "events/p2024",
+  "owner_table_name": "events"
+}
+{
+  "name": "events/pmax",
+  "owner_table_name": "events"
+}
+{
+  "name": "events_msg_idx/p2023",
+  "owner_table_name": "events_msg_idx"
+}
+{
+  "name": "events_msg_idx/p2024",
+  "owner_table_name": "events_msg_idx"
+}
+{
+  "name": "events_msg_idx/pmax",
+  "owner_table_name": "events_msg_idx"
+}
+*/
+
+-- test: select all
+SELECT id FROM events;
+/* result:
+{
+  "id": 1
+}
+{
+  "id": 2
+}
+{
+  "id": 3
+}
+{
+  "id": 4
+}
+{
+  "id": 5
+}
+*/
+
+-- test: select range
+SELECT id FROM events WHERE ts >= '2024-01-01' AND ts < '2025-01-01';
+/* result:
+{
+  "id": 2
+}
+{
+  "id": 3
+}
+*/
+
+-- test: select across partitions
+SELECT id FROM events WHERE ts > '2024-06-01' AND ts < '2026-01-01';
+/* result:
+{
+  "id": 3
+}
+{
+  "id": 4
+}
+*/
+
+-- test: select reverse
+SELECT id FROM events ORDER BY ts DESC LIMIT 2;
+/* result:
+{
+  "id": 5
+}
+{
+  "id": 4
+}
+*/
+
+-- test: select by index
+SELECT id FROM events WHERE msg = 'a';
+/* result:
+{
+  "id": 1
+}
+{
+  "id": 4
+}
+*/
+
+-- test: update moves rows between partitions
+UPDATE events SET ts = '2023-01-01' WHERE id = 5;
+SELECT id, msg FROM events WHERE ts < '2024-01-01';
+/* result:
+{
+  "id": 5,
+  "msg": "b"
+}
+{
+  "id": 1,
+  "msg": "a"
+}
+*/
+
+-- test: delete
+DELETE FROM events WHERE msg = 'a';
+SELECT COUNT(*) AS n FROM events;
+/* result:
+{
+  "n": 3
+}
+*/
+
+-- test: duplicate primary key
+INSERT INTO events VALUES ('2024-03-01', 2, 'd');
+-- error:
+
+-- test: unique index across partitions
+CREATE UNIQUE INDEX events_msg_uniq ON events(id);
+INSERT INTO events VALUES ('2030-03-01', 2, 'd');
+-- error:
+
+-- test: hash
+CREATE TABLE users(id INT PRIMARY KEY, name TEXT) PARTITION BY HASH (id) PARTITIONS 4;
+CREATE INDEX users_name_idx ON users(name);
+INSERT INTO users VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd'), (5, 'e'), (6, 'a');
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "users";
+/* result:
+{
+  "name": "users",
+  "sql": "CREATE TABLE users (id INTEGER NOT NULL, name TEXT, CONSTRAINT users_pk PRIMARY KEY (id)) PARTITION BY HASH (id) PARTITIONS 4"
+}
+*/
+
+-- test: hash / select ordered
+CREATE TABLE users(id INT PRIMARY KEY, name TEXT) PARTITION BY HASH (id) PARTITIONS 4;
+INSERT INTO users VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd'), (5, 'e'), (6, 'a');
+SELECT id FROM users WHERE id > 2 ORDER BY id DESC;
+/* result:
+{
+  "id": 6
+}
+{
+  "id": 5
+}
+{
+  "id": 4
+}
+{
+  "id": 3
+}
+*/
+
+-- test: hash / point lookup and index
+CREATE TABLE users(id INT PRIMARY KEY, name TEXT) PARTITION BY HASH (id) PARTITIONS 4;
+CREATE INDEX users_name_idx ON users(name);
+INSERT INTO users VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd'), (5, 'e'), (6, 'a');
+DELETE FROM users WHERE id = 6;
+SELECT id FROM users WHERE name = 'a' OR id = 5;
+/* result:
+{
+  "id": 1
+}
+{
+  "id": 5
+}
+*/
+
+-- test: no partition for value
+CREATE TABLE t(a INT PRIMARY KEY) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (10));
+INSERT INTO t VALUES (10);
+-- error: failed to insert row "(10)": no partition of table t for value 10
+
+-- test: not the primary key
+CREATE TABLE t(a INT PRIMARY KEY, b INT) PARTITION BY RANGE (b) (PARTITION p1 VALUES LESS THAN (10));
+-- error: partition column b must be the first column of the primary key
+
+-- test: no primary key
+CREATE TABLE t(a INT) PARTITION BY HASH (a) PARTITIONS 2;
+-- error: partition column a must be the first column of the primary key
+
+-- test: descending
+CREATE TABLE t(a INT, PRIMARY KEY (a DESC)) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (10));
+-- error: partition column a must be sorted in ascending order
+
+-- test: bounds not increasing
+CREATE TABLE t(a INT PRIMARY KEY) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (10), PARTITION p2 VALUES LESS THAN (10));
+-- error: bound of partition p2 must be greater than the bound of partition p1
+
+-- test: MAXVALUE not last
+CREATE TABLE t(a INT PRIMARY KEY) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (MAXVALUE), PARTITION p2 VALUES LESS THAN (10));
+-- error: partition p1 must be the last partition since its bound is MAXVALUE
+
+-- test: duplicate name
+CREATE TABLE t(a INT PRIMARY KEY) PARTITION BY RANGE (a) (PARTITION p1 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (20));
+-- error: duplicate partition name p1
+
+-- test: temporary
+CREATE TEMP TABLE t(a INT PRIMARY KEY) PARTITION BY HASH (a) PARTITIONS 2;
+-- error: temporary tables cannot be partitioned
+
+-- test: drop table
+DROP TABLE events;
+SELECT COUNT(*) AS n FROM __chai_catalog WHERE type = "partition";
+/* result:
+{
+  "n": 0
+}
+*/
@@ -0,0 +1,84 @@
+-- setup:
+CREATE TABLE other (a INT, b TEXT, c DOUBLE);
+INSERT INTO other VALUES (1, 'x', 1.5);
+
+-- test: strict
+CREATE TABLE test (
+    a INT,
+    b TEXT
+) WITH (strict = true);
+SELECT name, type, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  name: "test",
+  type: "table",
+  sql: "CREATE TABLE test (a INTEGER, b TEXT) WITH (strict = true)"
+}
+*/
+
+-- test: strict: false
+CREATE TABLE test (
+    a INT
+) WITH (strict = false);
+SELECT name, type, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  name: "test",
+  type: "table",
+  sql: "CREATE TABLE test (a INTEGER)"
+}
+*/
+
+-- test: strict: declared columns
+CREATE TABLE test (a INT, b TEXT) WITH (strict = true);
+INSERT INTO test (a) VALUES (1);
+INSERT INTO test SELECT a, b FROM other;
+UPDATE test SET b = 'y' WHERE a = 1 AND b IS NULL;
+SELECT * FROM test;
+/* result:
+{
+  a: 1,
+  b: "y"
+}
+{
+  a: 1,
+  b: "x"
+}
+*/
+
+-- test: strict: upsert
+CREATE TABLE test (a INT PRIMARY KEY, b TEXT) WITH (strict = true);
+INSERT INTO test (a, b) VALUES (1, 'x');
+INSERT INTO test (a, b) VALUES (1, 'y') ON CONFLICT DO REPLACE;
+SELECT * FROM test;
+/* result:
+{
+  a: 1,
+  b: "y"
+}
+*/
+
+-- test: strict: undeclared column
+CREATE TABLE test (a INT, b TEXT) WITH (strict = true);
+INSERT INTO test SELECT * FROM other;
+-- error: table has no column c
+
+-- test: not strict: undeclared column is ignored
+CREATE TABLE test (a INT, b TEXT);
+INSERT INTO test SELECT * FROM other;
+SELECT * FROM test;
+/* result:
+{
+  a: 1,
+  b: "x"
+}
+*/
+
+-- test: too many values
+CREATE TABLE test (a INT, b TEXT);
+INSERT INTO test VALUES (1, 'x', 3);
+-- error: table has 2 columns, got 3 values
+
+-- test: strict: not a boolean
+CREATE TABLE test (a INT) WITH (strict = 1);
+-- error:
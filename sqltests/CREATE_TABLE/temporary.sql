@@ -0,0 +1,160 @@
+-- setup:
+CREATE TABLE base(id INT PRIMARY KEY, a TEXT);
+INSERT INTO base (id, a) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+
+-- test: basic
+CREATE TEMP TABLE test(id INT PRIMARY KEY, a TEXT);
+INSERT INTO test (id, a) SELECT id, a FROM base WHERE id > 1;
+SELECT * FROM test;
+/* result:
+{"id": 2, "a": "b"}
+{"id": 3, "a": "c"}
+*/
+
+-- test: TEMPORARY
+CREATE TEMPORARY TABLE test(a INT);
+INSERT INTO test (a) VALUES (1), (2);
+SELECT COUNT(*) FROM test;
+/* result:
+{"COUNT(*)": 2}
+*/
+
+-- test: not stored in the catalog
+CREATE TEMP TABLE test(a INT UNIQUE, b SERIAL);
+SELECT name FROM __chai_catalog WHERE name LIKE 'test%';
+/* result:
+*/
+
+-- test: information schema
+CREATE TEMP TABLE test(a INT);
+SELECT table_name, table_type FROM information_schema.tables;
+/* result:
+{"table_name": "base", "table_type": "BASE TABLE"}
+{"table_name": "test", "table_type": "LOCAL TEMPORARY"}
+*/
+
+-- test: indexes
+CREATE TEMP TABLE test(a INT UNIQUE, b INT);
+CREATE INDEX test_b ON test(b);
+INSERT INTO test (a, b) VALUES (1, 10), (2, 20), (3, 30);
+SELECT a FROM test WHERE b = 20;
+/* result:
+{"a": 2}
+*/
+
+-- test: unique
+CREATE TEMP TABLE test(a INT UNIQUE);
+INSERT INTO test (a) VALUES (1), (1);
+-- error:
+
+-- test: serial
+CREATE TEMP TABLE test(id SERIAL PRIMARY KEY, a TEXT);
+INSERT INTO test (a) VALUES ('a'), ('b');
+SELECT * FROM test;
+/* result:
+{"id": 1, "a": "a"}
+{"id": 2, "a": "b"}
+*/
+
+-- test: drop
+CREATE TEMP TABLE test(a INT);
+DROP TABLE test;
+CREATE TEMP TABLE test(b INT);
+INSERT INTO test (b) VALUES (1);
+SELECT * FROM test;
+/* result:
+{"b": 1}
+*/
+
+-- test: same name as a table
+CREATE TEMP TABLE base(a INT);
+-- error:
+
+-- test: schema
+CREATE SCHEMA s;
+CREATE TEMP TABLE s.test(a INT);
+-- error: cannot create temporary table s.test in schema s
+
+-- test: if not exists
+CREATE TEMP TABLE test(a INT);
+CREATE TEMP TABLE IF NOT EXISTS test(b INT);
+INSERT INTO test (a) VALUES (1);
+SELECT * FROM test;
+/* result:
+{"a": 1}
+*/
+
+-- test: alter
+CREATE TEMP TABLE test(a INT);
+INSERT INTO test (a) VALUES (1);
+ALTER TABLE test ADD COLUMN b INT DEFAULT 10;
+ALTER TABLE test RENAME TO test2;
+SELECT * FROM test2;
+/* result:
+{"a": 1, "b": 10}
+*/
+
+-- test: on commit preserve rows
+CREATE TEMP TABLE test(a INT) ON COMMIT PRESERVE ROWS;
+INSERT INTO test (a) VALUES (1);
+SELECT * FROM test;
+/* result:
+{"a": 1}
+*/
+
+-- test: on commit delete rows
+CREATE TEMP TABLE test(a INT) ON COMMIT DELETE ROWS;
+BEGIN;
+INSERT INTO test (a) VALUES (10);
+INSERT INTO base (id, a) SELECT a, 'x' FROM test;
+COMMIT;
+SELECT id FROM base WHERE id = 10;
+/* result:
+{"id": 10}
+*/
+
+-- test: on commit delete rows after commit
+CREATE TEMP TABLE test(a INT) ON COMMIT DELETE ROWS;
+INSERT INTO test (a) VALUES (1);
+SELECT COUNT(*) FROM test;
+/* result:
+{"COUNT(*)": 0}
+*/
+
+-- test: on commit drop
+BEGIN;
+CREATE TEMP TABLE test(a INT) ON COMMIT DROP;
+INSERT INTO test (a) SELECT id FROM base;
+INSERT INTO base (id, a) SELECT a + 10, 'x' FROM test;
+COMMIT;
+SELECT COUNT(*) FROM base;
+/* result:
+{"COUNT(*)": 6}
+*/
+
+-- test: on commit drop after commit
+CREATE TEMP TABLE test(a INT) ON COMMIT DROP;
+SELECT * FROM test;
+-- error:
+
+-- test: rollback
+BEGIN;
+CREATE TEMP TABLE test(a INT);
+ROLLBACK;
+SELECT * FROM test;
+-- error:
+
+-- test: rollback rows
+CREATE TEMP TABLE test(a INT);
+INSERT INTO test (a) VALUES (1);
+BEGIN;
+INSERT INTO test (a) VALUES (2);
+ROLLBACK;
+SELECT * FROM test;
+/* result:
+{"a": 1}
+*/
+
+-- test: on commit on regular table
+CREATE TABLE test(a INT) ON COMMIT DROP;
+-- error:
@@ -0,0 +1,33 @@
+-- test: ttl_field
+CREATE TABLE test (
+    id INT PRIMARY KEY,
+    expires_at TIMESTAMP
+) WITH (ttl_field = expires_at);
+SELECT name, type, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  name: "test",
+  type: "table",
+  sql: "CREATE TABLE test (id INTEGER NOT NULL, expires_at TIMESTAMP, CONSTRAINT test_pk PRIMARY KEY (id)) WITH (ttl_field = expires_at)"
+}
+*/
+
+-- test: ttl_field: undeclared column
+CREATE TABLE test (
+    id INT PRIMARY KEY
+) WITH (ttl_field = expires_at);
+-- error:
+
+-- test: ttl_field: not a timestamp
+CREATE TABLE test (
+    id INT PRIMARY KEY,
+    expires_at INT
+) WITH (ttl_field = expires_at);
+-- error:
+
+-- test: unknown option
+CREATE TABLE test (
+    id INT PRIMARY KEY,
+    expires_at TIMESTAMP
+) WITH (foo = expires_at);
+-- error:
@@ -38,6 +38,44 @@ SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
 }
 */
 
+-- test: DATE
+CREATE TABLE test (a DATE, date TEXT);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a DATE, date TEXT)"
+}
+*/
+
+-- test: DECIMAL
+CREATE TABLE test (a DECIMAL, b NUMERIC(10, 2), c DECIMAL(5), decimal TEXT);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a DECIMAL, b DECIMAL(10, 2), c DECIMAL(5, 0), decimal TEXT)"
+}
+*/
+
+-- test: DECIMAL with invalid precision
+CREATE TABLE test (a DECIMAL(1001, 2));
+-- error:
+
+-- test: DECIMAL with invalid scale
+CREATE TABLE test (a DECIMAL(2, 3));
+-- error:
+
+-- test: INTERVAL
+CREATE TABLE test (a INTERVAL, interval TEXT);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTERVAL, interval TEXT)"
+}
+*/
+
 -- test: BLOB
 CREATE TABLE test (a BLOB);
 SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
@@ -141,3 +179,31 @@ SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
   "sql": "CREATE TABLE test (a TEXT)"
 }
 */
+
+-- test: UUID
+CREATE TABLE test (a UUID PRIMARY KEY DEFAULT gen_random_uuid(), uuid TEXT);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a UUID NOT NULL DEFAULT gen_random_uuid(), uuid TEXT, CONSTRAINT test_pk PRIMARY KEY (a))"
+}
+*/
+
+-- test: VECTOR
+CREATE TABLE test (a VECTOR(3), vector TEXT);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a VECTOR(3), vector TEXT)"
+}
+*/
+
+-- test: VECTOR without dimension
+CREATE TABLE test (a VECTOR);
+-- error:
+
+-- test: VECTOR with invalid dimension
+CREATE TABLE test (a VECTOR(0));
+-- error:
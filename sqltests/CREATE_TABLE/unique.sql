@@ -78,6 +78,25 @@ WHERE
 }
 */
 
+-- test: table constraint: named, multiple columns
+CREATE TABLE test(a INT, b INT, CONSTRAINT uq UNIQUE (a, b));
+SELECT name, sql 
+FROM __chai_catalog 
+WHERE 
+    (type = "table" AND name = "test") 
+  OR
+    (type = "index" AND name = "test_a_b_idx");
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTEGER, b INTEGER, CONSTRAINT uq UNIQUE (a, b))"
+}
+{
+  "name": "test_a_b_idx",
+  "sql": "CREATE UNIQUE INDEX test_a_b_idx ON test (a, b)"
+}
+*/
+
 -- test: table constraint: multiple columns with order
 CREATE TABLE test(a INT, b INT, c INT, UNIQUE(a DESC, b ASC, c));
 SELECT name, sql 
@@ -135,3 +154,19 @@ WHERE
   "sql": "CREATE UNIQUE INDEX test_b_idx ON test (b)"
 }
 */
+
+-- test: deferrable
+CREATE TABLE test(a INT UNIQUE DEFERRABLE INITIALLY DEFERRED, b INT, c INT, UNIQUE (b) DEFERRABLE, UNIQUE (c) DEFERRABLE INITIALLY IMMEDIATE);
+SELECT name, sql
+FROM __chai_catalog
+WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTEGER, b INTEGER, c INTEGER, CONSTRAINT test_a_unique UNIQUE (a) DEFERRABLE INITIALLY DEFERRED, CONSTRAINT test_b_unique UNIQUE (b) DEFERRABLE, CONSTRAINT test_c_unique UNIQUE (c) DEFERRABLE)"
+}
+*/
+
+-- test: deferrable: invalid
+CREATE TABLE test(a INT UNIQUE DEFERRABLE INITIALLY LATER);
+-- error:
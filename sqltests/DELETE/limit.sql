@@ -0,0 +1,51 @@
+-- setup:
+CREATE TABLE events(id INT PRIMARY KEY, ts INT, kind TEXT);
+CREATE INDEX events_ts ON events(ts);
+INSERT INTO events (id, ts, kind) VALUES (1, 50, 'a'), (2, 40, 'b'), (3, 30, 'a'), (4, 20, 'b'), (5, 10, 'a');
+
+-- test: limit
+DELETE FROM events LIMIT 2;
+SELECT id FROM events;
+/* result:
+{"id": 3}
+{"id": 4}
+{"id": 5}
+*/
+
+-- test: order by and limit
+DELETE FROM events WHERE kind = 'a' ORDER BY ts LIMIT 2;
+SELECT id FROM events;
+/* result:
+{"id": 1}
+{"id": 2}
+{"id": 4}
+*/
+
+-- test: order by desc, limit and offset
+DELETE FROM events ORDER BY ts DESC LIMIT 2 OFFSET 1;
+SELECT id FROM events;
+/* result:
+{"id": 1}
+{"id": 4}
+{"id": 5}
+*/
+
+-- test: chunks
+DELETE FROM events WHERE ts < 45 ORDER BY ts LIMIT 3;
+DELETE FROM events WHERE ts < 45 ORDER BY ts LIMIT 3;
+SELECT id FROM events;
+/* result:
+{"id": 1}
+*/
+
+-- test: index is used to sort
+EXPLAIN DELETE FROM events WHERE ts > 15 ORDER BY ts LIMIT 2;
+/* result:
+{
+    "plan": 'index.Scan("events_ts", [{"min": (15), "exclusive": true}]) | rows.Take(2) | index.Delete("events_ts") | table.Delete(\'events\') | discard()'
+}
+*/
+
+-- test: limit not a number
+DELETE FROM events LIMIT 'a';
+-- error:
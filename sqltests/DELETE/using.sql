@@ -0,0 +1,52 @@
+-- setup:
+CREATE TABLE accounts(id INT PRIMARY KEY, name TEXT);
+CREATE TABLE closed(account_id INT, reason TEXT);
+INSERT INTO accounts (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd');
+INSERT INTO closed (account_id, reason) VALUES (2, 'fraud'), (3, 'request'), (3, 'request'), (5, 'request');
+
+-- test: delete using
+DELETE FROM accounts USING closed WHERE accounts.id = closed.account_id;
+SELECT id FROM accounts;
+/* result:
+{"id": 1}
+{"id": 4}
+*/
+
+-- test: condition on both tables
+DELETE FROM accounts USING closed WHERE id = account_id AND reason = 'request' AND name != 'd';
+SELECT id FROM accounts;
+/* result:
+{"id": 1}
+{"id": 2}
+{"id": 4}
+*/
+
+-- test: with indexes
+CREATE INDEX accounts_name ON accounts(name);
+DELETE FROM accounts USING closed WHERE accounts.id = closed.account_id;
+SELECT name FROM accounts WHERE name >= 'a';
+/* result:
+{"name": "a"}
+{"name": "d"}
+*/
+
+-- test: plan
+EXPLAIN DELETE FROM accounts USING closed WHERE accounts.id = closed.account_id AND closed.reason = 'fraud';
+/* result:
+{
+    "plan": 'table.Scan("accounts") | stream.Join(table.Scan("closed"), id = account_id AND reason = "fraud") | table.Delete(\'accounts\') | discard()'
+}
+*/
+
+-- test: USING as a column name
+CREATE TABLE t(using INT);
+INSERT INTO t (using) VALUES (1), (2);
+DELETE FROM t WHERE using = 1;
+SELECT * FROM t;
+/* result:
+{"using": 2}
+*/
+
+-- test: with order by
+DELETE FROM accounts USING closed ORDER BY id;
+-- error: ORDER BY, LIMIT and OFFSET cannot be used with USING
@@ -0,0 +1,139 @@
+-- setup:
+CREATE TABLE test(
+    k INT PRIMARY KEY,
+    a INT NOT NULL,
+    b INT UNIQUE,
+    c INT CHECK (c > 0)
+);
+CREATE INDEX test_a ON test(a);
+INSERT INTO test (k, a, b, c) VALUES (1, 1, 1, 1), (2, 2, 2, 2), (3, 3, 3, 3), (4, 4, 4, 4);
+CREATE TABLE other(x INT);
+INSERT INTO other (x) VALUES (10), (20);
+
+-- test: without ANALYZE
+EXPLAIN DELETE FROM test WHERE c < 4;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(c < 4) | index.Delete("test_a") | index.Delete("test_b_idx") | table.Delete(\'test\') | discard()'
+}
+*/
+
+-- test: SELECT
+EXPLAIN ANALYZE SELECT * FROM test WHERE a > 1;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (1), "exclusive": true}])',
+    "rows": 3
+}
+*/
+
+-- test: INSERT VALUES
+EXPLAIN ANALYZE INSERT INTO test (k, a) VALUES (5, 5), (6, 6);
+/* result:
+{
+    "plan": 'rows.Emit((5, 5), (6, 6)) | table.Validate("test") | index.Validate("test_b_idx") | table.Insert("test") | index.Insert("test_a") | index.Insert("test_b_idx") | discard()',
+    "table": "test",
+    "indexes": "test_a, test_b_idx",
+    "constraints": "PRIMARY KEY (k), UNIQUE (b), CHECK (c > 0), NOT NULL (k), NOT NULL (a)",
+    "rows": 2
+}
+*/
+
+-- test: INSERT SELECT
+EXPLAIN ANALYZE INSERT INTO test (k, a) SELECT x, x FROM other;
+/* result:
+{
+    "plan": 'table.Scan("other") | rows.Project(x, x) | paths.Rename(k, a) | table.Validate("test") | index.Validate("test_b_idx") | table.Insert("test") | index.Insert("test_a") | index.Insert("test_b_idx") | discard()',
+    "table": "test",
+    "indexes": "test_a, test_b_idx",
+    "constraints": "PRIMARY KEY (k), UNIQUE (b), CHECK (c > 0), NOT NULL (k), NOT NULL (a)",
+    "rows": 2
+}
+*/
+
+-- test: INSERT SELECT from the same table
+EXPLAIN ANALYZE INSERT INTO test (k, a) SELECT k + 10, a FROM test WHERE a > 2;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (2), "exclusive": true}]) | rows.Project(k + 10, a) | rows.Materialize() | paths.Rename(k, a) | table.Validate("test") | index.Validate("test_b_idx") | table.Insert("test") | index.Insert("test_a") | index.Insert("test_b_idx") | discard()',
+    "table": "test",
+    "indexes": "test_a, test_b_idx",
+    "constraints": "PRIMARY KEY (k), UNIQUE (b), CHECK (c > 0), NOT NULL (k), NOT NULL (a)",
+    "rows": 2
+}
+*/
+
+-- test: UPDATE
+EXPLAIN ANALYZE UPDATE test SET c = 10 WHERE a > 2;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (2), "exclusive": true}]) | paths.Set(c, 10) | table.Validate("test") | index.Delete("test_a") | index.Delete("test_b_idx") | table.Replace("test") | index.Insert("test_a") | index.Validate("test_b_idx") | index.Insert("test_b_idx") | discard()',
+    "table": "test",
+    "indexes": "test_a, test_b_idx",
+    "constraints": "PRIMARY KEY (k), UNIQUE (b), CHECK (c > 0), NOT NULL (k), NOT NULL (a)",
+    "rows": 2
+}
+*/
+
+-- test: DELETE
+EXPLAIN ANALYZE DELETE FROM test WHERE c < 4;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(c < 4) | index.Delete("test_a") | index.Delete("test_b_idx") | table.Delete(\'test\') | discard()',
+    "table": "test",
+    "indexes": "test_a, test_b_idx",
+    "constraints": "",
+    "rows": 3
+}
+*/
+
+-- test: nothing is written
+EXPLAIN ANALYZE DELETE FROM test;
+SELECT COUNT(*) FROM test;
+/* result:
+{
+    "COUNT(*)": 4
+}
+*/
+
+-- test: CREATE INDEX
+EXPLAIN ANALYZE CREATE UNIQUE INDEX test_c ON test(c);
+/* result:
+{
+    "plan": 'table.Scan("test") | index.Build("test_c") | discard()',
+    "table": "test",
+    "indexes": "test_c",
+    "constraints": "UNIQUE (c)",
+    "rows": 4
+}
+*/
+
+-- test: CREATE INDEX without name
+EXPLAIN ANALYZE CREATE INDEX ON other(x);
+/* result:
+{
+    "plan": 'table.Scan("other") | index.Build("other_x_idx") | discard()',
+    "table": "other",
+    "indexes": "other_x_idx",
+    "constraints": "",
+    "rows": 2
+}
+*/
+
+-- test: CREATE INDEX doesn't create the index
+EXPLAIN ANALYZE CREATE INDEX test_c ON test(c);
+SELECT name FROM __chai_catalog WHERE name = 'test_c';
+/* result:
+*/
+
+-- test: CREATE INDEX on unknown column
+EXPLAIN CREATE INDEX test_d ON test(d);
+-- error:
+
+-- test: CREATE INDEX on existing index
+EXPLAIN CREATE INDEX test_a ON test(a);
+-- error:
+
+-- test: CREATE TABLE
+EXPLAIN CREATE TABLE foo(a INT);
+-- error:
@@ -0,0 +1,133 @@
+-- setup:
+CREATE FUNCTION area(w, h) RETURNS (w * h);
+CREATE TABLE test (id INT PRIMARY KEY, w INT, h INT);
+INSERT INTO test VALUES (1, 2, 3), (2, 4, 5), (3, 1, NULL);
+
+-- test: catalog
+SELECT name, type, sql FROM __chai_catalog WHERE type = "function";
+/* result:
+{
+  "name": "area",
+  "type": "function",
+  "sql": "CREATE FUNCTION area(w, h) RETURNS (w * h)"
+}
+*/
+
+-- test: call
+SELECT area(2, 10) AS a, AREA(1.5, 2) AS b;
+/* result:
+{
+  "a": 20,
+  "b": 3.0
+}
+*/
+
+-- test: columns
+SELECT id, area(w, h) AS a FROM test ORDER BY area(w, h);
+/* result:
+{
+  "id": 3,
+  "a": null
+}
+{
+  "id": 1,
+  "a": 6
+}
+{
+  "id": 2,
+  "a": 20
+}
+*/
+
+-- test: where
+SELECT id FROM test WHERE area(w, h + 1) > 10;
+/* result:
+{
+  "id": 2
+}
+*/
+
+-- test: update and delete
+UPDATE test SET w = area(w, 2) WHERE id = 1;
+DELETE FROM test WHERE area(w, h) = 20;
+SELECT id, w FROM test WHERE id < 3;
+/* result:
+{
+  "id": 1,
+  "w": 4
+}
+*/
+
+-- test: without parameters
+CREATE FUNCTION answer() RETURNS (42);
+SELECT answer() AS a;
+/* result:
+{
+  "a": 42
+}
+*/
+
+-- test: nested
+CREATE FUNCTION volume(w, h, d) RETURNS (area(w, h) * d);
+SELECT volume(2, 3, 4) AS v;
+/* result:
+{
+  "v": 24
+}
+*/
+
+-- test: already exists
+CREATE FUNCTION area(a, b) RETURNS (a + b);
+-- error:
+
+-- test: if not exists
+CREATE FUNCTION IF NOT EXISTS area(a, b) RETURNS (a + b);
+SELECT area(2, 3) AS a;
+/* result:
+{
+  "a": 6
+}
+*/
+
+-- test: builtin
+CREATE FUNCTION len(a) RETURNS (a);
+-- error: cannot redefine builtin function len
+
+-- test: unknown column
+CREATE FUNCTION f(a) RETURNS (a + b);
+-- error: column b is not a parameter of function f
+
+-- test: duplicate parameter
+CREATE FUNCTION f(a, a) RETURNS (a);
+-- error: parameter a specified more than once
+
+-- test: aggregate
+CREATE FUNCTION f(a) RETURNS (MAX(a));
+-- error: aggregate functions are not allowed in the body of function f
+
+-- test: unknown function
+CREATE FUNCTION f(a) RETURNS (g(a));
+-- error: no such function: "g"
+
+-- test: call unknown function
+SELECT foo(1);
+-- error: no such function: "foo"
+
+-- test: wrong number of arguments
+SELECT area(1);
+-- error: area() takes 2 argument(s), not 1
+
+-- test: drop
+DROP FUNCTION area;
+SELECT area(1, 2);
+-- error: no such function: "area"
+
+-- test: drop if exists
+DROP FUNCTION IF EXISTS foo;
+DROP FUNCTION foo;
+-- error:
+
+-- test: drop with dependent
+CREATE FUNCTION volume(w, h, d) RETURNS (area(w, h) * d);
+DROP FUNCTION area;
+-- error: cannot drop function area because function volume depends on it
@@ -26,7 +26,7 @@ SELECT * FROM test;
 -- test: non-boolean check constraint
 CREATE TABLE test (a text CHECK("hello"));
 INSERT INTO test (a) VALUES ("hello");
--- error: row violates check constraint "test_check"
+-- error: row violates check constraint "test_check" ("hello")
 
 -- test: non-boolean check constraint, NULL
 CREATE TABLE test (a text CHECK(NULL));
@@ -56,12 +56,12 @@ SELECT * FROM test;
 -- test: invalid int
 CREATE TABLE test (a INT CHECK(a > 10));
 INSERT INTO test (a) VALUES (1);
--- error: row violates check constraint "test_check"
+-- error: row violates check constraint "test_check" (a > 10): a = 1
 
 -- test: multiple checks, invalid int
 CREATE TABLE test (a INT CHECK(a > 10), CHECK(a < 20));
 INSERT INTO test (a) VALUES (40);
--- error: row violates check constraint "test_check1"
+-- error: row violates check constraint "test_check1" (a < 20): a = 40
 
 -- test: text
 CREATE TABLE test (a INT CHECK(a > 10));
@@ -87,3 +87,25 @@ SELECT * FROM test;
     a: 15
 }
 */
+
+/*
+Multiple columns: These tests check constraints referencing several columns
+*/
+
+-- test: multiple columns
+CREATE TABLE test (id INT PRIMARY KEY, start_date DATE, end_date DATE, CHECK (start_date < end_date));
+INSERT INTO test VALUES (1, '2024-01-01', '2024-02-01');
+INSERT INTO test VALUES (2, '2024-03-01', '2024-02-01');
+-- error: row violates check constraint "test_check" (start_date < end_date): start_date = "2024-03-01", end_date = "2024-02-01"
+
+-- test: multiple columns, update
+CREATE TABLE test (id INT PRIMARY KEY, start_date DATE, end_date DATE, CHECK (start_date < end_date));
+INSERT INTO test VALUES (1, '2024-01-01', '2024-02-01');
+UPDATE test SET end_date = '2023-12-31' WHERE id = 1;
+-- error: row violates check constraint "test_check" (start_date < end_date): start_date = "2024-01-01", end_date = "2023-12-31"
+
+-- test: json path
+CREATE TABLE test (id INT PRIMARY KEY, doc TEXT, CHECK (json_extract(doc, '$.range.min') <= json_extract(doc, '$.range.max')));
+INSERT INTO test VALUES (1, '{"range": {"min": 1, "max": 2}}');
+INSERT INTO test VALUES (2, '{"range": {"min": 3, "max": 2}}');
+-- error: row violates check constraint "test_check" (json_extract(doc, "$.range.min") <= json_extract(doc, "$.range.max")): doc = "{\"range\": {\"min\": 3, \"max\": 2}}"
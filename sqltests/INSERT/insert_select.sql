@@ -4,8 +4,24 @@ CREATE TABLE bar(a INT, b INT);
 INSERT INTO bar (a, b) VALUES (1, 10);
 
 -- test: same table
+INSERT INTO foo SELECT * FROM bar;
 INSERT INTO foo SELECT * FROM foo;
--- error:
+INSERT INTO foo (a, b) SELECT a + 1, b * 2 FROM foo;
+SELECT a, b FROM foo;
+/* result:
+{"a": 1, "b": 10}
+{"a": 1, "b": 10}
+{"a": 2, "b": 20}
+{"a": 2, "b": 20}
+*/
+
+-- test: same table in a union
+INSERT INTO bar (a, b) SELECT a + 1, b FROM bar UNION ALL SELECT a, b FROM foo;
+SELECT * FROM bar;
+/* result:
+{"a": 1, "b": 10}
+{"a": 2, "b": 10}
+*/
 
 -- test: No columns / No projection
 INSERT INTO foo SELECT * FROM bar;
@@ -74,3 +90,34 @@ INSERT INTO foo (c, d, e) SELECT * FROM bar;
 -- test: Too few columns / Projection
 INSERT INTO foo (c, d) SELECT a FROM bar;
 -- error:
+
+-- test: same table with primary key
+CREATE TABLE events(id INT PRIMARY KEY, ts INT UNIQUE);
+INSERT INTO events (id, ts) VALUES (1, 100), (2, 200), (3, 300);
+INSERT INTO events (id, ts) SELECT id + 3, ts + 300 FROM events ORDER BY id;
+SELECT * FROM events;
+/* result:
+{"id": 1, "ts": 100}
+{"id": 2, "ts": 200}
+{"id": 3, "ts": 300}
+{"id": 4, "ts": 400}
+{"id": 5, "ts": 500}
+{"id": 6, "ts": 600}
+*/
+
+-- test: same table with conflicts
+CREATE TABLE events(id INT PRIMARY KEY, ts INT);
+INSERT INTO events (id, ts) VALUES (1, 100), (2, 200);
+INSERT INTO events (id, ts) SELECT id + 1, ts FROM events;
+-- error: PRIMARY KEY constraint error: [id]
+
+-- test: archive
+CREATE TABLE events(id INT PRIMARY KEY, ts INT);
+CREATE TABLE archive(id INT PRIMARY KEY, ts INT);
+INSERT INTO events (id, ts) VALUES (1, 100), (2, 200), (3, 300);
+INSERT INTO archive SELECT * FROM events WHERE ts < 250;
+SELECT * FROM archive;
+/* result:
+{"id": 1, "ts": 100}
+{"id": 2, "ts": 200}
+*/
@@ -158,4 +158,17 @@ CREATE TABLE test (a int);
 EXPLAIN INSERT INTO test (a) VALUES (1);
 /* result:
 {plan: "rows.Emit((1)) | table.Validate(\"test\") | table.Insert(\"test\") | discard()"}
-*/
\ No newline at end of file
+*/
+
+-- test: default expressions are evaluated for each row
+CREATE TABLE test_def(a INTEGER PRIMARY KEY, b UUID DEFAULT uuid(), c TIMESTAMP NOT NULL DEFAULT now(), d DOUBLE DEFAULT random() * 0 + 1);
+INSERT INTO test_def (a) VALUES (1), (2), (3);
+SELECT COUNT(DISTINCT b) AS b, COUNT(c) AS c, typeof(MIN(c)) AS t, SUM(d) AS d FROM test_def;
+/* result:
+{
+  b: 3,
+  c: 3,
+  t: "timestamp",
+  d: 3.0
+}
+*/
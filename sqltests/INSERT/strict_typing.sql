@@ -0,0 +1,62 @@
+-- setup:
+CREATE TABLE test (a INT, b BIGINT, c DOUBLE, d DECIMAL(5, 2), e DATE, f TEXT, g BOOL);
+
+-- test: default
+INSERT INTO test (a, c, d, e, g) VALUES (1.5, 1, 1.005, '2023-05-07T10:00:00Z', 2);
+SELECT a, c, d, e, g FROM test;
+/* result:
+{
+  "a": 1,
+  "c": 1.0,
+  "d": "1.01",
+  "e": "2023-05-07T00:00:00Z",
+  "g": true
+}
+*/
+
+-- test: lossless conversions
+SET strict_typing = on;
+INSERT INTO test (a, b, c, d, e, f, g) VALUES (2.0, '10', 3, 1.5, '2023-05-07', 1.5, 1);
+SELECT * FROM test;
+/* result:
+{
+  "a": 2,
+  "b": 10,
+  "c": 3.0,
+  "d": "1.50",
+  "e": "2023-05-07T00:00:00Z",
+  "f": "1.5",
+  "g": true
+}
+*/
+
+-- test: double to integer
+SET strict_typing = on;
+INSERT INTO test (a) VALUES (1.5);
+-- error: strict typing: cannot convert 1.5 of type double to integer for column a without loss
+
+-- test: text to integer
+SET strict_typing = on;
+INSERT INTO test (b) VALUES ('10.5');
+-- error: strict typing: cannot convert "10.5" of type text to bigint for column b without loss
+
+-- test: decimal rounding
+SET strict_typing = on;
+INSERT INTO test (d) VALUES (1.005);
+-- error: strict typing: cannot convert 1.005 of type double to decimal(5, 2) for column d without loss
+
+-- test: timestamp to date
+SET strict_typing = on;
+INSERT INTO test (e) VALUES (CAST('2023-05-07T10:00:00Z' AS TIMESTAMP));
+-- error: strict typing: cannot convert "2023-05-07T10:00:00Z" of type timestamp to date for column e without loss
+
+-- test: integer to bool
+SET strict_typing = on;
+INSERT INTO test (g) VALUES (2);
+-- error: strict typing: cannot convert 2 of type integer to boolean for column g without loss
+
+-- test: update
+INSERT INTO test (a) VALUES (1);
+SET strict_typing = on;
+UPDATE test SET a = 2.5;
+-- error: strict typing: cannot convert 2.5 of type double to integer for column a without loss
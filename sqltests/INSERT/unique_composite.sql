@@ -37,3 +37,14 @@ SELECT a, b, c, d FROM test;
 {a: 5, b: NULL, c: 5, d: 5}
 {a: 5, b: NULL, c: 5, d: 5}
 */
+
+-- test: named constraint
+CREATE TABLE test_uq (a int, b int, CONSTRAINT uq UNIQUE (a, b));
+INSERT INTO test_uq (a, b) VALUES (1, 1), (1, 2);
+INSERT INTO test_uq (a, b) VALUES (1, 1);
+-- error: UNIQUE constraint "uq" error: [a b]
+
+-- test: named constraint, same statement
+CREATE TABLE test_uq (a int, b int, CONSTRAINT uq UNIQUE (a, b));
+INSERT INTO test_uq (a, b) VALUES (2, 1), (2, 1);
+-- error: UNIQUE constraint "uq" error: [a b]
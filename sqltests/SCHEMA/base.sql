@@ -0,0 +1,214 @@
+-- setup:
+CREATE SCHEMA app;
+
+-- test: catalog
+SELECT name, type, sql FROM __chai_catalog WHERE type = "schema";
+/* result:
+{
+  "name": "app",
+  "type": "schema",
+  "sql": "CREATE SCHEMA app"
+}
+*/
+
+-- test: already exists
+CREATE SCHEMA app;
+-- error:
+
+-- test: if not exists
+CREATE SCHEMA IF NOT EXISTS app;
+SELECT COUNT(*) FROM __chai_catalog WHERE type = "schema";
+/* result:
+{
+  "COUNT(*)": 1
+}
+*/
+
+-- test: default schema
+CREATE SCHEMA public;
+-- error:
+
+-- test: qualified names
+CREATE TABLE app.users (id INT PRIMARY KEY, name TEXT UNIQUE);
+CREATE INDEX app_users_idx ON app.users (name);
+CREATE TABLE users (id INT PRIMARY KEY, email TEXT);
+INSERT INTO app.users VALUES (1, 'a');
+INSERT INTO users VALUES (2, 'b');
+UPDATE app.users SET name = 'c' WHERE id = 1;
+SELECT name, type FROM __chai_catalog WHERE name LIKE '%users%' ORDER BY name;
+/* result:
+{
+  "name": "app.app_users_idx",
+  "type": "index"
+}
+{
+  "name": "app.users",
+  "type": "table"
+}
+{
+  "name": "app.users_name_idx",
+  "type": "index"
+}
+{
+  "name": "users",
+  "type": "table"
+}
+*/
+
+-- test: select qualified
+CREATE TABLE app.users (id INT PRIMARY KEY, name TEXT);
+CREATE TABLE users (id INT PRIMARY KEY, name TEXT);
+INSERT INTO app.users VALUES (1, 'a');
+INSERT INTO public.users VALUES (2, 'b');
+SELECT * FROM app.users;
+/* result:
+{
+  "id": 1,
+  "name": "a"
+}
+*/
+
+-- test: unknown schema
+CREATE TABLE other.users (id INT PRIMARY KEY);
+-- error: schema other does not exist
+
+-- test: search path
+CREATE TABLE users (id INT PRIMARY KEY, name TEXT);
+INSERT INTO users VALUES (1, 'public');
+SET search_path TO app;
+CREATE TABLE users (id INT PRIMARY KEY, name TEXT);
+INSERT INTO users VALUES (2, 'app');
+SELECT * FROM users;
+/* result:
+{
+  "id": 2,
+  "name": "app"
+}
+*/
+
+-- test: search path order
+CREATE TABLE a (id INT PRIMARY KEY);
+CREATE TABLE app.b (id INT PRIMARY KEY);
+INSERT INTO a VALUES (1);
+INSERT INTO app.b VALUES (2);
+SET search_path = app, public;
+SELECT * FROM a;
+SELECT * FROM b;
+/* result:
+{
+  "id": 2
+}
+*/
+
+-- test: search path default
+SET search_path TO app;
+SET search_path TO DEFAULT;
+CREATE TABLE users (id INT PRIMARY KEY);
+SELECT name FROM __chai_catalog WHERE type = "table" AND name LIKE '%users';
+/* result:
+{
+  "name": "users"
+}
+*/
+
+-- test: search path not found
+SET search_path TO app;
+SELECT * FROM __chai_catalog WHERE name = 'x';
+SELECT * FROM users;
+-- error: "users" not found
+
+-- test: sequences
+CREATE SEQUENCE app.seq;
+CREATE TABLE app.t (id INT PRIMARY KEY DEFAULT NEXT VALUE FOR app.seq, a TEXT);
+INSERT INTO app.t (a) VALUES ('a'), ('b');
+SET search_path TO app;
+CREATE TABLE u (id INT PRIMARY KEY DEFAULT NEXT VALUE FOR seq, a TEXT);
+INSERT INTO u (a) VALUES ('c');
+SELECT id FROM u;
+/* result:
+{
+  "id": 3
+}
+*/
+
+-- test: autoincrement sequence
+CREATE TABLE app.t (id SERIAL PRIMARY KEY);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "app.t";
+/* result:
+{
+  "name": "app.t",
+  "sql": "CREATE TABLE `app.t` (id INTEGER NOT NULL DEFAULT NEXT VALUE FOR app.t_id_seq, CONSTRAINT \"app.t_pk\" PRIMARY KEY (id))"
+}
+*/
+
+-- test: index in another schema
+CREATE TABLE app.t (id INT PRIMARY KEY, a INT);
+CREATE INDEX public.idx ON app.t (a);
+-- error: index public.idx must be created in the schema of table app.t
+
+-- test: rename
+CREATE TABLE app.t (id INT PRIMARY KEY);
+ALTER TABLE app.t RENAME TO u;
+SELECT name FROM __chai_catalog WHERE type = "table" AND name LIKE 'app%';
+/* result:
+{
+  "name": "app.u"
+}
+*/
+
+-- test: rename to another schema
+CREATE TABLE app.t (id INT PRIMARY KEY);
+ALTER TABLE app.t RENAME TO public.t;
+-- error: cannot move table app.t to schema public
+
+-- test: drop schema
+CREATE SCHEMA other;
+DROP SCHEMA other;
+DROP SCHEMA IF EXISTS other;
+SELECT name FROM __chai_catalog WHERE type = "schema";
+/* result:
+{
+  "name": "app"
+}
+*/
+
+-- test: drop non-empty schema
+CREATE TABLE app.t (id INT PRIMARY KEY);
+DROP SCHEMA app;
+-- error: cannot drop schema app because table app.t depends on it
+
+-- test: drop table
+CREATE TABLE app.t (id INT PRIMARY KEY, a INT UNIQUE, b SERIAL);
+CREATE INDEX ON app.t (b);
+DROP TABLE app.t;
+DROP SCHEMA app;
+SELECT COUNT(*) FROM __chai_catalog WHERE name LIKE 'app%';
+/* result:
+{
+  "COUNT(*)": 0
+}
+*/
+
+-- test: drop schema cascade
+CREATE TABLE app.t (id INT PRIMARY KEY, a INT UNIQUE, b SERIAL);
+CREATE TABLE app.u (a INT);
+CREATE INDEX ON app.u (a);
+CREATE SEQUENCE app.seq;
+CREATE TABLE t (id INT PRIMARY KEY);
+DROP SCHEMA app CASCADE;
+SELECT name FROM __chai_catalog WHERE name LIKE 'app%' OR name = 't';
+/* result:
+{
+  "name": "t"
+}
+*/
+
+-- test: drop schema restrict
+CREATE TABLE app.t (id INT PRIMARY KEY);
+DROP SCHEMA app RESTRICT;
+-- error: cannot drop schema app because table app.t depends on it
+
+-- test: drop unknown schema cascade
+DROP SCHEMA IF EXISTS other CASCADE;
+DROP SCHEMA other CASCADE;
+-- error:
@@ -0,0 +1,98 @@
+-- setup:
+CREATE SCHEMA app;
+CREATE TABLE users (id INT PRIMARY KEY, email TEXT NOT NULL, score DECIMAL(5, 2) DEFAULT 0, UNIQUE (email));
+CREATE TABLE app.items (name TEXT);
+
+-- test: tables
+SELECT * FROM information_schema.tables;
+/* result:
+{
+  "table_schema": "app",
+  "table_name": "items",
+  "table_type": "BASE TABLE"
+}
+{
+  "table_schema": "public",
+  "table_name": "users",
+  "table_type": "BASE TABLE"
+}
+*/
+
+-- test: columns
+SELECT column_name, ordinal_position, column_default, is_nullable, data_type, numeric_precision, numeric_scale
+FROM information_schema.columns
+WHERE table_schema = 'public' AND table_name = 'users';
+/* result:
+{
+  "column_name": "id",
+  "ordinal_position": 1,
+  "column_default": null,
+  "is_nullable": "NO",
+  "data_type": "integer",
+  "numeric_precision": null,
+  "numeric_scale": null
+}
+{
+  "column_name": "email",
+  "ordinal_position": 2,
+  "column_default": null,
+  "is_nullable": "NO",
+  "data_type": "text",
+  "numeric_precision": null,
+  "numeric_scale": null
+}
+{
+  "column_name": "score",
+  "ordinal_position": 3,
+  "column_default": "0",
+  "is_nullable": "YES",
+  "data_type": "decimal",
+  "numeric_precision": 5,
+  "numeric_scale": 2
+}
+*/
+
+-- test: key column usage
+SELECT constraint_name, table_name, column_name, ordinal_position, constraint_type
+FROM information_schema.key_column_usage;
+/* result:
+{
+  "constraint_name": "users_email_unique",
+  "table_name": "users",
+  "column_name": "email",
+  "ordinal_position": 1,
+  "constraint_type": "UNIQUE"
+}
+{
+  "constraint_name": "users_pk",
+  "table_name": "users",
+  "column_name": "id",
+  "ordinal_position": 1,
+  "constraint_type": "PRIMARY KEY"
+}
+*/
+
+-- test: search path
+SET search_path TO information_schema;
+SELECT table_name FROM tables WHERE table_schema = 'app';
+/* result:
+{
+  "table_name": "items"
+}
+*/
+
+-- test: read-only
+INSERT INTO information_schema.tables (table_schema, table_name) VALUES ('public', 'foo');
+-- error:
+
+-- test: create table
+CREATE TABLE information_schema.foo (id INT);
+-- error: cannot create relations in schema information_schema
+
+-- test: create schema
+CREATE SCHEMA information_schema;
+-- error:
+
+-- test: drop schema
+DROP SCHEMA information_schema;
+-- error:
@@ -0,0 +1,60 @@
+-- setup:
+CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+CREATE INDEX test_b_idx ON test(b);
+CREATE SEQUENCE seq INCREMENT BY 2;
+CREATE TABLE other(a INTEGER DEFAULT NEXT VALUE FOR seq, b TEXT);
+INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+INSERT INTO other (b) VALUES ('a');
+
+-- test: table stats
+SELECT rows, indexes FROM __chai_table_stats WHERE table_name = 'test';
+/* result:
+{
+  "rows": 3,
+  "indexes": 1
+}
+*/
+
+-- test: index stats
+SELECT table_name, entries FROM __chai_index_stats WHERE index_name = 'test_b_idx';
+/* result:
+{
+  "table_name": "test",
+  "entries": 3
+}
+*/
+
+-- test: sequence stats
+SELECT current_value, increment_by, owner_table FROM __chai_sequence_stats WHERE sequence_name = 'seq';
+/* result:
+{
+  "current_value": 1,
+  "increment_by": 2,
+  "owner_table": null
+}
+*/
+
+-- test: owned sequence stats
+SELECT current_value, owner_table FROM __chai_sequence_stats WHERE sequence_name = 'other_seq';
+/* result:
+{
+  "current_value": 1,
+  "owner_table": "other"
+}
+*/
+
+-- test: virtual tables are not listed
+SELECT COUNT(*) FROM __chai_table_stats WHERE table_name LIKE '%_stats';
+/* result:
+{
+  "COUNT(*)": 0
+}
+*/
+
+-- test: insert
+INSERT INTO __chai_table_stats (table_name) VALUES ('foo');
+-- error:
+
+-- test: delete
+DELETE FROM __chai_index_stats;
+-- error:
@@ -0,0 +1,67 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, grp text, name text, score int);
+INSERT INTO test (id, grp, name, score) VALUES
+    (1, 'a', 'alice', 3),
+    (2, 'a', 'bob', 3),
+    (3, 'a', 'alice', 1),
+    (4, 'b', 'carol', 2),
+    (5, 'b', NULL, 2),
+    (6, 'b', 'carol', 5),
+    (7, 'b', NULL, 5);
+
+-- test: count distinct
+SELECT COUNT(DISTINCT name) AS n, COUNT(name) AS c, COUNT(*) AS t FROM test;
+/* result:
+{"n": 3, "c": 5, "t": 7}
+*/
+
+-- test: sum and avg distinct
+SELECT SUM(DISTINCT score) AS s, SUM(score) AS t, AVG(DISTINCT score) AS a FROM test;
+/* result:
+{"s": 11, "t": 21, "a": 2.75}
+*/
+
+-- test: with GROUP BY
+SELECT grp, COUNT(DISTINCT name) AS n, SUM(DISTINCT score) AS s FROM test GROUP BY grp;
+/* result:
+{"grp": "a", "n": 2, "s": 4}
+{"grp": "b", "n": 1, "s": 7}
+*/
+
+-- test: string_agg distinct with ORDER BY
+SELECT string_agg(DISTINCT name, ',' ORDER BY name DESC) AS names FROM test;
+/* result:
+{"names": "carol,bob,alice"}
+*/
+
+-- test: with FILTER
+SELECT COUNT(DISTINCT score) FILTER (WHERE grp = 'b') AS n FROM test;
+/* result:
+{"n": 2}
+*/
+
+-- test: column name
+SELECT COUNT(DISTINCT name) FROM test;
+/* result:
+{"COUNT(DISTINCT name)": 3}
+*/
+
+-- test: lowercase
+SELECT count(distinct grp) AS n FROM test;
+/* result:
+{"n": 2}
+*/
+
+-- test: no rows
+SELECT COUNT(DISTINCT name) AS n, SUM(DISTINCT score) AS s FROM test WHERE id > 10;
+/* result:
+{"n": 0, "s": null}
+*/
+
+-- test: wildcard
+SELECT COUNT(DISTINCT *) FROM test;
+-- error:
+
+-- test: non aggregate function
+SELECT typeof(DISTINCT name) FROM test;
+-- error:
@@ -0,0 +1,50 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, status text, amount int);
+INSERT INTO test (id, status, amount) VALUES
+    (1, 'paid', 10),
+    (2, 'paid', 20),
+    (3, 'pending', 5),
+    (4, 'refunded', 7),
+    (5, 'pending', NULL);
+
+-- test: without GROUP BY
+SELECT COUNT(*), COUNT(*) FILTER (WHERE status = 'paid') AS paid, SUM(amount) FILTER (WHERE status != 'refunded') AS total FROM test;
+/* result:
+{"COUNT(*)": 5, "paid": 2, "total": 35}
+*/
+
+-- test: no matching rows
+SELECT COUNT(*) FILTER (WHERE status = 'unknown') AS c, MAX(amount) FILTER (WHERE status = 'unknown') AS m FROM test;
+/* result:
+{"c": 0, "m": null}
+*/
+
+-- test: with GROUP BY
+SELECT status, COUNT(*) AS n, COUNT(amount) FILTER (WHERE amount > 6) AS big FROM test GROUP BY status;
+/* result:
+{"status": "paid", "n": 2, "big": 2}
+{"status": "pending", "n": 2, "big": 0}
+{"status": "refunded", "n": 1, "big": 1}
+*/
+
+-- test: column name
+SELECT COUNT(*) FILTER (WHERE id > 3) FROM test;
+/* result:
+{"COUNT(*) FILTER (WHERE id > 3)": 2}
+*/
+
+-- test: non aggregate function
+SELECT typeof(id) FILTER (WHERE id > 3) FROM test;
+-- error:
+
+-- test: nested aggregate
+SELECT COUNT(*) FILTER (WHERE COUNT(*) > 3) FROM test;
+-- error:
+
+-- test: filter as an identifier
+CREATE TABLE t1(filter INT);
+INSERT INTO t1 (filter) VALUES (1), (2);
+SELECT COUNT(filter) FILTER (WHERE filter > 1) AS filter FROM t1;
+/* result:
+{"filter": 1}
+*/
@@ -0,0 +1,60 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, grp text, name text, score int);
+INSERT INTO test (id, grp, name, score) VALUES
+    (1, 'b', 'carol', 2),
+    (2, 'a', 'alice', 3),
+    (3, 'b', 'dave', 1),
+    (4, 'a', 'bob', 3),
+    (5, 'a', NULL, 1),
+    (6, 'b', 'erin', 2);
+
+-- test: without GROUP BY
+SELECT string_agg(name, ',' ORDER BY name) AS names FROM test;
+/* result:
+{"names": "alice,bob,carol,dave,erin"}
+*/
+
+-- test: descending
+SELECT string_agg(name, ',' ORDER BY name DESC) AS names FROM test;
+/* result:
+{"names": "erin,dave,carol,bob,alice"}
+*/
+
+-- test: multiple keys
+SELECT string_agg(name, ',' ORDER BY score DESC, name ASC) AS names FROM test;
+/* result:
+{"names": "alice,bob,carol,erin,dave"}
+*/
+
+-- test: with GROUP BY
+SELECT grp, string_agg(name, '|' ORDER BY score, name DESC) AS names FROM test GROUP BY grp;
+/* result:
+{"grp": "a", "names": "bob|alice"}
+{"grp": "b", "names": "dave|erin|carol"}
+*/
+
+-- test: with FILTER
+SELECT string_agg(name, ',' ORDER BY id DESC) FILTER (WHERE score > 1) AS names FROM test;
+/* result:
+{"names": "erin,bob,alice,carol"}
+*/
+
+-- test: column name
+SELECT string_agg(name, ',' ORDER BY id DESC) FROM test WHERE grp = 'a';
+/* result:
+{"STRING_AGG(name, \",\" ORDER BY id DESC)": "bob,alice"}
+*/
+
+-- test: no rows
+SELECT string_agg(name, ',' ORDER BY name) AS names FROM test WHERE id > 10;
+/* result:
+{"names": null}
+*/
+
+-- test: non aggregate function
+SELECT typeof(id ORDER BY id) FROM test;
+-- error:
+
+-- test: aggregate in ORDER BY
+SELECT string_agg(name, ',' ORDER BY COUNT(*)) FROM test;
+-- error:
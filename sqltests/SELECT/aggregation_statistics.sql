@@ -0,0 +1,120 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, grp text, score int, ratio double);
+INSERT INTO test (id, grp, score, ratio) VALUES
+    (1, 'a', 2, 0.5),
+    (2, 'a', 4, NULL),
+    (3, 'a', 4, 1.5),
+    (4, 'a', 4, 2.5),
+    (5, 'b', 5, 1.0),
+    (6, 'b', 5, NULL),
+    (7, 'b', 7, 3.0),
+    (8, 'b', 9, 2.0);
+
+-- test: variance and standard deviation
+SELECT var_pop(score) AS vp, var_samp(score) AS vs, variance(score) AS v, stddev_pop(score) AS sp, stddev(score) AS s, stddev_samp(score) AS ss FROM test WHERE id < 5;
+/* result:
+{"vp": 0.75, "vs": 1.0, "v": 1.0, "sp": 0.8660254037844386, "s": 1.0, "ss": 1.0}
+*/
+
+-- test: variance with GROUP BY
+SELECT grp, var_pop(ratio) AS v FROM test GROUP BY grp;
+/* result:
+{"grp": "a", "v": 0.6666666666666666}
+{"grp": "b", "v": 0.6666666666666666}
+*/
+
+-- test: sample variance of a single value
+SELECT var_samp(score) AS vs, var_pop(score) AS vp FROM test WHERE id = 1;
+/* result:
+{"vs": null, "vp": 0.0}
+*/
+
+-- test: variance of no rows
+SELECT stddev(score) AS s FROM test WHERE id > 10;
+/* result:
+{"s": null}
+*/
+
+-- test: percentile_cont
+SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY score) AS median, percentile_cont(0.25) WITHIN GROUP (ORDER BY score) AS q1 FROM test;
+/* result:
+{"median": 4.5, "q1": 4.0}
+*/
+
+-- test: percentile_cont descending
+SELECT percentile_cont(0.25) WITHIN GROUP (ORDER BY score DESC) AS q FROM test;
+/* result:
+{"q": 5.5}
+*/
+
+-- test: percentile_disc
+SELECT grp, percentile_disc(0.5) WITHIN GROUP (ORDER BY score) AS median FROM test GROUP BY grp;
+/* result:
+{"grp": "a", "median": 4}
+{"grp": "b", "median": 5}
+*/
+
+-- test: percentile_disc on texts
+SELECT percentile_disc(1) WITHIN GROUP (ORDER BY grp) AS p FROM test;
+/* result:
+{"p": "b"}
+*/
+
+-- test: percentile ignores NULLs
+SELECT percentile_cont(0) WITHIN GROUP (ORDER BY ratio) AS lo, percentile_cont(1) WITHIN GROUP (ORDER BY ratio) AS hi FROM test;
+/* result:
+{"lo": 0.5, "hi": 3.0}
+*/
+
+-- test: percentile with FILTER
+SELECT percentile_disc(0.5) WITHIN GROUP (ORDER BY id) FILTER (WHERE grp = 'b') AS p FROM test;
+/* result:
+{"p": 6}
+*/
+
+-- test: percentile column name
+SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY score) FROM test WHERE grp = 'a';
+/* result:
+{"PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY score)": 4.0}
+*/
+
+-- test: percentile of no rows
+SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY score) AS p FROM test WHERE id > 10;
+/* result:
+{"p": null}
+*/
+
+-- test: percentile without WITHIN GROUP
+SELECT percentile_cont(0.5) FROM test;
+-- error:
+
+-- test: percentile with ORDER BY in the arguments
+SELECT percentile_cont(0.5 ORDER BY score) FROM test;
+-- error:
+
+-- test: percentile fraction out of range
+SELECT percentile_cont(1.5) WITHIN GROUP (ORDER BY score) FROM test;
+-- error:
+
+-- test: percentile_cont on texts
+SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY grp) FROM test;
+-- error:
+
+-- test: array_agg
+SELECT grp, array_agg(ratio) AS a FROM test GROUP BY grp;
+/* result:
+{"grp": "a", "a": "[0.5,null,1.5,2.5]"}
+{"grp": "b", "a": "[1,null,3,2]"}
+*/
+
+-- test: array_agg with ORDER BY
+SELECT array_agg(grp ORDER BY id DESC) AS a FROM test WHERE score > 4;
+/* result:
+{"a": "[\"b\",\"b\",\"b\",\"b\"]"}
+*/
+
+-- test: array_agg of no rows
+SELECT array_agg(id) AS a FROM test WHERE id > 10;
+/* result:
+{"a": null}
+*/
@@ -0,0 +1,164 @@
+-- setup:
+CREATE TABLE test(id INT PRIMARY KEY, a TEXT COLLATE NOCASE, b TEXT);
+CREATE INDEX test_a_idx ON test(a);
+INSERT INTO test (id, a, b) VALUES (1, 'foo', 'foo'), (2, 'Bar', 'Bar'), (3, 'FOO', 'FOO'), (4, 'baz', 'baz');
+
+-- test: column collation
+SELECT id FROM test WHERE a = 'Foo';
+/* result:
+{
+  "id": 1
+}
+{
+  "id": 3
+}
+*/
+
+-- test: column collation uses the index
+EXPLAIN SELECT id FROM test WHERE a = 'Foo';
+/* result:
+{
+  "plan": 'index.Scan("test_a_idx", [{"min": ("Foo"), "exact": true}]) | rows.Project(id)'
+}
+*/
+
+-- test: column collation, range
+SELECT id FROM test WHERE a > 'BAR' AND a < 'FOZ';
+/* result:
+{
+  "id": 4
+}
+{
+  "id": 1
+}
+{
+  "id": 3
+}
+*/
+
+-- test: column collation, IN
+SELECT id FROM test WHERE a IN ('BAZ', 'bar') ORDER BY id;
+/* result:
+{
+  "id": 2
+}
+{
+  "id": 4
+}
+*/
+
+-- test: column collation, ORDER BY
+SELECT a FROM test WHERE a != 'foo' ORDER BY a;
+/* result:
+{
+  "a": "Bar"
+}
+{
+  "a": "baz"
+}
+*/
+
+-- test: COLLATE expression
+SELECT id FROM test WHERE b COLLATE NOCASE = 'foo';
+/* result:
+{
+  "id": 1
+}
+{
+  "id": 3
+}
+*/
+
+-- test: COLLATE expression, BETWEEN
+SELECT id FROM test WHERE b COLLATE NOCASE BETWEEN 'BAR' AND 'BAZ';
+/* result:
+{
+  "id": 2
+}
+{
+  "id": 4
+}
+*/
+
+-- test: COLLATE BINARY overrides the column collation
+SELECT id FROM test WHERE a COLLATE BINARY = 'foo';
+/* result:
+{
+  "id": 1
+}
+*/
+
+-- test: COLLATE expression, ORDER BY
+SELECT b FROM test WHERE b != 'FOO' ORDER BY b COLLATE NOCASE DESC;
+/* result:
+{
+  "b": "foo"
+}
+{
+  "b": "baz"
+}
+{
+  "b": "Bar"
+}
+*/
+
+-- test: COLLATE expression, String
+EXPLAIN SELECT id FROM test WHERE b COLLATE NOCASE = 'foo';
+/* result:
+{
+  "plan": 'table.Scan("test") | rows.Filter(b COLLATE NOCASE = "foo") | rows.Project(id)'
+}
+*/
+
+-- test: unique index
+CREATE TABLE test2(a TEXT COLLATE NOCASE UNIQUE);
+INSERT INTO test2 (a) VALUES ('foo');
+INSERT INTO test2 (a) VALUES ('FOO');
+-- error: UNIQUE constraint "test2_a_unique" error: [a]
+
+-- test: RTRIM
+CREATE TABLE test2(a TEXT COLLATE RTRIM);
+INSERT INTO test2 (a) VALUES ('foo  '), ('foo'), ('fo');
+SELECT COUNT(*) AS n FROM test2 WHERE a = 'foo ';
+/* result:
+{
+  "n": 2
+}
+*/
+
+-- test: locale
+CREATE TABLE test2(a TEXT COLLATE "fr");
+CREATE INDEX ON test2(a);
+INSERT INTO test2 (a) VALUES ('cote'), ('côte'), ('coté'), ('côté'), ('Cote');
+SELECT a FROM test2 ORDER BY a;
+/* result:
+{
+  "a": "cote"
+}
+{
+  "a": "Cote"
+}
+{
+  "a": "coté"
+}
+{
+  "a": "côte"
+}
+{
+  "a": "côté"
+}
+*/
+
+-- test: locale, lookup
+CREATE TABLE test2(a TEXT COLLATE "de-u-ks-level2");
+CREATE INDEX ON test2(a);
+INSERT INTO test2 (a) VALUES ('Straße'), ('strasse'), ('Strasse');
+SELECT a FROM test2 WHERE a = 'strasse';
+/* result:
+{
+  "a": "strasse"
+}
+{
+  "a": "Strasse"
+}
+*/
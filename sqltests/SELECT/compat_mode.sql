@@ -0,0 +1,63 @@
+-- setup:
+CREATE TABLE test (a TEXT, b INT, c DOUBLE);
+INSERT INTO test (a, b, c) VALUES ('ABC', 0, 0.0);
+
+-- test: default
+SELECT a LIKE 'abc' AS l, c / 0 AS d FROM test;
+/* result:
+{
+  "l": true,
+  "d": null
+}
+*/
+
+-- test: default division by zero
+SELECT 1 / b FROM test;
+-- error: division by zero
+
+-- test: sqlite
+SET compat_mode = 'sqlite';
+SELECT a LIKE 'abc' AS l, 1 / b AS d, 1 % b AS m, 1.5 / c AS e FROM test;
+/* result:
+{
+  "l": true,
+  "d": null,
+  "m": null,
+  "e": null
+}
+*/
+
+-- test: sqlite bigint division by zero
+SET compat_mode = 'sqlite';
+SELECT 10000000000 / b AS d, CAST(1 AS BIGINT) / b AS e FROM test;
+/* result:
+{
+  "d": null,
+  "e": null
+}
+*/
+
+-- test: postgres
+SET compat_mode TO postgres;
+SELECT a LIKE 'abc' AS l, a LIKE 'ABC' AS u FROM test;
+/* result:
+{
+  "l": false,
+  "u": true
+}
+*/
+
+-- test: postgres division by zero
+SET compat_mode TO postgres;
+SELECT 1.5 / c FROM test;
+-- error: division by zero
+
+-- test: back to default
+SET compat_mode TO postgres;
+SET compat_mode TO DEFAULT;
+SELECT a LIKE 'abc' AS l FROM test;
+/* result:
+{
+  "l": true
+}
+*/
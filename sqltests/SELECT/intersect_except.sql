@@ -0,0 +1,158 @@
+-- setup:
+CREATE TABLE foo(a INT, b TEXT);
+CREATE TABLE bar(a INT, b TEXT);
+CREATE TABLE baz(a INT, b TEXT);
+INSERT INTO foo (a, b) VALUES (1, 'a'), (2, 'b'), (2, 'b'), (2, 'b'), (3, 'c');
+INSERT INTO bar (a, b) VALUES (2, 'b'), (2, 'b'), (3, 'c'), (4, 'd');
+INSERT INTO baz (a, b) VALUES (3, 'c');
+
+-- test: intersect
+SELECT * FROM foo
+INTERSECT
+SELECT * FROM bar;
+/* result:
+{"a": 2, "b": "b"}
+{"a": 3, "b": "c"}
+*/
+
+-- test: intersect all
+SELECT * FROM foo
+INTERSECT ALL
+SELECT * FROM bar;
+/* result:
+{"a": 2, "b": "b"}
+{"a": 2, "b": "b"}
+{"a": 3, "b": "c"}
+*/
+
+-- test: multiple intersects
+SELECT * FROM foo
+INTERSECT
+SELECT * FROM bar
+INTERSECT
+SELECT * FROM baz;
+/* result:
+{"a": 3, "b": "c"}
+*/
+
+-- test: intersect with projection and conditions
+SELECT a FROM foo WHERE a > 1
+intersect
+SELECT a FROM bar WHERE b != 'c';
+/* result:
+{"a": 2}
+*/
+
+-- test: self intersect all
+SELECT * FROM foo
+INTERSECT ALL
+SELECT * FROM foo;
+/* result:
+{"a": 1, "b": "a"}
+{"a": 2, "b": "b"}
+{"a": 2, "b": "b"}
+{"a": 2, "b": "b"}
+{"a": 3, "b": "c"}
+*/
+
+-- test: except
+SELECT * FROM foo
+EXCEPT
+SELECT * FROM bar;
+/* result:
+{"a": 1, "b": "a"}
+*/
+
+-- test: except all
+SELECT * FROM foo
+EXCEPT ALL
+SELECT * FROM bar;
+/* result:
+{"a": 1, "b": "a"}
+{"a": 2, "b": "b"}
+*/
+
+-- test: except empty
+SELECT * FROM baz
+EXCEPT
+SELECT * FROM foo;
+/* result:
+*/
+
+-- test: multiple excepts
+SELECT * FROM foo
+EXCEPT ALL
+SELECT * FROM bar
+EXCEPT ALL
+SELECT * FROM foo WHERE a = 2;
+/* result:
+{"a": 1, "b": "a"}
+*/
+
+-- test: operators are applied from left to right
+SELECT * FROM foo
+EXCEPT
+SELECT * FROM baz
+UNION
+SELECT * FROM bar
+INTERSECT
+SELECT * FROM bar WHERE a < 4;
+/* result:
+{"a": 2, "b": "b"}
+{"a": 3, "b": "c"}
+*/
+
+-- test: except with order by and limit
+SELECT a FROM bar
+EXCEPT
+SELECT a FROM baz
+ORDER BY a DESC
+LIMIT 1;
+/* result:
+{"a": 4}
+*/
+
+-- test: except all with different types
+SELECT a FROM foo
+EXCEPT ALL
+SELECT b AS a FROM foo;
+/* result:
+{"a": 1}
+{"a": 2}
+{"a": 2}
+{"a": 2}
+{"a": 3}
+*/
+
+-- test: intersect with different column names
+SELECT a FROM foo
+INTERSECT
+SELECT 2;
+/* result:
+{"a": 2}
+*/
+
+-- test: except with different column names
+SELECT a FROM foo
+EXCEPT
+SELECT 2;
+/* result:
+{"a": 1}
+{"a": 3}
+*/
+
+-- test: except all with different column names
+SELECT a AS x, b AS y FROM foo
+EXCEPT ALL
+SELECT * FROM bar;
+/* result:
+{"x": 1, "y": "a"}
+{"x": 2, "y": "b"}
+*/
+
+-- test: intersect with columns in a different order
+SELECT a, b FROM foo
+INTERSECT
+SELECT b, a FROM bar;
+/* result:
+*/
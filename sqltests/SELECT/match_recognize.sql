@@ -0,0 +1,171 @@
+-- setup:
+CREATE TABLE events (id INT PRIMARY KEY, user_id INT, ts INT, type TEXT);
+INSERT INTO events (id, user_id, ts, type) VALUES
+    (1, 1, 1, 'view'),
+    (2, 1, 2, 'cart'),
+    (3, 1, 3, 'cart'),
+    (4, 1, 4, 'buy'),
+    (5, 2, 1, 'view'),
+    (6, 2, 2, 'buy'),
+    (7, 1, 5, 'view'),
+    (8, 1, 6, 'cart'),
+    (9, 2, 3, 'view'),
+    (10, 2, 4, 'cart'),
+    (11, 2, 5, 'buy');
+
+-- test: funnel
+SELECT user_id, ts, MATCH_NUMBER() AS m, CLASSIFIER() AS c FROM events
+MATCH_RECOGNIZE (
+    PARTITION BY user_id
+    ORDER BY ts
+    PATTERN (A B+ C)
+    DEFINE A AS type = 'view', B AS type = 'cart', C AS type = 'buy'
+);
+/* result:
+{
+  "user_id": 1,
+  "ts": 1,
+  "m": 1,
+  "c": "A"
+}
+{
+  "user_id": 1,
+  "ts": 2,
+  "m": 1,
+  "c": "B"
+}
+{
+  "user_id": 1,
+  "ts": 3,
+  "m": 1,
+  "c": "B"
+}
+{
+  "user_id": 1,
+  "ts": 4,
+  "m": 1,
+  "c": "C"
+}
+{
+  "user_id": 2,
+  "ts": 3,
+  "m": 1,
+  "c": "A"
+}
+{
+  "user_id": 2,
+  "ts": 4,
+  "m": 1,
+  "c": "B"
+}
+{
+  "user_id": 2,
+  "ts": 5,
+  "m": 1,
+  "c": "C"
+}
+*/
+
+-- test: optional symbol
+SELECT id, CLASSIFIER() AS c FROM events
+MATCH_RECOGNIZE (PARTITION BY user_id ORDER BY ts PATTERN (a b* c) DEFINE a AS type = 'view', b AS type = 'cart', c AS type = 'buy')
+WHERE user_id = 2;
+/* result:
+{
+  "id": 5,
+  "c": "A"
+}
+{
+  "id": 6,
+  "c": "C"
+}
+{
+  "id": 9,
+  "c": "A"
+}
+{
+  "id": 10,
+  "c": "B"
+}
+{
+  "id": 11,
+  "c": "C"
+}
+*/
+
+-- test: wildcard
+SELECT * FROM events MATCH_RECOGNIZE (ORDER BY id DESC PATTERN (B C?) DEFINE B AS type = 'cart', C AS type = 'view') WHERE id > 6;
+/* result:
+{
+  "id": 10,
+  "user_id": 2,
+  "ts": 4,
+  "type": "cart",
+  "match_number": 1,
+  "classifier": "B"
+}
+{
+  "id": 9,
+  "user_id": 2,
+  "ts": 3,
+  "type": "view",
+  "match_number": 1,
+  "classifier": "C"
+}
+{
+  "id": 8,
+  "user_id": 1,
+  "ts": 6,
+  "type": "cart",
+  "match_number": 2,
+  "classifier": "B"
+}
+{
+  "id": 7,
+  "user_id": 1,
+  "ts": 5,
+  "type": "view",
+  "match_number": 2,
+  "classifier": "C"
+}
+*/
+
+-- test: group by
+SELECT CLASSIFIER(), COUNT(*) FROM events
+MATCH_RECOGNIZE (PARTITION BY user_id ORDER BY ts PATTERN (A B* C) DEFINE A AS type = 'view', B AS type = 'cart', C AS type = 'buy')
+GROUP BY CLASSIFIER();
+/* result:
+{
+  "CLASSIFIER()": "A",
+  "COUNT(*)": 3
+}
+{
+  "CLASSIFIER()": "B",
+  "COUNT(*)": 3
+}
+{
+  "CLASSIFIER()": "C",
+  "COUNT(*)": 3
+}
+*/
+
+-- test: no match
+SELECT id FROM events MATCH_RECOGNIZE (ORDER BY ts PATTERN (A) DEFINE A AS type = 'unknown');
+/* result:
+*/
+
+-- test: undefined symbol
+SELECT id FROM events MATCH_RECOGNIZE (ORDER BY ts PATTERN (A) DEFINE B AS type = 'view');
+-- error:
+
+-- test: without MATCH_RECOGNIZE
+SELECT CLASSIFIER() FROM events;
+-- error:
+
+-- test: in WHERE
+SELECT id FROM events MATCH_RECOGNIZE (ORDER BY ts PATTERN (A)) WHERE CLASSIFIER() = 'A';
+-- error:
+
+-- test: unknown column
+SELECT id FROM events MATCH_RECOGNIZE (ORDER BY foo PATTERN (A));
+-- error:
@@ -0,0 +1,89 @@
+-- setup:
+CREATE TABLE test(a DATE);
+INSERT INTO test (a) VALUES ("2023-05-07"), ("2025-01-01T10:00:00Z"), ("1969-12-31"), ("2000-01-01");
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(a);
+
+-- test: type
+SELECT typeof(a) AS t FROM test LIMIT 1;
+/* result:
+{
+    t: "date"
+}
+*/
+
+-- test: asc
+SELECT a FROM test ORDER BY a;
+/* result:
+{
+    a: "1969-12-31T00:00:00Z"
+}
+{
+    a: "2000-01-01T00:00:00Z"
+}
+{
+    a: "2023-05-07T00:00:00Z"
+}
+{
+    a: "2025-01-01T00:00:00Z"
+}
+*/
+
+-- test: desc
+SELECT a FROM test ORDER BY a DESC;
+/* result:
+{
+    a: "2025-01-01T00:00:00Z"
+}
+{
+    a: "2023-05-07T00:00:00Z"
+}
+{
+    a: "2000-01-01T00:00:00Z"
+}
+{
+    a: "1969-12-31T00:00:00Z"
+}
+*/
+
+-- test: range
+SELECT a FROM test WHERE a > '2000-01-01' AND a <= '2025-01-01';
+/* result:
+{
+    a: "2023-05-07T00:00:00Z"
+}
+{
+    a: "2025-01-01T00:00:00Z"
+}
+*/
+
+-- test: equality
+SELECT a FROM test WHERE a = '2023-05-07';
+/* result:
+{
+    a: "2023-05-07T00:00:00Z"
+}
+*/
+
+-- test: compared to timestamps
+SELECT a FROM test WHERE a < CAST('2000-01-01T00:00:01Z' AS TIMESTAMP) ORDER BY a;
+/* result:
+{
+    a: "1969-12-31T00:00:00Z"
+}
+{
+    a: "2000-01-01T00:00:00Z"
+}
+*/
+
+-- test: EXTRACT
+SELECT EXTRACT(year FROM a) AS y, EXTRACT('doy' FROM a) AS doy FROM test WHERE a = '2023-05-07';
+/* result:
+{
+    y: 2023,
+    doy: 127
+}
+*/
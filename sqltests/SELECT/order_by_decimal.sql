@@ -0,0 +1,103 @@
+-- setup:
+CREATE TABLE test(a DECIMAL, b DECIMAL(10, 2));
+INSERT INTO test (a, b) VALUES
+    ('10.5', 1),
+    (-3, '-0.005'),
+    ('0.25', 2.675),
+    ('123456789012345678901234567890.1', '12345678.9'),
+    ('-0.3', 0);
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(a);
+CREATE INDEX ON test(b);
+
+-- test: type
+SELECT typeof(a) AS t FROM test LIMIT 1;
+/* result:
+{
+    t: "decimal"
+}
+*/
+
+-- test: asc
+SELECT a FROM test ORDER BY a;
+/* result:
+{
+    a: "-3"
+}
+{
+    a: "-0.3"
+}
+{
+    a: "0.25"
+}
+{
+    a: "10.5"
+}
+{
+    a: "123456789012345678901234567890.1"
+}
+*/
+
+-- test: desc
+SELECT b FROM test ORDER BY b DESC;
+/* result:
+{
+    b: "12345678.90"
+}
+{
+    b: "2.68"
+}
+{
+    b: "1.00"
+}
+{
+    b: "0.00"
+}
+{
+    b: "-0.01"
+}
+*/
+
+-- test: range
+SELECT a FROM test WHERE a > 0 AND a <= 10.5 ORDER BY a;
+/* result:
+{
+    a: "0.25"
+}
+{
+    a: "10.5"
+}
+*/
+
+-- test: equality with a different scale
+SELECT b FROM test WHERE b = 1;
+/* result:
+{
+    b: "1.00"
+}
+*/
+
+-- test: sum
+SELECT SUM(b) AS s, typeof(SUM(b)) AS t FROM test;
+/* result:
+{
+    s: "12345682.57",
+    t: "decimal"
+}
+*/
+
+-- test: update
+UPDATE test SET b = b * 1.1 WHERE b = 1;
+SELECT b FROM test WHERE a = 10.5;
+/* result:
+{
+    b: "1.10"
+}
+*/
+
+-- test: overflow
+INSERT INTO test (a, b) VALUES (1, 123456789);
+-- error:
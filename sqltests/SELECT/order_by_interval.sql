@@ -0,0 +1,99 @@
+-- setup:
+CREATE TABLE test(a INTERVAL, b TIMESTAMP);
+INSERT INTO test (a, b) VALUES
+    ('1 day', '2023-01-31T00:00:00Z'),
+    (INTERVAL '-2 hours', '2023-01-01T00:00:00Z'),
+    ('1 mon', '2023-03-01T00:00:00Z'),
+    ('23:00:00', '2024-01-31T12:00:00Z');
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(a);
+
+-- test: type
+SELECT typeof(a) AS t FROM test LIMIT 1;
+/* result:
+{
+    t: "interval"
+}
+*/
+
+-- test: asc
+SELECT a FROM test ORDER BY a;
+/* result:
+{
+    a: "-02:00:00"
+}
+{
+    a: "23:00:00"
+}
+{
+    a: "1 day"
+}
+{
+    a: "1 mon"
+}
+*/
+
+-- test: desc
+SELECT a FROM test ORDER BY a DESC;
+/* result:
+{
+    a: "1 mon"
+}
+{
+    a: "1 day"
+}
+{
+    a: "23:00:00"
+}
+{
+    a: "-02:00:00"
+}
+*/
+
+-- test: range
+SELECT a FROM test WHERE a > INTERVAL '0 days' AND a <= '1 day' ORDER BY a;
+/* result:
+{
+    a: "23:00:00"
+}
+{
+    a: "1 day"
+}
+*/
+
+-- test: equality with a different unit
+SELECT a FROM test WHERE a = INTERVAL '30 days';
+/* result:
+{
+    a: "1 mon"
+}
+*/
+
+-- test: timestamp arithmetic
+SELECT b + a AS c FROM test ORDER BY a;
+/* result:
+{
+    c: "2022-12-31T22:00:00Z"
+}
+{
+    c: "2024-02-01T11:00:00Z"
+}
+{
+    c: "2023-02-01T00:00:00Z"
+}
+{
+    c: "2023-04-01T00:00:00Z"
+}
+*/
+
+-- test: update
+UPDATE test SET a = a * 2 WHERE a < INTERVAL '0 days';
+SELECT a FROM test ORDER BY a LIMIT 1;
+/* result:
+{
+    a: "-04:00:00"
+}
+*/
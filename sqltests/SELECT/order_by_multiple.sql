@@ -0,0 +1,106 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, a int, b text);
+INSERT INTO test (id, a, b) VALUES (1, 1, 'x'), (2, 2, 'y'), (3, 1, null), (4, null, 'x'), (5, 2, 'x'), (6, 1, 'y');
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(a, b);
+
+-- test: asc, asc
+SELECT id FROM test ORDER BY a, b;
+/* result:
+{ id: 4 }
+{ id: 3 }
+{ id: 1 }
+{ id: 6 }
+{ id: 5 }
+{ id: 2 }
+*/
+
+-- test: desc, desc
+SELECT id FROM test ORDER BY a DESC, b DESC;
+/* result:
+{ id: 2 }
+{ id: 5 }
+{ id: 6 }
+{ id: 1 }
+{ id: 3 }
+{ id: 4 }
+*/
+
+-- test: asc, desc
+SELECT id FROM test ORDER BY a ASC, b DESC;
+/* result:
+{ id: 4 }
+{ id: 6 }
+{ id: 1 }
+{ id: 3 }
+{ id: 2 }
+{ id: 5 }
+*/
+
+-- test: desc, asc
+SELECT id FROM test ORDER BY a DESC, b;
+/* result:
+{ id: 5 }
+{ id: 2 }
+{ id: 3 }
+{ id: 1 }
+{ id: 6 }
+{ id: 4 }
+*/
+
+-- test: NULLS LAST
+SELECT id FROM test ORDER BY a NULLS LAST, b NULLS LAST;
+/* result:
+{ id: 1 }
+{ id: 6 }
+{ id: 3 }
+{ id: 5 }
+{ id: 2 }
+{ id: 4 }
+*/
+
+-- test: DESC NULLS FIRST
+SELECT id FROM test ORDER BY a DESC NULLS FIRST, b DESC NULLS FIRST;
+/* result:
+{ id: 4 }
+{ id: 2 }
+{ id: 5 }
+{ id: 3 }
+{ id: 6 }
+{ id: 1 }
+*/
+
+-- test: NULLS FIRST and LAST, mixed directions
+SELECT id FROM test ORDER BY a DESC NULLS FIRST, b NULLS LAST;
+/* result:
+{ id: 4 }
+{ id: 5 }
+{ id: 2 }
+{ id: 1 }
+{ id: 6 }
+{ id: 3 }
+*/
+
+-- test: with filter and limit
+SELECT id FROM test WHERE a = 1 ORDER BY a, b DESC LIMIT 2;
+/* result:
+{ id: 6 }
+{ id: 1 }
+*/
+
+-- test: DELETE
+DELETE FROM test ORDER BY a DESC, b NULLS FIRST LIMIT 2;
+SELECT id FROM test ORDER BY id;
+/* result:
+{ id: 1 }
+{ id: 3 }
+{ id: 4 }
+{ id: 6 }
+*/
+
+-- test: NULLS without FIRST or LAST
+SELECT id FROM test ORDER BY a NULLS;
+-- error:
@@ -0,0 +1,93 @@
+-- setup:
+CREATE TABLE test(id UUID PRIMARY KEY, b UUID);
+INSERT INTO test (id, b) VALUES
+    ('c0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', '00000000-0000-0000-0000-000000000002'),
+    ('0fffffff-ffff-4fff-bfff-ffffffffffff', '00000000-0000-0000-0000-000000000003'),
+    ('A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11', '00000000-0000-0000-0000-000000000001'),
+    ('ffffffff-ffff-ffff-ffff-ffffffffffff', '10000000-0000-0000-0000-000000000000');
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(b);
+
+-- test: type
+SELECT typeof(id) AS t FROM test LIMIT 1;
+/* result:
+{
+    t: "uuid"
+}
+*/
+
+-- test: asc
+SELECT id FROM test ORDER BY id;
+/* result:
+{
+    id: "0fffffff-ffff-4fff-bfff-ffffffffffff"
+}
+{
+    id: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+}
+{
+    id: "c0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+}
+{
+    id: "ffffffff-ffff-ffff-ffff-ffffffffffff"
+}
+*/
+
+-- test: desc
+SELECT b FROM test ORDER BY b DESC;
+/* result:
+{
+    b: "10000000-0000-0000-0000-000000000000"
+}
+{
+    b: "00000000-0000-0000-0000-000000000003"
+}
+{
+    b: "00000000-0000-0000-0000-000000000002"
+}
+{
+    b: "00000000-0000-0000-0000-000000000001"
+}
+*/
+
+-- test: lookup
+SELECT b FROM test WHERE id = 'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11';
+/* result:
+{
+    b: "00000000-0000-0000-0000-000000000001"
+}
+*/
+
+-- test: range
+SELECT id FROM test WHERE b > '00000000-0000-0000-0000-000000000001' AND b <= '00000000-0000-0000-0000-000000000003' ORDER BY b;
+/* result:
+{
+    id: "c0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+}
+{
+    id: "0fffffff-ffff-4fff-bfff-ffffffffffff"
+}
+*/
+
+-- test: default
+-- the ids are unique, or the second insert would fail
+CREATE TABLE gen(id UUID PRIMARY KEY DEFAULT uuid(), a INT);
+INSERT INTO gen (a) VALUES (1), (2);
+SELECT COUNT(*) AS n, typeof(MIN(id)) AS t FROM gen;
+/* result:
+{
+    n: 2,
+    t: "uuid"
+}
+*/
+
+-- test: duplicate
+INSERT INTO test (id, b) VALUES ('c0eebc999c0b4ef8bb6d6bb9bd380a11', '00000000-0000-0000-0000-000000000004');
+-- error:
+
+-- test: invalid
+INSERT INTO test (id, b) VALUES ('foo', '00000000-0000-0000-0000-000000000004');
+-- error:
@@ -0,0 +1,47 @@
+-- setup:
+CREATE SCHEMA app;
+CREATE TABLE test(a int, b text);
+CREATE TABLE app.test(a int);
+INSERT INTO test (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z');
+INSERT INTO app.test (a) VALUES (10);
+
+-- test: projection
+SELECT test.a, `test`.`b` FROM test WHERE test.a > 1 ORDER BY test.a DESC;
+/* result:
+{"a": 3, "b": "z"}
+{"a": 2, "b": "y"}
+*/
+
+-- test: schema
+SELECT app.test.a FROM app.test;
+/* result:
+{"a": 10}
+*/
+
+-- test: table name without schema
+SELECT test.a FROM app.test;
+/* result:
+{"a": 10}
+*/
+
+-- test: update
+UPDATE test SET b = 'w' WHERE test.a = 1;
+SELECT b FROM test WHERE a = 1;
+/* result:
+{"b": "w"}
+*/
+
+-- test: delete
+DELETE FROM test WHERE test.a < 3;
+SELECT a FROM test;
+/* result:
+{"a": 3}
+*/
+
+-- test: unknown table
+SELECT other.a FROM test;
+-- error: table other is not referenced by the statement
+
+-- test: other schema
+SELECT public.test.a FROM app.test;
+-- error: table public.test is not referenced by the statement
@@ -0,0 +1,75 @@
+-- setup:
+CREATE TABLE test (a TEXT, b INT, c DOUBLE, d TIMESTAMP, e BOOL);
+INSERT INTO test (a, b, c, d, e) VALUES ('1', 1, 1.5, '2023-01-01', true);
+
+-- test: default
+SELECT COUNT(*) AS n FROM test WHERE a = 1;
+/* result:
+{
+  "n": 1
+}
+*/
+
+-- test: text compared with integer
+SET strict_typing = on;
+SELECT * FROM test WHERE a = 1;
+-- error: strict typing: cannot compare a of type text with 1 of type integer
+
+-- test: columns of incompatible types
+SET strict_typing = on;
+SELECT * FROM test WHERE a = b;
+-- error: strict typing: cannot compare a of type text with b of type integer
+
+-- test: IN
+SET strict_typing = on;
+SELECT * FROM test WHERE b IN (1, 'a');
+-- error: strict typing: cannot compare b of type integer with "a" of type text
+
+-- test: BETWEEN
+SET strict_typing = on;
+SELECT * FROM test WHERE e BETWEEN 0 AND 1;
+-- error: strict typing: cannot compare e of type boolean with 0 of type integer
+
+-- test: LIKE
+SET strict_typing = on;
+SELECT * FROM test WHERE b LIKE '1%';
+-- error: strict typing: cannot use b of type integer with LIKE
+
+-- test: projection
+SET strict_typing = on;
+SELECT a < 2 FROM test;
+-- error: strict typing: cannot compare a of type text with 2 of type integer
+
+-- test: update
+SET strict_typing = on;
+UPDATE test SET b = 2 WHERE a != 1;
+-- error: strict typing: cannot compare a of type text with 1 of type integer
+
+-- test: compatible types
+SET strict_typing = on;
+SELECT COUNT(*) AS n FROM test WHERE a = '1' AND b < c AND b IN (1, 2) AND d > '2022-01-01' AND CAST(a AS INT) = b AND a LIKE '1%';
+/* result:
+{
+  "n": 1
+}
+*/
+
+-- test: disabled
+SET strict_typing = on;
+SET strict_typing = off;
+SELECT COUNT(*) AS n FROM test WHERE a = 1;
+/* result:
+{
+  "n": 1
+}
+*/
+
+-- test: cast between incompatible types
+SET strict_typing = on;
+SELECT CAST(d AS INT) FROM test;
+-- error: strict typing: cannot cast d of type timestamp as integer
+
+-- test: try_cast between incompatible types
+SET strict_typing = on;
+SELECT TRY_CAST(e AS DOUBLE) FROM test;
+-- error: strict typing: cannot cast e of type boolean as double
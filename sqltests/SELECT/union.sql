@@ -135,4 +135,37 @@ SELECT * FROM baz;
 {"a": 2.0, "b": 2.0}
 {"a": 3.0, "b": 3.0}
 {"a": "a", "b": "a"}
-{"a": "b", "b": "b"}
\ No newline at end of file
+{"a": "b", "b": "b"}
+*/
+
+-- test: union all with limit
+SELECT a FROM foo
+UNION ALL
+SELECT a FROM bar
+LIMIT 3;
+/* result:
+{"a": 1.0}
+{"a": 2.0}
+{"a": 2.0}
+*/
+
+-- test: union all with limit in the first stream
+SELECT a FROM foo
+UNION ALL
+SELECT a FROM bar
+UNION ALL
+SELECT x FROM baz
+LIMIT 1;
+/* result:
+{"a": 1.0}
+*/
+
+-- test: union all with limit and offset
+SELECT a FROM foo
+UNION ALL
+SELECT a FROM bar
+LIMIT 2 OFFSET 1;
+/* result:
+{"a": 2.0}
+{"a": 2.0}
+*/
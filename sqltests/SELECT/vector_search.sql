@@ -0,0 +1,151 @@
+-- setup:
+CREATE TABLE test(id INT PRIMARY KEY, v VECTOR(2));
+CREATE INDEX test_v_idx ON test USING ivf (v) WITH (metric = 'l2', lists = 2, probes = 2);
+INSERT INTO test (id, v) VALUES
+    (1, '[0, 0]'),
+    (2, '[10, 10]'),
+    (3, '[1, 0]'),
+    (4, '[9, 9]'),
+    (5, '[0, 2]');
+
+-- test: catalog
+SELECT sql FROM __chai_catalog WHERE name = "test_v_idx";
+/* result:
+{
+    sql: "CREATE INDEX test_v_idx ON test USING ivf (v) WITH (metric = 'l2', lists = 2, probes = 2)"
+}
+*/
+
+-- test: explain
+EXPLAIN SELECT id FROM test ORDER BY vector_distance(v, '[1, 1]', 'l2') LIMIT 3;
+/* result:
+{
+    plan: "index.VectorScan(\"test_v_idx\", \"[1, 1]\", 3) | rows.Take(3) | rows.Project(id)"
+}
+*/
+
+-- test: explain with offset
+EXPLAIN SELECT id FROM test ORDER BY vector_distance('[1, 1]', v, 'l2') LIMIT 3 OFFSET 1;
+/* result:
+{
+    plan: "index.VectorScan(\"test_v_idx\", \"[1, 1]\", 3 + 1) | rows.Skip(1) | rows.Take(3) | rows.Project(id)"
+}
+*/
+
+-- test: explain without limit
+EXPLAIN SELECT id FROM test ORDER BY vector_distance(v, '[1, 1]', 'l2');
+/* result:
+{
+    plan: "table.Scan(\"test\") | rows.Project(id) | rows.TempTreeSort(vector_distance(v, \"[1, 1]\", \"l2\"))"
+}
+*/
+
+-- test: explain other metric
+EXPLAIN SELECT id FROM test ORDER BY vector_distance(v, '[1, 1]', 'cosine') LIMIT 3;
+/* result:
+{
+    plan: "table.Scan(\"test\") | rows.Project(id) | rows.TempTreeSort(vector_distance(v, \"[1, 1]\", \"cosine\")) | rows.Take(3)"
+}
+*/
+
+-- test: nearest
+SELECT id FROM test ORDER BY vector_distance(v, '[1, 1]', 'l2') LIMIT 3;
+/* result:
+{
+    id: 3
+}
+{
+    id: 1
+}
+{
+    id: 5
+}
+*/
+
+-- test: nearest with offset
+SELECT id FROM test ORDER BY vector_distance(v, '[10, 10]', 'l2') LIMIT 2 OFFSET 1;
+/* result:
+{
+    id: 4
+}
+{
+    id: 5
+}
+*/
+
+-- test: after delete and update
+DELETE FROM test WHERE id = 3;
+UPDATE test SET v = '[1, 1]' WHERE id = 2;
+SELECT id FROM test ORDER BY vector_distance(v, '[1, 1]', 'l2') LIMIT 2;
+/* result:
+{
+    id: 2
+}
+{
+    id: 1
+}
+*/
+
+-- test: null vectors
+INSERT INTO test (id, v) VALUES (6, NULL), (7, NULL);
+SELECT id FROM test ORDER BY vector_distance(v, '[1, 1]', 'l2') LIMIT 3 OFFSET 1;
+/* result:
+{
+    id: 7
+}
+{
+    id: 3
+}
+{
+    id: 1
+}
+*/
+
+-- test: null vectors without index
+INSERT INTO test (id, v) VALUES (6, NULL), (7, NULL);
+SELECT /*+ NO_INDEX */ id FROM test ORDER BY vector_distance(v, '[1, 1]', 'l2') LIMIT 3 OFFSET 1;
+/* result:
+{
+    id: 7
+}
+{
+    id: 3
+}
+{
+    id: 1
+}
+*/
+
+-- test: updated to null
+UPDATE test SET v = NULL WHERE id = 5;
+SELECT id FROM test ORDER BY vector_distance(v, '[1, 1]', 'l2') LIMIT 3;
+/* result:
+{
+    id: 5
+}
+{
+    id: 3
+}
+{
+    id: 1
+}
+*/
+
+-- test: null searched vector
+SELECT id FROM test ORDER BY vector_distance(v, NULL, 'l2') LIMIT 2;
+/* result:
+{
+    id: 1
+}
+{
+    id: 2
+}
+*/
+
+-- test: ivf index on non vector column
+CREATE INDEX ON test USING ivf (id);
+-- error:
+
+-- test: unique ivf index
+CREATE UNIQUE INDEX ON test USING ivf (v);
+-- error:
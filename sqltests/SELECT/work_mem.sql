@@ -0,0 +1,299 @@
+-- setup:
+CREATE TABLE test(a INT PRIMARY KEY, b INT, c TEXT);
+INSERT INTO test (a, b, c) VALUES
+(0, 0, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(1, 1, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(2, 2, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(3, 3, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(4, 0, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(5, 1, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(6, 2, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(7, 3, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(8, 0, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(9, 1, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(10, 2, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(11, 3, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(12, 0, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(13, 1, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(14, 2, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(15, 3, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(16, 0, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(17, 1, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(18, 2, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx'),
+(19, 3, 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx');
+
+-- test: order by with work_mem = DEFAULT
+SET work_mem = DEFAULT;
+SELECT a FROM test ORDER BY b DESC, a;
+/* result:
+{
+  "a": 3
+}
+{
+  "a": 7
+}
+{
+  "a": 11
+}
+{
+  "a": 15
+}
+{
+  "a": 19
+}
+{
+  "a": 2
+}
+{
+  "a": 6
+}
+{
+  "a": 10
+}
+{
+  "a": 14
+}
+{
+  "a": 18
+}
+{
+  "a": 1
+}
+{
+  "a": 5
+}
+{
+  "a": 9
+}
+{
+  "a": 13
+}
+{
+  "a": 17
+}
+{
+  "a": 0
+}
+{
+  "a": 4
+}
+{
+  "a": 8
+}
+{
+  "a": 12
+}
+{
+  "a": 16
+}
+*/
+
+-- test: group by with work_mem = DEFAULT
+SET work_mem = DEFAULT;
+SELECT b, COUNT(*), COUNT(DISTINCT a) FROM test GROUP BY b;
+/* result:
+{
+  "b": 0,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 1,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 2,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 3,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+*/
+
+-- test: order by with work_mem = 100B
+SET work_mem = '100B';
+SELECT a FROM test ORDER BY b DESC, a;
+/* result:
+{
+  "a": 3
+}
+{
+  "a": 7
+}
+{
+  "a": 11
+}
+{
+  "a": 15
+}
+{
+  "a": 19
+}
+{
+  "a": 2
+}
+{
+  "a": 6
+}
+{
+  "a": 10
+}
+{
+  "a": 14
+}
+{
+  "a": 18
+}
+{
+  "a": 1
+}
+{
+  "a": 5
+}
+{
+  "a": 9
+}
+{
+  "a": 13
+}
+{
+  "a": 17
+}
+{
+  "a": 0
+}
+{
+  "a": 4
+}
+{
+  "a": 8
+}
+{
+  "a": 12
+}
+{
+  "a": 16
+}
+*/
+
+-- test: group by with work_mem = 100B
+SET work_mem = '100B';
+SELECT b, COUNT(*), COUNT(DISTINCT a) FROM test GROUP BY b;
+/* result:
+{
+  "b": 0,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 1,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 2,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 3,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+*/
+
+-- test: order by with work_mem = 64MB
+SET work_mem = '64MB';
+SELECT a FROM test ORDER BY b DESC, a;
+/* result:
+{
+  "a": 3
+}
+{
+  "a": 7
+}
+{
+  "a": 11
+}
+{
+  "a": 15
+}
+{
+  "a": 19
+}
+{
+  "a": 2
+}
+{
+  "a": 6
+}
+{
+  "a": 10
+}
+{
+  "a": 14
+}
+{
+  "a": 18
+}
+{
+  "a": 1
+}
+{
+  "a": 5
+}
+{
+  "a": 9
+}
+{
+  "a": 13
+}
+{
+  "a": 17
+}
+{
+  "a": 0
+}
+{
+  "a": 4
+}
+{
+  "a": 8
+}
+{
+  "a": 12
+}
+{
+  "a": 16
+}
+*/
+
+-- test: group by with work_mem = 64MB
+SET work_mem = '64MB';
+SELECT b, COUNT(*), COUNT(DISTINCT a) FROM test GROUP BY b;
+/* result:
+{
+  "b": 0,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 1,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 2,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+{
+  "b": 3,
+  "COUNT(*)": 5,
+  "COUNT(DISTINCT a)": 5
+}
+*/
@@ -0,0 +1,81 @@
+-- test: defaults
+SHOW strict_typing;
+/* result:
+{
+  "strict_typing": "off"
+}
+*/
+
+-- test: work_mem
+SET work_mem = '512kB';
+SHOW work_mem;
+/* result:
+{
+  "work_mem": "512kB"
+}
+*/
+
+-- test: strict_typing
+SET strict_typing = on;
+SHOW STRICT_TYPING;
+/* result:
+{
+  "strict_typing": "on"
+}
+*/
+
+-- test: statement_timeout
+SHOW statement_timeout;
+/* result:
+{
+  "statement_timeout": "0"
+}
+*/
+
+-- test: set statement_timeout
+SET statement_timeout = '90s';
+SHOW statement_timeout;
+/* result:
+{
+  "statement_timeout": "1m30s"
+}
+*/
+
+-- test: search_path
+SHOW search_path;
+/* result:
+{
+  "search_path": "public"
+}
+*/
+
+-- test: set search_path
+SET search_path = a, b;
+SHOW search_path;
+/* result:
+{
+  "search_path": "a, b"
+}
+*/
+
+-- test: synchronous
+SHOW synchronous;
+/* result:
+{
+  "synchronous": "FULL"
+}
+*/
+
+-- test: default
+SET work_mem = '512kB';
+SET work_mem = DEFAULT;
+SHOW work_mem;
+/* result:
+{
+  "work_mem": "0"
+}
+*/
+
+-- test: unknown
+SHOW unknown;
+-- error: unknown setting "unknown" at line 1, char 6
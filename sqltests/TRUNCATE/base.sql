@@ -0,0 +1,98 @@
+-- setup:
+CREATE TABLE test(id SERIAL PRIMARY KEY, a TEXT UNIQUE, b INT);
+CREATE INDEX test_b ON test(b);
+INSERT INTO test (a, b) VALUES ('a', 1), ('b', 2), ('c', 3);
+
+-- test: truncate
+TRUNCATE test;
+SELECT * FROM test;
+/* result:
+*/
+
+-- test: table keyword
+TRUNCATE TABLE test;
+SELECT COUNT(*) FROM test;
+/* result:
+{"COUNT(*)": 0}
+*/
+
+-- test: indexes
+TRUNCATE test;
+SELECT id FROM test WHERE b = 2;
+SELECT id FROM test WHERE a = 'b';
+/* result:
+*/
+
+-- test: unique values can be inserted again
+TRUNCATE test;
+INSERT INTO test (a, b) VALUES ('a', 1);
+SELECT a, b FROM test WHERE a = 'a';
+/* result:
+{"a": "a", "b": 1}
+*/
+
+-- test: continue identity
+TRUNCATE test;
+INSERT INTO test (a, b) VALUES ('d', 4);
+SELECT id, a FROM test;
+/* result:
+{"id": 4, "a": "d"}
+*/
+
+-- test: continue identity keyword
+TRUNCATE test CONTINUE IDENTITY;
+INSERT INTO test (a, b) VALUES ('d', 4);
+SELECT id, a FROM test;
+/* result:
+{"id": 4, "a": "d"}
+*/
+
+-- test: restart identity
+TRUNCATE test RESTART IDENTITY;
+INSERT INTO test (a, b) VALUES ('d', 4), ('e', 5);
+SELECT id, a FROM test;
+/* result:
+{"id": 1, "a": "d"}
+{"id": 2, "a": "e"}
+*/
+
+-- test: rowid
+CREATE TABLE norowid(a INT);
+INSERT INTO norowid (a) VALUES (1), (2);
+TRUNCATE norowid RESTART IDENTITY;
+INSERT INTO norowid (a) VALUES (3);
+SELECT a FROM norowid;
+/* result:
+{"a": 3}
+*/
+
+-- test: multiple tables
+CREATE TABLE other(id INT PRIMARY KEY);
+INSERT INTO other (id) VALUES (1), (2);
+TRUNCATE test, other;
+SELECT COUNT(*) FROM test UNION ALL SELECT COUNT(*) FROM other;
+/* result:
+{"COUNT(*)": 0}
+{"COUNT(*)": 0}
+*/
+
+-- test: other tables are kept
+CREATE TABLE other(id INT PRIMARY KEY);
+INSERT INTO other (id) VALUES (1), (2);
+TRUNCATE other;
+SELECT COUNT(*) FROM test;
+/* result:
+{"COUNT(*)": 3}
+*/
+
+-- test: unknown table
+TRUNCATE unknown;
+-- error:
+
+-- test: unknown table among others
+TRUNCATE test, unknown;
+-- error:
+
+-- test: read-only table
+TRUNCATE __chai_catalog;
+-- error: cannot write to read-only table
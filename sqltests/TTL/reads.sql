@@ -0,0 +1,86 @@
+-- setup:
+CREATE TABLE sessions (
+    id INT PRIMARY KEY,
+    name TEXT,
+    expires_at TIMESTAMP
+) WITH (ttl_field = expires_at);
+CREATE INDEX sessions_name ON sessions(name);
+INSERT INTO sessions (id, name, expires_at) VALUES
+    (1, 'a', '2000-01-01T00:00:00Z'),
+    (2, 'b', '2999-01-01T00:00:00Z'),
+    (3, 'c', NULL),
+    (4, 'a', '2999-01-01T00:00:00Z');
+
+-- test: expired rows are not returned
+SELECT id FROM sessions;
+/* result:
+{
+  id: 2
+}
+{
+  id: 3
+}
+{
+  id: 4
+}
+*/
+
+-- test: expired rows are not returned when using an index
+SELECT id FROM sessions WHERE name = 'a';
+/* result:
+{
+  id: 4
+}
+*/
+
+-- test: expired rows are not counted
+SELECT COUNT(*) FROM sessions;
+/* result:
+{
+  "COUNT(*)": 3
+}
+*/
+
+-- test: plan
+EXPLAIN SELECT id FROM sessions WHERE name = 'a';
+/* result:
+{
+  plan: 'index.Scan("sessions_name", [{"min": ("a"), "exact": true}]) | rows.Filter(expires_at IS NULL OR expires_at > NOW()) | rows.Project(id)'
+}
+*/
+
+-- test: expired rows are not updated
+UPDATE sessions SET name = 'z';
+SELECT id, name FROM sessions WHERE name = 'z';
+/* result:
+{
+  id: 2,
+  name: "z"
+}
+{
+  id: 3,
+  name: "z"
+}
+{
+  id: 4,
+  name: "z"
+}
+*/
+
+-- test: extending the expiration
+UPDATE sessions SET expires_at = '2999-01-01T00:00:00Z' WHERE id = 1;
+SELECT id FROM sessions WHERE id = 1;
+/* result:
+*/
+
+-- test: expiring a row
+UPDATE sessions SET expires_at = '2001-01-01T00:00:00Z' WHERE id = 2;
+SELECT id FROM sessions;
+/* result:
+{
+  id: 3
+}
+{
+  id: 4
+}
+*/
@@ -0,0 +1,93 @@
+-- setup:
+CREATE TABLE test(id INT PRIMARY KEY, a INT UNIQUE DEFERRABLE INITIALLY DEFERRED);
+INSERT INTO test VALUES (1, 1), (2, 2);
+
+-- test: swap
+UPDATE test SET a = 3 - a;
+SELECT * FROM test;
+/* result:
+{
+  "id": 1,
+  "a": 2
+}
+{
+  "id": 2,
+  "a": 1
+}
+*/
+
+-- test: shift
+UPDATE test SET a = a + 1;
+SELECT * FROM test;
+/* result:
+{
+  "id": 1,
+  "a": 2
+}
+{
+  "id": 2,
+  "a": 3
+}
+*/
+
+-- test: conflict
+UPDATE test SET a = 2 WHERE a = 1;
+-- error: UNIQUE constraint "test_a_unique" error: [a]
+
+-- test: transaction
+BEGIN;
+UPDATE test SET a = 2 WHERE id = 1;
+UPDATE test SET a = 1 WHERE id = 2;
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+  "id": 1,
+  "a": 2
+}
+{
+  "id": 2,
+  "a": 1
+}
+*/
+
+-- test: transaction conflict
+BEGIN;
+UPDATE test SET a = 2 WHERE id = 1;
+COMMIT;
+-- error: UNIQUE constraint "test_a_unique" error: [a]
+
+-- test: insert then delete
+BEGIN;
+INSERT INTO test VALUES (3, 1);
+DELETE FROM test WHERE id = 1;
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+  "id": 2,
+  "a": 2
+}
+{
+  "id": 3,
+  "a": 1
+}
+*/
+
+-- test: insert conflict
+INSERT INTO test VALUES (3, 1);
+-- error: UNIQUE constraint "test_a_unique" error: [a]
+
+-- test: insert on conflict
+INSERT INTO test VALUES (3, 1) ON CONFLICT DO NOTHING;
+SELECT * FROM test;
+/* result:
+{
+  "id": 1,
+  "a": 1
+}
+{
+  "id": 2,
+  "a": 2
+}
+*/
@@ -0,0 +1,79 @@
+-- setup:
+CREATE TABLE accounts(id INT PRIMARY KEY, name TEXT, balance INT);
+CREATE TABLE payments(id INT PRIMARY KEY, account_id INT, amount INT);
+CREATE INDEX payments_account_id ON payments(account_id);
+INSERT INTO accounts (id, name, balance) VALUES (1, 'a', 0), (2, 'b', 0), (3, 'c', 0);
+INSERT INTO payments (id, account_id, amount) VALUES (1, 1, 10), (2, 2, 20), (3, 2, 30), (4, 4, 40);
+
+-- test: update from
+UPDATE accounts SET balance = payments.amount FROM payments WHERE accounts.id = payments.account_id;
+SELECT * FROM accounts;
+/* result:
+{"id": 1, "name": "a", "balance": 10}
+{"id": 2, "name": "b", "balance": 20}
+{"id": 3, "name": "c", "balance": 0}
+*/
+
+-- test: unqualified columns
+UPDATE accounts SET balance = balance + amount FROM payments WHERE accounts.id = account_id AND amount > 10;
+SELECT * FROM accounts;
+/* result:
+{"id": 1, "name": "a", "balance": 0}
+{"id": 2, "name": "b", "balance": 20}
+{"id": 3, "name": "c", "balance": 0}
+*/
+
+-- test: condition on the updated table only
+UPDATE accounts SET balance = 1 FROM payments WHERE accounts.id > 1;
+SELECT * FROM accounts;
+/* result:
+{"id": 1, "name": "a", "balance": 0}
+{"id": 2, "name": "b", "balance": 1}
+{"id": 3, "name": "c", "balance": 1}
+*/
+
+-- test: without condition
+UPDATE accounts SET name = 'x' FROM payments;
+SELECT name FROM accounts;
+/* result:
+{"name": "x"}
+{"name": "x"}
+{"name": "x"}
+*/
+
+-- test: update the primary key
+UPDATE accounts SET id = payments.id + 10 FROM payments WHERE payments.account_id = accounts.id AND payments.amount >= 20;
+SELECT id FROM accounts;
+/* result:
+{"id": 1}
+{"id": 3}
+{"id": 12}
+*/
+
+-- test: plan
+EXPLAIN UPDATE accounts SET balance = payments.amount FROM payments WHERE accounts.id = payments.account_id AND accounts.id > 1;
+/* result:
+{
+    "plan": 'table.Scan("accounts", [{"min": (1), "exclusive": true}]) | stream.Join(table.Scan("payments"), id = account_id) | paths.Set(balance, amount) | table.Validate("accounts") | table.Replace("accounts") | discard()'
+}
+*/
+
+-- test: ambiguous column
+UPDATE accounts SET balance = amount FROM payments WHERE id = account_id;
+-- error: column reference id is ambiguous
+
+-- test: unknown table
+UPDATE accounts SET balance = 1 FROM unknown;
+-- error:
+
+-- test: table not referenced
+UPDATE accounts SET balance = 1 FROM payments WHERE foo.id = 1;
+-- error: table foo is not referenced by the statement
+
+-- test: same table
+UPDATE accounts SET balance = 1 FROM accounts;
+-- error: table accounts is specified more than once
+
+-- test: with limit
+UPDATE accounts SET balance = 1 FROM payments LIMIT 1;
+-- error: ORDER BY, LIMIT and OFFSET cannot be used with FROM
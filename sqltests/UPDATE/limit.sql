@@ -0,0 +1,58 @@
+-- setup:
+CREATE TABLE events(id INT PRIMARY KEY, ts INT, archived BOOL DEFAULT false);
+CREATE INDEX events_ts ON events(ts);
+INSERT INTO events (id, ts) VALUES (1, 50), (2, 40), (3, 30), (4, 20), (5, 10);
+
+-- test: limit
+UPDATE events SET archived = true LIMIT 2;
+SELECT id FROM events WHERE archived;
+/* result:
+{"id": 1}
+{"id": 2}
+*/
+
+-- test: order by and limit
+UPDATE events SET archived = true WHERE id > 1 ORDER BY ts LIMIT 2;
+SELECT id FROM events WHERE archived;
+/* result:
+{"id": 4}
+{"id": 5}
+*/
+
+-- test: order by desc, limit and offset
+UPDATE events SET archived = true ORDER BY ts DESC LIMIT 2 OFFSET 1;
+SELECT id FROM events WHERE archived;
+/* result:
+{"id": 2}
+{"id": 3}
+*/
+
+-- test: chunks
+UPDATE events SET archived = true WHERE NOT archived ORDER BY ts LIMIT 3;
+UPDATE events SET archived = true WHERE NOT archived ORDER BY ts LIMIT 3;
+SELECT COUNT(*) FROM events WHERE NOT archived;
+/* result:
+{"COUNT(*)": 0}
+*/
+
+-- test: sort by the updated column
+UPDATE events SET ts = ts + 100 ORDER BY ts LIMIT 2;
+SELECT id, ts FROM events;
+/* result:
+{"id": 1, "ts": 50}
+{"id": 2, "ts": 40}
+{"id": 3, "ts": 30}
+{"id": 4, "ts": 120}
+{"id": 5, "ts": 110}
+*/
+
+-- test: update the primary key
+UPDATE events SET id = id + 10 ORDER BY id DESC LIMIT 1;
+SELECT id FROM events;
+/* result:
+{"id": 1}
+{"id": 2}
+{"id": 3}
+{"id": 4}
+{"id": 15}
+*/
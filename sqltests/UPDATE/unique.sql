@@ -4,4 +4,4 @@ CREATE TABLE test(a int UNIQUE);
 -- test: conflict
 INSERT INTO test VALUES (1), (2);
 UPDATE test SET a = 2 WHERE a = 1;
--- error: UNIQUE constraint error: [a]
\ No newline at end of file
+-- error: UNIQUE constraint "test_a_unique" error: [a]
\ No newline at end of file
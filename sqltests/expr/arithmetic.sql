@@ -99,3 +99,36 @@ NULL
 ! 1000000000 * 1000000000
 
 ! 1000000000000000000 * 1000000000000000000 * 1000000000000000000
+
+-- test: double overflow
+! 1e308 * 10
+'double out of range'
+
+! 1e308 + 1e308
+'double out of range'
+
+! -1e308 - 1e308
+'double out of range'
+
+> 1.0 / 0
+NULL
+
+> 1.5 % 0
+NULL
+
+-- test: integer division overflow
+! -2147483648 / -1
+'integer out of range'
+
+! -9223372036854775808 / -1
+'bigint out of range'
+
+-- test: bigint division by zero
+! 10000000000 / 0
+'division by zero'
+
+! 10000000000 / CAST(0 AS BIGINT)
+'division by zero'
+
+! 1 / CAST(0 AS BIGINT)
+'division by zero'
@@ -92,3 +92,71 @@ false
 
 > CAST ('\x617364696e65' AS TEXT)
 'YXNkaW5l'
+
+-- test: source(DATE)
+> CAST ('2023-05-07T08:09:10Z' AS DATE)
+'2023-05-07'
+
+> typeof(CAST ('2023-05-07' AS DATE))
+'date'
+
+> CAST (CAST ('2023-05-07' AS DATE) AS TIMESTAMP)
+'2023-05-07T00:00:00Z'
+
+> CAST (CAST ('2023-05-07' AS DATE) AS TEXT)
+'2023-05-07'
+
+> CAST (CAST ('2023-05-07T23:59:59Z' AS TIMESTAMP) AS DATE)
+'2023-05-07'
+
+! CAST ('foo' AS DATE)
+'cannot cast "foo" as date'
+
+! CAST (CAST ('2023-05-07' AS DATE) AS INTEGER)
+'cannot cast date as integer'
+
+-- test: source(BIGINT)
+> CAST (3000000000 AS BOOL)
+true
+
+> CAST (CAST (true AS BIGINT) AS TEXT)
+'1'
+
+! CAST (3000000000 AS INTEGER)
+'integer out of range'
+
+> CAST ('3000000000' AS BIGINT)
+3000000000
+
+! CAST ('3000000000' AS INTEGER)
+'cannot cast "3000000000" as integer: out of range'
+
+! CAST ('1e30' AS BIGINT)
+'cannot cast "1e30" as bigint: out of range'
+
+-- test: TRY_CAST
+> TRY_CAST ('100' AS INTEGER)
+100
+
+> TRY_CAST ('a' AS INTEGER)
+NULL
+
+> TRY_CAST ('3000000000' AS INTEGER)
+NULL
+
+> TRY_CAST (1.1 AS BOOL)
+NULL
+
+> TRY_CAST ('foo' AS DATE)
+NULL
+
+> TRY_CAST (123.456 AS DECIMAL(4, 2))
+NULL
+
+> TRY_CAST (NULL AS INTEGER)
+NULL
+
+> typeof(TRY_CAST ('2023-05-07' AS DATE))
+'date'
+
+! TRY_CAST (1 / 0 AS INTEGER)
@@ -0,0 +1,119 @@
+-- test: cast
+> CAST('12.50' AS DECIMAL)
+CAST('12.50' AS DECIMAL)
+
+> typeof(CAST('12.50' AS DECIMAL))
+'decimal'
+
+> CAST(CAST('12.50' AS DECIMAL) AS TEXT)
+'12.50'
+
+> CAST(CAST('-0.05' AS NUMERIC) AS TEXT)
+'-0.05'
+
+> CAST(CAST('1.2e3' AS DECIMAL) AS TEXT)
+'1200'
+
+> CAST(CAST('1.5e-3' AS DECIMAL) AS TEXT)
+'0.0015'
+
+> CAST(CAST('123456789012345678901234567890.123456789' AS DECIMAL) AS TEXT)
+'123456789012345678901234567890.123456789'
+
+> CAST(CAST(10 AS DECIMAL) AS TEXT)
+'10'
+
+> CAST(CAST(0.1 AS DECIMAL) AS TEXT)
+'0.1'
+
+> CAST(CAST(2.675 AS DECIMAL(10, 2)) AS TEXT)
+'2.68'
+
+> CAST(CAST(-2.5 AS DECIMAL(10)) AS TEXT)
+'-3'
+
+> CAST(CAST(7 AS DECIMAL(5, 2)) AS TEXT)
+'7.00'
+
+> CAST(CAST('2.5' AS DECIMAL) AS INTEGER)
+3
+
+> CAST(CAST('-2.5' AS DECIMAL) AS BIGINT)
+-3
+
+> CAST(CAST('2.5' AS DECIMAL) AS DOUBLE)
+2.5
+
+! CAST('foo' AS DECIMAL)
+'cannot cast "foo" as decimal'
+
+! CAST(1000 AS DECIMAL(5, 2))
+'decimal field overflow'
+
+! CAST(1 AS DECIMAL(0))
+'decimal precision 0 must be between 1 and 1000'
+
+! CAST(1 AS DECIMAL(2, 3))
+'decimal scale 3 must be between 0 and precision 2'
+
+-- test: arithmetic
+> CAST(CAST('0.1' AS DECIMAL) + CAST('0.2' AS DECIMAL) AS TEXT)
+'0.3'
+
+> CAST('0.1' AS DECIMAL) + CAST('0.2' AS DECIMAL) = CAST('0.3' AS DECIMAL)
+true
+
+> CAST(CAST('1.10' AS DECIMAL) + 2 AS TEXT)
+'3.10'
+
+> CAST(2 - CAST('1.25' AS DECIMAL) AS TEXT)
+'0.75'
+
+> CAST(CAST('1.5' AS DECIMAL) * CAST('1.25' AS DECIMAL) AS TEXT)
+'1.875'
+
+> CAST(CAST('1' AS DECIMAL) / 3 AS TEXT)
+'0.3333333333333333'
+
+> CAST(2 / CAST('3' AS DECIMAL) AS TEXT)
+'0.6666666666666667'
+
+> CAST(CAST('10.5' AS DECIMAL) % 3 AS TEXT)
+'1.5'
+
+> CAST('10.5' AS DECIMAL) % 0
+NULL
+
+> typeof(CAST('1.5' AS DECIMAL) * 2.0)
+'double'
+
+> CAST('1.5' AS DECIMAL) * 2.0
+3.0
+
+! CAST('1.5' AS DECIMAL) / 0
+'division by zero'
+
+-- test: comparison
+> CAST('1.50' AS DECIMAL) = CAST('1.5' AS DECIMAL)
+true
+
+> CAST('1.50' AS DECIMAL) = 1.5
+true
+
+> CAST('2' AS DECIMAL) = 2
+true
+
+> CAST('0.1' AS DECIMAL) = 0.1
+true
+
+> CAST('0.1' AS DECIMAL) < 0.2
+true
+
+> 3 > CAST('2.99' AS DECIMAL)
+true
+
+> CAST('-1' AS DECIMAL) < CAST('-0.5' AS DECIMAL)
+true
+
+> CAST('1.5' AS DECIMAL) BETWEEN 1 AND 2
+true
@@ -0,0 +1,149 @@
+-- test: literals
+> INTERVAL '1 day'
+'1 day'
+
+> typeof(INTERVAL '1 day')
+'interval'
+
+> INTERVAL '2 hours 30 minutes'
+'02:30:00'
+
+> INTERVAL '1 year 2 mons 3 days 04:05:06.5'
+'1 year 2 mons 3 days 04:05:06.500000'
+
+> INTERVAL '14 months'
+'1 year 2 mons'
+
+> INTERVAL '1.5 days'
+'1 day 12:00:00'
+
+> INTERVAL '1 week ago'
+'-7 days'
+
+> INTERVAL '36:00'
+'36:00:00'
+
+> INTERVAL '10s'
+'00:00:10'
+
+> INTERVAL '0 days'
+'00:00:00'
+
+-- test: cast
+> CAST('3 days' AS INTERVAL)
+'3 days'
+
+> CAST(INTERVAL '3 days 01:00:00' AS TEXT)
+'3 days 01:00:00'
+
+! CAST('foo' AS INTERVAL)
+'cannot cast "foo" as interval'
+
+! CAST(INTERVAL '1 day' AS INTEGER)
+'cannot cast interval as integer'
+
+-- test: comparison
+> INTERVAL '1 mon' = INTERVAL '30 days'
+true
+
+> INTERVAL '1 day' = INTERVAL '24 hours'
+true
+
+> INTERVAL '1 day' > INTERVAL '23 hours'
+true
+
+> INTERVAL '1 year' < INTERVAL '366 days'
+true
+
+> INTERVAL '-1 day' < INTERVAL '0 days'
+true
+
+> INTERVAL '1 day' = '1 day'
+true
+
+> INTERVAL '2 days' BETWEEN INTERVAL '1 day' AND INTERVAL '3 days'
+true
+
+> INTERVAL '1 day' = 1
+false
+
+-- test: interval arithmetic
+> INTERVAL '1 day' + INTERVAL '2 hours'
+'1 day 02:00:00'
+
+> INTERVAL '1 mon' - INTERVAL '1 day'
+'1 mon -1 days'
+
+> INTERVAL '1 day' * 3
+'3 days'
+
+> 2 * INTERVAL '1 hour'
+'02:00:00'
+
+> INTERVAL '1 mon' * 1.5
+'1 mon 15 days'
+
+> INTERVAL '3 days' / 2
+'1 day 12:00:00'
+
+> INTERVAL '1 day' + NULL
+NULL
+
+> INTERVAL '1 day' + 1
+NULL
+
+! INTERVAL '1 day' / 0
+'division by zero'
+
+-- test: timestamp arithmetic
+> CAST('2023-01-01T10:00:00Z' AS TIMESTAMP) + INTERVAL '1 day 2 hours'
+'2023-01-02T12:00:00Z'
+
+> INTERVAL '1 day' + CAST('2023-01-01T10:00:00Z' AS TIMESTAMP)
+'2023-01-02T10:00:00Z'
+
+> CAST('2023-01-31T00:00:00Z' AS TIMESTAMP) + INTERVAL '1 mon'
+'2023-02-28T00:00:00Z'
+
+> CAST('2024-01-31T00:00:00Z' AS TIMESTAMP) + INTERVAL '1 mon'
+'2024-02-29T00:00:00Z'
+
+> CAST('2023-03-01T00:00:00Z' AS TIMESTAMP) - INTERVAL '1 mon 1 day'
+'2023-01-31T00:00:00Z'
+
+> CAST('2023-03-04T12:00:00Z' AS TIMESTAMP) - CAST('2023-03-01T18:00:00Z' AS TIMESTAMP)
+'2 days 18:00:00'
+
+> CAST('2023-03-01T00:00:00Z' AS TIMESTAMP) - CAST('2023-03-04T12:00:00Z' AS TIMESTAMP)
+'-3 days -12:00:00'
+
+> '2023-01-01' + INTERVAL '1 hour'
+'2023-01-01T01:00:00Z'
+
+> CAST('2023-01-01T10:00:00Z' AS TIMESTAMP) + NULL
+NULL
+
+! CAST('9999-12-31T00:00:00Z' AS TIMESTAMP) + INTERVAL '300000 years'
+'timestamp out of range'
+
+-- test: date arithmetic
+> CAST('2023-01-31' AS DATE) + INTERVAL '1 mon'
+'2023-02-28T00:00:00Z'
+
+> CAST('2023-01-31' AS DATE) + 1
+'2023-02-01'
+
+> typeof(CAST('2023-01-31' AS DATE) + 1)
+'date'
+
+> 1 + CAST('2023-01-31' AS DATE)
+'2023-02-01'
+
+> CAST('2023-01-01' AS DATE) - 1
+'2022-12-31'
+
+> CAST('2023-03-01' AS DATE) - CAST('2023-01-01' AS DATE)
+59
+
+> CAST('2023-03-01T06:00:00Z' AS TIMESTAMP) - CAST('2023-03-01' AS DATE)
+'06:00:00'
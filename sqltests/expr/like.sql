@@ -0,0 +1,69 @@
+-- test: LIKE
+> 'abc' LIKE 'a%'
+true
+
+> 'abc' LIKE 'a_c'
+true
+
+> 'abc' LIKE 'b%'
+false
+
+> 'abc' NOT LIKE 'b%'
+true
+
+> 'abc' LIKE NULL
+NULL
+
+> 1 LIKE '1'
+NULL
+
+-- test: LIKE case insensitive
+> 'ABC' LIKE 'abc'
+true
+
+> 'ÉTÉ' LIKE 'été'
+true
+
+> 'Straße' LIKE 'STRAßE'
+true
+
+> 'ΣΊΣΥΦΟΣ' LIKE '%σ'
+true
+
+-- test: LIKE default escape
+> 'a%c' LIKE 'a\\%c'
+true
+
+> 'abc' LIKE 'a\\%c'
+false
+
+-- test: LIKE ESCAPE
+> 'a%c' LIKE 'a!%c' ESCAPE '!'
+true
+
+> 'abc' LIKE 'a!%c' ESCAPE '!'
+false
+
+> 'a_c' NOT LIKE 'a!_c' ESCAPE '!'
+false
+
+> 'a\\c' LIKE 'a\\_' ESCAPE '!'
+true
+
+> 'a\\c' LIKE 'a\\c' ESCAPE ''
+true
+
+> 'a%' LIKE 'aé%' ESCAPE 'é'
+true
+
+> 'abc' LIKE 'a%' ESCAPE NULL
+NULL
+
+! 'abc' LIKE 'a%' ESCAPE '!!'
+'invalid escape string "!!": must be empty or one character long'
+
+! 'abc' LIKE 'a%' ESCAPE '%'
+'invalid escape string "%": cannot be a wildcard'
+
+! 'abc' LIKE 'a%' ESCAPE 1
+'invalid escape string: expected text, got integer'
@@ -0,0 +1,45 @@
+-- test: ~
+> 'abc' ~ '^a.c$'
+true
+
+> 'abc' ~ 'b'
+true
+
+> 'abc' ~ '^b'
+false
+
+> 'abc' !~ '^b'
+true
+
+> 'ABC' ~ 'abc'
+false
+
+> 'ABC' ~ '(?i)abc'
+true
+
+> 'abc' ~ NULL
+NULL
+
+> 1 ~ '1'
+NULL
+
+-- test: =~
+> 'abc' =~ 'b'
+true
+
+-- test: REGEXP
+> 'abc' REGEXP '[a-c]+'
+true
+
+> 'abc' regexp '[0-9]'
+false
+
+> 'abc' NOT REGEXP '[0-9]'
+true
+
+> 'abc' NOT REGEXP NULL
+NULL
+
+-- test: invalid regular expression
+! 'abc' ~ '('
+'missing closing )'
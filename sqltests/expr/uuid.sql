@@ -0,0 +1,59 @@
+-- test: cast
+> CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID)
+CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID)
+
+> typeof(CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID))
+'uuid'
+
+> CAST(CAST('A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11' AS UUID) AS TEXT)
+'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'
+
+> CAST(CAST('a0eebc999c0b4ef8bb6d6bb9bd380a11' AS UUID) AS TEXT)
+'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'
+
+> CAST(CAST('{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11}' AS UUID) AS TEXT)
+'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'
+
+> CAST(CAST('urn:uuid:a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID) AS TEXT)
+'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'
+
+> CAST(CAST('\xa0eebc999c0b4ef8bb6d6bb9bd380a11' AS UUID) AS BLOB)
+'\xa0eebc999c0b4ef8bb6d6bb9bd380a11'
+
+> CAST(CAST(CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID) AS BLOB) AS UUID)
+CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID)
+
+! CAST('a0eebc99-9c0b-4ef8-bb6d' AS UUID)
+'cannot cast "a0eebc99-9c0b-4ef8-bb6d" as uuid: invalid UUID'
+
+! CAST('a0eebc99+9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID)
+'cannot cast "a0eebc99+9c0b-4ef8-bb6d-6bb9bd380a11" as uuid: invalid UUID'
+
+! CAST('\xa0ee' AS UUID)
+'cannot cast blob of 2 bytes as uuid'
+
+! CAST(CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID) AS INTEGER)
+'cannot cast uuid as integer'
+
+-- test: comparison
+> CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID) = 'A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11'
+true
+
+> CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID) = CAST('a0eebc999c0b4ef8bb6d6bb9bd380a11' AS UUID)
+true
+
+> CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID) < CAST('b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID)
+true
+
+> CAST('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' AS UUID) > '00000000-0000-0000-0000-000000000000'
+true
+
+-- test: generation
+> typeof(uuid())
+'uuid'
+
+> typeof(gen_random_uuid())
+'uuid'
+
+> uuid() = uuid()
+false
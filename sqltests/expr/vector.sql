@@ -0,0 +1,59 @@
+-- test: cast
+> CAST('[1, 2.5, -3]' AS VECTOR)
+CAST('[1, 2.5, -3]' AS VECTOR)
+
+> typeof(CAST('[1, 2]' AS VECTOR))
+'vector'
+
+> CAST(CAST('[1,2,   3]' AS VECTOR) AS TEXT)
+'[1, 2, 3]'
+
+> CAST(CAST('[1, 2]' AS VECTOR(2)) AS TEXT)
+'[1, 2]'
+
+> CAST(CAST(CAST('[1, 2]' AS VECTOR) AS BLOB) AS VECTOR)
+CAST('[1, 2]' AS VECTOR)
+
+! CAST('[1, 2]' AS VECTOR(3))
+'expected 3 dimensions, not 2'
+
+! CAST('[]' AS VECTOR)
+'cannot cast "[]" as vector: vector must have at least one dimension'
+
+! CAST('1, 2' AS VECTOR)
+'cannot cast "1, 2" as vector: vector must be enclosed in brackets'
+
+! CAST('[1, a]' AS VECTOR)
+'cannot cast "[1, a]" as vector: invalid vector component "a"'
+
+! CAST('\xa0ee' AS VECTOR)
+'cannot cast blob of 2 bytes as vector'
+
+! CAST(CAST('[1, 2]' AS VECTOR) AS INTEGER)
+'cannot cast vector as integer'
+
+-- test: comparison
+> CAST('[1, 2]' AS VECTOR) = '[1.0, 2.0]'
+true
+
+> CAST('[1, 2]' AS VECTOR) = CAST('[1, 2, 3]' AS VECTOR)
+false
+
+-- test: vector_distance
+> vector_distance('[0, 0]', '[3, 4]', 'l2')
+5.0
+
+> vector_distance(CAST('[1, 0]' AS VECTOR), '[0, 1]', 'cosine')
+1.0
+
+> vector_distance('[1, 1]', '[2, 2]', 'COSINE')
+0.0
+
+> vector_distance(NULL, '[0, 1]', 'l2')
+NULL
+
+! vector_distance('[1, 0]', '[0, 1, 2]', 'l2')
+'different vector dimensions 2 and 3'
+
+! vector_distance('[1, 0]', '[0, 1]', 'dot')
+'unknown vector metric "dot", expected "l2" or "cosine"'
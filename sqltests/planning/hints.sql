@@ -0,0 +1,131 @@
+-- setup:
+CREATE TABLE test(a int PRIMARY KEY, b int, c int);
+
+CREATE INDEX test_b ON test(b);
+
+CREATE INDEX test_c ON test(c);
+
+CREATE TABLE other(x int);
+
+CREATE INDEX other_x ON other(x);
+
+INSERT INTO
+    test (a, b, c)
+VALUES
+    (1, 1, 1),
+    (2, 2, 2),
+    (3, 3, 3),
+    (4, 4, 4),
+    (5, 5, 5);
+
+-- test: no hint
+EXPLAIN SELECT * FROM test WHERE b = 1 AND c > 1;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (1), "exact": true}]) | rows.Filter(c > 1)'
+}
+*/
+
+-- test: INDEX
+EXPLAIN SELECT /*+ INDEX(test test_c) */ * FROM test WHERE b = 1 AND c > 1;
+/* result:
+{
+    "plan": 'index.Scan("test_c", [{"min": (1), "exclusive": true}]) | rows.Filter(b = 1)'
+}
+*/
+
+-- test: INDEX: unusable index
+EXPLAIN SELECT /*+ INDEX(test test_c) */ * FROM test WHERE b = 1;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(b = 1)'
+}
+*/
+
+-- test: INDEX: primary key is still used
+EXPLAIN SELECT /*+ INDEX(test test_c) */ * FROM test WHERE a = 1 AND b = 1;
+/* result:
+{
+    "plan": 'table.Scan("test", [{"min": (1), "exact": true}]) | rows.Filter(b = 1)'
+}
+*/
+
+-- test: NO_INDEX
+EXPLAIN SELECT /*+ NO_INDEX */ * FROM test WHERE b = 1 AND c > 1;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(b = 1) | rows.Filter(c > 1)'
+}
+*/
+
+-- test: NO_INDEX: table
+EXPLAIN SELECT /*+ NO_INDEX(test) */ * FROM test WHERE b = 1;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(b = 1)'
+}
+*/
+
+-- test: NO_INDEX: other table
+EXPLAIN SELECT /*+ NO_INDEX(other) */ * FROM test WHERE b = 1;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (1), "exact": true}])'
+}
+*/
+
+-- test: results are the same
+SELECT /*+ INDEX(test test_c) */ a FROM test WHERE b = 2 AND c > 1;
+/* result:
+{
+    "a": 2
+}
+*/
+
+-- test: UPDATE
+EXPLAIN UPDATE /*+ NO_INDEX */ test SET c = 10 WHERE b = 1;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(b = 1) | paths.Set(c, 10) | table.Validate("test") | index.Delete("test_b") | index.Delete("test_c") | table.Replace("test") | index.Insert("test_b") | index.Insert("test_c") | discard()'
+}
+*/
+
+-- test: DELETE
+EXPLAIN DELETE /*+ INDEX(test test_c) */ FROM test WHERE b = 1 AND c = 1;
+/* result:
+{
+    "plan": 'index.Scan("test_c", [{"min": (1), "exact": true}]) | rows.Filter(b = 1) | index.Delete("test_b") | index.Delete("test_c") | table.Delete(\'test\') | discard()'
+}
+*/
+
+-- test: several hints
+EXPLAIN SELECT /*+ NO_INDEX(other), INDEX(test test_c) */ * FROM test WHERE b = 1 AND c > 1;
+/* result:
+{
+    "plan": 'index.Scan("test_c", [{"min": (1), "exclusive": true}]) | rows.Filter(b = 1)'
+}
+*/
+
+-- test: other comments are ignored
+EXPLAIN SELECT /* INDEX(test test_c) */ * FROM test WHERE b = 1 AND c > 1;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (1), "exact": true}]) | rows.Filter(c > 1)'
+}
+*/
+
+-- test: index of another table
+SELECT /*+ INDEX(test other_x) */ * FROM test WHERE b = 1;
+-- error: invalid INDEX hint: other_x is not an index of table test
+
+-- test: unknown index
+SELECT /*+ INDEX(test unknown) */ * FROM test WHERE b = 1;
+-- error: "unknown" not found
+
+-- test: conflicting hints
+SELECT /*+ NO_INDEX, INDEX(test test_c) */ * FROM test WHERE b = 1;
+-- error: conflicting hints for table test
+
+-- test: unknown hint
+SELECT /*+ FULL(test) */ * FROM test;
+-- error:
@@ -0,0 +1,64 @@
+-- setup:
+CREATE TABLE test(a int PRIMARY KEY, b int, c int);
+
+CREATE INDEX test_b ON test(b);
+
+INSERT INTO
+    test (a, b, c)
+VALUES
+    (1, 5, 1),
+    (2, 4, 2),
+    (3, 3, 3),
+    (4, 2, 4),
+    (5, 1, 5);
+
+-- test: primary key order
+EXPLAIN SELECT * FROM test ORDER BY a LIMIT 2 OFFSET 1;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Skip(1) | rows.Take(2)'
+}
+*/
+
+-- test: primary key order, results
+SELECT a + c AS d FROM test ORDER BY a LIMIT 2 OFFSET 1;
+/* result:
+{
+    "d": 4
+}
+{
+    "d": 6
+}
+*/
+
+-- test: index order
+EXPLAIN SELECT a FROM test ORDER BY b DESC LIMIT 2;
+/* result:
+{
+    "plan": 'index.ScanReverse("test_b") | rows.Take(2) | rows.Project(a)'
+}
+*/
+
+-- test: index order, results
+SELECT a FROM test ORDER BY b DESC LIMIT 2;
+/* result:
+{
+    "a": 1
+}
+{
+    "a": 2
+}
+*/
+
+-- test: sort
+EXPLAIN SELECT a FROM test ORDER BY c LIMIT 2;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Project(a) | rows.TempTreeSort(c) | rows.Take(2)'
+}
+*/
+
+-- test: zero
+SELECT a FROM test LIMIT 0;
+/* result:
+*/
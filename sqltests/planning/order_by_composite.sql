@@ -84,3 +84,75 @@ EXPLAIN SELECT * FROM test WHERE b = 10 ORDER BY a DESC;
     "plan": 'index.ScanReverse("test_a_b") | rows.Filter(b = 10)'
 }
 */
+
+-- test: multiple keys, ASC
+EXPLAIN SELECT * FROM test ORDER BY a, b;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b")'
+}
+*/
+
+-- test: multiple keys, DESC
+EXPLAIN SELECT * FROM test ORDER BY a DESC, b DESC;
+/* result:
+{
+    "plan": 'index.ScanReverse("test_a_b")'
+}
+*/
+
+-- test: multiple keys, mixed directions
+EXPLAIN SELECT * FROM test ORDER BY a, b DESC;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(a, b DESC)'
+}
+*/
+
+-- test: multiple keys, NULLS LAST
+EXPLAIN SELECT * FROM test ORDER BY a, b NULLS LAST;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(a, b NULLS LAST)'
+}
+*/
+
+-- test: multiple keys, default NULLS order
+EXPLAIN SELECT * FROM test ORDER BY a NULLS FIRST, b;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b")'
+}
+*/
+
+-- test: multiple keys, more keys than indexed columns
+EXPLAIN SELECT * FROM test ORDER BY a, b, c;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(a, b, c)'
+}
+*/
+
+-- test: multiple keys, wrong order
+EXPLAIN SELECT * FROM test ORDER BY b, a;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(b, a)'
+}
+*/
+
+-- test: multiple keys, filtering on first key
+EXPLAIN SELECT * FROM test WHERE a > 10 ORDER BY a DESC, b DESC;
+/* result:
+{
+    "plan": 'index.ScanReverse("test_a_b", [{"min": (10), "exclusive": true}])'
+}
+*/
+
+-- test: multiple keys, filtering and sorting: IN
+EXPLAIN SELECT * FROM test WHERE a IN (3, 1) ORDER BY a, b;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (3), "exact": true}, {"min": (1), "exact": true}]) | rows.TempTreeSort(a, b)'
+}
+*/
@@ -0,0 +1,214 @@
+package chai
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// InsertStruct inserts v into the table. v is either a struct,
+// a pointer to a struct, or a slice of them, in which case
+// all the structs are inserted in the same transaction.
+//
+// Each exported field of a struct is a column named after the lowercased
+// name of the field, or after the "chai" key of the field tag:
+//
+//	type User struct {
+//		ID        int64     `chai:"id"`
+//		Name      string    `chai:"name"`
+//		CreatedAt time.Time `chai:"created_at"`
+//		Address   Address   `chai:"address"`
+//		Password  string    `chai:"-"`
+//	}
+//
+// Fields tagged with "-" are ignored, the fields of embedded structs are
+// columns of the row, nil pointers are omitted so that the column takes its
+// default value, time.Time fields are timestamps and nested structs,
+// maps and slices are stored as JSON texts.
+func (db *DB) InsertStruct(table string, v any) error {
+	stmts, err := insertStructStatements(table, v)
+	if err != nil {
+		return err
+	}
+
+	if len(stmts) == 1 {
+		return db.Exec(stmts[0].query, stmts[0].args...)
+	}
+
+	return db.withConn(func(c *Connection) error {
+		return c.Update(func(tx *Tx) error {
+			return tx.execStatements(stmts)
+		})
+	})
+}
+
+// InsertStruct inserts v into the table.
+// See DB.InsertStruct for details.
+func (c *Connection) InsertStruct(table string, v any) error {
+	stmts, err := insertStructStatements(table, v)
+	if err != nil {
+		return err
+	}
+
+	if len(stmts) == 1 {
+		return c.Exec(stmts[0].query, stmts[0].args...)
+	}
+
+	return c.Update(func(tx *Tx) error {
+		return tx.execStatements(stmts)
+	})
+}
+
+// InsertStruct inserts v into the table within the transaction.
+// See DB.InsertStruct for details.
+func (tx *Tx) InsertStruct(table string, v any) error {
+	stmts, err := insertStructStatements(table, v)
+	if err != nil {
+		return err
+	}
+
+	return tx.execStatements(stmts)
+}
+
+// execStatements runs the statements, preparing once
+// the statements with the same query.
+func (tx *Tx) execStatements(stmts []structStatement) error {
+	prepared := make(map[string]*Statement)
+	for _, st := range stmts {
+		ps, ok := prepared[st.query]
+		if !ok {
+			var err error
+			ps, err = tx.Prepare(st.query)
+			if err != nil {
+				return err
+			}
+			prepared[st.query] = ps
+		}
+
+		err := ps.Exec(st.args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// A structStatement inserts a struct.
+type structStatement struct {
+	query string
+	args  []any
+}
+
+// insertStructStatements returns an INSERT statement per struct of v.
+func insertStructStatements(table string, v any) ([]structStatement, error) {
+	if table == "" {
+		return nil, errors.New("missing table name")
+	}
+
+	ref := reflect.Indirect(reflect.ValueOf(v))
+	if !ref.IsValid() {
+		return nil, errors.New("expected struct, pointer to struct or slice of structs")
+	}
+
+	var values []reflect.Value
+	switch ref.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < ref.Len(); i++ {
+			values = append(values, ref.Index(i))
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+	default:
+		values = append(values, ref)
+	}
+
+	target := quoteTableName(table)
+
+	stmts := make([]structStatement, 0, len(values))
+	for _, sv := range values {
+		r, err := row.NewFromStruct(sv.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		var columns, params []string
+		var args []any
+		err = r.Iterate(func(column string, v types.Value) error {
+			columns = append(columns, quoteIdent(column))
+			params = append(params, "?")
+			args = append(args, v)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			return nil, errors.New("cannot insert a struct without columns")
+		}
+
+		stmts = append(stmts, structStatement{
+			query: "INSERT INTO " + target + " (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(params, ", ") + ")",
+			args:  args,
+		})
+	}
+
+	return stmts, nil
+}
+
+// quoteTableName quotes each part of a table name
+// that may be qualified by a schema.
+func quoteTableName(table string) string {
+	schema, name := database.SplitQualifiedName(table)
+	if schema == "" {
+		return quoteIdent(name)
+	}
+
+	return quoteIdent(schema) + "." + quoteIdent(name)
+}
+
+// quoteIdent quotes an identifier so that it is never mistaken for a keyword.
+func quoteIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "\\`") + "`"
+}
+
+// ScanStructs scans all the rows of the result into dest, which must be
+// a pointer to a slice of structs or of pointers to structs.
+// The rows are appended to the slice, with the same mapping
+// as DB.InsertStruct.
+func (r *Result) ScanStructs(dest any) error {
+	ref := reflect.ValueOf(dest)
+	if !ref.IsValid() || ref.Kind() != reflect.Ptr || ref.IsNil() || ref.Elem().Kind() != reflect.Slice {
+		return errors.New("target must be pointer to a slice")
+	}
+
+	sl := ref.Elem()
+	elemType := sl.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("target must be pointer to a slice of structs")
+	}
+
+	return r.Iterate(func(rr *Row) error {
+		elem := reflect.New(elemType)
+		err := rr.StructScan(elem.Interface())
+		if err != nil {
+			return err
+		}
+
+		if isPtr {
+			sl.Set(reflect.Append(sl, elem))
+		} else {
+			sl.Set(reflect.Append(sl, elem.Elem()))
+		}
+		return nil
+	})
+}